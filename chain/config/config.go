@@ -1,9 +1,24 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
 	"github.com/crytic/medusa-geth/common"
 	"github.com/crytic/medusa-geth/core/types"
 	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa-geth/params"
+)
+
+const (
+	// HardforkShanghai describes the Shanghai EVM rules, which introduce PUSH0.
+	HardforkShanghai = "shanghai"
+	// HardforkCancun describes the Cancun EVM rules, which additionally introduce transient storage, MCOPY and blobs.
+	HardforkCancun = "cancun"
+	// HardforkPrague describes the Prague EVM rules, which additionally introduce EOA code delegation.
+	HardforkPrague = "prague"
 )
 
 // TestChainConfig represents the chain configuration.
@@ -26,6 +41,38 @@ type TestChainConfig struct {
 
 	// StateOverrides make override to the state
 	StateOverrides map[common.Address]types.Account `json:"stateOverrides,omitempty"`
+
+	// Hardfork describes the EVM rules the chain executes with: HardforkShanghai, HardforkCancun or HardforkPrague. If
+	// empty, HardforkPrague is used.
+	Hardfork string `json:"hardfork"`
+
+	// ChainID describes the chain id of the chain, as returned by the CHAINID opcode. If zero, 1 is used.
+	ChainID uint64 `json:"chainId"`
+
+	// BaseFee describes the base fee of every block, as returned by the BASEFEE opcode. Transactions are never charged
+	// the base fee, so it does not affect balances.
+	BaseFee uint64 `json:"baseFee"`
+
+	// BlockGasLimit describes the maximum amount of gas the transactions of a block can use. If zero, the chain has no
+	// block gas limit until one is set on it.
+	BlockGasLimit uint64 `json:"blockGasLimit"`
+
+	// Mocks describes the mock contracts installed on the chain in place of external dependencies.
+	Mocks []MockConfig `json:"mocks"`
+}
+
+// MockConfig describes a mock contract installed at an address in place of an external dependency, such as an oracle,
+// a bridge or a chain-specific precompile. Its functions return values chosen by the fuzzer, rather than values
+// obtained from forked state.
+type MockConfig struct {
+	// Address describes the address the mock contract is installed at.
+	Address string `json:"address"`
+
+	// Name describes the name of the mock contract, which labels its address in execution traces.
+	Name string `json:"name"`
+
+	// ABI describes the JSON ABI of the functions the mock contract implements.
+	ABI json.RawMessage `json:"abi"`
 }
 
 // ForkConfig describes configuration for fuzzing using a network fork
@@ -34,6 +81,22 @@ type ForkConfig struct {
 	RpcUrl          string `json:"rpcUrl"`
 	RpcBlock        uint64 `json:"rpcBlock"`
 	PoolSize        uint   `json:"poolSize"`
+
+	// CacheDirectory describes the directory the state fetched from the RPC is cached in. If empty, the .medusacache
+	// directory of the working directory is used.
+	CacheDirectory string `json:"cacheDirectory"`
+
+	// CacheMemoryEntries describes the maximum amount of accounts, and of storage slots, fetched from the RPC which are
+	// kept in memory. The least recently used ones are read back from disk once exceeded. Zero keeps every one in memory.
+	CacheMemoryEntries uint64 `json:"cacheMemoryEntries"`
+
+	// CacheDiskSizeLimit describes the maximum total size, in megabytes, of the cache directory. The least recently
+	// used caches of other RPCs and blocks are removed once exceeded. Zero never removes them.
+	CacheDiskSizeLimit uint64 `json:"cacheDiskSizeLimit"`
+
+	// PinBlockContext describes whether the genesis block of the test chain takes the number and timestamp of the
+	// fork block, so that the block context seen by forked contracts continues from the forked chain's.
+	PinBlockContext bool `json:"pinBlockContext"`
 }
 
 // CheatCodeConfig describes any configuration options related to the use of vm extensions (a.k.a. cheat codes)
@@ -46,6 +109,58 @@ type CheatCodeConfig struct {
 	EnableFFI bool `json:"enableFFI"`
 }
 
+// ValidateMocks verifies the mock contracts of the TestChainConfig are installed at valid, distinct addresses.
+// Returns an error if they are not.
+func (t *TestChainConfig) ValidateMocks() error {
+	addresses := make(map[common.Address]bool, len(t.Mocks))
+	for _, mock := range t.Mocks {
+		if !common.IsHexAddress(mock.Address) {
+			return fmt.Errorf("mock '%v' has an invalid address '%v'", mock.Name, mock.Address)
+		}
+		address := common.HexToAddress(mock.Address)
+		if addresses[address] {
+			return fmt.Errorf("more than one mock is installed at address %v", address)
+		}
+		addresses[address] = true
+	}
+	return nil
+}
+
+// ValidateHardfork verifies the hardfork of the TestChainConfig is supported.
+// Returns an error if it is not.
+func (t *TestChainConfig) ValidateHardfork() error {
+	switch strings.ToLower(t.Hardfork) {
+	case "", HardforkShanghai, HardforkCancun, HardforkPrague:
+		return nil
+	default:
+		return fmt.Errorf("unsupported hardfork '%v', expected one of %v, %v or %v", t.Hardfork, HardforkShanghai, HardforkCancun, HardforkPrague)
+	}
+}
+
+// ApplyToChainConfig applies the hardfork and chain id of the TestChainConfig to the provided params.ChainConfig,
+// which is expected to enable every hardfork up to Prague from genesis.
+// Returns an error if the hardfork is not supported.
+func (t *TestChainConfig) ApplyToChainConfig(chainConfig *params.ChainConfig) error {
+	if err := t.ValidateHardfork(); err != nil {
+		return err
+	}
+	genesisTime := uint64(0)
+	chainConfig.ShanghaiTime = &genesisTime
+	chainConfig.CancunTime = &genesisTime
+	chainConfig.PragueTime = &genesisTime
+	switch strings.ToLower(t.Hardfork) {
+	case HardforkCancun:
+		chainConfig.PragueTime = nil
+	case HardforkShanghai:
+		chainConfig.CancunTime = nil
+		chainConfig.PragueTime = nil
+	}
+	if t.ChainID != 0 {
+		chainConfig.ChainID = new(big.Int).SetUint64(t.ChainID)
+	}
+	return nil
+}
+
 // GetVMConfigExtensions derives a vm.ConfigExtensions from the provided TestChainConfig.
 func (t *TestChainConfig) GetVMConfigExtensions() *vm.ConfigExtensions {
 	// Create a copy of the contract address overrides that can be ephemerally updated by medusa-geth
@@ -12,11 +12,20 @@ func DefaultTestChainConfig() (*TestChainConfig, error) {
 		},
 		SkipAccountChecks: true,
 		ForkConfig: ForkConfig{
-			ForkModeEnabled: false,
-			RpcUrl:          "",
-			RpcBlock:        1,
-			PoolSize:        20,
+			ForkModeEnabled:    false,
+			RpcUrl:             "",
+			RpcBlock:           1,
+			PoolSize:           20,
+			CacheDirectory:     "",
+			CacheMemoryEntries: 1_000_000,
+			CacheDiskSizeLimit: 2048,
+			PinBlockContext:    true,
 		},
+		Hardfork:      HardforkPrague,
+		ChainID:       1,
+		BaseFee:       0,
+		BlockGasLimit: 0x0FFFFFFFFFFFFFFF,
+		Mocks:         []MockConfig{},
 	}
 
 	// Return the generated configuration.
@@ -0,0 +1,200 @@
+package chain
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+
+	"github.com/crytic/medusa-geth/accounts/abi"
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa/chain/config"
+	"github.com/crytic/medusa/chain/types"
+)
+
+// MockResponseProviderFunc describes a function which chooses the return data of a call made to a function of a
+// MockContract during a transaction.
+// Returns the return data to use, or nil if the function should return the data it last returned.
+type MockResponseProviderFunc func(mock *MockContract, method *abi.Method) []byte
+
+// MockContract defines a pre-compiled contract installed in place of an external dependency, such as an oracle, a
+// bridge or a chain-specific precompile. Its functions return data chosen by the TestChain's MockResponseProvider,
+// which is recorded in the results of each transaction so that it can be replayed.
+type MockContract struct {
+	// name describes the name of the mock contract.
+	name string
+
+	// address defines the address the mock contract is installed at.
+	address common.Address
+
+	// abi refers to the ABI definition of the functions the mock contract implements.
+	abi abi.ABI
+
+	// chain refers to the TestChain the mock contract is installed on, which chooses and records its return data. This
+	// is nil when the mock contract is first created, but is set to the TestChain which created it, after it is added.
+	chain *TestChain
+}
+
+// newMockContract returns a new MockContract as described by the provided config. It must be bound to the chain it is
+// installed on before it is called.
+// Returns the mock contract, or an error if its config is invalid.
+func newMockContract(mockConfig config.MockConfig) (*MockContract, error) {
+	if !common.IsHexAddress(mockConfig.Address) {
+		return nil, fmt.Errorf("mock '%v' has an invalid address '%v'", mockConfig.Name, mockConfig.Address)
+	}
+	mockAbi, err := abi.JSON(bytes.NewReader(mockConfig.ABI))
+	if err != nil {
+		return nil, fmt.Errorf("mock '%v' has an invalid ABI: %v", mockConfig.Name, err)
+	}
+	return &MockContract{
+		name:    mockConfig.Name,
+		address: common.HexToAddress(mockConfig.Address),
+		abi:     mockAbi,
+	}, nil
+}
+
+// Name represents the name of the mock contract.
+func (c *MockContract) Name() string {
+	return c.name
+}
+
+// Address represents the address the mock contract is installed at.
+func (c *MockContract) Address() common.Address {
+	return c.address
+}
+
+// Abi provides the interface of the functions the mock contract implements.
+func (c *MockContract) Abi() *abi.ABI {
+	return &c.abi
+}
+
+// RequiredGas determines the amount of gas necessary to execute the pre-compile with the given input data.
+// Returns the gas cost.
+func (c *MockContract) RequiredGas(input []byte) uint64 {
+	return 0
+}
+
+// Run executes the given pre-compile with the provided input data.
+// Returns the output data from execution, or an error if one occurred.
+func (c *MockContract) Run(input []byte) ([]byte, error) {
+	// Calling any method should require at least a signature
+	if len(input) < 4 {
+		return []byte{}, vm.ErrExecutionReverted
+	}
+
+	// Ensure the call targets a function the mock implements.
+	method, err := c.abi.MethodById(input[:4])
+	if err != nil {
+		return []byte{}, vm.ErrExecutionReverted
+	}
+	return c.chain.mockReturnData(c, method), nil
+}
+
+// zeroReturnData returns the return data of the provided method when each of its outputs has its zero value.
+func zeroReturnData(method *abi.Method) []byte {
+	values := make([]any, len(method.Outputs))
+	for i, output := range method.Outputs {
+		values[i] = reflect.New(output.Type.GetType()).Elem().Interface()
+	}
+	returnData, err := method.Outputs.Pack(values...)
+	if err != nil {
+		return []byte{}
+	}
+	return returnData
+}
+
+// MockContracts returns all mock contracts which are installed in the chain, indexed by address.
+func (t *TestChain) MockContracts() map[common.Address]*MockContract {
+	return t.mockContracts
+}
+
+// SetMockResponses sets the responses returned by the mock contracts during the next transaction added to the pending
+// block, such as those it recorded when it was first executed. Each response is returned once, to the first call made
+// to its function, in order. Calls made once the responses of a function are exhausted obtain return data from the
+// MockResponseProvider as usual.
+func (t *TestChain) SetMockResponses(responses []*types.MockResponse) {
+	t.replayedMockResponses = append([]*types.MockResponse(nil), responses...)
+}
+
+// mockReturnData obtains the return data of a call made to the provided function of a mock contract. During a
+// transaction, it is replayed from the responses set with SetMockResponses, or chosen by the MockResponseProvider,
+// and recorded in the transaction's results. Otherwise, or if no data was chosen, the data last returned by the
+// function is returned, or the zero value of its outputs if it was never called.
+// Returns the return data.
+func (t *TestChain) mockReturnData(mock *MockContract, method *abi.Method) []byte {
+	var returnData []byte
+	if t.recordingMockResponses {
+		// Replay the first response set for this function, if any remain.
+		replayed := false
+		for i, response := range t.replayedMockResponses {
+			if response.Matches(mock.address, method.ID) {
+				returnData = response.ReturnData
+				replayed = true
+				t.replayedMockResponses = append(t.replayedMockResponses[:i], t.replayedMockResponses[i+1:]...)
+				break
+			}
+		}
+
+		// Otherwise, let the provider choose the return data.
+		if !replayed && t.MockResponseProvider != nil {
+			returnData = t.MockResponseProvider(mock, method)
+		}
+	}
+
+	// Fall back to the data the function last returned, or to zero values.
+	if returnData == nil {
+		returnData = t.lastMockReturnData(mock.address, method.ID)
+	}
+	if returnData == nil {
+		returnData = zeroReturnData(method)
+	}
+
+	// Record the response in the transaction's results, so it can be replayed.
+	if t.recordingMockResponses {
+		t.pendingMockResponses = append(t.pendingMockResponses, &types.MockResponse{
+			Address:    mock.address,
+			Selector:   append([]byte(nil), method.ID...),
+			ReturnData: returnData,
+		})
+	}
+	return returnData
+}
+
+// lastMockReturnData obtains the data last returned by the provided function of the mock contract at the provided
+// address, searching the current transaction, then the pending block, then committed blocks.
+// Returns the return data, or nil if the function was never called.
+func (t *TestChain) lastMockReturnData(address common.Address, selector []byte) []byte {
+	lastResponse := func(responses []*types.MockResponse) []byte {
+		for i := len(responses) - 1; i >= 0; i-- {
+			if responses[i].Matches(address, selector) {
+				return responses[i].ReturnData
+			}
+		}
+		return nil
+	}
+	lastBlockResponse := func(block *types.Block) []byte {
+		for i := len(block.MessageResults) - 1; i >= 0; i-- {
+			if returnData := lastResponse(block.MessageResults[i].MockResponses); returnData != nil {
+				return returnData
+			}
+		}
+		return nil
+	}
+
+	if t.recordingMockResponses {
+		if returnData := lastResponse(t.pendingMockResponses); returnData != nil {
+			return returnData
+		}
+	}
+	if t.pendingBlock != nil {
+		if returnData := lastBlockResponse(t.pendingBlock); returnData != nil {
+			return returnData
+		}
+	}
+	for i := len(t.blocks) - 1; i >= 0; i-- {
+		if returnData := lastBlockResponse(t.blocks[i]); returnData != nil {
+			return returnData
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package chain
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/crytic/medusa-geth/accounts/abi"
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core"
+	"github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa/chain/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestChainMockContracts ensures calls to mock contracts return the data chosen by the chain's provider, keep
+// returning it when none is chosen, and replay the recorded responses when the chain is cloned.
+func TestChainMockContracts(t *testing.T) {
+	// Create a chain with an oracle mock and a funded sender.
+	sender := common.HexToAddress("0x10000")
+	oracle := common.HexToAddress("0x20000")
+	testChainConfig, err := config.DefaultTestChainConfig()
+	assert.NoError(t, err)
+	testChainConfig.Mocks = []config.MockConfig{{
+		Address: oracle.Hex(),
+		Name:    "Oracle",
+		ABI:     []byte(`[{"type":"function","name":"latestAnswer","inputs":[],"outputs":[{"name":"","type":"int256"}],"stateMutability":"view"}]`),
+	}}
+	genesisAlloc := types.GenesisAlloc{
+		sender: {Balance: big.NewInt(1_000_000)},
+	}
+	testChain, err := NewTestChain(context.Background(), genesisAlloc, testChainConfig)
+	assert.NoError(t, err)
+	defer testChain.Close()
+	assert.Len(t, testChain.MockContracts(), 1)
+	assert.EqualValues(t, "Oracle", testChain.Labels[oracle])
+
+	// Let the provider choose the answer of the first call only.
+	method := testChain.MockContracts()[oracle].Abi().Methods["latestAnswer"]
+	answer, err := method.Outputs.Pack(big.NewInt(42))
+	assert.NoError(t, err)
+	provided := false
+	testChain.MockResponseProvider = func(mock *MockContract, method *abi.Method) []byte {
+		if provided {
+			return nil
+		}
+		provided = true
+		return answer
+	}
+
+	// Call the mock twice, in separate blocks.
+	for nonce := uint64(0); nonce < 2; nonce++ {
+		_, err = testChain.PendingBlockCreate()
+		assert.NoError(t, err)
+		err = testChain.PendingBlockAddTx(&core.Message{
+			From:      sender,
+			To:        &oracle,
+			Nonce:     nonce,
+			Value:     big.NewInt(0),
+			GasLimit:  1_000_000,
+			GasPrice:  big.NewInt(0),
+			GasFeeCap: big.NewInt(0),
+			GasTipCap: big.NewInt(0),
+			Data:      method.ID,
+		})
+		assert.NoError(t, err)
+		err = testChain.PendingBlockCommit()
+		assert.NoError(t, err)
+	}
+
+	// Both calls should have returned the provided answer, and recorded it.
+	for _, block := range testChain.CommittedBlocks()[1:] {
+		messageResults := block.MessageResults[0]
+		assert.EqualValues(t, answer, messageResults.ExecutionResult.ReturnData)
+		assert.Len(t, messageResults.MockResponses, 1)
+		assert.EqualValues(t, answer, messageResults.MockResponses[0].ReturnData)
+	}
+
+	// A clone without a provider should replay the recorded responses.
+	clonedChain, err := testChain.Clone(nil)
+	assert.NoError(t, err)
+	defer clonedChain.Close()
+	for _, block := range clonedChain.CommittedBlocks()[1:] {
+		assert.EqualValues(t, answer, block.MessageResults[0].ExecutionResult.ReturnData)
+	}
+}
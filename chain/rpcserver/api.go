@@ -0,0 +1,441 @@
+package rpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/common/hexutil"
+	"github.com/crytic/medusa-geth/core"
+	"github.com/crytic/medusa-geth/rpc"
+	chainTypes "github.com/crytic/medusa/chain/types"
+	"github.com/crytic/medusa/utils"
+)
+
+// clientVersion describes the client version reported by web3_clientVersion.
+const clientVersion = "medusa/testchain"
+
+// callGasLimit describes the gas limit used for eth_call and eth_estimateGas when none is provided.
+const callGasLimit = 30_000_000
+
+// web3API implements the web3_ JSON-RPC namespace.
+type web3API struct{}
+
+// ClientVersion implements web3_clientVersion.
+func (api *web3API) ClientVersion() string {
+	return clientVersion
+}
+
+// netAPI implements the net_ JSON-RPC namespace.
+type netAPI struct {
+	server *Server
+}
+
+// Version implements net_version.
+func (api *netAPI) Version() string {
+	api.server.lock.Lock()
+	defer api.server.lock.Unlock()
+	return api.server.testChain.GenesisDefinition().Config.ChainID.String()
+}
+
+// Listening implements net_listening.
+func (api *netAPI) Listening() bool {
+	return true
+}
+
+// ethAPI implements the subset of the eth_ JSON-RPC namespace which can be served from a chain.TestChain.
+type ethAPI struct {
+	server *Server
+}
+
+// CallArgs describes the arguments accepted by eth_call and eth_estimateGas.
+type CallArgs struct {
+	From     *common.Address `json:"from"`
+	To       *common.Address `json:"to"`
+	Gas      *hexutil.Uint64 `json:"gas"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	Value    *hexutil.Big    `json:"value"`
+	Data     *hexutil.Bytes  `json:"data"`
+	Input    *hexutil.Bytes  `json:"input"`
+}
+
+// toCoreMessage converts the CallArgs into a core.Message which can be executed by the TestChain.
+func (args *CallArgs) toCoreMessage() *core.Message {
+	msg := &core.Message{
+		To:               args.To,
+		GasLimit:         callGasLimit,
+		Value:            big.NewInt(0),
+		GasPrice:         big.NewInt(0),
+		GasFeeCap:        big.NewInt(0),
+		GasTipCap:        big.NewInt(0),
+		SkipNonceChecks:  true,
+		SkipFromEOACheck: true,
+	}
+	if args.From != nil {
+		msg.From = *args.From
+	}
+	if args.Gas != nil {
+		msg.GasLimit = uint64(*args.Gas)
+	}
+	if args.GasPrice != nil {
+		msg.GasPrice = args.GasPrice.ToInt()
+	}
+	if args.Value != nil {
+		msg.Value = args.Value.ToInt()
+	}
+	if args.Input != nil {
+		msg.Data = *args.Input
+	} else if args.Data != nil {
+		msg.Data = *args.Data
+	}
+	return msg
+}
+
+// ChainId implements eth_chainId.
+func (api *ethAPI) ChainId() *hexutil.Big {
+	api.server.lock.Lock()
+	defer api.server.lock.Unlock()
+	return (*hexutil.Big)(api.server.testChain.GenesisDefinition().Config.ChainID)
+}
+
+// BlockNumber implements eth_blockNumber.
+func (api *ethAPI) BlockNumber() hexutil.Uint64 {
+	api.server.lock.Lock()
+	defer api.server.lock.Unlock()
+	return hexutil.Uint64(api.server.testChain.HeadBlockNumber())
+}
+
+// GasPrice implements eth_gasPrice. The TestChain does not enforce fees, so zero is always reported.
+func (api *ethAPI) GasPrice() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(0))
+}
+
+// Syncing implements eth_syncing. The TestChain is always considered synced.
+func (api *ethAPI) Syncing() bool {
+	return false
+}
+
+// Accounts implements eth_accounts. The TestChain does not manage any keys.
+func (api *ethAPI) Accounts() []common.Address {
+	return []common.Address{}
+}
+
+// GetBalance implements eth_getBalance.
+func (api *ethAPI) GetBalance(address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error) {
+	api.server.lock.Lock()
+	defer api.server.lock.Unlock()
+
+	state, err := api.stateAt(blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(state.GetBalance(address).ToBig()), nil
+}
+
+// GetTransactionCount implements eth_getTransactionCount.
+func (api *ethAPI) GetTransactionCount(address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Uint64, error) {
+	api.server.lock.Lock()
+	defer api.server.lock.Unlock()
+
+	state, err := api.stateAt(blockNrOrHash)
+	if err != nil {
+		return 0, err
+	}
+	return hexutil.Uint64(state.GetNonce(address)), nil
+}
+
+// GetCode implements eth_getCode.
+func (api *ethAPI) GetCode(address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	api.server.lock.Lock()
+	defer api.server.lock.Unlock()
+
+	state, err := api.stateAt(blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	return state.GetCode(address), nil
+}
+
+// GetStorageAt implements eth_getStorageAt.
+func (api *ethAPI) GetStorageAt(address common.Address, slot hexutil.Big, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	api.server.lock.Lock()
+	defer api.server.lock.Unlock()
+
+	state, err := api.stateAt(blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	value := state.GetState(address, common.BigToHash(slot.ToInt()))
+	return value.Bytes(), nil
+}
+
+// Call implements eth_call. The call is executed over the state of the requested block and any changes are discarded.
+func (api *ethAPI) Call(ctx context.Context, args CallArgs, blockNrOrHash *rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	api.server.lock.Lock()
+	defer api.server.lock.Unlock()
+
+	result, err := api.call(args, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if result.Err != nil {
+		return result.Revert(), result.Err
+	}
+	return result.Return(), nil
+}
+
+// EstimateGas implements eth_estimateGas by reporting the gas used when executing the call over the latest state.
+func (api *ethAPI) EstimateGas(ctx context.Context, args CallArgs, blockNrOrHash *rpc.BlockNumberOrHash) (hexutil.Uint64, error) {
+	api.server.lock.Lock()
+	defer api.server.lock.Unlock()
+
+	result, err := api.call(args, blockNrOrHash)
+	if err != nil {
+		return 0, err
+	}
+	if result.Err != nil {
+		return 0, result.Err
+	}
+	return hexutil.Uint64(result.UsedGas), nil
+}
+
+// GetBlockByNumber implements eth_getBlockByNumber.
+func (api *ethAPI) GetBlockByNumber(number rpc.BlockNumber, fullTx bool) (map[string]any, error) {
+	api.server.lock.Lock()
+	defer api.server.lock.Unlock()
+
+	block, err := api.blockByNumber(number)
+	if err != nil {
+		return nil, nil
+	}
+	return marshalBlock(block, fullTx), nil
+}
+
+// GetBlockByHash implements eth_getBlockByHash.
+func (api *ethAPI) GetBlockByHash(hash common.Hash, fullTx bool) (map[string]any, error) {
+	api.server.lock.Lock()
+	defer api.server.lock.Unlock()
+
+	block := api.blockByHash(hash)
+	if block == nil {
+		return nil, nil
+	}
+	return marshalBlock(block, fullTx), nil
+}
+
+// GetBlockTransactionCountByNumber implements eth_getBlockTransactionCountByNumber.
+func (api *ethAPI) GetBlockTransactionCountByNumber(number rpc.BlockNumber) (*hexutil.Uint, error) {
+	api.server.lock.Lock()
+	defer api.server.lock.Unlock()
+
+	block, err := api.blockByNumber(number)
+	if err != nil {
+		return nil, nil
+	}
+	count := hexutil.Uint(len(block.Messages))
+	return &count, nil
+}
+
+// GetTransactionByHash implements eth_getTransactionByHash.
+func (api *ethAPI) GetTransactionByHash(hash common.Hash) (map[string]any, error) {
+	api.server.lock.Lock()
+	defer api.server.lock.Unlock()
+
+	block, index := api.transactionByHash(hash)
+	if block == nil {
+		return nil, nil
+	}
+	return marshalTransaction(block, index), nil
+}
+
+// GetTransactionReceipt implements eth_getTransactionReceipt.
+func (api *ethAPI) GetTransactionReceipt(hash common.Hash) (map[string]any, error) {
+	api.server.lock.Lock()
+	defer api.server.lock.Unlock()
+
+	block, index := api.transactionByHash(hash)
+	if block == nil {
+		return nil, nil
+	}
+	return marshalReceipt(block, index), nil
+}
+
+// call executes the provided CallArgs over the state of the requested block. The caller must hold the server lock.
+func (api *ethAPI) call(args CallArgs, blockNrOrHash *rpc.BlockNumberOrHash) (*core.ExecutionResult, error) {
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	if blockNrOrHash == nil {
+		blockNrOrHash = &latest
+	}
+	state, err := api.stateAt(*blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	return api.server.testChain.CallContract(args.toCoreMessage(), state)
+}
+
+// stateAt obtains the world state after the requested block. The caller must hold the server lock.
+func (api *ethAPI) stateAt(blockNrOrHash rpc.BlockNumberOrHash) (chainTypes.MedusaStateDB, error) {
+	var block *chainTypes.Block
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		block = api.blockByHash(hash)
+		if block == nil {
+			return nil, fmt.Errorf("could not find block with hash %s", hash.Hex())
+		}
+	} else if number, ok := blockNrOrHash.Number(); ok {
+		var err error
+		block, err = api.blockByNumber(number)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, errors.New("invalid block number or hash provided")
+	}
+	return api.server.testChain.StateFromRoot(block.Header.Root)
+}
+
+// blockByNumber resolves a block number, including the latest/pending/earliest tags, to a committed block.
+// The caller must hold the server lock.
+func (api *ethAPI) blockByNumber(number rpc.BlockNumber) (*chainTypes.Block, error) {
+	testChain := api.server.testChain
+	switch number {
+	case rpc.LatestBlockNumber, rpc.PendingBlockNumber, rpc.SafeBlockNumber, rpc.FinalizedBlockNumber:
+		return testChain.Head(), nil
+	case rpc.EarliestBlockNumber:
+		return testChain.CommittedBlocks()[0], nil
+	}
+	if number < 0 {
+		return nil, fmt.Errorf("unsupported block number %d", number)
+	}
+	return testChain.BlockFromNumber(uint64(number))
+}
+
+// blockByHash obtains the committed block with the provided hash, or nil if it does not exist.
+// The caller must hold the server lock.
+func (api *ethAPI) blockByHash(hash common.Hash) *chainTypes.Block {
+	for _, block := range api.server.testChain.CommittedBlocks() {
+		if block.Hash == hash {
+			return block
+		}
+	}
+	return nil
+}
+
+// transactionByHash obtains the committed block and message index for the transaction with the provided hash, or a
+// nil block if it does not exist. The caller must hold the server lock.
+func (api *ethAPI) transactionByHash(hash common.Hash) (*chainTypes.Block, int) {
+	for _, block := range api.server.testChain.CommittedBlocks() {
+		for i := range block.Messages {
+			if transactionHash(block, i) == hash {
+				return block, i
+			}
+		}
+	}
+	return nil, 0
+}
+
+// transactionHash obtains the hash of the transaction at the provided index in a block.
+func transactionHash(block *chainTypes.Block, index int) common.Hash {
+	if index < len(block.MessageResults) && block.MessageResults[index].Receipt != nil {
+		return block.MessageResults[index].Receipt.TxHash
+	}
+	return utils.MessageToTransaction(block.Messages[index]).Hash()
+}
+
+// marshalBlock converts a block into its JSON-RPC representation. If fullTx is true, full transaction objects are
+// included rather than only their hashes.
+func marshalBlock(block *chainTypes.Block, fullTx bool) map[string]any {
+	header := block.Header
+	transactions := make([]any, 0, len(block.Messages))
+	for i := range block.Messages {
+		if fullTx {
+			transactions = append(transactions, marshalTransaction(block, i))
+		} else {
+			transactions = append(transactions, transactionHash(block, i))
+		}
+	}
+
+	result := map[string]any{
+		"number":           (*hexutil.Big)(header.Number),
+		"hash":             block.Hash,
+		"parentHash":       header.ParentHash,
+		"nonce":            header.Nonce,
+		"sha3Uncles":       header.UncleHash,
+		"logsBloom":        header.Bloom,
+		"stateRoot":        header.Root,
+		"miner":            header.Coinbase,
+		"difficulty":       (*hexutil.Big)(header.Difficulty),
+		"totalDifficulty":  (*hexutil.Big)(big.NewInt(0)),
+		"extraData":        hexutil.Bytes(header.Extra),
+		"size":             hexutil.Uint64(header.Size()),
+		"gasLimit":         hexutil.Uint64(header.GasLimit),
+		"gasUsed":          hexutil.Uint64(header.GasUsed),
+		"timestamp":        hexutil.Uint64(header.Time),
+		"transactionsRoot": header.TxHash,
+		"receiptsRoot":     header.ReceiptHash,
+		"mixHash":          header.MixDigest,
+		"uncles":           []common.Hash{},
+		"transactions":     transactions,
+	}
+	if header.BaseFee != nil {
+		result["baseFeePerGas"] = (*hexutil.Big)(header.BaseFee)
+	}
+	return result
+}
+
+// marshalTransaction converts the message at the provided index in a block into its JSON-RPC transaction
+// representation.
+func marshalTransaction(block *chainTypes.Block, index int) map[string]any {
+	msg := block.Messages[index]
+	return map[string]any{
+		"blockHash":        block.Hash,
+		"blockNumber":      (*hexutil.Big)(block.Header.Number),
+		"hash":             transactionHash(block, index),
+		"transactionIndex": hexutil.Uint64(index),
+		"from":             msg.From,
+		"to":               msg.To,
+		"nonce":            hexutil.Uint64(msg.Nonce),
+		"gas":              hexutil.Uint64(msg.GasLimit),
+		"gasPrice":         (*hexutil.Big)(msg.GasPrice),
+		"value":            (*hexutil.Big)(msg.Value),
+		"input":            hexutil.Bytes(msg.Data),
+		"type":             hexutil.Uint64(0),
+	}
+}
+
+// marshalReceipt converts the results of the message at the provided index in a block into its JSON-RPC receipt
+// representation.
+func marshalReceipt(block *chainTypes.Block, index int) map[string]any {
+	msg := block.Messages[index]
+	result := map[string]any{
+		"blockHash":        block.Hash,
+		"blockNumber":      (*hexutil.Big)(block.Header.Number),
+		"transactionHash":  transactionHash(block, index),
+		"transactionIndex": hexutil.Uint64(index),
+		"from":             msg.From,
+		"to":               msg.To,
+	}
+	if index >= len(block.MessageResults) || block.MessageResults[index].Receipt == nil {
+		return result
+	}
+
+	receipt := block.MessageResults[index].Receipt
+	result["status"] = hexutil.Uint64(receipt.Status)
+	result["gasUsed"] = hexutil.Uint64(receipt.GasUsed)
+	result["cumulativeGasUsed"] = hexutil.Uint64(receipt.CumulativeGasUsed)
+	result["effectiveGasPrice"] = (*hexutil.Big)(msg.GasPrice)
+	result["logsBloom"] = receipt.Bloom
+	result["type"] = hexutil.Uint64(receipt.Type)
+	if receipt.Logs != nil {
+		result["logs"] = receipt.Logs
+	} else {
+		result["logs"] = []any{}
+	}
+	if receipt.ContractAddress != (common.Address{}) {
+		result["contractAddress"] = receipt.ContractAddress
+	} else {
+		result["contractAddress"] = nil
+	}
+	return result
+}
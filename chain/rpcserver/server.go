@@ -0,0 +1,103 @@
+package rpcserver
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/crytic/medusa-geth/rpc"
+	"github.com/crytic/medusa/chain"
+)
+
+// Server exposes a chain.TestChain over a minimal Ethereum JSON-RPC (eth_, net_, web3_) HTTP endpoint, so that
+// external tools such as block explorers, debuggers, or wallets can inspect the fuzzed chain state.
+// The TestChain is not thread-safe, so all requests are serialized through the Server's lock. The chain being
+// served must not be mutated by other goroutines while the Server is running.
+type Server struct {
+	// testChain describes the chain currently being served.
+	testChain *chain.TestChain
+
+	// lock provides thread-synchronization between concurrent requests and SetTestChain.
+	lock sync.Mutex
+
+	// rpcServer describes the underlying go-ethereum JSON-RPC server which dispatches requests to our services.
+	rpcServer *rpc.Server
+
+	// httpServer describes the HTTP server which hosts the rpcServer.
+	httpServer *http.Server
+
+	// listener describes the network listener used by httpServer.
+	listener net.Listener
+}
+
+// NewServer creates a new Server which serves the provided TestChain. The Server must be started with Start.
+// Returns the Server, or an error if one occurred.
+func NewServer(testChain *chain.TestChain) (*Server, error) {
+	s := &Server{
+		testChain: testChain,
+		rpcServer: rpc.NewServer(),
+	}
+
+	// Register our services under their respective namespaces.
+	services := map[string]any{
+		"eth":  &ethAPI{server: s},
+		"net":  &netAPI{server: s},
+		"web3": &web3API{},
+	}
+	for namespace, service := range services {
+		if err := s.rpcServer.RegisterName(namespace, service); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// SetTestChain replaces the chain being served, e.g. with a chain a reproducer was replayed on.
+func (s *Server) SetTestChain(testChain *chain.TestChain) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.testChain = testChain
+}
+
+// Start begins listening for JSON-RPC requests over HTTP on the provided address (e.g. "127.0.0.1:8545").
+// Requests are served on a separate goroutine until Close is called.
+// Returns an error if the listener could not be created.
+func (s *Server) Start(address string) error {
+	if s.httpServer != nil {
+		return errors.New("the JSON-RPC server was already started")
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+	s.httpServer = &http.Server{Handler: s.rpcServer}
+
+	go func() {
+		_ = s.httpServer.Serve(listener)
+	}()
+	return nil
+}
+
+// Address returns the network address the Server is listening on, or an empty string if it was not started.
+func (s *Server) Address() string {
+	if s.listener == nil {
+		return ""
+	}
+	return s.listener.Addr().String()
+}
+
+// Close stops the Server from serving any further requests.
+// Returns an error if one occurred while shutting down the HTTP server.
+func (s *Server) Close() error {
+	s.rpcServer.Stop()
+	if s.httpServer == nil {
+		return nil
+	}
+	err := s.httpServer.Close()
+	s.httpServer = nil
+	s.listener = nil
+	return err
+}
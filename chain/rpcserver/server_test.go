@@ -0,0 +1,54 @@
+package rpcserver
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/common/hexutil"
+	"github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa-geth/rpc"
+	"github.com/crytic/medusa/chain"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestServerQueriesTestChain starts a Server over a fresh TestChain and verifies basic eth_ queries are answered
+// from the chain's state.
+func TestServerQueriesTestChain(t *testing.T) {
+	// Create a chain with a single funded account
+	account := common.HexToAddress("0x0707")
+	balance := big.NewInt(1_000_000)
+	genesisAlloc := types.GenesisAlloc{account: types.Account{Balance: balance}}
+	testChain, err := chain.NewTestChain(context.Background(), genesisAlloc, nil)
+	assert.NoError(t, err)
+
+	// Start our server on an ephemeral port
+	server, err := NewServer(testChain)
+	assert.NoError(t, err)
+	assert.NoError(t, server.Start("127.0.0.1:0"))
+	defer server.Close()
+
+	client, err := rpc.Dial("http://" + server.Address())
+	assert.NoError(t, err)
+	defer client.Close()
+
+	// Verify the block number and chain ID match the chain
+	var blockNumber hexutil.Uint64
+	assert.NoError(t, client.Call(&blockNumber, "eth_blockNumber"))
+	assert.EqualValues(t, testChain.HeadBlockNumber(), blockNumber)
+
+	var chainId hexutil.Big
+	assert.NoError(t, client.Call(&chainId, "eth_chainId"))
+	assert.EqualValues(t, 0, testChain.GenesisDefinition().Config.ChainID.Cmp(chainId.ToInt()))
+
+	// Verify the account balance is read from the chain's state
+	var accountBalance hexutil.Big
+	assert.NoError(t, client.Call(&accountBalance, "eth_getBalance", account, "latest"))
+	assert.EqualValues(t, 0, balance.Cmp(accountBalance.ToInt()))
+
+	// Verify the genesis block can be obtained
+	var block map[string]any
+	assert.NoError(t, client.Call(&block, "eth_getBlockByNumber", "earliest", false))
+	assert.Equal(t, testChain.CommittedBlocks()[0].Hash.Hex(), block["hash"])
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/crytic/medusa-geth/common"
 	"github.com/stretchr/testify/assert"
@@ -121,7 +123,7 @@ func TestPersistentCache(t *testing.T) {
 	assert.NoError(t, err)
 	defer os.RemoveAll(tmpDir)
 
-	pc, err := newPersistentCache(ctx, tmpDir, rpcAddr, blockHeight)
+	pc, err := newPersistentCache(ctx, rpcAddr, blockHeight, PersistentCacheOptions{Directory: tmpDir})
 	assert.NoError(t, err)
 
 	stateObjectAddr := common.Address{0x55}
@@ -163,7 +165,7 @@ func TestPersistentCache(t *testing.T) {
 
 	ctx, cancel = context.WithCancel(context.Background())
 	defer cancel()
-	pc, err = newPersistentCache(ctx, tmpDir, rpcAddr, blockHeight)
+	pc, err = newPersistentCache(ctx, rpcAddr, blockHeight, PersistentCacheOptions{Directory: tmpDir})
 	assert.NoError(t, err)
 
 	// state cache matches
@@ -176,3 +178,114 @@ func TestPersistentCache(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, stateSlotData, data)
 }
+
+// TestLruStateCacheEviction tests that the least recently used state objects and slots are evicted once the cache
+// exceeds its capacity.
+func TestLruStateCacheEviction(t *testing.T) {
+	cache := newLruStateCache(2)
+	for i := byte(0); i < 3; i++ {
+		// Reading the first entry makes the second one the least recently used.
+		if i == 2 {
+			_, err := cache.GetStateObject(common.Address{0})
+			assert.NoError(t, err)
+			_, err = cache.GetSlotData(common.Address{0}, common.Hash{0})
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, cache.WriteStateObject(common.Address{i}, StateObject{Nonce: uint64(i)}))
+		assert.NoError(t, cache.WriteSlotData(common.Address{0}, common.Hash{i}, common.Hash{i}))
+	}
+
+	_, err := cache.GetStateObject(common.Address{1})
+	assert.Equal(t, ErrCacheMiss, err)
+	_, err = cache.GetSlotData(common.Address{0}, common.Hash{1})
+	assert.Equal(t, ErrCacheMiss, err)
+	for _, i := range []byte{0, 2} {
+		so, err := cache.GetStateObject(common.Address{i})
+		assert.NoError(t, err)
+		assert.EqualValues(t, i, so.Nonce)
+		data, err := cache.GetSlotData(common.Address{0}, common.Hash{i})
+		assert.NoError(t, err)
+		assert.Equal(t, common.Hash{i}, data)
+	}
+}
+
+// TestPersistentCacheMemoryEntries tests that entries evicted from the memory of a bounded persistent cache are read
+// back from disk.
+func TestPersistentCacheMemoryEntries(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pc, err := newPersistentCache(ctx, "www.rpc.net/ethereum/etc", 55555, PersistentCacheOptions{Directory: t.TempDir(), MemoryEntries: 1})
+	assert.NoError(t, err)
+	for i := byte(0); i < 3; i++ {
+		assert.NoError(t, pc.WriteStateObject(common.Address{i}, StateObject{Nonce: uint64(i)}))
+	}
+	assert.NoError(t, pc.flushWrites())
+
+	for i := byte(0); i < 3; i++ {
+		so, err := pc.GetStateObject(common.Address{i})
+		assert.NoError(t, err)
+		assert.EqualValues(t, i, so.Nonce)
+	}
+}
+
+// TestPersistentCacheDiskSizeLimit tests that opening a persistent cache removes the least recently used cache files
+// of other RPC addresses and blocks once the cache directory exceeds its size limit.
+func TestPersistentCacheDiskSizeLimit(t *testing.T) {
+	tmpDir := t.TempDir()
+	rpcAddr := "www.rpc.net/ethereum/etc"
+
+	// Create the caches of three blocks, from the least to the most recently used.
+	for height := uint64(1); height <= 3; height++ {
+		pc, err := newPersistentCache(context.Background(), rpcAddr, height, PersistentCacheOptions{Directory: tmpDir})
+		assert.NoError(t, err)
+		assert.NoError(t, pc.Close())
+
+		usedAt := time.Now().Add(time.Duration(height-4) * time.Hour)
+		assert.NoError(t, os.Chtimes(filepath.Join(tmpDir, getCacheFilename(rpcAddr, height)), usedAt, usedAt))
+	}
+	info, err := os.Stat(filepath.Join(tmpDir, getCacheFilename(rpcAddr, 1)))
+	assert.NoError(t, err)
+
+	// Opening the cache of the first block with room for two caches removes the cache of the second block.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err = newPersistentCache(ctx, rpcAddr, 1, PersistentCacheOptions{Directory: tmpDir, DiskSizeLimit: 2 * info.Size()})
+	assert.NoError(t, err)
+	for height, exists := range map[uint64]bool{1: true, 2: false, 3: true} {
+		_, err = os.Stat(filepath.Join(tmpDir, getCacheFilename(rpcAddr, height)))
+		assert.Equal(t, exists, err == nil, "cache of block %d", height)
+	}
+}
+
+// TestPersistentCacheDiskSizeLimitOpenFiles tests that the cache files another persistent cache holds open are not
+// removed when evicting cache files, and the next least recently used ones are removed instead.
+func TestPersistentCacheDiskSizeLimitOpenFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	rpcAddr := "www.rpc.net/ethereum/etc"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Create the caches of three blocks, from the least to the most recently used, keeping that of the second open.
+	for height := uint64(1); height <= 3; height++ {
+		pc, err := newPersistentCache(ctx, rpcAddr, height, PersistentCacheOptions{Directory: tmpDir})
+		assert.NoError(t, err)
+		if height != 2 {
+			assert.NoError(t, pc.Close())
+		}
+
+		usedAt := time.Now().Add(time.Duration(height-4) * time.Hour)
+		assert.NoError(t, os.Chtimes(filepath.Join(tmpDir, getCacheFilename(rpcAddr, height)), usedAt, usedAt))
+	}
+	info, err := os.Stat(filepath.Join(tmpDir, getCacheFilename(rpcAddr, 1)))
+	assert.NoError(t, err)
+
+	// Opening the cache of the first block with room for two caches removes the cache of the third block, as the
+	// cache of the second block is in use.
+	_, err = newPersistentCache(ctx, rpcAddr, 1, PersistentCacheOptions{Directory: tmpDir, DiskSizeLimit: 2 * info.Size()})
+	assert.NoError(t, err)
+	for height, exists := range map[uint64]bool{1: true, 2: true, 3: false} {
+		_, err = os.Stat(filepath.Join(tmpDir, getCacheFilename(rpcAddr, height)))
+		assert.Equal(t, exists, err == nil, "cache of block %d", height)
+	}
+}
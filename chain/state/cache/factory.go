@@ -4,21 +4,41 @@ import (
 	"context"
 	"errors"
 	"os"
+	"path/filepath"
 )
 
 var _ StateCache = (*nonPersistentStateCache)(nil)
 var _ StateCache = (*persistentCache)(nil)
+var _ StateCache = (*lruStateCache)(nil)
 
 var ErrCacheMiss = errors.New("not found in cache")
 
+// PersistentCacheOptions describes how a persistent cache stores its content in memory and on disk.
+type PersistentCacheOptions struct {
+	// Directory describes the directory the cache files are stored in. If empty, the .medusacache directory of the
+	// working directory is used.
+	Directory string
+
+	// MemoryEntries describes the maximum amount of state objects, and of slots, kept in memory. The least recently
+	// used entries are evicted from memory once exceeded, but remain on disk. Zero keeps every entry in memory.
+	MemoryEntries int
+
+	// DiskSizeLimit describes the maximum total size in bytes of the cache files in Directory. The least recently used
+	// cache files of other RPC addresses and blocks are removed once exceeded. Zero never removes cache files.
+	DiskSizeLimit int64
+}
+
 // NewPersistentCache creates a new set of persistent caches that will persist cache content to disk.
 // Each cache is indexed by the RPC address (to separate network caches) and blockNum
-func NewPersistentCache(ctx context.Context, rpcAddr string, height uint64) (StateCache, error) {
-	workingDir, err := os.Getwd()
-	if err != nil {
-		return nil, err
+func NewPersistentCache(ctx context.Context, rpcAddr string, height uint64, options PersistentCacheOptions) (StateCache, error) {
+	if options.Directory == "" {
+		workingDir, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		options.Directory = filepath.Join(workingDir, ".medusacache")
 	}
-	return newPersistentCache(ctx, workingDir, rpcAddr, height)
+	return newPersistentCache(ctx, rpcAddr, height, options)
 }
 
 func NewNonPersistentCache() (StateCache, error) {
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/common/lru"
+)
+
+// slotKey identifies a storage slot of an account in the lruStateCache.
+type slotKey struct {
+	addr common.Address
+	slot common.Hash
+}
+
+// lruStateCache provides a thread-safe cache for storing state objects and slots without persisting to disk, which
+// evicts the least recently used entries once it holds more than its capacity of each.
+type lruStateCache struct {
+	stateObjectCache *lru.Cache[common.Address, StateObject]
+	slotCache        *lru.Cache[slotKey, common.Hash]
+}
+
+func newLruStateCache(capacity int) *lruStateCache {
+	return &lruStateCache{
+		stateObjectCache: lru.NewCache[common.Address, StateObject](capacity),
+		slotCache:        lru.NewCache[slotKey, common.Hash](capacity),
+	}
+}
+
+// GetStateObject checks if the addr is present in the cache, and if not, returns an error
+func (s *lruStateCache) GetStateObject(addr common.Address) (*StateObject, error) {
+	if obj, ok := s.stateObjectCache.Get(addr); ok {
+		return &obj, nil
+	}
+	return nil, ErrCacheMiss
+}
+
+func (s *lruStateCache) WriteStateObject(addr common.Address, data StateObject) error {
+	s.stateObjectCache.Add(addr, data)
+	return nil
+}
+
+// GetSlotData checks if the specified data is stored in the cache, and if not, returns an error.
+func (s *lruStateCache) GetSlotData(addr common.Address, slot common.Hash) (common.Hash, error) {
+	if data, ok := s.slotCache.Get(slotKey{addr, slot}); ok {
+		return data, nil
+	}
+	return common.Hash{}, ErrCacheMiss
+}
+
+func (s *lruStateCache) WriteSlotData(addr common.Address, slot common.Hash, data common.Hash) error {
+	s.slotCache.Add(slotKey{addr, slot}, data)
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -18,7 +19,7 @@ import (
 
 // persistentCache provides a thread-safe cache for storing objects/slots that persists the cache to disk.
 type persistentCache struct {
-	memCache *nonPersistentStateCache
+	memCache StateCache
 	db       *bbolt.DB
 
 	pendingWriteMutex sync.Mutex
@@ -31,17 +32,25 @@ type pendingWrite struct {
 	value []byte
 }
 
-func newPersistentCache(ctx context.Context, workingDir string, rpcAddr string, height uint64) (*persistentCache, error) {
-	cacheDir, err := createCacheDirectory(workingDir)
+func newPersistentCache(ctx context.Context, rpcAddr string, height uint64, options PersistentCacheOptions) (*persistentCache, error) {
+	err := createCacheDirectory(options.Directory)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create cache directory: %w", err)
 	}
-	cacheFile := filepath.Join(cacheDir, getCacheFilename(rpcAddr, height))
-	db, err := bbolt.Open(cacheFile, 0600, &bbolt.Options{Timeout: 1 * time.Second})
+	cacheFilename := getCacheFilename(rpcAddr, height)
+	db, err := bbolt.Open(filepath.Join(options.Directory, cacheFilename), 0600, &bbolt.Options{Timeout: 1 * time.Second})
 	if err != nil {
 		return nil, fmt.Errorf("could not open db: %v", err)
 	}
 
+	// make room for this cache by evicting the least recently used caches of other RPCs/blocks
+	if options.DiskSizeLimit > 0 {
+		err = evictCacheFiles(options.Directory, cacheFilename, options.DiskSizeLimit)
+		if err != nil {
+			log.Printf("error evicting cache files: %v", err)
+		}
+	}
+
 	// create default bucket if it doesn't exist
 	err = db.Update(func(tx *bbolt.Tx) error {
 		_, err := tx.CreateBucketIfNotExists([]byte("cache"))
@@ -51,7 +60,10 @@ func newPersistentCache(ctx context.Context, workingDir string, rpcAddr string,
 		return nil, err
 	}
 
-	memCache := newNonPersistentStateCache()
+	var memCache StateCache = newNonPersistentStateCache()
+	if options.MemoryEntries > 0 {
+		memCache = newLruStateCache(options.MemoryEntries)
+	}
 	p := &persistentCache{
 		memCache:          memCache,
 		db:                db,
@@ -215,19 +227,84 @@ func (p *persistentCache) Close() error {
 	return err
 }
 
-func createCacheDirectory(workingDir string) (string, error) {
-	cachePath := filepath.Join(workingDir, ".medusacache")
+func createCacheDirectory(cachePath string) error {
 	_, err := os.Stat(cachePath)
 	if os.IsNotExist(err) {
 		// Create directory with 0755 permissions if it doesn't exist
-		err = os.Mkdir(cachePath, 0755)
+		err = os.MkdirAll(cachePath, 0755)
 		if err != nil {
-			return "", fmt.Errorf("failed to create cache directory: %w", err)
+			return fmt.Errorf("failed to create cache directory: %w", err)
 		}
 	} else if err != nil {
-		return "", fmt.Errorf("failed to check cache directory: %w", err)
+		return fmt.Errorf("failed to check cache directory: %w", err)
+	}
+	return nil
+}
+
+// evictionLockTimeout describes how long evictCacheFiles waits to lock a cache file before considering it in use.
+const evictionLockTimeout = 50 * time.Millisecond
+
+// evictCacheFiles marks the cache file in use as the most recently used, then removes the least recently used cache
+// files of the cache directory until their total size is within sizeLimit bytes. The cache file in use is never
+// removed, even if it exceeds sizeLimit on its own, and neither are cache files another process has open.
+func evictCacheFiles(cachePath string, currentFilename string, sizeLimit int64) error {
+	now := time.Now()
+	err := os.Chtimes(filepath.Join(cachePath, currentFilename), now, now)
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(cachePath)
+	if err != nil {
+		return err
+	}
+	files := make([]os.FileInfo, 0, len(entries))
+	totalSize := int64(0)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".dat" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, info)
+		totalSize += info.Size()
+	}
+
+	// remove the least recently used files first
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+	for _, file := range files {
+		if totalSize <= sizeLimit {
+			break
+		}
+		if file.Name() == currentFilename {
+			continue
+		}
+
+		// Lock the cache file before removing it, skipping it if another process holds it open. The lock is released
+		// before removal, as open files can't be removed on all platforms. A process opening the file in between keeps
+		// using its open file, but its cache is no longer persisted.
+		filePath := filepath.Join(cachePath, file.Name())
+		db, err := bbolt.Open(filePath, 0600, &bbolt.Options{Timeout: evictionLockTimeout})
+		if errors.Is(err, bbolt.ErrTimeout) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		err = db.Close()
+		if err != nil {
+			return err
+		}
+		err = os.Remove(filePath)
+		if err != nil {
+			return err
+		}
+		totalSize -= file.Size()
 	}
-	return cachePath, nil
+	return nil
 }
 
 func getCacheFilename(rpcAddr string, height uint64) string {
@@ -24,7 +24,7 @@ var _ stateBackend = (*RPCBackend)(nil)
 
 /*
 RPCBackend defines a stateBackend for fetching state from a remote RPC server. It is locked to a single block height,
-and caches data in-memory with no expiry.
+and caches data in-memory and on disk, so that it is shared across all TestChain instances and later campaigns.
 */
 type RPCBackend struct {
 	context    context.Context
@@ -34,17 +34,32 @@ type RPCBackend struct {
 	cache cache.StateCache
 }
 
+/*
+NewRPCBackend creates an RPCBackend which fetches state from the RPC server at the provided url at the given block
+height, persisting it to disk as described by cacheOptions. A height of zero pins the backend to the latest block at
+the time it is created.
+*/
 func NewRPCBackend(
 	ctx context.Context,
 	url string,
 	height uint64,
-	poolSize uint) (*RPCBackend, error) {
+	poolSize uint,
+	cacheOptions cache.PersistentCacheOptions) (*RPCBackend, error) {
 	clientPool, err := rpc.NewClientPool(url, poolSize)
 	if err != nil {
 		return nil, err
 	}
 
-	cache, err := cache.NewPersistentCache(ctx, url, height)
+	if height == 0 {
+		var latestHeight hexutil.Uint64
+		err = clientPool.ExecuteRequestBlocking(ctx, &latestHeight, "eth_blockNumber")
+		if err != nil {
+			return nil, err
+		}
+		height = uint64(latestHeight)
+	}
+
+	cache, err := cache.NewPersistentCache(ctx, url, height, cacheOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -82,6 +97,22 @@ func newRPCBackendNoPersistence(
 	}, nil
 }
 
+/*
+BlockContext returns the number and timestamp of the block the RPCBackend is locked to.
+Errors may be network errors or a context cancelled error when the fuzzer is shutting down.
+*/
+func (q *RPCBackend) BlockContext() (uint64, uint64, error) {
+	var header struct {
+		Number    hexutil.Uint64 `json:"number"`
+		Timestamp hexutil.Uint64 `json:"timestamp"`
+	}
+	err := q.clientPool.ExecuteRequestBlocking(q.context, &header, "eth_getBlockByNumber", q.height, false)
+	if err != nil {
+		return 0, 0, err
+	}
+	return uint64(header.Number), uint64(header.Timestamp), nil
+}
+
 /*
 GetStorageAt returns data stored in the remote RPC for the given address/slot.
 Note that Ethereum RPC will return zero for slots that have never been written to or are associated with undeployed
@@ -8,6 +8,7 @@ import (
 	compilationTypes "github.com/crytic/medusa/compilation/types"
 
 	"github.com/crytic/medusa/chain/state"
+	"github.com/crytic/medusa/chain/state/cache"
 	"golang.org/x/net/context"
 
 	"github.com/crytic/medusa-geth/core/rawdb"
@@ -41,6 +42,10 @@ type TestChain struct {
 	// will be spoofed when requested through the API, for efficiency.
 	blocks []*types.Block
 
+	// snapshots represents the committed block counts recorded by Snapshot, indexed by snapshot id. Snapshots
+	// beyond the chain's length are invalidated when it is reverted.
+	snapshots []int
+
 	// pendingBlock is a block currently under construction by the chain which has not yet been committed.
 	pendingBlock *types.Block
 
@@ -95,6 +100,24 @@ type TestChain struct {
 	// Events defines the event system for the TestChain.
 	Events TestChainEvents
 
+	// mockContracts describes the mock contracts installed on the chain, indexed by address.
+	mockContracts map[common.Address]*MockContract
+
+	// MockResponseProvider, if non-nil, chooses the return data of calls made to mock contracts during transactions,
+	// when they are not replayed. If nil, mocked functions keep returning the data they last returned.
+	MockResponseProvider MockResponseProviderFunc
+
+	// recordingMockResponses indicates whether a transaction is being added to the pending block, so the responses of
+	// mock contracts are replayed, provided and recorded for it.
+	recordingMockResponses bool
+
+	// replayedMockResponses describes the responses left to replay during the next or current transaction, as set by
+	// SetMockResponses.
+	replayedMockResponses []*types.MockResponse
+
+	// pendingMockResponses describes the responses returned by mock contracts during the current transaction.
+	pendingMockResponses []*types.MockResponse
+
 	// stateFactory used to construct state databases from db/root. Abstracts away the backing RPC when running in
 	// fork mode.
 	stateFactory      state.MedusaStateFactory
@@ -120,28 +143,44 @@ func NewTestChain(
 		}
 	}
 	var stateFactory state.MedusaStateFactory
+	var genesisNumber, genesisTime uint64
 	if testChainConfig.ForkConfig.ForkModeEnabled {
 		provider, err := state.NewRPCBackend(
 			fuzzerContext,
 			testChainConfig.ForkConfig.RpcUrl,
 			testChainConfig.ForkConfig.RpcBlock,
-			testChainConfig.ForkConfig.PoolSize)
+			testChainConfig.ForkConfig.PoolSize,
+			cache.PersistentCacheOptions{
+				Directory:     testChainConfig.ForkConfig.CacheDirectory,
+				MemoryEntries: int(testChainConfig.ForkConfig.CacheMemoryEntries),
+				DiskSizeLimit: int64(testChainConfig.ForkConfig.CacheDiskSizeLimit) * 1024 * 1024,
+			})
 		if err != nil {
 			return nil, err
 		}
 		stateFactory = state.NewForkedStateFactory(provider)
+
+		// Continue the block context of the fork block, if directed.
+		if testChainConfig.ForkConfig.PinBlockContext {
+			genesisNumber, genesisTime, err = provider.BlockContext()
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch the fork block: %v", err)
+			}
+		}
 	} else {
 		stateFactory = state.NewVanillaStateFactory()
 		// stateFactory = state.NewUnbackedStateFactory()
 	}
 
-	return newTestChainWithStateFactory(genesisAlloc, testChainConfig, stateFactory)
+	return newTestChainWithStateFactory(genesisAlloc, genesisNumber, genesisTime, testChainConfig, stateFactory)
 }
 
 // newTestChainWithStateFactory creates a simulated backend, using the provided stateFactory for optionally fetching
-// remote state if RPC mode is configured.
+// remote state if RPC mode is configured. The genesis block takes the provided number and timestamp.
 func newTestChainWithStateFactory(
 	genesisAlloc gethTypes.GenesisAlloc,
+	genesisNumber uint64,
+	genesisTime uint64,
 	testChainConfig *config.TestChainConfig,
 	stateFactory state.MedusaStateFactory) (*TestChain, error) {
 
@@ -151,15 +190,11 @@ func newTestChainWithStateFactory(
 		return nil, err
 	}
 
-	// TODO: go-ethereum doesn't set prague start time for THEIR test `ChainConfig` struct.
-	//   Note: We have our own `TestChainConfig` definition that is different (second argument in this function).
-	//  We should allow the user to provide a go-ethereum `ChainConfig` to do custom fork selection, inside of our
-	//  `TestChainConfig` definition. Or we should wrap it in our own struct to simplify the options and not pollute
-	//  our overall medusa project config.
-	pragueTime := uint64(0)
-	chainConfig.PragueTime = &pragueTime
-	chainConfig.ShanghaiTime = &pragueTime
-	chainConfig.CancunTime = &pragueTime
+	// Select the hardfork and chain id the chain executes with.
+	err = testChainConfig.ApplyToChainConfig(chainConfig)
+	if err != nil {
+		return nil, err
+	}
 	// Set the default blob schedule
 	chainConfig.BlobScheduleConfig = params.DefaultBlobSchedule
 
@@ -167,19 +202,19 @@ func newTestChainWithStateFactory(
 	genesisDefinition := &core.Genesis{
 		Config:    chainConfig,
 		Nonce:     0,
-		Timestamp: 0,
+		Timestamp: genesisTime,
 		ExtraData: []byte{
 			0x6D, 0x65, 0x64, 0x75, 0x24, 0x61,
 		},
-		GasLimit:   0,
+		GasLimit:   testChainConfig.BlockGasLimit,
 		Difficulty: common.Big0,
 		Mixhash:    common.Hash{},
 		Coinbase:   common.Address{},
 		Alloc:      maps.Clone(genesisAlloc), // cloned to avoid concurrent access issues across cloned chains
-		Number:     0,
+		Number:     genesisNumber,
 		GasUsed:    0,
 		ParentHash: common.Hash{},
-		BaseFee:    big.NewInt(0),
+		BaseFee:    new(big.Int).SetUint64(testChainConfig.BaseFee),
 	}
 
 	// Obtain our VM extensions from our config
@@ -206,6 +241,24 @@ func newTestChainWithStateFactory(
 		}
 	}
 
+	// Add all mock contracts to the genesis config and our vm extensions, in the same way as cheat code contracts.
+	mockContracts := make(map[common.Address]*MockContract, len(testChainConfig.Mocks))
+	for _, mockConfig := range testChainConfig.Mocks {
+		mockContract, err := newMockContract(mockConfig)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := vmConfigExtensions.AdditionalPrecompiles[mockContract.address]; exists {
+			return nil, fmt.Errorf("mock '%v' cannot be installed at address %v, which is already in use", mockContract.name, mockContract.address)
+		}
+		genesisDefinition.Alloc[mockContract.address] = gethTypes.Account{
+			Balance: big.NewInt(0),
+			Code:    []byte{0xFF},
+		}
+		vmConfigExtensions.AdditionalPrecompiles[mockContract.address] = mockContract
+		mockContracts[mockContract.address] = mockContract
+	}
+
 	// Create an in-memory database
 	db := rawdb.NewMemoryDatabase()
 	dbConfig := &triedb.Config{
@@ -243,9 +296,18 @@ func newTestChainWithStateFactory(
 		chainConfig:             genesisDefinition.Config,
 		vmConfigExtensions:      vmConfigExtensions,
 		stateFactory:            stateFactory,
+		mockContracts:           mockContracts,
 		CompiledContracts:       make(map[string]*compilationTypes.CompiledContract),
 	}
 
+	// Bind our mock contracts to this chain and label their addresses.
+	for address, mockContract := range mockContracts {
+		mockContract.chain = chain
+		if mockContract.name != "" {
+			chain.Labels[address] = mockContract.name
+		}
+	}
+
 	// Add our internal tracers to this chain.
 	chain.AddTracer(newTestChainDeploymentsTracer().NativeTracer(), true, false)
 	if testChainConfig.CheatCodeConfig.CheatCodesEnabled {
@@ -257,7 +319,7 @@ func newTestChainWithStateFactory(
 	chain.AddTracer(newTestChainContractDiscoveryTracer().NativeTracer(), true, true)
 
 	// Obtain the state for the genesis block and set it as the chain's current state.
-	stateDB, err := chain.StateAfterBlockNumber(0)
+	stateDB, err := chain.StateAfterBlockNumber(genesisNumber)
 	if err != nil {
 		return nil, err
 	}
@@ -281,7 +343,7 @@ func (t *TestChain) Close() {
 // Returns the new chain, or an error if one occurred.
 func (t *TestChain) Clone(onCreateFunc func(chain *TestChain) error) (*TestChain, error) {
 	// Create a new chain with the same genesis definition and config
-	targetChain, err := newTestChainWithStateFactory(t.genesisDefinition.Alloc, t.testChainConfig, t.stateFactory)
+	targetChain, err := newTestChainWithStateFactory(t.genesisDefinition.Alloc, t.genesisDefinition.Number, t.genesisDefinition.Timestamp, t.testChainConfig, t.stateFactory)
 	if err != nil {
 		return nil, err
 	}
@@ -308,6 +370,7 @@ func (t *TestChain) Clone(onCreateFunc func(chain *TestChain) error) (*TestChain
 		// Now add each transaction/message to it.
 		messages := t.blocks[i].Messages
 		for j := 0; j < len(messages); j++ {
+			targetChain.SetMockResponses(block.MessageResults[j].MockResponses)
 			err = targetChain.PendingBlockAddTx(messages[j])
 			if err != nil {
 				return nil, err
@@ -370,6 +433,19 @@ func (t *TestChain) CheatCodeContracts() map[common.Address]*CheatCodeContract {
 	return contracts
 }
 
+// StandardCheatCodeContract returns the standard cheat code contract installed in the chain, which test harnesses and
+// the fuzzer can call to impersonate senders, set balances, warp time, or write storage, or nil if cheat codes are
+// disabled.
+func (t *TestChain) StandardCheatCodeContract() *CheatCodeContract {
+	return t.CheatCodeContracts()[StandardCheatcodeContractAddress]
+}
+
+// ConsoleLogContract returns the console.log contract installed in the chain, which test harnesses can call to log
+// values as with Hardhat's console.log, or nil if cheat codes are disabled.
+func (t *TestChain) ConsoleLogContract() *CheatCodeContract {
+	return t.CheatCodeContracts()[ConsoleLogContractAddress]
+}
+
 // CommittedBlocks returns the real blocks which were committed to the chain, where methods such as BlockFromNumber
 // return the simulated chain state with intermediate blocks injected for block number jumps, etc.
 func (t *TestChain) CommittedBlocks() []*types.Block {
@@ -381,7 +457,8 @@ func (t *TestChain) Head() *types.Block {
 	return t.blocks[len(t.blocks)-1]
 }
 
-// HeadBlockNumber returns the test chain head's block number, where zero is the genesis block.
+// HeadBlockNumber returns the test chain head's block number, where the genesis block is zero, or the fork block if
+// the block context of a fork is pinned.
 func (t *TestChain) HeadBlockNumber() uint64 {
 	return t.Head().Header.Number.Uint64()
 }
@@ -466,6 +543,11 @@ func (t *TestChain) RevertToBlockIndex(index uint64) error {
 	// Keep the relevant blocks up till index
 	t.blocks = t.blocks[:index]
 
+	// Invalidate any snapshot which recorded blocks that were removed
+	for len(t.snapshots) > 0 && t.snapshots[len(t.snapshots)-1] > int(index) {
+		t.snapshots = t.snapshots[:len(t.snapshots)-1]
+	}
+
 	// Discard our pending block
 	err := t.PendingBlockDiscard()
 	if err != nil {
@@ -500,6 +582,32 @@ func (t *TestChain) RevertToBlockIndex(index uint64) error {
 	return err
 }
 
+// Snapshot records the committed chain state, so it can later be restored with RevertToSnapshot. Any pending block is
+// not part of the snapshot. Snapshots are not carried over to clones of the chain.
+// Returns the snapshot id.
+func (t *TestChain) Snapshot() int {
+	t.snapshots = append(t.snapshots, len(t.blocks))
+	return len(t.snapshots) - 1
+}
+
+// HasSnapshot determines whether the provided snapshot id can still be reverted to, as snapshots are invalidated once
+// the chain is reverted before them.
+func (t *TestChain) HasSnapshot(id int) bool {
+	return id >= 0 && id < len(t.snapshots)
+}
+
+// RevertToSnapshot reverts all blocks committed after the provided snapshot was recorded, as RevertToBlockIndex does.
+// The snapshot remains valid, so the chain can repeatedly branch from the same state, while any snapshot recorded
+// after it is invalidated. Results of tracers are attached to the message results of each block, so they are reverted
+// along with them.
+// Returns an error if the snapshot is invalid or the chain could not be reverted.
+func (t *TestChain) RevertToSnapshot(id int) error {
+	if !t.HasSnapshot(id) {
+		return fmt.Errorf("could not revert to snapshot %d because it does not exist", id)
+	}
+	return t.RevertToBlockIndex(uint64(t.snapshots[id]))
+}
+
 // CallContract performs a message call over the current test chain state and obtains a core.ExecutionResult.
 // This is similar to the CallContract method provided by Ethereum for use in calling pure/view functions, as it
 // executes a transaction without committing any changes, instead discarding them.
@@ -507,17 +615,38 @@ func (t *TestChain) RevertToBlockIndex(index uint64) error {
 // current pending state (or committed state if none is pending) will be used instead.
 // The state executed over may be a pending block state.
 func (t *TestChain) CallContract(msg *core.Message, state types.MedusaStateDB, additionalTracers ...*TestChainTracer) (*core.ExecutionResult, error) {
+	msgResults, err := t.CallContracts([]*core.Message{msg}, state, additionalTracers...)
+	if len(msgResults) == 0 {
+		return nil, err
+	}
+	return msgResults[0], err
+}
+
+// CallContracts performs message calls in succession over the current test chain state, as CallContract does, with
+// each call executing over the changes made by the previous ones. All changes are discarded once the calls complete.
+// Returns the execution result of each call executed, or an error if one occurs, in which case subsequent calls are
+// not executed.
+func (t *TestChain) CallContracts(msgs []*core.Message, state types.MedusaStateDB, additionalTracers ...*TestChainTracer) ([]*core.ExecutionResult, error) {
 	// If our provided state is nil, use our current chain state.
 	if state == nil {
 		state = t.state
 	}
 
+	// Each message call starts with a new access list, which the state journal does not account for, so the changes
+	// of multiple message calls cannot be reverted through a single snapshot. Instead, we execute them over a copy of
+	// the state, which is discarded.
+	copyState := len(msgs) > 1
+	if copyState {
+		stateCopier, ok := state.(interface{ Copy() *gethState.StateDB })
+		if !ok {
+			return nil, fmt.Errorf("could not perform message calls in succession as the state could not be copied")
+		}
+		state = stateCopier.Copy()
+	}
+
 	// Obtain our state snapshot to revert any changes after our call
 	snapshot := state.Snapshot()
 
-	// Set infinite balance to the fake caller account
-	state.SetBalance(msg.From, uint256.MustFromBig(math.MaxBig256), tracing.BalanceChangeUnspecified)
-
 	// Create our transaction and block contexts for the vm
 	var blockContext vm.BlockContext
 	if t.pendingBlock != nil {
@@ -526,57 +655,71 @@ func (t *TestChain) CallContract(msg *core.Message, state types.MedusaStateDB, a
 		blockContext = newTestChainBlockContext(t, t.Head().Header)
 	}
 
-	// Create a new call tracer router that incorporates any additional tracers provided just for this call, while
+	// Create a new call tracer router that incorporates any additional tracers provided just for these calls, while
 	// still calling our internal tracers.
 	extendedTracerRouter := NewTestChainTracerRouter()
 	extendedTracerRouter.AddTracer(t.callTracerRouter.NativeTracer())
 	extendedTracerRouter.AddTracers(additionalTracers...)
 
-	// Create our EVM instance.
-	evm := vm.NewEVM(blockContext, state, t.chainConfig, vm.Config{
-		Tracer:           extendedTracerRouter.NativeTracer().Tracer.Hooks,
-		NoBaseFee:        true,
-		ConfigExtensions: t.vmConfigExtensions,
-	})
+	msgResults := make([]*core.ExecutionResult, 0, len(msgs))
+	for _, msg := range msgs {
+		// Set infinite balance to the fake caller account
+		state.SetBalance(msg.From, uint256.MustFromBig(math.MaxBig256), tracing.BalanceChangeUnspecified)
 
-	// Set our block context and chain config in order for cheatcodes to override what EVM interpreter sees.
-	t.pendingBlockContext = &evm.Context
-	t.pendingBlockChainConfig = evm.ChainConfig()
+		// Create our EVM instance.
+		evm := vm.NewEVM(blockContext, state, t.chainConfig, vm.Config{
+			Tracer:           extendedTracerRouter.NativeTracer().Tracer.Hooks,
+			NoBaseFee:        true,
+			ConfigExtensions: t.vmConfigExtensions,
+		})
 
-	// Create a tx from our msg, for hashing/receipt purposes
-	tx := utils.MessageToTransaction(msg)
+		// Set our block context and chain config in order for cheatcodes to override what EVM interpreter sees.
+		t.pendingBlockContext = &evm.Context
+		t.pendingBlockChainConfig = evm.ChainConfig()
 
-	// Need to explicitly call OnTxStart hook
-	if evm.Config.Tracer != nil && evm.Config.Tracer.OnTxStart != nil {
-		evm.Config.Tracer.OnTxStart(evm.GetVMContext(), tx, msg.From)
-	}
-	// Fund the gas pool, so it can execute endlessly (no block gas limit).
+		// Create a tx from our msg, for hashing/receipt purposes
+		tx := utils.MessageToTransaction(msg)
 
-	gasPool := new(core.GasPool).AddGas(MAX_UINT_64.Uint64())
+		// Need to explicitly call OnTxStart hook
+		if evm.Config.Tracer != nil && evm.Config.Tracer.OnTxStart != nil {
+			evm.Config.Tracer.OnTxStart(evm.GetVMContext(), tx, msg.From)
+		}
+		// Fund the gas pool, so it can execute endlessly (no block gas limit).
 
-	// Perform our state transition to obtain the result.
-	msgResult, err := core.ApplyMessage(evm, msg, gasPool)
+		gasPool := new(core.GasPool).AddGas(MAX_UINT_64.Uint64())
 
-	// Revert to our state snapshot to undo any changes.
-	state.RevertToSnapshot(snapshot)
+		// Perform our state transition to obtain the result.
+		msgResult, err := core.ApplyMessage(evm, msg, gasPool)
 
-	// Gather receipt for OnTxEnd
-	receipt := &gethTypes.Receipt{Type: tx.Type()}
-	if msgResult.Failed() {
-		receipt.Status = gethTypes.ReceiptStatusFailed
-	} else {
-		receipt.Status = gethTypes.ReceiptStatusSuccessful
-	}
-	receipt.TxHash = tx.Hash()
-	receipt.GasUsed = msgResult.UsedGas
+		// Revert to our state snapshot to undo any changes, before tracers are notified that the call ended.
+		if !copyState {
+			state.RevertToSnapshot(snapshot)
+		}
 
-	// HACK: use OnTxEnd to store the execution trace.
-	// Need to explicitly call OnTxEnd
-	if evm.Config.Tracer != nil && evm.Config.Tracer.OnTxEnd != nil {
-		evm.Config.Tracer.OnTxEnd(receipt, err)
-	}
+		// Gather receipt for OnTxEnd
+		receipt := &gethTypes.Receipt{Type: tx.Type()}
+		if msgResult == nil || msgResult.Failed() {
+			receipt.Status = gethTypes.ReceiptStatusFailed
+		} else {
+			receipt.Status = gethTypes.ReceiptStatusSuccessful
+		}
+		receipt.TxHash = tx.Hash()
+		if msgResult != nil {
+			receipt.GasUsed = msgResult.UsedGas
+		}
+
+		// HACK: use OnTxEnd to store the execution trace.
+		// Need to explicitly call OnTxEnd
+		if evm.Config.Tracer != nil && evm.Config.Tracer.OnTxEnd != nil {
+			evm.Config.Tracer.OnTxEnd(receipt, err)
+		}
 
-	return msgResult, err
+		if err != nil {
+			return msgResults, err
+		}
+		msgResults = append(msgResults, msgResult)
+	}
+	return msgResults, nil
 }
 
 // PendingBlockContext is the vm.BlockContext for the current pending block.
@@ -747,9 +890,14 @@ func (t *TestChain) PendingBlockAddTx(message *core.Message, additionalTracers .
 	t.pendingBlockContext = &evm.Context
 	t.pendingBlockChainConfig = evm.ChainConfig()
 
-	// Apply our transaction
+	// Apply our transaction, recording the responses of any mock contracts it calls.
 	var usedGas uint64
+	t.recordingMockResponses = true
 	receipt, executionResult, err := vendored.EVMApplyTransaction(message, t.chainConfig, t.testChainConfig, &t.pendingBlock.Header.Coinbase, gasPool, t.state, t.pendingBlock.Header.Number, t.pendingBlock.Hash, tx, &usedGas, evm)
+	mockResponses := t.pendingMockResponses
+	t.recordingMockResponses = false
+	t.replayedMockResponses = nil
+	t.pendingMockResponses = nil
 	if err != nil {
 		return fmt.Errorf("test chain state write error when adding tx to pending block: %v", err)
 	}
@@ -760,6 +908,7 @@ func (t *TestChain) PendingBlockAddTx(message *core.Message, additionalTracers .
 		ExecutionResult:   executionResult,
 		Receipt:           receipt,
 		AdditionalResults: make(map[string]any, 0),
+		MockResponses:     mockResponses,
 	}
 
 	// For every tracer we have, we call upon them to set their results for this transaction now.
@@ -11,8 +11,8 @@ import (
 	"github.com/crytic/medusa/chain/types"
 )
 
-// testChainContractDiscoveryTracer implements TestChainTracer, capturing information regarding contract deployments and
-// self-destructs. It is a special tracer that is used internally by each TestChain. It subscribes to its block-related
+// testChainContractDiscoveryTracer implements TestChainTracer, capturing the code of contracts which are called or
+// created, including those deployed dynamically by factories. It is a special tracer that is used internally by each TestChain. It subscribes to its block-related
 // events in order to power the TestChain's contract deployment related events.
 type testChainContractDiscoveryTracer struct {
 	// results describes the results being currently captured.
@@ -37,6 +37,15 @@ type testChainContractDiscoveryTracer struct {
 type testChainContractDiscoveryTracerCallFrame struct {
 	// results describes the results being currently captured.
 	results []types.DeployedContractBytecode
+
+	// create indicates whether this call frame deploys a contract, whose runtime bytecode is recorded upon exit.
+	create bool
+
+	// createAddress describes the address of the contract deployed by this call frame, if create is true.
+	createAddress common.Address
+
+	// createInitBytecode describes the init bytecode executed by this call frame, if create is true.
+	createInitBytecode []byte
 }
 
 // newtestChainContractDiscoveryTracer creates a testChainContractDiscoveryTracer
@@ -89,9 +98,13 @@ func (t *testChainContractDiscoveryTracer) OnEnter(depth int, typ byte, from com
 		t.callDepth++
 	}
 
-	// If this is a contract creation, record the `to` address as a pending deployment (if it succeeds upon exit,
-	// we commit it).
-	if typ == byte(vm.CALL) || typ == byte(vm.STATICCALL) || typ == byte(vm.DELEGATECALL) {
+	// If this is a contract creation, record its init bytecode, so it can be committed along with the deployed
+	// runtime bytecode if it succeeds upon exit. Otherwise, record the code of the contract being called.
+	if typ == byte(vm.CREATE) || typ == byte(vm.CREATE2) {
+		callFrameData.create = true
+		callFrameData.createAddress = to
+		callFrameData.createInitBytecode = append([]byte(nil), input...)
+	} else if typ == byte(vm.CALL) || typ == byte(vm.STATICCALL) || typ == byte(vm.DELEGATECALL) {
 		callFrameData.results = append(callFrameData.results, types.DeployedContractBytecode{
 			Address:         to,
 			RuntimeBytecode: t.evmContext.StateDB.GetCode(to),
@@ -104,6 +117,17 @@ func (t *testChainContractDiscoveryTracer) OnExit(depth int, output []byte, gasU
 	// Check to see if this is the top level call frame
 	isTopLevelFrame := depth == 0
 
+	// If this call frame deployed a contract successfully, record its init and runtime bytecode. The output of a
+	// creation is the runtime bytecode deployed.
+	currentCallFrame := t.pendingCallFrames[t.callDepth]
+	if currentCallFrame.create && err == nil {
+		currentCallFrame.results = append(currentCallFrame.results, types.DeployedContractBytecode{
+			Address:         currentCallFrame.createAddress,
+			InitBytecode:    currentCallFrame.createInitBytecode,
+			RuntimeBytecode: append([]byte(nil), output...),
+		})
+	}
+
 	// If we didn't encounter any errors and this is the top level call frame, commit all the results
 	if isTopLevelFrame {
 		t.results = append(t.results, t.pendingCallFrames[t.callDepth].results...)
@@ -0,0 +1,68 @@
+package chain
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core"
+	"github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa-geth/crypto"
+	"github.com/crytic/medusa/chain/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestChainContractDiscoveryCreations ensures the contract discovery tracer records the init and runtime bytecode of
+// contracts created by a transaction, including those created dynamically by another contract.
+func TestChainContractDiscoveryCreations(t *testing.T) {
+	// Create a chain with a funded sender.
+	sender := common.HexToAddress("0x10000")
+	testChainConfig, err := config.DefaultTestChainConfig()
+	assert.NoError(t, err)
+	genesisAlloc := types.GenesisAlloc{
+		sender: {Balance: big.NewInt(1_000_000)},
+	}
+	testChain, err := NewTestChain(context.Background(), genesisAlloc, testChainConfig)
+	assert.NoError(t, err)
+	defer testChain.Close()
+
+	// The child's init bytecode returns its four-byte runtime bytecode. The factory's init bytecode creates the child
+	// from init bytecode stored in memory, and deploys no runtime bytecode itself.
+	childRuntimeBytecode := common.FromHex("0x60016000")
+	childInitBytecode := append(append(common.FromHex("0x63"), childRuntimeBytecode...), common.FromHex("0x6000526004601cf3")...)
+	factoryInitBytecode := append(append(common.FromHex("0x6c"), childInitBytecode...), common.FromHex("0x600052600d60136000f05000")...)
+
+	// Deploy the factory.
+	_, err = testChain.PendingBlockCreate()
+	assert.NoError(t, err)
+	err = testChain.PendingBlockAddTx(&core.Message{
+		From:      sender,
+		Nonce:     0,
+		Value:     big.NewInt(0),
+		GasLimit:  1_000_000,
+		GasPrice:  big.NewInt(0),
+		GasFeeCap: big.NewInt(0),
+		GasTipCap: big.NewInt(0),
+		Data:      factoryInitBytecode,
+	})
+	assert.NoError(t, err)
+	err = testChain.PendingBlockCommit()
+	assert.NoError(t, err)
+
+	// Both the factory and the child it created should have been discovered, with their init and runtime bytecode.
+	messageResults := testChain.Head().MessageResults[0]
+	assert.EqualValues(t, types.ReceiptStatusSuccessful, messageResults.Receipt.Status)
+	factoryAddress := crypto.CreateAddress(sender, 0)
+	childAddress := crypto.CreateAddress(factoryAddress, 1)
+	discovered := make(map[common.Address][2][]byte)
+	for _, contract := range messageResults.ContractDiscoverys {
+		discovered[contract.Address] = [2][]byte{contract.InitBytecode, contract.RuntimeBytecode}
+	}
+	assert.Len(t, discovered, 2)
+	assert.EqualValues(t, factoryInitBytecode, discovered[factoryAddress][0])
+	assert.Empty(t, discovered[factoryAddress][1])
+	assert.EqualValues(t, childInitBytecode, discovered[childAddress][0])
+	assert.EqualValues(t, childRuntimeBytecode, discovered[childAddress][1])
+	assert.EqualValues(t, childRuntimeBytecode, testChain.State().GetCode(childAddress))
+}
@@ -114,13 +114,13 @@ type ContractDeploymentsRemovedEvent struct {
 	Contract *types.DeployedContractBytecode
 }
 
-// ContractDeploymentsAddedEvent describes an event where a contract has become available on the TestChain, either
-// due to contract creation, or a self-destruct operation being reverted.
+// ContractDiscoveryEvent describes an event where a contract was called or created on the TestChain.
 type ContractDiscoveryEvent struct {
 	// Chain refers to the TestChain which emitted the event.
 	Chain *TestChain
 
-	// Contract defines information for the contract which was deployed to the Chain.
+	// Contract defines information for the contract which was discovered on the Chain. Its InitBytecode is only set
+	// if the contract was created, rather than called.
 	Contract *types.DeployedContractBytecode
 
 	// isInitialization describes whether this event is emitted for predeployed contract.
@@ -0,0 +1,103 @@
+package chain
+
+import (
+	"math/big"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core/tracing"
+	coretypes "github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa-geth/eth/tracers"
+	"github.com/crytic/medusa/chain/types"
+)
+
+// testChainSampledTracer wraps a TestChainTracer so that it only traces the transactions selected by a sampling
+// function, decided when each transaction starts.
+type testChainSampledTracer struct {
+	// tracer describes the wrapped tracer.
+	tracer *TestChainTracer
+
+	// sampleFunc describes the function deciding whether a transaction is traced.
+	sampleFunc func() bool
+
+	// active describes whether the current transaction is traced.
+	active bool
+}
+
+// NewSampledTestChainTracer wraps the provided tracer so that it only traces the transactions for which the provided
+// sampling function returns true when they start. Transactions which are not traced store no results of the tracer in
+// their message results, so heavy tracers can be attached to a fraction of transactions only.
+// Returns the wrapping tracer, which should be attached to the chain in place of the provided one.
+func NewSampledTestChainTracer(tracer *TestChainTracer, sampleFunc func() bool) *TestChainTracer {
+	_, nativeTracer := newTestChainSampledTracer(tracer, sampleFunc)
+	return nativeTracer
+}
+
+// newTestChainSampledTracer wraps the provided tracer so that it only traces the transactions for which the provided
+// sampling function returns true when they start.
+// Returns the sampled tracer, and the wrapping tracer which should be attached to the chain in place of the provided
+// one.
+func newTestChainSampledTracer(tracer *TestChainTracer, sampleFunc func() bool) (*testChainSampledTracer, *TestChainTracer) {
+	sampledTracer := &testChainSampledTracer{
+		tracer:     tracer,
+		sampleFunc: sampleFunc,
+	}
+	innerTracer := &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnTxStart: sampledTracer.OnTxStart,
+			OnTxEnd:   sampledTracer.OnTxEnd,
+			OnEnter:   sampledTracer.OnEnter,
+			OnExit:    sampledTracer.OnExit,
+		},
+	}
+
+	// Tracers whose opcodes are dispatched by a multiplexer have no OnOpcode hook, which would only add overhead.
+	if tracer.OnOpcode != nil {
+		innerTracer.OnOpcode = sampledTracer.OnOpcode
+	}
+	return sampledTracer, &TestChainTracer{Tracer: innerTracer, CaptureTxEndSetAdditionalResults: sampledTracer.CaptureTxEndSetAdditionalResults}
+}
+
+// OnTxStart is called upon the start of transaction execution, as defined by tracers.Tracer. It decides whether the
+// transaction is traced.
+func (t *testChainSampledTracer) OnTxStart(vm *tracing.VMContext, tx *coretypes.Transaction, from common.Address) {
+	t.active = t.sampleFunc()
+	if t.active && t.tracer.OnTxStart != nil {
+		t.tracer.OnTxStart(vm, tx, from)
+	}
+}
+
+// OnTxEnd is called upon the end of transaction execution, as defined by tracers.Tracer.
+func (t *testChainSampledTracer) OnTxEnd(receipt *coretypes.Receipt, err error) {
+	if t.active && t.tracer.OnTxEnd != nil {
+		t.tracer.OnTxEnd(receipt, err)
+	}
+}
+
+// OnEnter is called upon entering of the call frame, as defined by tracers.Tracer.
+func (t *testChainSampledTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if t.active && t.tracer.OnEnter != nil {
+		t.tracer.OnEnter(depth, typ, from, to, input, gas, value)
+	}
+}
+
+// OnExit is called after a call to finalize tracing completes for the top of a call frame, as defined by tracers.Tracer.
+func (t *testChainSampledTracer) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if t.active && t.tracer.OnExit != nil {
+		t.tracer.OnExit(depth, output, gasUsed, err, reverted)
+	}
+}
+
+// OnOpcode records data from an EVM state update, as defined by tracers.Tracer.
+func (t *testChainSampledTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	if t.active && t.tracer.OnOpcode != nil {
+		t.tracer.OnOpcode(pc, op, gas, cost, scope, rData, depth, err)
+	}
+}
+
+// CaptureTxEndSetAdditionalResults stores the results of the wrapped tracer, if the transaction was traced, as defined
+// by TestChainTracer.
+func (t *testChainSampledTracer) CaptureTxEndSetAdditionalResults(results *types.MessageResults) {
+	if t.active && t.tracer.CaptureTxEndSetAdditionalResults != nil {
+		t.tracer.CaptureTxEndSetAdditionalResults(results)
+	}
+}
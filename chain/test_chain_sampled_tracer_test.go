@@ -0,0 +1,46 @@
+package chain
+
+import (
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core/tracing"
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa-geth/eth/tracers"
+	"github.com/crytic/medusa/chain/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestChainSampledTracer ensures a sampled tracer only forwards the transactions selected by its sampling function to
+// the wrapped tracer.
+func TestChainSampledTracer(t *testing.T) {
+	// Create a tracer counting the opcodes and results of the transactions it traces.
+	opcodes, results := 0, 0
+	tracer := &TestChainTracer{
+		Tracer: &tracers.Tracer{
+			Hooks: &tracing.Hooks{
+				OnOpcode: func(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+					opcodes++
+				},
+			},
+		},
+		CaptureTxEndSetAdditionalResults: func(messageResults *types.MessageResults) {
+			results++
+		},
+	}
+
+	// Trace every other transaction.
+	sample := false
+	sampledTracer := NewSampledTestChainTracer(tracer, func() bool {
+		sample = !sample
+		return sample
+	})
+	for i := 0; i < 4; i++ {
+		sampledTracer.OnTxStart(nil, nil, common.Address{})
+		sampledTracer.OnOpcode(0, byte(vm.STOP), 0, 0, nil, nil, 0, nil)
+		sampledTracer.CaptureTxEndSetAdditionalResults(nil)
+	}
+
+	assert.EqualValues(t, 2, opcodes)
+	assert.EqualValues(t, 2, results)
+}
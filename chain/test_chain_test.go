@@ -9,7 +9,11 @@ import (
 	"github.com/crytic/medusa-geth/accounts/abi"
 	"github.com/crytic/medusa-geth/common"
 	"github.com/crytic/medusa-geth/core"
+	"github.com/crytic/medusa-geth/core/tracing"
 	"github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa-geth/eth/tracers"
+	"github.com/crytic/medusa/chain/config"
 	"github.com/crytic/medusa/compilation/platforms"
 	"github.com/crytic/medusa/utils"
 	"github.com/crytic/medusa/utils/testutils"
@@ -131,6 +135,43 @@ func TestChainReverting(t *testing.T) {
 	}
 }
 
+// TestChainSnapshots creates a TestChain, records snapshots, and branches from them repeatedly to ensure the chain state
+// is restored and snapshots recorded after a reverted state are invalidated.
+func TestChainSnapshots(t *testing.T) {
+	// Obtain our chain and create a prefix of blocks to snapshot.
+	chain, _ := createChain(t)
+	for x := 0; x < 5; x++ {
+		_, err := chain.PendingBlockCreate()
+		assert.NoError(t, err)
+		assert.NoError(t, chain.PendingBlockCommit())
+	}
+	prefixHead := chain.Head()
+	prefixSnapshot := chain.Snapshot()
+
+	// Branch from the prefix multiple times, each time committing a different amount of blocks.
+	for branch := 1; branch <= 3; branch++ {
+		for x := 0; x < branch; x++ {
+			_, err := chain.PendingBlockCreate()
+			assert.NoError(t, err)
+			assert.NoError(t, chain.PendingBlockCommit())
+		}
+		branchSnapshot := chain.Snapshot()
+		assert.True(t, chain.HasSnapshot(branchSnapshot))
+
+		// Revert to the prefix and verify the branch's snapshot was invalidated.
+		assert.NoError(t, chain.RevertToSnapshot(prefixSnapshot))
+		verifyChain(t, chain)
+		assert.EqualValues(t, prefixHead.Hash, chain.Head().Hash)
+		assert.True(t, chain.HasSnapshot(prefixSnapshot))
+		assert.False(t, chain.HasSnapshot(branchSnapshot))
+		assert.Error(t, chain.RevertToSnapshot(branchSnapshot))
+	}
+
+	// Reverting before the prefix invalidates its snapshot.
+	assert.NoError(t, chain.RevertToBlockIndex(1))
+	assert.False(t, chain.HasSnapshot(prefixSnapshot))
+}
+
 // TestChainBlockNumberJumping creates a TestChain and creates blocks with block numbers which jumped (are
 // non-consecutive) to ensure the chain appropriately spoofs intermediate blocks.
 func TestChainBlockNumberJumping(t *testing.T) {
@@ -431,6 +472,64 @@ func TestChainDeploymentWithArgs(t *testing.T) {
 	})
 }
 
+// TestChainCallContracts executes message calls with CallContract and CallContracts, ensuring their changes are
+// discarded, before tracers are notified a single call ended, and that calls made in succession execute over the
+// changes made by the previous ones.
+func TestChainCallContracts(t *testing.T) {
+	chain, senders := createChain(t)
+	recipient := common.HexToAddress("0x1234")
+	value := big.NewInt(5)
+	newMessage := func() *core.Message {
+		return &core.Message{
+			From:            senders[0],
+			To:              &recipient,
+			Value:           new(big.Int).Set(value),
+			GasLimit:        chain.BlockGasLimit,
+			GasPrice:        big.NewInt(0),
+			GasFeeCap:       big.NewInt(0),
+			GasTipCap:       big.NewInt(0),
+			SkipNonceChecks: true,
+		}
+	}
+
+	// Record the balance of the recipient in the state executed over when each call exits, and in the chain state
+	// when tracers are notified each call ended.
+	var stateDB tracing.StateDB
+	exitBalances, txEndBalances := make([]uint64, 0), make([]uint64, 0)
+	tracer := &TestChainTracer{
+		Tracer: &tracers.Tracer{
+			Hooks: &tracing.Hooks{
+				OnTxStart: func(vm *tracing.VMContext, tx *types.Transaction, from common.Address) {
+					stateDB = vm.StateDB
+				},
+				OnExit: func(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+					exitBalances = append(exitBalances, stateDB.GetBalance(recipient).Uint64())
+				},
+				OnTxEnd: func(receipt *types.Receipt, err error) {
+					txEndBalances = append(txEndBalances, chain.State().GetBalance(recipient).Uint64())
+				},
+			},
+		},
+	}
+
+	// The changes of a single call should be reverted before tracers are notified it ended.
+	msgResult, err := chain.CallContract(newMessage(), nil, tracer)
+	assert.NoError(t, err)
+	assert.False(t, msgResult.Failed())
+	assert.EqualValues(t, []uint64{5}, exitBalances)
+	assert.EqualValues(t, []uint64{0}, txEndBalances)
+	assert.True(t, chain.State().GetBalance(recipient).IsZero())
+
+	// Each call should execute over the changes made by the previous one, and changes should not be committed.
+	exitBalances, txEndBalances = exitBalances[:0], txEndBalances[:0]
+	msgResults, err := chain.CallContracts([]*core.Message{newMessage(), newMessage()}, nil, tracer)
+	assert.NoError(t, err)
+	assert.Len(t, msgResults, 2)
+	assert.EqualValues(t, []uint64{5, 10}, exitBalances)
+	assert.EqualValues(t, []uint64{0, 0}, txEndBalances)
+	assert.True(t, chain.State().GetBalance(recipient).IsZero())
+}
+
 // TestChainCloning creates a TestChain, sends some messages to it, then clones it into a new instance and ensures
 // that the ending state is the same.
 func TestChainCloning(t *testing.T) {
@@ -631,3 +730,81 @@ func TestChainCallSequenceReplayMatchSimple(t *testing.T) {
 		assert.EqualValues(t, chain.Head().Header.Root, recreatedChain.Head().Header.Root)
 	})
 }
+
+// TestChainConfiguredParameters creates TestChains with each supported hardfork, and ensures their chain config and
+// genesis header reflect the configured hardfork, chain id, base fee and block gas limit, which contracts observe.
+func TestChainConfiguredParameters(t *testing.T) {
+	sender := common.HexToAddress("0x10000")
+	contract := common.HexToAddress("0x20000")
+	// Store the chain id and base fee, then read transient storage, which is only supported from Cancun.
+	code := []byte{
+		byte(vm.CHAINID), byte(vm.PUSH0), byte(vm.SSTORE),
+		byte(vm.BASEFEE), byte(vm.PUSH1), 1, byte(vm.SSTORE),
+		byte(vm.PUSH0), byte(vm.TLOAD), byte(vm.STOP),
+	}
+	tests := []struct {
+		hardfork string
+		cancun   bool
+		prague   bool
+	}{
+		{hardfork: config.HardforkShanghai, cancun: false, prague: false},
+		{hardfork: config.HardforkCancun, cancun: true, prague: false},
+		{hardfork: config.HardforkPrague, cancun: true, prague: true},
+		{hardfork: "", cancun: true, prague: true},
+	}
+	for _, test := range tests {
+		testChainConfig, err := config.DefaultTestChainConfig()
+		assert.NoError(t, err)
+		testChainConfig.Hardfork = test.hardfork
+		testChainConfig.ChainID = 1337
+		testChainConfig.BaseFee = 7
+		testChainConfig.BlockGasLimit = 30_000_000
+		chain, err := NewTestChain(context.Background(), types.GenesisAlloc{
+			sender:   {Balance: big.NewInt(1_000_000)},
+			contract: {Balance: big.NewInt(0), Code: code},
+		}, testChainConfig)
+		assert.NoError(t, err)
+
+		chainConfig := chain.GenesisDefinition().Config
+		assert.True(t, chainConfig.IsShanghai(common.Big0, 0), test.hardfork)
+		assert.EqualValues(t, test.cancun, chainConfig.IsCancun(common.Big0, 0), test.hardfork)
+		assert.EqualValues(t, test.prague, chainConfig.IsPrague(common.Big0, 0), test.hardfork)
+		assert.EqualValues(t, 1337, chainConfig.ChainID.Uint64())
+		assert.EqualValues(t, 7, chain.Head().Header.BaseFee.Uint64())
+		assert.EqualValues(t, 30_000_000, chain.Head().Header.GasLimit)
+		assert.EqualValues(t, 30_000_000, chain.BlockGasLimit)
+
+		// Blocks created afterwards keep the configured parameters, which contracts observe.
+		block, err := chain.PendingBlockCreate()
+		assert.NoError(t, err)
+		assert.EqualValues(t, 7, block.Header.BaseFee.Uint64())
+		assert.EqualValues(t, 30_000_000, block.Header.GasLimit)
+		err = chain.PendingBlockAddTx(&core.Message{
+			From:      sender,
+			To:        &contract,
+			Value:     big.NewInt(0),
+			GasLimit:  1_000_000,
+			GasPrice:  big.NewInt(0),
+			GasFeeCap: big.NewInt(0),
+			GasTipCap: big.NewInt(0),
+		})
+		assert.NoError(t, err)
+		receipt := block.MessageResults[0].Receipt
+		if !test.cancun {
+			assert.EqualValues(t, types.ReceiptStatusFailed, receipt.Status, test.hardfork)
+		} else {
+			assert.EqualValues(t, types.ReceiptStatusSuccessful, receipt.Status, test.hardfork)
+			assert.EqualValues(t, common.BigToHash(big.NewInt(1337)), chain.State().GetState(contract, common.Hash{}))
+			assert.EqualValues(t, common.BigToHash(big.NewInt(7)), chain.State().GetState(contract, common.BigToHash(common.Big1)))
+		}
+		chain.Close()
+	}
+
+	// Unsupported hardforks are rejected.
+	testChainConfig, err := config.DefaultTestChainConfig()
+	assert.NoError(t, err)
+	testChainConfig.Hardfork = "london"
+	assert.Error(t, testChainConfig.ValidateHardfork())
+	_, err = NewTestChain(context.Background(), types.GenesisAlloc{}, testChainConfig)
+	assert.Error(t, err)
+}
@@ -0,0 +1,205 @@
+package chain
+
+import (
+	"math/big"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core/tracing"
+	coretypes "github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa-geth/eth/tracers"
+)
+
+// OpcodeStep describes an opcode executed by the EVM, along with the state derived from it which is shared by all
+// OpcodeHandler instances registered with a TestChainTracerMultiplexer.
+type OpcodeStep struct {
+	// Pc describes the program counter of the opcode.
+	Pc uint64
+
+	// Op describes the opcode executed.
+	Op vm.OpCode
+
+	// Scope describes the scope the opcode executes in, i.e. its contract, stack and memory.
+	Scope *vm.ScopeContext
+
+	// Address describes the address of the contract the opcode executes in, cached once per call frame.
+	Address common.Address
+
+	// Depth describes the depth of the call frame the opcode executes in.
+	Depth int
+}
+
+// OpcodeHandler describes a function which handles an OpcodeStep. It must not retain the step, as it is reused for
+// later opcodes.
+type OpcodeHandler func(step *OpcodeStep)
+
+// OpcodeStepTracer describes a tracer which, rather than receiving every opcode through its OnOpcode hook, can handle
+// the opcodes it records through a TestChainTracerMultiplexer.
+type OpcodeStepTracer interface {
+	// NativeTracer returns the underlying TestChainTracer.
+	NativeTracer() *TestChainTracer
+
+	// OnOpcodeStep handles an opcode in place of the OnOpcode hook of the tracer.
+	OnOpcodeStep(step *OpcodeStep)
+
+	// HandledOpcodes returns the opcodes OnOpcodeStep should be called for.
+	HandledOpcodes() []vm.OpCode
+}
+
+// multiplexerFrameAddress describes the contract address of a call frame traced by a TestChainTracerMultiplexer.
+type multiplexerFrameAddress struct {
+	// address describes the contract address of the call frame.
+	address common.Address
+
+	// cached indicates whether address was set, which happens upon the first opcode dispatched in the call frame.
+	cached bool
+}
+
+// TestChainTracerMultiplexer acts as a tracers.Tracer which receives each opcode executed once, derives the state
+// shared by the tracers handling it (scope, call frame address), and dispatches it only to the OpcodeHandler instances
+// registered for that opcode. This reduces the per-opcode overhead of many tracers each receiving every opcode.
+type TestChainTracerMultiplexer struct {
+	// handlers describes the handlers registered for each opcode.
+	handlers [256][]OpcodeHandler
+
+	// frameAddresses describes the contract address of each call frame entered, cached upon the first opcode
+	// dispatched in it.
+	frameAddresses []multiplexerFrameAddress
+
+	// step describes the OpcodeStep passed to handlers, reused across opcodes.
+	step OpcodeStep
+
+	// nativeTracer is the underlying tracer used to capture EVM execution.
+	nativeTracer *TestChainTracer
+}
+
+// NewTestChainTracerMultiplexer returns a new TestChainTracerMultiplexer instance with no registered handlers.
+func NewTestChainTracerMultiplexer() *TestChainTracerMultiplexer {
+	tracer := &TestChainTracerMultiplexer{
+		frameAddresses: make([]multiplexerFrameAddress, 0),
+	}
+	innerTracer := &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnTxStart: tracer.OnTxStart,
+			OnEnter:   tracer.OnEnter,
+			OnExit:    tracer.OnExit,
+			OnOpcode:  tracer.OnOpcode,
+		},
+	}
+	tracer.nativeTracer = &TestChainTracer{Tracer: innerTracer, CaptureTxEndSetAdditionalResults: nil}
+	return tracer
+}
+
+// NativeTracer returns the underlying TestChainTracer.
+func (t *TestChainTracerMultiplexer) NativeTracer() *TestChainTracer {
+	return t.nativeTracer
+}
+
+// AddHandler registers an OpcodeHandler to be called for the provided opcodes, or for every opcode if none are
+// provided.
+func (t *TestChainTracerMultiplexer) AddHandler(handler OpcodeHandler, ops ...vm.OpCode) {
+	if len(ops) == 0 {
+		for op := range t.handlers {
+			t.handlers[op] = append(t.handlers[op], handler)
+		}
+		return
+	}
+	for _, op := range ops {
+		t.handlers[op] = append(t.handlers[op], handler)
+	}
+}
+
+// HasHandlers determines whether any OpcodeHandler was registered, so the multiplexer needs to be attached to a chain.
+func (t *TestChainTracerMultiplexer) HasHandlers() bool {
+	for _, handlers := range t.handlers {
+		if len(handlers) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Multiplex registers the provided tracer's OnOpcodeStep for the opcodes it handles, and returns a copy of its native
+// tracer without its OnOpcode hook, which should be attached to the chain in place of it.
+func (t *TestChainTracerMultiplexer) Multiplex(tracer OpcodeStepTracer) *TestChainTracer {
+	t.AddHandler(tracer.OnOpcodeStep, tracer.HandledOpcodes()...)
+	return withoutOpcodeHook(tracer.NativeTracer())
+}
+
+// MultiplexSampled registers the provided tracer like Multiplex, but only traces the transactions for which the
+// provided sampling function returns true when they start (see NewSampledTestChainTracer). Opcodes are only dispatched
+// to the tracer during the transactions it traces.
+// Returns the tracer which should be attached to the chain in place of the provided one.
+func (t *TestChainTracerMultiplexer) MultiplexSampled(tracer OpcodeStepTracer, sampleFunc func() bool) *TestChainTracer {
+	sampledTracer, sampledNativeTracer := newTestChainSampledTracer(withoutOpcodeHook(tracer.NativeTracer()), sampleFunc)
+
+	t.AddHandler(func(step *OpcodeStep) {
+		if sampledTracer.active {
+			tracer.OnOpcodeStep(step)
+		}
+	}, tracer.HandledOpcodes()...)
+	return sampledNativeTracer
+}
+
+// withoutOpcodeHook returns a copy of the provided tracer without its OnOpcode hook, for tracers whose opcodes are
+// dispatched by a TestChainTracerMultiplexer.
+func withoutOpcodeHook(nativeTracer *TestChainTracer) *TestChainTracer {
+	hooks := *nativeTracer.Hooks
+	hooks.OnOpcode = nil
+	innerTracer := *nativeTracer.Tracer
+	innerTracer.Hooks = &hooks
+	return &TestChainTracer{Tracer: &innerTracer, CaptureTxEndSetAdditionalResults: nativeTracer.CaptureTxEndSetAdditionalResults}
+}
+
+// OnTxStart is called upon the start of transaction execution, as defined by tracers.Tracer.
+func (t *TestChainTracerMultiplexer) OnTxStart(vm *tracing.VMContext, tx *coretypes.Transaction, from common.Address) {
+	t.frameAddresses = t.frameAddresses[:0]
+}
+
+// OnEnter is called upon entering of the call frame, as defined by tracers.Tracer.
+func (t *TestChainTracerMultiplexer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.frameAddresses = append(t.frameAddresses, multiplexerFrameAddress{})
+}
+
+// OnExit is called after a call to finalize tracing completes for the top of a call frame, as defined by tracers.Tracer.
+func (t *TestChainTracerMultiplexer) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if len(t.frameAddresses) > 0 {
+		t.frameAddresses = t.frameAddresses[:len(t.frameAddresses)-1]
+	}
+}
+
+// OnOpcode records data from an EVM state update, as defined by tracers.Tracer, dispatching it to the handlers
+// registered for the opcode.
+func (t *TestChainTracerMultiplexer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	handlers := t.handlers[op]
+	if len(handlers) == 0 {
+		return
+	}
+
+	// We can cast OpContext to ScopeContext because that is the type passed to OnOpcode.
+	scopeContext := scope.(*vm.ScopeContext)
+
+	// Cache the address of the current call frame upon its first dispatched opcode.
+	var address common.Address
+	if frameCount := len(t.frameAddresses); frameCount > 0 {
+		frameAddress := &t.frameAddresses[frameCount-1]
+		if !frameAddress.cached {
+			frameAddress.address = scopeContext.Address()
+			frameAddress.cached = true
+		}
+		address = frameAddress.address
+	} else {
+		address = scopeContext.Address()
+	}
+
+	t.step = OpcodeStep{
+		Pc:      pc,
+		Op:      vm.OpCode(op),
+		Scope:   scopeContext,
+		Address: address,
+		Depth:   depth,
+	}
+	for _, handler := range handlers {
+		handler(&t.step)
+	}
+}
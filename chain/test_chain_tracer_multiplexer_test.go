@@ -0,0 +1,199 @@
+package chain
+
+import (
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core/tracing"
+	coretypes "github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa-geth/eth/tracers"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestChainTracerMultiplexerDispatch ensures a TestChainTracerMultiplexer only dispatches opcodes to the handlers
+// registered for them, and caches the address of each call frame.
+func TestChainTracerMultiplexerDispatch(t *testing.T) {
+	multiplexer := NewTestChainTracerMultiplexer()
+	assert.False(t, multiplexer.HasHandlers())
+
+	// Register a handler for JUMPI only, and one for every opcode.
+	jumpiSteps, allSteps := 0, 0
+	var lastAddress common.Address
+	multiplexer.AddHandler(func(step *OpcodeStep) {
+		assert.EqualValues(t, vm.JUMPI, step.Op)
+		jumpiSteps++
+	}, vm.JUMPI)
+	multiplexer.AddHandler(func(step *OpcodeStep) {
+		lastAddress = step.Address
+		allSteps++
+	})
+	assert.True(t, multiplexer.HasHandlers())
+
+	// Execute some opcodes in a call frame.
+	address := common.HexToAddress("0x1234")
+	scope := &vm.ScopeContext{Contract: vm.NewContract(common.Address{}, address, uint256.NewInt(0), 0, nil)}
+	multiplexer.OnTxStart(nil, nil, common.Address{})
+	multiplexer.OnEnter(0, byte(vm.CALL), common.Address{}, address, nil, 0, nil)
+	for _, op := range []vm.OpCode{vm.PUSH1, vm.JUMPI, vm.JUMPDEST, vm.STOP} {
+		multiplexer.OnOpcode(0, byte(op), 0, 0, scope, nil, 0, nil)
+	}
+	multiplexer.OnExit(0, nil, 0, nil, false)
+
+	assert.EqualValues(t, 1, jumpiSteps)
+	assert.EqualValues(t, 4, allSteps)
+	assert.EqualValues(t, address, lastAddress)
+}
+
+// TestChainTracerMultiplexerSampled ensures a tracer registered with MultiplexSampled only receives the opcodes and
+// hooks of the transactions its sampling function selects, while other tracers keep receiving every transaction.
+func TestChainTracerMultiplexerSampled(t *testing.T) {
+	multiplexer := NewTestChainTracerMultiplexer()
+	sampledTracer, tracer := newBenchmarkOpcodeTracer(vm.JUMPI), newBenchmarkOpcodeTracer(vm.JUMPI)
+	txStarts := 0
+	sampledTracer.nativeTracer.OnTxStart = func(vm *tracing.VMContext, tx *coretypes.Transaction, from common.Address) {
+		txStarts++
+	}
+
+	// Trace every other transaction with the sampled tracer.
+	sample := false
+	sampledNativeTracer := multiplexer.MultiplexSampled(sampledTracer, func() bool {
+		sample = !sample
+		return sample
+	})
+	assert.Nil(t, sampledNativeTracer.OnOpcode)
+	nativeTracer := multiplexer.Multiplex(tracer)
+	assert.Nil(t, nativeTracer.OnOpcode)
+
+	scope := &vm.ScopeContext{Contract: vm.NewContract(common.Address{}, common.Address{}, uint256.NewInt(0), 0, nil)}
+	for i := 0; i < 4; i++ {
+		sampledNativeTracer.OnTxStart(nil, nil, common.Address{})
+		multiplexer.OnTxStart(nil, nil, common.Address{})
+		multiplexer.OnOpcode(0, byte(vm.JUMPI), 0, 0, scope, nil, 0, nil)
+	}
+	assert.EqualValues(t, 2, sampledTracer.steps)
+	assert.EqualValues(t, 2, txStarts)
+	assert.EqualValues(t, 4, tracer.steps)
+}
+
+// benchmarkOpcodeTracer is an OpcodeStepTracer recording the address of the opcodes it handles. It receives them either
+// through its OnOpcode hook, as tracers chained through a TestChainTracerRouter do, or through a
+// TestChainTracerMultiplexer.
+type benchmarkOpcodeTracer struct {
+	// handledOps describes the opcodes handled by the tracer, or none if it handles every opcode.
+	handledOps []vm.OpCode
+
+	// handled describes whether each opcode is handled by the tracer.
+	handled [256]bool
+
+	// lastAddress describes the address of the latest opcode handled.
+	lastAddress common.Address
+
+	// steps describes the amount of opcodes handled.
+	steps int
+
+	// nativeTracer is the underlying tracer used to capture EVM execution.
+	nativeTracer *TestChainTracer
+}
+
+// newBenchmarkOpcodeTracer returns a new benchmarkOpcodeTracer handling the provided opcodes, or every opcode if none
+// are provided.
+func newBenchmarkOpcodeTracer(ops ...vm.OpCode) *benchmarkOpcodeTracer {
+	tracer := &benchmarkOpcodeTracer{handledOps: ops}
+	for op := range tracer.handled {
+		tracer.handled[op] = len(ops) == 0
+	}
+	for _, op := range ops {
+		tracer.handled[op] = true
+	}
+	innerTracer := &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnOpcode: tracer.OnOpcode,
+		},
+	}
+	tracer.nativeTracer = &TestChainTracer{Tracer: innerTracer, CaptureTxEndSetAdditionalResults: nil}
+	return tracer
+}
+
+// NativeTracer returns the underlying TestChainTracer.
+func (t *benchmarkOpcodeTracer) NativeTracer() *TestChainTracer {
+	return t.nativeTracer
+}
+
+// HandledOpcodes returns the opcodes handled by the tracer, as defined by OpcodeStepTracer.
+func (t *benchmarkOpcodeTracer) HandledOpcodes() []vm.OpCode {
+	return t.handledOps
+}
+
+// OnOpcode records the address of the opcode if it is handled by the tracer, as defined by tracers.Tracer.
+func (t *benchmarkOpcodeTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	if !t.handled[op] {
+		return
+	}
+	scopeContext := scope.(*vm.ScopeContext)
+	t.lastAddress = scopeContext.Address()
+	t.steps++
+}
+
+// OnOpcodeStep records the address of the opcode, as defined by OpcodeStepTracer.
+func (t *benchmarkOpcodeTracer) OnOpcodeStep(step *OpcodeStep) {
+	t.lastAddress = step.Address
+	t.steps++
+}
+
+// getBenchmarkOpcodeTracers returns tracers handling the opcodes the fitness metric tracers commonly record.
+func getBenchmarkOpcodeTracers() []*benchmarkOpcodeTracer {
+	return []*benchmarkOpcodeTracer{
+		newBenchmarkOpcodeTracer(),
+		newBenchmarkOpcodeTracer(vm.JUMPI),
+		newBenchmarkOpcodeTracer(vm.JUMP, vm.JUMPI),
+		newBenchmarkOpcodeTracer(vm.LT, vm.GT, vm.SLT, vm.SGT, vm.EQ, vm.ISZERO),
+		newBenchmarkOpcodeTracer(vm.SLOAD, vm.SSTORE),
+		newBenchmarkOpcodeTracer(vm.SSTORE),
+		newBenchmarkOpcodeTracer(vm.CALL, vm.LOG1, vm.LOG2, vm.LOG3),
+		newBenchmarkOpcodeTracer(vm.CALLDATALOAD),
+	}
+}
+
+// BenchmarkTestChainTracerMultiplexer compares dispatching the opcodes of a call frame to several tracers through a
+// TestChainTracerMultiplexer, against chaining the OnOpcode hook of each tracer through a TestChainTracerRouter.
+func BenchmarkTestChainTracerMultiplexer(b *testing.B) {
+	ops := []vm.OpCode{
+		vm.PUSH1, vm.CALLDATALOAD, vm.DUP1, vm.PUSH1, vm.LT, vm.ISZERO, vm.PUSH2, vm.JUMPI, vm.JUMPDEST, vm.PUSH1,
+		vm.SLOAD, vm.DUP2, vm.ADD, vm.PUSH1, vm.SSTORE, vm.PUSH1, vm.MLOAD, vm.SWAP1, vm.POP, vm.PUSH2, vm.JUMP,
+		vm.JUMPDEST, vm.STOP,
+	}
+	address := common.HexToAddress("0x1234")
+	scope := &vm.ScopeContext{Contract: vm.NewContract(common.Address{}, address, uint256.NewInt(0), 0, nil)}
+
+	// traceCallFrames traces a call frame executing the opcodes above for each benchmark iteration.
+	traceCallFrames := func(b *testing.B, hooks *tracing.Hooks) {
+		hooks.OnTxStart(nil, nil, common.Address{})
+		b.ReportAllocs()
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			hooks.OnEnter(0, byte(vm.CALL), common.Address{}, address, nil, 0, nil)
+			for pc, op := range ops {
+				hooks.OnOpcode(uint64(pc), byte(op), 0, 0, scope, nil, 0, nil)
+			}
+			hooks.OnExit(0, nil, 0, nil, false)
+		}
+	}
+	b.Run("router", func(b *testing.B) {
+		router := NewTestChainTracerRouter()
+		for _, tracer := range getBenchmarkOpcodeTracers() {
+			router.AddTracer(tracer.NativeTracer())
+		}
+		traceCallFrames(b, router.NativeTracer().Hooks)
+	})
+	b.Run("multiplexer", func(b *testing.B) {
+		router := NewTestChainTracerRouter()
+		multiplexer := NewTestChainTracerMultiplexer()
+		router.AddTracer(multiplexer.NativeTracer())
+		for _, tracer := range getBenchmarkOpcodeTracers() {
+			router.AddTracer(multiplexer.Multiplex(tracer))
+		}
+		traceCallFrames(b, router.NativeTracer().Hooks)
+	})
+}
@@ -32,6 +32,10 @@ type MessageResults struct {
 	// The hooks are executed as a stack (to support revert operations).
 	OnRevertHookFuncs GenericHookFuncs
 
+	// MockResponses describes the return data of the calls made to mock contracts during execution, in the order
+	// they were made. Replaying them ensures the message executes the same way when it is re-executed.
+	MockResponses []*MockResponse
+
 	// ContractDiscoverys describes contracts that were discovered during execution, such as via CALL operations.
 	ContractDiscoverys []DeployedContractBytecode
 }
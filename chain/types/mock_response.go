@@ -0,0 +1,26 @@
+package types
+
+import (
+	"bytes"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/common/hexutil"
+)
+
+// MockResponse describes the return data a function of a mock contract returned to a call made to it.
+type MockResponse struct {
+	// Address describes the address of the mock contract which was called.
+	Address common.Address `json:"address"`
+
+	// Selector describes the function selector of the mocked function which was called.
+	Selector hexutil.Bytes `json:"selector"`
+
+	// ReturnData describes the data the mocked function returned.
+	ReturnData hexutil.Bytes `json:"returnData"`
+}
+
+// Matches determines whether the MockResponse was returned by the function with the provided selector, of the mock
+// contract at the provided address.
+func (r *MockResponse) Matches(address common.Address, selector []byte) bool {
+	return r.Address == address && bytes.Equal(r.Selector, selector)
+}
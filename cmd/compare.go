@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/crytic/medusa/fuzzing"
+	"github.com/crytic/medusa/fuzzing/coverage"
+	"github.com/crytic/medusa/logging/colors"
+	"github.com/spf13/cobra"
+)
+
+// compareCmd represents the command provider for compare
+var compareCmd = &cobra.Command{
+	Use:   "compare <base> <new>",
+	Short: "Compares the results of two fuzzing campaigns",
+	Long: `Compares the results of two fuzzing campaigns, given their directories (the corpus directory, or crytic-export if
+it was unset) or their results.json files. Lists the bugs and findings each campaign found but not the other, the
+differences between the final totals of their metrics, and, if both campaigns wrote a coverage dump (the "json"
+coverage format), the branches each contract covered in one campaign but not the other.`,
+	Args:          cobra.ExactArgs(2),
+	RunE:          cmdRunCompare,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	// Add flags to compare command
+	compareCmd.Flags().Bool("json", false, "print the comparison as JSON")
+
+	// Add the compare command to the root command
+	rootCmd.AddCommand(compareCmd)
+}
+
+// cmdRunCompare executes the compare CLI command, printing the differences between the results of two campaigns.
+func cmdRunCompare(cmd *cobra.Command, args []string) error {
+	printJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		cmdLogger.Error("Failed to run the compare command", err)
+		return err
+	}
+
+	// Read the results and coverage dumps of both campaigns and compare them
+	baseResults, err := fuzzing.ReadResultsReport(args[0])
+	if err != nil {
+		cmdLogger.Error("Failed to run the compare command", err)
+		return err
+	}
+	newResults, err := fuzzing.ReadResultsReport(args[1])
+	if err != nil {
+		cmdLogger.Error("Failed to run the compare command", err)
+		return err
+	}
+	baseCoverage := readCampaignCoverageDump(args[0])
+	newCoverage := readCampaignCoverageDump(args[1])
+	if baseCoverage == nil || newCoverage == nil {
+		cmdLogger.Warn("Coverage is not compared, as the coverage dump of a campaign could not be read")
+	}
+	comparison := fuzzing.CompareCampaigns(baseResults, newResults, baseCoverage, newCoverage)
+
+	if printJSON {
+		b, err := json.MarshalIndent(comparison, "", "\t")
+		if err != nil {
+			cmdLogger.Error("Failed to run the compare command", err)
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	}
+
+	cmdLogger.Info("Bugs: ", colors.GreenBold, "+", len(comparison.NewBugIDs), colors.Reset, "/", colors.RedBold, "-", len(comparison.MissingBugIDs), colors.Reset,
+		", findings: ", colors.GreenBold, "+", len(comparison.NewFindings), colors.Reset, "/", colors.RedBold, "-", len(comparison.MissingFindings), colors.Reset)
+	for _, bugId := range comparison.NewBugIDs {
+		cmdLogger.Info(colors.GreenBold, "  + ", colors.Reset, bugId)
+	}
+	for _, bugId := range comparison.MissingBugIDs {
+		cmdLogger.Info(colors.RedBold, "  - ", colors.Reset, bugId)
+	}
+
+	cmdLogger.Info("Metrics (base -> new):")
+	for _, metric := range comparison.MetricDeltas {
+		cmdLogger.Info(colors.BULLET_POINT, " ", colors.Bold, metric.Name, colors.Reset, fmt.Sprintf(": %d -> %d (%+d)", metric.Base, metric.New, metric.Delta))
+	}
+
+	for _, diff := range comparison.CoverageDiffs {
+		if len(diff.AddedBranches) == 0 && len(diff.RemovedBranches) == 0 {
+			continue
+		}
+		contract := diff.Contract
+		if diff.Init {
+			contract += " (init)"
+		}
+		cmdLogger.Info(colors.Bold, contract, colors.Reset, ": ",
+			colors.GreenBold, "+", len(diff.AddedBranches), colors.Reset, " newly covered/", colors.RedBold, "-", len(diff.RemovedBranches), colors.Reset, " regressed branches")
+		for _, branch := range diff.AddedBranches {
+			cmdLogger.Info(colors.GreenBold, "  + ", colors.Reset, describeCoverageDiffBranch(branch))
+		}
+		for _, branch := range diff.RemovedBranches {
+			cmdLogger.Info(colors.RedBold, "  - ", colors.Reset, describeCoverageDiffBranch(branch))
+		}
+	}
+	return nil
+}
+
+// readCampaignCoverageDump reads the coverage dump a campaign wrote to the coverage directory of the provided
+// campaign directory, or of the directory containing the provided results file.
+// Returns the coverage dump, or nil if it could not be read.
+func readCampaignCoverageDump(path string) *coverage.CoverageDump {
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		path = filepath.Dir(path)
+	}
+	dump, err := coverage.ReadCoverageDump(filepath.Join(path, "coverage"))
+	if err != nil {
+		return nil
+	}
+	return dump
+}
@@ -0,0 +1,327 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/cmd/exitcodes"
+	"github.com/crytic/medusa/fuzzing"
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/crytic/medusa/fuzzing/corpus"
+	"github.com/crytic/medusa/logging"
+	"github.com/crytic/medusa/logging/colors"
+	"github.com/crytic/medusa/utils"
+	"github.com/spf13/cobra"
+)
+
+// corpusCmd represents the command provider for corpus maintenance
+var corpusCmd = &cobra.Command{
+	Use:   "corpus",
+	Short: "Maintains the corpus of a fuzzing campaign",
+	Long:  `Maintains the corpus of a fuzzing campaign`,
+}
+
+// corpusMinimizeCmd represents the command provider for corpus minimization
+var corpusMinimizeCmd = &cobra.Command{
+	Use:   "minimize",
+	Short: "Minimizes the corpus of a fuzzing campaign",
+	Long: `Replays the call sequences stored in the corpus directory against all enabled fitness metric tracers and
+rewrites the directory in place, keeping only a minimal subset of call sequences which preserves the fitness metrics
+they achieve (e.g. code and branch coverage, dataflow and tokenflow sets). Test results are left untouched.`,
+	Args:          cobra.NoArgs,
+	RunE:          cmdRunCorpusMinimize,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+// corpusImportCmd represents the command provider for importing call sequences from other tools into the corpus
+var corpusImportCmd = &cobra.Command{
+	Use:   "import <path>",
+	Short: "Imports call sequences from other tools into the corpus",
+	Long: `Reads the call sequences stored at the provided file or directory in the format of another tool (Echidna
+reproducers or coverage corpus files, or Foundry persisted invariant failures) and adds them to the corpus, so they are
+replayed and mutated by the next fuzzing campaign. As other tools deploy contracts at different addresses, the
+addresses of senders and targets can be translated with --map-address.`,
+	Args:          cobra.ExactArgs(1),
+	RunE:          cmdRunCorpusImport,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+// corpusExportCmd represents the command provider for exporting the corpus to other tools
+var corpusExportCmd = &cobra.Command{
+	Use:   "export <output-dir>",
+	Short: "Exports the corpus to other tools",
+	Long: `Writes the call sequences stored in the corpus, including test results, to the provided output directory in
+the format of another tool (Echidna reproducers and coverage corpus files, or Foundry persisted invariant failures).`,
+	Args:          cobra.ExactArgs(1),
+	RunE:          cmdRunCorpusExport,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+// corpusReplayCmd represents the command provider for replaying a single corpus call sequence
+var corpusReplayCmd = &cobra.Command{
+	Use:   "replay <call-sequence-file>",
+	Short: "Replays a single corpus call sequence with all tracers attached",
+	Long: `Replays the call sequence stored in the provided corpus file (from the call_sequences or test_results directory)
+against a freshly deployed test chain with all enabled fitness metric tracers attached, processing the results of every
+call as when fuzzing, and prints the execution trace of every call. This reproduces issues which occur for a specific
+call sequence in isolation from the rest of a fuzzing campaign.`,
+	Args:          cobra.ExactArgs(1),
+	RunE:          cmdRunCorpusReplay,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	// Add flags to all corpus subcommands
+	for _, subCmd := range []*cobra.Command{corpusMinimizeCmd, corpusImportCmd, corpusExportCmd, corpusReplayCmd} {
+		subCmd.Flags().String("config", "", "path to config file")
+		subCmd.Flags().String("corpus-dir", "", "directory path for corpus items (unless a config file is provided, it must be set)")
+	}
+	for _, subCmd := range []*cobra.Command{corpusImportCmd, corpusExportCmd} {
+		subCmd.Flags().String("format", string(corpus.InteropFormatEchidna), "format of the other tool (echidna, foundry)")
+	}
+	corpusImportCmd.Flags().StringSlice("map-address", []string{}, "address translations applied to imported senders and targets (e.g. --map-address 0xA=0xB)")
+
+	// Add the subcommands to the corpus command, and the corpus command to the root command
+	corpusReplayCmd.Flags().Int64("seed", 0, "seed of the random provider used while replaying (unless a config file is provided, 0 means that a seed is derived from the current time)")
+	corpusCmd.AddCommand(corpusMinimizeCmd, corpusImportCmd, corpusExportCmd, corpusReplayCmd)
+	rootCmd.AddCommand(corpusCmd)
+}
+
+// readCorpusProjectConfig reads the project configuration of a corpus subcommand (see readProjectConfig), updating the
+// corpus directory if one was provided, and changes the working directory to the parent directory of the project
+// configuration file, as when fuzzing, so compilation and corpus paths are resolved the same way.
+// Returns the project configuration, or an error if one occurs.
+func readCorpusProjectConfig(cmd *cobra.Command) (*config.ProjectConfig, error) {
+	// Read our project configuration
+	projectConfig, configPath, err := readProjectConfig(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update the corpus directory if it was provided
+	if cmd.Flags().Changed("corpus-dir") {
+		projectConfig.Fuzzing.CorpusDirectory, err = cmd.Flags().GetString("corpus-dir")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Change our working directory
+	err = os.Chdir(filepath.Dir(configPath))
+	if err != nil {
+		return nil, err
+	}
+	return projectConfig, nil
+}
+
+// cmdRunCorpusMinimize executes the corpus minimize CLI command, reading the project configuration (see
+// readCorpusProjectConfig) and minimizing the corpus directory it specifies.
+func cmdRunCorpusMinimize(cmd *cobra.Command, args []string) error {
+	// Read our project configuration
+	projectConfig, err := readCorpusProjectConfig(cmd)
+	if err != nil {
+		cmdLogger.Error("Failed to run the corpus minimize command", err)
+		return err
+	}
+
+	// Create our fuzzer
+	fuzzer, err := fuzzing.NewFuzzer(*projectConfig)
+	if err != nil {
+		return exitcodes.NewErrorWithExitCode(err, exitcodes.ExitCodeHandledError)
+	}
+
+	// Stop minimizing on keyboard interrupts
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt)
+	go func() {
+		<-c
+		fuzzer.Terminate()
+	}()
+
+	// Minimize the corpus
+	kept, removed, err := fuzzer.MinimizeCorpus()
+	if err != nil {
+		cmdLogger.Error("Failed to run the corpus minimize command", err)
+		return exitcodes.NewErrorWithExitCode(err, exitcodes.ExitCodeHandledError)
+	}
+	cmdLogger.Info(fmt.Sprintf("Kept %d corpus call sequences and removed %d from ", kept, removed), colors.Bold, projectConfig.Fuzzing.CorpusDirectory, colors.Reset)
+	return nil
+}
+
+// cmdRunCorpusReplay executes the corpus replay CLI command, reading the project configuration (see
+// readCorpusProjectConfig) and replaying the provided corpus call sequence with all tracers attached.
+func cmdRunCorpusReplay(cmd *cobra.Command, args []string) error {
+	// Resolve the call sequence file before changing our working directory
+	callSequencePath, err := filepath.Abs(args[0])
+	if err != nil {
+		cmdLogger.Error("Failed to run the corpus replay command", err)
+		return err
+	}
+
+	// Read our project configuration
+	projectConfig, err := readCorpusProjectConfig(cmd)
+	if err != nil {
+		cmdLogger.Error("Failed to run the corpus replay command", err)
+		return err
+	}
+
+	// Update the seed if it was provided
+	if cmd.Flags().Changed("seed") {
+		projectConfig.Fuzzing.Seed, err = cmd.Flags().GetInt64("seed")
+		if err != nil {
+			cmdLogger.Error("Failed to run the corpus replay command", err)
+			return err
+		}
+	}
+
+	// Create our fuzzer
+	fuzzer, err := fuzzing.NewFuzzer(*projectConfig)
+	if err != nil {
+		return exitcodes.NewErrorWithExitCode(err, exitcodes.ExitCodeHandledError)
+	}
+
+	// Replay the call sequence and print it along with its execution traces
+	callSequence, err := fuzzer.ReplayCallSequence(callSequencePath)
+	if err != nil {
+		cmdLogger.Error("Failed to run the corpus replay command", err)
+		return exitcodes.NewErrorWithExitCode(err, exitcodes.ExitCodeHandledError)
+	}
+	buffer := logging.NewLogBuffer()
+	buffer.Append(colors.Bold, "[Call Sequence]", colors.Reset, "\n")
+	buffer.Append(callSequence.Log().Elements()...)
+	cmdLogger.Info(buffer.Elements()...)
+	return nil
+}
+
+// cmdRunCorpusImport executes the corpus import CLI command, reading the project configuration (see
+// readCorpusProjectConfig) and importing call sequences from other tools into the corpus directory it specifies.
+func cmdRunCorpusImport(cmd *cobra.Command, args []string) error {
+	// Resolve the import path before changing our working directory
+	importPath, err := filepath.Abs(args[0])
+	if err != nil {
+		cmdLogger.Error("Failed to run the corpus import command", err)
+		return err
+	}
+
+	// Parse the format and address translations
+	format, err := getCorpusInteropFormat(cmd)
+	if err != nil {
+		cmdLogger.Error("Failed to run the corpus import command", err)
+		return err
+	}
+	addressMap, err := getCorpusAddressMap(cmd)
+	if err != nil {
+		cmdLogger.Error("Failed to run the corpus import command", err)
+		return err
+	}
+
+	// Read our project configuration and corpus
+	projectConfig, err := readCorpusProjectConfig(cmd)
+	if err != nil {
+		cmdLogger.Error("Failed to run the corpus import command", err)
+		return err
+	}
+	if projectConfig.Fuzzing.CorpusDirectory == "" {
+		err = fmt.Errorf("a corpus directory must be set to import call sequences into")
+		cmdLogger.Error("Failed to run the corpus import command", err)
+		return err
+	}
+	c, err := corpus.NewCorpus(projectConfig.Fuzzing.CorpusDirectory, &projectConfig.Fuzzing)
+	if err != nil {
+		cmdLogger.Error("Failed to run the corpus import command", err)
+		return exitcodes.NewErrorWithExitCode(err, exitcodes.ExitCodeHandledError)
+	}
+
+	// Import the call sequences
+	imported, err := c.ImportCallSequences(format, importPath, addressMap)
+	if err != nil {
+		cmdLogger.Error("Failed to run the corpus import command", err)
+		return exitcodes.NewErrorWithExitCode(err, exitcodes.ExitCodeHandledError)
+	}
+	cmdLogger.Info(fmt.Sprintf("Imported %d call sequences into ", imported), colors.Bold, projectConfig.Fuzzing.CorpusDirectory, colors.Reset)
+	return nil
+}
+
+// cmdRunCorpusExport executes the corpus export CLI command, reading the project configuration (see
+// readCorpusProjectConfig) and exporting the corpus directory it specifies for other tools.
+func cmdRunCorpusExport(cmd *cobra.Command, args []string) error {
+	// Resolve the output directory before changing our working directory
+	outputDirectory, err := filepath.Abs(args[0])
+	if err != nil {
+		cmdLogger.Error("Failed to run the corpus export command", err)
+		return err
+	}
+
+	// Parse the format
+	format, err := getCorpusInteropFormat(cmd)
+	if err != nil {
+		cmdLogger.Error("Failed to run the corpus export command", err)
+		return err
+	}
+
+	// Read our project configuration and corpus
+	projectConfig, err := readCorpusProjectConfig(cmd)
+	if err != nil {
+		cmdLogger.Error("Failed to run the corpus export command", err)
+		return err
+	}
+	if projectConfig.Fuzzing.CorpusDirectory == "" {
+		err = fmt.Errorf("a corpus directory must be set to export call sequences from")
+		cmdLogger.Error("Failed to run the corpus export command", err)
+		return err
+	}
+	c, err := corpus.NewCorpus(projectConfig.Fuzzing.CorpusDirectory, &projectConfig.Fuzzing)
+	if err != nil {
+		cmdLogger.Error("Failed to run the corpus export command", err)
+		return exitcodes.NewErrorWithExitCode(err, exitcodes.ExitCodeHandledError)
+	}
+
+	// Export the call sequences
+	exported, err := c.ExportCallSequences(format, outputDirectory)
+	if err != nil {
+		cmdLogger.Error("Failed to run the corpus export command", err)
+		return exitcodes.NewErrorWithExitCode(err, exitcodes.ExitCodeHandledError)
+	}
+	cmdLogger.Info(fmt.Sprintf("Exported %d call sequences to ", exported), colors.Bold, outputDirectory, colors.Reset)
+	return nil
+}
+
+// getCorpusInteropFormat obtains the format of the other tool provided to a corpus import or export command.
+// Returns the format, or an error if one occurs.
+func getCorpusInteropFormat(cmd *cobra.Command) (corpus.InteropFormat, error) {
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return "", err
+	}
+	return corpus.ParseInteropFormat(format)
+}
+
+// getCorpusAddressMap obtains the address translations provided to a corpus import command, each of which is
+// formatted as <from>=<to>.
+// Returns the address translations, or an error if one occurs.
+func getCorpusAddressMap(cmd *cobra.Command) (map[common.Address]common.Address, error) {
+	addressMappings, err := cmd.Flags().GetStringSlice("map-address")
+	if err != nil {
+		return nil, err
+	}
+
+	addressMap := make(map[common.Address]common.Address, len(addressMappings))
+	for _, addressMapping := range addressMappings {
+		from, to, found := strings.Cut(addressMapping, "=")
+		fromAddress, fromErr := utils.HexStringToAddress(from)
+		toAddress, toErr := utils.HexStringToAddress(to)
+		if !found || fromErr != nil || toErr != nil {
+			return nil, fmt.Errorf("invalid address translation '%v', expected '<from>=<to>'", addressMapping)
+		}
+		addressMap[fromAddress] = toAddress
+	}
+	return addressMap, nil
+}
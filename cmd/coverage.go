@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"github.com/crytic/medusa/cmd/exitcodes"
+	"github.com/crytic/medusa/fuzzing"
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/crytic/medusa/logging/colors"
+	"github.com/spf13/cobra"
+)
+
+// coverageCmd represents the command provider for coverage
+var coverageCmd = &cobra.Command{
+	Use:   "coverage",
+	Short: "Analyzes the coverage achieved by an existing corpus",
+	Long: `Replays the call sequences stored in the corpus directory against a freshly deployed test chain, without
+fuzzing, and prints the totals of the selected metrics they achieve (code coverage, branch coverage, dataflow and
+tokenflow). The totals can be dumped as JSON, and the command fails if the code or branch coverage percentage is
+below a minimum, e.g. to gate the coverage of checked-in seeds in CI.`,
+	Args:          cobra.NoArgs,
+	RunE:          cmdRunCoverage,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+// coverageMetricNames describes the metrics which may be selected with the --metrics flag of the coverage command.
+var coverageMetricNames = []string{"code", "branch", "dataflow", "tokenflow"}
+
+func init() {
+	// Add flags to coverage command
+	coverageCmd.Flags().String("config", "", "path to config file")
+	coverageCmd.Flags().String("corpus-dir", "", "directory path for corpus items (unless a config file is provided, it must be set)")
+	coverageCmd.Flags().StringSlice("metrics", coverageMetricNames, "metrics to record while replaying the corpus (code, branch, dataflow, tokenflow)")
+	coverageCmd.Flags().Bool("json", false, "print the metric totals as JSON")
+	coverageCmd.Flags().String("output", "", "path of a file to dump the metric totals to as JSON")
+	coverageCmd.Flags().Float64("min-code-coverage", 0, "minimum percentage of instructions the corpus must cover")
+	coverageCmd.Flags().Float64("min-branch-coverage", 0, "minimum percentage of branches the corpus must cover")
+
+	// Add the coverage command to the root command
+	rootCmd.AddCommand(coverageCmd)
+}
+
+// cmdRunCoverage executes the coverage CLI command, reading the project configuration (see readCorpusProjectConfig)
+// and printing the totals of the metrics achieved by replaying the corpus directory it specifies.
+func cmdRunCoverage(cmd *cobra.Command, args []string) error {
+	// Parse our flags, resolving the output file before changing our working directory
+	metricRecordConfig, err := getCoverageMetricRecordConfig(cmd)
+	if err != nil {
+		cmdLogger.Error("Failed to run the coverage command", err)
+		return err
+	}
+	printJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		cmdLogger.Error("Failed to run the coverage command", err)
+		return err
+	}
+	outputPath, err := cmd.Flags().GetString("output")
+	if err != nil {
+		cmdLogger.Error("Failed to run the coverage command", err)
+		return err
+	}
+	if outputPath != "" {
+		outputPath, err = filepath.Abs(outputPath)
+		if err != nil {
+			cmdLogger.Error("Failed to run the coverage command", err)
+			return err
+		}
+	}
+	minCodeCoverage, err := cmd.Flags().GetFloat64("min-code-coverage")
+	if err != nil {
+		cmdLogger.Error("Failed to run the coverage command", err)
+		return err
+	}
+	minBranchCoverage, err := cmd.Flags().GetFloat64("min-branch-coverage")
+	if err != nil {
+		cmdLogger.Error("Failed to run the coverage command", err)
+		return err
+	}
+
+	// Read our project configuration, recording only the selected metrics
+	projectConfig, err := readCorpusProjectConfig(cmd)
+	if err != nil {
+		cmdLogger.Error("Failed to run the coverage command", err)
+		return err
+	}
+	projectConfig.Fuzzing.MetricRecordConfig = metricRecordConfig
+
+	// Create our fuzzer
+	fuzzer, err := fuzzing.NewFuzzer(*projectConfig)
+	if err != nil {
+		return exitcodes.NewErrorWithExitCode(err, exitcodes.ExitCodeHandledError)
+	}
+
+	// Stop replaying on keyboard interrupts
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt)
+	go func() {
+		<-c
+		fuzzer.Terminate()
+	}()
+
+	// Replay the corpus and total its metrics
+	report, err := fuzzer.AnalyzeCorpusCoverage()
+	if err != nil {
+		cmdLogger.Error("Failed to run the coverage command", err)
+		return exitcodes.NewErrorWithExitCode(err, exitcodes.ExitCodeHandledError)
+	}
+
+	// Print and dump the totals
+	if printJSON || outputPath != "" {
+		b, err := json.MarshalIndent(report, "", "\t")
+		if err != nil {
+			cmdLogger.Error("Failed to run the coverage command", err)
+			return err
+		}
+		if printJSON {
+			fmt.Println(string(b))
+		}
+		if outputPath != "" {
+			err = os.WriteFile(outputPath, b, 0644)
+			if err != nil {
+				cmdLogger.Error("Failed to run the coverage command", err)
+				return err
+			}
+			cmdLogger.Info("Corpus coverage saved to: ", colors.Bold, outputPath, colors.Reset)
+		}
+	}
+	if !printJSON {
+		printCorpusCoverageReport(report)
+	}
+
+	// Fail if the corpus does not achieve the minimum coverage
+	err = checkCorpusCoverageMinimum("code", report.CodeCoverage, minCodeCoverage)
+	if err == nil {
+		err = checkCorpusCoverageMinimum("branch", report.BranchCoverage, minBranchCoverage)
+	}
+	if err != nil {
+		cmdLogger.Error("Failed to run the coverage command", err)
+		return exitcodes.NewErrorWithExitCode(err, exitcodes.ExitCodeHandledError)
+	}
+	return nil
+}
+
+// getCoverageMetricRecordConfig obtains the metrics selected with the --metrics flag of a coverage command.
+// Returns a metric record configuration enabling only the selected metrics, or an error if an unknown metric was
+// selected.
+func getCoverageMetricRecordConfig(cmd *cobra.Command) (config.MetricRecordConfig, error) {
+	metrics, err := cmd.Flags().GetStringSlice("metrics")
+	if err != nil {
+		return config.MetricRecordConfig{}, err
+	}
+
+	var metricRecordConfig config.MetricRecordConfig
+	for _, metric := range metrics {
+		switch metric {
+		case "code":
+			metricRecordConfig.CodeCoverageEnabled = true
+		case "branch":
+			metricRecordConfig.BranchCoverageEnabled = true
+		case "dataflow":
+			metricRecordConfig.DataflowEnabled = true
+		case "tokenflow":
+			metricRecordConfig.TokenflowEnabled = true
+		default:
+			return config.MetricRecordConfig{}, fmt.Errorf("unknown metric '%v', expected one of %v", metric, coverageMetricNames)
+		}
+	}
+	return metricRecordConfig, nil
+}
+
+// printCorpusCoverageReport prints the metric totals of the provided corpus coverage report.
+func printCorpusCoverageReport(report *fuzzing.CorpusCoverageReport) {
+	cmdLogger.Info(fmt.Sprintf("Replayed %d corpus call sequences, skipped %d which could no longer be replayed", report.CallSequences, report.SkippedCallSequences))
+	if report.CodeCoverage != nil {
+		cmdLogger.Info(colors.BULLET_POINT, " ", colors.Bold, "code coverage", colors.Reset,
+			fmt.Sprintf(": %d/%d instructions (%.2f%%)", report.CodeCoverage.Covered, report.CodeCoverage.Total, report.CodeCoverage.Percentage()))
+	}
+	if report.BranchCoverage != nil {
+		cmdLogger.Info(colors.BULLET_POINT, " ", colors.Bold, "branch coverage", colors.Reset,
+			fmt.Sprintf(": %d/%d branches (%.2f%%)", report.BranchCoverage.Covered, report.BranchCoverage.Total, report.BranchCoverage.Percentage()))
+	}
+	if report.Dataflows != nil {
+		cmdLogger.Info(colors.BULLET_POINT, " ", colors.Bold, "dataflow", colors.Reset, fmt.Sprintf(": %d", *report.Dataflows))
+	}
+	if report.Tokenflows != nil {
+		cmdLogger.Info(colors.BULLET_POINT, " ", colors.Bold, "tokenflow", colors.Reset, fmt.Sprintf(": %d", *report.Tokenflows))
+	}
+}
+
+// checkCorpusCoverageMinimum checks that the provided coverage total reaches the provided minimum percentage. A
+// minimum of zero is always reached.
+// Returns an error if the minimum is not reached, or if it is set for a metric which was not recorded.
+func checkCorpusCoverageMinimum(metric string, total *fuzzing.CorpusCoverageTotal, minimum float64) error {
+	if minimum <= 0 {
+		return nil
+	}
+	if total == nil {
+		return fmt.Errorf("a minimum %s coverage was set, but %s coverage was not recorded", metric, metric)
+	}
+	if total.Percentage() < minimum {
+		return fmt.Errorf("the corpus achieves %.2f%% %s coverage, below the minimum of %.2f%%", total.Percentage(), metric, minimum)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/crytic/medusa/fuzzing/coverage"
+	"github.com/crytic/medusa/logging/colors"
+	"github.com/spf13/cobra"
+)
+
+// coverageDiffCmd represents the command provider for coverage-diff
+var coverageDiffCmd = &cobra.Command{
+	Use:   "coverage-diff <base> <new>",
+	Short: "Compares the coverage achieved by two fuzzing campaigns",
+	Long: `Compares two coverage dumps (written by the "json" coverage format, or the coverage directories containing them)
+and lists the instructions and branches each contract covered in one campaign but not the other.`,
+	Args:          cobra.ExactArgs(2),
+	RunE:          cmdRunCoverageDiff,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	// Add flags to coverage-diff command
+	coverageDiffCmd.Flags().Bool("instructions", false, "list added and removed instructions, rather than only branches")
+	coverageDiffCmd.Flags().Bool("json", false, "print the coverage differences as JSON")
+
+	// Add the coverage-diff command to the root command
+	rootCmd.AddCommand(coverageDiffCmd)
+}
+
+// cmdRunCoverageDiff executes the coverage-diff CLI command, printing the coverage differences between two dumps.
+func cmdRunCoverageDiff(cmd *cobra.Command, args []string) error {
+	listInstructions, err := cmd.Flags().GetBool("instructions")
+	if err != nil {
+		cmdLogger.Error("Failed to run the coverage-diff command", err)
+		return err
+	}
+	printJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		cmdLogger.Error("Failed to run the coverage-diff command", err)
+		return err
+	}
+
+	// Read both dumps and compare them
+	baseDump, err := coverage.ReadCoverageDump(args[0])
+	if err != nil {
+		cmdLogger.Error("Failed to run the coverage-diff command", err)
+		return err
+	}
+	newDump, err := coverage.ReadCoverageDump(args[1])
+	if err != nil {
+		cmdLogger.Error("Failed to run the coverage-diff command", err)
+		return err
+	}
+	diffs := coverage.DiffCoverageDumps(baseDump, newDump)
+
+	if printJSON {
+		b, err := json.MarshalIndent(diffs, "", "\t")
+		if err != nil {
+			cmdLogger.Error("Failed to run the coverage-diff command", err)
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	}
+
+	if len(diffs) == 0 {
+		cmdLogger.Info("Both campaigns covered the same instructions and branches")
+		return nil
+	}
+	for _, diff := range diffs {
+		contract := diff.Contract
+		if diff.Init {
+			contract += " (init)"
+		}
+		cmdLogger.Info(colors.Bold, contract, colors.Reset, ": ",
+			colors.GreenBold, "+", len(diff.AddedBranches), colors.Reset, "/", colors.RedBold, "-", len(diff.RemovedBranches), colors.Reset, " branches, ",
+			colors.GreenBold, "+", len(diff.AddedInstructions), colors.Reset, "/", colors.RedBold, "-", len(diff.RemovedInstructions), colors.Reset, " instructions")
+		for _, branch := range diff.AddedBranches {
+			cmdLogger.Info(colors.GreenBold, "  + ", colors.Reset, describeCoverageDiffBranch(branch))
+		}
+		for _, branch := range diff.RemovedBranches {
+			cmdLogger.Info(colors.RedBold, "  - ", colors.Reset, describeCoverageDiffBranch(branch))
+		}
+		if listInstructions {
+			for _, instruction := range diff.AddedInstructions {
+				cmdLogger.Info(colors.GreenBold, "  + ", colors.Reset, describeCoverageDiffInstruction(instruction))
+			}
+			for _, instruction := range diff.RemovedInstructions {
+				cmdLogger.Info(colors.RedBold, "  - ", colors.Reset, describeCoverageDiffInstruction(instruction))
+			}
+		}
+	}
+	return nil
+}
+
+// describeCoverageDiffBranch returns a human-readable description of the location and direction of a branch arm.
+func describeCoverageDiffBranch(branch coverage.BranchCoverageDumpEntry) string {
+	description := fmt.Sprintf("branch at pc %d falling through", branch.Pc)
+	if branch.Jumped {
+		description = fmt.Sprintf("branch at pc %d jumping", branch.Pc)
+	}
+	if branch.Source != nil {
+		description += fmt.Sprintf(", at %s", branch.Source.String())
+	}
+	return description
+}
+
+// describeCoverageDiffInstruction returns a human-readable description of the location of an instruction.
+func describeCoverageDiffInstruction(instruction coverage.InstructionCoverageDumpEntry) string {
+	description := fmt.Sprintf("instruction at pc %d", instruction.Pc)
+	if instruction.Source != nil {
+		description += fmt.Sprintf(", at %s", instruction.Source.String())
+	}
+	return description
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/branchdistance"
+	"github.com/crytic/medusa/logging/colors"
+	"github.com/holiman/uint256"
+	"github.com/spf13/cobra"
+)
+
+// frontierCmd represents the command provider for frontier
+var frontierCmd = &cobra.Command{
+	Use:   "frontier <dump>",
+	Short: "Lists the branches a fuzzing campaign is stuck on",
+	Long: `Lists the branch arms a fuzzing campaign reached but never took, along with those which came close to being
+taken, from a branch distance dump (or the latest dump in a branch distance dump directory).`,
+	Args:          cobra.ExactArgs(1),
+	RunE:          cmdRunFrontier,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	// Add flags to frontier command
+	frontierCmd.Flags().String("max-distance", "1000", "maximum branch distance for an untaken branch arm to be considered nearly covered")
+	frontierCmd.Flags().Int("limit", 20, "maximum amount of branch arms listed, or zero to list all of them")
+	frontierCmd.Flags().Bool("json", false, "print the coverage frontier as JSON")
+
+	// Add the frontier command to the root command
+	rootCmd.AddCommand(frontierCmd)
+}
+
+// cmdRunFrontier executes the frontier CLI command, printing the coverage frontier of a branch distance dump.
+func cmdRunFrontier(cmd *cobra.Command, args []string) error {
+	maxDistanceStr, err := cmd.Flags().GetString("max-distance")
+	if err != nil {
+		cmdLogger.Error("Failed to run the frontier command", err)
+		return err
+	}
+	maxDistance, err := uint256.FromDecimal(maxDistanceStr)
+	if err != nil {
+		err = fmt.Errorf("invalid maximum branch distance '%s': %v", maxDistanceStr, err)
+		cmdLogger.Error("Failed to run the frontier command", err)
+		return err
+	}
+	limit, err := cmd.Flags().GetInt("limit")
+	if err != nil {
+		cmdLogger.Error("Failed to run the frontier command", err)
+		return err
+	}
+	printJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		cmdLogger.Error("Failed to run the frontier command", err)
+		return err
+	}
+
+	// Read the dump and compute its frontier
+	dump, err := branchdistance.ReadBranchDistanceDump(args[0])
+	if err != nil {
+		cmdLogger.Error("Failed to run the frontier command", err)
+		return err
+	}
+	frontier := dump.Frontier(maxDistance)
+
+	if printJSON {
+		b, err := json.MarshalIndent(frontier, "", "\t")
+		if err != nil {
+			cmdLogger.Error("Failed to run the frontier command", err)
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	}
+
+	cmdLogger.Info("Branch arms reached but never taken: ", colors.Bold, len(frontier.UncoveredArms), colors.Reset)
+	cmdLogger.Info("Nearly covered branch arms (distance at most ", maxDistance.Dec(), "): ", colors.Bold, len(frontier.NearlyCoveredArms), colors.Reset)
+	arms := frontier.NearlyCoveredArms
+	if len(arms) > 0 {
+		cmdLogger.Info("Closest nearly covered branch arms follow below ...")
+	} else if arms = frontier.UncoveredArms; len(arms) > 0 {
+		cmdLogger.Info("Closest branch arms reached but never taken follow below ...")
+	}
+	if limit > 0 && len(arms) > limit {
+		arms = arms[:limit]
+	}
+	for _, arm := range arms {
+		cmdLogger.Info(colors.BULLET_POINT, " ", colors.Bold, arm.Contract, colors.Reset, ": ", describeFrontierArm(arm))
+	}
+	return nil
+}
+
+// describeFrontierArm returns a human-readable description of the location, direction and distance of a branch arm.
+func describeFrontierArm(arm branchdistance.FrontierArm) string {
+	description := fmt.Sprintf("branch at pc %d", arm.Branch.Pc)
+	if arm.Branch.Jumped != nil {
+		if *arm.Branch.Jumped {
+			description += " jumping"
+		} else {
+			description += " falling through"
+		}
+	} else if arm.Branch.Destination != nil {
+		description += fmt.Sprintf(" jumping to pc %d", *arm.Branch.Destination)
+	}
+	description += fmt.Sprintf(", distance %s", arm.Branch.Distance)
+	if arm.Branch.Source != nil {
+		description += fmt.Sprintf(", at %s", arm.Branch.Source.String())
+	}
+	return description
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"syscall"
 
 	"github.com/crytic/medusa/cmd/exitcodes"
 	"github.com/crytic/medusa/logging/colors"
@@ -70,67 +71,25 @@ func cmdValidateFuzzArgs(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// cmdRunFuzz executes the CLI fuzz command and navigates through the following possibilities:
-// #1: We will search for either a custom config file (via --config) or the default (medusa.json).
-// If we find it, read it. If we can't read it, throw an error.
-// #2: If a custom file was provided (--config was used), and we can't find the file, throw an error.
-// #3: If medusa.json can't be found, use the default project configuration.
+// cmdRunFuzz executes the CLI fuzz command, reading the project configuration (see readProjectConfig) and starting a
+// fuzzing campaign with it.
 func cmdRunFuzz(cmd *cobra.Command, args []string) error {
-	var projectConfig *config.ProjectConfig
-
-	// Check to see if --config flag was used and store the value of --config flag
-	configFlagUsed := cmd.Flags().Changed("config")
-	configPath, err := cmd.Flags().GetString("config")
+	// Read our project configuration
+	projectConfig, configPath, err := readProjectConfig(cmd)
 	if err != nil {
 		cmdLogger.Error("Failed to run the fuzz command", err)
 		return err
 	}
 
-	// If --config was not used, look for `medusa.json` in the current work directory
-	if !configFlagUsed {
-		workingDirectory, err := os.Getwd()
-		if err != nil {
-			cmdLogger.Error("Failed to run the fuzz command", err)
-			return err
-		}
-		configPath = filepath.Join(workingDirectory, DefaultProjectConfigFilename)
-	}
-
-	// Check to see if the file exists at configPath
-	_, existenceError := os.Stat(configPath)
-
-	// Possibility #1: File was found
-	if existenceError == nil {
-		// Try to read the configuration file and throw an error if something goes wrong
-		cmdLogger.Info("Reading the configuration file at: ", colors.Bold, configPath, colors.Reset)
-		// Use the default compilation platform if the config file doesn't specify one
-		projectConfig, err = config.ReadProjectConfigFromFile(configPath, DefaultCompilationPlatform)
-		if err != nil {
-			cmdLogger.Error("Failed to run the fuzz command", err)
-			return err
-		}
-	}
-
-	// Possibility #2: If the --config flag was used, and we couldn't find the file, we'll throw an error
-	if configFlagUsed && existenceError != nil {
+	// Update the project configuration given whatever flags were set using the CLI
+	err = updateProjectConfigWithFuzzFlags(cmd, projectConfig)
+	if err != nil {
 		cmdLogger.Error("Failed to run the fuzz command", err)
-		return existenceError
-	}
-
-	// Possibility #3: --config flag was not used and medusa.json was not found, so use the default project config
-	if !configFlagUsed && existenceError != nil {
-		cmdLogger.Warn(fmt.Sprintf("Unable to find the config file at %v, will use the default project configuration for the "+
-			"%v compilation platform instead", configPath, DefaultCompilationPlatform))
-
-		projectConfig, err = config.GetDefaultProjectConfig(DefaultCompilationPlatform)
-		if err != nil {
-			cmdLogger.Error("Failed to run the fuzz command", err)
-			return err
-		}
+		return err
 	}
 
-	// Update the project configuration given whatever flags were set using the CLI
-	err = updateProjectConfigWithFuzzFlags(cmd, projectConfig)
+	// Resolve the project configuration file path before changing our working directory, so it can be reloaded
+	configPath, err = filepath.Abs(configPath)
 	if err != nil {
 		cmdLogger.Error("Failed to run the fuzz command", err)
 		return err
@@ -164,6 +123,16 @@ func cmdRunFuzz(cmd *cobra.Command, args []string) error {
 		fuzzer.Terminate()
 	}()
 
+	// Pause or resume metrics as the project configuration file specifies upon hangups
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	defer signal.Stop(reload)
+	go func() {
+		for range reload {
+			reloadMetricConfig(fuzzer, configPath)
+		}
+	}()
+
 	// Start the fuzzing process with our cancellable context.
 	fuzzErr = fuzzer.Start()
 	if fuzzErr != nil {
@@ -177,3 +146,75 @@ func cmdRunFuzz(cmd *cobra.Command, args []string) error {
 
 	return fuzzErr
 }
+
+// reloadMetricConfig re-reads the project configuration file at the provided path, pausing or resuming the metrics of
+// the provided running fuzzer accordingly (see fuzzing.Fuzzer.ReloadMetricConfig). Errors are logged rather than
+// returned, so a malformed configuration file does not interrupt the campaign.
+func reloadMetricConfig(fuzzer *fuzzing.Fuzzer, configPath string) {
+	cmdLogger.Info("Reloading the metric configuration from: ", colors.Bold, configPath, colors.Reset)
+	projectConfig, err := config.ReadProjectConfigFromFile(configPath, DefaultCompilationPlatform)
+	if err != nil {
+		cmdLogger.Error("Failed to reload the metric configuration", err)
+		return
+	}
+	fuzzer.ReloadMetricConfig(&projectConfig.Fuzzing)
+}
+
+// readProjectConfig reads the project configuration for a command with a --config flag, navigating through the
+// following possibilities:
+// #1: We will search for either a custom config file (via --config) or the default (medusa.json).
+// If we find it, read it. If we can't read it, throw an error.
+// #2: If a custom file was provided (--config was used), and we can't find the file, throw an error.
+// #3: If medusa.json can't be found, use the default project configuration.
+// Returns the project configuration and the path of the config file it was (or would have been) read from, or an
+// error if one occurred.
+func readProjectConfig(cmd *cobra.Command) (*config.ProjectConfig, string, error) {
+	var projectConfig *config.ProjectConfig
+
+	// Check to see if --config flag was used and store the value of --config flag
+	configFlagUsed := cmd.Flags().Changed("config")
+	configPath, err := cmd.Flags().GetString("config")
+	if err != nil {
+		return nil, "", err
+	}
+
+	// If --config was not used, look for `medusa.json` in the current work directory
+	if !configFlagUsed {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return nil, "", err
+		}
+		configPath = filepath.Join(workingDirectory, DefaultProjectConfigFilename)
+	}
+
+	// Check to see if the file exists at configPath
+	_, existenceError := os.Stat(configPath)
+
+	// Possibility #1: File was found
+	if existenceError == nil {
+		// Try to read the configuration file and throw an error if something goes wrong
+		cmdLogger.Info("Reading the configuration file at: ", colors.Bold, configPath, colors.Reset)
+		// Use the default compilation platform if the config file doesn't specify one
+		projectConfig, err = config.ReadProjectConfigFromFile(configPath, DefaultCompilationPlatform)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	// Possibility #2: If the --config flag was used, and we couldn't find the file, we'll throw an error
+	if configFlagUsed && existenceError != nil {
+		return nil, "", existenceError
+	}
+
+	// Possibility #3: --config flag was not used and medusa.json was not found, so use the default project config
+	if !configFlagUsed && existenceError != nil {
+		cmdLogger.Warn(fmt.Sprintf("Unable to find the config file at %v, will use the default project configuration for the "+
+			"%v compilation platform instead", configPath, DefaultCompilationPlatform))
+
+		projectConfig, err = config.GetDefaultProjectConfig(DefaultCompilationPlatform)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	return projectConfig, configPath, nil
+}
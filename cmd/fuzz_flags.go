@@ -34,6 +34,10 @@ func addFuzzFlags() error {
 	fuzzCmd.Flags().Int("timeout", 0,
 		fmt.Sprintf("number of seconds to run the fuzzer campaign for (unless a config file is provided, default is %d). 0 means that timeout is not enforced", defaultConfig.Fuzzing.Timeout))
 
+	// Seed
+	fuzzCmd.Flags().Int64("seed", 0,
+		fmt.Sprintf("seed of the random provider used for the fuzzer campaign (unless a config file is provided, default is %d). 0 means that a seed is derived from the current time", defaultConfig.Fuzzing.Seed))
+
 	// Test limit
 	fuzzCmd.Flags().Uint64("test-limit", 0,
 		fmt.Sprintf("number of transactions to test before exiting (unless a config file is provided, default is %d). 0 means that test limit is not enforced", defaultConfig.Fuzzing.TestLimit))
@@ -79,6 +83,22 @@ func addFuzzFlags() error {
 	// RPC block
 	fuzzCmd.Flags().Uint64("rpc-block", 0, "block number to use when fetching contracts over RPC")
 
+	// Dataflow graph export formats
+	fuzzCmd.Flags().StringSlice("dataflow-export", []string{},
+		"formats (dot, json) to export the dataflow graph in at the end of the campaign")
+
+	// Distributed fuzzing coordinator
+	fuzzCmd.Flags().String("coordinator", "", "address (host:port) of the coordinator to share corpus call sequences and coverage with")
+
+	// Serve the distributed fuzzing coordinator
+	fuzzCmd.Flags().Bool("serve-coordinator", false, "host the coordinator at the --coordinator address for other fuzzer instances")
+
+	// Block explorer API URL
+	fuzzCmd.Flags().String("explorer-api-url", "", "URL of the Etherscan-compatible API to fetch the verified ABIs of on-chain targets from")
+
+	// Block explorer API key
+	fuzzCmd.Flags().String("explorer-api-key", "", "key of the block explorer API used to fetch the verified ABIs of on-chain targets")
+
 	// Verbosity levels (-v, -vv, -vvv)
 	fuzzCmd.Flags().CountP("verbosity", "v", "set execution trace verbosity levels: -v (top-level calls only), -vv (detailed, default), -vvv (trace all call sequence elements)")
 
@@ -120,6 +140,14 @@ func updateProjectConfigWithFuzzFlags(cmd *cobra.Command, projectConfig *config.
 		}
 	}
 
+	// Update seed
+	if cmd.Flags().Changed("seed") {
+		projectConfig.Fuzzing.Seed, err = cmd.Flags().GetInt64("seed")
+		if err != nil {
+			return err
+		}
+	}
+
 	// Update test limit
 	if cmd.Flags().Changed("test-limit") {
 		projectConfig.Fuzzing.TestLimit, err = cmd.Flags().GetUint64("test-limit")
@@ -239,6 +267,49 @@ func updateProjectConfigWithFuzzFlags(cmd *cobra.Command, projectConfig *config.
 		}
 	}
 
+	// Update the dataflow graph export formats
+	if cmd.Flags().Changed("dataflow-export") {
+		projectConfig.Fuzzing.Dataflow.ExportFormats, err = cmd.Flags().GetStringSlice("dataflow-export")
+		if err != nil {
+			return err
+		}
+	}
+
+	// Update the distributed fuzzing coordinator
+	if cmd.Flags().Changed("coordinator") {
+		projectConfig.Fuzzing.Distributed.Address, err = cmd.Flags().GetString("coordinator")
+		if err != nil {
+			return err
+		}
+		projectConfig.Fuzzing.Distributed.Enabled = true
+	}
+
+	// Update whether to serve the distributed fuzzing coordinator
+	if cmd.Flags().Changed("serve-coordinator") {
+		projectConfig.Fuzzing.Distributed.ServeCoordinator, err = cmd.Flags().GetBool("serve-coordinator")
+		if err != nil {
+			return err
+		}
+		projectConfig.Fuzzing.Distributed.Enabled = projectConfig.Fuzzing.Distributed.Enabled || projectConfig.Fuzzing.Distributed.ServeCoordinator
+	}
+
+	// Update the block explorer API URL, which enables fetching verified ABIs from it
+	if cmd.Flags().Changed("explorer-api-url") {
+		projectConfig.Fuzzing.Explorer.ApiUrl, err = cmd.Flags().GetString("explorer-api-url")
+		if err != nil {
+			return err
+		}
+		projectConfig.Fuzzing.Explorer.Enabled = true
+	}
+
+	// Update the block explorer API key
+	if cmd.Flags().Changed("explorer-api-key") {
+		projectConfig.Fuzzing.Explorer.ApiKey, err = cmd.Flags().GetString("explorer-api-key")
+		if err != nil {
+			return err
+		}
+	}
+
 	// Update the verbosity levels
 	if cmd.Flags().Changed("verbosity") || cmd.Flags().Changed("v") {
 		verbosityCount, err := cmd.Flags().GetCount("verbosity")
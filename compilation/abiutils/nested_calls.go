@@ -0,0 +1,98 @@
+package abiutils
+
+import (
+	"reflect"
+
+	"github.com/crytic/medusa-geth/accounts/abi"
+)
+
+// maxNestedCallDepth describes the maximum depth to which nested calls are decoded.
+const maxNestedCallDepth = 4
+
+// NestedCall describes a call encoded within the arguments of another call (e.g. the calls provided to
+// multicall(bytes[])) which was decoded against a contract ABI.
+type NestedCall struct {
+	// Method is the method targeted by the nested call.
+	Method *abi.Method
+	// Args are the decoded input values of the nested call.
+	Args []any
+	// NestedCalls are the calls which were in turn decoded from the arguments of this nested call.
+	NestedCalls []*NestedCall
+}
+
+// UnpackNestedCalls decodes every bytes value in the provided arguments (including those within arrays, slices and
+// tuples) which holds call data for a method of the provided contract ABI. This allows multicall and router style
+// entry points to be described by the inner calls they dispatch.
+// Returns the decoded nested calls, in the order in which they occur in the arguments.
+func UnpackNestedCalls(contractAbi *abi.ABI, inputs abi.Arguments, values []any) []*NestedCall {
+	return unpackNestedCalls(contractAbi, inputs, values, 0)
+}
+
+// unpackNestedCalls decodes nested calls from the provided arguments at the given depth.
+func unpackNestedCalls(contractAbi *abi.ABI, inputs abi.Arguments, values []any, depth int) []*NestedCall {
+	if contractAbi == nil || depth >= maxNestedCallDepth || len(inputs) != len(values) {
+		return nil
+	}
+
+	var nestedCalls []*NestedCall
+	for i := 0; i < len(inputs); i++ {
+		nestedCalls = append(nestedCalls, unpackNestedCallsFromValue(contractAbi, &inputs[i].Type, reflect.ValueOf(values[i]), depth)...)
+	}
+	return nestedCalls
+}
+
+// unpackNestedCallsFromValue decodes nested calls from a single value of the provided type at the given depth.
+func unpackNestedCallsFromValue(contractAbi *abi.ABI, inputType *abi.Type, value reflect.Value, depth int) []*NestedCall {
+	if !value.IsValid() {
+		return nil
+	}
+
+	switch inputType.T {
+	case abi.BytesTy:
+		if data, ok := value.Interface().([]byte); ok {
+			if nestedCall := unpackNestedCall(contractAbi, data, depth); nestedCall != nil {
+				return []*NestedCall{nestedCall}
+			}
+		}
+	case abi.SliceTy, abi.ArrayTy:
+		if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+			return nil
+		}
+		var nestedCalls []*NestedCall
+		for i := 0; i < value.Len(); i++ {
+			nestedCalls = append(nestedCalls, unpackNestedCallsFromValue(contractAbi, inputType.Elem, value.Index(i), depth)...)
+		}
+		return nestedCalls
+	case abi.TupleTy:
+		if value.Kind() != reflect.Struct || value.NumField() != len(inputType.TupleElems) {
+			return nil
+		}
+		var nestedCalls []*NestedCall
+		for i, elemType := range inputType.TupleElems {
+			nestedCalls = append(nestedCalls, unpackNestedCallsFromValue(contractAbi, elemType, value.Field(i), depth)...)
+		}
+		return nestedCalls
+	}
+	return nil
+}
+
+// unpackNestedCall decodes the provided call data against the contract ABI at the given depth.
+// Returns the decoded call, or nil if the data does not hold a valid call to a method of the contract ABI.
+func unpackNestedCall(contractAbi *abi.ABI, data []byte, depth int) *NestedCall {
+	if len(data) < 4 {
+		return nil
+	}
+	method, err := contractAbi.MethodById(data[:4])
+	if err != nil {
+		return nil
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil
+	}
+	return &NestedCall{
+		Method:      method,
+		Args:        args,
+		NestedCalls: unpackNestedCalls(contractAbi, method.Inputs, args, depth+1),
+	}
+}
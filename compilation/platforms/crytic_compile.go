@@ -269,6 +269,18 @@ func (c *CryticCompilationConfig) Compile() ([]types.Compilation, string, error)
 				}
 			}
 
+			// Vyper sources have no Solidity AST to resolve the contract kind from, so it is derived from the source
+			// file extension instead: .vyi sources declare interfaces, while .vy sources declare contracts.
+			contractKind, ok := contractKinds[contractName]
+			if !ok {
+				switch filepath.Ext(sourcePath) {
+				case ".vy":
+					contractKind = types.ContractKindContract
+				case ".vyi":
+					contractKind = types.ContractKindInterface
+				}
+			}
+
 			// Add contract details
 			compilation.SourcePathToArtifact[sourcePath].Contracts[contractName] = types.CompiledContract{
 				Abi:                 *contractAbi,
@@ -276,7 +288,7 @@ func (c *CryticCompilationConfig) Compile() ([]types.Compilation, string, error)
 				RuntimeBytecode:     runtimeBytecode,
 				SrcMapsInit:         contract.SrcMap,
 				SrcMapsRuntime:      contract.SrcMapRuntime,
-				Kind:                contractKinds[contractName],
+				Kind:                contractKind,
 				LibraryPlaceholders: types.ParseBytecodeForPlaceholders(contract.Bin),
 			}
 		}
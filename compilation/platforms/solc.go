@@ -74,8 +74,8 @@ func (s *SolcCompilationConfig) SetSolcOutputOptions(v *semver.Version) string {
 		// Both 'hashes' and 'compact-format' are allowed as outputOptions
 		return "abi,ast,bin,bin-runtime,srcmap,srcmap-runtime,userdoc,devdoc,hashes,compact-format"
 	} else {
-		// Can't use 'compact-format', but 'hashes' is allowed as outputOption
-		return "abi,ast,bin,bin-runtime,srcmap,srcmap-runtime,userdoc,devdoc,hashes"
+		// Can't use 'compact-format', but 'hashes' and 'storage-layout' are allowed as outputOptions
+		return "abi,ast,bin,bin-runtime,srcmap,srcmap-runtime,userdoc,devdoc,hashes,storage-layout"
 	}
 }
 func (s *SolcCompilationConfig) Compile() ([]types.Compilation, string, error) {
@@ -159,6 +159,24 @@ func (s *SolcCompilationConfig) Compile() ([]types.Compilation, string, error) {
 		}
 	}
 
+	// Parse the storage layouts of our contracts, which are only output by newer versions of solc
+	storageLayouts := make(map[string]*types.StorageLayout)
+	var combinedStorageLayouts struct {
+		Contracts map[string]struct {
+			StorageLayout json.RawMessage `json:"storage-layout"`
+		} `json:"contracts"`
+	}
+	if err = json.Unmarshal(cmdStdout, &combinedStorageLayouts); err == nil {
+		for name, contract := range combinedStorageLayouts.Contracts {
+			if len(contract.StorageLayout) == 0 {
+				continue
+			}
+			if storageLayout, err := types.ParseStorageLayout(contract.StorageLayout); err == nil {
+				storageLayouts[name] = storageLayout
+			}
+		}
+	}
+
 	// Parse our contracts from solc output
 	contracts, err := compiler.ParseCombinedJSON(cmdStdout, "solc", v.String(), v.String(), "")
 	if err != nil {
@@ -201,6 +219,7 @@ func (s *SolcCompilationConfig) Compile() ([]types.Compilation, string, error) {
 			SrcMapsRuntime:      contract.Info.SrcMapRuntime,
 			Kind:                contractKinds[contractName],
 			LibraryPlaceholders: libraryPlaceholders,
+			StorageLayout:       storageLayouts[name],
 		}
 	}
 
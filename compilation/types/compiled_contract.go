@@ -40,6 +40,9 @@ type CompiledContract struct {
 	// Format is map[placeholder]libraryName
 	// When a contract has placeholders, these need to be resolved before deployment
 	LibraryPlaceholders map[string]any
+
+	// StorageLayout describes the layout of the contract's state variables in storage, or nil if it is unknown.
+	StorageLayout *StorageLayout
 }
 
 // IsMatch returns a boolean indicating whether provided contract bytecode is a match to this compiled contract
@@ -7,7 +7,8 @@ import (
 )
 
 // ContractMetadata is a CBOR-encoded structure describing contract information which is embedded within smart contract
-// bytecode by the Solidity compiler (unless explicitly directed not to).
+// bytecode by the Solidity compiler (unless explicitly directed not to). For bytecode compiled by Vyper, it describes
+// the compiler version of its VyperMetadata trailer under the "vyper" key.
 // Reference: https://docs.soliditylang.org/en/v0.8.16/metadata.html
 type ContractMetadata map[string]any
 
@@ -44,6 +45,11 @@ func ExtractContractMetadata(bytecode []byte) *ContractMetadata {
 			return &metadata
 		}
 	}
+
+	// Otherwise, try extracting a Vyper metadata trailer, which does not embed a bytecode hash.
+	if vyperMetadata := ExtractVyperMetadata(bytecode); vyperMetadata != nil {
+		return &ContractMetadata{"vyper": vyperMetadata.Version}
+	}
 	return nil
 }
 
@@ -60,6 +66,9 @@ func RemoveContractMetadata(bytecode []byte) []byte {
 			return bytecode[:metadataOffset-1]
 		}
 	}
+	if _, metadataOffset := findVyperMetadata(bytecode); metadataOffset != -1 {
+		return bytecode[:metadataOffset]
+	}
 	return bytecode
 }
 
@@ -0,0 +1,73 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// StorageLayout describes the layout of a contract's state variables in storage, in the format output by solc.
+type StorageLayout struct {
+	// Storage describes the state variables of the contract, in declaration order.
+	Storage []StorageLayoutVariable `json:"storage"`
+
+	// Types maps the identifiers of the types referenced by Storage to their definitions.
+	Types map[string]StorageLayoutType `json:"types"`
+}
+
+// StorageLayoutVariable describes a state variable, or a member of a struct, in a StorageLayout.
+type StorageLayoutVariable struct {
+	// Label describes the name of the variable.
+	Label string `json:"label"`
+
+	// Contract describes the fully qualified name of the contract which declares the variable.
+	Contract string `json:"contract"`
+
+	// Slot describes the storage slot the variable starts at, as a decimal string. For struct members, it is relative
+	// to the slot of the struct.
+	Slot string `json:"slot"`
+
+	// Offset describes the offset, in bytes, of the variable within its slot.
+	Offset uint64 `json:"offset"`
+
+	// Type describes the identifier of the variable's type, a key of StorageLayout.Types.
+	Type string `json:"type"`
+}
+
+// StorageLayoutType describes a type referenced by a StorageLayout.
+type StorageLayoutType struct {
+	// Encoding describes how values of the type are stored: "inplace", "mapping", "dynamic_array" or "bytes".
+	Encoding string `json:"encoding"`
+
+	// Label describes the canonical name of the type (e.g. uint256, mapping(address => uint256)).
+	Label string `json:"label"`
+
+	// NumberOfBytes describes the amount of bytes values of the type use, as a decimal string. Dynamic types use 32.
+	NumberOfBytes string `json:"numberOfBytes"`
+
+	// Base describes the identifier of the element type, for arrays.
+	Base string `json:"base,omitempty"`
+
+	// Key describes the identifier of the key type, for mappings.
+	Key string `json:"key,omitempty"`
+
+	// Value describes the identifier of the value type, for mappings.
+	Value string `json:"value,omitempty"`
+
+	// Members describes the members of the type, for structs.
+	Members []StorageLayoutVariable `json:"members,omitempty"`
+}
+
+// ParseStorageLayout parses a storage layout, provided either as a JSON object or as a string containing one, as older
+// versions of solc output it in combined JSON.
+// Returns the storage layout, or an error if it could not be parsed.
+func ParseStorageLayout(data []byte) (*StorageLayout, error) {
+	var encoded string
+	if err := json.Unmarshal(data, &encoded); err == nil {
+		data = []byte(encoded)
+	}
+	var layout StorageLayout
+	if err := json.Unmarshal(data, &layout); err != nil {
+		return nil, fmt.Errorf("could not parse storage layout: %v", err)
+	}
+	return &layout, nil
+}
@@ -0,0 +1,183 @@
+package types
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/fxamacker/cbor"
+)
+
+// vyperMetadataPrefix is the CBOR encoding of the start of the {"vyper": [major, minor, patch]} map which every Vyper
+// metadata trailer contains: a1 65 "vyper".
+var vyperMetadataPrefix = []byte{0xa1, 0x65, 'v', 'y', 'p', 'e', 'r'}
+
+// VyperMetadata describes the CBOR-encoded metadata trailer which the Vyper compiler (>= 0.3.4) appends to bytecode,
+// followed by its two-byte length. Unlike Solidity's, it does not embed a hash of the bytecode. Up to Vyper 0.3.9, it
+// is appended to the runtime bytecode and only describes the compiler version. From Vyper 0.3.10, it is appended to
+// the init bytecode instead, and also describes the layout of the runtime bytecode it deploys.
+// Reference: https://docs.vyperlang.org/en/stable/compiling-a-contract.html
+type VyperMetadata struct {
+	// Version describes the version of the Vyper compiler, as its major, minor and patch numbers.
+	Version []uint64
+
+	// RuntimeSize describes the size of the runtime bytecode deployed, including its data sections, or zero if the
+	// trailer does not describe it.
+	RuntimeSize uint64
+
+	// DataSectionLengths describes the lengths of the data sections appended to the runtime code, such as the jump
+	// tables of the selector dispatcher.
+	DataSectionLengths []uint64
+
+	// ImmutablesSize describes the size of the immutable values appended to the runtime bytecode upon deployment.
+	ImmutablesSize uint64
+}
+
+// ExtractVyperMetadata extracts the Vyper metadata trailer from the provided bytecode, which may be followed by
+// constructor arguments. If no trailer could be extracted, nil is returned.
+func ExtractVyperMetadata(bytecode []byte) *VyperMetadata {
+	metadata, _ := findVyperMetadata(bytecode)
+	return metadata
+}
+
+// findVyperMetadata locates the Vyper metadata trailer within the provided bytecode, which may be followed by
+// constructor arguments.
+// Returns the decoded metadata and the offset the trailer starts at, or nil and -1 if no trailer was found.
+func findVyperMetadata(bytecode []byte) (*VyperMetadata, int) {
+	// Every trailer ends with the version map, so we locate it first. The version array follows its key.
+	keyOffset := bytes.LastIndex(bytecode, vyperMetadataPrefix)
+	if keyOffset == -1 {
+		return nil, -1
+	}
+	lengthOffset := skipCBORUintArray(bytecode, keyOffset+len(vyperMetadataPrefix))
+	if lengthOffset == -1 || lengthOffset+2 > len(bytecode) {
+		return nil, -1
+	}
+
+	// The two bytes following the CBOR data describe the length of the trailer. Some compiler versions count these
+	// two bytes in the length, while others do not, so we try both.
+	length := int(binary.BigEndian.Uint16(bytecode[lengthOffset:]))
+	for _, start := range []int{lengthOffset + 2 - length, lengthOffset - length} {
+		if start < 0 || start > keyOffset {
+			continue
+		}
+		if metadata := decodeVyperMetadata(bytecode[start:lengthOffset], start == keyOffset); metadata != nil {
+			return metadata, start
+		}
+	}
+	return nil, -1
+}
+
+// decodeVyperMetadata decodes a Vyper metadata trailer without its length. The trailer is either the version map
+// alone, or an array ending with the runtime size, the data section lengths, the immutables size and the version map.
+// Returns the decoded metadata, or nil if it could not be decoded.
+func decodeVyperMetadata(data []byte, versionOnly bool) *VyperMetadata {
+	if versionOnly {
+		var versionMap map[string]any
+		if err := cbor.Unmarshal(data, &versionMap); err != nil {
+			return nil
+		}
+		version, ok := cborUintSlice(versionMap["vyper"])
+		if !ok {
+			return nil
+		}
+		return &VyperMetadata{Version: version}
+	}
+
+	var entries []any
+	if err := cbor.Unmarshal(data, &entries); err != nil || len(entries) < 4 {
+		return nil
+	}
+	versionMap, ok := entries[len(entries)-1].(map[any]any)
+	if !ok {
+		return nil
+	}
+	version, ok := cborUintSlice(versionMap["vyper"])
+	if !ok {
+		return nil
+	}
+	runtimeSize, ok := entries[len(entries)-4].(uint64)
+	if !ok {
+		return nil
+	}
+	dataSectionLengths, ok := cborUintSlice(entries[len(entries)-3])
+	if !ok {
+		return nil
+	}
+	immutablesSize, ok := entries[len(entries)-2].(uint64)
+	if !ok {
+		return nil
+	}
+	return &VyperMetadata{
+		Version:            version,
+		RuntimeSize:        runtimeSize,
+		DataSectionLengths: dataSectionLengths,
+		ImmutablesSize:     immutablesSize,
+	}
+}
+
+// cborUintSlice converts a decoded CBOR array of unsigned integers into a slice.
+// Returns the slice, and a boolean indicating whether the value was such an array.
+func cborUintSlice(value any) ([]uint64, bool) {
+	items, ok := value.([]any)
+	if !ok {
+		return nil, false
+	}
+	values := make([]uint64, len(items))
+	for i, item := range items {
+		if values[i], ok = item.(uint64); !ok {
+			return nil, false
+		}
+	}
+	return values, true
+}
+
+// skipCBORUintArray skips over the CBOR array of unsigned integers which starts at the provided offset of the
+// provided data, such as a Vyper version array.
+// Returns the offset following the array, or -1 if no such array starts at the offset.
+func skipCBORUintArray(data []byte, offset int) int {
+	// An array header (major type 4) with fewer than 24 items encodes its length within the header byte.
+	if offset >= len(data) || data[offset]>>5 != 4 || data[offset]&0x1f >= 24 {
+		return -1
+	}
+	count := int(data[offset] & 0x1f)
+	offset++
+	for i := 0; i < count; i++ {
+		// Unsigned integers (major type 0) encode values below 24 within the header byte, and larger ones in the 1,
+		// 2, 4 or 8 bytes which follow it.
+		if offset >= len(data) || data[offset]>>5 != 0 {
+			return -1
+		}
+		switch info := data[offset] & 0x1f; {
+		case info < 24:
+			offset++
+		case info <= 27:
+			offset += 1 + 1<<(info-24)
+		default:
+			return -1
+		}
+	}
+	return offset
+}
+
+// SplitRuntimeBytecode splits the provided runtime bytecode, deployed by the provided init bytecode, into its
+// executable code and the data sections which follow it, after removing any contract metadata. Vyper (>= 0.3.10)
+// appends data sections to the runtime code, such as the jump tables of its selector dispatcher, whose lengths are
+// described by the metadata trailer of the init bytecode. For other bytecode, the data sections are empty.
+// Returns the executable code and the data sections.
+func SplitRuntimeBytecode(initBytecode []byte, runtimeBytecode []byte) ([]byte, []byte) {
+	code := RemoveContractMetadata(runtimeBytecode)
+	metadata := ExtractVyperMetadata(initBytecode)
+	if metadata == nil || metadata.RuntimeSize == 0 || metadata.RuntimeSize > uint64(len(code)) {
+		return code, nil
+	}
+
+	dataSize := uint64(0)
+	for _, length := range metadata.DataSectionLengths {
+		dataSize += length
+	}
+	if dataSize > metadata.RuntimeSize {
+		return code, nil
+	}
+	codeSize := metadata.RuntimeSize - dataSize
+	return code[:codeSize], code[codeSize:metadata.RuntimeSize]
+}
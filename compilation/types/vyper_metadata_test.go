@@ -0,0 +1,44 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestVyperMetadata tests that the Vyper metadata trailers appended to runtime bytecode (Vyper < 0.3.10) and to init
+// bytecode (Vyper >= 0.3.10) are extracted and removed, and that runtime bytecode is split into code and data sections.
+func TestVyperMetadata(t *testing.T) {
+	versionMap := []byte{0xa1, 0x65, 'v', 'y', 'p', 'e', 'r', 0x83, 0x00, 0x03, 0x09}
+
+	// Up to Vyper 0.3.9, the version map is appended to the runtime bytecode, followed by its length.
+	code := []byte{0x60, 0x00, 0x56, 0x5b, 0x00}
+	runtimeBytecode := append(append(append([]byte{}, code...), versionMap...), 0x00, byte(len(versionMap)))
+	metadata := ExtractContractMetadata(runtimeBytecode)
+	assert.NotNil(t, metadata)
+	assert.Equal(t, []uint64{0, 3, 9}, (*metadata)["vyper"])
+	assert.Nil(t, metadata.ExtractBytecodeHash())
+	assert.Equal(t, code, RemoveContractMetadata(runtimeBytecode))
+
+	// From Vyper 0.3.10, the trailer is appended to the init bytecode, followed by constructor arguments once
+	// deployed, and describes the runtime size and the data sections appended to the runtime code.
+	data := []byte{0x00, 0x03}
+	runtimeBytecode = append(append([]byte{}, code...), data...)
+	trailer := []byte{0x84, 0x07, 0x81, 0x02, 0x00, 0xa1, 0x65, 'v', 'y', 'p', 'e', 'r', 0x83, 0x00, 0x03, 0x0a}
+	initBytecode := append(append([]byte{0x61, 0x00, 0x07, 0xf3}, runtimeBytecode...), trailer...)
+	initBytecode = append(initBytecode, 0x00, byte(len(trailer)+2))
+	deployedInitBytecode := append(append([]byte{}, initBytecode...), 0x2a, 0x2a)
+
+	vyperMetadata := ExtractVyperMetadata(deployedInitBytecode)
+	assert.Equal(t, &VyperMetadata{Version: []uint64{0, 3, 10}, RuntimeSize: 7, DataSectionLengths: []uint64{2}}, vyperMetadata)
+	assert.Equal(t, initBytecode[:len(initBytecode)-len(trailer)-2], RemoveContractMetadata(deployedInitBytecode))
+
+	splitCode, splitData := SplitRuntimeBytecode(initBytecode, runtimeBytecode)
+	assert.Equal(t, code, splitCode)
+	assert.Equal(t, data, splitData)
+
+	// Solidity bytecode has no data sections.
+	splitCode, splitData = SplitRuntimeBytecode([]byte{0x60, 0x00}, code)
+	assert.Equal(t, code, splitCode)
+	assert.Nil(t, splitData)
+}
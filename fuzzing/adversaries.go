@@ -0,0 +1,118 @@
+package fuzzing
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/chain"
+	fuzzerTypes "github.com/crytic/medusa/fuzzing/contracts"
+	"github.com/crytic/medusa/fuzzing/executiontracer"
+	"github.com/crytic/medusa/fuzzing/valuegeneration"
+	"golang.org/x/exp/slices"
+)
+
+// adversaries describes the addresses controlled by an attacker on a base test chain, which bug detectors treat as
+// adversarial, and the ether they held once the chain was set up.
+type adversaries struct {
+	// addresses describes the distinct adversarial addresses: the senders, the attacker accounts, the helper contract
+	// if deployed, and the attacker contracts.
+	addresses []common.Address
+
+	// originalEther describes the combined ether balance of addresses once the chain was set up. Ether leaking is
+	// detected when their combined balance exceeds it.
+	originalEther *big.Int
+}
+
+// newAdversaries creates the adversaries of a base test chain, given the attacker contracts deployed on it. Their
+// original ether must be recorded with recordOriginalEther once the chain is set up.
+func (f *Fuzzer) newAdversaries(adversarialContracts []common.Address) *adversaries {
+	candidates := append(append([]common.Address{}, f.senders...), f.attackers...)
+	if FuzzHelperContractAddress != (common.Address{}) {
+		candidates = append(candidates, FuzzHelperContractAddress)
+	}
+	candidates = append(candidates, adversarialContracts...)
+
+	addresses := make([]common.Address, 0, len(candidates))
+	for _, address := range candidates {
+		if !slices.Contains(addresses, address) {
+			addresses = append(addresses, address)
+		}
+	}
+	return &adversaries{
+		addresses:     addresses,
+		originalEther: big.NewInt(0),
+	}
+}
+
+// recordOriginalEther records the combined ether balance of the adversarial addresses in the provided test chain's
+// current state.
+func (a *adversaries) recordOriginalEther(testChain *chain.TestChain) {
+	originalEther := big.NewInt(0)
+	for _, address := range a.addresses {
+		originalEther.Add(originalEther, testChain.State().GetBalance(address).ToBig())
+	}
+	a.originalEther = originalEther
+}
+
+// deployAdversarialContracts deploys the attacker contracts of the project configuration to the provided test chain,
+// from the first attacker account, or the deployer if there is none. Constructor arguments are resolved as those of
+// the target contracts, and may reference the contracts deployed when the chain was set up by name.
+// Returns the addresses of the attacker contracts, or an error if one could not be deployed, along with the execution
+// trace of the failed deployment, if available.
+func (f *Fuzzer) deployAdversarialContracts(testChain *chain.TestChain) ([]common.Address, *executiontracer.ExecutionTrace, error) {
+	contractNames := f.config.Fuzzing.Adversaries.Contracts
+	if len(contractNames) == 0 {
+		return nil, nil, nil
+	}
+	sender := f.deployer
+	if len(f.attackers) > 0 {
+		sender = f.attackers[0]
+	}
+	deployedContracts := make(map[string]common.Address, len(f.deployedContractAddresses)+len(contractNames))
+	for name, address := range f.deployedContractAddresses {
+		deployedContracts[name] = address
+	}
+
+	addresses := make([]common.Address, 0, len(contractNames))
+	for _, contractName := range contractNames {
+		var contract *fuzzerTypes.Contract
+		for _, definition := range f.contractDefinitions {
+			if definition.Name() == contractName {
+				contract = definition
+				break
+			}
+		}
+		if contract == nil {
+			return nil, nil, fmt.Errorf("attacker contract %v was not found in the compilation artifacts", contractName)
+		}
+		testChain.CompiledContracts[contractName] = contract.CompiledContract()
+
+		args := make([]any, 0)
+		if len(contract.CompiledContract().Abi.Constructor.Inputs) > 0 {
+			jsonArgs, ok := f.config.Fuzzing.ConstructorArgs[contractName]
+			if !ok {
+				return nil, nil, fmt.Errorf("constructor arguments for attacker contract %s not provided", contractName)
+			}
+			decoded, err := valuegeneration.DecodeJSONArgumentsFromMap(contract.CompiledContract().Abi.Constructor.Inputs, jsonArgs, deployedContracts)
+			if err != nil {
+				return nil, nil, err
+			}
+			args = decoded
+		}
+
+		result, err := f.deployContract(testChain, sender, contract, args, big.NewInt(0), deployedContracts)
+		if err != nil {
+			if trace, ok := result.(*executiontracer.ExecutionTrace); ok {
+				return nil, trace, err
+			}
+			return nil, nil, err
+		}
+		address := result.(common.Address)
+		deployedContracts[contractName] = address
+		addresses = append(addresses, address)
+		f.baseValueSet.AddAddress(address)
+		f.logger.Info("Deployed attacker contract ", contractName, " at address ", address.Hex())
+	}
+	return addresses, nil, nil
+}
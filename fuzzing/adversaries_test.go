@@ -0,0 +1,38 @@
+package fuzzing
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa/chain"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAdversaries ensures the adversarial addresses comprise the senders, attacker accounts and attacker contracts
+// once each, and that their original ether is their combined balance on the test chain.
+func TestAdversaries(t *testing.T) {
+	sender := common.HexToAddress("0x10000")
+	attacker := common.HexToAddress("0x40000")
+	attackerContract := common.HexToAddress("0xA647ff3c36cFab592509E13860ab8c4F28781a66")
+	f := &Fuzzer{
+		senders:   []common.Address{sender, attacker},
+		attackers: []common.Address{attacker},
+	}
+
+	adversaries := f.newAdversaries([]common.Address{attackerContract})
+	assert.EqualValues(t, []common.Address{sender, attacker, attackerContract}, adversaries.addresses)
+
+	// Addresses not controlled by an attacker, such as the deployer, should not be counted.
+	genesisAlloc := types.GenesisAlloc{
+		sender:                         types.Account{Balance: big.NewInt(100)},
+		attacker:                       types.Account{Balance: big.NewInt(20)},
+		common.HexToAddress("0x30000"): types.Account{Balance: big.NewInt(1000)},
+	}
+	testChain, err := chain.NewTestChain(context.Background(), genesisAlloc, nil)
+	assert.NoError(t, err)
+	adversaries.recordOriginalEther(testChain)
+	assert.EqualValues(t, big.NewInt(120), adversaries.originalEther)
+}
@@ -8,6 +8,21 @@ import (
 
 const BLOCK_DEPENDENCY_ID = "BLOCK_DEPENDENCY"
 
+// BLOCK_TIMESTAMP_ID and BLOCK_NUMBER_ID additionally taint the block timestamp and number, so branch conditions
+// depending on them can be fed back to the fuzzer to warp block time and number.
+const BLOCK_TIMESTAMP_ID = "BLOCK_TIMESTAMP"
+const BLOCK_NUMBER_ID = "BLOCK_NUMBER"
+
+// BlockDependency describes the block properties branch conditions were observed to depend on, as flags.
+type BlockDependency byte
+
+const (
+	// TimestampDependency describes a branch condition depending on the block timestamp.
+	TimestampDependency BlockDependency = 1 << iota
+	// NumberDependency describes a branch condition depending on the block number.
+	NumberDependency
+)
+
 func isBlockDependencyTaintSource(opcode byte) bool {
 	return opcode == 0x42 || // BLOCKHASH
 		opcode == 0x43 || // COINBASE
@@ -60,9 +75,37 @@ func detect_block_dependency(tracer *BugDetectorTracer, pc uint64, opcode byte)
 
 	if isBlockDependencyTaintSource(opcode) {
 		lastCall.taintAnalyzer.AddTaintSourceByString(BLOCK_DEPENDENCY_ID)
+		if vm.OpCode(opcode) == vm.TIMESTAMP {
+			lastCall.taintAnalyzer.AddTaintSourceByString(BLOCK_TIMESTAMP_ID)
+		} else if vm.OpCode(opcode) == vm.NUMBER {
+			lastCall.taintAnalyzer.AddTaintSourceByString(BLOCK_NUMBER_ID)
+		}
 	} else if isBlockDependencyTaintSunk(opcode, lastCall.taintAnalyzer) {
 		id := fmt.Sprintf("BLOCKDEPENDENCY-%s-%d-%s", lastCall.codeAddress, pc, vm.OpCode(opcode).String())
 		tracer.bugMap.CoverBug(id)
+		recordBlockDependency(tracer, opcode, lastCall.taintAnalyzer)
+	}
+}
+
+// recordBlockDependency records the block timestamp or number dependency of the branch condition computed by the
+// provided comparison opcode, if its operands are tainted by them.
+func recordBlockDependency(tracer *BugDetectorTracer, opcode byte, ta *TaintAnalyzer) {
+	switch vm.OpCode(opcode) {
+	case vm.LT, vm.GT, vm.SLT, vm.SGT, vm.EQ, vm.ISZERO:
+	default:
+		return
+	}
+	operands := 2
+	if vm.OpCode(opcode) == vm.ISZERO {
+		operands = 1
+	}
+	for i := 0; i < operands; i++ {
+		if ta.IsTaintedByString(BLOCK_TIMESTAMP_ID, i) {
+			tracer.bugMap.AddBlockDependency(TimestampDependency)
+		}
+		if ta.IsTaintedByString(BLOCK_NUMBER_ID, i) {
+			tracer.bugMap.AddBlockDependency(NumberDependency)
+		}
 	}
 
 }
@@ -0,0 +1,58 @@
+package bugdetector
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/crytic/medusa-geth/common"
+)
+
+// BugLocation describes the type and location of a bug, as decoded from its bug ID (e.g.
+// OVERFLOW-codeAddress-pc-opcode).
+type BugLocation struct {
+	// Type describes the type of the bug (e.g. OVERFLOW or REENTRANCY).
+	Type string `json:"type"`
+
+	// Address describes the address of the code the bug was detected in, or of the account ether leaked to for
+	// ETHERLEAKING bugs.
+	Address *common.Address `json:"address,omitempty"`
+
+	// Pc describes the program counter of the instruction the bug was detected at, if any.
+	Pc *uint64 `json:"pc,omitempty"`
+
+	// Opcode describes the name of the instruction the bug was detected at, if any.
+	Opcode string `json:"opcode,omitempty"`
+
+	// Slot describes the storage slot involved in the bug, for UNINITIALIZEDSTORAGEREAD bugs.
+	Slot *common.Hash `json:"slot,omitempty"`
+
+	// Property describes the function name of the property test which failed, for PROPERTYVIOLATION bugs.
+	Property string `json:"property,omitempty"`
+}
+
+// ParseBugID decodes the type and location of a bug from its bug ID. Parts of the location which the bug ID does not
+// describe are left unset.
+func ParseBugID(bugId string) BugLocation {
+	parts := strings.Split(bugId, "-")
+	location := BugLocation{Type: parts[0]}
+	if len(parts) > 1 && common.IsHexAddress(parts[1]) {
+		address := common.HexToAddress(parts[1])
+		location.Address = &address
+	}
+	if len(parts) > 2 && location.Type == PROPERTYVIOLATION_ID {
+		location.Property = parts[2]
+	} else if len(parts) > 2 {
+		if pc, err := strconv.ParseUint(parts[2], 10, 64); err == nil {
+			location.Pc = &pc
+		}
+	}
+	if len(parts) > 3 {
+		if location.Type == "UNINITIALIZEDSTORAGEREAD" {
+			slot := common.HexToHash(parts[3])
+			location.Slot = &slot
+		} else {
+			location.Opcode = parts[3]
+		}
+	}
+	return location
+}
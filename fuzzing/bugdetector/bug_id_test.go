@@ -0,0 +1,53 @@
+package bugdetector
+
+import (
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseBugID tests that the type and location of bugs are decoded from their bug IDs, leaving the parts of the
+// location which a bug ID does not describe unset.
+func TestParseBugID(t *testing.T) {
+	address := common.HexToAddress("0x1000")
+	slot := common.HexToHash("0x2")
+	pc := uint64(42)
+	tests := []struct {
+		bugId    string
+		location BugLocation
+	}{
+		{
+			bugId:    "OVERFLOW-" + address.Hex() + "-42-ADD",
+			location: BugLocation{Type: "OVERFLOW", Address: &address, Pc: &pc, Opcode: "ADD"},
+		},
+		{
+			bugId:    "REENTRANCY-" + address.Hex() + "-42",
+			location: BugLocation{Type: "REENTRANCY", Address: &address, Pc: &pc},
+		},
+		{
+			bugId:    "UNINITIALIZEDSTORAGEREAD-" + address.Hex() + "-42-" + slot.Hex(),
+			location: BugLocation{Type: "UNINITIALIZEDSTORAGEREAD", Address: &address, Pc: &pc, Slot: &slot},
+		},
+		{
+			bugId:    PropertyViolationBugID(address, "property_balance"),
+			location: BugLocation{Type: PROPERTYVIOLATION_ID, Address: &address, Property: "property_balance"},
+		},
+		{
+			bugId:    "ETHERLEAKING-" + address.Hex(),
+			location: BugLocation{Type: "ETHERLEAKING", Address: &address},
+		},
+		{
+			// Parts which are not an address or a program counter are left unset.
+			bugId:    "SUICIDAL-notanaddress-pc",
+			location: BugLocation{Type: "SUICIDAL"},
+		},
+		{
+			bugId:    "",
+			location: BugLocation{},
+		},
+	}
+	for _, test := range tests {
+		assert.EqualValues(t, test.location, ParseBugID(test.bugId), test.bugId)
+	}
+}
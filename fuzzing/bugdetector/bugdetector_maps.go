@@ -2,8 +2,12 @@ package bugdetector
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"time"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/holiman/uint256"
 )
 
 var _bugTypes = []string{
@@ -12,7 +16,14 @@ var _bugTypes = []string{
 
 type BugMap struct {
 	bugMap map[string]string
-	lock   sync.RWMutex
+	// bugValues maps a bug ID to the concrete tainted value which reached the sink, if one was recorded.
+	bugValues map[string]*uint256.Int
+	// bugSlots maps a bug ID to the storage slot written by the bug, if one was recorded.
+	bugSlots map[string]common.Hash
+	// blockDependencies describes the block properties branch conditions were observed to depend on. It is not
+	// accumulated upon updates.
+	blockDependencies BlockDependency
+	lock              sync.RWMutex
 }
 
 func (ds *BugMap) BugDetectionResult() []string {
@@ -21,8 +32,7 @@ func (ds *BugMap) BugDetectionResult() []string {
 
 	var bugs []string
 	for bug := range ds.bugMap {
-		bugString := fmt.Sprintf("%s-%s", bug, ds.bugMap[bug])
-		bugs = append(bugs, bugString)
+		bugs = append(bugs, fmt.Sprintf("%s-%s", bug, ds.bugMap[bug]))
 	}
 
 	return bugs
@@ -38,6 +48,8 @@ func NewBugMap() *BugMap {
 // Reset clears the storage-write state for the BugMap.
 func (ds *BugMap) Reset() {
 	ds.bugMap = make(map[string]string)
+	ds.bugValues = make(map[string]*uint256.Int)
+	ds.bugSlots = make(map[string]common.Hash)
 }
 
 // Update updates the current storage-write set with the provided ones.
@@ -56,6 +68,12 @@ func (ds *BugMap) Update(bugMap *BugMap) (bool, error) {
 	for bug := range bugMap.bugMap {
 		if _, exists := ds.bugMap[bug]; !exists {
 			ds.bugMap[bug] = bugMap.bugMap[bug]
+			if value, exists := bugMap.bugValues[bug]; exists {
+				ds.bugValues[bug] = value
+			}
+			if slot, exists := bugMap.bugSlots[bug]; exists {
+				ds.bugSlots[bug] = slot
+			}
 			successUpdated = true
 		}
 	}
@@ -67,13 +85,132 @@ func (ds *BugMap) CoverBug(bugId string) (bool, error) {
 	ds.lock.Lock()
 	defer ds.lock.Unlock()
 
-	_, exists := ds.bugMap[bugId]
-	if exists {
-		return false, nil
-	} else {
-		covered_time := time.Since(StartTimeForBugDetector).Round(time.Microsecond).String()
-		ds.bugMap[bugId] = covered_time
+	return ds.coverBug(bugId), nil
+}
+
+// coverBug records the bug with the provided ID, along with the time elapsed since the campaign started, if it was not
+// recorded yet. The lock must be held by the caller.
+// Returns true if the bug was not recorded before.
+func (ds *BugMap) coverBug(bugId string) bool {
+	if _, exists := ds.bugMap[bugId]; exists {
+		return false
+	}
+	ds.bugMap[bugId] = time.Since(StartTimeForBugDetector).Round(time.Microsecond).String()
+	return true
+}
+
+// CoverBugWithValue records a bug like CoverBug, additionally attaching the concrete tainted value which reached the
+// sink, apart from the bug ID. The value is only recorded the first time the bug is covered.
+func (ds *BugMap) CoverBugWithValue(bugId string, value *uint256.Int) (bool, error) {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+
+	covered := ds.coverBug(bugId)
+	if covered && value != nil {
+		ds.bugValues[bugId] = new(uint256.Int).Set(value)
+	}
+	return covered, nil
+}
+
+// CoverBugWithSlot records a bug like CoverBug, additionally attaching the storage slot written by the bug (e.g. the
+// slot written after a reentrant call). The slot is only recorded the first time the bug is covered.
+func (ds *BugMap) CoverBugWithSlot(bugId string, slot common.Hash) (bool, error) {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+
+	covered := ds.coverBug(bugId)
+	if covered {
+		ds.bugSlots[bugId] = slot
+	}
+	return covered, nil
+}
+
+// AddBlockDependency records a branch condition depending on the provided block properties.
+func (ds *BugMap) AddBlockDependency(dependency BlockDependency) {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+	ds.blockDependencies |= dependency
+}
+
+// BlockDependencies returns the block properties branch conditions were observed to depend on, as recorded by a
+// single transaction.
+func (ds *BugMap) BlockDependencies() BlockDependency {
+	ds.lock.RLock()
+	defer ds.lock.RUnlock()
+	return ds.blockDependencies
+}
+
+// BugValue returns the concrete tainted value recorded for the provided bug ID, or nil if none was recorded.
+func (ds *BugMap) BugValue(bugId string) *uint256.Int {
+	ds.lock.RLock()
+	defer ds.lock.RUnlock()
+
+	return ds.bugValues[bugId]
+}
+
+// BugSlot returns the storage slot written by the bug with the provided ID, or nil if none was recorded.
+func (ds *BugMap) BugSlot(bugId string) *common.Hash {
+	ds.lock.RLock()
+	defer ds.lock.RUnlock()
+
+	if slot, exists := ds.bugSlots[bugId]; exists {
+		return &slot
+	}
+	return nil
+}
+
+// BugIDs returns the IDs of the bugs recorded, sorted.
+func (ds *BugMap) BugIDs() []string {
+	ds.lock.RLock()
+	defer ds.lock.RUnlock()
+
+	bugIds := make([]string, 0, len(ds.bugMap))
+	for bugId := range ds.bugMap {
+		bugIds = append(bugIds, bugId)
 	}
+	sort.Strings(bugIds)
+	return bugIds
+}
 
-	return true, nil
+// DetectedBug describes a bug recorded in a BugMap, along with the time elapsed since the campaign started when it was
+// first detected.
+type DetectedBug struct {
+	// ID describes the ID of the bug.
+	ID string
+	// Elapsed describes the time elapsed since the campaign started when the bug was first detected.
+	Elapsed time.Duration
+	// Value describes the concrete tainted value which reached the sink, or nil if none was recorded.
+	Value *uint256.Int
+}
+
+// LatestBugs returns the bugs recorded, sorted from the most to the least recently detected. At most limit bugs are
+// returned, or all of them if limit is not positive.
+func (ds *BugMap) LatestBugs(limit int) []DetectedBug {
+	ds.lock.RLock()
+	defer ds.lock.RUnlock()
+
+	bugs := make([]DetectedBug, 0, len(ds.bugMap))
+	for bugId, coveredTime := range ds.bugMap {
+		elapsed, _ := time.ParseDuration(coveredTime)
+		bugs = append(bugs, DetectedBug{ID: bugId, Elapsed: elapsed, Value: ds.bugValues[bugId]})
+	}
+	sort.Slice(bugs, func(i, j int) bool {
+		if bugs[i].Elapsed != bugs[j].Elapsed {
+			return bugs[i].Elapsed > bugs[j].Elapsed
+		}
+		return bugs[i].ID < bugs[j].ID
+	})
+	if limit > 0 && len(bugs) > limit {
+		bugs = bugs[:limit]
+	}
+	return bugs
+}
+
+// ContainsBug indicates whether the bug with the provided ID was recorded.
+func (ds *BugMap) ContainsBug(bugId string) bool {
+	ds.lock.RLock()
+	defer ds.lock.RUnlock()
+
+	_, exists := ds.bugMap[bugId]
+	return exists
 }
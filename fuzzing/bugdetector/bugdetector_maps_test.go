@@ -0,0 +1,83 @@
+package bugdetector
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBugMapValues tests that the tainted value recorded for a bug is kept apart from its ID, is only recorded the
+// first time the bug is covered, and is carried over when bug maps are merged.
+func TestBugMapValues(t *testing.T) {
+	bugMap := NewBugMap()
+	value := uint256.NewInt(0x1234)
+	covered, err := bugMap.CoverBugWithValue("UNSAFEDELEGATECALL-a", value)
+	assert.NoError(t, err)
+	assert.True(t, covered)
+
+	// The value is copied, so later changes to it are not recorded.
+	value.SetUint64(1)
+	assert.EqualValues(t, 0x1234, bugMap.BugValue("UNSAFEDELEGATECALL-a").Uint64())
+
+	// Covering the bug again does not replace its value.
+	covered, err = bugMap.CoverBugWithValue("UNSAFEDELEGATECALL-a", uint256.NewInt(2))
+	assert.NoError(t, err)
+	assert.False(t, covered)
+	assert.EqualValues(t, 0x1234, bugMap.BugValue("UNSAFEDELEGATECALL-a").Uint64())
+
+	// Bugs covered without a value have none.
+	covered, err = bugMap.CoverBugWithValue("UNSAFEDELEGATECALL-b", nil)
+	assert.NoError(t, err)
+	assert.True(t, covered)
+	assert.Nil(t, bugMap.BugValue("UNSAFEDELEGATECALL-b"))
+
+	// The value is not part of the bug IDs, but is reported alongside them.
+	assert.EqualValues(t, []string{"UNSAFEDELEGATECALL-a", "UNSAFEDELEGATECALL-b"}, bugMap.BugIDs())
+	for _, bug := range bugMap.BugDetectionResult() {
+		assert.False(t, strings.Contains(bug, value.Hex()))
+	}
+	for _, bug := range bugMap.LatestBugs(0) {
+		if bug.ID == "UNSAFEDELEGATECALL-a" {
+			assert.EqualValues(t, 0x1234, bug.Value.Uint64())
+		} else {
+			assert.Nil(t, bug.Value)
+		}
+	}
+
+	// Values are carried over when merging bug maps.
+	mergedBugMap := NewBugMap()
+	updated, err := mergedBugMap.Update(bugMap)
+	assert.NoError(t, err)
+	assert.True(t, updated)
+	assert.EqualValues(t, 0x1234, mergedBugMap.BugValue("UNSAFEDELEGATECALL-a").Uint64())
+}
+
+// TestBugMapCoverBugWithValueConcurrently tests that a bug covered concurrently is covered exactly once, with the
+// value of the caller which covered it.
+func TestBugMapCoverBugWithValueConcurrently(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		bugMap := NewBugMap()
+		var wg sync.WaitGroup
+		coveredBy := make([]bool, 8)
+		for j := range coveredBy {
+			wg.Add(1)
+			go func(j int) {
+				defer wg.Done()
+				coveredBy[j], _ = bugMap.CoverBugWithValue("bug", uint256.NewInt(uint64(j)))
+			}(j)
+		}
+		wg.Wait()
+
+		coveredCount := 0
+		for j, covered := range coveredBy {
+			if covered {
+				coveredCount++
+				assert.EqualValues(t, j, bugMap.BugValue("bug").Uint64())
+			}
+		}
+		assert.EqualValues(t, 1, coveredCount)
+	}
+}
@@ -12,6 +12,9 @@ import (
 	"github.com/crytic/medusa/chain"
 	"github.com/crytic/medusa/chain/types"
 	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/dataflow"
+	"github.com/crytic/medusa/logging"
+	"github.com/rs/zerolog"
 )
 
 var StartTimeForBugDetector time.Time
@@ -61,13 +64,30 @@ type BugDetectorTracer struct {
 	// config records the configures for bug detector
 	config *config.BugDetectionConfig
 
-	// originalEther is recording the orignal balance of ether, for ether leaking
+	// originalEther describes the combined ether balance of the adversarial addresses once the test chain was set up.
+	// Ether leaking is detected when their combined balance exceeds it.
 	originalEther *big.Int
 
-	// adversarial addresses
+	// adversarialAddresses describes the addresses controlled by an attacker: ether leaking is measured by their
+	// balances, and reentrancy and unsafe delegate calls are detected when control flows to them.
 	adversarialAddresses []common.Address
 
 	helperContract common.Address
+
+	// mockAddresses describes the addresses of mock contracts, whose return data is treated as a taint source.
+	mockAddresses []common.Address
+
+	// txOrigin and txGasPrice record the transaction context, so concrete taint source values can be observed.
+	txOrigin   common.Address
+	txGasPrice *big.Int
+
+	// sequenceWrites tracks the storage slots written over the call sequence, to detect reads of state variables which
+	// were never written.
+	sequenceWrites *dataflow.SequenceWrites
+
+	// taintTraceLogger is used by taint analyzers to log their state for every opcode. It is nil unless the global
+	// logger is set to the trace level.
+	taintTraceLogger *logging.Logger
 }
 
 // bugDetectorTracerCallFrameState tracks state across call frames in the tracer.
@@ -101,6 +121,12 @@ type bugDetectorTracerCallFrameState struct {
 	taintedCallPoints         map[string][]string // []string records the sloadPoints being used in call
 	isTouchedAdversialAddress bool
 	taintedJUMPIPoints        map[string][]string
+
+	// callOutputStart and callOutputEnd describe the memory region the last call made by this call frame writes its
+	// return data to, and returnDataMocked whether that return data was returned by a mock contract.
+	callOutputStart  uint64
+	callOutputEnd    uint64
+	returnDataMocked bool
 }
 
 // NewBugDetectorTracer returns a new BugDetectorTracer.
@@ -111,6 +137,9 @@ func NewBugDetectorTracer(helperContract common.Address, config *config.BugDetec
 		callFrameStates: make([]*bugDetectorTracerCallFrameState, 0),
 		config:          config,
 	}
+	if logging.GlobalLogger != nil && logging.GlobalLogger.Level() <= zerolog.TraceLevel {
+		tracer.taintTraceLogger = logging.GlobalLogger.NewSubLogger("module", "taint analysis")
+	}
 	nativeTracer := &tracers.Tracer{
 		Hooks: &tracing.Hooks{
 			OnTxStart: tracer.OnTxStart,
@@ -137,6 +166,8 @@ func (t *BugDetectorTracer) OnTxStart(vm *tracing.VMContext, tx *coretypes.Trans
 	t.bugMap = NewBugMap()
 	t.callFrameStates = make([]*bugDetectorTracerCallFrameState, 0)
 	t.evm = vm
+	t.txOrigin = from
+	t.txGasPrice = tx.GasPrice()
 }
 
 // OnTxEnd is called upon the end of transaction execution, as defined by tracers.Tracer.
@@ -150,12 +181,14 @@ func (t *BugDetectorTracer) OnEnter(depth int, typ byte, from common.Address, to
 		t.callDepth++
 	}
 	// Create our state tracking struct for this frame.
+	taintAnalyzer := NewTaintAnalyzer()
+	taintAnalyzer.SetTraceLogger(t.taintTraceLogger)
 	t.callFrameStates = append(t.callFrameStates, &bugDetectorTracerCallFrameState{
 		create:             typ == byte(vm.CREATE) || typ == byte(vm.CREATE2),
 		from:               from,
 		to:                 to,
 		codeAddress:        to,
-		taintAnalyzer:      NewTaintAnalyzer(),
+		taintAnalyzer:      taintAnalyzer,
 		overflowPoints:     make(map[string]bool),
 		etherleakingPoints: make(map[string]bool),
 		selfdestructPoints: make(map[string]bool),
@@ -201,6 +234,11 @@ func (t *BugDetectorTracer) OnExit(depth int, output []byte, gasUsed uint64, err
 	}
 
 	if !isTopLevelFrame {
+		// Taint the return data of calls to mock contracts in the parent call frame.
+		if len(t.mockAddresses) > 0 {
+			exit_mock_output(t)
+		}
+
 		// Pop the state tracking struct for this call frame off the stack.
 		t.callFrameStates = t.callFrameStates[:t.callDepth]
 		t.callDepth--
@@ -241,6 +279,14 @@ func (t *BugDetectorTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope
 		detect_unsafe_delegatecall(t, pc, op, scope)
 	}
 
+	if t.config.UninitializedStorageRead {
+		detect_uninitialized_storage_read(t, pc, op, scope)
+	}
+
+	if len(t.mockAddresses) > 0 {
+		track_mock_output(t, pc, op, scope)
+	}
+
 	// handle taint analysis
 	callFrameState.taintAnalyzer.PropagateTaint(op, scope)
 
@@ -255,15 +301,19 @@ func (t *BugDetectorTracer) CaptureTxEndSetAdditionalResults(results *types.Mess
 	results.AdditionalResults[bugDetectorTracerResultsKey] = t.bugMap
 }
 
-func (t *BugDetectorTracer) SetOriginalEther(bs []*big.Int) {
-	t.originalEther = big.NewInt(0)
-	for _, b := range bs {
-		t.originalEther = new(big.Int).Add(t.originalEther, b)
-	}
+// SetSequenceWrites sets the sequenceWrites value (see above).
+func (t *BugDetectorTracer) SetSequenceWrites(sequenceWrites *dataflow.SequenceWrites) {
+	t.sequenceWrites = sequenceWrites
 }
 
-func (t *BugDetectorTracer) SetAdversarialAddresses(ads []common.Address) {
-	for _, addr := range ads {
-		t.adversarialAddresses = append(t.adversarialAddresses, addr)
-	}
+// SetAdversaries sets the addresses controlled by an attacker, and the combined ether balance they held once the test
+// chain was set up, which ether leaking is detected against. The addresses replace any previously set.
+func (t *BugDetectorTracer) SetAdversaries(addresses []common.Address, originalEther *big.Int) {
+	t.adversarialAddresses = append([]common.Address(nil), addresses...)
+	t.originalEther = new(big.Int).Set(originalEther)
+}
+
+// SetMockAddresses sets the addresses of mock contracts, whose return data is treated as a taint source.
+func (t *BugDetectorTracer) SetMockAddresses(addresses []common.Address) {
+	t.mockAddresses = append(t.mockAddresses, addresses...)
 }
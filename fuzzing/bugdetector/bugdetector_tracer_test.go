@@ -0,0 +1,105 @@
+package bugdetector
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core"
+	coretypes "github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa/chain"
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/dataflow"
+	"github.com/stretchr/testify/assert"
+)
+
+// traceBugDetection executes a call from the provided sender to the provided code with the provided call data, with a
+// BugDetectorTracer attached which treats the sender as an adversary and starts a new call sequence.
+// Returns the bugs detected, along with the address of the code.
+func traceBugDetection(t *testing.T, sender common.Address, code string, data []byte) (*BugMap, common.Address) {
+	contract := common.HexToAddress("0x20000")
+	testChain, err := chain.NewTestChain(context.Background(), coretypes.GenesisAlloc{
+		contract: {Code: common.FromHex(code)},
+	}, nil)
+	assert.NoError(t, err)
+
+	tracer := NewBugDetectorTracer(common.Address{}, &config.BugDetectionConfig{
+		Enabled:                  true,
+		BlockDependency:          true,
+		UnsafeDelegateCall:       true,
+		UninitializedStorageRead: true,
+	})
+	tracer.SetSequenceWrites(dataflow.NewSequenceWrites())
+	tracer.SetAdversaries([]common.Address{common.HexToAddress("0x10000")}, big.NewInt(0))
+	_, err = testChain.CallContract(&core.Message{
+		From:            sender,
+		To:              &contract,
+		Value:           big.NewInt(0),
+		GasLimit:        testChain.BlockGasLimit,
+		GasPrice:        big.NewInt(0),
+		GasFeeCap:       big.NewInt(0),
+		GasTipCap:       big.NewInt(0),
+		Data:            data,
+		SkipNonceChecks: true,
+	}, nil, tracer.NativeTracer())
+	assert.NoError(t, err)
+	assert.Zero(t, tracer.callFrameStates[0].taintAnalyzer.StackDesyncs())
+	return tracer.bugMap, contract
+}
+
+// bugIdsWithPrefix returns the IDs of the bugs detected with the provided prefix.
+func bugIdsWithPrefix(bugMap *BugMap, prefix string) []string {
+	bugIds := make([]string, 0)
+	for _, bugId := range bugMap.BugIDs() {
+		if strings.HasPrefix(bugId, prefix) {
+			bugIds = append(bugIds, bugId)
+		}
+	}
+	return bugIds
+}
+
+// TestBugDetectorTracerBlockDependency ensures branch conditions depending on the block timestamp are detected once
+// its taint propagated through arithmetic, DUP and SWAP opcodes.
+func TestBugDetectorTracerBlockDependency(t *testing.T) {
+	// TIMESTAMP, PUSH1 1, ADD, DUP1, SWAP1, POP, ISZERO, POP, STOP
+	bugMap, contract := traceBugDetection(t, common.HexToAddress("0x10000"), "0x42600101809050155000", nil)
+	assert.EqualValues(t, []string{fmt.Sprintf("BLOCKDEPENDENCY-%s-7-ISZERO", contract)}, bugIdsWithPrefix(bugMap, "BLOCKDEPENDENCY"))
+	assert.EqualValues(t, TimestampDependency, bugMap.BlockDependencies())
+
+	// PUSH1 1, ISZERO, POP, STOP
+	bugMap, _ = traceBugDetection(t, common.HexToAddress("0x10000"), "0x6001155000", nil)
+	assert.Empty(t, bugIdsWithPrefix(bugMap, "BLOCKDEPENDENCY"))
+}
+
+// TestBugDetectorTracerUnsafeDelegatecall ensures delegate calls to addresses controlled by an adversary are detected
+// once the taint of the call data they were loaded from propagated through memory, reporting the tainted value.
+func TestBugDetectorTracerUnsafeDelegatecall(t *testing.T) {
+	// PUSH1 0, CALLDATALOAD, PUSH1 0, MSTORE, PUSH1 0 (x4), PUSH1 0, MLOAD, GAS, DELEGATECALL, POP, STOP
+	code := "0x60003560005260006000600060006000515af45000"
+	data := common.LeftPadBytes([]byte{0x12, 0x34}, 32)
+	bugMap, contract := traceBugDetection(t, common.HexToAddress("0x10000"), code, data)
+	bugId := fmt.Sprintf("UNSAFEDELEGATECALL-%s-18-DELEGATECALL", contract)
+	assert.EqualValues(t, []string{bugId}, bugIdsWithPrefix(bugMap, "UNSAFEDELEGATECALL"))
+	if assert.NotNil(t, bugMap.BugValue(bugId)) {
+		assert.EqualValues(t, 0x1234, bugMap.BugValue(bugId).Uint64())
+	}
+
+	// Call data sent by other accounts is not a taint source.
+	bugMap, _ = traceBugDetection(t, common.HexToAddress("0x30000"), code, data)
+	assert.Empty(t, bugIdsWithPrefix(bugMap, "UNSAFEDELEGATECALL"))
+}
+
+// TestBugDetectorTracerUninitializedStorageRead ensures zero values read from slots of declared state variables are
+// detected until the slot is written in the call sequence, while reads of hashed slots are not.
+func TestBugDetectorTracerUninitializedStorageRead(t *testing.T) {
+	// PUSH1 1, SLOAD, POP, PUSH1 0, PUSH1 1, SSTORE, PUSH1 1, SLOAD, POP, STOP
+	bugMap, contract := traceBugDetection(t, common.HexToAddress("0x10000"), "0x6001545060006001556001545000", nil)
+	assert.EqualValues(t, []string{fmt.Sprintf("UNINITIALIZEDSTORAGEREAD-%s-2-0x1", contract)}, bugIdsWithPrefix(bugMap, "UNINITIALIZEDSTORAGEREAD"))
+
+	// PUSH9 1 << 64, SLOAD, POP, STOP
+	bugMap, _ = traceBugDetection(t, common.HexToAddress("0x10000"), "0x68010000000000000000545000", nil)
+	assert.Empty(t, bugIdsWithPrefix(bugMap, "UNINITIALIZEDSTORAGEREAD"))
+}
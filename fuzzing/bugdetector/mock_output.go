@@ -0,0 +1,44 @@
+package bugdetector
+
+import (
+	"slices"
+
+	"github.com/crytic/medusa-geth/core/tracing"
+	"github.com/crytic/medusa-geth/core/vm"
+)
+
+// track_mock_output records the memory region the current call frame's next call writes its return data to, and
+// taints return data copied to memory after a call to a mock contract. The values mock contracts return are chosen by
+// the fuzzer in place of external dependencies, such as oracles or bridges, so they are treated as taint sources
+// under the RETURNDATACOPY opcode, whichever way the return data reached memory.
+func track_mock_output(tracer *BugDetectorTracer, pc uint64, opcode byte, scope tracing.OpContext) {
+	lastCall := tracer.callFrameStates[len(tracer.callFrameStates)-1]
+	scopeContext := scope.(*vm.ScopeContext)
+
+	switch vm.OpCode(opcode) {
+	case vm.CALL, vm.CALLCODE:
+		lastCall.callOutputStart = scopeContext.Stack.Back(5).Uint64()
+		lastCall.callOutputEnd = lastCall.callOutputStart + scopeContext.Stack.Back(6).Uint64()
+	case vm.DELEGATECALL, vm.STATICCALL:
+		lastCall.callOutputStart = scopeContext.Stack.Back(4).Uint64()
+		lastCall.callOutputEnd = lastCall.callOutputStart + scopeContext.Stack.Back(5).Uint64()
+	case vm.RETURNDATACOPY:
+		if lastCall.returnDataMocked {
+			start := scopeContext.Stack.Back(0).Uint64()
+			end := start + scopeContext.Stack.Back(2).Uint64()
+			lastCall.taintAnalyzer.AddTaintSourceMemoryByOpcode(opcode, start, end)
+		}
+	}
+}
+
+// exit_mock_output records whether the return data of the exiting call frame was returned by a mock contract, in its
+// parent call frame, tainting the memory region the return data was written to if so.
+func exit_mock_output(tracer *BugDetectorTracer) {
+	lastCall := tracer.callFrameStates[len(tracer.callFrameStates)-1]
+	parentCall := tracer.callFrameStates[len(tracer.callFrameStates)-2]
+
+	parentCall.returnDataMocked = slices.Contains(tracer.mockAddresses, lastCall.codeAddress)
+	if parentCall.returnDataMocked && parentCall.callOutputEnd > parentCall.callOutputStart {
+		parentCall.taintAnalyzer.AddTaintSourceMemoryByOpcode(byte(vm.RETURNDATACOPY), parentCall.callOutputStart, parentCall.callOutputEnd)
+	}
+}
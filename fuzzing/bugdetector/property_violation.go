@@ -0,0 +1,16 @@
+package bugdetector
+
+import (
+	"fmt"
+
+	"github.com/crytic/medusa-geth/common"
+)
+
+// PROPERTYVIOLATION_ID describes the type of the bugs recorded when property tests registered as oracles fail.
+const PROPERTYVIOLATION_ID string = "PROPERTYVIOLATION"
+
+// PropertyViolationBugID returns the ID of the bug recorded when the property test with the provided function name
+// fails on the contract deployed at the provided address.
+func PropertyViolationBugID(address common.Address, property string) string {
+	return fmt.Sprintf("%s-%s-%s", PROPERTYVIOLATION_ID, address.Hex(), property)
+}
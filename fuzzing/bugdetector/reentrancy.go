@@ -7,6 +7,7 @@ import (
 	"github.com/crytic/medusa-geth/common"
 	"github.com/crytic/medusa-geth/core/tracing"
 	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/holiman/uint256"
 )
 
 func isReentrancyTaintSunk(id string, opcode byte, ta *TaintAnalyzer) bool {
@@ -59,7 +60,7 @@ func detect_reentrancy(tracer *BugDetectorTracer, pc uint64, opcode byte, scope
 			slot:   key,
 			value:  value,
 		}
-		lastCall.taintAnalyzer.AddTaintSource(opcode, pc)
+		lastCall.taintAnalyzer.AddTaintSourceWithValue(opcode, pc, new(uint256.Int).SetBytes(value.Bytes()))
 		lastCall.sloadPoints[ts.id()] = ts
 	case vm.JUMPI:
 		// for the case that the sload value is only used to determine branch
@@ -92,7 +93,7 @@ func detect_reentrancy(tracer *BugDetectorTracer, pc uint64, opcode byte, scope
 					ts := lastCall.sloadPoints[sloadId]
 					if key == ts.slot {
 						bugId := fmt.Sprintf("REENTRANCY-%s-%s", lastCall.codeAddress, callId)
-						tracer.bugMap.CoverBug(bugId)
+						tracer.bugMap.CoverBugWithSlot(bugId, key)
 					}
 				}
 			}
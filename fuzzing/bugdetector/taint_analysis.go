@@ -2,15 +2,20 @@ package bugdetector
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/crytic/medusa-geth/common"
 	"github.com/crytic/medusa-geth/core/tracing"
 	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa/logging"
+	"github.com/holiman/uint256"
 )
 
 type TaintOpcode struct {
 	opcode byte
 	pc     uint64
+	// value is the concrete value observed at the taint source, or nil if it was not recorded.
+	value *uint256.Int
 }
 
 type TaintMemory struct {
@@ -18,6 +23,8 @@ type TaintMemory struct {
 	pc     uint64
 	start  uint64
 	end    uint64
+	// value is the concrete value observed at the taint source, or nil if it was not recorded.
+	value *uint256.Int
 }
 
 type TaintStorageSlot struct {
@@ -53,21 +60,38 @@ func (t *TaintMemory) id() string {
 
 type TaintOpcodes map[string]*TaintOpcode
 
+// taintDiagnostics provides debug logging for taint analysis, and counts the anomalies it recovers from, such as taint
+// stacks out of sync with the real stack (STACKDESYNC) or opcodes without a known stack effect (UNKNOWNSTACKEFFECT).
+var taintDiagnostics = logging.NewDiagnostics("taint")
+
 // TaintAnalyzer performs taint analysis on stack during EVM execution.
 type TaintAnalyzer struct {
-	// map from stack index to TaintOpcodes, which is a map from taint ID (pc-opcode) to TaintOpcode
-	taintStacks map[int]TaintOpcodes
+	// taintStacks shadows the EVM stack, holding the TaintOpcodes of each stack item, which is a map from taint ID
+	// (pc-opcode) to TaintOpcode. Items are ordered from the bottom of the stack to its top, so pushes and pops only
+	// touch the end of the slice, and untainted items are nil.
+	taintStacks []TaintOpcodes
 	// map from taint ID to TaintMemory
 	taintMemory map[string]TaintMemory
 	// map from storage slot to TaintOpcodes, which is a map from taint ID (pc-opcode) to TaintOpcode
 	taintStorage map[common.Hash]TaintOpcodes
+	// pendingTaints holds taint sources added for the current opcode. They are applied to the item the opcode pushes
+	// once its stack effect has been propagated.
+	pendingTaints TaintOpcodes
+	// stackDepth is the stack depth expected before the next opcode, if stackDepthKnown is set.
+	stackDepth      int
+	stackDepthKnown bool
+	// stackDesyncs counts the times the taint stack was found to be out of sync with the real stack.
+	stackDesyncs uint64
+	// traceLogger is used to log the taint state around every propagated opcode, or nil if trace logging is disabled.
+	traceLogger *logging.Logger
 }
 
 func NewTaintAnalyzer() *TaintAnalyzer {
 	return &TaintAnalyzer{
-		taintStacks:  make(map[int]TaintOpcodes),
-		taintMemory:  make(map[string]TaintMemory),
-		taintStorage: make(map[common.Hash]TaintOpcodes),
+		taintStacks:   make([]TaintOpcodes, 0),
+		taintMemory:   make(map[string]TaintMemory),
+		taintStorage:  make(map[common.Hash]TaintOpcodes),
+		pendingTaints: make(TaintOpcodes),
 	}
 }
 
@@ -77,29 +101,39 @@ func (ta *TaintAnalyzer) AddTaintSourceByOpcode(opcode byte) {
 		pc:     0, // pc is not relevant for this use case
 	}
 
-	if _, exists := ta.taintStacks[0]; !exists {
-		ta.taintStacks[0] = make(TaintOpcodes)
+	ta.pendingTaints[taint.id()] = taint
+}
+
+// AddTaintSourceByOpcodeWithValue adds a taint source identified by opcode only, carrying the concrete value
+// observed at the source so it can be reported once the taint reaches a sink.
+func (ta *TaintAnalyzer) AddTaintSourceByOpcodeWithValue(opcode byte, value *uint256.Int) {
+	taint := &TaintOpcode{
+		opcode: opcode,
+		pc:     0, // pc is not relevant for this use case
+		value:  value,
 	}
-	ta.taintStacks[0][taint.id()] = taint
+
+	ta.pendingTaints[taint.id()] = taint
 }
 
 func (ta *TaintAnalyzer) AddTaintSource(opcode byte, pc uint64) {
+	ta.AddTaintSourceWithValue(opcode, pc, nil)
+}
+
+// AddTaintSourceWithValue adds a taint source identified by pc and opcode, carrying the concrete value observed at
+// the source. A nil value indicates the value was not recorded.
+func (ta *TaintAnalyzer) AddTaintSourceWithValue(opcode byte, pc uint64, value *uint256.Int) {
 	taint := &TaintOpcode{
 		opcode: opcode,
 		pc:     pc,
+		value:  value,
 	}
 
-	if _, exists := ta.taintStacks[0]; !exists {
-		ta.taintStacks[0] = make(TaintOpcodes)
-	}
-	ta.taintStacks[0][taint.id()] = taint
+	ta.pendingTaints[taint.id()] = taint
 }
 
 func (ta *TaintAnalyzer) AddTaintSourceByString(id string) {
-	if _, exists := ta.taintStacks[0]; !exists {
-		ta.taintStacks[0] = make(TaintOpcodes)
-	}
-	ta.taintStacks[0][id] = &TaintOpcode{
+	ta.pendingTaints[id] = &TaintOpcode{
 		opcode: 0x0,
 		pc:     0,
 	}
@@ -127,173 +161,201 @@ func (ta *TaintAnalyzer) AddTaintSourceMemoryByOpcode(opcode byte, start, end ui
 	ta.taintMemory[taint.id()] = taint
 }
 
+// PropagateTaint updates the taint state to reflect the execution of the provided opcode. It must be called before
+// the opcode is executed, after any taint sources for the opcode have been added.
 func (ta *TaintAnalyzer) PropagateTaint(opcode byte, scope tracing.OpContext) {
-	if len(ta.taintStacks) == 0 {
-		return
-	}
-	op := vm.OpCode(opcode)
+	stack := scope.(*vm.ScopeContext).Stack
+	ta.propagateTaint(vm.OpCode(opcode), len(stack.Data()), stack.Back)
+}
 
-	if op.IsPush() || op == vm.PUSH0 {
-		ta.shiftDown()
-		return
-	}
+// propagateTaint updates the taint state to reflect the execution of the provided opcode, given the depth of the real
+// stack before the opcode executes and a function returning the n-th item from the top of the real stack.
+func (ta *TaintAnalyzer) propagateTaint(op vm.OpCode, depth int, stackBack func(n int) *uint256.Int) {
+	effect, known := lookupStackEffect(op)
+
+	// Verify the taint stack is still aligned with the real stack, then record the depth we expect after this opcode.
+	ta.checkStackDepth(op, depth)
+	ta.stackDepthKnown = known
+	ta.stackDepth = ta.stackDepth - effect.pops + effect.pushes
 
-	scopeContext := scope.(*vm.ScopeContext)
-
-	// fmt.Printf("[TAINT] TaintStacks before %s PropagateTaint: %v\n", vm.OpCode(opcode), ta.taintStacks)
-	switch op {
-	// Opcodes that push a value without consuming stack items that could be tainted sources.
-	// We treat the pushed value as untainted. The external tracer can add taint if needed.
-	case vm.ADDRESS, vm.ORIGIN, vm.CALLER, vm.CALLVALUE, vm.CALLDATASIZE, vm.CODESIZE, vm.GASPRICE,
-		vm.COINBASE, vm.TIMESTAMP, vm.NUMBER, vm.DIFFICULTY, vm.GASLIMIT, vm.BLOCKHASH, vm.MSIZE,
-		vm.PC, vm.GAS, vm.RETURNDATASIZE, vm.CHAINID, vm.SELFBALANCE, vm.BASEFEE:
-		ta.shiftDown()
-
-	// --- (1 pop, 1 push) ---
-	// Taint of the operand is propagated to the result. The stack depth doesn't change.
-	case vm.MLOAD:
-		// deal with memory propagate
-		offset := scopeContext.Stack.Back(0).Uint64()
-		size := uint64(32)
-		ta.memoryToStack(offset, offset+size)
-
-	case vm.SLOAD:
-		// key := common.BigToHash(scopeContext.Stack.Back(0).ToBig())
-		// ta.storageToStack(key)
-
-	case vm.ISZERO, vm.NOT, vm.BYTE, vm.BALANCE, vm.EXTCODESIZE, vm.EXTCODEHASH, vm.CALLDATALOAD:
-
-	// --- (2 pops, 1 push) ---
-	case vm.ADD, vm.SUB, vm.MUL, vm.DIV, vm.SDIV, vm.MOD, vm.SMOD, vm.EXP,
-		vm.SIGNEXTEND, vm.LT, vm.GT, vm.SLT, vm.SGT, vm.EQ, vm.AND, vm.OR,
-		vm.XOR, vm.SHL, vm.SHR, vm.SAR, vm.KECCAK256:
-		ta.mergeTaintStacks(1, 0)
-		ta.shiftUp()
-
-	// --- (3 pops, 1 push) ---
-	case vm.ADDMOD, vm.MULMOD:
-		ta.mergeTaintStacks(2, 0)
-		ta.mergeTaintStacks(2, 1)
-		ta.shiftUp()
-		ta.shiftUp()
-
-	// --- (1 pop, 0 push) ---
-	case vm.POP, vm.JUMP:
-		ta.shiftUp()
-
-	// --- (2 pops, 0 push) ---
-	case vm.MSTORE:
-		offset := scopeContext.Stack.Back(0).Uint64()
-		size := uint64(32)
-		ta.stackToMemory(1, offset, offset+size)
-
-		ta.shiftUp()
-		ta.shiftUp()
-
-	case vm.MSTORE8:
-		offset := scopeContext.Stack.Back(0).Uint64()
-		size := uint64(1)
-		ta.stackToMemory(1, offset, offset+size)
-
-		ta.shiftUp()
-		ta.shiftUp()
-
-	case vm.SSTORE:
-		// key := common.BigToHash(scopeContext.Stack.Back(0).ToBig())
-		// ta.stackToStorage(1, key)
-		ta.shiftUp()
-		ta.shiftUp()
-	case vm.JUMPI, vm.RETURN, vm.REVERT:
-		ta.shiftUp()
-		ta.shiftUp()
-
-	// --- (3 pops, 0 push) ---
-	case vm.CODECOPY, vm.CALLDATACOPY, vm.RETURNDATACOPY:
-		ta.shiftUp()
-		ta.shiftUp()
-		ta.shiftUp()
-
-	// --- (4 pops, 0 push) ---
-	case vm.EXTCODECOPY:
-		ta.shiftUp()
-		ta.shiftUp()
-		ta.shiftUp()
-		ta.shiftUp()
+	// The taint stack is updated in place for every opcode, which keeps it aligned with the real stack. The taint
+	// state is only traced while something is tainted, to keep trace logs readable.
+	if ta.traceLogger != nil && (ta.isStackTainted() || len(ta.taintMemory) > 0 || len(ta.pendingTaints) > 0) {
+		ta.traceTaint("before", op)
+		defer ta.traceTaint("after", op)
+	}
 
+	switch {
 	// --- DUPn ---
-	case vm.DUP1, vm.DUP2, vm.DUP3, vm.DUP4, vm.DUP5, vm.DUP6, vm.DUP7, vm.DUP8,
-		vm.DUP9, vm.DUP10, vm.DUP11, vm.DUP12, vm.DUP13, vm.DUP14, vm.DUP15, vm.DUP16:
+	case op >= vm.DUP1 && op <= vm.DUP16:
 		n := int(op - vm.DUP1 + 1)
-		ta.shiftDown()
-		ta.copyTaintStack(n, 0) // The original n-th item is at index n after shiftDown
+		ta.pushUntainted()
+		ta.copyTaintStack(n, 0) // The original n-th item is at index n after the push
 
 	// --- SWAPn ---
-	case vm.SWAP1, vm.SWAP2, vm.SWAP3, vm.SWAP4, vm.SWAP5, vm.SWAP6, vm.SWAP7, vm.SWAP8,
-		vm.SWAP9, vm.SWAP10, vm.SWAP11, vm.SWAP12, vm.SWAP13, vm.SWAP14, vm.SWAP15, vm.SWAP16:
+	case op >= vm.SWAP1 && op <= vm.SWAP16:
 		n := int(op - vm.SWAP1 + 1)
-		ta.taintStacks[0], ta.taintStacks[n] = ta.taintStacks[n], ta.taintStacks[0]
-		if len(ta.taintStacks[0]) == 0 {
-			delete(ta.taintStacks, 0)
+		top, nth := len(ta.taintStacks)-1, len(ta.taintStacks)-1-n
+		if nth >= 0 {
+			ta.taintStacks[top], ta.taintStacks[nth] = ta.taintStacks[nth], ta.taintStacks[top]
 		}
-		if len(ta.taintStacks[n]) == 0 {
-			delete(ta.taintStacks, n)
+
+	// Unknown opcodes leave the taint stack untouched, the depth check resynchronizes it on the next opcode.
+	case !known:
+		taintDiagnostics.Count("UNKNOWNSTACKEFFECT")
+		if taintDiagnostics.Enabled(2) {
+			taintDiagnostics.Log(2, "No stack effect is known for an opcode, its taint is not propagated", logging.StructuredLogInfo{
+				"opcode": op.String(),
+			})
 		}
 
-	// --- LOGn ---
-	case vm.LOG0, vm.LOG1, vm.LOG2, vm.LOG3, vm.LOG4:
-		n := int(op - vm.LOG0)
-		// pops n+2 items (2 for mem, n topics)
-		for i := 0; i < n+2; i++ {
-			ta.shiftUp()
+	default:
+		switch op {
+		case vm.MLOAD:
+			// deal with memory propagate
+			offset := stackBack(0).Uint64()
+			size := uint64(32)
+			ta.memoryToStack(offset, offset+size)
+
+		case vm.SLOAD:
+			// key := common.BigToHash(stackBack(0).ToBig())
+			// ta.storageToStack(key)
+
+		case vm.MSTORE:
+			offset := stackBack(0).Uint64()
+			size := uint64(32)
+			ta.stackToMemory(1, offset, offset+size)
+
+		case vm.MSTORE8:
+			offset := stackBack(0).Uint64()
+			size := uint64(1)
+			ta.stackToMemory(1, offset, offset+size)
+
+		case vm.MCOPY:
+			dest := stackBack(0).Uint64()
+			src := stackBack(1).Uint64()
+			size := stackBack(2).Uint64()
+			ta.copyTaintMemory(dest, src, size)
+
+		case vm.SSTORE:
+			// key := common.BigToHash(stackBack(0).ToBig())
+			// ta.stackToStorage(1, key)
+
+			// Cross contract taint (CREATE, CREATE2, CALL, CALLCODE, DELEGATECALL, STATICCALL) is ignored for
+			// simplicity, so their results are untainted.
 		}
+		ta.applyStackEffect(effect)
+	}
 
-	// --- Calls & Create ---
-	case vm.CREATE: // pops 3, pushes 1
-		// ignore the cross contract taint for simplicity
-		// ta.mergeTaintStacks(0, 1)
-		// ta.mergeTaintStacks(0, 2)
-		ta.shiftUp()
-		ta.shiftUp()
-		ta.shiftUp()
-		ta.shiftDown()
-	case vm.CREATE2: // pops 4, pushes 1
-		// ignore the cross contract taint for simplicity
-		// ta.mergeTaintStacks(0, 1)
-		// ta.mergeTaintStacks(0, 2)
-		// ta.mergeTaintStacks(0, 3)
-		ta.shiftUp()
-		ta.shiftUp()
-		ta.shiftUp()
-		ta.shiftUp()
-	case vm.CALL, vm.CALLCODE: // pops 7, pushes 1
-		// ignore the cross contract taint for simplicity
-		// for i := 1; i < 7; i++ {
-		// 	ta.mergeTaintStacks(0, i)
-		// }
-		for i := 0; i < 7; i++ {
-			ta.shiftUp()
+	// Taint sources added for this opcode describe the item it pushes, which is now at the top of the stack.
+	if len(ta.pendingTaints) > 0 {
+		if known && effect.pushes > 0 && len(ta.taintStacks) > 0 {
+			topStack := ta.stackItem(0)
+			if topStack == nil {
+				topStack = make(TaintOpcodes, len(ta.pendingTaints))
+				ta.setStackItem(0, topStack)
+			}
+			for id, taint := range ta.pendingTaints {
+				topStack[id] = taint
+			}
 		}
-		ta.shiftDown()
-	case vm.DELEGATECALL, vm.STATICCALL: // pops 6, pushes 1
-		// ignore the cross contract taint for simplicity
-		// for i := 1; i < 6; i++ {
-		// 	ta.mergeTaintStacks(0, i)
-		// }
-		for i := 0; i < 6; i++ {
-			ta.shiftUp()
+		clear(ta.pendingTaints)
+	}
+}
+
+// applyStackEffect pops and pushes taint stack items according to the provided stack effect. If the effect
+// propagates, the taint of all popped operands is merged into the pushed result, otherwise pushed items are
+// untainted.
+func (ta *TaintAnalyzer) applyStackEffect(effect stackEffect) {
+	if effect.propagates && effect.pushes == 1 && effect.pops > 0 {
+		// Merge every operand into the deepest one, which then becomes the result.
+		for i := 0; i < effect.pops-1; i++ {
+			ta.mergeTaintStacks(effect.pops-1, i)
 		}
-		ta.shiftDown()
+		ta.popPush(effect.pops-1, 0)
+		return
+	}
+	ta.popPush(effect.pops, effect.pushes)
+}
 
-		// Other opcodes do not manipulate the stack in a way that concerns taint propagation
-	default:
+// checkStackDepth compares the stack depth expected by the taint stack against the real stack depth. If they differ,
+// the taint stack is considered out of sync and the desync is counted. Either way, the taint stack is aligned with the
+// real stack, dropping taints beyond it.
+func (ta *TaintAnalyzer) checkStackDepth(op vm.OpCode, depth int) {
+	if ta.stackDepthKnown && ta.stackDepth != depth {
+		ta.stackDesyncs++
+		taintDiagnostics.Count("STACKDESYNC")
+		if taintDiagnostics.Enabled(1) {
+			taintDiagnostics.Log(1, "Taint stack out of sync with the real stack", logging.StructuredLogInfo{
+				"opcode":        op.String(),
+				"expectedDepth": ta.stackDepth,
+				"depth":         depth,
+			})
+		}
+		if ta.traceLogger != nil {
+			ta.traceLogger.Trace("[TAINT] taint stack out of sync before ", op.String(), ": expected depth ", ta.stackDepth, ", got ", depth)
+		}
+	}
+	ta.alignTaintStack(depth)
+	ta.stackDepth = depth
+}
 
+// alignTaintStack resizes the taint stack to the provided depth of the real stack, keeping the items at the top of
+// both stacks aligned. Taints of items beyond the bottom of the real stack are dropped, and items missing from the
+// taint stack are untainted. This only happens after an opcode with an unknown stack effect or a desync.
+func (ta *TaintAnalyzer) alignTaintStack(depth int) {
+	length := len(ta.taintStacks)
+	if length > depth {
+		excess := length - depth
+		copy(ta.taintStacks, ta.taintStacks[excess:])
+		clear(ta.taintStacks[depth:])
+		ta.taintStacks = ta.taintStacks[:depth]
+	} else if length < depth {
+		missing := depth - length
+		ta.taintStacks = slices.Grow(ta.taintStacks, missing)[:depth]
+		copy(ta.taintStacks[missing:], ta.taintStacks[:length])
+		clear(ta.taintStacks[:missing])
 	}
 }
 
+// stackItem returns the taint of the item at the provided index from the top of the stack, or nil if it is untainted.
+func (ta *TaintAnalyzer) stackItem(stackIndex int) TaintOpcodes {
+	i := len(ta.taintStacks) - 1 - stackIndex
+	if stackIndex < 0 || i < 0 {
+		return nil
+	}
+	return ta.taintStacks[i]
+}
+
+// setStackItem sets the taint of the item at the provided index from the top of the stack. Indexes beyond the stack
+// are ignored.
+func (ta *TaintAnalyzer) setStackItem(stackIndex int, taints TaintOpcodes) {
+	i := len(ta.taintStacks) - 1 - stackIndex
+	if stackIndex < 0 || i < 0 {
+		return
+	}
+	ta.taintStacks[i] = taints
+}
+
+// isStackTainted indicates whether any item on the stack is tainted.
+func (ta *TaintAnalyzer) isStackTainted() bool {
+	for _, taints := range ta.taintStacks {
+		if len(taints) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// StackDesyncs returns the amount of times the taint stack was found to be out of sync with the real stack.
+func (ta *TaintAnalyzer) StackDesyncs() uint64 {
+	return ta.stackDesyncs
+}
+
 // IsTaintedByOpcode checks if the item at a given stack depth is tainted by a specific source.
 func (ta *TaintAnalyzer) IsTaintedByOpcode(opcode byte, stackIndex int) bool {
-	taintStack, exists := ta.taintStacks[stackIndex]
-	if !exists {
+	taintStack := ta.stackItem(stackIndex)
+	if taintStack == nil {
 		return false
 	}
 
@@ -310,10 +372,28 @@ func (ta *TaintAnalyzer) IsTaintedByOpcode(opcode byte, stackIndex int) bool {
 	return tainted
 }
 
+// TaintValuesByOpcode returns the concrete source values of all taints introduced by the given opcode which reached
+// the item at a given stack depth. Taints which did not record a value are omitted.
+func (ta *TaintAnalyzer) TaintValuesByOpcode(opcode byte, stackIndex int) []*uint256.Int {
+	taintStack := ta.stackItem(stackIndex)
+	if taintStack == nil {
+		return nil
+	}
+
+	var values []*uint256.Int
+	for _, taint := range taintStack {
+		if taint.opcode == opcode && taint.value != nil {
+			values = append(values, taint.value)
+		}
+	}
+
+	return values
+}
+
 // IsTaintedBy checks if the item at a given stack depth is tainted by a specific source.
 func (ta *TaintAnalyzer) IsTaintedBy(opcode byte, stackIndex int) bool {
-	taintStack, exists := ta.taintStacks[stackIndex]
-	if !exists {
+	taintStack := ta.stackItem(stackIndex)
+	if taintStack == nil {
 		return false
 	}
 
@@ -329,8 +409,8 @@ func (ta *TaintAnalyzer) IsTaintedBy(opcode byte, stackIndex int) bool {
 }
 
 func (ta *TaintAnalyzer) IsTaintedByString(id string, stackIndex int) bool {
-	taintStack, exists := ta.taintStacks[stackIndex]
-	if !exists {
+	taintStack := ta.stackItem(stackIndex)
+	if taintStack == nil {
 		return false
 	}
 
@@ -363,35 +443,25 @@ func (ta *TaintAnalyzer) IsTantedMemoryByOpcode(opcode byte, start, end uint64)
 	return tainted
 }
 
-// shiftDown simulates a push operation on the taint stack.
-// It moves all existing taint stacks one level down (increasing their index).
-func (ta *TaintAnalyzer) shiftDown() {
-	newTaintStacks := make(map[int]TaintOpcodes, len(ta.taintStacks)+1)
-	for i, stack := range ta.taintStacks {
-		newTaintStacks[i+1] = stack
-	}
-	ta.taintStacks = newTaintStacks
+// pushUntainted simulates a push operation of an untainted item on the taint stack.
+func (ta *TaintAnalyzer) pushUntainted() {
+	ta.taintStacks = append(ta.taintStacks, nil)
 }
 
-// shiftUp simulates a pop operation on the taint stack.
-// It removes the top element and moves all other stacks one level up.
-func (ta *TaintAnalyzer) shiftUp() {
-	if len(ta.taintStacks) == 0 {
-		return
-	}
-	newTaintStacks := make(map[int]TaintOpcodes)
-	for i, stack := range ta.taintStacks {
-		if i > 0 {
-			newTaintStacks[i-1] = stack
-		}
+// popPush simulates popping the provided amount of items, followed by pushing the provided amount of untainted items.
+func (ta *TaintAnalyzer) popPush(pops, pushes int) {
+	length := max(len(ta.taintStacks)-pops, 0)
+	clear(ta.taintStacks[length:])
+	ta.taintStacks = ta.taintStacks[:length]
+	for i := 0; i < pushes; i++ {
+		ta.taintStacks = append(ta.taintStacks, nil)
 	}
-	ta.taintStacks = newTaintStacks
 }
 
 func (ta *TaintAnalyzer) copyTaintStack(src, dest int) {
-	srcStack, exists := ta.taintStacks[src]
-	if !exists {
-		delete(ta.taintStacks, dest)
+	srcStack := ta.stackItem(src)
+	if len(srcStack) == 0 {
+		ta.setStackItem(dest, nil)
 		return
 	}
 
@@ -399,25 +469,25 @@ func (ta *TaintAnalyzer) copyTaintStack(src, dest int) {
 	for id, taint := range srcStack {
 		destStack[id] = taint
 	}
-	ta.taintStacks[dest] = destStack
+	ta.setStackItem(dest, destStack)
 }
 
 func (ta *TaintAnalyzer) mergeTaintStacks(dest, src int) {
-	srcStack, srcExists := ta.taintStacks[src]
-	if !srcExists {
+	srcStack := ta.stackItem(src)
+	if srcStack == nil {
 		return
 	}
 
-	destStack, destExists := ta.taintStacks[dest]
-	if !destExists {
-		ta.taintStacks[dest] = make(TaintOpcodes)
-		destStack = ta.taintStacks[dest]
+	destStack := ta.stackItem(dest)
+	if destStack == nil {
+		destStack = make(TaintOpcodes, len(srcStack))
+		ta.setStackItem(dest, destStack)
 	}
 
 	for id, taint := range srcStack {
 		destStack[id] = taint
 	}
-	delete(ta.taintStacks, src)
+	ta.setStackItem(src, nil)
 }
 
 func (ta *TaintAnalyzer) memoryToStack(start, end uint64) {
@@ -431,14 +501,37 @@ func (ta *TaintAnalyzer) memoryToStack(start, end uint64) {
 			continue
 		} else {
 			// taint memory goes to stack
-			ta.AddTaintSource(taintMemory.opcode, taintMemory.pc)
+			ta.AddTaintSourceWithValue(taintMemory.opcode, taintMemory.pc, taintMemory.value)
+		}
+	}
+}
+
+// copyTaintMemory copies the taint of memory regions overlapping [src, src+size) to the corresponding regions of
+// [dest, dest+size), as done by MCOPY. As each taint tracks a single region, the region is widened to also cover the
+// copied bytes.
+func (ta *TaintAnalyzer) copyTaintMemory(dest, src, size uint64) {
+	if size == 0 {
+		return
+	}
+	for id, taintMemory := range ta.taintMemory {
+		if src+size <= taintMemory.start || src >= taintMemory.end {
+			continue
+		}
+		start := max(taintMemory.start, src)
+		end := min(taintMemory.end, src+size)
+		ta.taintMemory[id] = TaintMemory{
+			opcode: taintMemory.opcode,
+			pc:     taintMemory.pc,
+			start:  min(taintMemory.start, start-src+dest),
+			end:    max(taintMemory.end, end-src+dest),
+			value:  taintMemory.value,
 		}
 	}
 }
 
 func (ta *TaintAnalyzer) stackToMemory(stackIndex int, start, end uint64) {
-	taintStack, exists := ta.taintStacks[stackIndex]
-	if !exists {
+	taintStack := ta.stackItem(stackIndex)
+	if taintStack == nil {
 		return
 	}
 	for id, taintOpcode := range taintStack {
@@ -447,6 +540,7 @@ func (ta *TaintAnalyzer) stackToMemory(stackIndex int, start, end uint64) {
 			pc:     taintOpcode.pc,
 			start:  start,
 			end:    end,
+			value:  taintOpcode.value,
 		}
 	}
 }
@@ -457,24 +551,24 @@ func (ta *TaintAnalyzer) storageToStack(slot common.Hash) {
 	}
 
 	for _, taintOpcode := range ta.taintStorage[slot] {
-		ta.AddTaintSource(taintOpcode.opcode, taintOpcode.pc)
+		ta.AddTaintSourceWithValue(taintOpcode.opcode, taintOpcode.pc, taintOpcode.value)
 	}
 }
 
 func (ta *TaintAnalyzer) stackToStorage(stackIndex int, slot common.Hash) {
-	taintOpcodes, exists := ta.taintStacks[stackIndex]
-	if !exists {
+	taintOpcodes := ta.stackItem(stackIndex)
+	if taintOpcodes == nil {
 		return
 	}
 
 	for _, taintOpcode := range taintOpcodes {
 		if taintOpcode != nil {
-			ta.addTaintOpcodeToStorage(slot, taintOpcode.pc, taintOpcode.opcode)
+			ta.addTaintOpcodeToStorage(slot, taintOpcode.pc, taintOpcode.opcode, taintOpcode.value)
 		}
 	}
 }
 
-func (ta *TaintAnalyzer) addTaintOpcodeToStorage(slot common.Hash, pc uint64, opcode byte) {
+func (ta *TaintAnalyzer) addTaintOpcodeToStorage(slot common.Hash, pc uint64, opcode byte, value *uint256.Int) {
 	if _, exists := ta.taintStorage[slot]; !exists {
 		ta.taintStorage[slot] = make(TaintOpcodes)
 	}
@@ -482,6 +576,7 @@ func (ta *TaintAnalyzer) addTaintOpcodeToStorage(slot common.Hash, pc uint64, op
 	t := &TaintOpcode{
 		pc:     pc,
 		opcode: opcode,
+		value:  value,
 	}
 	ta.taintStorage[slot][t.id()] = t
 }
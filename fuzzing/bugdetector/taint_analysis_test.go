@@ -0,0 +1,196 @@
+package bugdetector
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/maps"
+)
+
+// mapTaintStack is the map-based taint stack the slice-backed one replaced, kept as a reference to test against. It
+// maps the index of stack items from the top of the stack to their taint, omitting untainted items.
+type mapTaintStack map[int]TaintOpcodes
+
+// shiftDown simulates a push operation on the taint stack, moving all items one level down.
+func (m *mapTaintStack) shiftDown() {
+	newTaintStacks := make(mapTaintStack, len(*m)+1)
+	for i, stack := range *m {
+		newTaintStacks[i+1] = stack
+	}
+	*m = newTaintStacks
+}
+
+// shift moves every item at or below the provided index by delta, dropping those above it.
+func (m *mapTaintStack) shift(from, delta int) {
+	if len(*m) == 0 || (from == 0 && delta == 0) {
+		return
+	}
+	newTaintStacks := make(mapTaintStack, len(*m))
+	for i, stack := range *m {
+		if i >= from {
+			newTaintStacks[i+delta] = stack
+		}
+	}
+	*m = newTaintStacks
+}
+
+// copyTaintStack copies the taint of the item at index src to the item at index dest.
+func (m mapTaintStack) copyTaintStack(src, dest int) {
+	srcStack, exists := m[src]
+	if !exists {
+		delete(m, dest)
+		return
+	}
+	m[dest] = maps.Clone(srcStack)
+}
+
+// mergeTaintStacks merges the taint of the item at index src into the item at index dest, untainting src.
+func (m mapTaintStack) mergeTaintStacks(dest, src int) {
+	srcStack, exists := m[src]
+	if !exists {
+		return
+	}
+	if _, exists := m[dest]; !exists {
+		m[dest] = make(TaintOpcodes)
+	}
+	for id, taint := range srcStack {
+		m[dest][id] = taint
+	}
+	delete(m, src)
+}
+
+// propagateTaint updates the taint stack to reflect the execution of the provided opcode, whose pending taint sources
+// are provided.
+func (m *mapTaintStack) propagateTaint(op vm.OpCode, pendingTaints TaintOpcodes) {
+	effect, _ := lookupStackEffect(op)
+	switch {
+	case op >= vm.DUP1 && op <= vm.DUP16:
+		n := int(op - vm.DUP1 + 1)
+		m.shiftDown()
+		m.copyTaintStack(n, 0)
+	case op >= vm.SWAP1 && op <= vm.SWAP16:
+		n := int(op - vm.SWAP1 + 1)
+		(*m)[0], (*m)[n] = (*m)[n], (*m)[0]
+		if len((*m)[0]) == 0 {
+			delete(*m, 0)
+		}
+		if len((*m)[n]) == 0 {
+			delete(*m, n)
+		}
+	case effect.propagates && effect.pushes == 1 && effect.pops > 0:
+		for i := 0; i < effect.pops-1; i++ {
+			m.mergeTaintStacks(effect.pops-1, i)
+		}
+		m.shift(effect.pops-1, -(effect.pops - 1))
+	default:
+		m.shift(effect.pops, effect.pushes-effect.pops)
+	}
+	if len(pendingTaints) > 0 && effect.pushes > 0 {
+		if _, exists := (*m)[0]; !exists {
+			(*m)[0] = make(TaintOpcodes)
+		}
+		for id, taint := range pendingTaints {
+			(*m)[0][id] = taint
+		}
+	}
+}
+
+// taintStackOperation describes an opcode executed on the taint stack, and the taint source added for it, if any.
+type taintStackOperation struct {
+	op       vm.OpCode
+	sourceId string
+}
+
+// getTaintStackOperations generates a random sequence of opcodes with a known stack effect which is valid for the
+// real stack, starting from an empty stack. MLOAD is excluded, as it taints stack items from memory.
+func getTaintStackOperations(randomProvider *rand.Rand, count int) []taintStackOperation {
+	tests := getStackEffectTests()
+	operations := make([]taintStackOperation, 0, count)
+	depth := 0
+	for len(operations) < count {
+		test := tests[randomProvider.Intn(len(tests))]
+		if test.op == vm.MLOAD || depth < test.effect.pops || depth-test.effect.pops+test.effect.pushes > 1024 {
+			continue
+		}
+		operation := taintStackOperation{op: test.op}
+		if randomProvider.Intn(4) == 0 {
+			operation.sourceId = fmt.Sprintf("source-%d", len(operations))
+		}
+		operations = append(operations, operation)
+		depth += test.effect.pushes - test.effect.pops
+	}
+	return operations
+}
+
+// TestTaintStackMatchesMapTaintStack tests that the slice-backed taint stack holds the same taints as the map-based
+// taint stack it replaced, over random sequences of opcodes.
+func TestTaintStackMatchesMapTaintStack(t *testing.T) {
+	randomProvider := rand.New(rand.NewSource(0))
+	for sequence := 0; sequence < 32; sequence++ {
+		ta := NewTaintAnalyzer()
+		reference := make(mapTaintStack)
+		depth := 0
+		for _, operation := range getTaintStackOperations(randomProvider, 256) {
+			pendingTaints := make(TaintOpcodes)
+			if operation.sourceId != "" {
+				ta.AddTaintSourceByString(operation.sourceId)
+				pendingTaints[operation.sourceId] = &TaintOpcode{}
+			}
+			ta.propagateTaint(operation.op, depth, zeroStackBack)
+			reference.propagateTaint(operation.op, pendingTaints)
+			effect, _ := lookupStackEffect(operation.op)
+			depth += effect.pushes - effect.pops
+
+			// Every stack item holds the same taint.
+			assert.Len(t, ta.taintStacks, depth, operation.op.String())
+			for i := 0; i < depth; i++ {
+				referenceIds := maps.Keys(reference[i])
+				slices.Sort(referenceIds)
+				assert.EqualValues(t, referenceIds, stackItemIds(ta, i), operation.op.String())
+			}
+			for i := range reference {
+				assert.Less(t, i, depth, operation.op.String())
+			}
+		}
+		assert.Zero(t, ta.StackDesyncs())
+	}
+}
+
+// BenchmarkTaintStack compares the slice-backed taint stack against the map-based taint stack it replaced, over a
+// random sequence of opcodes.
+func BenchmarkTaintStack(b *testing.B) {
+	operations := getTaintStackOperations(rand.New(rand.NewSource(0)), 4096)
+	b.Run("slice", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			ta := NewTaintAnalyzer()
+			depth := 0
+			for _, operation := range operations {
+				if operation.sourceId != "" {
+					ta.AddTaintSourceByString(operation.sourceId)
+				}
+				ta.propagateTaint(operation.op, depth, zeroStackBack)
+				effect, _ := lookupStackEffect(operation.op)
+				depth += effect.pushes - effect.pops
+			}
+		}
+	})
+	b.Run("map", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			reference := make(mapTaintStack)
+			pendingTaints := make(TaintOpcodes)
+			for _, operation := range operations {
+				if operation.sourceId != "" {
+					pendingTaints[operation.sourceId] = &TaintOpcode{}
+				}
+				reference.propagateTaint(operation.op, pendingTaints)
+				clear(pendingTaints)
+			}
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package bugdetector
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa/logging"
+)
+
+// TaintLabel describes a single taint carried by a stack item or storage slot in a TaintSnapshot.
+type TaintLabel struct {
+	// ID is the taint identifier (pc-opcode, opcode, or a detector-specific string).
+	ID string `json:"id"`
+	// Opcode is the name of the opcode which introduced the taint.
+	Opcode string `json:"opcode"`
+	// PC is the program counter of the taint source, or zero if it is not relevant.
+	PC uint64 `json:"pc"`
+	// Value is the hex-encoded concrete value observed at the taint source, if one was recorded.
+	Value string `json:"value,omitempty"`
+}
+
+// TaintMemoryRegion describes a tainted memory region in a TaintSnapshot.
+type TaintMemoryRegion struct {
+	TaintLabel
+	// Start is the inclusive start offset of the tainted memory region.
+	Start uint64 `json:"start"`
+	// End is the exclusive end offset of the tainted memory region.
+	End uint64 `json:"end"`
+}
+
+// TaintSnapshot is a serializable copy of the state held by a TaintAnalyzer.
+type TaintSnapshot struct {
+	// Stacks maps a stack index (zero being the top of the stack) to the taints it carries.
+	Stacks map[int][]TaintLabel `json:"stacks"`
+	// Memory lists the tainted memory regions.
+	Memory []TaintMemoryRegion `json:"memory"`
+	// Storage maps a hex-encoded storage slot to the taints it carries.
+	Storage map[string][]TaintLabel `json:"storage"`
+}
+
+// String returns the JSON representation of the TaintSnapshot.
+func (s *TaintSnapshot) String() string {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
+
+// newTaintLabel creates a TaintLabel for the provided taint.
+func newTaintLabel(id string, t *TaintOpcode) TaintLabel {
+	label := TaintLabel{
+		ID:     id,
+		Opcode: vm.OpCode(t.opcode).String(),
+		PC:     t.pc,
+	}
+	if t.value != nil {
+		label.Value = t.value.Hex()
+	}
+	return label
+}
+
+// newTaintLabels creates a sorted list of TaintLabel for the provided taints.
+func newTaintLabels(taints TaintOpcodes) []TaintLabel {
+	labels := make([]TaintLabel, 0, len(taints))
+	for id, t := range taints {
+		labels = append(labels, newTaintLabel(id, t))
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].ID < labels[j].ID
+	})
+	return labels
+}
+
+// Dump returns a serializable snapshot of the taint state for stacks, memory, and storage. The snapshot does not
+// share any state with the TaintAnalyzer.
+func (ta *TaintAnalyzer) Dump() *TaintSnapshot {
+	snapshot := &TaintSnapshot{
+		Stacks:  make(map[int][]TaintLabel),
+		Memory:  make([]TaintMemoryRegion, 0, len(ta.taintMemory)),
+		Storage: make(map[string][]TaintLabel, len(ta.taintStorage)),
+	}
+
+	for i, taints := range ta.taintStacks {
+		if len(taints) > 0 {
+			snapshot.Stacks[len(ta.taintStacks)-1-i] = newTaintLabels(taints)
+		}
+	}
+	for id, t := range ta.taintMemory {
+		snapshot.Memory = append(snapshot.Memory, TaintMemoryRegion{
+			TaintLabel: newTaintLabel(id, &TaintOpcode{opcode: t.opcode, pc: t.pc, value: t.value}),
+			Start:      t.start,
+			End:        t.end,
+		})
+	}
+	sort.Slice(snapshot.Memory, func(i, j int) bool {
+		return snapshot.Memory[i].ID < snapshot.Memory[j].ID
+	})
+	for slot, taints := range ta.taintStorage {
+		snapshot.Storage[slot.Hex()] = newTaintLabels(taints)
+	}
+
+	return snapshot
+}
+
+// SetTraceLogger enables per-opcode trace logging of the taint state through the provided logger. The state is only
+// dumped when the logger is set to the trace level. Providing nil disables trace logging.
+func (ta *TaintAnalyzer) SetTraceLogger(logger *logging.Logger) {
+	ta.traceLogger = logger
+}
+
+// traceTaint logs the current taint state for the provided opcode if trace logging is enabled.
+func (ta *TaintAnalyzer) traceTaint(stage string, op vm.OpCode) {
+	if ta.traceLogger == nil {
+		return
+	}
+	ta.traceLogger.Trace("[TAINT] taint state ", stage, " ", op.String(), ": ", ta.Dump().String())
+}
@@ -0,0 +1,83 @@
+package bugdetector
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa/logging"
+	"github.com/holiman/uint256"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTaintAnalyzerDump tests that a snapshot of the taint state indexes stack items from the top of the stack, omits
+// untainted items, sorts taints by ID and records the values observed at taint sources, without sharing state with the
+// TaintAnalyzer.
+func TestTaintAnalyzerDump(t *testing.T) {
+	slot := common.HexToHash("0x1")
+	ta := NewTaintAnalyzer()
+	ta.taintStacks = []TaintOpcodes{
+		{
+			"5-CALLVALUE": {opcode: byte(vm.CALLVALUE), pc: 5, value: uint256.NewInt(0x10)},
+			"2-CALLER":    {opcode: byte(vm.CALLER), pc: 2},
+		},
+		nil,
+		{"7-ORIGIN": {opcode: byte(vm.ORIGIN), pc: 7}},
+	}
+	ta.AddTaintSourceMemory(0, 32, byte(vm.CALLDATACOPY), 9)
+	ta.addTaintOpcodeToStorage(slot, 11, byte(vm.TIMESTAMP), uint256.NewInt(0x20))
+
+	snapshot := ta.Dump()
+	assert.EqualValues(t, map[int][]TaintLabel{
+		0: {{ID: "7-ORIGIN", Opcode: "ORIGIN", PC: 7}},
+		2: {
+			{ID: "2-CALLER", Opcode: "CALLER", PC: 2},
+			{ID: "5-CALLVALUE", Opcode: "CALLVALUE", PC: 5, Value: "0x10"},
+		},
+	}, snapshot.Stacks)
+	assert.Len(t, snapshot.Memory, 1)
+	assert.EqualValues(t, "CALLDATACOPY", snapshot.Memory[0].Opcode)
+	assert.EqualValues(t, 9, snapshot.Memory[0].PC)
+	assert.EqualValues(t, 0, snapshot.Memory[0].Start)
+	assert.EqualValues(t, 32, snapshot.Memory[0].End)
+	assert.Len(t, snapshot.Storage[slot.Hex()], 1)
+	assert.EqualValues(t, "TIMESTAMP", snapshot.Storage[slot.Hex()][0].Opcode)
+	assert.EqualValues(t, "0x20", snapshot.Storage[slot.Hex()][0].Value)
+
+	// The snapshot serializes to JSON, and is unaffected by later changes to the taint state.
+	var decoded TaintSnapshot
+	assert.NoError(t, json.Unmarshal([]byte(snapshot.String()), &decoded))
+	assert.EqualValues(t, *snapshot, decoded)
+	ta.popPush(3, 0)
+	assert.Len(t, snapshot.Stacks, 2)
+}
+
+// TestTaintAnalyzerTraceLogger tests that the taint state is only logged around propagated opcodes while something is
+// tainted, and only if a trace logger is set.
+func TestTaintAnalyzerTraceLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.NewLogger(zerolog.TraceLevel)
+	logger.AddWriter(&buf, logging.UNSTRUCTURED, false)
+
+	// Nothing is logged without a trace logger.
+	ta := NewTaintAnalyzer()
+	ta.AddTaintSource(byte(vm.CALLER), 2)
+	ta.propagateTaint(vm.CALLER, 0, nil)
+	assert.Empty(t, buf.String())
+
+	// Nothing is logged while nothing is tainted.
+	ta = NewTaintAnalyzer()
+	ta.SetTraceLogger(logger)
+	ta.propagateTaint(vm.CALLER, 0, nil)
+	assert.Empty(t, buf.String())
+
+	// Once tainted, the state is logged before and after the opcode.
+	ta.AddTaintSource(byte(vm.CALLER), 2)
+	ta.propagateTaint(vm.CALLER, 1, nil)
+	assert.Contains(t, buf.String(), "taint state before CALLER")
+	assert.Contains(t, buf.String(), "taint state after CALLER")
+	assert.Contains(t, buf.String(), "2-CALLER")
+}
@@ -0,0 +1,131 @@
+package bugdetector
+
+import (
+	"github.com/crytic/medusa-geth/core/vm"
+)
+
+// stackEffect describes the amount of items an opcode pops from and pushes onto the stack.
+type stackEffect struct {
+	pops   int
+	pushes int
+	// propagates indicates whether the taint of all popped operands flows into the pushed result. This is only
+	// meaningful for opcodes which push a single item.
+	propagates bool
+}
+
+// opcodeStackEffects describes the stack effect of every opcode supported by the EVM up to and including Prague.
+// DUPn, SWAPn, PUSHn and LOGn are populated by init.
+var opcodeStackEffects = map[vm.OpCode]stackEffect{
+	// 0x00 range: arithmetic
+	vm.STOP:       {0, 0, false},
+	vm.ADD:        {2, 1, true},
+	vm.MUL:        {2, 1, true},
+	vm.SUB:        {2, 1, true},
+	vm.DIV:        {2, 1, true},
+	vm.SDIV:       {2, 1, true},
+	vm.MOD:        {2, 1, true},
+	vm.SMOD:       {2, 1, true},
+	vm.ADDMOD:     {3, 1, true},
+	vm.MULMOD:     {3, 1, true},
+	vm.EXP:        {2, 1, true},
+	vm.SIGNEXTEND: {2, 1, true},
+
+	// 0x10 range: comparison and bitwise logic
+	vm.LT:     {2, 1, true},
+	vm.GT:     {2, 1, true},
+	vm.SLT:    {2, 1, true},
+	vm.SGT:    {2, 1, true},
+	vm.EQ:     {2, 1, true},
+	vm.ISZERO: {1, 1, true},
+	vm.AND:    {2, 1, true},
+	vm.OR:     {2, 1, true},
+	vm.XOR:    {2, 1, true},
+	vm.NOT:    {1, 1, true},
+	vm.BYTE:   {2, 1, true},
+	vm.SHL:    {2, 1, true},
+	vm.SHR:    {2, 1, true},
+	vm.SAR:    {2, 1, true},
+
+	// 0x20 range: hashing
+	vm.KECCAK256: {2, 1, true},
+
+	// 0x30 range: environment information
+	vm.ADDRESS:        {0, 1, false},
+	vm.BALANCE:        {1, 1, true},
+	vm.ORIGIN:         {0, 1, false},
+	vm.CALLER:         {0, 1, false},
+	vm.CALLVALUE:      {0, 1, false},
+	vm.CALLDATALOAD:   {1, 1, true},
+	vm.CALLDATASIZE:   {0, 1, false},
+	vm.CALLDATACOPY:   {3, 0, false},
+	vm.CODESIZE:       {0, 1, false},
+	vm.CODECOPY:       {3, 0, false},
+	vm.GASPRICE:       {0, 1, false},
+	vm.EXTCODESIZE:    {1, 1, true},
+	vm.EXTCODECOPY:    {4, 0, false},
+	vm.RETURNDATASIZE: {0, 1, false},
+	vm.RETURNDATACOPY: {3, 0, false},
+	vm.EXTCODEHASH:    {1, 1, true},
+
+	// 0x40 range: block information
+	vm.BLOCKHASH:   {1, 1, true},
+	vm.COINBASE:    {0, 1, false},
+	vm.TIMESTAMP:   {0, 1, false},
+	vm.NUMBER:      {0, 1, false},
+	vm.DIFFICULTY:  {0, 1, false},
+	vm.GASLIMIT:    {0, 1, false},
+	vm.CHAINID:     {0, 1, false},
+	vm.SELFBALANCE: {0, 1, false},
+	vm.BASEFEE:     {0, 1, false},
+	vm.BLOBHASH:    {1, 1, true},
+	vm.BLOBBASEFEE: {0, 1, false},
+
+	// 0x50 range: stack, memory, storage and flow operations
+	vm.POP:      {1, 0, false},
+	vm.MLOAD:    {1, 1, true},
+	vm.MSTORE:   {2, 0, false},
+	vm.MSTORE8:  {2, 0, false},
+	vm.SLOAD:    {1, 1, true},
+	vm.SSTORE:   {2, 0, false},
+	vm.JUMP:     {1, 0, false},
+	vm.JUMPI:    {2, 0, false},
+	vm.PC:       {0, 1, false},
+	vm.MSIZE:    {0, 1, false},
+	vm.GAS:      {0, 1, false},
+	vm.JUMPDEST: {0, 0, false},
+	vm.TLOAD:    {1, 1, true},
+	vm.TSTORE:   {2, 0, false},
+	vm.MCOPY:    {3, 0, false},
+
+	// 0xf0 range: system operations
+	vm.CREATE:       {3, 1, false},
+	vm.CALL:         {7, 1, false},
+	vm.CALLCODE:     {7, 1, false},
+	vm.RETURN:       {2, 0, false},
+	vm.DELEGATECALL: {6, 1, false},
+	vm.CREATE2:      {4, 1, false},
+	vm.STATICCALL:   {6, 1, false},
+	vm.REVERT:       {2, 0, false},
+	vm.INVALID:      {0, 0, false},
+	vm.SELFDESTRUCT: {1, 0, false},
+}
+
+func init() {
+	opcodeStackEffects[vm.PUSH0] = stackEffect{0, 1, false}
+	for i := 0; i < 32; i++ {
+		opcodeStackEffects[vm.PUSH1+vm.OpCode(i)] = stackEffect{0, 1, false}
+	}
+	for i := 0; i < 16; i++ {
+		opcodeStackEffects[vm.DUP1+vm.OpCode(i)] = stackEffect{i + 1, i + 2, false}
+		opcodeStackEffects[vm.SWAP1+vm.OpCode(i)] = stackEffect{i + 2, i + 2, false}
+	}
+	for i := 0; i < 5; i++ {
+		opcodeStackEffects[vm.LOG0+vm.OpCode(i)] = stackEffect{i + 2, 0, false}
+	}
+}
+
+// lookupStackEffect returns the stack effect of the provided opcode, or false if the opcode is unknown.
+func lookupStackEffect(op vm.OpCode) (stackEffect, bool) {
+	effect, ok := opcodeStackEffects[op]
+	return effect, ok
+}
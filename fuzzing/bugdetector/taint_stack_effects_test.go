@@ -0,0 +1,288 @@
+package bugdetector
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/maps"
+)
+
+// eofOpcodes describes the opcodes only valid in EOF code, which no stack effect is known for.
+var eofOpcodes = []vm.OpCode{
+	vm.DATALOAD, vm.DATALOADN, vm.DATASIZE, vm.DATACOPY,
+	vm.RJUMP, vm.RJUMPI, vm.RJUMPV, vm.CALLF, vm.RETF, vm.JUMPF, vm.DUPN, vm.SWAPN, vm.EXCHANGE,
+	vm.EOFCREATE, vm.RETURNCONTRACT, vm.RETURNDATALOAD, vm.EXTCALL, vm.EXTDELEGATECALL, vm.EXTSTATICCALL,
+}
+
+// stackEffectTest describes the stack effect expected for an opcode.
+type stackEffectTest struct {
+	op     vm.OpCode
+	effect stackEffect
+}
+
+// getStackEffectTests returns the stack effect expected for every opcode supported by the EVM up to and including
+// Prague, as specified by the yellow paper and its EIPs.
+func getStackEffectTests() []stackEffectTest {
+	tests := []stackEffectTest{
+		{vm.STOP, stackEffect{0, 0, false}},
+		{vm.ADD, stackEffect{2, 1, true}},
+		{vm.MUL, stackEffect{2, 1, true}},
+		{vm.SUB, stackEffect{2, 1, true}},
+		{vm.DIV, stackEffect{2, 1, true}},
+		{vm.SDIV, stackEffect{2, 1, true}},
+		{vm.MOD, stackEffect{2, 1, true}},
+		{vm.SMOD, stackEffect{2, 1, true}},
+		{vm.ADDMOD, stackEffect{3, 1, true}},
+		{vm.MULMOD, stackEffect{3, 1, true}},
+		{vm.EXP, stackEffect{2, 1, true}},
+		{vm.SIGNEXTEND, stackEffect{2, 1, true}},
+		{vm.LT, stackEffect{2, 1, true}},
+		{vm.GT, stackEffect{2, 1, true}},
+		{vm.SLT, stackEffect{2, 1, true}},
+		{vm.SGT, stackEffect{2, 1, true}},
+		{vm.EQ, stackEffect{2, 1, true}},
+		{vm.ISZERO, stackEffect{1, 1, true}},
+		{vm.AND, stackEffect{2, 1, true}},
+		{vm.OR, stackEffect{2, 1, true}},
+		{vm.XOR, stackEffect{2, 1, true}},
+		{vm.NOT, stackEffect{1, 1, true}},
+		{vm.BYTE, stackEffect{2, 1, true}},
+		{vm.SHL, stackEffect{2, 1, true}},
+		{vm.SHR, stackEffect{2, 1, true}},
+		{vm.SAR, stackEffect{2, 1, true}},
+		{vm.KECCAK256, stackEffect{2, 1, true}},
+		{vm.ADDRESS, stackEffect{0, 1, false}},
+		{vm.BALANCE, stackEffect{1, 1, true}},
+		{vm.ORIGIN, stackEffect{0, 1, false}},
+		{vm.CALLER, stackEffect{0, 1, false}},
+		{vm.CALLVALUE, stackEffect{0, 1, false}},
+		{vm.CALLDATALOAD, stackEffect{1, 1, true}},
+		{vm.CALLDATASIZE, stackEffect{0, 1, false}},
+		{vm.CALLDATACOPY, stackEffect{3, 0, false}},
+		{vm.CODESIZE, stackEffect{0, 1, false}},
+		{vm.CODECOPY, stackEffect{3, 0, false}},
+		{vm.GASPRICE, stackEffect{0, 1, false}},
+		{vm.EXTCODESIZE, stackEffect{1, 1, true}},
+		{vm.EXTCODECOPY, stackEffect{4, 0, false}},
+		{vm.RETURNDATASIZE, stackEffect{0, 1, false}},
+		{vm.RETURNDATACOPY, stackEffect{3, 0, false}},
+		{vm.EXTCODEHASH, stackEffect{1, 1, true}},
+		{vm.BLOCKHASH, stackEffect{1, 1, true}},
+		{vm.COINBASE, stackEffect{0, 1, false}},
+		{vm.TIMESTAMP, stackEffect{0, 1, false}},
+		{vm.NUMBER, stackEffect{0, 1, false}},
+		{vm.DIFFICULTY, stackEffect{0, 1, false}},
+		{vm.GASLIMIT, stackEffect{0, 1, false}},
+		{vm.CHAINID, stackEffect{0, 1, false}},
+		{vm.SELFBALANCE, stackEffect{0, 1, false}},
+		{vm.BASEFEE, stackEffect{0, 1, false}},
+		{vm.BLOBHASH, stackEffect{1, 1, true}},
+		{vm.BLOBBASEFEE, stackEffect{0, 1, false}},
+		{vm.POP, stackEffect{1, 0, false}},
+		{vm.MLOAD, stackEffect{1, 1, true}},
+		{vm.MSTORE, stackEffect{2, 0, false}},
+		{vm.MSTORE8, stackEffect{2, 0, false}},
+		{vm.SLOAD, stackEffect{1, 1, true}},
+		{vm.SSTORE, stackEffect{2, 0, false}},
+		{vm.JUMP, stackEffect{1, 0, false}},
+		{vm.JUMPI, stackEffect{2, 0, false}},
+		{vm.PC, stackEffect{0, 1, false}},
+		{vm.MSIZE, stackEffect{0, 1, false}},
+		{vm.GAS, stackEffect{0, 1, false}},
+		{vm.JUMPDEST, stackEffect{0, 0, false}},
+		{vm.TLOAD, stackEffect{1, 1, true}},
+		{vm.TSTORE, stackEffect{2, 0, false}},
+		{vm.MCOPY, stackEffect{3, 0, false}},
+		{vm.PUSH0, stackEffect{0, 1, false}},
+		{vm.CREATE, stackEffect{3, 1, false}},
+		{vm.CALL, stackEffect{7, 1, false}},
+		{vm.CALLCODE, stackEffect{7, 1, false}},
+		{vm.RETURN, stackEffect{2, 0, false}},
+		{vm.DELEGATECALL, stackEffect{6, 1, false}},
+		{vm.CREATE2, stackEffect{4, 1, false}},
+		{vm.STATICCALL, stackEffect{6, 1, false}},
+		{vm.REVERT, stackEffect{2, 0, false}},
+		{vm.INVALID, stackEffect{0, 0, false}},
+		{vm.SELFDESTRUCT, stackEffect{1, 0, false}},
+	}
+	for i := 0; i < 32; i++ {
+		tests = append(tests, stackEffectTest{vm.PUSH1 + vm.OpCode(i), stackEffect{0, 1, false}})
+	}
+	for i := 0; i < 16; i++ {
+		tests = append(tests, stackEffectTest{vm.DUP1 + vm.OpCode(i), stackEffect{i + 1, i + 2, false}})
+		tests = append(tests, stackEffectTest{vm.SWAP1 + vm.OpCode(i), stackEffect{i + 2, i + 2, false}})
+	}
+	for i := 0; i < 5; i++ {
+		tests = append(tests, stackEffectTest{vm.LOG0 + vm.OpCode(i), stackEffect{i + 2, 0, false}})
+	}
+	return tests
+}
+
+// TestOpcodeStackEffects tests that the stack effect of every opcode matches its specification, and that every opcode
+// defined outside of EOF has a stack effect.
+func TestOpcodeStackEffects(t *testing.T) {
+	tests := getStackEffectTests()
+	tested := make(map[vm.OpCode]bool)
+	for _, test := range tests {
+		effect, known := lookupStackEffect(test.op)
+		assert.True(t, known, test.op.String())
+		assert.EqualValues(t, test.effect, effect, test.op.String())
+		tested[test.op] = true
+	}
+
+	for i := 0; i < 256; i++ {
+		op := vm.OpCode(i)
+		_, known := lookupStackEffect(op)
+		switch {
+		case strings.Contains(op.String(), "not defined"):
+			assert.False(t, known, op.String())
+		case slices.Contains(eofOpcodes, op):
+			assert.False(t, known, op.String())
+		default:
+			assert.True(t, tested[op], "no stack effect test for %v", op.String())
+		}
+	}
+}
+
+// taintedStack creates a taint stack of the provided depth, where the item at index i from the top is tainted by the
+// string ID "i".
+func taintedStack(depth int) []TaintOpcodes {
+	taintStacks := make([]TaintOpcodes, depth)
+	for i := 0; i < depth; i++ {
+		taintStacks[depth-1-i] = TaintOpcodes{fmt.Sprint(i): &TaintOpcode{}}
+	}
+	return taintStacks
+}
+
+// stackItemIds returns the sorted taint IDs of the item at the provided index from the top of the taint stack.
+func stackItemIds(ta *TaintAnalyzer, stackIndex int) []string {
+	ids := maps.Keys(ta.stackItem(stackIndex))
+	slices.Sort(ids)
+	return ids
+}
+
+// zeroStackBack returns a zero word for any item of the real stack.
+func zeroStackBack(int) *uint256.Int {
+	return new(uint256.Int)
+}
+
+// TestPropagateTaintStackEffects tests that propagating the taint of each opcode pops and pushes taint stack items
+// according to its stack effect, merging the taint of popped operands into the result if the effect propagates.
+func TestPropagateTaintStackEffects(t *testing.T) {
+	const depth = 24
+	for _, test := range getStackEffectTests() {
+		op, effect := test.op, test.effect
+		ta := NewTaintAnalyzer()
+		ta.taintStacks = taintedStack(depth)
+		ta.propagateTaint(op, depth, zeroStackBack)
+		assert.Len(t, ta.taintStacks, depth-effect.pops+effect.pushes, op.String())
+		assert.Zero(t, ta.StackDesyncs(), op.String())
+
+		switch {
+		case op >= vm.DUP1 && op <= vm.DUP16:
+			// The n-th item is copied to the top, and the copy does not alias the original.
+			n := int(op - vm.DUP1 + 1)
+			assert.EqualValues(t, []string{fmt.Sprint(n - 1)}, stackItemIds(ta, 0), op.String())
+			assert.EqualValues(t, []string{fmt.Sprint(n - 1)}, stackItemIds(ta, n), op.String())
+			ta.stackItem(0)["copy"] = &TaintOpcode{}
+			assert.EqualValues(t, []string{fmt.Sprint(n - 1)}, stackItemIds(ta, n), op.String())
+		case op >= vm.SWAP1 && op <= vm.SWAP16:
+			// The top and the n+1-th items are swapped, while those in between are untouched.
+			n := int(op - vm.SWAP1 + 1)
+			assert.EqualValues(t, []string{fmt.Sprint(n)}, stackItemIds(ta, 0), op.String())
+			assert.EqualValues(t, []string{"0"}, stackItemIds(ta, n), op.String())
+			for i := 1; i < n; i++ {
+				assert.EqualValues(t, []string{fmt.Sprint(i)}, stackItemIds(ta, i), op.String())
+			}
+		case effect.propagates:
+			// The result is tainted by every operand.
+			expectedIds := make([]string, 0)
+			for i := 0; i < effect.pops; i++ {
+				expectedIds = append(expectedIds, fmt.Sprint(i))
+			}
+			slices.Sort(expectedIds)
+			assert.EqualValues(t, expectedIds, stackItemIds(ta, 0), op.String())
+		default:
+			// Pushed items are untainted.
+			for i := 0; i < effect.pushes; i++ {
+				assert.Empty(t, ta.stackItem(i), op.String())
+			}
+		}
+
+		// Items below the operands are untouched.
+		if op < vm.DUP1 || op > vm.SWAP16 {
+			for i := 0; i < depth-effect.pops; i++ {
+				assert.EqualValues(t, []string{fmt.Sprint(effect.pops + i)}, stackItemIds(ta, effect.pushes+i), op.String())
+			}
+		}
+	}
+}
+
+// TestPropagateTaintPendingTaints tests that taint sources added for an opcode taint the item it pushes, and are
+// discarded if it pushes none or its stack effect is unknown.
+func TestPropagateTaintPendingTaints(t *testing.T) {
+	// Sources taint the item pushed, along with the taint of the operands if it propagates.
+	ta := NewTaintAnalyzer()
+	ta.taintStacks = taintedStack(2)
+	ta.AddTaintSourceByOpcode(byte(vm.CALLDATALOAD))
+	ta.propagateTaint(vm.CALLDATALOAD, 2, zeroStackBack)
+	assert.True(t, ta.IsTaintedByOpcode(byte(vm.CALLDATALOAD), 0))
+	assert.True(t, ta.IsTaintedByString("0", 0))
+	assert.False(t, ta.IsTaintedByOpcode(byte(vm.CALLDATALOAD), 1))
+	assert.Empty(t, ta.pendingTaints)
+
+	// Sources of opcodes pushing no item are discarded.
+	ta.AddTaintSourceByString("POP")
+	ta.propagateTaint(vm.POP, 2, zeroStackBack)
+	assert.Empty(t, ta.pendingTaints)
+	ta.propagateTaint(vm.PUSH0, 1, zeroStackBack)
+	assert.False(t, ta.IsTaintedByString("POP", 0))
+
+	// Sources of opcodes with an unknown stack effect are discarded too, and do not taint the next item pushed.
+	ta.AddTaintSourceByString("RJUMP")
+	ta.propagateTaint(vm.RJUMP, 2, zeroStackBack)
+	assert.Empty(t, ta.pendingTaints)
+	ta.propagateTaint(vm.PUSH0, 2, zeroStackBack)
+	assert.False(t, ta.IsTaintedByString("RJUMP", 0))
+	assert.Zero(t, ta.StackDesyncs())
+}
+
+// TestCheckStackDepth tests that taint stacks out of sync with the real stack are counted as desyncs after opcodes
+// with a known stack effect, and that the taint stack is realigned with the real stack, keeping top items aligned.
+func TestCheckStackDepth(t *testing.T) {
+	// A real stack matching the expected depth is not a desync.
+	ta := NewTaintAnalyzer()
+	ta.taintStacks = taintedStack(3)
+	ta.propagateTaint(vm.ADD, 3, zeroStackBack)
+	ta.checkStackDepth(vm.STOP, 2)
+	assert.Zero(t, ta.StackDesyncs())
+
+	// A shallower real stack is a desync, and the deepest taint stack items are dropped.
+	ta.checkStackDepth(vm.STOP, 1)
+	assert.EqualValues(t, 1, ta.StackDesyncs())
+	assert.Len(t, ta.taintStacks, 1)
+	assert.EqualValues(t, []string{"0", "1"}, stackItemIds(ta, 0))
+
+	// A deeper real stack is a desync, and untainted items are added at the bottom of the taint stack.
+	ta.propagateTaint(vm.PUSH0, 1, zeroStackBack)
+	ta.checkStackDepth(vm.STOP, 4)
+	assert.EqualValues(t, 2, ta.StackDesyncs())
+	assert.Len(t, ta.taintStacks, 4)
+	assert.Empty(t, ta.stackItem(0))
+	assert.EqualValues(t, []string{"0", "1"}, stackItemIds(ta, 1))
+	assert.Empty(t, ta.stackItem(2))
+	assert.Empty(t, ta.stackItem(3))
+
+	// The depth after an opcode with an unknown stack effect is not expected, so it is realigned without a desync.
+	ta.propagateTaint(vm.RJUMP, 4, zeroStackBack)
+	ta.checkStackDepth(vm.STOP, 2)
+	assert.EqualValues(t, 2, ta.StackDesyncs())
+	assert.Len(t, ta.taintStacks, 2)
+	assert.Empty(t, ta.stackItem(0))
+	assert.EqualValues(t, []string{"0", "1"}, stackItemIds(ta, 1))
+}
@@ -0,0 +1,36 @@
+package bugdetector
+
+import (
+	"fmt"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core/tracing"
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/dataflow"
+)
+
+// detect_uninitialized_storage_read reports SLOADs of declared state variables which were never written in the call
+// sequence and are zero, which may indicate configuration which was never initialized. Writes are recorded in the
+// tracer's sequence writes, which may be shared with the dataflow tracer.
+func detect_uninitialized_storage_read(tracer *BugDetectorTracer, pc uint64, opcode byte, scope tracing.OpContext) {
+	if tracer.sequenceWrites == nil || (vm.OpCode(opcode) != vm.SLOAD && vm.OpCode(opcode) != vm.SSTORE) {
+		return
+	}
+
+	lastCall := tracer.callFrameStates[len(tracer.callFrameStates)-1]
+	scopeContext := scope.(*vm.ScopeContext)
+	variable := &dataflow.StorageSlot{Address: scopeContext.Contract.Address(), Slot: scopeContext.Stack.Back(0).Clone()}
+	if vm.OpCode(opcode) == vm.SSTORE {
+		tracer.sequenceWrites.SetWrite(variable, tracer.evm.BlockNumber.Uint64())
+		return
+	}
+
+	if tracer.helperContract == lastCall.to {
+		return
+	}
+	value := tracer.evm.StateDB.GetState(variable.Address, common.Hash(variable.Slot.Bytes32()))
+	if tracer.sequenceWrites.IsUninitializedRead(variable, value) {
+		id := fmt.Sprintf("UNINITIALIZEDSTORAGEREAD-%s-%d-%s", lastCall.codeAddress.Hex(), pc, variable.Slot.Hex())
+		tracer.bugMap.CoverBug(id)
+	}
+}
@@ -2,20 +2,62 @@ package bugdetector
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/crytic/medusa-geth/common"
 	"github.com/crytic/medusa-geth/core/tracing"
 	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/holiman/uint256"
 )
 
+// unsafeDelegatecallTaintSourceStackOpcodes describes the opcodes pushing values controlled by the caller onto the
+// stack, which are taint sources for unsafe delegatecalls.
+var unsafeDelegatecallTaintSourceStackOpcodes = []vm.OpCode{vm.CALLDATALOAD, vm.CALLDATASIZE, vm.CALLVALUE, vm.GASPRICE, vm.ORIGIN, vm.CALLER}
+
 func isUnsafeDelegatecallTaintSourceStack(opcode byte) bool {
-	op := vm.OpCode(opcode)
-	return op == vm.CALLDATALOAD ||
-		op == vm.CALLDATASIZE ||
-		op == vm.CALLVALUE ||
-		op == vm.GASPRICE ||
-		op == vm.ORIGIN ||
-		op == vm.CALLER
+	return slices.Contains(unsafeDelegatecallTaintSourceStackOpcodes, vm.OpCode(opcode))
+}
+
+// unsafeDelegatecallTaintSourceValue returns the concrete value an unsafe delegatecall taint source opcode is about to
+// push onto the stack, or nil if it cannot be determined.
+func unsafeDelegatecallTaintSourceValue(tracer *BugDetectorTracer, opcode byte, scope tracing.OpContext) *uint256.Int {
+	switch vm.OpCode(opcode) {
+	case vm.CALLDATALOAD:
+		scopeContext := scope.(*vm.ScopeContext)
+		offset, overflow := scopeContext.Stack.Back(0).Uint64WithOverflow()
+		word := make([]byte, 32)
+		input := scope.CallInput()
+		if !overflow && offset < uint64(len(input)) {
+			copy(word, input[offset:])
+		}
+		return new(uint256.Int).SetBytes(word)
+	case vm.CALLDATASIZE:
+		return uint256.NewInt(uint64(len(scope.CallInput())))
+	case vm.CALLVALUE:
+		return new(uint256.Int).Set(scope.CallValue())
+	case vm.CALLER:
+		return new(uint256.Int).SetBytes(scope.Caller().Bytes())
+	case vm.ORIGIN:
+		return new(uint256.Int).SetBytes(tracer.txOrigin.Bytes())
+	case vm.GASPRICE:
+		if tracer.txGasPrice != nil {
+			value, _ := uint256.FromBig(tracer.txGasPrice)
+			return value
+		}
+	}
+	return nil
+}
+
+// unsafeDelegatecallSinkValue returns the first concrete source value which reached the delegatecall arguments.
+func unsafeDelegatecallSinkValue(ta *TaintAnalyzer) *uint256.Int {
+	for stackIndex := 0; stackIndex < 4; stackIndex++ {
+		for _, opcode := range unsafeDelegatecallTaintSourceStackOpcodes {
+			if values := ta.TaintValuesByOpcode(byte(opcode), stackIndex); len(values) > 0 {
+				return values[0]
+			}
+		}
+	}
+	return nil
 }
 
 func isUnsafeDelegatecallTaintSourceMemory(opcode byte, scope tracing.OpContext) (bool, uint64, uint64) {
@@ -37,7 +79,8 @@ func isTaintedByUnsafeDelegatecall(ta *TaintAnalyzer, stackIndex int) bool {
 		ta.IsTaintedByOpcode(byte(vm.CALLVALUE), stackIndex) ||
 		ta.IsTaintedByOpcode(byte(vm.GASPRICE), stackIndex) ||
 		ta.IsTaintedByOpcode(byte(vm.ORIGIN), stackIndex) ||
-		ta.IsTaintedByOpcode(byte(vm.CALLDATACOPY), stackIndex)
+		ta.IsTaintedByOpcode(byte(vm.CALLDATACOPY), stackIndex) ||
+		ta.IsTaintedByOpcode(byte(vm.RETURNDATACOPY), stackIndex)
 }
 
 func isUnsafeDelegatecallTaintSunk(ta *TaintAnalyzer) bool {
@@ -52,9 +95,8 @@ func isUnsafeDelegatecallTaintMemorySunk(ta *TaintAnalyzer, scope tracing.OpCont
 	argsOffset := scopeContext.Stack.Back(2).Uint64()
 	argsSize := scopeContext.Stack.Back(3).Uint64()
 
-	// fmt.Println(argsOffset, argsOffset+argsSize)
-
-	return ta.IsTantedMemoryByOpcode(byte(vm.CALLDATACOPY), argsOffset, argsOffset+argsSize)
+	return ta.IsTantedMemoryByOpcode(byte(vm.CALLDATACOPY), argsOffset, argsOffset+argsSize) ||
+		ta.IsTantedMemoryByOpcode(byte(vm.RETURNDATACOPY), argsOffset, argsOffset+argsSize)
 }
 
 func detect_unsafe_delegatecall(tracer *BugDetectorTracer, pc uint64, opcode byte, scope tracing.OpContext) {
@@ -75,7 +117,7 @@ func detect_unsafe_delegatecall(tracer *BugDetectorTracer, pc uint64, opcode byt
 	}
 	if isFromAttacker {
 		if isUnsafeDelegatecallTaintSourceStack(opcode) {
-			lastCall.taintAnalyzer.AddTaintSourceByOpcode(opcode)
+			lastCall.taintAnalyzer.AddTaintSourceByOpcodeWithValue(opcode, unsafeDelegatecallTaintSourceValue(tracer, opcode, scope))
 		}
 		isSource, start, end := isUnsafeDelegatecallTaintSourceMemory(opcode, scope)
 		if isSource {
@@ -110,7 +152,7 @@ func detect_unsafe_delegatecall(tracer *BugDetectorTracer, pc uint64, opcode byt
 
 		if flag {
 			id := fmt.Sprintf("UNSAFEDELEGATECALL-%s-%d-%s", lastCall.codeAddress, pc, vm.OpCode(opcode).String())
-			tracer.bugMap.CoverBug(id)
+			tracer.bugMap.CoverBugWithValue(id, unsafeDelegatecallSinkValue(lastCall.taintAnalyzer))
 		}
 
 	}
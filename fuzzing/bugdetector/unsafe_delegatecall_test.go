@@ -0,0 +1,31 @@
+package bugdetector
+
+import (
+	"testing"
+
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestUnsafeDelegatecallSinkValue tests that the value reported for an unsafe delegatecall is that of any stack taint
+// source reaching its arguments, including the caller.
+func TestUnsafeDelegatecallSinkValue(t *testing.T) {
+	for _, opcode := range unsafeDelegatecallTaintSourceStackOpcodes {
+		ta := NewTaintAnalyzer()
+		assert.Nil(t, unsafeDelegatecallSinkValue(ta))
+
+		// The delegatecall arguments are the four items at the top of the stack, the deepest of which is tainted.
+		taint := &TaintOpcode{opcode: byte(opcode), value: uint256.NewInt(uint64(opcode))}
+		ta.taintStacks = []TaintOpcodes{{taint.id(): taint}, nil, nil, nil}
+		value := unsafeDelegatecallSinkValue(ta)
+		if assert.NotNil(t, value, opcode.String()) {
+			assert.EqualValues(t, opcode, value.Uint64())
+		}
+
+		// Taints beyond the delegatecall arguments are not reported.
+		ta.taintStacks = append([]TaintOpcodes{{taint.id(): taint}}, nil, nil, nil, nil)
+		assert.Nil(t, unsafeDelegatecallSinkValue(ta), opcode.String())
+	}
+	assert.Contains(t, unsafeDelegatecallTaintSourceStackOpcodes, vm.CALLER)
+}
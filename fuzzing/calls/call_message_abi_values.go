@@ -112,6 +112,15 @@ func (d *CallMessageDataAbiValues) Resolve(contractAbi abi.ABI) error {
 	return nil
 }
 
+// MethodSignature returns the signature of the method targeted, which is available before Resolve is called if the
+// data was deserialized.
+func (d *CallMessageDataAbiValues) MethodSignature() string {
+	if d.Method != nil {
+		return d.Method.Sig
+	}
+	return d.methodSignature
+}
+
 // Pack packs all the ABI argument InputValues into call data for the relevant Method it targets. If this was
 // deserialized, Resolve must be called first to resolve necessary runtime data (such as the Method).
 func (d *CallMessageDataAbiValues) Pack() ([]byte, error) {
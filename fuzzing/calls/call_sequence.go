@@ -3,7 +3,10 @@ package calls
 import (
 	"encoding/binary"
 	"fmt"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/crytic/medusa/chain"
 
@@ -11,10 +14,13 @@ import (
 	"github.com/crytic/medusa-geth/common"
 	"github.com/crytic/medusa-geth/crypto"
 	chainTypes "github.com/crytic/medusa/chain/types"
+	"github.com/crytic/medusa/compilation/abiutils"
 	fuzzingTypes "github.com/crytic/medusa/fuzzing/contracts"
 	"github.com/crytic/medusa/fuzzing/executiontracer"
+	"github.com/crytic/medusa/fuzzing/statediff"
 	"github.com/crytic/medusa/fuzzing/valuegeneration"
 	"github.com/crytic/medusa/logging"
+	"github.com/crytic/medusa/logging/colors"
 	"github.com/crytic/medusa/utils"
 )
 
@@ -36,17 +42,50 @@ func (cs CallSequence) Log() *logging.LogBuffer {
 		// Add the string representing the call
 		buffer.Append(fmt.Sprintf("%d) %s\n", i+1, cs[i].String()))
 
-		// If we have an execution trace attached, print information about it.
+		// If the call dispatched nested calls (e.g. multicall), add the inner calls it encoded.
+		for _, nestedCallString := range cs[i].NestedCallStrings() {
+			buffer.Append(nestedCallString, "\n")
+		}
+
+		// If we have an execution trace attached, print information about it. It includes the strings logged by the
+		// call, which we otherwise print on their own.
 		if cs[i].ExecutionTrace != nil {
 			buffer.Append(cs[i].ExecutionTrace.Log().Elements()...)
 			buffer.Append("\n")
+		} else if len(cs[i].ConsoleLogs) > 0 {
+			buffer.Append(colors.Bold, "[Logs]", colors.Reset, "\n")
+			for _, consoleLog := range cs[i].ConsoleLogs {
+				buffer.Append(colors.BULLET_POINT, " ", consoleLog, "\n")
+			}
 		}
 	}
 
+	// If state diffs were collected, add the state changes made by the whole sequence.
+	if stateDiff := cs.StateDiff(); stateDiff != nil {
+		buffer.Append(colors.Bold, "[State Changes]", colors.Reset, "\n")
+		buffer.Append(stateDiff.Log().Elements()...)
+	}
+
 	// Return the buffer
 	return buffer
 }
 
+// StateDiff merges the state diffs collected for each call of the call sequence.
+// Returns the state changes made by the call sequence, or nil if no call has a state diff attached.
+func (cs CallSequence) StateDiff() *statediff.StateDiff {
+	var stateDiff *statediff.StateDiff
+	for _, element := range cs {
+		if element.StateDiff == nil {
+			continue
+		}
+		if stateDiff == nil {
+			stateDiff = statediff.NewStateDiff()
+		}
+		stateDiff.Merge(element.StateDiff)
+	}
+	return stateDiff
+}
+
 // String returns the string representation of this call sequence
 func (cs CallSequence) String() string {
 	// Internally, we just call the log function, get the list of elements and create their non-colorized string representation
@@ -144,6 +183,11 @@ type CallSequenceElement struct {
 	// value will not be used.
 	BlockTimestampDelay uint64 `json:"blockTimestampDelay"`
 
+	// TimeJump describes the portion of BlockTimestampDelay which was applied by the time acceleration schedule, to
+	// advance the chain by days or weeks between sequence segments. It is informational, as BlockTimestampDelay already
+	// accounts for it.
+	TimeJump uint64 `json:"timeJump,omitempty"`
+
 	// ChainReference describes the inclusion of the Call as a transaction in a block. This block may not yet be
 	// committed to its underlying chain if this is a CallSequenceElement was just executed. Additional transactions
 	// may be included before the block is committed. This reference will remain compatible after the block finalizes.
@@ -151,6 +195,18 @@ type CallSequenceElement struct {
 
 	// ExecutionTrace represents a verbose execution trace collected. Nil if an execution trace was not collected.
 	ExecutionTrace *executiontracer.ExecutionTrace `json:"-"`
+
+	// ConsoleLogs describes the strings logged through console.log by the call when it was last executed. Nil if it
+	// logged nothing or logs were not captured.
+	ConsoleLogs []string `json:"-"`
+
+	// StateDiff describes the state changes made by the call when it was last executed. Nil if it made no state change
+	// or a state diff was not collected.
+	StateDiff *statediff.StateDiff `json:"stateDiff,omitempty"`
+
+	// MockResponses describes the return data of the calls the transaction made to mock contracts when it was last
+	// executed, in order. They are replayed when the call is executed again, so that it observes the same values.
+	MockResponses []*chainTypes.MockResponse `json:"mockResponses,omitempty"`
 }
 
 // NewCallSequenceElement returns a new CallSequenceElement struct to track a single call made within a CallSequence.
@@ -180,8 +236,12 @@ func (cse *CallSequenceElement) Clone() (*CallSequenceElement, error) {
 		Call:                clonedCall,
 		BlockNumberDelay:    cse.BlockNumberDelay,
 		BlockTimestampDelay: cse.BlockTimestampDelay,
+		TimeJump:            cse.TimeJump,
 		ChainReference:      cse.ChainReference,
 		ExecutionTrace:      cse.ExecutionTrace,
+		ConsoleLogs:         cse.ConsoleLogs,
+		StateDiff:           cse.StateDiff,
+		MockResponses:       slices.Clone(cse.MockResponses),
 	}
 	return clone, nil
 }
@@ -229,6 +289,51 @@ func (cse *CallSequenceElement) DecodedReturnValues() ([]any, error) {
 	return decodedReturnValues, nil
 }
 
+// NestedCalls decodes the calls encoded within the arguments of the CallSequenceElement.Call against the ABI of its
+// contract (e.g. the calls provided to multicall(bytes[])). Returns nil if no nested calls could be decoded.
+func (cse *CallSequenceElement) NestedCalls() []*abiutils.NestedCall {
+	method, err := cse.Method()
+	if err != nil || method == nil || len(cse.Call.Data) < 4 {
+		return nil
+	}
+	args, err := method.Inputs.Unpack(cse.Call.Data[4:])
+	if err != nil {
+		return nil
+	}
+	return abiutils.UnpackNestedCalls(&cse.Contract.CompiledContract().Abi, method.Inputs, args)
+}
+
+// NestedCallStrings returns a displayable string for each call encoded within the arguments of the
+// CallSequenceElement.Call, indented by their nesting depth.
+func (cse *CallSequenceElement) NestedCallStrings() []string {
+	nestedCalls := cse.NestedCalls()
+	if len(nestedCalls) == 0 {
+		return nil
+	}
+
+	// Obtain our contract name and labels
+	contractName := cse.Contract.Name()
+	var labels map[common.Address]string
+	if cse.ChainReference != nil {
+		labels = chain.GetLabels(cse.ChainReference.MessageResults())
+	}
+
+	var nestedCallStrings []string
+	var appendNestedCallStrings func(nestedCalls []*abiutils.NestedCall, depth int)
+	appendNestedCallStrings = func(nestedCalls []*abiutils.NestedCall, depth int) {
+		for _, nestedCall := range nestedCalls {
+			argsText, err := valuegeneration.EncodeABIArgumentsToString(nestedCall.Method.Inputs, nestedCall.Args, labels)
+			if err != nil {
+				argsText = "<unresolved args>"
+			}
+			nestedCallStrings = append(nestedCallStrings, fmt.Sprintf("%s-> %s.%s(%s)", strings.Repeat("\t", depth), contractName, nestedCall.Method.Sig, argsText))
+			appendNestedCallStrings(nestedCall.NestedCalls, depth+1)
+		}
+	}
+	appendNestedCallStrings(nestedCalls, 1)
+	return nestedCallStrings
+}
+
 // String returns a displayable string representing the CallSequenceElement.
 func (cse *CallSequenceElement) String() string {
 	// Obtain our contract name
@@ -268,14 +373,21 @@ func (cse *CallSequenceElement) String() string {
 	// Trim the leading zeros and use the labels
 	fromAddress := utils.AttachLabelToAddress(cse.Call.From, labels[cse.Call.From])
 
+	// If the time acceleration schedule advanced the chain before this call, note the jump.
+	timeJumpStr := ""
+	if cse.TimeJump > 0 {
+		timeJumpStr = fmt.Sprintf(", time jump=+%v", time.Duration(cse.TimeJump)*time.Second)
+	}
+
 	// Return a formatted string representing this element.
 	return fmt.Sprintf(
-		"%s.%s(%s) (block=%s, time=%s, gas=%d, gasprice=%s, value=%s, sender=%s)",
+		"%s.%s(%s) (block=%s, time=%s%s, gas=%d, gasprice=%s, value=%s, sender=%s)",
 		contractName,
 		methodName,
 		argsText,
 		blockNumberStr,
 		blockTimeStr,
+		timeJumpStr,
 		cse.Call.GasLimit,
 		cse.Call.GasPrice.String(),
 		cse.Call.Value.String(),
@@ -8,6 +8,7 @@ import (
 	"github.com/crytic/medusa/fuzzing/config"
 	"github.com/crytic/medusa/fuzzing/contracts"
 	"github.com/crytic/medusa/fuzzing/executiontracer"
+	"github.com/crytic/medusa/fuzzing/statediff"
 	"github.com/crytic/medusa/utils"
 )
 
@@ -109,7 +110,9 @@ func ExecuteCallSequenceIteratively(chain *chain.TestChain, fetchElementFunc Exe
 			}
 		}
 
-		// Add our transaction to this block.
+		// Add our transaction to this block, replaying the responses mock contracts returned to it, if it was executed
+		// before.
+		chain.SetMockResponses(callSequenceElement.MockResponses)
 		err = chain.PendingBlockAddTx(callSequenceElement.Call.ToCoreMessage(), additionalTracers...)
 		if err != nil {
 			return callSequenceExecuted, err
@@ -121,6 +124,15 @@ func ExecuteCallSequenceIteratively(chain *chain.TestChain, fetchElementFunc Exe
 			TransactionIndex: len(chain.PendingBlock().Messages) - 1,
 		}
 
+		// Record the responses mock contracts returned to the call, so it observes them again when replayed.
+		callSequenceElement.MockResponses = callSequenceElement.ChainReference.MessageResults().MockResponses
+
+		// Attach the strings the call logged, if a console.log tracer captured them.
+		callSequenceElement.ConsoleLogs = executiontracer.GetConsoleLogTracerResults(callSequenceElement.ChainReference.MessageResults())
+
+		// Attach the state changes the call made, if a state diff tracer recorded them.
+		callSequenceElement.StateDiff = statediff.GetStateDiffTracerResults(callSequenceElement.ChainReference.MessageResults())
+
 		// Add to our executed call sequence
 		callSequenceExecuted = append(callSequenceExecuted, callSequenceElement)
 
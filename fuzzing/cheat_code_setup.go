@@ -0,0 +1,118 @@
+package fuzzing
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/crytic/medusa-geth/accounts/abi"
+	coreTypes "github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa/chain"
+	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/fuzzing/valuegeneration"
+)
+
+// callSetupCheatCodes calls the project configuration's setup cheat codes from the deployer, with transactions
+// committed in a new block of the provided test chain, so that the preconditions they establish are replayed onto
+// every worker's chain. Cheat codes are pre-compiles, so the calls record no coverage.
+// Returns an error if a cheat code could not be called or reverted.
+func (f *Fuzzer) callSetupCheatCodes(testChain *chain.TestChain) error {
+	cheatCodeCalls := f.config.Fuzzing.SetupCheatCodes
+	if len(cheatCodeCalls) == 0 {
+		return nil
+	}
+	cheatCodeContract := testChain.StandardCheatCodeContract()
+	if cheatCodeContract == nil {
+		return fmt.Errorf("setup cheat codes are called, but cheat codes are disabled")
+	}
+
+	// Create the messages calling each cheat code.
+	msgs := make([]*calls.CallMessage, 0, len(cheatCodeCalls))
+	for _, cheatCodeCall := range cheatCodeCalls {
+		method, err := getCheatCodeMethod(cheatCodeContract, cheatCodeCall.Method)
+		if err != nil {
+			return err
+		}
+		args, err := valuegeneration.DecodeJSONArgumentsFromSlice(method.Inputs, cheatCodeCall.Args, nil)
+		if err != nil {
+			return fmt.Errorf("invalid arguments for cheat code %s: %v", cheatCodeCall.Method, err)
+		}
+		msg, err := f.newCheatCodeMessage(testChain, method.Sig, args...)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, msg)
+	}
+
+	// Execute the messages in a single block.
+	block, err := testChain.PendingBlockCreate()
+	if err != nil {
+		return err
+	}
+	for _, msg := range msgs {
+		msg.FillFromTestChainProperties(testChain)
+		if err = testChain.PendingBlockAddTx(msg.ToCoreMessage()); err != nil {
+			return err
+		}
+	}
+	if err = testChain.PendingBlockCommit(); err != nil {
+		return err
+	}
+	for i, messageResult := range block.MessageResults {
+		if messageResult.Receipt.Status != coreTypes.ReceiptStatusSuccessful {
+			return fmt.Errorf("cheat code %s reverted: %v", cheatCodeCalls[i].Method, messageResult.ExecutionResult.Err)
+		}
+	}
+
+	f.logger.Info(fmt.Sprintf("Called %d setup cheat code(s)", len(cheatCodeCalls)))
+	return nil
+}
+
+// getCheatCodeMethod returns the method of the provided cheat code contract with the provided name or signature. Cheat
+// code contracts key their methods by signature, as some of their names are overloaded, so methods are only resolved by
+// name if it is not overloaded.
+// Returns the method, or an error if no method or several methods match.
+func getCheatCodeMethod(cheatCodeContract *chain.CheatCodeContract, method string) (abi.Method, error) {
+	if abiMethod, ok := cheatCodeContract.Abi().Methods[method]; ok {
+		return abiMethod, nil
+	}
+	signatures := make([]string, 0)
+	for signature, abiMethod := range cheatCodeContract.Abi().Methods {
+		if abiMethod.Name == method {
+			signatures = append(signatures, signature)
+		}
+	}
+	switch len(signatures) {
+	case 0:
+		return abi.Method{}, fmt.Errorf("unknown cheat code %s", method)
+	case 1:
+		return cheatCodeContract.Abi().Methods[signatures[0]], nil
+	default:
+		sort.Strings(signatures)
+		return abi.Method{}, fmt.Errorf("cheat code %s is overloaded, specify one of %s", method, strings.Join(signatures, ", "))
+	}
+}
+
+// newCheatCodeMessage creates a message from the deployer calling the provided method of the standard cheat code
+// contract, given by name or signature (see getCheatCodeMethod), with the provided arguments. Its gas limit is that of
+// fuzzed transactions, so that several such messages fit in a single block.
+// Returns the message, or an error if cheat codes are disabled, the method could not be resolved, or the arguments
+// could not be packed.
+func (f *Fuzzer) newCheatCodeMessage(testChain *chain.TestChain, method string, args ...any) (*calls.CallMessage, error) {
+	cheatCodeContract := testChain.StandardCheatCodeContract()
+	if cheatCodeContract == nil {
+		return nil, fmt.Errorf("the %s cheat code is required, but cheat codes are disabled", method)
+	}
+	abiMethod, err := getCheatCodeMethod(cheatCodeContract, method)
+	if err != nil {
+		return nil, err
+	}
+	packedArgs, err := abiMethod.Inputs.Pack(args...)
+	if err != nil {
+		return nil, err
+	}
+	data := append(slices.Clone(abiMethod.ID), packedArgs...)
+	return calls.NewCallMessage(f.deployer, &chain.StandardCheatcodeContractAddress, 0, big.NewInt(0), f.config.Fuzzing.TransactionGasLimit, nil, nil, nil, data), nil
+}
@@ -0,0 +1,63 @@
+package fuzzing
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/chain"
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/crytic/medusa/fuzzing/executiontracer"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSetupCheatCodes ensures the configured setup cheat codes are called once the base test chain is set up, so the
+// preconditions they establish hold on the chains workers clone from it before executing their first call sequence,
+// and are included in the ether adversarial addresses hold originally.
+func TestSetupCheatCodes(t *testing.T) {
+	target := common.HexToAddress("0x12345")
+	slot, value := common.HexToHash("0x1"), common.HexToHash("0x2a")
+	projectConfig, err := config.GetDefaultProjectConfig("")
+	assert.NoError(t, err)
+	sender := common.HexToAddress(projectConfig.Fuzzing.SenderAddresses[0])
+	projectConfig.Fuzzing.SetupCheatCodes = []config.CheatCodeCallConfig{
+		{Method: "deal", Args: []any{target.Hex(), "1000"}},
+		{Method: "store", Args: []any{target.Hex(), slot.Hex(), value.Hex()}},
+		{Method: "deal", Args: []any{sender.Hex(), "5000"}},
+	}
+	fuzzer, err := NewFuzzer(*projectConfig)
+	assert.NoError(t, err)
+	fuzzer.Hooks.ChainSetupFunc = func(fuzzer *Fuzzer, testChain *chain.TestChain) (*executiontracer.ExecutionTrace, error) {
+		return nil, nil
+	}
+
+	baseTestChain, baseAdversaries, err := fuzzer.createBaseTestChain(false)
+	assert.NoError(t, err)
+	defer baseTestChain.Close()
+	workerChain, err := baseTestChain.Clone(nil)
+	assert.NoError(t, err)
+	defer workerChain.Close()
+	for _, testChain := range []*chain.TestChain{baseTestChain, workerChain} {
+		assert.EqualValues(t, big.NewInt(1000), testChain.State().GetBalance(target).ToBig())
+		assert.EqualValues(t, value, testChain.State().GetState(target, slot))
+		assert.EqualValues(t, big.NewInt(5000), testChain.State().GetBalance(sender).ToBig())
+	}
+	originalEther := big.NewInt(0)
+	for _, address := range baseAdversaries.addresses {
+		originalEther.Add(originalEther, baseTestChain.State().GetBalance(address).ToBig())
+	}
+	assert.EqualValues(t, originalEther, baseAdversaries.originalEther)
+
+	// Unknown cheat codes, and overloaded ones given by name rather than signature, fail the set up.
+	fuzzer.config.Fuzzing.SetupCheatCodes = []config.CheatCodeCallConfig{{Method: "unknown"}}
+	_, _, err = fuzzer.createBaseTestChain(false)
+	assert.ErrorContains(t, err, "unknown cheat code")
+	fuzzer.config.Fuzzing.SetupCheatCodes = []config.CheatCodeCallConfig{{Method: "toString", Args: []any{target.Hex()}}}
+	_, _, err = fuzzer.createBaseTestChain(false)
+	assert.ErrorContains(t, err, "toString(address)")
+	fuzzer.config.Fuzzing.SetupCheatCodes = []config.CheatCodeCallConfig{{Method: "deal(address,uint256)", Args: []any{target.Hex(), "1"}}}
+	baseTestChain, _, err = fuzzer.createBaseTestChain(false)
+	assert.NoError(t, err)
+	assert.EqualValues(t, big.NewInt(1), baseTestChain.State().GetBalance(target).ToBig())
+	baseTestChain.Close()
+}
@@ -1,11 +1,13 @@
 package config
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -45,6 +47,11 @@ type FuzzingConfig struct {
 	// zero value will result in no timeout.
 	Timeout int `json:"timeout"`
 
+	// Seed describes the seed of the random provider every other random provider of a fuzzing campaign is derived
+	// from, so that the decisions of each worker are reproducible. A zero value indicates a seed should be derived
+	// from the current time.
+	Seed int64 `json:"seed"`
+
 	// TestLimit describes a threshold for the number of transactions to test, after which it will exit. This number
 	// must be non-negative. A zero value indicates the test limit should not be enforced.
 	TestLimit uint64 `json:"testLimit"`
@@ -67,7 +74,10 @@ type FuzzingConfig struct {
 	// CoverageEnabled describes whether to use coverage-guided fuzzing
 	CoverageEnabled bool `json:"coverageEnabled"`
 
-	// CoverageFormats indicate which reports to generate: "lcov" and "html" are supported.
+	// CoverageFormats indicate which reports to generate: "lcov" and "html" are supported, as well as "fitness-lcov"
+	// and "fitness-html", LCOV and HTML reports of the line coverage, branch coverage and branch distances recorded by
+	// the fitness metrics, and "json", a dump of the covered instructions and branches which can be diffed across
+	// campaigns.
 	CoverageFormats []string `json:"coverageFormats"`
 
 	// CoverageExclusions defines file/directory patterns to exclude from coverage reports.
@@ -77,6 +87,26 @@ type FuzzingConfig struct {
 	// RevertReporterEnabled determines whether revert metrics should be collected and reported.
 	RevertReporterEnabled bool `json:"revertReporterEnabled"`
 
+	// StateDiffsEnabled determines whether the balance, nonce, code and storage changes made by each call should be
+	// recorded, stored with corpus call sequences and reported for failing call sequences.
+	StateDiffsEnabled bool `json:"stateDiffsEnabled"`
+
+	// GasProfilingEnabled determines whether the gas used by calls to each function should be profiled, and a gas
+	// report emitted at the end of the fuzzing campaign.
+	GasProfilingEnabled bool `json:"gasProfilingEnabled"`
+
+	// ResultsFileEnabled determines whether a machine-readable results file, describing the bugs found along with
+	// their reproducing call sequences and the final fuzzing metrics, should be written at the end of the campaign.
+	ResultsFileEnabled bool `json:"resultsFileEnabled"`
+
+	// SARIFFileEnabled determines whether the bugs found should be written to a SARIF file at the end of the campaign,
+	// so that code scanning tools can ingest them.
+	SARIFFileEnabled bool `json:"sarifFileEnabled"`
+
+	// FoundryReproducersEnabled determines whether a Foundry test replaying the minimal call sequence of each bug
+	// found should be written at the end of the campaign, so bugs can be reproduced without the fuzzer.
+	FoundryReproducersEnabled bool `json:"foundryReproducersEnabled"`
+
 	// TargetContracts are the target contracts for fuzz testing
 	TargetContracts []string `json:"targetContracts"`
 
@@ -127,6 +157,127 @@ type FuzzingConfig struct {
 
 	// BugDetectionConfig describes the configuration used for bug detection
 	BugDetectionConfig BugDetectionConfig `json:"bugDetectionConfig"`
+
+	// Adversaries describes the configuration used to declare the attacker accounts and attacker contracts which bug
+	// detectors treat as adversarial, along with the sender addresses and the helper contract.
+	Adversaries AdversariesConfig `json:"adversaries"`
+
+	// RPCServerConfig describes the configuration used to expose the test chain over a JSON-RPC endpoint.
+	RPCServerConfig RPCServerConfig `json:"rpcServerConfig"`
+
+	// DashboardConfig describes the configuration used to serve a live web dashboard of the campaign.
+	DashboardConfig DashboardConfig `json:"dashboardConfig"`
+
+	// BranchCoverage describes the configuration used by the branch coverage tracer.
+	BranchCoverage BranchCoverageConfig `json:"branchCoverage"`
+
+	// BranchDistance describes the configuration used by the branch distance tracer.
+	BranchDistance BranchDistanceConfig `json:"branchDistance"`
+
+	// Dataflow describes the configuration used by the dataflow tracer.
+	Dataflow DataflowConfig `json:"dataflow"`
+
+	// StorageWrite describes the configuration used by the storage write tracer.
+	StorageWrite StorageWriteConfig `json:"storageWrite"`
+
+	// Tokenflow describes the configuration used by the tokenflow tracer.
+	Tokenflow TokenflowConfig `json:"tokenflow"`
+
+	// BalanceDelta describes the configuration used by the balance delta tracer.
+	BalanceDelta BalanceDeltaConfig `json:"balanceDelta"`
+
+	// StatefulMode describes the configuration used to fuzz against a persistent, ever-evolving chain state.
+	StatefulMode StatefulModeConfig `json:"statefulMode"`
+
+	// PrefixBranching describes the configuration used to branch many call sequences from a shared, executed prefix.
+	PrefixBranching PrefixBranchingConfig `json:"prefixBranching"`
+
+	// TracerSampling describes the configuration used to attach heavy tracers to a fraction of transactions only.
+	TracerSampling TracerSamplingConfig `json:"tracerSampling"`
+
+	// CmpLog describes the configuration used to log comparison operands and substitute them into call data.
+	CmpLog CmpLogConfig `json:"cmpLog"`
+
+	// TargetDirected describes the configuration used to direct fuzzing towards specific code locations.
+	TargetDirected TargetDirectedConfig `json:"targetDirected"`
+
+	// TimeAcceleration describes the configuration used to advance block time by large jumps between sequence segments.
+	TimeAcceleration TimeAccelerationConfig `json:"timeAcceleration"`
+
+	// BlockWarp describes the configuration used to warp block time and number in call sequences whose branch
+	// conditions the block dependency bug detector found depending on them.
+	BlockWarp BlockWarpConfig `json:"blockWarp"`
+
+	// AdaptivePowerSchedule describes the configuration used to assign more mutation energy to corpus call sequences
+	// whose mutations recently proved productive.
+	AdaptivePowerSchedule AdaptivePowerScheduleConfig `json:"adaptivePowerSchedule"`
+
+	// MultiObjective describes the configuration used to select mutation targets from a Pareto archive across several
+	// fitness metrics.
+	MultiObjective MultiObjectiveConfig `json:"multiObjective"`
+
+	// SenderStrategy describes the configuration used to choose the senders of generated calls.
+	SenderStrategy SenderStrategyConfig `json:"senderStrategy"`
+
+	// ValueStrategy describes the configuration used to choose the msg.value of generated calls to payable methods.
+	ValueStrategy ValueStrategyConfig `json:"valueStrategy"`
+
+	// ContractFunctions maps the names of contracts to the configuration used to choose which of their functions
+	// generated calls target, and how often.
+	ContractFunctions map[string]ContractFunctionsConfig `json:"contractFunctions"`
+
+	// CallTransplant describes the configuration used to transplant high-value calls from the corpus into other corpus
+	// call sequences.
+	CallTransplant CallTransplantConfig `json:"callTransplant"`
+
+	// NestedCalls describes the configuration used to generate valid call data for arguments of methods which dispatch
+	// nested calls, such as multicall and router methods.
+	NestedCalls NestedCallsConfig `json:"nestedCalls"`
+
+	// CoverageAddressAttribution describes how coverage of contracts created during call sequences is attributed.
+	CoverageAddressAttribution AddressAttributionConfig `json:"coverageAddressAttribution"`
+
+	// PathCoverage describes the configuration used by the path coverage fitness metric.
+	PathCoverage PathCoverageConfig `json:"pathCoverage"`
+
+	// ConstructorArgsSearch describes the configuration used to search for target contract constructor arguments
+	// which maximize the coverage of their init bytecode.
+	ConstructorArgsSearch ConstructorArgsSearchConfig `json:"constructorArgsSearch"`
+
+	// CoverageTimeSeries describes the configuration used to periodically export fuzzing metrics as a time series.
+	CoverageTimeSeries CoverageTimeSeriesConfig `json:"coverageTimeSeries"`
+
+	// MetricExclusions describes the contracts excluded from code coverage, branch coverage and branch distance
+	// accounting.
+	MetricExclusions MetricExclusionsConfig `json:"metricExclusions"`
+
+	// Distributed describes the configuration used to share corpus call sequences and fitness metrics with other
+	// fuzzer instances through a coordinator.
+	Distributed DistributedConfig `json:"distributed"`
+
+	// Explorer describes the configuration used to fetch the verified ABIs of on-chain target contracts from a block
+	// explorer API.
+	Explorer ExplorerConfig `json:"explorer"`
+
+	// OnChainInteraction describes the configuration used to interleave calls among multiple on-chain target
+	// contracts.
+	OnChainInteraction OnChainInteractionConfig `json:"onChainInteraction"`
+
+	// ForkSeeding describes the configuration used to give adversarial addresses ether and ERC20 token balances on the
+	// forked chain before fuzzing begins.
+	ForkSeeding ForkSeedingConfig `json:"forkSeeding"`
+
+	// HistoricalTransactions describes the configuration used to import the recent transactions to on-chain target
+	// contracts as initial call sequences.
+	HistoricalTransactions HistoricalTransactionsConfig `json:"historicalTransactions"`
+
+	// ForkTimeline describes the configuration used to advance block time along with block numbers, and to
+	// periodically refresh the forked chain state.
+	ForkTimeline ForkTimelineConfig `json:"forkTimeline"`
+
+	// SetupCheatCodes describes the calls to the standard cheat code contract made once the test chain is set up, to
+	// establish preconditions (e.g. time, balances, or storage) every worker's chain starts from.
+	SetupCheatCodes []CheatCodeCallConfig `json:"setupCheatCodes"`
 }
 
 // ContractBalance wraps big.Int to provide custom JSON marshaling/unmarshaling
@@ -234,6 +385,9 @@ type TestingConfig struct {
 	// OptimizationTesting describes the configuration used for optimization testing.
 	OptimizationTesting OptimizationTestingConfig `json:"optimizationTesting"`
 
+	// IdempotencyTesting describes the configuration used for idempotency testing.
+	IdempotencyTesting IdempotencyTestingConfig `json:"idempotencyTesting"`
+
 	// TargetFunctionSignatures is a list of function signatures the fuzzer should exclusively target by omitting calls to other signatures.
 	// The signatures should specify the contract name and signature in the ABI format like `Contract.func(uint256,bytes32)`.
 	TargetFunctionSignatures []string `json:"targetFunctionSignatures"`
@@ -329,6 +483,15 @@ type PropertyTestingConfig struct {
 
 	// TestPrefixes dictates what method name prefixes will determine if a contract method is a property test.
 	TestPrefixes []string `json:"testPrefixes"`
+
+	// ReportAsBugs describes whether property tests are registered as oracles of the bug detector, so that their
+	// failures are recorded in the bug map and reported alongside detector findings.
+	ReportAsBugs bool `json:"reportAsBugs"`
+
+	// GuideBranchDistance describes whether the branch distances of the branches executed by property tests after
+	// each call are attributed to that call, so that the fuzzer is guided toward flipping the branches guarding their
+	// result. This requires the branch distance fitness metric.
+	GuideBranchDistance bool `json:"guideBranchDistance"`
 }
 
 // OptimizationTestingConfig describes the configuration options used for optimization testing
@@ -340,6 +503,20 @@ type OptimizationTestingConfig struct {
 	TestPrefixes []string `json:"testPrefixes"`
 }
 
+// IdempotencyTestingConfig describes the configuration options used for idempotency testing. When enabled, the last
+// call of each call sequence added to the corpus is repeated twice in succession, and the method called fails its test
+// if the second repetition still changes storage or balances (e.g. double-withdrawal or double-claim patterns). The
+// first repetition absorbs changes caused by the block context differing from the original call.
+type IdempotencyTestingConfig struct {
+	// Enabled describes whether testing is enabled.
+	Enabled bool `json:"enabled"`
+
+	// ExcludeFunctionSignatures is a list of function signatures which are expected to change state on every call
+	// (e.g. counters or deposits) and are thus not tested. The signatures should specify the contract name and
+	// signature in the ABI format like `Contract.func(uint256,bytes32)`.
+	ExcludeFunctionSignatures []string `json:"excludeFunctionSignatures"`
+}
+
 // LoggingConfig describes the configuration options for logging to console and file
 type LoggingConfig struct {
 	// Level describes whether logs of certain severity levels (eg info, warning, etc.) will be emitted or discarded.
@@ -352,6 +529,10 @@ type LoggingConfig struct {
 
 	// NoColor indicates whether log messages should be displayed with colored formatting.
 	NoColor bool `json:"noColor"`
+
+	// Diagnostics describes the verbosity of the debug logs of each subsystem (e.g. "branchdistance" or "taint"), by
+	// subsystem name. Subsystems which are not listed do not emit debug logs.
+	Diagnostics map[string]int `json:"diagnostics"`
 }
 
 // ConsoleLoggingConfig describes the configuration options for logging to console. Note that this not being used right now
@@ -449,6 +630,19 @@ func (p *ProjectConfig) Validate() error {
 	if p.Fuzzing.TransactionGasLimit == 0 {
 		return errors.New("project configuration must specify a transaction gas limit which is non-zero")
 	}
+	if p.Fuzzing.TestChainConfig.BlockGasLimit != 0 && p.Fuzzing.TransactionGasLimit > p.Fuzzing.TestChainConfig.BlockGasLimit {
+		return errors.New("project configuration must specify a transaction gas limit which does not exceed the block gas limit")
+	}
+
+	// Verify the chain executes with a supported hardfork
+	if err := p.Fuzzing.TestChainConfig.ValidateHardfork(); err != nil {
+		return fmt.Errorf("project configuration must specify a supported hardfork: %v", err)
+	}
+
+	// Verify the mock contracts are installed at valid addresses
+	if err := p.Fuzzing.TestChainConfig.ValidateMocks(); err != nil {
+		return fmt.Errorf("project configuration must specify valid mocks: %v", err)
+	}
 
 	// Log warning if max block delay is zero
 	if p.Fuzzing.MaxBlockNumberDelay == 0 {
@@ -468,6 +662,14 @@ func (p *ProjectConfig) Validate() error {
 		return errors.New("project configuration must specify only well-formed sender address(es)")
 	}
 
+	// Verify that attacker accounts are well-formed addresses, funded with a non-negative balance
+	if _, err := utils.HexStringsToAddresses(p.Fuzzing.Adversaries.Accounts); err != nil {
+		return errors.New("project configuration must specify only well-formed attacker account address(es)")
+	}
+	if len(p.Fuzzing.Adversaries.Accounts) > 0 && (p.Fuzzing.Adversaries.AccountBalance == nil || p.Fuzzing.Adversaries.AccountBalance.Sign() < 0) {
+		return errors.New("project configuration must specify a non-negative attacker account balance")
+	}
+
 	// Verify that deployer is a well-formed address
 	if _, err := utils.HexStringToAddress(p.Fuzzing.DeployerAddress); err != nil {
 		return errors.New("project configuration must specify only a well-formed deployer address")
@@ -480,21 +682,366 @@ func (p *ProjectConfig) Validate() error {
 		}
 	}
 
-	// The coverage report format must be either "lcov" or "html"
+	// The coverage report format must be either "lcov", "html", "fitness-lcov", "fitness-html" or "json"
 	if p.Fuzzing.CoverageFormats != nil {
 		for _, report := range p.Fuzzing.CoverageFormats {
-			if report != "lcov" && report != "html" {
-				return fmt.Errorf("project configuration must specify only valid coverage reports (lcov, html): %s", report)
+			if report != "lcov" && report != "html" && report != "fitness-lcov" && report != "fitness-html" && report != "json" {
+				return fmt.Errorf("project configuration must specify only valid coverage reports (lcov, html, fitness-lcov, fitness-html, json): %s", report)
+			}
+		}
+	}
+
+	// Verify that an address is provided if the JSON-RPC server is enabled
+	if p.Fuzzing.RPCServerConfig.Enabled && p.Fuzzing.RPCServerConfig.Address == "" {
+		return errors.New("project configuration must specify an address if the JSON-RPC server is enabled")
+	}
+
+	// Verify that an address is provided if the dashboard is enabled
+	if p.Fuzzing.DashboardConfig.Enabled && p.Fuzzing.DashboardConfig.Address == "" {
+		return errors.New("project configuration must specify an address if the dashboard is enabled")
+	}
+
+	// Verify the fitness metric saturation window is usable
+	if p.Fuzzing.FitnessMetricConfig.Saturation.Enabled && p.Fuzzing.FitnessMetricConfig.Saturation.Window == 0 {
+		return errors.New("project configuration must specify a positive fitness metric saturation window if saturation detection is enabled")
+	}
+
+	// Verify the stateful mode has persistent workers to run on
+	if p.Fuzzing.StatefulMode.Enabled {
+		if p.Fuzzing.StatefulMode.PersistentWorkers <= 0 || p.Fuzzing.StatefulMode.PersistentWorkers > p.Fuzzing.Workers {
+			return errors.New("project configuration must specify between one and the worker count of persistent workers if the stateful mode is enabled")
+		}
+		if p.Fuzzing.StatefulMode.SnapshotProbability < 0 || p.Fuzzing.StatefulMode.SnapshotProbability > 1 {
+			return errors.New("project configuration must specify a stateful mode snapshot probability between 0 and 1")
+		}
+	}
+
+	// Verify prefix branching has branches and does not conflict with the stateful mode
+	if p.Fuzzing.PrefixBranching.Enabled {
+		if p.Fuzzing.PrefixBranching.Branches <= 0 {
+			return errors.New("project configuration must specify a positive amount of branches if prefix branching is enabled")
+		}
+		if p.Fuzzing.StatefulMode.Enabled {
+			return errors.New("project configuration must not enable both prefix branching and the stateful mode")
+		}
+	}
+
+	// Verify the tracer sampling probability is a probability
+	if p.Fuzzing.TracerSampling.Probability < 0 || p.Fuzzing.TracerSampling.Probability > 1 {
+		return errors.New("project configuration must specify a tracer sampling probability between 0 and 1")
+	}
+
+	// Verify the comparison operand log can hold operands
+	if p.Fuzzing.CmpLog.Enabled && p.Fuzzing.CmpLog.MaxOperandPairs <= 0 {
+		return errors.New("project configuration must specify a positive maximum amount of comparison operand pairs if the comparison operand log is enabled")
+	}
+	if p.Fuzzing.CmpLog.DictionaryProbability < 0 || p.Fuzzing.CmpLog.DictionaryProbability > 1 {
+		return errors.New("project configuration must specify a comparison operand dictionary probability between 0 and 1")
+	}
+	if p.Fuzzing.CmpLog.Enabled && p.Fuzzing.CmpLog.DictionaryProbability > 0 && p.Fuzzing.CmpLog.MaxDictionaryEntries <= 0 {
+		return errors.New("project configuration must specify a positive maximum amount of comparison operand dictionary entries if the dictionary is used")
+	}
+
+	// Verify the target-directed mode has targets and the branch distances it is derived from
+	if p.Fuzzing.TargetDirected.Enabled {
+		if len(p.Fuzzing.TargetDirected.Targets) == 0 {
+			return errors.New("project configuration must specify at least one target if the target-directed mode is enabled")
+		}
+		if !p.Fuzzing.FitnessMetricConfig.BranchDistanceEnabled {
+			return errors.New("project configuration must enable the branch distance fitness metric if the target-directed mode is enabled")
+		}
+		if p.Fuzzing.TargetDirected.MaxWeightMultiplier == 0 {
+			return errors.New("project configuration must specify a positive target-directed weight multiplier")
+		}
+	}
+
+	// Verify property tests are only used to guide branch distances which are computed
+	if p.Fuzzing.Testing.PropertyTesting.GuideBranchDistance && !p.Fuzzing.FitnessMetricConfig.BranchDistanceEnabled {
+		return errors.New("project configuration must enable the branch distance fitness metric if property tests guide branch distances")
+	}
+
+	// Verify the time acceleration schedule is usable
+	if p.Fuzzing.TimeAcceleration.Enabled {
+		if p.Fuzzing.TimeAcceleration.SegmentLength <= 0 || len(p.Fuzzing.TimeAcceleration.Jumps) == 0 {
+			return errors.New("project configuration must specify a positive segment length and at least one jump if time acceleration is enabled")
+		}
+		if p.Fuzzing.TimeAcceleration.JumpProbability < 0 || p.Fuzzing.TimeAcceleration.JumpProbability > 1 {
+			return errors.New("project configuration must specify a time acceleration jump probability between 0 and 1")
+		}
+		if p.Fuzzing.TimeAcceleration.BlockTime == 0 {
+			return errors.New("project configuration must specify a positive time acceleration block time")
+		}
+	}
+
+	// Verify block warps are driven by the block dependency bug detector
+	if p.Fuzzing.BlockWarp.Enabled {
+		if !p.Fuzzing.UseBugDetector() || !p.Fuzzing.BugDetectionConfig.BlockDependency {
+			return errors.New("project configuration must enable the block dependency bug detector if block warps are enabled")
+		}
+		if len(p.Fuzzing.BlockWarp.Warps) == 0 {
+			return errors.New("project configuration must specify at least one block warp if block warps are enabled")
+		}
+		if p.Fuzzing.BlockWarp.WarpProbability < 0 || p.Fuzzing.BlockWarp.WarpProbability > 1 {
+			return errors.New("project configuration must specify a block warp probability between 0 and 1")
+		}
+		if p.Fuzzing.BlockWarp.BlockTime == 0 {
+			return errors.New("project configuration must specify a positive block warp block time")
+		}
+	}
+
+	// Verify the adaptive power schedule boosts and decays mutation weights
+	if p.Fuzzing.AdaptivePowerSchedule.Enabled {
+		if p.Fuzzing.AdaptivePowerSchedule.MaxExponent == 0 || p.Fuzzing.AdaptivePowerSchedule.MaxExponent > 32 {
+			return errors.New("project configuration must specify an adaptive power schedule maximum exponent between 1 and 32")
+		}
+		if p.Fuzzing.AdaptivePowerSchedule.DecayInterval == 0 {
+			return errors.New("project configuration must specify a positive adaptive power schedule decay interval")
+		}
+	}
+
+	// Verify the multi-objective seed selection objectives are known and traced
+	if p.Fuzzing.MultiObjective.Enabled {
+		if len(p.Fuzzing.MultiObjective.Objectives) == 0 {
+			return errors.New("project configuration must specify at least one objective if the multi-objective seed selection is enabled")
+		}
+		for objective, weight := range p.Fuzzing.MultiObjective.Objectives {
+			enabled, known := p.Fuzzing.FitnessMetricConfig.objectiveEnabled(objective)
+			if !known {
+				return fmt.Errorf("project configuration must specify only known multi-objective objectives: %s", objective)
 			}
+			if !enabled {
+				return fmt.Errorf("project configuration must enable the fitness metric of the multi-objective objective %s", objective)
+			}
+			if weight == 0 {
+				return fmt.Errorf("project configuration must specify a positive weight for the multi-objective objective %s", objective)
+			}
+		}
+		if p.Fuzzing.MultiObjective.ArchiveProbability < 0 || p.Fuzzing.MultiObjective.ArchiveProbability > 1 {
+			return errors.New("project configuration must specify a multi-objective archive probability between 0 and 1")
 		}
 	}
 
+	// Verify the call transplant mutation chooses high-value calls with a positive weight
+	if p.Fuzzing.CallTransplant.Enabled && p.Fuzzing.CallTransplant.TokenflowWeight == 0 {
+		return errors.New("project configuration must specify a positive call transplant tokenflow weight")
+	}
+
+	// Verify the nested call probability is a probability
+	if p.Fuzzing.NestedCalls.Probability < 0 || p.Fuzzing.NestedCalls.Probability > 1 {
+		return errors.New("project configuration must specify a nested call probability between 0 and 1")
+	}
+
+	// Verify the sender and value strategies are known and can be chosen
+	if err := validateStrategyWeights("sender", p.Fuzzing.SenderStrategy.Strategies, senderStrategies); err != nil {
+		return err
+	}
+	if p.Fuzzing.SenderStrategy.OwnerCalls < 0 {
+		return errors.New("project configuration must specify a non-negative amount of owner calls for the owner-then-attacker sender strategy")
+	}
+	if err := validateStrategyWeights("value", p.Fuzzing.ValueStrategy.Strategies, valueStrategies); err != nil {
+		return err
+	}
+
+	// Verify the functions of each contract are either targeted or excluded, with positive weights
+	for contractName, contractFunctions := range p.Fuzzing.ContractFunctions {
+		if len(contractFunctions.TargetFunctions) > 0 && len(contractFunctions.ExcludedFunctions) > 0 {
+			return fmt.Errorf("project configuration must specify only one of target or excluded functions for contract %s", contractName)
+		}
+		for function, weight := range contractFunctions.Weights {
+			if weight == 0 {
+				return fmt.Errorf("project configuration must specify a positive weight for function %s of contract %s", function, contractName)
+			}
+		}
+	}
+
+	// Verify the coverage address attribution mode is known
+	switch p.Fuzzing.CoverageAddressAttribution.Mode {
+	case AddressAttributionModeBlank:
+	case AddressAttributionModePseudo:
+		if p.Fuzzing.CoverageAddressAttribution.MaxPseudoAddresses <= 0 {
+			return errors.New("project configuration must specify a positive maximum amount of pseudo-addresses if pseudo-address coverage attribution is used")
+		}
+	default:
+		return fmt.Errorf("project configuration must specify a coverage address attribution mode of '%v' or '%v'", AddressAttributionModeBlank, AddressAttributionModePseudo)
+	}
+
+	// Verify the path coverage bloom filter can hold paths
+	if p.Fuzzing.UsePathCoverageTracing() && (p.Fuzzing.PathCoverage.BloomFilterBits == 0 || p.Fuzzing.PathCoverage.BloomFilterHashes == 0) {
+		return errors.New("project configuration must specify a positive amount of path coverage bloom filter bits and hashes if path coverage is enabled")
+	}
+
+	// Verify the constructor argument search deploys candidates
+	if p.Fuzzing.ConstructorArgsSearch.Enabled && p.Fuzzing.ConstructorArgsSearch.Iterations <= 0 {
+		return errors.New("project configuration must specify a positive amount of constructor argument search iterations if the search is enabled")
+	}
+
+	// Verify the coordinator can be reached if distributed fuzzing is enabled
+	if p.Fuzzing.Distributed.Enabled {
+		if p.Fuzzing.Distributed.Address == "" {
+			return errors.New("project configuration must specify a coordinator address if distributed fuzzing is enabled")
+		}
+		if p.Fuzzing.Distributed.SyncInterval == 0 {
+			return errors.New("project configuration must specify a positive sync interval if distributed fuzzing is enabled")
+		}
+	}
+
+	// Verify the block explorer API is specified if ABIs are fetched from it
+	if p.Fuzzing.Explorer.Enabled && p.Fuzzing.Explorer.ApiUrl == "" {
+		return errors.New("project configuration must specify a block explorer API URL if fetching verified ABIs is enabled")
+	}
+	if p.Fuzzing.Explorer.SourcifyEnabled && (p.Fuzzing.Explorer.SourcifyApiUrl == "" || p.Fuzzing.Explorer.ChainId == 0) {
+		return errors.New("project configuration must specify a Sourcify API URL and chain ID if fetching verified ABIs from Sourcify is enabled")
+	}
+
+	// Verify the balances seeded on the forked chain can be written
+	if _, err := utils.HexStringsToAddresses(p.Fuzzing.ForkSeeding.Holders); err != nil {
+		return errors.New("project configuration must specify only well-formed fork seeding holder address(es)")
+	}
+	for _, token := range p.Fuzzing.ForkSeeding.Tokens {
+		if _, err := utils.HexStringToAddress(token.Address); err != nil {
+			return errors.New("project configuration must specify only well-formed seeded token address(es)")
+		}
+		if token.Balance == nil {
+			return fmt.Errorf("project configuration must specify the balance of seeded token %s", token.Address)
+		}
+		if token.Whale != "" {
+			if _, err := utils.HexStringToAddress(token.Whale); err != nil {
+				return errors.New("project configuration must specify only well-formed seeded token whale address(es)")
+			}
+		} else if !p.Fuzzing.TestChainConfig.CheatCodeConfig.CheatCodesEnabled {
+			return errors.New("project configuration must enable cheat codes if token balances are seeded without a whale")
+		}
+	}
+	if p.Fuzzing.ForkSeeding.EtherBalance != nil && !p.Fuzzing.TestChainConfig.CheatCodeConfig.CheatCodesEnabled {
+		return errors.New("project configuration must enable cheat codes if ether balances are seeded")
+	}
+
+	// Verify historical transactions are imported from the forked chain
+	if p.Fuzzing.HistoricalTransactions.Count > 0 && !p.Fuzzing.TestChainConfig.ForkConfig.ForkModeEnabled {
+		return errors.New("project configuration must enable fork mode if historical transactions are imported")
+	}
+
+	// Verify the setup cheat codes can be called
+	if len(p.Fuzzing.SetupCheatCodes) > 0 && !p.Fuzzing.TestChainConfig.CheatCodeConfig.CheatCodesEnabled {
+		return errors.New("project configuration must enable cheat codes if setup cheat codes are called")
+	}
+	for _, cheatCodeCall := range p.Fuzzing.SetupCheatCodes {
+		if cheatCodeCall.Method == "" {
+			return errors.New("project configuration must specify the method of each setup cheat code call")
+		}
+	}
+
+	// Verify the forked chain state is refreshed from a fork
+	if p.Fuzzing.ForkTimeline.RefreshInterval > 0 && !p.Fuzzing.TestChainConfig.ForkConfig.ForkModeEnabled {
+		return errors.New("project configuration must enable fork mode if the forked chain state is refreshed")
+	}
+
+	// Verify the on-chain interaction switch probability is a probability
+	if p.Fuzzing.OnChainInteraction.SwitchProbability < 0 || p.Fuzzing.OnChainInteraction.SwitchProbability > 1 {
+		return errors.New("project configuration must specify an on-chain interaction switch probability between 0 and 1")
+	}
+
+	// Verify the coverage time series is written in a supported format
+	if p.Fuzzing.CoverageTimeSeries.Interval > 0 && p.Fuzzing.CoverageTimeSeries.Format != "csv" && p.Fuzzing.CoverageTimeSeries.Format != "json" {
+		return fmt.Errorf("project configuration must specify a valid coverage time series format (csv, json): %s", p.Fuzzing.CoverageTimeSeries.Format)
+	}
+
+	// Verify the dataflow graph is exported in supported formats
+	for _, format := range p.Fuzzing.Dataflow.ExportFormats {
+		if format != "dot" && format != "json" {
+			return fmt.Errorf("project configuration must specify only valid dataflow export formats (dot, json): %s", format)
+		}
+	}
+
+	// Verify the dataflow ordering probability is a probability
+	if p.Fuzzing.Dataflow.OrderingProbability < 0 || p.Fuzzing.Dataflow.OrderingProbability > 1 {
+		return errors.New("project configuration must specify a dataflow ordering probability between 0 and 1")
+	}
+
+	// Verify the storage write value buckets are usable
+	switch p.Fuzzing.StorageWrite.BucketMode {
+	case "", "range", "sign", "log2", "zero":
+	default:
+		return fmt.Errorf("project configuration must specify a valid storage write bucket mode (range, sign, log2, zero): %s", p.Fuzzing.StorageWrite.BucketMode)
+	}
+	for i, boundary := range p.Fuzzing.StorageWrite.BucketBoundaries {
+		if boundary == 0 || boundary >= 256 || (i > 0 && boundary <= p.Fuzzing.StorageWrite.BucketBoundaries[i-1]) {
+			return errors.New("project configuration must specify increasing storage write bucket boundaries between 1 and 255")
+		}
+	}
+
+	// Verify the balance delta addresses and tokens are well-formed
+	if _, err := utils.HexStringsToAddresses(p.Fuzzing.BalanceDelta.Addresses); err != nil {
+		return errors.New("project configuration must specify only well-formed balance delta address(es)")
+	}
+	for _, token := range p.Fuzzing.BalanceDelta.Tokens {
+		if _, err := utils.HexStringToAddress(token.Address); err != nil {
+			return errors.New("project configuration must specify only well-formed balance delta token address(es)")
+		}
+	}
+
+	// Verify the metric exclusions are well-formed
+	for _, addr := range p.Fuzzing.MetricExclusions.Addresses {
+		if _, err := utils.HexStringToAddress(addr); err != nil {
+			return errors.New("project configuration must specify only well-formed metric exclusion address(es)")
+		}
+	}
+	for _, codeHash := range p.Fuzzing.MetricExclusions.BytecodeHashes {
+		if b, err := hex.DecodeString(strings.TrimPrefix(codeHash, "0x")); err != nil || len(b) != 32 {
+			return errors.New("project configuration must specify only well-formed metric exclusion bytecode hash(es)")
+		}
+	}
+
+	// Verify the branch coverage calling context depth is usable
+	if p.Fuzzing.BranchCoverage.ContextDepth < 0 {
+		return errors.New("project configuration must specify a non-negative branch coverage calling context depth")
+	}
+
+	// Verify the limit of dynamically tracked branch maps is usable
+	if p.Fuzzing.BranchCoverage.DynamicBranchMapsLimit < 0 {
+		return errors.New("project configuration must specify a non-negative dynamic branch maps limit")
+	}
+
+	// Verify the branch distance lookback window is usable
+	if !p.Fuzzing.BranchDistance.AdaptiveLookback && p.Fuzzing.BranchDistance.MaxLookback <= 0 {
+		return errors.New("project configuration must specify a positive branch distance lookback window unless the adaptive lookback is enabled")
+	}
+	if p.Fuzzing.BranchDistance.AdaptiveLookback && p.Fuzzing.BranchDistance.MaxAdaptiveLookback <= 0 {
+		return errors.New("project configuration must specify a positive maximum adaptive branch distance lookback if the adaptive lookback is enabled")
+	}
+	if p.Fuzzing.BranchDistance.StackSlots < 2 {
+		return errors.New("project configuration must specify at least two branch distance stack slots")
+	}
+	if (p.Fuzzing.BranchDistance.UseRevertedDistance || p.Fuzzing.FitnessMetricConfig.UseRevertedFlows) && p.Fuzzing.BranchDistance.RevertedDistanceWeightDivisor == 0 {
+		return errors.New("project configuration must specify a positive reverted branch distance weight divisor if reverted branch distances are used")
+	}
+	if p.Fuzzing.BranchDistance.ReducedDistanceWeightMultiplier == 0 {
+		return errors.New("project configuration must specify a positive reduced branch distance weight multiplier")
+	}
+	switch p.Fuzzing.BranchDistance.Normalization {
+	case BranchDistanceNormalizationRatio, BranchDistanceNormalizationLog:
+	default:
+		return fmt.Errorf("project configuration must specify a branch distance normalization of '%v' or '%v'", BranchDistanceNormalizationRatio, BranchDistanceNormalizationLog)
+	}
+	switch p.Fuzzing.BranchDistance.Aggregation {
+	case BranchDistanceAggregationSum, BranchDistanceAggregationMin, BranchDistanceAggregationHarmonicMean:
+	default:
+		return fmt.Errorf("project configuration must specify a branch distance aggregation of '%v', '%v', or '%v'", BranchDistanceAggregationSum, BranchDistanceAggregationMin, BranchDistanceAggregationHarmonicMean)
+	}
+
 	// Ensure that the log level is a valid one
 	level, err := zerolog.ParseLevel(p.Logging.Level.String())
 	if err != nil || level == zerolog.FatalLevel {
 		return errors.New("project config must specify a valid log level (trace, debug, info, warn, error, or panic)")
 	}
 
+	// Ensure that diagnostics verbosities are not negative
+	for subsystem, verbosity := range p.Logging.Diagnostics {
+		if verbosity < 0 {
+			return fmt.Errorf("project configuration must specify a non-negative diagnostics verbosity for %v", subsystem)
+		}
+	}
+
 	return nil
 }
 
@@ -509,25 +1056,75 @@ type HelperContractConfig struct {
 type FitnessMetricConfig struct {
 	CodeCoverageEnabled   bool `json:"codeCoverageEnabled"`
 	BranchCoverageEnabled bool `json:"branchCoverageEnabled"`
+	// EdgeCoverageEnabled describes whether to track hashed (previous pc, current pc) control flow edges taken by
+	// jumps, which keep making progress on dispatcher-heavy contracts after instruction coverage saturates.
+	EdgeCoverageEnabled bool `json:"edgeCoverageEnabled"`
+	// PathCoverageEnabled describes whether to track hashes of the ordered branch decisions taken by each transaction,
+	// rewarding novel paths through already covered branches. See PathCoverageConfig.
+	PathCoverageEnabled bool `json:"pathCoverageEnabled"`
+	// SelectorCoverageEnabled describes whether to track which ABI function selectors of each contract were executed
+	// successfully at least once. Functions never reached are listed when fuzzing stops.
+	SelectorCoverageEnabled bool `json:"selectorCoverageEnabled"`
 
 	DataflowEnabled     bool `json:"dataflowEnabled"`
 	StorageWriteEnabled bool `json:"storageWriteEnabled"`
 	TokenflowEnabled    bool `json:"tokenflowEnabled"`
+	// BalanceDeltaEnabled describes whether to track the net deltas of the ether and ERC20 token balances of the
+	// addresses configured in BalanceDeltaConfig over each call sequence, rewarding sequences in which they profit.
+	BalanceDeltaEnabled bool `json:"balanceDeltaEnabled"`
+	// UseRevertedFlows describes whether call sequences which only produced new dataflow, storage writes or tokenflow
+	// in call frames which later reverted should be added to the corpus, as a secondary signal. Their mutation weight
+	// is divided by the branch distance RevertedDistanceWeightDivisor, like sequences added for reverted distances.
+	UseRevertedFlows bool `json:"useRevertedFlows"`
 
 	StateEnabled bool `json:"stateEnabled"`
 	SlotEnabled  bool `json:"slotEnabled"`
 
 	BranchDistanceEnabled bool `json:"branchDistanceEnabled"`
 	CmpDistanceEnabled    bool `json:"cmpDistanceEnabled"`
+
+	// Saturation describes the configuration used to stop tracing fitness metrics which no longer make progress.
+	Saturation FitnessMetricSaturationConfig `json:"saturation"`
+}
+
+// FitnessMetricSaturationConfig describes the configuration options used to detect saturated fitness metrics. A
+// saturated metric's tracer no longer traces the call sequences of workers, reclaiming its tracing cost, until it is
+// periodically re-probed.
+type FitnessMetricSaturationConfig struct {
+	// Enabled describes whether saturated fitness metrics should be disabled.
+	Enabled bool `json:"enabled"`
+
+	// Window describes the amount of executions over which a metric's marginal contribution is measured.
+	Window uint64 `json:"window"`
+
+	// MinNewItems describes the minimum amount of new items a metric must produce within a Window to not be
+	// considered saturated.
+	MinNewItems uint64 `json:"minNewItems"`
+
+	// ReprobeInterval describes the time in seconds after which a saturated metric is re-enabled for another Window,
+	// to check whether it makes progress again.
+	ReprobeInterval uint64 `json:"reprobeInterval"`
 }
 
 type MetricRecordConfig struct {
 	CodeCoverageEnabled   bool `json:"codeCoverageEnabled"`
 	BranchCoverageEnabled bool `json:"branchCoverageEnabled"`
+	// EdgeCoverageEnabled describes whether to track hashed (previous pc, current pc) control flow edges taken by
+	// jumps, which keep making progress on dispatcher-heavy contracts after instruction coverage saturates.
+	EdgeCoverageEnabled bool `json:"edgeCoverageEnabled"`
+	// PathCoverageEnabled describes whether to track hashes of the ordered branch decisions taken by each transaction,
+	// rewarding novel paths through already covered branches. See PathCoverageConfig.
+	PathCoverageEnabled bool `json:"pathCoverageEnabled"`
+	// SelectorCoverageEnabled describes whether to track which ABI function selectors of each contract were executed
+	// successfully at least once. Functions never reached are listed when fuzzing stops.
+	SelectorCoverageEnabled bool `json:"selectorCoverageEnabled"`
 
 	DataflowEnabled     bool `json:"dataflowEnabled"`
 	StorageWriteEnabled bool `json:"storageWriteEnabled"`
 	TokenflowEnabled    bool `json:"tokenflowEnabled"`
+	// BalanceDeltaEnabled describes whether to track the net deltas of the ether and ERC20 token balances of the
+	// addresses configured in BalanceDeltaConfig over each call sequence, rewarding sequences in which they profit.
+	BalanceDeltaEnabled bool `json:"balanceDeltaEnabled"`
 
 	StateEnabled bool `json:"stateEnabled"`
 	SlotEnabled  bool `json:"slotEnabled"`
@@ -544,6 +1141,18 @@ func (f *FuzzingConfig) UseBranchCoverageTracing() bool {
 	return f.FitnessMetricConfig.BranchCoverageEnabled || f.MetricRecordConfig.BranchCoverageEnabled
 }
 
+func (f *FuzzingConfig) UseEdgeCoverageTracing() bool {
+	return f.FitnessMetricConfig.EdgeCoverageEnabled || f.MetricRecordConfig.EdgeCoverageEnabled
+}
+
+func (f *FuzzingConfig) UsePathCoverageTracing() bool {
+	return f.FitnessMetricConfig.PathCoverageEnabled || f.MetricRecordConfig.PathCoverageEnabled
+}
+
+func (f *FuzzingConfig) UseSelectorCoverageTracing() bool {
+	return f.FitnessMetricConfig.SelectorCoverageEnabled || f.MetricRecordConfig.SelectorCoverageEnabled
+}
+
 func (f *FuzzingConfig) UseDataflowTracing() bool {
 	return f.FitnessMetricConfig.DataflowEnabled || f.MetricRecordConfig.DataflowEnabled
 }
@@ -556,6 +1165,10 @@ func (f *FuzzingConfig) UseTokenflowTracing() bool {
 	return f.FitnessMetricConfig.TokenflowEnabled || f.MetricRecordConfig.TokenflowEnabled
 }
 
+func (f *FuzzingConfig) UseBalanceDeltaTracing() bool {
+	return f.FitnessMetricConfig.BalanceDeltaEnabled || f.MetricRecordConfig.BalanceDeltaEnabled
+}
+
 func (f *FuzzingConfig) UseBranchDistanceTracing() bool {
 	return f.FitnessMetricConfig.BranchDistanceEnabled || f.MetricRecordConfig.BranchDistanceEnabled
 }
@@ -572,8 +1185,818 @@ type BugDetectionConfig struct {
 	Suicidal           bool `json:"suicidal"`
 	BlockDependency    bool `json:"blockDependency"`
 	UnsafeDelegateCall bool `json:"unsafeDelegateCall"`
+
+	// UninitializedStorageRead reports reads of declared state variables which were never written in the call
+	// sequence and are zero, which may indicate configuration which was never initialized.
+	UninitializedStorageRead bool `json:"uninitializedStorageRead"`
+
+	// ShrinkFindings describes whether each distinct bug detected is reported as a failed test case, along with the
+	// minimal call sequence found to trigger that exact bug ID upon shrinking.
+	ShrinkFindings bool `json:"shrinkFindings"`
 }
 
 func (f *FuzzingConfig) UseBugDetector() bool {
 	return f.BugDetectionConfig.Enabled
 }
+
+// AdversariesConfig describes the configuration options used to declare the addresses controlled by an attacker.
+// Bug detectors treat these addresses, the sender addresses and the helper contract as adversarial: ether leaking is
+// detected when their combined balance exceeds the one they held once the test chain was set up, and reentrancy and
+// unsafe delegate calls are detected when control flows to them.
+type AdversariesConfig struct {
+	// Accounts describes the addresses of the attacker accounts, which are funded in the genesis block.
+	Accounts []string `json:"accounts"`
+
+	// AccountBalance describes the ether balance, in wei, each attacker account is funded with.
+	AccountBalance *big.Int `json:"accountBalance"`
+
+	// SendTransactions describes whether the attacker accounts are added to the sender addresses, so that fuzzed calls
+	// are sent from them.
+	SendTransactions bool `json:"sendTransactions"`
+
+	// Contracts describes the names of contracts, such as attacker helper contracts reentering the target contracts,
+	// which are deployed by the first attacker account (or the deployer, if there is none) once the target contracts
+	// are deployed. Their constructor arguments are resolved as those of the target contracts.
+	Contracts []string `json:"contracts"`
+}
+
+// BranchCoverageConfig describes the configuration options used by the branch coverage tracer.
+type BranchCoverageConfig struct {
+	// ContextDepth describes the amount of callers (most recent first) making up the calling context branches are
+	// additionally recorded in. A branch reached through a new calling context (e.g. a pool called by a router rather
+	// than directly) is then considered new coverage. Each level multiplies the amount of coverage recorded by the
+	// amount of distinct callers, so it should be kept low. If zero, calling contexts are not recorded.
+	ContextDepth int `json:"contextDepth"`
+
+	// DynamicBranchMapsLimit describes the maximum amount of distinct runtime bytecode unknown to the fuzzer, such as
+	// that of contracts deployed by factories during the campaign, whose branches are tracked by the branch coverage
+	// and branch distance tracers. Their branch maps are built the first time the code executes. If zero, only the
+	// branches of compiled contracts are tracked.
+	DynamicBranchMapsLimit int `json:"dynamicBranchMapsLimit"`
+}
+
+// DataflowConfig describes the configuration options used by the dataflow tracer.
+type DataflowConfig struct {
+	// PersistWrites describes whether the storage writes of each transaction are seeded with those accumulated over the
+	// campaign, so that a write in one transaction and a read of the same slot in a later one are recorded as dataflow.
+	// Without it, only dataflow within a single transaction is recorded.
+	PersistWrites bool `json:"persistWrites"`
+
+	// TrackOutflows describes whether values read from storage are additionally tracked into the topics and data of
+	// emitted events and the arguments of external calls, recording which storage is observed externally. Values are
+	// matched by their 32-byte ABI encoding, so small values (e.g. booleans) are not tracked.
+	TrackOutflows bool `json:"trackOutflows"`
+
+	// ExportFormats describes the formats ("dot" or "json") the dataflow recorded is exported in as a graph at the end
+	// of the campaign, whose nodes are program positions and whose edges are flows over storage slots. If empty, the
+	// graph is not exported.
+	ExportFormats []string `json:"exportFormats"`
+
+	// TrackUninitializedReads describes whether reads of declared state variables which were never written in the
+	// call sequence and are zero are recorded, surfacing configuration which may never have been initialized.
+	TrackUninitializedReads bool `json:"trackUninitializedReads"`
+
+	// OrderingProbability describes the probability that a call generated after a call which wrote storage slots
+	// targets a method previously observed reading one of them, rather than a random method, so that call sequences
+	// follow dataflow. If zero, calls are not ordered along dataflow.
+	OrderingProbability float32 `json:"orderingProbability"`
+}
+
+// StorageWriteConfig describes the configuration options used by the storage write tracer.
+type StorageWriteConfig struct {
+	// BucketMode describes how the values written to storage slots are bucketed, so writes of values in different
+	// buckets at the same position count as distinct storage writes. It is one of "range" (the ranges between the
+	// BucketBoundaries), "sign" (like range, with negative two's complement values bucketed apart by their
+	// magnitude), "log2" (one bucket per bit length) or "zero" (zero or non-zero, which suits flag slots).
+	BucketMode string `json:"bucketMode"`
+
+	// BucketBoundaries describes the increasing exponents of the powers of two bounding the buckets of the range and
+	// sign modes. If empty, the buckets are bounded by 2^4, 2^16 and 2^64.
+	BucketBoundaries []uint `json:"bucketBoundaries"`
+
+	// SlotDiversity describes whether the storage write fitness metric rewards call sequences which write a slot a
+	// value in a bucket it never held before, from any position, rather than any new (position, slot, bucket) write.
+	// This favours sequences pushing state variables into new regimes over those reaching new write sites.
+	SlotDiversity bool `json:"slotDiversity"`
+}
+
+// TokenflowConfig describes the configuration options used by the tokenflow tracer.
+type TokenflowConfig struct {
+	// Selectors describes value-moving function selectors to decode token transfers from, in addition to the default
+	// ones (ERC20, ERC721 and ERC1155 transfers, WETH deposits and withdrawals, Permit2 transfers, mints and burns). A
+	// selector listed here overrides the default one it matches.
+	Selectors []TokenSelectorConfig `json:"selectors"`
+}
+
+// TokenSelectorConfig describes a value-moving function selector, and where the token transfer it performs is decoded
+// from. Each of From, To, Amount and Token is one of "argN" (the Nth 32-byte word of the call arguments, after the
+// selector), "caller" (the contract making the call), "callee" (the contract called), "zero", "one" or "value" (the
+// ether value sent with the call).
+type TokenSelectorConfig struct {
+	// Selector describes the function selector, as 4 hex-encoded bytes.
+	Selector string `json:"selector"`
+
+	// From describes where the address tokens are transferred from is decoded from.
+	From string `json:"from"`
+
+	// To describes where the address tokens are transferred to is decoded from.
+	To string `json:"to"`
+
+	// Amount describes where the amount of tokens transferred is decoded from.
+	Amount string `json:"amount"`
+
+	// Token describes where the address of the token transferred is decoded from. If empty, it is the callee.
+	Token string `json:"token,omitempty"`
+}
+
+// BalanceDeltaConfig describes the configuration options used by the balance delta tracer.
+type BalanceDeltaConfig struct {
+	// Addresses describes the addresses whose net balance deltas are tracked. If empty, the SenderAddresses are
+	// tracked, so sequences in which an attacker profits are surfaced.
+	Addresses []string `json:"addresses"`
+
+	// Tokens describes the ERC20 tokens whose balances are tracked, in addition to ether.
+	Tokens []BalanceDeltaTokenConfig `json:"tokens"`
+}
+
+// BalanceDeltaTokenConfig describes an ERC20 token whose balances are tracked by the balance delta tracer. Balances
+// are read directly from the token's storage, so its balance mapping must be laid out as Solidity lays out mappings.
+type BalanceDeltaTokenConfig struct {
+	// Address describes the address of the token.
+	Address string `json:"address"`
+
+	// BalanceSlot describes the storage slot of the token's address => balance mapping.
+	BalanceSlot uint64 `json:"balanceSlot"`
+}
+
+// BranchDistanceConfig describes the configuration options used by the branch distance tracer to compute the distance
+// to flipping a branch.
+type BranchDistanceConfig struct {
+	// MaxLookback describes the maximum amount of operations preceding a JUMPI which are inspected when back-propagating
+	// its condition to the comparison it originates from.
+	MaxLookback int `json:"maxLookback"`
+
+	// AdaptiveLookback describes whether back-propagation should ignore MaxLookback and extend the window until the
+	// comparison is found or the start of the call frame is reached. This handles deep require() expressions at the
+	// cost of additional tracing time. The window is still capped by MaxAdaptiveLookback, as older operations are not
+	// retained.
+	AdaptiveLookback bool `json:"adaptiveLookback"`
+
+	// MaxAdaptiveLookback describes the maximum amount of operations preceding a JUMPI retained per call frame for
+	// back-propagation when the adaptive lookback is enabled. Older operations are discarded, so conditions
+	// originating from further back in the call frame are not resolved.
+	MaxAdaptiveLookback int `json:"maxAdaptiveLookback"`
+
+	// StackSlots describes the amount of slots from the top of the stack retained for each operation cached for
+	// back-propagation. Conditions originating from deeper in the stack are not resolved, even within the lookback
+	// window.
+	StackSlots int `json:"stackSlots"`
+
+	// UseRevertedDistance describes whether call sequences which only got closer to flipping branches in call frames
+	// which later reverted (e.g. approaching a failing require()) should be added to the corpus. These distances are
+	// tracked separately and take lower priority than distances of successful call frames.
+	UseRevertedDistance bool `json:"useRevertedDistance"`
+
+	// RevertedDistanceWeightDivisor describes the factor the mutation weight of call sequences added to the corpus
+	// solely for reverted distances is divided by.
+	RevertedDistanceWeightDivisor uint64 `json:"revertedDistanceWeightDivisor"`
+
+	// IndirectJumpBranches describes whether indirect JUMPs (e.g. jump tables used for function dispatch) should be
+	// modelled as multi-way branches, with one branch per candidate destination and the distance defined over the
+	// jump destination value. This increases tracing time for code with many internal function returns. Indirect JUMPs
+	// are not modelled in code where the amount of indirect JUMPs times the amount of candidate destinations exceeds
+	// branchdistance.MaxIndirectJumpBranches.
+	IndirectJumpBranches bool `json:"indirectJumpBranches"`
+
+	// Normalization describes how raw branch distances are normalized into comparable values when computing a
+	// scalar fitness for a call sequence, either "ratio" (d/(d+1)) or "log" (bucketed by bit length).
+	Normalization string `json:"normalization"`
+
+	// Aggregation describes how the normalized distances of the branches of each contract are aggregated when
+	// computing a scalar fitness for a call sequence, either "sum", "min", or "harmonicMean".
+	Aggregation string `json:"aggregation"`
+
+	// DumpEnabled describes whether the best distance seen for each branch should be written to JSON files in the
+	// "branch_distance" directory of the corpus directory (or "crytic-export" if unset) at the end of the campaign.
+	DumpEnabled bool `json:"dumpEnabled"`
+
+	// DumpInterval describes the time in seconds between dumps of the best branch distances while fuzzing, so the
+	// convergence of individual branches can be plotted. If zero, branch distances are only dumped at the end of the
+	// campaign.
+	DumpInterval uint64 `json:"dumpInterval"`
+
+	// ReducedDistanceWeightMultiplier describes the factor the mutation weight of call sequences which reduced the
+	// distance of an already reached branch, or of the branch guarding an already reached revert, is multiplied by, so
+	// that they receive more mutation energy than call sequences which did not. A value of one disables this.
+	ReducedDistanceWeightMultiplier uint64 `json:"reducedDistanceWeightMultiplier"`
+
+	// UseLastComparisonFallback describes whether branches whose condition can't be traced back to a comparison
+	// within the lookback window should use the distance of the last comparison executed in the call frame instead.
+	// Otherwise, such branches are annotated as flat, as their distance carries no gradient.
+	UseLastComparisonFallback bool `json:"useLastComparisonFallback"`
+}
+
+// StatefulModeConfig describes the configuration options used by the stateful mode. In this mode, persistent workers
+// execute call sequences against an ever-evolving chain state rather than resetting it after each sequence, so that
+// long-horizon state (e.g. interest accrual, epoch transitions) can accumulate. The call history leading to the
+// persistent state is periodically snapshotted and used by other workers as alternative starting states.
+type StatefulModeConfig struct {
+	// Enabled describes whether the stateful mode is enabled.
+	Enabled bool `json:"enabled"`
+
+	// PersistentWorkers describes the amount of workers which execute against a persistent chain state.
+	PersistentWorkers int `json:"persistentWorkers"`
+
+	// SnapshotInterval describes the amount of call sequences a persistent worker executes between snapshots.
+	SnapshotInterval int `json:"snapshotInterval"`
+
+	// MaxSnapshots describes the maximum amount of snapshots retained. The oldest snapshots are discarded first.
+	MaxSnapshots int `json:"maxSnapshots"`
+
+	// SnapshotProbability describes the probability that a non-persistent worker starts a new call sequence from a
+	// snapshot rather than from the deployment state.
+	SnapshotProbability float32 `json:"snapshotProbability"`
+
+	// MaxHistoryLength describes the maximum amount of calls accumulated in a persistent chain state before it is
+	// reset to the deployment state.
+	MaxHistoryLength int `json:"maxHistoryLength"`
+}
+
+// PrefixBranchingConfig describes the configuration options used by prefix branching. In this mode, once a worker
+// executes a new call sequence, a prefix of it is kept on the worker's chain, and the following call sequences are
+// executed on top of it, so many mutated suffixes branch from the prefix without re-executing it.
+type PrefixBranchingConfig struct {
+	// Enabled describes whether prefix branching is enabled.
+	Enabled bool `json:"enabled"`
+
+	// Branches describes the amount of call sequences executed on top of a prefix before a new one is chosen.
+	Branches int `json:"branches"`
+}
+
+// TracerSamplingConfig describes the configuration options used to sample the transactions traced by heavy tracers
+// (branch distance, bug detectors), while cheap coverage tracers trace every transaction.
+type TracerSamplingConfig struct {
+	// Probability describes the probability of a transaction of a new call sequence being traced by heavy tracers.
+	Probability float32 `json:"probability"`
+
+	// TraceCorpusReplays describes whether heavy tracers should trace every transaction of corpus call sequences
+	// replayed without mutations (un-executed or received from other fuzzer instances), regardless of Probability.
+	// Sequences mutated from the corpus are sampled with Probability. Call sequences being shrunk are always traced in full.
+	TraceCorpusReplays bool `json:"traceCorpusReplays"`
+}
+
+// CmpLogConfig describes the configuration options used by the comparison operand log. When enabled, the concrete
+// operands of comparisons feeding conditional jumps are recorded for each call, and a mutation strategy substitutes
+// call data words matching one operand with the other, solving magic value comparisons directly.
+type CmpLogConfig struct {
+	// Enabled describes whether comparison operands should be logged and substituted into call data.
+	Enabled bool `json:"enabled"`
+
+	// MaxOperandPairs describes the maximum amount of operand pairs recorded per call, and retained by each worker.
+	MaxOperandPairs int `json:"maxOperandPairs"`
+
+	// SubstitutionWeight describes the weight of the mutation strategy which substitutes logged operands into corpus
+	// call sequences, relative to the other call sequence mutation strategies.
+	SubstitutionWeight uint64 `json:"substitutionWeight"`
+
+	// DictionaryProbability describes the probability that an integer, byte array or address argument generated for a
+	// call is sampled from the dictionary of operands (and short KECCAK256 preimages) logged in calls to the same
+	// contract. The dictionary persists across call sequences and is shared between workers. If zero, no dictionary is
+	// kept.
+	DictionaryProbability float32 `json:"dictionaryProbability"`
+
+	// MaxDictionaryEntries describes the maximum amount of integer and byte values the dictionary retains per contract.
+	MaxDictionaryEntries int `json:"maxDictionaryEntries"`
+}
+
+// TargetDirectedConfig describes the configuration options used by the target-directed mode. In this mode, the static
+// control flow distance from each branch to the configured targets is combined with runtime branch distances, and
+// call sequences which get closer to the targets are added to the corpus and favoured when selecting sequences to
+// mutate, AFLGo-style.
+type TargetDirectedConfig struct {
+	// Enabled describes whether the target-directed mode is enabled.
+	Enabled bool `json:"enabled"`
+
+	// Targets describes the code locations to direct fuzzing towards. Each target is either the runtime bytecode program
+	// counter of a JUMPI or JUMPDEST in a contract (e.g. "MyContract:0x1a4" or "MyContract:420"), or a source line
+	// (e.g. "src/MyContract.sol:42").
+	Targets []string `json:"targets"`
+
+	// MaxWeightMultiplier describes the factor applied to the mutation weight of the call sequences closest to the
+	// targets. Call sequences further away are scaled down linearly towards a factor of one.
+	MaxWeightMultiplier uint64 `json:"maxWeightMultiplier"`
+}
+
+// AdaptivePowerScheduleConfig describes the configuration options used by the AFL-style adaptive power schedule. When
+// enabled, each time a call sequence derived from a corpus call sequence is added to the corpus (e.g. for new coverage,
+// a reduced branch distance or a new tokenflow), the mutation weight of the corpus call sequence is doubled, up to a
+// limit. The boost decays over time, so energy flows to the call sequences which were productive most recently.
+type AdaptivePowerScheduleConfig struct {
+	// Enabled describes whether the adaptive power schedule is enabled.
+	Enabled bool `json:"enabled"`
+
+	// MaxExponent describes the maximum amount of times the mutation weight of a corpus call sequence can be doubled.
+	MaxExponent uint `json:"maxExponent"`
+
+	// DecayInterval describes the time in seconds after which the mutation weight boost of every corpus call sequence
+	// is halved.
+	DecayInterval uint64 `json:"decayInterval"`
+}
+
+// MultiObjectiveConfig describes the configuration options used by the multi-objective (MOSA-style) seed selection.
+// When enabled, the corpus keeps a Pareto archive of the call sequences which are not dominated by any other across
+// the configured objectives, and samples mutation targets from it by choosing an objective according to its weight,
+// then one of the call sequences best for it.
+type MultiObjectiveConfig struct {
+	// Enabled describes whether the multi-objective seed selection is enabled.
+	Enabled bool `json:"enabled"`
+
+	// Objectives maps the fitness metrics used as objectives to their weight. Objectives are "codeCoverage",
+	// "branchCoverage", "cmpDistance", "branchDistance", "dataflow", "storageWrite" or "tokenflow", and the fitness
+	// metric of each must be enabled.
+	Objectives map[string]uint64 `json:"objectives"`
+
+	// ArchiveProbability describes the probability that a mutation target is sampled from the Pareto archive rather
+	// than from the weighted corpus.
+	ArchiveProbability float64 `json:"archiveProbability"`
+}
+
+// NestedCallsConfig describes the configuration options used to generate nested calls. Methods whose arguments have
+// the shape of nested calls, such as execute(address,bytes), multicall(bytes[]) or Multicall3's
+// aggregate((address,bytes)[]), decode their call data arguments into calls, which act as coverage walls when
+// filled with random bytes. These arguments are instead populated with valid call data for methods of deployed
+// contracts.
+type NestedCallsConfig struct {
+	// Probability describes the probability that an argument inferred to carry call data is populated with a valid
+	// nested call when a call is generated or mutated.
+	Probability float32 `json:"probability"`
+
+	// MaxDepth describes the maximum depth of nested calls, as nested calls may carry nested calls themselves. A
+	// depth of zero disables nested calls.
+	MaxDepth uint64 `json:"maxDepth"`
+}
+
+// CallTransplantConfig describes the configuration options used by the call transplant mutation. When enabled, the
+// corpus keeps the calls which produced new coverage of any fitness metric, and a mutation strategy inserts one of them
+// at a random position of another corpus call sequence, as many stateful bugs need combinations of calls no single
+// corpus call sequence contains.
+type CallTransplantConfig struct {
+	// Enabled describes whether high-value calls are transplanted into corpus call sequences.
+	Enabled bool `json:"enabled"`
+
+	// Weight describes the weight of the mutation strategy which transplants high-value calls, relative to the other
+	// call sequence mutation strategies.
+	Weight uint64 `json:"weight"`
+
+	// TokenflowWeight describes the weight with which calls that produced a new tokenflow are chosen for transplanting,
+	// relative to a weight of one for calls that produced new coverage of other fitness metrics.
+	TokenflowWeight uint64 `json:"tokenflowWeight"`
+}
+
+const (
+	// CodeCoverageObjective describes the amount of instructions covered by a call sequence.
+	CodeCoverageObjective = "codeCoverage"
+	// BranchCoverageObjective describes the amount of branches covered by a call sequence.
+	BranchCoverageObjective = "branchCoverage"
+	// CmpDistanceObjective describes the amount of comparisons covered by a call sequence.
+	CmpDistanceObjective = "cmpDistance"
+	// BranchDistanceObjective describes the scalar branch distance fitness of a call sequence, which is minimized.
+	BranchDistanceObjective = "branchDistance"
+	// DataflowObjective describes the amount of dataflows produced by a call sequence.
+	DataflowObjective = "dataflow"
+	// StorageWriteObjective describes the amount of storage writes produced by a call sequence, or the amount of
+	// distinct (slot, value bucket) pairs it writes if StorageWriteConfig.SlotDiversity is enabled.
+	StorageWriteObjective = "storageWrite"
+	// TokenflowObjective describes the amount of tokenflows produced by a call sequence.
+	TokenflowObjective = "tokenflow"
+)
+
+// objectiveEnabled indicates whether the fitness metric of the provided multi-objective objective is enabled.
+// Returns the indicator, and a boolean indicating whether the objective is known.
+func (f *FitnessMetricConfig) objectiveEnabled(objective string) (bool, bool) {
+	switch objective {
+	case CodeCoverageObjective:
+		return f.CodeCoverageEnabled, true
+	case BranchCoverageObjective:
+		return f.BranchCoverageEnabled, true
+	case CmpDistanceObjective:
+		return f.CmpDistanceEnabled, true
+	case BranchDistanceObjective:
+		return f.BranchDistanceEnabled, true
+	case DataflowObjective:
+		return f.DataflowEnabled, true
+	case StorageWriteObjective:
+		return f.StorageWriteEnabled, true
+	case TokenflowObjective:
+		return f.TokenflowEnabled, true
+	default:
+		return false, false
+	}
+}
+
+// TimeAccelerationConfig describes the configuration options used by the time acceleration schedule. When enabled,
+// generated call sequences, including those mutated from the corpus, are split into segments, and the chain is
+// advanced by a large time jump (e.g. days or weeks) between segments, so that vesting, epoch and auction logic which only unlocks at realistic horizons becomes
+// reachable. The applied jumps are recorded in call sequences, and thus in reproducers.
+type TimeAccelerationConfig struct {
+	// Enabled describes whether the time acceleration schedule is enabled.
+	Enabled bool `json:"enabled"`
+
+	// SegmentLength describes the amount of calls in each sequence segment.
+	SegmentLength int `json:"segmentLength"`
+
+	// Jumps describes the time jumps, in seconds, one of which is randomly applied between segments.
+	Jumps []uint64 `json:"jumps"`
+
+	// JumpProbability describes the probability that a time jump is applied between two segments.
+	JumpProbability float32 `json:"jumpProbability"`
+
+	// BlockTime describes the amount of seconds per block, used to derive the blocks advanced alongside a time jump.
+	BlockTime uint64 `json:"blockTime"`
+}
+
+// SenderStrategyConfig describes the configuration options used to choose the senders of generated calls. A strategy
+// is chosen for each call sequence with a probability proportional to its weight.
+type SenderStrategyConfig struct {
+	// Strategies maps the sender strategies to their weight. Strategies are "random" (a random sender for each call),
+	// "roundRobin" (the senders in turn), "stickyAttacker" (a single random sender for the whole sequence) or
+	// "ownerThenAttacker" (the deployer for the first OwnerCalls calls, then a single random sender for the rest).
+	Strategies map[string]uint64 `json:"strategies"`
+
+	// OwnerCalls describes the amount of calls the deployer sends at the start of a sequence in the
+	// "ownerThenAttacker" strategy.
+	OwnerCalls int `json:"ownerCalls"`
+}
+
+const (
+	// RandomSenderStrategy sends each call from a random sender.
+	RandomSenderStrategy = "random"
+	// RoundRobinSenderStrategy sends the calls of a sequence from each sender in turn.
+	RoundRobinSenderStrategy = "roundRobin"
+	// StickyAttackerSenderStrategy sends all calls of a sequence from a single random sender.
+	StickyAttackerSenderStrategy = "stickyAttacker"
+	// OwnerThenAttackerSenderStrategy sends the first calls of a sequence from the deployer, then the rest from a
+	// single random sender.
+	OwnerThenAttackerSenderStrategy = "ownerThenAttacker"
+)
+
+// senderStrategies describes the known sender strategies.
+var senderStrategies = []string{RandomSenderStrategy, RoundRobinSenderStrategy, StickyAttackerSenderStrategy, OwnerThenAttackerSenderStrategy}
+
+// ContractFunctionsConfig describes the configuration options used to focus the calls generated to a contract on the
+// functions making up the attack surface of interest. Functions are identified by their signature (e.g.
+// "setOwner(address)") or their 4-byte selector (e.g. "0x13af4035").
+type ContractFunctionsConfig struct {
+	// TargetFunctions describes the functions of the contract generated calls exclusively target, if non-empty.
+	TargetFunctions []string `json:"targetFunctions"`
+
+	// ExcludedFunctions describes the functions of the contract generated calls never target, such as
+	// governance-only functions.
+	ExcludedFunctions []string `json:"excludedFunctions"`
+
+	// Weights maps functions of the contract to the relative frequency generated calls target them with. Functions
+	// which are not listed have a weight of one.
+	Weights map[string]uint64 `json:"weights"`
+}
+
+// ValueStrategyConfig describes the configuration options used to choose the msg.value of generated calls to payable
+// methods. A strategy is chosen for each call with a probability proportional to its weight.
+type ValueStrategyConfig struct {
+	// Strategies maps the value strategies to their weight. Strategies are "random" (a random 64-bit value),
+	// "zeroHeavy" (mostly zero, otherwise random), "balanceProportional" (a random fraction of the sender's balance,
+	// up to all of it), "dust" (a few wei) or "threshold" (a comparison operand logged for the called contract, or one
+	// off it, from the operand dictionary of the comparison operand log, or a random value if no dictionary is kept).
+	Strategies map[string]uint64 `json:"strategies"`
+}
+
+const (
+	// RandomValueStrategy sends a random 64-bit value.
+	RandomValueStrategy = "random"
+	// ZeroHeavyValueStrategy mostly sends no value, otherwise a random one.
+	ZeroHeavyValueStrategy = "zeroHeavy"
+	// BalanceProportionalValueStrategy sends a random fraction of the sender's balance.
+	BalanceProportionalValueStrategy = "balanceProportional"
+	// DustValueStrategy sends a few wei.
+	DustValueStrategy = "dust"
+	// ThresholdValueStrategy sends a comparison operand logged for the called contract, or one off it.
+	ThresholdValueStrategy = "threshold"
+)
+
+// valueStrategies describes the known value strategies.
+var valueStrategies = []string{RandomValueStrategy, ZeroHeavyValueStrategy, BalanceProportionalValueStrategy, DustValueStrategy, ThresholdValueStrategy}
+
+// validateStrategyWeights verifies the provided strategy weights only refer to known strategies and that at least one
+// strategy can be chosen.
+// Returns an error describing the first problem found, if any.
+func validateStrategyWeights(kind string, weights map[string]uint64, knownStrategies []string) error {
+	totalWeight := uint64(0)
+	for strategy, weight := range weights {
+		if !slices.Contains(knownStrategies, strategy) {
+			return fmt.Errorf("project configuration must specify only known %s strategies: %s", kind, strategy)
+		}
+		totalWeight += weight
+	}
+	if totalWeight == 0 {
+		return fmt.Errorf("project configuration must specify a positive weight for at least one %s strategy", kind)
+	}
+	return nil
+}
+
+// BlockWarpConfig describes the configuration options used by block warps. When enabled, once a call's branch
+// conditions are found by the block dependency bug detector to depend on the block timestamp or number, the calls
+// following it in the call sequence advance block time and number by large warps (including far-future ones) with
+// the configured probability, rather than the default block advancement. Warps advance the block number only by a
+// single block if the branch conditions depend on the timestamp alone.
+type BlockWarpConfig struct {
+	// Enabled describes whether block warps are enabled.
+	Enabled bool `json:"enabled"`
+
+	// Warps describes the time warps, in seconds, one of which is randomly applied before a call.
+	Warps []uint64 `json:"warps"`
+
+	// WarpProbability describes the probability that a warp is applied before each call following a call with block
+	// dependent branch conditions.
+	WarpProbability float32 `json:"warpProbability"`
+
+	// BlockTime describes the amount of seconds per block, used to derive the blocks advanced alongside a warp.
+	BlockTime uint64 `json:"blockTime"`
+}
+
+const (
+	// BranchDistanceNormalizationRatio normalizes a branch distance d to d/(d+1).
+	BranchDistanceNormalizationRatio = "ratio"
+
+	// BranchDistanceNormalizationLog normalizes a branch distance to its bit length divided by 256, bucketing
+	// distances by order of magnitude.
+	BranchDistanceNormalizationLog = "log"
+
+	// BranchDistanceAggregationSum aggregates the normalized distances of a contract by summing them.
+	BranchDistanceAggregationSum = "sum"
+
+	// BranchDistanceAggregationMin aggregates the normalized distances of a contract by taking the closest one.
+	BranchDistanceAggregationMin = "min"
+
+	// BranchDistanceAggregationHarmonicMean aggregates the normalized distances of a contract by taking their harmonic
+	// mean, which favors contracts with some branches close to being flipped.
+	BranchDistanceAggregationHarmonicMean = "harmonicMean"
+)
+
+const (
+	// AddressAttributionModeBlank attributes coverage of all contracts created during call sequences to the zero
+	// address, merging it into a single bucket.
+	AddressAttributionModeBlank = "blank"
+
+	// AddressAttributionModePseudo attributes coverage of each contract created during call sequences to a stable
+	// pseudo-address derived from its creator and the creator's nonce.
+	AddressAttributionModePseudo = "pseudo"
+)
+
+// AddressAttributionConfig describes the configuration options used to attribute coverage of contracts which are not
+// present in the base chain (i.e. created during call sequences). Recording their real addresses would grow coverage
+// maps (and thus the corpus) indefinitely, so they are either merged under the zero address, or attributed a bounded
+// set of pseudo-addresses which keeps coverage of distinct instances (e.g. factory-created pools) apart.
+type AddressAttributionConfig struct {
+	// Mode describes the attribution mode, either "blank" or "pseudo".
+	Mode string `json:"mode"`
+
+	// MaxPseudoAddresses describes the maximum amount of pseudo-addresses retained per coverage tracer in the "pseudo"
+	// mode. Once exceeded, the least recently used pseudo-address is evicted, and its contract falls back to the zero
+	// address.
+	MaxPseudoAddresses int `json:"maxPseudoAddresses"`
+}
+
+// PathCoverageConfig describes the configuration options used by the path coverage fitness metric. The branch decisions
+// (conditional jump locations and outcomes) taken by a transaction are hashed in execution order into a path hash, which
+// is recorded in a bounded bloom filter. A call sequence is considered interesting if it takes a path that was not seen
+// before, even if every branch along it was already covered. As the bloom filter fills up, distinct paths increasingly
+// collide with one another; the estimated collision rate is reported alongside the amount of paths.
+type PathCoverageConfig struct {
+	// BloomFilterBits describes the amount of bits in the bloom filter recording path hashes.
+	BloomFilterBits uint64 `json:"bloomFilterBits"`
+
+	// BloomFilterHashes describes the amount of bits set in the bloom filter per path hash.
+	BloomFilterHashes uint64 `json:"bloomFilterHashes"`
+
+	// PerSequence describes whether the paths of all transactions in a call sequence should additionally be hashed
+	// together, rewarding novel orderings of already seen transaction paths.
+	PerSequence bool `json:"perSequence"`
+}
+
+// ConstructorArgsSearchConfig describes the configuration options used to search for constructor arguments of target
+// contracts before they are deployed. Candidate arguments are mutated from the best ones found so far (seeded by the
+// ConstructorArgs provided, if any) and deployed on a scratch chain, keeping those which deploy successfully while
+// covering the most of the init bytecode (and that of contracts it creates). This lets contracts with complex
+// constructors get fully initialized without hand-picking their arguments.
+type ConstructorArgsSearchConfig struct {
+	// Enabled describes whether constructor arguments should be searched for. If enabled, target contracts with no
+	// ConstructorArgs provided are deployed with the arguments found rather than failing.
+	Enabled bool `json:"enabled"`
+
+	// Iterations describes the amount of candidate constructor arguments to deploy per target contract.
+	Iterations int `json:"iterations"`
+}
+
+// CoverageTimeSeriesConfig describes the configuration options used to export a time series of fuzzing metrics (covered
+// instructions and branches, distinct dataflows and tokenflows, and bugs found) while fuzzing, so campaigns can be
+// compared with coverage-over-time plots. The time series is written to the "coverage_time_series.csv" (or ".json")
+// file of the corpus directory (or "crytic-export" if unset).
+type CoverageTimeSeriesConfig struct {
+	// Interval describes the time in seconds between samples of the time series. If zero, no time series is exported.
+	Interval uint64 `json:"interval"`
+
+	// Format describes the format the time series is written in, either "csv" or "json".
+	Format string `json:"format"`
+}
+
+// MetricExclusionsConfig describes the contracts (e.g. libraries, mocks and helper contracts) which are not traced by
+// the code coverage, branch coverage and branch distance metrics, so their totals only reflect the system under test.
+type MetricExclusionsConfig struct {
+	// Addresses describes the addresses of excluded contracts. Code executed through a DELEGATECALL (e.g. libraries)
+	// executes in the context of its caller, so it must be excluded by contract name or bytecode hash instead.
+	Addresses []string `json:"addresses"`
+
+	// ContractNames describes the names of excluded contracts.
+	ContractNames []string `json:"contractNames"`
+
+	// BytecodeHashes describes the keccak256 hashes of the init or runtime bytecode of excluded contracts.
+	BytecodeHashes []string `json:"bytecodeHashes"`
+}
+
+// DistributedConfig describes the configuration options used to fuzz across multiple processes or machines. Each
+// fuzzer instance periodically synchronizes with a coordinator over HTTP, publishing the corpus call sequences and the
+// code coverage and branch distances it discovered since its last synchronization, and receiving those discovered by
+// the other instances. One of the instances hosts the coordinator. All instances must fuzz the same project with the
+// same configuration, so call sequences target the same contracts.
+type DistributedConfig struct {
+	// Enabled describes whether the fuzzer should synchronize with a coordinator.
+	Enabled bool `json:"enabled"`
+
+	// Address describes the network address (host:port) of the coordinator.
+	Address string `json:"address"`
+
+	// ServeCoordinator describes whether this fuzzer instance should host the coordinator at Address, for itself and
+	// the other instances to synchronize with.
+	ServeCoordinator bool `json:"serveCoordinator"`
+
+	// SyncInterval describes the time in seconds between synchronizations with the coordinator.
+	SyncInterval uint64 `json:"syncInterval"`
+}
+
+// ExplorerConfig describes the configuration options used to obtain the ABIs of on-chain target contracts. Verified ABIs
+// are fetched from an Etherscan-compatible block explorer API, such as those of Etherscan or Blockscout, then from
+// Sourcify, and are cached on disk. If none can be fetched (e.g. when offline), the local ABI files are used instead.
+// As a last resort, an ABI is synthesized from the function selectors found in the contract's runtime bytecode.
+type ExplorerConfig struct {
+	// Enabled describes whether verified ABIs should be fetched from the block explorer API.
+	Enabled bool `json:"enabled"`
+
+	// ApiUrl describes the URL of the block explorer API (e.g. https://api.etherscan.io/v2/api).
+	ApiUrl string `json:"apiUrl"`
+
+	// ApiKey describes the key sent with each request to the block explorer API. It may be empty if the API does not
+	// require one.
+	ApiKey string `json:"apiKey"`
+
+	// ChainId describes the ID of the chain the on-chain targets are deployed on, sent with each request for APIs
+	// serving multiple chains. Zero omits it.
+	ChainId uint64 `json:"chainId"`
+
+	// CacheDirectory describes the directory fetched ABIs are cached in. If empty, ABIs are not cached.
+	CacheDirectory string `json:"cacheDirectory"`
+
+	// RequestsPerSecond describes the maximum amount of requests sent to the block explorer API per second. Zero
+	// disables rate limiting.
+	RequestsPerSecond uint64 `json:"requestsPerSecond"`
+
+	// SourcifyEnabled describes whether verified ABIs should be fetched from Sourcify if the block explorer API has
+	// none. Sourcify is queried for the chain described by ChainId.
+	SourcifyEnabled bool `json:"sourcifyEnabled"`
+
+	// SourcifyApiUrl describes the URL of the Sourcify server (e.g. https://sourcify.dev/server).
+	SourcifyApiUrl string `json:"sourcifyApiUrl"`
+
+	// SelectorHeuristicEnabled describes whether an ABI should be synthesized from the function selectors found in the
+	// runtime bytecode of on-chain targets for which no ABI could be obtained otherwise. Selectors are resolved to
+	// function signatures using a bundled signature database.
+	SelectorHeuristicEnabled bool `json:"selectorHeuristicEnabled"`
+
+	// ProxyResolutionEnabled describes whether on-chain targets which are proxies (EIP-1967, UUPS, beacon or minimal
+	// proxies) should be fuzzed using the ABI of their implementation, with fitness metrics attributed to the
+	// implementation's code.
+	ProxyResolutionEnabled bool `json:"proxyResolutionEnabled"`
+}
+
+// OnChainInteractionConfig describes the configuration options used to interleave calls among multiple on-chain target
+// contracts (e.g. a protocol, its tokens and its periphery). An interaction model shared by all workers counts, for
+// each pair of targets, how often a call to one directly following a call to the other produced new coverage, so that
+// the transitions between contracts which reach new cross-contract paths are favoured.
+type OnChainInteractionConfig struct {
+	// SwitchProbability describes the probability that a generated call targets a different on-chain target than the
+	// previous call of the sequence, chosen by the interaction model, rather than a random method. If zero, calls are
+	// not interleaved by the interaction model.
+	SwitchProbability float32 `json:"switchProbability"`
+}
+
+// ForkSeedingConfig describes the configuration options used to give adversarial addresses ether and ERC20 token
+// balances before fuzzing begins, so that paths of on-chain targets which depend on the value held by the caller are
+// reachable. Balances are seeded with transactions once the test chain is set up, so they are part of every worker's
+// chain.
+type ForkSeedingConfig struct {
+	// Holders describes the addresses which are given the seeded balances. If empty, the adversarial addresses (see
+	// AdversariesConfig) are.
+	Holders []string `json:"holders"`
+
+	// EtherBalance describes the balance of ether, in wei, each holder is given, using the deal cheat code. If nil,
+	// ether balances are left unchanged.
+	EtherBalance *ContractBalance `json:"etherBalance"`
+
+	// Tokens describes the ERC20 tokens each holder is given a balance of.
+	Tokens []TokenSeedConfig `json:"tokens"`
+}
+
+// TokenSeedConfig describes an ERC20 token balance given to each holder of a ForkSeedingConfig.
+type TokenSeedConfig struct {
+	// Address describes the address of the token.
+	Address string `json:"address"`
+
+	// Balance describes the balance of the token, in its smallest unit, each holder is given.
+	Balance *ContractBalance `json:"balance"`
+
+	// Whale describes an address holding enough of the token, which is impersonated to transfer the balance to each
+	// holder. If empty, the balance is instead written to the token's storage, using the store cheat code.
+	Whale string `json:"whale"`
+
+	// BalanceSlot describes the storage slot of the token's balances mapping, used if the balance is written to the
+	// token's storage. If nil, the slot is found by probing the token's balanceOf method.
+	BalanceSlot *uint64 `json:"balanceSlot"`
+}
+
+// HistoricalTransactionsConfig describes the configuration options used to import the most recent transactions sent to
+// on-chain target contracts before the fork block, from the fork's RPC, and replay them as initial call sequences. This
+// gives the fuzzer realistic call data (selectors, amounts, routes) to mutate from. Transactions are split into call
+// sequences of at most the call sequence length, in the order they were mined, and are not written to the corpus
+// directory unless they achieve new coverage.
+type HistoricalTransactionsConfig struct {
+	// Count describes the maximum amount of transactions to import. If zero, no transactions are imported.
+	Count uint64 `json:"count"`
+
+	// MaxBlocks describes the maximum amount of blocks, back from the fork block, searched for transactions to the
+	// targets. Every block is fetched from the RPC, so archive or local endpoints are recommended for large values.
+	MaxBlocks uint64 `json:"maxBlocks"`
+
+	// KeepSenders describes whether transactions are replayed from their original senders, rather than from the
+	// sender addresses. Original senders are impersonated, so their authorization is kept, but they are not used by
+	// the fuzzer when generating new calls.
+	KeepSenders bool `json:"keepSenders"`
+}
+
+// ForkTimelineConfig describes the configuration options used to fuzz forked protocols whose logic depends on time
+// (e.g. auctions, vesting) over realistic timelines. Along with the fork block, set by the chain configuration, they
+// control how block numbers and time progress during call sequences, and how the forked state is refreshed.
+type ForkTimelineConfig struct {
+	// BlockTime describes the amount of seconds between blocks. If non-zero, the timestamp delay of each generated
+	// call is its block number delay times the block time, so block numbers and time advance together as on the
+	// forked chain. Otherwise, they are chosen independently.
+	BlockTime uint64 `json:"blockTime"`
+
+	// RefreshInterval describes the amount of seconds after which the base test chain is set up again from the
+	// latest block of the fork's RPC, so workers created afterwards fuzz the refreshed state. If zero, the state is
+	// never refreshed.
+	RefreshInterval uint64 `json:"refreshInterval"`
+}
+
+// CheatCodeCallConfig describes a call to a method of the standard cheat code contract.
+type CheatCodeCallConfig struct {
+	// Method describes the name of the cheat code method to call (e.g. warp, roll, deal, or store).
+	Method string `json:"method"`
+
+	// Args describes the arguments of the call, in the same JSON format as constructor arguments.
+	Args []any `json:"args"`
+}
+
+// RPCServerConfig describes the configuration options used to expose the fuzzer's test chain over a minimal eth_
+// JSON-RPC endpoint, so external tools can inspect the chain state.
+type RPCServerConfig struct {
+	// Enabled describes whether the test chain should be served over JSON-RPC while fuzzing.
+	Enabled bool `json:"enabled"`
+
+	// Address describes the network address (host:port) the JSON-RPC server should listen on.
+	Address string `json:"address"`
+
+	// ServeAfterCampaign describes whether the server should keep serving once the campaign has ended, until the
+	// process is interrupted. Failing call sequences are replayed onto the served chain so they can be inspected.
+	ServeAfterCampaign bool `json:"serveAfterCampaign"`
+}
+
+// DashboardConfig describes the configuration options used to serve a web dashboard displaying per-contract coverage,
+// the best distances of the hardest branches, and the latest bugs detected while fuzzing.
+type DashboardConfig struct {
+	// Enabled describes whether the dashboard should be served while fuzzing.
+	Enabled bool `json:"enabled"`
+
+	// Address describes the network address (host:port) the dashboard should listen on.
+	Address string `json:"address"`
+
+	// RefreshInterval describes the amount of seconds the dashboard waits between updates.
+	RefreshInterval uint64 `json:"refreshInterval"`
+}
@@ -45,6 +45,7 @@ func GetDefaultProjectConfig(platform string) (*ProjectConfig, error) {
 			Workers:                 10,
 			WorkerResetLimit:        50,
 			Timeout:                 0,
+			Seed:                    0,
 			TestLimit:               0,
 			ShrinkLimit:             5_000,
 			CallSequenceLength:      100,
@@ -67,11 +68,16 @@ func GetDefaultProjectConfig(platform string) (*ProjectConfig, error) {
 				new(big.Int).Div(abi.MaxInt256, big.NewInt(2)),
 				new(big.Int).Div(abi.MaxInt256, big.NewInt(2)),
 			},
-			DeployerAddress:        "0x30000",
-			MaxBlockNumberDelay:    60480,
-			MaxBlockTimestampDelay: 604800,
-			TransactionGasLimit:    12_500_000,
-			RevertReporterEnabled:  false,
+			DeployerAddress:           "0x30000",
+			MaxBlockNumberDelay:       60480,
+			MaxBlockTimestampDelay:    604800,
+			TransactionGasLimit:       12_500_000,
+			RevertReporterEnabled:     false,
+			StateDiffsEnabled:         false,
+			GasProfilingEnabled:       false,
+			ResultsFileEnabled:        false,
+			SARIFFileEnabled:          false,
+			FoundryReproducersEnabled: false,
 			Testing: TestingConfig{
 				StopOnFailedTest:             true,
 				StopOnFailedContractMatching: false,
@@ -92,6 +98,8 @@ func GetDefaultProjectConfig(platform string) (*ProjectConfig, error) {
 					TestPrefixes: []string{
 						"property_",
 					},
+					ReportAsBugs:        false,
+					GuideBranchDistance: false,
 				},
 				OptimizationTesting: OptimizationTestingConfig{
 					Enabled: true,
@@ -99,6 +107,10 @@ func GetDefaultProjectConfig(platform string) (*ProjectConfig, error) {
 						"optimize_",
 					},
 				},
+				IdempotencyTesting: IdempotencyTestingConfig{
+					Enabled:                   false,
+					ExcludeFunctionSignatures: []string{},
+				},
 				HelperContract: HelperContractConfig{
 					Enabled:                 true,
 					EnabledContractCall:     true,
@@ -108,6 +120,201 @@ func GetDefaultProjectConfig(platform string) (*ProjectConfig, error) {
 				},
 			},
 			TestChainConfig: *chainConfig,
+			FitnessMetricConfig: FitnessMetricConfig{
+				Saturation: FitnessMetricSaturationConfig{
+					Enabled:         false,
+					Window:          10_000,
+					MinNewItems:     1,
+					ReprobeInterval: 600,
+				},
+			},
+			Adversaries: AdversariesConfig{
+				Accounts:         []string{},
+				AccountBalance:   new(big.Int).Div(abi.MaxInt256, big.NewInt(2)),
+				SendTransactions: true,
+				Contracts:        []string{},
+			},
+			RPCServerConfig: RPCServerConfig{
+				Enabled:            false,
+				Address:            "127.0.0.1:8545",
+				ServeAfterCampaign: false,
+			},
+			DashboardConfig: DashboardConfig{
+				Enabled:         false,
+				Address:         "127.0.0.1:8080",
+				RefreshInterval: 3,
+			},
+			BranchCoverage: BranchCoverageConfig{
+				ContextDepth:           0,
+				DynamicBranchMapsLimit: 256,
+			},
+			Dataflow: DataflowConfig{
+				PersistWrites:           false,
+				TrackOutflows:           false,
+				ExportFormats:           []string{},
+				TrackUninitializedReads: false,
+				OrderingProbability:     0,
+			},
+			StorageWrite: StorageWriteConfig{
+				BucketMode:       "range",
+				BucketBoundaries: []uint{4, 16, 64},
+				SlotDiversity:    false,
+			},
+			Tokenflow: TokenflowConfig{
+				Selectors: []TokenSelectorConfig{},
+			},
+			BalanceDelta: BalanceDeltaConfig{
+				Addresses: []string{},
+				Tokens:    []BalanceDeltaTokenConfig{},
+			},
+			BranchDistance: BranchDistanceConfig{
+				MaxLookback:                     40,
+				AdaptiveLookback:                false,
+				MaxAdaptiveLookback:             1024,
+				StackSlots:                      32,
+				UseRevertedDistance:             false,
+				RevertedDistanceWeightDivisor:   4,
+				IndirectJumpBranches:            false,
+				Normalization:                   BranchDistanceNormalizationRatio,
+				Aggregation:                     BranchDistanceAggregationSum,
+				DumpEnabled:                     false,
+				DumpInterval:                    0,
+				ReducedDistanceWeightMultiplier: 4,
+				UseLastComparisonFallback:       false,
+			},
+			StatefulMode: StatefulModeConfig{
+				Enabled:             false,
+				PersistentWorkers:   1,
+				SnapshotInterval:    10,
+				MaxSnapshots:        5,
+				SnapshotProbability: 0.25,
+				MaxHistoryLength:    1_000,
+			},
+			PrefixBranching: PrefixBranchingConfig{
+				Enabled:  false,
+				Branches: 16,
+			},
+			TracerSampling: TracerSamplingConfig{
+				Probability:        1,
+				TraceCorpusReplays: true,
+			},
+			CmpLog: CmpLogConfig{
+				Enabled:               false,
+				MaxOperandPairs:       256,
+				SubstitutionWeight:    40,
+				DictionaryProbability: 0.1,
+				MaxDictionaryEntries:  1_024,
+			},
+			TargetDirected: TargetDirectedConfig{
+				Enabled:             false,
+				Targets:             []string{},
+				MaxWeightMultiplier: 16,
+			},
+			TimeAcceleration: TimeAccelerationConfig{
+				Enabled:         false,
+				SegmentLength:   5,
+				Jumps:           []uint64{86_400, 604_800, 2_592_000},
+				JumpProbability: 0.5,
+				BlockTime:       12,
+			},
+			BlockWarp: BlockWarpConfig{
+				Enabled:         false,
+				Warps:           []uint64{1, 3_600, 86_400, 604_800, 2_592_000, 31_536_000, 315_360_000},
+				WarpProbability: 0.5,
+				BlockTime:       12,
+			},
+			AdaptivePowerSchedule: AdaptivePowerScheduleConfig{
+				Enabled:       false,
+				MaxExponent:   8,
+				DecayInterval: 60,
+			},
+			MultiObjective: MultiObjectiveConfig{
+				Enabled: false,
+				Objectives: map[string]uint64{
+					CodeCoverageObjective:   1,
+					BranchDistanceObjective: 1,
+				},
+				ArchiveProbability: 0.5,
+			},
+			CallTransplant: CallTransplantConfig{
+				Enabled:         false,
+				Weight:          40,
+				TokenflowWeight: 4,
+			},
+			NestedCalls: NestedCallsConfig{
+				Probability: 0.5,
+				MaxDepth:    2,
+			},
+			SenderStrategy: SenderStrategyConfig{
+				Strategies: map[string]uint64{
+					RandomSenderStrategy: 1,
+				},
+				OwnerCalls: 1,
+			},
+			ValueStrategy: ValueStrategyConfig{
+				Strategies: map[string]uint64{
+					RandomValueStrategy: 1,
+				},
+			},
+			ContractFunctions: map[string]ContractFunctionsConfig{},
+			CoverageAddressAttribution: AddressAttributionConfig{
+				Mode:               AddressAttributionModeBlank,
+				MaxPseudoAddresses: 256,
+			},
+			PathCoverage: PathCoverageConfig{
+				BloomFilterBits:   1 << 24,
+				BloomFilterHashes: 3,
+				PerSequence:       false,
+			},
+			ConstructorArgsSearch: ConstructorArgsSearchConfig{
+				Enabled:    false,
+				Iterations: 500,
+			},
+			CoverageTimeSeries: CoverageTimeSeriesConfig{
+				Interval: 0,
+				Format:   "csv",
+			},
+			MetricExclusions: MetricExclusionsConfig{
+				Addresses:      []string{},
+				ContractNames:  []string{},
+				BytecodeHashes: []string{},
+			},
+			Distributed: DistributedConfig{
+				Enabled:          false,
+				Address:          "127.0.0.1:9545",
+				ServeCoordinator: false,
+				SyncInterval:     10,
+			},
+			Explorer: ExplorerConfig{
+				Enabled:                  false,
+				ApiUrl:                   "https://api.etherscan.io/v2/api",
+				ApiKey:                   "",
+				ChainId:                  1,
+				CacheDirectory:           "explorer-cache",
+				RequestsPerSecond:        5,
+				SourcifyEnabled:          false,
+				SourcifyApiUrl:           "https://sourcify.dev/server",
+				SelectorHeuristicEnabled: true,
+				ProxyResolutionEnabled:   true,
+			},
+			OnChainInteraction: OnChainInteractionConfig{
+				SwitchProbability: 0.5,
+			},
+			ForkSeeding: ForkSeedingConfig{
+				Holders:      []string{},
+				EtherBalance: nil,
+				Tokens:       []TokenSeedConfig{},
+			},
+			HistoricalTransactions: HistoricalTransactionsConfig{
+				Count:       0,
+				MaxBlocks:   1000,
+				KeepSenders: false,
+			},
+			ForkTimeline: ForkTimelineConfig{
+				BlockTime:       0,
+				RefreshInterval: 0,
+			},
+			SetupCheatCodes: []CheatCodeCallConfig{},
 		},
 		Compilation: compilationConfig,
 		Slither:     slitherConfig,
@@ -115,6 +322,7 @@ func GetDefaultProjectConfig(platform string) (*ProjectConfig, error) {
 			Level:        zerolog.InfoLevel,
 			LogDirectory: "",
 			NoColor:      false,
+			Diagnostics:  map[string]int{},
 		},
 	}
 
@@ -0,0 +1,56 @@
+package coordinator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// requestTimeout describes the maximum duration of a synchronization with the coordinator.
+const requestTimeout = 60 * time.Second
+
+// Client synchronizes a fuzzer instance with a coordinator Server.
+type Client struct {
+	// url describes the URL SyncRequest objects are posted to.
+	url string
+
+	// httpClient describes the HTTP client used to send requests.
+	httpClient *http.Client
+}
+
+// NewClient creates a new Client which synchronizes with the coordinator at the provided network address (host:port).
+func NewClient(address string) *Client {
+	return &Client{
+		url:        "http://" + address + syncPath,
+		httpClient: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// Sync publishes an update to the coordinator and obtains the updates published by other fuzzer instances.
+// Returns the coordinator's response, or an error if one occurred.
+func (c *Client) Sync(request *SyncRequest) (*SyncResponse, error) {
+	b, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	httpResponse, err := c.httpClient.Post(c.url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(httpResponse.Body, 1024))
+		return nil, fmt.Errorf("coordinator responded with status %v: %s", httpResponse.Status, bytes.TrimSpace(message))
+	}
+
+	var response SyncResponse
+	if err = json.NewDecoder(httpResponse.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
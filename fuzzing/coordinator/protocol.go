@@ -0,0 +1,59 @@
+package coordinator
+
+import (
+	"encoding/json"
+
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/branchdistance"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/codecoverage"
+)
+
+// syncPath describes the HTTP path fuzzer instances post SyncRequest objects to.
+const syncPath = "/sync"
+
+// SyncRequest describes a request sent by a fuzzer instance to the coordinator, publishing what it discovered since
+// its last synchronization and asking for what other instances discovered since then.
+type SyncRequest struct {
+	// NodeID uniquely identifies the fuzzer instance, so it is not sent back its own discoveries.
+	NodeID string `json:"nodeId"`
+
+	// Cursor describes the amount of updates the fuzzer instance already received from the coordinator.
+	Cursor uint64 `json:"cursor"`
+
+	// Update describes what the fuzzer instance discovered since its last synchronization.
+	Update Update `json:"update"`
+}
+
+// SyncResponse describes the coordinator's response to a SyncRequest.
+type SyncResponse struct {
+	// Cursor describes the amount of updates the fuzzer instance received from the coordinator after this response,
+	// to be sent with its next SyncRequest.
+	Cursor uint64 `json:"cursor"`
+
+	// Updates describes what other fuzzer instances discovered since the cursor of the SyncRequest. Merging them in
+	// order, or in any other, produces the same fitness metrics.
+	Updates []Update `json:"updates"`
+}
+
+// Update describes corpus call sequences and fitness metric deltas discovered by a fuzzer instance. Fitness metrics
+// are merged with their Update method, which only ever keeps the best value recorded for each item, so merging the
+// same delta twice, or deltas in different orders, is conflict-free.
+type Update struct {
+	// NodeID identifies the fuzzer instance the update was discovered by.
+	NodeID string `json:"nodeId"`
+
+	// CallSequences describes the serialized corpus call sequences discovered.
+	CallSequences []json.RawMessage `json:"callSequences,omitempty"`
+
+	// CodeCoverageMaps describes the code coverage discovered, or nil if there is none.
+	CodeCoverageMaps *codecoverage.CoverageMaps `json:"codeCoverageMaps,omitempty"`
+
+	// BranchDistanceMaps describes the branch distances discovered, or nil if there are none.
+	BranchDistanceMaps *branchdistance.BranchDistanceMaps `json:"branchDistanceMaps,omitempty"`
+}
+
+// IsEmpty indicates whether the update holds no call sequences or fitness metric deltas.
+func (u *Update) IsEmpty() bool {
+	return len(u.CallSequences) == 0 &&
+		(u.CodeCoverageMaps == nil || u.CodeCoverageMaps.IsEmpty()) &&
+		(u.BranchDistanceMaps == nil || u.BranchDistanceMaps.IsEmpty())
+}
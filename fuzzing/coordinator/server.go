@@ -0,0 +1,192 @@
+package coordinator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/crypto"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/branchdistance"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/codecoverage"
+)
+
+// maxRequestSize describes the maximum size in bytes of a SyncRequest accepted by the Server.
+const maxRequestSize = 256 << 20
+
+// Server coordinates fuzzer instances running across processes or machines. It records the updates each instance
+// publishes, keeping only the call sequences and fitness metric deltas no instance published before, and serves every
+// instance the updates published by the others since its last synchronization.
+type Server struct {
+	// codeCoverageMaps describes the code coverage published by all fuzzer instances.
+	codeCoverageMaps *codecoverage.CoverageMaps
+
+	// branchDistanceMaps describes the branch distances published by all fuzzer instances.
+	branchDistanceMaps *branchdistance.BranchDistanceMaps
+
+	// callSequenceHashes describes the hashes of the call sequences published by all fuzzer instances, so each call
+	// sequence is only distributed once.
+	callSequenceHashes map[common.Hash]struct{}
+
+	// updates describes the updates published by fuzzer instances, in the order they were received. A fuzzer
+	// instance's cursor indexes into it.
+	updates []Update
+
+	// lock provides thread-synchronization between concurrent requests.
+	lock sync.Mutex
+
+	// httpServer describes the HTTP server which serves requests.
+	httpServer *http.Server
+
+	// listener describes the network listener used by httpServer.
+	listener net.Listener
+}
+
+// NewServer creates a new Server which has not recorded any updates. The Server must be started with Start.
+func NewServer() *Server {
+	return &Server{
+		codeCoverageMaps:   codecoverage.NewCoverageMaps(),
+		branchDistanceMaps: branchdistance.NewBranchDistanceMaps(),
+		callSequenceHashes: make(map[common.Hash]struct{}),
+		updates:            make([]Update, 0),
+	}
+}
+
+// Start begins listening for requests over HTTP on the provided address (e.g. "127.0.0.1:9545"). Requests are served
+// on a separate goroutine until Close is called.
+// Returns an error if the listener could not be created.
+func (s *Server) Start(address string) error {
+	if s.httpServer != nil {
+		return errors.New("the coordinator was already started")
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(syncPath, s.handleSync)
+	s.listener = listener
+	s.httpServer = &http.Server{Handler: mux}
+
+	go func() {
+		_ = s.httpServer.Serve(listener)
+	}()
+	return nil
+}
+
+// Address returns the network address the Server is listening on, or an empty string if it was not started.
+func (s *Server) Address() string {
+	if s.listener == nil {
+		return ""
+	}
+	return s.listener.Addr().String()
+}
+
+// Close stops the Server from serving any further requests.
+// Returns an error if one occurred while shutting down the HTTP server.
+func (s *Server) Close() error {
+	if s.httpServer == nil {
+		return nil
+	}
+	err := s.httpServer.Close()
+	s.httpServer = nil
+	s.listener = nil
+	return err
+}
+
+// UpdateCount returns the amount of updates recorded by the Server.
+func (s *Server) UpdateCount() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.updates)
+}
+
+// handleSync serves a SyncRequest posted by a fuzzer instance.
+func (s *Server) handleSync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "expected a POST request", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request SyncRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(&request); err != nil {
+		http.Error(w, "invalid sync request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.Sync(&request)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Sync records the update published by a fuzzer instance, then collects the updates published by other instances
+// since the cursor it provided. If the cursor is ahead of the recorded updates, e.g. because the coordinator was
+// restarted, every recorded update is returned.
+// Returns the response to send to the fuzzer instance.
+func (s *Server) Sync(request *SyncRequest) *SyncResponse {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Record the part of the update no instance published before.
+	if update := s.novelUpdate(request.NodeID, &request.Update); !update.IsEmpty() {
+		s.updates = append(s.updates, update)
+	}
+
+	// Collect the updates published by other instances.
+	cursor := request.Cursor
+	if cursor > uint64(len(s.updates)) {
+		cursor = 0
+	}
+	response := &SyncResponse{
+		Cursor:  uint64(len(s.updates)),
+		Updates: make([]Update, 0),
+	}
+	for _, update := range s.updates[cursor:] {
+		if update.NodeID != request.NodeID {
+			response.Updates = append(response.Updates, update)
+		}
+	}
+	return response
+}
+
+// novelUpdate returns the part of an update published by the provided fuzzer instance which no instance published
+// before, and merges it into the state of the Server. The returned update does not reference the one provided.
+func (s *Server) novelUpdate(nodeID string, update *Update) Update {
+	novel := Update{NodeID: nodeID}
+
+	// Keep the call sequences which were not published before. Call sequences are compacted before being hashed, so
+	// differences in formatting do not matter.
+	for _, callSequence := range update.CallSequences {
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, callSequence); err != nil {
+			continue
+		}
+		hash := crypto.Keccak256Hash(compacted.Bytes())
+		if _, exists := s.callSequenceHashes[hash]; exists {
+			continue
+		}
+		s.callSequenceHashes[hash] = struct{}{}
+		novel.CallSequences = append(novel.CallSequences, compacted.Bytes())
+	}
+
+	// Keep the fitness metrics which improve upon those published before. Deltas are copies, so the maps of the
+	// Server and the update recorded do not share any data.
+	if update.CodeCoverageMaps != nil {
+		if delta := update.CodeCoverageMaps.Delta(s.codeCoverageMaps); !delta.IsEmpty() {
+			novel.CodeCoverageMaps = delta
+			_, _ = s.codeCoverageMaps.Update(delta.Delta(nil))
+		}
+	}
+	if update.BranchDistanceMaps != nil {
+		if delta := update.BranchDistanceMaps.Delta(s.branchDistanceMaps); !delta.IsEmpty() {
+			novel.BranchDistanceMaps = delta
+			_, _ = s.branchDistanceMaps.Update(delta)
+		}
+	}
+	return novel
+}
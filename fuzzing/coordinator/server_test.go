@@ -0,0 +1,73 @@
+package coordinator
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/branchdistance"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/codecoverage"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+// newUpdate returns an update holding the provided call sequence, coverage of the provided program counter, and
+// distance for the first branch of a single contract.
+func newUpdate(t *testing.T, nodeID string, callSequence string, pc uint64, distance uint64) Update {
+	coverageMaps := codecoverage.NewCoverageMaps()
+	_, err := coverageMaps.SetAt(common.Address{1}, common.Hash{1}, 8, 8, pc)
+	assert.NoError(t, err)
+	distanceMaps := branchdistance.NewBranchDistanceMaps()
+	_, err = distanceMaps.SetAt(common.Address{1}, common.Hash{1}, 4, 0, uint256.NewInt(distance))
+	assert.NoError(t, err)
+	return Update{
+		NodeID:             nodeID,
+		CallSequences:      []json.RawMessage{json.RawMessage(callSequence)},
+		CodeCoverageMaps:   coverageMaps,
+		BranchDistanceMaps: distanceMaps,
+	}
+}
+
+// TestServerSync starts a Server and synchronizes two fuzzer instances with it, verifying each receives the novel
+// discoveries of the other only once.
+func TestServerSync(t *testing.T) {
+	server := NewServer()
+	assert.NoError(t, server.Start("127.0.0.1:0"))
+	defer server.Close()
+	client := NewClient(server.Address())
+
+	// The first instance publishes its discoveries and receives nothing.
+	response, err := client.Sync(&SyncRequest{NodeID: "a", Update: newUpdate(t, "a", `[{"a": 1}]`, 1, 10)})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, response.Cursor)
+	assert.Empty(t, response.Updates)
+
+	// The second instance publishes the same call sequence, the same coverage and a closer distance. Only the
+	// distance is novel, and it receives the discoveries of the first instance.
+	response, err = client.Sync(&SyncRequest{NodeID: "b", Update: newUpdate(t, "b", `[{"a":1}]`, 1, 5)})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, response.Cursor)
+	assert.EqualValues(t, 1, len(response.Updates))
+	assert.EqualValues(t, "a", response.Updates[0].NodeID)
+	assert.JSONEq(t, `[{"a":1}]`, string(response.Updates[0].CallSequences[0]))
+	assert.False(t, response.Updates[0].CodeCoverageMaps.IsEmpty())
+
+	// The first instance receives the closer distance, but not its own discoveries.
+	response, err = client.Sync(&SyncRequest{NodeID: "a", Cursor: 1})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, response.Cursor)
+	assert.EqualValues(t, 1, len(response.Updates))
+	update := response.Updates[0]
+	assert.EqualValues(t, "b", update.NodeID)
+	assert.Empty(t, update.CallSequences)
+	assert.Nil(t, update.CodeCoverageMaps)
+	_, reduced, _, err := newUpdate(t, "a", "[]", 1, 10).BranchDistanceMaps.UpdateWithReducedDistance(update.BranchDistanceMaps)
+	assert.NoError(t, err)
+	assert.True(t, reduced)
+
+	// A cursor ahead of the recorded updates, e.g. after a coordinator restart, obtains every update.
+	response, err = client.Sync(&SyncRequest{NodeID: "c", Cursor: 100})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, len(response.Updates))
+	assert.EqualValues(t, 2, server.UpdateCount())
+}
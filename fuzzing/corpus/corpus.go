@@ -15,16 +15,22 @@ import (
 
 	"github.com/crytic/medusa-geth/common"
 	"github.com/crytic/medusa/chain"
+	chainTypes "github.com/crytic/medusa/chain/types"
 	"github.com/crytic/medusa/fuzzing/bugdetector"
 	"github.com/crytic/medusa/fuzzing/calls"
 	"github.com/crytic/medusa/fuzzing/config"
 	"github.com/crytic/medusa/fuzzing/contracts"
 	"github.com/crytic/medusa/fuzzing/coverage"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/balancedelta"
 	"github.com/crytic/medusa/fuzzing/fitnessmetrics/branchcoverage"
 	branchdistance "github.com/crytic/medusa/fuzzing/fitnessmetrics/branchdistance"
 	cmpdistance "github.com/crytic/medusa/fuzzing/fitnessmetrics/cmpdistance"
 	codecoverage "github.com/crytic/medusa/fuzzing/fitnessmetrics/codecoverage"
 	"github.com/crytic/medusa/fuzzing/fitnessmetrics/dataflow"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/edgecoverage"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/pathcoverage"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/selectorcoverage"
 	"github.com/crytic/medusa/fuzzing/fitnessmetrics/storagewrite"
 	"github.com/crytic/medusa/fuzzing/fitnessmetrics/tokenflow"
 	"github.com/crytic/medusa/logging"
@@ -55,10 +61,23 @@ type Corpus struct {
 	// are executed to check for test failures.
 	unexecutedCallSequences []calls.CallSequence
 
+	// remoteCallSequences defines the call sequences received from other fuzzer instances which have not yet been
+	// executed by the fuzzer. Like unexecutedCallSequences, each item is removed as it is selected for execution, and
+	// is used in mutations once it executed successfully.
+	remoteCallSequences []calls.CallSequence
+
 	// mutationTargetSequenceChooser is a provider that allows for weighted random selection of callSequences. If a
 	// call sequence was not found to be compatible with this run, it is not added to the chooser.
 	mutationTargetSequenceChooser *randomutils.WeightedRandomChooser[calls.CallSequence]
 
+	// highValueCallChooser is a provider that allows for weighted random selection of calls which produced new
+	// coverage of a fitness metric, to be transplanted into other call sequences. It is nil if the call transplant
+	// mutation is disabled.
+	highValueCallChooser *randomutils.WeightedRandomChooser[*calls.CallSequenceElement]
+
+	// pruneRandomProvider provides the random order in which call sequences are considered when pruning the corpus.
+	pruneRandomProvider *rand.Rand
+
 	// callSequencesLock provides thread synchronization to prevent concurrent access errors into
 	// callSequences.
 	callSequencesLock sync.Mutex
@@ -78,6 +97,18 @@ type Corpus struct {
 	// branchCoverageMaps describes the total branches known to be achieved across all corpus call sequences
 	branchCoverageMaps *branchcoverage.CoverageMaps
 
+	// edgeCoverageMaps describes the total control flow edges known to be taken across all corpus call sequences
+	edgeCoverageMaps *edgecoverage.CoverageMaps
+
+	// transactionPaths describes the paths known to be taken by transactions across all corpus call sequences
+	transactionPaths *pathcoverage.PathSet
+
+	// sequencePaths describes the paths known to be taken by corpus call sequences as a whole, if enabled
+	sequencePaths *pathcoverage.PathSet
+
+	// selectorSet describes the function selectors known to be executed successfully across all corpus call sequences
+	selectorSet *selectorcoverage.SelectorSet
+
 	// cmpDistanceMaps describes the closest distance to trigger unseen condidions in comparison opeartions
 	cmpDistanceMaps *cmpdistance.CmpDistanceMaps
 
@@ -93,14 +124,43 @@ type Corpus struct {
 	// tokenflowMaps describes the token flow being triggered
 	tokenflowMaps *tokenflow.TokenflowSet
 
+	// balanceDeltaMaps describes the largest net balance deltas of tracked addresses over call sequences
+	balanceDeltaMaps *balancedelta.BalanceDeltaSet
+
+	// powerSchedule describes the adaptive power schedule over the mutation weights of mutationTargetSequenceChooser,
+	// or nil if the adaptive power schedule is disabled
+	powerSchedule *PowerSchedule
+
+	// paretoArchive describes the archive of call sequences not dominated across the multi-objective seed selection
+	// objectives, or nil if the multi-objective seed selection is disabled
+	paretoArchive *ParetoArchive
+
+	// saturationMonitor describes the monitor used to detect fitness metrics which no longer produce new items
+	saturationMonitor *fitnessmetrics.SaturationMonitor
+
 	// for risk bug detector
 	bugMap *bugdetector.BugMap
+
+	// minTargetDistance and maxTargetDistance describe the range of target distances observed for call sequences in
+	// the target-directed mode, used to normalize their mutation weights.
+	minTargetDistance uint64
+	maxTargetDistance uint64
+	// targetDistanceLock provides thread synchronization when accessing the target distance range.
+	targetDistanceLock sync.Mutex
 }
 
 // NewCorpus initializes a new Corpus object, reading artifacts from the provided directory and preparing in-memory
-// state required for fuzzing. If the directory refers to an empty path, artifacts will not be persistently stored.
+// state required for fuzzing. If the directory refers to an empty path, artifacts will not be persistently stored. If
+// no fuzzing configuration is provided, the default one is used.
 func NewCorpus(corpusDirectory string, fuzzingConfig *config.FuzzingConfig) (*Corpus, error) {
 	var err error
+	if fuzzingConfig == nil {
+		defaultProjectConfig, err := config.GetDefaultProjectConfig("")
+		if err != nil {
+			return nil, err
+		}
+		fuzzingConfig = &defaultProjectConfig.Fuzzing
+	}
 	corpus := &Corpus{
 		storageDirectory:        corpusDirectory,
 		coverageMaps:            coverage.NewCoverageMaps(),
@@ -113,14 +173,28 @@ func NewCorpus(corpusDirectory string, fuzzingConfig *config.FuzzingConfig) (*Co
 		fuzzingConfig:      fuzzingConfig,
 		codeCoverageMaps:   codecoverage.NewCoverageMaps(),
 		branchCoverageMaps: branchcoverage.NewCoverageMaps(),
+		edgeCoverageMaps:   edgecoverage.NewCoverageMaps(),
+		transactionPaths:   pathcoverage.NewPathSet(fuzzingConfig.PathCoverage.BloomFilterBits, fuzzingConfig.PathCoverage.BloomFilterHashes),
+		sequencePaths:      pathcoverage.NewPathSet(fuzzingConfig.PathCoverage.BloomFilterBits, fuzzingConfig.PathCoverage.BloomFilterHashes),
+		selectorSet:        selectorcoverage.NewSelectorSet(),
 		cmpDistanceMaps:    cmpdistance.NewCmpDistanceMaps(),
 		branchDistanceMaps: branchdistance.NewBranchDistanceMaps(),
 		dataflowMaps:       dataflow.NewDataflowSet(),
 		storageWriteMaps:   storagewrite.NewStorageWriteSet(),
 		tokenflowMaps:      tokenflow.NewTokenflowSet(),
+		balanceDeltaMaps:   balancedelta.NewBalanceDeltaSet(),
 
 		// for bug detector
 		bugMap: bugdetector.NewBugMap(),
+
+		// for the target-directed mode
+		minTargetDistance: branchdistance.NoTargetDistance,
+		maxTargetDistance: 0,
+	}
+	corpus.storageWriteMaps.SetRewardSlotDiversity(fuzzingConfig.StorageWrite.SlotDiversity)
+	corpus.saturationMonitor = fitnessmetrics.NewSaturationMonitor(fuzzingConfig.FitnessMetricConfig.Saturation, corpus.logger)
+	if fuzzingConfig.MultiObjective.Enabled {
+		corpus.paretoArchive = NewParetoArchive(fuzzingConfig.MultiObjective)
 	}
 
 	// If we have a corpus directory set, parse our call sequences.
@@ -229,14 +303,23 @@ func (c *Corpus) migrateLegacyCorpus() error {
 // Initialize initializes the in-memory corpus state but does not actually replay any of the sequences stored in the corpus.
 // It seeds coverage information from the post-setup chain while enqueueing all persisted sequences for execution. The fuzzer workers
 // will concurrently execute all the sequences stored in the corpus before actually starting the fuzzing campaign.
-func (c *Corpus) Initialize(baseTestChain *chain.TestChain, contractDefinitions contracts.Contracts) error {
+// The random providers of the corpus are derived from the provided one, so its random choices are reproducible.
+func (c *Corpus) Initialize(baseTestChain *chain.TestChain, contractDefinitions contracts.Contracts, randomProvider *rand.Rand) error {
 	// Acquire our call sequences lock during the duration of this method.
 	c.callSequencesLock.Lock()
 	defer c.callSequencesLock.Unlock()
 
 	// Initialize our call sequence structures.
-	c.mutationTargetSequenceChooser = randomutils.NewWeightedRandomChooser[calls.CallSequence]()
+	c.mutationTargetSequenceChooser = randomutils.NewWeightedRandomChooserWithRand[calls.CallSequence](randomutils.ForkRandomProvider(randomProvider), &sync.Mutex{})
+	if c.fuzzingConfig.AdaptivePowerSchedule.Enabled {
+		c.powerSchedule = NewPowerSchedule(c.fuzzingConfig.AdaptivePowerSchedule, c.mutationTargetSequenceChooser)
+	}
+	if c.fuzzingConfig.CallTransplant.Enabled {
+		c.highValueCallChooser = randomutils.NewWeightedRandomChooserWithRand[*calls.CallSequenceElement](randomutils.ForkRandomProvider(randomProvider), &sync.Mutex{})
+	}
+	c.pruneRandomProvider = randomutils.ForkRandomProvider(randomProvider)
 	c.unexecutedCallSequences = make([]calls.CallSequence, 0)
+	c.remoteCallSequences = make([]calls.CallSequence, 0)
 
 	// Create a coverage tracer to track coverage across all blocks.
 	c.coverageMaps = coverage.NewCoverageMaps()
@@ -289,6 +372,7 @@ func (c *Corpus) Initialize(baseTestChain *chain.TestChain, contractDefinitions
 		initialContractsSet[addr] = struct{}{}
 	}
 	coverageTracer.SetInitialContractsSet(&initialContractsSet)
+	coverageTracer.SetAddressAttribution(c.fuzzingConfig.CoverageAddressAttribution)
 
 	// Set our coverage maps to those collected when replaying all blocks when cloning.
 	c.coverageMaps = coverage.NewCoverageMaps()
@@ -335,6 +419,32 @@ func (c *Corpus) CallSequenceEntryCount() (int, int) {
 	return len(c.callSequenceFiles.files), len(c.testResultSequenceFiles.files)
 }
 
+// CallSequenceFiles returns the coverage-increasing call sequences stored in the corpus, keyed by their file name.
+func (c *Corpus) CallSequenceFiles() map[string]calls.CallSequence {
+	c.callSequenceFiles.filesLock.Lock()
+	defer c.callSequenceFiles.filesLock.Unlock()
+
+	sequences := make(map[string]calls.CallSequence, len(c.callSequenceFiles.files))
+	for _, file := range c.callSequenceFiles.files {
+		sequences[file.fileName] = file.data
+	}
+	return sequences
+}
+
+// RemoveCallSequenceFiles removes the coverage-increasing call sequences stored under the provided file names from
+// the corpus, deleting them from the corpus directory. Returns an error if one occurs.
+func (c *Corpus) RemoveCallSequenceFiles(fileNames []string) error {
+	c.callSequencesLock.Lock()
+	defer c.callSequencesLock.Unlock()
+
+	for _, fileName := range fileNames {
+		if _, err := c.callSequenceFiles.deleteFile(fileName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // InitializingCorpus returns true if the corpus is still initializing, false otherwise.
 func (c *Corpus) InitializingCorpus() bool {
 	return len(c.unexecutedCallSequences) > 0
@@ -349,19 +459,100 @@ func (c *Corpus) ActiveMutableSequenceCount() int {
 	return c.mutationTargetSequenceChooser.ChoiceCount()
 }
 
-// RandomMutationTargetSequence returns a weighted random call sequence from the Corpus, or an error if one occurs.
-func (c *Corpus) RandomMutationTargetSequence() (calls.CallSequence, error) {
+// RandomMutationTargetSequence returns a weighted random call sequence from the Corpus, chosen with the provided random
+// provider, or an error if one occurs.
+func (c *Corpus) RandomMutationTargetSequence(randomProvider *rand.Rand) (calls.CallSequence, error) {
+	seq, _, err := c.RandomMutationTarget(randomProvider)
+	return seq, err
+}
+
+// RandomMutationTarget returns a weighted random call sequence from the Corpus, along with the MutationTarget
+// identifying it so it can be rewarded with RewardMutationTargets. The MutationTarget is nil if the call sequence was
+// not chosen from the weighted corpus. The call sequence is chosen with the provided random provider, so each worker
+// makes its choices from its own random provider, regardless of how workers are scheduled.
+// Returns an error if one occurs.
+func (c *Corpus) RandomMutationTarget(randomProvider *rand.Rand) (calls.CallSequence, *MutationTarget, error) {
 	// If we didn't initialize a chooser, return an error
 	if c.mutationTargetSequenceChooser == nil {
-		return nil, fmt.Errorf("corpus could not return a random call sequence because the corpus was not initialized")
+		return nil, nil, fmt.Errorf("corpus could not return a random call sequence because the corpus was not initialized")
+	}
+
+	// In the multi-objective seed selection, the call sequence may be sampled from the Pareto archive instead.
+	if c.paretoArchive != nil {
+		if seq := c.paretoArchive.Choose(randomProvider); seq != nil {
+			seq, err := seq.Clone()
+			return seq, nil, err
+		}
+	}
+
+	// Let boosted mutation weights decay before choosing.
+	if c.powerSchedule != nil {
+		c.powerSchedule.Decay()
 	}
 
 	// Pick a random call sequence, then clone it before returning it, so the original is untainted.
-	seq, err := c.mutationTargetSequenceChooser.Choose()
-	if seq == nil || err != nil {
+	target, err := c.mutationTargetSequenceChooser.ChooseChoiceWithRand(randomProvider)
+	if target == nil || err != nil {
+		return nil, nil, err
+	}
+	seq, err := target.Data.Clone()
+	return seq, target, err
+}
+
+// RandomHighValueCall returns a weighted random call which produced new coverage of a fitness metric, to be
+// transplanted into another call sequence, chosen with the provided random provider. Returns nil if the call transplant
+// mutation is disabled or no such call is known yet, or an error if one occurs.
+func (c *Corpus) RandomHighValueCall(randomProvider *rand.Rand) (*calls.CallSequenceElement, error) {
+	if c.highValueCallChooser == nil || c.highValueCallChooser.ChoiceCount() == 0 {
+		return nil, nil
+	}
+
+	// Pick a random call, then clone it before returning it, so the original is untainted.
+	call, err := c.highValueCallChooser.ChooseWithRand(randomProvider)
+	if call == nil || err != nil {
 		return nil, err
 	}
-	return seq.Clone()
+	return (*call).Clone()
+}
+
+// addHighValueCall records the provided call, which produced new coverage of a fitness metric, to be transplanted into
+// other call sequences with the provided weight. Its execution results are not retained.
+// Returns an error if one occurs.
+func (c *Corpus) addHighValueCall(call *calls.CallSequenceElement, weight uint64) error {
+	clonedCall, err := call.Clone()
+	if err != nil {
+		return err
+	}
+	clonedCall.ChainReference = nil
+	clonedCall.ExecutionTrace = nil
+	c.highValueCallChooser.AddChoices(randomutils.NewWeightedRandomChoice(clonedCall, new(big.Int).SetUint64(weight)))
+	return nil
+}
+
+// RewardMutationTargets rewards the provided corpus call sequences, from which a call sequence added to the corpus
+// was derived, with more mutation energy if the adaptive power schedule is enabled.
+func (c *Corpus) RewardMutationTargets(targets []*MutationTarget) {
+	if c.powerSchedule != nil && len(targets) > 0 {
+		c.powerSchedule.Reward(targets)
+	}
+}
+
+// MutationTargetEnergy returns the factor the adaptive power schedule currently multiplies the mutation weight of the
+// provided corpus call sequence by, which is one if it is disabled.
+func (c *Corpus) MutationTargetEnergy(target *MutationTarget) uint64 {
+	if c.powerSchedule == nil || target == nil {
+		return 1
+	}
+	return c.powerSchedule.Energy(target)
+}
+
+// BoostedMutationTargetCount returns the amount of corpus call sequences whose mutation weight is currently boosted by
+// the adaptive power schedule.
+func (c *Corpus) BoostedMutationTargetCount() int {
+	if c.powerSchedule == nil {
+		return 0
+	}
+	return c.powerSchedule.BoostedCount()
 }
 
 // addCallSequence adds a call sequence to the corpus in a given corpus directory.
@@ -530,6 +721,33 @@ func (c *Corpus) UnexecutedCallSequence() *calls.CallSequence {
 	return &firstSequence
 }
 
+// AddRemoteCallSequences queues call sequences received from other fuzzer instances, or imported from elsewhere (such
+// as historical transactions), to be executed by the fuzzer and used in mutations if they execute successfully. They are not added to the corpus directory unless they achieve
+// a fitness metric the Corpus did not.
+func (c *Corpus) AddRemoteCallSequences(callSequences []calls.CallSequence) {
+	c.callSequencesLock.Lock()
+	defer c.callSequencesLock.Unlock()
+	c.remoteCallSequences = append(c.remoteCallSequences, callSequences...)
+}
+
+// RemoteCallSequence returns a call sequence received from another fuzzer instance which has not yet been returned by
+// this method, or nil if there is none. If a call sequence is returned, it will not be returned by this method again.
+func (c *Corpus) RemoteCallSequence() *calls.CallSequence {
+	// Prior to thread locking, if we have no remote call sequences, quit, as in UnexecutedCallSequence.
+	if len(c.remoteCallSequences) == 0 {
+		return nil
+	}
+
+	c.callSequencesLock.Lock()
+	defer c.callSequencesLock.Unlock()
+	if len(c.remoteCallSequences) == 0 {
+		return nil
+	}
+	remoteSequence := c.remoteCallSequences[0]
+	c.remoteCallSequences = c.remoteCallSequences[1:]
+	return &remoteSequence
+}
+
 // Flush writes corpus changes to disk. Returns an error if one occurs.
 func (c *Corpus) Flush() error {
 	// If our corpus directory is empty, it indicates we do not want to write corpus artifacts to persistent storage.
@@ -592,7 +810,7 @@ func (c *Corpus) PruneSequences(ctx context.Context, chain *chain.TestChain) (in
 	toRemove := map[int]bool{}
 
 	// Iterate seqs in a random order
-	for _, i := range rand.Perm(len(seqs)) {
+	for _, i := range c.pruneRandomProvider.Perm(len(seqs)) {
 		if utils.CheckContextDone(ctx) {
 			return 0, nil
 		}
@@ -630,6 +848,11 @@ func (c *Corpus) PruneSequences(ctx context.Context, chain *chain.TestChain) (in
 		}
 	}
 
+	if c.powerSchedule != nil {
+		for i := range toRemove {
+			c.powerSchedule.Forget(c.mutationTargetSequenceChooser.Choices[i])
+		}
+	}
 	c.mutationTargetSequenceChooser.RemoveChoices(toRemove)
 	return len(toRemove), nil
 }
@@ -637,11 +860,11 @@ func (c *Corpus) PruneSequences(ctx context.Context, chain *chain.TestChain) (in
 // CheckSequenceMetricAndUpdate checks if the most recent call executed in the provided call sequence achieved
 // any better metric the Corpus did not with any of its call sequences. If it did, the call sequence is added
 // to the corpus and the Corpus global metric are updated accordingly.
-// Returns an error if one occurs.
-func (c *Corpus) CheckSequenceMetricAndUpdate(callSequence calls.CallSequence, mutationChooserWeight *big.Int, flushImmediately bool) error {
+// Returns a boolean indicating whether the call sequence was added to the corpus, or an error if one occurs.
+func (c *Corpus) CheckSequenceMetricAndUpdate(callSequence calls.CallSequence, mutationChooserWeight *big.Int, flushImmediately bool) (bool, error) {
 	// If we have coverage-guided fuzzing disabled or no calls in our sequence, there is nothing to do.
 	if len(callSequence) == 0 {
-		return nil
+		return false, nil
 	}
 
 	// Obtain our coverage maps for our last call.
@@ -650,13 +873,19 @@ func (c *Corpus) CheckSequenceMetricAndUpdate(callSequence calls.CallSequence, m
 	lastMessageResult := lastCallChainReference.Block.MessageResults[lastCallChainReference.TransactionIndex]
 
 	updated := false
+	revertedDistanceUpdated := false
+	distanceReduced := false
+	revertedFlowsUpdated := false
+	tokenflowUpdated := false
+	targetDistance := branchdistance.NoTargetDistance
 
 	if c.fuzzingConfig.FitnessMetricConfig.CodeCoverageEnabled {
 		codeCoverageMaps := codecoverage.GetCoverageTracerResults(lastMessageResult)
 		coverageUpdated, err := c.codeCoverageMaps.Update(codeCoverageMaps)
 		if err != nil {
-			return err
+			return false, err
 		}
+		c.saturationMonitor.Record(fitnessmetrics.CodeCoverageMetric, codeCoverageMaps != nil, coverageUpdated)
 		updated = coverageUpdated || updated
 	}
 
@@ -665,17 +894,68 @@ func (c *Corpus) CheckSequenceMetricAndUpdate(callSequence calls.CallSequence, m
 		coverageMaps := branchcoverage.GetCoverageTracerResults(lastMessageResult)
 		coverageUpdated, err := c.branchCoverageMaps.Update(coverageMaps)
 		if err != nil {
-			return err
+			return false, err
+		}
+		c.saturationMonitor.Record(fitnessmetrics.BranchCoverageMetric, coverageMaps != nil, coverageUpdated)
+		updated = coverageUpdated || updated
+	}
+
+	if c.fuzzingConfig.FitnessMetricConfig.EdgeCoverageEnabled {
+		edgeCoverageMaps := edgecoverage.GetCoverageTracerResults(lastMessageResult)
+		coverageUpdated, err := c.edgeCoverageMaps.Update(edgeCoverageMaps)
+		if err != nil {
+			return false, err
 		}
+		c.saturationMonitor.Record(fitnessmetrics.EdgeCoverageMetric, edgeCoverageMaps != nil, coverageUpdated)
 		updated = coverageUpdated || updated
 	}
 
+	if c.fuzzingConfig.FitnessMetricConfig.PathCoverageEnabled {
+		// Reverted transactions are not rewarded, as their branch decisions did not take effect.
+		transactionPath := pathcoverage.GetPathCoverageTracerResults(lastMessageResult)
+		pathUpdated := false
+		if transactionPath != nil && !transactionPath.Reverted {
+			pathUpdated = c.transactionPaths.Add(transactionPath.Hash)
+		}
+
+		// Optionally reward novel orderings of transaction paths across the whole call sequence.
+		if c.fuzzingConfig.PathCoverage.PerSequence {
+			messageResults := make([]*chainTypes.MessageResults, 0, len(callSequence))
+			for _, element := range callSequence {
+				if element.ChainReference != nil {
+					messageResults = append(messageResults, element.ChainReference.MessageResults())
+				}
+			}
+			if sequencePathHash, recorded := pathcoverage.GetSequencePathHash(messageResults); recorded {
+				pathUpdated = c.sequencePaths.Add(sequencePathHash) || pathUpdated
+			}
+		}
+		c.saturationMonitor.Record(fitnessmetrics.PathCoverageMetric, transactionPath != nil, pathUpdated)
+		updated = pathUpdated || updated
+	}
+
+	if c.fuzzingConfig.FitnessMetricConfig.SelectorCoverageEnabled {
+		selectorSet := selectorcoverage.GetSelectorCoverageTracerResults(lastMessageResult)
+		selectorsUpdated, err := c.selectorSet.Update(selectorSet)
+		if err != nil {
+			return false, err
+		}
+		c.saturationMonitor.Record(fitnessmetrics.SelectorCoverageMetric, selectorSet != nil, selectorsUpdated)
+		updated = selectorsUpdated || updated
+	}
+
 	if c.fuzzingConfig.FitnessMetricConfig.BranchDistanceEnabled {
 		branchdistanceMaps := branchdistance.GetBranchDistanceTracerResults(lastMessageResult)
-		branchDistanceUpdated, err := c.branchDistanceMaps.Update(branchdistanceMaps)
+		branchDistanceUpdated, branchDistanceReduced, revertedUpdated, err := c.branchDistanceMaps.UpdateWithReducedDistance(branchdistanceMaps)
 		if err != nil {
-			return err
+			return false, err
 		}
+		distanceReduced = branchDistanceReduced
+		revertedDistanceUpdated = revertedUpdated && c.fuzzingConfig.BranchDistance.UseRevertedDistance
+		if branchdistanceMaps != nil {
+			targetDistance = branchdistanceMaps.TargetDistance()
+		}
+		c.saturationMonitor.Record(fitnessmetrics.BranchDistanceMetric, branchdistanceMaps != nil, branchDistanceUpdated)
 		updated = branchDistanceUpdated || updated
 	}
 
@@ -683,61 +963,174 @@ func (c *Corpus) CheckSequenceMetricAndUpdate(callSequence calls.CallSequence, m
 		cmpDistanceMaps := cmpdistance.GetCmpDistanceTracerResults(lastMessageResult)
 		cmpDistanceUpdated, err := c.cmpDistanceMaps.Update(cmpDistanceMaps)
 		if err != nil {
-			return err
+			return false, err
 		}
+		c.saturationMonitor.Record(fitnessmetrics.CmpDistanceMetric, cmpDistanceMaps != nil, cmpDistanceUpdated)
 		updated = cmpDistanceUpdated || updated
 	}
 
 	if c.fuzzingConfig.FitnessMetricConfig.DataflowEnabled {
 		dataflowMaps := dataflow.GetDataflowTracerResults(lastMessageResult)
-		dataflowUpdated, err := c.dataflowMaps.Update(dataflowMaps)
+		dataflowUpdated, dataflowRevertedUpdated, err := c.dataflowMaps.UpdateWithReverted(dataflowMaps)
 		if err != nil {
-			return err
+			return false, err
 		}
+		c.saturationMonitor.Record(fitnessmetrics.DataflowMetric, dataflowMaps != nil, dataflowUpdated)
 		updated = dataflowUpdated || updated
+		revertedFlowsUpdated = dataflowRevertedUpdated || revertedFlowsUpdated
 	}
 
 	if c.fuzzingConfig.FitnessMetricConfig.StorageWriteEnabled {
 		storageWriteMaps := storagewrite.GetStorageWriteTracerResults(lastMessageResult)
-		storageWriteUpdated, err := c.storageWriteMaps.Update(storageWriteMaps)
+		storageWriteUpdated, storageWriteRevertedUpdated, err := c.storageWriteMaps.UpdateWithReverted(storageWriteMaps)
 		if err != nil {
-			return err
+			return false, err
 		}
+		c.saturationMonitor.Record(fitnessmetrics.StorageWriteMetric, storageWriteMaps != nil, storageWriteUpdated)
 		updated = storageWriteUpdated || updated
+		revertedFlowsUpdated = storageWriteRevertedUpdated || revertedFlowsUpdated
 	}
 
 	if c.fuzzingConfig.FitnessMetricConfig.TokenflowEnabled {
 		tokenflowMaps := tokenflow.GetTokenflowTracerResults(lastMessageResult)
-		tokenflowUpdated, err := c.tokenflowMaps.Update(tokenflowMaps)
+		var tokenflowRevertedUpdated bool
+		var err error
+		tokenflowUpdated, tokenflowRevertedUpdated, err = c.tokenflowMaps.UpdateWithReverted(tokenflowMaps)
 		if err != nil {
-			return err
+			return false, err
 		}
+		c.saturationMonitor.Record(fitnessmetrics.TokenflowMetric, tokenflowMaps != nil, tokenflowUpdated)
 		updated = tokenflowUpdated || updated
+		revertedFlowsUpdated = tokenflowRevertedUpdated || revertedFlowsUpdated
 	}
 
+	if c.fuzzingConfig.FitnessMetricConfig.BalanceDeltaEnabled {
+		balanceDeltaMaps := balancedelta.GetBalanceDeltaTracerResults(lastMessageResult)
+		balanceDeltaUpdated, err := c.balanceDeltaMaps.Update(balanceDeltaMaps)
+		if err != nil {
+			return false, err
+		}
+		c.saturationMonitor.Record(fitnessmetrics.BalanceDeltaMetric, balanceDeltaMaps != nil, balanceDeltaUpdated)
+		updated = balanceDeltaUpdated || updated
+	}
+
+	// Reverted dataflow, storage writes and tokenflow are a secondary signal, like reverted branch distances.
+	revertedDistanceUpdated = revertedDistanceUpdated || (revertedFlowsUpdated && c.fuzzingConfig.FitnessMetricConfig.UseRevertedFlows)
+
 	if c.fuzzingConfig.UseBugDetector() {
 		bugMap := bugdetector.GetBugDetectorTracerResults(lastMessageResult)
 		_, err := c.bugMap.Update(bugMap)
 		if err != nil {
-			return err
+			return false, err
 		}
 	}
 
 	// If we had an increase in non-reverted or reverted coverage, we save the sequence.
 	// Note: We only want to save the sequence once. We're most interested if it can be used for mutations first.
 	if updated {
-		// If we achieved new coverage, save this sequence for mutation purposes.
-		err := c.addCallSequence(c.callSequenceFiles, callSequence, true, mutationChooserWeight, flushImmediately)
+		// If we achieved new coverage, save this sequence for mutation purposes. Sequences which got closer to flipping
+		// an already reached branch are favoured, as are those closer to the targets in the target-directed mode.
+		mutationChooserWeight = c.reducedDistanceMutationWeight(mutationChooserWeight, distanceReduced)
+		err := c.addCallSequence(c.callSequenceFiles, callSequence, true, c.directedMutationWeight(mutationChooserWeight, targetDistance), flushImmediately)
 		if err != nil {
-			return err
+			return false, err
 		}
+
+		// Keep the call which produced the new coverage to transplant it into other call sequences, favouring those
+		// which produced a new tokenflow.
+		if c.highValueCallChooser != nil {
+			weight := uint64(1)
+			if tokenflowUpdated {
+				weight = c.fuzzingConfig.CallTransplant.TokenflowWeight
+			}
+			err = c.addHighValueCall(lastCall, weight)
+			if err != nil {
+				return false, err
+			}
+		}
+	} else if revertedDistanceUpdated {
+		// If we only got closer to flipping branches (or only produced new flows) in reverted call frames, save this
+		// sequence with a lower weight.
+		err := c.addCallSequence(c.callSequenceFiles, callSequence, true, c.revertedDistanceMutationWeight(mutationChooserWeight), flushImmediately)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	// Offer call sequences added to the corpus to the Pareto archive of the multi-objective seed selection.
+	if c.paretoArchive != nil && (updated || revertedDistanceUpdated) {
+		c.paretoArchive.Add(callSequence, sequenceObjectives(c.paretoArchive.Objectives(), lastMessageResult, c.fuzzingConfig))
 	}
 
 	// debug: print execution trace
 	// hash := utils.MessageToTransaction(latestCallSequenceElement.Call.ToCoreMessage()).Hash()
 	// fmt.Println(hash, fw.executionTracer.GetTrace(hash))
 
-	return nil
+	return updated || revertedDistanceUpdated, nil
+}
+
+// revertedDistanceMutationWeight scales down the provided mutation weight of a call sequence which was added to the
+// corpus solely as it got closer to flipping branches (or produced new flows) in reverted call frames, by the
+// configured divisor.
+// Returns the scaled mutation weight, which is at least one.
+func (c *Corpus) revertedDistanceMutationWeight(mutationChooserWeight *big.Int) *big.Int {
+	if mutationChooserWeight == nil {
+		return big.NewInt(1)
+	}
+	weight := new(big.Int).Div(mutationChooserWeight, new(big.Int).SetUint64(c.fuzzingConfig.BranchDistance.RevertedDistanceWeightDivisor))
+	if weight.Sign() <= 0 {
+		weight.SetInt64(1)
+	}
+	return weight
+}
+
+// reducedDistanceMutationWeight scales up the provided mutation weight of a call sequence which reduced the distance of
+// a branch already reached by the corpus, by the configured multiplier.
+// Returns the scaled mutation weight.
+func (c *Corpus) reducedDistanceMutationWeight(mutationChooserWeight *big.Int, distanceReduced bool) *big.Int {
+	multiplier := c.fuzzingConfig.BranchDistance.ReducedDistanceWeightMultiplier
+	if !distanceReduced || multiplier <= 1 {
+		return mutationChooserWeight
+	}
+	if mutationChooserWeight == nil {
+		mutationChooserWeight = big.NewInt(1)
+	}
+	return new(big.Int).Mul(mutationChooserWeight, new(big.Int).SetUint64(multiplier))
+}
+
+// directedMutationWeight scales the provided mutation weight of a call sequence by how close it got to the targets of
+// the target-directed mode, relative to the range of target distances observed so far. The closest call sequences
+// receive the configured maximum multiplier, the furthest ones keep their weight.
+// Returns the scaled mutation weight.
+func (c *Corpus) directedMutationWeight(mutationChooserWeight *big.Int, targetDistance uint64) *big.Int {
+	if !c.fuzzingConfig.TargetDirected.Enabled || targetDistance == branchdistance.NoTargetDistance {
+		return mutationChooserWeight
+	}
+	if mutationChooserWeight == nil {
+		mutationChooserWeight = big.NewInt(1)
+	}
+
+	// Update the range of target distances observed.
+	c.targetDistanceLock.Lock()
+	c.minTargetDistance = min(c.minTargetDistance, targetDistance)
+	c.maxTargetDistance = max(c.maxTargetDistance, targetDistance)
+	minTargetDistance, maxTargetDistance := c.minTargetDistance, c.maxTargetDistance
+	c.targetDistanceLock.Unlock()
+
+	// Scale the multiplier linearly between the closest and furthest distances.
+	maxMultiplier := c.fuzzingConfig.TargetDirected.MaxWeightMultiplier
+	multiplier := maxMultiplier
+	if maxTargetDistance > minTargetDistance {
+		closeness := float64(maxTargetDistance-targetDistance) / float64(maxTargetDistance-minTargetDistance)
+		multiplier = 1 + uint64(closeness*float64(maxMultiplier-1))
+	}
+	return new(big.Int).Mul(mutationChooserWeight, new(big.Int).SetUint64(multiplier))
+}
+
+// SaturationMonitor exposes the monitor used to detect saturated fitness metrics, which determines whether their
+// tracers should be attached.
+func (c *Corpus) SaturationMonitor() *fitnessmetrics.SaturationMonitor {
+	return c.saturationMonitor
 }
 
 // CoverageMaps exposes coverage details for all call sequences known to the corpus.
@@ -749,6 +1142,22 @@ func (c *Corpus) BranchCoverageMaps() *branchcoverage.CoverageMaps {
 	return c.branchCoverageMaps
 }
 
+func (c *Corpus) EdgeCoverageMaps() *edgecoverage.CoverageMaps {
+	return c.edgeCoverageMaps
+}
+
+func (c *Corpus) TransactionPaths() *pathcoverage.PathSet {
+	return c.transactionPaths
+}
+
+func (c *Corpus) SequencePaths() *pathcoverage.PathSet {
+	return c.sequencePaths
+}
+
+func (c *Corpus) SelectorSet() *selectorcoverage.SelectorSet {
+	return c.selectorSet
+}
+
 func (c *Corpus) DataflowSet() *dataflow.DataflowSet {
 	return c.dataflowMaps
 }
@@ -761,6 +1170,10 @@ func (c *Corpus) TokenflowMaps() *tokenflow.TokenflowSet {
 	return c.tokenflowMaps
 }
 
+func (c *Corpus) BalanceDeltaMaps() *balancedelta.BalanceDeltaSet {
+	return c.balanceDeltaMaps
+}
+
 func (c *Corpus) BranchDistanceMaps() *branchdistance.BranchDistanceMaps {
 	return c.branchDistanceMaps
 }
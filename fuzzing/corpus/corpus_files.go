@@ -93,6 +93,32 @@ func (cd *corpusDirectory[T]) removeFile(fileName string) bool {
 	return false
 }
 
+// deleteFile removes a given file from the file list and, if it was written to the directory, deletes it from disk.
+// Returns a boolean indicating if a corpusFile with the provided file name was found and removed, or an error if one
+// occurred.
+func (cd *corpusDirectory[T]) deleteFile(fileName string) (bool, error) {
+	// Lock to avoid concurrency issues when accessing the files list
+	cd.filesLock.Lock()
+	defer cd.filesLock.Unlock()
+
+	// If we find the filename, remove it from our list of files and delete it from disk.
+	lowerFileName := strings.ToLower(fileName)
+	for i := 0; i < len(cd.files); i++ {
+		if lowerFileName == strings.ToLower(cd.files[i].fileName) {
+			file := cd.files[i]
+			cd.files = append(cd.files[:i], cd.files[i+1:]...)
+			if cd.path != "" && file.writtenToDisk {
+				err := os.Remove(filepath.Join(cd.path, file.fileName))
+				if err != nil && !os.IsNotExist(err) {
+					return true, fmt.Errorf("an error occurred while deleting corpus data file: %v", err)
+				}
+			}
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // readFiles takes a provided glob pattern representing files to parse within the corpusDirectory.path.
 // It parses any matching file into a corpusFile and adds it to the corpusDirectory.
 // Returns an error, if one occurred.
@@ -0,0 +1,229 @@
+package corpus
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/common/hexutil"
+	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/utils"
+)
+
+// InteropFormat describes a call sequence format used by another fuzzing tool, which corpus call sequences can be
+// imported from or exported to.
+type InteropFormat string
+
+const (
+	// InteropFormatEchidna describes Echidna's reproducer and coverage corpus format, a JSON list of transactions.
+	InteropFormatEchidna InteropFormat = "echidna"
+	// InteropFormatFoundry describes the call sequences Foundry persists for failed invariant tests.
+	InteropFormatFoundry InteropFormat = "foundry"
+)
+
+// ParseInteropFormat parses the provided string into an InteropFormat, or returns an error if it is not supported.
+func ParseInteropFormat(format string) (InteropFormat, error) {
+	switch InteropFormat(strings.ToLower(format)) {
+	case InteropFormatEchidna:
+		return InteropFormatEchidna, nil
+	case InteropFormatFoundry:
+		return InteropFormatFoundry, nil
+	default:
+		return "", fmt.Errorf("unsupported corpus format '%v', expected '%v' or '%v'", format, InteropFormatEchidna, InteropFormatFoundry)
+	}
+}
+
+// decodeInteropCallSequence decodes a call sequence in the provided format. Calls which do not specify a gas limit
+// use the provided one.
+// Returns the decoded call sequence, or an error if one occurs.
+func decodeInteropCallSequence(format InteropFormat, data []byte, gasLimit uint64) (calls.CallSequence, error) {
+	switch format {
+	case InteropFormatEchidna:
+		return decodeEchidnaCallSequence(data)
+	case InteropFormatFoundry:
+		return decodeFoundryCallSequence(data, gasLimit)
+	default:
+		return nil, fmt.Errorf("unsupported corpus format '%v'", format)
+	}
+}
+
+// encodeInteropCallSequence encodes a call sequence in the provided format.
+// Returns the encoded call sequence, or an error if one occurs.
+func encodeInteropCallSequence(format InteropFormat, callSequence calls.CallSequence) ([]byte, error) {
+	switch format {
+	case InteropFormatEchidna:
+		return encodeEchidnaCallSequence(callSequence)
+	case InteropFormatFoundry:
+		return encodeFoundryCallSequence(callSequence)
+	default:
+		return nil, fmt.Errorf("unsupported corpus format '%v'", format)
+	}
+}
+
+// interopExportPath returns the path a corpus call sequence file is exported to in the provided format, following the
+// layout of the corpus directory of the other tool where it has one.
+func interopExportPath(format InteropFormat, outputDirectory string, fileName string, testResult bool) string {
+	baseName := utils.GetFileNameWithoutExtension(fileName)
+	if format == InteropFormatEchidna {
+		if testResult {
+			return filepath.Join(outputDirectory, "reproducers", baseName+".txt")
+		}
+		return filepath.Join(outputDirectory, "coverage", baseName+".txt")
+	}
+	if testResult {
+		return filepath.Join(outputDirectory, "test_results", baseName+".json")
+	}
+	return filepath.Join(outputDirectory, "call_sequences", baseName+".json")
+}
+
+// newInteropCallMessage creates a call message for a call imported from another tool. As the other tool ran against
+// a different chain, nonces are not checked, and gas pricing matches the defaults used for generated calls.
+func newInteropCallMessage(from common.Address, to *common.Address, value *big.Int, gasLimit uint64, data []byte) *calls.CallMessage {
+	msg := calls.NewCallMessage(from, to, 0, value, gasLimit, big.NewInt(1), big.NewInt(0), big.NewInt(0), data)
+	msg.SkipNonceChecks = true
+	return msg
+}
+
+// parseInteropWord parses a JSON encoded 256-bit word, which other tools encode either as a number or as a decimal
+// or 0x-prefixed hexadecimal string.
+// Returns the parsed word, or an error if one occurs.
+func parseInteropWord(data json.RawMessage) (*big.Int, error) {
+	// A missing word is treated as zero.
+	text := strings.TrimSpace(string(data))
+	if text == "" || text == "null" {
+		return big.NewInt(0), nil
+	}
+
+	// Unquote the word if it is a string.
+	if strings.HasPrefix(text, "\"") {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return nil, err
+		}
+	}
+
+	value, ok := new(big.Int).SetString(text, 0)
+	if !ok || value.Sign() < 0 || value.BitLen() > 256 {
+		return nil, fmt.Errorf("invalid 256-bit word '%v'", text)
+	}
+	return value, nil
+}
+
+// encodeInteropWord encodes a 256-bit word as a JSON string holding its 0x-prefixed hexadecimal representation.
+func encodeInteropWord(value *big.Int) json.RawMessage {
+	return json.RawMessage(strconv.Quote(hexutil.EncodeBig(value)))
+}
+
+// parseInteropDelay parses a JSON encoded 256-bit word describing a block number or timestamp delay.
+// Returns the parsed delay, or an error if one occurs.
+func parseInteropDelay(data json.RawMessage) (uint64, error) {
+	value, err := parseInteropWord(data)
+	if err != nil {
+		return 0, err
+	}
+	if !value.IsUint64() {
+		return 0, fmt.Errorf("delay '%v' exceeds the maximum supported delay", value.String())
+	}
+	return value.Uint64(), nil
+}
+
+// ImportCallSequences reads the call sequences stored in the provided format at the provided path, which is either a
+// single file or a directory which is searched recursively, and adds them to the corpus as coverage-increasing call
+// sequences, so they are replayed and mutated when fuzzing. Other tools deploy contracts and send calls from different
+// addresses, so senders and targets are translated using the provided address map.
+// Returns the amount of call sequences added to the corpus, or an error if one occurs.
+func (c *Corpus) ImportCallSequences(format InteropFormat, path string, addressMap map[common.Address]common.Address) (int, error) {
+	// Collect the files to import.
+	filePaths := make([]string, 0)
+	err := filepath.WalkDir(path, func(filePath string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			filePaths = append(filePaths, filePath)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Decode every file into a call sequence and add it to the corpus.
+	existingSequenceCount := len(c.callSequenceFiles.files)
+	for _, filePath := range filePaths {
+		b, err := os.ReadFile(filePath)
+		if err != nil {
+			return 0, err
+		}
+		callSequence, err := decodeInteropCallSequence(format, b, c.fuzzingConfig.TransactionGasLimit)
+		if err != nil {
+			return 0, fmt.Errorf("failed to import call sequence from '%v': %v", filePath, err)
+		}
+		if len(callSequence) == 0 {
+			continue
+		}
+
+		// Translate the addresses used by the other tool.
+		for _, element := range callSequence {
+			if from, ok := addressMap[element.Call.From]; ok {
+				element.Call.From = from
+			}
+			if element.Call.To != nil {
+				if to, ok := addressMap[*element.Call.To]; ok {
+					element.Call.To = &to
+				}
+			}
+		}
+
+		err = c.addCallSequence(c.callSequenceFiles, callSequence, false, nil, false)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// Write the imported call sequences to disk.
+	err = c.Flush()
+	if err != nil {
+		return 0, err
+	}
+	return len(c.callSequenceFiles.files) - existingSequenceCount, nil
+}
+
+// ExportCallSequences writes every call sequence stored in the corpus, including test results, to the provided output
+// directory in the provided format, so they can be replayed or used as seeds by other tools. Call sequences which
+// cannot be represented in the format are skipped.
+// Returns the amount of call sequences exported, or an error if one occurs.
+func (c *Corpus) ExportCallSequences(format InteropFormat, outputDirectory string) (int, error) {
+	c.callSequencesLock.Lock()
+	defer c.callSequencesLock.Unlock()
+
+	exported := 0
+	for _, sequenceFiles := range []*corpusDirectory[calls.CallSequence]{c.callSequenceFiles, c.testResultSequenceFiles} {
+		testResult := sequenceFiles == c.testResultSequenceFiles
+		for _, file := range sequenceFiles.files {
+			// Encode the call sequence, skipping it if it cannot be represented in the format.
+			b, err := encodeInteropCallSequence(format, file.data)
+			if err != nil {
+				c.logger.Warn(fmt.Sprintf("Skipping call sequence '%v' which cannot be exported", file.fileName), err)
+				continue
+			}
+
+			// Write it to its path, creating its directory if needed.
+			filePath := interopExportPath(format, outputDirectory, file.fileName, testResult)
+			err = utils.MakeDirectory(filepath.Dir(filePath))
+			if err != nil {
+				return exported, err
+			}
+			err = os.WriteFile(filePath, b, os.ModePerm)
+			if err != nil {
+				return exported, err
+			}
+			exported++
+		}
+	}
+	return exported, nil
+}
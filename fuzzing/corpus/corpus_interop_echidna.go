@@ -0,0 +1,595 @@
+package corpus
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/crypto"
+	"github.com/crytic/medusa/fuzzing/calls"
+)
+
+// echidnaTx describes a transaction in an Echidna reproducer or coverage corpus file.
+type echidnaTx struct {
+	Call     echidnaTagged      `json:"call"`
+	Src      string             `json:"src"`
+	Dst      string             `json:"dst"`
+	Gas      uint64             `json:"gas"`
+	GasPrice json.RawMessage    `json:"gasprice"`
+	Value    json.RawMessage    `json:"value"`
+	Delay    [2]json.RawMessage `json:"delay"`
+}
+
+// echidnaTagged describes a Haskell sum type value, as Echidna encodes them in JSON (e.g. a call or an ABI value).
+type echidnaTagged struct {
+	Tag      string          `json:"tag"`
+	Contents json.RawMessage `json:"contents,omitempty"`
+}
+
+// echidnaAbiValue describes an ABI value decoded from an Echidna SolCall, alongside the information needed to ABI
+// encode it as part of the arguments of a call.
+type echidnaAbiValue struct {
+	// abiType describes the canonical ABI type of the value, as used in method signatures.
+	abiType string
+	// dynamic describes whether the ABI type is dynamic, and thus referenced by an offset when encoded in a tuple.
+	dynamic bool
+	// encoded describes the ABI encoding of the value.
+	encoded []byte
+}
+
+// echidnaControlCharacterNames describes the names Haskell uses to escape ASCII control characters, indexed by the
+// character.
+var echidnaControlCharacterNames = []string{
+	"NUL", "SOH", "STX", "ETX", "EOT", "ENQ", "ACK", "a", "b", "t", "n", "v", "f", "r", "SO", "SI",
+	"DLE", "DC1", "DC2", "DC3", "DC4", "NAK", "SYN", "ETB", "CAN", "EM", "SUB", "ESC", "FS", "GS", "RS", "US",
+}
+
+// decodeEchidnaCallSequence decodes a call sequence from the contents of an Echidna reproducer or coverage corpus
+// file. The delays of transactions which make no call are carried over to the next call.
+// Returns the decoded call sequence, or an error if one occurs.
+func decodeEchidnaCallSequence(data []byte) (calls.CallSequence, error) {
+	var txs []echidnaTx
+	if err := json.Unmarshal(data, &txs); err != nil {
+		return nil, err
+	}
+
+	callSequence := make(calls.CallSequence, 0, len(txs))
+	var blockTimestampDelay, blockNumberDelay uint64
+	for i, tx := range txs {
+		// Accumulate the delays of this transaction.
+		timestampDelay, err := parseInteropDelay(tx.Delay[0])
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+		numberDelay, err := parseInteropDelay(tx.Delay[1])
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+		blockTimestampDelay += timestampDelay
+		blockNumberDelay += numberDelay
+
+		// Obtain the call data of this transaction, if it makes a call.
+		var callData []byte
+		to := common.HexToAddress(tx.Dst)
+		toPtr := &to
+		switch tx.Call.Tag {
+		case "NoCall":
+			continue
+		case "SolCall":
+			callData, err = decodeEchidnaSolCall(tx.Call.Contents)
+		case "SolCalldata":
+			callData, err = decodeEchidnaByteStringJSON(tx.Call.Contents)
+		case "SolCreate":
+			callData, err = decodeEchidnaByteStringJSON(tx.Call.Contents)
+			toPtr = nil
+		default:
+			err = fmt.Errorf("unsupported call type '%v'", tx.Call.Tag)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+
+		// Decode the remaining transaction fields.
+		if !common.IsHexAddress(tx.Src) || (toPtr != nil && !common.IsHexAddress(tx.Dst)) {
+			return nil, fmt.Errorf("transaction %d: invalid source or destination address", i)
+		}
+		value, err := parseInteropWord(tx.Value)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+		msg := newInteropCallMessage(common.HexToAddress(tx.Src), toPtr, value, tx.Gas, callData)
+		gasPrice, err := parseInteropWord(tx.GasPrice)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+		if gasPrice.Sign() > 0 {
+			msg.GasPrice = gasPrice
+		}
+
+		callSequence = append(callSequence, calls.NewCallSequenceElement(nil, msg, blockNumberDelay, blockTimestampDelay))
+		blockTimestampDelay, blockNumberDelay = 0, 0
+	}
+	return callSequence, nil
+}
+
+// encodeEchidnaCallSequence encodes a call sequence as the contents of an Echidna reproducer or coverage corpus file.
+// Calls are encoded with their raw call data, so they do not depend on how Echidna represents ABI values.
+// Returns the encoded call sequence, or an error if one occurs.
+func encodeEchidnaCallSequence(callSequence calls.CallSequence) ([]byte, error) {
+	txs := make([]echidnaTx, 0, len(callSequence))
+	for _, element := range callSequence {
+		// Encode the call, which is a contract creation if it has no target.
+		tag, dst := "SolCalldata", common.Address{}
+		if element.Call.To == nil {
+			tag = "SolCreate"
+		} else {
+			dst = *element.Call.To
+		}
+		contents, err := json.Marshal(encodeEchidnaByteString(element.Call.Data))
+		if err != nil {
+			return nil, err
+		}
+
+		gasPrice := element.Call.GasPrice
+		if gasPrice == nil {
+			gasPrice = big.NewInt(0)
+		}
+		value := element.Call.Value
+		if value == nil {
+			value = big.NewInt(0)
+		}
+		txs = append(txs, echidnaTx{
+			Call:     echidnaTagged{Tag: tag, Contents: contents},
+			Src:      element.Call.From.Hex(),
+			Dst:      dst.Hex(),
+			Gas:      element.Call.GasLimit,
+			GasPrice: encodeInteropWord(gasPrice),
+			Value:    encodeInteropWord(value),
+			Delay: [2]json.RawMessage{
+				encodeInteropWord(new(big.Int).SetUint64(element.BlockTimestampDelay)),
+				encodeInteropWord(new(big.Int).SetUint64(element.BlockNumberDelay)),
+			},
+		})
+	}
+	return json.Marshal(txs)
+}
+
+// decodeEchidnaSolCall decodes the contents of an Echidna SolCall, a method name and its ABI values, into call data.
+// Returns the call data, or an error if one occurs.
+func decodeEchidnaSolCall(contents json.RawMessage) ([]byte, error) {
+	// A SolCall is encoded as a pair of the method name and its arguments.
+	var solCall []json.RawMessage
+	if err := json.Unmarshal(contents, &solCall); err != nil || len(solCall) != 2 {
+		return nil, fmt.Errorf("invalid SolCall")
+	}
+	var methodName string
+	var rawArgs []json.RawMessage
+	if err := json.Unmarshal(solCall[0], &methodName); err != nil {
+		return nil, fmt.Errorf("invalid SolCall method name: %v", err)
+	}
+	if err := json.Unmarshal(solCall[1], &rawArgs); err != nil {
+		return nil, fmt.Errorf("invalid SolCall arguments: %v", err)
+	}
+
+	// Decode the arguments and derive the method signature from their types.
+	args := make([]*echidnaAbiValue, len(rawArgs))
+	argTypes := make([]string, len(rawArgs))
+	for i, rawArg := range rawArgs {
+		arg, err := decodeEchidnaAbiValue(rawArg)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = arg
+		argTypes[i] = arg.abiType
+	}
+	methodSignature := fmt.Sprintf("%s(%s)", methodName, strings.Join(argTypes, ","))
+
+	// Our call data is the method ID followed by the arguments, encoded as a tuple.
+	callData := crypto.Keccak256([]byte(methodSignature))[:4]
+	return append(callData, encodeEchidnaAbiTuple(args)...), nil
+}
+
+// decodeEchidnaAbiType decodes an Echidna ABI type.
+// Returns the canonical ABI type and whether it is dynamic, or an error if one occurs.
+func decodeEchidnaAbiType(data json.RawMessage) (string, bool, error) {
+	var tagged echidnaTagged
+	if err := json.Unmarshal(data, &tagged); err != nil {
+		return "", false, err
+	}
+
+	switch tagged.Tag {
+	case "AbiUIntType", "AbiIntType", "AbiBytesType":
+		var size int
+		if err := json.Unmarshal(tagged.Contents, &size); err != nil {
+			return "", false, err
+		}
+		prefix := map[string]string{"AbiUIntType": "uint", "AbiIntType": "int", "AbiBytesType": "bytes"}[tagged.Tag]
+		return fmt.Sprintf("%s%d", prefix, size), false, nil
+	case "AbiAddressType":
+		return "address", false, nil
+	case "AbiBoolType":
+		return "bool", false, nil
+	case "AbiFunctionType":
+		return "function", false, nil
+	case "AbiBytesDynamicType":
+		return "bytes", true, nil
+	case "AbiStringType":
+		return "string", true, nil
+	case "AbiArrayDynamicType":
+		elementType, _, err := decodeEchidnaAbiType(tagged.Contents)
+		return elementType + "[]", true, err
+	case "AbiArrayType":
+		var contents []json.RawMessage
+		var length int
+		if err := json.Unmarshal(tagged.Contents, &contents); err != nil || len(contents) != 2 {
+			return "", false, fmt.Errorf("invalid AbiArrayType")
+		}
+		if err := json.Unmarshal(contents[0], &length); err != nil {
+			return "", false, err
+		}
+		elementType, elementDynamic, err := decodeEchidnaAbiType(contents[1])
+		return fmt.Sprintf("%s[%d]", elementType, length), elementDynamic, err
+	case "AbiTupleType":
+		var contents []json.RawMessage
+		if err := json.Unmarshal(tagged.Contents, &contents); err != nil {
+			return "", false, err
+		}
+		elementTypes := make([]string, len(contents))
+		dynamic := false
+		for i, content := range contents {
+			elementType, elementDynamic, err := decodeEchidnaAbiType(content)
+			if err != nil {
+				return "", false, err
+			}
+			elementTypes[i] = elementType
+			dynamic = dynamic || elementDynamic
+		}
+		return "(" + strings.Join(elementTypes, ",") + ")", dynamic, nil
+	default:
+		return "", false, fmt.Errorf("unsupported ABI type '%v'", tagged.Tag)
+	}
+}
+
+// decodeEchidnaAbiValue decodes an Echidna ABI value and ABI encodes it.
+// Returns the decoded value, or an error if one occurs.
+func decodeEchidnaAbiValue(data json.RawMessage) (*echidnaAbiValue, error) {
+	var tagged echidnaTagged
+	if err := json.Unmarshal(data, &tagged); err != nil {
+		return nil, err
+	}
+
+	// Most values are encoded with multiple fields, so we split them up front.
+	var fields []json.RawMessage
+	switch tagged.Tag {
+	case "AbiUInt", "AbiInt", "AbiBytes", "AbiArrayDynamic", "AbiArray":
+		if err := json.Unmarshal(tagged.Contents, &fields); err != nil {
+			return nil, fmt.Errorf("invalid %v: %v", tagged.Tag, err)
+		}
+		expectedFields := 2
+		if tagged.Tag == "AbiArray" {
+			expectedFields = 3
+		}
+		if len(fields) != expectedFields {
+			return nil, fmt.Errorf("invalid %v", tagged.Tag)
+		}
+	}
+
+	switch tagged.Tag {
+	case "AbiUInt", "AbiInt":
+		var size int
+		if err := json.Unmarshal(fields[0], &size); err != nil || size <= 0 || size > 256 || size%8 != 0 {
+			return nil, fmt.Errorf("invalid %v size", tagged.Tag)
+		}
+		value, err := parseEchidnaInteger(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		prefix := "uint"
+		if tagged.Tag == "AbiInt" {
+			prefix = "int"
+		} else if value.Sign() < 0 {
+			return nil, fmt.Errorf("invalid negative %v value", tagged.Tag)
+		}
+		return &echidnaAbiValue{abiType: fmt.Sprintf("%s%d", prefix, size), encoded: encodeEchidnaAbiWord(value)}, nil
+	case "AbiAddress":
+		var address string
+		if err := json.Unmarshal(tagged.Contents, &address); err != nil || !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("invalid AbiAddress")
+		}
+		return &echidnaAbiValue{abiType: "address", encoded: common.LeftPadBytes(common.HexToAddress(address).Bytes(), 32)}, nil
+	case "AbiBool":
+		var value bool
+		if err := json.Unmarshal(tagged.Contents, &value); err != nil {
+			return nil, fmt.Errorf("invalid AbiBool")
+		}
+		encoded := make([]byte, 32)
+		if value {
+			encoded[31] = 1
+		}
+		return &echidnaAbiValue{abiType: "bool", encoded: encoded}, nil
+	case "AbiBytes":
+		var size int
+		if err := json.Unmarshal(fields[0], &size); err != nil || size <= 0 || size > 32 {
+			return nil, fmt.Errorf("invalid AbiBytes size")
+		}
+		b, err := decodeEchidnaByteStringJSON(fields[1])
+		if err != nil || len(b) > size {
+			return nil, fmt.Errorf("invalid AbiBytes value")
+		}
+		return &echidnaAbiValue{abiType: fmt.Sprintf("bytes%d", size), encoded: common.RightPadBytes(b, 32)}, nil
+	case "AbiFunction":
+		b, err := decodeEchidnaByteStringJSON(tagged.Contents)
+		if err != nil || len(b) > 24 {
+			return nil, fmt.Errorf("invalid AbiFunction value")
+		}
+		return &echidnaAbiValue{abiType: "function", encoded: common.RightPadBytes(b, 32)}, nil
+	case "AbiBytesDynamic", "AbiString":
+		b, err := decodeEchidnaByteStringJSON(tagged.Contents)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %v value", tagged.Tag)
+		}
+		abiType := "bytes"
+		if tagged.Tag == "AbiString" {
+			abiType = "string"
+		}
+		encoded := encodeEchidnaAbiWord(big.NewInt(int64(len(b))))
+		encoded = append(encoded, common.RightPadBytes(b, (len(b)+31)/32*32)...)
+		return &echidnaAbiValue{abiType: abiType, dynamic: true, encoded: encoded}, nil
+	case "AbiArrayDynamic":
+		elementType, _, err := decodeEchidnaAbiType(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		elements, err := decodeEchidnaAbiValues(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		encoded := append(encodeEchidnaAbiWord(big.NewInt(int64(len(elements)))), encodeEchidnaAbiTuple(elements)...)
+		return &echidnaAbiValue{abiType: elementType + "[]", dynamic: true, encoded: encoded}, nil
+	case "AbiArray":
+		var length int
+		if err := json.Unmarshal(fields[0], &length); err != nil {
+			return nil, fmt.Errorf("invalid AbiArray length")
+		}
+		elementType, elementDynamic, err := decodeEchidnaAbiType(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		elements, err := decodeEchidnaAbiValues(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		if len(elements) != length {
+			return nil, fmt.Errorf("invalid AbiArray, expected %d elements but found %d", length, len(elements))
+		}
+		return &echidnaAbiValue{abiType: fmt.Sprintf("%s[%d]", elementType, length), dynamic: elementDynamic, encoded: encodeEchidnaAbiTuple(elements)}, nil
+	case "AbiTuple":
+		elements, err := decodeEchidnaAbiValues(tagged.Contents)
+		if err != nil {
+			return nil, err
+		}
+		elementTypes := make([]string, len(elements))
+		dynamic := false
+		for i, element := range elements {
+			elementTypes[i] = element.abiType
+			dynamic = dynamic || element.dynamic
+		}
+		return &echidnaAbiValue{abiType: "(" + strings.Join(elementTypes, ",") + ")", dynamic: dynamic, encoded: encodeEchidnaAbiTuple(elements)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported ABI value '%v'", tagged.Tag)
+	}
+}
+
+// decodeEchidnaAbiValues decodes a JSON list of Echidna ABI values.
+// Returns the decoded values, or an error if one occurs.
+func decodeEchidnaAbiValues(data json.RawMessage) ([]*echidnaAbiValue, error) {
+	var rawValues []json.RawMessage
+	if err := json.Unmarshal(data, &rawValues); err != nil {
+		return nil, err
+	}
+	values := make([]*echidnaAbiValue, len(rawValues))
+	for i, rawValue := range rawValues {
+		value, err := decodeEchidnaAbiValue(rawValue)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// encodeEchidnaAbiTuple ABI encodes the provided values as a tuple, where static values are encoded in place and
+// dynamic values are referenced by their offset from the start of the tuple.
+func encodeEchidnaAbiTuple(values []*echidnaAbiValue) []byte {
+	// Determine the size of the heads, after which the tails of dynamic values start.
+	headSize := 0
+	for _, value := range values {
+		if value.dynamic {
+			headSize += 32
+		} else {
+			headSize += len(value.encoded)
+		}
+	}
+
+	heads, tails := make([]byte, 0, headSize), make([]byte, 0)
+	for _, value := range values {
+		if value.dynamic {
+			heads = append(heads, encodeEchidnaAbiWord(big.NewInt(int64(headSize+len(tails))))...)
+			tails = append(tails, value.encoded...)
+		} else {
+			heads = append(heads, value.encoded...)
+		}
+	}
+	return append(heads, tails...)
+}
+
+// encodeEchidnaAbiWord ABI encodes an integer as a 256-bit two's complement word.
+func encodeEchidnaAbiWord(value *big.Int) []byte {
+	word := new(big.Int).Set(value)
+	if word.Sign() < 0 {
+		word.Add(word, new(big.Int).Lsh(big.NewInt(1), 256))
+	}
+	return common.LeftPadBytes(word.Bytes(), 32)
+}
+
+// parseEchidnaInteger parses an integer ABI value, which Echidna encodes as a decimal string.
+// Returns the parsed integer, or an error if one occurs.
+func parseEchidnaInteger(data json.RawMessage) (*big.Int, error) {
+	text := strings.TrimSpace(string(data))
+	if strings.HasPrefix(text, "\"") {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return nil, err
+		}
+	}
+	value, ok := new(big.Int).SetString(text, 0)
+	if !ok || value.BitLen() > 256 {
+		return nil, fmt.Errorf("invalid integer '%v'", text)
+	}
+	return value, nil
+}
+
+// decodeEchidnaByteStringJSON decodes a JSON string holding a byte string, which Echidna encodes using Haskell's
+// string representation.
+// Returns the decoded bytes, or an error if one occurs.
+func decodeEchidnaByteStringJSON(data json.RawMessage) ([]byte, error) {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return nil, err
+	}
+	return decodeEchidnaByteString(text)
+}
+
+// decodeEchidnaByteString decodes a byte string from its Haskell string representation (e.g. "\"\\SOH\\128a\"").
+// Returns the decoded bytes, or an error if one occurs.
+func decodeEchidnaByteString(text string) ([]byte, error) {
+	if len(text) < 2 || text[0] != '"' || text[len(text)-1] != '"' {
+		return nil, fmt.Errorf("invalid byte string %v", text)
+	}
+	text = text[1 : len(text)-1]
+
+	b := make([]byte, 0, len(text))
+	for i := 0; i < len(text); i++ {
+		// Characters which are not escaped are taken as-is.
+		if text[i] != '\\' {
+			b = append(b, text[i])
+			continue
+		}
+		i++
+		if i >= len(text) {
+			return nil, fmt.Errorf("invalid byte string escape at end of string")
+		}
+
+		// Numeric escapes may be decimal, hexadecimal or octal.
+		base, digitsStart := 10, i
+		switch text[i] {
+		case 'x':
+			base, digitsStart = 16, i+1
+		case 'o':
+			base, digitsStart = 8, i+1
+		}
+		digitsEnd := digitsStart
+		for digitsEnd < len(text) && isEchidnaDigit(text[digitsEnd], base) {
+			digitsEnd++
+		}
+		if digitsEnd > digitsStart {
+			value, err := strconv.ParseUint(text[digitsStart:digitsEnd], base, 8)
+			if err != nil {
+				return nil, fmt.Errorf("invalid byte string escape '%v'", text[i:digitsEnd])
+			}
+			b = append(b, byte(value))
+			i = digitsEnd - 1
+			continue
+		}
+
+		switch text[i] {
+		case '&':
+			// An empty escape, used to separate numeric escapes from digits.
+		case '"', '\\', '\'':
+			b = append(b, text[i])
+		case '^':
+			if i+1 >= len(text) || text[i+1] < '@' || text[i+1] > '_' {
+				return nil, fmt.Errorf("invalid byte string control escape")
+			}
+			b = append(b, text[i+1]-'@')
+			i++
+		default:
+			// Match named escapes, preferring the longest name (e.g. SOH over SO).
+			matched := false
+			for _, length := range []int{3, 2, 1} {
+				if i+length > len(text) {
+					continue
+				}
+				name := text[i : i+length]
+				if name == "DEL" {
+					b, matched = append(b, 0x7f), true
+				} else if name == "SP" {
+					b, matched = append(b, ' '), true
+				} else {
+					for c, controlName := range echidnaControlCharacterNames {
+						if name == controlName {
+							b, matched = append(b, byte(c)), true
+							break
+						}
+					}
+				}
+				if matched {
+					i += length - 1
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("invalid byte string escape '\\%c'", text[i])
+			}
+		}
+	}
+	return b, nil
+}
+
+// isEchidnaDigit determines whether the provided character is a digit in the provided base (8, 10 or 16).
+func isEchidnaDigit(c byte, base int) bool {
+	switch {
+	case c >= '0' && c <= '7':
+		return true
+	case c == '8' || c == '9':
+		return base >= 10
+	case (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F'):
+		return base == 16
+	default:
+		return false
+	}
+}
+
+// encodeEchidnaByteString encodes a byte string using its Haskell string representation, as Echidna expects it.
+func encodeEchidnaByteString(b []byte) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for i, c := range b {
+		// Separate escapes from following characters which would otherwise be read as part of them.
+		if i > 0 {
+			previous := b[i-1]
+			if (previous >= 0x80 && c >= '0' && c <= '9') || (previous == 0x0e && c == 'H') {
+				sb.WriteString("\\&")
+			}
+		}
+
+		switch {
+		case c == '"' || c == '\\':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case c < 0x20:
+			sb.WriteByte('\\')
+			sb.WriteString(echidnaControlCharacterNames[c])
+		case c == 0x7f:
+			sb.WriteString("\\DEL")
+		case c >= 0x80:
+			sb.WriteString("\\" + strconv.Itoa(int(c)))
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
@@ -0,0 +1,106 @@
+package corpus
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/common/hexutil"
+	"github.com/crytic/medusa/fuzzing/calls"
+)
+
+// foundryCounterExample describes a call in a call sequence Foundry persists for a failed invariant test.
+type foundryCounterExample struct {
+	Sender    *common.Address `json:"sender"`
+	Addr      *common.Address `json:"addr"`
+	Calldata  hexutil.Bytes   `json:"calldata"`
+	FuncName  string          `json:"func_name,omitempty"`
+	Signature string          `json:"signature,omitempty"`
+	Warp      json.RawMessage `json:"warp,omitempty"`
+	Roll      json.RawMessage `json:"roll,omitempty"`
+}
+
+// foundryPersistedFailure describes the file Foundry persists for a failed invariant test.
+type foundryPersistedFailure struct {
+	CallSequence []foundryCounterExample `json:"call_sequence"`
+}
+
+// decodeFoundryCallSequence decodes a call sequence from the contents of a file Foundry persists for a failed invariant
+// test. Older Foundry versions persist the list of calls on its own, which is supported as well. As Foundry does not
+// record gas limits, calls use the provided one.
+// Returns the decoded call sequence, or an error if one occurs.
+func decodeFoundryCallSequence(data []byte, gasLimit uint64) (calls.CallSequence, error) {
+	var persistedFailure foundryPersistedFailure
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(data, &persistedFailure.CallSequence); err != nil {
+			return nil, err
+		}
+	} else if err := json.Unmarshal(data, &persistedFailure); err != nil {
+		return nil, err
+	}
+
+	callSequence := make(calls.CallSequence, 0, len(persistedFailure.CallSequence))
+	for i, counterExample := range persistedFailure.CallSequence {
+		if counterExample.Sender == nil || counterExample.Addr == nil {
+			return nil, fmt.Errorf("call %d: missing sender or target address", i)
+		}
+
+		// Foundry warps and rolls the chain forward before a call, which we represent as delays.
+		blockTimestampDelay, err := parseInteropDelay(counterExample.Warp)
+		if err != nil {
+			return nil, fmt.Errorf("call %d: %v", i, err)
+		}
+		blockNumberDelay, err := parseInteropDelay(counterExample.Roll)
+		if err != nil {
+			return nil, fmt.Errorf("call %d: %v", i, err)
+		}
+
+		to := *counterExample.Addr
+		msg := newInteropCallMessage(*counterExample.Sender, &to, big.NewInt(0), gasLimit, counterExample.Calldata)
+		callSequence = append(callSequence, calls.NewCallSequenceElement(nil, msg, blockNumberDelay, blockTimestampDelay))
+	}
+	return callSequence, nil
+}
+
+// encodeFoundryCallSequence encodes a call sequence as the contents of a file Foundry persists for a failed invariant
+// test. Foundry cannot replay contract creations or calls which send value, so they are rejected.
+// Returns the encoded call sequence, or an error if one occurs.
+func encodeFoundryCallSequence(callSequence calls.CallSequence) ([]byte, error) {
+	persistedFailure := foundryPersistedFailure{
+		CallSequence: make([]foundryCounterExample, 0, len(callSequence)),
+	}
+	for i, element := range callSequence {
+		if element.Call.To == nil {
+			return nil, fmt.Errorf("call %d: contract creations are not supported", i)
+		}
+		if element.Call.Value != nil && element.Call.Value.Sign() != 0 {
+			return nil, fmt.Errorf("call %d: calls sending value are not supported", i)
+		}
+
+		sender, target := element.Call.From, *element.Call.To
+		counterExample := foundryCounterExample{
+			Sender:   &sender,
+			Addr:     &target,
+			Calldata: element.Call.Data,
+		}
+
+		// Name the method called if it is known.
+		if element.Call.DataAbiValues != nil {
+			counterExample.Signature = element.Call.DataAbiValues.MethodSignature()
+			counterExample.FuncName, _, _ = strings.Cut(counterExample.Signature, "(")
+		}
+
+		// Delays are represented as warps and rolls before the call.
+		if element.BlockTimestampDelay != 0 {
+			counterExample.Warp = encodeInteropWord(new(big.Int).SetUint64(element.BlockTimestampDelay))
+		}
+		if element.BlockNumberDelay != 0 {
+			counterExample.Roll = encodeInteropWord(new(big.Int).SetUint64(element.BlockNumberDelay))
+		}
+		persistedFailure.CallSequence = append(persistedFailure.CallSequence, counterExample)
+	}
+	return json.MarshalIndent(persistedFailure, "", " ")
+}
@@ -0,0 +1,173 @@
+package corpus
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/crytic/medusa-geth/accounts/abi"
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/utils/testutils"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEchidnaByteStringRoundTrip ensures byte strings survive a round trip through their Haskell string
+// representation, including escapes which must be separated from the characters following them.
+func TestEchidnaByteStringRoundTrip(t *testing.T) {
+	// Every byte value, followed by the sequences which require an empty escape to be separated.
+	b := make([]byte, 0, 260)
+	for i := 0; i < 256; i++ {
+		b = append(b, byte(i))
+	}
+	b = append(b, 0x80, '1', 0x0e, 'H')
+
+	decoded, err := decodeEchidnaByteString(encodeEchidnaByteString(b))
+	assert.NoError(t, err)
+	assert.EqualValues(t, b, decoded)
+
+	// Ensure byte strings as Haskell shows them are read correctly.
+	decoded, err = decodeEchidnaByteString(`"\SOH\SO\&H\128\&1a\"\\\x41\o102\^C\DEL"`)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []byte{0x01, 0x0e, 'H', 0x80, '1', 'a', '"', '\\', 'A', 'B', 0x03, 0x7f}, decoded)
+}
+
+// TestEchidnaSolCallDecoding ensures Echidna SolCall transactions are decoded into the same call data the ABI encoder
+// produces for the method they call.
+func TestEchidnaSolCallDecoding(t *testing.T) {
+	reproducer := `[{"call":{"tag":"SolCall","contents":["f",[
+			{"tag":"AbiUInt","contents":[256,"1234"]},
+			{"tag":"AbiInt","contents":[8,"-2"]},
+			{"tag":"AbiString","contents":"\"hi\""},
+			{"tag":"AbiArrayDynamic","contents":[{"tag":"AbiAddressType"},[{"tag":"AbiAddress","contents":"0x0000000000000000000000000000000000010000"}]]},
+			{"tag":"AbiTuple","contents":[{"tag":"AbiBool","contents":true},{"tag":"AbiBytesDynamic","contents":"\"\\SOH\""}]}
+		]]},
+		"src":"0x0000000000000000000000000000000000010000","dst":"0x00a329c0648769A73afAc7F9381E08FB43dBEA72",
+		"gas":12500000,"gasprice":"0x0","value":"0x5","delay":["0x3c","0x2"]},
+		{"call":{"tag":"NoCall"},"src":"0x0000000000000000000000000000000000010000","dst":"0x0000000000000000000000000000000000000000",
+		"gas":12500000,"gasprice":"0x0","value":"0x0","delay":["0x10","0x1"]},
+		{"call":{"tag":"SolCalldata","contents":"\"\\NUL\\SOH\\STX\\ETX\""},
+		"src":"0x0000000000000000000000000000000000020000","dst":"0x00a329c0648769A73afAc7F9381E08FB43dBEA72",
+		"gas":12500000,"gasprice":"0x0","value":"0x0","delay":["0x0","0x0"]}]`
+
+	callSequence, err := decodeEchidnaCallSequence([]byte(reproducer))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, len(callSequence))
+
+	// Construct the method and arguments the SolCall encodes, and ensure the call data matches.
+	newType := func(t string, components []abi.ArgumentMarshaling) abi.Type {
+		typ, _ := abi.NewType(t, "", components)
+		return typ
+	}
+	method := abi.NewMethod("f", "f", abi.Function, "", false, false, abi.Arguments{
+		{Type: newType("uint256", nil)},
+		{Type: newType("int8", nil)},
+		{Type: newType("string", nil)},
+		{Type: newType("address[]", nil)},
+		{Type: newType("tuple", []abi.ArgumentMarshaling{{Name: "a", Type: "bool"}, {Name: "b", Type: "bytes"}})},
+	}, nil)
+	args, err := method.Inputs.Pack(big.NewInt(1234), int8(-2), "hi", []common.Address{common.HexToAddress("0x10000")}, struct {
+		A bool
+		B []byte
+	}{true, []byte{0x01}})
+	assert.NoError(t, err)
+	assert.EqualValues(t, append(method.ID, args...), callSequence[0].Call.Data)
+	assert.EqualValues(t, big.NewInt(5), callSequence[0].Call.Value)
+	assert.EqualValues(t, 60, callSequence[0].BlockTimestampDelay)
+	assert.EqualValues(t, 2, callSequence[0].BlockNumberDelay)
+
+	// The delay of the transaction making no call is carried over to the next call.
+	assert.EqualValues(t, []byte{0x00, 0x01, 0x02, 0x03}, callSequence[1].Call.Data)
+	assert.EqualValues(t, 16, callSequence[1].BlockTimestampDelay)
+	assert.EqualValues(t, 1, callSequence[1].BlockNumberDelay)
+	assert.True(t, callSequence[1].Call.SkipNonceChecks)
+}
+
+// TestInteropCallSequenceRoundTrip ensures call sequences retain their calls and delays when encoded to, and decoded
+// from, every supported format.
+func TestInteropCallSequenceRoundTrip(t *testing.T) {
+	for _, format := range []InteropFormat{InteropFormatEchidna, InteropFormatFoundry} {
+		// Foundry does not record values, so we do not send any.
+		callSequence := getMockCallSequence(5)
+		for _, element := range callSequence {
+			element.Call.Value = big.NewInt(0)
+		}
+
+		b, err := encodeInteropCallSequence(format, callSequence)
+		assert.NoError(t, err)
+		decoded, err := decodeInteropCallSequence(format, b, 1_000_000)
+		assert.NoError(t, err)
+		assert.EqualValues(t, len(callSequence), len(decoded))
+		for i := range callSequence {
+			assert.EqualValues(t, callSequence[i].Call.From, decoded[i].Call.From)
+			assert.EqualValues(t, *callSequence[i].Call.To, *decoded[i].Call.To)
+			assert.EqualValues(t, callSequence[i].Call.Data, decoded[i].Call.Data)
+			assert.EqualValues(t, callSequence[i].BlockNumberDelay, decoded[i].BlockNumberDelay)
+			assert.EqualValues(t, callSequence[i].BlockTimestampDelay, decoded[i].BlockTimestampDelay)
+		}
+	}
+}
+
+// TestCorpusImportExport ensures call sequences exported from a corpus can be imported into another one, translating
+// addresses as requested.
+func TestCorpusImportExport(t *testing.T) {
+	testutils.ExecuteInDirectory(t, t.TempDir(), func() {
+		for _, format := range []InteropFormat{InteropFormatEchidna, InteropFormatFoundry} {
+			// Create a corpus with call sequences to export.
+			corpus, err := NewCorpus(filepath.Join(string(format), "source"), nil)
+			assert.NoError(t, err)
+			for i := 0; i < 5; i++ {
+				callSequence := getMockCallSequence(3)
+				for _, element := range callSequence {
+					element.Call.Value = big.NewInt(0)
+				}
+				err = corpus.addCallSequence(corpus.callSequenceFiles, callSequence, false, nil, false)
+				assert.NoError(t, err)
+			}
+			exported, err := corpus.ExportCallSequences(format, filepath.Join(string(format), "exported"))
+			assert.NoError(t, err)
+			assert.EqualValues(t, 5, exported)
+
+			// Import them into a new corpus, mapping the target of the first call of every sequence.
+			addressMap := make(map[common.Address]common.Address)
+			for _, callSequence := range corpus.CallSequenceFiles() {
+				addressMap[*callSequence[0].Call.To] = common.HexToAddress("0x1234")
+			}
+			importedCorpus, err := NewCorpus(filepath.Join(string(format), "imported"), nil)
+			assert.NoError(t, err)
+			imported, err := importedCorpus.ImportCallSequences(format, filepath.Join(string(format), "exported"), addressMap)
+			assert.NoError(t, err)
+			assert.EqualValues(t, 5, imported)
+			for _, callSequence := range importedCorpus.CallSequenceFiles() {
+				assert.EqualValues(t, common.HexToAddress("0x1234"), *callSequence[0].Call.To)
+			}
+
+			// The imported call sequences are written to disk and can be read back.
+			matches, err := filepath.Glob(filepath.Join(string(format), "imported", "call_sequences", "*.json"))
+			assert.NoError(t, err)
+			assert.EqualValues(t, 5, len(matches))
+			_, err = NewCorpus(filepath.Join(string(format), "imported"), nil)
+			assert.NoError(t, err)
+		}
+
+		// Importing a file which is not in the expected format fails.
+		err := os.WriteFile("invalid.txt", []byte("{"), os.ModePerm)
+		assert.NoError(t, err)
+		corpus, err := NewCorpus("", nil)
+		assert.NoError(t, err)
+		_, err = corpus.ImportCallSequences(InteropFormatEchidna, "invalid.txt", nil)
+		assert.Error(t, err)
+	})
+}
+
+// TestParseInteropFormat ensures supported formats are parsed case-insensitively and others are rejected.
+func TestParseInteropFormat(t *testing.T) {
+	format, err := ParseInteropFormat("Echidna")
+	assert.NoError(t, err)
+	assert.EqualValues(t, InteropFormatEchidna, format)
+	format, err = ParseInteropFormat("foundry")
+	assert.NoError(t, err)
+	assert.EqualValues(t, InteropFormatFoundry, format)
+	_, err = ParseInteropFormat("hardhat")
+	assert.Error(t, err)
+}
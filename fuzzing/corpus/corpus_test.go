@@ -1,6 +1,7 @@
 package corpus
 
 import (
+	"context"
 	"encoding/json"
 	"math/big"
 	"math/rand"
@@ -8,8 +9,14 @@ import (
 	"testing"
 
 	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa/chain"
+	chainTypes "github.com/crytic/medusa/chain/types"
 	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/branchdistance"
 	"github.com/crytic/medusa/utils/testutils"
+	"github.com/holiman/uint256"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -146,3 +153,215 @@ func TestCorpusCallSequenceMarshaling(t *testing.T) {
 		assert.Empty(t, corpus.callSequenceFiles.files)
 	})
 }
+
+// TestCorpusRemoveCallSequenceFiles ensures that call sequences removed from the corpus are deleted from disk, while
+// the remaining ones are kept.
+func TestCorpusRemoveCallSequenceFiles(t *testing.T) {
+	// Create a mock corpus
+	corpus, err := getMockSimpleCorpus(10, 20, 1, 7)
+	assert.NoError(t, err)
+	testutils.ExecuteInDirectory(t, t.TempDir(), func() {
+		// Write to disk
+		err := corpus.Flush()
+		assert.NoError(t, err)
+
+		// Remove every other call sequence
+		removed := make([]string, 0)
+		for i, file := range corpus.callSequenceFiles.files {
+			if i%2 == 0 {
+				removed = append(removed, file.fileName)
+			}
+		}
+		totalSequences := len(corpus.CallSequenceFiles())
+		err = corpus.RemoveCallSequenceFiles(removed)
+		assert.NoError(t, err)
+
+		// Ensure the removed call sequences are gone from both the corpus and the disk
+		sequences := corpus.CallSequenceFiles()
+		assert.EqualValues(t, totalSequences-len(removed), len(sequences))
+		for _, fileName := range removed {
+			assert.NotContains(t, sequences, fileName)
+		}
+		matches, err := filepath.Glob(filepath.Join(corpus.callSequenceFiles.path, "*.json"))
+		assert.NoError(t, err)
+		assert.EqualValues(t, len(sequences), len(matches))
+
+		// Reading the corpus back from disk should only yield the remaining call sequences
+		corpus, err = NewCorpus(corpus.storageDirectory, nil)
+		assert.NoError(t, err)
+		assert.EqualValues(t, len(sequences), len(corpus.CallSequenceFiles()))
+	})
+}
+
+// TestCorpusRandomMutationTargetDeterministic ensures the mutation targets chosen with a random provider only depend
+// on its seed, regardless of the choices made concurrently with the random providers of other workers.
+func TestCorpusRandomMutationTargetDeterministic(t *testing.T) {
+	projectConfig, err := config.GetDefaultProjectConfig("")
+	assert.NoError(t, err)
+	corpus, err := NewCorpus("", &projectConfig.Fuzzing)
+	assert.NoError(t, err)
+	testChain, err := chain.NewTestChain(context.Background(), types.GenesisAlloc{}, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, corpus.Initialize(testChain, nil, rand.New(rand.NewSource(0))))
+	for i := 1; i <= 8; i++ {
+		assert.NoError(t, corpus.MarkCallSequenceForMutation(calls.CallSequence{}, big.NewInt(int64(i))))
+	}
+
+	// chooseTargets chooses mutation targets with a worker seeded with the provided seed, drawing from the random
+	// provider of another worker between each choice if requested.
+	chooseTargets := func(seed int64, interleaved bool) []*MutationTarget {
+		randomProvider := rand.New(rand.NewSource(seed))
+		otherRandomProvider := rand.New(rand.NewSource(seed + 1))
+		targets := make([]*MutationTarget, 0)
+		for i := 0; i < 32; i++ {
+			_, target, err := corpus.RandomMutationTarget(randomProvider)
+			assert.NoError(t, err)
+			targets = append(targets, target)
+			if interleaved {
+				_, _, err = corpus.RandomMutationTarget(otherRandomProvider)
+				assert.NoError(t, err)
+			}
+		}
+		return targets
+	}
+	assert.Equal(t, chooseTargets(1, false), chooseTargets(1, true))
+}
+
+// getBranchDistanceCorpus creates an initialized corpus which only uses branch distances as a fitness metric, with the
+// provided configuration applied.
+func getBranchDistanceCorpus(t *testing.T, configure func(fuzzingConfig *config.FuzzingConfig)) *Corpus {
+	projectConfig, err := config.GetDefaultProjectConfig("")
+	assert.NoError(t, err)
+	projectConfig.Fuzzing.FitnessMetricConfig = config.FitnessMetricConfig{BranchDistanceEnabled: true}
+	configure(&projectConfig.Fuzzing)
+	corpus, err := NewCorpus("", &projectConfig.Fuzzing)
+	assert.NoError(t, err)
+	testChain, err := chain.NewTestChain(context.Background(), types.GenesisAlloc{}, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, corpus.Initialize(testChain, nil, rand.New(rand.NewSource(0))))
+	return corpus
+}
+
+// getMockBranchDistances creates branch distance maps recording the provided distance for the provided branch id of a
+// single contract.
+func getMockBranchDistances(t *testing.T, id int, distance uint64) *branchdistance.BranchDistanceMaps {
+	branchDistanceMaps := branchdistance.NewBranchDistanceMaps()
+	_, err := branchDistanceMaps.SetAt(common.Address{1}, common.Hash{1}, 4, id, uint256.NewInt(distance))
+	assert.NoError(t, err)
+	return branchDistanceMaps
+}
+
+// checkMockBranchDistances checks a mock call sequence whose last call recorded the provided branch distance maps for
+// admission into the corpus, with the provided mutation weight.
+// Returns whether it was added to the corpus, and the mutation weight it was added with, if any.
+func checkMockBranchDistances(t *testing.T, corpus *Corpus, branchDistanceMaps *branchdistance.BranchDistanceMaps, mutationChooserWeight int64) (bool, *big.Int) {
+	sequence := getMockCallSequence(1)
+	sequence[0].ChainReference = &calls.CallSequenceElementChainReference{
+		Block: &chainTypes.Block{MessageResults: []*chainTypes.MessageResults{{
+			AdditionalResults: map[string]any{"BranchDistanceTracerResults": branchDistanceMaps},
+		}}},
+	}
+	choiceCount := corpus.mutationTargetSequenceChooser.ChoiceCount()
+	added, err := corpus.CheckSequenceMetricAndUpdate(sequence, big.NewInt(mutationChooserWeight), false)
+	assert.NoError(t, err)
+	if corpus.mutationTargetSequenceChooser.ChoiceCount() == choiceCount {
+		return added, nil
+	}
+	return added, corpus.mutationTargetSequenceChooser.Choices[choiceCount].Weight()
+}
+
+// TestCheckSequenceMetricAndUpdateReducedDistance ensures call sequences which reduce the distance of an already
+// reached branch are added to the corpus with their mutation weight multiplied, while those reaching a new branch
+// keep their mutation weight.
+func TestCheckSequenceMetricAndUpdateReducedDistance(t *testing.T) {
+	corpus := getBranchDistanceCorpus(t, func(fuzzingConfig *config.FuzzingConfig) {
+		fuzzingConfig.BranchDistance.ReducedDistanceWeightMultiplier = 4
+	})
+
+	// Reaching a branch is new coverage rather than a reduced distance.
+	added, weight := checkMockBranchDistances(t, corpus, getMockBranchDistances(t, 0, 10), 10)
+	assert.True(t, added)
+	assert.EqualValues(t, 10, weight.Int64())
+
+	// The same distance is not an update, while a closer one is rewarded.
+	added, _ = checkMockBranchDistances(t, corpus, getMockBranchDistances(t, 0, 10), 10)
+	assert.False(t, added)
+	added, weight = checkMockBranchDistances(t, corpus, getMockBranchDistances(t, 0, 5), 10)
+	assert.True(t, added)
+	assert.EqualValues(t, 40, weight.Int64())
+	added, weight = checkMockBranchDistances(t, corpus, getMockBranchDistances(t, 1, 5), 10)
+	assert.True(t, added)
+	assert.EqualValues(t, 10, weight.Int64())
+}
+
+// TestCheckSequenceMetricAndUpdateRevertSiteDistance ensures call sequences which get closer to passing the guard of an
+// already reached revert site are added to the corpus with their mutation weight multiplied, like those reducing the
+// distance of an already reached branch.
+func TestCheckSequenceMetricAndUpdateRevertSiteDistance(t *testing.T) {
+	corpus := getBranchDistanceCorpus(t, func(fuzzingConfig *config.FuzzingConfig) {
+		fuzzingConfig.BranchDistance.ReducedDistanceWeightMultiplier = 4
+	})
+
+	// getMockRevertSiteDistances creates branch distance maps recording the provided distance for a revert site.
+	revertSite := branchdistance.RevertSite{CodeHash: common.Hash{1}, RevertPc: 20, BranchPc: 18}
+	getMockRevertSiteDistances := func(distance uint64) *branchdistance.BranchDistanceMaps {
+		branchDistanceMaps := branchdistance.NewBranchDistanceMaps()
+		branchDistanceMaps.SetRevertSiteDistance(revertSite, uint256.NewInt(distance))
+		return branchDistanceMaps
+	}
+
+	// Reaching a revert site keeps the mutation weight, while getting closer to passing its guard is rewarded.
+	added, weight := checkMockBranchDistances(t, corpus, getMockRevertSiteDistances(10), 10)
+	assert.True(t, added)
+	assert.EqualValues(t, 10, weight.Int64())
+	added, _ = checkMockBranchDistances(t, corpus, getMockRevertSiteDistances(10), 10)
+	assert.False(t, added)
+	added, weight = checkMockBranchDistances(t, corpus, getMockRevertSiteDistances(2), 10)
+	assert.True(t, added)
+	assert.EqualValues(t, 40, weight.Int64())
+	assert.EqualValues(t, 2, corpus.BranchDistanceMaps().AlmostPassingRevertSites(1)[0].Distance.Uint64())
+}
+
+// TestCheckSequenceMetricAndUpdateRevertedDistance ensures call sequences which only get closer to flipping branches in
+// reverted call frames are only added to the corpus if reverted distances are used, with their mutation weight divided
+// by the configured divisor, and only for branches not reached by a successful call frame.
+func TestCheckSequenceMetricAndUpdateRevertedDistance(t *testing.T) {
+	// getMockRevertedBranchDistances creates branch distance maps recording the provided distance for the provided
+	// branch id in a reverted call frame.
+	getMockRevertedBranchDistances := func(id int, distance uint64) *branchdistance.BranchDistanceMaps {
+		branchDistanceMaps := getMockBranchDistances(t, id, distance)
+		branchDistanceMaps.RevertAll()
+		return branchDistanceMaps
+	}
+
+	// Without reverted distances, such call sequences are not added.
+	corpus := getBranchDistanceCorpus(t, func(fuzzingConfig *config.FuzzingConfig) {
+		fuzzingConfig.BranchDistance.UseRevertedDistance = false
+	})
+	added, weight := checkMockBranchDistances(t, corpus, getMockRevertedBranchDistances(0, 10), 12)
+	assert.False(t, added)
+	assert.Nil(t, weight)
+
+	corpus = getBranchDistanceCorpus(t, func(fuzzingConfig *config.FuzzingConfig) {
+		fuzzingConfig.BranchDistance.UseRevertedDistance = true
+		fuzzingConfig.BranchDistance.RevertedDistanceWeightDivisor = 4
+	})
+	added, weight = checkMockBranchDistances(t, corpus, getMockRevertedBranchDistances(0, 10), 12)
+	assert.True(t, added)
+	assert.EqualValues(t, 3, weight.Int64())
+
+	// The same reverted distance is not an update, while a closer one is, with a weight of at least one.
+	added, _ = checkMockBranchDistances(t, corpus, getMockRevertedBranchDistances(0, 10), 12)
+	assert.False(t, added)
+	added, weight = checkMockBranchDistances(t, corpus, getMockRevertedBranchDistances(0, 5), 2)
+	assert.True(t, added)
+	assert.EqualValues(t, 1, weight.Int64())
+
+	// Reaching the branch in a successful call frame keeps the full weight, after which closer reverted distances for
+	// it are no longer an update.
+	added, weight = checkMockBranchDistances(t, corpus, getMockBranchDistances(t, 0, 20), 12)
+	assert.True(t, added)
+	assert.EqualValues(t, 12, weight.Int64())
+	added, _ = checkMockBranchDistances(t, corpus, getMockRevertedBranchDistances(0, 1), 12)
+	assert.False(t, added)
+}
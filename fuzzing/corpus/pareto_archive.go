@@ -0,0 +1,204 @@
+package corpus
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/crytic/medusa/chain/types"
+	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/branchcoverage"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/branchdistance"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/cmpdistance"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/codecoverage"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/dataflow"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/storagewrite"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/tokenflow"
+)
+
+// paretoArchiveEntry describes a call sequence kept in a ParetoArchive, along with its objective values.
+type paretoArchiveEntry struct {
+	// sequence describes the call sequence.
+	sequence calls.CallSequence
+	// objectives describes the value the call sequence achieved for each objective of the archive, higher being better.
+	objectives []float64
+}
+
+// ParetoArchive keeps the call sequences which are not dominated by any other across several fitness metrics
+// (objectives), in the fashion of MOSA. A call sequence dominates another if it is at least as good for every
+// objective, and better for at least one. Mutation targets are sampled by choosing an objective according to its
+// weight, then one of the call sequences best for it.
+type ParetoArchive struct {
+	// objectives describes the fitness metrics used as objectives, sorted by name.
+	objectives []string
+	// weights describes the weight of each objective, in the order of objectives.
+	weights []uint64
+	// totalWeight describes the sum of weights.
+	totalWeight uint64
+
+	// probability describes the probability that a mutation target is sampled from the archive.
+	probability float64
+
+	// entries describes the non-dominated call sequences.
+	entries []*paretoArchiveEntry
+
+	// lock offers concurrent thread safety for entry accesses.
+	lock sync.Mutex
+}
+
+// NewParetoArchive creates a new, empty ParetoArchive with the provided configuration.
+func NewParetoArchive(multiObjectiveConfig config.MultiObjectiveConfig) *ParetoArchive {
+	archive := &ParetoArchive{
+		objectives:  make([]string, 0, len(multiObjectiveConfig.Objectives)),
+		weights:     make([]uint64, 0, len(multiObjectiveConfig.Objectives)),
+		probability: multiObjectiveConfig.ArchiveProbability,
+		entries:     make([]*paretoArchiveEntry, 0),
+	}
+	for objective := range multiObjectiveConfig.Objectives {
+		archive.objectives = append(archive.objectives, objective)
+	}
+	sort.Strings(archive.objectives)
+	for _, objective := range archive.objectives {
+		weight := multiObjectiveConfig.Objectives[objective]
+		archive.weights = append(archive.weights, weight)
+		archive.totalWeight += weight
+	}
+	return archive
+}
+
+// Objectives returns the fitness metrics used as objectives, in the order objective values are provided to Add.
+func (a *ParetoArchive) Objectives() []string {
+	return a.objectives
+}
+
+// Size returns the amount of call sequences in the archive.
+func (a *ParetoArchive) Size() int {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return len(a.entries)
+}
+
+// atLeastAsGood indicates whether the provided objective values are at least as good as the other provided ones for
+// every objective.
+func atLeastAsGood(objectives []float64, otherObjectives []float64) bool {
+	for i := range objectives {
+		if objectives[i] < otherObjectives[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// dominates indicates whether the provided objective values dominate the other provided ones: they are at least as
+// good for every objective, and better for at least one.
+func dominates(objectives []float64, otherObjectives []float64) bool {
+	return atLeastAsGood(objectives, otherObjectives) && !atLeastAsGood(otherObjectives, objectives)
+}
+
+// Add adds the provided call sequence with the provided objective values to the archive, unless an archived call
+// sequence dominates or equals it. Archived call sequences it dominates are removed.
+// Returns a boolean indicating whether the call sequence was added.
+func (a *ParetoArchive) Add(sequence calls.CallSequence, objectives []float64) bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	// Reject the call sequence if an archived one is at least as good for every objective.
+	for _, entry := range a.entries {
+		if atLeastAsGood(entry.objectives, objectives) {
+			return false
+		}
+	}
+
+	// Remove the archived call sequences the new one dominates.
+	entries := a.entries[:0]
+	for _, entry := range a.entries {
+		if !dominates(objectives, entry.objectives) {
+			entries = append(entries, entry)
+		}
+	}
+	a.entries = append(entries, &paretoArchiveEntry{sequence: sequence, objectives: objectives})
+	return true
+}
+
+// Choose decides whether a mutation target should be sampled from the archive, according to the configured
+// probability, and samples one if so. An objective is chosen according to its weight, then one of the call sequences
+// best for it is chosen at random, using the provided random provider.
+// Returns the call sequence sampled, or nil if none was.
+func (a *ParetoArchive) Choose(randomProvider *rand.Rand) calls.CallSequence {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if len(a.entries) == 0 || a.totalWeight == 0 || randomProvider.Float64() >= a.probability {
+		return nil
+	}
+
+	// Choose an objective according to its weight.
+	objective := 0
+	selected := uint64(randomProvider.Int63n(int64(a.totalWeight)))
+	for i, weight := range a.weights {
+		if selected < weight {
+			objective = i
+			break
+		}
+		selected -= weight
+	}
+
+	// Choose one of the call sequences best for it.
+	best := make([]*paretoArchiveEntry, 0)
+	for _, entry := range a.entries {
+		if len(best) == 0 || entry.objectives[objective] > best[0].objectives[objective] {
+			best = append(best[:0], entry)
+		} else if entry.objectives[objective] == best[0].objectives[objective] {
+			best = append(best, entry)
+		}
+	}
+	return best[randomProvider.Intn(len(best))].sequence
+}
+
+// sequenceObjectives returns the objective values of a call sequence whose last call produced the provided message
+// results, in the order of the provided objectives. Distances are negated, so higher values are better for every
+// objective.
+func sequenceObjectives(objectives []string, lastMessageResult *types.MessageResults, fuzzingConfig *config.FuzzingConfig) []float64 {
+	values := make([]float64, len(objectives))
+	for i, objective := range objectives {
+		switch objective {
+		case config.CodeCoverageObjective:
+			if maps := codecoverage.GetCoverageTracerResults(lastMessageResult); maps != nil {
+				covered, _ := maps.TotalCodeCoverage(nil)
+				values[i] = float64(covered)
+			}
+		case config.BranchCoverageObjective:
+			if maps := branchcoverage.GetCoverageTracerResults(lastMessageResult); maps != nil {
+				covered, _ := maps.TotalBranchCoverage(nil)
+				values[i] = float64(covered)
+			}
+		case config.CmpDistanceObjective:
+			if maps := cmpdistance.GetCmpDistanceTracerResults(lastMessageResult); maps != nil {
+				values[i] = float64(maps.TotalCoveredCmpNum(false, nil))
+			}
+		case config.BranchDistanceObjective:
+			if maps := branchdistance.GetBranchDistanceTracerResults(lastMessageResult); maps != nil {
+				values[i] = -maps.Fitness(fuzzingConfig.BranchDistance.Normalization, fuzzingConfig.BranchDistance.Aggregation)
+			}
+		case config.DataflowObjective:
+			if set := dataflow.GetDataflowTracerResults(lastMessageResult); set != nil {
+				values[i] = float64(set.TotalDataflowCount(false))
+			}
+		case config.StorageWriteObjective:
+			if set := storagewrite.GetStorageWriteTracerResults(lastMessageResult); set != nil {
+				// If slot diversity is rewarded, so are sequences writing slots values in many distinct buckets.
+				if fuzzingConfig.StorageWrite.SlotDiversity {
+					values[i] = float64(set.TotalSlotBucketCount())
+				} else {
+					values[i] = float64(set.TotalStorageWriteCount(false))
+				}
+			}
+		case config.TokenflowObjective:
+			if set := tokenflow.GetTokenflowTracerResults(lastMessageResult); set != nil {
+				values[i] = float64(set.TotalTokenflowCount(false))
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,84 @@
+package corpus
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	chainTypes "github.com/crytic/medusa/chain/types"
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/storagewrite"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParetoArchiveAdd tests that the Pareto archive only keeps call sequences not dominated by any other.
+func TestParetoArchiveAdd(t *testing.T) {
+	archive := NewParetoArchive(config.MultiObjectiveConfig{
+		Enabled:            true,
+		Objectives:         map[string]uint64{config.CodeCoverageObjective: 1, config.BranchDistanceObjective: 1},
+		ArchiveProbability: 1,
+	})
+	assert.EqualValues(t, []string{config.BranchDistanceObjective, config.CodeCoverageObjective}, archive.Objectives())
+	randomProvider := rand.New(rand.NewSource(0))
+
+	// Call sequences which trade one objective for the other are both kept.
+	assert.True(t, archive.Add(getMockCallSequence(1), []float64{-2, 10}))
+	assert.True(t, archive.Add(getMockCallSequence(1), []float64{-1, 5}))
+	assert.EqualValues(t, 2, archive.Size())
+
+	// Call sequences dominated by, or equal to, an archived one are rejected.
+	assert.False(t, archive.Add(getMockCallSequence(1), []float64{-3, 10}))
+	assert.False(t, archive.Add(getMockCallSequence(1), []float64{-1, 5}))
+	assert.EqualValues(t, 2, archive.Size())
+
+	// A call sequence dominating every archived one replaces them.
+	dominating := getMockCallSequence(1)
+	assert.True(t, archive.Add(dominating, []float64{0, 10}))
+	assert.EqualValues(t, 1, archive.Size())
+	assert.Equal(t, dominating, archive.Choose(randomProvider))
+}
+
+// TestParetoArchiveChoose tests that the Pareto archive samples the call sequences best for the chosen objective.
+func TestParetoArchiveChoose(t *testing.T) {
+	archive := NewParetoArchive(config.MultiObjectiveConfig{
+		Enabled:            true,
+		Objectives:         map[string]uint64{config.CodeCoverageObjective: 1, config.BranchDistanceObjective: 0},
+		ArchiveProbability: 1,
+	})
+	randomProvider := rand.New(rand.NewSource(0))
+
+	// Nothing is sampled from an empty archive.
+	assert.Nil(t, archive.Choose(randomProvider))
+
+	// Only the code coverage objective has weight, so the call sequence best for it is always sampled.
+	best := getMockCallSequence(1)
+	archive.Add(getMockCallSequence(1), []float64{0, 5})
+	archive.Add(best, []float64{-10, 10})
+	for i := 0; i < 16; i++ {
+		assert.Equal(t, best, archive.Choose(randomProvider))
+	}
+}
+
+// TestSequenceObjectivesStorageWrite tests that the storage write objective of a call sequence counts its storage
+// writes, or the distinct (slot, value bucket) pairs it writes if slot diversity is rewarded.
+func TestSequenceObjectivesStorageWrite(t *testing.T) {
+	bucketer, err := storagewrite.NewValueBucketer(config.StorageWriteConfig{})
+	assert.NoError(t, err)
+
+	// The same value bucket written to a slot from two positions, and another bucket written from one of them.
+	storageWriteSet := storagewrite.NewStorageWriteSet()
+	for _, write := range []struct {
+		pc    uint64
+		value uint64
+	}{{pc: 1, value: 1}, {pc: 2, value: 2}, {pc: 2, value: 1 << 32}} {
+		_, err = storageWriteSet.SetWrite(common.Address{1}, uint256.NewInt(0), uint256.NewInt(write.value), common.Address{1}, false, write.pc, bucketer, false)
+		assert.NoError(t, err)
+	}
+	messageResults := &chainTypes.MessageResults{AdditionalResults: map[string]any{"StorageWriteTracerResults": storageWriteSet}}
+
+	fuzzingConfig := &config.FuzzingConfig{}
+	assert.EqualValues(t, []float64{3}, sequenceObjectives([]string{config.StorageWriteObjective}, messageResults, fuzzingConfig))
+	fuzzingConfig.StorageWrite.SlotDiversity = true
+	assert.EqualValues(t, []float64{2}, sequenceObjectives([]string{config.StorageWriteObjective}, messageResults, fuzzingConfig))
+}
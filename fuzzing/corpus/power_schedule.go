@@ -0,0 +1,120 @@
+package corpus
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/crytic/medusa/utils/randomutils"
+)
+
+// MutationTarget describes a corpus call sequence chosen as a mutation target, identifying it so it can be rewarded by
+// the adaptive power schedule if call sequences derived from it prove productive.
+type MutationTarget = randomutils.WeightedRandomChoice[calls.CallSequence]
+
+// seedEnergy describes the mutation energy the adaptive power schedule assigned to a corpus call sequence.
+type seedEnergy struct {
+	// baseWeight describes the mutation weight the call sequence was added to the corpus with.
+	baseWeight *big.Int
+	// exponent describes the amount of times the base weight is doubled.
+	exponent uint
+}
+
+// PowerSchedule implements an AFL-style adaptive power schedule over the mutation weights of corpus call sequences.
+// Each time a call sequence derived from a corpus call sequence is added to the corpus, the mutation weight of the
+// corpus call sequence is doubled, up to a limit. Every decay interval, the boost of every call sequence is halved.
+type PowerSchedule struct {
+	// config describes the configuration of the adaptive power schedule.
+	config config.AdaptivePowerScheduleConfig
+
+	// chooser describes the weighted chooser whose mutation weights are scheduled.
+	chooser *randomutils.WeightedRandomChooser[calls.CallSequence]
+
+	// energies describes the energy of each corpus call sequence whose mutation weight is boosted.
+	energies map[*MutationTarget]*seedEnergy
+
+	// lastDecay describes the time the boosts were last halved.
+	lastDecay time.Time
+
+	// lock offers concurrent thread safety for energy accesses.
+	lock sync.Mutex
+}
+
+// NewPowerSchedule creates a new PowerSchedule with the provided configuration, scheduling the mutation weights of the
+// provided chooser.
+func NewPowerSchedule(powerScheduleConfig config.AdaptivePowerScheduleConfig, chooser *randomutils.WeightedRandomChooser[calls.CallSequence]) *PowerSchedule {
+	return &PowerSchedule{
+		config:    powerScheduleConfig,
+		chooser:   chooser,
+		energies:  make(map[*MutationTarget]*seedEnergy),
+		lastDecay: time.Now(),
+	}
+}
+
+// setExponent sets the exponent of the provided energy, updating the mutation weight of its call sequence.
+func (s *PowerSchedule) setExponent(target *MutationTarget, energy *seedEnergy, exponent uint) {
+	energy.exponent = exponent
+	s.chooser.SetWeight(target, new(big.Int).Lsh(energy.baseWeight, exponent))
+}
+
+// Reward doubles the mutation weight of each of the provided corpus call sequences, up to the configured limit, as a
+// call sequence derived from them proved productive.
+func (s *PowerSchedule) Reward(targets []*MutationTarget) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, target := range targets {
+		energy, exists := s.energies[target]
+		if !exists {
+			energy = &seedEnergy{baseWeight: target.Weight()}
+			s.energies[target] = energy
+		}
+		if energy.exponent < s.config.MaxExponent {
+			s.setExponent(target, energy, energy.exponent+1)
+		}
+	}
+}
+
+// Decay halves the boost of every corpus call sequence for each decay interval elapsed since it was last halved.
+func (s *PowerSchedule) Decay() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	interval := time.Duration(s.config.DecayInterval) * time.Second
+	for time.Since(s.lastDecay) >= interval {
+		s.lastDecay = s.lastDecay.Add(interval)
+		for target, energy := range s.energies {
+			s.setExponent(target, energy, energy.exponent-1)
+			if energy.exponent == 0 {
+				delete(s.energies, target)
+			}
+		}
+	}
+}
+
+// Forget stops scheduling the mutation weight of the provided corpus call sequence, e.g. as it was removed from the
+// corpus.
+func (s *PowerSchedule) Forget(target *MutationTarget) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.energies, target)
+}
+
+// Energy returns the factor the mutation weight of the provided corpus call sequence is currently multiplied by.
+func (s *PowerSchedule) Energy(target *MutationTarget) uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if energy, exists := s.energies[target]; exists {
+		return 1 << energy.exponent
+	}
+	return 1
+}
+
+// BoostedCount returns the amount of corpus call sequences whose mutation weight is currently boosted.
+func (s *PowerSchedule) BoostedCount() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.energies)
+}
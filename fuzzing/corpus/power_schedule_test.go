@@ -0,0 +1,45 @@
+package corpus
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/crytic/medusa/utils/randomutils"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPowerScheduleRewardAndDecay tests that the adaptive power schedule doubles the mutation weight of rewarded call
+// sequences up to its limit, and halves it back once per decay interval.
+func TestPowerScheduleRewardAndDecay(t *testing.T) {
+	chooser := randomutils.NewWeightedRandomChooser[calls.CallSequence]()
+	target := randomutils.NewWeightedRandomChoice(getMockCallSequence(1), big.NewInt(3))
+	other := randomutils.NewWeightedRandomChoice(getMockCallSequence(1), big.NewInt(5))
+	chooser.AddChoices(target, other)
+
+	schedule := NewPowerSchedule(config.AdaptivePowerScheduleConfig{Enabled: true, MaxExponent: 2, DecayInterval: 60}, chooser)
+	assert.EqualValues(t, 1, schedule.Energy(target))
+
+	// Rewards double the weight, up to the maximum exponent.
+	for i := 0; i < 3; i++ {
+		schedule.Reward([]*MutationTarget{target})
+	}
+	assert.EqualValues(t, 4, schedule.Energy(target))
+	assert.EqualValues(t, 12, target.Weight().Uint64())
+	assert.EqualValues(t, 5, other.Weight().Uint64())
+	assert.EqualValues(t, 1, schedule.BoostedCount())
+
+	// Each elapsed decay interval halves the boost, until the base weight is restored.
+	schedule.lastDecay = time.Now().Add(-61 * time.Second)
+	schedule.Decay()
+	assert.EqualValues(t, 2, schedule.Energy(target))
+	assert.EqualValues(t, 6, target.Weight().Uint64())
+
+	schedule.lastDecay = time.Now().Add(-121 * time.Second)
+	schedule.Decay()
+	assert.EqualValues(t, 1, schedule.Energy(target))
+	assert.EqualValues(t, 3, target.Weight().Uint64())
+	assert.EqualValues(t, 0, schedule.BoostedCount())
+}
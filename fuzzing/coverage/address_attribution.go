@@ -0,0 +1,90 @@
+package coverage
+
+import (
+	"encoding/binary"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/crypto"
+	"github.com/crytic/medusa/fuzzing/config"
+)
+
+// pseudoAddressEntry describes a pseudo-address allocated to a dynamically created contract.
+type pseudoAddressEntry struct {
+	// pseudoAddress is the address coverage of the contract is attributed to.
+	pseudoAddress common.Address
+	// lastUsed is the attribution clock value at which the entry was last used, for eviction purposes.
+	lastUsed uint64
+}
+
+// addressAttributor attributes the addresses of executing contracts to the address their coverage is recorded under.
+// Contracts present in the base chain keep their address. Depending on the configured mode, contracts created during
+// call sequences are either merged into BLANK_ADDRESS, or each attributed a stable pseudo-address derived from its
+// creator and the creator's nonce, so that coverage of distinct instances is not merged while still being consistent
+// across call sequences and workers.
+type addressAttributor struct {
+	// initialContractsSet records the set of contract addresses present in the base chain. If nil, all addresses are
+	// preserved.
+	initialContractsSet *map[common.Address]struct{}
+
+	// config describes how the addresses of contracts created during call sequences are attributed.
+	config config.AddressAttributionConfig
+
+	// pseudoAddresses maps the addresses of created contracts to their allocated pseudo-address. At most
+	// config.MaxPseudoAddresses entries are retained, the least recently used entry is evicted first.
+	pseudoAddresses map[common.Address]*pseudoAddressEntry
+
+	// clock is incremented on every pseudo-address use, to order entries for eviction.
+	clock uint64
+}
+
+// attribute returns the address coverage for the provided contract address should be recorded under.
+func (a *addressAttributor) attribute(address common.Address) common.Address {
+	if a.initialContractsSet == nil {
+		return address
+	} else if _, ok := (*a.initialContractsSet)[address]; ok {
+		return address
+	}
+
+	// Created contracts with an allocated pseudo-address are attributed to it, all others fall back to the blank
+	// address.
+	if entry, ok := a.pseudoAddresses[address]; ok {
+		a.clock++
+		entry.lastUsed = a.clock
+		return entry.pseudoAddress
+	}
+	return BLANK_ADDRESS
+}
+
+// recordCreation allocates a pseudo-address for a contract created by the provided creator at the given nonce, if the
+// pseudo-address mode is enabled. The pseudo-address is derived from the attributed address of the creator, so that
+// contracts created by other created contracts are attributed stably as well.
+func (a *addressAttributor) recordCreation(createdAddress common.Address, creator common.Address, creatorNonce uint64) {
+	if a.config.Mode != config.AddressAttributionModePseudo || a.initialContractsSet == nil || a.config.MaxPseudoAddresses <= 0 {
+		return
+	}
+	if _, ok := a.pseudoAddresses[createdAddress]; ok {
+		return
+	}
+
+	// Evict the least recently used pseudo-address if we are at capacity.
+	if len(a.pseudoAddresses) >= a.config.MaxPseudoAddresses {
+		var evictedAddress common.Address
+		var evictedEntry *pseudoAddressEntry
+		for address, entry := range a.pseudoAddresses {
+			if evictedEntry == nil || entry.lastUsed < evictedEntry.lastUsed {
+				evictedAddress, evictedEntry = address, entry
+			}
+		}
+		delete(a.pseudoAddresses, evictedAddress)
+	}
+
+	// Derive our pseudo-address from the hash of the attributed creator and its nonce.
+	var nonceBytes [8]byte
+	binary.BigEndian.PutUint64(nonceBytes[:], creatorNonce)
+	attributedCreator := a.attribute(creator)
+	a.clock++
+	a.pseudoAddresses[createdAddress] = &pseudoAddressEntry{
+		pseudoAddress: common.BytesToAddress(crypto.Keccak256(attributedCreator.Bytes(), nonceBytes[:])),
+		lastUsed:      a.clock,
+	}
+}
@@ -0,0 +1,95 @@
+package coverage
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/crypto"
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestAddressAttributor returns an addressAttributor whose base chain only contains the provided address, using
+// the provided attribution mode and maximum amount of pseudo-addresses.
+func newTestAddressAttributor(baseAddress common.Address, mode string, maxPseudoAddresses int) *addressAttributor {
+	initialContractsSet := map[common.Address]struct{}{baseAddress: {}}
+	return &addressAttributor{
+		initialContractsSet: &initialContractsSet,
+		config:              config.AddressAttributionConfig{Mode: mode, MaxPseudoAddresses: maxPseudoAddresses},
+		pseudoAddresses:     make(map[common.Address]*pseudoAddressEntry),
+	}
+}
+
+// getTestPseudoAddress returns the pseudo-address expected for a contract created by the provided attributed creator
+// at the given nonce.
+func getTestPseudoAddress(attributedCreator common.Address, creatorNonce uint64) common.Address {
+	var nonceBytes [8]byte
+	binary.BigEndian.PutUint64(nonceBytes[:], creatorNonce)
+	return common.BytesToAddress(crypto.Keccak256(attributedCreator.Bytes(), nonceBytes[:]))
+}
+
+// TestAddressAttributorCreatedContracts tests that contracts present in the base chain keep their address, while
+// created contracts are attributed a pseudo-address derived from their attributed creator and its nonce, or merged
+// into the blank address if pseudo-addresses are disabled.
+func TestAddressAttributorCreatedContracts(t *testing.T) {
+	baseAddress := common.HexToAddress("0x1000")
+	createdAddress := common.HexToAddress("0x2000")
+	nestedAddress := common.HexToAddress("0x3000")
+
+	// Without a base chain, all addresses are preserved.
+	attributor := &addressAttributor{}
+	assert.Equal(t, createdAddress, attributor.attribute(createdAddress))
+
+	// In blank mode, created contracts are merged into the blank address.
+	attributor = newTestAddressAttributor(baseAddress, config.AddressAttributionModeBlank, 4)
+	attributor.recordCreation(createdAddress, baseAddress, 1)
+	assert.Equal(t, baseAddress, attributor.attribute(baseAddress))
+	assert.Equal(t, BLANK_ADDRESS, attributor.attribute(createdAddress))
+
+	// In pseudo mode, created contracts are attributed to their pseudo-address, including those created by other
+	// created contracts, which derive theirs from the pseudo-address of their creator.
+	attributor = newTestAddressAttributor(baseAddress, config.AddressAttributionModePseudo, 4)
+	attributor.recordCreation(createdAddress, baseAddress, 1)
+	createdPseudoAddress := getTestPseudoAddress(baseAddress, 1)
+	attributor.recordCreation(nestedAddress, createdAddress, 1)
+	assert.Equal(t, baseAddress, attributor.attribute(baseAddress))
+	assert.Equal(t, createdPseudoAddress, attributor.attribute(createdAddress))
+	assert.Equal(t, getTestPseudoAddress(createdPseudoAddress, 1), attributor.attribute(nestedAddress))
+
+	// Recording a creation again keeps the allocated pseudo-address, and unknown addresses fall back to the blank
+	// address.
+	attributor.recordCreation(createdAddress, baseAddress, 2)
+	assert.Equal(t, createdPseudoAddress, attributor.attribute(createdAddress))
+	assert.Equal(t, BLANK_ADDRESS, attributor.attribute(common.HexToAddress("0x4000")))
+}
+
+// TestAddressAttributorEviction tests that once the maximum amount of pseudo-addresses is reached, the least recently
+// used pseudo-address is evicted, with attributing an address counting as a use.
+func TestAddressAttributorEviction(t *testing.T) {
+	baseAddress := common.HexToAddress("0x1000")
+	createdAddresses := []common.Address{
+		common.HexToAddress("0x2000"),
+		common.HexToAddress("0x2001"),
+		common.HexToAddress("0x2002"),
+		common.HexToAddress("0x2003"),
+	}
+	attributor := newTestAddressAttributor(baseAddress, config.AddressAttributionModePseudo, 2)
+	attributor.recordCreation(createdAddresses[0], baseAddress, 0)
+	attributor.recordCreation(createdAddresses[1], baseAddress, 1)
+
+	// Using the first address makes the second the least recently used, so it is evicted first.
+	assert.Equal(t, getTestPseudoAddress(baseAddress, 0), attributor.attribute(createdAddresses[0]))
+	attributor.recordCreation(createdAddresses[2], baseAddress, 2)
+	assert.Len(t, attributor.pseudoAddresses, 2)
+	assert.Equal(t, BLANK_ADDRESS, attributor.attribute(createdAddresses[1]))
+	assert.Equal(t, getTestPseudoAddress(baseAddress, 0), attributor.attribute(createdAddresses[0]))
+	assert.Equal(t, getTestPseudoAddress(baseAddress, 2), attributor.attribute(createdAddresses[2]))
+
+	// The first address was used before the third, so it is evicted next.
+	attributor.recordCreation(createdAddresses[3], baseAddress, 3)
+	assert.Len(t, attributor.pseudoAddresses, 2)
+	assert.Equal(t, BLANK_ADDRESS, attributor.attribute(createdAddresses[0]))
+	assert.Equal(t, getTestPseudoAddress(baseAddress, 2), attributor.attribute(createdAddresses[2]))
+	assert.Equal(t, getTestPseudoAddress(baseAddress, 3), attributor.attribute(createdAddresses[3]))
+}
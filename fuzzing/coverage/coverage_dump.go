@@ -0,0 +1,350 @@
+package coverage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa/compilation/types"
+	"github.com/crytic/medusa/logging"
+	"github.com/crytic/medusa/utils"
+)
+
+// coverageDumpFileName describes the name of the file a CoverageDump is written to within a coverage report directory.
+const coverageDumpFileName = "coverage_dump.json"
+
+// CoverageDumpSourceLocation describes the source code line a covered instruction maps to.
+type CoverageDumpSourceLocation struct {
+	// File is the path of the source file.
+	File string `json:"file"`
+	// Line is the one-based line number within the source file.
+	Line int `json:"line"`
+}
+
+// String returns a human-readable representation of the source location.
+func (l CoverageDumpSourceLocation) String() string {
+	return fmt.Sprintf("%s:%d", l.File, l.Line)
+}
+
+// InstructionCoverageDumpEntry describes an instruction which was covered.
+type InstructionCoverageDumpEntry struct {
+	// Pc is the program counter of the instruction.
+	Pc uint64 `json:"pc"`
+	// Source is the source code location the instruction maps to, or nil if it does not map to source code.
+	Source *CoverageDumpSourceLocation `json:"source,omitempty"`
+}
+
+// BranchCoverageDumpEntry describes a branch arm (the outcome of a JUMPI) which was covered.
+type BranchCoverageDumpEntry struct {
+	// Pc is the program counter of the JUMPI the branch arm belongs to.
+	Pc uint64 `json:"pc"`
+	// Jumped describes whether the branch arm is the jumping path of the JUMPI, rather than the fall through one.
+	Jumped bool `json:"jumped"`
+	// Source is the source code location the JUMPI maps to, or nil if it does not map to source code.
+	Source *CoverageDumpSourceLocation `json:"source,omitempty"`
+}
+
+// ContractCoverageDump describes the instructions and branch arms covered in the init or runtime bytecode of a
+// contract, across all addresses it was deployed at.
+type ContractCoverageDump struct {
+	// Contract is the name of the contract.
+	Contract string `json:"contract"`
+	// Init describes whether the coverage is of the init bytecode of the contract, rather than its runtime bytecode.
+	Init bool `json:"init"`
+	// Instructions describes the covered instructions, sorted by program counter.
+	Instructions []InstructionCoverageDumpEntry `json:"instructions"`
+	// Branches describes the covered branch arms, sorted by program counter.
+	Branches []BranchCoverageDumpEntry `json:"branches"`
+}
+
+// key returns a string uniquely identifying the contract bytecode the dump describes.
+func (d ContractCoverageDump) key() string {
+	if d.Init {
+		return d.Contract + " (init)"
+	}
+	return d.Contract
+}
+
+// CoverageDump describes the coverage achieved by a fuzzing campaign in a form which can be compared across campaigns,
+// even if contracts were recompiled in between.
+type CoverageDump struct {
+	// Contracts describes the coverage achieved for each contract, sorted by name.
+	Contracts []ContractCoverageDump `json:"contracts"`
+}
+
+// GenerateCoverageDump takes a list of compilations and coverage maps, and describes the instructions and branch arms
+// covered for each contract as a CoverageDump.
+// Returns the CoverageDump, or an error if one occurs.
+func GenerateCoverageDump(compilations []types.Compilation, coverageMaps *CoverageMaps, logger *logging.Logger) (*CoverageDump, error) {
+	dump := &CoverageDump{
+		Contracts: make([]ContractCoverageDump, 0),
+	}
+	for _, compilation := range compilations {
+		// Cache the line offsets of each source file, to resolve the lines instructions map to.
+		lineOffsetsBySource := make(map[string][]int)
+		for sourcePath, sourceCode := range compilation.SourceCode {
+			_, lineOffsetsBySource[sourcePath] = parseSourceLines(sourceCode)
+		}
+
+		for _, source := range compilation.SourcePathToArtifact {
+			for contractName, contract := range source.Contracts {
+				// Skip interfaces.
+				if contract.Kind == types.ContractKindInterface {
+					continue
+				}
+
+				// Dump coverage of both init and runtime bytecode.
+				for _, init := range []bool{true, false} {
+					bytecode, srcMap := contract.RuntimeBytecode, contract.SrcMapsRuntime
+					if init {
+						bytecode, srcMap = contract.InitBytecode, contract.SrcMapsInit
+					}
+					contractCoverageMap, err := coverageMaps.GetContractCoverageMap(bytecode, init)
+					if err != nil {
+						return nil, fmt.Errorf("could not generate coverage dump due to error fetching coverage map data: %v", err)
+					}
+					if len(bytecode) == 0 || contractCoverageMap == nil {
+						continue
+					}
+					sourceMap, err := types.ParseSourceMap(srcMap)
+					if err != nil {
+						return nil, fmt.Errorf("could not generate coverage dump due to error fetching source map: %v", err)
+					}
+
+					contractDump := getContractCoverageDump(compilation, lineOffsetsBySource, sourceMap, bytecode, contractCoverageMap, logger)
+					contractDump.Contract = contractName
+					contractDump.Init = init
+					dump.Contracts = append(dump.Contracts, contractDump)
+				}
+			}
+		}
+	}
+
+	sort.Slice(dump.Contracts, func(i, j int) bool {
+		return dump.Contracts[i].key() < dump.Contracts[j].key()
+	})
+	return dump, nil
+}
+
+// getContractCoverageDump describes the instructions and branch arms covered in the provided bytecode, resolving the
+// source lines they map to using the provided source map and line offsets of each source file.
+func getContractCoverageDump(compilation types.Compilation, lineOffsetsBySource map[string][]int, sourceMap types.SourceMap, bytecode []byte, contractCoverageData *ContractCoverageMap, logger *logging.Logger) ContractCoverageDump {
+	contractDump := ContractCoverageDump{
+		Instructions: make([]InstructionCoverageDumpEntry, 0),
+		Branches:     make([]BranchCoverageDumpEntry, 0),
+	}
+
+	// Resolve the source line of each instruction by its index.
+	indexToOffset := GetInstructionIndexToOffsetLookup(bytecode)
+	offsetToIndex := make(map[uint64]int, len(indexToOffset))
+	for idx, pc := range indexToOffset {
+		offsetToIndex[uint64(pc)] = idx
+	}
+	getSourceLocation := func(idx int) *CoverageDumpSourceLocation {
+		if idx >= len(sourceMap) || sourceMap[idx].SourceUnitID == -1 {
+			return nil
+		}
+		sourcePath, ok := compilation.SourceIdToPath[sourceMap[idx].SourceUnitID]
+		if !ok {
+			return nil
+		}
+		lineOffsets, ok := lineOffsetsBySource[sourcePath]
+		if !ok {
+			return nil
+		}
+		line := sort.Search(len(lineOffsets), func(i int) bool {
+			return lineOffsets[i] > sourceMap[idx].Offset
+		})
+		return &CoverageDumpSourceLocation{File: sourcePath, Line: line}
+	}
+
+	// Record each instruction which was hit, whether it reverted or not.
+	succHitCounts, revertHitCounts := determineLinesCovered(contractCoverageData, bytecode, logger)
+	for idx, pc := range indexToOffset {
+		if succHitCounts[idx] > 0 || revertHitCounts[idx] > 0 {
+			contractDump.Instructions = append(contractDump.Instructions, InstructionCoverageDumpEntry{
+				Pc:     uint64(pc),
+				Source: getSourceLocation(idx),
+			})
+		}
+	}
+
+	// Record each branch arm taken, from the markers of jumps leaving a JUMPI. Revert and return markers have
+	// destinations outside the bytecode, so they are skipped.
+	takenArms := make(map[uint64]map[bool]struct{})
+	for marker := range contractCoverageData.executedMarkers {
+		src, dst := marker>>32, marker&0xFFFFFFFF
+		if src >= uint64(len(bytecode)) || dst >= uint64(len(bytecode)) || vm.OpCode(bytecode[src]) != vm.JUMPI {
+			continue
+		}
+		jumped := dst != src+1
+		if _, ok := takenArms[src][jumped]; ok {
+			continue
+		}
+		if _, ok := takenArms[src]; !ok {
+			takenArms[src] = make(map[bool]struct{})
+		}
+		takenArms[src][jumped] = struct{}{}
+		contractDump.Branches = append(contractDump.Branches, BranchCoverageDumpEntry{
+			Pc:     src,
+			Jumped: jumped,
+			Source: getSourceLocation(offsetToIndex[src]),
+		})
+	}
+	sort.Slice(contractDump.Branches, func(i, j int) bool {
+		if contractDump.Branches[i].Pc != contractDump.Branches[j].Pc {
+			return contractDump.Branches[i].Pc < contractDump.Branches[j].Pc
+		}
+		return !contractDump.Branches[i].Jumped && contractDump.Branches[j].Jumped
+	})
+	return contractDump
+}
+
+// WriteCoverageDump writes the provided CoverageDump to a JSON file in the provided directory.
+// Returns the path of the dump file, or an error if one occurred.
+func WriteCoverageDump(dump *CoverageDump, reportDir string) (string, error) {
+	// If the directory doesn't exist, create it.
+	err := utils.MakeDirectory(reportDir)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := json.MarshalIndent(dump, "", "\t")
+	if err != nil {
+		return "", fmt.Errorf("could not export coverage dump: %v", err)
+	}
+	dumpPath := filepath.Join(reportDir, coverageDumpFileName)
+	err = os.WriteFile(dumpPath, b, 0644)
+	if err != nil {
+		return "", fmt.Errorf("could not export coverage dump: %v", err)
+	}
+	return dumpPath, nil
+}
+
+// ReadCoverageDump reads a CoverageDump from the provided path. If the path is a directory, the dump written to it by
+// WriteCoverageDump is read.
+// Returns the dump, or an error if one occurred.
+func ReadCoverageDump(path string) (*CoverageDump, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read coverage dump at %v: %v", path, err)
+	}
+	if info.IsDir() {
+		path = filepath.Join(path, coverageDumpFileName)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read coverage dump at %v: %v", path, err)
+	}
+	var dump CoverageDump
+	err = json.Unmarshal(b, &dump)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse coverage dump at %v: %v", path, err)
+	}
+	return &dump, nil
+}
+
+// ContractCoverageDiff describes the difference in instructions and branch arms covered for a contract between two
+// coverage dumps.
+type ContractCoverageDiff struct {
+	// Contract is the name of the contract.
+	Contract string `json:"contract"`
+	// Init describes whether the coverage is of the init bytecode of the contract, rather than its runtime bytecode.
+	Init bool `json:"init"`
+	// AddedInstructions describes the instructions only covered in the new dump.
+	AddedInstructions []InstructionCoverageDumpEntry `json:"addedInstructions"`
+	// RemovedInstructions describes the instructions only covered in the base dump.
+	RemovedInstructions []InstructionCoverageDumpEntry `json:"removedInstructions"`
+	// AddedBranches describes the branch arms only covered in the new dump.
+	AddedBranches []BranchCoverageDumpEntry `json:"addedBranches"`
+	// RemovedBranches describes the branch arms only covered in the base dump.
+	RemovedBranches []BranchCoverageDumpEntry `json:"removedBranches"`
+}
+
+// Empty indicates whether the contract coverage is the same in both dumps.
+func (d ContractCoverageDiff) Empty() bool {
+	return len(d.AddedInstructions) == 0 && len(d.RemovedInstructions) == 0 && len(d.AddedBranches) == 0 && len(d.RemovedBranches) == 0
+}
+
+// DiffCoverageDumps compares the coverage of a base dump with that of a new dump.
+// Returns the difference for each contract whose coverage changed, sorted by name.
+func DiffCoverageDumps(base *CoverageDump, new *CoverageDump) []ContractCoverageDiff {
+	baseContracts := make(map[string]ContractCoverageDump)
+	for _, contractDump := range base.Contracts {
+		baseContracts[contractDump.key()] = contractDump
+	}
+	newContracts := make(map[string]ContractCoverageDump)
+	for _, contractDump := range new.Contracts {
+		newContracts[contractDump.key()] = contractDump
+	}
+
+	// Contracts missing from either dump are compared against empty coverage.
+	keys := make([]string, 0)
+	for key := range baseContracts {
+		keys = append(keys, key)
+	}
+	for key := range newContracts {
+		if _, ok := baseContracts[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	diffs := make([]ContractCoverageDiff, 0)
+	for _, key := range keys {
+		baseContract, newContract := baseContracts[key], newContracts[key]
+		diff := ContractCoverageDiff{
+			Contract:            baseContract.Contract,
+			Init:                baseContract.Init,
+			AddedInstructions:   subtractInstructions(newContract.Instructions, baseContract.Instructions),
+			RemovedInstructions: subtractInstructions(baseContract.Instructions, newContract.Instructions),
+			AddedBranches:       subtractBranches(newContract.Branches, baseContract.Branches),
+			RemovedBranches:     subtractBranches(baseContract.Branches, newContract.Branches),
+		}
+		if _, ok := baseContracts[key]; !ok {
+			diff.Contract, diff.Init = newContract.Contract, newContract.Init
+		}
+		if !diff.Empty() {
+			diffs = append(diffs, diff)
+		}
+	}
+	return diffs
+}
+
+// subtractInstructions returns the instructions of a which are not in b, by program counter.
+func subtractInstructions(a []InstructionCoverageDumpEntry, b []InstructionCoverageDumpEntry) []InstructionCoverageDumpEntry {
+	bPcs := make(map[uint64]struct{}, len(b))
+	for _, instruction := range b {
+		bPcs[instruction.Pc] = struct{}{}
+	}
+	difference := make([]InstructionCoverageDumpEntry, 0)
+	for _, instruction := range a {
+		if _, ok := bPcs[instruction.Pc]; !ok {
+			difference = append(difference, instruction)
+		}
+	}
+	return difference
+}
+
+// subtractBranches returns the branch arms of a which are not in b, by program counter and direction.
+func subtractBranches(a []BranchCoverageDumpEntry, b []BranchCoverageDumpEntry) []BranchCoverageDumpEntry {
+	type branchArm struct {
+		pc     uint64
+		jumped bool
+	}
+	bArms := make(map[branchArm]struct{}, len(b))
+	for _, branch := range b {
+		bArms[branchArm{branch.Pc, branch.Jumped}] = struct{}{}
+	}
+	difference := make([]BranchCoverageDumpEntry, 0)
+	for _, branch := range a {
+		if _, ok := bArms[branchArm{branch.Pc, branch.Jumped}]; !ok {
+			difference = append(difference, branch)
+		}
+	}
+	return difference
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/crytic/medusa-geth/eth/tracers"
 	"github.com/crytic/medusa/chain"
 	"github.com/crytic/medusa/chain/types"
+	"github.com/crytic/medusa/fuzzing/config"
 	"github.com/crytic/medusa/logging"
 )
 
@@ -61,10 +62,11 @@ type CoverageTracer struct {
 	// The Hash key is a contract's codehash, which uniquely identifies it.
 	codeHashCache [2]map[common.Hash]common.Hash
 
-	// initialContractsSet records the set of contract addresses present in the base chain,
-	// before any contracts are added by test sequences. Only these addresses will be recorded
-	// in coverage; others will be replaced with the zero address to prevent infinitely growing corpus.
-	initialContractsSet *map[common.Address]struct{}
+	// addressAttributor attributes contract addresses to the address their coverage is recorded under. It records
+	// the set of contract addresses present in the base chain, before any contracts are added by test sequences. Only
+	// these addresses will be recorded in coverage; others will be replaced with the zero address (or a bounded
+	// pseudo-address, see config.AddressAttributionConfig) to prevent infinitely growing corpus.
+	addressAttributor addressAttributor
 }
 
 // coverageTracerCallFrameState tracks state across call frames in the tracer.
@@ -99,6 +101,9 @@ func NewCoverageTracer() *CoverageTracer {
 		coverageMaps:    NewCoverageMaps(),
 		callFrameStates: make([]*coverageTracerCallFrameState, 0),
 		codeHashCache:   [2]map[common.Hash]common.Hash{make(map[common.Hash]common.Hash), make(map[common.Hash]common.Hash)},
+		addressAttributor: addressAttributor{
+			pseudoAddresses: make(map[common.Address]*pseudoAddressEntry),
+		},
 	}
 	nativeTracer := &tracers.Tracer{
 		Hooks: &tracing.Hooks{
@@ -118,27 +123,29 @@ func (t *CoverageTracer) NativeTracer() *chain.TestChainTracer {
 	return t.nativeTracer
 }
 
-// SetInitialContractsSet sets the initialContractsSet value (see above).
+// SetInitialContractsSet sets the set of contract addresses present in the base chain (see addressAttributor).
 func (t *CoverageTracer) SetInitialContractsSet(initialContractsSet *map[common.Address]struct{}) {
-	t.initialContractsSet = initialContractsSet
+	t.addressAttributor.initialContractsSet = initialContractsSet
+}
+
+// SetAddressAttribution sets how coverage of contracts created during call sequences is attributed. Any previously
+// allocated pseudo-addresses are discarded.
+func (t *CoverageTracer) SetAddressAttribution(addressAttributionConfig config.AddressAttributionConfig) {
+	t.addressAttributor.config = addressAttributionConfig
+	t.addressAttributor.pseudoAddresses = make(map[common.Address]*pseudoAddressEntry)
 }
 
 // BLANK_ADDRESS is an all-zero address; it's a global var so that we don't have to recalculate (and reallocate) it every time.
 var BLANK_ADDRESS = common.BytesToAddress([]byte{})
 
-// addressForCoverage modifies an address based on the initialContractsSet value.
+// addressForCoverage modifies an address based on the initial contracts set.
 // This is applied to all addresses before they are recorded in the coverage map.
-// If t.initialContractsSet is nil, we preserve all addresses.
-// If t.initialContractsSet is defined, we only preserve addresses present in this set.
-// Addresses not present in this set are zeroed to prevent issues with infinitely growing corpus.
+// If the initial contracts set is nil, we preserve all addresses.
+// If the initial contracts set is defined, we only preserve addresses present in this set.
+// Addresses not present in this set are zeroed (or attributed a pseudo-address, if enabled) to prevent issues with
+// infinitely growing corpus.
 func (t *CoverageTracer) addressForCoverage(address common.Address) common.Address {
-	if t.initialContractsSet == nil {
-		return address
-	} else if _, ok := (*t.initialContractsSet)[address]; ok {
-		return address
-	} else {
-		return BLANK_ADDRESS
-	}
+	return t.addressAttributor.attribute(address)
 }
 
 // OnTxStart is called upon the start of transaction execution, as defined by tracers.Tracer.
@@ -160,9 +167,15 @@ func (t *CoverageTracer) OnEnter(depth int, typ byte, from common.Address, to co
 		t.callDepth++
 	}
 
+	// If this frame creates a contract, allocate it a pseudo-address (if enabled) from its creator and their nonce.
+	create := typ == byte(vm.CREATE) || typ == byte(vm.CREATE2)
+	if create && t.evmContext != nil {
+		t.addressAttributor.recordCreation(to, from, t.evmContext.StateDB.GetNonce(from))
+	}
+
 	// Create our state tracking struct for this frame.
 	t.callFrameStates = append(t.callFrameStates, &coverageTracerCallFrameState{
-		create:             typ == byte(vm.CREATE) || typ == byte(vm.CREATE2),
+		create:             create,
 		pendingCoverageMap: NewCoverageMaps(),
 	})
 }
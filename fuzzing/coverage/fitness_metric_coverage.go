@@ -0,0 +1,369 @@
+package coverage
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/crytic/medusa/compilation/types"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/branchcoverage"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/branchdistance"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/codecoverage"
+	"github.com/holiman/uint256"
+)
+
+// FitnessMetricCoverage describes source line and branch coverage derived from the code coverage, branch coverage and
+// branch distance fitness metrics, as opposed to the corpus coverage maps used by SourceAnalysis.
+type FitnessMetricCoverage struct {
+	// Files describes the coverage of each source file, by path.
+	Files map[string]*FitnessMetricFileCoverage
+
+	// HasLineCoverage describes whether code coverage was provided, such that line coverage is meaningful.
+	HasLineCoverage bool
+
+	// HasBranchCoverage describes whether branch coverage was provided, such that taken branches are meaningful.
+	HasBranchCoverage bool
+
+	// HasBranchDistance describes whether branch distances were provided, such that branch distances are meaningful.
+	HasBranchDistance bool
+}
+
+// SortedFiles returns a list of FitnessMetricFileCoverage objects, sorted by path.
+func (c *FitnessMetricCoverage) SortedFiles() []*FitnessMetricFileCoverage {
+	files := make([]*FitnessMetricFileCoverage, 0, len(c.Files))
+	for _, file := range c.Files {
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files
+}
+
+// FitnessMetricFileCoverage describes the line and branch coverage of a single source file.
+type FitnessMetricFileCoverage struct {
+	// Path describes the file path of the source file.
+	Path string
+
+	// Lines describes whether each executable line was executed, by line number (starting at one).
+	Lines map[int]bool
+
+	// Branches describes the branches of the file, sorted by source offset.
+	Branches []*FitnessMetricBranchCoverage
+
+	// sourceLines describes the lines of the source file, indexed by line number minus one.
+	sourceLines []*SourceLineAnalysis
+
+	// cumulativeOffsetByLine describes the source offset each line starts at, indexed by line number minus one.
+	cumulativeOffsetByLine []int
+
+	// branchesBySourceRange describes the branches of the file by their source offset and length, so branch
+	// instructions mapping to the same source code across contracts are merged.
+	branchesBySourceRange map[[2]int]*FitnessMetricBranchCoverage
+}
+
+// SortedLines returns the line numbers of the executable lines of the file, in ascending order.
+func (f *FitnessMetricFileCoverage) SortedLines() []int {
+	lines := make([]int, 0, len(f.Lines))
+	for line := range f.Lines {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// getLine returns the line number (starting at one) of the provided source offset.
+func (f *FitnessMetricFileCoverage) getLine(offset int) int {
+	return sort.Search(len(f.cumulativeOffsetByLine), func(i int) bool {
+		return f.cumulativeOffsetByLine[i] > offset
+	})
+}
+
+// FitnessMetricBranchCoverage describes the coverage of a conditional branch (JUMPI) in source code.
+type FitnessMetricBranchCoverage struct {
+	// Line is the line number (starting at one) the branch's source range starts at.
+	Line int
+
+	// Offset is the source offset of the branch's source range.
+	Offset int
+
+	// Length is the length of the branch's source range.
+	Length int
+
+	// Reached describes whether the branch instruction was executed, such that one of its arms was taken.
+	Reached bool
+
+	// Taken describes whether each arm of the branch was taken. The first arm is the fall-through path (the jump
+	// condition was false), and the second is the jumping path.
+	Taken [2]bool
+
+	// Distances describes the closest branch distance observed for each arm of the branch, in the same order as Taken,
+	// or nil if no distance was recorded for the arm. A distance of zero indicates the arm was taken.
+	Distances [2]*uint256.Int
+}
+
+// AnalyzeFitnessMetricCoverage takes a list of compilations, along with code coverage, branch coverage and branch
+// distance maps recorded by the fitness metrics, and maps them onto source lines and branches using the solc source
+// maps. Any of the maps may be nil if it was not recorded. Files matching the exclusion patterns are filtered out of
+// the results.
+// Returns a FitnessMetricCoverage object, or an error if one occurs.
+func AnalyzeFitnessMetricCoverage(compilations []types.Compilation, codeCoverageMaps *codecoverage.CoverageMaps, branchCoverageMaps *branchcoverage.CoverageMaps, branchDistanceMaps *branchdistance.BranchDistanceMaps, exclusionPatterns []string) (*FitnessMetricCoverage, error) {
+	fitnessMetricCoverage := &FitnessMetricCoverage{
+		Files:             make(map[string]*FitnessMetricFileCoverage),
+		HasLineCoverage:   codeCoverageMaps != nil,
+		HasBranchCoverage: branchCoverageMaps != nil,
+		HasBranchDistance: branchDistanceMaps != nil,
+	}
+
+	// Loop through all sources in all compilations to process coverage information.
+	for _, compilation := range compilations {
+		for _, source := range compilation.SourcePathToArtifact {
+			// Loop for each contract in this source
+			for _, contract := range source.Contracts {
+				// Skip interfaces.
+				if contract.Kind == types.ContractKindInterface {
+					continue
+				}
+
+				// Parse the source maps for this contract.
+				initSourceMap, err := types.ParseSourceMap(contract.SrcMapsInit)
+				if err != nil {
+					return nil, fmt.Errorf("could not perform fitness metric coverage analysis due to error fetching init source map: %v", err)
+				}
+				runtimeSourceMap, err := types.ParseSourceMap(contract.SrcMapsRuntime)
+				if err != nil {
+					return nil, fmt.Errorf("could not perform fitness metric coverage analysis due to error fetching runtime source map: %v", err)
+				}
+
+				// Obtain the coverage map data for this contract, if any was recorded.
+				var initCodeCoverage, runtimeCodeCoverage *codecoverage.ContractCoverageMap
+				if codeCoverageMaps != nil {
+					if initCodeCoverage, err = codeCoverageMaps.GetContractCoverageMap(contract.InitBytecode, true); err != nil {
+						return nil, fmt.Errorf("could not perform fitness metric coverage analysis due to error fetching init code coverage map data: %v", err)
+					}
+					if runtimeCodeCoverage, err = codeCoverageMaps.GetContractCoverageMap(contract.RuntimeBytecode, false); err != nil {
+						return nil, fmt.Errorf("could not perform fitness metric coverage analysis due to error fetching runtime code coverage map data: %v", err)
+					}
+				}
+				var initBranchCoverage, runtimeBranchCoverage *branchcoverage.ContractCoverageMap
+				if branchCoverageMaps != nil {
+					if initBranchCoverage, err = branchCoverageMaps.GetContractCoverageMap(contract.InitBytecode, true); err != nil {
+						return nil, fmt.Errorf("could not perform fitness metric coverage analysis due to error fetching init branch coverage map data: %v", err)
+					}
+					if runtimeBranchCoverage, err = branchCoverageMaps.GetContractCoverageMap(contract.RuntimeBytecode, false); err != nil {
+						return nil, fmt.Errorf("could not perform fitness metric coverage analysis due to error fetching runtime branch coverage map data: %v", err)
+					}
+				}
+				var initBranchDistance, runtimeBranchDistance *branchdistance.ContractBranchDistanceMap
+				if branchDistanceMaps != nil {
+					if initBranchDistance, err = branchDistanceMaps.GetContractDistanceDistanceMap(contract.InitBytecode, true); err != nil {
+						return nil, fmt.Errorf("could not perform fitness metric coverage analysis due to error fetching init branch distance map data: %v", err)
+					}
+					if runtimeBranchDistance, err = branchDistanceMaps.GetContractDistanceDistanceMap(contract.RuntimeBytecode, false); err != nil {
+						return nil, fmt.Errorf("could not perform fitness metric coverage analysis due to error fetching runtime branch distance map data: %v", err)
+					}
+				}
+
+				// Strip the bytecode the same way the coverage tracers do, so program counters and branch ids match.
+				initBytecode := contract.InitBytecode
+				if runtimeBytecodeOffset := bytes.LastIndex(initBytecode, contract.RuntimeBytecode); runtimeBytecodeOffset != -1 {
+					initBytecode = initBytecode[:runtimeBytecodeOffset]
+				}
+				runtimeBytecode, _ := types.SplitRuntimeBytecode(contract.InitBytecode, contract.RuntimeBytecode)
+
+				// Analyze both init and runtime coverage.
+				fitnessMetricCoverage.analyzeContractCoverage(compilation, initSourceMap, initBytecode, initCodeCoverage, initBranchCoverage, initBranchDistance)
+				fitnessMetricCoverage.analyzeContractCoverage(compilation, runtimeSourceMap, runtimeBytecode, runtimeCodeCoverage, runtimeBranchCoverage, runtimeBranchDistance)
+			}
+		}
+	}
+
+	// Sort the branches of each file by their source range.
+	for _, file := range fitnessMetricCoverage.Files {
+		sort.Slice(file.Branches, func(i, j int) bool {
+			if file.Branches[i].Offset != file.Branches[j].Offset {
+				return file.Branches[i].Offset < file.Branches[j].Offset
+			}
+			return file.Branches[i].Length < file.Branches[j].Length
+		})
+	}
+
+	// Apply exclusion filtering if patterns are provided
+	fitnessMetricCoverage.filterExcludedFiles(exclusionPatterns)
+
+	return fitnessMetricCoverage, nil
+}
+
+// getFile returns the FitnessMetricFileCoverage for the source file the provided source map element maps to, creating
+// it if it does not exist yet. Returns nil if the element does not map to cached source code.
+func (c *FitnessMetricCoverage) getFile(compilation types.Compilation, sourceMapElement types.SourceMapElement) *FitnessMetricFileCoverage {
+	sourcePath, ok := compilation.SourceIdToPath[sourceMapElement.SourceUnitID]
+	if !ok {
+		return nil
+	}
+	if file, ok := c.Files[sourcePath]; ok {
+		return file
+	}
+	sourceCode, ok := compilation.SourceCode[sourcePath]
+	if !ok {
+		return nil
+	}
+	sourceLines, cumulativeOffset := parseSourceLines(sourceCode)
+	file := &FitnessMetricFileCoverage{
+		Path:                   sourcePath,
+		Lines:                  make(map[int]bool),
+		Branches:               make([]*FitnessMetricBranchCoverage, 0),
+		sourceLines:            sourceLines,
+		cumulativeOffsetByLine: cumulativeOffset,
+		branchesBySourceRange:  make(map[[2]int]*FitnessMetricBranchCoverage),
+	}
+	c.Files[sourcePath] = file
+	return file
+}
+
+// analyzeContractCoverage updates the line and branch coverage with the coverage and branch distances of the provided
+// (stripped) bytecode, using the source map it was compiled with. The maps provided may be nil if the code was never
+// executed or the metric was not recorded.
+func (c *FitnessMetricCoverage) analyzeContractCoverage(compilation types.Compilation, sourceMap types.SourceMap, bytecode []byte, codeCoverage *codecoverage.ContractCoverageMap, branchCoverage *branchcoverage.ContractCoverageMap, branchDistance *branchdistance.ContractBranchDistanceMap) {
+	indexToOffset := GetInstructionIndexToOffsetLookup(bytecode)
+
+	// Mark executable lines using the filtered source map, so elements spanning entire functions are not considered.
+	for _, sourceMapElement := range filterSourceMaps(compilation, sourceMap) {
+		if sourceMapElement.Index >= len(indexToOffset) {
+			continue
+		}
+		file := c.getFile(compilation, sourceMapElement)
+		if file == nil {
+			continue
+		}
+		line := file.getLine(sourceMapElement.Offset)
+		file.Lines[line] = file.Lines[line] || codeCoverage.IsCovered(uint64(indexToOffset[sourceMapElement.Index]))
+	}
+
+	// Mark branches using the unfiltered source map, as branch instructions often map to entire statements. JUMPI
+	// branch ids are shared by the branch coverage and branch distance metrics.
+	branchMap := branchcoverage.GetBranchMapFromBytecode(bytecode)
+	if branchMap == nil {
+		return
+	}
+	offsetToIndex := make(map[uint64]int, len(indexToOffset))
+	for index, offset := range indexToOffset {
+		offsetToIndex[uint64(offset)] = index
+	}
+	for pc := range branchMap.BranchIds {
+		index, ok := offsetToIndex[pc]
+		if !ok || index >= len(sourceMap) {
+			continue
+		}
+		sourceMapElement := sourceMap[index]
+		file := c.getFile(compilation, sourceMapElement)
+		if file == nil {
+			continue
+		}
+
+		// Merge branch instructions which map to the same source range, such as those of inherited code.
+		sourceRange := [2]int{sourceMapElement.Offset, sourceMapElement.Length}
+		branch, ok := file.branchesBySourceRange[sourceRange]
+		if !ok {
+			branch = &FitnessMetricBranchCoverage{
+				Line:   file.getLine(sourceMapElement.Offset),
+				Offset: sourceMapElement.Offset,
+				Length: sourceMapElement.Length,
+			}
+			file.branchesBySourceRange[sourceRange] = branch
+			file.Branches = append(file.Branches, branch)
+		}
+		for i, jumped := range []bool{false, true} {
+			branchId := branchMap.GetBranchId(pc, jumped)
+			branch.Taken[i] = branch.Taken[i] || branchCoverage.IsCovered(branchId)
+			if distance := branchDistance.GetDistance(branchId); distance != nil {
+				if branch.Distances[i] == nil || branch.Distances[i].Gt(distance) {
+					branch.Distances[i] = distance
+				}
+				branch.Taken[i] = branch.Taken[i] || distance.IsZero()
+				branch.Reached = true
+			}
+		}
+		branch.Reached = branch.Reached || branch.Taken[0] || branch.Taken[1] || codeCoverage.IsCovered(pc)
+	}
+}
+
+// filterExcludedFiles removes files matching exclusion patterns from the coverage. Pattern matching is performed on
+// paths relative to the current working directory, as with SourceAnalysis.
+func (c *FitnessMetricCoverage) filterExcludedFiles(exclusionPatterns []string) {
+	if len(exclusionPatterns) == 0 {
+		return
+	}
+
+	// Get current working directory to convert absolute paths to relative paths
+	cwd, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	for filePath := range c.Files {
+		relativePath := filePath
+		if relPath, err := filepath.Rel(cwd, filePath); err == nil {
+			relativePath = relPath
+		}
+		if shouldExcludeFile(relativePath, exclusionPatterns) {
+			delete(c.Files, filePath)
+		}
+	}
+}
+
+// GenerateLCOVReport generates an LCOV report from the fitness metric coverage, with line coverage (DA records) if
+// code coverage was provided, and branch coverage (BRDA records) if branch coverage or branch distances were provided.
+// The spec of the format is here https://github.com/linux-test-project/lcov/blob/07a1127c2b4390abf4a516e9763fb28a956a9ce4/man/geninfo.1#L989
+func (c *FitnessMetricCoverage) GenerateLCOVReport() string {
+	var buffer bytes.Buffer
+	buffer.WriteString("TN:\n")
+	for _, file := range c.SortedFiles() {
+		// SF:<path to the source file>
+		buffer.WriteString(fmt.Sprintf("SF:%s\n", file.Path))
+
+		if c.HasBranchCoverage || c.HasBranchDistance {
+			// BRDA:<line number>,<block number>,<branch number>,<taken>
+			branchesFound, branchesHit := 0, 0
+			for block, branch := range file.Branches {
+				for i, taken := range branch.Taken {
+					takenCount := "-"
+					if branch.Reached {
+						takenCount = "0"
+						if taken {
+							takenCount = "1"
+							branchesHit++
+						}
+					}
+					buffer.WriteString(fmt.Sprintf("BRDA:%d,%d,%d,%s\n", branch.Line, block, i, takenCount))
+					branchesFound++
+				}
+			}
+			// BRF:<number of branches found>
+			// BRH:<number of branches hit>
+			buffer.WriteString(fmt.Sprintf("BRF:%d\n", branchesFound))
+			buffer.WriteString(fmt.Sprintf("BRH:%d\n", branchesHit))
+		}
+
+		if c.HasLineCoverage {
+			// DA:<line number>,<execution count>
+			linesHit := 0
+			lines := file.SortedLines()
+			for _, line := range lines {
+				executionCount := 0
+				if file.Lines[line] {
+					executionCount = 1
+					linesHit++
+				}
+				buffer.WriteString(fmt.Sprintf("DA:%d,%d\n", line, executionCount))
+			}
+			// LF:<number of instrumented lines>
+			// LH:<number of lines with a non-zero execution count>
+			buffer.WriteString(fmt.Sprintf("LF:%d\n", len(lines)))
+			buffer.WriteString(fmt.Sprintf("LH:%d\n", linesHit))
+		}
+		buffer.WriteString("end_of_record\n")
+	}
+	return buffer.String()
+}
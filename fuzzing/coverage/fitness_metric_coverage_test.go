@@ -0,0 +1,188 @@
+package coverage
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/crypto"
+	"github.com/crytic/medusa/compilation/types"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/branchcoverage"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/branchdistance"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/codecoverage"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+// updateGoldenFiles describes whether the golden files of the report tests should be rewritten with the reports
+// generated, rather than compared against them.
+var updateGoldenFiles = flag.Bool("update", false, "rewrite the golden files of the report tests")
+
+// assertGoldenFile asserts the provided report matches the golden file with the provided name in the testdata
+// directory, or rewrites the golden file with it if updateGoldenFiles is set.
+func assertGoldenFile(t *testing.T, name string, report string) {
+	goldenPath := filepath.Join("testdata", name)
+	if *updateGoldenFiles {
+		assert.NoError(t, os.MkdirAll("testdata", 0755))
+		assert.NoError(t, os.WriteFile(goldenPath, []byte(report), 0644))
+		return
+	}
+	golden, err := os.ReadFile(goldenPath)
+	assert.NoError(t, err)
+	assert.Equal(t, string(golden), report, goldenPath)
+}
+
+// fitnessMetricTestSourcePath describes the path of the source file of the fitness metric coverage tests.
+const fitnessMetricTestSourcePath = "src/Counter.sol"
+
+// fitnessMetricTestSource describes the source code the bytecode of the fitness metric coverage tests maps to.
+const fitnessMetricTestSource = `contract Counter {
+    uint256 count;
+
+    function increment(uint256 x) public {
+        if (x > 10) {
+            count += x;
+        }
+        require(count < 100);
+    }
+}
+`
+
+// fitnessMetricTestBytecode describes the runtime bytecode of the fitness metric coverage tests, along with the
+// source code snippet each instruction maps to.
+var fitnessMetricTestBytecode = []struct {
+	code    string
+	snippet string
+}{
+	{"6004", "x > 10"}, // 0: PUSH1 0x04
+	{"35", "x > 10"},   // 2: CALLDATALOAD
+	{"600a", "10"},     // 3: PUSH1 0x0a
+	{"10", "x > 10"},   // 5: LT
+	{"15", "x > 10"},   // 6: ISZERO
+	{"600d", "if (x > 10) {\n            count += x;\n        }"}, // 7: PUSH1 0x0d
+	{"57", "if (x > 10) {\n            count += x;\n        }"},   // 9: JUMPI
+	{"6001", "count += x"},           // 10: PUSH1 0x01
+	{"50", "count += x"},             // 12: POP
+	{"5b", "require(count < 100)"},   // 13: JUMPDEST
+	{"6001", "count < 100"},          // 14: PUSH1 0x01
+	{"6014", "require(count < 100)"}, // 16: PUSH1 0x14
+	{"57", "require(count < 100)"},   // 18: JUMPI
+	{"00", "require(count < 100)"},   // 19: STOP
+	{"5b", "require(count < 100)"},   // 20: JUMPDEST
+	{"00", "require(count < 100)"},   // 21: STOP
+}
+
+// getFitnessMetricTestCompilation returns a compilation of a single contract with the source code, runtime bytecode
+// and runtime source map described above.
+func getFitnessMetricTestCompilation(t *testing.T) types.Compilation {
+	var bytecode string
+	sourceMapElements := make([]string, 0, len(fitnessMetricTestBytecode))
+	for _, instruction := range fitnessMetricTestBytecode {
+		offset := strings.Index(fitnessMetricTestSource, instruction.snippet)
+		assert.GreaterOrEqual(t, offset, 0, instruction.snippet)
+		bytecode += instruction.code
+		sourceMapElements = append(sourceMapElements, fmt.Sprintf("%d:%d:0:-", offset, len(instruction.snippet)))
+	}
+
+	compilation := types.NewCompilation()
+	compilation.SourceIdToPath[0] = fitnessMetricTestSourcePath
+	compilation.SourceCode[fitnessMetricTestSourcePath] = []byte(fitnessMetricTestSource)
+	compilation.SourcePathToArtifact[fitnessMetricTestSourcePath] = types.SourceArtifact{
+		Contracts: map[string]types.CompiledContract{
+			"Counter": {
+				RuntimeBytecode: common.FromHex(bytecode),
+				SrcMapsRuntime:  strings.Join(sourceMapElements, ";"),
+				Kind:            types.ContractKindContract,
+			},
+		},
+	}
+	return *compilation
+}
+
+// getFitnessMetricTestMaps returns the code coverage, branch coverage and branch distance maps recorded by a call to
+// increment(5) on the provided compilation's contract, which skips the if statement's body and passes the require.
+func getFitnessMetricTestMaps(t *testing.T, compilation types.Compilation) (*codecoverage.CoverageMaps, *branchcoverage.CoverageMaps, *branchdistance.BranchDistanceMaps) {
+	bytecode := compilation.SourcePathToArtifact[fitnessMetricTestSourcePath].Contracts["Counter"].RuntimeBytecode
+	codeHash := crypto.Keccak256Hash(types.RemoveContractMetadata(bytecode))
+	address := common.HexToAddress("0x1000")
+
+	codeCoverageMaps := codecoverage.NewCoverageMaps()
+	for _, pc := range []uint64{0, 2, 3, 5, 6, 7, 9, 13, 14, 16, 18, 20, 21} {
+		_, err := codeCoverageMaps.SetAt(address, codeHash, len(bytecode), len(fitnessMetricTestBytecode), pc)
+		assert.NoError(t, err)
+	}
+
+	// The if statement jumped past its body, while the require jumped past its revert.
+	branchMap := branchcoverage.GetBranchMapFromBytecode(bytecode)
+	branchCoverageMaps := branchcoverage.NewCoverageMaps()
+	branchDistanceMaps := branchdistance.NewBranchDistanceMaps()
+	for _, branch := range []struct {
+		pc       uint64
+		jumped   bool
+		distance uint64
+	}{
+		{pc: 9, jumped: true, distance: 0},
+		{pc: 9, jumped: false, distance: 6},
+		{pc: 18, jumped: true, distance: 0},
+		{pc: 18, jumped: false, distance: 100},
+	} {
+		branchId := branchMap.GetBranchId(branch.pc, branch.jumped)
+		if branch.distance == 0 {
+			_, err := branchCoverageMaps.SetAt(address, codeHash, branchMap.Size(), branchId, nil)
+			assert.NoError(t, err)
+		}
+		_, err := branchDistanceMaps.SetAt(address, codeHash, branchMap.Size(), branchId, uint256.NewInt(branch.distance))
+		assert.NoError(t, err)
+	}
+	return codeCoverageMaps, branchCoverageMaps, branchDistanceMaps
+}
+
+// TestFitnessMetricCoverageLCOVReport ensures the LCOV reports generated from the fitness metric coverage match their
+// golden files, with line and branch records only emitted for the metrics which were recorded.
+func TestFitnessMetricCoverageLCOVReport(t *testing.T) {
+	compilation := getFitnessMetricTestCompilation(t)
+	codeCoverageMaps, branchCoverageMaps, branchDistanceMaps := getFitnessMetricTestMaps(t, compilation)
+	tests := []struct {
+		name               string
+		codeCoverageMaps   *codecoverage.CoverageMaps
+		branchCoverageMaps *branchcoverage.CoverageMaps
+		branchDistanceMaps *branchdistance.BranchDistanceMaps
+		exclusionPatterns  []string
+	}{
+		{name: "fitness_metric_coverage_all.lcov", codeCoverageMaps: codeCoverageMaps, branchCoverageMaps: branchCoverageMaps, branchDistanceMaps: branchDistanceMaps},
+		{name: "fitness_metric_coverage_code_coverage.lcov", codeCoverageMaps: codeCoverageMaps},
+		{name: "fitness_metric_coverage_branch_distance.lcov", branchDistanceMaps: branchDistanceMaps},
+		{name: "fitness_metric_coverage_unexecuted.lcov", codeCoverageMaps: codecoverage.NewCoverageMaps(), branchCoverageMaps: branchcoverage.NewCoverageMaps()},
+		{name: "fitness_metric_coverage_excluded.lcov", codeCoverageMaps: codeCoverageMaps, exclusionPatterns: []string{"src/**"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fitnessMetricCoverage, err := AnalyzeFitnessMetricCoverage([]types.Compilation{compilation}, test.codeCoverageMaps, test.branchCoverageMaps, test.branchDistanceMaps, test.exclusionPatterns)
+			assert.NoError(t, err)
+			assertGoldenFile(t, test.name, fitnessMetricCoverage.GenerateLCOVReport())
+		})
+	}
+}
+
+// TestAnalyzeFitnessMetricCoverage ensures branch instructions are mapped to the line their source range starts at,
+// with the closest distance recorded for each arm, and that statements encapsulating others do not mark lines.
+func TestAnalyzeFitnessMetricCoverage(t *testing.T) {
+	compilation := getFitnessMetricTestCompilation(t)
+	codeCoverageMaps, branchCoverageMaps, branchDistanceMaps := getFitnessMetricTestMaps(t, compilation)
+	fitnessMetricCoverage, err := AnalyzeFitnessMetricCoverage([]types.Compilation{compilation}, codeCoverageMaps, branchCoverageMaps, branchDistanceMaps, nil)
+	assert.NoError(t, err)
+
+	file := fitnessMetricCoverage.Files[fitnessMetricTestSourcePath]
+	assert.EqualValues(t, map[int]bool{5: true, 6: false, 8: true}, file.Lines)
+	assert.Len(t, file.Branches, 2)
+	assert.EqualValues(t, 5, file.Branches[0].Line)
+	assert.True(t, file.Branches[0].Reached)
+	assert.EqualValues(t, [2]bool{false, true}, file.Branches[0].Taken)
+	assert.EqualValues(t, 6, file.Branches[0].Distances[0].Uint64())
+	assert.EqualValues(t, 8, file.Branches[1].Line)
+	assert.EqualValues(t, 100, file.Branches[1].Distances[0].Uint64())
+}
@@ -0,0 +1,201 @@
+package coverage
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/branchdistance"
+	"github.com/crytic/medusa/utils"
+)
+
+var (
+	//go:embed fitness_report_template.gohtml
+	fitnessMetricHTMLReportTemplate []byte
+)
+
+// fitnessMetricReport describes the data rendered by the fitness metric HTML report template.
+type fitnessMetricReport struct {
+	// Files describes each source file, sorted by path.
+	Files []*fitnessMetricReportFile
+
+	// HasLineCoverage, HasBranchCoverage and HasBranchDistance describe which metrics were recorded.
+	HasLineCoverage   bool
+	HasBranchCoverage bool
+	HasBranchDistance bool
+
+	// Normalization describes the normalization used to map branch distances onto the heatmap.
+	Normalization string
+
+	// LinesExecutable and LinesExecuted describe the amount of executable and executed lines across all files.
+	LinesExecutable int
+	LinesExecuted   int
+
+	// ArmsFound and ArmsTaken describe the amount of branch arms found and taken across all files.
+	ArmsFound int
+	ArmsTaken int
+}
+
+// fitnessMetricReportFile describes a source file as rendered in the fitness metric HTML report.
+type fitnessMetricReportFile struct {
+	// Path describes the file path of the source file.
+	Path string
+
+	// Lines describes each line of the source file, in order.
+	Lines []*fitnessMetricReportLine
+
+	// LinesExecutable and LinesExecuted describe the amount of executable and executed lines in the file.
+	LinesExecutable int
+	LinesExecuted   int
+
+	// ArmsFound and ArmsTaken describe the amount of branch arms found and taken in the file.
+	ArmsFound int
+	ArmsTaken int
+}
+
+// fitnessMetricReportLine describes a source line as rendered in the fitness metric HTML report.
+type fitnessMetricReportLine struct {
+	// Number is the line number, starting at one.
+	Number int
+
+	// Contents is the source code of the line.
+	Contents string
+
+	// Executable and Executed describe whether the line maps to bytecode, and whether that bytecode was executed.
+	Executable bool
+	Executed   bool
+
+	// Arms describes the arms of the branches starting on this line.
+	Arms []*fitnessMetricReportArm
+
+	// Heat is the lowest normalized distance of the untaken arms of this line which were reached, within [0, 1].
+	// It is only meaningful if HasHeat is set.
+	Heat    float64
+	HasHeat bool
+}
+
+// fitnessMetricReportArm describes a branch arm as rendered in the fitness metric HTML report.
+type fitnessMetricReportArm struct {
+	// Label describes which arm of the branch this is.
+	Label string
+
+	// Reached describes whether the branch the arm belongs to was reached.
+	Reached bool
+
+	// Taken describes whether the arm was taken.
+	Taken bool
+
+	// Distance is the closest branch distance observed for the arm, in decimal, or empty if none was recorded.
+	Distance string
+
+	// Heat is the normalized distance of the arm, within [0, 1]. It is only meaningful if Distance is set.
+	Heat float64
+}
+
+// newFitnessMetricReport creates the data rendered by the fitness metric HTML report template from the provided
+// coverage, normalizing branch distances with the provided normalization.
+func newFitnessMetricReport(fitnessMetricCoverage *FitnessMetricCoverage, normalization string) *fitnessMetricReport {
+	report := &fitnessMetricReport{
+		Files:             make([]*fitnessMetricReportFile, 0, len(fitnessMetricCoverage.Files)),
+		HasLineCoverage:   fitnessMetricCoverage.HasLineCoverage,
+		HasBranchCoverage: fitnessMetricCoverage.HasBranchCoverage,
+		HasBranchDistance: fitnessMetricCoverage.HasBranchDistance,
+		Normalization:     normalization,
+	}
+	for _, file := range fitnessMetricCoverage.SortedFiles() {
+		reportFile := &fitnessMetricReportFile{
+			Path:  file.Path,
+			Lines: make([]*fitnessMetricReportLine, len(file.sourceLines)),
+		}
+		for i, sourceLine := range file.sourceLines {
+			executed, executable := file.Lines[i+1]
+			reportFile.Lines[i] = &fitnessMetricReportLine{
+				Number:     i + 1,
+				Contents:   string(sourceLine.Contents),
+				Executable: executable,
+				Executed:   executed,
+			}
+			if executable {
+				reportFile.LinesExecutable++
+				if executed {
+					reportFile.LinesExecuted++
+				}
+			}
+		}
+
+		// Attach the arms of each branch to the line it starts at, heating the line up by its closest untaken arm.
+		for _, branch := range file.Branches {
+			if branch.Line < 1 || branch.Line > len(reportFile.Lines) {
+				continue
+			}
+			reportLine := reportFile.Lines[branch.Line-1]
+			for i, label := range []string{"false", "true"} {
+				arm := &fitnessMetricReportArm{
+					Label:   label,
+					Reached: branch.Reached,
+					Taken:   branch.Taken[i],
+				}
+				if distance := branch.Distances[i]; distance != nil {
+					arm.Distance = distance.Dec()
+					arm.Heat = branchdistance.NormalizeDistance(distance, normalization)
+					if !arm.Taken && (!reportLine.HasHeat || arm.Heat < reportLine.Heat) {
+						reportLine.Heat = arm.Heat
+						reportLine.HasHeat = true
+					}
+				}
+				reportLine.Arms = append(reportLine.Arms, arm)
+				reportFile.ArmsFound++
+				if arm.Taken {
+					reportFile.ArmsTaken++
+				}
+			}
+		}
+
+		report.Files = append(report.Files, reportFile)
+		report.LinesExecutable += reportFile.LinesExecutable
+		report.LinesExecuted += reportFile.LinesExecuted
+		report.ArmsFound += reportFile.ArmsFound
+		report.ArmsTaken += reportFile.ArmsTaken
+	}
+	return report
+}
+
+// WriteFitnessMetricHTMLReport takes a set of FitnessMetricCoverage data and generates an HTML report from it, showing
+// executed lines, taken and untaken branch arms, and a heatmap of the remaining branch distance of untaken arms,
+// normalized with the provided branch distance normalization.
+// Returns the path of the HTML report file, or an error if one occurred.
+func WriteFitnessMetricHTMLReport(fitnessMetricCoverage *FitnessMetricCoverage, normalization string, reportDir string) (string, error) {
+	// Parse our HTML template
+	tmpl, err := template.New("fitness_coverage_report.html").Funcs(htmlReportFunctions).Funcs(template.FuncMap{
+		"getHeatColor": func(heat float64) template.CSS {
+			// Color gradient: green (close to flipping) -> red (far from flipping)
+			return template.CSS(fmt.Sprintf("hsla(%d, 90%%, 50%%, 0.35)", int((1-heat)*120)))
+		},
+	}).Parse(string(fitnessMetricHTMLReportTemplate))
+	if err != nil {
+		return "", fmt.Errorf("could not export report, failed to parse report template: %v", err)
+	}
+
+	// If the directory doesn't exist, create it.
+	err = utils.MakeDirectory(reportDir)
+	if err != nil {
+		return "", err
+	}
+
+	// Create our report file
+	htmlReportPath := filepath.Join(reportDir, "fitness_coverage_report.html")
+	file, err := os.Create(htmlReportPath)
+	if err != nil {
+		return "", fmt.Errorf("could not export report, failed to open file for writing: %v", err)
+	}
+
+	// Execute the template and write it back to file.
+	err = tmpl.Execute(file, newFitnessMetricReport(fitnessMetricCoverage, normalization))
+	fileCloseErr := file.Close()
+	if err == nil {
+		err = fileCloseErr
+	}
+	return htmlReportPath, err
+}
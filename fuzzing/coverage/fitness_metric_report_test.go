@@ -0,0 +1,35 @@
+package coverage
+
+import (
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/crytic/medusa/compilation/types"
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// reportTimestampRegex matches the time the report was generated at in the footer of the HTML reports, which is
+// replaced prior to comparing the reports against their golden files.
+var reportTimestampRegex = regexp.MustCompile(`Report generated by Medusa on [^<]*`)
+
+// TestWriteFitnessMetricHTMLReport ensures the HTML reports written from the fitness metric coverage match their golden
+// files, with untaken branch arms colored by their remaining distance under each normalization.
+func TestWriteFitnessMetricHTMLReport(t *testing.T) {
+	compilation := getFitnessMetricTestCompilation(t)
+	codeCoverageMaps, branchCoverageMaps, branchDistanceMaps := getFitnessMetricTestMaps(t, compilation)
+	fitnessMetricCoverage, err := AnalyzeFitnessMetricCoverage([]types.Compilation{compilation}, codeCoverageMaps, branchCoverageMaps, branchDistanceMaps, nil)
+	assert.NoError(t, err)
+
+	for _, normalization := range []string{config.BranchDistanceNormalizationRatio, config.BranchDistanceNormalizationLog} {
+		t.Run(normalization, func(t *testing.T) {
+			reportPath, err := WriteFitnessMetricHTMLReport(fitnessMetricCoverage, normalization, t.TempDir())
+			assert.NoError(t, err)
+			report, err := os.ReadFile(reportPath)
+			assert.NoError(t, err)
+			report = reportTimestampRegex.ReplaceAll(report, []byte("Report generated by Medusa on <timestamp>"))
+			assertGoldenFile(t, "fitness_metric_report_"+normalization+".html", string(report))
+		})
+	}
+}
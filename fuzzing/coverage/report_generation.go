@@ -18,98 +18,98 @@ var (
 	htmlReportTemplate []byte
 )
 
-// WriteHTMLReport takes a previously performed source analysis and generates an HTML coverage report from it.
-func WriteHTMLReport(sourceAnalysis *SourceAnalysis, reportDir string) (string, error) {
-	// Define mappings onto some useful variables/functions.
-	functionMap := template.FuncMap{
-		"formatNumber": func(num uint64) string {
-			// Format large numbers to be more readable (e.g., 1234 → 1.2K, 1500000 → 1.5M)
-			if num < 1000 {
-				return fmt.Sprintf("%d", num) // Keep small numbers as is
-			} else if num < 1000000 {
-				// Format as K (thousands)
-				value := float64(num) / 1000.0
-				return fmt.Sprintf("%.1fK", value)
-			} else if num < 1000000000 {
-				// Format as M (millions)
-				value := float64(num) / 1000000.0
-				return fmt.Sprintf("%.1fM", value)
-			} else {
-				// Format as B (billions)
-				value := float64(num) / 1000000000.0
-				return fmt.Sprintf("%.1fB", value)
-			}
-		},
-		"timeNow": time.Now,
-		"add": func(x int, y int) int {
-			return x + y
-		},
-		"relativePath": func(path string) string {
-			// Obtain a path relative to our current working directory.
-			// If we encounter an error, return the original path.
-			cwd, err := os.Getwd()
-			if err != nil {
-				return path
-			}
-			relativePath, err := filepath.Rel(cwd, path)
-			if err != nil {
-				return path
-			}
+// htmlReportFunctions defines mappings onto some useful variables/functions for the HTML report templates.
+var htmlReportFunctions = template.FuncMap{
+	"formatNumber": func(num uint64) string {
+		// Format large numbers to be more readable (e.g., 1234 → 1.2K, 1500000 → 1.5M)
+		if num < 1000 {
+			return fmt.Sprintf("%d", num) // Keep small numbers as is
+		} else if num < 1000000 {
+			// Format as K (thousands)
+			value := float64(num) / 1000.0
+			return fmt.Sprintf("%.1fK", value)
+		} else if num < 1000000000 {
+			// Format as M (millions)
+			value := float64(num) / 1000000.0
+			return fmt.Sprintf("%.1fM", value)
+		} else {
+			// Format as B (billions)
+			value := float64(num) / 1000000000.0
+			return fmt.Sprintf("%.1fB", value)
+		}
+	},
+	"timeNow": time.Now,
+	"add": func(x int, y int) int {
+		return x + y
+	},
+	"relativePath": func(path string) string {
+		// Obtain a path relative to our current working directory.
+		// If we encounter an error, return the original path.
+		cwd, err := os.Getwd()
+		if err != nil {
+			return path
+		}
+		relativePath, err := filepath.Rel(cwd, path)
+		if err != nil {
+			return path
+		}
 
-			return relativePath
-		},
-		"filePathToId": func(path string) string {
-			// Convert a file path to a safe HTML ID by replacing non-alphanumeric characters with underscores
-			safeId := ""
-			for _, c := range path {
-				if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
-					safeId += string(c)
-				} else {
-					safeId += "_"
-				}
-			}
-			return safeId
-		},
-		"percentageStr": func(x int, y int, decimals int) string {
-			// Determine our precision string
-			formatStr := "%." + strconv.Itoa(decimals) + "f"
-
-			// If no lines are active and none are covered, show 0% coverage
-			if x == 0 && y == 0 {
-				return fmt.Sprintf(formatStr, float64(0))
-			}
-			return fmt.Sprintf(formatStr, (float64(x)/float64(y))*100)
-		},
-		"percentageInt": func(x int, y int) int {
-			if y == 0 {
-				return 100
-			}
-			return int(math.Round(float64(x) / float64(y) * 100))
-		},
-		"getCoverageColor": func(percentage int) string {
-			// Color gradient: red (0%) -> yellow (50%) -> green (100%)
-			if percentage < 50 {
-				// Red to yellow (0-50%)
-				return fmt.Sprintf("hsl(%d, 90%%, 50%%)", int(float64(percentage)*1.2))
-			} else {
-				// Yellow to green (50-100%)
-				return fmt.Sprintf("hsl(%d, 90%%, 45%%)", 60+int(float64(percentage-50)*1.2))
-			}
-		},
-		"getCoverageColorAlpha": func(percentage int) string {
-			// Color gradient with alpha: red (0%) -> yellow (50%) -> green (100%)
-			if percentage < 50 {
-				// Red to yellow (0-50%)
-				return fmt.Sprintf("hsla(%d, 90%%, 50%%, 0.15)", int(float64(percentage)*1.2))
+		return relativePath
+	},
+	"filePathToId": func(path string) string {
+		// Convert a file path to a safe HTML ID by replacing non-alphanumeric characters with underscores
+		safeId := ""
+		for _, c := range path {
+			if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+				safeId += string(c)
 			} else {
-				// Yellow to green (50-100%)
-				return fmt.Sprintf("hsla(%d, 90%%, 45%%, 0.15)", 60+int(float64(percentage-50)*1.2))
+				safeId += "_"
 			}
-		},
-	}
+		}
+		return safeId
+	},
+	"percentageStr": func(x int, y int, decimals int) string {
+		// Determine our precision string
+		formatStr := "%." + strconv.Itoa(decimals) + "f"
 
+		// If no lines are active and none are covered, show 0% coverage
+		if x == 0 && y == 0 {
+			return fmt.Sprintf(formatStr, float64(0))
+		}
+		return fmt.Sprintf(formatStr, (float64(x)/float64(y))*100)
+	},
+	"percentageInt": func(x int, y int) int {
+		if y == 0 {
+			return 100
+		}
+		return int(math.Round(float64(x) / float64(y) * 100))
+	},
+	"getCoverageColor": func(percentage int) string {
+		// Color gradient: red (0%) -> yellow (50%) -> green (100%)
+		if percentage < 50 {
+			// Red to yellow (0-50%)
+			return fmt.Sprintf("hsl(%d, 90%%, 50%%)", int(float64(percentage)*1.2))
+		} else {
+			// Yellow to green (50-100%)
+			return fmt.Sprintf("hsl(%d, 90%%, 45%%)", 60+int(float64(percentage-50)*1.2))
+		}
+	},
+	"getCoverageColorAlpha": func(percentage int) string {
+		// Color gradient with alpha: red (0%) -> yellow (50%) -> green (100%)
+		if percentage < 50 {
+			// Red to yellow (0-50%)
+			return fmt.Sprintf("hsla(%d, 90%%, 50%%, 0.15)", int(float64(percentage)*1.2))
+		} else {
+			// Yellow to green (50-100%)
+			return fmt.Sprintf("hsla(%d, 90%%, 45%%, 0.15)", 60+int(float64(percentage-50)*1.2))
+		}
+	},
+}
+
+// WriteHTMLReport takes a previously performed source analysis and generates an HTML coverage report from it.
+func WriteHTMLReport(sourceAnalysis *SourceAnalysis, reportDir string) (string, error) {
 	// Parse our HTML template
-	tmpl, err := template.New("coverage_report.html").Funcs(functionMap).Parse(string(htmlReportTemplate))
+	tmpl, err := template.New("coverage_report.html").Funcs(htmlReportFunctions).Parse(string(htmlReportTemplate))
 	if err != nil {
 		return "", fmt.Errorf("could not export report, failed to parse report template: %v", err)
 	}
@@ -157,3 +157,26 @@ func WriteLCOVReport(sourceAnalysis *SourceAnalysis, reportDir string) (string,
 
 	return lcovReportPath, nil
 }
+
+// WriteFitnessMetricLCOVReport takes a set of FitnessMetricCoverage data and outputs an LCOV report to the provided
+// directory.
+// Returns the path of the LCOV report file, or an error if one occurred.
+func WriteFitnessMetricLCOVReport(fitnessMetricCoverage *FitnessMetricCoverage, reportDir string) (string, error) {
+	// Generate the LCOV report.
+	lcovReport := fitnessMetricCoverage.GenerateLCOVReport()
+
+	// If the directory doesn't exist, create it.
+	err := utils.MakeDirectory(reportDir)
+	if err != nil {
+		return "", err
+	}
+
+	// Write the LCOV report to a file.
+	lcovReportPath := filepath.Join(reportDir, "fitness_lcov.info")
+	err = os.WriteFile(lcovReportPath, []byte(lcovReport), 0644)
+	if err != nil {
+		return "", fmt.Errorf("could not export fitness metric LCOV report: %v", err)
+	}
+
+	return lcovReportPath, nil
+}
@@ -480,7 +480,7 @@ func analyzeContractSourceCoverage(compilation types.Compilation, sourceAnalysis
 // Returns two slices: one for successful hits and one for reverts. These slices are indexed by instruction index (not program counter),
 // and their values are the number of hits (0 if the PC was not hit).
 func determineLinesCovered(cm *ContractCoverageMap, bytecode []byte, logger *logging.Logger) ([]uint64, []uint64) {
-	indexToOffset := getInstructionIndexToOffsetLookup(bytecode)
+	indexToOffset := GetInstructionIndexToOffsetLookup(bytecode)
 
 	// executedMakers as src -> dst -> hit count, and dst -> src -> hit count
 	execMarkersSrcDst, execMarkersDstSrc := getExecMarkersMapping(cm.executedMarkers)
@@ -538,7 +538,7 @@ func determineLinesCovered(cm *ContractCoverageMap, bytecode []byte, logger *log
 
 // GetInstructionIndexToOffsetLookup obtains a slice where each index of the slice corresponds to an instruction index,
 // and the element of the slice represents the instruction offset.
-func getInstructionIndexToOffsetLookup(bytecode []byte) []int {
+func GetInstructionIndexToOffsetLookup(bytecode []byte) []int {
 	// Create our resulting lookup
 	indexToOffsetLookup := make([]int, 0, len(bytecode))
 
@@ -0,0 +1,118 @@
+package dashboard
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// snapshotPath describes the HTTP path the Server serves the latest Snapshot at.
+const snapshotPath = "/api/snapshot"
+
+var (
+	//go:embed dashboard.html
+	dashboardPage string
+)
+
+// Server serves a web page displaying the state of a fuzzing campaign, such as per-contract coverage, the branches
+// closest to being flipped, and the latest bugs detected. The page polls the Server for a new Snapshot every refresh
+// interval.
+type Server struct {
+	// snapshot is called to obtain the state of the campaign upon each request for a Snapshot. It must be
+	// thread-safe.
+	snapshot func() *Snapshot
+
+	// refreshInterval describes the amount of seconds the page waits between requests for a Snapshot.
+	refreshInterval uint64
+
+	// httpServer describes the HTTP server which serves requests.
+	httpServer *http.Server
+
+	// listener describes the network listener used by httpServer.
+	listener net.Listener
+}
+
+// NewServer creates a new Server which serves the Snapshot returned by the provided function, refreshed by the page
+// every refreshInterval seconds. The Server must be started with Start.
+func NewServer(snapshot func() *Snapshot, refreshInterval uint64) *Server {
+	if refreshInterval == 0 {
+		refreshInterval = 1
+	}
+	return &Server{
+		snapshot:        snapshot,
+		refreshInterval: refreshInterval,
+	}
+}
+
+// Start begins listening for requests over HTTP on the provided address (e.g. "127.0.0.1:8080"). Requests are served
+// on a separate goroutine until Close is called.
+// Returns an error if the listener could not be created.
+func (s *Server) Start(address string) error {
+	if s.httpServer != nil {
+		return errors.New("the dashboard was already started")
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handlePage)
+	mux.HandleFunc(snapshotPath, s.handleSnapshot)
+	s.listener = listener
+	s.httpServer = &http.Server{Handler: mux}
+
+	go func() {
+		_ = s.httpServer.Serve(listener)
+	}()
+	return nil
+}
+
+// Address returns the network address the Server is listening on, or an empty string if it was not started.
+func (s *Server) Address() string {
+	if s.listener == nil {
+		return ""
+	}
+	return s.listener.Addr().String()
+}
+
+// Close stops the Server from serving any further requests.
+// Returns an error if one occurred while shutting down the HTTP server.
+func (s *Server) Close() error {
+	if s.httpServer == nil {
+		return nil
+	}
+	err := s.httpServer.Close()
+	s.httpServer = nil
+	s.listener = nil
+	return err
+}
+
+// handlePage serves the dashboard page.
+func (s *Server) handlePage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	page := strings.ReplaceAll(dashboardPage, "{{refreshIntervalMs}}", fmt.Sprintf("%d", s.refreshInterval*1000))
+	_, _ = w.Write([]byte(page))
+}
+
+// handleSnapshot serves the latest Snapshot of the campaign.
+func (s *Server) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "expected a GET request", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(s.snapshot()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
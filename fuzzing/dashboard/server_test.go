@@ -0,0 +1,51 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestServer starts a Server and verifies it serves the dashboard page, refreshing at the configured interval, and
+// the latest Snapshot as JSON.
+func TestServer(t *testing.T) {
+	snapshots := 0
+	server := NewServer(func() *Snapshot {
+		snapshots++
+		return &Snapshot{
+			ElapsedSeconds: uint64(snapshots),
+			Contracts:      []ContractCoverage{{Name: "Vault", CoveredInstructions: 5, TotalInstructions: 10}},
+		}
+	}, 3)
+	assert.NoError(t, server.Start("127.0.0.1:0"))
+	defer server.Close()
+	assert.Error(t, server.Start("127.0.0.1:0"))
+
+	// The page should poll for snapshots at the refresh interval.
+	response, err := http.Get("http://" + server.Address() + "/")
+	assert.NoError(t, err)
+	page, err := io.ReadAll(response.Body)
+	assert.NoError(t, err)
+	_ = response.Body.Close()
+	assert.Contains(t, string(page), "setInterval(refresh, 3000);")
+
+	// Each request for a snapshot should obtain a new one.
+	for i := 1; i <= 2; i++ {
+		response, err = http.Get("http://" + server.Address() + snapshotPath)
+		assert.NoError(t, err)
+		var snapshot Snapshot
+		assert.NoError(t, json.NewDecoder(response.Body).Decode(&snapshot))
+		_ = response.Body.Close()
+		assert.EqualValues(t, i, snapshot.ElapsedSeconds)
+		assert.EqualValues(t, "Vault", snapshot.Contracts[0].Name)
+	}
+
+	// Other paths should not be served.
+	response, err = http.Get("http://" + server.Address() + "/missing")
+	assert.NoError(t, err)
+	_ = response.Body.Close()
+	assert.EqualValues(t, http.StatusNotFound, response.StatusCode)
+}
@@ -0,0 +1,72 @@
+package dashboard
+
+// Snapshot describes the state of a fuzzing campaign displayed by the dashboard at a point in time.
+type Snapshot struct {
+	// ElapsedSeconds describes the amount of seconds elapsed since the campaign started.
+	ElapsedSeconds uint64 `json:"elapsedSeconds"`
+
+	// CallsTested describes the amount of calls tested so far.
+	CallsTested string `json:"callsTested"`
+
+	// SequencesTested describes the amount of call sequences tested so far.
+	SequencesTested string `json:"sequencesTested"`
+
+	// Contracts describes the coverage of each contract, sorted by name.
+	Contracts []ContractCoverage `json:"contracts"`
+
+	// HardestBranches describes the branches guarding reverts which were closest to being flipped, sorted by
+	// ascending distance.
+	HardestBranches []BranchDistance `json:"hardestBranches"`
+
+	// LatestBugs describes the bugs detected most recently, sorted from the most to the least recent.
+	LatestBugs []Bug `json:"latestBugs"`
+}
+
+// ContractCoverage describes the code and branch coverage of a contract, summed across its init and runtime bytecode
+// and the addresses it was deployed at.
+type ContractCoverage struct {
+	// Name describes the name of the contract.
+	Name string `json:"name"`
+
+	// CoveredInstructions describes the amount of instructions covered, if code coverage is traced.
+	CoveredInstructions int `json:"coveredInstructions"`
+
+	// TotalInstructions describes the amount of instructions of the contract, if code coverage is traced.
+	TotalInstructions int `json:"totalInstructions"`
+
+	// CoveredBranches describes the amount of branches covered, if branch coverage is traced.
+	CoveredBranches int `json:"coveredBranches"`
+
+	// TotalBranches describes the amount of branches of the contract, if branch coverage is traced.
+	TotalBranches int `json:"totalBranches"`
+}
+
+// BranchDistance describes the best distance observed to flipping a branch guarding a revert.
+type BranchDistance struct {
+	// ContractName describes the name of the contract containing the branch, or its code hash if it is unknown.
+	ContractName string `json:"contractName"`
+
+	// BranchPc describes the program counter of the JUMPI instruction guarding the revert.
+	BranchPc uint64 `json:"branchPc"`
+
+	// RevertPc describes the program counter of the REVERT instruction.
+	RevertPc uint64 `json:"revertPc"`
+
+	// Source describes the source location of the branch, if it could be resolved.
+	Source string `json:"source,omitempty"`
+
+	// Distance describes the best distance observed to flipping the branch, as a decimal string.
+	Distance string `json:"distance"`
+}
+
+// Bug describes a bug detected during the campaign.
+type Bug struct {
+	// ID describes the ID of the bug.
+	ID string `json:"id"`
+
+	// ElapsedSeconds describes the amount of seconds elapsed since the campaign started when the bug was detected.
+	ElapsedSeconds float64 `json:"elapsedSeconds"`
+
+	// Value describes the concrete tainted value which reached the sink, as a hex string, if one was recorded.
+	Value string `json:"value,omitempty"`
+}
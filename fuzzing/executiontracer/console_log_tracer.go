@@ -0,0 +1,126 @@
+package executiontracer
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core/tracing"
+	coretypes "github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa-geth/eth/tracers"
+	"github.com/crytic/medusa/chain"
+	"github.com/crytic/medusa/chain/types"
+	"github.com/crytic/medusa/fuzzing/valuegeneration"
+)
+
+// consoleLogTracerResultsKey describes the key to use when storing tracer results in call message results, or when
+// querying them.
+const consoleLogTracerResultsKey = "ConsoleLogTracerResults"
+
+// GetConsoleLogTracerResults obtains the console.log strings stored by a ConsoleLogTracer from message results, in the
+// order they were logged. This is nil if the message logged nothing, or if no ConsoleLogTracer was attached during this
+// message execution.
+func GetConsoleLogTracerResults(messageResults *types.MessageResults) []string {
+	// Try to obtain the results the tracer should've stored.
+	if genericResult, ok := messageResults.AdditionalResults[consoleLogTracerResultsKey]; ok {
+		if castedResult, ok := genericResult.([]string); ok {
+			return castedResult
+		}
+	}
+
+	// If we could not obtain them, return nil.
+	return nil
+}
+
+// formatConsoleLog formats the provided input values of a call to the console.log precompile contract as a log string.
+// If the first value is a format string and there are values to format, they are formatted with it. Otherwise, the
+// provided encoded input string is used.
+// Returns the log string.
+func formatConsoleLog(inputValues []any, encodedInputString string) string {
+	if len(inputValues) > 1 {
+		if stringInput, isString := inputValues[0].(string); isString && strings.Contains(stringInput, "%") {
+			return fmt.Sprintf(stringInput, inputValues[1:]...)
+		}
+	}
+	return encodedInputString
+}
+
+// ConsoleLogTracer implements tracers.Tracer to capture the strings logged through the console.log precompile contract
+// during each transaction, so they can be reported for any call without collecting a full execution trace.
+type ConsoleLogTracer struct {
+	// testChain represents the underlying chain that the tracer runs on, whose console.log contract decodes logs and
+	// whose labels are used to format addresses.
+	testChain *chain.TestChain
+
+	// logs describes the strings logged during the current transaction.
+	logs []string
+
+	// nativeTracer is the underlying tracer used to capture EVM execution.
+	nativeTracer *chain.TestChainTracer
+}
+
+// NewConsoleLogTracer returns a new ConsoleLogTracer for the provided chain.
+func NewConsoleLogTracer(testChain *chain.TestChain) *ConsoleLogTracer {
+	tracer := &ConsoleLogTracer{
+		testChain: testChain,
+	}
+	innerTracer := &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnTxStart: tracer.OnTxStart,
+			OnEnter:   tracer.OnEnter,
+		},
+	}
+	tracer.nativeTracer = &chain.TestChainTracer{Tracer: innerTracer, CaptureTxEndSetAdditionalResults: tracer.CaptureTxEndSetAdditionalResults}
+	return tracer
+}
+
+// NativeTracer returns the underlying TestChainTracer.
+func (t *ConsoleLogTracer) NativeTracer() *chain.TestChainTracer {
+	return t.nativeTracer
+}
+
+// OnTxStart is called upon the start of transaction execution, as defined by tracers.Tracer.
+func (t *ConsoleLogTracer) OnTxStart(vm *tracing.VMContext, tx *coretypes.Transaction, from common.Address) {
+	t.logs = nil
+}
+
+// OnEnter is called upon entering of the call frame, as defined by tracers.Tracer. It decodes calls to the
+// console.log precompile contract into log strings.
+func (t *ConsoleLogTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if to != chain.ConsoleLogContractAddress || len(input) < 4 {
+		return
+	}
+	consoleLogContract := t.testChain.ConsoleLogContract()
+	if consoleLogContract == nil {
+		return
+	}
+
+	// Decode the call into the values logged, skipping calls which do not match a console.log function.
+	method, err := consoleLogContract.Abi().MethodById(input)
+	if err != nil {
+		return
+	}
+	inputValues, err := method.Inputs.Unpack(input[4:])
+	if err != nil || len(inputValues) == 0 {
+		return
+	}
+	encodedInputString, err := valuegeneration.EncodeABIArgumentsToString(method.Inputs, inputValues, t.testChain.Labels)
+	if err != nil {
+		return
+	}
+	if consoleLogString := formatConsoleLog(inputValues, encodedInputString); len(consoleLogString) > 0 {
+		t.logs = append(t.logs, consoleLogString)
+	}
+}
+
+// CaptureTxEndSetAdditionalResults can be used to set additional results captured from execution tracing. If this
+// tracer is used during transaction execution (block creation), the results can later be queried from the block.
+// This method will only be called on the added tracer if it implements the extended TestChainTracer interface.
+func (t *ConsoleLogTracer) CaptureTxEndSetAdditionalResults(results *types.MessageResults) {
+	// Store our tracer results, if anything was logged.
+	if len(t.logs) > 0 {
+		results.AdditionalResults[consoleLogTracerResultsKey] = t.logs
+	}
+	t.logs = nil
+}
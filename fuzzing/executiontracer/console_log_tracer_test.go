@@ -0,0 +1,55 @@
+package executiontracer
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/crytic/medusa-geth/accounts/abi"
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core"
+	gethTypes "github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa-geth/crypto"
+	"github.com/crytic/medusa/chain"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestConsoleLogTracer ensures a ConsoleLogTracer captures the formatted strings logged through the console.log
+// precompile contract.
+func TestConsoleLogTracer(t *testing.T) {
+	// Create a chain with cheat codes enabled and a console.log tracer attached.
+	sender := common.HexToAddress("0x10000")
+	genesisAlloc := gethTypes.GenesisAlloc{
+		sender: {Balance: big.NewInt(1_000_000)},
+	}
+	testChain, err := chain.NewTestChain(context.Background(), genesisAlloc, nil)
+	assert.NoError(t, err)
+	defer testChain.Close()
+	assert.NotNil(t, testChain.ConsoleLogContract())
+	testChain.AddTracer(NewConsoleLogTracer(testChain).NativeTracer(), true, false)
+
+	// Call log(string,uint256) with a format string.
+	typeString, err := abi.NewType("string", "", nil)
+	assert.NoError(t, err)
+	typeUint256, err := abi.NewType("uint256", "", nil)
+	assert.NoError(t, err)
+	args, err := abi.Arguments{{Type: typeString}, {Type: typeUint256}}.Pack("value=%v", big.NewInt(7))
+	assert.NoError(t, err)
+	data := append(crypto.Keccak256([]byte("log(string,uint256)"))[:4], args...)
+
+	_, err = testChain.PendingBlockCreate()
+	assert.NoError(t, err)
+	err = testChain.PendingBlockAddTx(&core.Message{
+		From:      sender,
+		To:        &chain.ConsoleLogContractAddress,
+		Value:     big.NewInt(0),
+		GasLimit:  1_000_000,
+		GasPrice:  big.NewInt(0),
+		GasFeeCap: big.NewInt(0),
+		GasTipCap: big.NewInt(0),
+		Data:      data,
+	})
+	assert.NoError(t, err)
+
+	assert.EqualValues(t, []string{"value=7"}, GetConsoleLogTracerResults(testChain.PendingBlock().MessageResults[0]))
+}
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"github.com/crytic/medusa/fuzzing/config"
-	"regexp"
 	"strings"
 
 	"github.com/crytic/medusa-geth/common"
@@ -129,17 +128,7 @@ func (t *ExecutionTrace) generateCallFrameEnterElements(callFrame *CallFrame) ([
 
 			// If the call was made to the console log precompile address, let's retrieve the log and format it
 			if callFrame.ToAddress == chain.ConsoleLogContractAddress {
-				// First, attempt to do string formatting if the first element is a string, has a percent sign in it,
-				// and there is at least one argument provided for formatting.
-				exp := regexp.MustCompile(`%`)
-				stringInput, isString := inputValues[0].(string)
-				if isString && exp.MatchString(stringInput) && len(inputValues) > 1 {
-					// Format the string and add it to the list of logs
-					consoleLogString = fmt.Sprintf(inputValues[0].(string), inputValues[1:]...)
-				} else {
-					// The string does not need to be formatted, and we can just use the encoded input string
-					consoleLogString = encodedInputString
-				}
+				consoleLogString = formatConsoleLog(inputValues, encodedInputString)
 
 				// Add a bullet point before the string and a new line after the string
 				if len(consoleLogString) > 0 {
@@ -188,6 +177,48 @@ func (t *ExecutionTrace) generateCallFrameEnterElements(callFrame *CallFrame) ([
 	return elements, consoleLogString
 }
 
+// generateNestedCallElements generates a list of elements describing the calls encoded within the input arguments of
+// this call frame (e.g. the calls provided to multicall(bytes[])), each preceded by the provided prefix and indented by
+// its nesting depth. The list may also hold formatting options for console output.
+func (t *ExecutionTrace) generateNestedCallElements(callFrame *CallFrame, prefix string) []any {
+	// Create list of elements
+	elements := make([]any, 0)
+
+	// Nested calls can only be decoded from calls to a resolved method
+	if callFrame.CodeContractAbi == nil || callFrame.IsContractCreation() || len(callFrame.InputData) < 4 {
+		return elements
+	}
+	method, err := callFrame.CodeContractAbi.MethodById(callFrame.InputData)
+	if err != nil {
+		return elements
+	}
+	inputValues, err := method.Inputs.Unpack(callFrame.InputData[4:])
+	if err != nil {
+		return elements
+	}
+
+	// Resolve our contract name
+	codeContractName := callFrame.CodeContractName
+	if label, ok := t.labels[callFrame.CodeAddress]; ok {
+		codeContractName = label
+	}
+
+	var appendNestedCallElements func(nestedCalls []*abiutils.NestedCall, depth int)
+	appendNestedCallElements = func(nestedCalls []*abiutils.NestedCall, depth int) {
+		for _, nestedCall := range nestedCalls {
+			argsText, err := valuegeneration.EncodeABIArgumentsToString(nestedCall.Method.Inputs, nestedCall.Args, t.labels)
+			if err != nil {
+				argsText = "<unresolved args>"
+			}
+			elements = append(elements, prefix, strings.Repeat("\t", depth), colors.CyanBold, "[nested call] ", colors.Reset)
+			elements = append(elements, fmt.Sprintf("%v.%v(%v)", codeContractName, nestedCall.Method.Sig, argsText), "\n")
+			appendNestedCallElements(nestedCall.NestedCalls, depth+1)
+		}
+	}
+	appendNestedCallElements(abiutils.UnpackNestedCalls(callFrame.CodeContractAbi, method.Inputs, inputValues), 0)
+	return elements
+}
+
 // generateCallFrameExitElements generates a list of elements describing the return data of the call frame (e.g.
 // traditional return data, assertion failure, revert data, etc.). Additionally, the list may also hold formatting options for console output.
 func (t *ExecutionTrace) generateCallFrameExitElements(callFrame *CallFrame) []any {
@@ -362,6 +393,9 @@ func (t *ExecutionTrace) generateElementsAndLogsForCallFrame(currentDepth int, c
 	// If we executed some code underneath this frame, we'll output additional information. If we did not,
 	// we shorten our trace by skipping over blank call scope returns, etc.
 	if callFrame.ExecutedCode {
+		// If the call frame's input encoded nested calls (e.g. multicall), describe them first.
+		elements = append(elements, t.generateNestedCallElements(callFrame, prefix)...)
+
 		// Loop for each operation performed in the call frame, to provide a chronological history of operations in the
 		// frame.
 		for _, operation := range callFrame.Operations {
@@ -0,0 +1,81 @@
+package explorer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/crytic/medusa-geth/common"
+)
+
+// contractCache stores verified contracts on disk, so that they are not fetched again by later campaigns. Contracts are
+// cached per source and chain, as the same address may hold different contracts on different chains.
+type contractCache struct {
+	// directory describes the directory of the cached contracts, or an empty string if caching is disabled.
+	directory string
+}
+
+// newContractCache creates a new contractCache which stores the contracts fetched from the API at the provided URL for
+// the provided chain in a subdirectory of cacheDirectory. If cacheDirectory is empty, caching is disabled.
+func newContractCache(cacheDirectory string, apiUrl string, chainId uint64) *contractCache {
+	if cacheDirectory == "" {
+		return &contractCache{}
+	}
+	source := "default"
+	if parsedUrl, err := url.Parse(apiUrl); err == nil && parsedUrl.Host != "" {
+		source = parsedUrl.Host
+	}
+	if chainId != 0 {
+		source = fmt.Sprintf("%s-%d", source, chainId)
+	}
+	return &contractCache{
+		directory: filepath.Join(cacheDirectory, source),
+	}
+}
+
+// path returns the path of the file the contract at the provided address is cached in, or an empty string if caching
+// is disabled.
+func (c *contractCache) path(address common.Address) string {
+	if c.directory == "" {
+		return ""
+	}
+	return filepath.Join(c.directory, strings.ToLower(address.Hex())+".json")
+}
+
+// read reads the contract at the provided address from the cache.
+// Returns the cached contract, or an error if it is not cached.
+func (c *contractCache) read(address common.Address) (*VerifiedContract, error) {
+	path := c.path(address)
+	if path == "" {
+		return nil, os.ErrNotExist
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var contract VerifiedContract
+	if err = json.Unmarshal(b, &contract); err != nil {
+		return nil, err
+	}
+	return &contract, nil
+}
+
+// write writes the provided contract to the cache.
+// Returns an error if one occurred.
+func (c *contractCache) write(contract *VerifiedContract) error {
+	path := c.path(contract.Address)
+	if path == "" {
+		return nil
+	}
+	b, err := json.MarshalIndent(contract, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0644)
+}
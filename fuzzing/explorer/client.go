@@ -0,0 +1,241 @@
+package explorer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/crytic/medusa-geth/common"
+)
+
+// requestTimeout describes the maximum duration of a request to the block explorer API.
+const requestTimeout = 30 * time.Second
+
+// maxRateLimitRetries describes the amount of times a request is retried after the block explorer API reported its
+// rate limit was exceeded.
+const maxRateLimitRetries = 3
+
+// ErrNotVerified is returned when the block explorer API has no verified source code for a contract.
+var ErrNotVerified = errors.New("contract source code is not verified")
+
+// ContractSource describes a source of verified contracts, such as a block explorer API.
+type ContractSource interface {
+	// Name describes the source in log messages.
+	Name() string
+
+	// FetchContract obtains the verified contract at the provided address.
+	// Returns the verified contract, ErrNotVerified if the source has no verified source code for it, or another
+	// error if the source could not be queried.
+	FetchContract(address common.Address) (*VerifiedContract, error)
+}
+
+// VerifiedContract describes the verified ABI and compilation details of an on-chain contract, as reported by a
+// block explorer.
+type VerifiedContract struct {
+	// Address describes the address of the contract.
+	Address common.Address `json:"address"`
+
+	// ContractName describes the name of the verified contract.
+	ContractName string `json:"contractName"`
+
+	// CompilerVersion describes the version of the compiler the contract was verified with (e.g. v0.8.20+commit.a1b79de6).
+	CompilerVersion string `json:"compilerVersion"`
+
+	// ABI describes the JSON ABI of the contract.
+	ABI json.RawMessage `json:"abi"`
+
+	// Implementation describes the implementation of the contract, if the block explorer reports it as a proxy, or a
+	// zero address otherwise.
+	Implementation common.Address `json:"implementation,omitempty"`
+
+	// StorageLayout describes the storage layout of the contract in the format output by solc, if the source of the
+	// contract reports it. Etherscan-compatible APIs do not.
+	StorageLayout json.RawMessage `json:"storageLayout,omitempty"`
+}
+
+// Client fetches verified contracts from an Etherscan-compatible block explorer API, such as those of Etherscan or
+// Blockscout. Fetched contracts are cached on disk, and requests are spaced out to respect the API's rate limit. It is
+// safe for concurrent use.
+type Client struct {
+	// apiUrl describes the URL of the block explorer API (e.g. https://api.etherscan.io/v2/api).
+	apiUrl string
+
+	// apiKey describes the key sent with each request, or an empty string if none is required.
+	apiKey string
+
+	// chainId describes the chain ID sent with each request, for APIs serving multiple chains, or zero if none is sent.
+	chainId uint64
+
+	// cache describes the cache fetched contracts are stored in.
+	cache *contractCache
+
+	// rateLimiter spaces out the requests sent to the API.
+	rateLimiter *rateLimiter
+
+	// httpClient describes the HTTP client used to send requests.
+	httpClient *http.Client
+}
+
+// NewClient creates a new Client which fetches verified contracts from the Etherscan-compatible API at the provided
+// URL, sending at most requestsPerSecond requests per second. If chainId is non-zero, it is sent with each request. If
+// cacheDirectory is non-empty, fetched contracts are cached in a subdirectory of it, per API and chain.
+func NewClient(apiUrl string, apiKey string, chainId uint64, cacheDirectory string, requestsPerSecond uint64) *Client {
+	var interval time.Duration
+	if requestsPerSecond > 0 {
+		interval = time.Second / time.Duration(requestsPerSecond)
+	}
+	return &Client{
+		apiUrl:      apiUrl,
+		apiKey:      apiKey,
+		chainId:     chainId,
+		cache:       newContractCache(cacheDirectory, apiUrl, chainId),
+		rateLimiter: newRateLimiter(interval),
+		httpClient:  &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// Name describes the block explorer API in log messages.
+func (c *Client) Name() string {
+	return c.apiUrl
+}
+
+// FetchContract obtains the verified contract at the provided address, from the cache if it was fetched before, or
+// from the block explorer API otherwise.
+// Returns the verified contract, ErrNotVerified if the API has no verified source code for it, or another error if
+// the API could not be queried.
+func (c *Client) FetchContract(address common.Address) (*VerifiedContract, error) {
+	if contract, err := c.cache.read(address); err == nil {
+		return contract, nil
+	}
+
+	contract, err := c.requestContract(address)
+	if err != nil {
+		return nil, err
+	}
+
+	// Failing to cache the contract does not prevent using it, it is simply fetched again next time.
+	_ = c.cache.write(contract)
+	return contract, nil
+}
+
+// sourceCodeResponse describes the response of the API's getsourcecode action.
+type sourceCodeResponse struct {
+	// Status is "1" if the request succeeded, or "0" otherwise.
+	Status string `json:"status"`
+
+	// Message describes the outcome of the request (e.g. "OK" or "NOTOK").
+	Message string `json:"message"`
+
+	// Result describes the verified contracts if the request succeeded, or an error message otherwise.
+	Result json.RawMessage `json:"result"`
+}
+
+// sourceCodeResult describes a verified contract in the response of the API's getsourcecode action.
+type sourceCodeResult struct {
+	// ABI describes the JSON ABI of the contract, or an error message if it is not verified.
+	ABI string `json:"ABI"`
+
+	// ContractName describes the name of the verified contract.
+	ContractName string `json:"ContractName"`
+
+	// CompilerVersion describes the version of the compiler the contract was verified with.
+	CompilerVersion string `json:"CompilerVersion"`
+
+	// Proxy is "1" if the block explorer detected the contract as a proxy, or "0" otherwise.
+	Proxy string `json:"Proxy"`
+
+	// Implementation describes the address of the implementation of the contract if it is a proxy.
+	Implementation string `json:"Implementation"`
+}
+
+// requestContract queries the API for the verified contract at the provided address, retrying if the API reported its
+// rate limit was exceeded.
+// Returns the verified contract, or an error if one occurred.
+func (c *Client) requestContract(address common.Address) (*VerifiedContract, error) {
+	for attempt := 0; ; attempt++ {
+		c.rateLimiter.wait()
+		response, err := c.getSourceCode(address)
+		if err != nil {
+			return nil, err
+		}
+
+		// Errors are reported with a message as the result rather than an array of contracts.
+		if response.Status != "1" {
+			var message string
+			_ = json.Unmarshal(response.Result, &message)
+			if isRateLimitMessage(message) && attempt < maxRateLimitRetries {
+				c.rateLimiter.backOff()
+				continue
+			}
+			if strings.Contains(strings.ToLower(message), "not verified") {
+				return nil, ErrNotVerified
+			}
+			return nil, fmt.Errorf("block explorer API error for %s: %s: %s", address.Hex(), response.Message, message)
+		}
+
+		var results []sourceCodeResult
+		if err = json.Unmarshal(response.Result, &results); err != nil {
+			return nil, fmt.Errorf("failed to parse the block explorer API response for %s: %w", address.Hex(), err)
+		}
+		if len(results) == 0 || results[0].ABI == "" || !json.Valid([]byte(results[0].ABI)) {
+			return nil, ErrNotVerified
+		}
+		contract := &VerifiedContract{
+			Address:         address,
+			ContractName:    results[0].ContractName,
+			CompilerVersion: results[0].CompilerVersion,
+			ABI:             json.RawMessage(results[0].ABI),
+		}
+		if results[0].Proxy == "1" && common.IsHexAddress(results[0].Implementation) {
+			contract.Implementation = common.HexToAddress(results[0].Implementation)
+		}
+		return contract, nil
+	}
+}
+
+// getSourceCode sends a single getsourcecode request for the provided address to the API.
+// Returns the parsed response, or an error if the request failed.
+func (c *Client) getSourceCode(address common.Address) (*sourceCodeResponse, error) {
+	query := url.Values{}
+	query.Set("module", "contract")
+	query.Set("action", "getsourcecode")
+	query.Set("address", address.Hex())
+	if c.chainId != 0 {
+		query.Set("chainid", strconv.FormatUint(c.chainId, 10))
+	}
+	if c.apiKey != "" {
+		query.Set("apikey", c.apiKey)
+	}
+	separator := "?"
+	if strings.Contains(c.apiUrl, "?") {
+		separator = "&"
+	}
+
+	httpResponse, err := c.httpClient.Get(c.apiUrl + separator + query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(httpResponse.Body, 1024))
+		return nil, fmt.Errorf("block explorer API responded with status %v: %s", httpResponse.Status, strings.TrimSpace(string(message)))
+	}
+
+	var response sourceCodeResponse
+	if err = json.NewDecoder(httpResponse.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to parse the block explorer API response: %w", err)
+	}
+	return &response, nil
+}
+
+// isRateLimitMessage indicates whether an error message returned by the API reports its rate limit was exceeded.
+func isRateLimitMessage(message string) bool {
+	return strings.Contains(strings.ToLower(message), "rate limit")
+}
@@ -0,0 +1,93 @@
+package explorer
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// testAbi describes the ABI served for verified contracts in tests.
+const testAbi = `[{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable"}]`
+
+// newTestServer starts an Etherscan-compatible API serving testAbi for common.Address{1}, reporting every other
+// contract as unverified. The first rateLimitedRequests requests are answered with a rate limit error. The amount of
+// requests served is counted in requestCount.
+func newTestServer(t *testing.T, rateLimitedRequests int64, requestCount *atomic.Int64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count := requestCount.Add(1)
+		query := r.URL.Query()
+		assert.EqualValues(t, "getsourcecode", query.Get("action"))
+		assert.EqualValues(t, "key", query.Get("apikey"))
+		assert.EqualValues(t, "8453", query.Get("chainid"))
+
+		switch {
+		case count <= rateLimitedRequests:
+			fmt.Fprint(w, `{"status":"0","message":"NOTOK","result":"Max rate limit reached"}`)
+		case common.HexToAddress(query.Get("address")) == common.Address{1}:
+			fmt.Fprintf(w, `{"status":"1","message":"OK","result":[{"ABI":%q,"ContractName":"Token","CompilerVersion":"v0.8.20+commit.a1b79de6","Proxy":"0","Implementation":""}]}`, testAbi)
+		case common.HexToAddress(query.Get("address")) == common.Address{3}:
+			fmt.Fprintf(w, `{"status":"1","message":"OK","result":[{"ABI":%q,"ContractName":"Proxy","CompilerVersion":"v0.8.20+commit.a1b79de6","Proxy":"1","Implementation":%q}]}`, testAbi, common.Address{1}.Hex())
+		default:
+			fmt.Fprint(w, `{"status":"1","message":"OK","result":[{"ABI":"Contract source code not verified","ContractName":"","CompilerVersion":""}]}`)
+		}
+	}))
+}
+
+// TestClientFetchContract tests that verified contracts are fetched and cached, and that unverified contracts are
+// reported as such.
+func TestClientFetchContract(t *testing.T) {
+	var requestCount atomic.Int64
+	server := newTestServer(t, 0, &requestCount)
+	defer server.Close()
+	cacheDirectory := t.TempDir()
+	client := NewClient(server.URL, "key", 8453, cacheDirectory, 0)
+
+	contract, err := client.FetchContract(common.Address{1})
+	assert.NoError(t, err)
+	assert.EqualValues(t, "Token", contract.ContractName)
+	assert.EqualValues(t, "v0.8.20+commit.a1b79de6", contract.CompilerVersion)
+	assert.JSONEq(t, testAbi, string(contract.ABI))
+
+	assert.EqualValues(t, common.Address{}, contract.Implementation)
+
+	_, err = client.FetchContract(common.Address{2})
+	assert.ErrorIs(t, err, ErrNotVerified)
+	assert.EqualValues(t, 2, requestCount.Load())
+
+	// Proxies report their implementation.
+	proxyContract, err := client.FetchContract(common.Address{3})
+	assert.NoError(t, err)
+	assert.EqualValues(t, common.Address{1}, proxyContract.Implementation)
+	assert.EqualValues(t, 3, requestCount.Load())
+
+	// The verified contract is served from the cache, even by another client, without querying the API.
+	server.Close()
+	cachedContract, err := NewClient(server.URL, "key", 8453, cacheDirectory, 0).FetchContract(common.Address{1})
+	assert.NoError(t, err)
+	assert.EqualValues(t, contract.ContractName, cachedContract.ContractName)
+	assert.EqualValues(t, contract.CompilerVersion, cachedContract.CompilerVersion)
+	assert.JSONEq(t, testAbi, string(cachedContract.ABI))
+	assert.EqualValues(t, 3, requestCount.Load())
+
+	// Contracts are cached per chain.
+	_, err = NewClient(server.URL, "key", 1, cacheDirectory, 0).FetchContract(common.Address{1})
+	assert.Error(t, err)
+}
+
+// TestClientFetchContractRateLimited tests that requests answered with a rate limit error are retried.
+func TestClientFetchContractRateLimited(t *testing.T) {
+	var requestCount atomic.Int64
+	server := newTestServer(t, 1, &requestCount)
+	defer server.Close()
+	client := NewClient(server.URL, "key", 8453, "", 0)
+
+	contract, err := client.FetchContract(common.Address{1})
+	assert.NoError(t, err)
+	assert.EqualValues(t, "Token", contract.ContractName)
+	assert.EqualValues(t, 2, requestCount.Load())
+}
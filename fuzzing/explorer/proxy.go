@@ -0,0 +1,109 @@
+package explorer
+
+import (
+	"bytes"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/common/hexutil"
+)
+
+// ProxyKind describes the standard a proxy contract follows to store the address of its implementation.
+type ProxyKind string
+
+const (
+	// EIP1967Proxy describes proxies storing their implementation in the EIP-1967 implementation slot, such as
+	// transparent and UUPS proxies.
+	EIP1967Proxy ProxyKind = "EIP-1967"
+
+	// BeaconProxy describes proxies storing a beacon in the EIP-1967 beacon slot, whose implementation() returns the
+	// implementation.
+	BeaconProxy ProxyKind = "EIP-1967 beacon"
+
+	// EIP1822Proxy describes proxies storing their implementation in the EIP-1822 (UUPS) PROXIABLE slot.
+	EIP1822Proxy ProxyKind = "EIP-1822"
+
+	// ZeppelinOSProxy describes proxies storing their implementation in the slot of the legacy OpenZeppelin proxies.
+	ZeppelinOSProxy ProxyKind = "ZeppelinOS"
+
+	// EIP1167Proxy describes minimal proxies, whose runtime bytecode embeds the implementation.
+	EIP1167Proxy ProxyKind = "EIP-1167"
+)
+
+var (
+	// eip1967ImplementationSlot is bytes32(uint256(keccak256("eip1967.proxy.implementation")) - 1).
+	eip1967ImplementationSlot = common.HexToHash("0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc")
+
+	// eip1967BeaconSlot is bytes32(uint256(keccak256("eip1967.proxy.beacon")) - 1).
+	eip1967BeaconSlot = common.HexToHash("0xa3f0ad74e5423aebfd80d3ef4346578335a9a72aeaee59ff6cb3582b35133d50")
+
+	// eip1822ProxiableSlot is keccak256("PROXIABLE").
+	eip1822ProxiableSlot = common.HexToHash("0xc5f16f0fcc639fa48a6947836d9850f504798523bf8c9a3a87d5876cf622bcf7")
+
+	// zeppelinOSImplementationSlot is keccak256("org.zeppelinos.proxy.implementation").
+	zeppelinOSImplementationSlot = common.HexToHash("0x7050c9e0f4ca769c69bd3a8ef740bc37934f8e2c036e5a723fd8ee048ed3f8c3")
+
+	// beaconImplementationSelector is the selector of implementation(), called on beacons.
+	beaconImplementationSelector = hexutil.Bytes{0x5c, 0x60, 0xda, 0x1b}
+
+	// eip1167Prefix and eip1167Suffix surround the implementation address in the runtime bytecode of minimal proxies.
+	eip1167Prefix = common.FromHex("0x363d3d373d3d3d363d73")
+	eip1167Suffix = common.FromHex("0x5af43d82803e903d91602b57fd5bf3")
+)
+
+// RpcCaller describes a JSON-RPC client used to query the state of a chain, such as rpc.Client.
+type RpcCaller interface {
+	// Call performs a JSON-RPC call with the provided method and arguments, unmarshalling its result into result.
+	Call(result any, method string, args ...any) error
+}
+
+// ResolveProxy determines whether the contract at the provided address is a proxy following a standard layout, by
+// querying its storage slots and runtime bytecode at the provided block (e.g. "latest" or a hex block number).
+// Returns the address of the implementation and the kind of proxy, a zero address if the contract is not a proxy, or
+// an error if the chain could not be queried.
+func ResolveProxy(caller RpcCaller, address common.Address, block string) (common.Address, ProxyKind, error) {
+	// Check the implementation slots, which are empty for contracts which are not proxies.
+	slots := []struct {
+		slot common.Hash
+		kind ProxyKind
+	}{
+		{eip1967ImplementationSlot, EIP1967Proxy},
+		{eip1967BeaconSlot, BeaconProxy},
+		{eip1822ProxiableSlot, EIP1822Proxy},
+		{zeppelinOSImplementationSlot, ZeppelinOSProxy},
+	}
+	for _, s := range slots {
+		var value common.Hash
+		if err := caller.Call(&value, "eth_getStorageAt", address, s.slot, block); err != nil {
+			return common.Address{}, "", err
+		}
+		slotAddress := common.BytesToAddress(value[12:])
+		if slotAddress == (common.Address{}) {
+			continue
+		}
+		if s.kind != BeaconProxy {
+			return slotAddress, s.kind, nil
+		}
+
+		// Beacons are asked for the implementation.
+		var result hexutil.Bytes
+		call := map[string]any{"to": slotAddress, "data": beaconImplementationSelector}
+		if err := caller.Call(&result, "eth_call", call, block); err != nil {
+			return common.Address{}, "", err
+		}
+		if len(result) < 32 {
+			continue
+		}
+		return common.BytesToAddress(result[12:32]), s.kind, nil
+	}
+
+	// Minimal proxies embed the implementation in their code rather than their storage.
+	var code hexutil.Bytes
+	if err := caller.Call(&code, "eth_getCode", address, block); err != nil {
+		return common.Address{}, "", err
+	}
+	if len(code) == len(eip1167Prefix)+common.AddressLength+len(eip1167Suffix) &&
+		bytes.HasPrefix(code, eip1167Prefix) && bytes.HasSuffix(code, eip1167Suffix) {
+		return common.BytesToAddress(code[len(eip1167Prefix) : len(eip1167Prefix)+common.AddressLength]), EIP1167Proxy, nil
+	}
+	return common.Address{}, "", nil
+}
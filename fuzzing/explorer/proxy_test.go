@@ -0,0 +1,79 @@
+package explorer
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/common/hexutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// testChainState describes the state of a chain served by an RpcCaller in tests.
+type testChainState struct {
+	// storage describes the storage slots of each account. Missing slots are zero.
+	storage map[common.Address]map[common.Hash]common.Hash
+
+	// code describes the runtime bytecode of each account.
+	code map[common.Address][]byte
+
+	// beaconImplementations describes the implementation returned by each beacon.
+	beaconImplementations map[common.Address]common.Address
+}
+
+// Call serves the eth_getStorageAt, eth_getCode and eth_call requests used to resolve proxies from the chain state.
+func (s *testChainState) Call(result any, method string, args ...any) error {
+	var value any
+	switch method {
+	case "eth_getStorageAt":
+		value = s.storage[args[0].(common.Address)][args[1].(common.Hash)]
+	case "eth_getCode":
+		value = hexutil.Bytes(s.code[args[0].(common.Address)])
+	case "eth_call":
+		beacon := args[0].(map[string]any)["to"].(common.Address)
+		value = hexutil.Bytes(common.BytesToHash(s.beaconImplementations[beacon].Bytes()).Bytes())
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, result)
+}
+
+// TestResolveProxy tests that the implementations of standard proxies are resolved, and that other contracts are not
+// reported as proxies.
+func TestResolveProxy(t *testing.T) {
+	implementation := common.Address{0xaa}
+	beacon := common.Address{0xbb}
+	state := &testChainState{
+		storage: map[common.Address]map[common.Hash]common.Hash{
+			{1}: {eip1967ImplementationSlot: common.BytesToHash(implementation[:])},
+			{2}: {eip1967BeaconSlot: common.BytesToHash(beacon[:])},
+			{3}: {eip1822ProxiableSlot: common.BytesToHash(implementation[:])},
+			{4}: {zeppelinOSImplementationSlot: common.BytesToHash(implementation[:])},
+		},
+		code: map[common.Address][]byte{
+			{5}: append(append(append([]byte{}, eip1167Prefix...), implementation[:]...), eip1167Suffix...),
+			{6}: testDispatcherBytecode,
+		},
+		beaconImplementations: map[common.Address]common.Address{beacon: implementation},
+	}
+
+	expectedKinds := map[common.Address]ProxyKind{
+		{1}: EIP1967Proxy,
+		{2}: BeaconProxy,
+		{3}: EIP1822Proxy,
+		{4}: ZeppelinOSProxy,
+		{5}: EIP1167Proxy,
+	}
+	for proxy, expectedKind := range expectedKinds {
+		resolved, kind, err := ResolveProxy(state, proxy, "latest")
+		assert.NoError(t, err)
+		assert.EqualValues(t, implementation, resolved, "proxy %v", proxy)
+		assert.EqualValues(t, expectedKind, kind, "proxy %v", proxy)
+	}
+
+	resolved, _, err := ResolveProxy(state, common.Address{6}, "latest")
+	assert.NoError(t, err)
+	assert.EqualValues(t, common.Address{}, resolved)
+}
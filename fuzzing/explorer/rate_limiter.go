@@ -0,0 +1,51 @@
+package explorer
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter spaces out requests so that at most one is sent per interval.
+type rateLimiter struct {
+	// interval describes the minimum duration between two requests.
+	interval time.Duration
+
+	// next describes the earliest time the next request may be sent at.
+	next time.Time
+
+	// lock provides thread-synchronization between concurrent requests.
+	lock sync.Mutex
+}
+
+// newRateLimiter creates a new rateLimiter which lets a request through every interval. A zero interval lets every
+// request through immediately.
+func newRateLimiter(interval time.Duration) *rateLimiter {
+	return &rateLimiter{
+		interval: interval,
+	}
+}
+
+// wait blocks until the next request may be sent, and reserves its slot.
+func (r *rateLimiter) wait() {
+	r.lock.Lock()
+	now := time.Now()
+	sendAt := r.next
+	if sendAt.Before(now) {
+		sendAt = now
+	}
+	r.next = sendAt.Add(r.interval)
+	r.lock.Unlock()
+
+	time.Sleep(time.Until(sendAt))
+}
+
+// backOff delays the next request by a second, after the API reported its rate limit was exceeded regardless.
+func (r *rateLimiter) backOff() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delayed := time.Now().Add(time.Second)
+	if r.next.Before(delayed) {
+		r.next = delayed
+	}
+}
@@ -0,0 +1,96 @@
+package explorer
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core/vm"
+)
+
+// instruction describes a decoded EVM instruction.
+type instruction struct {
+	// op describes the opcode of the instruction.
+	op vm.OpCode
+
+	// data describes the immediate data pushed by PUSH instructions, or nil for other instructions.
+	data []byte
+}
+
+// decodeInstructions decodes the provided bytecode into its instructions. Truncated PUSH data at the end of the
+// bytecode (e.g. within the metadata) is kept as-is.
+func decodeInstructions(code []byte) []instruction {
+	instructions := make([]instruction, 0, len(code))
+	for pc := 0; pc < len(code); pc++ {
+		op := vm.OpCode(code[pc])
+		if op < vm.PUSH1 || op > vm.PUSH32 {
+			instructions = append(instructions, instruction{op: op})
+			continue
+		}
+		end := min(pc+1+int(op-vm.PUSH1)+1, len(code))
+		instructions = append(instructions, instruction{op: op, data: code[pc+1 : end]})
+		pc = end - 1
+	}
+	return instructions
+}
+
+// ExtractSelectors heuristically extracts the function selectors a contract's dispatcher compares the calldata against
+// from its runtime bytecode. The dispatchers emitted by solc compare the selector with each of the contract's functions
+// using a "PUSH4 <selector> EQ PUSH <destination> JUMPI" sequence (possibly with a DUP before the EQ), using a shorter
+// PUSH for selectors with leading zero bytes.
+// Returns the selectors in the order they are compared, without duplicates.
+func ExtractSelectors(code []byte) [][4]byte {
+	instructions := decodeInstructions(code)
+	selectors := make([][4]byte, 0)
+	seen := make(map[[4]byte]struct{})
+	for i, ins := range instructions {
+		// Selectors with more than one leading zero byte are too rare to be worth the false positives of shorter PUSHes.
+		if (ins.op != vm.PUSH3 && ins.op != vm.PUSH4) || len(ins.data) != int(ins.op-vm.PUSH1)+1 {
+			continue
+		}
+
+		// Find the comparison, skipping a DUP which brings the calldata selector to the top of the stack.
+		next := i + 1
+		if next < len(instructions) && instructions[next].op >= vm.DUP1 && instructions[next].op <= vm.DUP16 {
+			next++
+		}
+		if next+2 >= len(instructions) || instructions[next].op != vm.EQ {
+			continue
+		}
+		if !instructions[next+1].op.IsPush() || instructions[next+2].op != vm.JUMPI {
+			continue
+		}
+
+		var selector [4]byte
+		copy(selector[4-len(ins.data):], ins.data)
+		if _, exists := seen[selector]; !exists {
+			seen[selector] = struct{}{}
+			selectors = append(selectors, selector)
+		}
+	}
+	return selectors
+}
+
+// SynthesizeContract synthesizes a fuzzable contract from the runtime bytecode of an unverified contract, by extracting
+// the selectors its dispatcher compares against and resolving them to the bundled function signatures. Selectors which
+// resolve to multiple signatures use the first one. The synthesized contract has no name or compiler version.
+// Returns the synthesized contract and the selectors which could not be resolved, or an error if none could be.
+func SynthesizeContract(address common.Address, code []byte) (*VerifiedContract, [][4]byte, error) {
+	selectors := ExtractSelectors(code)
+	signatures := make([]string, 0, len(selectors))
+	unresolved := make([][4]byte, 0)
+	for _, selector := range selectors {
+		if matches := LookupSignatures(selector); len(matches) > 0 {
+			signatures = append(signatures, matches[0])
+		} else {
+			unresolved = append(unresolved, selector)
+		}
+	}
+	if len(signatures) == 0 {
+		return nil, unresolved, fmt.Errorf("none of the %d selector(s) found in the bytecode of %s are known", len(selectors), address.Hex())
+	}
+	return &VerifiedContract{
+		Address: address,
+		ABI:     json.RawMessage(SynthesizeAbi(signatures)),
+	}, unresolved, nil
+}
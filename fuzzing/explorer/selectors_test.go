@@ -0,0 +1,56 @@
+package explorer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/crytic/medusa-geth/accounts/abi"
+	"github.com/crytic/medusa-geth/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// testDispatcherBytecode describes the runtime bytecode of a contract whose dispatcher compares the calldata selector
+// against transfer(address,uint256), an unknown selector, and balanceOf(address) using the layouts emitted by solc.
+// A PUSH4 which is not part of a comparison and a truncated PUSH4 at the end are included as well.
+var testDispatcherBytecode = common.FromHex(
+	"60003560e01c" + // PUSH1 0 CALLDATALOAD PUSH1 0xe0 SHR
+		"8063a9059cbb14610040" + "57" + // DUP1 PUSH4 transfer EQ PUSH2 0x0040 JUMPI
+		"63deadbeef8114610050" + "57" + // PUSH4 unknown DUP2 EQ PUSH2 0x0050 JUMPI
+		"806370a08231146100" + "6057" + // DUP1 PUSH4 balanceOf EQ PUSH2 0x0060 JUMPI
+		"8063a9059cbb14610040" + "57" + // a duplicate comparison
+		"63ffffffff16" + // PUSH4 mask AND
+		"5b00" + // JUMPDEST STOP
+		"63a9059c", // truncated PUSH4
+)
+
+// TestExtractSelectors tests that the selectors compared by a dispatcher are extracted in order, without duplicates.
+func TestExtractSelectors(t *testing.T) {
+	selectors := ExtractSelectors(testDispatcherBytecode)
+	assert.EqualValues(t, [][4]byte{
+		{0xa9, 0x05, 0x9c, 0xbb},
+		{0xde, 0xad, 0xbe, 0xef},
+		{0x70, 0xa0, 0x82, 0x31},
+	}, selectors)
+
+	// Selectors with a leading zero byte are pushed with a PUSH3.
+	selectors = ExtractSelectors(common.FromHex("8062fdd58e1461004057"))
+	assert.EqualValues(t, [][4]byte{{0x00, 0xfd, 0xd5, 0x8e}}, selectors)
+}
+
+// TestSynthesizeContract tests that a fuzzable ABI is synthesized from the known selectors of a dispatcher, and that
+// unknown selectors are reported.
+func TestSynthesizeContract(t *testing.T) {
+	contract, unresolved, err := SynthesizeContract(common.Address{1}, testDispatcherBytecode)
+	assert.NoError(t, err)
+	assert.EqualValues(t, [][4]byte{{0xde, 0xad, 0xbe, 0xef}}, unresolved)
+
+	contractAbi, err := abi.JSON(strings.NewReader(string(contract.ABI)))
+	assert.NoError(t, err)
+	assert.Len(t, contractAbi.Methods, 2)
+	assert.EqualValues(t, common.FromHex("a9059cbb"), contractAbi.Methods["transfer"].ID)
+	assert.EqualValues(t, common.FromHex("70a08231"), contractAbi.Methods["balanceOf"].ID)
+
+	// Contracts without known selectors cannot be synthesized.
+	_, _, err = SynthesizeContract(common.Address{1}, common.FromHex("63deadbeef8114610050576000"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,77 @@
+package explorer
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/crytic/medusa-geth/crypto"
+)
+
+// bundledSignatures describes the bundled function signatures, one per line.
+//
+//go:embed signatures.txt
+var bundledSignatures string
+
+var (
+	// signatureDatabase maps selectors to the bundled function signatures which hash to them.
+	signatureDatabase map[[4]byte][]string
+
+	// signatureDatabaseOnce ensures signatureDatabase is built once, when it is first used.
+	signatureDatabaseOnce sync.Once
+)
+
+// LookupSignatures obtains the bundled function signatures (e.g. "transfer(address,uint256)") which hash to the
+// provided selector.
+// Returns the matching signatures, or an empty slice if none are known.
+func LookupSignatures(selector [4]byte) []string {
+	signatureDatabaseOnce.Do(func() {
+		signatureDatabase = make(map[[4]byte][]string)
+		for _, line := range strings.Split(bundledSignatures, "\n") {
+			signature := strings.TrimSpace(line)
+			if signature == "" || strings.HasPrefix(signature, "#") {
+				continue
+			}
+			var hashSelector [4]byte
+			copy(hashSelector[:], crypto.Keccak256([]byte(signature))[:4])
+			signatureDatabase[hashSelector] = append(signatureDatabase[hashSelector], signature)
+		}
+	})
+	return signatureDatabase[selector]
+}
+
+// SynthesizeAbi synthesizes a JSON ABI from the provided function signatures (e.g. "transfer(address,uint256)").
+// Functions are assumed to be payable, to allow fuzzing with value transfers, and to have no outputs. Signatures which
+// are not formatted as such are skipped.
+// Returns the ABI as a JSON string.
+func SynthesizeAbi(signatures []string) string {
+	var methodAbis []string
+	for _, sig := range signatures {
+		parts := strings.SplitN(sig, "(", 2)
+		if len(parts) < 2 {
+			continue // Invalid signature format
+		}
+		name := parts[0]
+		inputTypesStr := strings.TrimSuffix(parts[1], ")")
+
+		var inputTypes []string
+		if inputTypesStr != "" {
+			inputTypes = strings.Split(inputTypesStr, ",")
+		}
+
+		var inputsJson []string
+		for i, inputType := range inputTypes {
+			inputsJson = append(inputsJson, fmt.Sprintf(`{"name": "arg%d", "type": "%s"}`, i, inputType))
+		}
+
+		methodAbi := fmt.Sprintf(
+			`{"type": "function", "name": "%s", "inputs": [%s], "outputs": [], "stateMutability": "payable"}`,
+			name,
+			strings.Join(inputsJson, ","),
+		)
+		methodAbis = append(methodAbis, methodAbi)
+	}
+
+	return "[" + strings.Join(methodAbis, ",") + "]"
+}
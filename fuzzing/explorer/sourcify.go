@@ -0,0 +1,113 @@
+package explorer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/crytic/medusa-geth/common"
+)
+
+// SourcifyClient fetches verified contracts from a Sourcify server's API. Fetched contracts are cached on disk. It is
+// safe for concurrent use.
+type SourcifyClient struct {
+	// apiUrl describes the URL of the Sourcify server (e.g. https://sourcify.dev/server).
+	apiUrl string
+
+	// chainId describes the chain the contracts are fetched for.
+	chainId uint64
+
+	// cache describes the cache fetched contracts are stored in.
+	cache *contractCache
+
+	// httpClient describes the HTTP client used to send requests.
+	httpClient *http.Client
+}
+
+// NewSourcifyClient creates a new SourcifyClient which fetches verified contracts of the provided chain from the
+// Sourcify server at the provided URL. If cacheDirectory is non-empty, fetched contracts are cached in a subdirectory
+// of it, per server and chain.
+func NewSourcifyClient(apiUrl string, chainId uint64, cacheDirectory string) *SourcifyClient {
+	return &SourcifyClient{
+		apiUrl:     strings.TrimSuffix(apiUrl, "/"),
+		chainId:    chainId,
+		cache:      newContractCache(cacheDirectory, apiUrl, chainId),
+		httpClient: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// sourcifyContractResponse describes the response of the Sourcify API's contract lookup.
+type sourcifyContractResponse struct {
+	// ABI describes the JSON ABI of the contract.
+	ABI json.RawMessage `json:"abi"`
+
+	// Compilation describes how the verified contract was compiled.
+	Compilation struct {
+		// Name describes the name of the verified contract.
+		Name string `json:"name"`
+
+		// CompilerVersion describes the version of the compiler the contract was verified with.
+		CompilerVersion string `json:"compilerVersion"`
+	} `json:"compilation"`
+
+	// StorageLayout describes the storage layout of the contract, if its compiler output one.
+	StorageLayout json.RawMessage `json:"storageLayout"`
+}
+
+// Name describes the Sourcify server in log messages.
+func (c *SourcifyClient) Name() string {
+	return c.apiUrl
+}
+
+// FetchContract obtains the verified contract at the provided address, from the cache if it was fetched before, or
+// from the Sourcify API otherwise.
+// Returns the verified contract, ErrNotVerified if Sourcify has no verified source code for it, or another error if
+// the API could not be queried.
+func (c *SourcifyClient) FetchContract(address common.Address) (*VerifiedContract, error) {
+	if c.chainId == 0 {
+		return nil, errors.New("a chain ID is required to fetch contracts from Sourcify")
+	}
+	if contract, err := c.cache.read(address); err == nil {
+		return contract, nil
+	}
+
+	requestUrl := fmt.Sprintf("%s/v2/contract/%d/%s?fields=abi,compilation,storageLayout", c.apiUrl, c.chainId, address.Hex())
+	httpResponse, err := c.httpClient.Get(requestUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResponse.Body.Close()
+
+	// Sourcify reports contracts it has no verified source code for as not found.
+	if httpResponse.StatusCode == http.StatusNotFound {
+		return nil, ErrNotVerified
+	}
+	if httpResponse.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(httpResponse.Body, 1024))
+		return nil, fmt.Errorf("Sourcify API responded with status %v: %s", httpResponse.Status, strings.TrimSpace(string(message)))
+	}
+
+	var response sourcifyContractResponse
+	if err = json.NewDecoder(httpResponse.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to parse the Sourcify API response for %s: %w", address.Hex(), err)
+	}
+	if len(response.ABI) == 0 || string(response.ABI) == "null" {
+		return nil, ErrNotVerified
+	}
+	contract := &VerifiedContract{
+		Address:         address,
+		ContractName:    response.Compilation.Name,
+		CompilerVersion: response.Compilation.CompilerVersion,
+		ABI:             response.ABI,
+	}
+	if string(response.StorageLayout) != "null" {
+		contract.StorageLayout = response.StorageLayout
+	}
+
+	// Failing to cache the contract does not prevent using it, it is simply fetched again next time.
+	_ = c.cache.write(contract)
+	return contract, nil
+}
@@ -0,0 +1,52 @@
+package explorer
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// testStorageLayout describes the storage layout of a contract with a single state variable.
+const testStorageLayout = `{"storage":[{"label":"totalSupply","contract":"Token.sol:Token","slot":"0","offset":0,"type":"t_uint256"}],"types":{"t_uint256":{"encoding":"inplace","label":"uint256","numberOfBytes":"32"}}}`
+
+// TestSourcifyClientFetchContract tests that contracts verified on Sourcify are fetched and cached, and that
+// unverified contracts are reported as such.
+func TestSourcifyClientFetchContract(t *testing.T) {
+	var requestCount atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount.Add(1)
+		if r.URL.Path != "/v2/contract/8453/"+(common.Address{1}).Hex() {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"customCode":"not_found","message":"Contract not found"}`)
+			return
+		}
+		assert.EqualValues(t, "abi,compilation,storageLayout", r.URL.Query().Get("fields"))
+		fmt.Fprintf(w, `{"abi":%s,"compilation":{"name":"Token","compilerVersion":"0.8.20+commit.a1b79de6"},"storageLayout":%s,"match":"exact_match"}`, testAbi, testStorageLayout)
+	}))
+	defer server.Close()
+	cacheDirectory := t.TempDir()
+	client := NewSourcifyClient(server.URL+"/", 8453, cacheDirectory)
+
+	contract, err := client.FetchContract(common.Address{1})
+	assert.NoError(t, err)
+	assert.EqualValues(t, "Token", contract.ContractName)
+	assert.EqualValues(t, "0.8.20+commit.a1b79de6", contract.CompilerVersion)
+	assert.JSONEq(t, testAbi, string(contract.ABI))
+	assert.JSONEq(t, testStorageLayout, string(contract.StorageLayout))
+
+	_, err = client.FetchContract(common.Address{2})
+	assert.ErrorIs(t, err, ErrNotVerified)
+
+	// The verified contract is served from the cache without querying the API.
+	server.Close()
+	cachedContract, err := NewSourcifyClient(server.URL, 8453, cacheDirectory).FetchContract(common.Address{1})
+	assert.NoError(t, err)
+	assert.JSONEq(t, testAbi, string(cachedContract.ABI))
+	assert.JSONEq(t, testStorageLayout, string(cachedContract.StorageLayout))
+	assert.EqualValues(t, 2, requestCount.Load())
+}
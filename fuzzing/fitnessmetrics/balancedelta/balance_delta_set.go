@@ -0,0 +1,116 @@
+package balancedelta
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/crytic/medusa-geth/common"
+)
+
+// BalanceKey identifies a balance tracked for net deltas: the balance of an asset held by an address.
+type BalanceKey struct {
+	// Holder describes the address holding the balance.
+	Holder common.Address
+	// Token describes the address of the ERC20 token the balance is in, or the zero address for ether.
+	Token common.Address
+}
+
+// BalanceDelta describes the net change of a tracked balance.
+type BalanceDelta struct {
+	BalanceKey
+	// Delta describes the net change of the balance, which is negative if the balance decreased.
+	Delta *big.Int
+}
+
+// BalanceDeltaSet describes net deltas of tracked balances. As tracer results, it describes the net deltas over the
+// call sequence so far. Once merged, it describes the largest net delta each balance achieved over any call sequence.
+type BalanceDeltaSet struct {
+	deltas map[BalanceKey]*big.Int
+	lock   sync.RWMutex
+}
+
+// NewBalanceDeltaSet initializes a new BalanceDeltaSet object.
+func NewBalanceDeltaSet() *BalanceDeltaSet {
+	maps := &BalanceDeltaSet{}
+	maps.Reset()
+	return maps
+}
+
+// Reset clears the net deltas of the BalanceDeltaSet.
+func (ds *BalanceDeltaSet) Reset() {
+	ds.deltas = make(map[BalanceKey]*big.Int)
+}
+
+// Update updates the current set with the net deltas of the provided one, keeping the largest net delta of each
+// balance.
+// Returns a boolean indicating whether any balance achieved a larger net gain than before, or an error if one occurred.
+func (ds *BalanceDeltaSet) Update(balanceDeltaSet *BalanceDeltaSet) (bool, error) {
+	// If our maps provided are nil, do nothing
+	if balanceDeltaSet == nil {
+		return false, nil
+	}
+
+	// Acquire our thread lock and defer our unlocking for when we exit this method
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+	balanceDeltaSet.lock.RLock()
+	defer balanceDeltaSet.lock.RUnlock()
+
+	updated := false
+	for key, delta := range balanceDeltaSet.deltas {
+		if existingDelta, exists := ds.deltas[key]; exists && existingDelta.Cmp(delta) >= 0 {
+			continue
+		}
+		ds.deltas[key] = new(big.Int).Set(delta)
+		if delta.Sign() > 0 {
+			updated = true
+		}
+	}
+	return updated, nil
+}
+
+// AddDelta adds the provided change to the net delta of the provided balance.
+func (ds *BalanceDeltaSet) AddDelta(key BalanceKey, delta *big.Int) {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+
+	if existingDelta, exists := ds.deltas[key]; exists {
+		ds.deltas[key] = new(big.Int).Add(existingDelta, delta)
+	} else {
+		ds.deltas[key] = new(big.Int).Set(delta)
+	}
+}
+
+// Clone returns a copy of the BalanceDeltaSet.
+func (ds *BalanceDeltaSet) Clone() *BalanceDeltaSet {
+	ds.lock.RLock()
+	defer ds.lock.RUnlock()
+
+	clone := NewBalanceDeltaSet()
+	for key, delta := range ds.deltas {
+		clone.deltas[key] = new(big.Int).Set(delta)
+	}
+	return clone
+}
+
+// Gains returns the positive net deltas of the set, sorted by holder and then token.
+func (ds *BalanceDeltaSet) Gains() []BalanceDelta {
+	ds.lock.RLock()
+	defer ds.lock.RUnlock()
+
+	gains := make([]BalanceDelta, 0)
+	for key, delta := range ds.deltas {
+		if delta.Sign() > 0 {
+			gains = append(gains, BalanceDelta{BalanceKey: key, Delta: new(big.Int).Set(delta)})
+		}
+	}
+	sort.Slice(gains, func(i, j int) bool {
+		if c := bytes.Compare(gains[i].Holder[:], gains[j].Holder[:]); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(gains[i].Token[:], gains[j].Token[:]) < 0
+	})
+	return gains
+}
@@ -0,0 +1,185 @@
+package balancedelta
+
+import (
+	"math/big"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core/tracing"
+	coretypes "github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa-geth/crypto"
+	"github.com/crytic/medusa-geth/eth/tracers"
+	"github.com/crytic/medusa/chain"
+	"github.com/crytic/medusa/chain/types"
+)
+
+// balanceDeltaTracerResultsKey describes the key to use when storing tracer results in call message results, or when
+// querying them.
+const balanceDeltaTracerResultsKey = "BalanceDeltaTracerResults"
+
+// GetBalanceDeltaTracerResults obtains the BalanceDeltaSet stored by a BalanceDeltaTracer from message results,
+// describing the net balance deltas over the call sequence up to and including the message. This is nil if no
+// BalanceDeltaSet was recorded by a tracer (e.g. BalanceDeltaTracer was not attached during this message execution).
+func GetBalanceDeltaTracerResults(messageResults *types.MessageResults) *BalanceDeltaSet {
+	// Try to obtain the results the tracer should've stored.
+	if genericResult, ok := messageResults.AdditionalResults[balanceDeltaTracerResultsKey]; ok {
+		if castedResult, ok := genericResult.(*BalanceDeltaSet); ok {
+			return castedResult
+		}
+	}
+
+	// If we could not obtain them, return nil.
+	return nil
+}
+
+// RemoveBalanceDeltaTracerResults removes the BalanceDeltaSet stored by a BalanceDeltaTracer from message results.
+func RemoveBalanceDeltaTracerResults(messageResults *types.MessageResults) {
+	delete(messageResults.AdditionalResults, balanceDeltaTracerResultsKey)
+}
+
+// TrackedToken describes an ERC20 token whose balances are tracked, read directly from its storage.
+type TrackedToken struct {
+	// Address describes the address of the token.
+	Address common.Address
+	// BalanceSlot describes the storage slot of the token's balance mapping, whose entries are laid out as Solidity
+	// lays out mappings.
+	BalanceSlot uint64
+}
+
+// balanceSlot returns the storage slot holding the balance of the provided holder.
+func (t TrackedToken) balanceSlot(holder common.Address) common.Hash {
+	return crypto.Keccak256Hash(common.LeftPadBytes(holder.Bytes(), 32), common.BigToHash(new(big.Int).SetUint64(t.BalanceSlot)).Bytes())
+}
+
+// blockBalanceDeltas describes the net balance deltas of the transactions in a block, so they can be undone if the
+// block is removed from the chain.
+type blockBalanceDeltas struct {
+	blockNumber uint64
+	deltas      map[BalanceKey]*big.Int
+}
+
+// BalanceDeltaTracer implements tracers.Tracer to record the net deltas of the ether and ERC20 token balances of
+// tracked addresses over each call sequence. Balances are snapshotted from the StateDB at the start and end of each
+// transaction, and their deltas accumulated until the blocks they were made in are removed from the chain, so a chain
+// reverted to its testing base starts a new sequence.
+type BalanceDeltaTracer struct {
+	// holders describes the addresses whose balances are tracked.
+	holders []common.Address
+
+	// tokens describes the ERC20 tokens whose balances are tracked, in addition to ether.
+	tokens []TrackedToken
+
+	// sequenceDeltas describes the net balance deltas over the call sequence so far.
+	sequenceDeltas *BalanceDeltaSet
+
+	// blockDeltas describes the net balance deltas made in each block of the call sequence so far.
+	blockDeltas []*blockBalanceDeltas
+
+	// txStartBalances describes the tracked balances at the start of the current transaction.
+	txStartBalances map[BalanceKey]*big.Int
+
+	// evmContext holds the VM context during tracing
+	evmContext *tracing.VMContext
+
+	// nativeTracer is the underlying tracer used to capture EVM execution.
+	nativeTracer *chain.TestChainTracer
+}
+
+// NewBalanceDeltaTracer returns a new BalanceDeltaTracer tracking the ether and provided token balances of the
+// provided holders.
+func NewBalanceDeltaTracer(holders []common.Address, tokens []TrackedToken) *BalanceDeltaTracer {
+	tracer := &BalanceDeltaTracer{
+		holders:        holders,
+		tokens:         tokens,
+		sequenceDeltas: NewBalanceDeltaSet(),
+		blockDeltas:    make([]*blockBalanceDeltas, 0),
+	}
+	nativeTracer := &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnTxStart: tracer.OnTxStart,
+			OnTxEnd:   tracer.OnTxEnd,
+		},
+	}
+	tracer.nativeTracer = &chain.TestChainTracer{Tracer: nativeTracer, CaptureTxEndSetAdditionalResults: tracer.CaptureTxEndSetAdditionalResults}
+	return tracer
+}
+
+// NativeTracer returns the underlying TestChainTracer.
+func (t *BalanceDeltaTracer) NativeTracer() *chain.TestChainTracer {
+	return t.nativeTracer
+}
+
+// snapshotBalances returns the current tracked balances.
+func (t *BalanceDeltaTracer) snapshotBalances() map[BalanceKey]*big.Int {
+	balances := make(map[BalanceKey]*big.Int, len(t.holders)*(len(t.tokens)+1))
+	for _, holder := range t.holders {
+		balances[BalanceKey{Holder: holder}] = t.evmContext.StateDB.GetBalance(holder).ToBig()
+		for _, token := range t.tokens {
+			balance := t.evmContext.StateDB.GetState(token.Address, token.balanceSlot(holder))
+			balances[BalanceKey{Holder: holder, Token: token.Address}] = balance.Big()
+		}
+	}
+	return balances
+}
+
+// OnTxStart is called upon the start of transaction execution, as defined by tracers.Tracer.
+func (t *BalanceDeltaTracer) OnTxStart(vm *tracing.VMContext, tx *coretypes.Transaction, from common.Address) {
+	t.evmContext = vm
+	t.txStartBalances = t.snapshotBalances()
+}
+
+// OnTxEnd is called upon the end of transaction execution, as defined by tracers.Tracer.
+func (t *BalanceDeltaTracer) OnTxEnd(receipt *coretypes.Receipt, err error) {
+	if t.txStartBalances == nil {
+		return
+	}
+
+	// Accumulate the deltas of the transaction into those of the sequence, remembering which block they were made in.
+	blockNumber := t.evmContext.BlockNumber.Uint64()
+	if len(t.blockDeltas) == 0 || t.blockDeltas[len(t.blockDeltas)-1].blockNumber != blockNumber {
+		t.blockDeltas = append(t.blockDeltas, &blockBalanceDeltas{blockNumber: blockNumber, deltas: make(map[BalanceKey]*big.Int)})
+	}
+	currentBlockDeltas := t.blockDeltas[len(t.blockDeltas)-1]
+	for key, balance := range t.snapshotBalances() {
+		delta := new(big.Int).Sub(balance, t.txStartBalances[key])
+		t.sequenceDeltas.AddDelta(key, delta)
+		if existingDelta, exists := currentBlockDeltas.deltas[key]; exists {
+			delta.Add(delta, existingDelta)
+		}
+		currentBlockDeltas.deltas[key] = delta
+	}
+	t.txStartBalances = nil
+}
+
+// forgetFrom undoes the balance deltas made in the block with the provided number, or any later block.
+func (t *BalanceDeltaTracer) forgetFrom(blockNumber uint64) {
+	for len(t.blockDeltas) > 0 && t.blockDeltas[len(t.blockDeltas)-1].blockNumber >= blockNumber {
+		for key, delta := range t.blockDeltas[len(t.blockDeltas)-1].deltas {
+			t.sequenceDeltas.AddDelta(key, new(big.Int).Neg(delta))
+		}
+		t.blockDeltas = t.blockDeltas[:len(t.blockDeltas)-1]
+	}
+}
+
+// OnBlocksRemoved undoes the balance deltas made in the removed blocks. It is to be subscribed to the chain's
+// BlocksRemoved event.
+func (t *BalanceDeltaTracer) OnBlocksRemoved(event chain.BlocksRemovedEvent) error {
+	for _, block := range event.Blocks {
+		t.forgetFrom(block.Header.Number.Uint64())
+	}
+	return nil
+}
+
+// OnPendingBlockDiscarded undoes the balance deltas made in the discarded pending block. It is to be subscribed to the
+// chain's PendingBlockDiscarded event.
+func (t *BalanceDeltaTracer) OnPendingBlockDiscarded(event chain.PendingBlockDiscardedEvent) error {
+	t.forgetFrom(event.Block.Header.Number.Uint64())
+	return nil
+}
+
+// CaptureTxEndSetAdditionalResults can be used to set additional results captured from execution tracing. If this
+// tracer is used during transaction execution (block creation), the results can later be queried from the block.
+// This method will only be called on the added tracer if it implements the extended TestChainTracer interface.
+func (t *BalanceDeltaTracer) CaptureTxEndSetAdditionalResults(results *types.MessageResults) {
+	// Store our tracer results, as the net deltas over the sequence so far.
+	results.AdditionalResults[balanceDeltaTracerResultsKey] = t.sequenceDeltas.Clone()
+}
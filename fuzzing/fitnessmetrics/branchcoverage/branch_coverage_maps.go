@@ -1,11 +1,15 @@
 package branchcoverage
 
 import (
+	"bytes"
 	"sync"
 
 	"github.com/crytic/medusa-geth/common"
 	"github.com/crytic/medusa-geth/crypto"
 	compilationTypes "github.com/crytic/medusa/compilation/types"
+	fuzzerTypes "github.com/crytic/medusa/fuzzing/contracts"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics"
+	"github.com/crytic/medusa/utils"
 )
 
 // CoverageMaps represents a data structure used to identify branch coverage of various smart contracts
@@ -28,6 +32,14 @@ type CoverageMaps struct {
 	// cachedCodeAddress and matches the cachedCodeHash, then this map is used to avoid an expensive lookup into maps.
 	cachedMap *ContractCoverageMap
 
+	// spareMaps describes the ContractCoverageMap objects emptied by Clear, which are reused before new ones are
+	// allocated.
+	spareMaps []*ContractCoverageMap
+
+	// spareMapsByAddress describes the lookups of ContractCoverageMap objects by address emptied by Clear, which are
+	// reused before new ones are allocated.
+	spareMapsByAddress []map[common.Address]*ContractCoverageMap
+
 	// lock is a read-write mutex to offer concurrent thread safety for map accesses.
 	lock sync.RWMutex
 }
@@ -60,6 +72,125 @@ func (cm *CoverageMaps) TotalBranchCoverage(targetAddresses []common.Address) (i
 	return coveredBranchSize, totalBranchSize
 }
 
+// BranchCoverageByLookupHash returns the amount of covered and total branches of the code identified by each lookup
+// hash, summed across the addresses it was deployed at.
+func (cm *CoverageMaps) BranchCoverageByLookupHash() (map[common.Hash]int, map[common.Hash]int) {
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+
+	coveredBranchSizes := make(map[common.Hash]int)
+	totalBranchSizes := make(map[common.Hash]int)
+	for codeHash, mapsByAddress := range cm.maps {
+		for _, contractCoverageMap := range mapsByAddress {
+			c, t := contractCoverageMap.getCoverageRate()
+			coveredBranchSizes[codeHash] += c
+			totalBranchSizes[codeHash] += t
+		}
+	}
+	return coveredBranchSizes, totalBranchSizes
+}
+
+// TotalContextualBranchCoverage returns the amount of distinct (calling context, branch) pairs covered across all
+// contracts, or only those deployed at the provided target addresses if any are provided. This is zero unless coverage
+// was recorded with a calling context depth.
+func (cm *CoverageMaps) TotalContextualBranchCoverage(targetAddresses []common.Address) int {
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+
+	coveredContextualBranches := 0
+	for _, mapsByAddress := range cm.maps {
+		if len(targetAddresses) > 0 {
+			for _, address := range targetAddresses {
+				if contractCoverageMap, exists := mapsByAddress[address]; exists {
+					coveredContextualBranches += contractCoverageMap.getContextualCoverageCount()
+				}
+			}
+		} else {
+			for _, contractCoverageMap := range mapsByAddress {
+				coveredContextualBranches += contractCoverageMap.getContextualCoverageCount()
+			}
+		}
+	}
+	return coveredContextualBranches
+}
+
+// UncoveredBranchArm describes a branch arm of a JUMPI which was never taken, although the other arm was.
+type UncoveredBranchArm struct {
+	// CodeHash is the lookup hash of the contract code the branch belongs to.
+	CodeHash common.Hash
+	// Address is the address the contract code executed at.
+	Address common.Address
+	// Pc is the program counter of the JUMPI.
+	Pc uint64
+	// Jumped describes whether the uncovered arm is the jumping one.
+	Jumped bool
+}
+
+// UncoveredBranchArms returns the arms of the JUMPIs which were reached, but only ever went one way, using the
+// provided branch maps (by code lookup hash) to resolve branch ids. Contract code without a branch map is skipped.
+func (cm *CoverageMaps) UncoveredBranchArms(branchMaps map[common.Hash]*BranchMap) []UncoveredBranchArm {
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+
+	uncoveredArms := make([]UncoveredBranchArm, 0)
+	for codeHash, mapsByAddress := range cm.maps {
+		branchMap, ok := branchMaps[codeHash]
+		if !ok || branchMap == nil {
+			continue
+		}
+		for address, contractCoverageMap := range mapsByAddress {
+			for pc, falseBranchId := range branchMap.BranchIds {
+				falseCovered := contractCoverageMap.IsCovered(falseBranchId)
+				trueCovered := contractCoverageMap.IsCovered(falseBranchId + 1)
+				if falseCovered != trueCovered {
+					uncoveredArms = append(uncoveredArms, UncoveredBranchArm{CodeHash: codeHash, Address: address, Pc: pc, Jumped: falseCovered})
+				}
+			}
+		}
+	}
+	return uncoveredArms
+}
+
+// BranchMapsByLookupHash returns the branch map of the init and runtime bytecode of each provided contract, keyed by
+// the lookup hashes used to identify code in CoverageMaps.
+func BranchMapsByLookupHash(contracts fuzzerTypes.Contracts) map[common.Hash]*BranchMap {
+	branchMaps := make(map[common.Hash]*BranchMap)
+	for _, contract := range contracts {
+		compiledContract := contract.CompiledContract()
+		initBytecode := compiledContract.InitBytecode
+		runtimeBytecode := compiledContract.RuntimeBytecode
+
+		if initBytecode != nil {
+			initBytecodeHash := getContractCoverageMapHash(initBytecode, true)
+			// remove runtime bytecode (including metadata here) from init bytecode
+			if runtimeBytecodeOffset := bytes.LastIndex(initBytecode, runtimeBytecode); runtimeBytecodeOffset != -1 {
+				initBytecode = initBytecode[:runtimeBytecodeOffset]
+			}
+			branchMaps[initBytecodeHash] = GetBranchMapFromBytecode(initBytecode)
+		}
+
+		runtimeBytecodeHash := getContractCoverageMapHash(runtimeBytecode, false)
+		// remove metadata and data sections from runtime bytecode
+		runtimeCode, _ := compilationTypes.SplitRuntimeBytecode(compiledContract.InitBytecode, runtimeBytecode)
+		branchMaps[runtimeBytecodeHash] = GetBranchMapFromBytecode(runtimeCode)
+	}
+	return branchMaps
+}
+
+// NewDynamicBranchMapRegistry returns a registry which lazily builds the branch maps of runtime bytecode unknown to
+// the fuzzer the first time it executes, up to the provided maximum amount of branch maps. Branch maps are never built
+// for the provided excluded contracts.
+func NewDynamicBranchMapRegistry(excludedContracts fuzzerTypes.Contracts, maxSize int) *fitnessmetrics.CodeMapRegistry[*BranchMap] {
+	registry := fitnessmetrics.NewCodeMapRegistry(maxSize, func(code []byte) (*BranchMap, bool) {
+		branchMap := GetBranchMapFromBytecode(compilationTypes.RemoveContractMetadata(code))
+		return branchMap, branchMap != nil
+	})
+	for lookupHash := range BranchMapsByLookupHash(excludedContracts) {
+		registry.Exclude(lookupHash)
+	}
+	return registry
+}
+
 // NewCoverageMaps initializes a new CoverageMaps object.
 func NewCoverageMaps() *CoverageMaps {
 	maps := &CoverageMaps{}
@@ -78,6 +209,54 @@ func (cm *CoverageMaps) Reset() {
 	cm.cachedMap = nil
 }
 
+// Clear clears the coverage state for the CoverageMaps like Reset, but retains the memory allocated for it, so that
+// coverage recorded afterwards reuses it.
+func (cm *CoverageMaps) Clear() {
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+
+	for _, mapsByAddress := range cm.maps {
+		for _, coverageMap := range mapsByAddress {
+			coverageMap.successfulCoverage.Reset()
+			clear(coverageMap.contextualCoverage)
+			cm.spareMaps = append(cm.spareMaps, coverageMap)
+		}
+		clear(mapsByAddress)
+		cm.spareMapsByAddress = append(cm.spareMapsByAddress, mapsByAddress)
+	}
+	clear(cm.maps)
+	cm.cachedCodeAddress = common.Address{}
+	cm.cachedCodeHash = common.Hash{}
+	cm.cachedMap = nil
+}
+
+// getMapsByAddress obtains the lookup of ContractCoverageMap objects by address for the provided code hash, creating
+// it if it does not exist. The lock must be held by the caller.
+func (cm *CoverageMaps) getMapsByAddress(codeHash common.Hash) map[common.Address]*ContractCoverageMap {
+	mapsByAddress, codeHashExists := cm.maps[codeHash]
+	if !codeHashExists {
+		if count := len(cm.spareMapsByAddress); count > 0 {
+			mapsByAddress = cm.spareMapsByAddress[count-1]
+			cm.spareMapsByAddress = cm.spareMapsByAddress[:count-1]
+		} else {
+			mapsByAddress = make(map[common.Address]*ContractCoverageMap)
+		}
+		cm.maps[codeHash] = mapsByAddress
+	}
+	return mapsByAddress
+}
+
+// newContractCoverageMap obtains an empty ContractCoverageMap, reusing one emptied by Clear if possible. The lock must
+// be held by the caller.
+func (cm *CoverageMaps) newContractCoverageMap() *ContractCoverageMap {
+	if count := len(cm.spareMaps); count > 0 {
+		coverageMap := cm.spareMaps[count-1]
+		cm.spareMaps = cm.spareMaps[:count-1]
+		return coverageMap
+	}
+	return newContractCoverageMap()
+}
+
 // getContractCoverageMapHash obtain the hash used to look up a given contract's ContractCoverageMap.
 // If this is init bytecode, metadata and abi arguments will attempt to be stripped, then a hash is computed.
 // If this is runtime bytecode, the metadata ipfs/swarm hash will be used if available, otherwise the bytecode
@@ -145,14 +324,11 @@ func (cm *CoverageMaps) Update(coverageMaps *CoverageMaps) (bool, error) {
 	for codeHash, mapsByAddressToMerge := range coverageMaps.maps {
 		for codeAddress, coverageMapToMerge := range mapsByAddressToMerge {
 			// If a coverage map lookup for this code hash doesn't exist, create the mapping.
-			mapsByAddress, codeHashExists := cm.maps[codeHash]
-			if !codeHashExists {
-				mapsByAddress = make(map[common.Address]*ContractCoverageMap)
-				cm.maps[codeHash] = mapsByAddress
-			}
+			mapsByAddress := cm.getMapsByAddress(codeHash)
 
 			// If a coverage map for this address already exists in our current mapping, update it with the one
-			// to merge. If it doesn't exist, set it to the one to merge.
+			// to merge. If it doesn't exist, create it from a copy of the one to merge, as the provided maps may be
+			// cleared and reused by their owner.
 			if existingCoverageMap, codeAddressExists := mapsByAddress[codeAddress]; codeAddressExists {
 				sChanged, err := existingCoverageMap.update(coverageMapToMerge)
 				successCoverageChanged = successCoverageChanged || sChanged
@@ -160,7 +336,11 @@ func (cm *CoverageMaps) Update(coverageMaps *CoverageMaps) (bool, error) {
 					return successCoverageChanged, err
 				}
 			} else {
-				mapsByAddress[codeAddress] = coverageMapToMerge
+				newCoverageMap := cm.newContractCoverageMap()
+				if _, err := newCoverageMap.update(coverageMapToMerge); err != nil {
+					return successCoverageChanged, err
+				}
+				mapsByAddress[codeAddress] = newCoverageMap
 				successCoverageChanged = coverageMapToMerge.successfulCoverage != nil
 			}
 		}
@@ -170,8 +350,9 @@ func (cm *CoverageMaps) Update(coverageMaps *CoverageMaps) (bool, error) {
 	return successCoverageChanged, nil
 }
 
-// SetAt sets the coverage state of a given path of a branch instruction within code coverage data.
-func (cm *CoverageMaps) SetAt(codeAddress common.Address, codeLookupHash common.Hash, branchSize, id int) (bool, error) {
+// SetAt sets the coverage state of a given path of a branch instruction within code coverage data. If a calling context
+// hash is provided, the branch is additionally recorded as covered within that calling context.
+func (cm *CoverageMaps) SetAt(codeAddress common.Address, codeLookupHash common.Hash, branchSize, id int, callingContext *common.Hash) (bool, error) {
 	// If the branch size is zero, do nothing
 	if branchSize == 0 {
 		return false, nil
@@ -193,18 +374,14 @@ func (cm *CoverageMaps) SetAt(codeAddress common.Address, codeLookupHash common.
 		coverageMap = cm.cachedMap
 	} else {
 		// If a coverage map lookup for this code hash doesn't exist, create the mapping.
-		mapsByCodeAddress, codeHashExists := cm.maps[codeLookupHash]
-		if !codeHashExists {
-			mapsByCodeAddress = make(map[common.Address]*ContractCoverageMap)
-			cm.maps[codeLookupHash] = mapsByCodeAddress
-		}
+		mapsByCodeAddress := cm.getMapsByAddress(codeLookupHash)
 
 		// Obtain the coverage map for this code address if it already exists. If it does not, create a new one.
 		if existingCoverageMap, codeAddressExists := mapsByCodeAddress[codeAddress]; codeAddressExists {
 			coverageMap = existingCoverageMap
 		} else {
-			coverageMap = newContractCoverageMap()
-			cm.maps[codeLookupHash][codeAddress] = coverageMap
+			coverageMap = cm.newContractCoverageMap()
+			mapsByCodeAddress[codeAddress] = coverageMap
 			addedNewMap = true
 		}
 
@@ -215,7 +392,7 @@ func (cm *CoverageMaps) SetAt(codeAddress common.Address, codeLookupHash common.
 	}
 
 	// Set our coverage in the map and return our change state
-	changedInMap, err = coverageMap.setCoveredAt(branchSize, id)
+	changedInMap, err = coverageMap.setCoveredAt(branchSize, id, callingContext)
 	return addedNewMap || changedInMap, err
 }
 
@@ -233,6 +410,7 @@ func (cm *CoverageMaps) RevertAll() {
 
 			// Clear our successful coverage, as these maps were marked as reverted.
 			contractCoverageMap.successfulCoverage.Reset()
+			clear(contractCoverageMap.contextualCoverage)
 		}
 	}
 }
@@ -242,12 +420,18 @@ type ContractCoverageMap struct {
 	// successfulCoverage represents coverage for the contract bytecode, which did not encounter a revert and was
 	// deemed successful.
 	successfulCoverage *CoverageMapBranchData
+
+	// contextualCoverage represents successful coverage for the contract bytecode, keyed by the hash of the calling
+	// context (the chain of callers) it was achieved in. A branch already covered is new coverage again when reached
+	// through a new calling context (e.g. a pool called through a router rather than directly).
+	contextualCoverage map[common.Hash]*CoverageMapBranchData
 }
 
 // newContractCoverageMap creates and returns a new ContractCoverageMap.
 func newContractCoverageMap() *ContractCoverageMap {
 	return &ContractCoverageMap{
 		successfulCoverage: &CoverageMapBranchData{},
+		contextualCoverage: make(map[common.Hash]*CoverageMapBranchData),
 	}
 }
 
@@ -260,15 +444,51 @@ func (cm *ContractCoverageMap) update(coverageMap *ContractCoverageMap) (bool, e
 		return false, err
 	}
 
+	// Update our coverage data for each calling context
+	for callingContext, contextualCoverageToMerge := range coverageMap.contextualCoverage {
+		contextualCoverage, exists := cm.contextualCoverage[callingContext]
+		if !exists {
+			contextualCoverage = &CoverageMapBranchData{}
+			cm.contextualCoverage[callingContext] = contextualCoverage
+		}
+		contextualCoverageChanged, err := contextualCoverage.update(contextualCoverageToMerge)
+		if err != nil {
+			return false, err
+		}
+		successfulCoverageChanged = successfulCoverageChanged || contextualCoverageChanged
+	}
+
 	return successfulCoverageChanged, nil
 }
 
 // setCoveredAt sets the coverage state at a given branch within a ContractCoverageMap used for
-// "successful" coverage (non-reverted).
+// "successful" coverage (non-reverted), and within the provided calling context if one is provided.
 // Returns a boolean indicating whether new coverage was achieved, or an error if one occurred.
-func (cm *ContractCoverageMap) setCoveredAt(branchSize, id int) (bool, error) {
+func (cm *ContractCoverageMap) setCoveredAt(branchSize, id int, callingContext *common.Hash) (bool, error) {
 	// Set our coverage data for the successful branch.
-	return cm.successfulCoverage.setCoveredAt(branchSize, id)
+	changed, err := cm.successfulCoverage.setCoveredAt(branchSize, id)
+	if err != nil || callingContext == nil {
+		return changed, err
+	}
+
+	// Set our coverage data for the successful branch within its calling context.
+	contextualCoverage, exists := cm.contextualCoverage[*callingContext]
+	if !exists {
+		contextualCoverage = &CoverageMapBranchData{}
+		cm.contextualCoverage[*callingContext] = contextualCoverage
+	}
+	contextualChanged, err := contextualCoverage.setCoveredAt(branchSize, id)
+	return changed || contextualChanged, err
+}
+
+// IsCovered checks if a given branch was taken successfully within the contract.
+// Returns a boolean indicating if the branch was taken on this map.
+func (cm *ContractCoverageMap) IsCovered(id int) bool {
+	// If the contract coverage map is nil, the contract was never executed.
+	if cm == nil {
+		return false
+	}
+	return cm.successfulCoverage.IsCovered(id)
 }
 
 // getCoverageRate returns the covered branch size and the total branch size of the contract.
@@ -276,6 +496,16 @@ func (cm *ContractCoverageMap) getCoverageRate() (int, int) {
 	return cm.successfulCoverage.getCoverageRate()
 }
 
+// getContextualCoverageCount returns the amount of distinct (calling context, branch) pairs covered in the contract.
+func (cm *ContractCoverageMap) getContextualCoverageCount() int {
+	coveredContextualBranches := 0
+	for _, contextualCoverage := range cm.contextualCoverage {
+		covered, _ := contextualCoverage.getCoverageRate()
+		coveredContextualBranches += covered
+	}
+	return coveredContextualBranches
+}
+
 func (cm *ContractCoverageMap) getCoverageByteMap() []byte {
 	return cm.successfulCoverage.executedFlags
 }
@@ -286,22 +516,40 @@ type CoverageMapBranchData struct {
 	executedFlags []byte
 }
 
-// Reset resets the branch coverage map data to be empty.
+// Reset resets the branch coverage map data to be empty. The buffer holding the execution flags is retained, so that
+// coverage recorded afterwards reuses it.
 func (cm *CoverageMapBranchData) Reset() {
-	cm.executedFlags = nil
+	cm.executedFlags = cm.executedFlags[:0]
+}
+
+// IsCovered checks if a given branch id is covered by the map.
+// Returns a boolean indicating if the branch was taken on this map.
+func (cm *CoverageMapBranchData) IsCovered(id int) bool {
+	// If the coverage map branch data is nil, this is not covered.
+	if cm == nil {
+		return false
+	}
+
+	// If this map has no execution data or is out of bounds, it is not covered.
+	if id < 0 || len(cm.executedFlags) <= id {
+		return false
+	}
+
+	// Otherwise, return the execution flag
+	return cm.executedFlags[id] != 0
 }
 
 // update creates updates the current CoverageMapBranchData with the provided one.
 // Returns a boolean indicating whether new coverage was achieved, or an error if one was encountered.
 func (cm *CoverageMapBranchData) update(coverageMap *CoverageMapBranchData) (bool, error) {
-	// If the coverage map execution data provided is nil, exit early
-	if coverageMap.executedFlags == nil {
+	// If the coverage map execution data provided is empty, exit early
+	if len(coverageMap.executedFlags) == 0 {
 		return false, nil
 	}
 
-	// If the current map has no execution data, simply set it to the provided one.
-	if cm.executedFlags == nil {
-		cm.executedFlags = coverageMap.executedFlags
+	// If the current map has no execution data, simply copy the provided one.
+	if len(cm.executedFlags) == 0 {
+		cm.executedFlags = append(cm.executedFlags[:0], coverageMap.executedFlags...)
 		return true, nil
 	}
 
@@ -319,9 +567,9 @@ func (cm *CoverageMapBranchData) update(coverageMap *CoverageMapBranchData) (boo
 // setCoveredAt sets the coverage state at a given branch id within a CoverageMapBlockData.
 // Returns a boolean indicating whether new coverage was achieved, or an error if one occurred.
 func (cm *CoverageMapBranchData) setCoveredAt(branchSize, id int) (bool, error) {
-	// If the execution flags don't exist, create them for this code size.
-	if cm.executedFlags == nil {
-		cm.executedFlags = make([]byte, branchSize)
+	// If the execution flags don't exist, create them for this code size, reusing the buffer of previous flags if any.
+	if len(cm.executedFlags) == 0 {
+		cm.executedFlags = utils.SliceResizeZeroed(cm.executedFlags, branchSize)
 	}
 
 	// If our program counter is in range, determine if we achieved new coverage for the first time, and update it.
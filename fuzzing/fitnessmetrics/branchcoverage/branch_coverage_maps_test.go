@@ -0,0 +1,164 @@
+package branchcoverage
+
+import (
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa/chain/types"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCoverageMapsUpdateCopies tests that updating maps copies the coverage merged, including that of each calling
+// context, so the merged maps can be released and reused for another call frame without affecting the updated maps.
+func TestCoverageMapsUpdateCopies(t *testing.T) {
+	pool := fitnessmetrics.NewResultPool(NewCoverageMaps)
+	maps := NewCoverageMaps()
+	frameMaps := pool.Get()
+	_, err := frameMaps.SetAt(common.Address{1}, common.Hash{1}, 4, 0, &common.Hash{2})
+	assert.NoError(t, err)
+
+	changed, err := maps.Update(frameMaps)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	pool.Put(frameMaps)
+
+	// Reuse the released maps for another call frame, which starts out empty.
+	otherFrameMaps := pool.Get()
+	assert.Same(t, frameMaps, otherFrameMaps)
+	covered, total := otherFrameMaps.TotalBranchCoverage(nil)
+	assert.EqualValues(t, 0, covered)
+	assert.EqualValues(t, 0, total)
+	assert.EqualValues(t, 0, otherFrameMaps.TotalContextualBranchCoverage(nil))
+	_, err = otherFrameMaps.SetAt(common.Address{1}, common.Hash{1}, 4, 1, &common.Hash{2})
+	assert.NoError(t, err)
+
+	contractMap := maps.maps[common.Hash{1}][common.Address{1}]
+	assert.True(t, contractMap.IsCovered(0))
+	assert.False(t, contractMap.IsCovered(1))
+	assert.EqualValues(t, 1, maps.TotalContextualBranchCoverage(nil))
+	changed, err = maps.Update(otherFrameMaps)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.True(t, contractMap.IsCovered(1))
+	assert.EqualValues(t, 2, maps.TotalContextualBranchCoverage(nil))
+}
+
+// TestCoverageTracerReleaseResults tests that the tracer only releases the coverage maps of its latest transaction from
+// the message results they were stored in, and reuses them once released.
+func TestCoverageTracerReleaseResults(t *testing.T) {
+	tracer := &CoverageTracer{resultPool: fitnessmetrics.NewResultPool(NewCoverageMaps)}
+	tracer.coverageMaps = tracer.resultPool.Get()
+	coverageMaps := tracer.coverageMaps
+	_, err := coverageMaps.SetAt(common.Address{1}, common.Hash{1}, 4, 0, nil)
+	assert.NoError(t, err)
+	results := &types.MessageResults{AdditionalResults: make(map[string]any)}
+	tracer.CaptureTxEndSetAdditionalResults(results)
+
+	// Results stored in other message results are not released.
+	tracer.ReleaseResults(&types.MessageResults{AdditionalResults: make(map[string]any)})
+	assert.Same(t, coverageMaps, GetCoverageTracerResults(results))
+
+	tracer.ReleaseResults(results)
+	assert.Nil(t, GetCoverageTracerResults(results))
+	assert.Nil(t, tracer.coverageMaps)
+	assert.NotPanics(t, func() { tracer.ReleaseResults(results) })
+	assert.NotPanics(t, func() { (*CoverageTracer)(nil).ReleaseResults(results) })
+
+	// The released maps are cleared and reused for the next transaction.
+	assert.Same(t, coverageMaps, tracer.resultPool.Get())
+	covered, _ := coverageMaps.TotalBranchCoverage(nil)
+	assert.EqualValues(t, 0, covered)
+}
+
+// BenchmarkCoverageMapsPerCallFrame measures recording the branch coverage of a call frame and merging it into that of
+// its transaction, with maps allocated for each call frame.
+func BenchmarkCoverageMapsPerCallFrame(b *testing.B) {
+	transactionMaps := NewCoverageMaps()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		frameMaps := NewCoverageMaps()
+		_, _ = frameMaps.SetAt(common.Address{1}, common.Hash{1}, 64, i%64, nil)
+		_, _ = transactionMaps.Update(frameMaps)
+	}
+}
+
+// BenchmarkCoverageMapsResultPool measures recording the branch coverage of a call frame and merging it into that of
+// its transaction, with maps recycled through a fitnessmetrics.ResultPool as done by the tracer.
+func BenchmarkCoverageMapsResultPool(b *testing.B) {
+	pool := fitnessmetrics.NewResultPool(NewCoverageMaps)
+	transactionMaps := NewCoverageMaps()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		frameMaps := pool.Get()
+		_, _ = frameMaps.SetAt(common.Address{1}, common.Hash{1}, 64, i%64, nil)
+		_, _ = transactionMaps.Update(frameMaps)
+		pool.Put(frameMaps)
+	}
+}
+
+// TestCoverageMapsCallingContexts tests that the same branch reached under different call stacks is counted as new
+// coverage for each of them, while only being counted once towards the branch coverage of its contract.
+func TestCoverageMapsCallingContexts(t *testing.T) {
+	user, router := common.Address{0xaa}, common.Address{0xbb}
+	directContext := getCallingContextHash([]common.Address{user})
+	routedContext := getCallingContextHash([]common.Address{router, user})
+	assert.NotEqual(t, directContext, routedContext)
+	assert.NotEqual(t, routedContext, getCallingContextHash([]common.Address{user, router}))
+
+	maps := NewCoverageMaps()
+	changed, err := maps.SetAt(common.Address{1}, common.Hash{1}, 4, 0, &directContext)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+
+	// Reaching the branch again under the same call stack is not new coverage, while reaching it under another is.
+	changed, err = maps.SetAt(common.Address{1}, common.Hash{1}, 4, 0, &directContext)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	changed, err = maps.SetAt(common.Address{1}, common.Hash{1}, 4, 0, &routedContext)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+
+	covered, _ := maps.TotalBranchCoverage(nil)
+	assert.EqualValues(t, 1, covered)
+	assert.EqualValues(t, 2, maps.TotalContextualBranchCoverage(nil))
+
+	// Merging coverage of a call stack already recorded is not new coverage either.
+	frameMaps := NewCoverageMaps()
+	_, err = frameMaps.SetAt(common.Address{1}, common.Hash{1}, 4, 0, &routedContext)
+	assert.NoError(t, err)
+	changed, err = maps.Update(frameMaps)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.EqualValues(t, 2, maps.TotalContextualBranchCoverage(nil))
+}
+
+// TestCoverageTracerCallingContexts tests that the tracer keys the coverage of each call frame by its callers, up to
+// the configured context depth, so a contract called directly and through another contract has distinct contexts.
+func TestCoverageTracerCallingContexts(t *testing.T) {
+	user, router, pool, token := common.Address{0xaa}, common.Address{0xbb}, common.Address{0xcc}, common.Address{0xdd}
+	tracer := &CoverageTracer{resultPool: fitnessmetrics.NewResultPool(NewCoverageMaps)}
+	tracer.SetContextDepth(2)
+
+	// The pool called directly by the user.
+	tracer.OnTxStart(nil, nil, user)
+	tracer.OnEnter(0, byte(vm.CALL), user, pool, nil, 0, nil)
+	assert.EqualValues(t, []common.Address{user}, tracer.callFrameStates[0].callers)
+	assert.Equal(t, getCallingContextHash([]common.Address{user}), *tracer.callFrameStates[0].callingContext)
+
+	// The pool called through the router, which calls the token in turn, whose context is truncated to two callers.
+	tracer.OnTxStart(nil, nil, user)
+	tracer.OnEnter(0, byte(vm.CALL), user, router, nil, 0, nil)
+	tracer.OnEnter(1, byte(vm.CALL), router, pool, nil, 0, nil)
+	tracer.OnEnter(2, byte(vm.CALL), pool, token, nil, 0, nil)
+	assert.EqualValues(t, []common.Address{router, user}, tracer.callFrameStates[1].callers)
+	assert.Equal(t, getCallingContextHash([]common.Address{router, user}), *tracer.callFrameStates[1].callingContext)
+	assert.EqualValues(t, []common.Address{pool, router}, tracer.callFrameStates[2].callers)
+
+	// Without a context depth, no calling context is recorded.
+	tracer.SetContextDepth(0)
+	tracer.OnTxStart(nil, nil, user)
+	tracer.OnEnter(0, byte(vm.CALL), user, pool, nil, 0, nil)
+	assert.Nil(t, tracer.callFrameStates[0].callingContext)
+}
@@ -1,18 +1,18 @@
 package branchcoverage
 
 import (
-	"bytes"
 	"math/big"
 
 	"github.com/crytic/medusa-geth/common"
 	"github.com/crytic/medusa-geth/core/tracing"
 	coretypes "github.com/crytic/medusa-geth/core/types"
 	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa-geth/crypto"
 	"github.com/crytic/medusa-geth/eth/tracers"
 	"github.com/crytic/medusa/chain"
 	"github.com/crytic/medusa/chain/types"
-	compilationTypes "github.com/crytic/medusa/compilation/types"
 	fuzzerTypes "github.com/crytic/medusa/fuzzing/contracts"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics"
 	"github.com/crytic/medusa/logging"
 )
 
@@ -48,6 +48,12 @@ type CoverageTracer struct {
 	// callFrameStates describes the state tracked by the tracer per call frame.
 	callFrameStates []*coverageTracerCallFrameState
 
+	// resultPool recycles the coverage maps recorded for each transaction and call frame.
+	resultPool *fitnessmetrics.ResultPool[*CoverageMaps]
+
+	// lastResults describes the message results the coverage maps of the latest transaction were stored in.
+	lastResults *types.MessageResults
+
 	// callDepth refers to the current EVM depth during tracing.
 	callDepth int
 
@@ -60,8 +66,23 @@ type CoverageTracer struct {
 	// branchMaps stores branch map for each contract code
 	branchMaps map[common.Hash]*BranchMap
 
+	// dynamicBranchMaps stores the branch maps of runtime bytecode not in branchMaps, such as that of contracts
+	// deployed by factories, built the first time it executes. If nil, such code is not traced.
+	dynamicBranchMaps *fitnessmetrics.CodeMapRegistry[*BranchMap]
+
+	// lookupHashAliases maps the lookup hashes of code deployed dynamically, which differs from the compiled contract
+	// it matched (e.g. due to immutables or constructor arguments), to the lookup hash of the compiled contract.
+	lookupHashAliases map[common.Hash]common.Hash
+
 	// initialContractsSet records the set of contract addresses present in the base chain.
 	initialContractsSet *map[common.Address]struct{}
+
+	// exclusions describes the contracts which are not traced, or nil if none are excluded.
+	exclusions *fitnessmetrics.MetricExclusions
+
+	// contextDepth describes the amount of callers making up the calling context branches are additionally recorded
+	// in. If zero, calling contexts are not recorded.
+	contextDepth int
 }
 
 // coverageTracerCallFrameState tracks state across call frames in the tracer.
@@ -77,41 +98,33 @@ type coverageTracerCallFrameState struct {
 	// lookupHash describes the hash used to look up the ContractCoverageMap being updated in this frame.
 	lookupHash *common.Hash
 
+	// excluded indicates whether the code executing in this frame is excluded from tracing.
+	excluded bool
+
 	// address is used by OnOpcode to cache the result of scope.Address(), which is slow.
 	// It records the address of the current contract.
 	address common.Address
+
+	// callers describes the addresses of the callers of this call frame, most recent first, bounded by the tracer's
+	// context depth.
+	callers []common.Address
+
+	// callingContext describes the hash of callers, which branches in this call frame are recorded in. It is nil if
+	// calling contexts are not recorded.
+	callingContext *common.Hash
 }
 
 // NewCoverageTracer returns a new CoverageTracer.
 func NewCoverageTracer(contracts fuzzerTypes.Contracts) *CoverageTracer {
 	// Create a map of block maps for each contract code
-	branchMaps := make(map[common.Hash]*BranchMap)
-	for _, contract := range contracts {
-		compiledContract := contract.CompiledContract()
-
-		initBytecode := compiledContract.InitBytecode
-		runtimeBytecode := compiledContract.RuntimeBytecode
-
-		if initBytecode != nil {
-			initBytecodeHash := getContractCoverageMapHash(initBytecode, true)
-			// remove runtime bytecode (including metadata here) from init bytecode
-			runtimeBytecodeOffset := bytes.LastIndex(initBytecode, runtimeBytecode)
-			if runtimeBytecodeOffset != -1 {
-				initBytecode = initBytecode[:runtimeBytecodeOffset]
-			}
-			branchMaps[initBytecodeHash] = GetBranchMapFromBytecode(initBytecode)
-		}
-
-		runtimeBytecodeHash := getContractCoverageMapHash(runtimeBytecode, false)
-		// remove metadata from runtime bytecode
-		runtimeBytecode = compilationTypes.RemoveContractMetadata(runtimeBytecode)
-		branchMaps[runtimeBytecodeHash] = GetBranchMapFromBytecode(runtimeBytecode)
-	}
+	branchMaps := BranchMapsByLookupHash(contracts)
 
 	tracer := &CoverageTracer{
-		coverageMaps:    NewCoverageMaps(),
-		callFrameStates: make([]*coverageTracerCallFrameState, 0),
-		branchMaps:      branchMaps,
+		coverageMaps:      NewCoverageMaps(),
+		callFrameStates:   make([]*coverageTracerCallFrameState, 0),
+		resultPool:        fitnessmetrics.NewResultPool(NewCoverageMaps),
+		lookupHashAliases: make(map[common.Hash]common.Hash),
+		branchMaps:        branchMaps,
 	}
 	nativeTracer := &tracers.Tracer{
 		Hooks: &tracing.Hooks{
@@ -135,6 +148,49 @@ func (t *CoverageTracer) SetInitialContractsSet(initialContractsSet *map[common.
 	t.initialContractsSet = initialContractsSet
 }
 
+// SetExclusions sets the exclusions value (see above).
+func (t *CoverageTracer) SetExclusions(exclusions *fitnessmetrics.MetricExclusions) {
+	t.exclusions = exclusions
+}
+
+// RegisterDeployedContract registers the init and runtime bytecode of a contract deployed dynamically (e.g. by a
+// factory), which was matched to the provided contract definition, so that its coverage is recorded with that of the
+// definition's compiled bytecode. This is a no-op if the tracer is nil, or if the definition is not traced.
+func (t *CoverageTracer) RegisterDeployedContract(contract *fuzzerTypes.Contract, initBytecode []byte, runtimeBytecode []byte) {
+	if t == nil {
+		return
+	}
+	compiledContract := contract.CompiledContract()
+	t.registerLookupHashAlias(initBytecode, compiledContract.InitBytecode, true)
+	t.registerLookupHashAlias(runtimeBytecode, compiledContract.RuntimeBytecode, false)
+}
+
+// registerLookupHashAlias maps the lookup hash of the provided deployed bytecode to that of the compiled bytecode it
+// was matched to, if they differ and the compiled bytecode is traced.
+func (t *CoverageTracer) registerLookupHashAlias(deployedBytecode []byte, compiledBytecode []byte, init bool) {
+	if len(deployedBytecode) == 0 || len(compiledBytecode) == 0 {
+		return
+	}
+	lookupHash := getContractCoverageMapHash(deployedBytecode, init)
+	if _, exists := t.branchMaps[lookupHash]; exists {
+		return
+	}
+	compiledLookupHash := getContractCoverageMapHash(compiledBytecode, init)
+	if _, exists := t.branchMaps[compiledLookupHash]; exists {
+		t.lookupHashAliases[lookupHash] = compiledLookupHash
+	}
+}
+
+// SetDynamicBranchMaps sets the dynamicBranchMaps value (see above).
+func (t *CoverageTracer) SetDynamicBranchMaps(dynamicBranchMaps *fitnessmetrics.CodeMapRegistry[*BranchMap]) {
+	t.dynamicBranchMaps = dynamicBranchMaps
+}
+
+// SetContextDepth sets the contextDepth value (see above).
+func (t *CoverageTracer) SetContextDepth(contextDepth int) {
+	t.contextDepth = contextDepth
+}
+
 // BLANK_ADDRESS is an all-zero address; it's a global var so that we don't have to recalculate (and reallocate) it every time.
 var BLANK_ADDRESS = common.BytesToAddress([]byte{})
 
@@ -157,8 +213,8 @@ func (t *CoverageTracer) addressForCoverage(address common.Address) common.Addre
 func (t *CoverageTracer) OnTxStart(vm *tracing.VMContext, tx *coretypes.Transaction, from common.Address) {
 	// Reset our call frame states
 	t.callDepth = 0
-	t.coverageMaps = NewCoverageMaps()
-	t.callFrameStates = make([]*coverageTracerCallFrameState, 0)
+	t.coverageMaps = t.resultPool.Get()
+	t.callFrameStates = t.callFrameStates[:0]
 	t.evmContext = vm
 }
 
@@ -169,10 +225,31 @@ func (t *CoverageTracer) OnEnter(depth int, typ byte, from common.Address, to co
 		t.callDepth++
 	}
 	// Create our state tracking struct for this frame.
-	t.callFrameStates = append(t.callFrameStates, &coverageTracerCallFrameState{
+	callFrameState := &coverageTracerCallFrameState{
 		create:             typ == byte(vm.CREATE) || typ == byte(vm.CREATE2),
-		pendingCoverageMap: NewCoverageMaps(),
-	})
+		pendingCoverageMap: t.resultPool.Get(),
+	}
+
+	// If calling contexts are recorded, derive this frame's callers from its parent's, and hash them.
+	if t.contextDepth > 0 {
+		callFrameState.callers = append(callFrameState.callers, t.addressForCoverage(from))
+		if !isTopLevelFrame {
+			parentCallers := t.callFrameStates[t.callDepth-1].callers
+			callFrameState.callers = append(callFrameState.callers, parentCallers[:min(len(parentCallers), t.contextDepth-1)]...)
+		}
+		callingContext := getCallingContextHash(callFrameState.callers)
+		callFrameState.callingContext = &callingContext
+	}
+	t.callFrameStates = append(t.callFrameStates, callFrameState)
+}
+
+// getCallingContextHash obtains the hash used to key the coverage of a call frame with the provided callers.
+func getCallingContextHash(callers []common.Address) common.Hash {
+	data := make([]byte, 0, len(callers)*common.AddressLength)
+	for _, caller := range callers {
+		data = append(data, caller.Bytes()...)
+	}
+	return crypto.Keccak256Hash(data)
 }
 
 // OnExit is called upon exiting of the call frame, as defined by tracers.Tracer.
@@ -204,6 +281,9 @@ func (t *CoverageTracer) OnExit(depth int, output []byte, gasUsed uint64, err er
 		t.callFrameStates = t.callFrameStates[:t.callDepth]
 		t.callDepth--
 	}
+
+	// The coverage of this call frame was copied up, so its maps can be reused.
+	t.resultPool.Put(currentCoverageMap)
 }
 
 // OnOpcode records data from an EVM state update, as defined by tracers.Tracer.
@@ -211,36 +291,75 @@ func (t *CoverageTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope tr
 	// Obtain our call frame state tracking struct
 	callFrameState := t.callFrameStates[t.callDepth]
 
+	scopeContext := scope.(*vm.ScopeContext)
+
 	if !callFrameState.initialized {
-		callFrameState.initialized = true
-		callFrameState.address = scope.Address()
+		t.initializeCallFrame(callFrameState, scope.Address(), scopeContext)
 	}
 
-	scopeContext := scope.(*vm.ScopeContext)
-
 	// If there is code we're executing and opcode is JUMPI, collect coverage.
-	if len(scopeContext.Contract.Code) > 0 && vm.OpCode(op) == vm.JUMPI {
-		// Obtain our contract coverage map lookup hash.
-		if callFrameState.lookupHash == nil {
-			lookupHash := getContractCoverageMapHash(scopeContext.Contract.Code, callFrameState.create)
-			callFrameState.lookupHash = &lookupHash
-		}
+	if vm.OpCode(op) == vm.JUMPI {
+		t.recordBranch(callFrameState, pc, scopeContext)
+	}
+}
 
-		// Obtain branch id using condition from stack.
-		cond := !scopeContext.Stack.Back(1).IsZero()
-		branchMap, exists := t.branchMaps[*callFrameState.lookupHash]
-		if !exists {
-			// This contract is not in our list of contracts to trace.
-			return
-		}
-		branchSize := branchMap.Size()
-		branchId := branchMap.GetBranchId(pc, cond)
+// OnOpcodeStep records an opcode dispatched by a chain.TestChainTracerMultiplexer, as defined by
+// chain.OpcodeStepTracer.
+func (t *CoverageTracer) OnOpcodeStep(step *chain.OpcodeStep) {
+	// The call frame's address and code are the same for all its opcodes, so it is initialized upon its first branch.
+	callFrameState := t.callFrameStates[t.callDepth]
+	if !callFrameState.initialized {
+		t.initializeCallFrame(callFrameState, step.Address, step.Scope)
+	}
+	t.recordBranch(callFrameState, step.Pc, step.Scope)
+}
 
-		// Record branch coverage for this path of this instruction location in our map.
-		_, coverageUpdateErr := callFrameState.pendingCoverageMap.SetAt(t.addressForCoverage(callFrameState.address), *callFrameState.lookupHash, branchSize, branchId)
-		if coverageUpdateErr != nil {
-			logging.GlobalLogger.Panic("Coverage tracer failed to update coverage map while tracing state", coverageUpdateErr)
+// HandledOpcodes returns the opcodes OnOpcodeStep should be called for, as defined by chain.OpcodeStepTracer.
+func (t *CoverageTracer) HandledOpcodes() []vm.OpCode {
+	return []vm.OpCode{vm.JUMPI}
+}
+
+// initializeCallFrame records the address of the provided call frame's contract, and whether its code is excluded.
+func (t *CoverageTracer) initializeCallFrame(callFrameState *coverageTracerCallFrameState, address common.Address, scopeContext *vm.ScopeContext) {
+	callFrameState.initialized = true
+	callFrameState.address = address
+	callFrameState.excluded = t.exclusions.Excludes(callFrameState.address, scopeContext.Contract.CodeHash)
+}
+
+// recordBranch records the coverage of the branch taken by the JUMPI at the provided location, unless the code
+// executing is excluded or not traced.
+func (t *CoverageTracer) recordBranch(callFrameState *coverageTracerCallFrameState, pc uint64, scopeContext *vm.ScopeContext) {
+	// If the code executing is excluded, do not collect coverage.
+	if callFrameState.excluded || len(scopeContext.Contract.Code) == 0 {
+		return
+	}
+
+	// Obtain our contract coverage map lookup hash.
+	if callFrameState.lookupHash == nil {
+		lookupHash := getContractCoverageMapHash(scopeContext.Contract.Code, callFrameState.create)
+		if alias, ok := t.lookupHashAliases[lookupHash]; ok {
+			lookupHash = alias
 		}
+		callFrameState.lookupHash = &lookupHash
+	}
+
+	// Obtain branch id using condition from stack.
+	cond := !scopeContext.Stack.Back(1).IsZero()
+	branchMap, exists := t.branchMaps[*callFrameState.lookupHash]
+	if !exists && !callFrameState.create {
+		branchMap, exists = t.dynamicBranchMaps.Get(*callFrameState.lookupHash, scopeContext.Contract.Code)
+	}
+	if !exists {
+		// This contract is not in our list of contracts to trace.
+		return
+	}
+	branchSize := branchMap.Size()
+	branchId := branchMap.GetBranchId(pc, cond)
+
+	// Record branch coverage for this path of this instruction location in our map.
+	_, coverageUpdateErr := callFrameState.pendingCoverageMap.SetAt(t.addressForCoverage(callFrameState.address), *callFrameState.lookupHash, branchSize, branchId, callFrameState.callingContext)
+	if coverageUpdateErr != nil {
+		logging.GlobalLogger.Panic("Coverage tracer failed to update coverage map while tracing state", coverageUpdateErr)
 	}
 }
 
@@ -250,4 +369,19 @@ func (t *CoverageTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope tr
 func (t *CoverageTracer) CaptureTxEndSetAdditionalResults(results *types.MessageResults) {
 	// Store our tracer results.
 	results.AdditionalResults[coverageTracerResultsKey] = t.coverageMaps
+	t.lastResults = results
+}
+
+// ReleaseResults releases the coverage maps of the latest transaction for reuse, if they were stored in the provided
+// message results, as defined by fitnessmetrics.ResultReleaser. They are removed from the message results.
+func (t *CoverageTracer) ReleaseResults(results *types.MessageResults) {
+	if t == nil || t.coverageMaps == nil || results != t.lastResults {
+		return
+	}
+	if GetCoverageTracerResults(results) == t.coverageMaps {
+		RemoveCoverageTracerResults(results)
+	}
+	t.resultPool.Put(t.coverageMaps)
+	t.coverageMaps = nil
+	t.lastResults = nil
 }
@@ -0,0 +1,231 @@
+package branchdistance
+
+import (
+	"encoding/json"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/holiman/uint256"
+)
+
+// contractBranchDistanceMapJSON describes the serialized form of a ContractBranchDistanceMap, used to exchange branch
+// distances between fuzzer instances. Only the branches reached are listed, as distance deltas are typically sparse.
+type contractBranchDistanceMapJSON struct {
+	CodeHash          common.Hash          `json:"codeHash"`
+	CodeAddress       common.Address       `json:"codeAddress"`
+	BranchCount       int                  `json:"branchCount"`
+	Distances         map[int]*uint256.Int `json:"distances,omitempty"`
+	RevertedDistances map[int]*uint256.Int `json:"revertedDistances,omitempty"`
+}
+
+// revertSiteDistanceJSON describes the serialized form of a revert site distance.
+type revertSiteDistanceJSON struct {
+	CodeHash common.Hash  `json:"codeHash"`
+	RevertPc uint64       `json:"revertPc"`
+	BranchPc uint64       `json:"branchPc"`
+	Distance *uint256.Int `json:"distance"`
+}
+
+// branchSiteJSON describes the serialized form of a BranchSite.
+type branchSiteJSON struct {
+	CodeHash common.Hash `json:"codeHash"`
+	Pc       uint64      `json:"pc"`
+}
+
+// branchDistanceMapsJSON describes the serialized form of BranchDistanceMaps.
+type branchDistanceMapsJSON struct {
+	Maps           []contractBranchDistanceMapJSON `json:"maps"`
+	RevertSites    []revertSiteDistanceJSON        `json:"revertSites,omitempty"`
+	TargetDistance uint64                          `json:"targetDistance"`
+	FlatBranches   []branchSiteJSON                `json:"flatBranches,omitempty"`
+}
+
+// Delta returns new distance maps holding the distances recorded in the current maps which are closer than those
+// recorded in the provided base maps, or which the base maps do not record at all. If no base maps are provided, a
+// copy of the current maps is returned. Merging the returned maps into the base maps with Update produces the same
+// distances as merging the current maps.
+func (cm *BranchDistanceMaps) Delta(base *BranchDistanceMaps) *BranchDistanceMaps {
+	cm.updateLock.Lock()
+	defer cm.updateLock.Unlock()
+	if base == nil {
+		base = NewBranchDistanceMaps()
+	} else if base != cm {
+		base.updateLock.Lock()
+		defer base.updateLock.Unlock()
+	}
+
+	delta := NewBranchDistanceMaps()
+	for codeHash, mapsByAddress := range cm.maps {
+		for codeAddress, contractDistanceMap := range mapsByAddress {
+			var baseData *DistanceMapBranchData
+			if baseContractDistanceMap, ok := base.maps[codeHash][codeAddress]; ok {
+				baseData = baseContractDistanceMap.distanceMap
+			}
+			deltaData := contractDistanceMap.distanceMap.delta(baseData)
+			if deltaData == nil {
+				continue
+			}
+
+			if _, ok := delta.maps[codeHash]; !ok {
+				delta.maps[codeHash] = make(map[common.Address]*ContractBranchDistanceMap)
+			}
+			delta.maps[codeHash][codeAddress] = &ContractBranchDistanceMap{distanceMap: deltaData}
+		}
+	}
+
+	// Copy the revert site distances, target distance and flat branches the base maps do not improve upon.
+	for revertSite, distance := range cm.revertSiteDistances {
+		if baseDistance, exists := base.revertSiteDistances[revertSite]; !exists || baseDistance.Gt(distance) {
+			delta.revertSiteDistances[revertSite] = new(uint256.Int).Set(distance)
+		}
+	}
+	if cm.targetDistance < base.targetDistance {
+		delta.targetDistance = cm.targetDistance
+	}
+	for branchSite := range cm.flatBranches {
+		if _, exists := base.flatBranches[branchSite]; !exists {
+			delta.flatBranches[branchSite] = struct{}{}
+		}
+	}
+	return delta
+}
+
+// IsEmpty indicates whether the distance maps record no distances, target distance or flat branches.
+func (cm *BranchDistanceMaps) IsEmpty() bool {
+	cm.updateLock.Lock()
+	defer cm.updateLock.Unlock()
+
+	for _, mapsByAddress := range cm.maps {
+		for _, contractDistanceMap := range mapsByAddress {
+			if len(contractDistanceMap.distanceMap.executedFlags) > 0 || len(contractDistanceMap.distanceMap.revertedDistance) > 0 {
+				return false
+			}
+		}
+	}
+	return len(cm.revertSiteDistances) == 0 && cm.targetDistance == NoTargetDistance && len(cm.flatBranches) == 0
+}
+
+// MarshalJSON provides custom JSON marshalling for the distance maps, listing the distances of each branch reached.
+func (cm *BranchDistanceMaps) MarshalJSON() ([]byte, error) {
+	cm.updateLock.Lock()
+	defer cm.updateLock.Unlock()
+
+	serializedMaps := branchDistanceMapsJSON{
+		Maps:           make([]contractBranchDistanceMapJSON, 0),
+		TargetDistance: cm.targetDistance,
+	}
+	for codeHash, mapsByAddress := range cm.maps {
+		for codeAddress, contractDistanceMap := range mapsByAddress {
+			data := contractDistanceMap.distanceMap
+			serializedMap := contractBranchDistanceMapJSON{
+				CodeHash:          codeHash,
+				CodeAddress:       codeAddress,
+				BranchCount:       len(data.executedFlags),
+				Distances:         make(map[int]*uint256.Int),
+				RevertedDistances: data.revertedDistance,
+			}
+			for id, executed := range data.executedFlags {
+				if executed != 0 {
+					serializedMap.Distances[id] = &data.distance[id]
+				}
+			}
+			serializedMaps.Maps = append(serializedMaps.Maps, serializedMap)
+		}
+	}
+	for revertSite, distance := range cm.revertSiteDistances {
+		serializedMaps.RevertSites = append(serializedMaps.RevertSites, revertSiteDistanceJSON{
+			CodeHash: revertSite.CodeHash,
+			RevertPc: revertSite.RevertPc,
+			BranchPc: revertSite.BranchPc,
+			Distance: distance,
+		})
+	}
+	for branchSite := range cm.flatBranches {
+		serializedMaps.FlatBranches = append(serializedMaps.FlatBranches, branchSiteJSON{CodeHash: branchSite.CodeHash, Pc: branchSite.Pc})
+	}
+	return json.Marshal(serializedMaps)
+}
+
+// UnmarshalJSON provides custom JSON unmarshalling for the distance maps. Distances for branch ids outside the branch
+// count of their contract are ignored.
+func (cm *BranchDistanceMaps) UnmarshalJSON(b []byte) error {
+	var serializedMaps branchDistanceMapsJSON
+	if err := json.Unmarshal(b, &serializedMaps); err != nil {
+		return err
+	}
+
+	cm.updateLock.Lock()
+	defer cm.updateLock.Unlock()
+	cm.Reset()
+	for _, serializedMap := range serializedMaps.Maps {
+		data := &DistanceMapBranchData{}
+		if serializedMap.BranchCount > 0 && len(serializedMap.Distances) > 0 {
+			data.executedFlags = make([]byte, serializedMap.BranchCount)
+			data.distance = make([]uint256.Int, serializedMap.BranchCount)
+			for id, distance := range serializedMap.Distances {
+				if id >= 0 && id < serializedMap.BranchCount && distance != nil {
+					data.executedFlags[id] = 1
+					data.distance[id].Set(distance)
+				}
+			}
+		}
+		for id, distance := range serializedMap.RevertedDistances {
+			if distance != nil {
+				data.setRevertedDistanceAt(id, distance)
+			}
+		}
+
+		if _, ok := cm.maps[serializedMap.CodeHash]; !ok {
+			cm.maps[serializedMap.CodeHash] = make(map[common.Address]*ContractBranchDistanceMap)
+		}
+		cm.maps[serializedMap.CodeHash][serializedMap.CodeAddress] = &ContractBranchDistanceMap{distanceMap: data}
+	}
+	for _, revertSite := range serializedMaps.RevertSites {
+		if revertSite.Distance != nil {
+			cm.setRevertSiteDistance(RevertSite{CodeHash: revertSite.CodeHash, RevertPc: revertSite.RevertPc, BranchPc: revertSite.BranchPc}, revertSite.Distance)
+		}
+	}
+	cm.targetDistance = serializedMaps.TargetDistance
+	for _, branchSite := range serializedMaps.FlatBranches {
+		cm.flatBranches[BranchSite{CodeHash: branchSite.CodeHash, Pc: branchSite.Pc}] = struct{}{}
+	}
+	return nil
+}
+
+// delta returns new branch data holding the successful and reverted distances of the current data which are closer
+// than those of the provided base data, or which the base data does not record at all.
+// Returns the branch data, or nil if the base data records all distances of the current data.
+func (cm *DistanceMapBranchData) delta(base *DistanceMapBranchData) *DistanceMapBranchData {
+	var deltaData *DistanceMapBranchData
+	if base == nil {
+		base = &DistanceMapBranchData{}
+	}
+
+	for id, executed := range cm.executedFlags {
+		if executed == 0 {
+			continue
+		}
+		if id < len(base.executedFlags) && base.executedFlags[id] != 0 && !base.distance[id].Gt(&cm.distance[id]) {
+			continue
+		}
+		if deltaData == nil {
+			deltaData = &DistanceMapBranchData{}
+		}
+		if len(deltaData.executedFlags) == 0 {
+			deltaData.executedFlags = make([]byte, len(cm.executedFlags))
+			deltaData.distance = make([]uint256.Int, len(cm.executedFlags))
+		}
+		deltaData.executedFlags[id] = 1
+		deltaData.distance[id].Set(&cm.distance[id])
+	}
+
+	for id, distance := range cm.revertedDistance {
+		if baseDistance, exists := base.revertedDistance[id]; exists && !baseDistance.Gt(distance) {
+			continue
+		}
+		if deltaData == nil {
+			deltaData = &DistanceMapBranchData{}
+		}
+		deltaData.setRevertedDistanceAt(id, distance)
+	}
+	return deltaData
+}
@@ -0,0 +1,64 @@
+package branchdistance
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBranchDistanceMapsDelta tests that deltas only hold the distances closer than those of the base maps, and that
+// merging a delta into the base maps is equivalent to merging the maps it was derived from.
+func TestBranchDistanceMapsDelta(t *testing.T) {
+	maps := newMapsWithDistance(t, 0, 5)
+	_, err := maps.SetAt(common.Address{1}, common.Hash{1}, 4, 1, uint256.NewInt(9))
+	assert.NoError(t, err)
+	maps.SetRevertSiteDistance(RevertSite{CodeHash: common.Hash{1}, RevertPc: 10, BranchPc: 5}, uint256.NewInt(3))
+	maps.SetFlatBranch(BranchSite{CodeHash: common.Hash{1}, Pc: 7})
+
+	// The base maps already record a closer distance for branch 1, but a further one for branch 0.
+	base := newMapsWithDistance(t, 0, 8)
+	_, err = base.SetAt(common.Address{1}, common.Hash{1}, 4, 1, uint256.NewInt(2))
+	assert.NoError(t, err)
+
+	delta := maps.Delta(base)
+	assert.False(t, delta.IsEmpty())
+	deltaMap := delta.maps[common.Hash{1}][common.Address{1}]
+	assert.EqualValues(t, uint256.NewInt(5), deltaMap.GetDistance(0))
+	assert.Nil(t, deltaMap.GetDistance(1))
+	assert.EqualValues(t, 1, delta.FlatBranchCount())
+	assert.EqualValues(t, 1, len(delta.AlmostPassingRevertSites(0)))
+
+	// Merging the delta brings the base maps up to date, after which no delta remains.
+	changed, err := base.Update(delta)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.True(t, maps.Delta(base).IsEmpty())
+
+	// A delta against no base maps is a copy of the maps.
+	assert.True(t, maps.Delta(maps.Delta(nil)).IsEmpty())
+}
+
+// TestBranchDistanceMapsJSON tests that distance maps survive a round trip through their JSON representation.
+func TestBranchDistanceMapsJSON(t *testing.T) {
+	maps := newMapsWithDistance(t, 2, 42)
+	maps.RevertAll()
+	_, err := maps.SetAt(common.Address{1}, common.Hash{1}, 4, 0, uint256.NewInt(7))
+	assert.NoError(t, err)
+	maps.SetRevertSiteDistance(RevertSite{CodeHash: common.Hash{2}, RevertPc: 1, BranchPc: 2}, uint256.NewInt(11))
+	maps.SetTargetDistance(100)
+
+	b, err := json.Marshal(maps)
+	assert.NoError(t, err)
+	decoded := NewBranchDistanceMaps()
+	assert.NoError(t, json.Unmarshal(b, decoded))
+
+	assert.True(t, maps.Delta(decoded).IsEmpty())
+	assert.True(t, decoded.Delta(maps).IsEmpty())
+	assert.EqualValues(t, 100, decoded.TargetDistance())
+	decodedMap := decoded.maps[common.Hash{1}][common.Address{1}]
+	assert.EqualValues(t, uint256.NewInt(7), decodedMap.GetDistance(0))
+	assert.EqualValues(t, uint256.NewInt(42), decodedMap.distanceMap.revertedDistance[2])
+}
@@ -0,0 +1,184 @@
+package branchdistance
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/fuzzing/config"
+	fuzzerTypes "github.com/crytic/medusa/fuzzing/contracts"
+)
+
+// BranchDistanceDumpEntry describes the best distance seen so far for a single branch.
+type BranchDistanceDumpEntry struct {
+	// BranchId is the id of the branch within its contract code.
+	BranchId int `json:"branchId"`
+	// Pc is the program counter of the JUMPI or indirect JUMP the branch belongs to.
+	Pc uint64 `json:"pc"`
+	// Jumped describes whether the branch is the jumping path of a JUMPI. It is nil for indirect JUMP branches.
+	Jumped *bool `json:"jumped,omitempty"`
+	// Destination is the candidate destination of an indirect JUMP branch. It is nil for JUMPI branches.
+	Destination *uint64 `json:"destination,omitempty"`
+	// Source is the source code location the branch instruction maps to, or nil if it does not map to source code.
+	Source *BranchSourceLocation `json:"source,omitempty"`
+	// Distance is the best distance seen so far, in decimal.
+	Distance string `json:"distance"`
+}
+
+// ContractBranchDistanceDump describes the best distances seen so far for the branches of a contract deployed at an
+// address.
+type ContractBranchDistanceDump struct {
+	// Contract is the name of the contract, or the lookup hash of its code if it is unknown.
+	Contract string `json:"contract"`
+	// Address is the address the contract code executed at.
+	Address common.Address `json:"address"`
+	// Branches describes the best distance seen so far for each branch reached, sorted by branch id.
+	Branches []BranchDistanceDumpEntry `json:"branches"`
+}
+
+// BranchDistanceDump describes the best branch distances seen at some point of a fuzzing campaign.
+type BranchDistanceDump struct {
+	// ElapsedSeconds is the amount of seconds elapsed since the dump writer was created.
+	ElapsedSeconds uint64 `json:"elapsedSeconds"`
+	// Contracts describes the best branch distances seen for each contract.
+	Contracts []ContractBranchDistanceDump `json:"contracts"`
+}
+
+// BranchDistanceDumpWriter writes the best branch distances seen during a fuzzing campaign to JSON files, so the
+// convergence of individual branches can be inspected over time.
+type BranchDistanceDumpWriter struct {
+	// path is the directory the dumps are written to.
+	path string
+	// startTime is the time the writer was created, which dumps record their elapsed time relative to.
+	startTime time.Time
+	// dumpCount is the amount of dumps written so far, which distinguishes dumps written within the same second.
+	dumpCount atomic.Uint64
+	// contractNames describes the name of each contract by the lookup hash of its code.
+	contractNames map[common.Hash]string
+	// branchEntries describes each branch by its id, by the lookup hash of its code. Distances are left unset.
+	branchEntries map[common.Hash]map[int]BranchDistanceDumpEntry
+}
+
+// NewBranchDistanceDumpWriter returns a new BranchDistanceDumpWriter which writes dumps for the provided contracts to
+// the provided directory.
+func NewBranchDistanceDumpWriter(contracts fuzzerTypes.Contracts, branchDistanceConfig config.BranchDistanceConfig, path string) *BranchDistanceDumpWriter {
+	branchEntries := make(map[common.Hash]map[int]BranchDistanceDumpEntry)
+	sourceLocations := BranchSourceLocationsByLookupHash(contracts)
+	for _, contract := range contracts {
+		initBytecodeHash, initBytecode, runtimeBytecodeHash, runtimeBytecode, runtimeData := getContractBranchBytecode(contract)
+		branchEntries[initBytecodeHash] = getBranchDumpEntries(GetBranchMapFromBytecode(initBytecode, branchDistanceConfig.IndirectJumpBranches), sourceLocations[initBytecodeHash])
+		branchEntries[runtimeBytecodeHash] = getBranchDumpEntries(GetBranchMapFromCode(runtimeBytecode, runtimeData, branchDistanceConfig.IndirectJumpBranches), sourceLocations[runtimeBytecodeHash])
+	}
+
+	return &BranchDistanceDumpWriter{
+		path:          path,
+		startTime:     time.Now(),
+		contractNames: ContractNamesByLookupHash(contracts),
+		branchEntries: branchEntries,
+	}
+}
+
+// getBranchDumpEntries returns a dump entry describing each branch of the provided branch map, by branch id, along
+// with the source location of its branch instruction, if any.
+func getBranchDumpEntries(branchMap *BranchMap, sourceLocations map[uint64]BranchSourceLocation) map[int]BranchDistanceDumpEntry {
+	entries := make(map[int]BranchDistanceDumpEntry)
+	if branchMap == nil {
+		return entries
+	}
+	for pc := range branchMap.BranchIds {
+		for _, jumped := range []bool{false, true} {
+			branchId := branchMap.GetBranchId(pc, jumped)
+			entries[branchId] = BranchDistanceDumpEntry{BranchId: branchId, Pc: pc, Jumped: &jumped}
+		}
+	}
+	for pc, jumpBranchIds := range branchMap.JumpBranchIds {
+		for destination, branchId := range jumpBranchIds {
+			entries[branchId] = BranchDistanceDumpEntry{BranchId: branchId, Pc: pc, Destination: &destination}
+		}
+	}
+	for branchId, entry := range entries {
+		if sourceLocation, ok := sourceLocations[entry.Pc]; ok {
+			entry.Source = &sourceLocation
+			entries[branchId] = entry
+		}
+	}
+	return entries
+}
+
+// Dump returns the best distances recorded in the provided BranchDistanceMaps for each branch reached.
+func (w *BranchDistanceDumpWriter) Dump(maps *BranchDistanceMaps) BranchDistanceDump {
+	// Acquire the maps' thread lock while reading them
+	maps.updateLock.Lock()
+	defer maps.updateLock.Unlock()
+
+	dump := BranchDistanceDump{
+		ElapsedSeconds: uint64(time.Since(w.startTime).Seconds()),
+		Contracts:      make([]ContractBranchDistanceDump, 0),
+	}
+	for codeHash, mapsByAddress := range maps.maps {
+		contractName, ok := w.contractNames[codeHash]
+		if !ok {
+			contractName = codeHash.Hex()
+		}
+		for address, contractMap := range mapsByAddress {
+			contractDump := ContractBranchDistanceDump{
+				Contract: contractName,
+				Address:  address,
+				Branches: make([]BranchDistanceDumpEntry, 0),
+			}
+			for id, executed := range contractMap.distanceMap.executedFlags {
+				if executed == 0 {
+					continue
+				}
+				entry, ok := w.branchEntries[codeHash][id]
+				if !ok {
+					entry = BranchDistanceDumpEntry{BranchId: id}
+				}
+				entry.Distance = contractMap.distanceMap.distance[id].Dec()
+				contractDump.Branches = append(contractDump.Branches, entry)
+			}
+			dump.Contracts = append(dump.Contracts, contractDump)
+		}
+	}
+
+	// Sort the contracts so that dumps are comparable across time.
+	sort.Slice(dump.Contracts, func(i, j int) bool {
+		if dump.Contracts[i].Contract != dump.Contracts[j].Contract {
+			return dump.Contracts[i].Contract < dump.Contracts[j].Contract
+		}
+		return dump.Contracts[i].Address.Cmp(dump.Contracts[j].Address) < 0
+	})
+	return dump
+}
+
+// Write dumps the best distances recorded in the provided BranchDistanceMaps to a JSON file in the writer's directory,
+// named after the amount of seconds elapsed and the index of the dump, so that dumps written within the same second
+// do not overwrite each other.
+// Returns the path of the file written, or an error if one occurred.
+func (w *BranchDistanceDumpWriter) Write(maps *BranchDistanceMaps) (string, error) {
+	dump := w.Dump(maps)
+
+	// Create the directory if it does not exist
+	err := os.MkdirAll(w.path, 0755)
+	if err != nil {
+		return "", fmt.Errorf("failed to create branch distance dump directory at %v: %v", w.path, err)
+	}
+
+	b, err := json.MarshalIndent(dump, "", "\t")
+	if err != nil {
+		return "", err
+	}
+
+	index := w.dumpCount.Add(1)
+	path := filepath.Join(w.path, fmt.Sprintf("branch_distance_%d_%d.json", dump.ElapsedSeconds, index))
+	err = os.WriteFile(path, b, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to write branch distance dump at %v: %v", path, err)
+	}
+	return path, nil
+}
@@ -0,0 +1,55 @@
+package branchdistance
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBranchDistanceDumpWriterWrite tests that dumps written within the same second are written to distinct files,
+// and that reading the dump directory reads the last of them.
+func TestBranchDistanceDumpWriterWrite(t *testing.T) {
+	dir := t.TempDir()
+	writer := NewBranchDistanceDumpWriter(nil, config.BranchDistanceConfig{}, dir)
+
+	firstPath, err := writer.Write(newMapsWithDistance(t, 0, 10))
+	assert.NoError(t, err)
+	lastPath, err := writer.Write(newMapsWithDistance(t, 0, 5))
+	assert.NoError(t, err)
+	assert.NotEqual(t, firstPath, lastPath)
+	assert.Equal(t, filepath.Join(dir, "branch_distance_0_1.json"), firstPath)
+	assert.Equal(t, filepath.Join(dir, "branch_distance_0_2.json"), lastPath)
+
+	// Both dumps are kept, and the last one written is the latest.
+	firstDump, err := ReadBranchDistanceDump(firstPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "10", firstDump.Contracts[0].Branches[0].Distance)
+	lastDump, err := ReadBranchDistanceDump(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, "5", lastDump.Contracts[0].Branches[0].Distance)
+}
+
+// TestParseBranchDistanceDumpName tests that the seconds elapsed and the index of dumps are parsed from their file
+// names, including dumps named after the seconds elapsed alone.
+func TestParseBranchDistanceDumpName(t *testing.T) {
+	tests := []struct {
+		name           string
+		elapsedSeconds uint64
+		index          uint64
+		ok             bool
+	}{
+		{name: "branch_distance_30_4.json", elapsedSeconds: 30, index: 4, ok: true},
+		{name: "branch_distance_30.json", elapsedSeconds: 30, index: 0, ok: true},
+		{name: "branch_distance_30_x.json", ok: false},
+		{name: "branch_distance_30_4_1.json", ok: false},
+		{name: "branch_distance_.json", ok: false},
+	}
+	for _, test := range tests {
+		elapsedSeconds, index, ok := parseBranchDistanceDumpName(test.name)
+		assert.Equal(t, test.ok, ok, test.name)
+		assert.Equal(t, test.elapsedSeconds, elapsedSeconds, test.name)
+		assert.Equal(t, test.index, index, test.name)
+	}
+}
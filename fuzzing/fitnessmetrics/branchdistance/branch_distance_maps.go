@@ -1,11 +1,14 @@
 package branchdistance
 
 import (
+	"bytes"
+	"sort"
 	"sync"
 
 	"github.com/crytic/medusa-geth/common"
 	"github.com/crytic/medusa-geth/crypto"
 	compilationTypes "github.com/crytic/medusa/compilation/types"
+	"github.com/crytic/medusa/utils"
 	"github.com/holiman/uint256"
 )
 
@@ -29,10 +32,56 @@ type BranchDistanceMaps struct {
 	// cachedCodeAddress and matches the cachedCodeHash, then this map is used to avoid an expensive lookup into maps.
 	cachedMap *ContractBranchDistanceMap
 
+	// spareMaps describes the ContractBranchDistanceMap objects emptied by Clear, which are reused before new ones are
+	// allocated.
+	spareMaps []*ContractBranchDistanceMap
+
+	// spareMapsByAddress describes the lookups of ContractBranchDistanceMap objects by address emptied by Clear, which
+	// are reused before new ones are allocated.
+	spareMapsByAddress []map[common.Address]*ContractBranchDistanceMap
+
+	// revertSiteDistances tracks, for each revert site, the minimum distance to flipping its guarding branch observed
+	// while the revert was still taken. Unlike branch distances, these are retained when a call frame reverts.
+	revertSiteDistances map[RevertSite]*uint256.Int
+
+	// targetDistance describes the closest combined distance to a target observed in the target-directed mode, or
+	// NoTargetDistance if no target was approached. Like revert site distances, it is retained when a call frame reverts.
+	targetDistance uint64
+
+	// flatBranches tracks the JUMPIs whose condition could not be traced back to a comparison, such that their distance
+	// carries no gradient. Like revert site distances, they are retained when a call frame reverts.
+	flatBranches map[BranchSite]struct{}
+
 	// updateLock is a lock to offer concurrent thread safety for map accesses.
 	updateLock sync.Mutex
 }
 
+// RevertSite identifies a REVERT instruction in some contract code, along with the JUMPI guarding it (the last branch
+// taken in the call frame before reverting).
+type RevertSite struct {
+	// CodeHash is the lookup hash of the contract code containing the revert site.
+	CodeHash common.Hash
+	// RevertPc is the program counter of the REVERT instruction.
+	RevertPc uint64
+	// BranchPc is the program counter of the JUMPI instruction guarding the REVERT.
+	BranchPc uint64
+}
+
+// BranchSite identifies a JUMPI instruction in some contract code.
+type BranchSite struct {
+	// CodeHash is the lookup hash of the contract code containing the JUMPI.
+	CodeHash common.Hash
+	// Pc is the program counter of the JUMPI instruction.
+	Pc uint64
+}
+
+// RevertSiteDistance describes the minimum distance observed to flipping the branch guarding a RevertSite.
+type RevertSiteDistance struct {
+	RevertSite
+	// Distance is the minimum distance observed to flipping the guarding branch while the revert was still taken.
+	Distance *uint256.Int
+}
+
 type DumpDistance map[string]map[string]uint
 
 func (cm *BranchDistanceMaps) DumpBranchDistance(includeReverted bool) DumpDistance {
@@ -85,6 +134,60 @@ func (cm *BranchDistanceMaps) Reset() {
 	cm.cachedCodeAddress = common.Address{}
 	cm.cachedCodeHash = common.Hash{}
 	cm.cachedMap = nil
+	cm.revertSiteDistances = make(map[RevertSite]*uint256.Int)
+	cm.targetDistance = NoTargetDistance
+	cm.flatBranches = make(map[BranchSite]struct{})
+}
+
+// Clear clears the coverage state for the BranchDistanceMaps like Reset, but retains the memory allocated for it, so
+// that distances recorded afterwards reuse it.
+func (cm *BranchDistanceMaps) Clear() {
+	// Acquire our thread lock and defer our unlocking for when we exit this method
+	cm.updateLock.Lock()
+	defer cm.updateLock.Unlock()
+
+	for _, mapsByAddress := range cm.maps {
+		for _, contractDistanceMap := range mapsByAddress {
+			contractDistanceMap.distanceMap.release()
+			clear(contractDistanceMap.distanceMap.revertedDistance)
+			cm.spareMaps = append(cm.spareMaps, contractDistanceMap)
+		}
+		clear(mapsByAddress)
+		cm.spareMapsByAddress = append(cm.spareMapsByAddress, mapsByAddress)
+	}
+	clear(cm.maps)
+	cm.cachedCodeAddress = common.Address{}
+	cm.cachedCodeHash = common.Hash{}
+	cm.cachedMap = nil
+	clear(cm.revertSiteDistances)
+	cm.targetDistance = NoTargetDistance
+	clear(cm.flatBranches)
+}
+
+// getMapsByAddress obtains the lookup of ContractBranchDistanceMap objects by address for the provided code hash,
+// creating it if it does not exist.
+func (cm *BranchDistanceMaps) getMapsByAddress(codeHash common.Hash) map[common.Address]*ContractBranchDistanceMap {
+	mapsByAddress, codeHashExists := cm.maps[codeHash]
+	if !codeHashExists {
+		if count := len(cm.spareMapsByAddress); count > 0 {
+			mapsByAddress = cm.spareMapsByAddress[count-1]
+			cm.spareMapsByAddress = cm.spareMapsByAddress[:count-1]
+		} else {
+			mapsByAddress = make(map[common.Address]*ContractBranchDistanceMap)
+		}
+		cm.maps[codeHash] = mapsByAddress
+	}
+	return mapsByAddress
+}
+
+// newContractBranchDistanceMap obtains an empty ContractBranchDistanceMap, reusing one emptied by Clear if possible.
+func (cm *BranchDistanceMaps) newContractBranchDistanceMap() *ContractBranchDistanceMap {
+	if count := len(cm.spareMaps); count > 0 {
+		contractDistanceMap := cm.spareMaps[count-1]
+		cm.spareMaps = cm.spareMaps[:count-1]
+		return contractDistanceMap
+	}
+	return newContractBranchDistanceMap()
 }
 
 // getContractBranchDistanceMapHash obtain the hash used to look up a given contract's ContractBranchDistanceMap.
@@ -124,7 +227,7 @@ func (cm *BranchDistanceMaps) GetContractDistanceDistanceMap(bytecode []byte, in
 	if distanceByAddresses, ok := cm.maps[hash]; ok {
 		totalDistance := newContractBranchDistanceMap()
 		for _, coverage := range distanceByAddresses {
-			_, err := totalDistance.update(coverage)
+			_, _, _, err := totalDistance.update(coverage)
 			if err != nil {
 				return nil, err
 			}
@@ -136,47 +239,198 @@ func (cm *BranchDistanceMaps) GetContractDistanceDistanceMap(bytecode []byte, in
 }
 
 // Update updates the current distance maps with the provided ones.
-// Returns two booleans indicating whether successful or reverted coverage changed, or an error if one occurred.
+// Returns a boolean indicating whether successful distances changed, or an error if one occurred.
 func (cm *BranchDistanceMaps) Update(coverageMaps *BranchDistanceMaps) (bool, error) {
+	distanceChanged, _, err := cm.UpdateWithReverted(coverageMaps)
+	return distanceChanged, err
+}
+
+// UpdateWithReverted updates the current distance maps with the provided ones.
+// Returns two booleans indicating whether successful distances changed, and whether reverted distances changed for
+// branches without a successful distance, or an error if one occurred.
+func (cm *BranchDistanceMaps) UpdateWithReverted(coverageMaps *BranchDistanceMaps) (bool, bool, error) {
+	distanceChanged, _, revertedDistanceChanged, err := cm.UpdateWithReducedDistance(coverageMaps)
+	return distanceChanged, revertedDistanceChanged, err
+}
+
+// UpdateWithReducedDistance updates the current distance maps with the provided ones.
+// Returns three booleans indicating whether successful distances changed, whether any of them got strictly closer for
+// a branch or revert site the current maps already recorded a successful distance for (rather than reaching a new
+// one), and
+// whether reverted distances changed for branches without a successful distance, or an error if one occurred.
+func (cm *BranchDistanceMaps) UpdateWithReducedDistance(coverageMaps *BranchDistanceMaps) (bool, bool, bool, error) {
 	// If our maps provided are nil, do nothing
 	if coverageMaps == nil {
-		return false, nil
+		return false, false, false, nil
 	}
 
 	// Acquire our thread lock and defer our unlocking for when we exit this method
 	cm.updateLock.Lock()
 	defer cm.updateLock.Unlock()
 
-	// Create a boolean indicating whether we achieved new coverage
+	// Create booleans indicating whether we achieved new coverage
 	distanceChanged := false
+	distanceReduced := false
+	revertedDistanceChanged := false
 
 	// Loop for each coverage map provided
 	for codeHash, mapsByAddressToMerge := range coverageMaps.maps {
 		for codeAddress, coverageMapToMerge := range mapsByAddressToMerge {
 			// If a coverage map lookup for this code hash doesn't exist, create the mapping.
-			mapsByAddress, codeHashExists := cm.maps[codeHash]
-			if !codeHashExists {
-				mapsByAddress = make(map[common.Address]*ContractBranchDistanceMap)
-				cm.maps[codeHash] = mapsByAddress
+			mapsByAddress := cm.getMapsByAddress(codeHash)
+
+			// If a coverage map for this address doesn't exist in our current mapping, create one. Either way, update
+			// it in place with the one to merge, so the one to merge is not referenced and can be released.
+			existingCoverageMap, codeAddressExists := mapsByAddress[codeAddress]
+			if !codeAddressExists {
+				existingCoverageMap = cm.newContractBranchDistanceMap()
+				mapsByAddress[codeAddress] = existingCoverageMap
 			}
-
-			// If a coverage map for this address already exists in our current mapping, update it with the one
-			// to merge. If it doesn't exist, set it to the one to merge.
-			if existingCoverageMap, codeAddressExists := mapsByAddress[codeAddress]; codeAddressExists {
-				sChanged, err := existingCoverageMap.update(coverageMapToMerge)
-				distanceChanged = distanceChanged || sChanged
-				if err != nil {
-					return distanceChanged, err
-				}
-			} else {
-				mapsByAddress[codeAddress] = coverageMapToMerge
-				distanceChanged = coverageMapToMerge.distanceMap != nil
+			sChanged, sReduced, rChanged, err := existingCoverageMap.update(coverageMapToMerge)
+			distanceChanged = distanceChanged || sChanged
+			distanceReduced = distanceReduced || sReduced
+			revertedDistanceChanged = revertedDistanceChanged || rChanged
+			if err != nil {
+				return distanceChanged, distanceReduced, revertedDistanceChanged, err
 			}
 		}
 	}
 
+	// Merge our revert site distances, keeping the closest distance for each site. Getting closer to passing the guard
+	// of an already reached revert site is a reduction, so call sequences nearing it are favoured for mutations.
+	for revertSite, distance := range coverageMaps.revertSiteDistances {
+		_, revertSiteReached := cm.revertSiteDistances[revertSite]
+		if cm.setRevertSiteDistance(revertSite, distance) {
+			distanceChanged = true
+			distanceReduced = distanceReduced || revertSiteReached
+		}
+	}
+
+	// Keep the closest target distance.
+	distanceChanged = cm.setTargetDistance(coverageMaps.targetDistance) || distanceChanged
+
+	// Merge our flat branches. Learning that a branch is flat is not progress, so it is not considered a change.
+	for branchSite := range coverageMaps.flatBranches {
+		cm.flatBranches[branchSite] = struct{}{}
+	}
+
 	// Return our results
-	return distanceChanged, nil
+	return distanceChanged, distanceReduced, revertedDistanceChanged, nil
+}
+
+// Release clears the distances recorded, returning their buffers to a pool for reuse by other maps. The maps must not
+// be used after being released.
+func (cm *BranchDistanceMaps) Release() {
+	// Acquire our thread lock and defer our unlocking for when we exit this method
+	cm.updateLock.Lock()
+	defer cm.updateLock.Unlock()
+
+	for _, mapsByAddress := range cm.maps {
+		for _, contractDistanceMap := range mapsByAddress {
+			contractDistanceMap.distanceMap.release()
+		}
+	}
+	cm.maps = nil
+	cm.cachedMap = nil
+}
+
+// SetRevertSiteDistance records the distance to flipping the branch guarding a revert site which was reached,
+// keeping the closest distance observed.
+// Returns a boolean indicating whether the distance for the revert site decreased.
+func (cm *BranchDistanceMaps) SetRevertSiteDistance(revertSite RevertSite, distance *uint256.Int) bool {
+	// Acquire our thread lock and defer our unlocking for when we exit this method
+	cm.updateLock.Lock()
+	defer cm.updateLock.Unlock()
+
+	return cm.setRevertSiteDistance(revertSite, distance)
+}
+
+// setRevertSiteDistance records the distance for a revert site without acquiring the update lock.
+func (cm *BranchDistanceMaps) setRevertSiteDistance(revertSite RevertSite, distance *uint256.Int) bool {
+	if existingDistance, exists := cm.revertSiteDistances[revertSite]; exists && !existingDistance.Gt(distance) {
+		return false
+	}
+	cm.revertSiteDistances[revertSite] = new(uint256.Int).Set(distance)
+	return true
+}
+
+// SetFlatBranch annotates a JUMPI as flat, i.e. its condition could not be traced back to a comparison, such that its
+// distance carries no gradient. Flat branches are only reported, so users can tell how much of the code branch
+// distances fail to guide the fuzzer through.
+func (cm *BranchDistanceMaps) SetFlatBranch(branchSite BranchSite) {
+	// Acquire our thread lock and defer our unlocking for when we exit this method
+	cm.updateLock.Lock()
+	defer cm.updateLock.Unlock()
+
+	cm.flatBranches[branchSite] = struct{}{}
+}
+
+// FlatBranchCount returns the amount of JUMPIs annotated as flat.
+func (cm *BranchDistanceMaps) FlatBranchCount() int {
+	// Acquire our thread lock and defer our unlocking for when we exit this method
+	cm.updateLock.Lock()
+	defer cm.updateLock.Unlock()
+
+	return len(cm.flatBranches)
+}
+
+// TargetDistance returns the closest combined distance to a target observed in the target-directed mode, or
+// NoTargetDistance if no target was approached.
+func (cm *BranchDistanceMaps) TargetDistance() uint64 {
+	// Acquire our thread lock and defer our unlocking for when we exit this method
+	cm.updateLock.Lock()
+	defer cm.updateLock.Unlock()
+
+	return cm.targetDistance
+}
+
+// SetTargetDistance records a combined distance to a target, keeping the closest distance observed.
+// Returns a boolean indicating whether the target distance decreased.
+func (cm *BranchDistanceMaps) SetTargetDistance(distance uint64) bool {
+	// Acquire our thread lock and defer our unlocking for when we exit this method
+	cm.updateLock.Lock()
+	defer cm.updateLock.Unlock()
+
+	return cm.setTargetDistance(distance)
+}
+
+// setTargetDistance records a target distance without acquiring the update lock.
+func (cm *BranchDistanceMaps) setTargetDistance(distance uint64) bool {
+	if distance >= cm.targetDistance {
+		return false
+	}
+	cm.targetDistance = distance
+	return true
+}
+
+// AlmostPassingRevertSites returns the revert sites whose guarding branch was closest to being flipped, sorted by
+// ascending distance. Revert sites for which the distance is unknown are omitted. At most limit sites are returned,
+// or all of them if limit is not positive.
+func (cm *BranchDistanceMaps) AlmostPassingRevertSites(limit int) []RevertSiteDistance {
+	// Acquire our thread lock and defer our unlocking for when we exit this method
+	cm.updateLock.Lock()
+	defer cm.updateLock.Unlock()
+
+	revertSites := make([]RevertSiteDistance, 0, len(cm.revertSiteDistances))
+	for revertSite, distance := range cm.revertSiteDistances {
+		if distance.Eq(UnknownDistance) {
+			continue
+		}
+		revertSites = append(revertSites, RevertSiteDistance{RevertSite: revertSite, Distance: new(uint256.Int).Set(distance)})
+	}
+	sort.Slice(revertSites, func(i, j int) bool {
+		if c := revertSites[i].Distance.Cmp(revertSites[j].Distance); c != 0 {
+			return c < 0
+		}
+		if revertSites[i].CodeHash != revertSites[j].CodeHash {
+			return bytes.Compare(revertSites[i].CodeHash[:], revertSites[j].CodeHash[:]) < 0
+		}
+		return revertSites[i].RevertPc < revertSites[j].RevertPc
+	})
+	if limit > 0 && len(revertSites) > limit {
+		revertSites = revertSites[:limit]
+	}
+	return revertSites
 }
 
 // SetAt sets the coverage state of a given path of a branch instruction within code coverage data.
@@ -199,18 +453,14 @@ func (cm *BranchDistanceMaps) SetAt(codeAddress common.Address, codeLookupHash c
 		branchDistanceMap = cm.cachedMap
 	} else {
 		// If a coverage map lookup for this code hash doesn't exist, create the mapping.
-		mapsByCodeAddress, codeHashExists := cm.maps[codeLookupHash]
-		if !codeHashExists {
-			mapsByCodeAddress = make(map[common.Address]*ContractBranchDistanceMap)
-			cm.maps[codeLookupHash] = mapsByCodeAddress
-		}
+		mapsByCodeAddress := cm.getMapsByAddress(codeLookupHash)
 
 		// Obtain the coverage map for this code address if it already exists. If it does not, create a new one.
 		if existingCoverageMap, codeAddressExists := mapsByCodeAddress[codeAddress]; codeAddressExists {
 			branchDistanceMap = existingCoverageMap
 		} else {
-			branchDistanceMap = newContractBranchDistanceMap()
-			cm.maps[codeLookupHash][codeAddress] = branchDistanceMap
+			branchDistanceMap = cm.newContractBranchDistanceMap()
+			mapsByCodeAddress[codeAddress] = branchDistanceMap
 			addedNewMap = true
 		}
 
@@ -225,9 +475,8 @@ func (cm *BranchDistanceMaps) SetAt(codeAddress common.Address, codeLookupHash c
 	return addedNewMap || changedInMap, err
 }
 
-// RevertAll sets all coverage in the coverage map as reverted coverage. Reverted coverage is updated with successful
-// coverage, the successful coverage is cleared.
-// Returns a boolean indicating whether reverted coverage increased, and an error if one occurred.
+// RevertAll sets all distances in the distance map as reverted distances. Reverted distances are updated with
+// successful distances, the successful distances are cleared.
 func (cm *BranchDistanceMaps) RevertAll() {
 	// Acquire our thread lock and defer our unlocking for when we exit this method
 	cm.updateLock.Lock()
@@ -236,8 +485,8 @@ func (cm *BranchDistanceMaps) RevertAll() {
 	// Loop for each coverage map provided
 	for _, mapsByAddressToMerge := range cm.maps {
 		for _, contractDistanceMap := range mapsByAddressToMerge {
-			// Clear our successful coverage, as these maps were marked as reverted.
-			contractDistanceMap.distanceMap.Reset()
+			// Move our successful distances to the reverted ones, as these maps were marked as reverted.
+			contractDistanceMap.distanceMap.revert()
 		}
 	}
 }
@@ -257,15 +506,16 @@ func newContractBranchDistanceMap() *ContractBranchDistanceMap {
 }
 
 // update creates updates the current ContractBranchDistanceMap with the provided one.
-// Returns two booleans indicating whether successful or reverted coverage changed, or an error if one was encountered.
-func (cm *ContractBranchDistanceMap) update(coverageMap *ContractBranchDistanceMap) (bool, error) {
-	// Update our success coverage data
-	successfulCoverageChanged, err := cm.distanceMap.update(coverageMap.distanceMap)
+// Returns three booleans indicating whether successful distances changed, whether any of them got closer for an
+// already reached branch, and whether reverted distances changed, or an error if one was encountered.
+func (cm *ContractBranchDistanceMap) update(coverageMap *ContractBranchDistanceMap) (bool, bool, bool, error) {
+	// Update our distance data
+	successfulDistanceChanged, successfulDistanceReduced, revertedDistanceChanged, err := cm.distanceMap.update(coverageMap.distanceMap)
 	if err != nil {
-		return false, err
+		return false, false, false, err
 	}
 
-	return successfulCoverageChanged, nil
+	return successfulDistanceChanged, successfulDistanceReduced, revertedDistanceChanged, nil
 }
 
 // setDistanceAt sets the distance at a given branch within a ContractBranchDistanceMap used for
@@ -276,91 +526,180 @@ func (cm *ContractBranchDistanceMap) setDistanceAt(branchSize, id int, distance
 	return cm.distanceMap.setDistanceAt(branchSize, id, distance)
 }
 
-// GetCoverageRate returns the covered branch size and the total branch size of the contract.
+// GetDistance returns the closest distance observed for the provided branch id in non-reverted call frames, or nil if
+// the branch was never reached.
+func (cm *ContractBranchDistanceMap) GetDistance(id int) *uint256.Int {
+	if cm == nil || id < 0 || id >= len(cm.distanceMap.executedFlags) || cm.distanceMap.executedFlags[id] == 0 {
+		return nil
+	}
+	return new(uint256.Int).Set(&cm.distanceMap.distance[id])
+}
+
+// GetCoverageRate returns the covered branch size and the total branch size of the contract. If includeReverted is
+// set, branches only reached in reverted call frames are counted as covered.
 func (cm *ContractBranchDistanceMap) GetCoverageRate(includeReverted bool) (int, int) {
 	if !includeReverted {
 		return cm.distanceMap.getDistance()
 	}
-	allCoverage := &DistanceMapBranchData{}
-	_, _ = allCoverage.update(cm.distanceMap)
-	return allCoverage.getDistance()
+	coveredBranchSize, totalBranchSize := cm.distanceMap.getDistance()
+	for id := range cm.distanceMap.revertedDistance {
+		if id < totalBranchSize && cm.distanceMap.executedFlags[id] == 0 {
+			coveredBranchSize++
+		}
+	}
+	return coveredBranchSize, totalBranchSize
+}
+
+// distanceBufferPool pools the buffers holding the distances of DistanceMapBranchData, so that the maps created for
+// each call frame by the tracer do not allocate new buffers once warm.
+var distanceBufferPool = sync.Pool{
+	New: func() any {
+		buffer := make([]uint256.Int, 0)
+		return &buffer
+	},
+}
+
+// acquireDistanceBuffer obtains a zeroed distance buffer of the provided size, reusing a pooled one if possible.
+func acquireDistanceBuffer(size int) []uint256.Int {
+	buffer := *distanceBufferPool.Get().(*[]uint256.Int)
+	if cap(buffer) < size {
+		return make([]uint256.Int, size)
+	}
+	buffer = buffer[:size]
+	clear(buffer)
+	return buffer
+}
+
+// releaseDistanceBuffer returns a distance buffer to the pool. It must not be used after being released.
+func releaseDistanceBuffer(buffer []uint256.Int) {
+	if buffer == nil {
+		return
+	}
+	buffer = buffer[:0]
+	distanceBufferPool.Put(&buffer)
 }
 
 // DistanceMapBranchData represents a data structure used to identify branch coverage of some init
 // or runtime bytecode.
 type DistanceMapBranchData struct {
 	executedFlags []byte
-	distance      map[int]*uint256.Int
+
+	// distance holds the closest distance observed for each branch, by branch id. It is allocated lazily alongside
+	// executedFlags and updated in place. Only distances of branches with an executed flag set are meaningful.
+	distance []uint256.Int
+
+	// revertedDistance tracks the closest distance observed for each branch in call frames which later reverted. It
+	// is kept apart from distance, as distances of successful call frames take priority.
+	revertedDistance map[int]*uint256.Int
 }
 
 // Reset resets the branch coverage map data to be empty.
 func (cm *DistanceMapBranchData) Reset() {
-	cm.executedFlags = nil
-	cm.distance = make(map[int]*uint256.Int)
+	cm.release()
+	cm.revertedDistance = make(map[int]*uint256.Int)
 }
 
-// update creates updates the current DistanceMapBranchData with the provided one.
-// Returns a boolean indicating whether new coverage was achieved, or an error if one was encountered.
-func (cm *DistanceMapBranchData) update(branchDistanceMap *DistanceMapBranchData) (bool, error) {
-	// If the coverage map execution data provided is nil, exit early
-	if branchDistanceMap.executedFlags == nil {
-		return false, nil
+// release clears the successful distances, returning their buffer to the pool. The buffer of executed flags is
+// retained for reuse.
+func (cm *DistanceMapBranchData) release() {
+	releaseDistanceBuffer(cm.distance)
+	cm.executedFlags = cm.executedFlags[:0]
+	cm.distance = nil
+}
+
+// revert moves the successful distances to the reverted distances, keeping the closest distance for each branch, and
+// clears the successful distances.
+func (cm *DistanceMapBranchData) revert() {
+	for id, executed := range cm.executedFlags {
+		if executed != 0 {
+			cm.setRevertedDistanceAt(id, &cm.distance[id])
+		}
 	}
+	cm.release()
+}
 
-	// If the current map has no execution data, simply set it to the provided one.
-	if cm.executedFlags == nil {
-		cm.executedFlags = branchDistanceMap.executedFlags
-		cm.distance = make(map[int]*uint256.Int)
-		// fmt.Println(branchDistanceMap.executedFlags, branchDistanceMap.distance)
-		for i := 0; i < len(branchDistanceMap.executedFlags); i++ {
-			if branchDistanceMap.executedFlags[i] == 1 {
-				cm.distance[i] = new(uint256.Int).Set(branchDistanceMap.distance[i])
-			}
+// setRevertedDistanceAt records the reverted distance at a given branch id, keeping the closest distance observed.
+// Returns a boolean indicating whether the reverted distance decreased.
+func (cm *DistanceMapBranchData) setRevertedDistanceAt(id int, distance *uint256.Int) bool {
+	if cm.revertedDistance == nil {
+		cm.revertedDistance = make(map[int]*uint256.Int)
+	}
+	if existingDistance, exists := cm.revertedDistance[id]; exists {
+		if !existingDistance.Gt(distance) {
+			return false
+		}
+		existingDistance.Set(distance)
+		return true
+	}
+	cm.revertedDistance[id] = new(uint256.Int).Set(distance)
+	return true
+}
+
+// update creates updates the current DistanceMapBranchData with the provided one, in place. The provided data is
+// copied rather than referenced, so it may be released afterwards.
+// Returns three booleans indicating whether new successful coverage was achieved, whether a successful distance
+// decreased for an already reached branch, and whether a reverted distance decreased for a branch without successful
+// coverage, or an error if one was encountered.
+func (cm *DistanceMapBranchData) update(branchDistanceMap *DistanceMapBranchData) (bool, bool, bool, error) {
+	// Merge the reverted distances with lower priority: a closer reverted distance is only considered a change if the
+	// branch has not been reached by a successful call frame.
+	revertedChanged := false
+	for id, distance := range branchDistanceMap.revertedDistance {
+		if cm.setRevertedDistanceAt(id, distance) && (id >= len(cm.executedFlags) || cm.executedFlags[id] == 0) {
+			revertedChanged = true
 		}
-		// fmt.Println("new distance map", cm.distance)
-		return true, nil
+	}
+
+	// If the coverage map execution data provided is empty, exit early
+	if len(branchDistanceMap.executedFlags) == 0 {
+		return false, false, revertedChanged, nil
+	}
+
+	// If the current map has no execution data, simply copy the provided one.
+	if len(cm.executedFlags) == 0 {
+		cm.executedFlags = append(cm.executedFlags[:0], branchDistanceMap.executedFlags...)
+		cm.distance = acquireDistanceBuffer(len(branchDistanceMap.executedFlags))
+		copy(cm.distance, branchDistanceMap.distance)
+		return true, false, revertedChanged, nil
 	}
 
 	// Update each byte which represents a branch which was covered.
 	changed := false
+	reduced := false
 	for i := 0; i < len(cm.executedFlags) && i < len(branchDistanceMap.executedFlags); i++ {
 		if cm.executedFlags[i] == 0 && branchDistanceMap.executedFlags[i] != 0 {
 			cm.executedFlags[i] = 1
-			cm.distance[i] = new(uint256.Int).Set(branchDistanceMap.distance[i])
-			// fmt.Println("new distance", cm.distance)
+			cm.distance[i].Set(&branchDistanceMap.distance[i])
 			changed = true
 		} else if cm.executedFlags[i] == 1 && branchDistanceMap.executedFlags[i] == 1 {
-			if cm.distance[i].Gt(branchDistanceMap.distance[i]) {
-				cm.distance[i] = new(uint256.Int).Set(branchDistanceMap.distance[i])
-				// fmt.Println("closer distance", cm.distance)
+			if cm.distance[i].Gt(&branchDistanceMap.distance[i]) {
+				cm.distance[i].Set(&branchDistanceMap.distance[i])
 				changed = true
+				reduced = true
 			}
 		}
 	}
-	return changed, nil
+	return changed, reduced, revertedChanged, nil
 }
 
-// setDistanceAt sets the distance at a given branch id within a DistanceMapBranchData.
+// setDistanceAt sets the distance at a given branch id within a DistanceMapBranchData. The distance is copied.
 // Returns a boolean indicating whether lower distance was achieved, or an error if one occurred.
 func (cm *DistanceMapBranchData) setDistanceAt(branchSize, id int, distance *uint256.Int) (bool, error) {
 	// If the execution flags don't exist, create them for this code size.
-	if cm.executedFlags == nil {
-		cm.executedFlags = make([]byte, branchSize)
-	}
-
-	if cm.distance == nil {
-		cm.distance = make(map[int]*uint256.Int)
+	if len(cm.executedFlags) == 0 {
+		cm.executedFlags = utils.SliceResizeZeroed(cm.executedFlags, branchSize)
+		cm.distance = acquireDistanceBuffer(branchSize)
 	}
 
 	// If our program counter is in range, determine if we achieved new coverage for the first time, and update it.
 	if id < len(cm.executedFlags) {
 		if cm.executedFlags[id] == 0 {
 			cm.executedFlags[id] = 1
-			cm.distance[id] = distance
+			cm.distance[id].Set(distance)
 			return true, nil
 		} else {
 			if cm.distance[id].Gt(distance) {
-				cm.distance[id] = distance
+				cm.distance[id].Set(distance)
 				return true, nil
 			}
 		}
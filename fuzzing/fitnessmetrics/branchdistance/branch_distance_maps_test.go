@@ -0,0 +1,222 @@
+package branchdistance
+
+import (
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+// newMapsWithDistance returns new BranchDistanceMaps recording the provided distance for the provided branch id of a
+// single contract.
+func newMapsWithDistance(t *testing.T, id int, distance uint64) *BranchDistanceMaps {
+	maps := NewBranchDistanceMaps()
+	_, err := maps.SetAt(common.Address{1}, common.Hash{1}, 4, id, uint256.NewInt(distance))
+	assert.NoError(t, err)
+	return maps
+}
+
+// TestBranchDistanceMapsUpdateWithReducedDistance tests that only strictly closer distances for already reached
+// branches are reported as reductions by an update, while reaching a new branch is only reported as a change.
+func TestBranchDistanceMapsUpdateWithReducedDistance(t *testing.T) {
+	corpusMaps := newMapsWithDistance(t, 0, 10)
+	tests := []struct {
+		maps    *BranchDistanceMaps
+		changed bool
+		reduced bool
+	}{
+		{maps: nil},
+		{maps: newMapsWithDistance(t, 0, 10)},
+		{maps: newMapsWithDistance(t, 1, 5), changed: true},
+		{maps: newMapsWithDistance(t, 0, 5), changed: true, reduced: true},
+		{maps: newMapsWithDistance(t, 0, 5)},
+	}
+	for i, test := range tests {
+		changed, reduced, _, err := corpusMaps.UpdateWithReducedDistance(test.maps)
+		assert.NoError(t, err)
+		assert.EqualValues(t, test.changed, changed, i)
+		assert.EqualValues(t, test.reduced, reduced, i)
+	}
+}
+
+// TestBranchDistanceMapsUpdateCopies tests that updating maps copies the distances merged, so the merged maps can be
+// released and their buffers reused without affecting the updated maps.
+func TestBranchDistanceMapsUpdateCopies(t *testing.T) {
+	maps := NewBranchDistanceMaps()
+	frameMaps := NewBranchDistanceMaps()
+	_, err := frameMaps.SetAt(common.Address{1}, common.Hash{1}, 4, 0, uint256.NewInt(7))
+	assert.NoError(t, err)
+
+	changed, err := maps.Update(frameMaps)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	frameMaps.Release()
+
+	// Reuse the released buffer for another call frame.
+	otherFrameMaps := NewBranchDistanceMaps()
+	_, err = otherFrameMaps.SetAt(common.Address{1}, common.Hash{1}, 4, 0, uint256.NewInt(3))
+	assert.NoError(t, err)
+
+	assert.EqualValues(t, 7, maps.maps[common.Hash{1}][common.Address{1}].GetDistance(0).Uint64())
+	_, reduced, _, err := maps.UpdateWithReducedDistance(otherFrameMaps)
+	assert.NoError(t, err)
+	assert.True(t, reduced)
+}
+
+// TestBranchDistanceMapsClear tests that cleared maps are empty, and that the contract maps they reuse afterwards do
+// not carry over any distance recorded before they were cleared.
+func TestBranchDistanceMapsClear(t *testing.T) {
+	maps := newMapsWithDistance(t, 0, 10)
+	maps.SetRevertSiteDistance(RevertSite{CodeHash: common.Hash{1}, RevertPc: 2, BranchPc: 1}, uint256.NewInt(4))
+	maps.SetFlatBranch(BranchSite{CodeHash: common.Hash{1}, Pc: 1})
+	maps.SetTargetDistance(5)
+	maps.RevertAll()
+	_, err := maps.SetAt(common.Address{1}, common.Hash{1}, 4, 1, uint256.NewInt(3))
+	assert.NoError(t, err)
+
+	maps.Clear()
+	assert.True(t, maps.IsEmpty())
+	assert.Empty(t, maps.AlmostPassingRevertSites(0))
+	assert.EqualValues(t, 0, maps.FlatBranchCount())
+	assert.EqualValues(t, NoTargetDistance, maps.TargetDistance())
+	covered, total := maps.TotalBranchDistance(true, nil)
+	assert.EqualValues(t, 0, covered)
+	assert.EqualValues(t, 0, total)
+
+	// Record a distance for another branch, reusing the cleared contract map.
+	_, err = maps.SetAt(common.Address{1}, common.Hash{1}, 4, 2, uint256.NewInt(7))
+	assert.NoError(t, err)
+	contractMap := maps.maps[common.Hash{1}][common.Address{1}]
+	assert.Nil(t, contractMap.GetDistance(0))
+	assert.Nil(t, contractMap.GetDistance(1))
+	assert.EqualValues(t, 7, contractMap.GetDistance(2).Uint64())
+	covered, total = maps.TotalBranchDistance(true, nil)
+	assert.EqualValues(t, 1, covered)
+	assert.EqualValues(t, 4, total)
+}
+
+// BenchmarkBranchDistanceMapsPerCallFrame measures recording the distances of a call frame and merging them into
+// those of its transaction, with maps allocated for each call frame.
+func BenchmarkBranchDistanceMapsPerCallFrame(b *testing.B) {
+	transactionMaps := NewBranchDistanceMaps()
+	distance := uint256.NewInt(7)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		frameMaps := NewBranchDistanceMaps()
+		_, _ = frameMaps.SetAt(common.Address{1}, common.Hash{1}, 64, i%64, distance)
+		_, _ = transactionMaps.Update(frameMaps)
+		frameMaps.Release()
+	}
+}
+
+// BenchmarkBranchDistanceMapsResultPool measures recording the distances of a call frame and merging them into those
+// of its transaction, with maps recycled through a fitnessmetrics.ResultPool as done by the tracer.
+func BenchmarkBranchDistanceMapsResultPool(b *testing.B) {
+	pool := fitnessmetrics.NewResultPool(NewBranchDistanceMaps)
+	transactionMaps := NewBranchDistanceMaps()
+	distance := uint256.NewInt(7)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		frameMaps := pool.Get()
+		_, _ = frameMaps.SetAt(common.Address{1}, common.Hash{1}, 64, i%64, distance)
+		_, _ = transactionMaps.Update(frameMaps)
+		pool.Put(frameMaps)
+	}
+}
+
+// newRevertedMapsWithDistance returns new BranchDistanceMaps recording the provided distance for the provided branch id
+// of a single contract in a reverted call frame.
+func newRevertedMapsWithDistance(t *testing.T, id int, distance uint64) *BranchDistanceMaps {
+	maps := newMapsWithDistance(t, id, distance)
+	maps.RevertAll()
+	return maps
+}
+
+// TestBranchDistanceMapsUpdateWithReverted tests that reverted distances are kept apart from successful ones, and that
+// a closer reverted distance is only reported as a change for branches not reached by a successful call frame.
+func TestBranchDistanceMapsUpdateWithReverted(t *testing.T) {
+	maps := NewBranchDistanceMaps()
+	tests := []struct {
+		maps            *BranchDistanceMaps
+		changed         bool
+		revertedChanged bool
+	}{
+		{maps: newRevertedMapsWithDistance(t, 0, 10), revertedChanged: true},
+		{maps: newRevertedMapsWithDistance(t, 0, 10)},
+		{maps: newRevertedMapsWithDistance(t, 0, 12)},
+		{maps: newRevertedMapsWithDistance(t, 0, 5), revertedChanged: true},
+		{maps: newMapsWithDistance(t, 0, 20), changed: true},
+		{maps: newRevertedMapsWithDistance(t, 0, 1)},
+	}
+	for i, test := range tests {
+		changed, revertedChanged, err := maps.UpdateWithReverted(test.maps)
+		assert.NoError(t, err)
+		assert.EqualValues(t, test.changed, changed, i)
+		assert.EqualValues(t, test.revertedChanged, revertedChanged, i)
+	}
+
+	// The successful distance is not affected by closer reverted distances, which are still recorded.
+	contractMap := maps.maps[common.Hash{1}][common.Address{1}]
+	assert.EqualValues(t, 20, contractMap.GetDistance(0).Uint64())
+	assert.EqualValues(t, 1, contractMap.distanceMap.revertedDistance[0].Uint64())
+
+	// Branches only reached in reverted call frames are only covered when including reverted distances.
+	_, err := maps.Update(newRevertedMapsWithDistance(t, 1, 3))
+	assert.NoError(t, err)
+	covered, total := contractMap.GetCoverageRate(false)
+	assert.EqualValues(t, 1, covered)
+	assert.EqualValues(t, 4, total)
+	covered, _ = contractMap.GetCoverageRate(true)
+	assert.EqualValues(t, 2, covered)
+}
+
+// TestBranchDistanceMapsRevertSiteDistances tests that the closest distance is kept for each revert site, that merging
+// a closer distance for an already reached revert site is reported as a reduction, and that almost passing revert
+// sites are ordered by ascending distance, omitting those whose distance is unknown.
+func TestBranchDistanceMapsRevertSiteDistances(t *testing.T) {
+	requireSite := RevertSite{CodeHash: common.Hash{1}, RevertPc: 20, BranchPc: 18}
+	otherRequireSite := RevertSite{CodeHash: common.Hash{1}, RevertPc: 10, BranchPc: 8}
+	otherContractSite := RevertSite{CodeHash: common.Hash{2}, RevertPc: 20, BranchPc: 18}
+	unknownSite := RevertSite{CodeHash: common.Hash{3}, RevertPc: 5, BranchPc: 4}
+
+	// Only strictly closer distances are recorded.
+	maps := NewBranchDistanceMaps()
+	assert.True(t, maps.SetRevertSiteDistance(requireSite, uint256.NewInt(50)))
+	assert.False(t, maps.SetRevertSiteDistance(requireSite, uint256.NewInt(50)))
+	assert.False(t, maps.SetRevertSiteDistance(requireSite, uint256.NewInt(80)))
+	assert.True(t, maps.SetRevertSiteDistance(requireSite, uint256.NewInt(30)))
+
+	tests := []struct {
+		revertSite RevertSite
+		distance   uint64
+		changed    bool
+		reduced    bool
+	}{
+		{revertSite: requireSite, distance: 30},
+		{revertSite: requireSite, distance: 40},
+		{revertSite: otherRequireSite, distance: 7, changed: true},
+		{revertSite: requireSite, distance: 3, changed: true, reduced: true},
+		{revertSite: otherContractSite, distance: 7, changed: true},
+	}
+	for i, test := range tests {
+		txMaps := NewBranchDistanceMaps()
+		txMaps.SetRevertSiteDistance(test.revertSite, uint256.NewInt(test.distance))
+		changed, reduced, revertedChanged, err := maps.UpdateWithReducedDistance(txMaps)
+		assert.NoError(t, err)
+		assert.EqualValues(t, test.changed, changed, i)
+		assert.EqualValues(t, test.reduced, reduced, i)
+		assert.False(t, revertedChanged, i)
+	}
+	maps.SetRevertSiteDistance(unknownSite, UnknownDistance)
+
+	// Ties in distance are ordered by code hash, and then by program counter.
+	expected := []RevertSiteDistance{
+		{RevertSite: requireSite, Distance: uint256.NewInt(3)},
+		{RevertSite: otherRequireSite, Distance: uint256.NewInt(7)},
+		{RevertSite: otherContractSite, Distance: uint256.NewInt(7)},
+	}
+	assert.EqualValues(t, expected, maps.AlmostPassingRevertSites(0))
+	assert.EqualValues(t, expected[:2], maps.AlmostPassingRevertSites(2))
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"fmt"
 	"math/big"
+	"math/bits"
+	"sync/atomic"
 
 	"github.com/crytic/medusa-geth/common"
 	"github.com/crytic/medusa-geth/core/tracing"
@@ -13,7 +15,9 @@ import (
 	"github.com/crytic/medusa/chain"
 	"github.com/crytic/medusa/chain/types"
 	compilationTypes "github.com/crytic/medusa/compilation/types"
+	"github.com/crytic/medusa/fuzzing/config"
 	fuzzerTypes "github.com/crytic/medusa/fuzzing/contracts"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics"
 	"github.com/crytic/medusa/logging"
 	"github.com/holiman/uint256"
 )
@@ -50,21 +54,56 @@ type BranchDistanceTracer struct {
 	// callFrameStates describes the state tracked by the tracer per call frame.
 	callFrameStates []*branchDistanceTracerCallFrameState
 
+	// resultPool recycles the distance maps recorded for each transaction and call frame.
+	resultPool *fitnessmetrics.ResultPool[*BranchDistanceMaps]
+
+	// lastResults describes the message results the distance maps of the latest transaction were stored in.
+	lastResults *types.MessageResults
+
 	// callDepth refers to the current EVM depth during tracing.
 	callDepth int
 
 	// branchMaps stores branch map for each contract code
 	branchMaps map[common.Hash]*BranchMap
 
+	// dynamicBranchMaps stores the branch maps of runtime bytecode not in branchMaps, such as that of contracts
+	// deployed by factories, built the first time it executes. If nil, such code is not traced.
+	dynamicBranchMaps *fitnessmetrics.CodeMapRegistry[*BranchMap]
+
 	// evmContext holds the VM context during tracing
 	evmContext *tracing.VMContext
 
 	// nativeTracer is the underlying tracer used to capture EVM execution.
 	nativeTracer *chain.TestChainTracer
+
+	// config describes the configuration used to back-propagate branch conditions.
+	config config.BranchDistanceConfig
+
+	// targetDistanceMaps stores the target distance map for each contract code containing targets in the
+	// target-directed mode.
+	targetDistanceMaps map[common.Hash]*TargetDistanceMap
+
+	// exclusions describes the contracts which are not traced, or nil if none are excluded.
+	exclusions *fitnessmetrics.MetricExclusions
+
+	// operationRingPool holds the operation buffers of exited call frames, so that they can be reused by new ones.
+	operationRingPool []*operationRing
+
+	// backPropagationFailures counts the back-propagations which failed to find a branch distance, for which
+	// UnknownDistance was recorded instead. It may be shared by the tracers of a fuzzing campaign.
+	backPropagationFailures *atomic.Uint64
 }
 
 var DD *uint256.Int = uint256.NewInt(1)
 
+// UnknownDistance is the sentinel distance recorded for a branch when back-propagation fails to find its distance. As
+// the maximum distance, it is replaced by any distance found later on.
+var UnknownDistance *uint256.Int = new(uint256.Int).SetAllOne()
+
+// diagnostics provides debug logging for branch distance tracing, and counts the anomalies it recovers from, such as
+// failed back-propagations (by BranchDistanceStatus) or code executed without a known branch map (UNKNOWNCODEHASH).
+var diagnostics = logging.NewDiagnostics("branchdistance")
+
 type BranchDistanceStatus int
 
 const (
@@ -87,11 +126,6 @@ func IsFoundDistance(x BranchDistanceStatus) bool {
 	return x == FOUND || x == ENDWITHCALL
 }
 
-type Operation struct {
-	opcode   vm.OpCode
-	tmpStack []uint256.Int
-}
-
 // branchDistanceTracerCallFrameState tracks state across call frames in the tracer.
 type branchDistanceTracerCallFrameState struct {
 	// initialized tracks whether or not this has happened yet.
@@ -106,42 +140,61 @@ type branchDistanceTracerCallFrameState struct {
 	// lookupHash describes the hash used to look up the ContractCoverageMap being updated in this frame.
 	lookupHash *common.Hash
 
-	cachedOperations []Operation
+	// traced indicates whether the code executing in this frame has a branch map, i.e. whether it is traced.
+	traced bool
+
+	// branchMap describes the branch map of the code executing in this frame, if it is traced.
+	branchMap *BranchMap
+
+	// operations caches the most recent operations executed in this frame on traced code, for back-propagation.
+	operations *operationRing
+
+	// lastBranchPc is the program counter of the last JUMPI executed in this frame on traced code.
+	lastBranchPc uint64
+	// lastBranchDistance is the distance to flipping the last JUMPI executed in this frame on traced code, or nil if
+	// no such JUMPI was executed yet.
+	lastBranchDistance *uint256.Int
+
+	// lastComparisonDistance is the distance between the operands of the last comparison executed in this frame on
+	// traced code, regardless of the lookback window. It is only set if hasLastComparison is.
+	lastComparisonDistance uint256.Int
+	// hasLastComparison indicates whether a comparison was executed in this frame on traced code yet.
+	hasLastComparison bool
 
 	// address is used by OnOpcode to cache the result of scope.Address(), which is slow.
 	// It records the address of the current contract.
 	address common.Address
 }
 
-// NewBranchDistanceTracer returns a new CoverageTracer.
-func NewBranchDistanceTracer(contracts fuzzerTypes.Contracts) *BranchDistanceTracer {
+// NewBranchDistanceTracer returns a new CoverageTracer. In the target-directed mode, targetPcs provides the runtime
+// bytecode program counters to direct fuzzing towards, by contract name.
+func NewBranchDistanceTracer(contracts fuzzerTypes.Contracts, branchDistanceConfig config.BranchDistanceConfig, targetPcs map[string][]uint64) *BranchDistanceTracer {
 	// Create a map of block maps for each contract code
 	branchMaps := make(map[common.Hash]*BranchMap)
+	targetDistanceMaps := make(map[common.Hash]*TargetDistanceMap)
 	for _, contract := range contracts {
-		compiledContract := contract.CompiledContract()
-
-		initBytecode := compiledContract.InitBytecode
-		initBytecodeHash := getContractBranchDistanceMapHash(initBytecode, true)
+		initBytecodeHash, initBytecode, runtimeBytecodeHash, runtimeBytecode, runtimeData := getContractBranchBytecode(contract)
 
-		runtimeBytecode := compiledContract.RuntimeBytecode
-		runtimeBytecodeHash := getContractBranchDistanceMapHash(runtimeBytecode, false)
+		branchMaps[initBytecodeHash] = GetBranchMapFromBytecode(initBytecode, branchDistanceConfig.IndirectJumpBranches)
+		branchMaps[runtimeBytecodeHash] = GetBranchMapFromCode(runtimeBytecode, runtimeData, branchDistanceConfig.IndirectJumpBranches)
 
-		// remove runtime bytecode (including metadata here) from init bytecode
-		runtimeBytecodeOffset := bytes.LastIndex(initBytecode, runtimeBytecode)
-		if runtimeBytecodeOffset != -1 {
-			initBytecode = initBytecode[:runtimeBytecodeOffset]
+		// Compute the static distances to any targets within the runtime bytecode
+		if pcs, ok := targetPcs[contract.Name()]; ok {
+			if targetDistanceMap := BuildTargetDistanceMap(runtimeBytecode, pcs); targetDistanceMap != nil {
+				targetDistanceMaps[runtimeBytecodeHash] = targetDistanceMap
+			}
 		}
-		// remove metadata from runtime bytecode
-		runtimeBytecode = compilationTypes.RemoveContractMetadata(runtimeBytecode)
-
-		branchMaps[initBytecodeHash] = GetBranchMapFromBytecode(initBytecode)
-		branchMaps[runtimeBytecodeHash] = GetBranchMapFromBytecode(runtimeBytecode)
 	}
 
 	tracer := &BranchDistanceTracer{
 		branchDistanceMaps: NewBranchDistanceMaps(),
 		callFrameStates:    make([]*branchDistanceTracerCallFrameState, 0),
+		resultPool:         fitnessmetrics.NewResultPool(NewBranchDistanceMaps),
 		branchMaps:         branchMaps,
+		config:             branchDistanceConfig,
+		targetDistanceMaps: targetDistanceMaps,
+
+		backPropagationFailures: new(atomic.Uint64),
 	}
 
 	nativeTracer := &tracers.Tracer{
@@ -157,6 +210,79 @@ func NewBranchDistanceTracer(contracts fuzzerTypes.Contracts) *BranchDistanceTra
 	return tracer
 }
 
+// getContractBranchBytecode returns the lookup hashes of the provided contract's init and runtime bytecode, along with
+// the bytecode branches are identified in: the init bytecode without the runtime bytecode it embeds, and the runtime
+// code without its metadata, followed by the data sections which Vyper appends to it, if any.
+func getContractBranchBytecode(contract *fuzzerTypes.Contract) (common.Hash, []byte, common.Hash, []byte, []byte) {
+	compiledContract := contract.CompiledContract()
+
+	initBytecode := compiledContract.InitBytecode
+	initBytecodeHash := getContractBranchDistanceMapHash(initBytecode, true)
+
+	runtimeBytecode := compiledContract.RuntimeBytecode
+	runtimeBytecodeHash := getContractBranchDistanceMapHash(runtimeBytecode, false)
+
+	// remove runtime bytecode (including metadata here) from init bytecode
+	runtimeBytecodeOffset := bytes.LastIndex(initBytecode, runtimeBytecode)
+	if runtimeBytecodeOffset != -1 {
+		initBytecode = initBytecode[:runtimeBytecodeOffset]
+	}
+	// remove metadata and data sections from runtime bytecode
+	runtimeCode, runtimeData := compilationTypes.SplitRuntimeBytecode(compiledContract.InitBytecode, runtimeBytecode)
+
+	return initBytecodeHash, initBytecode, runtimeBytecodeHash, runtimeCode, runtimeData
+}
+
+// NewDynamicBranchMapRegistry returns a registry which lazily builds the branch maps of runtime bytecode unknown to
+// the fuzzer the first time it executes, up to the provided maximum amount of branch maps. Branch maps are never built
+// for the provided excluded contracts.
+func NewDynamicBranchMapRegistry(excludedContracts fuzzerTypes.Contracts, maxSize int, indirectJumpBranches bool) *fitnessmetrics.CodeMapRegistry[*BranchMap] {
+	registry := fitnessmetrics.NewCodeMapRegistry(maxSize, func(code []byte) (*BranchMap, bool) {
+		branchMap := GetBranchMapFromBytecode(compilationTypes.RemoveContractMetadata(code), indirectJumpBranches)
+		return branchMap, branchMap != nil
+	})
+	for _, contract := range excludedContracts {
+		initBytecodeHash, _, runtimeBytecodeHash, _, _ := getContractBranchBytecode(contract)
+		registry.Exclude(initBytecodeHash)
+		registry.Exclude(runtimeBytecodeHash)
+	}
+	return registry
+}
+
+// ContractNamesByLookupHash returns the name of each provided contract, keyed by the lookup hashes of its init and
+// runtime bytecode used to identify code in BranchDistanceMaps (e.g. RevertSite.CodeHash).
+func ContractNamesByLookupHash(contracts fuzzerTypes.Contracts) map[common.Hash]string {
+	contractNames := make(map[common.Hash]string)
+	for _, contract := range contracts {
+		compiledContract := contract.CompiledContract()
+		contractNames[getContractBranchDistanceMapHash(compiledContract.InitBytecode, true)] = contract.Name()
+		contractNames[getContractBranchDistanceMapHash(compiledContract.RuntimeBytecode, false)] = contract.Name()
+	}
+	return contractNames
+}
+
+// SetExclusions sets the exclusions value (see above).
+func (t *BranchDistanceTracer) SetExclusions(exclusions *fitnessmetrics.MetricExclusions) {
+	t.exclusions = exclusions
+}
+
+// SetDynamicBranchMaps sets the dynamicBranchMaps value (see above).
+func (t *BranchDistanceTracer) SetDynamicBranchMaps(dynamicBranchMaps *fitnessmetrics.CodeMapRegistry[*BranchMap]) {
+	t.dynamicBranchMaps = dynamicBranchMaps
+}
+
+// SetBackPropagationFailures sets the counter of failed back-propagations (see above), so that it can be shared by the
+// tracers of a fuzzing campaign.
+func (t *BranchDistanceTracer) SetBackPropagationFailures(backPropagationFailures *atomic.Uint64) {
+	t.backPropagationFailures = backPropagationFailures
+}
+
+// BackPropagationFailures returns the amount of times back-propagation failed to find a branch distance, for which
+// UnknownDistance was recorded instead.
+func (t *BranchDistanceTracer) BackPropagationFailures() uint64 {
+	return t.backPropagationFailures.Load()
+}
+
 // NativeTracer returns the underlying TestChainTracer.
 func (t *BranchDistanceTracer) NativeTracer() *chain.TestChainTracer {
 	return t.nativeTracer
@@ -166,8 +292,8 @@ func (t *BranchDistanceTracer) NativeTracer() *chain.TestChainTracer {
 func (t *BranchDistanceTracer) OnTxStart(vm *tracing.VMContext, tx *coretypes.Transaction, from common.Address) {
 	// Reset our call frame states
 	t.callDepth = 0
-	t.branchDistanceMaps = NewBranchDistanceMaps()
-	t.callFrameStates = make([]*branchDistanceTracerCallFrameState, 0)
+	t.branchDistanceMaps = t.resultPool.Get()
+	t.callFrameStates = t.callFrameStates[:0]
 	t.evmContext = vm
 }
 
@@ -184,15 +310,38 @@ func (t *BranchDistanceTracer) OnEnter(depth int, typ byte, from common.Address,
 	// Create our state tracking struct for this frame.
 	t.callFrameStates = append(t.callFrameStates, &branchDistanceTracerCallFrameState{
 		create:                   typ == byte(vm.CREATE) || typ == byte(vm.CREATE2),
-		pendingBranchDistanceMap: NewBranchDistanceMaps(),
+		pendingBranchDistanceMap: t.resultPool.Get(),
+		operations:               t.getOperationRing(),
 	})
 }
 
+// getOperationRing returns an operation buffer for a new call frame, reusing one from an exited call frame if
+// possible. It caches the configured amount of operations preceding a JUMPI along with the JUMPI itself. Without
+// adaptive lookback, no more than the lookback window is ever inspected, so no more is cached.
+func (t *BranchDistanceTracer) getOperationRing() *operationRing {
+	if len(t.operationRingPool) > 0 {
+		operations := t.operationRingPool[len(t.operationRingPool)-1]
+		t.operationRingPool = t.operationRingPool[:len(t.operationRingPool)-1]
+		return operations
+	}
+	capacity := t.config.MaxLookback
+	if t.config.AdaptiveLookback {
+		capacity = t.config.MaxAdaptiveLookback
+	}
+	return newOperationRing(capacity+1, t.config.StackSlots)
+}
+
 // OnExit is called after a call to finalize tracing completes for the top of a call frame, as defined by tracers.Tracer.
 func (t *BranchDistanceTracer) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
 	currentCallFrameState := t.callFrameStates[t.callDepth]
 	currentDistanceMap := currentCallFrameState.pendingBranchDistanceMap
 
+	// Return our operation buffer for reuse by later call frames.
+	currentCallFrameState.operations.reset()
+	t.operationRingPool = append(t.operationRingPool, currentCallFrameState.operations)
+
+	// If this frame reverted, keep its distances apart as reverted distances, as they describe how close it came to
+	// passing the checks which failed.
 	if reverted {
 		currentDistanceMap.RevertAll()
 	}
@@ -217,30 +366,54 @@ func (t *BranchDistanceTracer) OnExit(depth int, output []byte, gasUsed uint64,
 	if distanceUpdateErr != nil {
 		logging.GlobalLogger.Panic("Branch distance tracer failed to update distance map during OnExit", distanceUpdateErr)
 	}
+
+	// The distances of this call frame were copied into the parent's, so its maps and their buffers can be reused.
+	t.resultPool.Put(currentDistanceMap)
 }
 
-func (t *branchDistanceTracerCallFrameState) backPropagationToFindDistance() (*uint256.Int, BranchDistanceStatus, error) {
+// backPropagationToFindDistance walks back from the last cached operation, which must be a JUMPI, to find the
+// operation its condition originates from and the distance to flipping it. At most maxLookback operations preceding the
+// JUMPI are inspected, unless adaptive is set, in which case every cached operation is inspected if needed. Operations
+// are only cached up to the capacity of the operation ring (MaxAdaptiveLookback with adaptive lookback), so the
+// adaptive lookback does not reach further back than that in long call frames.
+func (t *branchDistanceTracerCallFrameState) backPropagationToFindDistance(maxLookback int, adaptive bool) (*uint256.Int, BranchDistanceStatus, error) {
 	// require that the last operation is jumpi
-	lastOperation := t.cachedOperations[len(t.cachedOperations)-1]
+	operationCount := t.operations.len()
+	lastOperation := t.operations.at(operationCount - 1)
 	if vm.OpCode(lastOperation.opcode) != vm.JUMPI {
 		return uint256.NewInt(0), NOTJUMPI, fmt.Errorf("the last opeartion is not JUMPI when performing backPropagationToFindDistance")
 	}
-	// fmt.Printf("------------------------------------\n")
 
-	sourceIndex := len(lastOperation.tmpStack) - 2
+	sourceIndex := lastOperation.stackLen - 2
+	condition := lastOperation.stackAt(sourceIndex)
+	if condition == nil {
+		return uint256.NewInt(0), STACKOUTOFSCOPE, fmt.Errorf("JUMPI condition was not cached (stackLen = %d)", lastOperation.stackLen)
+	}
 
-	baseValue := new(uint256.Int).Set(&lastOperation.tmpStack[sourceIndex])
+	baseValue := new(uint256.Int).Set(condition)
 	bs := NOTFOUND
 	diff := uint256.NewInt(0)
-	for i := len(t.cachedOperations) - 1; i > len(t.cachedOperations)-40 && i >= 0; i-- {
-		o := t.cachedOperations[i]
+	lowerBound := operationCount - 2 - maxLookback
+	if adaptive {
+		lowerBound = -1
+	}
+	for i := operationCount - 1; i > lowerBound && i >= 0; i-- {
+		o := t.operations.at(i)
 		op := vm.OpCode(o.opcode)
-		stack := o.tmpStack
-		stackLen := len(stack)
+		stackLen := o.stackLen
+
+		// Obtain the operands of binary operations from the cached top of the stack.
+		var x, y *uint256.Int
+		if stackLen >= 2 {
+			x, y = o.stackAt(stackLen-1), o.stackAt(stackLen-2)
+		}
+		if sourceIndex == stackLen-2 && (x == nil || y == nil) {
+			return diff, STACKOUTOFSCOPE, fmt.Errorf("operands of %v were not cached (stackLen = %d)", op, stackLen)
+		}
+
 		switch {
 		// deal with the case of comparison operation
 		case (op == vm.LT || op == vm.GT || op == vm.EQ) && sourceIndex == stackLen-2:
-			x, y := &stack[stackLen-1], &stack[stackLen-2]
 			if x.Gt(y) { // if x > y
 				diff = diff.Sub(x, y)
 			} else { // if x <= y
@@ -248,7 +421,6 @@ func (t *branchDistanceTracerCallFrameState) backPropagationToFindDistance() (*u
 			}
 			bs = FOUND
 		case (op == vm.SLT || op == vm.SGT) && sourceIndex == stackLen-2:
-			x, y := &stack[stackLen-1], &stack[stackLen-2]
 			if x.Sgt(y) { // if x > y
 				diff = diff.Sub(x, y)
 			} else { // if x <= y
@@ -256,15 +428,23 @@ func (t *branchDistanceTracerCallFrameState) backPropagationToFindDistance() (*u
 			}
 			bs = FOUND
 		case (op == vm.AND) && sourceIndex == stackLen-2:
-			x, y := &stack[stackLen-1], &stack[stackLen-2]
-			if x.Gt(y) {
+			// If either operand is a mask (e.g. truncating an address or uintN), the distance is the field the mask
+			// extracts from the other operand, aligned to the mask so that wider operands don't inflate it. Otherwise,
+			// take the smaller operand.
+			if maskOffset, ok := getAndMaskOffset(x, y); ok {
+				diff = new(uint256.Int).And(x, y)
+				diff.Rsh(diff, maskOffset)
+			} else if x.Gt(y) {
 				diff = new(uint256.Int).Set(y)
 			} else {
 				diff = new(uint256.Int).Set(x)
 			}
 			bs = FOUND
+		// deal with byte extraction, the distance is the value extracted from the second operand
+		case (op == vm.SHR || op == vm.SHL || op == vm.SAR || op == vm.BYTE) && sourceIndex == stackLen-2:
+			diff = getExtractedField(op, x, y)
+			bs = FOUND
 		case (op == vm.OR) && sourceIndex == stackLen-2:
-			x, y := &stack[stackLen-1], &stack[stackLen-2]
 			if x.Gt(y) {
 				diff = new(uint256.Int).Set(x)
 			} else {
@@ -303,32 +483,33 @@ func (t *branchDistanceTracerCallFrameState) backPropagationToFindDistance() (*u
 			bs = FOUND
 		// deal with call
 		case (op == vm.CALL) && sourceIndex == stackLen-7:
-			diff = new(uint256.Int).Set(&lastOperation.tmpStack[len(lastOperation.tmpStack)-2])
+			diff = new(uint256.Int).Set(condition)
 			bs = ENDWITHCALL
 		case (op == vm.STATICCALL) && sourceIndex == stackLen-6:
-			diff = new(uint256.Int).Set(&lastOperation.tmpStack[len(lastOperation.tmpStack)-2])
+			diff = new(uint256.Int).Set(condition)
 			bs = ENDWITHCALL
 		case (op == vm.DELEGATECALL) && sourceIndex == stackLen-6:
-			diff = new(uint256.Int).Set(&lastOperation.tmpStack[len(lastOperation.tmpStack)-2])
+			diff = new(uint256.Int).Set(condition)
 			bs = ENDWITHCALL
 		case (op == vm.CALLVALUE) && sourceIndex == stackLen:
-			diff = new(uint256.Int).Set(&t.cachedOperations[i+1].tmpStack[sourceIndex])
+			callValue := t.operations.at(i + 1).stackAt(sourceIndex)
+			if callValue == nil {
+				return diff, STACKOUTOFSCOPE, fmt.Errorf("call value was not cached (sourceIndex = %d)", sourceIndex)
+			}
+			diff = new(uint256.Int).Set(callValue)
 			bs = FOUND
 		}
 		if sourceIndex > stackLen {
 			return diff, STACKOUTOFSCOPE, fmt.Errorf("sourceIndex (%d) out of scope (stackLen = %d)", sourceIndex, stackLen)
 		}
-		// fmt.Printf("opcode: %v, sourceIndex: %v, stackLen:%v, stacks:[ ", op.String(), sourceIndex, stackLen)
-		// for j := len(o.tmpStack) - 1; j > len(o.tmpStack)-10 && j >= 0; j-- {
-		// 	if j == sourceIndex {
-		// 		red := "\033[31m"
-		// 		reset := "\033[0m"
-		// 		fmt.Printf(red+"%s,"+reset, o.tmpStack[j].String())
-		// 	} else {
-		// 		fmt.Printf("%s,", o.tmpStack[j].String())
-		// 	}
-		// }
-		// fmt.Printf("]\n")
+		if diagnostics.Enabled(3) {
+			diagnostics.Log(3, "Back-propagated the branch condition through an operation", logging.StructuredLogInfo{
+				"opcode":      op.String(),
+				"sourceIndex": sourceIndex,
+				"stackLen":    stackLen,
+				"source":      o.stackAt(sourceIndex),
+			})
+		}
 
 		if IsFoundDistance(bs) {
 			return diff, bs, nil
@@ -337,75 +518,224 @@ func (t *branchDistanceTracerCallFrameState) backPropagationToFindDistance() (*u
 	return diff, NOTFOUND, nil
 }
 
+// getContiguousMaskOffset returns the offset of the lowest set bit of the provided value, and whether it is a mask as
+// Solidity uses to truncate addresses, uintN and bytesN values: a contiguous run of set bits spanning whole bytes.
+// Values such as 1 or 7 are not masks, as they are rather booleans or small integers.
+func getContiguousMaskOffset(mask *uint256.Int) (uint, bool) {
+	if mask.IsZero() {
+		return 0, false
+	}
+	offset := uint(0)
+	for _, word := range mask {
+		if word != 0 {
+			offset += uint(bits.TrailingZeros64(word))
+			break
+		}
+		offset += 64
+	}
+
+	// The set bits are contiguous if the shifted mask is one less than a power of two.
+	shiftedMask := new(uint256.Int).Rsh(mask, offset)
+	if !new(uint256.Int).And(shiftedMask, new(uint256.Int).AddUint64(shiftedMask, 1)).IsZero() {
+		return offset, false
+	}
+	return offset, offset%8 == 0 && shiftedMask.BitLen()%8 == 0
+}
+
+// getAndMaskOffset determines which operand of an AND is a mask, preferring the wider one if both are. Returns the
+// offset of the mask's lowest set bit, or false if neither operand is a mask.
+func getAndMaskOffset(x *uint256.Int, y *uint256.Int) (uint, bool) {
+	xOffset, xIsMask := getContiguousMaskOffset(x)
+	yOffset, yIsMask := getContiguousMaskOffset(y)
+	switch {
+	case xIsMask && (!yIsMask || x.BitLen() >= y.BitLen()):
+		return xOffset, true
+	case yIsMask:
+		return yOffset, true
+	default:
+		return 0, false
+	}
+}
+
+// getExtractedField returns the result of the provided SHR, SHL, SAR or BYTE operation, which extracts a field from
+// the value operand at the position provided by the other operand.
+func getExtractedField(op vm.OpCode, position *uint256.Int, value *uint256.Int) *uint256.Int {
+	if op == vm.BYTE {
+		return new(uint256.Int).Set(value).Byte(position)
+	}
+
+	// Shifts of 256 bits or more shift out the whole value.
+	shift := uint(256)
+	if position.LtUint64(256) {
+		shift = uint(position.Uint64())
+	}
+	switch op {
+	case vm.SHL:
+		return new(uint256.Int).Lsh(value, shift)
+	case vm.SAR:
+		return new(uint256.Int).SRsh(value, shift)
+	default:
+		return new(uint256.Int).Rsh(value, shift)
+	}
+}
+
+// findDistance back-propagates from the JUMPI which was just cached to find the distance to flip its condition, adding
+// the K distance. If back-propagation fails, the failure is counted with the provided counter and UnknownDistance is returned so a single odd
+// bytecode pattern does not halt the campaign.
+//
+// If no comparison the condition originates from is found, the branch is flat: its distance carries no gradient. If
+// the last comparison fallback is enabled, the distance between the operands of the last comparison executed in the
+// frame is used instead, if any.
+// Returns the distance, and whether the branch is flat.
+func (t *branchDistanceTracerCallFrameState) findDistance(branchDistanceConfig config.BranchDistanceConfig, backPropagationFailures *atomic.Uint64) (*uint256.Int, bool) {
+	distance, status, err := t.backPropagationToFindDistance(branchDistanceConfig.MaxLookback, branchDistanceConfig.AdaptiveLookback)
+	if err != nil {
+		backPropagationFailures.Add(1)
+		diagnostics.Count(branchDistanceStatusToStr[status])
+		if diagnostics.Enabled(1) {
+			diagnostics.Log(1, "Failed to back-propagate a branch condition", logging.StructuredLogInfo{
+				"status": branchDistanceStatusToStr[status],
+				"error":  err.Error(),
+			})
+		}
+		return new(uint256.Int).Set(UnknownDistance), false
+	}
+	if status == NOTFOUND {
+		if !branchDistanceConfig.UseLastComparisonFallback || !t.hasLastComparison {
+			return new(uint256.Int).Add(distance, DD), true
+		}
+		distance = &t.lastComparisonDistance
+	}
+	// add K distance
+	return new(uint256.Int).Add(distance, DD), false
+}
+
+// recordComparison records the distance between the operands of a comparison about to be executed, as the last
+// comparison executed in this frame.
+func (t *branchDistanceTracerCallFrameState) recordComparison(op vm.OpCode, x *uint256.Int, y *uint256.Int) {
+	greater := x.Gt(y)
+	if op == vm.SLT || op == vm.SGT {
+		greater = x.Sgt(y)
+	}
+	if greater {
+		t.lastComparisonDistance.Sub(x, y)
+	} else {
+		t.lastComparisonDistance.Sub(y, x)
+	}
+	t.hasLastComparison = true
+}
+
 // OnOpcode records data from an EVM state update, as defined by tracers.Tracer.
 func (t *BranchDistanceTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
 	// Obtain our call frame state tracking struct
 	callFrameState := t.callFrameStates[t.callDepth]
 
+	// We can cast OpContext to ScopeContext because that is the type passed to OnOpcode.
+	scopeContext := scope.(*vm.ScopeContext)
+
+	// Upon the first operation in this frame, determine whether its code is traced, so that we only cache operations
+	// for back-propagation on code we record distances for.
 	if !callFrameState.initialized {
 		callFrameState.initialized = true
 		callFrameState.address = scope.Address()
+		if len(scopeContext.Contract.Code) > 0 {
+			lookupHash := getContractBranchDistanceMapHash(scopeContext.Contract.Code, callFrameState.create)
+			callFrameState.lookupHash = &lookupHash
+			callFrameState.branchMap = t.branchMaps[lookupHash]
+			if callFrameState.branchMap == nil && !callFrameState.create {
+				callFrameState.branchMap, _ = t.dynamicBranchMaps.Get(lookupHash, scopeContext.Contract.Code)
+			}
+			callFrameState.traced = callFrameState.branchMap != nil && !t.exclusions.Excludes(callFrameState.address, scopeContext.Contract.CodeHash)
+			if callFrameState.branchMap == nil {
+				diagnostics.Count("UNKNOWNCODEHASH")
+				if diagnostics.Enabled(1) {
+					diagnostics.Log(1, "No branch map is known for the executed code, its branch distances are not recorded", logging.StructuredLogInfo{
+						"address":    callFrameState.address.Hex(),
+						"lookupHash": lookupHash.Hex(),
+						"create":     callFrameState.create,
+					})
+				}
+			}
+		}
 	}
 
-	// We can cast OpContext to ScopeContext because that is the type passed to OnOpcode.
-	scopeContext := scope.(*vm.ScopeContext)
+	// If there is traced code we're executing and opcode is JUMPI, collect coverage.
+	if callFrameState.traced {
+		callFrameState.operations.push(vm.OpCode(op), scopeContext.Stack.Data())
 
-	// If there is code we're executing and opcode is JUMPI, collect coverage.
-	if len(scopeContext.Contract.Code) > 0 {
-		tmpOperation := Operation{
-			opcode:   vm.OpCode(op),
-			tmpStack: make([]uint256.Int, len(scopeContext.Stack.Data())),
+		// Remember the last comparison, so branches whose condition can't be traced back can fall back to it.
+		switch vm.OpCode(op) {
+		case vm.LT, vm.GT, vm.SLT, vm.SGT, vm.EQ:
+			callFrameState.recordComparison(vm.OpCode(op), scopeContext.Stack.Back(0), scopeContext.Stack.Back(1))
 		}
-		copy(tmpOperation.tmpStack, scopeContext.Stack.Data())
-		callFrameState.cachedOperations = append(callFrameState.cachedOperations, tmpOperation)
 
-		if vm.OpCode(op) == vm.JUMPI {
-			// Obtain our contract coverage map lookup hash.
-			if callFrameState.lookupHash == nil {
-				lookupHash := getContractBranchDistanceMapHash(scopeContext.Contract.Code, callFrameState.create)
-				callFrameState.lookupHash = &lookupHash
-			}
+		// If we are reverting after a traced branch, record how close its guard was to being satisfied.
+		if vm.OpCode(op) == vm.REVERT && callFrameState.lastBranchDistance != nil {
+			callFrameState.pendingBranchDistanceMap.SetRevertSiteDistance(RevertSite{
+				CodeHash: *callFrameState.lookupHash,
+				RevertPc: pc,
+				BranchPc: callFrameState.lastBranchPc,
+			}, callFrameState.lastBranchDistance)
+		}
 
+		if vm.OpCode(op) == vm.JUMPI {
 			// Obtain branch id using condition from stack.
 			cond := scopeContext.Stack.Back(1)
-			branchMap, exists := t.branchMaps[*callFrameState.lookupHash]
-			if !exists {
-				// This contract is not in our list of contracts to trace.
-				return
-			}
+			branchMap := callFrameState.branchMap
 			branchSize := branchMap.Size()
 
 			var distanceToCondIsZero *uint256.Int
 			var distanceToCondIsNotZero *uint256.Int
-			var vmErr error
+			var flat bool
 
 			if !cond.IsZero() { // cond != 0, jump to pos - 1, distanceCondIsZero = 0, distanceCondIsNotZero = DD
 				if cond.Gt(uint256.NewInt(1)) {
-					distanceToCondIsZero = new(uint256.Int).Set(cond)
+					// add K distance
+					distanceToCondIsZero = new(uint256.Int).Add(cond, DD)
 				} else {
-					// var branchDistanceStatus BranchDistanceStatus
-					distanceToCondIsZero, _, vmErr = callFrameState.backPropagationToFindDistance()
-					if vmErr != nil {
-						panic(fmt.Sprintf("error in backPropagationToFindDistance %v", vmErr))
-					}
+					distanceToCondIsZero, flat = callFrameState.findDistance(t.config, t.backPropagationFailures)
 				}
-				// add K distance
-				distanceToCondIsZero = new(uint256.Int).Add(distanceToCondIsZero, DD)
 				// deal with the distance of another branch
 				distanceToCondIsNotZero = uint256.NewInt(0)
 			} else { // cond == 0, not jumping, distanceCondIsZero = 0, distanceCondIsNotZero = DD
 				// deal with the distance of another branch
 				distanceToCondIsZero = uint256.NewInt(0)
 
-				distanceToCondIsNotZero, _, vmErr = callFrameState.backPropagationToFindDistance()
-				if vmErr != nil {
-					panic(fmt.Sprintf("error in backPropagationToFindDistance %v", vmErr))
-				}
-				// add K distance
-				distanceToCondIsNotZero = new(uint256.Int).Add(distanceToCondIsNotZero, DD)
+				distanceToCondIsNotZero, flat = callFrameState.findDistance(t.config, t.backPropagationFailures)
+			}
+			if diagnostics.Enabled(2) {
+				diagnostics.Log(2, "Recorded the distances of a branch", logging.StructuredLogInfo{
+					"pc":                      pc,
+					"condition":               cond.Dec(),
+					"distanceToCondIsZero":    distanceToCondIsZero.Dec(),
+					"distanceToCondIsNotZero": distanceToCondIsNotZero.Dec(),
+					"flat":                    flat,
+				})
+			}
+
+			// Annotate branches whose distance carries no gradient, so they can be reported.
+			if flat {
+				callFrameState.pendingBranchDistanceMap.SetFlatBranch(BranchSite{CodeHash: *callFrameState.lookupHash, Pc: pc})
+			}
+
+			// Remember the distance to flipping this branch, so that a REVERT it guards can be attributed to it.
+			callFrameState.lastBranchPc = pc
+			if !cond.IsZero() {
+				callFrameState.lastBranchDistance = distanceToCondIsZero
+			} else {
+				callFrameState.lastBranchDistance = distanceToCondIsNotZero
+			}
+
+			// In the target-directed mode, record how close this branch brings us to a target: either by the branch
+			// taken, or by the distance to flipping it towards the other one.
+			if targetDistanceMap, ok := t.targetDistanceMaps[*callFrameState.lookupHash]; ok {
+				jumped := !cond.IsZero()
+				targetDistance := min(
+					combinedTargetDistance(targetDistanceMap.BranchDistance(pc, jumped), uint256.NewInt(0)),
+					combinedTargetDistance(targetDistanceMap.BranchDistance(pc, !jumped), callFrameState.lastBranchDistance),
+				)
+				callFrameState.pendingBranchDistanceMap.SetTargetDistance(targetDistance)
 			}
-			// fmt.Printf("JUMPI, COND: %s, DistanceToCondIsZero: %s, DistanceToCondIsNotZero: %s .\n", cond.String(), distanceToCondIsZero.String(), distanceToCondIsNotZero.String())
-			// fmt.Println("------------------")
 
 			// Record branch coverage for this path of this instruction location in our map.
 			_, coverageUpdateErr := callFrameState.pendingBranchDistanceMap.SetAt(scopeContext.Contract.Address(), *callFrameState.lookupHash, branchSize, branchMap.GetBranchId(pc, false), distanceToCondIsZero)
@@ -417,6 +747,34 @@ func (t *BranchDistanceTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, sc
 				logging.GlobalLogger.Panic("Coverage tracer failed to update coverage map while tracing state", coverageUpdateErr)
 			}
 		}
+
+		// If this is an indirect JUMP, record the distance to each of its candidate destinations: zero for the one
+		// taken, otherwise the difference between the destination value and the candidate.
+		if vm.OpCode(op) == vm.JUMP {
+			branchMap := callFrameState.branchMap
+			if jumpBranchIds := branchMap.GetJumpBranchIds(pc); jumpBranchIds != nil {
+				branchSize := branchMap.Size()
+				dest := scopeContext.Stack.Back(0)
+				for candidateDest, branchId := range jumpBranchIds {
+					distance := new(uint256.Int).SetUint64(candidateDest)
+					if distance.Eq(dest) {
+						distance.Clear()
+					} else {
+						if distance.Lt(dest) {
+							distance.Sub(dest, distance)
+						} else {
+							distance.Sub(distance, dest)
+						}
+						// add K distance
+						distance.Add(distance, DD)
+					}
+					_, coverageUpdateErr := callFrameState.pendingBranchDistanceMap.SetAt(scopeContext.Contract.Address(), *callFrameState.lookupHash, branchSize, branchId, distance)
+					if coverageUpdateErr != nil {
+						logging.GlobalLogger.Panic("Coverage tracer failed to update coverage map while tracing state", coverageUpdateErr)
+					}
+				}
+			}
+		}
 	}
 }
 
@@ -426,4 +784,33 @@ func (t *BranchDistanceTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, sc
 func (t *BranchDistanceTracer) CaptureTxEndSetAdditionalResults(results *types.MessageResults) {
 	// Store our tracer results.
 	results.AdditionalResults[branchDistanceTracerResultsKey] = t.branchDistanceMaps
+	t.lastResults = results
+}
+
+// MergeResults merges the distance maps of the latest call traced into the provided ones, then releases them for
+// reuse. This attributes the distances of message calls made outside of transactions (e.g. to view functions), whose
+// results are not stored, to a transaction. Distance maps stored in message results are left untouched.
+// Returns an error if one occurred.
+func (t *BranchDistanceTracer) MergeResults(branchDistanceMaps *BranchDistanceMaps) error {
+	if t.branchDistanceMaps == nil || t.lastResults != nil {
+		return nil
+	}
+	_, err := branchDistanceMaps.Update(t.branchDistanceMaps)
+	t.resultPool.Put(t.branchDistanceMaps)
+	t.branchDistanceMaps = nil
+	return err
+}
+
+// ReleaseResults releases the distance maps of the latest transaction for reuse, if they were stored in the provided
+// message results, as defined by fitnessmetrics.ResultReleaser. They are removed from the message results.
+func (t *BranchDistanceTracer) ReleaseResults(results *types.MessageResults) {
+	if t == nil || t.branchDistanceMaps == nil || results != t.lastResults {
+		return
+	}
+	if GetBranchDistanceTracerResults(results) == t.branchDistanceMaps {
+		RemoveBranchDistanceTracerResults(results)
+	}
+	t.resultPool.Put(t.branchDistanceMaps)
+	t.branchDistanceMaps = nil
+	t.lastResults = nil
 }
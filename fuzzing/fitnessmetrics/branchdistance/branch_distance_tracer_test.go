@@ -0,0 +1,186 @@
+package branchdistance
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+// backPropagateStacks caches the provided operations, each with the stack prior to its execution (top of the stack
+// last), and back-propagates from the last one, which must be a JUMPI.
+func backPropagateStacks(t *testing.T, opcodes []vm.OpCode, stacks [][]uint64) (*uint256.Int, BranchDistanceStatus) {
+	callFrameState := &branchDistanceTracerCallFrameState{operations: newOperationRing(len(opcodes), 32)}
+	for i, opcode := range opcodes {
+		stack := make([]uint256.Int, len(stacks[i]))
+		for j, value := range stacks[i] {
+			stack[j].SetUint64(value)
+		}
+		callFrameState.operations.push(opcode, stack)
+	}
+	distance, status, err := callFrameState.backPropagationToFindDistance(len(opcodes)+1, false)
+	assert.NoError(t, err)
+	return distance, status
+}
+
+// TestBackPropagationMaskAndShift tests that conditions extracted with masks and shifts yield the distance of the
+// extracted value rather than of the mask constant.
+func TestBackPropagationMaskAndShift(t *testing.T) {
+	// A mask of the second byte of a value.
+	distance, status := backPropagateStacks(t,
+		[]vm.OpCode{vm.AND, vm.JUMPI},
+		[][]uint64{{0x1234, 0xff00}, {0x1200, 0x40}},
+	)
+	assert.Equal(t, FOUND, status)
+	assert.EqualValues(t, 0x12, distance.Uint64())
+
+	// A conjunction of two booleans, one of which is false.
+	distance, status = backPropagateStacks(t,
+		[]vm.OpCode{vm.AND, vm.JUMPI},
+		[][]uint64{{0, 1}, {0, 0x40}},
+	)
+	assert.Equal(t, FOUND, status)
+	assert.EqualValues(t, 0, distance.Uint64())
+
+	// An AND of two values which are not masks takes the smaller one.
+	distance, status = backPropagateStacks(t,
+		[]vm.OpCode{vm.AND, vm.JUMPI},
+		[][]uint64{{0x1234, 0x0505}, {0x0404, 0x40}},
+	)
+	assert.Equal(t, FOUND, status)
+	assert.EqualValues(t, 0x0505, distance.Uint64())
+
+	// Contiguous operands which don't span whole bytes are not masks, so the smaller operand is taken.
+	distance, status = backPropagateStacks(t,
+		[]vm.OpCode{vm.AND, vm.JUMPI},
+		[][]uint64{{0x1234, 0x7}, {0x4, 0x40}},
+	)
+	assert.Equal(t, FOUND, status)
+	assert.EqualValues(t, 0x7, distance.Uint64())
+
+	// The field a mask extracts is computed from its operands, even if the condition was negated since.
+	distance, status = backPropagateStacks(t,
+		[]vm.OpCode{vm.AND, vm.NOT, vm.JUMPI},
+		[][]uint64{{0x1234, 0xff00}, {0x1200}, {0, 0x40}},
+	)
+	assert.Equal(t, FOUND, status)
+	assert.EqualValues(t, 0x12, distance.Uint64())
+
+	// A right shift extracting the upper byte of a value.
+	distance, status = backPropagateStacks(t,
+		[]vm.OpCode{vm.SHR, vm.JUMPI},
+		[][]uint64{{0x1234, 8}, {0x12, 0x40}},
+	)
+	assert.Equal(t, FOUND, status)
+	assert.EqualValues(t, 0x12, distance.Uint64())
+
+	// A left shift and a byte extraction of a value, whose condition was negated since.
+	distance, status = backPropagateStacks(t,
+		[]vm.OpCode{vm.SHL, vm.NOT, vm.JUMPI},
+		[][]uint64{{0x12, 8}, {0x1200}, {0, 0x40}},
+	)
+	assert.Equal(t, FOUND, status)
+	assert.EqualValues(t, 0x1200, distance.Uint64())
+	distance, status = backPropagateStacks(t,
+		[]vm.OpCode{vm.BYTE, vm.NOT, vm.JUMPI},
+		[][]uint64{{0x1234, 30}, {0x12}, {0, 0x40}},
+	)
+	assert.Equal(t, FOUND, status)
+	assert.EqualValues(t, 0x12, distance.Uint64())
+
+	// A shift by 256 bits or more shifts out the whole value.
+	distance, status = backPropagateStacks(t,
+		[]vm.OpCode{vm.SHR, vm.JUMPI},
+		[][]uint64{{0x1234, 256}, {0, 0x40}},
+	)
+	assert.Equal(t, FOUND, status)
+	assert.Zero(t, distance.Uint64())
+}
+
+// TestFindDistanceLastComparisonFallback tests that a branch whose condition can't be traced back to a comparison is
+// flat, unless the last comparison fallback is enabled and a comparison was executed in the frame.
+func TestFindDistanceLastComparisonFallback(t *testing.T) {
+	callFrameState := &branchDistanceTracerCallFrameState{operations: newOperationRing(8, 32)}
+	stack := []uint256.Int{*uint256.NewInt(1), *uint256.NewInt(0x40)}
+	callFrameState.operations.push(vm.JUMPI, stack)
+
+	var failures atomic.Uint64
+	branchDistanceConfig := config.BranchDistanceConfig{MaxLookback: 8}
+	distance, flat := callFrameState.findDistance(branchDistanceConfig, &failures)
+	assert.True(t, flat)
+	assert.EqualValues(t, 1, distance.Uint64())
+
+	// Without a comparison in the frame, the fallback has nothing to fall back to.
+	branchDistanceConfig.UseLastComparisonFallback = true
+	_, flat = callFrameState.findDistance(branchDistanceConfig, &failures)
+	assert.True(t, flat)
+
+	callFrameState.recordComparison(vm.LT, uint256.NewInt(3), uint256.NewInt(10))
+	distance, flat = callFrameState.findDistance(branchDistanceConfig, &failures)
+	assert.False(t, flat)
+	assert.EqualValues(t, 8, distance.Uint64())
+	assert.Zero(t, failures.Load())
+}
+
+// TestFindDistanceFailures tests that failed back-propagations record an unknown distance and are counted by the
+// counter provided, so tracers of distinct campaigns count their own failures.
+func TestFindDistanceFailures(t *testing.T) {
+	// The condition of a JUMPI whose stack was not cached can't be back-propagated.
+	callFrameState := &branchDistanceTracerCallFrameState{operations: newOperationRing(8, 32)}
+	callFrameState.operations.push(vm.JUMPI, nil)
+
+	var failures atomic.Uint64
+	branchDistanceConfig := config.BranchDistanceConfig{MaxLookback: 8}
+	for i := 0; i < 3; i++ {
+		distance, flat := callFrameState.findDistance(branchDistanceConfig, &failures)
+		assert.False(t, flat)
+		assert.EqualValues(t, UnknownDistance, distance)
+	}
+	assert.EqualValues(t, 3, failures.Load())
+
+	// A tracer counts its own failures, unless it is provided a counter shared with other tracers.
+	tracer := NewBranchDistanceTracer(nil, branchDistanceConfig, nil)
+	assert.Zero(t, tracer.BackPropagationFailures())
+	tracer.SetBackPropagationFailures(&failures)
+	assert.EqualValues(t, 3, tracer.BackPropagationFailures())
+}
+
+// TestBackPropagationLookbackBoundary tests that a comparison is found exactly as far back as the lookback window
+// reaches, both with a fixed lookback window and with the adaptive lookback, which is capped by the amount of
+// operations retained per call frame.
+func TestBackPropagationLookbackBoundary(t *testing.T) {
+	// findDistance caches a comparison followed by the provided amount of operations which do not touch the condition,
+	// then back-propagates from the JUMPI using the condition, using the operation ring of a tracer with the provided
+	// configuration.
+	findDistance := func(branchDistanceConfig config.BranchDistanceConfig, fillers int) (*uint256.Int, BranchDistanceStatus) {
+		tracer := &BranchDistanceTracer{config: branchDistanceConfig}
+		callFrameState := &branchDistanceTracerCallFrameState{operations: tracer.getOperationRing()}
+		callFrameState.operations.push(vm.LT, []uint256.Int{*uint256.NewInt(10), *uint256.NewInt(3)})
+		for i := 0; i < fillers; i++ {
+			callFrameState.operations.push(vm.JUMPDEST, []uint256.Int{*uint256.NewInt(1)})
+		}
+		callFrameState.operations.push(vm.JUMPI, []uint256.Int{*uint256.NewInt(1), *uint256.NewInt(0x40)})
+		distance, status, err := callFrameState.backPropagationToFindDistance(branchDistanceConfig.MaxLookback, branchDistanceConfig.AdaptiveLookback)
+		assert.NoError(t, err)
+		return distance, status
+	}
+
+	// The comparison is found if it is the furthest operation preceding the JUMPI within the window.
+	branchDistanceConfig := config.BranchDistanceConfig{MaxLookback: 8, MaxAdaptiveLookback: 16, StackSlots: 2}
+	distance, status := findDistance(branchDistanceConfig, 7)
+	assert.Equal(t, FOUND, status)
+	assert.EqualValues(t, 7, distance.Uint64())
+	_, status = findDistance(branchDistanceConfig, 8)
+	assert.Equal(t, NOTFOUND, status)
+
+	// The adaptive lookback ignores the window, but only reaches as far back as the operations retained.
+	branchDistanceConfig.AdaptiveLookback = true
+	distance, status = findDistance(branchDistanceConfig, 15)
+	assert.Equal(t, FOUND, status)
+	assert.EqualValues(t, 7, distance.Uint64())
+	_, status = findDistance(branchDistanceConfig, 16)
+	assert.Equal(t, NOTFOUND, status)
+}
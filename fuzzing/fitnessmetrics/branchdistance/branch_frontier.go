@@ -0,0 +1,166 @@
+package branchdistance
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/holiman/uint256"
+)
+
+// FrontierArm describes a branch arm which was reached but never taken, along with the closest distance observed to
+// taking it.
+type FrontierArm struct {
+	// Contract is the name of the contract, or the lookup hash of its code if it is unknown.
+	Contract string `json:"contract"`
+	// Address is the address the contract code executed at.
+	Address common.Address `json:"address"`
+	// Branch describes the branch arm, along with its best distance.
+	Branch BranchDistanceDumpEntry `json:"branch"`
+}
+
+// distance returns the best distance of the arm, or nil if it could not be parsed.
+func (a FrontierArm) distance() *uint256.Int {
+	distance, err := uint256.FromDecimal(a.Branch.Distance)
+	if err != nil {
+		return nil
+	}
+	return distance
+}
+
+// CoverageFrontier describes what a fuzzing campaign is stuck on: the branch arms it reached but never took, and those
+// of them which came close to being taken.
+type CoverageFrontier struct {
+	// UncoveredArms describes the branch arms reached but never taken, closest first.
+	UncoveredArms []FrontierArm `json:"uncoveredArms"`
+	// NearlyCoveredArms describes the uncovered arms whose best distance is at most the nearly covered threshold,
+	// closest first.
+	NearlyCoveredArms []FrontierArm `json:"nearlyCoveredArms"`
+}
+
+// Frontier returns the coverage frontier of the dump. Branch arms which were reached but never taken are uncovered,
+// and considered nearly covered if their best distance is at most the provided distance.
+func (d BranchDistanceDump) Frontier(maxNearlyCoveredDistance *uint256.Int) CoverageFrontier {
+	frontier := CoverageFrontier{
+		UncoveredArms:     make([]FrontierArm, 0),
+		NearlyCoveredArms: make([]FrontierArm, 0),
+	}
+	for _, contractDump := range d.Contracts {
+		for _, entry := range contractDump.Branches {
+			arm := FrontierArm{Contract: contractDump.Contract, Address: contractDump.Address, Branch: entry}
+			distance := arm.distance()
+			if distance == nil || distance.IsZero() {
+				continue
+			}
+			frontier.UncoveredArms = append(frontier.UncoveredArms, arm)
+			if maxNearlyCoveredDistance != nil && !distance.Gt(maxNearlyCoveredDistance) {
+				frontier.NearlyCoveredArms = append(frontier.NearlyCoveredArms, arm)
+			}
+		}
+	}
+
+	// Sort the arms closest first, then by location so the frontier is stable.
+	for _, arms := range [][]FrontierArm{frontier.UncoveredArms, frontier.NearlyCoveredArms} {
+		sort.SliceStable(arms, func(i, j int) bool {
+			if c := arms[i].distance().Cmp(arms[j].distance()); c != 0 {
+				return c < 0
+			}
+			if arms[i].Contract != arms[j].Contract {
+				return arms[i].Contract < arms[j].Contract
+			}
+			return arms[i].Branch.BranchId < arms[j].Branch.BranchId
+		})
+	}
+	return frontier
+}
+
+// Frontier returns the coverage frontier of the provided BranchDistanceMaps (see BranchDistanceDump.Frontier).
+func (w *BranchDistanceDumpWriter) Frontier(maps *BranchDistanceMaps, maxNearlyCoveredDistance *uint256.Int) CoverageFrontier {
+	return w.Dump(maps).Frontier(maxNearlyCoveredDistance)
+}
+
+// ReadBranchDistanceDump reads a BranchDistanceDump from the provided path. If the path is a directory, the latest dump
+// written to it by a BranchDistanceDumpWriter is read.
+// Returns the dump, or an error if one occurred.
+func ReadBranchDistanceDump(path string) (BranchDistanceDump, error) {
+	var dump BranchDistanceDump
+
+	// If we were provided a dump directory, resolve the latest dump in it.
+	info, err := os.Stat(path)
+	if err != nil {
+		return dump, fmt.Errorf("failed to read branch distance dump at %v: %v", path, err)
+	}
+	if info.IsDir() {
+		path, err = latestBranchDistanceDumpPath(path)
+		if err != nil {
+			return dump, err
+		}
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return dump, fmt.Errorf("failed to read branch distance dump at %v: %v", path, err)
+	}
+	err = json.Unmarshal(b, &dump)
+	if err != nil {
+		return dump, fmt.Errorf("failed to parse branch distance dump at %v: %v", path, err)
+	}
+	return dump, nil
+}
+
+// latestBranchDistanceDumpPath returns the path of the dump with the most seconds elapsed in the provided directory,
+// using the index of the dumps to order those written within the same second.
+// Returns the path, or an error if the directory contains no dumps.
+func latestBranchDistanceDumpPath(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read branch distance dump directory at %v: %v", dir, err)
+	}
+	latestPath := ""
+	latestElapsedSeconds, latestIndex := uint64(0), uint64(0)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, "branch_distance_") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		elapsedSeconds, index, ok := parseBranchDistanceDumpName(name)
+		if !ok {
+			continue
+		}
+		if latestPath == "" || elapsedSeconds > latestElapsedSeconds || (elapsedSeconds == latestElapsedSeconds && index > latestIndex) {
+			latestPath = filepath.Join(dir, name)
+			latestElapsedSeconds, latestIndex = elapsedSeconds, index
+		}
+	}
+	if latestPath == "" {
+		return "", fmt.Errorf("no branch distance dumps found in %v", dir)
+	}
+	return latestPath, nil
+}
+
+// parseBranchDistanceDumpName parses the seconds elapsed and the index of a dump from its file name. Dumps named after
+// the seconds elapsed alone have an index of zero.
+// Returns the seconds elapsed, the index, and a boolean indicating whether the name could be parsed.
+func parseBranchDistanceDumpName(name string) (uint64, uint64, bool) {
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(name, "branch_distance_"), ".json"), "_")
+	if len(parts) > 2 {
+		return 0, 0, false
+	}
+	elapsedSeconds, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	index := uint64(0)
+	if len(parts) == 2 {
+		index, err = strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			return 0, 0, false
+		}
+	}
+	return elapsedSeconds, index, true
+}
@@ -0,0 +1,66 @@
+package branchdistance
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBranchDistanceDumpFrontier tests that the frontier of a dump lists the untaken branch arms closest first, and
+// only considers those within the provided distance nearly covered.
+func TestBranchDistanceDumpFrontier(t *testing.T) {
+	jumped, fellThrough := true, false
+	dump := BranchDistanceDump{
+		Contracts: []ContractBranchDistanceDump{
+			{
+				Contract: "A",
+				Branches: []BranchDistanceDumpEntry{
+					{BranchId: 0, Pc: 4, Jumped: &fellThrough, Distance: "0"},
+					{BranchId: 1, Pc: 4, Jumped: &jumped, Distance: "5000"},
+					{BranchId: 2, Pc: 9, Jumped: &fellThrough, Distance: "3"},
+					{BranchId: 3, Pc: 9, Jumped: &jumped, Distance: "0"},
+				},
+			},
+			{
+				Contract: "B",
+				Branches: []BranchDistanceDumpEntry{
+					{BranchId: 0, Pc: 4, Jumped: &fellThrough, Distance: "3"},
+					{BranchId: 1, Pc: 4, Jumped: &jumped, Distance: "0"},
+				},
+			},
+		},
+	}
+
+	frontier := dump.Frontier(uint256.NewInt(1000))
+	assert.Len(t, frontier.UncoveredArms, 3)
+	assert.Equal(t, "A", frontier.UncoveredArms[0].Contract)
+	assert.EqualValues(t, 2, frontier.UncoveredArms[0].Branch.BranchId)
+	assert.Equal(t, "B", frontier.UncoveredArms[1].Contract)
+	assert.Equal(t, "5000", frontier.UncoveredArms[2].Branch.Distance)
+	assert.Len(t, frontier.NearlyCoveredArms, 2)
+	for _, arm := range frontier.NearlyCoveredArms {
+		assert.Equal(t, "3", arm.Branch.Distance)
+	}
+}
+
+// TestReadBranchDistanceDumpDirectory tests that reading a dump directory reads the latest dump written to it.
+func TestReadBranchDistanceDumpDirectory(t *testing.T) {
+	dir := t.TempDir()
+	for _, elapsedSeconds := range []uint64{9, 30, 120} {
+		b, err := json.Marshal(BranchDistanceDump{ElapsedSeconds: elapsedSeconds})
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "branch_distance_"+strconv.FormatUint(elapsedSeconds, 10)+".json"), b, 0644))
+	}
+
+	dump, err := ReadBranchDistanceDump(dir)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 120, dump.ElapsedSeconds)
+
+	_, err = ReadBranchDistanceDump(t.TempDir())
+	assert.Error(t, err)
+}
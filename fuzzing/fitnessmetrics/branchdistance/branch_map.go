@@ -1,18 +1,35 @@
 package branchdistance
 
 import (
+	"encoding/binary"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/holiman/uint256"
+	"golang.org/x/exp/maps"
 )
 
+// MaxIndirectJumpBranches describes the maximum amount of branch ids assigned to the indirect JUMPs of some code. As
+// each indirect JUMP is assigned a branch per candidate destination, this grows with the product of both, so indirect
+// JUMPs are not modelled as branches in code where it exceeds this amount.
+const MaxIndirectJumpBranches = 4096
+
 type BranchMap struct {
 	BranchIds map[uint64]int // pc -> false branch id, true branch id = false branch id + 1
+
+	// JumpBranchIds maps the pc of each indirect JUMP (one whose destination is computed rather than pushed right
+	// before it) to the branch id of each of its candidate destinations. Indirect JUMPs are modelled as multi-way
+	// branches, with one branch per candidate destination.
+	JumpBranchIds map[uint64]map[uint64]int
+
+	// size is the total amount of branch ids.
+	size int
 }
 
 func (bm *BranchMap) Size() int {
-	return len(bm.BranchIds) * 2
+	return bm.size
 }
 
 func (bm *BranchMap) GetBranchId(pc uint64, cond bool) int {
@@ -23,17 +40,66 @@ func (bm *BranchMap) GetBranchId(pc uint64, cond bool) int {
 	return branchId
 }
 
-func GetBranchMapFromBytecode(bytecode []byte) *BranchMap {
+// GetJumpBranchIds returns the branch id of each candidate destination of the indirect JUMP at the provided pc, by
+// destination. Returns nil if there is no indirect JUMP at the pc.
+func (bm *BranchMap) GetJumpBranchIds(pc uint64) map[uint64]int {
+	return bm.JumpBranchIds[pc]
+}
+
+// GetBranchMapFromBytecode assigns branch ids to the two paths of each JUMPI in the provided bytecode. If
+// indirectJumps is set, indirect JUMPs (e.g. jump tables used for dispatch) are assigned a branch per candidate
+// destination as well. Candidate destinations are the JUMPDESTs whose pc is pushed somewhere in the bytecode other
+// than directly as the destination of a static jump. Indirect JUMPs are only modelled if this takes at most
+// MaxIndirectJumpBranches branch ids.
+// Returns the branch map, or nil if the bytecode could not be disassembled.
+func GetBranchMapFromBytecode(bytecode []byte, indirectJumps bool) *BranchMap {
+	return GetBranchMapFromCode(bytecode, nil, indirectJumps)
+}
+
+// GetBranchMapFromCode assigns branch ids like GetBranchMapFromBytecode, for the provided code followed by the
+// provided data sections, which are not disassembled. Jump tables stored in the data sections (e.g. by the selector
+// dispatcher of Vyper) hold the destinations of indirect JUMPs as two-byte values, so any two-byte value in them which
+// is the pc of a JUMPDEST is a candidate destination as well.
+// Returns the branch map, or nil if the code could not be disassembled.
+func GetBranchMapFromCode(bytecode []byte, data []byte, indirectJumps bool) *BranchMap {
 	branchIds := make(map[uint64]int)
 	id := 0
 
+	// Record JUMPI branches, along with what we need to determine indirect JUMPs and their candidate destinations.
+	jumpDests := make(map[uint64]struct{})
+	pushedValues := make([]uint64, 0)
+	indirectJumpPcs := make([]uint64, 0)
+	var pendingPushValue *uint64
+	previousWasPush := false
+
 	it := NewInstructionIterator(bytecode)
 
 	for it.Next() {
-		if it.Op() == vm.JUMPI {
+		op := it.Op()
+		if op == vm.JUMPI {
 			branchIds[it.PC()] = id
 			id += 2
 		}
+
+		if indirectJumps {
+			switch {
+			case op == vm.JUMPDEST:
+				jumpDests[it.PC()] = struct{}{}
+			case op == vm.JUMP && !previousWasPush:
+				indirectJumpPcs = append(indirectJumpPcs, it.PC())
+			}
+
+			// A pushed value is a candidate destination unless it is consumed directly by a static jump.
+			if pendingPushValue != nil && op != vm.JUMP && op != vm.JUMPI {
+				pushedValues = append(pushedValues, *pendingPushValue)
+			}
+			pendingPushValue = nil
+			if op.IsPush() && len(it.Arg()) <= 8 {
+				value := new(uint256.Int).SetBytes(it.Arg()).Uint64()
+				pendingPushValue = &value
+			}
+			previousWasPush = op.IsPush()
+		}
 	}
 	if err := it.Error(); err != nil {
 		// Ignore incomplete push instruction errors
@@ -42,8 +108,39 @@ func GetBranchMapFromBytecode(bytecode []byte) *BranchMap {
 		}
 	}
 
+	// Add the values of any jump tables in the data sections to the candidate destinations.
+	if indirectJumps {
+		for i := 0; i+2 <= len(data); i++ {
+			pushedValues = append(pushedValues, uint64(binary.BigEndian.Uint16(data[i:])))
+		}
+	}
+
+	// Assign a branch id to each candidate destination of each indirect JUMP.
+	jumpBranchIds := make(map[uint64]map[uint64]int)
+	if len(indirectJumpPcs) > 0 {
+		candidateDestSet := make(map[uint64]struct{})
+		for _, value := range pushedValues {
+			if _, ok := jumpDests[value]; ok {
+				candidateDestSet[value] = struct{}{}
+			}
+		}
+		candidateDests := maps.Keys(candidateDestSet)
+		slices.Sort(candidateDests)
+		if len(candidateDests) > 0 && len(indirectJumpPcs)*len(candidateDests) <= MaxIndirectJumpBranches {
+			for _, pc := range indirectJumpPcs {
+				jumpBranchIds[pc] = make(map[uint64]int, len(candidateDests))
+				for _, dest := range candidateDests {
+					jumpBranchIds[pc][dest] = id
+					id++
+				}
+			}
+		}
+	}
+
 	return &BranchMap{
-		BranchIds: branchIds,
+		BranchIds:     branchIds,
+		JumpBranchIds: jumpBranchIds,
+		size:          id,
 	}
 }
 
@@ -0,0 +1,103 @@
+package branchdistance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGetBranchMapFromBytecodeIndirectJumps tests that indirect JUMPs are assigned a branch per candidate destination,
+// and that destinations pushed directly for static jumps are not considered candidates.
+func TestGetBranchMapFromBytecodeIndirectJumps(t *testing.T) {
+	bytecode := []byte{
+		0x60, 0x08, // 0: PUSH1 0x08 (address-taken JUMPDEST)
+		0x80,       // 2: DUP1
+		0x56,       // 3: JUMP (indirect)
+		0x60, 0x0a, // 4: PUSH1 0x0a
+		0x56,       // 6: JUMP (static)
+		0x00,       // 7: STOP
+		0x5b,       // 8: JUMPDEST
+		0x00,       // 9: STOP
+		0x5b,       // 10: JUMPDEST
+		0x60, 0x00, // 11: PUSH1 0x00
+		0x60, 0x08, // 13: PUSH1 0x08
+		0x57, // 15: JUMPI
+	}
+
+	// Without indirect jumps, only the JUMPI should be assigned branches.
+	branchMap := GetBranchMapFromBytecode(bytecode, false)
+	assert.Equal(t, 2, branchMap.Size())
+	assert.Equal(t, map[uint64]int{15: 0}, branchMap.BranchIds)
+	assert.Nil(t, branchMap.GetJumpBranchIds(3))
+
+	// With indirect jumps, the indirect JUMP should be assigned a branch for its only candidate destination.
+	branchMap = GetBranchMapFromBytecode(bytecode, true)
+	assert.Equal(t, 3, branchMap.Size())
+	assert.Equal(t, map[uint64]int{15: 0}, branchMap.BranchIds)
+	assert.Equal(t, map[uint64]int{8: 2}, branchMap.GetJumpBranchIds(3))
+	assert.Nil(t, branchMap.GetJumpBranchIds(6))
+}
+
+// TestGetBranchMapFromCodeJumpTables tests that two-byte values in the data sections following the code, such as the
+// jump tables of Vyper's selector dispatcher, are candidate destinations of indirect JUMPs if they are JUMPDESTs.
+func TestGetBranchMapFromCodeJumpTables(t *testing.T) {
+	code := []byte{
+		0x60, 0x08, // 0: PUSH1 0x08 (address-taken JUMPDEST)
+		0x80,       // 2: DUP1
+		0x56,       // 3: JUMP (indirect)
+		0x60, 0x0a, // 4: PUSH1 0x0a
+		0x56, // 6: JUMP (static)
+		0x00, // 7: STOP
+		0x5b, // 8: JUMPDEST
+		0x00, // 9: STOP
+		0x5b, // 10: JUMPDEST
+		0x00, // 11: STOP
+	}
+	data := []byte{0x00, 0x0a, 0x57}
+
+	// The JUMPDEST only referenced by the jump table should be a candidate destination, and the data section should
+	// not be disassembled (its JUMPI byte is not a branch).
+	branchMap := GetBranchMapFromCode(code, data, true)
+	assert.Equal(t, 2, branchMap.Size())
+	assert.Empty(t, branchMap.BranchIds)
+	assert.Equal(t, map[uint64]int{8: 0, 10: 1}, branchMap.GetJumpBranchIds(3))
+}
+
+// getIndirectJumpBytecode returns bytecode with the provided amount of indirect JUMPs, followed by the provided amount of
+// JUMPDESTs whose pc is pushed twice, making them candidate destinations of each indirect JUMP.
+func getIndirectJumpBytecode(jumps int, candidates int) []byte {
+	bytecode := make([]byte, 0)
+	for i := 0; i < jumps; i++ {
+		bytecode = append(bytecode, 0x80, 0x56) // DUP1, JUMP (indirect)
+	}
+	for i := 0; i < candidates; i++ {
+		pc := len(bytecode)
+		bytecode = append(bytecode, 0x5b)                        // JUMPDEST
+		bytecode = append(bytecode, 0x61, byte(pc>>8), byte(pc)) // PUSH2 pc
+		bytecode = append(bytecode, 0x61, byte(pc>>8), byte(pc)) // PUSH2 pc
+		bytecode = append(bytecode, 0x50, 0x50)                  // POP, POP
+	}
+	return bytecode
+}
+
+// TestGetBranchMapFromBytecodeIndirectJumpLimit tests that candidate destinations pushed several times are assigned a
+// single branch per indirect JUMP, and that indirect JUMPs are not modelled if they would take more than
+// MaxIndirectJumpBranches branch ids.
+func TestGetBranchMapFromBytecodeIndirectJumpLimit(t *testing.T) {
+	branchMap := GetBranchMapFromBytecode(getIndirectJumpBytecode(2, 3), true)
+	assert.Equal(t, 6, branchMap.Size())
+	assert.Len(t, branchMap.JumpBranchIds, 2)
+	assert.Len(t, branchMap.GetJumpBranchIds(1), 3)
+	assert.Len(t, branchMap.GetJumpBranchIds(3), 3)
+
+	// Right at the limit, every indirect JUMP is modelled.
+	branchMap = GetBranchMapFromBytecode(getIndirectJumpBytecode(64, MaxIndirectJumpBranches/64), true)
+	assert.Equal(t, MaxIndirectJumpBranches, branchMap.Size())
+	assert.Len(t, branchMap.JumpBranchIds, 64)
+
+	// Past the limit, no indirect JUMP is modelled.
+	branchMap = GetBranchMapFromBytecode(getIndirectJumpBytecode(64, MaxIndirectJumpBranches/64+1), true)
+	assert.Equal(t, 0, branchMap.Size())
+	assert.Empty(t, branchMap.JumpBranchIds)
+	assert.Nil(t, branchMap.GetJumpBranchIds(1))
+}
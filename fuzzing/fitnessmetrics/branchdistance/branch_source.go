@@ -0,0 +1,103 @@
+package branchdistance
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core/vm"
+	compilationTypes "github.com/crytic/medusa/compilation/types"
+	fuzzerTypes "github.com/crytic/medusa/fuzzing/contracts"
+)
+
+// maxBranchExpressionLength describes the maximum length of the source expression recorded for a branch, beyond which
+// it is truncated. Branch instructions may map to entire statements or functions.
+const maxBranchExpressionLength = 120
+
+// BranchSourceLocation describes the source code a branch instruction maps to.
+type BranchSourceLocation struct {
+	// File is the path of the source file.
+	File string `json:"file"`
+	// Line is the line of the start of the source range, starting at one.
+	Line int `json:"line"`
+	// Column is the column of the start of the source range, starting at one.
+	Column int `json:"column"`
+	// Expression is the source code of the range, with whitespace collapsed, truncated if long.
+	Expression string `json:"expression"`
+}
+
+// String returns a human-readable description of the source location.
+func (l BranchSourceLocation) String() string {
+	return fmt.Sprintf("%s:%d:%d (%s)", l.File, l.Line, l.Column, l.Expression)
+}
+
+// BranchSourceLocationsByLookupHash returns the source location of each branch instruction (JUMPI or JUMP) of the
+// provided contracts, by program counter, keyed by the lookup hashes of their init and runtime bytecode. Branches
+// which do not map to source code (e.g. compiler generated code) are omitted.
+func BranchSourceLocationsByLookupHash(contracts fuzzerTypes.Contracts) map[common.Hash]map[uint64]BranchSourceLocation {
+	sourceLocations := make(map[common.Hash]map[uint64]BranchSourceLocation)
+	for _, contract := range contracts {
+		compiledContract := contract.CompiledContract()
+		compilation := contract.Compilation()
+		if compilation == nil {
+			continue
+		}
+		initBytecodeHash, initBytecode, runtimeBytecodeHash, runtimeBytecode, _ := getContractBranchBytecode(contract)
+		if initSourceMap, err := compilationTypes.ParseSourceMap(compiledContract.SrcMapsInit); err == nil {
+			sourceLocations[initBytecodeHash] = getBranchSourceLocations(compilation, initSourceMap, initBytecode)
+		}
+		if runtimeSourceMap, err := compilationTypes.ParseSourceMap(compiledContract.SrcMapsRuntime); err == nil {
+			sourceLocations[runtimeBytecodeHash] = getBranchSourceLocations(compilation, runtimeSourceMap, runtimeBytecode)
+		}
+	}
+	return sourceLocations
+}
+
+// getBranchSourceLocations returns the source location of each branch instruction in the provided bytecode, by
+// program counter, using the provided source map (indexed by instruction index).
+func getBranchSourceLocations(compilation *compilationTypes.Compilation, sourceMap compilationTypes.SourceMap, bytecode []byte) map[uint64]BranchSourceLocation {
+	sourceLocations := make(map[uint64]BranchSourceLocation)
+	it := NewInstructionIterator(bytecode)
+	for instructionIndex := 0; it.Next(); instructionIndex++ {
+		if it.Op() != vm.JUMPI && it.Op() != vm.JUMP {
+			continue
+		}
+		if instructionIndex >= len(sourceMap) {
+			break
+		}
+		if sourceLocation, ok := getSourceLocation(compilation, sourceMap[instructionIndex]); ok {
+			sourceLocations[it.PC()] = sourceLocation
+		}
+	}
+	return sourceLocations
+}
+
+// getSourceLocation resolves the source range of the provided source map element.
+// Returns the source location, or false if the element does not map to cached source code.
+func getSourceLocation(compilation *compilationTypes.Compilation, sourceMapElement compilationTypes.SourceMapElement) (BranchSourceLocation, bool) {
+	sourcePath, ok := compilation.SourceIdToPath[sourceMapElement.SourceUnitID]
+	if !ok {
+		return BranchSourceLocation{}, false
+	}
+	sourceCode, ok := compilation.SourceCode[sourcePath]
+	start, end := sourceMapElement.Offset, sourceMapElement.Offset+sourceMapElement.Length
+	if !ok || start < 0 || sourceMapElement.Length < 0 || end > len(sourceCode) {
+		return BranchSourceLocation{}, false
+	}
+
+	// Determine the line and column from the amount of lines preceding the range.
+	line := bytes.Count(sourceCode[:start], []byte("\n")) + 1
+	column := start - bytes.LastIndexByte(sourceCode[:start], '\n')
+
+	expression := strings.Join(strings.Fields(string(sourceCode[start:end])), " ")
+	if len(expression) > maxBranchExpressionLength {
+		expression = expression[:maxBranchExpressionLength] + "..."
+	}
+	return BranchSourceLocation{
+		File:       sourcePath,
+		Line:       line,
+		Column:     column,
+		Expression: expression,
+	}, true
+}
@@ -0,0 +1,36 @@
+package branchdistance
+
+import (
+	"bytes"
+	"testing"
+
+	compilationTypes "github.com/crytic/medusa/compilation/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGetBranchSourceLocations tests that branch instructions are resolved to the line, column and expression of the
+// source range they map to.
+func TestGetBranchSourceLocations(t *testing.T) {
+	sourceCode := []byte("contract A {\n  function f(uint x) public {\n    require(x >   5);\n  }\n}\n")
+	compilation := compilationTypes.NewCompilation()
+	compilation.SourceIdToPath[0] = "A.sol"
+	compilation.SourceCode["A.sol"] = sourceCode
+
+	bytecode := []byte{
+		0x60, 0x00, // 0: PUSH1 0x00
+		0x60, 0x06, // 2: PUSH1 0x06
+		0x57, // 4: JUMPI
+		0x00, // 5: STOP
+	}
+	conditionOffset := bytes.Index(sourceCode, []byte("x >   5"))
+	sourceMap := compilationTypes.SourceMap{
+		{Index: 0, Offset: 0, Length: len(sourceCode), SourceUnitID: 0},
+		{Index: 1, Offset: 0, Length: len(sourceCode), SourceUnitID: 0},
+		{Index: 2, Offset: conditionOffset, Length: len("x >   5"), SourceUnitID: 0},
+		{Index: 3, Offset: 0, Length: 0, SourceUnitID: -1},
+	}
+
+	sourceLocations := getBranchSourceLocations(compilation, sourceMap, bytecode)
+	assert.Len(t, sourceLocations, 1)
+	assert.Equal(t, BranchSourceLocation{File: "A.sol", Line: 3, Column: 13, Expression: "x > 5"}, sourceLocations[4])
+}
@@ -0,0 +1,77 @@
+package branchdistance
+
+import (
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/holiman/uint256"
+)
+
+// NormalizeDistance normalizes a raw branch distance into the range [0, 1] using the provided normalization, so that
+// distances of different branches are comparable. A distance of zero always normalizes to zero.
+func NormalizeDistance(distance *uint256.Int, normalization string) float64 {
+	if distance.IsZero() {
+		return 0
+	}
+	switch normalization {
+	case config.BranchDistanceNormalizationLog:
+		return float64(distance.BitLen()) / 256
+	default:
+		d := distance.Float64()
+		return d / (d + 1)
+	}
+}
+
+// AggregateDistances aggregates normalized distances into a single value using the provided aggregation. Returns zero
+// if no distances are provided.
+func AggregateDistances(distances []float64, aggregation string) float64 {
+	if len(distances) == 0 {
+		return 0
+	}
+	switch aggregation {
+	case config.BranchDistanceAggregationMin:
+		minDistance := distances[0]
+		for _, distance := range distances[1:] {
+			minDistance = min(minDistance, distance)
+		}
+		return minDistance
+	case config.BranchDistanceAggregationHarmonicMean:
+		reciprocalSum := 0.0
+		for _, distance := range distances {
+			if distance == 0 {
+				return 0
+			}
+			reciprocalSum += 1 / distance
+		}
+		return float64(len(distances)) / reciprocalSum
+	default:
+		sum := 0.0
+		for _, distance := range distances {
+			sum += distance
+		}
+		return sum
+	}
+}
+
+// Fitness returns a single scalar fitness for the branch distances recorded, such as those of a call sequence. The
+// distances of the branches reached but not taken in each contract are normalized and aggregated per contract, and
+// the contract aggregates are summed. Lower values are closer to flipping branches, and zero indicates no branch
+// remains to be flipped.
+func (cm *BranchDistanceMaps) Fitness(normalization string, aggregation string) float64 {
+	// Acquire our thread lock and defer our unlocking for when we exit this method
+	cm.updateLock.Lock()
+	defer cm.updateLock.Unlock()
+
+	fitness := 0.0
+	distances := make([]float64, 0)
+	for _, mapsByAddress := range cm.maps {
+		for _, contractMap := range mapsByAddress {
+			distances = distances[:0]
+			for id, executed := range contractMap.distanceMap.executedFlags {
+				if distance := &contractMap.distanceMap.distance[id]; executed != 0 && !distance.IsZero() {
+					distances = append(distances, NormalizeDistance(distance, normalization))
+				}
+			}
+			fitness += AggregateDistances(distances, aggregation)
+		}
+	}
+	return fitness
+}
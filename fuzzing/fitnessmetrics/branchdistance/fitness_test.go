@@ -0,0 +1,38 @@
+package branchdistance
+
+import (
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBranchDistanceFitness tests that branch distances are normalized and aggregated per contract into a single
+// scalar fitness, ignoring branches which were taken.
+func TestBranchDistanceFitness(t *testing.T) {
+	assert.Equal(t, 0.0, NormalizeDistance(uint256.NewInt(0), config.BranchDistanceNormalizationRatio))
+	assert.Equal(t, 0.5, NormalizeDistance(uint256.NewInt(1), config.BranchDistanceNormalizationRatio))
+	assert.Equal(t, 3.0/256, NormalizeDistance(uint256.NewInt(4), config.BranchDistanceNormalizationLog))
+
+	distances := []float64{0.5, 0.25}
+	assert.Equal(t, 0.75, AggregateDistances(distances, config.BranchDistanceAggregationSum))
+	assert.Equal(t, 0.25, AggregateDistances(distances, config.BranchDistanceAggregationMin))
+	assert.InDelta(t, 1.0/3, AggregateDistances(distances, config.BranchDistanceAggregationHarmonicMean), 1e-9)
+	assert.Equal(t, 0.0, AggregateDistances(nil, config.BranchDistanceAggregationHarmonicMean))
+
+	// Record a JUMPI whose false branch was taken in one contract, and one in another contract.
+	maps := NewBranchDistanceMaps()
+	_, err := maps.SetAt(common.Address{1}, common.Hash{1}, 2, 0, uint256.NewInt(0))
+	assert.NoError(t, err)
+	_, err = maps.SetAt(common.Address{1}, common.Hash{1}, 2, 1, uint256.NewInt(1))
+	assert.NoError(t, err)
+	_, err = maps.SetAt(common.Address{2}, common.Hash{2}, 2, 0, uint256.NewInt(0))
+	assert.NoError(t, err)
+	_, err = maps.SetAt(common.Address{2}, common.Hash{2}, 2, 1, uint256.NewInt(3))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 0.5+0.75, maps.Fitness(config.BranchDistanceNormalizationRatio, config.BranchDistanceAggregationSum))
+	assert.Equal(t, 0.0, NewBranchDistanceMaps().Fitness(config.BranchDistanceNormalizationRatio, config.BranchDistanceAggregationSum))
+}
@@ -0,0 +1,88 @@
+package branchdistance
+
+import (
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/holiman/uint256"
+)
+
+// Operation describes an operation cached for back-propagation, along with the top of the stack prior to its
+// execution.
+type Operation struct {
+	// opcode is the opcode of the operation.
+	opcode vm.OpCode
+	// stackLen is the length of the full stack prior to the operation's execution.
+	stackLen int
+	// stackTop holds the top slots of the stack prior to the operation's execution, with the top of the stack last.
+	stackTop []uint256.Int
+}
+
+// stackAt returns the slot at the provided index of the full stack (zero being the bottom of the stack), or nil if the
+// slot was not copied.
+func (o *Operation) stackAt(index int) *uint256.Int {
+	offset := index - (o.stackLen - len(o.stackTop))
+	if offset < 0 || offset >= len(o.stackTop) {
+		return nil
+	}
+	return &o.stackTop[offset]
+}
+
+// operationRing is a bounded ring buffer of the most recent operations of a call frame. Only the top slots of the
+// stack are copied for each operation, and the memory of overwritten operations is reused, so that caching operations
+// does not allocate once the buffer is warm.
+type operationRing struct {
+	// operations holds the cached operations. It grows up to capacity, after which the oldest operation is overwritten.
+	operations []Operation
+	// start is the index of the oldest operation in operations.
+	start int
+	// size is the amount of operations currently cached.
+	size int
+	// capacity is the maximum amount of operations cached.
+	capacity int
+	// stackSlots is the maximum amount of stack slots copied for each operation.
+	stackSlots int
+}
+
+// newOperationRing returns a new operationRing caching up to capacity operations, with up to stackSlots of their top
+// stack slots.
+func newOperationRing(capacity int, stackSlots int) *operationRing {
+	return &operationRing{
+		operations: make([]Operation, 0, min(capacity, 64)),
+		capacity:   max(capacity, 1),
+		stackSlots: stackSlots,
+	}
+}
+
+// push caches an operation with the provided stack, overwriting the oldest operation if the buffer is full.
+func (r *operationRing) push(opcode vm.OpCode, stack []uint256.Int) {
+	var operation *Operation
+	if r.size < r.capacity {
+		if r.size == len(r.operations) {
+			r.operations = append(r.operations, Operation{stackTop: make([]uint256.Int, 0, r.stackSlots)})
+		}
+		operation = &r.operations[r.size]
+		r.size++
+	} else {
+		operation = &r.operations[r.start]
+		r.start = (r.start + 1) % r.size
+	}
+
+	operation.opcode = opcode
+	operation.stackLen = len(stack)
+	operation.stackTop = append(operation.stackTop[:0], stack[max(0, len(stack)-r.stackSlots):]...)
+}
+
+// len returns the amount of operations currently cached.
+func (r *operationRing) len() int {
+	return r.size
+}
+
+// at returns the cached operation at the provided index, zero being the oldest cached operation.
+func (r *operationRing) at(index int) *Operation {
+	return &r.operations[(r.start+index)%r.size]
+}
+
+// reset clears the cached operations, retaining their memory for reuse.
+func (r *operationRing) reset() {
+	r.start = 0
+	r.size = 0
+}
@@ -0,0 +1,86 @@
+package branchdistance
+
+import (
+	"testing"
+
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+// benchmarkStack returns a stack of the provided depth, with distinct values in every slot.
+func benchmarkStack(depth int) []uint256.Int {
+	stack := make([]uint256.Int, depth)
+	for i := range stack {
+		stack[i].SetUint64(uint64(i))
+	}
+	return stack
+}
+
+// TestOperationRing tests that the operation ring retains the most recent operations and the top of their stacks.
+func TestOperationRing(t *testing.T) {
+	ring := newOperationRing(4, 2)
+	for i := 0; i < 6; i++ {
+		ring.push(vm.OpCode(i), benchmarkStack(i+3))
+	}
+
+	// Only the last four operations should be retained, oldest first.
+	assert.Equal(t, 4, ring.len())
+	for i := 0; i < ring.len(); i++ {
+		operation := ring.at(i)
+		assert.EqualValues(t, i+2, operation.opcode)
+		assert.Equal(t, i+5, operation.stackLen)
+
+		// Only the top two slots should be retained, by their index in the full stack.
+		assert.Nil(t, operation.stackAt(operation.stackLen-3))
+		assert.EqualValues(t, operation.stackLen-2, operation.stackAt(operation.stackLen-2).Uint64())
+		assert.EqualValues(t, operation.stackLen-1, operation.stackAt(operation.stackLen-1).Uint64())
+	}
+
+	// Resetting the ring should clear it.
+	ring.reset()
+	assert.Equal(t, 0, ring.len())
+}
+
+// BenchmarkOperationCachingFullStack benchmarks caching every operation with a copy of its full stack, as done prior
+// to the operation ring, to serve as a baseline for BenchmarkOperationCachingRing.
+func BenchmarkOperationCachingFullStack(b *testing.B) {
+	stack := benchmarkStack(64)
+	type operation struct {
+		opcode   vm.OpCode
+		tmpStack []uint256.Int
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	operations := make([]operation, 0)
+	for i := 0; i < b.N; i++ {
+		// Emulate call frames of 1000 operations each.
+		if i%1000 == 0 {
+			operations = make([]operation, 0)
+		}
+		tmpOperation := operation{
+			opcode:   vm.ADD,
+			tmpStack: make([]uint256.Int, len(stack)),
+		}
+		copy(tmpOperation.tmpStack, stack)
+		operations = append(operations, tmpOperation)
+	}
+}
+
+// BenchmarkOperationCachingRing benchmarks caching every operation in an operation ring using the default lookback
+// window and stack slots.
+func BenchmarkOperationCachingRing(b *testing.B) {
+	stack := benchmarkStack(64)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	ring := newOperationRing(40, 32)
+	for i := 0; i < b.N; i++ {
+		// Emulate call frames of 1000 operations each, reusing the ring as the tracer does.
+		if i%1000 == 0 {
+			ring.reset()
+		}
+		ring.push(vm.ADD, stack)
+	}
+}
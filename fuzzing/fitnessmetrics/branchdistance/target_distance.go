@@ -0,0 +1,196 @@
+package branchdistance
+
+import (
+	"math"
+
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/holiman/uint256"
+)
+
+// NoTargetDistance is the static distance of a basic block from which no target is reachable, and the target distance
+// recorded for executions which did not approach any target.
+const NoTargetDistance uint64 = math.MaxUint64
+
+// targetDistanceScale is the factor applied to static distances when combining them with runtime branch distances.
+// It exceeds the bit length of any runtime distance, so that the static distance always dominates and the runtime
+// distance only orders executions at the same static distance.
+const targetDistanceScale = 257
+
+// TargetDistanceMap describes, for every JUMPI in some bytecode, the static distance in basic blocks from each of its
+// successors to the closest target program counter, computed over the bytecode's control flow graph.
+type TargetDistanceMap struct {
+	// branchDistances maps the pc of each JUMPI to the static distance from its fall-through [0] and jump [1]
+	// successors to the closest target.
+	branchDistances map[uint64][2]uint64
+}
+
+// basicBlock describes a sequence of instructions with a single entry and exit in a control flow graph.
+type basicBlock struct {
+	// start is the pc of the first instruction of the block.
+	start uint64
+	// successors are the pcs of the blocks control may flow to once the block exits.
+	successors []uint64
+	// predecessors are the pcs of the blocks which may flow into this block.
+	predecessors []uint64
+}
+
+// isBlockTerminator determines whether the provided opcode ends the execution of a basic block without falling through
+// to the next instruction.
+func isBlockTerminator(op vm.OpCode) bool {
+	switch op {
+	case vm.STOP, vm.JUMP, vm.RETURN, vm.REVERT, vm.INVALID, vm.SELFDESTRUCT:
+		return true
+	}
+	return false
+}
+
+// BuildTargetDistanceMap builds the control flow graph of the provided bytecode and computes the static distance from
+// every JUMPI successor to the closest of the provided target pcs, AFLGo-style. Only statically resolvable jumps (a
+// JUMP or JUMPI directly preceded by a PUSH of its destination) are followed, so internal function returns are not
+// considered, while internal function calls are.
+// Returns the target distance map, or nil if no targets lie within the bytecode.
+func BuildTargetDistanceMap(bytecode []byte, targetPcs []uint64) *TargetDistanceMap {
+	// Split the bytecode into basic blocks and record the edges between them.
+	blocks := make(map[uint64]*basicBlock)
+	blockStarts := make([]uint64, 0)
+	jumpis := make(map[uint64]uint64)
+	var current *basicBlock
+	var lastPushValue *uint256.Int
+	it := NewInstructionIterator(bytecode)
+	for it.Next() {
+		pc, op := it.PC(), it.Op()
+		if current == nil || op == vm.JUMPDEST {
+			next := &basicBlock{start: pc}
+			if current != nil {
+				current.successors = append(current.successors, pc)
+			}
+			current = next
+			blocks[pc] = current
+			blockStarts = append(blockStarts, pc)
+		}
+
+		if op == vm.JUMP || op == vm.JUMPI {
+			if lastPushValue != nil && lastPushValue.IsUint64() {
+				current.successors = append(current.successors, lastPushValue.Uint64())
+			}
+			if op == vm.JUMPI {
+				jumpis[pc] = current.start
+			}
+		}
+
+		if op.IsPush() {
+			lastPushValue = new(uint256.Int).SetBytes(it.Arg())
+		} else {
+			lastPushValue = nil
+		}
+
+		// Blocks end on jumps and terminators. A JUMPI also falls through to the block starting at the next instruction.
+		if op == vm.JUMPI {
+			current.successors = append(current.successors, pc+1)
+			current = nil
+		} else if isBlockTerminator(op) {
+			current = nil
+		}
+	}
+
+	// Jump destinations which are not the start of a block (i.e. not a JUMPDEST) are invalid, so drop those edges and
+	// link the remaining ones in reverse.
+	for _, start := range blockStarts {
+		block := blocks[start]
+		validSuccessors := block.successors[:0]
+		for _, successor := range block.successors {
+			if successorBlock, ok := blocks[successor]; ok {
+				validSuccessors = append(validSuccessors, successor)
+				successorBlock.predecessors = append(successorBlock.predecessors, start)
+			}
+		}
+		block.successors = validSuccessors
+	}
+
+	// Find the blocks containing our targets, these are at distance zero.
+	distances := make(map[uint64]uint64)
+	queue := make([]uint64, 0)
+	for _, targetPc := range targetPcs {
+		blockStart, ok := containingBlockStart(blockStarts, targetPc)
+		if !ok {
+			continue
+		}
+		if _, seen := distances[blockStart]; !seen {
+			distances[blockStart] = 0
+			queue = append(queue, blockStart)
+		}
+	}
+	if len(queue) == 0 {
+		return nil
+	}
+
+	// Walk the graph backwards from our targets to determine the distance of every block which can reach one.
+	for len(queue) > 0 {
+		start := queue[0]
+		queue = queue[1:]
+		for _, predecessor := range blocks[start].predecessors {
+			if _, seen := distances[predecessor]; !seen {
+				distances[predecessor] = distances[start] + 1
+				queue = append(queue, predecessor)
+			}
+		}
+	}
+
+	// Record the distance of each JUMPI's successors. Its fall-through successor starts at the next instruction, while
+	// its jump successor is the other successor of its block (if statically resolved).
+	targetDistanceMap := &TargetDistanceMap{
+		branchDistances: make(map[uint64][2]uint64, len(jumpis)),
+	}
+	for jumpiPc, blockStart := range jumpis {
+		branchDistance := [2]uint64{NoTargetDistance, NoTargetDistance}
+		for _, successor := range blocks[blockStart].successors {
+			distance, reachable := distances[successor]
+			if !reachable {
+				continue
+			}
+			if successor == jumpiPc+1 {
+				branchDistance[0] = distance
+			} else {
+				branchDistance[1] = distance
+			}
+		}
+		targetDistanceMap.branchDistances[jumpiPc] = branchDistance
+	}
+	return targetDistanceMap
+}
+
+// containingBlockStart returns the start of the block containing the provided pc, given the sorted block starts.
+func containingBlockStart(blockStarts []uint64, pc uint64) (uint64, bool) {
+	found := false
+	var blockStart uint64
+	for _, start := range blockStarts {
+		if start > pc {
+			break
+		}
+		blockStart, found = start, true
+	}
+	return blockStart, found
+}
+
+// BranchDistance returns the static distance from the successor of the JUMPI at the provided pc (the jump successor if
+// jumped is set, otherwise the fall-through one) to the closest target, or NoTargetDistance if none is reachable.
+func (m *TargetDistanceMap) BranchDistance(pc uint64, jumped bool) uint64 {
+	branchDistance, ok := m.branchDistances[pc]
+	if !ok {
+		return NoTargetDistance
+	}
+	if jumped {
+		return branchDistance[1]
+	}
+	return branchDistance[0]
+}
+
+// combinedTargetDistance combines the static distance of a branch successor to the closest target with the runtime
+// distance to taking that branch (zero if it was taken) into a single distance. Returns NoTargetDistance if no target
+// is reachable or the runtime distance is unknown.
+func combinedTargetDistance(staticDistance uint64, runtimeDistance *uint256.Int) uint64 {
+	if staticDistance == NoTargetDistance || runtimeDistance.Eq(UnknownDistance) || staticDistance >= NoTargetDistance/targetDistanceScale {
+		return NoTargetDistance
+	}
+	return staticDistance*targetDistanceScale + uint64(runtimeDistance.BitLen())
+}
@@ -30,6 +30,14 @@ type CmpDistanceMaps struct {
 	// cachedCodeAddress and matches the cachedCodeHash, then this map is used to avoid an expensive lookup into maps.
 	cachedMap *ContractCmpDistanceMap
 
+	// spareMaps describes the ContractCmpDistanceMap objects emptied by Clear, which are reused before new ones are
+	// allocated.
+	spareMaps []*ContractCmpDistanceMap
+
+	// spareMapsByAddress describes the lookups of ContractCmpDistanceMap objects by address emptied by Clear, which
+	// are reused before new ones are allocated.
+	spareMapsByAddress []map[common.Address]*ContractCmpDistanceMap
+
 	// updateLock is a lock to offer concurrent thread safety for map accesses.
 	updateLock sync.Mutex
 }
@@ -82,6 +90,52 @@ func (cm *CmpDistanceMaps) Reset() {
 	cm.cachedMap = nil
 }
 
+// Clear clears the distance state for the CmpDistanceMaps like Reset, but retains the memory allocated for it, so that
+// distances recorded afterwards reuse it.
+func (cm *CmpDistanceMaps) Clear() {
+	cm.updateLock.Lock()
+	defer cm.updateLock.Unlock()
+
+	for _, mapsByAddress := range cm.maps {
+		for _, cmpDistanceMap := range mapsByAddress {
+			cmpDistanceMap.distanceMap.clear()
+			cm.spareMaps = append(cm.spareMaps, cmpDistanceMap)
+		}
+		clear(mapsByAddress)
+		cm.spareMapsByAddress = append(cm.spareMapsByAddress, mapsByAddress)
+	}
+	clear(cm.maps)
+	cm.cachedCodeAddress = common.Address{}
+	cm.cachedCodeHash = common.Hash{}
+	cm.cachedMap = nil
+}
+
+// getMapsByAddress obtains the lookup of ContractCmpDistanceMap objects by address for the provided code hash,
+// creating it if it does not exist.
+func (cm *CmpDistanceMaps) getMapsByAddress(codeHash common.Hash) map[common.Address]*ContractCmpDistanceMap {
+	mapsByAddress, codeHashExists := cm.maps[codeHash]
+	if !codeHashExists {
+		if count := len(cm.spareMapsByAddress); count > 0 {
+			mapsByAddress = cm.spareMapsByAddress[count-1]
+			cm.spareMapsByAddress = cm.spareMapsByAddress[:count-1]
+		} else {
+			mapsByAddress = make(map[common.Address]*ContractCmpDistanceMap)
+		}
+		cm.maps[codeHash] = mapsByAddress
+	}
+	return mapsByAddress
+}
+
+// newContractCmpDistanceMap obtains an empty ContractCmpDistanceMap, reusing one emptied by Clear if possible.
+func (cm *CmpDistanceMaps) newContractCmpDistanceMap() *ContractCmpDistanceMap {
+	if count := len(cm.spareMaps); count > 0 {
+		cmpDistanceMap := cm.spareMaps[count-1]
+		cm.spareMaps = cm.spareMaps[:count-1]
+		return cmpDistanceMap
+	}
+	return newContractCmpDistanceMap()
+}
+
 // getContractCmpDistanceMapHash obtain the hash used to look up a given contract's ContractCmpDistanceMap.
 // If this is init bytecode, metadata and abi arguments will attempt to be stripped, then a hash is computed.
 // If this is runtime bytecode, the metadata ipfs/swarm hash will be used if available, otherwise the bytecode
@@ -149,14 +203,11 @@ func (cm *CmpDistanceMaps) Update(coverageMaps *CmpDistanceMaps) (bool, error) {
 	for codeHash, mapsByAddressToMerge := range coverageMaps.maps {
 		for codeAddress, coverageMapToMerge := range mapsByAddressToMerge {
 			// If a coverage map lookup for this code hash doesn't exist, create the mapping.
-			mapsByAddress, codeHashExists := cm.maps[codeHash]
-			if !codeHashExists {
-				mapsByAddress = make(map[common.Address]*ContractCmpDistanceMap)
-				cm.maps[codeHash] = mapsByAddress
-			}
+			mapsByAddress := cm.getMapsByAddress(codeHash)
 
 			// If a coverage map for this address already exists in our current mapping, update it with the one
-			// to merge. If it doesn't exist, set it to the one to merge.
+			// to merge. If it doesn't exist, create it from a copy of the one to merge, as the provided maps may be
+			// cleared and reused by their owner.
 			if existingCoverageMap, codeAddressExists := mapsByAddress[codeAddress]; codeAddressExists {
 				changed, err := existingCoverageMap.update(coverageMapToMerge)
 				distanceChanged = distanceChanged || changed
@@ -164,7 +215,11 @@ func (cm *CmpDistanceMaps) Update(coverageMaps *CmpDistanceMaps) (bool, error) {
 					return distanceChanged, err
 				}
 			} else {
-				mapsByAddress[codeAddress] = coverageMapToMerge
+				newDistanceMap := cm.newContractCmpDistanceMap()
+				if _, err := newDistanceMap.update(coverageMapToMerge); err != nil {
+					return distanceChanged, err
+				}
+				mapsByAddress[codeAddress] = newDistanceMap
 				distanceChanged = coverageMapToMerge.distanceMap != nil
 			}
 		}
@@ -190,18 +245,14 @@ func (cm *CmpDistanceMaps) SetAt(codeAddress common.Address, codeLookupHash comm
 		cmpDistanceMap = cm.cachedMap
 	} else {
 		// If a coverage map lookup for this code hash doesn't exist, create the mapping.
-		mapsByCodeAddress, codeHashExists := cm.maps[codeLookupHash]
-		if !codeHashExists {
-			mapsByCodeAddress = make(map[common.Address]*ContractCmpDistanceMap)
-			cm.maps[codeLookupHash] = mapsByCodeAddress
-		}
+		mapsByCodeAddress := cm.getMapsByAddress(codeLookupHash)
 
 		// Obtain the distance map for this code address if it already exists. If it does not, create a new one.
 		if existingCoverageMap, codeAddressExists := mapsByCodeAddress[codeAddress]; codeAddressExists {
 			cmpDistanceMap = existingCoverageMap
 		} else {
-			cmpDistanceMap = newContractCmpDistanceMap()
-			cm.maps[codeLookupHash][codeAddress] = cmpDistanceMap
+			cmpDistanceMap = cm.newContractCmpDistanceMap()
+			mapsByCodeAddress[codeAddress] = cmpDistanceMap
 			addedNewMap = true
 		}
 
@@ -227,7 +278,7 @@ func (cm *CmpDistanceMaps) RevertAll() {
 	// Loop for each coverage map provided
 	for _, mapsByAddressToMerge := range cm.maps {
 		for _, cmpDistanceMap := range mapsByAddressToMerge {
-			cmpDistanceMap.distanceMap.Reset()
+			cmpDistanceMap.distanceMap.clear()
 		}
 	}
 }
@@ -280,6 +331,9 @@ func (cm *ContractCmpDistanceMap) getCoveredCmpNum(includeReverted bool) int {
 // or runtime bytecode.
 type DistanceMapBranchData struct {
 	distance map[uint64]*uint256.Int
+
+	// spareDistances describes the distances emptied by clear, which are reused before new ones are allocated.
+	spareDistances []*uint256.Int
 }
 
 // Reset resets the branch coverage map data to be empty.
@@ -287,6 +341,25 @@ func (cm *DistanceMapBranchData) Reset() {
 	cm.distance = make(map[uint64]*uint256.Int)
 }
 
+// clear resets the branch coverage map data to be empty like Reset, but retains the memory allocated for it, so that
+// distances recorded afterwards reuse it.
+func (cm *DistanceMapBranchData) clear() {
+	for _, distance := range cm.distance {
+		cm.spareDistances = append(cm.spareDistances, distance)
+	}
+	clear(cm.distance)
+}
+
+// newDistance obtains a copy of the provided distance, reusing a distance emptied by clear if possible.
+func (cm *DistanceMapBranchData) newDistance(distance *uint256.Int) *uint256.Int {
+	if count := len(cm.spareDistances); count > 0 {
+		spareDistance := cm.spareDistances[count-1]
+		cm.spareDistances = cm.spareDistances[:count-1]
+		return spareDistance.Set(distance)
+	}
+	return new(uint256.Int).Set(distance)
+}
+
 // update creates updates the current DistanceMapBranchData with the provided one.
 // Returns a boolean indicating whether new coverage was achieved, or an error if one was encountered.
 func (cm *DistanceMapBranchData) update(cmpDistanceMap *DistanceMapBranchData) (bool, error) {
@@ -300,9 +373,9 @@ func (cm *DistanceMapBranchData) update(cmpDistanceMap *DistanceMapBranchData) (
 	changed := false
 	for id := range cmpDistanceMap.distance {
 		if _, exists := cm.distance[id]; !exists {
-			cm.distance[id] = new(uint256.Int).Set(cmpDistanceMap.distance[id])
+			cm.distance[id] = cm.newDistance(cmpDistanceMap.distance[id])
 		} else if cm.distance[id].Gt(cmpDistanceMap.distance[id]) {
-			cm.distance[id] = new(uint256.Int).Set(cmpDistanceMap.distance[id])
+			cm.distance[id].Set(cmpDistanceMap.distance[id])
 			changed = true
 		}
 	}
@@ -320,10 +393,10 @@ func (cm *DistanceMapBranchData) setDistanceAt(id uint64, distance *uint256.Int)
 
 	// If our program counter is in range, determine if we achieved new coverage for the first time, and update it.
 	if _, exists := cm.distance[id]; !exists {
-		cm.distance[id] = new(uint256.Int).Set(distance)
+		cm.distance[id] = cm.newDistance(distance)
 		return true, nil
 	} else if cm.distance[id].Gt(distance) {
-		cm.distance[id] = new(uint256.Int).Set(distance)
+		cm.distance[id].Set(distance)
 		return true, nil
 	}
 
@@ -0,0 +1,93 @@
+package cmpdistance
+
+import (
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/chain/types"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCmpDistanceMapsUpdateCopies tests that updating maps copies the distances merged, so the merged maps can be
+// released and their distances reused for another call frame without affecting the updated maps.
+func TestCmpDistanceMapsUpdateCopies(t *testing.T) {
+	pool := fitnessmetrics.NewResultPool(NewCmpDistanceMaps)
+	maps := NewCmpDistanceMaps()
+	frameMaps := pool.Get()
+	_, err := frameMaps.SetAt(common.Address{1}, common.Hash{1}, 0, uint256.NewInt(7))
+	assert.NoError(t, err)
+
+	_, err = maps.Update(frameMaps)
+	assert.NoError(t, err)
+	pool.Put(frameMaps)
+
+	// Reuse the released maps for another call frame, which starts out empty and reuses the released distance.
+	otherFrameMaps := pool.Get()
+	assert.Same(t, frameMaps, otherFrameMaps)
+	assert.EqualValues(t, 0, otherFrameMaps.TotalCoveredCmpNum(false, nil))
+	_, err = otherFrameMaps.SetAt(common.Address{1}, common.Hash{1}, 0, uint256.NewInt(3))
+	assert.NoError(t, err)
+
+	distances := maps.maps[common.Hash{1}][common.Address{1}].distanceMap.distance
+	assert.EqualValues(t, 7, distances[0].Uint64())
+	changed, err := maps.Update(otherFrameMaps)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.EqualValues(t, 3, distances[0].Uint64())
+}
+
+// TestCmpDistanceTracerReleaseResults tests that the tracer only releases the distance maps of its latest transaction
+// from the message results they were stored in, and reuses them once released.
+func TestCmpDistanceTracerReleaseResults(t *testing.T) {
+	tracer := &CmpDistanceTracer{resultPool: fitnessmetrics.NewResultPool(NewCmpDistanceMaps)}
+	tracer.cmpDistanceMaps = tracer.resultPool.Get()
+	cmpDistanceMaps := tracer.cmpDistanceMaps
+	_, err := cmpDistanceMaps.SetAt(common.Address{1}, common.Hash{1}, 0, uint256.NewInt(7))
+	assert.NoError(t, err)
+	results := &types.MessageResults{AdditionalResults: make(map[string]any)}
+	tracer.CaptureTxEndSetAdditionalResults(results)
+
+	// Results stored in other message results are not released.
+	tracer.ReleaseResults(&types.MessageResults{AdditionalResults: make(map[string]any)})
+	assert.Same(t, cmpDistanceMaps, GetCmpDistanceTracerResults(results))
+
+	tracer.ReleaseResults(results)
+	assert.Nil(t, GetCmpDistanceTracerResults(results))
+	assert.Nil(t, tracer.cmpDistanceMaps)
+	assert.NotPanics(t, func() { tracer.ReleaseResults(results) })
+	assert.NotPanics(t, func() { (*CmpDistanceTracer)(nil).ReleaseResults(results) })
+
+	// The released maps are cleared and reused for the next transaction.
+	assert.Same(t, cmpDistanceMaps, tracer.resultPool.Get())
+	assert.EqualValues(t, 0, cmpDistanceMaps.TotalCoveredCmpNum(false, nil))
+}
+
+// BenchmarkCmpDistanceMapsPerCallFrame measures recording the distances of a call frame and merging them into those of
+// its transaction, with maps allocated for each call frame.
+func BenchmarkCmpDistanceMapsPerCallFrame(b *testing.B) {
+	transactionMaps := NewCmpDistanceMaps()
+	distance := uint256.NewInt(7)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		frameMaps := NewCmpDistanceMaps()
+		_, _ = frameMaps.SetAt(common.Address{1}, common.Hash{1}, uint64(i%64), distance)
+		_, _ = transactionMaps.Update(frameMaps)
+	}
+}
+
+// BenchmarkCmpDistanceMapsResultPool measures recording the distances of a call frame and merging them into those of
+// its transaction, with maps recycled through a fitnessmetrics.ResultPool as done by the tracer.
+func BenchmarkCmpDistanceMapsResultPool(b *testing.B) {
+	pool := fitnessmetrics.NewResultPool(NewCmpDistanceMaps)
+	transactionMaps := NewCmpDistanceMaps()
+	distance := uint256.NewInt(7)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		frameMaps := pool.Get()
+		_, _ = frameMaps.SetAt(common.Address{1}, common.Hash{1}, uint64(i%64), distance)
+		_, _ = transactionMaps.Update(frameMaps)
+		pool.Put(frameMaps)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/crytic/medusa/chain"
 	"github.com/crytic/medusa/chain/types"
 	fuzzerTypes "github.com/crytic/medusa/fuzzing/contracts"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics"
 	"github.com/crytic/medusa/logging"
 	"github.com/holiman/uint256"
 )
@@ -47,6 +48,12 @@ type CmpDistanceTracer struct {
 	// callFrameStates describes the state tracked by the tracer per call frame.
 	callFrameStates []*cmpDistanceTracerCallFrameState
 
+	// resultPool recycles the distance maps recorded for each transaction and call frame.
+	resultPool *fitnessmetrics.ResultPool[*CmpDistanceMaps]
+
+	// lastResults describes the message results the distance maps of the latest transaction were stored in.
+	lastResults *types.MessageResults
+
 	// callDepth refers to the current EVM depth during tracing.
 	callDepth int
 
@@ -90,6 +97,7 @@ func NewCmpDistanceTracer(contracts fuzzerTypes.Contracts) *CmpDistanceTracer {
 	tracer := &CmpDistanceTracer{
 		cmpDistanceMaps: NewCmpDistanceMaps(),
 		callFrameStates: make([]*cmpDistanceTracerCallFrameState, 0),
+		resultPool:      fitnessmetrics.NewResultPool(NewCmpDistanceMaps),
 		codeHashCache:   make(map[common.Hash]common.Hash),
 	}
 
@@ -138,8 +146,8 @@ func (t *CmpDistanceTracer) addressForCoverage(address common.Address) common.Ad
 func (t *CmpDistanceTracer) OnTxStart(vm *tracing.VMContext, tx *coretypes.Transaction, from common.Address) {
 	// Reset our call frame states
 	t.callDepth = 0
-	t.cmpDistanceMaps = NewCmpDistanceMaps()
-	t.callFrameStates = make([]*cmpDistanceTracerCallFrameState, 0)
+	t.cmpDistanceMaps = t.resultPool.Get()
+	t.callFrameStates = t.callFrameStates[:0]
 	t.evmContext = vm
 }
 
@@ -156,7 +164,7 @@ func (t *CmpDistanceTracer) OnEnter(depth int, typ byte, from common.Address, to
 	// Create our state tracking struct for this frame.
 	t.callFrameStates = append(t.callFrameStates, &cmpDistanceTracerCallFrameState{
 		create:                typ == byte(vm.CREATE) || typ == byte(vm.CREATE2),
-		pendingCmpDistanceMap: NewCmpDistanceMaps(),
+		pendingCmpDistanceMap: t.resultPool.Get(),
 	})
 }
 
@@ -188,6 +196,9 @@ func (t *CmpDistanceTracer) OnExit(depth int, output []byte, gasUsed uint64, err
 	if distanceUpdateErr != nil {
 		logging.GlobalLogger.Panic("CmpDistance tracer failed to update distance map during capture end", distanceUpdateErr)
 	}
+
+	// The distances of this call frame were copied up, so its maps can be reused.
+	t.resultPool.Put(currentDistanceMap)
 }
 
 // OnOpcode records data from an EVM state update, as defined by tracers.Tracer.
@@ -202,33 +213,54 @@ func (t *CmpDistanceTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope
 
 	// If there is code we're executing and opcode is a comparison operation, collect distance information.
 	if vm.OpCode(op) == vm.LT || vm.OpCode(op) == vm.GT || vm.OpCode(op) == vm.EQ || vm.OpCode(op) == vm.SLT || vm.OpCode(op) == vm.SGT {
-		diff := uint256.NewInt(0)
-
 		// We can cast OpContext to ScopeContext because that is the type passed to OnOpcode.
-		scopeContext := scope.(*vm.ScopeContext)
-		code := scopeContext.Contract.Code
-		isCreate := callFrameState.create
-
-		// Get stack values for comparison operations
-		if len(scopeContext.Stack.Data()) >= 2 {
-			x := scopeContext.Stack.Back(0)
-			y := scopeContext.Stack.Back(1)
-			if x.Gt(y) { // if x > y
-				diff = diff.Sub(x, y)
-			} else { // if x <= y
-				diff = diff.Sub(y, x)
-			}
-
-			// Obtain our contract distance map lookup hash.
-			if callFrameState.lookupHash == nil {
-				lookupHash := getContractCmpDistanceMapHash(code, isCreate)
-				callFrameState.lookupHash = &lookupHash
-			}
-
-			_, distanceUpdateErr := callFrameState.pendingCmpDistanceMap.SetAt(t.addressForCoverage(callFrameState.address), *callFrameState.lookupHash, pc, diff)
-			if distanceUpdateErr != nil {
-				logging.GlobalLogger.Panic("CmpDistance tracer failed to update distance map while tracing state", distanceUpdateErr)
-			}
+		t.recordComparison(pc, scope.(*vm.ScopeContext))
+	}
+}
+
+// OnOpcodeStep records an opcode dispatched by a chain.TestChainTracerMultiplexer, as defined by
+// chain.OpcodeStepTracer.
+func (t *CmpDistanceTracer) OnOpcodeStep(step *chain.OpcodeStep) {
+	// The multiplexer caches the address of the call frame, so we use it rather than waiting for the first opcode.
+	callFrameState := t.callFrameStates[t.callDepth]
+	if !callFrameState.initialized {
+		callFrameState.initialized = true
+		callFrameState.address = step.Address
+	}
+	t.recordComparison(step.Pc, step.Scope)
+}
+
+// HandledOpcodes returns the opcodes OnOpcodeStep should be called for, as defined by chain.OpcodeStepTracer.
+func (t *CmpDistanceTracer) HandledOpcodes() []vm.OpCode {
+	return []vm.OpCode{vm.LT, vm.GT, vm.EQ, vm.SLT, vm.SGT}
+}
+
+// recordComparison records the distance between the operands of the comparison at the provided location.
+func (t *CmpDistanceTracer) recordComparison(pc uint64, scopeContext *vm.ScopeContext) {
+	callFrameState := t.callFrameStates[t.callDepth]
+	diff := uint256.NewInt(0)
+	code := scopeContext.Contract.Code
+	isCreate := callFrameState.create
+
+	// Get stack values for comparison operations
+	if len(scopeContext.Stack.Data()) >= 2 {
+		x := scopeContext.Stack.Back(0)
+		y := scopeContext.Stack.Back(1)
+		if x.Gt(y) { // if x > y
+			diff = diff.Sub(x, y)
+		} else { // if x <= y
+			diff = diff.Sub(y, x)
+		}
+
+		// Obtain our contract distance map lookup hash.
+		if callFrameState.lookupHash == nil {
+			lookupHash := getContractCmpDistanceMapHash(code, isCreate)
+			callFrameState.lookupHash = &lookupHash
+		}
+
+		_, distanceUpdateErr := callFrameState.pendingCmpDistanceMap.SetAt(t.addressForCoverage(callFrameState.address), *callFrameState.lookupHash, pc, diff)
+		if distanceUpdateErr != nil {
+			logging.GlobalLogger.Panic("CmpDistance tracer failed to update distance map while tracing state", distanceUpdateErr)
 		}
 	}
 }
@@ -239,4 +271,19 @@ func (t *CmpDistanceTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope
 func (t *CmpDistanceTracer) CaptureTxEndSetAdditionalResults(results *types.MessageResults) {
 	// Store our tracer results.
 	results.AdditionalResults[cmpDistanceTracerResultsKey] = t.cmpDistanceMaps
+	t.lastResults = results
+}
+
+// ReleaseResults releases the distance maps of the latest transaction for reuse, if they were stored in the provided
+// message results, as defined by fitnessmetrics.ResultReleaser. They are removed from the message results.
+func (t *CmpDistanceTracer) ReleaseResults(results *types.MessageResults) {
+	if t == nil || t.cmpDistanceMaps == nil || results != t.lastResults {
+		return
+	}
+	if GetCmpDistanceTracerResults(results) == t.cmpDistanceMaps {
+		RemoveCmpDistanceTracerResults(results)
+	}
+	t.resultPool.Put(t.cmpDistanceMaps)
+	t.cmpDistanceMaps = nil
+	t.lastResults = nil
 }
@@ -0,0 +1,248 @@
+package cmplog
+
+import (
+	"math/big"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core/tracing"
+	coretypes "github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa-geth/eth/tracers"
+	"github.com/crytic/medusa/chain"
+	"github.com/crytic/medusa/chain/types"
+	"github.com/holiman/uint256"
+)
+
+// cmpLogTracerResultsKey describes the key to use when storing tracer results in call message results, or when
+// querying them.
+const cmpLogTracerResultsKey = "CmpLogTracerResults"
+
+// cmpLogTracerPreimageResultsKey describes the key to use when storing the recorded hash preimages in call message
+// results, or when querying them.
+const cmpLogTracerPreimageResultsKey = "CmpLogTracerPreimageResults"
+
+// maxPreimageLength describes the maximum length of KECCAK256 preimages recorded, which covers single words and the
+// (key, slot) pairs hashed to compute mapping storage slots.
+const maxPreimageLength = 64
+
+// maxJumpiDistance describes the maximum amount of operations which may separate a comparison from the JUMPI it
+// feeds. This allows for the ISZERO, AND and stack operations solc emits between the two.
+const maxJumpiDistance = 8
+
+// GetCmpLogTracerResults obtains the OperandPair values stored by a CmpLogTracer from message results. This is nil if
+// no operands were recorded by a tracer (e.g. CmpLogTracer was not attached during this message execution).
+func GetCmpLogTracerResults(messageResults *types.MessageResults) []OperandPair {
+	// Try to obtain the results the tracer should've stored.
+	if genericResult, ok := messageResults.AdditionalResults[cmpLogTracerResultsKey]; ok {
+		if castedResult, ok := genericResult.([]OperandPair); ok {
+			return castedResult
+		}
+	}
+
+	// If we could not obtain them, return nil.
+	return nil
+}
+
+// GetCmpLogTracerPreimages obtains the KECCAK256 preimages stored by a CmpLogTracer from message results. This is nil
+// if no preimages were recorded by a tracer (e.g. CmpLogTracer was not attached during this message execution).
+func GetCmpLogTracerPreimages(messageResults *types.MessageResults) [][]byte {
+	// Try to obtain the results the tracer should've stored.
+	if genericResult, ok := messageResults.AdditionalResults[cmpLogTracerPreimageResultsKey]; ok {
+		if castedResult, ok := genericResult.([][]byte); ok {
+			return castedResult
+		}
+	}
+
+	// If we could not obtain them, return nil.
+	return nil
+}
+
+// OperandPair describes the concrete operands of a comparison which fed a JUMPI.
+type OperandPair struct {
+	// Op is the comparison opcode.
+	Op vm.OpCode
+	// Lhs is the first (top of stack) operand of the comparison.
+	Lhs uint256.Int
+	// Rhs is the second operand of the comparison.
+	Rhs uint256.Int
+}
+
+// Substitutes returns the values an input word equal to the provided operand should be substituted with to flip the
+// outcome of the comparison. For equality this is the other operand, for ordering comparisons it also includes the
+// values on either side of the other operand.
+func (p OperandPair) Substitutes(operand *uint256.Int) []*uint256.Int {
+	var other *uint256.Int
+	if operand.Eq(&p.Lhs) {
+		other = &p.Rhs
+	} else if operand.Eq(&p.Rhs) {
+		other = &p.Lhs
+	} else {
+		return nil
+	}
+
+	substitutes := []*uint256.Int{new(uint256.Int).Set(other)}
+	if p.Op != vm.EQ {
+		substitutes = append(substitutes, new(uint256.Int).AddUint64(other, 1), new(uint256.Int).SubUint64(other, 1))
+	}
+	return substitutes
+}
+
+// CmpLogTracer implements tracers.Tracer to record the operands of comparisons feeding JUMPI operations, and short
+// KECCAK256 preimages, into a per-transaction buffer. This allows the mutator to substitute expected constants directly into call data
+// (RedQueen-style input-to-state correspondence), closing magic value branches which distance metrics only approach.
+type CmpLogTracer struct {
+	// operandPairs describes the unique operand pairs recorded during the current transaction.
+	operandPairs []OperandPair
+
+	// recorded is used to deduplicate the operand pairs recorded during the current transaction.
+	recorded map[OperandPair]struct{}
+
+	// preimages describes the unique KECCAK256 preimages recorded during the current transaction.
+	preimages [][]byte
+
+	// recordedPreimages is used to deduplicate the preimages recorded during the current transaction.
+	recordedPreimages map[string]struct{}
+
+	// maxOperandPairs describes the maximum amount of operand pairs, and of preimages, recorded per transaction.
+	maxOperandPairs int
+
+	// callFrameStates describes the state tracked by the tracer per call frame.
+	callFrameStates []*cmpLogTracerCallFrameState
+
+	// nativeTracer is the underlying tracer used to capture EVM execution.
+	nativeTracer *chain.TestChainTracer
+}
+
+// cmpLogTracerCallFrameState tracks state across call frames in the tracer.
+type cmpLogTracerCallFrameState struct {
+	// pendingPair describes the operands of the most recent comparison in this call frame, if any.
+	pendingPair *OperandPair
+
+	// operationsSincePending describes the amount of operations executed since pendingPair was recorded.
+	operationsSincePending int
+}
+
+// NewCmpLogTracer returns a new CmpLogTracer which records up to the provided amount of operand pairs per transaction.
+func NewCmpLogTracer(maxOperandPairs int) *CmpLogTracer {
+	tracer := &CmpLogTracer{
+		maxOperandPairs: maxOperandPairs,
+		callFrameStates: make([]*cmpLogTracerCallFrameState, 0),
+	}
+
+	nativeTracer := &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnTxStart: tracer.OnTxStart,
+			OnEnter:   tracer.OnEnter,
+			OnExit:    tracer.OnExit,
+			OnOpcode:  tracer.OnOpcode,
+		},
+	}
+	tracer.nativeTracer = &chain.TestChainTracer{Tracer: nativeTracer, CaptureTxEndSetAdditionalResults: tracer.CaptureTxEndSetAdditionalResults}
+
+	return tracer
+}
+
+// NativeTracer returns the underlying TestChainTracer.
+func (t *CmpLogTracer) NativeTracer() *chain.TestChainTracer {
+	return t.nativeTracer
+}
+
+// OnTxStart is called upon the start of transaction execution, as defined by tracers.Tracer.
+func (t *CmpLogTracer) OnTxStart(vm *tracing.VMContext, tx *coretypes.Transaction, from common.Address) {
+	// Reset our operand buffer and call frame states
+	t.operandPairs = nil
+	t.recorded = make(map[OperandPair]struct{})
+	t.preimages = nil
+	t.recordedPreimages = make(map[string]struct{})
+	t.callFrameStates = make([]*cmpLogTracerCallFrameState, 0)
+}
+
+// OnEnter initializes the tracing operation for the top of a call frame, as defined by tracers.Tracer.
+func (t *CmpLogTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.callFrameStates = append(t.callFrameStates, &cmpLogTracerCallFrameState{})
+}
+
+// OnExit is called after a call to finalize tracing completes for the top of a call frame, as defined by tracers.Tracer.
+// Operands are kept for reverted call frames, as they describe the comparisons guarding the revert.
+func (t *CmpLogTracer) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if len(t.callFrameStates) > 0 {
+		t.callFrameStates = t.callFrameStates[:len(t.callFrameStates)-1]
+	}
+}
+
+// OnOpcode records data from an EVM state update, as defined by tracers.Tracer.
+func (t *CmpLogTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	if len(t.callFrameStates) == 0 {
+		return
+	}
+	callFrameState := t.callFrameStates[len(t.callFrameStates)-1]
+
+	switch vm.OpCode(op) {
+	case vm.LT, vm.GT, vm.SLT, vm.SGT, vm.EQ:
+		// We can cast OpContext to ScopeContext because that is the type passed to OnOpcode.
+		stack := scope.(*vm.ScopeContext).Stack
+		if len(stack.Data()) < 2 {
+			return
+		}
+		callFrameState.pendingPair = &OperandPair{
+			Op:  vm.OpCode(op),
+			Lhs: *stack.Back(0),
+			Rhs: *stack.Back(1),
+		}
+		callFrameState.operationsSincePending = 0
+	case vm.KECCAK256:
+		// Record the preimage of short hashes, such as mapping keys.
+		scopeContext := scope.(*vm.ScopeContext)
+		offset, size := scopeContext.Stack.Back(0), scopeContext.Stack.Back(1)
+		if size.IsUint64() && size.Uint64() > 0 && size.Uint64() <= maxPreimageLength && offset.IsUint64() {
+			memory := scopeContext.Memory.Data()
+			if end := offset.Uint64() + size.Uint64(); end <= uint64(len(memory)) {
+				t.recordPreimage(memory[offset.Uint64():end])
+			}
+		}
+		callFrameState.operationsSincePending++
+	case vm.JUMPI:
+		// If a recent comparison fed this JUMPI, record its operands.
+		if callFrameState.pendingPair != nil && callFrameState.operationsSincePending <= maxJumpiDistance {
+			t.recordOperandPair(*callFrameState.pendingPair)
+		}
+		callFrameState.pendingPair = nil
+	default:
+		callFrameState.operationsSincePending++
+	}
+}
+
+// recordOperandPair adds the provided operand pair to the buffer, unless it was already recorded, its operands are
+// equal, or the buffer is full.
+func (t *CmpLogTracer) recordOperandPair(pair OperandPair) {
+	if pair.Lhs.Eq(&pair.Rhs) || len(t.operandPairs) >= t.maxOperandPairs {
+		return
+	}
+	if _, exists := t.recorded[pair]; exists {
+		return
+	}
+	t.recorded[pair] = struct{}{}
+	t.operandPairs = append(t.operandPairs, pair)
+}
+
+// recordPreimage adds a copy of the provided preimage to the buffer, unless it was already recorded or the buffer is
+// full.
+func (t *CmpLogTracer) recordPreimage(preimage []byte) {
+	if len(t.preimages) >= t.maxOperandPairs {
+		return
+	}
+	if _, exists := t.recordedPreimages[string(preimage)]; exists {
+		return
+	}
+	t.recordedPreimages[string(preimage)] = struct{}{}
+	t.preimages = append(t.preimages, common.CopyBytes(preimage))
+}
+
+// CaptureTxEndSetAdditionalResults can be used to set additional results captured from execution tracing. If this
+// tracer is used during transaction execution (block creation), the results can later be queried from the block.
+// This method will only be called on the added tracer if it implements the extended TestChainTracer interface.
+func (t *CmpLogTracer) CaptureTxEndSetAdditionalResults(results *types.MessageResults) {
+	// Store our tracer results.
+	results.AdditionalResults[cmpLogTracerResultsKey] = t.operandPairs
+	results.AdditionalResults[cmpLogTracerPreimageResultsKey] = t.preimages
+}
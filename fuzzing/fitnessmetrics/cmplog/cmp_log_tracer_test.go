@@ -0,0 +1,182 @@
+package cmplog
+
+import (
+	"context"
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core"
+	coretypes "github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa/chain"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+// executeCmpLogTracer executes a call to a contract with the provided runtime bytecode, with a CmpLogTracer recording
+// up to the provided amount of operand pairs attached.
+// Returns the operand pairs and preimages recorded.
+func executeCmpLogTracer(t *testing.T, maxOperandPairs int, code []byte) ([]OperandPair, [][]byte) {
+	sender := common.HexToAddress("0x10000")
+	contract := common.HexToAddress("0x20000")
+	testChain, err := chain.NewTestChain(context.Background(), coretypes.GenesisAlloc{
+		sender:   {Balance: big.NewInt(1_000_000)},
+		contract: {Balance: big.NewInt(0), Code: code},
+	}, nil)
+	assert.NoError(t, err)
+	defer testChain.Close()
+	tracer := NewCmpLogTracer(maxOperandPairs)
+	testChain.AddTracer(tracer.NativeTracer(), true, false)
+
+	_, err = testChain.PendingBlockCreate()
+	assert.NoError(t, err)
+	err = testChain.PendingBlockAddTx(&core.Message{
+		From:      sender,
+		To:        &contract,
+		Value:     big.NewInt(0),
+		GasLimit:  1_000_000,
+		GasPrice:  big.NewInt(0),
+		GasFeeCap: big.NewInt(0),
+		GasTipCap: big.NewInt(0),
+	})
+	assert.NoError(t, err)
+	messageResults := testChain.PendingBlock().MessageResults[0]
+	assert.EqualValues(t, coretypes.ReceiptStatusSuccessful, messageResults.Receipt.Status)
+	return GetCmpLogTracerResults(messageResults), GetCmpLogTracerPreimages(messageResults)
+}
+
+// appendComparison appends bytecode to the provided bytecode comparing lhs (top of the stack) with rhs using the
+// provided comparison, followed by the provided operations and a JUMPI the comparison's result feeds, which continues
+// at the same location whether it jumps or not.
+func appendComparison(code []byte, lhs byte, rhs byte, op vm.OpCode, operations ...vm.OpCode) []byte {
+	code = append(code, byte(vm.PUSH1), rhs, byte(vm.PUSH1), lhs, byte(op))
+	for _, operation := range operations {
+		code = append(code, byte(operation))
+	}
+	destination := byte(len(code) + 3)
+	return append(code, byte(vm.PUSH1), destination, byte(vm.JUMPI), byte(vm.JUMPDEST))
+}
+
+// TestCmpLogTracerOperandPairs tests that the operands of comparisons are only recorded if the comparison feeds a JUMPI
+// within maxJumpiDistance operations, and that equal or duplicate operands are not recorded.
+func TestCmpLogTracerOperandPairs(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     []byte
+		expected []OperandPair
+	}{
+		{
+			name:     "adjacent",
+			code:     appendComparison(nil, 7, 5, vm.LT),
+			expected: []OperandPair{{Op: vm.LT, Lhs: *uint256.NewInt(7), Rhs: *uint256.NewInt(5)}},
+		},
+		{
+			// solc negates conditions with ISZERO before the JUMPI.
+			name:     "negated",
+			code:     appendComparison(nil, 7, 5, vm.EQ, vm.ISZERO),
+			expected: []OperandPair{{Op: vm.EQ, Lhs: *uint256.NewInt(7), Rhs: *uint256.NewInt(5)}},
+		},
+		{
+			// The comparison is separated from the JUMPI by maxJumpiDistance operations, including the jump destination
+			// being pushed.
+			name:     "furthest",
+			code:     appendComparison(nil, 7, 5, vm.GT, slices.Repeat([]vm.OpCode{vm.JUMPDEST}, maxJumpiDistance-1)...),
+			expected: []OperandPair{{Op: vm.GT, Lhs: *uint256.NewInt(7), Rhs: *uint256.NewInt(5)}},
+		},
+		{
+			name: "too far",
+			code: appendComparison(nil, 7, 5, vm.GT, slices.Repeat([]vm.OpCode{vm.JUMPDEST}, maxJumpiDistance)...),
+		},
+		{
+			// A comparison which does not feed a JUMPI is not recorded.
+			name: "unused",
+			code: []byte{byte(vm.PUSH1), 5, byte(vm.PUSH1), 7, byte(vm.SLT), byte(vm.STOP)},
+		},
+		{
+			// Comparisons of equal operands offer no substitution.
+			name: "equal operands",
+			code: appendComparison(nil, 5, 5, vm.EQ),
+		},
+		{
+			// The same comparison executed twice is recorded once.
+			name:     "duplicate",
+			code:     appendComparison(appendComparison(nil, 7, 5, vm.SGT), 7, 5, vm.SGT),
+			expected: []OperandPair{{Op: vm.SGT, Lhs: *uint256.NewInt(7), Rhs: *uint256.NewInt(5)}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			operandPairs, _ := executeCmpLogTracer(t, 8, test.code)
+			assert.EqualValues(t, test.expected, operandPairs)
+		})
+	}
+
+	// The amount of operand pairs recorded per transaction is limited.
+	code := appendComparison(appendComparison(appendComparison(nil, 7, 5, vm.LT), 7, 5, vm.GT), 9, 5, vm.EQ)
+	operandPairs, _ := executeCmpLogTracer(t, 8, code)
+	assert.Len(t, operandPairs, 3)
+	operandPairs, _ = executeCmpLogTracer(t, 2, code)
+	assert.Len(t, operandPairs, 2)
+	operandPairs, _ = executeCmpLogTracer(t, 1, code)
+	assert.EqualValues(t, []OperandPair{{Op: vm.LT, Lhs: *uint256.NewInt(7), Rhs: *uint256.NewInt(5)}}, operandPairs)
+}
+
+// TestOperandPairSubstitutes tests that an operand of a comparison is substituted with the other operand, and for
+// ordering comparisons also with the values on either side of it.
+func TestOperandPairSubstitutes(t *testing.T) {
+	tests := []struct {
+		pair     OperandPair
+		operand  uint64
+		expected []uint64
+	}{
+		{pair: OperandPair{Op: vm.EQ, Lhs: *uint256.NewInt(7), Rhs: *uint256.NewInt(5)}, operand: 7, expected: []uint64{5}},
+		{pair: OperandPair{Op: vm.EQ, Lhs: *uint256.NewInt(7), Rhs: *uint256.NewInt(5)}, operand: 5, expected: []uint64{7}},
+		{pair: OperandPair{Op: vm.LT, Lhs: *uint256.NewInt(7), Rhs: *uint256.NewInt(5)}, operand: 7, expected: []uint64{5, 6, 4}},
+		{pair: OperandPair{Op: vm.SGT, Lhs: *uint256.NewInt(7), Rhs: *uint256.NewInt(5)}, operand: 5, expected: []uint64{7, 8, 6}},
+		{pair: OperandPair{Op: vm.EQ, Lhs: *uint256.NewInt(7), Rhs: *uint256.NewInt(5)}, operand: 6},
+	}
+	for _, test := range tests {
+		var substitutes []uint64
+		for _, substitute := range test.pair.Substitutes(uint256.NewInt(test.operand)) {
+			substitutes = append(substitutes, substitute.Uint64())
+		}
+		assert.EqualValues(t, test.expected, substitutes)
+	}
+
+	// Values on either side of the other operand wrap around.
+	pair := OperandPair{Op: vm.GT, Lhs: *uint256.NewInt(1), Rhs: *uint256.NewInt(0)}
+	substitutes := pair.Substitutes(uint256.NewInt(1))
+	assert.Len(t, substitutes, 3)
+	assert.True(t, substitutes[2].Eq(new(uint256.Int).SetAllOne()))
+}
+
+// TestCmpLogTracerPreimages tests that short KECCAK256 preimages are recorded once per transaction, while those
+// exceeding maxPreimageLength are not, and that the amount recorded is limited.
+func TestCmpLogTracerPreimages(t *testing.T) {
+	// keccakBytecode returns bytecode hashing the provided amount of bytes of memory holding 0x2a in its first word.
+	keccakBytecode := func(size byte) []byte {
+		return []byte{byte(vm.PUSH1), 0x2a, byte(vm.PUSH1), 0, byte(vm.MSTORE), byte(vm.PUSH1), size, byte(vm.PUSH1), 0, byte(vm.KECCAK256), byte(vm.POP)}
+	}
+	word := common.LeftPadBytes([]byte{0x2a}, 32)
+
+	// Expand the memory to two words, as done when hashing mapping keys along with their slot.
+	code := []byte{byte(vm.PUSH1), 0, byte(vm.PUSH1), 0x20, byte(vm.MSTORE)}
+	for _, size := range []byte{32, 32, 64, maxPreimageLength + 1, 0} {
+		code = append(code, keccakBytecode(size)...)
+	}
+	_, preimages := executeCmpLogTracer(t, 8, code)
+	assert.EqualValues(t, [][]byte{word, append(common.CopyBytes(word), make([]byte, 32)...)}, preimages)
+
+	_, preimages = executeCmpLogTracer(t, 1, code)
+	assert.EqualValues(t, [][]byte{word}, preimages)
+
+	// Recorded preimages are copies of the memory they were read from.
+	tracer := NewCmpLogTracer(8)
+	tracer.OnTxStart(nil, nil, common.Address{})
+	memory := common.CopyBytes(word)
+	tracer.recordPreimage(memory)
+	memory[31] = 0
+	assert.EqualValues(t, [][]byte{word}, tracer.preimages)
+}
@@ -0,0 +1,99 @@
+package fitnessmetrics
+
+import (
+	"sync"
+
+	"github.com/crytic/medusa-geth/common"
+)
+
+// CodeMapBuilderFunc describes a function which builds a map of the provided code (e.g. a branch map) used by a tracer
+// to record a fitness metric over it.
+// Returns the map, and a boolean indicating whether one could be built.
+type CodeMapBuilderFunc[T any] func(code []byte) (T, bool)
+
+// CodeMapRegistry lazily builds and stores the maps of code which is not known to the fuzzer ahead of time, such as
+// contracts deployed by factories during a fuzzing campaign, the first time it executes. It is thread-safe, so that it
+// can be shared by the tracers of all workers, and bounded, so that campaigns deploying unbounded amounts of distinct
+// code do not grow it indefinitely.
+type CodeMapRegistry[T any] struct {
+	// maps describes the maps built, by the lookup hash of the code they were built for.
+	maps map[common.Hash]T
+
+	// excluded describes the lookup hashes of code which is never registered, such as excluded contracts, or code a
+	// map could not be built for.
+	excluded map[common.Hash]struct{}
+
+	// build describes the function used to build the map of code executed for the first time.
+	build CodeMapBuilderFunc[T]
+
+	// maxSize describes the maximum amount of maps built. Once reached, code executed for the first time is not
+	// registered.
+	maxSize int
+
+	// lock provides thread synchronization to prevent concurrent access errors.
+	lock sync.RWMutex
+}
+
+// NewCodeMapRegistry returns a new, empty CodeMapRegistry, which builds maps with the provided function, up to the
+// provided maximum amount of maps.
+func NewCodeMapRegistry[T any](maxSize int, build CodeMapBuilderFunc[T]) *CodeMapRegistry[T] {
+	return &CodeMapRegistry[T]{
+		maps:     make(map[common.Hash]T),
+		excluded: make(map[common.Hash]struct{}),
+		build:    build,
+		maxSize:  maxSize,
+	}
+}
+
+// Exclude prevents a map from being built for the code with the provided lookup hash.
+func (r *CodeMapRegistry[T]) Exclude(lookupHash common.Hash) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.excluded[lookupHash] = struct{}{}
+}
+
+// Get obtains the map of the provided code, with the provided lookup hash, building it if this is the first time it is
+// requested. This is safe to call on a nil registry, which registers nothing.
+// Returns the map, and a boolean indicating whether the code is registered.
+func (r *CodeMapRegistry[T]) Get(lookupHash common.Hash, code []byte) (T, bool) {
+	var codeMap T
+	if r == nil {
+		return codeMap, false
+	}
+
+	// Try to obtain the map if it was built already.
+	r.lock.RLock()
+	codeMap, ok := r.maps[lookupHash]
+	_, excluded := r.excluded[lookupHash]
+	full := len(r.maps) >= r.maxSize
+	r.lock.RUnlock()
+	if ok || excluded || full {
+		return codeMap, ok
+	}
+
+	// Otherwise, build it, unless another tracer did so in the meantime.
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if codeMap, ok = r.maps[lookupHash]; ok {
+		return codeMap, true
+	}
+	if _, excluded = r.excluded[lookupHash]; excluded || len(r.maps) >= r.maxSize {
+		return codeMap, false
+	}
+	if codeMap, ok = r.build(code); !ok {
+		r.excluded[lookupHash] = struct{}{}
+		return codeMap, false
+	}
+	r.maps[lookupHash] = codeMap
+	return codeMap, true
+}
+
+// Len returns the amount of maps built.
+func (r *CodeMapRegistry[T]) Len() int {
+	if r == nil {
+		return 0
+	}
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return len(r.maps)
+}
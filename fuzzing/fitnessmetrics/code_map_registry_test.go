@@ -0,0 +1,51 @@
+package fitnessmetrics
+
+import (
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCodeMapRegistry ensures maps are built once per lookup hash, never for excluded code or code they could not be
+// built for, and no longer once the registry is full.
+func TestCodeMapRegistry(t *testing.T) {
+	builds := 0
+	registry := NewCodeMapRegistry(2, func(code []byte) (int, bool) {
+		builds++
+		return len(code), len(code) > 0
+	})
+	registry.Exclude(common.Hash{0x3})
+
+	// The map of code is built upon its first request only.
+	codeMap, ok := registry.Get(common.Hash{0x1}, []byte{0x1, 0x2})
+	assert.True(t, ok)
+	assert.EqualValues(t, 2, codeMap)
+	codeMap, ok = registry.Get(common.Hash{0x1}, []byte{0x1, 0x2})
+	assert.True(t, ok)
+	assert.EqualValues(t, 2, codeMap)
+	assert.EqualValues(t, 1, builds)
+
+	// Excluded code, or code a map could not be built for, is not registered, nor built again.
+	_, ok = registry.Get(common.Hash{0x3}, []byte{0x1})
+	assert.False(t, ok)
+	_, ok = registry.Get(common.Hash{0x4}, []byte{})
+	assert.False(t, ok)
+	_, ok = registry.Get(common.Hash{0x4}, []byte{})
+	assert.False(t, ok)
+	assert.EqualValues(t, 2, builds)
+
+	// Once full, new code is not registered, while registered code still is.
+	_, ok = registry.Get(common.Hash{0x5}, []byte{0x1})
+	assert.True(t, ok)
+	_, ok = registry.Get(common.Hash{0x6}, []byte{0x1})
+	assert.False(t, ok)
+	_, ok = registry.Get(common.Hash{0x1}, nil)
+	assert.True(t, ok)
+	assert.EqualValues(t, 2, registry.Len())
+
+	// A nil registry registers nothing.
+	var nilRegistry *CodeMapRegistry[int]
+	_, ok = nilRegistry.Get(common.Hash{0x1}, []byte{0x1})
+	assert.False(t, ok)
+}
@@ -0,0 +1,151 @@
+package codecoverage
+
+import (
+	"encoding/json"
+
+	"github.com/crytic/medusa-geth/common"
+)
+
+// contractCoverageMapJSON describes the serialized form of a ContractCoverageMap, used to exchange coverage between
+// fuzzer instances. Only the covered program counters are listed, as coverage deltas are typically sparse.
+type contractCoverageMapJSON struct {
+	CodeHash          common.Hash    `json:"codeHash"`
+	CodeAddress       common.Address `json:"codeAddress"`
+	CodeSize          int            `json:"codeSize"`
+	InstructionLength int            `json:"instructionLength"`
+	CoveredPcs        []int          `json:"coveredPcs"`
+}
+
+// Delta returns new coverage maps holding the coverage recorded in the current maps which is not recorded in the
+// provided base maps. If no base maps are provided, a copy of the current maps is returned. The returned maps do not
+// reference any data of the current maps, so they can be merged into other maps with Update safely.
+func (cm *CoverageMaps) Delta(base *CoverageMaps) *CoverageMaps {
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+	if base != nil && base != cm {
+		base.lock.RLock()
+		defer base.lock.RUnlock()
+	}
+
+	delta := NewCoverageMaps()
+	for codeHash, mapsByAddress := range cm.maps {
+		for codeAddress, coverageMap := range mapsByAddress {
+			executedFlags := coverageMap.successfulCoverage.executedFlags
+			if len(executedFlags) == 0 {
+				continue
+			}
+
+			// Obtain the flags already recorded by the base maps, if any.
+			var baseFlags []byte
+			if base != nil {
+				if baseCoverageMap, ok := base.maps[codeHash][codeAddress]; ok {
+					baseFlags = baseCoverageMap.successfulCoverage.executedFlags
+				}
+			}
+
+			// Copy the flags which the base maps have not recorded.
+			var deltaFlags []byte
+			for pc, flag := range executedFlags {
+				if flag == 0 || (pc < len(baseFlags) && baseFlags[pc] != 0) {
+					continue
+				}
+				if deltaFlags == nil {
+					deltaFlags = make([]byte, len(executedFlags))
+				}
+				deltaFlags[pc] = 1
+			}
+			if deltaFlags == nil {
+				continue
+			}
+
+			if _, ok := delta.maps[codeHash]; !ok {
+				delta.maps[codeHash] = make(map[common.Address]*ContractCoverageMap)
+			}
+			delta.maps[codeHash][codeAddress] = &ContractCoverageMap{
+				successfulCoverage: &CoverageMapBytecodeData{
+					executedFlags: deltaFlags,
+					instrLen:      coverageMap.successfulCoverage.instrLen,
+				},
+			}
+		}
+	}
+	return delta
+}
+
+// IsEmpty indicates whether the coverage maps record no coverage.
+func (cm *CoverageMaps) IsEmpty() bool {
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+
+	for _, mapsByAddress := range cm.maps {
+		for _, coverageMap := range mapsByAddress {
+			if len(coverageMap.successfulCoverage.executedFlags) > 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// MarshalJSON provides custom JSON marshalling for the coverage maps, listing the covered program counters of each
+// contract.
+func (cm *CoverageMaps) MarshalJSON() ([]byte, error) {
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+
+	serializedMaps := make([]contractCoverageMapJSON, 0)
+	for codeHash, mapsByAddress := range cm.maps {
+		for codeAddress, coverageMap := range mapsByAddress {
+			bytecodeData := coverageMap.successfulCoverage
+			if len(bytecodeData.executedFlags) == 0 {
+				continue
+			}
+			serializedMap := contractCoverageMapJSON{
+				CodeHash:          codeHash,
+				CodeAddress:       codeAddress,
+				CodeSize:          len(bytecodeData.executedFlags),
+				InstructionLength: bytecodeData.instrLen,
+				CoveredPcs:        make([]int, 0),
+			}
+			for pc, flag := range bytecodeData.executedFlags {
+				if flag != 0 {
+					serializedMap.CoveredPcs = append(serializedMap.CoveredPcs, pc)
+				}
+			}
+			serializedMaps = append(serializedMaps, serializedMap)
+		}
+	}
+	return json.Marshal(serializedMaps)
+}
+
+// UnmarshalJSON provides custom JSON unmarshalling for the coverage maps. Program counters outside the code size of
+// their contract are ignored.
+func (cm *CoverageMaps) UnmarshalJSON(b []byte) error {
+	var serializedMaps []contractCoverageMapJSON
+	if err := json.Unmarshal(b, &serializedMaps); err != nil {
+		return err
+	}
+
+	cm.Reset()
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+	for _, serializedMap := range serializedMaps {
+		if serializedMap.CodeSize <= 0 {
+			continue
+		}
+		coverageMap := newContractCoverageMap()
+		coverageMap.successfulCoverage.executedFlags = make([]byte, serializedMap.CodeSize)
+		coverageMap.successfulCoverage.instrLen = serializedMap.InstructionLength
+		for _, pc := range serializedMap.CoveredPcs {
+			if pc >= 0 && pc < serializedMap.CodeSize {
+				coverageMap.successfulCoverage.executedFlags[pc] = 1
+			}
+		}
+
+		if _, ok := cm.maps[serializedMap.CodeHash]; !ok {
+			cm.maps[serializedMap.CodeHash] = make(map[common.Address]*ContractCoverageMap)
+		}
+		cm.maps[serializedMap.CodeHash][serializedMap.CodeAddress] = coverageMap
+	}
+	return nil
+}
@@ -30,6 +30,14 @@ type CoverageMaps struct {
 	// cachedCodeAddress and matches the cachedCodeHash, then this map is used to avoid an expensive lookup into maps.
 	cachedMap *ContractCoverageMap
 
+	// spareMaps describes the ContractCoverageMap objects emptied by Clear, which are reused before new ones are
+	// allocated.
+	spareMaps []*ContractCoverageMap
+
+	// spareMapsByAddress describes the lookups of ContractCoverageMap objects by address emptied by Clear, which are
+	// reused before new ones are allocated.
+	spareMapsByAddress []map[common.Address]*ContractCoverageMap
+
 	// lock is a read-write mutex to offer concurrent thread safety for map accesses.
 	lock sync.RWMutex
 }
@@ -62,6 +70,24 @@ func (cm *CoverageMaps) TotalCodeCoverage(targetAddresses []common.Address) (int
 	return coveredCodeSize, totalCodeSize
 }
 
+// CodeCoverageByLookupHash returns the amount of covered and total instructions of the code identified by each lookup
+// hash, summed across the addresses it was deployed at.
+func (cm *CoverageMaps) CodeCoverageByLookupHash() (map[common.Hash]int, map[common.Hash]int) {
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+
+	coveredCodeSizes := make(map[common.Hash]int)
+	totalCodeSizes := make(map[common.Hash]int)
+	for codeHash, mapsByAddress := range cm.maps {
+		for _, contractCoverageMap := range mapsByAddress {
+			c, t := contractCoverageMap.getCoverageRate()
+			coveredCodeSizes[codeHash] += c
+			totalCodeSizes[codeHash] += t
+		}
+	}
+	return coveredCodeSizes, totalCodeSizes
+}
+
 // NewCoverageMaps initializes a new CoverageMaps object.
 func NewCoverageMaps() *CoverageMaps {
 	maps := &CoverageMaps{}
@@ -80,6 +106,53 @@ func (cm *CoverageMaps) Reset() {
 	cm.cachedMap = nil
 }
 
+// Clear clears the coverage state for the CoverageMaps like Reset, but retains the memory allocated for it, so that
+// coverage recorded afterwards reuses it.
+func (cm *CoverageMaps) Clear() {
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+
+	for _, mapsByAddress := range cm.maps {
+		for _, coverageMap := range mapsByAddress {
+			coverageMap.successfulCoverage.Reset()
+			cm.spareMaps = append(cm.spareMaps, coverageMap)
+		}
+		clear(mapsByAddress)
+		cm.spareMapsByAddress = append(cm.spareMapsByAddress, mapsByAddress)
+	}
+	clear(cm.maps)
+	cm.cachedCodeAddress = common.Address{}
+	cm.cachedCodeHash = common.Hash{}
+	cm.cachedMap = nil
+}
+
+// getMapsByAddress obtains the lookup of ContractCoverageMap objects by address for the provided code hash, creating
+// it if it does not exist. The lock must be held by the caller.
+func (cm *CoverageMaps) getMapsByAddress(codeHash common.Hash) map[common.Address]*ContractCoverageMap {
+	mapsByAddress, codeHashExists := cm.maps[codeHash]
+	if !codeHashExists {
+		if count := len(cm.spareMapsByAddress); count > 0 {
+			mapsByAddress = cm.spareMapsByAddress[count-1]
+			cm.spareMapsByAddress = cm.spareMapsByAddress[:count-1]
+		} else {
+			mapsByAddress = make(map[common.Address]*ContractCoverageMap)
+		}
+		cm.maps[codeHash] = mapsByAddress
+	}
+	return mapsByAddress
+}
+
+// newContractCoverageMap obtains an empty ContractCoverageMap, reusing one emptied by Clear if possible. The lock must
+// be held by the caller.
+func (cm *CoverageMaps) newContractCoverageMap() *ContractCoverageMap {
+	if count := len(cm.spareMaps); count > 0 {
+		coverageMap := cm.spareMaps[count-1]
+		cm.spareMaps = cm.spareMaps[:count-1]
+		return coverageMap
+	}
+	return newContractCoverageMap()
+}
+
 // Equal checks whether two coverage maps are the same. Equality is determined if the keys and values are all the same.
 func (cm *CoverageMaps) Equal(b *CoverageMaps) bool {
 	cm.lock.RLock()
@@ -177,14 +250,11 @@ func (cm *CoverageMaps) Update(coverageMaps *CoverageMaps) (bool, error) {
 	for codeHash, mapsByAddressToMerge := range coverageMaps.maps {
 		for codeAddress, coverageMapToMerge := range mapsByAddressToMerge {
 			// If a coverage map lookup for this code hash doesn't exist, create the mapping.
-			mapsByAddress, codeHashExists := cm.maps[codeHash]
-			if !codeHashExists {
-				mapsByAddress = make(map[common.Address]*ContractCoverageMap)
-				cm.maps[codeHash] = mapsByAddress
-			}
+			mapsByAddress := cm.getMapsByAddress(codeHash)
 
 			// If a coverage map for this address already exists in our current mapping, update it with the one
-			// to merge. If it doesn't exist, set it to the one to merge.
+			// to merge. If it doesn't exist, create it from a copy of the one to merge, as the provided maps may be
+			// cleared and reused by their owner.
 			if existingCoverageMap, codeAddressExists := mapsByAddress[codeAddress]; codeAddressExists {
 				sChanged, err := existingCoverageMap.update(coverageMapToMerge)
 				successCoverageChanged = successCoverageChanged || sChanged
@@ -192,7 +262,11 @@ func (cm *CoverageMaps) Update(coverageMaps *CoverageMaps) (bool, error) {
 					return successCoverageChanged, err
 				}
 			} else {
-				mapsByAddress[codeAddress] = coverageMapToMerge
+				newCoverageMap := cm.newContractCoverageMap()
+				if _, err := newCoverageMap.update(coverageMapToMerge); err != nil {
+					return successCoverageChanged, err
+				}
+				mapsByAddress[codeAddress] = newCoverageMap
 				successCoverageChanged = coverageMapToMerge.successfulCoverage != nil
 			}
 		}
@@ -225,18 +299,14 @@ func (cm *CoverageMaps) SetAt(codeAddress common.Address, codeLookupHash common.
 		coverageMap = cm.cachedMap
 	} else {
 		// If a coverage map lookup for this code hash doesn't exist, create the mapping.
-		mapsByCodeAddress, codeHashExists := cm.maps[codeLookupHash]
-		if !codeHashExists {
-			mapsByCodeAddress = make(map[common.Address]*ContractCoverageMap)
-			cm.maps[codeLookupHash] = mapsByCodeAddress
-		}
+		mapsByCodeAddress := cm.getMapsByAddress(codeLookupHash)
 
 		// Obtain the coverage map for this code address if it already exists. If it does not, create a new one.
 		if existingCoverageMap, codeAddressExists := mapsByCodeAddress[codeAddress]; codeAddressExists {
 			coverageMap = existingCoverageMap
 		} else {
-			coverageMap = newContractCoverageMap()
-			cm.maps[codeLookupHash][codeAddress] = coverageMap
+			coverageMap = cm.newContractCoverageMap()
+			mapsByCodeAddress[codeAddress] = coverageMap
 			addedNewMap = true
 		}
 
@@ -299,6 +369,16 @@ func (cm *ContractCoverageMap) setCoveredAt(codeSize int, instrLen int, pc uint6
 	return cm.successfulCoverage.setCoveredAt(codeSize, instrLen, pc)
 }
 
+// IsCovered checks if a given program counter location was executed successfully within the contract.
+// Returns a boolean indicating if the program counter was executed on this map.
+func (cm *ContractCoverageMap) IsCovered(pc uint64) bool {
+	// If the contract coverage map is nil, the contract was never executed.
+	if cm == nil {
+		return false
+	}
+	return cm.successfulCoverage.IsCovered(int(pc))
+}
+
 // getCoverageRate returns the covered code size and the total code size of the contract.
 func (cm *ContractCoverageMap) getCoverageRate() (int, int) {
 	return cm.successfulCoverage.getCoverageRate()
@@ -315,9 +395,10 @@ type CoverageMapBytecodeData struct {
 	instrLen      int
 }
 
-// Reset resets the bytecode coverage map data to be empty.
+// Reset resets the bytecode coverage map data to be empty. The buffer holding the execution flags is retained, so
+// that coverage recorded afterwards reuses it.
 func (cm *CoverageMapBytecodeData) Reset() {
-	cm.executedFlags = nil
+	cm.executedFlags = cm.executedFlags[:0]
 }
 
 // Equal checks whether the provided CoverageMapBytecodeData contains the same data as the current one.
@@ -338,7 +419,7 @@ func (cm *CoverageMapBytecodeData) IsCovered(pc int) bool {
 	}
 
 	// If this map has no execution data or is out of bounds, it is not covered.
-	if cm.executedFlags == nil || len(cm.executedFlags) <= pc {
+	if len(cm.executedFlags) <= pc {
 		return false
 	}
 
@@ -349,14 +430,14 @@ func (cm *CoverageMapBytecodeData) IsCovered(pc int) bool {
 // update creates updates the current CoverageMapBytecodeData with the provided one.
 // Returns a boolean indicating whether new coverage was achieved, or an error if one was encountered.
 func (cm *CoverageMapBytecodeData) update(coverageMap *CoverageMapBytecodeData) (bool, error) {
-	// If the coverage map execution data provided is nil, exit early
-	if coverageMap.executedFlags == nil {
+	// If the coverage map execution data provided is empty, exit early
+	if len(coverageMap.executedFlags) == 0 {
 		return false, nil
 	}
 
-	// If the current map has no execution data, simply set it to the provided one.
-	if cm.executedFlags == nil {
-		cm.executedFlags = coverageMap.executedFlags
+	// If the current map has no execution data, simply copy the provided one.
+	if len(cm.executedFlags) == 0 {
+		cm.executedFlags = append(cm.executedFlags[:0], coverageMap.executedFlags...)
 		cm.instrLen = coverageMap.instrLen
 		return true, nil
 	}
@@ -375,9 +456,9 @@ func (cm *CoverageMapBytecodeData) update(coverageMap *CoverageMapBytecodeData)
 // setCoveredAt sets the coverage state at a given program counter location within a CoverageMapBytecodeData.
 // Returns a boolean indicating whether new coverage was achieved, or an error if one occurred.
 func (cm *CoverageMapBytecodeData) setCoveredAt(codeSize int, instrLen int, pc uint64) (bool, error) {
-	// If the execution flags don't exist, create them for this code size.
-	if cm.executedFlags == nil {
-		cm.executedFlags = make([]byte, codeSize)
+	// If the execution flags don't exist, create them for this code size, reusing the buffer of previous flags if any.
+	if len(cm.executedFlags) == 0 {
+		cm.executedFlags = utils.SliceResizeZeroed(cm.executedFlags, codeSize)
 		cm.instrLen = instrLen
 	}
 
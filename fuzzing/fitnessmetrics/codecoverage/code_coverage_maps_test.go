@@ -0,0 +1,95 @@
+package codecoverage
+
+import (
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/chain/types"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCoverageMapsUpdateCopies tests that updating maps copies the coverage merged, so the merged maps can be released
+// and reused for another call frame without affecting the updated maps.
+func TestCoverageMapsUpdateCopies(t *testing.T) {
+	pool := fitnessmetrics.NewResultPool(NewCoverageMaps)
+	maps := NewCoverageMaps()
+	frameMaps := pool.Get()
+	_, err := frameMaps.SetAt(common.Address{1}, common.Hash{1}, 4, 4, 0)
+	assert.NoError(t, err)
+
+	changed, err := maps.Update(frameMaps)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	pool.Put(frameMaps)
+
+	// Reuse the released maps for another call frame, which starts out empty.
+	otherFrameMaps := pool.Get()
+	assert.Same(t, frameMaps, otherFrameMaps)
+	covered, total := otherFrameMaps.TotalCodeCoverage(nil)
+	assert.EqualValues(t, 0, covered)
+	assert.EqualValues(t, 0, total)
+	_, err = otherFrameMaps.SetAt(common.Address{1}, common.Hash{1}, 4, 4, 2)
+	assert.NoError(t, err)
+
+	contractMap := maps.maps[common.Hash{1}][common.Address{1}]
+	assert.True(t, contractMap.IsCovered(0))
+	assert.False(t, contractMap.IsCovered(2))
+	changed, err = maps.Update(otherFrameMaps)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.True(t, contractMap.IsCovered(2))
+}
+
+// TestCoverageTracerReleaseResults tests that the tracer only releases the coverage maps of its latest transaction from
+// the message results they were stored in, and reuses them once released.
+func TestCoverageTracerReleaseResults(t *testing.T) {
+	tracer := &CoverageTracer{resultPool: fitnessmetrics.NewResultPool(NewCoverageMaps)}
+	tracer.coverageMaps = tracer.resultPool.Get()
+	coverageMaps := tracer.coverageMaps
+	_, err := coverageMaps.SetAt(common.Address{1}, common.Hash{1}, 4, 4, 0)
+	assert.NoError(t, err)
+	results := &types.MessageResults{AdditionalResults: make(map[string]any)}
+	tracer.CaptureTxEndSetAdditionalResults(results)
+
+	// Results stored in other message results are not released.
+	tracer.ReleaseResults(&types.MessageResults{AdditionalResults: make(map[string]any)})
+	assert.Same(t, coverageMaps, GetCoverageTracerResults(results))
+
+	tracer.ReleaseResults(results)
+	assert.Nil(t, GetCoverageTracerResults(results))
+	assert.Nil(t, tracer.coverageMaps)
+	assert.NotPanics(t, func() { tracer.ReleaseResults(results) })
+	assert.NotPanics(t, func() { (*CoverageTracer)(nil).ReleaseResults(results) })
+
+	// The released maps are cleared and reused for the next transaction.
+	assert.Same(t, coverageMaps, tracer.resultPool.Get())
+	covered, _ := coverageMaps.TotalCodeCoverage(nil)
+	assert.EqualValues(t, 0, covered)
+}
+
+// BenchmarkCoverageMapsPerCallFrame measures recording the coverage of a call frame and merging it into that of its
+// transaction, with maps allocated for each call frame.
+func BenchmarkCoverageMapsPerCallFrame(b *testing.B) {
+	transactionMaps := NewCoverageMaps()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		frameMaps := NewCoverageMaps()
+		_, _ = frameMaps.SetAt(common.Address{1}, common.Hash{1}, 1024, 1024, uint64(i%1024))
+		_, _ = transactionMaps.Update(frameMaps)
+	}
+}
+
+// BenchmarkCoverageMapsResultPool measures recording the coverage of a call frame and merging it into that of its
+// transaction, with maps recycled through a fitnessmetrics.ResultPool as done by the tracer.
+func BenchmarkCoverageMapsResultPool(b *testing.B) {
+	pool := fitnessmetrics.NewResultPool(NewCoverageMaps)
+	transactionMaps := NewCoverageMaps()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		frameMaps := pool.Get()
+		_, _ = frameMaps.SetAt(common.Address{1}, common.Hash{1}, 1024, 1024, uint64(i%1024))
+		_, _ = transactionMaps.Update(frameMaps)
+		pool.Put(frameMaps)
+	}
+}
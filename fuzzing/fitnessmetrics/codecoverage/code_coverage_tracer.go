@@ -13,6 +13,7 @@ import (
 	"github.com/crytic/medusa/chain/types"
 	compilationTypes "github.com/crytic/medusa/compilation/types"
 	fuzzerTypes "github.com/crytic/medusa/fuzzing/contracts"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics"
 	"github.com/crytic/medusa/logging"
 )
 
@@ -48,6 +49,12 @@ type CoverageTracer struct {
 	// callFrameStates describes the state tracked by the tracer per call frame.
 	callFrameStates []*coverageTracerCallFrameState
 
+	// resultPool recycles the coverage maps recorded for each transaction and call frame.
+	resultPool *fitnessmetrics.ResultPool[*CoverageMaps]
+
+	// lastResults describes the message results the coverage maps of the latest transaction were stored in.
+	lastResults *types.MessageResults
+
 	// callDepth refers to the current EVM depth during tracing.
 	callDepth int
 
@@ -60,8 +67,15 @@ type CoverageTracer struct {
 	// instrMaps describes the instruction maps for each contract.
 	instrMaps map[common.Hash]*InstrMap
 
+	// lookupHashAliases maps the lookup hashes of code deployed dynamically, which differs from the compiled contract
+	// it matched (e.g. due to immutables or constructor arguments), to the lookup hash of the compiled contract.
+	lookupHashAliases map[common.Hash]common.Hash
+
 	// initialContractsSet records the set of contract addresses present in the base chain.
 	initialContractsSet *map[common.Address]struct{}
+
+	// exclusions describes the contracts which are not traced, or nil if none are excluded.
+	exclusions *fitnessmetrics.MetricExclusions
 }
 
 // coverageTracerCallFrameState tracks state across call frames in the tracer.
@@ -77,6 +91,9 @@ type coverageTracerCallFrameState struct {
 	// lookupHash describes the hash used to look up the ContractCoverageMap being updated in this frame.
 	lookupHash *common.Hash
 
+	// excluded indicates whether the code executing in this frame is excluded from tracing.
+	excluded bool
+
 	// address is used by OnOpcode to cache the result of scope.Address(), which is slow.
 	// It records the address of the current contract.
 	address common.Address
@@ -103,15 +120,17 @@ func NewCoverageTracer(contracts fuzzerTypes.Contracts) *CoverageTracer {
 		}
 
 		runtimeBytecodeHash := getContractCoverageMapHash(runtimeBytecode, false)
-		// remove metadata from runtime bytecode
-		runtimeBytecode = compilationTypes.RemoveContractMetadata(runtimeBytecode)
+		// remove metadata and data sections from runtime bytecode
+		runtimeBytecode, _ = compilationTypes.SplitRuntimeBytecode(compiledContract.InitBytecode, runtimeBytecode)
 		instrMaps[runtimeBytecodeHash] = GetInstrMapFromBytecode(runtimeBytecode)
 	}
 
 	tracer := &CoverageTracer{
-		coverageMaps:    NewCoverageMaps(),
-		callFrameStates: make([]*coverageTracerCallFrameState, 0),
-		instrMaps:       instrMaps,
+		coverageMaps:      NewCoverageMaps(),
+		callFrameStates:   make([]*coverageTracerCallFrameState, 0),
+		resultPool:        fitnessmetrics.NewResultPool(NewCoverageMaps),
+		lookupHashAliases: make(map[common.Hash]common.Hash),
+		instrMaps:         instrMaps,
 	}
 	nativeTracer := &tracers.Tracer{
 		Hooks: &tracing.Hooks{
@@ -135,6 +154,39 @@ func (t *CoverageTracer) SetInitialContractsSet(initialContractsSet *map[common.
 	t.initialContractsSet = initialContractsSet
 }
 
+// SetExclusions sets the exclusions value (see above).
+func (t *CoverageTracer) SetExclusions(exclusions *fitnessmetrics.MetricExclusions) {
+	t.exclusions = exclusions
+}
+
+// RegisterDeployedContract registers the init and runtime bytecode of a contract deployed dynamically (e.g. by a
+// factory), which was matched to the provided contract definition, so that its coverage is recorded with that of the
+// definition's compiled bytecode. This is a no-op if the tracer is nil, or if the definition is not traced.
+func (t *CoverageTracer) RegisterDeployedContract(contract *fuzzerTypes.Contract, initBytecode []byte, runtimeBytecode []byte) {
+	if t == nil {
+		return
+	}
+	compiledContract := contract.CompiledContract()
+	t.registerLookupHashAlias(initBytecode, compiledContract.InitBytecode, true)
+	t.registerLookupHashAlias(runtimeBytecode, compiledContract.RuntimeBytecode, false)
+}
+
+// registerLookupHashAlias maps the lookup hash of the provided deployed bytecode to that of the compiled bytecode it
+// was matched to, if they differ and the compiled bytecode is traced.
+func (t *CoverageTracer) registerLookupHashAlias(deployedBytecode []byte, compiledBytecode []byte, init bool) {
+	if len(deployedBytecode) == 0 || len(compiledBytecode) == 0 {
+		return
+	}
+	lookupHash := getContractCoverageMapHash(deployedBytecode, init)
+	if _, exists := t.instrMaps[lookupHash]; exists {
+		return
+	}
+	compiledLookupHash := getContractCoverageMapHash(compiledBytecode, init)
+	if _, exists := t.instrMaps[compiledLookupHash]; exists {
+		t.lookupHashAliases[lookupHash] = compiledLookupHash
+	}
+}
+
 // BLANK_ADDRESS is an all-zero address; it's a global var so that we don't have to recalculate (and reallocate) it every time.
 var BLANK_ADDRESS = common.BytesToAddress([]byte{})
 
@@ -157,8 +209,8 @@ func (t *CoverageTracer) addressForCoverage(address common.Address) common.Addre
 func (t *CoverageTracer) OnTxStart(vm *tracing.VMContext, tx *coretypes.Transaction, from common.Address) {
 	// Reset our call frame states
 	t.callDepth = 0
-	t.coverageMaps = NewCoverageMaps()
-	t.callFrameStates = make([]*coverageTracerCallFrameState, 0)
+	t.coverageMaps = t.resultPool.Get()
+	t.callFrameStates = t.callFrameStates[:0]
 	t.evmContext = vm
 }
 
@@ -171,7 +223,7 @@ func (t *CoverageTracer) OnEnter(depth int, typ byte, from common.Address, to co
 	// Create our state tracking struct for this frame.
 	t.callFrameStates = append(t.callFrameStates, &coverageTracerCallFrameState{
 		create:             typ == byte(vm.CREATE) || typ == byte(vm.CREATE2),
-		pendingCoverageMap: NewCoverageMaps(),
+		pendingCoverageMap: t.resultPool.Get(),
 	})
 }
 
@@ -204,6 +256,9 @@ func (t *CoverageTracer) OnExit(depth int, output []byte, gasUsed uint64, err er
 	if coverageUpdateErr != nil {
 		logging.GlobalLogger.Panic("Coverage tracer failed to update coverage map during capture end", coverageUpdateErr)
 	}
+
+	// The coverage of this call frame was copied up, so its maps can be reused.
+	t.resultPool.Put(currentCoverageMap)
 }
 
 // OnOpcode records data from an EVM state update, as defined by tracers.Tracer.
@@ -211,18 +266,27 @@ func (t *CoverageTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope tr
 	// Obtain our call frame state tracking struct
 	callFrameState := t.callFrameStates[t.callDepth]
 
+	scopeContext := scope.(*vm.ScopeContext)
+
 	if !callFrameState.initialized {
 		callFrameState.initialized = true
 		callFrameState.address = scope.Address()
+		callFrameState.excluded = t.exclusions.Excludes(callFrameState.address, scopeContext.Contract.CodeHash)
 	}
 
-	scopeContext := scope.(*vm.ScopeContext)
+	// If the code executing is excluded, do not collect coverage.
+	if callFrameState.excluded {
+		return
+	}
 
 	// If there is code we're executing, collect coverage.
 	if len(scopeContext.Contract.Code) > 0 {
 		// Obtain our contract coverage map lookup hash.
 		if callFrameState.lookupHash == nil {
 			lookupHash := getContractCoverageMapHash(scopeContext.Contract.Code, callFrameState.create)
+			if alias, ok := t.lookupHashAliases[lookupHash]; ok {
+				lookupHash = alias
+			}
 			callFrameState.lookupHash = &lookupHash
 		}
 
@@ -247,4 +311,19 @@ func (t *CoverageTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope tr
 func (t *CoverageTracer) CaptureTxEndSetAdditionalResults(results *types.MessageResults) {
 	// Store our tracer results.
 	results.AdditionalResults[coverageTracerResultsKey] = t.coverageMaps
+	t.lastResults = results
+}
+
+// ReleaseResults releases the coverage maps of the latest transaction for reuse, if they were stored in the provided
+// message results, as defined by fitnessmetrics.ResultReleaser. They are removed from the message results.
+func (t *CoverageTracer) ReleaseResults(results *types.MessageResults) {
+	if t == nil || t.coverageMaps == nil || results != t.lastResults {
+		return
+	}
+	if GetCoverageTracerResults(results) == t.coverageMaps {
+		RemoveCoverageTracerResults(results)
+	}
+	t.resultPool.Put(t.coverageMaps)
+	t.coverageMaps = nil
+	t.lastResults = nil
 }
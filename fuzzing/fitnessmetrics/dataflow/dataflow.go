@@ -59,3 +59,62 @@ func (df *Dataflow) String() string {
 
 	return sb.String()
 }
+
+// OutflowSink describes the kind of operation a value read from storage flows out of a contract through.
+type OutflowSink byte
+
+const (
+	// EventOutflowSink describes a value read from storage being emitted as an event topic or data.
+	EventOutflowSink OutflowSink = iota
+	// CallOutflowSink describes a value read from storage being passed as an external call argument.
+	CallOutflowSink
+)
+
+// String returns a human-readable name for the OutflowSink.
+func (s OutflowSink) String() string {
+	if s == EventOutflowSink {
+		return "event"
+	}
+	return "call"
+}
+
+// Outflow describes a value read from a storage slot which is observed externally, by being emitted in an event or
+// passed to an external call.
+type Outflow struct {
+	Read     *ProgramPosition
+	Variable *StorageSlot
+	Sink     OutflowSink
+	// SinkPosition describes the position of the LOG or CALL operation the value flows into.
+	SinkPosition *ProgramPosition
+}
+
+func (of *Outflow) String() string {
+	var sb strings.Builder
+
+	sb.WriteString(of.Variable.String())
+	sb.WriteString("-")
+	sb.WriteString(of.Read.String())
+	sb.WriteString("-")
+	sb.WriteString(of.Sink.String())
+	sb.WriteString("-")
+	sb.WriteString(of.SinkPosition.String())
+
+	return sb.String()
+}
+
+// UninitializedRead describes a read of a declared state variable which was never written in the call sequence and
+// was still zero, which may indicate configuration which was never initialized.
+type UninitializedRead struct {
+	Read     *ProgramPosition
+	Variable *StorageSlot
+}
+
+func (ur *UninitializedRead) String() string {
+	var sb strings.Builder
+
+	sb.WriteString(ur.Variable.String())
+	sb.WriteString("-")
+	sb.WriteString(ur.Read.String())
+
+	return sb.String()
+}
@@ -0,0 +1,202 @@
+package dataflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/utils"
+)
+
+// dataflowGraphFileName describes the name of the file (without extension) a DataflowGraph is written to.
+const dataflowGraphFileName = "dataflow_graph"
+
+// DataflowGraphNode describes a program position in a DataflowGraph, which writes, reads, emits or calls.
+type DataflowGraphNode struct {
+	// Id uniquely identifies the node in the graph.
+	Id string `json:"id"`
+	// Address describes the address of the code the program position is in.
+	Address string `json:"address"`
+	// Create indicates whether Pc is in the init bytecode.
+	Create bool `json:"create"`
+	// Pc describes the program counter of the operation.
+	Pc uint64 `json:"pc"`
+}
+
+// DataflowGraphEdge describes a flow of a storage slot's value between two nodes of a DataflowGraph.
+type DataflowGraphEdge struct {
+	// From describes the id of the node writing the slot (or reading it, for outflows).
+	From string `json:"from"`
+	// To describes the id of the node reading the slot (or emitting or calling with its value, for outflows).
+	To string `json:"to"`
+	// Address describes the address of the contract whose storage the slot belongs to.
+	Address string `json:"address"`
+	// Slot describes the storage slot the value flows through.
+	Slot string `json:"slot"`
+	// Kind describes the kind of flow: "storage" for a write to a read, or the OutflowSink for outflows.
+	Kind string `json:"kind"`
+	// Variable describes the name of the state variable held in the slot, if it is known (see NameVariables).
+	Variable string `json:"variable,omitempty"`
+}
+
+// DataflowGraph describes the dataflow recorded in a DataflowSet as a graph, whose nodes are program positions and
+// whose edges are flows over storage slots.
+type DataflowGraph struct {
+	Nodes []DataflowGraphNode `json:"nodes"`
+	Edges []DataflowGraphEdge `json:"edges"`
+}
+
+// Export returns the dataflow and outflows recorded in the DataflowSet as a DataflowGraph, sorted so that exporting
+// the same set twice yields the same graph.
+func (ds *DataflowSet) Export() *DataflowGraph {
+	ds.lock.RLock()
+	defer ds.lock.RUnlock()
+
+	graph := &DataflowGraph{
+		Nodes: make([]DataflowGraphNode, 0),
+		Edges: make([]DataflowGraphEdge, 0, len(ds.set)+len(ds.outflows)),
+	}
+	nodes := make(map[string]struct{})
+	addNode := func(position *ProgramPosition) string {
+		id := position.String()
+		if _, exists := nodes[id]; !exists {
+			nodes[id] = struct{}{}
+			graph.Nodes = append(graph.Nodes, DataflowGraphNode{
+				Id:      id,
+				Address: position.Address.Hex(),
+				Create:  position.Create,
+				Pc:      position.Pc,
+			})
+		}
+		return id
+	}
+
+	for _, dataflow := range ds.set {
+		graph.Edges = append(graph.Edges, DataflowGraphEdge{
+			From:    addNode(dataflow.Write),
+			To:      addNode(dataflow.Read),
+			Address: dataflow.Variable.Address.Hex(),
+			Slot:    dataflow.Variable.Slot.Hex(),
+			Kind:    "storage",
+		})
+	}
+	for _, outflow := range ds.outflows {
+		graph.Edges = append(graph.Edges, DataflowGraphEdge{
+			From:    addNode(outflow.Read),
+			To:      addNode(outflow.SinkPosition),
+			Address: outflow.Variable.Address.Hex(),
+			Slot:    outflow.Variable.Slot.Hex(),
+			Kind:    outflow.Sink.String(),
+		})
+	}
+
+	sort.Slice(graph.Nodes, func(i, j int) bool {
+		return graph.Nodes[i].Id < graph.Nodes[j].Id
+	})
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		a, b := graph.Edges[i], graph.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		if a.Slot != b.Slot {
+			return a.Slot < b.Slot
+		}
+		return a.Kind < b.Kind
+	})
+	return graph
+}
+
+// NameVariables names the state variables held in the storage slots of the DataflowGraph's edges, using the provided
+// function, which returns the name of the variable held in a slot of the contract at an address, or an empty string
+// if it is unknown.
+func (g *DataflowGraph) NameVariables(variableName func(address common.Address, slot common.Hash) string) {
+	for i, edge := range g.Edges {
+		g.Edges[i].Variable = variableName(common.HexToAddress(edge.Address), common.HexToHash(edge.Slot))
+	}
+}
+
+// DOT returns the DataflowGraph in the Graphviz DOT language. Nodes are grouped into a cluster per code address, and
+// outflow edges are dashed.
+func (g *DataflowGraph) DOT() string {
+	var sb strings.Builder
+	sb.WriteString("digraph dataflow {\n")
+	sb.WriteString("\tnode [shape=box];\n")
+
+	// Group the nodes by the address of their code, so the graph shows which contracts communicate.
+	clusters := make(map[string][]DataflowGraphNode)
+	addresses := make([]string, 0)
+	for _, node := range g.Nodes {
+		if _, exists := clusters[node.Address]; !exists {
+			addresses = append(addresses, node.Address)
+		}
+		clusters[node.Address] = append(clusters[node.Address], node)
+	}
+	for i, address := range addresses {
+		sb.WriteString(fmt.Sprintf("\tsubgraph cluster_%d {\n", i))
+		sb.WriteString(fmt.Sprintf("\t\tlabel=%q;\n", address))
+		for _, node := range clusters[address] {
+			label := fmt.Sprintf("pc %d", node.Pc)
+			if node.Create {
+				label += " (init)"
+			}
+			sb.WriteString(fmt.Sprintf("\t\t%q [label=%q];\n", node.Id, label))
+		}
+		sb.WriteString("\t}\n")
+	}
+
+	for _, edge := range g.Edges {
+		label := edge.Slot
+		if edge.Variable != "" {
+			label = edge.Variable
+		}
+		attributes := fmt.Sprintf("label=%q", label)
+		if edge.Kind != "storage" {
+			attributes = fmt.Sprintf("label=%q, style=dashed", edge.Kind+" "+label)
+		}
+		sb.WriteString(fmt.Sprintf("\t%q -> %q [%s];\n", edge.From, edge.To, attributes))
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// WriteDataflowGraph writes the provided DataflowGraph to the provided directory, once in each of the provided
+// formats ("dot" or "json").
+// Returns the paths written to, or an error if one occurred.
+func WriteDataflowGraph(graph *DataflowGraph, directory string, formats []string) ([]string, error) {
+	// Create the directory if it does not exist
+	err := utils.MakeDirectory(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(formats))
+	for _, format := range formats {
+		var b []byte
+		switch format {
+		case "dot":
+			b = []byte(graph.DOT())
+		case "json":
+			b, err = json.MarshalIndent(graph, "", "\t")
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unsupported dataflow graph format: %s", format)
+		}
+
+		path := filepath.Join(directory, dataflowGraphFileName+"."+format)
+		err = os.WriteFile(path, b, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write dataflow graph at %v: %v", path, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
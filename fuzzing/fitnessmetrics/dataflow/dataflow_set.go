@@ -8,19 +8,55 @@ import (
 )
 
 type DataflowSet struct {
-	set       map[string]*Dataflow
-	writeMaps map[string]map[string]*ProgramPosition
-	lock      sync.RWMutex
+	set map[string]*Dataflow
+	// revertedSet describes the dataflow recorded in call frames which reverted.
+	revertedSet map[string]*Dataflow
+	writeMaps   map[string]map[string]*ProgramPosition
+	outflows    map[string]*Outflow
+	// uninitializedReads describes the reads of declared state variables never written in the call sequence.
+	uninitializedReads map[string]*UninitializedRead
+	// reads describes the string representation of each StorageSlot read, whether or not a write was paired with it.
+	// It is not accumulated upon updates.
+	reads map[string]struct{}
+	lock  sync.RWMutex
+
+	// seed describes a DataflowSet whose writes are also paired with the reads recorded in this one, so that dataflow
+	// spanning several transactions is recorded. It is not cleared upon Reset.
+	seed *DataflowSet
 }
 
-func (ds *DataflowSet) TotalDataflowCount() int {
+func (ds *DataflowSet) TotalDataflowCount(includeReverted bool) int {
 	ds.lock.RLock()
 	defer ds.lock.RUnlock()
 
 	count := len(ds.set)
+	if includeReverted {
+		for key := range ds.revertedSet {
+			if _, exists := ds.set[key]; !exists {
+				count++
+			}
+		}
+	}
 	return count
 }
 
+// TotalOutflowCount returns the amount of distinct outflows of storage values into events and external calls recorded.
+func (ds *DataflowSet) TotalOutflowCount() int {
+	ds.lock.RLock()
+	defer ds.lock.RUnlock()
+
+	return len(ds.outflows)
+}
+
+// TotalUninitializedReadCount returns the amount of distinct reads of state variables never written in their call
+// sequence recorded.
+func (ds *DataflowSet) TotalUninitializedReadCount() int {
+	ds.lock.RLock()
+	defer ds.lock.RUnlock()
+
+	return len(ds.uninitializedReads)
+}
+
 // NewDataflowSet initializes a new DataflowSet object.
 func NewDataflowSet() *DataflowSet {
 	maps := &DataflowSet{}
@@ -31,15 +67,41 @@ func NewDataflowSet() *DataflowSet {
 // Reset clears the dataflow state for the DataflowSet.
 func (ds *DataflowSet) Reset() {
 	ds.set = make(map[string]*Dataflow)
+	ds.revertedSet = make(map[string]*Dataflow)
 	ds.writeMaps = make(map[string]map[string]*ProgramPosition)
+	ds.outflows = make(map[string]*Outflow)
+	ds.uninitializedReads = make(map[string]*UninitializedRead)
+	ds.reads = make(map[string]struct{})
+}
+
+// Clear clears the dataflow state for the DataflowSet like Reset, but retains the memory allocated for it, so that
+// dataflow recorded afterwards reuses it. The seed is not cleared, as with Reset.
+func (ds *DataflowSet) Clear() {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+
+	clear(ds.set)
+	clear(ds.revertedSet)
+	clear(ds.writeMaps)
+	clear(ds.outflows)
+	clear(ds.uninitializedReads)
+	clear(ds.reads)
 }
 
 // Update updates the current dataflow set with the provided ones.
-// Returns two booleans indicating whether dataflow increased, or an error if one occurred.
+// Returns a boolean indicating whether dataflow increased, or an error if one occurred.
 func (ds *DataflowSet) Update(dataflowSet *DataflowSet) (bool, error) {
+	updated, _, err := ds.UpdateWithReverted(dataflowSet)
+	return updated, err
+}
+
+// UpdateWithReverted updates the current dataflow set with the provided ones.
+// Returns two booleans indicating whether dataflow increased, and whether reverted dataflow which was never successful
+// increased, or an error if one occurred.
+func (ds *DataflowSet) UpdateWithReverted(dataflowSet *DataflowSet) (bool, bool, error) {
 	// If our maps provided are nil, do nothing
 	if dataflowSet == nil {
-		return false, nil
+		return false, false, nil
 	}
 
 	// Acquire our thread lock and defer our unlocking for when we exit this method
@@ -47,6 +109,7 @@ func (ds *DataflowSet) Update(dataflowSet *DataflowSet) (bool, error) {
 	defer ds.lock.Unlock()
 
 	updated := false
+	revertedUpdated := false
 
 	for key, dataflow := range dataflowSet.set {
 		if _, exists := ds.set[key]; !exists {
@@ -55,7 +118,50 @@ func (ds *DataflowSet) Update(dataflowSet *DataflowSet) (bool, error) {
 		}
 	}
 
-	return updated, nil
+	for key, dataflow := range dataflowSet.revertedSet {
+		if _, exists := ds.revertedSet[key]; !exists {
+			ds.revertedSet[key] = dataflow
+			_, successful := ds.set[key]
+			revertedUpdated = revertedUpdated || !successful
+		}
+	}
+
+	for key, outflow := range dataflowSet.outflows {
+		if _, exists := ds.outflows[key]; !exists {
+			ds.outflows[key] = outflow
+			updated = true
+		}
+	}
+
+	for key, uninitializedRead := range dataflowSet.uninitializedReads {
+		if _, exists := ds.uninitializedReads[key]; !exists {
+			ds.uninitializedReads[key] = uninitializedRead
+			updated = true
+		}
+	}
+
+	// Accumulate the writes too, so they may seed the writes of later transactions. New writes alone do not count as
+	// an update, as they only matter once read.
+	for variable, writes := range dataflowSet.writeMaps {
+		writeMaps := ds.writeMaps[variable]
+		if writeMaps == nil {
+			writeMaps = make(map[string]*ProgramPosition, len(writes))
+			ds.writeMaps[variable] = writeMaps
+		}
+		for writeStr, write := range writes {
+			writeMaps[writeStr] = write
+		}
+	}
+
+	return updated, revertedUpdated, nil
+}
+
+// SetSeed sets the DataflowSet whose writes are paired with the reads recorded in this one (see above). Providing nil
+// removes the seed.
+func (ds *DataflowSet) SetSeed(seed *DataflowSet) {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+	ds.seed = seed
 }
 
 func (ds *DataflowSet) SetWrite(storageAddress common.Address, slot *uint256.Int, codeAddress common.Address, create bool, pc uint64) (bool, error) {
@@ -94,18 +200,30 @@ func (ds *DataflowSet) SetRead(storageAddress common.Address, slot *uint256.Int,
 		Address: storageAddress,
 		Slot:    slot,
 	}
-	writeMaps := ds.writeMaps[variable.String()]
-	if writeMaps == nil {
-		return false, nil
-	}
-
-	updated := false
-
 	read := &ProgramPosition{
 		Address: codeAddress,
 		Create:  create,
 		Pc:      pc,
 	}
+	ds.reads[variable.String()] = struct{}{}
+	updated := ds.pairRead(ds.writeMaps[variable.String()], read, variable)
+
+	// Pair the read with the writes of previous transactions too, if we were seeded with them.
+	if ds.seed != nil {
+		ds.seed.lock.RLock()
+		seedUpdated := ds.pairRead(ds.seed.writeMaps[variable.String()], read, variable)
+		ds.seed.lock.RUnlock()
+		updated = seedUpdated || updated
+	}
+
+	return updated, nil
+}
+
+// pairRead records the dataflow from each of the provided writes to the provided read of the provided variable. The
+// lock must be held by the caller.
+// Returns whether any dataflow was newly recorded.
+func (ds *DataflowSet) pairRead(writeMaps map[string]*ProgramPosition, read *ProgramPosition, variable *StorageSlot) bool {
+	updated := false
 	for _, write := range writeMaps {
 		dataflow := &Dataflow{
 			Write:    write,
@@ -118,12 +236,85 @@ func (ds *DataflowSet) SetRead(storageAddress common.Address, slot *uint256.Int,
 			updated = true
 		}
 	}
+	return updated
+}
 
-	return updated, nil
+// ReadVariables returns the string representation of each StorageSlot read, as recorded by a single transaction.
+func (ds *DataflowSet) ReadVariables() []string {
+	ds.lock.RLock()
+	defer ds.lock.RUnlock()
+
+	variables := make([]string, 0, len(ds.reads))
+	for variable := range ds.reads {
+		variables = append(variables, variable)
+	}
+	return variables
+}
+
+// WrittenVariables returns the string representation of each StorageSlot written.
+func (ds *DataflowSet) WrittenVariables() []string {
+	ds.lock.RLock()
+	defer ds.lock.RUnlock()
+
+	variables := make([]string, 0, len(ds.writeMaps))
+	for variable := range ds.writeMaps {
+		variables = append(variables, variable)
+	}
+	return variables
 }
 
+// SetOutflow records a value read from the provided variable at the provided read position flowing into the provided
+// sink (an event or external call) at the provided sink position.
+// Returns whether the outflow was newly recorded, or an error if one occurred.
+func (ds *DataflowSet) SetOutflow(read *ProgramPosition, variable *StorageSlot, sink OutflowSink, sinkPosition *ProgramPosition) (bool, error) {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+
+	outflow := &Outflow{
+		Read:         read,
+		Variable:     variable,
+		Sink:         sink,
+		SinkPosition: sinkPosition,
+	}
+	outflowStr := outflow.String()
+	if _, exists := ds.outflows[outflowStr]; !exists {
+		ds.outflows[outflowStr] = outflow
+		return true, nil
+	}
+	return false, nil
+}
+
+// SetUninitializedRead records a read of the provided variable at the provided position, which was never written in
+// the call sequence.
+// Returns whether the uninitialized read was newly recorded, or an error if one occurred.
+func (ds *DataflowSet) SetUninitializedRead(read *ProgramPosition, variable *StorageSlot) (bool, error) {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+
+	uninitializedRead := &UninitializedRead{
+		Read:     read,
+		Variable: variable,
+	}
+	uninitializedReadStr := uninitializedRead.String()
+	if _, exists := ds.uninitializedReads[uninitializedReadStr]; !exists {
+		ds.uninitializedReads[uninitializedReadStr] = uninitializedRead
+		return true, nil
+	}
+	return false, nil
+}
+
+// RevertAll sets all dataflow in the set as reverted dataflow. Reverted dataflow set is updated with the dataflow set,
+// which is cleared along with the writes, outflows and uninitialized reads, as they did not happen.
 func (ds *DataflowSet) RevertAll() {
 	ds.lock.Lock()
 	defer ds.lock.Unlock()
-	ds.Reset()
+
+	for key, dataflow := range ds.set {
+		ds.revertedSet[key] = dataflow
+	}
+	clear(ds.set)
+	clear(ds.writeMaps)
+	clear(ds.outflows)
+	clear(ds.uninitializedReads)
+	clear(ds.reads)
 }
@@ -0,0 +1,100 @@
+package dataflow
+
+import (
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/chain/types"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+// setDataflow records a write to the provided slot followed by a read of it in the provided dataflow set.
+func setDataflow(t testing.TB, dataflowSet *DataflowSet, slot uint64) {
+	_, err := dataflowSet.SetWrite(common.Address{1}, uint256.NewInt(slot), common.Address{1}, false, 1)
+	assert.NoError(t, err)
+	_, err = dataflowSet.SetRead(common.Address{1}, uint256.NewInt(slot), common.Address{1}, false, 2)
+	assert.NoError(t, err)
+}
+
+// TestDataflowSetUpdateCopies tests that updating a set copies the dataflow and writes merged, so the merged set can be
+// released and reused for another transaction without affecting the updated set.
+func TestDataflowSetUpdateCopies(t *testing.T) {
+	pool := fitnessmetrics.NewResultPool(NewDataflowSet)
+	dataflowSet := NewDataflowSet()
+	transactionSet := pool.Get()
+	setDataflow(t, transactionSet, 0)
+
+	updated, err := dataflowSet.Update(transactionSet)
+	assert.NoError(t, err)
+	assert.True(t, updated)
+	pool.Put(transactionSet)
+
+	// Reuse the released set for another transaction, which starts out empty.
+	otherTransactionSet := pool.Get()
+	assert.Same(t, transactionSet, otherTransactionSet)
+	assert.EqualValues(t, 0, otherTransactionSet.TotalDataflowCount(true))
+	assert.Empty(t, otherTransactionSet.WrittenVariables())
+	assert.Empty(t, otherTransactionSet.ReadVariables())
+	setDataflow(t, otherTransactionSet, 1)
+
+	assert.EqualValues(t, 1, dataflowSet.TotalDataflowCount(true))
+	assert.Len(t, dataflowSet.WrittenVariables(), 1)
+	updated, err = dataflowSet.Update(otherTransactionSet)
+	assert.NoError(t, err)
+	assert.True(t, updated)
+	assert.EqualValues(t, 2, dataflowSet.TotalDataflowCount(true))
+	assert.Len(t, dataflowSet.WrittenVariables(), 2)
+}
+
+// TestDataflowTracerReleaseResults tests that the tracer only releases the dataflow set of its latest transaction from
+// the message results it was stored in, and reuses it once released.
+func TestDataflowTracerReleaseResults(t *testing.T) {
+	tracer := &DataflowTracer{resultPool: fitnessmetrics.NewResultPool(NewDataflowSet)}
+	tracer.dataflowSet = tracer.resultPool.Get()
+	dataflowSet := tracer.dataflowSet
+	setDataflow(t, dataflowSet, 0)
+	results := &types.MessageResults{AdditionalResults: make(map[string]any)}
+	tracer.CaptureTxEndSetAdditionalResults(results)
+
+	// Results stored in other message results are not released.
+	tracer.ReleaseResults(&types.MessageResults{AdditionalResults: make(map[string]any)})
+	assert.Same(t, dataflowSet, GetDataflowTracerResults(results))
+
+	tracer.ReleaseResults(results)
+	assert.Nil(t, GetDataflowTracerResults(results))
+	assert.Nil(t, tracer.dataflowSet)
+	assert.NotPanics(t, func() { tracer.ReleaseResults(results) })
+	assert.NotPanics(t, func() { (*DataflowTracer)(nil).ReleaseResults(results) })
+
+	// The released set is cleared and reused for the next transaction.
+	assert.Same(t, dataflowSet, tracer.resultPool.Get())
+	assert.EqualValues(t, 0, dataflowSet.TotalDataflowCount(true))
+}
+
+// BenchmarkDataflowSetPerTransaction measures recording the dataflow of a transaction and merging it into that of its
+// call sequence, with a set allocated for each transaction.
+func BenchmarkDataflowSetPerTransaction(b *testing.B) {
+	sequenceSet := NewDataflowSet()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		transactionSet := NewDataflowSet()
+		setDataflow(b, transactionSet, uint64(i%64))
+		_, _ = sequenceSet.Update(transactionSet)
+	}
+}
+
+// BenchmarkDataflowSetResultPool measures recording the dataflow of a transaction and merging it into that of its call
+// sequence, with sets recycled through a fitnessmetrics.ResultPool as done by the tracer.
+func BenchmarkDataflowSetResultPool(b *testing.B) {
+	pool := fitnessmetrics.NewResultPool(NewDataflowSet)
+	sequenceSet := NewDataflowSet()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		transactionSet := pool.Get()
+		setDataflow(b, transactionSet, uint64(i%64))
+		_, _ = sequenceSet.Update(transactionSet)
+		pool.Put(transactionSet)
+	}
+}
@@ -10,7 +10,9 @@ import (
 	"github.com/crytic/medusa-geth/eth/tracers"
 	"github.com/crytic/medusa/chain"
 	"github.com/crytic/medusa/chain/types"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics"
 	"github.com/crytic/medusa/logging"
+	"github.com/holiman/uint256"
 )
 
 // dataflowTracerResultsKey describes the key to use when storing tracer results in call message results, or when
@@ -45,6 +47,12 @@ type DataflowTracer struct {
 	// callFrameStates describes the state tracked by the tracer per call frame.
 	callFrameStates []*dataflowTracerCallFrameState
 
+	// resultPool recycles the dataflow sets recorded for each transaction.
+	resultPool *fitnessmetrics.ResultPool[*DataflowSet]
+
+	// lastResults describes the message results the dataflow set of the latest transaction was stored in.
+	lastResults *types.MessageResults
+
 	// callDepth refers to the current EVM depth during tracing.
 	callDepth int
 
@@ -54,6 +62,22 @@ type DataflowTracer struct {
 	// nativeTracer is the underlying tracer used to capture EVM execution.
 	nativeTracer *chain.TestChainTracer
 
+	// seedDataflowSet describes the campaign-level DataflowSet whose writes seed those of each transaction, so that
+	// dataflow spanning several transactions is recorded. If nil, only dataflow within a transaction is recorded.
+	seedDataflowSet *DataflowSet
+
+	// trackOutflows describes whether values read from storage are tracked into emitted events and external call
+	// arguments (see Outflow).
+	trackOutflows bool
+
+	// loadedValues maps values read from storage during the current transaction to the reads which loaded them, so
+	// they can be recognized when they flow into an event or external call.
+	loadedValues map[common.Hash][]*loadedStorageValue
+
+	// sequenceWrites tracks the storage slots written over the call sequence, to record reads of state variables which
+	// were never written (see UninitializedRead). If nil, uninitialized reads are not recorded.
+	sequenceWrites *SequenceWrites
+
 	// hashTracebackMap maps storage the lower 32 bytes of the original data of a hash from KECCAK256 operation.
 	// hashTracebackMap map[common.Hash]common.Hash
 	// hasher is the keccak hasher used to hash data.
@@ -72,11 +96,24 @@ type dataflowTracerCallFrameState struct {
 	address common.Address
 }
 
+// loadedStorageValue describes a read of a storage slot, as tracked by the DataflowTracer to record outflows.
+type loadedStorageValue struct {
+	// read describes the position of the SLOAD operation.
+	read *ProgramPosition
+	// variable describes the storage slot which was read.
+	variable *StorageSlot
+}
+
+// minOutflowValue describes the smallest value read from storage which is tracked into events and external calls.
+// Smaller values (e.g. zero, booleans and enums) are too common to be matched to the read they originate from.
+var minOutflowValue = common.BigToHash(big.NewInt(256))
+
 // NewDataflowTracer returns a new DataflowTracer.
 func NewDataflowTracer() *DataflowTracer {
 	tracer := &DataflowTracer{
 		dataflowSet:     NewDataflowSet(),
 		callFrameStates: make([]*dataflowTracerCallFrameState, 0),
+		resultPool:      fitnessmetrics.NewResultPool(NewDataflowSet),
 		// hashTracebackMap: make(map[common.Hash]common.Hash),
 		// hasher:           crypto.NewKeccakState(),
 	}
@@ -97,13 +134,30 @@ func (t *DataflowTracer) NativeTracer() *chain.TestChainTracer {
 	return t.nativeTracer
 }
 
+// SetSeedDataflowSet sets the seedDataflowSet value (see above).
+func (t *DataflowTracer) SetSeedDataflowSet(seedDataflowSet *DataflowSet) {
+	t.seedDataflowSet = seedDataflowSet
+}
+
+// SetTrackOutflows sets the trackOutflows value (see above).
+func (t *DataflowTracer) SetTrackOutflows(trackOutflows bool) {
+	t.trackOutflows = trackOutflows
+}
+
+// SetSequenceWrites sets the sequenceWrites value (see above).
+func (t *DataflowTracer) SetSequenceWrites(sequenceWrites *SequenceWrites) {
+	t.sequenceWrites = sequenceWrites
+}
+
 // OnTxStart is called upon the start of transaction execution, as defined by tracers.Tracer.
 func (t *DataflowTracer) OnTxStart(vm *tracing.VMContext, tx *coretypes.Transaction, from common.Address) {
 	// Reset our call frame states
 	t.callDepth = 0
-	t.dataflowSet = NewDataflowSet()
+	t.dataflowSet = t.resultPool.Get()
+	t.dataflowSet.SetSeed(t.seedDataflowSet)
+	t.loadedValues = make(map[common.Hash][]*loadedStorageValue)
 	// t.hashTracebackMap = make(map[common.Hash]common.Hash)
-	t.callFrameStates = make([]*dataflowTracerCallFrameState, 0)
+	t.callFrameStates = t.callFrameStates[:0]
 	t.evmContext = vm
 }
 
@@ -176,6 +230,92 @@ func (t *DataflowTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope tr
 		if updateErr != nil {
 			logging.GlobalLogger.Panic("Dataflow tracer failed to update dataflow set while tracing state", updateErr)
 		}
+
+		// Record reads of state variables which were never written in the sequence, and remember writes for later
+		// transactions of the sequence.
+		if t.sequenceWrites != nil {
+			t.recordSequenceAccess(pc, vm.OpCode(op), storageAddress, slot, codeAddress, callFrameState)
+		}
+
+		// Remember the value read, so we can recognize it flowing into an event or external call.
+		if t.trackOutflows && vm.OpCode(op) == vm.SLOAD {
+			value := t.evmContext.StateDB.GetState(storageAddress, common.Hash(slot.Bytes32()))
+			if value.Cmp(minOutflowValue) >= 0 {
+				t.loadedValues[value] = append(t.loadedValues[value], &loadedStorageValue{
+					read:     &ProgramPosition{Address: codeAddress, Create: callFrameState.create, Pc: pc},
+					variable: &StorageSlot{Address: storageAddress, Slot: slot.Clone()},
+				})
+			}
+		}
+	}
+
+	// Record the values read from storage which flow into an event or external call.
+	if t.trackOutflows && len(t.loadedValues) > 0 {
+		t.recordOutflows(pc, vm.OpCode(op), scopeContext, callFrameState)
+	}
+}
+
+// recordSequenceAccess records the provided storage access in the sequence writes if it is a write, or as an
+// uninitialized read if it reads a declared state variable which was never written in the sequence and is zero.
+func (t *DataflowTracer) recordSequenceAccess(pc uint64, op vm.OpCode, storageAddress common.Address, slot *uint256.Int, codeAddress common.Address, callFrameState *dataflowTracerCallFrameState) {
+	variable := &StorageSlot{Address: storageAddress, Slot: slot.Clone()}
+	if op == vm.SSTORE {
+		t.sequenceWrites.SetWrite(variable, t.evmContext.BlockNumber.Uint64())
+		return
+	}
+
+	value := t.evmContext.StateDB.GetState(storageAddress, common.Hash(slot.Bytes32()))
+	if t.sequenceWrites.IsUninitializedRead(variable, value) {
+		read := &ProgramPosition{Address: codeAddress, Create: callFrameState.create, Pc: pc}
+		_, err := t.dataflowSet.SetUninitializedRead(read, variable)
+		if err != nil {
+			logging.GlobalLogger.Panic("Dataflow tracer failed to update dataflow set while tracing an uninitialized read", err)
+		}
+	}
+}
+
+// recordOutflows records the values read from storage during this transaction which flow into the topics or data of
+// an event, or the arguments of an external call, if the provided operation emits or calls. Values are matched by the
+// 32-byte words of the event data or call arguments, which is how the ABI encodes them.
+func (t *DataflowTracer) recordOutflows(pc uint64, op vm.OpCode, scopeContext *vm.ScopeContext, callFrameState *dataflowTracerCallFrameState) {
+	// Determine the memory region holding the event data or call arguments, and the sink they are observed through.
+	var sink OutflowSink
+	var words []common.Hash
+	var offset, size uint64
+	stack := scopeContext.Stack
+	switch op {
+	case vm.LOG0, vm.LOG1, vm.LOG2, vm.LOG3, vm.LOG4:
+		sink = EventOutflowSink
+		offset, size = stack.Back(0).Uint64(), stack.Back(1).Uint64()
+		for i := 0; i < int(op-vm.LOG0); i++ {
+			words = append(words, common.Hash(stack.Back(2+i).Bytes32()))
+		}
+	case vm.CALL, vm.CALLCODE:
+		// Skip the function selector preceding the arguments.
+		sink = CallOutflowSink
+		offset, size = stack.Back(3).Uint64()+4, stack.Back(4).Uint64()
+	case vm.DELEGATECALL, vm.STATICCALL:
+		sink = CallOutflowSink
+		offset, size = stack.Back(2).Uint64()+4, stack.Back(3).Uint64()
+	default:
+		return
+	}
+
+	// Split the memory region into words. Memory has not been expanded yet, so only read what is allocated.
+	memoryLength := uint64(scopeContext.Memory.Len())
+	for wordOffset := offset; wordOffset+32 <= offset+size && wordOffset+32 <= memoryLength; wordOffset += 32 {
+		words = append(words, common.BytesToHash(scopeContext.Memory.GetPtr(wordOffset, 32)))
+	}
+
+	// Record an outflow for every read which loaded a value in the event or call.
+	sinkPosition := &ProgramPosition{Address: callFrameState.address, Create: callFrameState.create, Pc: pc}
+	for _, word := range words {
+		for _, loadedValue := range t.loadedValues[word] {
+			_, err := t.dataflowSet.SetOutflow(loadedValue.read, loadedValue.variable, sink, sinkPosition)
+			if err != nil {
+				logging.GlobalLogger.Panic("Dataflow tracer failed to update dataflow set while tracing an outflow", err)
+			}
+		}
 	}
 }
 
@@ -185,4 +325,19 @@ func (t *DataflowTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope tr
 func (t *DataflowTracer) CaptureTxEndSetAdditionalResults(results *types.MessageResults) {
 	// Store our tracer results.
 	results.AdditionalResults[dataflowTracerResultsKey] = t.dataflowSet
+	t.lastResults = results
+}
+
+// ReleaseResults releases the dataflow set of the latest transaction for reuse, if it was stored in the provided
+// message results, as defined by fitnessmetrics.ResultReleaser. It is removed from the message results.
+func (t *DataflowTracer) ReleaseResults(results *types.MessageResults) {
+	if t == nil || t.dataflowSet == nil || results != t.lastResults {
+		return
+	}
+	if GetDataflowTracerResults(results) == t.dataflowSet {
+		RemoveDataflowTracerResults(results)
+	}
+	t.resultPool.Put(t.dataflowSet)
+	t.dataflowSet = nil
+	t.lastResults = nil
 }
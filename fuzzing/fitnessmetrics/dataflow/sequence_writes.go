@@ -0,0 +1,69 @@
+package dataflow
+
+import (
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/chain"
+)
+
+// SequenceWrites tracks the storage slots written over the transactions of a call sequence, so that reads of slots
+// which were never written in the sequence can be recognized. Writes are forgotten as the blocks they were made in are
+// removed from the chain, so a chain reverted to its testing base starts a new sequence.
+type SequenceWrites struct {
+	// writes maps the string representation of each written StorageSlot to the number of the first block it was
+	// written in.
+	writes map[string]uint64
+}
+
+// NewSequenceWrites returns a new SequenceWrites with no writes tracked.
+func NewSequenceWrites() *SequenceWrites {
+	return &SequenceWrites{
+		writes: make(map[string]uint64),
+	}
+}
+
+// SetWrite records a write of the provided variable in the block with the provided number.
+func (w *SequenceWrites) SetWrite(variable *StorageSlot, blockNumber uint64) {
+	key := variable.String()
+	if existingBlockNumber, exists := w.writes[key]; !exists || blockNumber < existingBlockNumber {
+		w.writes[key] = blockNumber
+	}
+}
+
+// Written indicates whether the provided variable was written in the sequence.
+func (w *SequenceWrites) Written(variable *StorageSlot) bool {
+	_, written := w.writes[variable.String()]
+	return written
+}
+
+// IsUninitializedRead indicates whether a read of the provided variable, which loaded the provided value, reads a
+// declared state variable which was never written in the sequence and is still zero. State variables are laid out
+// from slot zero, while mapping and dynamic array elements live at hashed slots, so only slots fitting in 64 bits are
+// considered, rather than any unset mapping entry.
+func (w *SequenceWrites) IsUninitializedRead(variable *StorageSlot, value common.Hash) bool {
+	return value == (common.Hash{}) && variable.Slot.IsUint64() && !w.Written(variable)
+}
+
+// forgetFrom forgets the writes made in the block with the provided number, or any later block.
+func (w *SequenceWrites) forgetFrom(blockNumber uint64) {
+	for key, writeBlockNumber := range w.writes {
+		if writeBlockNumber >= blockNumber {
+			delete(w.writes, key)
+		}
+	}
+}
+
+// OnBlocksRemoved forgets the writes made in the removed blocks. It is to be subscribed to the chain's
+// BlocksRemoved event.
+func (w *SequenceWrites) OnBlocksRemoved(event chain.BlocksRemovedEvent) error {
+	for _, block := range event.Blocks {
+		w.forgetFrom(block.Header.Number.Uint64())
+	}
+	return nil
+}
+
+// OnPendingBlockDiscarded forgets the writes made in the discarded pending block. It is to be subscribed to the
+// chain's PendingBlockDiscarded event.
+func (w *SequenceWrites) OnPendingBlockDiscarded(event chain.PendingBlockDiscardedEvent) error {
+	w.forgetFrom(event.Block.Header.Number.Uint64())
+	return nil
+}
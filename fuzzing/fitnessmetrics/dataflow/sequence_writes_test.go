@@ -0,0 +1,93 @@
+package dataflow
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core"
+	coretypes "github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa/chain"
+	"github.com/crytic/medusa/chain/types"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSequenceWritesUninitializedRead tests that only zero values read from slots of declared state variables which
+// were not written in the sequence are uninitialized reads, and that writes are forgotten with the blocks they were
+// made in.
+func TestSequenceWritesUninitializedRead(t *testing.T) {
+	sequenceWrites := NewSequenceWrites()
+	variable := &StorageSlot{Address: common.Address{1}, Slot: uint256.NewInt(1)}
+	otherVariable := &StorageSlot{Address: common.Address{2}, Slot: uint256.NewInt(1)}
+	hashedVariable := &StorageSlot{Address: common.Address{1}, Slot: new(uint256.Int).Lsh(uint256.NewInt(1), 64)}
+	assert.True(t, sequenceWrites.IsUninitializedRead(variable, common.Hash{}))
+	assert.False(t, sequenceWrites.IsUninitializedRead(variable, common.Hash{1}))
+	assert.False(t, sequenceWrites.IsUninitializedRead(hashedVariable, common.Hash{}))
+
+	// Writes are tracked per contract, from the first block they were made in.
+	sequenceWrites.SetWrite(variable, 3)
+	sequenceWrites.SetWrite(variable, 4)
+	assert.False(t, sequenceWrites.IsUninitializedRead(variable, common.Hash{}))
+	assert.True(t, sequenceWrites.IsUninitializedRead(otherVariable, common.Hash{}))
+
+	// Removing blocks after the write keeps it, while removing its block forgets it.
+	newBlock := func(number int64) *types.Block {
+		return &types.Block{Header: &coretypes.Header{Number: big.NewInt(number)}}
+	}
+	assert.NoError(t, sequenceWrites.OnPendingBlockDiscarded(chain.PendingBlockDiscardedEvent{Block: newBlock(4)}))
+	assert.True(t, sequenceWrites.Written(variable))
+	assert.NoError(t, sequenceWrites.OnBlocksRemoved(chain.BlocksRemovedEvent{Blocks: []*types.Block{newBlock(4), newBlock(3)}}))
+	assert.False(t, sequenceWrites.Written(variable))
+}
+
+// TestDataflowTracerUninitializedReads tests that the tracer records reads of declared state variables never written
+// in the sequence, including by earlier transactions of the sequence, once it is given the sequence writes.
+func TestDataflowTracerUninitializedReads(t *testing.T) {
+	sender := common.HexToAddress("0x10000")
+	contract := common.HexToAddress("0x20000")
+	// Read slot 1, write zero to it, then read it again.
+	code := []byte{
+		byte(vm.PUSH1), 1, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 1, byte(vm.SSTORE),
+		byte(vm.PUSH1), 1, byte(vm.SLOAD), byte(vm.POP), byte(vm.STOP),
+	}
+	testChain, err := chain.NewTestChain(context.Background(), coretypes.GenesisAlloc{
+		sender:   {Balance: big.NewInt(1_000_000)},
+		contract: {Balance: big.NewInt(0), Code: code},
+	}, nil)
+	assert.NoError(t, err)
+	defer testChain.Close()
+	tracer := NewDataflowTracer()
+	tracer.SetSequenceWrites(NewSequenceWrites())
+	testChain.AddTracer(tracer.NativeTracer(), true, false)
+
+	_, err = testChain.PendingBlockCreate()
+	assert.NoError(t, err)
+	for nonce := uint64(0); nonce < 2; nonce++ {
+		err = testChain.PendingBlockAddTx(&core.Message{
+			From:      sender,
+			To:        &contract,
+			Nonce:     nonce,
+			Value:     big.NewInt(0),
+			GasLimit:  1_000_000,
+			GasPrice:  big.NewInt(0),
+			GasFeeCap: big.NewInt(0),
+			GasTipCap: big.NewInt(0),
+		})
+		assert.NoError(t, err)
+	}
+
+	// Only the first read of the first transaction precedes the write.
+	messageResults := testChain.PendingBlock().MessageResults
+	firstDataflowSet := GetDataflowTracerResults(messageResults[0])
+	assert.EqualValues(t, 1, firstDataflowSet.TotalUninitializedReadCount())
+	read := &UninitializedRead{
+		Read:     &ProgramPosition{Address: contract, Pc: 2},
+		Variable: &StorageSlot{Address: contract, Slot: uint256.NewInt(1)},
+	}
+	assert.Contains(t, firstDataflowSet.uninitializedReads, read.String())
+	assert.EqualValues(t, 0, GetDataflowTracerResults(messageResults[1]).TotalUninitializedReadCount())
+}
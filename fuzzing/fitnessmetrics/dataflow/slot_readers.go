@@ -0,0 +1,63 @@
+package dataflow
+
+import (
+	"sync"
+
+	"github.com/crytic/medusa-geth/common"
+)
+
+// MethodKey identifies an ABI method of a deployed contract by the address of the contract and the selector of the
+// method.
+type MethodKey struct {
+	Address  common.Address
+	Selector [4]byte
+}
+
+// SlotReaders maps storage slots to the ABI methods whose calls were observed reading them. The program positions of
+// the reads are attributed to the method of the transaction which executed them, so reads in internal functions,
+// modifiers and other contracts are attributed to the entry point they were reached through. It is shared between
+// workers to order calls along dataflow: after a call writes a slot, a method which reads it can be scheduled next.
+type SlotReaders struct {
+	// readers maps the string representation of each StorageSlot to the methods observed reading it.
+	readers map[string]map[MethodKey]struct{}
+
+	// lock offers concurrent thread safety for reader accesses.
+	lock sync.RWMutex
+}
+
+// NewSlotReaders returns a new SlotReaders with no reads recorded.
+func NewSlotReaders() *SlotReaders {
+	return &SlotReaders{
+		readers: make(map[string]map[MethodKey]struct{}),
+	}
+}
+
+// Record records the provided method as a reader of each of the provided variables, as returned by
+// DataflowSet.ReadVariables.
+func (r *SlotReaders) Record(method MethodKey, variables []string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, variable := range variables {
+		methods := r.readers[variable]
+		if methods == nil {
+			methods = make(map[MethodKey]struct{})
+			r.readers[variable] = methods
+		}
+		methods[method] = struct{}{}
+	}
+}
+
+// ReadsAny indicates whether the provided method was observed reading any of the provided variables, as returned by
+// DataflowSet.WrittenVariables.
+func (r *SlotReaders) ReadsAny(method MethodKey, variables []string) bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	for _, variable := range variables {
+		if _, exists := r.readers[variable][method]; exists {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,298 @@
+package edgecoverage
+
+import (
+	"sync"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/crypto"
+	compilationTypes "github.com/crytic/medusa/compilation/types"
+)
+
+// EdgeMapSize describes the amount of distinct edge ids per contract code. Edges are hashed into this range AFL-style,
+// so that the size of a contract's edge map is bounded regardless of the amount of edges executed.
+const EdgeMapSize = 1 << 16
+
+// GetEdgeId returns the id of the control flow edge from the instruction at prevPc to the instruction at pc. As in AFL,
+// the previous location is shifted before being combined with the current one, so that the edges A->B and B->A, as
+// well as tight loops A->A, have distinct ids.
+func GetEdgeId(prevPc uint64, pc uint64) uint32 {
+	return (hashLocation(pc) ^ (hashLocation(prevPc) >> 1)) & (EdgeMapSize - 1)
+}
+
+// hashLocation spreads a program counter across 32 bits, as AFL assigns random ids to locations. Program counters of
+// neighbouring instructions are otherwise too close to one another to hash edges uniformly.
+func hashLocation(pc uint64) uint32 {
+	return uint32((pc * 0x9E3779B97F4A7C15) >> 32)
+}
+
+// CoverageMaps represents a data structure used to identify edge coverage of various smart contracts across a
+// transaction or multiple transactions.
+type CoverageMaps struct {
+	// maps represents a structure used to track every ContractCoverageMap by a given deployed address/lookup hash.
+	maps map[common.Hash]map[common.Address]*ContractCoverageMap
+
+	// cachedCodeAddress represents the last code address which coverage was updated for. This is used to prevent an
+	// expensive lookup in maps. If cachedCodeHash does not match the current code address for which we are updating
+	// coverage for, it, along with other cache variables are updated.
+	cachedCodeAddress common.Address
+
+	// cachedCodeHash represents the last lookup hash which coverage was updated for. This is used to prevent an
+	// expensive lookup in maps. If cachedCodeHash does not match the current code hash which we are updating
+	// coverage for, it, along with other cache variables are updated.
+	cachedCodeHash common.Hash
+
+	// cachedMap represents the last coverage map which was updated. If the coverage to update resides at the
+	// cachedCodeAddress and matches the cachedCodeHash, then this map is used to avoid an expensive lookup into maps.
+	cachedMap *ContractCoverageMap
+
+	// spareMaps describes the ContractCoverageMap objects emptied by Clear, which are reused before new ones are
+	// allocated.
+	spareMaps []*ContractCoverageMap
+
+	// spareMapsByAddress describes the lookups of ContractCoverageMap objects by address emptied by Clear, which are
+	// reused before new ones are allocated.
+	spareMapsByAddress []map[common.Address]*ContractCoverageMap
+
+	// lock is a read-write mutex to offer concurrent thread safety for map accesses.
+	lock sync.RWMutex
+}
+
+// NewCoverageMaps initializes a new CoverageMaps object.
+func NewCoverageMaps() *CoverageMaps {
+	maps := &CoverageMaps{}
+	maps.Reset()
+	return maps
+}
+
+// Reset clears the coverage state for the CoverageMaps.
+func (cm *CoverageMaps) Reset() {
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+
+	cm.maps = make(map[common.Hash]map[common.Address]*ContractCoverageMap)
+	cm.cachedCodeAddress = common.Address{}
+	cm.cachedCodeHash = common.Hash{}
+	cm.cachedMap = nil
+}
+
+// Clear clears the coverage state for the CoverageMaps like Reset, but retains the memory allocated for it, so that
+// coverage recorded afterwards reuses it.
+func (cm *CoverageMaps) Clear() {
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+
+	for _, mapsByAddress := range cm.maps {
+		for _, coverageMap := range mapsByAddress {
+			coverageMap.Reset()
+			cm.spareMaps = append(cm.spareMaps, coverageMap)
+		}
+		clear(mapsByAddress)
+		cm.spareMapsByAddress = append(cm.spareMapsByAddress, mapsByAddress)
+	}
+	clear(cm.maps)
+	cm.cachedCodeAddress = common.Address{}
+	cm.cachedCodeHash = common.Hash{}
+	cm.cachedMap = nil
+}
+
+// getMapsByAddress obtains the lookup of ContractCoverageMap objects by address for the provided code hash, creating
+// it if it does not exist. The lock must be held by the caller.
+func (cm *CoverageMaps) getMapsByAddress(codeHash common.Hash) map[common.Address]*ContractCoverageMap {
+	mapsByAddress, codeHashExists := cm.maps[codeHash]
+	if !codeHashExists {
+		if count := len(cm.spareMapsByAddress); count > 0 {
+			mapsByAddress = cm.spareMapsByAddress[count-1]
+			cm.spareMapsByAddress = cm.spareMapsByAddress[:count-1]
+		} else {
+			mapsByAddress = make(map[common.Address]*ContractCoverageMap)
+		}
+		cm.maps[codeHash] = mapsByAddress
+	}
+	return mapsByAddress
+}
+
+// newContractCoverageMap obtains an empty ContractCoverageMap, reusing one emptied by Clear if possible. The lock must
+// be held by the caller.
+func (cm *CoverageMaps) newContractCoverageMap() *ContractCoverageMap {
+	if count := len(cm.spareMaps); count > 0 {
+		coverageMap := cm.spareMaps[count-1]
+		cm.spareMaps = cm.spareMaps[:count-1]
+		return coverageMap
+	}
+	return newContractCoverageMap()
+}
+
+// TotalEdgeCoverage returns the amount of distinct edges covered across all contracts, or only those deployed at the
+// provided target addresses if any are provided.
+func (cm *CoverageMaps) TotalEdgeCoverage(targetAddresses []common.Address) int {
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+
+	coveredEdges := 0
+	for _, mapsByAddress := range cm.maps {
+		if len(targetAddresses) > 0 {
+			for _, address := range targetAddresses {
+				if contractCoverageMap, exists := mapsByAddress[address]; exists {
+					coveredEdges += len(contractCoverageMap.executedEdges)
+				}
+			}
+		} else {
+			for _, contractCoverageMap := range mapsByAddress {
+				coveredEdges += len(contractCoverageMap.executedEdges)
+			}
+		}
+	}
+	return coveredEdges
+}
+
+// getContractCoverageMapHash obtain the hash used to look up a given contract's ContractCoverageMap.
+// If this is init bytecode, metadata and abi arguments will attempt to be stripped, then a hash is computed.
+// If this is runtime bytecode, the metadata ipfs/swarm hash will be used if available, otherwise the bytecode
+// is hashed.
+// Returns the resulting lookup hash.
+func getContractCoverageMapHash(bytecode []byte, init bool) common.Hash {
+	// If available, the metadata code hash should be unique and reliable to use above all (for runtime bytecode).
+	if !init {
+		metadata := compilationTypes.ExtractContractMetadata(bytecode)
+		if metadata != nil {
+			metadataHash := metadata.ExtractBytecodeHash()
+			if metadataHash != nil {
+				return common.BytesToHash(metadataHash)
+			}
+		}
+	}
+
+	// Otherwise, we use the hash of the bytecode after attempting to strip metadata (and constructor args).
+	strippedBytecode := compilationTypes.RemoveContractMetadata(bytecode)
+	return crypto.Keccak256Hash(strippedBytecode)
+}
+
+// Update updates the current coverage maps with the provided ones.
+// Returns a boolean indicating whether new edges were covered, or an error if one occurred.
+func (cm *CoverageMaps) Update(coverageMaps *CoverageMaps) (bool, error) {
+	// If our maps provided are nil, do nothing
+	if coverageMaps == nil {
+		return false, nil
+	}
+
+	// Acquire our thread lock and defer our unlocking for when we exit this method
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+
+	// Create a boolean indicating whether we achieved new coverage
+	coverageChanged := false
+
+	// Loop for each coverage map provided
+	for codeHash, mapsByAddressToMerge := range coverageMaps.maps {
+		for codeAddress, coverageMapToMerge := range mapsByAddressToMerge {
+			// If a coverage map lookup for this code hash doesn't exist, create the mapping.
+			mapsByAddress := cm.getMapsByAddress(codeHash)
+
+			// If a coverage map for this address does not exist yet, create it, then merge the one provided into it.
+			// Maps are not adopted, as the provided maps may still be updated by their owner.
+			existingCoverageMap, codeAddressExists := mapsByAddress[codeAddress]
+			if !codeAddressExists {
+				existingCoverageMap = cm.newContractCoverageMap()
+				mapsByAddress[codeAddress] = existingCoverageMap
+			}
+			coverageChanged = existingCoverageMap.update(coverageMapToMerge) || coverageChanged
+		}
+	}
+
+	// Return our results
+	return coverageChanged, nil
+}
+
+// SetAt sets the coverage state of a given edge within a contract's coverage data.
+// Returns a boolean indicating whether the edge was covered for the first time, or an error if one occurred.
+func (cm *CoverageMaps) SetAt(codeAddress common.Address, codeLookupHash common.Hash, edgeId uint32) (bool, error) {
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+
+	// Try to obtain a coverage map from our cache
+	var coverageMap *ContractCoverageMap
+	if cm.cachedMap != nil && cm.cachedCodeAddress == codeAddress && cm.cachedCodeHash == codeLookupHash {
+		coverageMap = cm.cachedMap
+	} else {
+		// If a coverage map lookup for this code hash doesn't exist, create the mapping.
+		mapsByCodeAddress := cm.getMapsByAddress(codeLookupHash)
+
+		// Obtain the coverage map for this code address if it already exists. If it does not, create a new one.
+		if existingCoverageMap, codeAddressExists := mapsByCodeAddress[codeAddress]; codeAddressExists {
+			coverageMap = existingCoverageMap
+		} else {
+			coverageMap = cm.newContractCoverageMap()
+			mapsByCodeAddress[codeAddress] = coverageMap
+		}
+
+		// Set our cached variables for faster coverage setting next time this method is called.
+		cm.cachedMap = coverageMap
+		cm.cachedCodeHash = codeLookupHash
+		cm.cachedCodeAddress = codeAddress
+	}
+
+	// Set our coverage in the map and return our change state
+	return coverageMap.setCoveredAt(edgeId), nil
+}
+
+// RevertAll clears all coverage in the coverage maps, as the call frames they were recorded in reverted.
+func (cm *CoverageMaps) RevertAll() {
+	// Acquire our thread lock and defer our unlocking for when we exit this method
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+
+	for _, mapsByAddress := range cm.maps {
+		for _, contractCoverageMap := range mapsByAddress {
+			contractCoverageMap.Reset()
+		}
+	}
+}
+
+// ContractCoverageMap represents a data structure used to identify edge coverage of a contract.
+type ContractCoverageMap struct {
+	// executedEdges describes the ids of the edges executed successfully (in call frames which did not revert).
+	executedEdges map[uint32]struct{}
+}
+
+// newContractCoverageMap creates and returns a new ContractCoverageMap.
+func newContractCoverageMap() *ContractCoverageMap {
+	return &ContractCoverageMap{
+		executedEdges: make(map[uint32]struct{}),
+	}
+}
+
+// Reset resets the contract coverage map to be empty.
+func (cm *ContractCoverageMap) Reset() {
+	clear(cm.executedEdges)
+}
+
+// IsCovered checks if a given edge id was executed successfully within the contract.
+// Returns a boolean indicating if the edge was executed on this map.
+func (cm *ContractCoverageMap) IsCovered(edgeId uint32) bool {
+	// If the contract coverage map is nil, the contract was never executed.
+	if cm == nil {
+		return false
+	}
+	_, covered := cm.executedEdges[edgeId]
+	return covered
+}
+
+// update updates the current ContractCoverageMap with the provided one.
+// Returns a boolean indicating whether new edges were covered.
+func (cm *ContractCoverageMap) update(coverageMap *ContractCoverageMap) bool {
+	changed := false
+	for edgeId := range coverageMap.executedEdges {
+		changed = cm.setCoveredAt(edgeId) || changed
+	}
+	return changed
+}
+
+// setCoveredAt sets the coverage state of a given edge id within the ContractCoverageMap.
+// Returns a boolean indicating whether the edge was covered for the first time.
+func (cm *ContractCoverageMap) setCoveredAt(edgeId uint32) bool {
+	if _, covered := cm.executedEdges[edgeId]; covered {
+		return false
+	}
+	cm.executedEdges[edgeId] = struct{}{}
+	return true
+}
@@ -0,0 +1,224 @@
+package edgecoverage
+
+import (
+	"math/big"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core/tracing"
+	coretypes "github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa-geth/eth/tracers"
+	"github.com/crytic/medusa/chain"
+	"github.com/crytic/medusa/chain/types"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics"
+	"github.com/crytic/medusa/logging"
+)
+
+// coverageTracerResultsKey describes the key to use when storing tracer results in call message results, or when
+// querying them.
+const coverageTracerResultsKey = "EdgeCoverageTracerResults"
+
+// GetCoverageTracerResults obtains CoverageMaps stored by a CoverageTracer from message results. This is nil if
+// no CoverageMaps were recorded by a tracer (e.g. CoverageTracer was not attached during this message execution).
+func GetCoverageTracerResults(messageResults *types.MessageResults) *CoverageMaps {
+	// Try to obtain the results the tracer should've stored.
+	if genericResult, ok := messageResults.AdditionalResults[coverageTracerResultsKey]; ok {
+		if castedResult, ok := genericResult.(*CoverageMaps); ok {
+			return castedResult
+		}
+	}
+
+	// If we could not obtain them, return nil.
+	return nil
+}
+
+// RemoveCoverageTracerResults removes CoverageMaps stored by a CoverageTracer from message results.
+func RemoveCoverageTracerResults(messageResults *types.MessageResults) {
+	delete(messageResults.AdditionalResults, coverageTracerResultsKey)
+}
+
+// CoverageTracer implements vm.EVMLogger to collect edge coverage maps for fuzzing campaigns from EVM execution
+// traces. An edge is a control flow transfer made by a JUMP or JUMPI, from the jump instruction to the instruction
+// executed next (the jump destination, or the fall-through instruction of a JUMPI).
+type CoverageTracer struct {
+	// coverageMaps describes the edge coverage recorded. Call frames which errored are not recorded.
+	coverageMaps *CoverageMaps
+
+	// callFrameStates describes the state tracked by the tracer per call frame.
+	callFrameStates []*coverageTracerCallFrameState
+
+	// resultPool recycles the coverage maps recorded for each transaction and call frame.
+	resultPool *fitnessmetrics.ResultPool[*CoverageMaps]
+
+	// lastResults describes the message results the coverage maps of the latest transaction were stored in.
+	lastResults *types.MessageResults
+
+	// callDepth refers to the current EVM depth during tracing.
+	callDepth int
+
+	// evmContext holds the VM context during tracing
+	evmContext *tracing.VMContext
+
+	// nativeTracer is the underlying tracer used to capture EVM execution.
+	nativeTracer *chain.TestChainTracer
+}
+
+// coverageTracerCallFrameState tracks state across call frames in the tracer.
+type coverageTracerCallFrameState struct {
+	// initialized tracks whether or not this has happened yet.
+	initialized bool
+	// create indicates whether the current call frame is executing on init bytecode (deploying a contract).
+	create bool
+
+	// pendingCoverageMap describes the coverage maps recorded for this call frame.
+	pendingCoverageMap *CoverageMaps
+
+	// lookupHash describes the hash used to look up the ContractCoverageMap being updated in this frame.
+	lookupHash *common.Hash
+
+	// address is used by OnOpcode to cache the result of scope.Address(), which is slow.
+	// It records the address of the current contract.
+	address common.Address
+
+	// jumped indicates whether the last instruction executed in this frame was a JUMP or JUMPI, such that the next
+	// instruction executed ends an edge starting at jumpPc.
+	jumped bool
+	// jumpPc is the program counter of the last JUMP or JUMPI executed in this frame.
+	jumpPc uint64
+}
+
+// NewCoverageTracer returns a new CoverageTracer.
+func NewCoverageTracer() *CoverageTracer {
+	tracer := &CoverageTracer{
+		coverageMaps:    NewCoverageMaps(),
+		callFrameStates: make([]*coverageTracerCallFrameState, 0),
+		resultPool:      fitnessmetrics.NewResultPool(NewCoverageMaps),
+	}
+	nativeTracer := &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnTxStart: tracer.OnTxStart,
+			OnEnter:   tracer.OnEnter,
+			OnExit:    tracer.OnExit,
+			OnOpcode:  tracer.OnOpcode,
+		},
+	}
+	tracer.nativeTracer = &chain.TestChainTracer{Tracer: nativeTracer, CaptureTxEndSetAdditionalResults: tracer.CaptureTxEndSetAdditionalResults}
+	return tracer
+}
+
+// NativeTracer returns the underlying TestChainTracer.
+func (t *CoverageTracer) NativeTracer() *chain.TestChainTracer {
+	return t.nativeTracer
+}
+
+// OnTxStart is called upon the start of transaction execution, as defined by tracers.Tracer.
+func (t *CoverageTracer) OnTxStart(vm *tracing.VMContext, tx *coretypes.Transaction, from common.Address) {
+	// Reset our call frame states
+	t.callDepth = 0
+	t.coverageMaps = t.resultPool.Get()
+	t.callFrameStates = t.callFrameStates[:0]
+	t.evmContext = vm
+}
+
+// OnEnter is called upon entering of the call frame, as defined by tracers.Tracer.
+func (t *CoverageTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	isTopLevelFrame := depth == 0
+	if !isTopLevelFrame {
+		t.callDepth++
+	}
+	// Create our state tracking struct for this frame.
+	t.callFrameStates = append(t.callFrameStates, &coverageTracerCallFrameState{
+		create:             typ == byte(vm.CREATE) || typ == byte(vm.CREATE2),
+		pendingCoverageMap: t.resultPool.Get(),
+	})
+}
+
+// OnExit is called upon exiting of the call frame, as defined by tracers.Tracer.
+func (t *CoverageTracer) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	currentCallFrameState := t.callFrameStates[t.callDepth]
+	currentCoverageMap := currentCallFrameState.pendingCoverageMap
+
+	if reverted {
+		currentCoverageMap.RevertAll()
+	}
+
+	// Check to see if this is the top level call frame
+	isTopLevelFrame := depth == 0
+
+	// Commit all our coverage maps up one call frame.
+	if isTopLevelFrame {
+		_, coverageUpdateErr := t.coverageMaps.Update(currentCoverageMap)
+		if coverageUpdateErr != nil {
+			logging.GlobalLogger.Panic("Edge coverage tracer failed to update coverage map during capture end", coverageUpdateErr)
+		}
+	} else {
+		// Move coverage up one call frame
+		_, coverageUpdateErr := t.callFrameStates[t.callDepth-1].pendingCoverageMap.Update(currentCoverageMap)
+		if coverageUpdateErr != nil {
+			logging.GlobalLogger.Panic("Edge coverage tracer failed to update coverage map during capture exit", coverageUpdateErr)
+		}
+
+		// Pop the state tracking struct for this call frame off the stack and decrement the call depth
+		t.callFrameStates = t.callFrameStates[:t.callDepth]
+		t.callDepth--
+	}
+
+	// The coverage of this call frame was copied up, so its maps can be reused.
+	t.resultPool.Put(currentCoverageMap)
+}
+
+// OnOpcode records data from an EVM state update, as defined by tracers.Tracer.
+func (t *CoverageTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	// Obtain our call frame state tracking struct
+	callFrameState := t.callFrameStates[t.callDepth]
+
+	if !callFrameState.initialized {
+		callFrameState.initialized = true
+		callFrameState.address = scope.Address()
+	}
+
+	// If the previous instruction was a jump, this instruction ends an edge. Record it.
+	if callFrameState.jumped {
+		callFrameState.jumped = false
+
+		// Obtain our contract coverage map lookup hash.
+		if callFrameState.lookupHash == nil {
+			lookupHash := getContractCoverageMapHash(scope.(*vm.ScopeContext).Contract.Code, callFrameState.create)
+			callFrameState.lookupHash = &lookupHash
+		}
+
+		_, coverageUpdateErr := callFrameState.pendingCoverageMap.SetAt(callFrameState.address, *callFrameState.lookupHash, GetEdgeId(callFrameState.jumpPc, pc))
+		if coverageUpdateErr != nil {
+			logging.GlobalLogger.Panic("Edge coverage tracer failed to update coverage map while tracing state", coverageUpdateErr)
+		}
+	}
+
+	// If this instruction is a jump, the next instruction executed in this frame ends an edge starting here.
+	if vm.OpCode(op) == vm.JUMP || vm.OpCode(op) == vm.JUMPI {
+		callFrameState.jumped = true
+		callFrameState.jumpPc = pc
+	}
+}
+
+// CaptureTxEndSetAdditionalResults can be used to set additional results captured from execution tracing. If this
+// tracer is used during transaction execution (block creation), the results can later be queried from the block.
+// This method will only be called on the added tracer if it implements the extended TestChainTracer interface.
+func (t *CoverageTracer) CaptureTxEndSetAdditionalResults(results *types.MessageResults) {
+	// Store our tracer results.
+	results.AdditionalResults[coverageTracerResultsKey] = t.coverageMaps
+	t.lastResults = results
+}
+
+// ReleaseResults releases the coverage maps of the latest transaction for reuse, if they were stored in the provided
+// message results, as defined by fitnessmetrics.ResultReleaser. They are removed from the message results.
+func (t *CoverageTracer) ReleaseResults(results *types.MessageResults) {
+	if t == nil || t.coverageMaps == nil || results != t.lastResults {
+		return
+	}
+	if GetCoverageTracerResults(results) == t.coverageMaps {
+		RemoveCoverageTracerResults(results)
+	}
+	t.resultPool.Put(t.coverageMaps)
+	t.coverageMaps = nil
+	t.lastResults = nil
+}
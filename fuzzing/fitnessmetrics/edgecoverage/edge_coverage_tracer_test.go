@@ -0,0 +1,94 @@
+package edgecoverage
+
+import (
+	"context"
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core"
+	coretypes "github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa/chain"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	// edgeCoverageTestSender is the address sending the transactions executed by the tracer tests.
+	edgeCoverageTestSender = common.HexToAddress("0x10000")
+	// edgeCoverageTestContract is the address of the contract executed by the tracer tests.
+	edgeCoverageTestContract = common.HexToAddress("0x20000")
+)
+
+// executeCoverageTracer executes a call with the provided data to edgeCoverageTestContract, holding the provided
+// runtime bytecode, with a CoverageTracer attached.
+// Returns the edge coverage recorded for edgeCoverageTestContract.
+func executeCoverageTracer(t *testing.T, code []byte, data []byte) *ContractCoverageMap {
+	testChain, err := chain.NewTestChain(context.Background(), coretypes.GenesisAlloc{
+		edgeCoverageTestSender:   {Balance: big.NewInt(1_000_000)},
+		edgeCoverageTestContract: {Balance: big.NewInt(0), Code: code},
+	}, nil)
+	assert.NoError(t, err)
+	defer testChain.Close()
+	tracer := NewCoverageTracer()
+	testChain.AddTracer(tracer.NativeTracer(), true, false)
+
+	_, err = testChain.PendingBlockCreate()
+	assert.NoError(t, err)
+	err = testChain.PendingBlockAddTx(&core.Message{
+		From:      edgeCoverageTestSender,
+		To:        &edgeCoverageTestContract,
+		Value:     big.NewInt(0),
+		Data:      data,
+		GasLimit:  1_000_000,
+		GasPrice:  big.NewInt(0),
+		GasFeeCap: big.NewInt(0),
+		GasTipCap: big.NewInt(0),
+	})
+	assert.NoError(t, err)
+	coverageMaps := GetCoverageTracerResults(testChain.PendingBlock().MessageResults[0])
+	return coverageMaps.maps[getContractCoverageMapHash(code, false)][edgeCoverageTestContract]
+}
+
+// TestGetEdgeId tests that edges between the same instructions in opposite directions, and loops on a single
+// instruction, have distinct ids within the edge map.
+func TestGetEdgeId(t *testing.T) {
+	edgeIds := map[uint32]struct{}{
+		GetEdgeId(3, 6): {},
+		GetEdgeId(6, 3): {},
+		GetEdgeId(3, 3): {},
+		GetEdgeId(6, 6): {},
+	}
+	assert.Len(t, edgeIds, 4)
+	for edgeId := range edgeIds {
+		assert.Less(t, edgeId, uint32(EdgeMapSize))
+	}
+}
+
+// TestCoverageTracerEdges tests that the tracer records the edge from a JUMPI to the instruction executed next, whether
+// the jump was taken or fell through, and discards the edges of call frames which reverted.
+func TestCoverageTracerEdges(t *testing.T) {
+	// Jump to the JUMPDEST at pc 6 if call data was provided, falling through to the STOP at pc 4 otherwise.
+	jumpCode := []byte{byte(vm.CALLDATASIZE), byte(vm.PUSH1), 6, byte(vm.JUMPI), byte(vm.STOP), byte(vm.INVALID), byte(vm.JUMPDEST)}
+	stopCode := append(jumpCode, byte(vm.STOP))
+	revertCode := append(jumpCode, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.REVERT))
+	tests := []struct {
+		name     string
+		code     []byte
+		data     []byte
+		expected []uint32
+	}{
+		{name: "jump falling through", code: stopCode, expected: []uint32{GetEdgeId(3, 4)}},
+		{name: "jump taken", code: stopCode, data: []byte{1}, expected: []uint32{GetEdgeId(3, 6)}},
+		{name: "jump taken before reverting", code: revertCode, data: []byte{1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			coverageMap := executeCoverageTracer(t, test.code, test.data)
+			for _, edgeId := range []uint32{GetEdgeId(3, 4), GetEdgeId(3, 6)} {
+				assert.EqualValues(t, slices.Contains(test.expected, edgeId), coverageMap.IsCovered(edgeId))
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package fitnessmetrics
+
+import (
+	"slices"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/crypto"
+	"github.com/crytic/medusa/fuzzing/config"
+	fuzzerTypes "github.com/crytic/medusa/fuzzing/contracts"
+	"github.com/crytic/medusa/utils"
+)
+
+// MetricExclusions describes the contracts (e.g. libraries, mocks and helper contracts) excluded from code coverage,
+// branch coverage and branch distance accounting, so those metrics only reflect the system under test.
+type MetricExclusions struct {
+	// addresses describes the excluded addresses. Code executing in the context of these addresses is excluded.
+	addresses map[common.Address]struct{}
+
+	// codeHashes describes the keccak256 hashes of excluded (init or runtime) bytecode.
+	codeHashes map[common.Hash]struct{}
+
+	// contractNames describes the names of excluded contracts.
+	contractNames map[string]struct{}
+}
+
+// NewMetricExclusions returns a new MetricExclusions from the provided configuration.
+// Returns the exclusions, or an error if an address could not be parsed.
+func NewMetricExclusions(metricExclusionsConfig config.MetricExclusionsConfig) (*MetricExclusions, error) {
+	exclusions := &MetricExclusions{
+		addresses:     make(map[common.Address]struct{}),
+		codeHashes:    make(map[common.Hash]struct{}),
+		contractNames: make(map[string]struct{}),
+	}
+	addresses, err := utils.HexStringsToAddresses(metricExclusionsConfig.Addresses)
+	if err != nil {
+		return nil, err
+	}
+	for _, address := range addresses {
+		exclusions.addresses[address] = struct{}{}
+	}
+	for _, codeHash := range metricExclusionsConfig.BytecodeHashes {
+		exclusions.codeHashes[common.HexToHash(codeHash)] = struct{}{}
+	}
+	for _, contractName := range metricExclusionsConfig.ContractNames {
+		exclusions.contractNames[contractName] = struct{}{}
+	}
+	return exclusions, nil
+}
+
+// FilterContracts returns the provided contracts which are not excluded by name or by the hash of their compiled
+// bytecode. If the exclusions are nil, the contracts are returned as is.
+func (e *MetricExclusions) FilterContracts(contracts fuzzerTypes.Contracts) fuzzerTypes.Contracts {
+	if e == nil || (len(e.contractNames) == 0 && len(e.codeHashes) == 0) {
+		return contracts
+	}
+	filteredContracts := make(fuzzerTypes.Contracts, 0, len(contracts))
+	for _, contract := range contracts {
+		if _, excluded := e.contractNames[contract.Name()]; excluded {
+			continue
+		}
+		compiledContract := contract.CompiledContract()
+		if _, excluded := e.codeHashes[crypto.Keccak256Hash(compiledContract.InitBytecode)]; excluded {
+			continue
+		}
+		if _, excluded := e.codeHashes[crypto.Keccak256Hash(compiledContract.RuntimeBytecode)]; excluded {
+			continue
+		}
+		filteredContracts = append(filteredContracts, contract)
+	}
+	return filteredContracts
+}
+
+// ExcludedContracts returns the provided contracts which are excluded by name or by the hash of their compiled
+// bytecode, i.e. those FilterContracts removes.
+func (e *MetricExclusions) ExcludedContracts(contracts fuzzerTypes.Contracts) fuzzerTypes.Contracts {
+	filteredContracts := e.FilterContracts(contracts)
+	excludedContracts := make(fuzzerTypes.Contracts, 0, len(contracts)-len(filteredContracts))
+	for _, contract := range contracts {
+		if !slices.Contains(filteredContracts, contract) {
+			excludedContracts = append(excludedContracts, contract)
+		}
+	}
+	return excludedContracts
+}
+
+// Excludes indicates whether code executing in the context of the provided address, with the provided code hash, is
+// excluded. Code executed through a DELEGATECALL executes in the context of its caller, so it can only be excluded by
+// contract name or code hash. If the exclusions are nil, nothing is excluded.
+func (e *MetricExclusions) Excludes(address common.Address, codeHash common.Hash) bool {
+	if e == nil {
+		return false
+	}
+	if _, excluded := e.addresses[address]; excluded {
+		return true
+	}
+	_, excluded := e.codeHashes[codeHash]
+	return excluded
+}
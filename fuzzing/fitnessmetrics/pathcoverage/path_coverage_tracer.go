@@ -0,0 +1,186 @@
+package pathcoverage
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core/tracing"
+	coretypes "github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa-geth/eth/tracers"
+	"github.com/crytic/medusa/chain"
+	"github.com/crytic/medusa/chain/types"
+)
+
+// pathCoverageTracerResultsKey describes the key to use when storing tracer results in call message results, or when
+// querying them.
+const pathCoverageTracerResultsKey = "PathCoverageTracerResults"
+
+// GetPathCoverageTracerResults obtains the TransactionPath stored by a PathCoverageTracer from message results. This is
+// nil if no TransactionPath was recorded by a tracer (e.g. PathCoverageTracer was not attached during this message
+// execution).
+func GetPathCoverageTracerResults(messageResults *types.MessageResults) *TransactionPath {
+	// Try to obtain the results the tracer should've stored.
+	if genericResult, ok := messageResults.AdditionalResults[pathCoverageTracerResultsKey]; ok {
+		if castedResult, ok := genericResult.(*TransactionPath); ok {
+			return castedResult
+		}
+	}
+
+	// If we could not obtain them, return nil.
+	return nil
+}
+
+// RemovePathCoverageTracerResults removes the TransactionPath stored by a PathCoverageTracer from message results.
+func RemovePathCoverageTracerResults(messageResults *types.MessageResults) {
+	delete(messageResults.AdditionalResults, pathCoverageTracerResultsKey)
+}
+
+// TransactionPath describes the path taken by a transaction, as recorded by a PathCoverageTracer.
+type TransactionPath struct {
+	// Hash is the hash of the ordered branch decisions taken by the transaction, across the call frames which did not
+	// revert.
+	Hash uint64
+
+	// Reverted indicates whether the transaction reverted, in which case its path should not be rewarded.
+	Reverted bool
+}
+
+// PathCoverageTracer implements vm.EVMLogger to hash the ordered branch decisions (conditional jump locations and
+// outcomes) taken by transactions into path hashes. Branch decisions of call frames which reverted are discarded, and
+// those of nested call frames are folded into the path of their parent frame as a whole, once they exit.
+type PathCoverageTracer struct {
+	// transactionPath describes the path recorded for the current transaction.
+	transactionPath *TransactionPath
+
+	// callFrameStates describes the state tracked by the tracer per call frame.
+	callFrameStates []*pathCoverageTracerCallFrameState
+
+	// callDepth refers to the current EVM depth during tracing.
+	callDepth int
+
+	// evmContext holds the VM context during tracing
+	evmContext *tracing.VMContext
+
+	// nativeTracer is the underlying tracer used to capture EVM execution.
+	nativeTracer *chain.TestChainTracer
+}
+
+// pathCoverageTracerCallFrameState tracks state across call frames in the tracer.
+type pathCoverageTracerCallFrameState struct {
+	// hash describes the path hash of the branch decisions taken in this call frame (and its nested call frames which
+	// exited successfully) so far.
+	hash uint64
+}
+
+// NewPathCoverageTracer returns a new PathCoverageTracer.
+func NewPathCoverageTracer() *PathCoverageTracer {
+	tracer := &PathCoverageTracer{
+		callFrameStates: make([]*pathCoverageTracerCallFrameState, 0),
+	}
+	nativeTracer := &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnTxStart: tracer.OnTxStart,
+			OnEnter:   tracer.OnEnter,
+			OnExit:    tracer.OnExit,
+			OnOpcode:  tracer.OnOpcode,
+		},
+	}
+	tracer.nativeTracer = &chain.TestChainTracer{Tracer: nativeTracer, CaptureTxEndSetAdditionalResults: tracer.CaptureTxEndSetAdditionalResults}
+	return tracer
+}
+
+// NativeTracer returns the underlying TestChainTracer.
+func (t *PathCoverageTracer) NativeTracer() *chain.TestChainTracer {
+	return t.nativeTracer
+}
+
+// OnTxStart is called upon the start of transaction execution, as defined by tracers.Tracer.
+func (t *PathCoverageTracer) OnTxStart(vm *tracing.VMContext, tx *coretypes.Transaction, from common.Address) {
+	// Reset our call frame states
+	t.callDepth = 0
+	t.transactionPath = nil
+	t.callFrameStates = make([]*pathCoverageTracerCallFrameState, 0)
+	t.evmContext = vm
+}
+
+// OnEnter is called upon entering of the call frame, as defined by tracers.Tracer.
+func (t *PathCoverageTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	isTopLevelFrame := depth == 0
+	if !isTopLevelFrame {
+		t.callDepth++
+	}
+
+	// Create our state tracking struct for this frame, seeding its path with the called address, so the same branch
+	// decisions taken in distinct contracts yield distinct paths.
+	t.callFrameStates = append(t.callFrameStates, &pathCoverageTracerCallFrameState{
+		hash: foldPathHash(pathHashOffset, binary.BigEndian.Uint64(to[common.AddressLength-8:])),
+	})
+}
+
+// OnExit is called upon exiting of the call frame, as defined by tracers.Tracer.
+func (t *PathCoverageTracer) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	currentCallFrameState := t.callFrameStates[t.callDepth]
+
+	// Check to see if this is the top level call frame
+	isTopLevelFrame := depth == 0
+
+	// Commit our path up one call frame, unless this frame reverted, in which case its branch decisions are discarded.
+	if isTopLevelFrame {
+		t.transactionPath = &TransactionPath{
+			Hash:     currentCallFrameState.hash,
+			Reverted: reverted,
+		}
+	} else {
+		if !reverted {
+			parentCallFrameState := t.callFrameStates[t.callDepth-1]
+			parentCallFrameState.hash = foldPathHash(parentCallFrameState.hash, currentCallFrameState.hash)
+		}
+
+		// Pop the state tracking struct for this call frame off the stack and decrement the call depth
+		t.callFrameStates = t.callFrameStates[:t.callDepth]
+		t.callDepth--
+	}
+}
+
+// OnOpcode records data from an EVM state update, as defined by tracers.Tracer.
+func (t *PathCoverageTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	// Only conditional jumps make branch decisions.
+	if vm.OpCode(op) != vm.JUMPI {
+		return
+	}
+	t.recordBranchDecision(pc, scope.(*vm.ScopeContext))
+}
+
+// OnOpcodeStep records an opcode dispatched by a chain.TestChainTracerMultiplexer, as defined by
+// chain.OpcodeStepTracer.
+func (t *PathCoverageTracer) OnOpcodeStep(step *chain.OpcodeStep) {
+	t.recordBranchDecision(step.Pc, step.Scope)
+}
+
+// HandledOpcodes returns the opcodes OnOpcodeStep should be called for, as defined by chain.OpcodeStepTracer.
+func (t *PathCoverageTracer) HandledOpcodes() []vm.OpCode {
+	return []vm.OpCode{vm.JUMPI}
+}
+
+// recordBranchDecision folds the branch decision (its location and outcome) of the JUMPI at the provided location into
+// our call frame's path.
+func (t *PathCoverageTracer) recordBranchDecision(pc uint64, scope *vm.ScopeContext) {
+	callFrameState := t.callFrameStates[t.callDepth]
+	decision := pc << 1
+	if !scope.Stack.Back(1).IsZero() {
+		decision |= 1
+	}
+	callFrameState.hash = foldPathHash(callFrameState.hash, decision)
+}
+
+// CaptureTxEndSetAdditionalResults can be used to set additional results captured from execution tracing. If this
+// tracer is used during transaction execution (block creation), the results can later be queried from the block.
+// This method will only be called on the added tracer if it implements the extended TestChainTracer interface.
+func (t *PathCoverageTracer) CaptureTxEndSetAdditionalResults(results *types.MessageResults) {
+	// Store our tracer results.
+	if t.transactionPath != nil {
+		results.AdditionalResults[pathCoverageTracerResultsKey] = t.transactionPath
+	}
+}
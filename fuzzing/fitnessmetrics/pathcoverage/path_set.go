@@ -0,0 +1,138 @@
+package pathcoverage
+
+import (
+	"math"
+	"sync"
+
+	"github.com/crytic/medusa/chain/types"
+)
+
+// pathHashOffset is the initial value of a path hash, before any branch decision was folded into it (the FNV-1a 64-bit
+// offset basis).
+const pathHashOffset uint64 = 0xcbf29ce484222325
+
+// pathHashPrime is the multiplier used to fold values into a path hash (the FNV-1a 64-bit prime).
+const pathHashPrime uint64 = 0x100000001b3
+
+// foldPathHash folds the provided value into a path hash, such that the resulting hash depends on the order in which
+// values were folded.
+func foldPathHash(hash uint64, value uint64) uint64 {
+	return (hash ^ value) * pathHashPrime
+}
+
+// mixPathHash finalizes a path hash so its bits are uniformly distributed (the SplitMix64 finalizer). It is used to
+// derive the second hash of the bloom filter's double hashing scheme.
+func mixPathHash(hash uint64) uint64 {
+	hash ^= hash >> 30
+	hash *= 0xbf58476d1ce4e5b9
+	hash ^= hash >> 27
+	hash *= 0x94d049bb133111eb
+	hash ^= hash >> 31
+	return hash
+}
+
+// GetSequencePathHash folds the path hashes of the transactions which did not revert, in the order of the provided
+// message results, into a single path hash for a call sequence.
+// Returns the sequence path hash, and a boolean indicating whether any transaction path was recorded.
+func GetSequencePathHash(messageResults []*types.MessageResults) (uint64, bool) {
+	hash := pathHashOffset
+	recorded := false
+	for _, messageResult := range messageResults {
+		transactionPath := GetPathCoverageTracerResults(messageResult)
+		if transactionPath == nil || transactionPath.Reverted {
+			continue
+		}
+		hash = foldPathHash(hash, transactionPath.Hash)
+		recorded = true
+	}
+	return hash, recorded
+}
+
+// PathSet represents a bounded set of path hashes, backed by a bloom filter. Paths are never reported as novel twice,
+// but as the filter fills up, novel paths are increasingly reported as already observed (collisions).
+type PathSet struct {
+	// bits describes the bloom filter bits. It is allocated upon the first path being added, so unused sets are cheap.
+	bits []uint64
+
+	// numBits describes the amount of bits in the bloom filter.
+	numBits uint64
+
+	// numHashes describes the amount of bits set in the bloom filter per path.
+	numHashes uint64
+
+	// setBits describes the amount of bits currently set in the bloom filter.
+	setBits uint64
+
+	// novelPaths describes the amount of paths which were added to the set as novel.
+	novelPaths uint64
+
+	// observedPaths describes the amount of paths which were added to the set, novel or not.
+	observedPaths uint64
+
+	// lock offers concurrent thread safety for bloom filter accesses.
+	lock sync.Mutex
+}
+
+// NewPathSet initializes a new PathSet backed by a bloom filter of the provided amount of bits, setting the provided
+// amount of bits per path.
+func NewPathSet(numBits uint64, numHashes uint64) *PathSet {
+	// Ensure the bloom filter holds at least one word and sets at least one bit per path.
+	numBits = max(numBits, 64)
+	numHashes = max(numHashes, 1)
+	return &PathSet{
+		numBits:   numBits,
+		numHashes: numHashes,
+	}
+}
+
+// Add adds the provided path hash to the set.
+// Returns a boolean indicating whether the path was novel (not observed before, barring collisions).
+func (ps *PathSet) Add(hash uint64) bool {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	if ps.bits == nil {
+		ps.bits = make([]uint64, (ps.numBits+63)/64)
+	}
+
+	// Derive the bit indexes using double hashing (Kirsch-Mitzenmacher), setting each unset bit.
+	novel := false
+	secondHash := mixPathHash(hash) | 1
+	for i := uint64(0); i < ps.numHashes; i++ {
+		index := (hash + i*secondHash) % ps.numBits
+		word, mask := index/64, uint64(1)<<(index%64)
+		if ps.bits[word]&mask == 0 {
+			ps.bits[word] |= mask
+			ps.setBits++
+			novel = true
+		}
+	}
+
+	ps.observedPaths++
+	if novel {
+		ps.novelPaths++
+	}
+	return novel
+}
+
+// NovelPathCount returns the amount of distinct paths recorded in the set.
+func (ps *PathSet) NovelPathCount() uint64 {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	return ps.novelPaths
+}
+
+// ObservedPathCount returns the amount of paths added to the set, including paths observed before.
+func (ps *PathSet) ObservedPathCount() uint64 {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	return ps.observedPaths
+}
+
+// CollisionRate returns the estimated probability that a novel path is mistaken for one observed before, given the
+// current fill ratio of the bloom filter.
+func (ps *PathSet) CollisionRate() float64 {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	return math.Pow(float64(ps.setBits)/float64(ps.numBits), float64(ps.numHashes))
+}
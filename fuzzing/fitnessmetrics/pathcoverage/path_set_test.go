@@ -0,0 +1,76 @@
+package pathcoverage
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPathSetAdd tests that paths are reported as novel the first time they are added to a path set only.
+func TestPathSetAdd(t *testing.T) {
+	pathSet := NewPathSet(1<<16, 4)
+
+	// Use hashes derived from a path hash fold, as the tracer does, so they are spread like real path hashes.
+	hashes := make([]uint64, 100)
+	for i := range hashes {
+		hashes[i] = foldPathHash(pathHashOffset, uint64(i))
+	}
+
+	// With a large filter, every distinct path should be novel the first time it is added.
+	for _, hash := range hashes {
+		assert.True(t, pathSet.Add(hash))
+	}
+	assert.EqualValues(t, len(hashes), pathSet.NovelPathCount())
+	assert.EqualValues(t, len(hashes), pathSet.ObservedPathCount())
+
+	// Adding the paths again should never report them as novel, but should count them as observed.
+	for _, hash := range hashes {
+		assert.False(t, pathSet.Add(hash))
+	}
+	assert.EqualValues(t, len(hashes), pathSet.NovelPathCount())
+	assert.EqualValues(t, 2*len(hashes), pathSet.ObservedPathCount())
+}
+
+// TestPathSetBounds tests that a path set is clamped to a filter of at least one word and one bit per path.
+func TestPathSetBounds(t *testing.T) {
+	pathSet := NewPathSet(0, 0)
+	assert.EqualValues(t, 64, pathSet.numBits)
+	assert.EqualValues(t, 1, pathSet.numHashes)
+
+	// The filter is only allocated once a path is added.
+	assert.Nil(t, pathSet.bits)
+	assert.Zero(t, pathSet.CollisionRate())
+
+	// With a single bit per path, a path sets exactly one bit.
+	assert.True(t, pathSet.Add(pathHashOffset))
+	assert.Len(t, pathSet.bits, 1)
+	assert.EqualValues(t, 1, pathSet.setBits)
+}
+
+// TestPathSetCollisionRate tests that the collision rate of a path set follows the fill ratio of its bloom filter, and
+// that a saturated filter reports novel paths as already observed.
+func TestPathSetCollisionRate(t *testing.T) {
+	pathSet := NewPathSet(64, 2)
+	assert.Zero(t, pathSet.CollisionRate())
+
+	// The collision rate should be the fill ratio raised to the amount of bits per path, and grow as paths are added.
+	previousRate := 0.0
+	for i := uint64(0); i < 8; i++ {
+		pathSet.Add(foldPathHash(pathHashOffset, i))
+		rate := pathSet.CollisionRate()
+		assert.InDelta(t, math.Pow(float64(pathSet.setBits)/64, 2), rate, 1e-12)
+		assert.GreaterOrEqual(t, rate, previousRate)
+		previousRate = rate
+	}
+
+	// Saturate the filter. Once every bit is set, the collision rate is one, and no path can be novel anymore.
+	for i := uint64(8); pathSet.setBits < pathSet.numBits; i++ {
+		pathSet.Add(foldPathHash(pathHashOffset, i))
+	}
+	assert.EqualValues(t, 1, pathSet.CollisionRate())
+	novelPaths := pathSet.NovelPathCount()
+	assert.False(t, pathSet.Add(foldPathHash(pathHashOffset, math.MaxUint64)))
+	assert.Equal(t, novelPaths, pathSet.NovelPathCount())
+	assert.Less(t, novelPaths, pathSet.ObservedPathCount())
+}
@@ -0,0 +1,55 @@
+package fitnessmetrics
+
+import (
+	"github.com/crytic/medusa/chain/types"
+)
+
+// ClearableResult describes a result recorded by a tracer (e.g. coverage maps) which can be emptied for reuse.
+type ClearableResult interface {
+	// Clear empties the result like Reset, but retains the memory allocated for it so that it may be reused.
+	Clear()
+}
+
+// ResultReleaser describes a tracer whose results can be released back to it once they were consumed, so that it
+// reuses them for later transactions rather than allocating new ones.
+type ResultReleaser interface {
+	// ReleaseResults releases the results of the latest transaction traced, if they were stored in the provided
+	// message results. They are removed from the message results, so that they are not referenced after being reused.
+	ReleaseResults(results *types.MessageResults)
+}
+
+// ResultPool recycles the results recorded by a tracer for each transaction and call frame, so that steady-state
+// tracing reuses the memory of released results rather than allocating new ones. It is not thread-safe, as each tracer
+// is owned by a single worker.
+type ResultPool[T ClearableResult] struct {
+	// results describes the released results which are available for reuse.
+	results []T
+
+	// newResult creates a result when none are available for reuse.
+	newResult func() T
+}
+
+// NewResultPool creates a new ResultPool which uses the provided function to create results when none are available
+// for reuse.
+func NewResultPool[T ClearableResult](newResult func() T) *ResultPool[T] {
+	return &ResultPool[T]{
+		results:   make([]T, 0),
+		newResult: newResult,
+	}
+}
+
+// Get obtains an empty result, reusing a released one if possible.
+func (p *ResultPool[T]) Get() T {
+	if count := len(p.results); count > 0 {
+		result := p.results[count-1]
+		p.results = p.results[:count-1]
+		return result
+	}
+	return p.newResult()
+}
+
+// Put clears the provided result and makes it available for reuse. It must not be referenced after being released.
+func (p *ResultPool[T]) Put(result T) {
+	result.Clear()
+	p.results = append(p.results, result)
+}
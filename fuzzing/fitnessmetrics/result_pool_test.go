@@ -0,0 +1,48 @@
+package fitnessmetrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testResult is a ClearableResult counting the values recorded in it.
+type testResult struct {
+	values []int
+}
+
+// Clear empties the result, retaining the memory allocated for its values.
+func (r *testResult) Clear() {
+	r.values = r.values[:0]
+}
+
+// TestResultPool tests that results are only created when no released result is available, and that released results
+// are cleared before they are reused.
+func TestResultPool(t *testing.T) {
+	created := 0
+	pool := NewResultPool(func() *testResult {
+		created++
+		return &testResult{}
+	})
+
+	first := pool.Get()
+	second := pool.Get()
+	assert.NotSame(t, first, second)
+	assert.EqualValues(t, 2, created)
+
+	// Released results are cleared, and reused in the reverse order they were released in.
+	first.values = append(first.values, 1, 2)
+	second.values = append(second.values, 3)
+	pool.Put(first)
+	pool.Put(second)
+	assert.Empty(t, first.values)
+	assert.Empty(t, second.values)
+	assert.Same(t, second, pool.Get())
+	assert.Same(t, first, pool.Get())
+	assert.Positive(t, cap(first.values))
+	assert.EqualValues(t, 2, created)
+
+	// Once released results are exhausted, new ones are created.
+	assert.NotSame(t, first, pool.Get())
+	assert.EqualValues(t, 3, created)
+}
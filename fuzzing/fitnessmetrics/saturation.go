@@ -0,0 +1,140 @@
+package fitnessmetrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/crytic/medusa/logging"
+	"github.com/crytic/medusa/logging/colors"
+)
+
+// FitnessMetric identifies a fitness metric whose tracer guides the fuzzer.
+type FitnessMetric string
+
+const (
+	CodeCoverageMetric     FitnessMetric = "code coverage"
+	BranchCoverageMetric   FitnessMetric = "branch coverage"
+	EdgeCoverageMetric     FitnessMetric = "edge coverage"
+	PathCoverageMetric     FitnessMetric = "path coverage"
+	SelectorCoverageMetric FitnessMetric = "selector coverage"
+	CmpDistanceMetric      FitnessMetric = "cmp distance"
+	BranchDistanceMetric   FitnessMetric = "branch distance"
+	DataflowMetric         FitnessMetric = "dataflow"
+	StorageWriteMetric     FitnessMetric = "storage write"
+	TokenflowMetric        FitnessMetric = "tokenflow"
+	BalanceDeltaMetric     FitnessMetric = "balance delta"
+)
+
+// metricSaturationState tracks the marginal contribution of a single fitness metric.
+type metricSaturationState struct {
+	// executions is the amount of executions traced by the metric in the current window.
+	executions uint64
+	// newItems is the amount of executions which produced new items for the metric in the current window.
+	newItems uint64
+	// saturated indicates whether the metric was found to be saturated and should not be traced.
+	saturated bool
+	// saturatedAt is the time at which the metric was last found to be saturated.
+	saturatedAt time.Time
+}
+
+// SaturationMonitor monitors the marginal contribution of each fitness metric (new items per window of executions)
+// and reports metrics which stopped producing new items as saturated, so their tracers can stop tracing.
+// Saturated metrics are periodically re-probed to check whether they make progress again.
+type SaturationMonitor struct {
+	// config describes the configuration used to detect saturated metrics.
+	config config.FitnessMetricSaturationConfig
+
+	// states tracks the saturation state of each metric which was recorded.
+	states map[FitnessMetric]*metricSaturationState
+
+	// logger describes the logger used to report metrics being disabled or re-probed.
+	logger *logging.Logger
+
+	// lock offers concurrent thread safety for state accesses.
+	lock sync.Mutex
+}
+
+// NewSaturationMonitor creates a new SaturationMonitor with the provided configuration.
+func NewSaturationMonitor(saturationConfig config.FitnessMetricSaturationConfig, logger *logging.Logger) *SaturationMonitor {
+	return &SaturationMonitor{
+		config: saturationConfig,
+		states: make(map[FitnessMetric]*metricSaturationState),
+		logger: logger,
+	}
+}
+
+// Record records an execution for the provided metric. traced indicates whether the metric's tracer was attached
+// during the execution, and updated indicates whether the execution produced new items for the metric. Executions
+// which were not traced are ignored. Once a full window has been recorded, the metric is marked as saturated if it
+// produced too few new items.
+func (m *SaturationMonitor) Record(metric FitnessMetric, traced bool, updated bool) {
+	if !m.config.Enabled || !traced {
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	state := m.state(metric)
+	if state.saturated {
+		return
+	}
+	state.executions++
+	if updated {
+		state.newItems++
+	}
+
+	// Once our window is complete, determine whether the metric has saturated and start a new window.
+	if state.executions >= m.config.Window {
+		if state.newItems < m.config.MinNewItems {
+			state.saturated = true
+			state.saturatedAt = time.Now()
+			m.logger.Info("Fitness metric ", colors.Bold, string(metric), colors.Reset, " saturated (", state.newItems, " new items in ", state.executions, " executions), its tracer will no longer trace call sequences")
+		}
+		state.executions = 0
+		state.newItems = 0
+	}
+}
+
+// Enabled determines whether the tracer for the provided metric should be attached. Saturated metrics are re-enabled
+// for another window once the re-probe interval has elapsed.
+func (m *SaturationMonitor) Enabled(metric FitnessMetric) bool {
+	if !m.config.Enabled {
+		return true
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	state := m.state(metric)
+	if state.saturated && time.Since(state.saturatedAt) >= time.Duration(m.config.ReprobeInterval)*time.Second {
+		state.saturated = false
+		m.logger.Info("Re-probing saturated fitness metric ", colors.Bold, string(metric), colors.Reset)
+	}
+	return !state.saturated
+}
+
+// SaturatedMetrics returns the metrics which are currently saturated.
+func (m *SaturationMonitor) SaturatedMetrics() []FitnessMetric {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	saturatedMetrics := make([]FitnessMetric, 0)
+	for metric, state := range m.states {
+		if state.saturated {
+			saturatedMetrics = append(saturatedMetrics, metric)
+		}
+	}
+	return saturatedMetrics
+}
+
+// state obtains the saturation state for the provided metric, creating it if needed. The lock must be held.
+func (m *SaturationMonitor) state(metric FitnessMetric) *metricSaturationState {
+	state, exists := m.states[metric]
+	if !exists {
+		state = &metricSaturationState{}
+		m.states[metric] = state
+	}
+	return state
+}
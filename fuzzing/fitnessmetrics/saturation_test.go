@@ -0,0 +1,96 @@
+package fitnessmetrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/crytic/medusa/logging"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestSaturationMonitor returns a SaturationMonitor with saturation detection enabled, which marks metrics with
+// less than two new items in a window of four executions as saturated.
+func newTestSaturationMonitor(reprobeInterval uint64) *SaturationMonitor {
+	return NewSaturationMonitor(config.FitnessMetricSaturationConfig{
+		Enabled:         true,
+		Window:          4,
+		MinNewItems:     2,
+		ReprobeInterval: reprobeInterval,
+	}, logging.NewLogger(zerolog.Disabled))
+}
+
+// TestSaturationMonitorWindow tests that a metric is only marked as saturated once a full window of traced executions
+// produced too few new items, and that each window is measured afresh.
+func TestSaturationMonitorWindow(t *testing.T) {
+	monitor := newTestSaturationMonitor(60)
+
+	// A window with enough new items does not saturate the metric.
+	for _, updated := range []bool{true, false, true, false} {
+		monitor.Record(CodeCoverageMetric, true, updated)
+	}
+	assert.True(t, monitor.Enabled(CodeCoverageMetric))
+
+	// Executions which were not traced are not counted towards the window.
+	monitor.Record(CodeCoverageMetric, true, true)
+	for i := 0; i < 8; i++ {
+		monitor.Record(CodeCoverageMetric, false, false)
+	}
+	for i := 0; i < 2; i++ {
+		monitor.Record(CodeCoverageMetric, true, false)
+	}
+	assert.True(t, monitor.Enabled(CodeCoverageMetric))
+	assert.Empty(t, monitor.SaturatedMetrics())
+
+	// Completing the window with a single new item saturates the metric, and only that metric.
+	monitor.Record(CodeCoverageMetric, true, false)
+	assert.False(t, monitor.Enabled(CodeCoverageMetric))
+	assert.True(t, monitor.Enabled(BranchCoverageMetric))
+	assert.EqualValues(t, []FitnessMetric{CodeCoverageMetric}, monitor.SaturatedMetrics())
+
+	// Executions recorded while saturated are ignored.
+	for i := 0; i < 4; i++ {
+		monitor.Record(CodeCoverageMetric, true, true)
+	}
+	assert.False(t, monitor.Enabled(CodeCoverageMetric))
+}
+
+// TestSaturationMonitorReprobe tests that a saturated metric is re-enabled for another window once the re-probe
+// interval elapsed, and saturates again if it still makes no progress.
+func TestSaturationMonitorReprobe(t *testing.T) {
+	monitor := newTestSaturationMonitor(60)
+	for i := 0; i < 4; i++ {
+		monitor.Record(BranchDistanceMetric, true, false)
+	}
+	assert.False(t, monitor.Enabled(BranchDistanceMetric))
+
+	// Once the re-probe interval elapsed, the metric is traced for another window.
+	monitor.states[BranchDistanceMetric].saturatedAt = time.Now().Add(-time.Minute)
+	assert.True(t, monitor.Enabled(BranchDistanceMetric))
+	assert.Empty(t, monitor.SaturatedMetrics())
+
+	// A re-probed metric which still makes no progress saturates again, restarting the re-probe interval.
+	for i := 0; i < 4; i++ {
+		monitor.Record(BranchDistanceMetric, true, false)
+	}
+	assert.False(t, monitor.Enabled(BranchDistanceMetric))
+
+	// A re-probed metric which makes progress again stays enabled.
+	monitor.states[BranchDistanceMetric].saturatedAt = time.Now().Add(-time.Minute)
+	assert.True(t, monitor.Enabled(BranchDistanceMetric))
+	for _, updated := range []bool{true, true, false, false} {
+		monitor.Record(BranchDistanceMetric, true, updated)
+	}
+	assert.True(t, monitor.Enabled(BranchDistanceMetric))
+}
+
+// TestSaturationMonitorDisabled tests that metrics are always enabled if saturation detection is disabled.
+func TestSaturationMonitorDisabled(t *testing.T) {
+	monitor := NewSaturationMonitor(config.FitnessMetricSaturationConfig{Window: 1}, logging.NewLogger(zerolog.Disabled))
+	for i := 0; i < 4; i++ {
+		monitor.Record(CodeCoverageMetric, true, false)
+	}
+	assert.True(t, monitor.Enabled(CodeCoverageMetric))
+	assert.Empty(t, monitor.SaturatedMetrics())
+}
@@ -0,0 +1,209 @@
+package selectorcoverage
+
+import (
+	"math/big"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core/tracing"
+	coretypes "github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa-geth/eth/tracers"
+	"github.com/crytic/medusa/chain"
+	"github.com/crytic/medusa/chain/types"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics"
+	"github.com/crytic/medusa/logging"
+)
+
+// selectorCoverageTracerResultsKey describes the key to use when storing tracer results in call message results, or
+// when querying them.
+const selectorCoverageTracerResultsKey = "SelectorCoverageTracerResults"
+
+// GetSelectorCoverageTracerResults obtains the SelectorSet stored by a SelectorCoverageTracer from message results.
+// This is nil if no SelectorSet was recorded by a tracer (e.g. SelectorCoverageTracer was not attached during this
+// message execution).
+func GetSelectorCoverageTracerResults(messageResults *types.MessageResults) *SelectorSet {
+	// Try to obtain the results the tracer should've stored.
+	if genericResult, ok := messageResults.AdditionalResults[selectorCoverageTracerResultsKey]; ok {
+		if castedResult, ok := genericResult.(*SelectorSet); ok {
+			return castedResult
+		}
+	}
+
+	// If we could not obtain them, return nil.
+	return nil
+}
+
+// RemoveSelectorCoverageTracerResults removes the SelectorSet stored by a SelectorCoverageTracer from message results.
+func RemoveSelectorCoverageTracerResults(messageResults *types.MessageResults) {
+	delete(messageResults.AdditionalResults, selectorCoverageTracerResultsKey)
+}
+
+// SelectorCoverageTracer implements vm.EVMLogger to record the function selectors executed successfully by each
+// contract, for top level and nested calls alike. Only the first instruction of each call frame is inspected, which
+// keeps the tracer lightweight.
+type SelectorCoverageTracer struct {
+	// selectorSet describes the selectors recorded for the current transaction.
+	selectorSet *SelectorSet
+
+	// callFrameStates describes the state tracked by the tracer per call frame.
+	callFrameStates []*selectorCoverageTracerCallFrameState
+
+	// resultPool recycles the selectors recorded for each transaction and call frame.
+	resultPool *fitnessmetrics.ResultPool[*SelectorSet]
+
+	// lastResults describes the message results the selectors of the latest transaction were stored in.
+	lastResults *types.MessageResults
+
+	// callDepth refers to the current EVM depth during tracing.
+	callDepth int
+
+	// evmContext holds the VM context during tracing
+	evmContext *tracing.VMContext
+
+	// nativeTracer is the underlying tracer used to capture EVM execution.
+	nativeTracer *chain.TestChainTracer
+}
+
+// selectorCoverageTracerCallFrameState tracks state across call frames in the tracer.
+type selectorCoverageTracerCallFrameState struct {
+	// initialized tracks whether or not this has happened yet.
+	initialized bool
+
+	// selector describes the function selector called in this call frame. It is nil for contract creations and calls
+	// with less than four bytes of call data (e.g. receive or fallback functions).
+	selector *Selector
+
+	// pendingSelectorSet describes the selectors recorded for this call frame and its nested call frames.
+	pendingSelectorSet *SelectorSet
+}
+
+// NewSelectorCoverageTracer returns a new SelectorCoverageTracer.
+func NewSelectorCoverageTracer() *SelectorCoverageTracer {
+	tracer := &SelectorCoverageTracer{
+		selectorSet:     NewSelectorSet(),
+		callFrameStates: make([]*selectorCoverageTracerCallFrameState, 0),
+		resultPool:      fitnessmetrics.NewResultPool(NewSelectorSet),
+	}
+	nativeTracer := &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnTxStart: tracer.OnTxStart,
+			OnEnter:   tracer.OnEnter,
+			OnExit:    tracer.OnExit,
+			OnOpcode:  tracer.OnOpcode,
+		},
+	}
+	tracer.nativeTracer = &chain.TestChainTracer{Tracer: nativeTracer, CaptureTxEndSetAdditionalResults: tracer.CaptureTxEndSetAdditionalResults}
+	return tracer
+}
+
+// NativeTracer returns the underlying TestChainTracer.
+func (t *SelectorCoverageTracer) NativeTracer() *chain.TestChainTracer {
+	return t.nativeTracer
+}
+
+// OnTxStart is called upon the start of transaction execution, as defined by tracers.Tracer.
+func (t *SelectorCoverageTracer) OnTxStart(vm *tracing.VMContext, tx *coretypes.Transaction, from common.Address) {
+	// Reset our call frame states
+	t.callDepth = 0
+	t.selectorSet = t.resultPool.Get()
+	t.callFrameStates = t.callFrameStates[:0]
+	t.evmContext = vm
+}
+
+// OnEnter is called upon entering of the call frame, as defined by tracers.Tracer.
+func (t *SelectorCoverageTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	isTopLevelFrame := depth == 0
+	if !isTopLevelFrame {
+		t.callDepth++
+	}
+
+	// Create our state tracking struct for this frame, recording the selector called, if any.
+	callFrameState := &selectorCoverageTracerCallFrameState{
+		pendingSelectorSet: t.resultPool.Get(),
+	}
+	isCreate := typ == byte(vm.CREATE) || typ == byte(vm.CREATE2)
+	if !isCreate && len(input) >= 4 {
+		selector := Selector(input[:4])
+		callFrameState.selector = &selector
+	}
+	t.callFrameStates = append(t.callFrameStates, callFrameState)
+}
+
+// OnExit is called upon exiting of the call frame, as defined by tracers.Tracer.
+func (t *SelectorCoverageTracer) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	currentCallFrameState := t.callFrameStates[t.callDepth]
+	currentSelectorSet := currentCallFrameState.pendingSelectorSet
+
+	// Selectors of call frames which reverted were not executed successfully, discard them.
+	if reverted {
+		currentSelectorSet.Clear()
+	}
+
+	// Check to see if this is the top level call frame
+	isTopLevelFrame := depth == 0
+
+	// Commit all our selectors up one call frame.
+	if isTopLevelFrame {
+		_, selectorUpdateErr := t.selectorSet.Update(currentSelectorSet)
+		if selectorUpdateErr != nil {
+			logging.GlobalLogger.Panic("Selector coverage tracer failed to update selector set during capture end", selectorUpdateErr)
+		}
+	} else {
+		// Move selectors up one call frame
+		_, selectorUpdateErr := t.callFrameStates[t.callDepth-1].pendingSelectorSet.Update(currentSelectorSet)
+		if selectorUpdateErr != nil {
+			logging.GlobalLogger.Panic("Selector coverage tracer failed to update selector set during capture exit", selectorUpdateErr)
+		}
+
+		// Pop the state tracking struct for this call frame off the stack and decrement the call depth
+		t.callFrameStates = t.callFrameStates[:t.callDepth]
+		t.callDepth--
+	}
+
+	// The selectors of this call frame were copied up, so its set can be reused.
+	t.resultPool.Put(currentSelectorSet)
+}
+
+// OnOpcode records data from an EVM state update, as defined by tracers.Tracer.
+func (t *SelectorCoverageTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	// Obtain our call frame state tracking struct
+	callFrameState := t.callFrameStates[t.callDepth]
+
+	// Only the first instruction of a call frame is inspected, to obtain the code the selector is executed on.
+	if callFrameState.initialized {
+		return
+	}
+	callFrameState.initialized = true
+	if callFrameState.selector == nil {
+		return
+	}
+
+	// Record the selector as executed on this code. It is discarded upon exit if this call frame reverts.
+	code := scope.(*vm.ScopeContext).Contract.Code
+	if len(code) > 0 {
+		callFrameState.pendingSelectorSet.SetAt(getContractSelectorSetHash(code), *callFrameState.selector)
+	}
+}
+
+// CaptureTxEndSetAdditionalResults can be used to set additional results captured from execution tracing. If this
+// tracer is used during transaction execution (block creation), the results can later be queried from the block.
+// This method will only be called on the added tracer if it implements the extended TestChainTracer interface.
+func (t *SelectorCoverageTracer) CaptureTxEndSetAdditionalResults(results *types.MessageResults) {
+	// Store our tracer results.
+	results.AdditionalResults[selectorCoverageTracerResultsKey] = t.selectorSet
+	t.lastResults = results
+}
+
+// ReleaseResults releases the selectors of the latest transaction for reuse, if they were stored in the provided
+// message results, as defined by fitnessmetrics.ResultReleaser. They are removed from the message results.
+func (t *SelectorCoverageTracer) ReleaseResults(results *types.MessageResults) {
+	if t == nil || t.selectorSet == nil || results != t.lastResults {
+		return
+	}
+	if GetSelectorCoverageTracerResults(results) == t.selectorSet {
+		RemoveSelectorCoverageTracerResults(results)
+	}
+	t.resultPool.Put(t.selectorSet)
+	t.selectorSet = nil
+	t.lastResults = nil
+}
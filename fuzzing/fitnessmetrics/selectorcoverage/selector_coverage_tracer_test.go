@@ -0,0 +1,110 @@
+package selectorcoverage
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/crytic/medusa-geth/accounts/abi"
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core"
+	coretypes "github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa/chain"
+	compilationTypes "github.com/crytic/medusa/compilation/types"
+	fuzzerTypes "github.com/crytic/medusa/fuzzing/contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	// selectorCoverageTestSender is the address sending the transactions executed by the tracer tests.
+	selectorCoverageTestSender = common.HexToAddress("0x10000")
+	// selectorCoverageTestContract is the address of the contract executed by the tracer tests.
+	selectorCoverageTestContract = common.HexToAddress("0x20000")
+)
+
+// executeSelectorCoverageTracer executes a call with the provided data to selectorCoverageTestContract, holding the
+// provided runtime bytecode, with a SelectorCoverageTracer attached.
+// Returns the selectors recorded.
+func executeSelectorCoverageTracer(t *testing.T, code []byte, data []byte) *SelectorSet {
+	testChain, err := chain.NewTestChain(context.Background(), coretypes.GenesisAlloc{
+		selectorCoverageTestSender:   {Balance: big.NewInt(1_000_000)},
+		selectorCoverageTestContract: {Balance: big.NewInt(0), Code: code},
+	}, nil)
+	assert.NoError(t, err)
+	defer testChain.Close()
+	tracer := NewSelectorCoverageTracer()
+	testChain.AddTracer(tracer.NativeTracer(), true, false)
+
+	_, err = testChain.PendingBlockCreate()
+	assert.NoError(t, err)
+	err = testChain.PendingBlockAddTx(&core.Message{
+		From:      selectorCoverageTestSender,
+		To:        &selectorCoverageTestContract,
+		Value:     big.NewInt(0),
+		Data:      data,
+		GasLimit:  1_000_000,
+		GasPrice:  big.NewInt(0),
+		GasFeeCap: big.NewInt(0),
+		GasTipCap: big.NewInt(0),
+	})
+	assert.NoError(t, err)
+	return GetSelectorCoverageTracerResults(testChain.PendingBlock().MessageResults[0])
+}
+
+// TestSelectorCoverageTracer tests that the tracer records the selector called on the code of a contract, unless the
+// call reverts or its call data is too short to hold a selector.
+func TestSelectorCoverageTracer(t *testing.T) {
+	stopCode := []byte{byte(vm.STOP)}
+	revertCode := []byte{byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.REVERT)}
+	selector := Selector{0xa9, 0x05, 0x9c, 0xbb}
+	tests := []struct {
+		name     string
+		code     []byte
+		data     []byte
+		recorded bool
+	}{
+		{name: "call succeeding", code: stopCode, data: append(selector[:], 1, 2), recorded: true},
+		{name: "call reverting", code: revertCode, data: selector[:]},
+		{name: "call data without a selector", code: stopCode, data: selector[:3]},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selectorSet := executeSelectorCoverageTracer(t, test.code, test.data)
+			if test.recorded {
+				assert.EqualValues(t, 1, selectorSet.TotalSelectorCount())
+				assert.Contains(t, selectorSet.selectors[getContractSelectorSetHash(test.code)], selector)
+			} else {
+				assert.EqualValues(t, 0, selectorSet.TotalSelectorCount())
+			}
+		})
+	}
+}
+
+// TestGetUnreachedMethods tests that the methods of a contract which were not executed successfully on its runtime
+// bytecode are listed, sorted by signature.
+func TestGetUnreachedMethods(t *testing.T) {
+	contractAbi, err := abi.JSON(strings.NewReader(`[
+		{"type": "function", "name": "withdraw", "inputs": [], "outputs": []},
+		{"type": "function", "name": "deposit", "inputs": [], "outputs": []},
+		{"type": "function", "name": "approve", "inputs": [{"name": "spender", "type": "address"}], "outputs": []}
+	]`))
+	assert.NoError(t, err)
+	runtimeBytecode := []byte{byte(vm.STOP)}
+	contract := fuzzerTypes.NewContract("Vault", "src/Vault.sol", &compilationTypes.CompiledContract{
+		Abi:             contractAbi,
+		RuntimeBytecode: runtimeBytecode,
+	}, nil)
+
+	selectorSet := NewSelectorSet()
+	assert.Len(t, selectorSet.GetUnreachedMethods(contract), 3)
+
+	// Selectors executed on other code do not reach the contract's methods.
+	selectorSet.SetAt(common.Hash{1}, Selector(contractAbi.Methods["deposit"].ID))
+	selectorSet.SetAt(getContractSelectorSetHash(runtimeBytecode), Selector(contractAbi.Methods["withdraw"].ID))
+	unreachedMethods := selectorSet.GetUnreachedMethods(contract)
+	assert.Len(t, unreachedMethods, 2)
+	assert.EqualValues(t, "approve(address)", unreachedMethods[0].Sig)
+	assert.EqualValues(t, "deposit()", unreachedMethods[1].Sig)
+}
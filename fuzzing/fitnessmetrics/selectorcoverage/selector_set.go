@@ -0,0 +1,149 @@
+package selectorcoverage
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/crytic/medusa-geth/accounts/abi"
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/crypto"
+	compilationTypes "github.com/crytic/medusa/compilation/types"
+	fuzzerTypes "github.com/crytic/medusa/fuzzing/contracts"
+)
+
+// Selector represents an ABI function selector, the first four bytes of call data.
+type Selector [4]byte
+
+// SelectorSet represents a data structure used to identify which function selectors of various smart contracts were
+// executed successfully (in call frames which did not revert) across a transaction or multiple transactions.
+type SelectorSet struct {
+	// selectors describes the selectors executed successfully, keyed by the lookup hash of the contract code they
+	// were executed on.
+	selectors map[common.Hash]map[Selector]struct{}
+
+	// lock is a read-write mutex to offer concurrent thread safety for set accesses.
+	lock sync.RWMutex
+}
+
+// NewSelectorSet initializes a new SelectorSet object.
+func NewSelectorSet() *SelectorSet {
+	set := &SelectorSet{}
+	set.Reset()
+	return set
+}
+
+// Reset clears the selector coverage state for the SelectorSet.
+func (ss *SelectorSet) Reset() {
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+
+	ss.selectors = make(map[common.Hash]map[Selector]struct{})
+}
+
+// Clear clears the selector coverage state for the SelectorSet like Reset, but retains the memory allocated for it, so
+// that selectors recorded afterwards reuse it.
+func (ss *SelectorSet) Clear() {
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+
+	// Empty selector lookups are kept, as only the selectors within them are observable.
+	for _, selectors := range ss.selectors {
+		clear(selectors)
+	}
+}
+
+// TotalSelectorCount returns the amount of distinct (contract code, selector) pairs executed successfully.
+func (ss *SelectorSet) TotalSelectorCount() int {
+	ss.lock.RLock()
+	defer ss.lock.RUnlock()
+
+	count := 0
+	for _, selectors := range ss.selectors {
+		count += len(selectors)
+	}
+	return count
+}
+
+// GetUnreachedMethods returns the ABI methods of the provided contract which were never executed successfully, sorted
+// by signature.
+func (ss *SelectorSet) GetUnreachedMethods(contract *fuzzerTypes.Contract) []abi.Method {
+	compiledContract := contract.CompiledContract()
+	lookupHash := getContractSelectorSetHash(compiledContract.RuntimeBytecode)
+
+	ss.lock.RLock()
+	defer ss.lock.RUnlock()
+
+	unreachedMethods := make([]abi.Method, 0)
+	for _, method := range compiledContract.Abi.Methods {
+		if _, covered := ss.selectors[lookupHash][Selector(method.ID)]; !covered {
+			unreachedMethods = append(unreachedMethods, method)
+		}
+	}
+	sort.Slice(unreachedMethods, func(i, j int) bool {
+		return unreachedMethods[i].Sig < unreachedMethods[j].Sig
+	})
+	return unreachedMethods
+}
+
+// Update updates the current selector set with the provided one.
+// Returns a boolean indicating whether new selectors were executed successfully, or an error if one occurred.
+func (ss *SelectorSet) Update(selectorSet *SelectorSet) (bool, error) {
+	// If our set provided is nil, do nothing
+	if selectorSet == nil {
+		return false, nil
+	}
+
+	// Acquire our thread lock and defer our unlocking for when we exit this method
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+
+	updated := false
+	for lookupHash, selectors := range selectorSet.selectors {
+		for selector := range selectors {
+			updated = ss.add(lookupHash, selector) || updated
+		}
+	}
+	return updated, nil
+}
+
+// SetAt records the provided selector as executed successfully on the contract code with the provided lookup hash.
+// Returns a boolean indicating whether the selector was executed successfully for the first time.
+func (ss *SelectorSet) SetAt(codeLookupHash common.Hash, selector Selector) bool {
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+
+	return ss.add(codeLookupHash, selector)
+}
+
+// add records the provided selector for the provided contract code lookup hash. The lock must be held by the caller.
+// Returns a boolean indicating whether the selector was recorded for the first time.
+func (ss *SelectorSet) add(codeLookupHash common.Hash, selector Selector) bool {
+	selectors, exists := ss.selectors[codeLookupHash]
+	if !exists {
+		selectors = make(map[Selector]struct{})
+		ss.selectors[codeLookupHash] = selectors
+	}
+	if _, covered := selectors[selector]; covered {
+		return false
+	}
+	selectors[selector] = struct{}{}
+	return true
+}
+
+// getContractSelectorSetHash obtain the hash used to look up the selectors executed on a given contract's runtime
+// bytecode. The metadata ipfs/swarm hash will be used if available, as it also matches deployed bytecode whose
+// immutables were set, otherwise the bytecode is hashed after attempting to strip metadata.
+func getContractSelectorSetHash(bytecode []byte) common.Hash {
+	// If available, the metadata code hash should be unique and reliable to use above all.
+	metadata := compilationTypes.ExtractContractMetadata(bytecode)
+	if metadata != nil {
+		metadataHash := metadata.ExtractBytecodeHash()
+		if metadataHash != nil {
+			return common.BytesToHash(metadataHash)
+		}
+	}
+
+	// Otherwise, we use the hash of the bytecode after attempting to strip metadata.
+	strippedBytecode := compilationTypes.RemoveContractMetadata(bytecode)
+	return crypto.Keccak256Hash(strippedBytecode)
+}
@@ -0,0 +1,88 @@
+package selectorcoverage
+
+import (
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/chain/types"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSelectorSetUpdateCopies tests that updating a set copies the selectors merged, so the merged set can be released
+// and reused for another call frame without affecting the updated set.
+func TestSelectorSetUpdateCopies(t *testing.T) {
+	pool := fitnessmetrics.NewResultPool(NewSelectorSet)
+	selectorSet := NewSelectorSet()
+	frameSet := pool.Get()
+	frameSet.SetAt(common.Hash{1}, Selector{1})
+
+	updated, err := selectorSet.Update(frameSet)
+	assert.NoError(t, err)
+	assert.True(t, updated)
+	pool.Put(frameSet)
+
+	// Reuse the released set for another call frame, which starts out empty.
+	otherFrameSet := pool.Get()
+	assert.Same(t, frameSet, otherFrameSet)
+	assert.EqualValues(t, 0, otherFrameSet.TotalSelectorCount())
+	otherFrameSet.SetAt(common.Hash{1}, Selector{2})
+
+	assert.Len(t, selectorSet.selectors[common.Hash{1}], 1)
+	assert.Contains(t, selectorSet.selectors[common.Hash{1}], Selector{1})
+	updated, err = selectorSet.Update(otherFrameSet)
+	assert.NoError(t, err)
+	assert.True(t, updated)
+	assert.EqualValues(t, 2, selectorSet.TotalSelectorCount())
+}
+
+// TestSelectorCoverageTracerReleaseResults tests that the tracer only releases the selectors of its latest transaction
+// from the message results they were stored in, and reuses them once released.
+func TestSelectorCoverageTracerReleaseResults(t *testing.T) {
+	tracer := &SelectorCoverageTracer{resultPool: fitnessmetrics.NewResultPool(NewSelectorSet)}
+	tracer.selectorSet = tracer.resultPool.Get()
+	selectorSet := tracer.selectorSet
+	selectorSet.SetAt(common.Hash{1}, Selector{1})
+	results := &types.MessageResults{AdditionalResults: make(map[string]any)}
+	tracer.CaptureTxEndSetAdditionalResults(results)
+
+	// Results stored in other message results are not released.
+	tracer.ReleaseResults(&types.MessageResults{AdditionalResults: make(map[string]any)})
+	assert.Same(t, selectorSet, GetSelectorCoverageTracerResults(results))
+
+	tracer.ReleaseResults(results)
+	assert.Nil(t, GetSelectorCoverageTracerResults(results))
+	assert.Nil(t, tracer.selectorSet)
+	assert.NotPanics(t, func() { tracer.ReleaseResults(results) })
+	assert.NotPanics(t, func() { (*SelectorCoverageTracer)(nil).ReleaseResults(results) })
+
+	// The released set is cleared and reused for the next transaction.
+	assert.Same(t, selectorSet, tracer.resultPool.Get())
+	assert.EqualValues(t, 0, selectorSet.TotalSelectorCount())
+}
+
+// BenchmarkSelectorSetPerCallFrame measures recording the selector of a call frame and merging it into those of its
+// transaction, with a set allocated for each call frame.
+func BenchmarkSelectorSetPerCallFrame(b *testing.B) {
+	transactionSet := NewSelectorSet()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		frameSet := NewSelectorSet()
+		frameSet.SetAt(common.Hash{1}, Selector{byte(i % 64)})
+		_, _ = transactionSet.Update(frameSet)
+	}
+}
+
+// BenchmarkSelectorSetResultPool measures recording the selector of a call frame and merging it into those of its
+// transaction, with sets recycled through a fitnessmetrics.ResultPool as done by the tracer.
+func BenchmarkSelectorSetResultPool(b *testing.B) {
+	pool := fitnessmetrics.NewResultPool(NewSelectorSet)
+	transactionSet := NewSelectorSet()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		frameSet := pool.Get()
+		frameSet.SetAt(common.Hash{1}, Selector{byte(i % 64)})
+		_, _ = transactionSet.Update(frameSet)
+		pool.Put(frameSet)
+	}
+}
@@ -46,6 +46,9 @@ func (s *StorageSlot) String() string {
 type StorageWrite struct {
 	Position *ProgramPosition
 	Variable *StorageSlot
+	// Delete indicates whether the write set a previously non-zero slot to zero (e.g. a position closed or a lock
+	// released), which is kept apart from writes of small values.
+	Delete bool
 }
 
 func (s *StorageWrite) String() string {
@@ -58,26 +61,18 @@ func (s *StorageWrite) String() string {
 	return sb.String()
 }
 
-var (
-	slice0 = uint256.NewInt(uint64(1)).Lsh(uint256.NewInt(uint64(1)), 4)  // 2^4
-	slice1 = uint256.NewInt(uint64(1)).Lsh(uint256.NewInt(uint64(1)), 16) // 2^16
-	slice2 = uint256.NewInt(uint64(1)).Lsh(uint256.NewInt(uint64(1)), 64) // 2^64
-)
-
-// mapping a value to a abstract bucket string
-func bucket(value *uint256.Int) string {
-	if value.Cmp(slice0) < 0 {
-		return "0-2^4"
-	} else if value.Cmp(slice1) < 0 {
-		return "2^4-2^16"
-	} else if value.Cmp(slice2) < 0 {
-		return "2^16-2^64"
-	} else {
-		return "2^64-2^256"
+// ValueBucket returns the abstract bucket the provided bucketer maps the written value to, or "delete" if the write
+// deleted the slot.
+func (s *StorageWrite) ValueBucket(bucketer *ValueBucketer) string {
+	if s.Delete {
+		return "delete"
 	}
+	return bucketer.Bucket(s.Variable.Value)
 }
 
-func (s *StorageWrite) Bucket() string {
+// Bucket returns the string representation of the storage write, with the written value mapped to its abstract bucket
+// by the provided bucketer.
+func (s *StorageWrite) Bucket(bucketer *ValueBucketer) string {
 	var sb strings.Builder
 
 	sb.WriteString(s.Position.String())
@@ -85,7 +80,7 @@ func (s *StorageWrite) Bucket() string {
 	sb.WriteString(s.Variable.String())
 
 	sb.WriteString("-")
-	sb.WriteString(bucket(s.Variable.Value))
+	sb.WriteString(s.ValueBucket(bucketer))
 
 	return sb.String()
 }
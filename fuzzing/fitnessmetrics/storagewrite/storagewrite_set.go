@@ -8,18 +8,97 @@ import (
 )
 
 type StorageWriteSet struct {
-	successSet map[string]*StorageWrite
-	lock       sync.RWMutex
+	successSet  map[string]*StorageWrite
+	revertedSet map[string]*StorageWrite
+
+	// slotBuckets maps the string representation of each written StorageSlot to the set of value buckets written to
+	// it, from any program position.
+	slotBuckets map[string]map[string]struct{}
+
+	// rewardSlotDiversity describes whether Update reports an update only when a slot was written a value in a bucket
+	// it never held before, rather than whenever a new storage write is recorded.
+	rewardSlotDiversity bool
+
+	lock sync.RWMutex
 }
 
-func (ds *StorageWriteSet) TotalStorageWriteCount() int {
+func (ds *StorageWriteSet) TotalStorageWriteCount(includeReverted bool) int {
 	ds.lock.RLock()
 	defer ds.lock.RUnlock()
 
 	count := len(ds.successSet)
+	if includeReverted {
+		for key := range ds.revertedSet {
+			if _, exists := ds.successSet[key]; !exists {
+				count++
+			}
+		}
+	}
 	return count
 }
 
+// TotalStorageDeleteCount returns the amount of storage writes in the set which set a previously non-zero slot to zero.
+func (ds *StorageWriteSet) TotalStorageDeleteCount() int {
+	ds.lock.RLock()
+	defer ds.lock.RUnlock()
+
+	count := 0
+	for _, storageWrite := range ds.successSet {
+		if storageWrite.Delete {
+			count++
+		}
+	}
+	return count
+}
+
+// TotalSlotBucketCount returns the amount of distinct (slot, value bucket) pairs written in the set, from any position.
+func (ds *StorageWriteSet) TotalSlotBucketCount() int {
+	ds.lock.RLock()
+	defer ds.lock.RUnlock()
+
+	bucketCount := 0
+	for _, buckets := range ds.slotBuckets {
+		bucketCount += len(buckets)
+	}
+	return bucketCount
+}
+
+// DiversityScore returns the average amount of distinct value buckets written to each slot in the set, or zero if no
+// slot was written.
+func (ds *StorageWriteSet) DiversityScore() float64 {
+	ds.lock.RLock()
+	defer ds.lock.RUnlock()
+
+	if len(ds.slotBuckets) == 0 {
+		return 0
+	}
+	bucketCount := 0
+	for _, buckets := range ds.slotBuckets {
+		bucketCount += len(buckets)
+	}
+	return float64(bucketCount) / float64(len(ds.slotBuckets))
+}
+
+// SetRewardSlotDiversity sets the rewardSlotDiversity value (see above).
+func (ds *StorageWriteSet) SetRewardSlotDiversity(rewardSlotDiversity bool) {
+	ds.rewardSlotDiversity = rewardSlotDiversity
+}
+
+// addSlotBucket records the provided value bucket as written to the slot with the provided string representation.
+// Returns a boolean indicating whether the slot never held a value in the bucket before.
+func (ds *StorageWriteSet) addSlotBucket(slot string, bucket string) bool {
+	buckets, exists := ds.slotBuckets[slot]
+	if !exists {
+		buckets = make(map[string]struct{})
+		ds.slotBuckets[slot] = buckets
+	}
+	if _, exists := buckets[bucket]; exists {
+		return false
+	}
+	buckets[bucket] = struct{}{}
+	return true
+}
+
 // NewStorageWriteSet initializes a new StorageWriteSet object.
 func NewStorageWriteSet() *StorageWriteSet {
 	maps := &StorageWriteSet{}
@@ -30,14 +109,35 @@ func NewStorageWriteSet() *StorageWriteSet {
 // Reset clears the storage-write state for the StorageWriteSet.
 func (ds *StorageWriteSet) Reset() {
 	ds.successSet = make(map[string]*StorageWrite)
+	ds.revertedSet = make(map[string]*StorageWrite)
+	ds.slotBuckets = make(map[string]map[string]struct{})
+}
+
+// Clear clears the storage-write state for the StorageWriteSet like Reset, but retains the memory allocated for it, so
+// that storage writes recorded afterwards reuse it.
+func (ds *StorageWriteSet) Clear() {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+
+	clear(ds.successSet)
+	clear(ds.revertedSet)
+	clear(ds.slotBuckets)
 }
 
 // Update updates the current storage-write set with the provided ones.
-// Returns two booleans indicating whether successful or reverted storage-write increased, or an error if one occurred.
+// Returns a boolean indicating whether successful storage-write increased, or an error if one occurred.
 func (ds *StorageWriteSet) Update(storageWriteSet *StorageWriteSet) (bool, error) {
+	successUpdated, _, err := ds.UpdateWithReverted(storageWriteSet)
+	return successUpdated, err
+}
+
+// UpdateWithReverted updates the current storage-write set with the provided ones.
+// Returns two booleans indicating whether successful storage-write increased, and whether reverted storage-write which
+// was never successful increased, or an error if one occurred.
+func (ds *StorageWriteSet) UpdateWithReverted(storageWriteSet *StorageWriteSet) (bool, bool, error) {
 	// If our maps provided are nil, do nothing
 	if storageWriteSet == nil {
-		return false, nil
+		return false, false, nil
 	}
 
 	// Acquire our thread lock and defer our unlocking for when we exit this method
@@ -45,6 +145,8 @@ func (ds *StorageWriteSet) Update(storageWriteSet *StorageWriteSet) (bool, error
 	defer ds.lock.Unlock()
 
 	successUpdated := false
+	revertedUpdated := false
+	slotDiversityUpdated := false
 
 	for key, storageWrite := range storageWriteSet.successSet {
 		if _, exists := ds.successSet[key]; !exists {
@@ -52,11 +154,30 @@ func (ds *StorageWriteSet) Update(storageWriteSet *StorageWriteSet) (bool, error
 			successUpdated = true
 		}
 	}
+	for key, storageWrite := range storageWriteSet.revertedSet {
+		if _, exists := ds.revertedSet[key]; !exists {
+			ds.revertedSet[key] = storageWrite
+			_, successful := ds.successSet[key]
+			revertedUpdated = revertedUpdated || !successful
+		}
+	}
+	for slot, buckets := range storageWriteSet.slotBuckets {
+		for bucket := range buckets {
+			slotDiversityUpdated = ds.addSlotBucket(slot, bucket) || slotDiversityUpdated
+		}
+	}
 
-	return successUpdated, nil
+	if ds.rewardSlotDiversity {
+		return slotDiversityUpdated, revertedUpdated, nil
+	}
+	return successUpdated, revertedUpdated, nil
 }
 
-func (ds *StorageWriteSet) SetWrite(storageAddress common.Address, slot, value *uint256.Int, codeAddress common.Address, create bool, pc uint64) (bool, error) {
+// SetWrite records a write of the provided value to the provided slot at the provided program position, keyed by the
+// bucket the provided bucketer maps the value to. Writes which delete the slot, setting it from non-zero to zero, are
+// keyed apart from any bucket.
+// Returns a boolean indicating whether the storage write is new to the set, or an error if one occurred.
+func (ds *StorageWriteSet) SetWrite(storageAddress common.Address, slot, value *uint256.Int, codeAddress common.Address, create bool, pc uint64, bucketer *ValueBucketer, isDelete bool) (bool, error) {
 	ds.lock.Lock()
 	defer ds.lock.Unlock()
 
@@ -74,9 +195,11 @@ func (ds *StorageWriteSet) SetWrite(storageAddress common.Address, slot, value *
 	storageWrite := &StorageWrite{
 		Position: position,
 		Variable: variable,
+		Delete:   isDelete,
 	}
 
-	storageWritebucket := storageWrite.Bucket()
+	storageWritebucket := storageWrite.Bucket(bucketer)
+	ds.addSlotBucket(variable.String(), storageWrite.ValueBucket(bucketer))
 	// storageWriteStr := storageWrite.String()
 	if _, exists := ds.successSet[storageWritebucket]; !exists {
 		ds.successSet[storageWritebucket] = storageWrite
@@ -88,11 +211,14 @@ func (ds *StorageWriteSet) SetWrite(storageAddress common.Address, slot, value *
 
 // RevertAll sets all storage-write in the set as reverted storage-write. Reverted storage-write set is
 // updated with successful storage-write set, the successful storage-write set is cleared.
-// Returns a boolean indicating whether reverted storage-write set increased, and an error if one occurred.
 func (ds *StorageWriteSet) RevertAll() {
 	// Acquire our thread lock and defer our unlocking for when we exit this method
 	ds.lock.Lock()
 	defer ds.lock.Unlock()
 
-	ds.Reset()
+	for key, storageWrite := range ds.successSet {
+		ds.revertedSet[key] = storageWrite
+	}
+	clear(ds.successSet)
+	clear(ds.slotBuckets)
 }
@@ -0,0 +1,221 @@
+package storagewrite
+
+import (
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/chain/types"
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+// getValueBucketer returns a ValueBucketer with the default bucket mode and boundaries.
+func getValueBucketer(t testing.TB) *ValueBucketer {
+	bucketer, err := NewValueBucketer(config.StorageWriteConfig{})
+	assert.NoError(t, err)
+	return bucketer
+}
+
+// TestStorageWriteSetUpdateCopies tests that updating a set copies the storage writes and slot buckets merged, so the
+// merged set can be released and reused for another call frame without affecting the updated set.
+func TestStorageWriteSetUpdateCopies(t *testing.T) {
+	bucketer := getValueBucketer(t)
+	pool := fitnessmetrics.NewResultPool(NewStorageWriteSet)
+	storageWriteSet := NewStorageWriteSet()
+	frameSet := pool.Get()
+	_, err := frameSet.SetWrite(common.Address{1}, uint256.NewInt(0), uint256.NewInt(1), common.Address{1}, false, 1, bucketer, false)
+	assert.NoError(t, err)
+
+	updated, err := storageWriteSet.Update(frameSet)
+	assert.NoError(t, err)
+	assert.True(t, updated)
+	pool.Put(frameSet)
+
+	// Reuse the released set for another call frame, which starts out empty.
+	otherFrameSet := pool.Get()
+	assert.Same(t, frameSet, otherFrameSet)
+	assert.EqualValues(t, 0, otherFrameSet.TotalStorageWriteCount(true))
+	assert.Zero(t, otherFrameSet.DiversityScore())
+	_, err = otherFrameSet.SetWrite(common.Address{1}, uint256.NewInt(0), uint256.NewInt(1<<32), common.Address{1}, false, 1, bucketer, false)
+	assert.NoError(t, err)
+
+	assert.EqualValues(t, 1, storageWriteSet.TotalStorageWriteCount(true))
+	assert.EqualValues(t, 1, storageWriteSet.DiversityScore())
+	updated, err = storageWriteSet.Update(otherFrameSet)
+	assert.NoError(t, err)
+	assert.True(t, updated)
+	assert.EqualValues(t, 2, storageWriteSet.TotalStorageWriteCount(true))
+	assert.EqualValues(t, 2, storageWriteSet.DiversityScore())
+}
+
+// TestStorageWriteTracerReleaseResults tests that the tracer only releases the storage-write set of its latest
+// transaction from the message results it was stored in, and reuses it once released.
+func TestStorageWriteTracerReleaseResults(t *testing.T) {
+	tracer := &StorageWriteTracer{resultPool: fitnessmetrics.NewResultPool(NewStorageWriteSet)}
+	tracer.storageWriteSet = tracer.resultPool.Get()
+	storageWriteSet := tracer.storageWriteSet
+	_, err := storageWriteSet.SetWrite(common.Address{1}, uint256.NewInt(0), uint256.NewInt(1), common.Address{1}, false, 1, getValueBucketer(t), false)
+	assert.NoError(t, err)
+	results := &types.MessageResults{AdditionalResults: make(map[string]any)}
+	tracer.CaptureTxEndSetAdditionalResults(results)
+
+	// Results stored in other message results are not released.
+	tracer.ReleaseResults(&types.MessageResults{AdditionalResults: make(map[string]any)})
+	assert.Same(t, storageWriteSet, GetStorageWriteTracerResults(results))
+
+	tracer.ReleaseResults(results)
+	assert.Nil(t, GetStorageWriteTracerResults(results))
+	assert.Nil(t, tracer.storageWriteSet)
+	assert.NotPanics(t, func() { tracer.ReleaseResults(results) })
+	assert.NotPanics(t, func() { (*StorageWriteTracer)(nil).ReleaseResults(results) })
+
+	// The released set is cleared and reused for the next transaction.
+	assert.Same(t, storageWriteSet, tracer.resultPool.Get())
+	assert.EqualValues(t, 0, storageWriteSet.TotalStorageWriteCount(true))
+}
+
+// BenchmarkStorageWriteSetPerCallFrame measures recording the storage writes of a call frame and merging them into
+// those of its transaction, with a set allocated for each call frame.
+func BenchmarkStorageWriteSetPerCallFrame(b *testing.B) {
+	bucketer := getValueBucketer(b)
+	transactionSet := NewStorageWriteSet()
+	value := uint256.NewInt(1)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		frameSet := NewStorageWriteSet()
+		_, _ = frameSet.SetWrite(common.Address{1}, uint256.NewInt(uint64(i%64)), value, common.Address{1}, false, 1, bucketer, false)
+		_, _ = transactionSet.Update(frameSet)
+	}
+}
+
+// BenchmarkStorageWriteSetResultPool measures recording the storage writes of a call frame and merging them into those
+// of its transaction, with sets recycled through a fitnessmetrics.ResultPool as done by the tracer.
+func BenchmarkStorageWriteSetResultPool(b *testing.B) {
+	bucketer := getValueBucketer(b)
+	pool := fitnessmetrics.NewResultPool(NewStorageWriteSet)
+	transactionSet := NewStorageWriteSet()
+	value := uint256.NewInt(1)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		frameSet := pool.Get()
+		_, _ = frameSet.SetWrite(common.Address{1}, uint256.NewInt(uint64(i%64)), value, common.Address{1}, false, 1, bucketer, false)
+		_, _ = transactionSet.Update(frameSet)
+		pool.Put(frameSet)
+	}
+}
+
+// TestStorageWriteSetDeletes tests that deleting a slot is recorded apart from writing it zero or a small value at the
+// same position, and is bucketed apart from either.
+func TestStorageWriteSetDeletes(t *testing.T) {
+	bucketer := getValueBucketer(t)
+	storageWriteSet := NewStorageWriteSet()
+	for _, write := range []struct {
+		value    uint64
+		isDelete bool
+		added    bool
+	}{
+		{value: 0, isDelete: false, added: true},
+		{value: 1, isDelete: false, added: false},
+		{value: 0, isDelete: true, added: true},
+		{value: 0, isDelete: true, added: false},
+	} {
+		added, err := storageWriteSet.SetWrite(common.Address{1}, uint256.NewInt(0), uint256.NewInt(write.value), common.Address{1}, false, 1, bucketer, write.isDelete)
+		assert.NoError(t, err)
+		assert.Equal(t, write.added, added)
+	}
+	assert.EqualValues(t, 2, storageWriteSet.TotalStorageWriteCount(false))
+	assert.EqualValues(t, 1, storageWriteSet.TotalStorageDeleteCount())
+	assert.EqualValues(t, 2, storageWriteSet.DiversityScore())
+
+	storageWrite := &StorageWrite{Variable: &StorageSlot{Value: uint256.NewInt(0)}, Delete: true}
+	assert.Equal(t, "delete", storageWrite.ValueBucket(bucketer))
+	storageWrite.Delete = false
+	assert.Equal(t, "0-2^4", storageWrite.ValueBucket(bucketer))
+}
+
+// TestStorageWriteSetSlotDiversity tests that the value buckets written to each slot are tracked from any position,
+// and that updates only report new slot buckets rather than new storage writes if slot diversity is rewarded.
+func TestStorageWriteSetSlotDiversity(t *testing.T) {
+	bucketer := getValueBucketer(t)
+	for _, rewardSlotDiversity := range []bool{false, true} {
+		storageWriteSet := NewStorageWriteSet()
+		storageWriteSet.SetRewardSlotDiversity(rewardSlotDiversity)
+		frameSet := NewStorageWriteSet()
+		_, err := frameSet.SetWrite(common.Address{1}, uint256.NewInt(0), uint256.NewInt(1), common.Address{1}, false, 1, bucketer, false)
+		assert.NoError(t, err)
+		updated, err := storageWriteSet.Update(frameSet)
+		assert.NoError(t, err)
+		assert.True(t, updated)
+
+		// A write of a known bucket to the slot from a new position is a new storage write, but not a new slot bucket.
+		frameSet = NewStorageWriteSet()
+		_, err = frameSet.SetWrite(common.Address{1}, uint256.NewInt(0), uint256.NewInt(2), common.Address{1}, false, 2, bucketer, false)
+		assert.NoError(t, err)
+		updated, err = storageWriteSet.Update(frameSet)
+		assert.NoError(t, err)
+		assert.Equal(t, !rewardSlotDiversity, updated)
+		assert.EqualValues(t, 2, storageWriteSet.TotalStorageWriteCount(false))
+		assert.EqualValues(t, 1, storageWriteSet.TotalSlotBucketCount())
+
+		// A write of a new bucket to the slot from a known position is both.
+		frameSet = NewStorageWriteSet()
+		_, err = frameSet.SetWrite(common.Address{1}, uint256.NewInt(0), uint256.NewInt(1<<32), common.Address{1}, false, 2, bucketer, false)
+		assert.NoError(t, err)
+		updated, err = storageWriteSet.Update(frameSet)
+		assert.NoError(t, err)
+		assert.True(t, updated)
+		assert.EqualValues(t, 2, storageWriteSet.TotalSlotBucketCount())
+
+		// Writes to another slot lower the average amount of buckets written per slot.
+		_, err = storageWriteSet.SetWrite(common.Address{1}, uint256.NewInt(1), uint256.NewInt(1), common.Address{1}, false, 3, bucketer, false)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 3, storageWriteSet.TotalSlotBucketCount())
+		assert.EqualValues(t, 1.5, storageWriteSet.DiversityScore())
+	}
+}
+
+// TestStorageWriteSetReverted tests that reverting a set moves its storage writes to its reverted set, which are only
+// counted when reverted storage writes are included, and that updates only report reverted storage writes which were
+// never successful.
+func TestStorageWriteSetReverted(t *testing.T) {
+	bucketer := getValueBucketer(t)
+	storageWriteSet := NewStorageWriteSet()
+	frameSet := NewStorageWriteSet()
+	_, err := frameSet.SetWrite(common.Address{1}, uint256.NewInt(0), uint256.NewInt(1), common.Address{1}, false, 1, bucketer, false)
+	assert.NoError(t, err)
+	frameSet.RevertAll()
+	assert.EqualValues(t, 0, frameSet.TotalStorageWriteCount(false))
+	assert.EqualValues(t, 1, frameSet.TotalStorageWriteCount(true))
+	assert.Zero(t, frameSet.DiversityScore())
+
+	successUpdated, revertedUpdated, err := storageWriteSet.UpdateWithReverted(frameSet)
+	assert.NoError(t, err)
+	assert.False(t, successUpdated)
+	assert.True(t, revertedUpdated)
+	assert.EqualValues(t, 0, storageWriteSet.TotalStorageWriteCount(false))
+	assert.EqualValues(t, 1, storageWriteSet.TotalStorageWriteCount(true))
+
+	// Once the storage write succeeds, it is only counted once.
+	frameSet = NewStorageWriteSet()
+	_, err = frameSet.SetWrite(common.Address{1}, uint256.NewInt(0), uint256.NewInt(1), common.Address{1}, false, 1, bucketer, false)
+	assert.NoError(t, err)
+	successUpdated, revertedUpdated, err = storageWriteSet.UpdateWithReverted(frameSet)
+	assert.NoError(t, err)
+	assert.True(t, successUpdated)
+	assert.False(t, revertedUpdated)
+	assert.EqualValues(t, 1, storageWriteSet.TotalStorageWriteCount(false))
+	assert.EqualValues(t, 1, storageWriteSet.TotalStorageWriteCount(true))
+
+	// Reverting a storage write which already succeeded is not reported.
+	storageWriteSet = NewStorageWriteSet()
+	_, err = storageWriteSet.Update(frameSet)
+	assert.NoError(t, err)
+	frameSet.RevertAll()
+	successUpdated, revertedUpdated, err = storageWriteSet.UpdateWithReverted(frameSet)
+	assert.NoError(t, err)
+	assert.False(t, successUpdated)
+	assert.False(t, revertedUpdated)
+	assert.EqualValues(t, 1, storageWriteSet.TotalStorageWriteCount(true))
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/crytic/medusa-geth/eth/tracers"
 	"github.com/crytic/medusa/chain"
 	"github.com/crytic/medusa/chain/types"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics"
 	"github.com/crytic/medusa/logging"
 )
 
@@ -46,6 +47,12 @@ type StorageWriteTracer struct {
 	// callFrameStates describes the state tracked by the tracer per call frame.
 	callFrameStates []*storageWriteTracerCallFrameState
 
+	// resultPool recycles the storage-write sets recorded for each transaction and call frame.
+	resultPool *fitnessmetrics.ResultPool[*StorageWriteSet]
+
+	// lastResults describes the message results the storage-write set of the latest transaction was stored in.
+	lastResults *types.MessageResults
+
 	// callDepth refers to the current EVM depth during tracing.
 	callDepth int
 
@@ -54,6 +61,9 @@ type StorageWriteTracer struct {
 
 	// nativeTracer is the underlying tracer used to capture EVM execution.
 	nativeTracer *chain.TestChainTracer
+
+	// bucketer describes how the values written are mapped to abstract buckets.
+	bucketer *ValueBucketer
 }
 
 // storageWriteTracerCallFrameState tracks state across call frames in the tracer.
@@ -75,6 +85,8 @@ func NewStorageWriteTracer() *StorageWriteTracer {
 	tracer := &StorageWriteTracer{
 		storageWriteSet: NewStorageWriteSet(),
 		callFrameStates: make([]*storageWriteTracerCallFrameState, 0),
+		resultPool:      fitnessmetrics.NewResultPool(NewStorageWriteSet),
+		bucketer:        defaultValueBucketer,
 	}
 	nativeTracer := &tracers.Tracer{
 		Hooks: &tracing.Hooks{
@@ -93,12 +105,17 @@ func (t *StorageWriteTracer) NativeTracer() *chain.TestChainTracer {
 	return t.nativeTracer
 }
 
+// SetValueBucketer sets the bucketer value (see above).
+func (t *StorageWriteTracer) SetValueBucketer(bucketer *ValueBucketer) {
+	t.bucketer = bucketer
+}
+
 // OnTxStart is called upon the start of transaction execution, as defined by tracers.Tracer.
 func (t *StorageWriteTracer) OnTxStart(vm *tracing.VMContext, tx *coretypes.Transaction, from common.Address) {
 	// Reset our call frame states
 	t.callDepth = 0
-	t.storageWriteSet = NewStorageWriteSet()
-	t.callFrameStates = make([]*storageWriteTracerCallFrameState, 0)
+	t.storageWriteSet = t.resultPool.Get()
+	t.callFrameStates = t.callFrameStates[:0]
 	t.evmContext = vm
 }
 
@@ -111,7 +128,7 @@ func (t *StorageWriteTracer) OnEnter(depth int, typ byte, from common.Address, t
 	// Create our state tracking struct for this frame.
 	t.callFrameStates = append(t.callFrameStates, &storageWriteTracerCallFrameState{
 		create:                 typ == byte(vm.CREATE) || typ == byte(vm.CREATE2),
-		pendingStorageWriteSet: NewStorageWriteSet(),
+		pendingStorageWriteSet: t.resultPool.Get(),
 		address:                to,
 	})
 }
@@ -143,26 +160,47 @@ func (t *StorageWriteTracer) OnExit(depth int, output []byte, gasUsed uint64, er
 	if updateErr != nil {
 		logging.GlobalLogger.Panic("StorageWrite tracer failed to update storage-write set during OnExit", updateErr)
 	}
+
+	// The storage writes of this call frame were copied up, so its set can be reused.
+	t.resultPool.Put(currentStorageWriteSet)
 }
 
 // OnOpcode records data from an EVM state update, as defined by tracers.Tracer.
 func (t *StorageWriteTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	if vm.OpCode(op) == vm.SSTORE {
+		scopeContext := scope.(*vm.ScopeContext)
+		t.recordWrite(pc, scopeContext, scopeContext.Contract.Address())
+	}
+}
+
+// OnOpcodeStep records an opcode dispatched by a chain.TestChainTracerMultiplexer, as defined by
+// chain.OpcodeStepTracer.
+func (t *StorageWriteTracer) OnOpcodeStep(step *chain.OpcodeStep) {
+	t.recordWrite(step.Pc, step.Scope, step.Address)
+}
+
+// HandledOpcodes returns the opcodes OnOpcodeStep should be called for, as defined by chain.OpcodeStepTracer.
+func (t *StorageWriteTracer) HandledOpcodes() []vm.OpCode {
+	return []vm.OpCode{vm.SSTORE}
+}
+
+// recordWrite records the storage write of the SSTORE at the provided location, executed in the provided scope on the
+// storage of the provided address.
+func (t *StorageWriteTracer) recordWrite(pc uint64, scope *vm.ScopeContext, storageAddress common.Address) {
 	// Obtain our call frame state tracking struct
 	callFrameState := t.callFrameStates[t.callDepth]
 
-	scopeContext := scope.(*vm.ScopeContext)
+	slot := scope.Stack.Back(0)
+	value := scope.Stack.Back(1)
+	codeAddress := callFrameState.address
 
-	if vm.OpCode(op) == vm.SSTORE {
-		slot := scopeContext.Stack.Back(0)
-		value := scopeContext.Stack.Back(1)
-		storageAddress := scopeContext.Contract.Address()
-		codeAddress := callFrameState.address
-
-		// Record storage write for this location in our storage-write set.
-		_, updateErr := callFrameState.pendingStorageWriteSet.SetWrite(storageAddress, slot, value, codeAddress, callFrameState.create, pc)
-		if updateErr != nil {
-			logging.GlobalLogger.Panic("StorageWrite tracer failed to update storage-write set while tracing state", updateErr)
-		}
+	// A write of zero to a slot which currently holds a non-zero value deletes it.
+	isDelete := value.IsZero() && t.evmContext.StateDB.GetState(storageAddress, common.Hash(slot.Bytes32())) != (common.Hash{})
+
+	// Record storage write for this location in our storage-write set.
+	_, updateErr := callFrameState.pendingStorageWriteSet.SetWrite(storageAddress, slot, value, codeAddress, callFrameState.create, pc, t.bucketer, isDelete)
+	if updateErr != nil {
+		logging.GlobalLogger.Panic("StorageWrite tracer failed to update storage-write set while tracing state", updateErr)
 	}
 }
 
@@ -172,4 +210,19 @@ func (t *StorageWriteTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scop
 func (t *StorageWriteTracer) CaptureTxEndSetAdditionalResults(results *types.MessageResults) {
 	// Store our tracer results.
 	results.AdditionalResults[storageWriteTracerResultsKey] = t.storageWriteSet
+	t.lastResults = results
+}
+
+// ReleaseResults releases the storage-write set of the latest transaction for reuse, if it was stored in the provided
+// message results, as defined by fitnessmetrics.ResultReleaser. It is removed from the message results.
+func (t *StorageWriteTracer) ReleaseResults(results *types.MessageResults) {
+	if t == nil || t.storageWriteSet == nil || results != t.lastResults {
+		return
+	}
+	if GetStorageWriteTracerResults(results) == t.storageWriteSet {
+		RemoveStorageWriteTracerResults(results)
+	}
+	t.resultPool.Put(t.storageWriteSet)
+	t.storageWriteSet = nil
+	t.lastResults = nil
 }
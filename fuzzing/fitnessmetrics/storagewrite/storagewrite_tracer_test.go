@@ -0,0 +1,73 @@
+package storagewrite
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core"
+	coretypes "github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa/chain"
+	"github.com/stretchr/testify/assert"
+)
+
+// executeStorageWriteTracer executes a call to a contract with the provided runtime bytecode and storage, with a
+// StorageWriteTracer attached.
+// Returns the storage-write set recorded.
+func executeStorageWriteTracer(t *testing.T, code []byte, storage map[common.Hash]common.Hash) *StorageWriteSet {
+	sender := common.HexToAddress("0x10000")
+	contract := common.HexToAddress("0x20000")
+	testChain, err := chain.NewTestChain(context.Background(), coretypes.GenesisAlloc{
+		sender:   {Balance: big.NewInt(1_000_000)},
+		contract: {Balance: big.NewInt(0), Code: code, Storage: storage},
+	}, nil)
+	assert.NoError(t, err)
+	defer testChain.Close()
+	tracer := NewStorageWriteTracer()
+	testChain.AddTracer(tracer.NativeTracer(), true, false)
+
+	_, err = testChain.PendingBlockCreate()
+	assert.NoError(t, err)
+	err = testChain.PendingBlockAddTx(&core.Message{
+		From:      sender,
+		To:        &contract,
+		Value:     big.NewInt(0),
+		GasLimit:  1_000_000,
+		GasPrice:  big.NewInt(0),
+		GasFeeCap: big.NewInt(0),
+		GasTipCap: big.NewInt(0),
+	})
+	assert.NoError(t, err)
+	messageResults := testChain.PendingBlock().MessageResults[0]
+	assert.EqualValues(t, coretypes.ReceiptStatusSuccessful, messageResults.Receipt.Status)
+	return GetStorageWriteTracerResults(messageResults)
+}
+
+// TestStorageWriteTracerDeletes tests that only writes of zero to slots currently holding a non-zero value, whether
+// set in the same transaction or before it, are recorded as deletes, and that they are kept apart from writes of small
+// values at the same position.
+func TestStorageWriteTracerDeletes(t *testing.T) {
+	code := []byte{
+		// slot 0: written one, then zero
+		byte(vm.PUSH1), 1, byte(vm.PUSH1), 0, byte(vm.SSTORE),
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.SSTORE),
+		// slot 1: written zero while empty
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 1, byte(vm.SSTORE),
+		// slot 2: written zero while holding a value from before the transaction
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 2, byte(vm.SSTORE),
+		byte(vm.STOP),
+	}
+	storageWriteSet := executeStorageWriteTracer(t, code, map[common.Hash]common.Hash{
+		common.BigToHash(big.NewInt(2)): common.BigToHash(big.NewInt(5)),
+	})
+	assert.EqualValues(t, 4, storageWriteSet.TotalStorageWriteCount(false))
+	assert.EqualValues(t, 2, storageWriteSet.TotalStorageDeleteCount())
+
+	deletesByPc := make(map[uint64]bool)
+	for _, storageWrite := range storageWriteSet.successSet {
+		deletesByPc[storageWrite.Position.Pc] = storageWrite.Delete
+	}
+	assert.EqualValues(t, map[uint64]bool{4: false, 9: true, 14: false, 19: true}, deletesByPc)
+}
@@ -0,0 +1,95 @@
+package storagewrite
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/holiman/uint256"
+)
+
+const (
+	// RangeBucketMode buckets values by the ranges between configured powers of two.
+	RangeBucketMode = "range"
+	// SignBucketMode buckets values like RangeBucketMode, but interprets them as two's complement signed integers, so
+	// negative values are bucketed by their magnitude apart from positive ones.
+	SignBucketMode = "sign"
+	// Log2BucketMode buckets values by their bit length, one bucket per power of two.
+	Log2BucketMode = "log2"
+	// ZeroBucketMode only distinguishes zero from non-zero values, which suits flag slots.
+	ZeroBucketMode = "zero"
+)
+
+// defaultBucketBoundaries describes the exponents of the powers of two bounding the value buckets by default.
+var defaultBucketBoundaries = []uint{4, 16, 64}
+
+// defaultValueBucketer describes the bucketer used by tracers which were not provided one.
+var defaultValueBucketer = &ValueBucketer{mode: RangeBucketMode, boundaries: defaultBucketBoundaries}
+
+// ValueBucketer maps the values written to storage slots to abstract buckets, so that writes of values of a different
+// magnitude at the same position count as distinct storage writes.
+type ValueBucketer struct {
+	// mode describes how values are bucketed.
+	mode string
+	// boundaries describes the increasing exponents of the powers of two bounding the buckets, for the range and sign
+	// modes.
+	boundaries []uint
+}
+
+// NewValueBucketer returns a new ValueBucketer using the provided configuration. If no bucket boundaries are
+// configured, the defaults (2^4, 2^16 and 2^64) are used.
+// Returns the bucketer, or an error if the configuration is invalid.
+func NewValueBucketer(storageWriteConfig config.StorageWriteConfig) (*ValueBucketer, error) {
+	mode := storageWriteConfig.BucketMode
+	if mode == "" {
+		mode = RangeBucketMode
+	}
+	if mode != RangeBucketMode && mode != SignBucketMode && mode != Log2BucketMode && mode != ZeroBucketMode {
+		return nil, fmt.Errorf("invalid storage write bucket mode %q (expected range, sign, log2 or zero)", mode)
+	}
+
+	boundaries := storageWriteConfig.BucketBoundaries
+	if len(boundaries) == 0 {
+		boundaries = defaultBucketBoundaries
+	}
+	for i, boundary := range boundaries {
+		if boundary == 0 || boundary >= 256 || (i > 0 && boundary <= boundaries[i-1]) {
+			return nil, fmt.Errorf("invalid storage write bucket boundaries %v (expected increasing exponents between 1 and 255)", boundaries)
+		}
+	}
+	return &ValueBucketer{mode: mode, boundaries: boundaries}, nil
+}
+
+// rangeBucket returns the range bucket of the provided value, e.g. "2^4-2^16".
+func (b *ValueBucketer) rangeBucket(value *uint256.Int) string {
+	bitLen := uint(value.BitLen())
+	lower := "0"
+	for _, boundary := range b.boundaries {
+		// A value is below 2^boundary if it has at most boundary bits.
+		if bitLen <= boundary {
+			return lower + "-2^" + strconv.FormatUint(uint64(boundary), 10)
+		}
+		lower = "2^" + strconv.FormatUint(uint64(boundary), 10)
+	}
+	return lower + "-2^256"
+}
+
+// Bucket maps the provided value to its abstract bucket.
+func (b *ValueBucketer) Bucket(value *uint256.Int) string {
+	switch b.mode {
+	case SignBucketMode:
+		if value.Sign() < 0 {
+			return "-" + b.rangeBucket(new(uint256.Int).Neg(value))
+		}
+		return b.rangeBucket(value)
+	case Log2BucketMode:
+		return "bits-" + strconv.Itoa(value.BitLen())
+	case ZeroBucketMode:
+		if value.IsZero() {
+			return "zero"
+		}
+		return "nonzero"
+	default:
+		return b.rangeBucket(value)
+	}
+}
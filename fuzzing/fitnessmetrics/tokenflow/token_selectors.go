@@ -0,0 +1,204 @@
+package tokenflow
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/holiman/uint256"
+)
+
+// tokenArgumentSource describes where a TokenArgument obtains its value from.
+type tokenArgumentSource int
+
+const (
+	// argumentSource obtains the value from a 32-byte word of the call arguments.
+	argumentSource tokenArgumentSource = iota
+	// callerSource obtains the address of the contract making the call.
+	callerSource
+	// calleeSource obtains the address of the contract being called.
+	calleeSource
+	// zeroSource obtains the zero address or amount, e.g. the sender of a mint or recipient of a burn.
+	zeroSource
+	// oneSource obtains an amount of one, e.g. for a single non-fungible token.
+	oneSource
+	// callValueSource obtains the ether value sent with the call, e.g. for a WETH deposit.
+	callValueSource
+)
+
+// TokenArgument describes how the from address, to address, amount or token of a token transfer is obtained from a
+// call. It is parsed from a specification: "argN" for the Nth 32-byte word of the call arguments (after the selector),
+// "caller", "callee", "zero", "one" or "value".
+type TokenArgument struct {
+	// source describes where the value is obtained from.
+	source tokenArgumentSource
+	// index describes the index of the argument word the value is obtained from, if it is obtained from an argument.
+	index int
+}
+
+// parseTokenArgument parses a TokenArgument from the provided specification (see TokenArgument).
+// Returns the parsed TokenArgument, or an error if the specification is invalid.
+func parseTokenArgument(spec string) (TokenArgument, error) {
+	switch spec {
+	case "caller":
+		return TokenArgument{source: callerSource}, nil
+	case "callee":
+		return TokenArgument{source: calleeSource}, nil
+	case "zero":
+		return TokenArgument{source: zeroSource}, nil
+	case "one":
+		return TokenArgument{source: oneSource}, nil
+	case "value":
+		return TokenArgument{source: callValueSource}, nil
+	}
+	if index, err := strconv.Atoi(strings.TrimPrefix(spec, "arg")); err == nil && strings.HasPrefix(spec, "arg") && index >= 0 {
+		return TokenArgument{source: argumentSource, index: index}, nil
+	}
+	return TokenArgument{}, fmt.Errorf("invalid token argument %q (expected argN, caller, callee, zero, one or value)", spec)
+}
+
+// word returns the 32-byte argument word the TokenArgument obtains its value from. The arguments must have been
+// checked to hold it.
+func (a TokenArgument) word(args []byte) []byte {
+	return args[4+32*a.index : 4+32*(a.index+1)]
+}
+
+// address returns the address the TokenArgument describes for the provided call.
+func (a TokenArgument) address(args []byte, caller common.Address, callee common.Address) common.Address {
+	switch a.source {
+	case argumentSource:
+		return common.BytesToAddress(a.word(args))
+	case callerSource:
+		return caller
+	case calleeSource:
+		return callee
+	default:
+		return common.Address{}
+	}
+}
+
+// amount returns the amount the TokenArgument describes for the provided call.
+func (a TokenArgument) amount(args []byte, callValue *uint256.Int) *uint256.Int {
+	switch a.source {
+	case argumentSource:
+		return uint256.NewInt(0).SetBytes(a.word(args))
+	case oneSource:
+		return uint256.NewInt(1)
+	case callValueSource:
+		return uint256.NewInt(0).Set(callValue)
+	default:
+		return uint256.NewInt(0)
+	}
+}
+
+// TokenSelector describes a "value-moving" function selector, and the layout of the arguments the token transfer it
+// performs is decoded from.
+type TokenSelector struct {
+	// Selector describes the function selector.
+	Selector [4]byte
+	// From, To, Amount and Token describe how the respective parts of the token transfer are obtained.
+	From, To, Amount, Token TokenArgument
+	// argsLength describes the minimum length of call data holding every argument word used.
+	argsLength int
+}
+
+// ParseTokenSelector parses a TokenSelector from the provided configuration.
+// Returns the parsed TokenSelector, or an error if the configuration is invalid.
+func ParseTokenSelector(selectorConfig config.TokenSelectorConfig) (*TokenSelector, error) {
+	selector, err := hex.DecodeString(strings.TrimPrefix(selectorConfig.Selector, "0x"))
+	if err != nil || len(selector) != 4 {
+		return nil, fmt.Errorf("invalid tokenflow selector %q (expected 4 hex-encoded bytes)", selectorConfig.Selector)
+	}
+	tokenSelector := &TokenSelector{argsLength: 4}
+	copy(tokenSelector.Selector[:], selector)
+
+	// The token defaults to the contract being called.
+	tokenSpec := selectorConfig.Token
+	if tokenSpec == "" {
+		tokenSpec = "callee"
+	}
+	specs := []string{selectorConfig.From, selectorConfig.To, selectorConfig.Amount, tokenSpec}
+	arguments := []*TokenArgument{&tokenSelector.From, &tokenSelector.To, &tokenSelector.Amount, &tokenSelector.Token}
+	for i, spec := range specs {
+		*arguments[i], err = parseTokenArgument(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tokenflow selector %q: %v", selectorConfig.Selector, err)
+		}
+		if arguments[i].source == argumentSource {
+			tokenSelector.argsLength = max(tokenSelector.argsLength, 4+32*(arguments[i].index+1))
+		}
+	}
+	return tokenSelector, nil
+}
+
+// defaultTokenSelectors describes the value-moving selectors the tokenflow tracer decodes by default.
+var defaultTokenSelectors = []config.TokenSelectorConfig{
+	// ERC20 transfer(address,uint256) and transferFrom(address,address,uint256)
+	{Selector: "a9059cbb", From: "caller", To: "arg0", Amount: "arg1"},
+	{Selector: "23b872dd", From: "arg0", To: "arg1", Amount: "arg2"},
+	// ERC721 safeTransferFrom(address,address,uint256) and safeTransferFrom(address,address,uint256,bytes)
+	{Selector: "42842e0e", From: "arg0", To: "arg1", Amount: "one"},
+	{Selector: "b88d4fde", From: "arg0", To: "arg1", Amount: "one"},
+	// ERC1155 safeTransferFrom(address,address,uint256,uint256,bytes) and
+	// safeBatchTransferFrom(address,address,uint256[],uint256[],bytes), whose amounts are not decoded
+	{Selector: "f242432a", From: "arg0", To: "arg1", Amount: "arg3"},
+	{Selector: "2eb2c2d6", From: "arg0", To: "arg1", Amount: "zero"},
+	// WETH deposit() and withdraw(uint256)
+	{Selector: "d0e30db0", From: "zero", To: "caller", Amount: "value"},
+	{Selector: "2e1a7d4d", From: "caller", To: "zero", Amount: "arg0"},
+	// Permit2 permitTransferFrom(((address,uint256),uint256,uint256),(address,uint256),address,bytes)
+	{Selector: "30f28b7a", From: "arg6", To: "arg4", Amount: "arg5", Token: "arg0"},
+	// mint(address,uint256), burn(uint256) and burn(address,uint256)
+	{Selector: "40c10f19", From: "zero", To: "arg0", Amount: "arg1"},
+	{Selector: "42966c68", From: "caller", To: "zero", Amount: "arg0"},
+	{Selector: "9dc29fac", From: "arg0", To: "zero", Amount: "arg1"},
+}
+
+// defaultTokenSelectorRegistry describes the registry of the default selectors, used by tracers which were not
+// provided one.
+var defaultTokenSelectorRegistry, _ = NewTokenSelectorRegistry(nil)
+
+// TokenSelectorRegistry describes the value-moving selectors the tokenflow tracer decodes token transfers from.
+type TokenSelectorRegistry struct {
+	// selectors maps each registered function selector to its TokenSelector.
+	selectors map[[4]byte]*TokenSelector
+}
+
+// NewTokenSelectorRegistry returns a new TokenSelectorRegistry holding the default selectors, extended (or
+// overridden) by the provided ones.
+// Returns the registry, or an error if a provided selector is invalid.
+func NewTokenSelectorRegistry(selectorConfigs []config.TokenSelectorConfig) (*TokenSelectorRegistry, error) {
+	registry := &TokenSelectorRegistry{
+		selectors: make(map[[4]byte]*TokenSelector),
+	}
+	for _, selectorConfig := range append(append([]config.TokenSelectorConfig{}, defaultTokenSelectors...), selectorConfigs...) {
+		tokenSelector, err := ParseTokenSelector(selectorConfig)
+		if err != nil {
+			return nil, err
+		}
+		registry.selectors[tokenSelector.Selector] = tokenSelector
+	}
+	return registry, nil
+}
+
+// Decode decodes the token transfer performed by a call with the provided arguments (including the selector) and
+// value, made by the provided caller to the provided callee.
+// Returns the decoded Flow, or nil if the call does not use a registered selector or its arguments are too short.
+func (r *TokenSelectorRegistry) Decode(args []byte, callValue *uint256.Int, caller common.Address, callee common.Address) *Flow {
+	if len(args) < 4 {
+		return nil
+	}
+	tokenSelector, exists := r.selectors[[4]byte(args[:4])]
+	if !exists || len(args) < tokenSelector.argsLength {
+		return nil
+	}
+	return &Flow{
+		From:   tokenSelector.From.address(args, caller, callee),
+		To:     tokenSelector.To.address(args, caller, callee),
+		Amount: tokenSelector.Amount.amount(args, callValue),
+		Token:  tokenSelector.Token.address(args, caller, callee),
+	}
+}
@@ -0,0 +1,100 @@
+package tokenflow
+
+import (
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+// getCallArgs returns call data holding the provided selector followed by the provided 32-byte argument words.
+func getCallArgs(selector string, words ...[]byte) []byte {
+	args := common.FromHex(selector)
+	for _, word := range words {
+		args = append(args, common.LeftPadBytes(word, 32)...)
+	}
+	return args
+}
+
+// TestTokenSelectorRegistryDecode tests that token transfers are decoded from calls using the default selectors, with
+// their parts obtained from the call arguments, caller, callee or call value as their layouts describe, and that calls
+// using other selectors or with too few arguments are not decoded.
+func TestTokenSelectorRegistryDecode(t *testing.T) {
+	registry, err := NewTokenSelectorRegistry(nil)
+	assert.NoError(t, err)
+	caller, callee := common.HexToAddress("0x1000"), common.HexToAddress("0x2000")
+	from, to := common.HexToAddress("0x3000"), common.HexToAddress("0x4000")
+	callValue := uint256.NewInt(9)
+	tests := []struct {
+		name     string
+		args     []byte
+		expected *Flow
+	}{
+		{
+			name:     "ERC20 transfer",
+			args:     getCallArgs("a9059cbb", to.Bytes(), []byte{7}),
+			expected: &Flow{From: caller, To: to, Amount: uint256.NewInt(7), Token: callee},
+		},
+		{
+			name:     "ERC721 safeTransferFrom",
+			args:     getCallArgs("42842e0e", from.Bytes(), to.Bytes(), []byte{42}),
+			expected: &Flow{From: from, To: to, Amount: uint256.NewInt(1), Token: callee},
+		},
+		{
+			name:     "WETH deposit",
+			args:     getCallArgs("d0e30db0"),
+			expected: &Flow{From: common.Address{}, To: caller, Amount: callValue, Token: callee},
+		},
+		{
+			name:     "burn",
+			args:     getCallArgs("42966c68", []byte{5}),
+			expected: &Flow{From: caller, To: common.Address{}, Amount: uint256.NewInt(5), Token: callee},
+		},
+		{
+			name: "ERC20 transfer missing its amount",
+			args: getCallArgs("a9059cbb", to.Bytes()),
+		},
+		{
+			name: "unregistered selector",
+			args: getCallArgs("12345678", to.Bytes(), []byte{7}),
+		},
+		{
+			name: "call data without a selector",
+			args: []byte{0xa9, 0x05},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.EqualValues(t, test.expected, registry.Decode(test.args, callValue, caller, callee))
+		})
+	}
+}
+
+// TestNewTokenSelectorRegistryConfig tests that configured selectors extend or override the default ones, and that
+// invalid selectors or argument layouts are rejected.
+func TestNewTokenSelectorRegistryConfig(t *testing.T) {
+	caller, callee := common.HexToAddress("0x1000"), common.HexToAddress("0x2000")
+	token, to := common.HexToAddress("0x3000"), common.HexToAddress("0x4000")
+	registry, err := NewTokenSelectorRegistry([]config.TokenSelectorConfig{
+		{Selector: "0x12345678", From: "caller", To: "arg1", Amount: "arg2", Token: "arg0"},
+		{Selector: "a9059cbb", From: "callee", To: "arg0", Amount: "arg1"},
+	})
+	assert.NoError(t, err)
+	flow := registry.Decode(getCallArgs("12345678", token.Bytes(), to.Bytes(), []byte{3}), uint256.NewInt(0), caller, callee)
+	assert.EqualValues(t, &Flow{From: caller, To: to, Amount: uint256.NewInt(3), Token: token}, flow)
+	flow = registry.Decode(getCallArgs("a9059cbb", to.Bytes(), []byte{7}), uint256.NewInt(0), caller, callee)
+	assert.EqualValues(t, callee, flow.From)
+	assert.NotNil(t, registry.Decode(getCallArgs("23b872dd", caller.Bytes(), to.Bytes(), []byte{7}), uint256.NewInt(0), caller, callee))
+
+	for _, selectorConfig := range []config.TokenSelectorConfig{
+		{Selector: "a9059c", From: "caller", To: "arg0", Amount: "arg1"},
+		{Selector: "nothex00", From: "caller", To: "arg0", Amount: "arg1"},
+		{Selector: "a9059cbb", From: "sender", To: "arg0", Amount: "arg1"},
+		{Selector: "a9059cbb", From: "caller", To: "arg-1", Amount: "arg1"},
+	} {
+		_, err = NewTokenSelectorRegistry([]config.TokenSelectorConfig{selectorConfig})
+		assert.Error(t, err, selectorConfig)
+	}
+}
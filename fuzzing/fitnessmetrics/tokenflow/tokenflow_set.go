@@ -4,9 +4,13 @@ import (
 	"sync"
 
 	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/logging"
 	"github.com/holiman/uint256"
 )
 
+// diagnostics provides debug logging for tokenflow tracing.
+var diagnostics = logging.NewDiagnostics("tokenflow")
+
 type TokenflowSet struct {
 	successSet  map[string]*Tokenflow
 	revertedSet map[string]*Tokenflow
@@ -19,7 +23,7 @@ func (ds *TokenflowSet) TotalTokenflowCount(includeReverted bool) int {
 
 	count := len(ds.successSet)
 	if includeReverted {
-		for key, _ := range ds.revertedSet {
+		for key := range ds.revertedSet {
 			if _, exists := ds.successSet[key]; !exists {
 				count++
 			}
@@ -41,12 +45,30 @@ func (ds *TokenflowSet) Reset() {
 	ds.revertedSet = make(map[string]*Tokenflow)
 }
 
-// Update updates the current storage-write set with the provided ones.
-// Returns two booleans indicating whether successful or reverted storage-write increased, or an error if one occurred.
-func (ds *TokenflowSet) Update(storageWriteSet *TokenflowSet) (bool, error) {
+// Clear clears the tokenflow state for the TokenflowSet like Reset, but retains the memory allocated for it, so that
+// token flows recorded afterwards reuse it.
+func (ds *TokenflowSet) Clear() {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+
+	clear(ds.successSet)
+	clear(ds.revertedSet)
+}
+
+// Update updates the current tokenflow set with the provided ones.
+// Returns a boolean indicating whether successful tokenflow increased, or an error if one occurred.
+func (ds *TokenflowSet) Update(tokenflowSet *TokenflowSet) (bool, error) {
+	successUpdated, _, err := ds.UpdateWithReverted(tokenflowSet)
+	return successUpdated, err
+}
+
+// UpdateWithReverted updates the current tokenflow set with the provided ones.
+// Returns two booleans indicating whether successful tokenflow increased, and whether reverted tokenflow which was
+// never successful increased, or an error if one occurred.
+func (ds *TokenflowSet) UpdateWithReverted(tokenflowSet *TokenflowSet) (bool, bool, error) {
 	// If our maps provided are nil, do nothing
-	if storageWriteSet == nil {
-		return false, nil
+	if tokenflowSet == nil {
+		return false, false, nil
 	}
 
 	// Acquire our thread lock and defer our unlocking for when we exit this method
@@ -54,22 +76,39 @@ func (ds *TokenflowSet) Update(storageWriteSet *TokenflowSet) (bool, error) {
 	defer ds.lock.Unlock()
 
 	successUpdated := false
+	revertedUpdated := false
 
-	for key, storageWrite := range storageWriteSet.successSet {
+	for key, tokenflow := range tokenflowSet.successSet {
 		if _, exists := ds.successSet[key]; !exists {
-			ds.successSet[key] = storageWrite
+			ds.successSet[key] = tokenflow
 			successUpdated = true
 		}
 	}
+	for key, tokenflow := range tokenflowSet.revertedSet {
+		if _, exists := ds.revertedSet[key]; !exists {
+			ds.revertedSet[key] = tokenflow
+			_, successful := ds.successSet[key]
+			revertedUpdated = revertedUpdated || !successful
+		}
+	}
 
-	return successUpdated, nil
+	return successUpdated, revertedUpdated, nil
 }
 
 func (ds *TokenflowSet) SetTokenFlow(storageAddress common.Address, codeAddress common.Address, create bool, pc uint64, amount *uint256.Int, from, to, token common.Address) (bool, error) {
 	ds.lock.Lock()
 	defer ds.lock.Unlock()
 
-	// fmt.Println(pc, token)
+	if diagnostics.Enabled(2) {
+		diagnostics.Log(2, "Recorded a token flow", logging.StructuredLogInfo{
+			"codeAddress": codeAddress.Hex(),
+			"pc":          pc,
+			"token":       token.Hex(),
+			"from":        from.Hex(),
+			"to":          to.Hex(),
+			"amount":      amount.Dec(),
+		})
+	}
 
 	flow := &Flow{
 		From:   from,
@@ -98,13 +137,27 @@ func (ds *TokenflowSet) SetTokenFlow(storageAddress common.Address, codeAddress
 	return false, nil
 }
 
-// RevertAll sets all storage-write in the set as reverted storage-write. Reverted storage-write set is
-// updated with successful storage-write set, the successful storage-write set is cleared.
-// Returns a boolean indicating whether reverted storage-write set increased, and an error if one occurred.
+// Tokenflows returns the successful token flows of the set.
+func (ds *TokenflowSet) Tokenflows() []*Tokenflow {
+	ds.lock.RLock()
+	defer ds.lock.RUnlock()
+
+	tokenflows := make([]*Tokenflow, 0, len(ds.successSet))
+	for _, tokenflow := range ds.successSet {
+		tokenflows = append(tokenflows, tokenflow)
+	}
+	return tokenflows
+}
+
+// RevertAll sets all tokenflow in the set as reverted tokenflow. Reverted tokenflow set is updated with successful
+// tokenflow set, the successful tokenflow set is cleared.
 func (ds *TokenflowSet) RevertAll() {
 	// Acquire our thread lock and defer our unlocking for when we exit this method
 	ds.lock.Lock()
 	defer ds.lock.Unlock()
 
-	ds.successSet = make(map[string]*Tokenflow)
+	for key, tokenflow := range ds.successSet {
+		ds.revertedSet[key] = tokenflow
+	}
+	clear(ds.successSet)
 }
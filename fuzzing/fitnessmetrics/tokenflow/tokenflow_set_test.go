@@ -0,0 +1,98 @@
+package tokenflow
+
+import (
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/chain/types"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+// setTokenFlow records a transfer of the provided amount of a token at the provided pc in the provided tokenflow set.
+func setTokenFlow(t testing.TB, tokenflowSet *TokenflowSet, pc uint64, amount *uint256.Int) {
+	_, err := tokenflowSet.SetTokenFlow(common.Address{1}, common.Address{1}, false, pc, amount, common.Address{2}, common.Address{3}, common.Address{4})
+	assert.NoError(t, err)
+}
+
+// TestTokenflowSetUpdateCopies tests that updating a set copies the token flows merged, so the merged set can be
+// released and reused for another call frame without affecting the updated set.
+func TestTokenflowSetUpdateCopies(t *testing.T) {
+	pool := fitnessmetrics.NewResultPool(NewTokenflowSet)
+	tokenflowSet := NewTokenflowSet()
+	frameSet := pool.Get()
+	setTokenFlow(t, frameSet, 1, uint256.NewInt(5))
+
+	updated, err := tokenflowSet.Update(frameSet)
+	assert.NoError(t, err)
+	assert.True(t, updated)
+	pool.Put(frameSet)
+
+	// Reuse the released set for another call frame, which starts out empty.
+	otherFrameSet := pool.Get()
+	assert.Same(t, frameSet, otherFrameSet)
+	assert.EqualValues(t, 0, otherFrameSet.TotalTokenflowCount(true))
+	setTokenFlow(t, otherFrameSet, 2, uint256.NewInt(5))
+
+	tokenflows := tokenflowSet.Tokenflows()
+	assert.Len(t, tokenflows, 1)
+	assert.EqualValues(t, 1, tokenflows[0].Position.Pc)
+	updated, err = tokenflowSet.Update(otherFrameSet)
+	assert.NoError(t, err)
+	assert.True(t, updated)
+	assert.EqualValues(t, 2, tokenflowSet.TotalTokenflowCount(true))
+}
+
+// TestTokenflowTracerReleaseResults tests that the tracer only releases the tokenflow set of its latest transaction
+// from the message results it was stored in, and reuses it once released.
+func TestTokenflowTracerReleaseResults(t *testing.T) {
+	tracer := &TokenflowTracer{resultPool: fitnessmetrics.NewResultPool(NewTokenflowSet)}
+	tracer.tokenflowSet = tracer.resultPool.Get()
+	tokenflowSet := tracer.tokenflowSet
+	setTokenFlow(t, tokenflowSet, 1, uint256.NewInt(5))
+	results := &types.MessageResults{AdditionalResults: make(map[string]any)}
+	tracer.CaptureTxEndSetAdditionalResults(results)
+
+	// Results stored in other message results are not released.
+	tracer.ReleaseResults(&types.MessageResults{AdditionalResults: make(map[string]any)})
+	assert.Same(t, tokenflowSet, GetTokenflowTracerResults(results))
+
+	tracer.ReleaseResults(results)
+	assert.Nil(t, GetTokenflowTracerResults(results))
+	assert.Nil(t, tracer.tokenflowSet)
+	assert.NotPanics(t, func() { tracer.ReleaseResults(results) })
+	assert.NotPanics(t, func() { (*TokenflowTracer)(nil).ReleaseResults(results) })
+
+	// The released set is cleared and reused for the next transaction.
+	assert.Same(t, tokenflowSet, tracer.resultPool.Get())
+	assert.EqualValues(t, 0, tokenflowSet.TotalTokenflowCount(true))
+}
+
+// BenchmarkTokenflowSetPerCallFrame measures recording the token flows of a call frame and merging them into those of
+// its transaction, with a set allocated for each call frame.
+func BenchmarkTokenflowSetPerCallFrame(b *testing.B) {
+	transactionSet := NewTokenflowSet()
+	amount := uint256.NewInt(5)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		frameSet := NewTokenflowSet()
+		setTokenFlow(b, frameSet, uint64(i%64), amount)
+		_, _ = transactionSet.Update(frameSet)
+	}
+}
+
+// BenchmarkTokenflowSetResultPool measures recording the token flows of a call frame and merging them into those of its
+// transaction, with sets recycled through a fitnessmetrics.ResultPool as done by the tracer.
+func BenchmarkTokenflowSetResultPool(b *testing.B) {
+	pool := fitnessmetrics.NewResultPool(NewTokenflowSet)
+	transactionSet := NewTokenflowSet()
+	amount := uint256.NewInt(5)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		frameSet := pool.Get()
+		setTokenFlow(b, frameSet, uint64(i%64), amount)
+		_, _ = transactionSet.Update(frameSet)
+		pool.Put(frameSet)
+	}
+}
@@ -1,7 +1,6 @@
 package tokenflow
 
 import (
-	"encoding/hex"
 	"math/big"
 
 	"github.com/crytic/medusa-geth/common"
@@ -11,6 +10,7 @@ import (
 	"github.com/crytic/medusa-geth/eth/tracers"
 	"github.com/crytic/medusa/chain"
 	"github.com/crytic/medusa/chain/types"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics"
 	"github.com/crytic/medusa/logging"
 	"github.com/holiman/uint256"
 )
@@ -48,6 +48,12 @@ type TokenflowTracer struct {
 	// callFrameStates describes the state tracked by the tracer per call frame.
 	callFrameStates []*tokenflowTracerCallFrameState
 
+	// resultPool recycles the tokenflow sets recorded for each transaction and call frame.
+	resultPool *fitnessmetrics.ResultPool[*TokenflowSet]
+
+	// lastResults describes the message results the tokenflow set of the latest transaction was stored in.
+	lastResults *types.MessageResults
+
 	// callDepth refers to the current EVM depth during tracing.
 	callDepth int
 
@@ -56,6 +62,9 @@ type TokenflowTracer struct {
 
 	// nativeTracer is the underlying tracer used to capture EVM execution.
 	nativeTracer *chain.TestChainTracer
+
+	// selectors describes the value-moving selectors token transfers are decoded from.
+	selectors *TokenSelectorRegistry
 }
 
 // tokenflowTracerCallFrameState tracks state across call frames in the tracer.
@@ -68,6 +77,46 @@ type tokenflowTracerCallFrameState struct {
 
 	// address is the address of the code being executed.
 	address common.Address
+
+	// pendingCallTokenflows describes the token transfers performed by the call this frame is currently making, which
+	// are only recorded once the call returns successfully.
+	pendingCallTokenflows []*pendingTokenflow
+}
+
+// pendingTokenflow describes a token transfer observed at a CALL, CREATE, CREATE2 or SELFDESTRUCT, whose success is
+// not yet known.
+type pendingTokenflow struct {
+	// storageAddress is the address of the contract performing the transfer, whose storage is being executed on.
+	storageAddress common.Address
+	// codeAddress is the address of the code performing the transfer, which the transfer's position is recorded under.
+	codeAddress common.Address
+	// create indicates whether the transfer is performed by init bytecode (deploying a contract).
+	create bool
+	// pc is the program counter of the CALL, CREATE, CREATE2 or SELFDESTRUCT performing the transfer.
+	pc uint64
+	// flow describes the tokens transferred, and where from and to.
+	flow *Flow
+	// decoded indicates whether the transfer was decoded from the call data, rather than being the ether sent, in which
+	// case the call returning false signals it failed.
+	decoded bool
+	// created indicates whether the transfer is ether sent to a contract being created, whose address is only known
+	// once its call frame is entered.
+	created bool
+}
+
+// callSucceeded indicates whether a token transfer call which returned the provided output without reverting
+// succeeded. Tokens which return a boolean signal failure by returning false, while those returning nothing (e.g.
+// USDT) succeed.
+func callSucceeded(output []byte) bool {
+	if len(output) != 32 {
+		return true
+	}
+	for _, b := range output {
+		if b != 0 {
+			return true
+		}
+	}
+	return false
 }
 
 // NewTokenflowTracer returns a new TokenflowTracer.
@@ -75,6 +124,8 @@ func NewTokenflowTracer() *TokenflowTracer {
 	tracer := &TokenflowTracer{
 		tokenflowSet:    NewTokenflowSet(),
 		callFrameStates: make([]*tokenflowTracerCallFrameState, 0),
+		resultPool:      fitnessmetrics.NewResultPool(NewTokenflowSet),
+		selectors:       defaultTokenSelectorRegistry,
 	}
 	nativeTracer := &tracers.Tracer{
 		Hooks: &tracing.Hooks{
@@ -93,12 +144,17 @@ func (t *TokenflowTracer) NativeTracer() *chain.TestChainTracer {
 	return t.nativeTracer
 }
 
+// SetSelectorRegistry sets the selectors value (see above).
+func (t *TokenflowTracer) SetSelectorRegistry(selectors *TokenSelectorRegistry) {
+	t.selectors = selectors
+}
+
 // OnTxStart is called upon the start of transaction execution, as defined by tracers.Tracer.
 func (t *TokenflowTracer) OnTxStart(vm *tracing.VMContext, tx *coretypes.Transaction, from common.Address) {
 	// Reset our call frame states
 	t.callDepth = 0
-	t.tokenflowSet = NewTokenflowSet()
-	t.callFrameStates = make([]*tokenflowTracerCallFrameState, 0)
+	t.tokenflowSet = t.resultPool.Get()
+	t.callFrameStates = t.callFrameStates[:0]
 	t.evmContext = vm
 }
 
@@ -106,12 +162,20 @@ func (t *TokenflowTracer) OnTxStart(vm *tracing.VMContext, tx *coretypes.Transac
 func (t *TokenflowTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
 	isTopLevelFrame := depth == 0
 	if !isTopLevelFrame {
+		// Ether sent by a CREATE or CREATE2 moves to the address of the contract created, now that it is known.
+		if typ == byte(vm.CREATE) || typ == byte(vm.CREATE2) {
+			for _, pending := range t.callFrameStates[t.callDepth].pendingCallTokenflows {
+				if pending.created {
+					pending.flow.To = to
+				}
+			}
+		}
 		t.callDepth++
 	}
 	// Create our state tracking struct for this frame.
 	t.callFrameStates = append(t.callFrameStates, &tokenflowTracerCallFrameState{
 		create:              typ == byte(vm.CREATE) || typ == byte(vm.CREATE2),
-		pendingTokenflowSet: NewTokenflowSet(),
+		pendingTokenflowSet: t.resultPool.Get(),
 		address:             to,
 	})
 }
@@ -131,13 +195,31 @@ func (t *TokenflowTracer) OnExit(depth int, output []byte, gasUsed uint64, err e
 	if isTopLevelFrame {
 		_, updateErr = t.tokenflowSet.Update(currentPendingTokenflowSet)
 	} else {
-		_, updateErr = t.callFrameStates[t.callDepth-1].pendingTokenflowSet.Update(currentPendingTokenflowSet)
+		// Record the token transfers the caller performed through this call, now that we know whether it succeeded.
+		parentCallFrameState := t.callFrameStates[t.callDepth-1]
+		if !reverted {
+			for _, pending := range parentCallFrameState.pendingCallTokenflows {
+				if pending.decoded && !callSucceeded(output) {
+					continue
+				}
+				_, updateErr = parentCallFrameState.pendingTokenflowSet.SetTokenFlow(pending.storageAddress, pending.codeAddress, pending.create, pending.pc, pending.flow.Amount, pending.flow.From, pending.flow.To, pending.flow.Token)
+				if updateErr != nil {
+					logging.GlobalLogger.Panic("Tokenflow tracer failed to update tokenflow set during OnExit", updateErr)
+				}
+			}
+		}
+		parentCallFrameState.pendingCallTokenflows = nil
+
+		_, updateErr = parentCallFrameState.pendingTokenflowSet.Update(currentPendingTokenflowSet)
 		t.callFrameStates = t.callFrameStates[:t.callDepth]
 		t.callDepth--
 	}
 	if updateErr != nil {
 		logging.GlobalLogger.Panic("Tokenflow tracer failed to update tokenflow set during OnExit", updateErr)
 	}
+
+	// The token flows of this call frame were copied up, so its set can be reused.
+	t.resultPool.Put(currentPendingTokenflowSet)
 }
 
 // OnOpcode records data from an EVM state update, as defined by tracers.Tracer.
@@ -146,7 +228,11 @@ func (t *TokenflowTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope t
 	callFrameState := t.callFrameStates[t.callDepth]
 	scopeContext := scope.(*vm.ScopeContext)
 
-	if vm.OpCode(op) == vm.CALL {
+	// Any token transfers pending from a previous call which never entered its call frame did not happen.
+	callFrameState.pendingCallTokenflows = nil
+
+	switch vm.OpCode(op) {
+	case vm.CALL:
 		addr, value, inOffset, inSize := scopeContext.Stack.Back(1), scopeContext.Stack.Back(2), scopeContext.Stack.Back(3), scopeContext.Stack.Back(4)
 		toAddr := common.Address(addr.Bytes20())
 		// Get the arguments from the memory.
@@ -154,36 +240,56 @@ func (t *TokenflowTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope t
 		storageAddress := scopeContext.Contract.Address()
 		codeAddress := callFrameState.address
 
+		// The transfers are only recorded once the call returns successfully (see OnExit).
 		if value.Cmp(uint256.NewInt(0)) > 0 {
-			_, updateErr := callFrameState.pendingTokenflowSet.SetTokenFlow(storageAddress, codeAddress, callFrameState.create, pc, value, storageAddress, toAddr, common.HexToAddress("0x"))
-			if updateErr != nil {
-				logging.GlobalLogger.Panic("Tokenflow tracer failed to update tokenflow set while tracing state", updateErr)
-			}
+			callFrameState.pendingCallTokenflows = append(callFrameState.pendingCallTokenflows, &pendingTokenflow{
+				storageAddress: storageAddress,
+				codeAddress:    codeAddress,
+				create:         callFrameState.create,
+				pc:             pc,
+				flow:           &Flow{From: storageAddress, To: toAddr, Amount: value.Clone(), Token: common.HexToAddress("0x")},
+			})
 		}
 
-		if len(args) >= 4 {
-			if hex.EncodeToString(args[:4]) == "a9059cbb" && len(args) == 68 {
-				// This is a token transfer, parse the arguments.
-				to := common.BytesToAddress(args[4:36])
-				amount := uint256.NewInt(0).SetBytes(args[36:68])
-
-				// Set the token flow.
-				_, updateErr := callFrameState.pendingTokenflowSet.SetTokenFlow(storageAddress, codeAddress, callFrameState.create, pc, amount, storageAddress, to, toAddr)
-				if updateErr != nil {
-					logging.GlobalLogger.Panic("Tokenflow tracer failed to update tokenflow set while tracing state", updateErr)
-				}
-			} else if hex.EncodeToString(args[:4]) == "23b872dd" && len(args) == 100 {
-				// This is a token transfer from, parse the arguments.
-				from := common.BytesToAddress(args[4:36])
-				to := common.BytesToAddress(args[36:68])
-				amount := uint256.NewInt(0).SetBytes(args[68:100])
-
-				// Set the token flow.
-				_, updateErr := callFrameState.pendingTokenflowSet.SetTokenFlow(storageAddress, codeAddress, callFrameState.create, pc, amount, from, to, toAddr)
-				if updateErr != nil {
-					logging.GlobalLogger.Panic("Tokenflow tracer failed to update tokenflow set while tracing state", updateErr)
-				}
-			}
+		// If the call uses a value-moving selector, decode the token transfer it performs.
+		if flow := t.selectors.Decode(args, value, storageAddress, toAddr); flow != nil {
+			callFrameState.pendingCallTokenflows = append(callFrameState.pendingCallTokenflows, &pendingTokenflow{
+				storageAddress: storageAddress,
+				codeAddress:    codeAddress,
+				create:         callFrameState.create,
+				pc:             pc,
+				flow:           flow,
+				decoded:        true,
+			})
+		}
+	case vm.CREATE, vm.CREATE2:
+		// The ether sent to the contract being created is recorded once its creation succeeds (see OnExit).
+		value := scopeContext.Stack.Back(0)
+		if value.Cmp(uint256.NewInt(0)) > 0 {
+			storageAddress := scopeContext.Contract.Address()
+			callFrameState.pendingCallTokenflows = append(callFrameState.pendingCallTokenflows, &pendingTokenflow{
+				storageAddress: storageAddress,
+				codeAddress:    callFrameState.address,
+				create:         callFrameState.create,
+				pc:             pc,
+				flow:           &Flow{From: storageAddress, Amount: value.Clone(), Token: common.HexToAddress("0x")},
+				created:        true,
+			})
+		}
+	case vm.SELFDESTRUCT:
+		// The balance of the contract moves to the beneficiary. The EVM reports this as a call frame which always
+		// succeeds, so it is recorded in OnExit like any other call.
+		storageAddress := scopeContext.Contract.Address()
+		balance := t.evmContext.StateDB.GetBalance(storageAddress)
+		if balance.Cmp(uint256.NewInt(0)) > 0 {
+			beneficiary := common.Address(scopeContext.Stack.Back(0).Bytes20())
+			callFrameState.pendingCallTokenflows = append(callFrameState.pendingCallTokenflows, &pendingTokenflow{
+				storageAddress: storageAddress,
+				codeAddress:    callFrameState.address,
+				create:         callFrameState.create,
+				pc:             pc,
+				flow:           &Flow{From: storageAddress, To: beneficiary, Amount: balance.Clone(), Token: common.HexToAddress("0x")},
+			})
 		}
 	}
 }
@@ -194,4 +300,19 @@ func (t *TokenflowTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope t
 func (t *TokenflowTracer) CaptureTxEndSetAdditionalResults(results *types.MessageResults) {
 	// Store our tracer results.
 	results.AdditionalResults[tokenflowTracerResultsKey] = t.tokenflowSet
+	t.lastResults = results
+}
+
+// ReleaseResults releases the tokenflow set of the latest transaction for reuse, if it was stored in the provided
+// message results, as defined by fitnessmetrics.ResultReleaser. It is removed from the message results.
+func (t *TokenflowTracer) ReleaseResults(results *types.MessageResults) {
+	if t == nil || t.tokenflowSet == nil || results != t.lastResults {
+		return
+	}
+	if GetTokenflowTracerResults(results) == t.tokenflowSet {
+		RemoveTokenflowTracerResults(results)
+	}
+	t.resultPool.Put(t.tokenflowSet)
+	t.tokenflowSet = nil
+	t.lastResults = nil
 }
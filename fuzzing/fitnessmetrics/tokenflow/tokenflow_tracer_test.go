@@ -0,0 +1,176 @@
+package tokenflow
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core"
+	coretypes "github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa-geth/crypto"
+	"github.com/crytic/medusa/chain"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	// tokenflowTestSender is the address sending the transactions executed by the tracer tests.
+	tokenflowTestSender = common.HexToAddress("0x10000")
+	// tokenflowTestContract is the address of the contract performing transfers in the tracer tests.
+	tokenflowTestContract = common.HexToAddress("0x20000")
+	// tokenflowTestCallee is the address of the contract called by tokenflowTestContract in the tracer tests.
+	tokenflowTestCallee = common.HexToAddress("0x30000")
+	// tokenflowTestRecipient is the address tokens are transferred to in the tracer tests.
+	tokenflowTestRecipient = common.HexToAddress("0x40000")
+)
+
+// Runtime bytecode of callees which succeed returning nothing, return false, return true, or revert.
+var (
+	tokenflowTestStopCode   = []byte{byte(vm.STOP)}
+	tokenflowTestFalseCode  = []byte{byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0, byte(vm.RETURN)}
+	tokenflowTestTrueCode   = []byte{byte(vm.PUSH1), 1, byte(vm.PUSH1), 0, byte(vm.MSTORE), byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0, byte(vm.RETURN)}
+	tokenflowTestRevertCode = []byte{byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.REVERT)}
+)
+
+// executeTokenflowTracer executes a call with the provided data and value to tokenflowTestContract, holding the
+// provided runtime bytecode and balance, with tokenflowTestCallee holding the provided runtime bytecode and a
+// TokenflowTracer attached.
+// Returns the successful token flows recorded.
+func executeTokenflowTracer(t *testing.T, code []byte, balance int64, calleeCode []byte, data []byte, value int64) []*Tokenflow {
+	testChain, err := chain.NewTestChain(context.Background(), coretypes.GenesisAlloc{
+		tokenflowTestSender:   {Balance: big.NewInt(1_000_000)},
+		tokenflowTestContract: {Balance: big.NewInt(balance), Code: code},
+		tokenflowTestCallee:   {Balance: big.NewInt(0), Code: calleeCode},
+	}, nil)
+	assert.NoError(t, err)
+	defer testChain.Close()
+	tracer := NewTokenflowTracer()
+	testChain.AddTracer(tracer.NativeTracer(), true, false)
+
+	_, err = testChain.PendingBlockCreate()
+	assert.NoError(t, err)
+	err = testChain.PendingBlockAddTx(&core.Message{
+		From:      tokenflowTestSender,
+		To:        &tokenflowTestContract,
+		Value:     big.NewInt(value),
+		Data:      data,
+		GasLimit:  1_000_000,
+		GasPrice:  big.NewInt(0),
+		GasFeeCap: big.NewInt(0),
+		GasTipCap: big.NewInt(0),
+	})
+	assert.NoError(t, err)
+	return GetTokenflowTracerResults(testChain.PendingBlock().MessageResults[0]).Tokenflows()
+}
+
+// getForwarderCode returns runtime bytecode which calls tokenflowTestCallee with the call data and value it was
+// called with, and either stops or reverts afterwards.
+// Returns the bytecode and the program counter of its CALL.
+func getForwarderCode(revert bool) ([]byte, uint64) {
+	code := []byte{
+		byte(vm.CALLDATASIZE), byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.CALLDATACOPY),
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.CALLDATASIZE), byte(vm.PUSH1), 0, byte(vm.CALLVALUE),
+		byte(vm.PUSH20),
+	}
+	code = append(code, tokenflowTestCallee.Bytes()...)
+	code = append(code, byte(vm.GAS))
+	callPc := uint64(len(code))
+	code = append(code, byte(vm.CALL), byte(vm.POP))
+	if revert {
+		return append(code, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.REVERT)), callPc
+	}
+	return append(code, byte(vm.STOP)), callPc
+}
+
+// getCreatorCode returns runtime bytecode which creates a contract from the provided init bytecode (of at most 32
+// bytes), sending it the value it was called with.
+// Returns the bytecode and the program counter of its CREATE.
+func getCreatorCode(initCode []byte) ([]byte, uint64) {
+	code := []byte{byte(vm.PUSH1) + byte(len(initCode)) - 1}
+	code = append(code, initCode...)
+	code = append(code,
+		byte(vm.PUSH1), 0, byte(vm.MSTORE),
+		byte(vm.PUSH1), byte(len(initCode)), byte(vm.PUSH1), byte(32-len(initCode)), byte(vm.CALLVALUE),
+	)
+	createPc := uint64(len(code))
+	return append(code, byte(vm.CREATE), byte(vm.POP), byte(vm.STOP)), createPc
+}
+
+// getTransferData returns the call data of an ERC20 transfer of the provided amount to tokenflowTestRecipient.
+func getTransferData(amount uint64) []byte {
+	data := []byte{0xa9, 0x05, 0x9c, 0xbb}
+	data = append(data, common.LeftPadBytes(tokenflowTestRecipient.Bytes(), 32)...)
+	return append(data, common.LeftPadBytes(new(big.Int).SetUint64(amount).Bytes(), 32)...)
+}
+
+// TestTokenflowTracerDeferredCallFlows tests that token transfers performed by calls are only recorded once the call
+// returns successfully, with decoded transfers also failing if the call returns false, while ether sent is recorded
+// regardless of the call's return value.
+func TestTokenflowTracerDeferredCallFlows(t *testing.T) {
+	forwarderCode, callPc := getForwarderCode(false)
+	revertingForwarderCode, _ := getForwarderCode(true)
+	etherFlow := Flow{From: tokenflowTestContract, To: tokenflowTestCallee, Amount: uint256.NewInt(5)}
+	transferFlow := Flow{From: tokenflowTestContract, To: tokenflowTestRecipient, Amount: uint256.NewInt(7), Token: tokenflowTestCallee}
+	tests := []struct {
+		name       string
+		code       []byte
+		calleeCode []byte
+		data       []byte
+		value      int64
+		expected   []Flow
+	}{
+		{name: "ether sent", code: forwarderCode, calleeCode: tokenflowTestStopCode, value: 5, expected: []Flow{etherFlow}},
+		{name: "ether sent to a reverting callee", code: forwarderCode, calleeCode: tokenflowTestRevertCode, value: 5},
+		{name: "ether sent by a reverting caller", code: revertingForwarderCode, calleeCode: tokenflowTestStopCode, value: 5},
+		{name: "transfer returning true", code: forwarderCode, calleeCode: tokenflowTestTrueCode, data: getTransferData(7), expected: []Flow{transferFlow}},
+		{name: "transfer returning nothing", code: forwarderCode, calleeCode: tokenflowTestStopCode, data: getTransferData(7), expected: []Flow{transferFlow}},
+		{name: "transfer returning false", code: forwarderCode, calleeCode: tokenflowTestFalseCode, data: getTransferData(7)},
+		{name: "transfer reverting", code: forwarderCode, calleeCode: tokenflowTestRevertCode, data: getTransferData(7)},
+		{name: "transfer returning false with ether sent", code: forwarderCode, calleeCode: tokenflowTestFalseCode, data: getTransferData(7), value: 5, expected: []Flow{etherFlow}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tokenflows := executeTokenflowTracer(t, test.code, 0, test.calleeCode, test.data, test.value)
+			assert.Len(t, tokenflows, len(test.expected))
+			for i, expected := range test.expected {
+				assert.EqualValues(t, ProgramPosition{Address: tokenflowTestContract, Pc: callPc}, *tokenflows[i].Position)
+				assert.EqualValues(t, expected, *tokenflows[i].Flow)
+			}
+		})
+	}
+}
+
+// TestTokenflowTracerCreateFlows tests that ether sent to a contract being created is recorded as sent to the address
+// of the contract created, once its creation succeeds.
+func TestTokenflowTracerCreateFlows(t *testing.T) {
+	creatorCode, createPc := getCreatorCode([]byte{byte(vm.STOP)})
+	tokenflows := executeTokenflowTracer(t, creatorCode, 0, nil, nil, 5)
+	assert.Len(t, tokenflows, 1)
+	assert.EqualValues(t, ProgramPosition{Address: tokenflowTestContract, Pc: createPc}, *tokenflows[0].Position)
+	assert.EqualValues(t, Flow{
+		From:   tokenflowTestContract,
+		To:     crypto.CreateAddress(tokenflowTestContract, 0),
+		Amount: uint256.NewInt(5),
+	}, *tokenflows[0].Flow)
+
+	// Creations which revert do not receive the ether.
+	creatorCode, _ = getCreatorCode(tokenflowTestRevertCode)
+	assert.Empty(t, executeTokenflowTracer(t, creatorCode, 0, nil, nil, 5))
+}
+
+// TestTokenflowTracerSelfdestructFlows tests that the balance of a contract which self-destructs is recorded as sent
+// to its beneficiary, while self-destructing without a balance transfers nothing.
+func TestTokenflowTracerSelfdestructFlows(t *testing.T) {
+	code := append([]byte{byte(vm.PUSH20)}, tokenflowTestRecipient.Bytes()...)
+	selfdestructPc := uint64(len(code))
+	code = append(code, byte(vm.SELFDESTRUCT))
+
+	tokenflows := executeTokenflowTracer(t, code, 100, nil, nil, 0)
+	assert.Len(t, tokenflows, 1)
+	assert.EqualValues(t, ProgramPosition{Address: tokenflowTestContract, Pc: selfdestructPc}, *tokenflows[0].Position)
+	assert.EqualValues(t, Flow{From: tokenflowTestContract, To: tokenflowTestRecipient, Amount: uint256.NewInt(100)}, *tokenflows[0].Flow)
+
+	assert.Empty(t, executeTokenflowTracer(t, code, 0, nil, nil, 0))
+}
@@ -0,0 +1,199 @@
+package fuzzing
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/crytic/medusa-geth/accounts/abi"
+	"github.com/crytic/medusa-geth/common"
+	coreTypes "github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa-geth/crypto"
+	"github.com/crytic/medusa/chain"
+	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/crytic/medusa/utils"
+)
+
+// erc20SeedingAbi describes the ERC20 methods used to seed token balances.
+const erc20SeedingAbi = `[
+	{"type":"function","name":"balanceOf","inputs":[{"name":"account","type":"address"}],"outputs":[{"name":"","type":"uint256"}],"stateMutability":"view"},
+	{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable"}
+]`
+
+// maxBalanceSlotProbes describes the amount of storage slots probed for the balances mapping of a token whose
+// balance slot is not configured.
+const maxBalanceSlotProbes = 100
+
+// balanceSlotProbeValue describes the balance written to candidate slots of a token's balances mapping while probing,
+// chosen so that it is unlikely to be the actual balance of a holder.
+var balanceSlotProbeValue = common.HexToHash("0x6d6564757361206261616c616e63652070726f6265")
+
+// seedForkBalances gives the holders of the project configuration's fork seeding ether and ERC20 token balances, with
+// transactions committed in a new block of the provided test chain, so that they are replayed onto every worker's
+// chain. Holders default to the provided adversarial addresses.
+// Returns an error if a balance could not be seeded.
+func (f *Fuzzer) seedForkBalances(testChain *chain.TestChain, adversarialAddresses []common.Address) error {
+	seedingConfig := f.config.Fuzzing.ForkSeeding
+	if seedingConfig.EtherBalance == nil && len(seedingConfig.Tokens) == 0 {
+		return nil
+	}
+	holders, err := f.forkSeedingHolders(adversarialAddresses)
+	if err != nil {
+		return err
+	}
+	erc20Abi, err := abi.JSON(strings.NewReader(erc20SeedingAbi))
+	if err != nil {
+		return err
+	}
+
+	// Create the messages seeding each balance, described for error messages.
+	msgs := make([]*calls.CallMessage, 0)
+	descriptions := make([]string, 0)
+	if seedingConfig.EtherBalance != nil {
+		for _, holder := range holders {
+			msg, err := f.newCheatCodeMessage(testChain, "deal", holder, &seedingConfig.EtherBalance.Int)
+			if err != nil {
+				return err
+			}
+			msgs = append(msgs, msg)
+			descriptions = append(descriptions, fmt.Sprintf("dealing ether to %s", holder.Hex()))
+		}
+	}
+	for _, token := range seedingConfig.Tokens {
+		tokenMsgs, err := f.newTokenSeedingMessages(testChain, &erc20Abi, token, holders)
+		if err != nil {
+			return err
+		}
+		for _, holder := range holders {
+			descriptions = append(descriptions, fmt.Sprintf("seeding token %s to %s", token.Address, holder.Hex()))
+		}
+		msgs = append(msgs, tokenMsgs...)
+	}
+
+	// Execute the messages in a single block.
+	block, err := testChain.PendingBlockCreate()
+	if err != nil {
+		return err
+	}
+	for _, msg := range msgs {
+		msg.FillFromTestChainProperties(testChain)
+		if err = testChain.PendingBlockAddTx(msg.ToCoreMessage()); err != nil {
+			return err
+		}
+	}
+	if err = testChain.PendingBlockCommit(); err != nil {
+		return err
+	}
+	for i, messageResult := range block.MessageResults {
+		if messageResult.Receipt.Status != coreTypes.ReceiptStatusSuccessful {
+			return fmt.Errorf("failed %s: %v", descriptions[i], messageResult.ExecutionResult.Err)
+		}
+	}
+
+	f.logger.Info(fmt.Sprintf("Seeded balances of %d holder(s) in ether and %d token(s)", len(holders), len(seedingConfig.Tokens)))
+	return nil
+}
+
+// forkSeedingHolders returns the holders of the project configuration's fork seeding, which default to the provided
+// adversarial addresses: the senders, the attacker accounts, the helper contract, if deployed, and the attacker
+// contracts.
+// Returns the holders, or an error if they could not be resolved.
+func (f *Fuzzer) forkSeedingHolders(adversarialAddresses []common.Address) ([]common.Address, error) {
+	if len(f.config.Fuzzing.ForkSeeding.Holders) > 0 {
+		return utils.HexStringsToAddresses(f.config.Fuzzing.ForkSeeding.Holders)
+	}
+	return adversarialAddresses, nil
+}
+
+// newTokenSeedingMessages creates the messages giving each of the provided holders the configured balance of a token,
+// either transferred by its whale, which is impersonated, or written to the token's storage with the store cheat code.
+// Returns the messages, or an error if they could not be created.
+func (f *Fuzzer) newTokenSeedingMessages(testChain *chain.TestChain, erc20Abi *abi.ABI, token config.TokenSeedConfig, holders []common.Address) ([]*calls.CallMessage, error) {
+	tokenAddress, err := utils.HexStringToAddress(token.Address)
+	if err != nil {
+		return nil, err
+	}
+	msgs := make([]*calls.CallMessage, 0, len(holders))
+
+	// Transfer the balance from the whale, without requiring its key or it to be an externally owned account.
+	if token.Whale != "" {
+		whale, err := utils.HexStringToAddress(token.Whale)
+		if err != nil {
+			return nil, err
+		}
+		for _, holder := range holders {
+			data, err := erc20Abi.Pack("transfer", holder, &token.Balance.Int)
+			if err != nil {
+				return nil, err
+			}
+			msg := calls.NewCallMessage(whale, &tokenAddress, 0, big.NewInt(0), testChain.BlockGasLimit, big.NewInt(0), nil, nil, data)
+			msg.SkipFromEOACheck = true
+			msg.SkipNonceChecks = true
+			msgs = append(msgs, msg)
+		}
+		return msgs, nil
+	}
+
+	// Otherwise, write the balance to the slot of each holder in the token's balances mapping.
+	for _, holder := range holders {
+		slot, err := f.findBalanceSlot(testChain, erc20Abi, tokenAddress, holder, token.BalanceSlot)
+		if err != nil {
+			return nil, err
+		}
+		msg, err := f.newCheatCodeMessage(testChain, "store", tokenAddress, [32]byte(slot), [32]byte(common.BigToHash(&token.Balance.Int)))
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+// findBalanceSlot determines the storage slot of the provided holder in the balances mapping of the provided token.
+// If the mapping's slot is configured, the holder's slot is derived from it using the Solidity mapping layout.
+// Otherwise, candidate slots of the Solidity and Vyper mapping layouts are written with a probe value, and the first
+// one whose value is returned by the token's balanceOf method is chosen. Writes are reverted after each probe.
+// Returns the storage slot, or an error if none was found.
+func (f *Fuzzer) findBalanceSlot(testChain *chain.TestChain, erc20Abi *abi.ABI, token common.Address, holder common.Address, mappingSlot *uint64) (common.Hash, error) {
+	if mappingSlot != nil {
+		return mappingValueSlot(holder, *mappingSlot, false), nil
+	}
+
+	data, err := erc20Abi.Pack("balanceOf", holder)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	msg := calls.NewCallMessage(f.deployer, &token, 0, big.NewInt(0), testChain.BlockGasLimit, nil, nil, nil, data)
+	msg.FillFromTestChainProperties(testChain)
+
+	state := testChain.State()
+	for i := uint64(0); i < maxBalanceSlotProbes; i++ {
+		for _, vyperLayout := range []bool{false, true} {
+			slot := mappingValueSlot(holder, i, vyperLayout)
+			snapshot := state.Snapshot()
+			state.SetState(token, slot, balanceSlotProbeValue)
+			result, err := testChain.CallContract(msg.ToCoreMessage(), nil)
+			state.RevertToSnapshot(snapshot)
+			if err != nil {
+				return common.Hash{}, err
+			}
+			if !result.Failed() && bytes.Equal(result.ReturnData, balanceSlotProbeValue.Bytes()) {
+				return slot, nil
+			}
+		}
+	}
+	return common.Hash{}, fmt.Errorf("could not find the balances mapping of token %s in its first %d storage slots, configure its balance slot or a whale", token.Hex(), maxBalanceSlotProbes)
+}
+
+// mappingValueSlot returns the storage slot of the value of the provided key in a mapping stored at the provided slot,
+// using the layout of Vyper mappings if directed, or of Solidity mappings otherwise.
+func mappingValueSlot(key common.Address, mappingSlot uint64, vyperLayout bool) common.Hash {
+	keyWord := common.LeftPadBytes(key.Bytes(), 32)
+	slotWord := common.BigToHash(new(big.Int).SetUint64(mappingSlot)).Bytes()
+	if vyperLayout {
+		return crypto.Keccak256Hash(slotWord, keyWord)
+	}
+	return crypto.Keccak256Hash(keyWord, slotWord)
+}
@@ -19,6 +19,16 @@ import (
 
 	"github.com/crytic/medusa/fuzzing/bugdetector"
 	"github.com/crytic/medusa/fuzzing/executiontracer"
+	"github.com/crytic/medusa/fuzzing/explorer"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/balancedelta"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/branchcoverage"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/branchdistance"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/codecoverage"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/dataflow"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/selectorcoverage"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/storagewrite"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/tokenflow"
 	"github.com/crytic/medusa/fuzzing/reverts"
 
 	"github.com/crytic/medusa/fuzzing/coverage"
@@ -62,6 +72,9 @@ type Fuzzer struct {
 	senders []common.Address
 	// deployer describes an account address used to deploy contracts in fuzzing campaigns.
 	deployer common.Address
+	// attackers describes the attacker accounts, which are funded in the genesis block and treated as adversarial by
+	// bug detectors. They are also part of senders, unless configured otherwise.
+	attackers []common.Address
 
 	// compilations describes all compilations added as targets.
 	compilations []compilationTypes.Compilation
@@ -72,6 +85,10 @@ type Fuzzer struct {
 	// Fuzzer but down the line we can use slither for other capabilities that may require storage of the results.
 	slitherResults *compilationTypes.SlitherResults
 
+	// deployedContractAddresses describes the addresses of the contracts deployed when the test chain was set up, by
+	// name.
+	deployedContractAddresses map[string]common.Address
+
 	// baseValueSet represents a valuegeneration.ValueSet containing input values for our fuzz tests.
 	baseValueSet *valuegeneration.ValueSet
 
@@ -108,6 +125,9 @@ type Fuzzer struct {
 	// logger describes the Fuzzer's log object that can be used to log important events
 	logger *logging.Logger
 
+	// startTime describes the time the fuzzing campaign started at, once the workers are about to start.
+	startTime time.Time
+
 	// lastPCsLogMsg records the last time we logged total PCs hit.
 	// It takes a decent amount of time to calculate, so we only log once a minute,
 	// and only when debug logging is enabled.
@@ -116,13 +136,81 @@ type Fuzzer struct {
 
 	// is on-chain target
 	isOnChainTarget bool
+
+	// contractSources describes the sources the verified ABIs of on-chain targets are fetched from, in order of
+	// preference. They are created when the first on-chain target is loaded, as enabled by the project configuration.
+	contractSources []explorer.ContractSource
+
+	// branchDistanceDumpWriter writes the best branch distances of the corpus to disk, or is nil if branch distance
+	// dumps are disabled.
+	branchDistanceDumpWriter *branchdistance.BranchDistanceDumpWriter
+
+	// coverageTimeSeriesWriter writes a time series of the fuzzing metrics to disk, or is nil if it is disabled.
+	coverageTimeSeriesWriter *coverageTimeSeriesWriter
+
+	// statefulSnapshots holds the call histories leading to persistent chain states recorded in the stateful mode,
+	// which workers can use as alternative starting states.
+	statefulSnapshots []calls.CallSequence
+	// statefulSnapshotsLock provides thread-synchronization when accessing statefulSnapshots.
+	statefulSnapshotsLock sync.Mutex
+
+	// directedTargetPcs describes the runtime bytecode program counters, by contract name, which the target-directed
+	// mode directs fuzzing towards.
+	directedTargetPcs map[string][]uint64
+
+	// metricExclusions describes the contracts excluded from code coverage, branch coverage and branch distance
+	// accounting.
+	metricExclusions *fitnessmetrics.MetricExclusions
+
+	// dynamicBranchCoverageMaps and dynamicBranchDistanceMaps describe the branch maps of runtime bytecode unknown to
+	// the fuzzer, such as that of contracts deployed by factories, built by the tracers of all workers as it executes.
+	dynamicBranchCoverageMaps *fitnessmetrics.CodeMapRegistry[*branchcoverage.BranchMap]
+	dynamicBranchDistanceMaps *fitnessmetrics.CodeMapRegistry[*branchdistance.BranchMap]
+
+	// storageWriteBucketer describes how the storage write tracers bucket the values written.
+	storageWriteBucketer *storagewrite.ValueBucketer
+
+	// operandDictionary describes the per-contract dictionary of logged comparison operands and hash preimages which
+	// the value generators of workers sample arguments from, or nil if none is kept.
+	operandDictionary *valuegeneration.OperandDictionary
+
+	// slotReaders describes the methods observed reading each storage slot, which workers use to order calls along
+	// dataflow, or nil if calls are not ordered along dataflow.
+	slotReaders *dataflow.SlotReaders
+
+	// onChainInteractions describes the productive transitions between on-chain targets observed by workers, which
+	// they use to interleave calls among targets, or nil if calls are not interleaved.
+	onChainInteractions *onChainInteractionModel
+
+	// baseTestChain describes the set up test chain which workers clone when they are created. It is replaced when the
+	// forked chain state is refreshed.
+	baseTestChain *chain.TestChain
+	// baseAdversaries describes the adversarial addresses of baseTestChain, and the ether they held once it was set up.
+	baseAdversaries *adversaries
+	// baseTestChainLock provides thread-synchronization when accessing baseTestChain.
+	baseTestChainLock sync.Mutex
+
+	// storageLayouts describes the storage layouts of the contracts deployed on the chains of workers, used to read
+	// and name their state variables.
+	storageLayouts *storageLayoutRegistry
+
+	// tokenSelectorRegistry describes the value-moving selectors the tokenflow tracers decode token transfers from.
+	tokenSelectorRegistry *tokenflow.TokenSelectorRegistry
+
+	// balanceDeltaHolders and balanceDeltaTokens describe the addresses whose net balance deltas the balance delta
+	// tracers track, and the ERC20 tokens whose balances they track in addition to ether.
+	balanceDeltaHolders []common.Address
+	balanceDeltaTokens  []balancedelta.TrackedToken
+
+	// metricControl describes the metrics paused while fuzzing through ReloadMetricConfig.
+	metricControl metricControl
 }
 
 // Amount of time between "total PCs hit" log messages. This message is only output when debug logging is enabled.
 const timeBetweenPCsLogMsgs = time.Minute
 
-// Large number used for block gas limit that should never get hit.
-const blockGasLimit = 0x0FFFFFFFFFFFFFFF
+// Maximum amount of almost passing revert sites printed when the fuzzer exits.
+const maxAlmostPassingRevertSitesToPrint = 10
 
 // NewFuzzer returns an instance of a new Fuzzer provided a project configuration, or an error if one is encountered
 // while initializing the code.
@@ -160,6 +248,16 @@ func NewFuzzer(config config.ProjectConfig) (*Fuzzer, error) {
 	// Update the log level of the global logger now
 	logging.GlobalLogger.SetLevel(config.Logging.Level)
 
+	// Set the verbosity of the diagnostics of each subsystem, now that the global logger is set up
+	for _, subsystem := range logging.DiagnosticsSubsystems() {
+		logging.SetDiagnosticsVerbosity(subsystem, config.Logging.Diagnostics[subsystem])
+	}
+	for subsystem := range config.Logging.Diagnostics {
+		if !slices.Contains(logging.DiagnosticsSubsystems(), subsystem) {
+			logging.GlobalLogger.Warn("Ignoring the diagnostics verbosity of unknown subsystem ", subsystem, " (known subsystems: ", strings.Join(logging.DiagnosticsSubsystems(), ", "), ")")
+		}
+	}
+
 	// Get the fuzzer's custom sub-logger
 	logger := logging.GlobalLogger.NewSubLogger("module", "fuzzer")
 
@@ -170,6 +268,20 @@ func NewFuzzer(config config.ProjectConfig) (*Fuzzer, error) {
 		return nil, err
 	}
 
+	// Parse the attacker accounts, which send transactions along with the senders unless configured otherwise.
+	attackers, err := utils.HexStringsToAddresses(config.Fuzzing.Adversaries.Accounts)
+	if err != nil {
+		logger.Error("Invalid attacker account address(es)", err)
+		return nil, err
+	}
+	if config.Fuzzing.Adversaries.SendTransactions {
+		for _, attacker := range attackers {
+			if !slices.Contains(senders, attacker) {
+				senders = append(senders, attacker)
+			}
+		}
+	}
+
 	// Parse the deployer address from our account config
 	deployer, err := utils.HexStringToAddress(config.Fuzzing.DeployerAddress)
 	if err != nil {
@@ -193,12 +305,14 @@ func NewFuzzer(config config.ProjectConfig) (*Fuzzer, error) {
 		config:              config,
 		senders:             senders,
 		deployer:            deployer,
+		attackers:           attackers,
 		baseValueSet:        valuegeneration.NewValueSet(),
 		contractDefinitions: make(fuzzerTypes.Contracts, 0),
 		testCases:           make([]TestCase, 0),
 		testCasesFinished:   make(map[string]TestCase),
 		revertReporter:      revertReporter,
 		corpusPruner:        corpusPruner,
+		storageLayouts:      newStorageLayoutRegistry(),
 		Hooks: FuzzerHooks{
 			NewCallSequenceGeneratorConfigFunc: defaultCallSequenceGeneratorConfigFunc,
 			NewShrinkingValueMutatorFunc:       defaultShrinkingValueMutatorFunc,
@@ -214,6 +328,9 @@ func NewFuzzer(config config.ProjectConfig) (*Fuzzer, error) {
 	for _, sender := range fuzzer.senders {
 		fuzzer.baseValueSet.AddAddress(sender)
 	}
+	for _, attacker := range fuzzer.attackers {
+		fuzzer.baseValueSet.AddAddress(attacker)
+	}
 
 	// init on-chain target contracts
 	for _, target := range config.Fuzzing.TargetContracts {
@@ -222,12 +339,11 @@ func NewFuzzer(config config.ProjectConfig) (*Fuzzer, error) {
 			// load ABI to init contract definition
 			targetAddress := strings.ToLower(target)
 			fuzzer.logger.Info(fmt.Sprintf("Init contract of target %s ", targetAddress), colors.Reset)
-			contract, err := fuzzer.loadOnChainContract(targetAddress)
+			contractDefinitions, err := fuzzer.loadOnChainTarget(targetAddress)
 			if err != nil {
 				return nil, err
 			}
-			contractDefinition := fuzzerTypes.NewContract(targetAddress, targetAddress, contract, nil)
-			fuzzer.contractDefinitions = append(fuzzer.contractDefinitions, contractDefinition)
+			fuzzer.addOnChainContractDefinitions(contractDefinitions)
 		}
 	}
 
@@ -273,6 +389,12 @@ func NewFuzzer(config config.ProjectConfig) (*Fuzzer, error) {
 	if fuzzer.config.Fuzzing.Testing.OptimizationTesting.Enabled {
 		attachOptimizationTestCaseProvider(fuzzer)
 	}
+	if fuzzer.config.Fuzzing.Testing.IdempotencyTesting.Enabled {
+		attachIdempotencyTestCaseProvider(fuzzer)
+	}
+	if fuzzer.config.Fuzzing.UseBugDetector() && fuzzer.config.Fuzzing.BugDetectionConfig.ShrinkFindings {
+		attachBugDetectorTestCaseProvider(fuzzer)
+	}
 	return fuzzer, nil
 }
 
@@ -482,8 +604,9 @@ func (f *Fuzzer) AddCompilationTargets(compilations []compilationTypes.Compilati
 	}
 }
 
-// createTestChain creates a test chain with the account balance allocations specified by the config.
-func (f *Fuzzer) createTestChain() (*chain.TestChain, error) {
+// createTestChain creates a test chain with the account balance allocations specified by the config. If directed, a
+// forked chain is forked from the latest block of the fork's RPC rather than the configured block.
+func (f *Fuzzer) createTestChain(latestForkBlock bool) (*chain.TestChain, error) {
 	// Create our genesis allocations.
 	// NOTE: Sharing GenesisAlloc between chains will result in some accounts not being funded for some reason.
 	genesisAlloc := make(types.GenesisAlloc)
@@ -500,6 +623,15 @@ func (f *Fuzzer) createTestChain() (*chain.TestChain, error) {
 		}
 	}
 
+	// Fund our attacker accounts in the genesis block, unless they are funded as senders
+	for _, attacker := range f.attackers {
+		if _, ok := genesisAlloc[attacker]; !ok {
+			genesisAlloc[attacker] = types.Account{
+				Balance: new(big.Int).Set(f.config.Fuzzing.Adversaries.AccountBalance),
+			}
+		}
+	}
+
 	// Fund our deployer address in the genesis block
 	genesisAlloc[f.deployer] = types.Account{
 		Balance: new(big.Int).Set(initBalance),
@@ -540,14 +672,17 @@ func (f *Fuzzer) createTestChain() (*chain.TestChain, error) {
 	f.config.Fuzzing.TestChainConfig.ContractAddressOverrides = contractAddressOverrides
 
 	// Create our test chain with our basic allocations and passed medusa's chain configuration
-	testChain, err := chain.NewTestChain(f.ctx, genesisAlloc, &f.config.Fuzzing.TestChainConfig)
+	testChainConfig := &f.config.Fuzzing.TestChainConfig
+	if latestForkBlock {
+		latestConfig := *testChainConfig
+		latestConfig.ForkConfig.RpcBlock = 0
+		testChainConfig = &latestConfig
+	}
+	testChain, err := chain.NewTestChain(f.ctx, genesisAlloc, testChainConfig)
 	if err != nil {
 		return nil, err
 	}
 
-	// Set our block gas limit
-	testChain.BlockGasLimit = blockGasLimit
-
 	// deal with on-chain target contracts
 	if f.isOnChainTarget {
 
@@ -635,15 +770,16 @@ func chainSetupFromCompilations(fuzzer *Fuzzer, testChain *chain.TestChain) (*ex
 						return nil, fmt.Errorf("predeployed contracts cannot accept constructor arguments")
 					}
 					jsonArgs, ok := fuzzer.config.Fuzzing.ConstructorArgs[contractName]
-					if !ok {
+					if ok {
+						decoded, err := valuegeneration.DecodeJSONArgumentsFromMap(contract.CompiledContract().Abi.Constructor.Inputs,
+							jsonArgs, deployedContractAddr)
+						if err != nil {
+							return nil, err
+						}
+						args = decoded
+					} else if !fuzzer.config.Fuzzing.ConstructorArgsSearch.Enabled {
 						return nil, fmt.Errorf("constructor arguments for contract %s not provided", contractName)
 					}
-					decoded, err := valuegeneration.DecodeJSONArgumentsFromMap(contract.CompiledContract().Abi.Constructor.Inputs,
-						jsonArgs, deployedContractAddr)
-					if err != nil {
-						return nil, err
-					}
-					args = decoded
 				}
 
 				// If our project config has a non-zero balance for this target contract, retrieve it
@@ -651,8 +787,17 @@ func chainSetupFromCompilations(fuzzer *Fuzzer, testChain *chain.TestChain) (*ex
 				if len(balances) > i {
 					contractBalance = new(big.Int).Set(&balances[i].Int)
 				}
+
+				// Search for constructor arguments covering the most of the init bytecode, if requested.
+				if fuzzer.config.Fuzzing.ConstructorArgsSearch.Enabled && len(contract.CompiledContract().Abi.Constructor.Inputs) > 0 {
+					var err error
+					args, err = fuzzer.searchConstructorArgs(testChain, contract, args, contractBalance, deployedContractAddr)
+					if err != nil {
+						return nil, err
+					}
+				}
 				// Deploy the contract with resolved library dependencies
-				result, err := fuzzer.deployContract(testChain, contract, args, contractBalance, deployedContractAddr)
+				result, err := fuzzer.deployContract(testChain, fuzzer.deployer, contract, args, contractBalance, deployedContractAddr)
 				if err != nil {
 					// If the result is an execution trace, return it
 					if trace, ok := result.(*executiontracer.ExecutionTrace); ok {
@@ -676,10 +821,13 @@ func chainSetupFromCompilations(fuzzer *Fuzzer, testChain *chain.TestChain) (*ex
 			return nil, fmt.Errorf("%v was specified in the target contracts but was not found in the compilation artifacts", contractName)
 		}
 	}
+
+	// Record the deployed contracts, so the constructor arguments of attacker contracts can reference them by name.
+	fuzzer.deployedContractAddresses = deployedContractAddr
 	return nil, nil
 }
 
-func (f *Fuzzer) deployContract(testChain *chain.TestChain, contract *fuzzerTypes.Contract, args []any, contractBalance *big.Int, deployedContracts map[string]common.Address) (any, error) {
+func (f *Fuzzer) deployContract(testChain *chain.TestChain, sender common.Address, contract *fuzzerTypes.Contract, args []any, contractBalance *big.Int, deployedContracts map[string]common.Address) (any, error) {
 	contractName := contract.Name()
 	contract.CompiledContract().LinkBytecodes(contractName, deployedContracts)
 
@@ -701,7 +849,7 @@ func (f *Fuzzer) deployContract(testChain *chain.TestChain, contract *fuzzerType
 
 	// Create a message to represent our contract deployment (we let deployments consume the whole block
 	// gas limit rather than use tx gas limit)
-	msg := calls.NewCallMessage(f.deployer, nil, 0, contractBalance, blockGasLimit, nil, nil, nil, msgData)
+	msg := calls.NewCallMessage(sender, nil, 0, contractBalance, testChain.BlockGasLimit, nil, nil, nil, msgData)
 	msg.FillFromTestChainProperties(testChain)
 
 	// Create a new pending block we'll commit to chain
@@ -786,6 +934,24 @@ func defaultCallSequenceGeneratorConfigFunc(fuzzer *Fuzzer, valueSet *valuegener
 	}
 	mutationalGenerator := valuegeneration.NewMutationalValueGenerator(mutationalGeneratorConfig, valueSet, randomProvider)
 
+	// Sample arguments from the dictionary of logged comparison operands, if one is kept.
+	if fuzzer.operandDictionary != nil {
+		mutationalGeneratorConfig.DictionaryValueProbability = fuzzer.config.Fuzzing.CmpLog.DictionaryProbability
+		mutationalGenerator.SetOperandDictionary(fuzzer.operandDictionary)
+	}
+
+	// Substitute logged comparison operands into call data only if they are being recorded.
+	cmpLogWeight := uint64(0)
+	if fuzzer.config.Fuzzing.CmpLog.Enabled {
+		cmpLogWeight = fuzzer.config.Fuzzing.CmpLog.SubstitutionWeight
+	}
+
+	// Transplant high-value calls only if the corpus keeps them.
+	callTransplantWeight := uint64(0)
+	if fuzzer.config.Fuzzing.CallTransplant.Enabled {
+		callTransplantWeight = fuzzer.config.Fuzzing.CallTransplant.Weight
+	}
+
 	// Create a sequence generator config which uses the created value generator.
 	sequenceGenConfig := &CallSequenceGeneratorConfig{
 		NewSequenceProbability:                   0.3,
@@ -797,8 +963,12 @@ func defaultCallSequenceGeneratorConfigFunc(fuzzer *Fuzzer, valueSet *valuegener
 		RandomMutatedCorpusTailWeight:            10,
 		RandomMutatedSpliceAtRandomWeight:        20,
 		RandomMutatedInterleaveAtRandomWeight:    10,
+		RandomCmpLogCorpusHeadWeight:             cmpLogWeight,
+		RandomCallTransplantWeight:               callTransplantWeight,
 		ValueGenerator:                           mutationalGenerator,
 		ValueMutator:                             mutationalGenerator,
+		NestedCallProbability:                    fuzzer.config.Fuzzing.NestedCalls.Probability,
+		NestedCallMaxDepth:                       fuzzer.config.Fuzzing.NestedCalls.MaxDepth,
 	}
 	return sequenceGenConfig, nil
 }
@@ -816,7 +986,7 @@ func defaultShrinkingValueMutatorFunc(fuzzer *Fuzzer, valueSet *valuegeneration.
 
 // spawnWorkersLoop is a method which spawns a config-defined amount of FuzzerWorker to carry out the fuzzing campaign.
 // This function exits when Fuzzer.ctx is cancelled.
-func (f *Fuzzer) spawnWorkersLoop(baseTestChain *chain.TestChain) error {
+func (f *Fuzzer) spawnWorkersLoop() error {
 	// We create our fuzz workers in a loop, using a channel to block when we reach capacity.
 	// If we encounter any errors, we stop.
 	f.workers = make([]*FuzzerWorker, f.config.Fuzzing.Workers)
@@ -874,7 +1044,7 @@ func (f *Fuzzer) spawnWorkersLoop(baseTestChain *chain.TestChain) error {
 
 			// Run the worker and check if we received a cancelled signal, or we encountered an error.
 			if err == nil {
-				ctxCancelled, workerErr := worker.run(baseTestChain)
+				ctxCancelled, workerErr := worker.run(f.currentBaseTestChain())
 				if workerErr != nil {
 					err = workerErr
 				}
@@ -924,6 +1094,17 @@ func (f *Fuzzer) spawnWorkersLoop(baseTestChain *chain.TestChain) error {
 	return err
 }
 
+// newRandomProvider creates the random provider every other random provider of the Fuzzer is derived from. It is
+// seeded with the configured seed, or with the current time if none was configured.
+// Returns the random provider and the seed it was created with.
+func (f *Fuzzer) newRandomProvider() (*rand.Rand, int64) {
+	seed := f.config.Fuzzing.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return rand.New(rand.NewSource(seed)), seed
+}
+
 // Start begins a fuzzing operation on the provided project configuration. This operation will not return until an error
 // is encountered or the fuzzing operation has completed. Its execution can be cancelled using the Stop method.
 // Returns an error if one is encountered.
@@ -931,8 +1112,11 @@ func (f *Fuzzer) Start() error {
 	// Define our variable to catch errors
 	var err error
 
-	// While we're fuzzing, we'll want to have an initialized random provider.
-	f.randomProvider = rand.New(rand.NewSource(time.Now().UnixNano()))
+	// While we're fuzzing, we'll want to have an initialized random provider. We log its seed, so the campaign can
+	// be reproduced.
+	var seed int64
+	f.randomProvider, seed = f.newRandomProvider()
+	f.logger.Info("Using random seed ", colors.Bold, seed, colors.Reset)
 
 	// Create our main and emergency running context (allows us to cancel across threads)
 	f.ctx, f.ctxCancelFunc = context.WithCancel(context.Background())
@@ -944,11 +1128,15 @@ func (f *Fuzzer) Start() error {
 		f.ctx, f.ctxCancelFunc = context.WithTimeout(f.ctx, time.Duration(f.config.Fuzzing.Timeout)*time.Second)
 	}
 
+	// Start counting the anomalies recovered from by tracers afresh
+	logging.ResetDiagnosticsCounters()
+
 	// Start the revert reporter
 	f.revertReporter.Start(f.ctx)
 
 	// Initialize our metrics and valueGenerator.
 	f.metrics = newFuzzerMetrics(f.config.Fuzzing.Workers, f.revertReporter.RevertMetricsCh, &f.config.Fuzzing)
+	defer f.metrics.stopIndicatorAggregator()
 
 	// Initialize our test cases and providers
 	f.testCasesLock.Lock()
@@ -956,39 +1144,16 @@ func (f *Fuzzer) Start() error {
 	f.testCasesFinished = make(map[string]TestCase)
 	f.testCasesLock.Unlock()
 
-	// Create our test chain
-	baseTestChain, err := f.createTestChain()
-	if err != nil {
-		f.logger.Error("Failed to create the test chain", err)
-		return err
-	}
-
-	// Set it up with our deployment/setup strategy defined by the fuzzer.
-	f.logger.Info("Setting up test chain")
-	trace, err := f.Hooks.ChainSetupFunc(f, baseTestChain)
+	// Create our test chain, set it up and resolve the state our tracers depend on.
+	baseTestChain, err := f.setUpCampaign()
 	if err != nil {
-		if trace != nil {
-			f.logger.Error("Failed to initialize the test chain", err, errors.New(trace.Log().ColorString()))
-		} else {
-			f.logger.Error("Failed to initialize the test chain", err)
-		}
 		return err
 	}
-	f.logger.Info("Finished setting up test chain")
 
-	// Set up helper contract
-	if f.config.Fuzzing.Testing.HelperContract.Enabled {
-		trace, err, helperContractAddress := setupFuzzHelperContract(f, baseTestChain)
-		if err != nil {
-			if trace != nil {
-				f.logger.Error("Failed to set up helper contract", err, errors.New(trace.Log().ColorString()))
-			} else {
-				f.logger.Error("Failed to set up helper contract", err)
-			}
-			return err
-		}
-		f.baseValueSet.AddAddress(helperContractAddress)
-		f.logger.Info("Setting up helper contract at address ", helperContractAddress.Hex())
+	// Expose the test chain over JSON-RPC if requested
+	rpcServer := f.startRPCServer(baseTestChain)
+	if rpcServer != nil {
+		defer rpcServer.Close()
 	}
 
 	// Create and initialize the corpus
@@ -998,12 +1163,19 @@ func (f *Fuzzer) Start() error {
 		f.logger.Error("Failed to create the corpus", err)
 		return err
 	}
-	err = f.corpus.Initialize(baseTestChain, f.contractDefinitions)
+	err = f.corpus.Initialize(baseTestChain, f.contractDefinitions, randomutils.ForkRandomProvider(f.randomProvider))
 	if err != nil {
 		f.logger.Error("Failed to initialize the corpus", err)
 		return err
 	}
 
+	// Replay the historical transactions to on-chain targets before fuzzing, so they are used in mutations
+	historicalSequences, err := f.importHistoricalTransactions()
+	if err != nil {
+		f.logger.Warn("Failed to import historical transactions to on-chain targets", err)
+	}
+	f.corpus.AddRemoteCallSequences(historicalSequences)
+
 	// Log that we will initialize corpus if there are any call sequences or test results
 	if totalCallSequences, testResults := f.corpus.CallSequenceEntryCount(); totalCallSequences > 0 || testResults > 0 {
 		f.logger.Info("Initializing corpus...")
@@ -1012,6 +1184,25 @@ func (f *Fuzzer) Start() error {
 		go f.monitorCorpusInitialization()
 	}
 
+	// Host the coordinator and synchronize with it periodically, if distributed fuzzing is enabled. We synchronize
+	// once before starting the workers, so the discoveries of the other fuzzer instances are replayed first.
+	coordinatorServer := f.startCoordinator()
+	if coordinatorServer != nil {
+		defer coordinatorServer.Close()
+	}
+	syncState := f.newDistributedSync()
+	if syncState != nil {
+		f.logger.Info("Synchronizing with the coordinator at ", colors.Bold, "http://", f.config.Fuzzing.Distributed.Address, colors.Reset, " every ", f.config.Fuzzing.Distributed.SyncInterval, " seconds")
+		f.synchronize(syncState)
+		go f.synchronizeLoop(syncState)
+	}
+
+	// Serve the live dashboard, if enabled.
+	dashboardServer := f.startDashboard()
+	if dashboardServer != nil {
+		defer dashboardServer.Close()
+	}
+
 	// Start the corpus pruner.
 	err = f.corpusPruner.Start(f.ctx, f.corpus, baseTestChain)
 	if err != nil {
@@ -1023,8 +1214,28 @@ func (f *Fuzzer) Start() error {
 	f.logger.Info("Fuzzing with ", colors.Bold, f.config.Fuzzing.Workers, colors.Reset, " workers")
 
 	// Start our printing loop now that we're about to begin fuzzing.
+	f.startTime = time.Now()
 	go f.printMetricsLoop()
 
+	// Periodically refresh the forked chain state if requested.
+	if f.config.Fuzzing.TestChainConfig.ForkConfig.ForkModeEnabled && f.config.Fuzzing.ForkTimeline.RefreshInterval > 0 {
+		go f.refreshForkLoop()
+	}
+
+	// Start dumping branch distances if requested.
+	if f.branchDistanceDumpEnabled() {
+		f.branchDistanceDumpWriter = branchdistance.NewBranchDistanceDumpWriter(f.contractDefinitions, f.config.Fuzzing.BranchDistance, f.branchDistanceDumpDirectory())
+		if f.config.Fuzzing.BranchDistance.DumpInterval > 0 {
+			go f.dumpBranchDistanceLoop()
+		}
+	}
+
+	// Start writing the coverage time series if requested.
+	if f.config.Fuzzing.CoverageTimeSeries.Interval > 0 {
+		f.coverageTimeSeriesWriter = newCoverageTimeSeriesWriter(f.coverageTimeSeriesDirectory(), f.config.Fuzzing.CoverageTimeSeries.Format)
+		go f.writeCoverageTimeSeriesLoop()
+	}
+
 	// Publish a fuzzer starting event.
 	err = f.Events.FuzzerStarting.Publish(FuzzerStartingEvent{Fuzzer: f})
 	if err != nil {
@@ -1043,13 +1254,19 @@ func (f *Fuzzer) Start() error {
 	}
 
 	// Run the main worker loop
-	err = f.spawnWorkersLoop(baseTestChain)
+	err = f.spawnWorkersLoop()
 	if err != nil {
 		f.logger.Error("Encountered an error in the main fuzzing loop", err)
 	}
 
+	// All workers exited, merge the fuzzing indicators they recorded before reporting on them.
+	f.metrics.stopIndicatorAggregator()
+
 	// NOTE: After this point, we capture errors but do not return immediately, as we want to exit gracefully.
 
+	// Publish our latest discoveries to the coordinator before exiting.
+	f.synchronize(syncState)
+
 	// If we have coverage enabled and a corpus directory set, write the corpus. We do this even if we had a
 	// previous error, as we don't want to lose corpus entries.
 	if f.config.Fuzzing.CoverageEnabled {
@@ -1069,6 +1286,18 @@ func (f *Fuzzer) Start() error {
 
 	// Print our results on exit.
 	f.printExitingResults()
+	f.printBugFindings()
+	f.printAlmostPassingRevertSites()
+	f.printUnreachedSelectors()
+	f.printBalanceDeltaGains()
+	f.printDiagnosticsCounters()
+	f.reportGasProfiles()
+	f.writeResultsReport()
+	f.writeSARIFReport()
+	f.writeFoundryReproducers()
+	f.dumpBranchDistance()
+	f.writeCoverageTimeSeries()
+	f.exportDataflowGraph()
 
 	// Finally, generate our coverage report if we have set a valid corpus directory.
 	if err == nil && len(f.config.Fuzzing.CoverageFormats) > 0 {
@@ -1089,6 +1318,14 @@ func (f *Fuzzer) Start() error {
 					path, err = coverage.WriteHTMLReport(sourceAnalysis, coverageReportDir)
 				case "lcov":
 					path, err = coverage.WriteLCOVReport(sourceAnalysis, coverageReportDir)
+				case "json":
+					var dump *coverage.CoverageDump
+					dump, err = coverage.GenerateCoverageDump(f.compilations, f.corpus.CoverageMaps(), f.logger)
+					if err == nil {
+						path, err = coverage.WriteCoverageDump(dump, coverageReportDir)
+					}
+				case "fitness-lcov", "fitness-html":
+					path, err = f.writeFitnessMetricCoverageReport(reportType, coverageReportDir)
 				default:
 					err = fmt.Errorf("unsupported coverage report type: %s", reportType)
 				}
@@ -1107,10 +1344,256 @@ func (f *Fuzzer) Start() error {
 		f.logger.Error("Failed to write reversion metrics to disk", err)
 	}
 
+	// Keep serving the test chain with any failing call sequences replayed, if requested
+	f.serveRPCServerAfterCampaign(rpcServer, baseTestChain)
+
 	// Return any encountered error.
 	return err
 }
 
+// currentBaseTestChain returns the base test chain workers clone when they are created.
+func (f *Fuzzer) currentBaseTestChain() *chain.TestChain {
+	f.baseTestChainLock.Lock()
+	defer f.baseTestChainLock.Unlock()
+	return f.baseTestChain
+}
+
+// currentBaseAdversaries returns the adversarial addresses of the base test chain workers clone when they are
+// created, and the ether they held once it was set up.
+func (f *Fuzzer) currentBaseAdversaries() *adversaries {
+	f.baseTestChainLock.Lock()
+	defer f.baseTestChainLock.Unlock()
+	return f.baseAdversaries
+}
+
+// setBaseTestChain sets the base test chain workers clone when they are created, along with its adversarial addresses.
+func (f *Fuzzer) setBaseTestChain(baseTestChain *chain.TestChain, baseAdversaries *adversaries) {
+	f.baseTestChainLock.Lock()
+	defer f.baseTestChainLock.Unlock()
+	f.baseTestChain = baseTestChain
+	f.baseAdversaries = baseAdversaries
+}
+
+// refreshForkLoop periodically sets up a new base test chain forked from the latest block of the fork's RPC, so that
+// workers created afterwards fuzz the refreshed state. Workers which are already running keep their chain until they
+// are reset. Failures are logged, and the previous base test chain is kept.
+func (f *Fuzzer) refreshForkLoop() {
+	ticker := time.NewTicker(time.Duration(f.config.Fuzzing.ForkTimeline.RefreshInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.ctx.Done():
+			return
+		case <-ticker.C:
+			// The previous base test chain is not closed, as workers being created may still be cloning it, and the
+			// corpus pruner and RPC server keep using the original one.
+			baseTestChain, baseAdversaries, err := f.createBaseTestChain(true)
+			if err != nil {
+				f.logger.Warn("Failed to refresh the forked chain state", err)
+				continue
+			}
+			f.setBaseTestChain(baseTestChain, baseAdversaries)
+			f.logger.Info("Refreshed the forked chain state at block ", colors.Bold, baseTestChain.HeadBlockNumber(), colors.Reset)
+		}
+	}
+}
+
+// createBaseTestChain creates the test chain fuzzing is based on and sets it up: it deploys the contracts (or resolves
+// the on-chain targets), the helper contract and attacker contracts, seeds balances on the forked chain, and calls the
+// setup cheat codes. If directed, a forked chain is forked from the latest block of the fork's RPC rather than the
+// configured block.
+// Returns the test chain and its adversarial addresses, or an error if it could not be set up.
+func (f *Fuzzer) createBaseTestChain(latestForkBlock bool) (*chain.TestChain, *adversaries, error) {
+	// Create our test chain
+	baseTestChain, err := f.createTestChain(latestForkBlock)
+	if err != nil {
+		f.logger.Error("Failed to create the test chain", err)
+		return nil, nil, err
+	}
+
+	// Set it up with our deployment/setup strategy defined by the fuzzer.
+	f.logger.Info("Setting up test chain")
+	trace, err := f.Hooks.ChainSetupFunc(f, baseTestChain)
+	if err != nil {
+		if trace != nil {
+			f.logger.Error("Failed to initialize the test chain", err, errors.New(trace.Log().ColorString()))
+		} else {
+			f.logger.Error("Failed to initialize the test chain", err)
+		}
+		return nil, nil, err
+	}
+	f.logger.Info("Finished setting up test chain")
+
+	// Set up helper contract
+	if f.config.Fuzzing.Testing.HelperContract.Enabled {
+		trace, err, helperContractAddress := setupFuzzHelperContract(f, baseTestChain)
+		if err != nil {
+			if trace != nil {
+				f.logger.Error("Failed to set up helper contract", err, errors.New(trace.Log().ColorString()))
+			} else {
+				f.logger.Error("Failed to set up helper contract", err)
+			}
+			return nil, nil, err
+		}
+		f.baseValueSet.AddAddress(helperContractAddress)
+		f.logger.Info("Setting up helper contract at address ", helperContractAddress.Hex())
+	}
+
+	// Deploy the attacker contracts, and resolve the adversarial addresses of the chain
+	adversarialContracts, trace, err := f.deployAdversarialContracts(baseTestChain)
+	if err != nil {
+		if trace != nil {
+			f.logger.Error("Failed to deploy attacker contracts", err, errors.New(trace.Log().ColorString()))
+		} else {
+			f.logger.Error("Failed to deploy attacker contracts", err)
+		}
+		return nil, nil, err
+	}
+	baseAdversaries := f.newAdversaries(adversarialContracts)
+
+	// Seed the balances of adversarial addresses on the forked chain
+	if err = f.seedForkBalances(baseTestChain, baseAdversaries.addresses); err != nil {
+		f.logger.Error("Failed to seed balances", err)
+		return nil, nil, err
+	}
+
+	// Establish the preconditions set by cheat codes
+	if err = f.callSetupCheatCodes(baseTestChain); err != nil {
+		f.logger.Error("Failed to call setup cheat codes", err)
+		return nil, nil, err
+	}
+
+	// Record the ether held by the adversarial addresses once the chain is set up, which ether leaking is detected
+	// against
+	baseAdversaries.recordOriginalEther(baseTestChain)
+	return baseTestChain, baseAdversaries, nil
+}
+
+// setUpCampaign creates the base test chain and sets it up with the fuzzer's deployment strategy and helper contract,
+// then resolves the state the fuzzer's tracers and call sequence generators depend on.
+// Returns the base test chain, or an error if one occurred.
+func (f *Fuzzer) setUpCampaign() (*chain.TestChain, error) {
+	baseTestChain, baseAdversaries, err := f.createBaseTestChain(false)
+	if err != nil {
+		return nil, err
+	}
+	f.setBaseTestChain(baseTestChain, baseAdversaries)
+
+	// Resolve the targets to direct fuzzing towards
+	if f.config.Fuzzing.TargetDirected.Enabled {
+		f.directedTargetPcs, err = f.resolveDirectedTargets()
+		if err != nil {
+			f.logger.Error("Failed to resolve the target-directed mode targets", err)
+			return nil, err
+		}
+		targetPcCount := 0
+		for _, pcs := range f.directedTargetPcs {
+			targetPcCount += len(pcs)
+		}
+		f.logger.Info("Directing fuzzing towards ", colors.Bold, targetPcCount, colors.Reset, " instructions in ", colors.Bold, len(f.directedTargetPcs), colors.Reset, " contracts")
+	}
+
+	// Warn about function configuration which matches no method, as it is likely misspelled
+	f.warnUnmatchedContractFunctions()
+
+	// Resolve the contracts to exclude from coverage and distance metrics
+	f.metricExclusions, err = fitnessmetrics.NewMetricExclusions(f.config.Fuzzing.MetricExclusions)
+	if err != nil {
+		f.logger.Error("Failed to resolve the metric exclusions", err)
+		return nil, err
+	}
+
+	// Track the branches of code deployed during the campaign which is unknown to the fuzzer, unless disabled
+	if dynamicBranchMapsLimit := f.config.Fuzzing.BranchCoverage.DynamicBranchMapsLimit; dynamicBranchMapsLimit > 0 {
+		excludedContracts := f.metricExclusions.ExcludedContracts(f.contractDefinitions)
+		f.dynamicBranchCoverageMaps = branchcoverage.NewDynamicBranchMapRegistry(excludedContracts, dynamicBranchMapsLimit)
+		f.dynamicBranchDistanceMaps = branchdistance.NewDynamicBranchMapRegistry(excludedContracts, dynamicBranchMapsLimit, f.config.Fuzzing.BranchDistance.IndirectJumpBranches)
+	}
+
+	// Resolve how the values written to storage are bucketed
+	f.storageWriteBucketer, err = storagewrite.NewValueBucketer(f.config.Fuzzing.StorageWrite)
+	if err != nil {
+		f.logger.Error("Failed to resolve the storage write buckets", err)
+		return nil, err
+	}
+
+	// Resolve the value-moving selectors to decode token transfers from
+	f.tokenSelectorRegistry, err = tokenflow.NewTokenSelectorRegistry(f.config.Fuzzing.Tokenflow.Selectors)
+	if err != nil {
+		f.logger.Error("Failed to resolve the tokenflow selectors", err)
+		return nil, err
+	}
+
+	// Create the dictionary of logged comparison operands, if argument values are sampled from it
+	if f.config.Fuzzing.CmpLog.Enabled && f.config.Fuzzing.CmpLog.DictionaryProbability > 0 {
+		f.operandDictionary = valuegeneration.NewOperandDictionary(f.config.Fuzzing.CmpLog.MaxDictionaryEntries)
+	}
+
+	// Record the methods reading each storage slot, if calls are ordered along dataflow
+	if f.config.Fuzzing.FitnessMetricConfig.DataflowEnabled && f.config.Fuzzing.Dataflow.OrderingProbability > 0 {
+		f.slotReaders = dataflow.NewSlotReaders()
+	}
+
+	// Record the productive transitions between on-chain targets, if calls are interleaved among them
+	if f.isOnChainTarget && f.config.Fuzzing.OnChainInteraction.SwitchProbability > 0 {
+		f.onChainInteractions = newOnChainInteractionModel()
+	}
+
+	// Resolve the addresses and tokens to track net balance deltas of
+	if f.config.Fuzzing.UseBalanceDeltaTracing() {
+		holders := f.config.Fuzzing.BalanceDelta.Addresses
+		if len(holders) == 0 {
+			holders = f.config.Fuzzing.SenderAddresses
+		}
+		f.balanceDeltaHolders, err = utils.HexStringsToAddresses(holders)
+		if err != nil {
+			f.logger.Error("Failed to resolve the balance delta addresses", err)
+			return nil, err
+		}
+		f.balanceDeltaTokens = make([]balancedelta.TrackedToken, 0, len(f.config.Fuzzing.BalanceDelta.Tokens))
+		for _, token := range f.config.Fuzzing.BalanceDelta.Tokens {
+			tokenAddress, err := utils.HexStringToAddress(token.Address)
+			if err != nil {
+				f.logger.Error("Failed to resolve the balance delta tokens", err)
+				return nil, err
+			}
+			f.balanceDeltaTokens = append(f.balanceDeltaTokens, balancedelta.TrackedToken{Address: tokenAddress, BalanceSlot: token.BalanceSlot})
+		}
+	}
+
+	return baseTestChain, nil
+}
+
+// writeFitnessMetricCoverageReport writes a report of the source line coverage, branch coverage and branch distances
+// recorded by the fitness metrics to the provided directory, as LCOV ("fitness-lcov") or HTML ("fitness-html").
+// Returns the path of the report, or an error if one occurred.
+func (f *Fuzzer) writeFitnessMetricCoverageReport(reportType string, reportDir string) (string, error) {
+	var codeCoverageMaps *codecoverage.CoverageMaps
+	if f.config.Fuzzing.UseCodeCoverageTracing() {
+		codeCoverageMaps = f.metrics.CodeCoverageMaps()
+	}
+	var branchCoverageMaps *branchcoverage.CoverageMaps
+	if f.config.Fuzzing.UseBranchCoverageTracing() {
+		branchCoverageMaps = f.metrics.BranchCoverageMaps()
+	}
+	var branchDistanceMaps *branchdistance.BranchDistanceMaps
+	if f.config.Fuzzing.FitnessMetricConfig.BranchDistanceEnabled {
+		branchDistanceMaps = f.corpus.BranchDistanceMaps()
+	}
+	if codeCoverageMaps == nil && branchCoverageMaps == nil && branchDistanceMaps == nil {
+		return "", errors.New("code coverage, branch coverage or branch distance fitness metrics must be enabled")
+	}
+
+	fitnessMetricCoverage, err := coverage.AnalyzeFitnessMetricCoverage(f.compilations, codeCoverageMaps, branchCoverageMaps, branchDistanceMaps, f.config.Fuzzing.CoverageExclusions)
+	if err != nil {
+		return "", err
+	}
+	if reportType == "fitness-html" {
+		return coverage.WriteFitnessMetricHTMLReport(fitnessMetricCoverage, f.config.Fuzzing.BranchDistance.Normalization, reportDir)
+	}
+	return coverage.WriteFitnessMetricLCOVReport(fitnessMetricCoverage, reportDir)
+}
+
 // Stop attempts to stop all running operations invoked by the Start method. Note that Stop is not guaranteed to fully
 // terminate the operations across all threads. For example, the optimization testing provider may request a thread to
 // shrink some call sequences before the thread is torn down. Stop will not prevent those shrink requests from
@@ -1231,16 +1714,60 @@ func (f *Fuzzer) printMetricsLoop() {
 			c, t := f.metrics.BranchCoverageMaps().TotalBranchCoverage([]common.Address{})
 			rate := float64(c) / float64(t)
 			logBuffer.Append(", branch coverage: ", colors.Bold, fmt.Sprintf("%v (%.2f)", c, rate), colors.Reset)
+			if f.config.Fuzzing.BranchCoverage.ContextDepth > 0 {
+				c := f.metrics.BranchCoverageMaps().TotalContextualBranchCoverage([]common.Address{})
+				logBuffer.Append(", contextual branches: ", colors.Bold, fmt.Sprintf("%v", c), colors.Reset)
+			}
+		}
+
+		if f.config.Fuzzing.UseEdgeCoverageTracing() {
+			c := f.metrics.EdgeCoverageMaps().TotalEdgeCoverage([]common.Address{})
+			logBuffer.Append(", edge coverage: ", colors.Bold, fmt.Sprintf("%v", c), colors.Reset)
+		}
+
+		if f.config.Fuzzing.UsePathCoverageTracing() {
+			// Report the paths recorded by the corpus if they guide fuzzing, as those are the ones subject to collisions.
+			paths := f.metrics.TransactionPaths()
+			if f.config.Fuzzing.FitnessMetricConfig.PathCoverageEnabled {
+				paths = f.corpus.TransactionPaths()
+			}
+			logBuffer.Append(", paths: ", colors.Bold, fmt.Sprintf("%v (%.2f%% collision rate)", paths.NovelPathCount(), paths.CollisionRate()*100), colors.Reset)
+		}
+
+		if f.config.Fuzzing.UseSelectorCoverageTracing() {
+			c := f.selectorSet().TotalSelectorCount()
+			logBuffer.Append(", selectors: ", colors.Bold, fmt.Sprintf("%v", c), colors.Reset)
+		}
+
+		if f.config.Fuzzing.UseBranchDistanceTracing() {
+			if failures := f.metrics.BranchDistanceBackPropagationFailures(); failures > 0 {
+				logBuffer.Append(", unknown branch distances: ", colors.Bold, fmt.Sprintf("%d", failures), colors.Reset)
+			}
+			if f.config.Fuzzing.FitnessMetricConfig.BranchDistanceEnabled {
+				if flatBranches := f.corpus.BranchDistanceMaps().FlatBranchCount(); flatBranches > 0 {
+					logBuffer.Append(", flat branches: ", colors.Bold, fmt.Sprintf("%d", flatBranches), colors.Reset)
+				}
+			}
 		}
 
 		if f.config.Fuzzing.UseDataflowTracing() {
-			c := f.metrics.DataflowSet().TotalDataflowCount()
+			c := f.metrics.DataflowSet().TotalDataflowCount(false)
 			logBuffer.Append(", dataflow: ", colors.Bold, fmt.Sprintf("%d", c), colors.Reset)
+			if f.config.Fuzzing.Dataflow.TrackOutflows {
+				logBuffer.Append(", outflows: ", colors.Bold, fmt.Sprintf("%d", f.metrics.DataflowSet().TotalOutflowCount()), colors.Reset)
+			}
+			if f.config.Fuzzing.Dataflow.TrackUninitializedReads {
+				logBuffer.Append(", uninitialized reads: ", colors.Bold, fmt.Sprintf("%d", f.metrics.DataflowSet().TotalUninitializedReadCount()), colors.Reset)
+			}
 		}
 
 		if f.config.Fuzzing.UseStorageWriteTracing() {
-			c := f.metrics.StorageWriteMaps().TotalStorageWriteCount()
+			c := f.metrics.StorageWriteMaps().TotalStorageWriteCount(false)
 			logBuffer.Append(", storage writes: ", colors.Bold, fmt.Sprintf("%d", c), colors.Reset)
+			logBuffer.Append(", storage deletes: ", colors.Bold, fmt.Sprintf("%d", f.metrics.StorageWriteMaps().TotalStorageDeleteCount()), colors.Reset)
+			if f.config.Fuzzing.StorageWrite.SlotDiversity {
+				logBuffer.Append(", slot diversity: ", colors.Bold, fmt.Sprintf("%.2f", f.metrics.StorageWriteMaps().DiversityScore()), colors.Reset)
+			}
 		}
 
 		if f.config.Fuzzing.UseTokenflowTracing() {
@@ -1248,6 +1775,17 @@ func (f *Fuzzer) printMetricsLoop() {
 			logBuffer.Append(", tokenflow: ", colors.Bold, fmt.Sprintf("%v", c), colors.Reset)
 		}
 
+		if f.config.Fuzzing.UseBalanceDeltaTracing() {
+			c := len(f.balanceDeltaSet().Gains())
+			logBuffer.Append(", profitable balances: ", colors.Bold, fmt.Sprintf("%d", c), colors.Reset)
+		}
+
+		if f.config.Fuzzing.AdaptivePowerSchedule.Enabled {
+			meanEnergy, maxEnergy := f.metrics.MutationTargetEnergies()
+			logBuffer.Append(", boosted seeds: ", colors.Bold, fmt.Sprintf("%d", f.corpus.BoostedMutationTargetCount()), colors.Reset)
+			logBuffer.Append(", seed energy: ", colors.Bold, fmt.Sprintf("%.2fx (max %dx)", meanEnergy, maxEnergy), colors.Reset)
+		}
+
 		if f.logger.Level() <= zerolog.DebugLevel {
 			logBuffer.Append(", shrinking: ", colors.Bold, fmt.Sprintf("%v", workersShrinking), colors.Reset)
 			logBuffer.Append(", mem: ", colors.Bold, fmt.Sprintf("%v/%v MB", memoryUsedMB, memoryTotalMB), colors.Reset)
@@ -1344,3 +1882,177 @@ func (f *Fuzzer) printExitingResults() {
 	// Print our final tally of test statuses.
 	f.logger.Info("Test summary: ", colors.GreenBold, testCountPassed, colors.Reset, " test(s) passed, ", colors.RedBold, testCountFailed, colors.Reset, " test(s) failed")
 }
+
+// branchDistanceDumpEnabled indicates whether the best branch distances should be dumped to disk.
+func (f *Fuzzer) branchDistanceDumpEnabled() bool {
+	return f.config.Fuzzing.BranchDistance.DumpEnabled && f.config.Fuzzing.FitnessMetricConfig.BranchDistanceEnabled
+}
+
+// branchDistanceDumpDirectory returns the directory branch distance dumps are written to.
+func (f *Fuzzer) branchDistanceDumpDirectory() string {
+	// Write to the default directory if we have no corpus directory set.
+	if f.config.Fuzzing.CorpusDirectory != "" {
+		return filepath.Join(f.config.Fuzzing.CorpusDirectory, "branch_distance")
+	}
+	return filepath.Join("crytic-export", "branch_distance")
+}
+
+// dumpBranchDistanceLoop periodically dumps the best branch distances of the corpus to disk until the fuzzer stops.
+func (f *Fuzzer) dumpBranchDistanceLoop() {
+	ticker := time.NewTicker(time.Duration(f.config.Fuzzing.BranchDistance.DumpInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.ctx.Done():
+			return
+		case <-ticker.C:
+			f.dumpBranchDistance()
+		}
+	}
+}
+
+// dumpBranchDistance dumps the best branch distances of the corpus to disk, if enabled.
+func (f *Fuzzer) dumpBranchDistance() {
+	if f.branchDistanceDumpWriter == nil {
+		return
+	}
+	path, err := f.branchDistanceDumpWriter.Write(f.corpus.BranchDistanceMaps())
+	if err != nil {
+		f.logger.Error("Failed to dump branch distances", err)
+		return
+	}
+	f.logger.Debug("Branch distances dumped to: ", path)
+}
+
+// dataflowSet returns the dataflow recorded, by the corpus if dataflow guides fuzzing, or by the fuzzer metrics
+// otherwise.
+func (f *Fuzzer) dataflowSet() *dataflow.DataflowSet {
+	if f.config.Fuzzing.FitnessMetricConfig.DataflowEnabled {
+		return f.corpus.DataflowSet()
+	}
+	return f.metrics.DataflowSet()
+}
+
+// exportDataflowGraph writes the dataflow recorded during the campaign as a graph in each of the configured formats,
+// so users can visualize which code communicates through which storage slots.
+func (f *Fuzzer) exportDataflowGraph() {
+	if len(f.config.Fuzzing.Dataflow.ExportFormats) == 0 || !f.config.Fuzzing.UseDataflowTracing() {
+		return
+	}
+
+	// Write to the default directory if we have no corpus directory set.
+	directory := "crytic-export"
+	if f.config.Fuzzing.CorpusDirectory != "" {
+		directory = f.config.Fuzzing.CorpusDirectory
+	}
+	// Name the state variables the slots hold, for contracts whose storage layout is known.
+	graph := f.dataflowSet().Export()
+	graph.NameVariables(f.StateVariableName)
+	paths, err := dataflow.WriteDataflowGraph(graph, directory, f.config.Fuzzing.Dataflow.ExportFormats)
+	if err != nil {
+		f.logger.Error("Failed to export the dataflow graph", err)
+		return
+	}
+	for _, path := range paths {
+		f.logger.Info("Dataflow graph exported to: ", path)
+	}
+}
+
+// balanceDeltaSet returns the largest net balance deltas over call sequences, as recorded by the corpus if balance
+// deltas guide fuzzing, or by the fuzzer metrics otherwise.
+func (f *Fuzzer) balanceDeltaSet() *balancedelta.BalanceDeltaSet {
+	if f.config.Fuzzing.FitnessMetricConfig.BalanceDeltaEnabled {
+		return f.corpus.BalanceDeltaMaps()
+	}
+	return f.metrics.BalanceDeltaMaps()
+}
+
+// printBalanceDeltaGains prints the largest net gain each tracked address achieved in each tracked asset over a single
+// call sequence, so users can tell whether an attacker could profit.
+func (f *Fuzzer) printBalanceDeltaGains() {
+	if !f.config.Fuzzing.UseBalanceDeltaTracing() {
+		return
+	}
+	gains := f.balanceDeltaSet().Gains()
+	if len(gains) == 0 {
+		return
+	}
+
+	f.logger.Info("Tracked addresses profited over a call sequence, largest net gains follow below ...")
+	for _, gain := range gains {
+		asset := "ether"
+		if gain.Token != (common.Address{}) {
+			asset = "token " + gain.Token.Hex()
+		}
+		f.logger.Info(colors.BULLET_POINT, " ", colors.Bold, gain.Holder.Hex(), colors.Reset, ": gained ", gain.Delta.String(), " of ", asset)
+	}
+}
+
+// selectorSet returns the function selectors executed successfully, as recorded by the corpus if selector coverage
+// guides fuzzing, or by the fuzzer metrics otherwise.
+func (f *Fuzzer) selectorSet() *selectorcoverage.SelectorSet {
+	if f.config.Fuzzing.FitnessMetricConfig.SelectorCoverageEnabled {
+		return f.corpus.SelectorSet()
+	}
+	return f.metrics.SelectorSet()
+}
+
+// printUnreachedSelectors prints the functions of each target contract which were never executed successfully, so
+// users can tell which entry points the fuzzer could not exercise.
+func (f *Fuzzer) printUnreachedSelectors() {
+	if !f.config.Fuzzing.UseSelectorCoverageTracing() {
+		return
+	}
+
+	selectorSet := f.selectorSet()
+	headerPrinted := false
+	for _, contract := range f.contractDefinitions {
+		isTarget := slices.ContainsFunc(f.config.Fuzzing.TargetContracts, func(target string) bool {
+			return strings.EqualFold(target, contract.Name())
+		})
+		if !isTarget {
+			continue
+		}
+
+		unreachedMethods := selectorSet.GetUnreachedMethods(contract)
+		if len(unreachedMethods) == 0 {
+			continue
+		}
+		if !headerPrinted {
+			f.logger.Info("Functions never executed successfully follow below ...")
+			headerPrinted = true
+		}
+		signatures := make([]string, 0, len(unreachedMethods))
+		for _, method := range unreachedMethods {
+			signatures = append(signatures, method.Sig)
+		}
+		f.logger.Info(colors.BULLET_POINT, " ", colors.Bold, contract.Name(), colors.Reset, ": ", strings.Join(signatures, ", "))
+	}
+}
+
+// printAlmostPassingRevertSites prints the revert sites whose guarding branch was closest to being satisfied, so users
+// can focus on requires which are nearly passing.
+func (f *Fuzzer) printAlmostPassingRevertSites() {
+	if !f.config.Fuzzing.FitnessMetricConfig.BranchDistanceEnabled {
+		return
+	}
+	revertSites := f.corpus.BranchDistanceMaps().AlmostPassingRevertSites(maxAlmostPassingRevertSitesToPrint)
+	if len(revertSites) == 0 {
+		return
+	}
+
+	contractNames := branchdistance.ContractNamesByLookupHash(f.contractDefinitions)
+	sourceLocations := branchdistance.BranchSourceLocationsByLookupHash(f.contractDefinitions)
+	f.logger.Info("Almost passing requires (closest guard distances) follow below ...")
+	for _, revertSite := range revertSites {
+		contractName, ok := contractNames[revertSite.CodeHash]
+		if !ok {
+			contractName = revertSite.CodeHash.Hex()
+		}
+		message := fmt.Sprintf(": revert at pc %d guarded by branch at pc %d, distance %s", revertSite.RevertPc, revertSite.BranchPc, revertSite.Distance.Dec())
+		if sourceLocation, ok := sourceLocations[revertSite.CodeHash][revertSite.BranchPc]; ok {
+			message += fmt.Sprintf(", at %s", sourceLocation.String())
+		}
+		f.logger.Info(colors.BULLET_POINT, " ", colors.Bold, contractName, colors.Reset, message)
+	}
+}
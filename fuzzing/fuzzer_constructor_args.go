@@ -0,0 +1,139 @@
+package fuzzing
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa/chain"
+	"github.com/crytic/medusa/fuzzing/calls"
+	fuzzerTypes "github.com/crytic/medusa/fuzzing/contracts"
+	"github.com/crytic/medusa/fuzzing/coverage"
+	"github.com/crytic/medusa/fuzzing/valuegeneration"
+	"github.com/crytic/medusa/logging/colors"
+	"github.com/crytic/medusa/utils/randomutils"
+)
+
+// constructorArgsSearchNewCandidateProbability describes the probability of a constructor argument search candidate
+// being generated anew, rather than mutated from the best candidate found so far.
+const constructorArgsSearchNewCandidateProbability = 0.2
+
+// searchConstructorArgs searches for constructor arguments which deploy the provided contract successfully while
+// covering the most of its init bytecode, and that of the contracts it creates. Candidate arguments are deployed on a
+// scratch clone of the provided test chain, which is left untouched. If seed arguments are provided for every
+// constructor input, they are the first candidate, otherwise it is generated.
+// Returns the best arguments found (or the first candidate if none deployed successfully), or an error if one occurred.
+func (f *Fuzzer) searchConstructorArgs(testChain *chain.TestChain, contract *fuzzerTypes.Contract, seedArgs []any, contractBalance *big.Int, deployedContracts map[string]common.Address) ([]any, error) {
+	contractName := contract.Name()
+	contract.CompiledContract().LinkBytecodes(contractName, deployedContracts)
+	inputs := contract.CompiledContract().Abi.Constructor.Inputs
+
+	// Create a scratch chain to deploy candidates on, recording the coverage of each deployment.
+	coverageTracer := coverage.NewCoverageTracer()
+	scratchChain, err := testChain.Clone(func(newChain *chain.TestChain) error {
+		newChain.AddTracer(coverageTracer.NativeTracer(), true, false)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("constructor argument search failed for contract \"%v\", error: %v", contractName, err)
+	}
+	defer scratchChain.Close()
+
+	// Create a value generator which can also produce the addresses of contracts deployed so far.
+	valueSet := f.baseValueSet.Clone()
+	for _, deployedAddress := range deployedContracts {
+		valueSet.AddAddress(deployedAddress)
+	}
+	randomProvider := randomutils.ForkRandomProvider(f.randomProvider)
+	callSequenceGenConfig, err := f.Hooks.NewCallSequenceGeneratorConfigFunc(f, valueSet, randomProvider)
+	if err != nil {
+		return nil, err
+	}
+	generateArgs := func() []any {
+		args := make([]any, len(inputs))
+		for i, input := range inputs {
+			args[i] = valuegeneration.GenerateAbiValue(callSequenceGenConfig.ValueGenerator, &input.Type)
+		}
+		return args
+	}
+
+	// Deploy candidates, keeping the one which deployed successfully with the most coverage.
+	var bestArgs []any
+	bestCoverage := uint64(0)
+	firstArgs := seedArgs
+	if len(firstArgs) != len(inputs) {
+		firstArgs = generateArgs()
+	}
+	for i := 0; i < f.config.Fuzzing.ConstructorArgsSearch.Iterations; i++ {
+		var args []any
+		if i == 0 {
+			args = firstArgs
+		} else if bestArgs == nil || randomProvider.Float32() < constructorArgsSearchNewCandidateProbability {
+			args = generateArgs()
+		} else {
+			args = make([]any, len(inputs))
+			for j, input := range inputs {
+				args[j], err = valuegeneration.MutateAbiValue(callSequenceGenConfig.ValueGenerator, callSequenceGenConfig.ValueMutator, &input.Type, bestArgs[j])
+				if err != nil {
+					return nil, fmt.Errorf("constructor argument search failed for contract \"%v\", error: %v", contractName, err)
+				}
+			}
+		}
+
+		succeeded, candidateCoverage, err := f.deployConstructorArgsCandidate(scratchChain, contract, args, contractBalance)
+		if err != nil {
+			return nil, fmt.Errorf("constructor argument search failed for contract \"%v\", error: %v", contractName, err)
+		}
+		if succeeded && (bestArgs == nil || candidateCoverage > bestCoverage) {
+			bestArgs = args
+			bestCoverage = candidateCoverage
+		}
+	}
+
+	if bestArgs == nil {
+		f.logger.Warn(fmt.Sprintf("Constructor argument search found no arguments deploying %s successfully", contractName))
+		return firstArgs, nil
+	}
+	f.logger.Info("Constructor argument search for ", colors.Bold, contractName, colors.Reset, " covered ", bestCoverage, " init code branches with arguments ", fmt.Sprintf("%v", bestArgs))
+	return bestArgs, nil
+}
+
+// deployConstructorArgsCandidate deploys the provided contract with the provided constructor arguments on the provided
+// scratch chain, which must have a coverage.CoverageTracer attached, then reverts the deployment.
+// Returns whether the deployment succeeded and the amount of coverage markers it hit, or an error if one occurred.
+func (f *Fuzzer) deployConstructorArgsCandidate(scratchChain *chain.TestChain, contract *fuzzerTypes.Contract, args []any, contractBalance *big.Int) (bool, uint64, error) {
+	msgData, err := contract.CompiledContract().GetDeploymentMessageData(args)
+	if err != nil {
+		return false, 0, err
+	}
+	msg := calls.NewCallMessage(f.deployer, nil, 0, contractBalance, scratchChain.BlockGasLimit, nil, nil, nil, msgData)
+	msg.FillFromTestChainProperties(scratchChain)
+
+	block, err := scratchChain.PendingBlockCreate()
+	if err != nil {
+		return false, 0, err
+	}
+	err = scratchChain.PendingBlockAddTx(msg.ToCoreMessage())
+	if err != nil {
+		return false, 0, err
+	}
+	err = scratchChain.PendingBlockCommit()
+	if err != nil {
+		return false, 0, err
+	}
+
+	messageResults := block.MessageResults[0]
+	succeeded := messageResults.Receipt.Status == types.ReceiptStatusSuccessful
+	candidateCoverage := uint64(0)
+	if coverageMaps := coverage.GetCoverageTracerResults(messageResults); coverageMaps != nil {
+		candidateCoverage = coverageMaps.BranchesHit()
+	}
+
+	// Revert the deployment so the next candidate is deployed on the same state.
+	err = scratchChain.RevertToBlockIndex(uint64(len(scratchChain.CommittedBlocks()) - 1))
+	if err != nil {
+		return false, 0, err
+	}
+	return succeeded, candidateCoverage, nil
+}
@@ -0,0 +1,181 @@
+package fuzzing
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/fuzzing/corpus"
+	"github.com/crytic/medusa/logging/colors"
+	"github.com/crytic/medusa/utils"
+)
+
+// CorpusCoverageReport describes the totals of the metrics achieved by replaying the call sequences stored in a
+// corpus, as computed by Fuzzer.AnalyzeCorpusCoverage.
+type CorpusCoverageReport struct {
+	// CallSequences describes the amount of corpus call sequences which were replayed.
+	CallSequences int `json:"callSequences"`
+
+	// SkippedCallSequences describes the amount of corpus call sequences which could not be replayed, as they target
+	// contracts which are no longer deployed.
+	SkippedCallSequences int `json:"skippedCallSequences"`
+
+	// CodeCoverage describes the instructions covered, or nil if code coverage was not recorded.
+	CodeCoverage *CorpusCoverageTotal `json:"codeCoverage,omitempty"`
+
+	// BranchCoverage describes the branches covered, or nil if branch coverage was not recorded.
+	BranchCoverage *CorpusCoverageTotal `json:"branchCoverage,omitempty"`
+
+	// Dataflows describes the amount of distinct dataflows recorded, or nil if dataflow was not recorded.
+	Dataflows *int `json:"dataflows,omitempty"`
+
+	// Tokenflows describes the amount of distinct tokenflows recorded, or nil if tokenflow was not recorded.
+	Tokenflows *int `json:"tokenflows,omitempty"`
+}
+
+// CorpusCoverageTotal describes the amount of items (e.g. instructions or branches) covered out of those which exist.
+type CorpusCoverageTotal struct {
+	// Covered describes the amount of items covered.
+	Covered int `json:"covered"`
+
+	// Total describes the amount of items which exist.
+	Total int `json:"total"`
+}
+
+// Percentage returns the percentage of items covered, or zero if there are none.
+func (t CorpusCoverageTotal) Percentage() float64 {
+	if t.Total == 0 {
+		return 0
+	}
+	return float64(t.Covered) * 100 / float64(t.Total)
+}
+
+// AnalyzeCorpusCoverage replays the call sequences stored in the corpus directory against a freshly deployed test
+// chain, without fuzzing, and totals the metrics recorded by the tracers enabled in the metric record configuration:
+// code coverage, branch coverage, dataflow and tokenflow. Call sequences are replayed in the order of their file
+// names, and those which can no longer be replayed are skipped. Test results are not replayed.
+// Returns the totals of the recorded metrics, or an error if one occurred or the analysis was interrupted.
+func (f *Fuzzer) AnalyzeCorpusCoverage() (*CorpusCoverageReport, error) {
+	// We need a corpus directory to read call sequences from.
+	if f.config.Fuzzing.CorpusDirectory == "" {
+		return nil, fmt.Errorf("a corpus directory must be set to analyze its coverage")
+	}
+
+	// Read the call sequences stored in the corpus, ordered by file name so the analysis is deterministic.
+	storedCorpus, err := corpus.NewCorpus(f.config.Fuzzing.CorpusDirectory, &f.config.Fuzzing)
+	if err != nil {
+		f.logger.Error("Failed to read the corpus", err)
+		return nil, err
+	}
+	sequenceFiles := storedCorpus.CallSequenceFiles()
+	fileNames := make([]string, 0, len(sequenceFiles))
+	for fileName := range sequenceFiles {
+		fileNames = append(fileNames, fileName)
+	}
+	sort.Strings(fileNames)
+
+	// Replay the call sequences, recording their metrics as fuzzing indicators, which are merged once every indicator
+	// recorded was sent by our worker.
+	f.logger.Info("Replaying ", colors.Bold, len(fileNames), colors.Reset, " corpus call sequences")
+	f.metrics = newFuzzerMetrics(1, f.revertReporter.RevertMetricsCh, &f.config.Fuzzing)
+	report, err := f.replayCorpusCoverage(sequenceFiles, fileNames)
+	f.metrics.stopIndicatorAggregator()
+	if err != nil {
+		return nil, err
+	}
+
+	// Total the recorded metrics.
+	if f.config.Fuzzing.MetricRecordConfig.CodeCoverageEnabled {
+		covered, total := f.metrics.CodeCoverageMaps().TotalCodeCoverage([]common.Address{})
+		report.CodeCoverage = &CorpusCoverageTotal{Covered: covered, Total: total}
+	}
+	if f.config.Fuzzing.MetricRecordConfig.BranchCoverageEnabled {
+		covered, total := f.metrics.BranchCoverageMaps().TotalBranchCoverage([]common.Address{})
+		report.BranchCoverage = &CorpusCoverageTotal{Covered: covered, Total: total}
+	}
+	if f.config.Fuzzing.MetricRecordConfig.DataflowEnabled {
+		dataflows := f.metrics.DataflowSet().TotalDataflowCount(false)
+		report.Dataflows = &dataflows
+	}
+	if f.config.Fuzzing.MetricRecordConfig.TokenflowEnabled {
+		tokenflows := f.metrics.TokenflowMaps().TotalTokenflowCount(true)
+		report.Tokenflows = &tokenflows
+	}
+	return report, nil
+}
+
+// replayCorpusCoverage replays the provided corpus call sequences, in the order of the provided file names, with a
+// replay worker recording their fuzzing indicators.
+// Returns a report counting the call sequences replayed and skipped, or an error if one occurred or the replay was
+// interrupted.
+func (f *Fuzzer) replayCorpusCoverage(sequenceFiles map[string]calls.CallSequence, fileNames []string) (*CorpusCoverageReport, error) {
+	worker, err := f.newReplayWorker()
+	if err != nil {
+		return nil, err
+	}
+	defer worker.chain.Close()
+	defer worker.workerMetrics().flushIndicators()
+
+	report := &CorpusCoverageReport{}
+	for _, fileName := range fileNames {
+		if utils.CheckContextDone(f.emergencyCtx) {
+			return nil, fmt.Errorf("the corpus coverage analysis was interrupted")
+		}
+		replayed, err := worker.replayCallSequenceIndicators(sequenceFiles[fileName])
+		if err != nil {
+			f.logger.Error("Failed to replay corpus call sequence "+fileName, err)
+			return nil, err
+		}
+		if replayed {
+			report.CallSequences++
+		} else {
+			report.SkippedCallSequences++
+		}
+	}
+	return report, nil
+}
+
+// replayCallSequenceIndicators replays the provided de-serialized corpus call sequence against the worker's chain,
+// recording the fuzzing indicators of every call, then reverts the chain.
+// Returns a boolean indicating whether the call sequence was replayed, which is false if it could not be bound to the
+// contracts deployed on the chain, or an error if one occurred.
+func (fw *FuzzerWorker) replayCallSequenceIndicators(callSequence calls.CallSequence) (bool, error) {
+	// Prepare every element for runtime execution.
+	for _, element := range callSequence {
+		if err := fw.bindCallSequenceElement(element); err != nil {
+			fw.fuzzer.logger.Debug("Corpus call sequence could not be replayed: ", err)
+			return false, nil
+		}
+	}
+
+	// Our "fetch next call" method simply returns the next element of our call sequence.
+	fetchElementFunc := func(currentIndex int) (*calls.CallSequenceElement, error) {
+		if currentIndex >= len(callSequence) {
+			return nil, nil
+		}
+		return callSequence[currentIndex], nil
+	}
+
+	// Our "post-execution check" method records the fuzzing indicators of the last call.
+	executionCheckFunc := func(currentlyExecutedSequence calls.CallSequence) (bool, error) {
+		latestCallSequenceElement := currentlyExecutedSequence[len(currentlyExecutedSequence)-1]
+		err := fw.workerMetrics().updateIndicators(latestCallSequenceElement)
+		if err != nil {
+			return true, fmt.Errorf("error updating fuzzing indicators from call sequence element: %v", err)
+		}
+		fw.releaseTracerResults(latestCallSequenceElement)
+		return utils.CheckContextDone(fw.fuzzer.emergencyCtx), nil
+	}
+
+	// Execute our call sequence, then revert the changes it made to our chain.
+	_, err := calls.ExecuteCallSequenceIteratively(fw.chain, fetchElementFunc, executionCheckFunc)
+	if err != nil {
+		return false, err
+	}
+	err = fw.chain.RevertToBlockIndex(fw.testingBaseBlockIndex)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
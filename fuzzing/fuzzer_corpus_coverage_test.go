@@ -0,0 +1,65 @@
+package fuzzing
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/chain"
+	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/crytic/medusa/fuzzing/executiontracer"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAnalyzeCorpusCoverage ensures corpus call sequences are replayed when they can be bound to the deployed
+// contracts and counted as skipped otherwise, that only the metrics recorded are totaled, that the indicator aggregator
+// is stopped once the analysis completes, and that a corpus directory is required.
+func TestAnalyzeCorpusCoverage(t *testing.T) {
+	projectConfig, err := config.GetDefaultProjectConfig("")
+	assert.NoError(t, err)
+	projectConfig.Fuzzing.CorpusDirectory = t.TempDir()
+	projectConfig.Fuzzing.MetricRecordConfig.CodeCoverageEnabled = true
+	projectConfig.Fuzzing.MetricRecordConfig.BranchCoverageEnabled = false
+	sender := common.HexToAddress(projectConfig.Fuzzing.SenderAddresses[1])
+	undeployed := common.HexToAddress("0x12345")
+
+	// Store a call sequence creating a contract, which can be replayed, and two calling a contract which is not
+	// deployed, which cannot.
+	newCallSequence := func(to *common.Address) calls.CallSequence {
+		call := calls.NewCallMessage(sender, to, 0, big.NewInt(0), projectConfig.Fuzzing.TransactionGasLimit, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil)
+		return calls.CallSequence{calls.NewCallSequenceElement(nil, call, 0, 0)}
+	}
+	sequenceDirectory := filepath.Join(projectConfig.Fuzzing.CorpusDirectory, "call_sequences")
+	assert.NoError(t, os.MkdirAll(sequenceDirectory, 0755))
+	for fileName, callSequence := range map[string]calls.CallSequence{
+		"1.json": newCallSequence(nil),
+		"2.json": newCallSequence(&undeployed),
+		"3.json": newCallSequence(&undeployed),
+	} {
+		b, err := json.Marshal(callSequence)
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(filepath.Join(sequenceDirectory, fileName), b, 0644))
+	}
+
+	fuzzer, err := NewFuzzer(*projectConfig)
+	assert.NoError(t, err)
+	fuzzer.Hooks.ChainSetupFunc = func(fuzzer *Fuzzer, testChain *chain.TestChain) (*executiontracer.ExecutionTrace, error) {
+		return nil, nil
+	}
+	report, err := fuzzer.AnalyzeCorpusCoverage()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, report.CallSequences)
+	assert.EqualValues(t, 2, report.SkippedCallSequences)
+	assert.NotNil(t, report.CodeCoverage)
+	assert.Nil(t, report.BranchCoverage)
+	assertIndicatorAggregatorStopped(t, fuzzer.metrics)
+
+	// Without a corpus directory, there is nothing to analyze.
+	fuzzer.config.Fuzzing.CorpusDirectory = ""
+	_, err = fuzzer.AnalyzeCorpusCoverage()
+	assert.ErrorContains(t, err, "corpus directory")
+}
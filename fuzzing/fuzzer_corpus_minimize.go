@@ -0,0 +1,129 @@
+package fuzzing
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/fuzzing/corpus"
+	"github.com/crytic/medusa/logging/colors"
+	"github.com/crytic/medusa/utils"
+)
+
+// MinimizeCorpus replays the call sequences stored in the corpus directory against all enabled fitness metric tracers
+// and rewrites the directory in place, keeping only a minimal subset of call sequences which preserves the fitness
+// metrics they achieve (e.g. code and branch coverage, dataflow and tokenflow sets). Call sequences are replayed from
+// shortest to longest, and each is kept only if it improves a fitness metric over the call sequences kept before it.
+// Call sequences which can no longer be replayed are removed, while test results are left untouched.
+// Returns the amount of call sequences kept and removed, or an error if one occurred.
+func (f *Fuzzer) MinimizeCorpus() (int, int, error) {
+	// We need a corpus directory to read call sequences from and rewrite.
+	if f.config.Fuzzing.CorpusDirectory == "" {
+		return 0, 0, fmt.Errorf("a corpus directory must be set to minimize the corpus")
+	}
+
+	// Read the call sequences stored in the corpus.
+	storedCorpus, err := corpus.NewCorpus(f.config.Fuzzing.CorpusDirectory, &f.config.Fuzzing)
+	if err != nil {
+		f.logger.Error("Failed to read the corpus", err)
+		return 0, 0, err
+	}
+
+	// Create a worker to replay call sequences with. The fitness metrics achieved by the call sequences we keep are
+	// recorded in a separate in-memory corpus.
+	f.metrics = newFuzzerMetrics(1, f.revertReporter.RevertMetricsCh, &f.config.Fuzzing)
+	defer f.metrics.stopIndicatorAggregator()
+	worker, err := f.newReplayWorker()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer worker.chain.Close()
+
+	// Order the call sequences from shortest to longest, so shorter call sequences are kept in favour of longer ones
+	// achieving the same fitness metrics. File names break ties to keep the result deterministic.
+	sequenceFiles := storedCorpus.CallSequenceFiles()
+	fileNames := make([]string, 0, len(sequenceFiles))
+	for fileName := range sequenceFiles {
+		fileNames = append(fileNames, fileName)
+	}
+	sort.Slice(fileNames, func(i, j int) bool {
+		if len(sequenceFiles[fileNames[i]]) != len(sequenceFiles[fileNames[j]]) {
+			return len(sequenceFiles[fileNames[i]]) < len(sequenceFiles[fileNames[j]])
+		}
+		return fileNames[i] < fileNames[j]
+	})
+
+	// Replay each call sequence, recording those which do not improve any fitness metric for removal. If we are
+	// interrupted, call sequences which were not replayed yet are kept.
+	f.logger.Info("Minimizing ", colors.Bold, len(fileNames), colors.Reset, " corpus call sequences")
+	removedFileNames := make([]string, 0)
+	for _, fileName := range fileNames {
+		if utils.CheckContextDone(f.emergencyCtx) {
+			break
+		}
+		improved, err := worker.replayCorpusCallSequence(sequenceFiles[fileName])
+		if err != nil {
+			f.logger.Error("Failed to replay corpus call sequence "+fileName, err)
+			return 0, 0, err
+		}
+		if !improved {
+			removedFileNames = append(removedFileNames, fileName)
+		}
+	}
+
+	// Rewrite the corpus directory.
+	err = storedCorpus.RemoveCallSequenceFiles(removedFileNames)
+	if err != nil {
+		f.logger.Error("Failed to remove corpus call sequences", err)
+		return 0, 0, err
+	}
+	return len(fileNames) - len(removedFileNames), len(removedFileNames), nil
+}
+
+// replayCorpusCallSequence replays the provided de-serialized corpus call sequence against the worker's chain,
+// checking every call for fitness metric improvements over the Fuzzer's corpus, then reverts the chain.
+// Returns a boolean indicating whether any call improved a fitness metric, which is false if the call sequence could
+// not be bound to the contracts deployed on the chain, or an error if one occurred.
+func (fw *FuzzerWorker) replayCorpusCallSequence(callSequence calls.CallSequence) (bool, error) {
+	// Prepare every element for runtime execution. A call sequence targeting contracts which no longer exist is
+	// not worth keeping.
+	for _, element := range callSequence {
+		if err := fw.bindCallSequenceElement(element); err != nil {
+			fw.fuzzer.logger.Debug("Corpus call sequence could not be replayed: ", err)
+			return false, nil
+		}
+	}
+
+	// Our "fetch next call" method simply returns the next element of our call sequence.
+	fetchElementFunc := func(currentIndex int) (*calls.CallSequenceElement, error) {
+		if currentIndex >= len(callSequence) {
+			return nil, nil
+		}
+		return callSequence[currentIndex], nil
+	}
+
+	// Our "post-execution check" method records any fitness metric improvements made by the last call.
+	improved := false
+	executionCheckFunc := func(currentlyExecutedSequence calls.CallSequence) (bool, error) {
+		updated, err := fw.fuzzer.corpus.CheckSequenceMetricAndUpdate(currentlyExecutedSequence, big.NewInt(1), false)
+		if err != nil {
+			return true, err
+		}
+		improved = improved || updated
+		return utils.CheckContextDone(fw.fuzzer.emergencyCtx), nil
+	}
+
+	// Execute our call sequence, then revert the changes it made to our chain.
+	_, err := calls.ExecuteCallSequenceIteratively(fw.chain, fetchElementFunc, executionCheckFunc)
+	if err != nil {
+		return false, err
+	}
+	err = fw.chain.RevertToBlockIndex(fw.testingBaseBlockIndex)
+	if err != nil {
+		return false, err
+	}
+
+	// If we were interrupted, the call sequence may not have been replayed in full, so we keep it.
+	return improved || utils.CheckContextDone(fw.fuzzer.emergencyCtx), nil
+}
@@ -0,0 +1,112 @@
+package fuzzing
+
+import (
+	"sort"
+	"time"
+
+	"github.com/crytic/medusa/fuzzing/dashboard"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/branchdistance"
+	"github.com/crytic/medusa/logging/colors"
+)
+
+// maxDashboardItems describes the maximum amount of branches and bugs listed by the dashboard.
+const maxDashboardItems = 10
+
+// startDashboard serves the live dashboard of the campaign, if enabled by the project configuration.
+// Returns the started server, or nil if it is disabled or could not be started.
+func (f *Fuzzer) startDashboard() *dashboard.Server {
+	dashboardConfig := f.config.Fuzzing.DashboardConfig
+	if !dashboardConfig.Enabled {
+		return nil
+	}
+
+	server := dashboard.NewServer(f.dashboardSnapshot, dashboardConfig.RefreshInterval)
+	err := server.Start(dashboardConfig.Address)
+	if err != nil {
+		f.logger.Error("Failed to start the dashboard", err)
+		return nil
+	}
+
+	f.logger.Info("Serving the dashboard at ", colors.Bold, "http://", server.Address(), colors.Reset)
+	return server
+}
+
+// dashboardSnapshot returns the current state of the campaign displayed by the dashboard: the coverage of each
+// contract, the best distances of the branches guarding reverts which were closest to being flipped, and the latest
+// bugs detected. It is called concurrently with the fuzzer workers.
+func (f *Fuzzer) dashboardSnapshot() *dashboard.Snapshot {
+	snapshot := &dashboard.Snapshot{
+		CallsTested:     f.metrics.CallsTested().String(),
+		SequencesTested: f.metrics.SequencesTested().String(),
+		Contracts:       make([]dashboard.ContractCoverage, 0),
+		HardestBranches: make([]dashboard.BranchDistance, 0),
+		LatestBugs:      make([]dashboard.Bug, 0),
+	}
+	if !f.startTime.IsZero() {
+		snapshot.ElapsedSeconds = uint64(time.Since(f.startTime).Seconds())
+	}
+
+	// Sum the coverage of the init and runtime bytecode of each contract. All coverage maps share the same lookup
+	// hashes.
+	contractNames := branchdistance.ContractNamesByLookupHash(f.contractDefinitions)
+	contractCoverages := make(map[string]*dashboard.ContractCoverage)
+	contractCoverage := func(name string) *dashboard.ContractCoverage {
+		if _, ok := contractCoverages[name]; !ok {
+			contractCoverages[name] = &dashboard.ContractCoverage{Name: name}
+		}
+		return contractCoverages[name]
+	}
+	if f.config.Fuzzing.UseCodeCoverageTracing() {
+		covered, total := f.metrics.CodeCoverageMaps().CodeCoverageByLookupHash()
+		for codeHash, name := range contractNames {
+			if total[codeHash] > 0 {
+				contractCoverage(name).CoveredInstructions += covered[codeHash]
+				contractCoverage(name).TotalInstructions += total[codeHash]
+			}
+		}
+	}
+	if f.config.Fuzzing.UseBranchCoverageTracing() {
+		covered, total := f.metrics.BranchCoverageMaps().BranchCoverageByLookupHash()
+		for codeHash, name := range contractNames {
+			if total[codeHash] > 0 {
+				contractCoverage(name).CoveredBranches += covered[codeHash]
+				contractCoverage(name).TotalBranches += total[codeHash]
+			}
+		}
+	}
+	for _, coverage := range contractCoverages {
+		snapshot.Contracts = append(snapshot.Contracts, *coverage)
+	}
+	sort.Slice(snapshot.Contracts, func(i, j int) bool {
+		return snapshot.Contracts[i].Name < snapshot.Contracts[j].Name
+	})
+
+	if f.config.Fuzzing.FitnessMetricConfig.BranchDistanceEnabled {
+		sourceLocations := branchdistance.BranchSourceLocationsByLookupHash(f.contractDefinitions)
+		for _, revertSite := range f.corpus.BranchDistanceMaps().AlmostPassingRevertSites(maxDashboardItems) {
+			branch := dashboard.BranchDistance{
+				ContractName: revertSite.CodeHash.Hex(),
+				BranchPc:     revertSite.BranchPc,
+				RevertPc:     revertSite.RevertPc,
+				Distance:     revertSite.Distance.Dec(),
+			}
+			if contractName, ok := contractNames[revertSite.CodeHash]; ok {
+				branch.ContractName = contractName
+			}
+			if sourceLocation, ok := sourceLocations[revertSite.CodeHash][revertSite.BranchPc]; ok {
+				branch.Source = sourceLocation.String()
+			}
+			snapshot.HardestBranches = append(snapshot.HardestBranches, branch)
+		}
+	}
+	if f.config.Fuzzing.UseBugDetector() {
+		for _, bug := range f.corpus.BugMap().LatestBugs(maxDashboardItems) {
+			dashboardBug := dashboard.Bug{ID: bug.ID, ElapsedSeconds: bug.Elapsed.Seconds()}
+			if bug.Value != nil {
+				dashboardBug.Value = bug.Value.Hex()
+			}
+			snapshot.LatestBugs = append(snapshot.LatestBugs, dashboardBug)
+		}
+	}
+	return snapshot
+}
@@ -0,0 +1,36 @@
+package fuzzing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/crytic/medusa/logging"
+	"github.com/crytic/medusa/logging/colors"
+)
+
+// printDiagnosticsCounters prints the anomalies tracers recovered from during the campaign (e.g. failed branch distance
+// back-propagations or taint stack desyncs), by subsystem, so that silently degraded fitness metrics can be noticed.
+// It must only be called once all workers exited.
+func (f *Fuzzer) printDiagnosticsCounters() {
+	counters := logging.DiagnosticsCounters()
+	if len(counters) == 0 {
+		return
+	}
+
+	subsystems := make([]string, 0, len(counters))
+	for subsystem := range counters {
+		subsystems = append(subsystems, subsystem)
+	}
+	sort.Strings(subsystems)
+
+	f.logger.Info("Anomalies recovered from by tracers follow below (see the logging diagnostics configuration for details) ...")
+	for _, subsystem := range subsystems {
+		anomalies := make([]string, 0, len(counters[subsystem]))
+		for anomaly, count := range counters[subsystem] {
+			anomalies = append(anomalies, fmt.Sprintf("%s: %d", anomaly, count))
+		}
+		sort.Strings(anomalies)
+		f.logger.Info(colors.BULLET_POINT, " ", colors.Bold, subsystem, colors.Reset, ": ", strings.Join(anomalies, ", "))
+	}
+}
@@ -0,0 +1,175 @@
+package fuzzing
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/fuzzing/coordinator"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/branchdistance"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/codecoverage"
+	"github.com/crytic/medusa/logging/colors"
+	"github.com/google/uuid"
+)
+
+// distributedSync tracks the state of the fuzzer's synchronization with a coordinator.
+type distributedSync struct {
+	// client describes the client used to reach the coordinator.
+	client *coordinator.Client
+
+	// nodeID uniquely identifies the fuzzer to the coordinator.
+	nodeID string
+
+	// cursor describes the amount of updates already received from the coordinator.
+	cursor uint64
+
+	// publishedCallSequences describes the file names of the corpus call sequences already published.
+	publishedCallSequences map[string]struct{}
+
+	// publishedCodeCoverageMaps describes the code coverage known to the coordinator, either because it was published
+	// or received. Only coverage beyond it is published.
+	publishedCodeCoverageMaps *codecoverage.CoverageMaps
+
+	// publishedBranchDistanceMaps describes the branch distances known to the coordinator, either because they were
+	// published or received. Only distances closer than them are published.
+	publishedBranchDistanceMaps *branchdistance.BranchDistanceMaps
+
+	// lock provides thread-synchronization between the periodic synchronizations and the final one.
+	lock sync.Mutex
+}
+
+// startCoordinator hosts the coordinator other fuzzer instances synchronize with, if enabled by the project
+// configuration.
+// Returns the started coordinator, or nil if it is disabled or could not be started.
+func (f *Fuzzer) startCoordinator() *coordinator.Server {
+	distributedConfig := f.config.Fuzzing.Distributed
+	if !distributedConfig.Enabled || !distributedConfig.ServeCoordinator {
+		return nil
+	}
+
+	server := coordinator.NewServer()
+	err := server.Start(distributedConfig.Address)
+	if err != nil {
+		f.logger.Error("Failed to start the coordinator", err)
+		return nil
+	}
+
+	f.logger.Info("Serving the distributed fuzzing coordinator at ", colors.Bold, "http://", server.Address(), colors.Reset)
+	return server
+}
+
+// newDistributedSync creates the state used to synchronize with the coordinator, if distributed fuzzing is enabled by
+// the project configuration.
+// Returns the synchronization state, or nil if distributed fuzzing is disabled.
+func (f *Fuzzer) newDistributedSync() *distributedSync {
+	if !f.config.Fuzzing.Distributed.Enabled {
+		return nil
+	}
+	return &distributedSync{
+		client:                      coordinator.NewClient(f.config.Fuzzing.Distributed.Address),
+		nodeID:                      uuid.New().String(),
+		publishedCallSequences:      make(map[string]struct{}),
+		publishedCodeCoverageMaps:   codecoverage.NewCoverageMaps(),
+		publishedBranchDistanceMaps: branchdistance.NewBranchDistanceMaps(),
+	}
+}
+
+// synchronizeLoop periodically synchronizes with the coordinator until the fuzzer's context is cancelled.
+func (f *Fuzzer) synchronizeLoop(syncState *distributedSync) {
+	ticker := time.NewTicker(time.Duration(f.config.Fuzzing.Distributed.SyncInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.ctx.Done():
+			return
+		case <-ticker.C:
+			f.synchronize(syncState)
+		}
+	}
+}
+
+// synchronize publishes the corpus call sequences and fitness metrics discovered since the last synchronization to the
+// coordinator, then receives those discovered by other fuzzer instances. Received call sequences are queued to be
+// executed by the workers, who add them to the corpus if they achieve a fitness metric it did not when replayed against
+// their chain. Failures are logged rather
+// than returned, as the fuzzer can keep fuzzing on its own until the coordinator can be reached again.
+func (f *Fuzzer) synchronize(syncState *distributedSync) {
+	if syncState == nil {
+		return
+	}
+	syncState.lock.Lock()
+	defer syncState.lock.Unlock()
+	fitnessMetricConfig := f.config.Fuzzing.FitnessMetricConfig
+
+	// Collect the corpus call sequences which were not published yet.
+	request := &coordinator.SyncRequest{
+		NodeID: syncState.nodeID,
+		Cursor: syncState.cursor,
+		Update: coordinator.Update{NodeID: syncState.nodeID},
+	}
+	newFileNames := make([]string, 0)
+	for fileName, callSequence := range f.corpus.CallSequenceFiles() {
+		if _, published := syncState.publishedCallSequences[fileName]; published {
+			continue
+		}
+		b, err := json.Marshal(callSequence)
+		if err != nil {
+			f.logger.Debug("Failed to serialize corpus call sequence ", fileName, " for the coordinator: ", err)
+			continue
+		}
+		request.Update.CallSequences = append(request.Update.CallSequences, b)
+		newFileNames = append(newFileNames, fileName)
+	}
+
+	// Collect the fitness metrics the coordinator does not know of yet.
+	if fitnessMetricConfig.CodeCoverageEnabled {
+		request.Update.CodeCoverageMaps = f.corpus.CodeCoverageMaps().Delta(syncState.publishedCodeCoverageMaps)
+	}
+	if fitnessMetricConfig.BranchDistanceEnabled {
+		request.Update.BranchDistanceMaps = f.corpus.BranchDistanceMaps().Delta(syncState.publishedBranchDistanceMaps)
+	}
+
+	response, err := syncState.client.Sync(request)
+	if err != nil {
+		f.logger.Warn("Failed to synchronize with the coordinator", err)
+		return
+	}
+
+	// Record what the coordinator now knows of. The deltas are copies, so they can be merged as-is.
+	for _, fileName := range newFileNames {
+		syncState.publishedCallSequences[fileName] = struct{}{}
+	}
+	_, _ = syncState.publishedCodeCoverageMaps.Update(request.Update.CodeCoverageMaps)
+	_, _ = syncState.publishedBranchDistanceMaps.Update(request.Update.BranchDistanceMaps)
+	syncState.cursor = response.Cursor
+
+	// Merge the discoveries of the other fuzzer instances.
+	remoteSequences := make([]calls.CallSequence, 0)
+	for _, update := range response.Updates {
+		for _, b := range update.CallSequences {
+			var callSequence calls.CallSequence
+			if err := json.Unmarshal(b, &callSequence); err != nil {
+				f.logger.Debug("Failed to deserialize a call sequence received from the coordinator: ", err)
+				continue
+			}
+			if len(callSequence) > 0 {
+				remoteSequences = append(remoteSequences, callSequence)
+			}
+		}
+
+		// The fitness metrics received are only recorded as known to the coordinator, so they are not published back.
+		// They are not merged into the corpus, as the received call sequences would then never achieve a fitness
+		// metric the corpus did not when replayed, and would be discarded. The corpus obtains them by replaying the
+		// call sequences instead, only if they reproduce them against our chain.
+		if update.CodeCoverageMaps != nil && fitnessMetricConfig.CodeCoverageEnabled {
+			_, _ = syncState.publishedCodeCoverageMaps.Update(update.CodeCoverageMaps)
+		}
+		if update.BranchDistanceMaps != nil && fitnessMetricConfig.BranchDistanceEnabled {
+			_, _ = syncState.publishedBranchDistanceMaps.Update(update.BranchDistanceMaps)
+		}
+	}
+	f.corpus.AddRemoteCallSequences(remoteSequences)
+
+	f.logger.Debug("Synchronized with the coordinator: published ", len(request.Update.CallSequences), " call sequence(s), received ", len(remoteSequences), " call sequence(s) from ", len(response.Updates), " update(s)")
+}
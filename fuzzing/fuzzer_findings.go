@@ -0,0 +1,154 @@
+package fuzzing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/crytic/medusa/logging/colors"
+)
+
+// ResultsReportFinding describes a group of bugs sharing a root cause, such as the same flaw detected in several
+// deployments of a contract (e.g. behind proxies, or redeployed by factories) or at several sinks mapping to the same
+// source location.
+type ResultsReportFinding struct {
+	// Type describes the type of the bugs of the finding (e.g. OVERFLOW or REENTRANCY).
+	Type string `json:"type"`
+
+	// RootCause describes the key the bugs of the finding share: their bug type, the source location they were
+	// detected at (or their code location if it could not be resolved) and the storage slot they involve, if any.
+	RootCause string `json:"rootCause"`
+
+	// PrimaryBugID describes the ID of the bug reported for the finding, the one with the shortest reproducing call
+	// sequence.
+	PrimaryBugID string `json:"primaryBugId"`
+
+	// Occurrences describes the amount of bugs of the finding.
+	Occurrences int `json:"occurrences"`
+
+	// BugIDs describes the IDs of the bugs of the finding, sorted.
+	BugIDs []string `json:"bugIds"`
+
+	// Severity describes the severity of the finding, as estimated from Score.
+	Severity FindingSeverity `json:"severity"`
+
+	// Score describes the severity score of the finding, estimated from the type of its bugs and the evidence of
+	// exploitability observed for its primary bug. Higher scores are more severe.
+	Score int `json:"score"`
+
+	// Annotations describes the evidence of exploitability the score was estimated from, in a human-readable form.
+	Annotations []string `json:"annotations"`
+
+	// primary describes the bug reported for the finding.
+	primary ResultsReportBug
+}
+
+// bugRootCause returns the key of the root cause of the provided bug, which bugs reported for the same flaw share. It
+// consists of the bug type, the source location the bug was detected at, and the state variable or storage slot it
+// involves, or the property test which failed. If the source location could not be resolved, the contract and program counter are used instead, or the
+// code address if the contract could not be resolved either.
+func bugRootCause(bug ResultsReportBug) string {
+	location := ""
+	if bug.Source != nil {
+		location = fmt.Sprintf("%s:%d:%d", bug.Source.File, bug.Source.Line, bug.Source.Column)
+	} else {
+		if bug.ContractName != "" {
+			location = bug.ContractName
+		} else if bug.Address != nil {
+			location = bug.Address.Hex()
+		}
+		if bug.Pc != nil {
+			location = fmt.Sprintf("%s@%d", location, *bug.Pc)
+		}
+	}
+
+	slot := bug.StateVariable
+	if slot == "" && bug.Slot != nil {
+		slot = bug.Slot.Hex()
+	} else if slot == "" {
+		slot = bug.Property
+	}
+	return strings.Join([]string{bug.Type, location, slot}, "|")
+}
+
+// isPreferredPrimaryBug indicates whether bug a should be reported for a finding rather than bug b: bugs with a shrunk
+// call sequence are preferred, then those with the shortest call sequence, then those with the lowest ID.
+func isPreferredPrimaryBug(a ResultsReportBug, b ResultsReportBug) bool {
+	aReproducible := a.Status == TestCaseStatusFailed && len(a.CallSequence) > 0
+	bReproducible := b.Status == TestCaseStatusFailed && len(b.CallSequence) > 0
+	if aReproducible != bReproducible {
+		return aReproducible
+	}
+	if len(a.CallSequence) != len(b.CallSequence) {
+		return len(a.CallSequence) < len(b.CallSequence)
+	}
+	return a.ID < b.ID
+}
+
+// groupBugFindings groups the provided bugs by root cause into findings, each reporting a primary bug along with the
+// amount of bugs sharing its root cause, and scores their severity.
+// Returns the findings, sorted by decreasing severity score and then by the ID of their primary bug.
+func groupBugFindings(bugs []ResultsReportBug) []ResultsReportFinding {
+	findingIndexes := make(map[string]int)
+	findings := make([]ResultsReportFinding, 0)
+	for _, bug := range bugs {
+		rootCause := bugRootCause(bug)
+		index, ok := findingIndexes[rootCause]
+		if !ok {
+			findingIndexes[rootCause] = len(findings)
+			findings = append(findings, ResultsReportFinding{
+				Type:      bug.Type,
+				RootCause: rootCause,
+				primary:   bug,
+			})
+			index = len(findings) - 1
+		}
+
+		finding := &findings[index]
+		finding.Occurrences++
+		finding.BugIDs = append(finding.BugIDs, bug.ID)
+		if isPreferredPrimaryBug(bug, finding.primary) {
+			finding.primary = bug
+		}
+	}
+
+	for i := range findings {
+		findings[i].PrimaryBugID = findings[i].primary.ID
+		sort.Strings(findings[i].BugIDs)
+		scoreFinding(&findings[i])
+	}
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].Score != findings[j].Score {
+			return findings[i].Score > findings[j].Score
+		}
+		return findings[i].PrimaryBugID < findings[j].PrimaryBugID
+	})
+	return findings
+}
+
+// printBugFindings prints the bugs found grouped by root cause, so that flaws detected in several deployments or at
+// several sinks are reported once, from the most to the least severe. It must only be called once all workers exited.
+func (f *Fuzzer) printBugFindings() {
+	if !f.config.Fuzzing.UseBugDetector() {
+		return
+	}
+	report := f.resultsReport()
+	if len(report.Findings) == 0 {
+		return
+	}
+
+	f.logger.Info(fmt.Sprintf("Bug findings grouped by root cause (%d finding(s) from %d bug(s)) follow below ...", len(report.Findings), len(report.Bugs)))
+	for _, finding := range report.Findings {
+		location := ""
+		if finding.primary.ContractName != "" {
+			location = " in " + finding.primary.ContractName
+		}
+		if finding.primary.Source != nil {
+			location += fmt.Sprintf(" at %s:%d", finding.primary.Source.File, finding.primary.Source.Line)
+		}
+		f.logger.Info(colors.BULLET_POINT, " ", colors.Bold, "[", strings.ToUpper(string(finding.Severity)), "] ", finding.Type, colors.Reset, location, ": ", finding.PrimaryBugID, fmt.Sprintf(" (%d occurrence(s))", finding.Occurrences))
+		for _, annotation := range finding.Annotations {
+			f.logger.Info("\t", colors.BULLET_POINT, " ", annotation)
+		}
+	}
+}
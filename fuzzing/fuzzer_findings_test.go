@@ -0,0 +1,99 @@
+package fuzzing
+
+import (
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/fuzzing/bugdetector"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGroupBugFindings ensures bugs detected at the same source location are grouped into one finding, reporting the
+// bug with the shortest reproducing call sequence, while bugs of other types, locations or slots are not.
+func TestGroupBugFindings(t *testing.T) {
+	newBug := func(bugId string, line int, stateVariable string, status TestCaseStatus, callSequenceLength int) ResultsReportBug {
+		bug := ResultsReportBug{
+			ID:            bugId,
+			BugLocation:   bugdetector.ParseBugID(bugId),
+			ContractName:  "Vault",
+			StateVariable: stateVariable,
+			Status:        status,
+			CallSequence:  make([]ResultsReportCall, callSequenceLength),
+		}
+		if line > 0 {
+			bug.Source = &ResultsReportSourceLocation{File: "Vault.sol", Line: line, Column: 1}
+		}
+		return bug
+	}
+	bugs := []ResultsReportBug{
+		// The same overflow in a proxied deployment and a redeployment, and at a second sink of the same expression.
+		newBug("OVERFLOW-0x0000000000000000000000000000000000001000-42-ADD", 12, "", TestCaseStatusFailed, 3),
+		newBug("OVERFLOW-0x0000000000000000000000000000000000002000-42-ADD", 12, "", TestCaseStatusFailed, 2),
+		newBug("OVERFLOW-0x0000000000000000000000000000000000001000-47-MUL", 12, "", TestCaseStatusRunning, 0),
+		// A reentrancy at the same location, and an overflow at another one.
+		newBug("REENTRANCY-0x0000000000000000000000000000000000001000-42-CALL", 12, "", TestCaseStatusFailed, 1),
+		newBug("OVERFLOW-0x0000000000000000000000000000000000001000-90-SUB", 20, "", TestCaseStatusFailed, 1),
+		// Reads of distinct state variables at the same location.
+		newBug("UNINITIALIZEDSTORAGEREAD-0x0000000000000000000000000000000000001000-7-0x1", 30, "owner", TestCaseStatusFailed, 1),
+		newBug("UNINITIALIZEDSTORAGEREAD-0x0000000000000000000000000000000000001000-7-0x2", 30, "admin", TestCaseStatusFailed, 1),
+		// Bugs whose source location could not be resolved are grouped by contract and program counter.
+		newBug("OVERFLOW-0x0000000000000000000000000000000000001000-100-ADD", 0, "", TestCaseStatusFailed, 1),
+		newBug("OVERFLOW-0x0000000000000000000000000000000000002000-100-ADD", 0, "", TestCaseStatusFailed, 1),
+	}
+
+	findings := groupBugFindings(bugs)
+	assert.Len(t, findings, 6)
+	occurrences := make(map[string]int)
+	for _, finding := range findings {
+		occurrences[finding.PrimaryBugID] = finding.Occurrences
+	}
+	assert.EqualValues(t, map[string]int{
+		"OVERFLOW-0x0000000000000000000000000000000000002000-42-ADD":                3,
+		"OVERFLOW-0x0000000000000000000000000000000000001000-90-SUB":                1,
+		"OVERFLOW-0x0000000000000000000000000000000000001000-100-ADD":               2,
+		"REENTRANCY-0x0000000000000000000000000000000000001000-42-CALL":             1,
+		"UNINITIALIZEDSTORAGEREAD-0x0000000000000000000000000000000000001000-7-0x1": 1,
+		"UNINITIALIZEDSTORAGEREAD-0x0000000000000000000000000000000000001000-7-0x2": 1,
+	}, occurrences)
+
+	// The findings should be sorted by the ID of their primary bug, and list the IDs of all of their bugs.
+	assert.EqualValues(t, "OVERFLOW-0x0000000000000000000000000000000000001000-100-ADD", findings[0].PrimaryBugID)
+	assert.EqualValues(t, []string{
+		"OVERFLOW-0x0000000000000000000000000000000000001000-42-ADD",
+		"OVERFLOW-0x0000000000000000000000000000000000001000-47-MUL",
+		"OVERFLOW-0x0000000000000000000000000000000000002000-42-ADD",
+	}, findings[2].BugIDs)
+}
+
+// TestGroupPropertyViolationFindings ensures failures of property tests registered as bug detector oracles are
+// grouped by contract and property, regardless of the address the contract was deployed at.
+func TestGroupPropertyViolationFindings(t *testing.T) {
+	newBug := func(bugId string) ResultsReportBug {
+		return ResultsReportBug{
+			ID:           bugId,
+			BugLocation:  bugdetector.ParseBugID(bugId),
+			ContractName: "Vault",
+			Status:       TestCaseStatusFailed,
+			CallSequence: make([]ResultsReportCall, 1),
+		}
+	}
+	bugs := []ResultsReportBug{
+		newBug(bugdetector.PropertyViolationBugID(common.HexToAddress("0x1000"), "property_solvent")),
+		newBug(bugdetector.PropertyViolationBugID(common.HexToAddress("0x2000"), "property_solvent")),
+		newBug(bugdetector.PropertyViolationBugID(common.HexToAddress("0x1000"), "echidna_owner")),
+	}
+	assert.EqualValues(t, "PROPERTYVIOLATION", bugs[0].Type)
+	assert.EqualValues(t, "property_solvent", bugs[0].Property)
+	assert.Nil(t, bugs[0].Pc)
+
+	findings := groupBugFindings(bugs)
+	assert.Len(t, findings, 2)
+	occurrences := make(map[string]int)
+	for _, finding := range findings {
+		occurrences[finding.PrimaryBugID] = finding.Occurrences
+	}
+	assert.EqualValues(t, map[string]int{
+		"PROPERTYVIOLATION-0x0000000000000000000000000000000000001000-echidna_owner":    1,
+		"PROPERTYVIOLATION-0x0000000000000000000000000000000000001000-property_solvent": 2,
+	}, occurrences)
+}
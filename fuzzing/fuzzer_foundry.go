@@ -0,0 +1,226 @@
+package fuzzing
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/utils"
+	"golang.org/x/exp/slices"
+)
+
+// foundryDeployment describes a contract deployed on the base test chain, which Foundry reproducers deploy to the
+// same address in their set up.
+type foundryDeployment struct {
+	// artifact describes the Foundry artifact identifier of the contract (e.g. Vault.sol:Vault).
+	artifact string
+
+	// address describes the address the contract was deployed to.
+	address common.Address
+
+	// args describes the ABI-encoded constructor arguments the contract was deployed with.
+	args []byte
+
+	// value describes the amount of ether sent with the deployment, in wei.
+	value *big.Int
+}
+
+// foundryAccount describes an account funded in the set up of Foundry reproducers.
+type foundryAccount struct {
+	// address describes the address of the account.
+	address common.Address
+
+	// balance describes the balance of the account, in wei.
+	balance *big.Int
+}
+
+// foundryReproducerSetUp describes the chain state Foundry reproducers set up before replaying a call sequence.
+type foundryReproducerSetUp struct {
+	// deployer describes the account which deploys the contracts.
+	deployer common.Address
+
+	// accounts describes the accounts funded before the contracts are deployed.
+	accounts []foundryAccount
+
+	// deployments describes the contracts deployed, in the order they were deployed on the base test chain.
+	deployments []foundryDeployment
+
+	// blockNumber and blockTimestamp describe the block the call sequence is replayed from.
+	blockNumber    uint64
+	blockTimestamp uint64
+}
+
+// foundryIdentifierRegex matches the characters which are not allowed in Solidity identifiers.
+var foundryIdentifierRegex = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// foundryReproducerSetUp resolves the chain state Foundry reproducers set up from the base test chain: the deployer,
+// senders and attacker accounts are funded, and each contract deployed by the deployer or an attacker account is
+// deployed to the same address, with the same constructor arguments and value. It must only be called once all workers exited.
+func (f *Fuzzer) foundryReproducerSetUp() foundryReproducerSetUp {
+	setUp := foundryReproducerSetUp{
+		deployer: f.deployer,
+		accounts: []foundryAccount{{address: f.deployer, balance: new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))}},
+	}
+	for index, sender := range f.senders {
+		if index < len(f.config.Fuzzing.SenderAddressBalances) && sender != f.deployer {
+			setUp.accounts = append(setUp.accounts, foundryAccount{address: sender, balance: f.config.Fuzzing.SenderAddressBalances[index]})
+		}
+	}
+	for _, attacker := range f.attackers {
+		if !slices.ContainsFunc(setUp.accounts, func(account foundryAccount) bool { return account.address == attacker }) {
+			setUp.accounts = append(setUp.accounts, foundryAccount{address: attacker, balance: f.config.Fuzzing.Adversaries.AccountBalance})
+		}
+	}
+
+	baseTestChain := f.currentBaseTestChain()
+	if baseTestChain == nil {
+		return setUp
+	}
+	head := baseTestChain.Head()
+	setUp.blockNumber = head.Header.Number.Uint64()
+	setUp.blockTimestamp = head.Header.Time
+
+	// Match each contract creation of the deployer and attacker accounts to the contract definition whose init
+	// bytecode it deploys.
+	for _, block := range baseTestChain.CommittedBlocks() {
+		for i, message := range block.Messages {
+			if message.To != nil || (message.From != f.deployer && !slices.Contains(f.attackers, message.From)) || i >= len(block.MessageResults) {
+				continue
+			}
+			receipt := block.MessageResults[i].Receipt
+			if receipt == nil || receipt.ContractAddress == (common.Address{}) {
+				continue
+			}
+			for _, contract := range f.contractDefinitions {
+				initBytecode := contract.CompiledContract().InitBytecode
+				if len(initBytecode) == 0 || !bytes.HasPrefix(message.Data, initBytecode) {
+					continue
+				}
+				value := big.NewInt(0)
+				if message.Value != nil {
+					value = new(big.Int).Set(message.Value)
+				}
+				setUp.deployments = append(setUp.deployments, foundryDeployment{
+					artifact: fmt.Sprintf("%s:%s", filepath.Base(contract.SourcePath()), contract.Name()),
+					address:  receipt.ContractAddress,
+					args:     message.Data[len(initBytecode):],
+					value:    value,
+				})
+				break
+			}
+		}
+	}
+	return setUp
+}
+
+// newFoundryReproducer returns the source of a Foundry test contract with the provided name, which sets up the
+// provided chain state and replays the call sequence of the provided bug, with its senders, values, calldata and
+// block number and timestamp delays.
+func newFoundryReproducer(contractName string, bug ResultsReportBug, setUp foundryReproducerSetUp) string {
+	var source strings.Builder
+	source.WriteString("// SPDX-License-Identifier: UNLICENSED\n")
+	source.WriteString("pragma solidity >=0.6.2 <0.9.0;\n\n")
+	source.WriteString("import \"forge-std/Test.sol\";\n\n")
+
+	// Describe the bug reproduced.
+	source.WriteString(fmt.Sprintf("// Reproduces %s", bug.ID))
+	if bug.ContractName != "" {
+		source.WriteString(fmt.Sprintf(", detected in %s", bug.ContractName))
+	}
+	if bug.Source != nil {
+		source.WriteString(fmt.Sprintf(" at %s:%d:%d", bug.Source.File, bug.Source.Line, bug.Source.Column))
+	}
+	source.WriteString(".\n")
+	if bug.StateVariable != "" {
+		source.WriteString(fmt.Sprintf("// State variable: %s.\n", bug.StateVariable))
+	}
+	source.WriteString(fmt.Sprintf("contract %s is Test {\n", contractName))
+
+	// Fund the accounts and deploy the contracts to the addresses they were deployed to during the campaign.
+	source.WriteString("    function setUp() public {\n")
+	source.WriteString(fmt.Sprintf("        vm.roll(%d);\n", setUp.blockNumber))
+	source.WriteString(fmt.Sprintf("        vm.warp(%d);\n", setUp.blockTimestamp))
+	for _, account := range setUp.accounts {
+		source.WriteString(fmt.Sprintf("        vm.deal(%s, %s);\n", account.address.Hex(), account.balance.String()))
+	}
+	if len(setUp.deployments) > 0 {
+		source.WriteString(fmt.Sprintf("        vm.startPrank(%s);\n", setUp.deployer.Hex()))
+		for _, deployment := range setUp.deployments {
+			source.WriteString(fmt.Sprintf("        deployCodeTo(\"%s\", hex\"%x\", %s, %s);\n",
+				deployment.artifact, deployment.args, deployment.value.String(), deployment.address.Hex()))
+		}
+		source.WriteString("        vm.stopPrank();\n")
+	}
+	source.WriteString("    }\n\n")
+
+	// Replay the call sequence, logging the calls which reverted.
+	source.WriteString("    function test_reproduce() public {\n")
+	source.WriteString("        bool success;\n")
+	for i, call := range bug.CallSequence {
+		source.WriteString(fmt.Sprintf("\n        // %d) %s\n", i+1, call.String()))
+		if call.BlockNumberDelay > 0 {
+			source.WriteString(fmt.Sprintf("        vm.roll(block.number + %d);\n", call.BlockNumberDelay))
+		}
+		if call.BlockTimestampDelay > 0 {
+			source.WriteString(fmt.Sprintf("        vm.warp(block.timestamp + %d);\n", call.BlockTimestampDelay))
+		}
+		source.WriteString(fmt.Sprintf("        vm.prank(%s);\n", call.From.Hex()))
+		if call.To == nil {
+			// Contract creations are replayed with CREATE, which only fails if the created contract reverted.
+			source.WriteString("        {\n")
+			source.WriteString(fmt.Sprintf("            bytes memory initCode = hex\"%x\";\n", []byte(call.Data)))
+			source.WriteString("            address created;\n")
+			source.WriteString(fmt.Sprintf("            assembly { created := create(%s, add(initCode, 0x20), mload(initCode)) }\n", call.Value))
+			source.WriteString("            success = created != address(0);\n")
+			source.WriteString("        }\n")
+		} else {
+			source.WriteString(fmt.Sprintf("        (success, ) = address(%s).call{value: %s}(hex\"%x\");\n", call.To.Hex(), call.Value, []byte(call.Data)))
+		}
+		source.WriteString(fmt.Sprintf("        if (!success) console.log(\"call %d reverted\");\n", i+1))
+	}
+	source.WriteString("    }\n")
+	source.WriteString("}\n")
+	return source.String()
+}
+
+// writeFoundryReproducers writes a Foundry test reproducing the primary bug of each finding whose call sequence was
+// shrunk to a foundry directory in the corpus directory (or crytic-export if unset), if enabled, so bugs can be
+// reproduced without the fuzzer. It must only be called once all workers exited.
+func (f *Fuzzer) writeFoundryReproducers() {
+	if !f.config.Fuzzing.FoundryReproducersEnabled {
+		return
+	}
+
+	// Name each reproducer after the type of its bug and its index among bugs of that type.
+	directory := filepath.Join(f.coverageTimeSeriesDirectory(), "foundry")
+	setUp := f.foundryReproducerSetUp()
+	bugTypeCounts := make(map[string]int)
+	written := 0
+	for _, finding := range f.resultsReport().Findings {
+		bug := finding.primary
+		if bug.Status != TestCaseStatusFailed || len(bug.CallSequence) == 0 {
+			continue
+		}
+		bugTypeCounts[bug.Type]++
+		contractName := fmt.Sprintf("MedusaReproducer_%s_%d", foundryIdentifierRegex.ReplaceAllString(bug.Type, "_"), bugTypeCounts[bug.Type])
+		path := filepath.Join(directory, contractName+".t.sol")
+
+		err := utils.MakeDirectory(directory)
+		if err == nil {
+			err = os.WriteFile(path, []byte(newFoundryReproducer(contractName, bug, setUp)), 0644)
+		}
+		if err != nil {
+			f.logger.Error("Failed to write the Foundry reproducer of "+bug.ID, err)
+			continue
+		}
+		written++
+	}
+	if written > 0 {
+		f.logger.Info(fmt.Sprintf("%d Foundry reproducer(s) saved to: %s", written, directory))
+	}
+}
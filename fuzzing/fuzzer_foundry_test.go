@@ -0,0 +1,48 @@
+package fuzzing
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFoundryReproducer ensures Foundry reproducers deploy the contracts to the addresses they were deployed to, and
+// replay the call sequence of their bug with its senders, values, calldata and block delays.
+func TestFoundryReproducer(t *testing.T) {
+	deployer := common.HexToAddress("0x30000")
+	sender := common.HexToAddress("0x10000")
+	vault := common.HexToAddress("0xA647ff3c36cFab592509E13860ab8c4F28781a66")
+	setUp := foundryReproducerSetUp{
+		deployer:       deployer,
+		accounts:       []foundryAccount{{address: sender, balance: big.NewInt(100)}},
+		deployments:    []foundryDeployment{{artifact: "Vault.sol:Vault", address: vault, args: []byte{0x01}, value: big.NewInt(5)}},
+		blockNumber:    1,
+		blockTimestamp: 2,
+	}
+	bug := ResultsReportBug{
+		ID:           "REENTRANCY-0xA647ff3c36cFab592509E13860ab8c4F28781a66-10-CALL",
+		ContractName: "Vault",
+		CallSequence: []ResultsReportCall{
+			{From: sender, To: &vault, Value: "7", Data: []byte{0xde, 0xad}, BlockNumberDelay: 3, BlockTimestampDelay: 4},
+			{From: sender, Value: "0", Data: []byte{0x60, 0x00}},
+		},
+	}
+
+	source := newFoundryReproducer("MedusaReproducer_REENTRANCY_1", bug, setUp)
+	assert.Contains(t, source, "contract MedusaReproducer_REENTRANCY_1 is Test {")
+	assert.Contains(t, source, "// Reproduces "+bug.ID+", detected in Vault.")
+
+	// The set up should fund the accounts and deploy the contracts as the deployer.
+	assert.Contains(t, source, "vm.roll(1);\n        vm.warp(2);")
+	assert.Contains(t, source, "vm.deal("+sender.Hex()+", 100);")
+	assert.Contains(t, source, "vm.startPrank("+deployer.Hex()+");")
+	assert.Contains(t, source, "deployCodeTo(\"Vault.sol:Vault\", hex\"01\", 5, "+vault.Hex()+");")
+
+	// The calls should be replayed in order, after their block delays.
+	assert.Contains(t, source, "vm.roll(block.number + 3);\n        vm.warp(block.timestamp + 4);\n        vm.prank("+sender.Hex()+");")
+	assert.Contains(t, source, "(success, ) = address("+vault.Hex()+").call{value: 7}(hex\"dead\");")
+	assert.Contains(t, source, "bytes memory initCode = hex\"6000\";")
+	assert.Contains(t, source, "if (!success) console.log(\"call 2 reverted\");")
+}
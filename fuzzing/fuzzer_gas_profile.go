@@ -0,0 +1,198 @@
+package fuzzing
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/logging/colors"
+)
+
+// gasReportMaxPrintedFunctions describes the maximum amount of functions printed in the gas report at the end of a
+// fuzzing campaign. Every function is written to the JSON gas report.
+const gasReportMaxPrintedFunctions = 20
+
+// gasProfileKey identifies a function of a contract whose gas usage is profiled.
+type gasProfileKey struct {
+	contractName string
+	selector     [4]byte
+}
+
+// GasProfile describes the gas used by the calls made to a function of a contract over a fuzzing campaign.
+type GasProfile struct {
+	// ContractName describes the name of the contract the function belongs to.
+	ContractName string `json:"contractName"`
+
+	// Function describes the signature of the function, or its selector if the call was not made through its ABI.
+	Function string `json:"function"`
+
+	// Calls describes the amount of calls made to the function.
+	Calls uint64 `json:"calls"`
+
+	// MinGas describes the least gas used by a call to the function.
+	MinGas uint64 `json:"minGas"`
+
+	// MaxGas describes the most gas used by a call to the function.
+	MaxGas uint64 `json:"maxGas"`
+
+	// TotalGas describes the gas used by all calls to the function.
+	TotalGas uint64 `json:"totalGas"`
+
+	// MaxGasSequence describes the call sequence whose last call used the most gas calling the function.
+	MaxGasSequence calls.CallSequence `json:"maxGasSequence"`
+}
+
+// MeanGas returns the mean gas used by the calls made to the function.
+func (p *GasProfile) MeanGas() float64 {
+	if p.Calls == 0 {
+		return 0
+	}
+	return float64(p.TotalGas) / float64(p.Calls)
+}
+
+// merge merges the calls recorded by the provided GasProfile of the same function into this one.
+func (p *GasProfile) merge(other *GasProfile) {
+	if other.MaxGas > p.MaxGas || p.Calls == 0 {
+		p.MaxGas = other.MaxGas
+		p.MaxGasSequence = other.MaxGasSequence
+	}
+	if other.MinGas < p.MinGas || p.Calls == 0 {
+		p.MinGas = other.MinGas
+	}
+	p.Calls += other.Calls
+	p.TotalGas += other.TotalGas
+}
+
+// recordGasProfile records the gas used by the last call of the provided call sequence into the gas profile of the
+// function it called, keeping a copy of the call sequence if the call used more gas than any earlier call to it.
+// Returns an error if one occurred.
+func (m *fuzzerWorkerMetrics) recordGasProfile(callSequence calls.CallSequence) error {
+	lastCall := callSequence[len(callSequence)-1]
+	if lastCall.Contract == nil || lastCall.ChainReference == nil || len(lastCall.Call.Data) < 4 {
+		return nil
+	}
+	gasUsed := lastCall.ChainReference.MessageResults().Receipt.GasUsed
+
+	// Obtain the profile of the function called, creating it upon its first call.
+	key := gasProfileKey{contractName: lastCall.Contract.Name(), selector: [4]byte(lastCall.Call.Data[:4])}
+	profile, ok := m.gasProfiles[key]
+	if !ok {
+		function := "0x" + hex.EncodeToString(key.selector[:])
+		if lastCall.Call.DataAbiValues != nil {
+			function = lastCall.Call.DataAbiValues.Method.Sig
+		}
+		profile = &GasProfile{
+			ContractName: key.contractName,
+			Function:     function,
+			MinGas:       gasUsed,
+		}
+		m.gasProfiles[key] = profile
+	}
+
+	// Update the profile, keeping a copy of the call sequence which used the most gas, detached from the chain.
+	if gasUsed > profile.MaxGas || profile.Calls == 0 {
+		maxGasSequence, err := callSequence.Clone()
+		if err != nil {
+			return err
+		}
+		for _, element := range maxGasSequence {
+			element.ChainReference = nil
+			element.ExecutionTrace = nil
+		}
+		profile.MaxGas = gasUsed
+		profile.MaxGasSequence = maxGasSequence
+	}
+	profile.MinGas = min(profile.MinGas, gasUsed)
+	profile.Calls++
+	profile.TotalGas += gasUsed
+	return nil
+}
+
+// GasProfiles returns the gas profiles of the functions called over the fuzzing campaign, merged across workers and
+// sorted by the most gas used by a call, descending. This is empty if gas profiling is disabled. It must only be
+// called once all workers exited.
+func (m *FuzzerMetrics) GasProfiles() []*GasProfile {
+	mergedProfiles := make(map[gasProfileKey]*GasProfile)
+	for _, workerMetrics := range m.workerMetrics {
+		for key, profile := range workerMetrics.gasProfiles {
+			mergedProfile, ok := mergedProfiles[key]
+			if !ok {
+				mergedProfile = &GasProfile{ContractName: profile.ContractName, Function: profile.Function}
+				mergedProfiles[key] = mergedProfile
+			}
+			mergedProfile.merge(profile)
+		}
+	}
+
+	profiles := make([]*GasProfile, 0, len(mergedProfiles))
+	for _, profile := range mergedProfiles {
+		profiles = append(profiles, profile)
+	}
+	slices.SortFunc(profiles, func(a, b *GasProfile) int {
+		if a.MaxGas != b.MaxGas {
+			if a.MaxGas > b.MaxGas {
+				return -1
+			}
+			return 1
+		}
+		if a.ContractName != b.ContractName {
+			if a.ContractName < b.ContractName {
+				return -1
+			}
+			return 1
+		}
+		if a.Function < b.Function {
+			return -1
+		} else if a.Function > b.Function {
+			return 1
+		}
+		return 0
+	})
+	return profiles
+}
+
+// reportGasProfiles prints the functions which used the most gas over the fuzzing campaign, and writes the gas
+// profiles of all functions, along with the call sequences which maximized their gas usage, to a JSON gas report.
+func (f *Fuzzer) reportGasProfiles() {
+	if !f.config.Fuzzing.GasProfilingEnabled {
+		return
+	}
+	profiles := f.metrics.GasProfiles()
+	if len(profiles) == 0 {
+		return
+	}
+
+	// Print the functions which used the most gas.
+	f.logger.Info("Gas report, functions using the most gas follow below ...")
+	for i, profile := range profiles {
+		if i >= gasReportMaxPrintedFunctions {
+			f.logger.Info(colors.BULLET_POINT, " ... ", len(profiles)-i, " more function(s) in the JSON gas report")
+			break
+		}
+		f.logger.Info(colors.BULLET_POINT, " ", colors.Bold, profile.ContractName, ".", profile.Function, colors.Reset,
+			fmt.Sprintf(": calls=%d, min=%d, mean=%.0f, max=%d", profile.Calls, profile.MinGas, profile.MeanGas(), profile.MaxGas))
+	}
+
+	// Write the JSON gas report next to the other reports.
+	reportDir := filepath.Join("crytic-export", "coverage")
+	if f.config.Fuzzing.CorpusDirectory != "" {
+		reportDir = filepath.Join(f.config.Fuzzing.CorpusDirectory, "coverage")
+	}
+	path := filepath.Join(reportDir, "gas_report.json")
+	b, err := json.MarshalIndent(profiles, "", "\t")
+	if err == nil {
+		err = os.MkdirAll(reportDir, 0755)
+	}
+	if err == nil {
+		err = os.WriteFile(path, b, 0644)
+	}
+	if err != nil {
+		f.logger.Error("Failed to write the gas report", err)
+		return
+	}
+	f.logger.Info("JSON gas report saved to: ", path)
+}
@@ -2,6 +2,7 @@ package fuzzing
 
 import (
 	"github.com/crytic/medusa/fuzzing/config"
+	"math/big"
 	"math/rand"
 
 	"github.com/crytic/medusa/fuzzing/executiontracer"
@@ -31,8 +32,22 @@ type FuzzerHooks struct {
 	// CallSequenceTestFuncs describes a list of functions to be called upon by a FuzzerWorker after every call
 	// in a call sequence. These must not commit to state
 	CallSequenceTestFuncs []CallSequenceTestFunc
+
+	// CallSequenceFitnessFuncs describes a list of functions to be called upon by a FuzzerWorker after every call
+	// in a call sequence, before the call sequence is checked for admission into the corpus. These must not commit to
+	// state.
+	CallSequenceFitnessFuncs []CallSequenceFitnessFunc
+
+	// PowerScheduleFunc describes the function used by a FuzzerWorker to assign mutation energy to a call sequence
+	// which is checked for admission into the corpus. If nil, the base mutation weight is used.
+	PowerScheduleFunc PowerScheduleFunc
 }
 
+// PowerScheduleFunc defines a method called by a FuzzerWorker to determine the mutation weight of a call sequence
+// prior to it being checked for admission into the corpus, given the base weight the worker would otherwise assign.
+// Returns the mutation weight to use, which must be positive.
+type PowerScheduleFunc func(worker *FuzzerWorker, callSequence calls.CallSequence, mutationChooserWeight *big.Int) *big.Int
+
 // NewShrinkingValueMutatorFunc describes the function used to set up a value mutator used to shrink call
 // values in the fuzzer's call sequence shrinking process.
 // Returns a new value mutator, or an error if one occurred.
@@ -56,6 +71,13 @@ type TestChainSetupFunc func(fuzzer *Fuzzer, testChain *chain.TestChain) (*execu
 // current call sequence from being further generated and tested.
 type CallSequenceTestFunc func(worker *FuzzerWorker, callSequence calls.CallSequence) ([]ShrinkCallSequenceRequest, error)
 
+// CallSequenceFitnessFunc defines a method called after a fuzzing.FuzzerWorker sends another call in a
+// types.CallSequence during a fuzzing campaign, before the call sequence is checked for admission into the corpus. It
+// may attribute additional fitness metric results to the last call, stored in its message results, so that the
+// corpus is guided by them.
+// Returns an error if one occurred.
+type CallSequenceFitnessFunc func(worker *FuzzerWorker, callSequence calls.CallSequence) error
+
 // ShrinkCallSequenceRequest is a structure signifying a request for a shrunken call sequence from the FuzzerWorker.
 type ShrinkCallSequenceRequest struct {
 	// TestName represents the name of the test case that is having a call sequence that is being shrunk.
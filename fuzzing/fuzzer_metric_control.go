@@ -0,0 +1,120 @@
+package fuzzing
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/crytic/medusa/fuzzing/config"
+)
+
+// metricControl describes the metrics of a running campaign which were paused through Fuzzer.ReloadMetricConfig.
+type metricControl struct {
+	// paused describes the names of the metric toggles (see metricToggles) which were paused, despite being enabled
+	// by the project configuration the fuzzer was created with.
+	paused map[string]bool
+
+	// generation is incremented whenever a metric is paused or resumed, so workers which attached their tracers
+	// beforehand know to restart.
+	generation atomic.Uint64
+
+	// lock provides thread-synchronization when accessing paused.
+	lock sync.RWMutex
+}
+
+// metricToggles returns the flags of the provided fuzzing configuration which enable the metrics that may be paused
+// and resumed while fuzzing, by their path in the project configuration.
+func metricToggles(fuzzingConfig *config.FuzzingConfig) map[string]*bool {
+	fitness := &fuzzingConfig.FitnessMetricConfig
+	record := &fuzzingConfig.MetricRecordConfig
+	return map[string]*bool{
+		"fitnessMetricConfig.codeCoverageEnabled":     &fitness.CodeCoverageEnabled,
+		"fitnessMetricConfig.branchCoverageEnabled":   &fitness.BranchCoverageEnabled,
+		"fitnessMetricConfig.edgeCoverageEnabled":     &fitness.EdgeCoverageEnabled,
+		"fitnessMetricConfig.pathCoverageEnabled":     &fitness.PathCoverageEnabled,
+		"fitnessMetricConfig.selectorCoverageEnabled": &fitness.SelectorCoverageEnabled,
+		"fitnessMetricConfig.dataflowEnabled":         &fitness.DataflowEnabled,
+		"fitnessMetricConfig.storageWriteEnabled":     &fitness.StorageWriteEnabled,
+		"fitnessMetricConfig.tokenflowEnabled":        &fitness.TokenflowEnabled,
+		"fitnessMetricConfig.balanceDeltaEnabled":     &fitness.BalanceDeltaEnabled,
+		"fitnessMetricConfig.branchDistanceEnabled":   &fitness.BranchDistanceEnabled,
+		"fitnessMetricConfig.cmpDistanceEnabled":      &fitness.CmpDistanceEnabled,
+		"metricRecordConfig.codeCoverageEnabled":      &record.CodeCoverageEnabled,
+		"metricRecordConfig.branchCoverageEnabled":    &record.BranchCoverageEnabled,
+		"metricRecordConfig.edgeCoverageEnabled":      &record.EdgeCoverageEnabled,
+		"metricRecordConfig.pathCoverageEnabled":      &record.PathCoverageEnabled,
+		"metricRecordConfig.selectorCoverageEnabled":  &record.SelectorCoverageEnabled,
+		"metricRecordConfig.dataflowEnabled":          &record.DataflowEnabled,
+		"metricRecordConfig.storageWriteEnabled":      &record.StorageWriteEnabled,
+		"metricRecordConfig.tokenflowEnabled":         &record.TokenflowEnabled,
+		"metricRecordConfig.balanceDeltaEnabled":      &record.BalanceDeltaEnabled,
+		"bugDetectionConfig.enabled":                  &fuzzingConfig.BugDetectionConfig.Enabled,
+	}
+}
+
+// ReloadMetricConfig pauses the metrics and bug detection enabled by the fuzzer's project configuration which the
+// provided fuzzing configuration disables, and resumes the paused ones it enables again, e.g. to stop tracing
+// expensive metrics once coverage has plateaued. Metrics disabled by the fuzzer's project configuration cannot be
+// enabled while fuzzing, as the state they rely on is only set up when fuzzing starts, so they are ignored with a
+// warning. Workers attach their tracers accordingly once they finish testing their current call sequence.
+// This is safe to call while the fuzzer is running.
+func (f *Fuzzer) ReloadMetricConfig(fuzzingConfig *config.FuzzingConfig) {
+	startupToggles := metricToggles(&f.config.Fuzzing)
+	reloadedToggles := metricToggles(fuzzingConfig)
+	names := make([]string, 0, len(startupToggles))
+	for name := range startupToggles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	f.metricControl.lock.Lock()
+	defer f.metricControl.lock.Unlock()
+
+	changed := false
+	for _, name := range names {
+		enabled := *reloadedToggles[name]
+		if !*startupToggles[name] {
+			if enabled {
+				f.logger.Warn("Cannot enable ", name, " while fuzzing, as it was disabled when fuzzing started")
+			}
+			continue
+		}
+		// Skip metrics which are already paused or running as requested
+		if f.metricControl.paused[name] == !enabled {
+			continue
+		}
+
+		if enabled {
+			delete(f.metricControl.paused, name)
+			f.logger.Info("Resumed ", name)
+		} else {
+			if f.metricControl.paused == nil {
+				f.metricControl.paused = make(map[string]bool)
+			}
+			f.metricControl.paused[name] = true
+			f.logger.Info("Paused ", name)
+		}
+		changed = true
+	}
+
+	if changed {
+		f.metricControl.generation.Add(1)
+	} else {
+		f.logger.Info("The reloaded configuration did not pause or resume any metric")
+	}
+}
+
+// runtimeFuzzingConfig returns a copy of the fuzzing configuration of the fuzzer's project configuration, with the
+// metrics paused through ReloadMetricConfig disabled, along with the generation of the paused metrics it reflects.
+func (f *Fuzzer) runtimeFuzzingConfig() (config.FuzzingConfig, uint64) {
+	f.metricControl.lock.RLock()
+	defer f.metricControl.lock.RUnlock()
+
+	fuzzingConfig := f.config.Fuzzing
+	for name, enabled := range metricToggles(&fuzzingConfig) {
+		if f.metricControl.paused[name] {
+			*enabled = false
+		}
+	}
+	return fuzzingConfig, f.metricControl.generation.Load()
+}
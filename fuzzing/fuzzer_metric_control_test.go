@@ -0,0 +1,48 @@
+package fuzzing
+
+import (
+	"testing"
+
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/crytic/medusa/logging"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReloadMetricConfig ensures reloading the metric configuration pauses and resumes the metrics enabled when
+// fuzzing started, ignores those which were disabled, and signals workers to restart only when something changed.
+func TestReloadMetricConfig(t *testing.T) {
+	projectConfig, err := config.GetDefaultProjectConfig("")
+	assert.NoError(t, err)
+	projectConfig.Fuzzing.FitnessMetricConfig.BranchDistanceEnabled = true
+	projectConfig.Fuzzing.MetricRecordConfig.DataflowEnabled = false
+	projectConfig.Fuzzing.BugDetectionConfig.Enabled = true
+	f := &Fuzzer{config: *projectConfig, logger: logging.NewLogger(zerolog.Disabled)}
+
+	// Pause branch distance and bug detection, and attempt to enable a metric disabled when fuzzing started.
+	reloaded := projectConfig.Fuzzing
+	reloaded.FitnessMetricConfig.BranchDistanceEnabled = false
+	reloaded.MetricRecordConfig.DataflowEnabled = true
+	reloaded.BugDetectionConfig.Enabled = false
+	f.ReloadMetricConfig(&reloaded)
+
+	fuzzingConfig, generation := f.runtimeFuzzingConfig()
+	assert.EqualValues(t, 1, generation)
+	assert.False(t, fuzzingConfig.FitnessMetricConfig.BranchDistanceEnabled)
+	assert.False(t, fuzzingConfig.MetricRecordConfig.DataflowEnabled)
+	assert.False(t, fuzzingConfig.UseBugDetector())
+	assert.True(t, f.config.Fuzzing.FitnessMetricConfig.BranchDistanceEnabled)
+
+	// Reloading the same configuration changes nothing.
+	f.ReloadMetricConfig(&reloaded)
+	_, generation = f.runtimeFuzzingConfig()
+	assert.EqualValues(t, 1, generation)
+
+	// Resume branch distance only.
+	reloaded.FitnessMetricConfig.BranchDistanceEnabled = true
+	f.ReloadMetricConfig(&reloaded)
+	fuzzingConfig, generation = f.runtimeFuzzingConfig()
+	assert.EqualValues(t, 2, generation)
+	assert.True(t, fuzzingConfig.FitnessMetricConfig.BranchDistanceEnabled)
+	assert.False(t, fuzzingConfig.UseBugDetector())
+}
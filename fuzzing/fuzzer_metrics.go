@@ -2,17 +2,32 @@ package fuzzing
 
 import (
 	"math/big"
+	"sync"
+	"sync/atomic"
 
+	"github.com/crytic/medusa-geth/accounts/abi"
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core"
 	"github.com/crytic/medusa/fuzzing/calls"
 	"github.com/crytic/medusa/fuzzing/config"
+	fuzzerTypes "github.com/crytic/medusa/fuzzing/contracts"
+	balancedelta "github.com/crytic/medusa/fuzzing/fitnessmetrics/balancedelta"
 	branchcoverage "github.com/crytic/medusa/fuzzing/fitnessmetrics/branchcoverage"
 	codecoverage "github.com/crytic/medusa/fuzzing/fitnessmetrics/codecoverage"
 	dataflow "github.com/crytic/medusa/fuzzing/fitnessmetrics/dataflow"
+	edgecoverage "github.com/crytic/medusa/fuzzing/fitnessmetrics/edgecoverage"
+	pathcoverage "github.com/crytic/medusa/fuzzing/fitnessmetrics/pathcoverage"
+	selectorcoverage "github.com/crytic/medusa/fuzzing/fitnessmetrics/selectorcoverage"
 	storagewrite "github.com/crytic/medusa/fuzzing/fitnessmetrics/storagewrite"
 	tokenflow "github.com/crytic/medusa/fuzzing/fitnessmetrics/tokenflow"
 	"github.com/crytic/medusa/fuzzing/reverts"
+	"github.com/crytic/medusa/logging"
 )
 
+// indicatorDeltaFlushInterval describes the amount of calls a worker records indicators for in its own delta maps
+// before sending them to be merged into the global indicator maps.
+const indicatorDeltaFlushInterval = 64
+
 // FuzzerMetrics represents a struct tracking metrics for a Fuzzer run.
 type FuzzerMetrics struct {
 	// workerMetrics describes the metrics for each individual worker. This expands as needed and some slots may be nil
@@ -26,6 +41,15 @@ type FuzzerMetrics struct {
 	// branchCoverageMaps describes the total branches known to be achieved across all corpus call sequences
 	branchCoverageMaps *branchcoverage.CoverageMaps
 
+	// edgeCoverageMaps describes the total control flow edges known to be taken across all corpus call sequences
+	edgeCoverageMaps *edgecoverage.CoverageMaps
+
+	// transactionPaths describes the paths known to be taken by transactions across all tested call sequences
+	transactionPaths *pathcoverage.PathSet
+
+	// selectorSet describes the function selectors known to be executed successfully across all tested call sequences
+	selectorSet *selectorcoverage.SelectorSet
+
 	// dataflowMaps describes the triggered dataflw
 	dataflowMaps *dataflow.DataflowSet
 
@@ -35,8 +59,60 @@ type FuzzerMetrics struct {
 	// tokenflowMaps describes the token flow being triggered
 	tokenflowMaps *tokenflow.TokenflowSet
 
+	// balanceDeltaMaps describes the largest net balance deltas of tracked addresses over all tested call sequences
+	balanceDeltaMaps *balancedelta.BalanceDeltaSet
+
 	// fuzzingConfig describes the configuration for fuzzing.
 	fuzzingConfig *config.FuzzingConfig
+
+	// indicatorDeltasCh is the channel workers send their indicator deltas on, to be merged into the global indicator
+	// maps by the aggregator goroutine. Workers only ever update their own delta, so the global maps are only
+	// contended by the aggregator and readers.
+	indicatorDeltasCh chan *indicatorDelta
+
+	// indicatorAggregatorDone is closed once the aggregator goroutine merged all indicator deltas and exited.
+	indicatorAggregatorDone chan struct{}
+
+	// stopIndicatorAggregatorOnce ensures the aggregator goroutine is only stopped once, so stopIndicatorAggregator
+	// can be deferred to stop it on early returns while still being called explicitly before reporting.
+	stopIndicatorAggregatorOnce sync.Once
+
+	// branchDistanceBackPropagationFailures counts the times the branch distance tracers of the workers failed to find
+	// the distance of a branch.
+	branchDistanceBackPropagationFailures *atomic.Uint64
+}
+
+// indicatorDelta describes the indicators recorded by a single worker since it last sent them to be merged into the
+// global indicator maps.
+type indicatorDelta struct {
+	codeCoverageMaps   *codecoverage.CoverageMaps
+	branchCoverageMaps *branchcoverage.CoverageMaps
+	edgeCoverageMaps   *edgecoverage.CoverageMaps
+	selectorSet        *selectorcoverage.SelectorSet
+	dataflowMaps       *dataflow.DataflowSet
+	storageWriteMaps   *storagewrite.StorageWriteSet
+	tokenflowMaps      *tokenflow.TokenflowSet
+	balanceDeltaMaps   *balancedelta.BalanceDeltaSet
+
+	// transactionPathHashes describes the hashes of the paths taken by transactions which did not revert.
+	transactionPathHashes []uint64
+
+	// callCount describes the amount of calls recorded in this delta.
+	callCount int
+}
+
+// newIndicatorDelta creates an empty indicatorDelta.
+func newIndicatorDelta() *indicatorDelta {
+	return &indicatorDelta{
+		codeCoverageMaps:   codecoverage.NewCoverageMaps(),
+		branchCoverageMaps: branchcoverage.NewCoverageMaps(),
+		edgeCoverageMaps:   edgecoverage.NewCoverageMaps(),
+		selectorSet:        selectorcoverage.NewSelectorSet(),
+		dataflowMaps:       dataflow.NewDataflowSet(),
+		storageWriteMaps:   storagewrite.NewStorageWriteSet(),
+		tokenflowMaps:      tokenflow.NewTokenflowSet(),
+		balanceDeltaMaps:   balancedelta.NewBalanceDeltaSet(),
+	}
 }
 
 // fuzzerWorkerMetrics represents metrics for a single FuzzerWorker instance.
@@ -62,6 +138,37 @@ type fuzzerWorkerMetrics struct {
 
 	// shrinking indicates whether the fuzzer worker is currently shrinking.
 	shrinking bool
+
+	// mutationTargetsChosen is the amount of corpus call sequences the worker chose as mutation targets.
+	mutationTargetsChosen uint64
+
+	// mutationTargetEnergySum is the sum of the adaptive power schedule energies the mutation targets were chosen with.
+	mutationTargetEnergySum uint64
+
+	// maxMutationTargetEnergy is the largest adaptive power schedule energy a mutation target was chosen with.
+	maxMutationTargetEnergy uint64
+
+	// fuzzingConfig describes the configuration for fuzzing.
+	fuzzingConfig *config.FuzzingConfig
+
+	// gasProfiles describes the gas used by the calls the worker made to each function, if gas profiling is enabled.
+	gasProfiles map[gasProfileKey]*GasProfile
+
+	// indicatorDelta describes the indicators recorded by the worker which were not yet sent to be merged.
+	indicatorDelta *indicatorDelta
+
+	// indicatorDeltasCh is the channel the worker sends its indicator deltas on (see FuzzerMetrics).
+	indicatorDeltasCh chan *indicatorDelta
+
+	// revertMetricsMethods caches the methods resolved for nested calls by updateRevertMetrics.
+	revertMetricsMethods map[revertMetricsMethodKey]*abi.Method
+}
+
+// revertMetricsMethodKey describes the contract and function selector a method is resolved for by
+// updateRevertMetrics.
+type revertMetricsMethodKey struct {
+	contract *fuzzerTypes.Contract
+	selector [4]byte
 }
 
 // newFuzzerMetrics obtains a new FuzzerMetrics struct for a given number of workers specified by workerCount.
@@ -70,7 +177,11 @@ type fuzzerWorkerMetrics struct {
 func newFuzzerMetrics(workerCount int, revertMetricsCh chan reverts.RevertMetricsUpdate, fuzzingConfig *config.FuzzingConfig) *FuzzerMetrics {
 	// Create a new metrics struct and return it with as many slots as required.
 	metrics := FuzzerMetrics{
-		workerMetrics: make([]fuzzerWorkerMetrics, workerCount),
+		workerMetrics:           make([]fuzzerWorkerMetrics, workerCount),
+		indicatorDeltasCh:       make(chan *indicatorDelta, workerCount),
+		indicatorAggregatorDone: make(chan struct{}),
+
+		branchDistanceBackPropagationFailures: new(atomic.Uint64),
 	}
 	for i := 0; i < len(metrics.workerMetrics); i++ {
 		metrics.workerMetrics[i].sequencesTested = big.NewInt(0)
@@ -79,19 +190,50 @@ func newFuzzerMetrics(workerCount int, revertMetricsCh chan reverts.RevertMetric
 		metrics.workerMetrics[i].workerStartupCount = big.NewInt(0)
 		metrics.workerMetrics[i].gasUsed = big.NewInt(0)
 		metrics.workerMetrics[i].revertMetricsChan = revertMetricsCh
-
+		metrics.workerMetrics[i].fuzzingConfig = fuzzingConfig
+		metrics.workerMetrics[i].indicatorDelta = newIndicatorDelta()
+		metrics.workerMetrics[i].indicatorDeltasCh = metrics.indicatorDeltasCh
+		metrics.workerMetrics[i].gasProfiles = make(map[gasProfileKey]*GasProfile)
 	}
 
 	// init indicators maps
 	metrics.fuzzingConfig = fuzzingConfig
 	metrics.codeCoverageMaps = codecoverage.NewCoverageMaps()
 	metrics.branchCoverageMaps = branchcoverage.NewCoverageMaps()
+	metrics.edgeCoverageMaps = edgecoverage.NewCoverageMaps()
+	metrics.transactionPaths = pathcoverage.NewPathSet(fuzzingConfig.PathCoverage.BloomFilterBits, fuzzingConfig.PathCoverage.BloomFilterHashes)
+	metrics.selectorSet = selectorcoverage.NewSelectorSet()
 	metrics.dataflowMaps = dataflow.NewDataflowSet()
 	metrics.storageWriteMaps = storagewrite.NewStorageWriteSet()
 	metrics.tokenflowMaps = tokenflow.NewTokenflowSet()
+	metrics.balanceDeltaMaps = balancedelta.NewBalanceDeltaSet()
+
+	// Start merging worker indicator deltas into the global indicator maps
+	go metrics.aggregateIndicatorDeltas()
 	return &metrics
 }
 
+// aggregateIndicatorDeltas merges the indicator deltas sent by workers into the global indicator maps, until the
+// channel is closed by stopIndicatorAggregator.
+func (m *FuzzerMetrics) aggregateIndicatorDeltas() {
+	defer close(m.indicatorAggregatorDone)
+	for delta := range m.indicatorDeltasCh {
+		err := m.mergeIndicatorDelta(delta)
+		if err != nil {
+			logging.GlobalLogger.Error("Failed to merge fuzzing indicators recorded by a worker", err)
+		}
+	}
+}
+
+// stopIndicatorAggregator stops accepting indicator deltas and waits for those pending to be merged into the global
+// indicator maps. It must only be called once all workers exited. Subsequent calls have no effect.
+func (m *FuzzerMetrics) stopIndicatorAggregator() {
+	m.stopIndicatorAggregatorOnce.Do(func() {
+		close(m.indicatorDeltasCh)
+	})
+	<-m.indicatorAggregatorDone
+}
+
 // FailedSequences returns the number of sequences that led to failures across all workers
 func (m *FuzzerMetrics) FailedSequences() *big.Int {
 	failedSequences := big.NewInt(0)
@@ -148,29 +290,116 @@ func (m *FuzzerMetrics) WorkersShrinkingCount() uint64 {
 	return shrinkingCount
 }
 
-// updateRevertMetrics updates the revert metrics for the fuzzer worker based on the call sequence element.
-func (m *fuzzerWorkerMetrics) updateRevertMetrics(callSequenceElement *calls.CallSequenceElement) {
+// MutationTargetEnergies returns the mean and largest factors the adaptive power schedule multiplied the mutation
+// weights of the corpus call sequences chosen as mutation targets by. The mean is zero if none were chosen.
+func (m *FuzzerMetrics) MutationTargetEnergies() (float64, uint64) {
+	chosen, energySum, maxEnergy := uint64(0), uint64(0), uint64(0)
+	for _, workerMetrics := range m.workerMetrics {
+		chosen += workerMetrics.mutationTargetsChosen
+		energySum += workerMetrics.mutationTargetEnergySum
+		maxEnergy = max(maxEnergy, workerMetrics.maxMutationTargetEnergy)
+	}
+	if chosen == 0 {
+		return 0, maxEnergy
+	}
+	return float64(energySum) / float64(chosen), maxEnergy
+}
+
+// BranchDistanceBackPropagationFailures returns the amount of times the branch distance tracer failed to find the
+// distance of a branch, recording an unknown distance instead.
+func (m *FuzzerMetrics) BranchDistanceBackPropagationFailures() uint64 {
+	return m.branchDistanceBackPropagationFailures.Load()
+}
+
+// recordMutationTargetEnergy records the adaptive power schedule energy a mutation target was chosen with.
+func (m *fuzzerWorkerMetrics) recordMutationTargetEnergy(energy uint64) {
+	m.mutationTargetsChosen++
+	m.mutationTargetEnergySum += energy
+	m.maxMutationTargetEnergy = max(m.maxMutationTargetEnergy, energy)
+}
+
+// updateRevertMetrics updates the revert metrics for the fuzzer worker based on the call sequence element. Calls
+// dispatched from call data encoded in its arguments (e.g. by multicall) are attributed to the functions they executed,
+// with their own result, if they executed the code of one of the provided deployed contracts.
+func (m *fuzzerWorkerMetrics) updateRevertMetrics(callSequenceElement *calls.CallSequenceElement, deployedContracts map[common.Address]*fuzzerTypes.Contract) {
 	// The channel will be nil if revert metrics are not enabled
 	if callSequenceElement == nil || m.revertMetricsChan == nil {
 		return
 	}
 
 	// Send the revert metrics update to the revert reporter
+	functionName := callSequenceElement.Call.DataAbiValues.Method.Name
+	messageResults := callSequenceElement.ChainReference.MessageResults()
 	m.revertMetricsChan <- reverts.RevertMetricsUpdate{
 		ContractName:    callSequenceElement.Contract.Name(),
-		FunctionName:    callSequenceElement.Call.DataAbiValues.Method.Name,
-		ExecutionResult: callSequenceElement.ChainReference.MessageResults().ExecutionResult,
+		FunctionName:    functionName,
+		ExecutionResult: messageResults.ExecutionResult,
+	}
+
+	// Name each nested call after the calls it is nested within, so metrics reference the path of functions exercised.
+	// Nested calls whose function could not be resolved are named after their parent.
+	nestedCallFrames := reverts.GetNestedCallTracerResults(messageResults)
+	if len(nestedCallFrames) == 0 {
+		return
+	}
+	nestedNames := make([]string, len(nestedCallFrames))
+	for i, nestedCallFrame := range nestedCallFrames {
+		parentName := functionName
+		if nestedCallFrame.Parent >= 0 {
+			parentName = nestedNames[nestedCallFrame.Parent]
+		}
+		nestedNames[i] = parentName
+
+		contract := deployedContracts[nestedCallFrame.CodeAddress]
+		if contract == nil {
+			continue
+		}
+		method := m.revertMetricsMethod(contract, nestedCallFrame.Selector)
+		if method == nil {
+			continue
+		}
+		nestedNames[i] = parentName + "/" + method.Name
+		m.revertMetricsChan <- reverts.RevertMetricsUpdate{
+			ContractName: contract.Name(),
+			FunctionName: nestedNames[i],
+			ExecutionResult: &core.ExecutionResult{
+				Err:        nestedCallFrame.Err,
+				ReturnData: nestedCallFrame.ReturnData,
+			},
+		}
 	}
 }
 
-func (m *FuzzerMetrics) updateIndicators(lastCall *calls.CallSequenceElement) error {
+// revertMetricsMethod resolves the method of the provided contract with the provided selector, caching the result
+// for later nested calls.
+// Returns the method, or nil if the contract has no such method.
+func (m *fuzzerWorkerMetrics) revertMetricsMethod(contract *fuzzerTypes.Contract, selector [4]byte) *abi.Method {
+	key := revertMetricsMethodKey{contract: contract, selector: selector}
+	if method, ok := m.revertMetricsMethods[key]; ok {
+		return method
+	}
+	method, err := contract.CompiledContract().Abi.MethodById(selector[:])
+	if err != nil {
+		method = nil
+	}
+	if m.revertMetricsMethods == nil {
+		m.revertMetricsMethods = make(map[revertMetricsMethodKey]*abi.Method)
+	}
+	m.revertMetricsMethods[key] = method
+	return method
+}
 
+// updateIndicators records the indicators of the provided call into the worker's indicator delta, sending the delta
+// to be merged into the global indicator maps every indicatorDeltaFlushInterval calls.
+// Returns an error if one occurred.
+func (m *fuzzerWorkerMetrics) updateIndicators(lastCall *calls.CallSequenceElement) error {
 	lastCallChainReference := lastCall.ChainReference
 	lastMessageResult := lastCallChainReference.Block.MessageResults[lastCallChainReference.TransactionIndex]
+	delta := m.indicatorDelta
 
 	if m.fuzzingConfig.MetricRecordConfig.CodeCoverageEnabled {
 		codeCoverageMaps := codecoverage.GetCoverageTracerResults(lastMessageResult)
-		_, err := m.codeCoverageMaps.Update(codeCoverageMaps)
+		_, err := delta.codeCoverageMaps.Update(codeCoverageMaps)
 		if err != nil {
 			return err
 		}
@@ -178,7 +407,30 @@ func (m *FuzzerMetrics) updateIndicators(lastCall *calls.CallSequenceElement) er
 
 	if m.fuzzingConfig.MetricRecordConfig.BranchCoverageEnabled {
 		branchCoverageMaps := branchcoverage.GetCoverageTracerResults(lastMessageResult)
-		_, err := m.branchCoverageMaps.Update(branchCoverageMaps)
+		_, err := delta.branchCoverageMaps.Update(branchCoverageMaps)
+		if err != nil {
+			return err
+		}
+	}
+
+	if m.fuzzingConfig.MetricRecordConfig.EdgeCoverageEnabled {
+		edgeCoverageMaps := edgecoverage.GetCoverageTracerResults(lastMessageResult)
+		_, err := delta.edgeCoverageMaps.Update(edgeCoverageMaps)
+		if err != nil {
+			return err
+		}
+	}
+
+	if m.fuzzingConfig.MetricRecordConfig.PathCoverageEnabled {
+		transactionPath := pathcoverage.GetPathCoverageTracerResults(lastMessageResult)
+		if transactionPath != nil && !transactionPath.Reverted {
+			delta.transactionPathHashes = append(delta.transactionPathHashes, transactionPath.Hash)
+		}
+	}
+
+	if m.fuzzingConfig.MetricRecordConfig.SelectorCoverageEnabled {
+		selectorSet := selectorcoverage.GetSelectorCoverageTracerResults(lastMessageResult)
+		_, err := delta.selectorSet.Update(selectorSet)
 		if err != nil {
 			return err
 		}
@@ -186,7 +438,7 @@ func (m *FuzzerMetrics) updateIndicators(lastCall *calls.CallSequenceElement) er
 
 	if m.fuzzingConfig.MetricRecordConfig.DataflowEnabled {
 		dataflowMaps := dataflow.GetDataflowTracerResults(lastMessageResult)
-		_, err := m.dataflowMaps.Update(dataflowMaps)
+		_, err := delta.dataflowMaps.Update(dataflowMaps)
 		if err != nil {
 			return err
 		}
@@ -194,7 +446,7 @@ func (m *FuzzerMetrics) updateIndicators(lastCall *calls.CallSequenceElement) er
 
 	if m.fuzzingConfig.MetricRecordConfig.StorageWriteEnabled {
 		storageWriteMaps := storagewrite.GetStorageWriteTracerResults(lastMessageResult)
-		_, err := m.storageWriteMaps.Update(storageWriteMaps)
+		_, err := delta.storageWriteMaps.Update(storageWriteMaps)
 		if err != nil {
 			return err
 		}
@@ -202,14 +454,69 @@ func (m *FuzzerMetrics) updateIndicators(lastCall *calls.CallSequenceElement) er
 
 	if m.fuzzingConfig.MetricRecordConfig.TokenflowEnabled {
 		tokenflowMaps := tokenflow.GetTokenflowTracerResults(lastMessageResult)
-		_, err := m.tokenflowMaps.Update(tokenflowMaps)
+		_, err := delta.tokenflowMaps.Update(tokenflowMaps)
+		if err != nil {
+			return err
+		}
+	}
+
+	if m.fuzzingConfig.MetricRecordConfig.BalanceDeltaEnabled {
+		balanceDeltaMaps := balancedelta.GetBalanceDeltaTracerResults(lastMessageResult)
+		_, err := delta.balanceDeltaMaps.Update(balanceDeltaMaps)
 		if err != nil {
 			return err
 		}
 	}
+
+	// Send our delta to be merged periodically, rather than contending the global maps on every call.
+	delta.callCount++
+	if delta.callCount >= indicatorDeltaFlushInterval {
+		m.flushIndicators()
+	}
 	return nil
 }
 
+// flushIndicators sends the worker's indicator delta to be merged into the global indicator maps, and starts a new
+// one. Workers call this upon exiting, so no recorded indicators are lost.
+func (m *fuzzerWorkerMetrics) flushIndicators() {
+	if m.indicatorDelta.callCount == 0 {
+		return
+	}
+	m.indicatorDeltasCh <- m.indicatorDelta
+	m.indicatorDelta = newIndicatorDelta()
+}
+
+// mergeIndicatorDelta merges the provided worker indicator delta into the global indicator maps.
+// Returns an error if one occurred.
+func (m *FuzzerMetrics) mergeIndicatorDelta(delta *indicatorDelta) error {
+	if _, err := m.codeCoverageMaps.Update(delta.codeCoverageMaps); err != nil {
+		return err
+	}
+	if _, err := m.branchCoverageMaps.Update(delta.branchCoverageMaps); err != nil {
+		return err
+	}
+	if _, err := m.edgeCoverageMaps.Update(delta.edgeCoverageMaps); err != nil {
+		return err
+	}
+	for _, transactionPathHash := range delta.transactionPathHashes {
+		m.transactionPaths.Add(transactionPathHash)
+	}
+	if _, err := m.selectorSet.Update(delta.selectorSet); err != nil {
+		return err
+	}
+	if _, err := m.dataflowMaps.Update(delta.dataflowMaps); err != nil {
+		return err
+	}
+	if _, err := m.storageWriteMaps.Update(delta.storageWriteMaps); err != nil {
+		return err
+	}
+	if _, err := m.tokenflowMaps.Update(delta.tokenflowMaps); err != nil {
+		return err
+	}
+	_, err := m.balanceDeltaMaps.Update(delta.balanceDeltaMaps)
+	return err
+}
+
 // CoverageMaps exposes coverage details for all call sequences known to the corpus.
 func (m *FuzzerMetrics) CodeCoverageMaps() *codecoverage.CoverageMaps {
 	return m.codeCoverageMaps
@@ -219,6 +526,18 @@ func (m *FuzzerMetrics) BranchCoverageMaps() *branchcoverage.CoverageMaps {
 	return m.branchCoverageMaps
 }
 
+func (m *FuzzerMetrics) EdgeCoverageMaps() *edgecoverage.CoverageMaps {
+	return m.edgeCoverageMaps
+}
+
+func (m *FuzzerMetrics) TransactionPaths() *pathcoverage.PathSet {
+	return m.transactionPaths
+}
+
+func (m *FuzzerMetrics) SelectorSet() *selectorcoverage.SelectorSet {
+	return m.selectorSet
+}
+
 func (m *FuzzerMetrics) DataflowSet() *dataflow.DataflowSet {
 	return m.dataflowMaps
 }
@@ -230,3 +549,7 @@ func (m *FuzzerMetrics) StorageWriteMaps() *storagewrite.StorageWriteSet {
 func (m *FuzzerMetrics) TokenflowMaps() *tokenflow.TokenflowSet {
 	return m.tokenflowMaps
 }
+
+func (m *FuzzerMetrics) BalanceDeltaMaps() *balancedelta.BalanceDeltaSet {
+	return m.balanceDeltaMaps
+}
@@ -0,0 +1,130 @@
+package fuzzing
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/crytic/medusa-geth/accounts/abi"
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core"
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa/chain"
+	chainTypes "github.com/crytic/medusa/chain/types"
+	compilationTypes "github.com/crytic/medusa/compilation/types"
+	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/fuzzing/config"
+	fuzzerTypes "github.com/crytic/medusa/fuzzing/contracts"
+	"github.com/crytic/medusa/fuzzing/executiontracer"
+	"github.com/crytic/medusa/fuzzing/reverts"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestUpdateRevertMetricsNestedCalls ensures nested calls are attributed to the functions of the contracts whose code
+// they executed, each with its own result rather than that of the top-level call.
+func TestUpdateRevertMetricsNestedCalls(t *testing.T) {
+	routerAbi, err := abi.JSON(strings.NewReader(`[{"type": "function", "name": "multicall", "stateMutability": "nonpayable", "inputs": [{"name": "data", "type": "bytes[]"}]}]`))
+	assert.NoError(t, err)
+	tokenAbi, err := abi.JSON(strings.NewReader(`[{"type": "function", "name": "transfer", "stateMutability": "nonpayable", "inputs": [{"name": "to", "type": "address"}, {"name": "amount", "type": "uint256"}]}]`))
+	assert.NoError(t, err)
+	router := fuzzerTypes.NewContract("Router", "Router.sol", &compilationTypes.CompiledContract{Abi: routerAbi}, nil)
+	token := fuzzerTypes.NewContract("Token", "Token.sol", &compilationTypes.CompiledContract{Abi: tokenAbi}, nil)
+	routerAddress := common.HexToAddress("0x1000")
+	tokenAddress := common.HexToAddress("0x2000")
+	deployedContracts := map[common.Address]*fuzzerTypes.Contract{routerAddress: router, tokenAddress: token}
+
+	var multicallSelector, transferSelector [4]byte
+	copy(multicallSelector[:], routerAbi.Methods["multicall"].ID)
+	copy(transferSelector[:], tokenAbi.Methods["transfer"].ID)
+
+	// The router's multicall succeeds, while a nested multicall dispatches a transfer which reverts, and a nested call
+	// to an unknown contract or function is not attributed.
+	method := routerAbi.Methods["multicall"]
+	msg := calls.NewCallMessageWithAbiValueData(common.Address{}, &routerAddress, 0, big.NewInt(0), 1_000_000, big.NewInt(1), big.NewInt(1), big.NewInt(1), &calls.CallMessageDataAbiValues{
+		Method:      &method,
+		InputValues: []any{[][]byte{}},
+	})
+	element := calls.NewCallSequenceElement(router, msg, 0, 0)
+	messageResults := &chainTypes.MessageResults{
+		ExecutionResult: &core.ExecutionResult{},
+		AdditionalResults: map[string]any{
+			"NestedCallTracerResults": []reverts.NestedCallFrame{
+				{Parent: -1, CodeAddress: routerAddress, Selector: multicallSelector},
+				{Parent: 0, CodeAddress: tokenAddress, Selector: transferSelector, Err: vm.ErrExecutionReverted},
+				{Parent: 0, CodeAddress: common.HexToAddress("0x3000"), Selector: transferSelector},
+				{Parent: 0, CodeAddress: tokenAddress, Selector: [4]byte{1, 2, 3, 4}},
+			},
+		},
+	}
+	element.ChainReference = &calls.CallSequenceElementChainReference{
+		Block: &chainTypes.Block{MessageResults: []*chainTypes.MessageResults{messageResults}},
+	}
+
+	revertMetricsCh := make(chan reverts.RevertMetricsUpdate, 16)
+	metrics := &fuzzerWorkerMetrics{revertMetricsChan: revertMetricsCh}
+	for i := 0; i < 2; i++ {
+		metrics.updateRevertMetrics(element, deployedContracts)
+	}
+	close(revertMetricsCh)
+
+	revertMetrics := reverts.NewRevertMetrics()
+	for update := range revertMetricsCh {
+		revertMetrics.Update(&update, nil)
+	}
+	routerMetrics := revertMetrics.ContractRevertMetrics["Router"].FunctionRevertMetrics
+	assert.Len(t, routerMetrics, 2)
+	assert.EqualValues(t, 2, routerMetrics["multicall"].TotalCalls)
+	assert.EqualValues(t, 0, routerMetrics["multicall"].TotalReverts)
+	assert.EqualValues(t, 2, routerMetrics["multicall/multicall"].TotalCalls)
+	assert.EqualValues(t, 0, routerMetrics["multicall/multicall"].TotalReverts)
+	tokenMetrics := revertMetrics.ContractRevertMetrics["Token"].FunctionRevertMetrics
+	assert.Len(t, tokenMetrics, 1)
+	assert.EqualValues(t, 2, tokenMetrics["multicall/multicall/transfer"].TotalCalls)
+	assert.EqualValues(t, 2, tokenMetrics["multicall/multicall/transfer"].TotalReverts)
+
+	// Methods are resolved once per contract and selector, including those which could not be resolved.
+	assert.Len(t, metrics.revertMetricsMethods, 3)
+}
+
+// assertIndicatorAggregatorStopped asserts the goroutine merging indicator deltas into the provided metrics exited.
+func assertIndicatorAggregatorStopped(t *testing.T, metrics *FuzzerMetrics) {
+	select {
+	case <-metrics.indicatorAggregatorDone:
+	default:
+		assert.Fail(t, "the indicator aggregator goroutine is still running")
+	}
+}
+
+// TestStopIndicatorAggregator ensures pending indicator deltas are merged when the indicator aggregator is stopped,
+// and that stopping it again has no effect.
+func TestStopIndicatorAggregator(t *testing.T) {
+	projectConfig, err := config.GetDefaultProjectConfig("")
+	assert.NoError(t, err)
+	metrics := newFuzzerMetrics(1, nil, &projectConfig.Fuzzing)
+
+	delta := newIndicatorDelta()
+	delta.transactionPathHashes = append(delta.transactionPathHashes, 1)
+	metrics.indicatorDeltasCh <- delta
+
+	metrics.stopIndicatorAggregator()
+	assertIndicatorAggregatorStopped(t, metrics)
+	assert.EqualValues(t, 1, metrics.TransactionPaths().ObservedPathCount())
+	assert.NotPanics(t, metrics.stopIndicatorAggregator)
+}
+
+// TestStartStopsIndicatorAggregatorOnEarlyReturn ensures the indicator aggregator goroutine is stopped when a
+// campaign fails to start after its metrics were created.
+func TestStartStopsIndicatorAggregatorOnEarlyReturn(t *testing.T) {
+	projectConfig, err := config.GetDefaultProjectConfig("")
+	assert.NoError(t, err)
+	fuzzer, err := NewFuzzer(*projectConfig)
+	assert.NoError(t, err)
+
+	setupErr := errors.New("chain setup failed")
+	fuzzer.Hooks.ChainSetupFunc = func(fuzzer *Fuzzer, testChain *chain.TestChain) (*executiontracer.ExecutionTrace, error) {
+		return nil, setupErr
+	}
+	assert.ErrorIs(t, fuzzer.Start(), setupErr)
+	assertIndicatorAggregatorStopped(t, fuzzer.metrics)
+}
@@ -0,0 +1,114 @@
+package fuzzing
+
+import (
+	"github.com/crytic/medusa/fuzzing/calls"
+)
+
+// callSequencePrefixBranch describes a prefix of a call sequence kept on a worker's chain, which the following new
+// call sequences are executed on top of, so that they branch from it without re-executing it.
+type callSequencePrefixBranch struct {
+	// prefix describes the calls the chain state reflects on top of the testing base.
+	prefix calls.CallSequence
+
+	// blockIndex describes the amount of committed blocks of the chain once the prefix is executed.
+	blockIndex uint64
+
+	// snapshot describes the id of the chain snapshot recorded once the chain was reverted to the end of the prefix,
+	// or -1 if it was not recorded yet.
+	snapshot int
+
+	// remainingBranches describes the amount of call sequences which will still be executed on top of the prefix.
+	remainingBranches int
+}
+
+// prepareBranchPrefix prepares the chain state the next call sequence should be executed on top of, when prefix
+// branching is enabled. New call sequences are executed on top of the current prefix, if any. Corpus call sequences
+// being replayed always start from the testing base, as do all call sequences if the chain was reverted past the
+// prefix in the meantime (e.g. while shrinking).
+// Returns the calls the chain state reflects on top of the testing base, or an error if one occurred.
+func (fw *FuzzerWorker) prepareBranchPrefix(isNewSequence bool) (calls.CallSequence, error) {
+	if fw.branchPrefix == nil {
+		return nil, nil
+	}
+
+	// Drop the prefix if it cannot be branched from.
+	branchPrefix := fw.branchPrefix
+	if !isNewSequence || !fw.chain.HasSnapshot(branchPrefix.snapshot) || uint64(len(fw.chain.CommittedBlocks())) != branchPrefix.blockIndex {
+		fw.branchPrefix = nil
+		return nil, fw.chain.RevertToBlockIndex(fw.testingBaseBlockIndex)
+	}
+	branchPrefix.remainingBranches--
+	return branchPrefix.prefix, nil
+}
+
+// finishBranchPrefix updates the prefix call sequences branch from once the provided call sequence was executed. Once
+// the prefix has been branched from the configured amount of times, it is dropped, and a new prefix is chosen from the
+// next new call sequence executed on top of the testing base. Call sequences which produced shrink requests are never
+// branched from, as shrinking resets the chain.
+func (fw *FuzzerWorker) finishBranchPrefix(executedSequence calls.CallSequence, isNewSequence bool, shrinkRequests []ShrinkCallSequenceRequest) {
+	if !isNewSequence || len(shrinkRequests) > 0 {
+		fw.branchPrefix = nil
+		return
+	}
+
+	// If we executed on top of a prefix, keep it until it runs out of branches.
+	if fw.branchPrefix != nil {
+		if fw.branchPrefix.remainingBranches <= 0 {
+			fw.branchPrefix = nil
+		}
+		return
+	}
+	fw.branchPrefix = fw.newBranchPrefix(executedSequence)
+}
+
+// newBranchPrefix chooses a random prefix of the provided call sequence, executed on top of the testing base, to
+// branch from. As the chain can only be reverted to block boundaries, the prefix ends with the last call of a block,
+// and excludes at least the last call of the sequence.
+// Returns the prefix, or nil if the sequence has no such prefix.
+func (fw *FuzzerWorker) newBranchPrefix(executedSequence calls.CallSequence) *callSequencePrefixBranch {
+	// Collect the lengths of prefixes ending at block boundaries.
+	prefixLengths := make([]int, 0)
+	for i := 1; i < len(executedSequence); i++ {
+		previous, current := executedSequence[i-1].ChainReference, executedSequence[i].ChainReference
+		if previous != nil && current != nil && previous.Block != current.Block {
+			prefixLengths = append(prefixLengths, i)
+		}
+	}
+	if len(prefixLengths) == 0 {
+		return nil
+	}
+	prefixLength := prefixLengths[fw.randomProvider.Intn(len(prefixLengths))]
+
+	// Locate the block the prefix ends with, searching backwards as it was committed recently.
+	lastBlock := executedSequence[prefixLength-1].ChainReference.Block
+	committedBlocks := fw.chain.CommittedBlocks()
+	for i := len(committedBlocks) - 1; i >= int(fw.testingBaseBlockIndex); i-- {
+		if committedBlocks[i] == lastBlock {
+			return &callSequencePrefixBranch{
+				prefix:            executedSequence[:prefixLength],
+				blockIndex:        uint64(i + 1),
+				snapshot:          -1,
+				remainingBranches: fw.fuzzer.config.Fuzzing.PrefixBranching.Branches,
+			}
+		}
+	}
+	return nil
+}
+
+// revertToBranchPrefix reverts the chain to the state the next call sequence starts from: the end of the current
+// prefix when branching from one, recording a snapshot of it the first time, or the testing base otherwise.
+// Returns an error if one occurred.
+func (fw *FuzzerWorker) revertToBranchPrefix() error {
+	branchPrefix := fw.branchPrefix
+	if branchPrefix == nil {
+		return fw.chain.RevertToBlockIndex(fw.testingBaseBlockIndex)
+	}
+	if branchPrefix.snapshot < 0 {
+		if err := fw.chain.RevertToBlockIndex(branchPrefix.blockIndex); err != nil {
+			return err
+		}
+		branchPrefix.snapshot = fw.chain.Snapshot()
+		return nil
+	}
+	return fw.chain.RevertToSnapshot(branchPrefix.snapshot)
+}
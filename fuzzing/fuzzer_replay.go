@@ -0,0 +1,126 @@
+package fuzzing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/crytic/medusa/fuzzing/corpus"
+	"github.com/crytic/medusa/fuzzing/executiontracer"
+	"github.com/crytic/medusa/utils"
+	"github.com/crytic/medusa/utils/randomutils"
+)
+
+// newReplayWorker initializes the state required to replay call sequences outside a fuzzing campaign, as when
+// fuzzing, and creates a single worker with all tracers attached to replay call sequences with. The fitness metrics
+// achieved by replayed call sequences are recorded in an in-memory corpus. The caller is expected to have initialized
+// the Fuzzer's metrics, and to close the worker's chain once it is done.
+// Returns the worker, or an error if one occurred.
+func (f *Fuzzer) newReplayWorker() (*FuzzerWorker, error) {
+	// Initialize the state required to set up our test chain and replay call sequences.
+	f.randomProvider, _ = f.newRandomProvider()
+	f.ctx, f.ctxCancelFunc = context.WithCancel(context.Background())
+	f.emergencyCtx, f.emergencyCtxCancelFunc = context.WithCancel(context.Background())
+
+	// Create our test chain, set it up and resolve the state our tracers depend on.
+	baseTestChain, err := f.setUpCampaign()
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the corpus our fitness metrics are recorded in, which is never written to disk.
+	f.corpus, err = corpus.NewCorpus("", &f.config.Fuzzing)
+	if err != nil {
+		f.logger.Error("Failed to create the corpus", err)
+		return nil, err
+	}
+
+	// Create our worker and set up its chain.
+	worker, err := newFuzzerWorker(f, 0, randomutils.ForkRandomProvider(f.randomProvider))
+	if err != nil {
+		f.logger.Error("Failed to create a worker to replay call sequences with", err)
+		return nil, err
+	}
+	err = worker.setUpChain(baseTestChain)
+	if err != nil {
+		f.logger.Error("Failed to set up a worker to replay call sequences with", err)
+		return nil, err
+	}
+	worker.testingBaseBlockIndex = uint64(len(worker.chain.CommittedBlocks()))
+	return worker, nil
+}
+
+// ReplayCallSequence replays the call sequence stored in the provided corpus file (a coverage-increasing call
+// sequence or a test result) against a worker with all tracers attached, processing the results of every call as
+// when fuzzing, and attaches an execution trace to every call. This reproduces failures which occur when tracing a
+// specific call sequence in isolation from the rest of a fuzzing campaign.
+// Returns the executed call sequence, or an error if one occurred.
+func (f *Fuzzer) ReplayCallSequence(filePath string) (calls.CallSequence, error) {
+	// Read our call sequence.
+	b, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	var callSequence calls.CallSequence
+	err = json.Unmarshal(b, &callSequence)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse call sequence '%v': %v", filePath, err)
+	}
+
+	// Create a worker to replay our call sequence with.
+	f.metrics = newFuzzerMetrics(1, f.revertReporter.RevertMetricsCh, &f.config.Fuzzing)
+	defer f.metrics.stopIndicatorAggregator()
+	worker, err := f.newReplayWorker()
+	if err != nil {
+		return nil, err
+	}
+	defer worker.chain.Close()
+
+	// Prepare every element for runtime execution.
+	for i, element := range callSequence {
+		if err = worker.bindCallSequenceElement(element); err != nil {
+			return nil, fmt.Errorf("call %d of the call sequence could not be replayed: %v", i+1, err)
+		}
+	}
+
+	// Our "fetch next call" method simply returns the next element of our call sequence.
+	fetchElementFunc := func(currentIndex int) (*calls.CallSequenceElement, error) {
+		if currentIndex >= len(callSequence) {
+			return nil, nil
+		}
+		return callSequence[currentIndex], nil
+	}
+
+	// Our "post-execution check" method processes the results of every call, as the worker does when fuzzing.
+	executionCheckFunc := func(currentlyExecutedSequence calls.CallSequence) (bool, error) {
+		latestCallSequenceElement := currentlyExecutedSequence[len(currentlyExecutedSequence)-1]
+		worker.recordCmpLog(latestCallSequenceElement)
+		worker.recordDataflowOrdering(latestCallSequenceElement)
+		worker.recordBlockDependencies(latestCallSequenceElement)
+		_, err := f.corpus.CheckSequenceMetricAndUpdate(currentlyExecutedSequence, big.NewInt(1), false)
+		if err != nil {
+			return true, err
+		}
+		err = worker.workerMetrics().updateIndicators(latestCallSequenceElement)
+		if err != nil {
+			return true, fmt.Errorf("error updating fuzzing indicators from call sequence element: %v", err)
+		}
+		return utils.CheckContextDone(f.emergencyCtx), nil
+	}
+
+	// Execute our call sequence with an execution tracer attached, then attach the trace of every call.
+	executionTracer := executiontracer.NewExecutionTracer(f.contractDefinitions, worker.chain, config.VeryVeryVerbose)
+	defer executionTracer.Close()
+	executedSequence, err := calls.ExecuteCallSequenceIteratively(worker.chain, fetchElementFunc, executionCheckFunc, executionTracer.NativeTracer())
+	if err != nil {
+		return nil, err
+	}
+	for _, element := range executedSequence {
+		element.ExecutionTrace = executionTracer.GetTrace(utils.MessageToTransaction(element.Call.ToCoreMessage()).Hash())
+	}
+	return executedSequence, nil
+}
@@ -0,0 +1,325 @@
+package fuzzing
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/common/hexutil"
+	compilationTypes "github.com/crytic/medusa/compilation/types"
+	"github.com/crytic/medusa/fuzzing/bugdetector"
+	"github.com/crytic/medusa/fuzzing/calls"
+	fuzzerTypes "github.com/crytic/medusa/fuzzing/contracts"
+	"github.com/crytic/medusa/fuzzing/coverage"
+	"github.com/crytic/medusa/fuzzing/valuegeneration"
+	"github.com/crytic/medusa/utils"
+)
+
+// ResultsReport describes the results of a fuzzing campaign in a machine-readable form, so that downstream tooling
+// does not have to parse the fuzzer's logs.
+type ResultsReport struct {
+	// Bugs describes the bugs found by the bug detector, including the failures of property tests registered as its
+	// oracles, sorted by bug ID.
+	Bugs []ResultsReportBug `json:"bugs"`
+
+	// Findings describes the bugs grouped by root cause, sorted by decreasing severity score and then by the ID of
+	// their primary bug.
+	Findings []ResultsReportFinding `json:"findings"`
+
+	// FailedTests describes the other test cases which failed, sorted by ID.
+	FailedTests []ResultsReportTest `json:"failedTests"`
+
+	// Metrics describes the totals of the fuzzing metrics at the end of the campaign.
+	Metrics coverageTimeSeriesSample `json:"metrics"`
+}
+
+// ResultsReportBug describes a bug found by the bug detector, along with its minimal reproducing call sequence.
+type ResultsReportBug struct {
+	// ID describes the bug ID (e.g. OVERFLOW-codeAddress-pc-opcode).
+	ID string `json:"id"`
+
+	// BugLocation describes the type and location of the bug, as decoded from its ID.
+	bugdetector.BugLocation
+
+	// ContractName describes the name of the contract the bug was detected in, if it could be resolved.
+	ContractName string `json:"contractName,omitempty"`
+
+	// Source describes the source code the instruction the bug was detected at maps to, if it could be resolved.
+	Source *ResultsReportSourceLocation `json:"source,omitempty"`
+
+	// StateVariable describes the name of the state variable involved in the bug, if any.
+	StateVariable string `json:"stateVariable,omitempty"`
+
+	// TaintedValue describes the concrete tainted value which reached the sink of the bug, if one was recorded.
+	TaintedValue string `json:"taintedValue,omitempty"`
+
+	// Exploitability describes the evidence of the bug's exploitability observed in its call sequence, if any.
+	Exploitability *ResultsReportExploitability `json:"exploitability,omitempty"`
+
+	// Status describes the status of the bug's test case. Bugs whose call sequence was still being shrunk when the
+	// campaign stopped, or whose call sequences are not shrunk (see BugDetectionConfig.ShrinkFindings), have no call
+	// sequence.
+	Status TestCaseStatus `json:"status"`
+
+	// CallSequence describes the minimal call sequence reproducing the bug.
+	CallSequence []ResultsReportCall `json:"callSequence"`
+}
+
+// ResultsReportTest describes a failed test case, along with the call sequence which made it fail.
+type ResultsReportTest struct {
+	// ID describes the unique identifier of the test case.
+	ID string `json:"id"`
+
+	// Name describes the name of the test case.
+	Name string `json:"name"`
+
+	// CallSequence describes the call sequence which made the test case fail, or nil if it is not related to one.
+	CallSequence []ResultsReportCall `json:"callSequence"`
+}
+
+// ResultsReportSourceLocation describes the location of an instruction in the source code of a contract.
+type ResultsReportSourceLocation struct {
+	// File describes the path of the source file.
+	File string `json:"file"`
+
+	// Line describes the line of the start of the source range, starting at one.
+	Line int `json:"line"`
+
+	// Column describes the column of the start of the source range, starting at one.
+	Column int `json:"column"`
+}
+
+// ResultsReportCall describes a call of a call sequence, with its ABI-decoded arguments.
+type ResultsReportCall struct {
+	// From describes the sender of the call.
+	From common.Address `json:"from"`
+
+	// To describes the address of the contract called, or nil for contract creations.
+	To *common.Address `json:"to"`
+
+	// ContractName describes the name of the contract called, if it is known.
+	ContractName string `json:"contractName,omitempty"`
+
+	// Function describes the signature of the function called, if the call was made through its ABI.
+	Function string `json:"function,omitempty"`
+
+	// Arguments describes the ABI-decoded arguments of the call, in the order of the function's inputs.
+	Arguments []ResultsReportArgument `json:"arguments,omitempty"`
+
+	// Value describes the amount of ether sent with the call, in wei.
+	Value string `json:"value"`
+
+	// Data describes the call data.
+	Data hexutil.Bytes `json:"data"`
+
+	// BlockNumberDelay describes how much the block number advanced before the call.
+	BlockNumberDelay uint64 `json:"blockNumberDelay"`
+
+	// BlockTimestampDelay describes how much the block timestamp advanced before the call.
+	BlockTimestampDelay uint64 `json:"blockTimestampDelay"`
+}
+
+// ResultsReportArgument describes an ABI-decoded argument of a call.
+type ResultsReportArgument struct {
+	// Name describes the name of the function input.
+	Name string `json:"name"`
+
+	// Type describes the ABI type of the function input.
+	Type string `json:"type"`
+
+	// Value describes the value of the argument, encoded as it is in the corpus.
+	Value any `json:"value"`
+}
+
+// newResultsReportCallSequence returns the provided call sequence with the arguments of each call ABI-decoded, or nil
+// if no call sequence is provided.
+func newResultsReportCallSequence(callSequence *calls.CallSequence) []ResultsReportCall {
+	if callSequence == nil {
+		return nil
+	}
+	reportCalls := make([]ResultsReportCall, 0, len(*callSequence))
+	for _, element := range *callSequence {
+		reportCall := ResultsReportCall{
+			From:                element.Call.From,
+			To:                  element.Call.To,
+			Value:               "0",
+			Data:                element.Call.Data,
+			BlockNumberDelay:    element.BlockNumberDelay,
+			BlockTimestampDelay: element.BlockTimestampDelay,
+		}
+		if element.Call.Value != nil {
+			reportCall.Value = element.Call.Value.String()
+		}
+		if element.Contract != nil {
+			reportCall.ContractName = element.Contract.Name()
+		}
+
+		// Decode the arguments of the call, if it was made through the function's ABI.
+		if abiValues := element.Call.DataAbiValues; abiValues != nil && abiValues.Method != nil {
+			reportCall.Function = abiValues.Method.Sig
+			encodedArgs, err := valuegeneration.EncodeJSONArgumentsToSlice(abiValues.Method.Inputs, abiValues.InputValues)
+			if err == nil {
+				for i, input := range abiValues.Method.Inputs {
+					reportCall.Arguments = append(reportCall.Arguments, ResultsReportArgument{
+						Name:  input.Name,
+						Type:  input.Type.String(),
+						Value: encodedArgs[i],
+					})
+				}
+			}
+		}
+		reportCalls = append(reportCalls, reportCall)
+	}
+	return reportCalls
+}
+
+// resultsReportSourceLocation resolves the source location of the instruction at the provided program counter of
+// the runtime bytecode of the provided contract.
+// Returns the source location, or nil if it could not be resolved.
+func resultsReportSourceLocation(contract *fuzzerTypes.Contract, pc uint64) *ResultsReportSourceLocation {
+	compilation := contract.Compilation()
+	if compilation == nil {
+		return nil
+	}
+	sourceMap, err := compilationTypes.ParseSourceMap(contract.CompiledContract().SrcMapsRuntime)
+	if err != nil {
+		return nil
+	}
+
+	// Find the source map element of the instruction at the program counter, by its index.
+	for index, offset := range coverage.GetInstructionIndexToOffsetLookup(contract.CompiledContract().RuntimeBytecode) {
+		if uint64(offset) != pc {
+			continue
+		}
+		if index >= len(sourceMap) {
+			return nil
+		}
+		sourceMapElement := sourceMap[index]
+		sourcePath, ok := compilation.SourceIdToPath[sourceMapElement.SourceUnitID]
+		if !ok {
+			return nil
+		}
+		sourceCode, ok := compilation.SourceCode[sourcePath]
+		if !ok || sourceCode == nil {
+			if sourceCode, err = os.ReadFile(sourcePath); err != nil {
+				return nil
+			}
+		}
+		start := sourceMapElement.Offset
+		if start < 0 || start > len(sourceCode) {
+			return nil
+		}
+		return &ResultsReportSourceLocation{
+			File:   sourcePath,
+			Line:   bytes.Count(sourceCode[:start], []byte("\n")) + 1,
+			Column: start - bytes.LastIndexByte(sourceCode[:start], '\n'),
+		}
+	}
+	return nil
+}
+
+// resultsReport collects the results of the fuzzing campaign. It must only be called once all workers exited.
+func (f *Fuzzer) resultsReport() *ResultsReport {
+	report := &ResultsReport{
+		Bugs:        make([]ResultsReportBug, 0),
+		FailedTests: make([]ResultsReportTest, 0),
+		Metrics:     f.sampleCoverageTimeSeries(),
+	}
+	if !f.startTime.IsZero() {
+		report.Metrics.ElapsedSeconds = uint64(time.Since(f.startTime).Seconds())
+	}
+
+	// Test cases are sorted by status and ID once the campaign printed its results.
+	reportedBugIds := make(map[string]bool)
+	for _, testCase := range f.testCases {
+		if bugTestCase, ok := testCase.(*BugDetectorTestCase); ok {
+			reportedBugIds[bugTestCase.bugId] = true
+			bug := ResultsReportBug{
+				ID:             bugTestCase.bugId,
+				BugLocation:    bugdetector.ParseBugID(bugTestCase.bugId),
+				StateVariable:  bugTestCase.stateVariable,
+				Status:         bugTestCase.status,
+				CallSequence:   newResultsReportCallSequence(bugTestCase.callSequence),
+				Exploitability: newResultsReportExploitability(bugTestCase),
+			}
+			if bugTestCase.bugValue != nil {
+				bug.TaintedValue = bugTestCase.bugValue.Hex()
+			}
+			if bugTestCase.contract != nil {
+				bug.ContractName = bugTestCase.contract.Name()
+				if bug.Pc != nil {
+					bug.Source = resultsReportSourceLocation(bugTestCase.contract, *bug.Pc)
+				}
+			}
+			report.Bugs = append(report.Bugs, bug)
+		} else if propertyTestCase, ok := testCase.(*PropertyTestCase); ok && propertyTestCase.bugId != "" {
+			reportedBugIds[propertyTestCase.bugId] = true
+			report.Bugs = append(report.Bugs, ResultsReportBug{
+				ID:           propertyTestCase.bugId,
+				BugLocation:  bugdetector.ParseBugID(propertyTestCase.bugId),
+				ContractName: propertyTestCase.targetContract.Name(),
+				Status:       propertyTestCase.status,
+				CallSequence: newResultsReportCallSequence(propertyTestCase.callSequence),
+			})
+		} else if testCase.Status() == TestCaseStatusFailed {
+			report.FailedTests = append(report.FailedTests, ResultsReportTest{
+				ID:           testCase.ID(),
+				Name:         testCase.Name(),
+				CallSequence: newResultsReportCallSequence(testCase.CallSequence()),
+			})
+		}
+	}
+
+	// Bugs only have test cases if their call sequences are shrunk, so report the other bugs the bug detector
+	// recorded, without a call sequence.
+	if f.config.Fuzzing.UseBugDetector() && f.corpus != nil {
+		bugMap := f.corpus.BugMap()
+		for _, bugId := range bugMap.BugIDs() {
+			if reportedBugIds[bugId] {
+				continue
+			}
+			bug := ResultsReportBug{
+				ID:          bugId,
+				BugLocation: bugdetector.ParseBugID(bugId),
+				Status:      TestCaseStatusFailed,
+			}
+			if bug.Address != nil && bug.Slot != nil {
+				bug.StateVariable = f.StateVariableName(*bug.Address, *bug.Slot)
+			}
+			if bugValue := bugMap.BugValue(bugId); bugValue != nil {
+				bug.TaintedValue = bugValue.Hex()
+			}
+			report.Bugs = append(report.Bugs, bug)
+		}
+	}
+	sort.SliceStable(report.Bugs, func(i, j int) bool {
+		return report.Bugs[i].ID < report.Bugs[j].ID
+	})
+	report.Findings = groupBugFindings(report.Bugs)
+	return report
+}
+
+// writeResultsReport writes the results of the fuzzing campaign to a results.json file in the corpus directory (or
+// crytic-export if unset), if enabled. It must only be called once all workers exited.
+func (f *Fuzzer) writeResultsReport() {
+	if !f.config.Fuzzing.ResultsFileEnabled {
+		return
+	}
+	path := filepath.Join(f.coverageTimeSeriesDirectory(), resultsReportFileName)
+	b, err := json.MarshalIndent(f.resultsReport(), "", "\t")
+	if err == nil {
+		err = utils.MakeDirectory(filepath.Dir(path))
+	}
+	if err == nil {
+		err = os.WriteFile(path, b, 0644)
+	}
+	if err != nil {
+		f.logger.Error("Failed to write the results file", err)
+		return
+	}
+	f.logger.Info("JSON results saved to: ", path)
+}
@@ -0,0 +1,151 @@
+package fuzzing
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/crytic/medusa/fuzzing/coverage"
+)
+
+// resultsReportFileName describes the name of the file the results of a campaign are written to.
+const resultsReportFileName = "results.json"
+
+// ReadResultsReport reads the results of a campaign from a results file, or the results file in a campaign directory
+// (the corpus directory, or crytic-export if it was unset).
+// Returns the results, or an error if they could not be read.
+func ReadResultsReport(path string) (*ResultsReport, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read results file at %v: %v", path, err)
+	}
+	if info.IsDir() {
+		path = filepath.Join(path, resultsReportFileName)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read results file at %v: %v", path, err)
+	}
+	var report ResultsReport
+	err = json.Unmarshal(b, &report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse results file at %v: %v", path, err)
+	}
+	return &report, nil
+}
+
+// CampaignComparison describes the differences between the results of a base campaign and a new campaign, so that
+// changes to bug detectors or schedulers can be evaluated objectively.
+type CampaignComparison struct {
+	// NewBugIDs describes the IDs of the bugs only found by the new campaign, sorted.
+	NewBugIDs []string `json:"newBugIds"`
+
+	// MissingBugIDs describes the IDs of the bugs only found by the base campaign, sorted.
+	MissingBugIDs []string `json:"missingBugIds"`
+
+	// NewFindings describes the root causes of the findings only reported by the new campaign, sorted.
+	NewFindings []string `json:"newFindings"`
+
+	// MissingFindings describes the root causes of the findings only reported by the base campaign, sorted.
+	MissingFindings []string `json:"missingFindings"`
+
+	// MetricDeltas describes the final totals of the fuzzing metrics of both campaigns.
+	MetricDeltas []CampaignMetricDelta `json:"metricDeltas"`
+
+	// CoverageDiffs describes the branches and instructions each contract covered in one campaign but not the other,
+	// or nil if the coverage dumps of both campaigns were not provided.
+	CoverageDiffs []coverage.ContractCoverageDiff `json:"coverageDiffs"`
+}
+
+// CampaignMetricDelta describes the final total of a fuzzing metric in two campaigns.
+type CampaignMetricDelta struct {
+	// Name describes the name of the metric, as written in results files.
+	Name string `json:"name"`
+
+	// Base describes the total of the metric in the base campaign.
+	Base int64 `json:"base"`
+
+	// New describes the total of the metric in the new campaign.
+	New int64 `json:"new"`
+
+	// Delta describes the difference between the totals of the new and base campaigns.
+	Delta int64 `json:"delta"`
+}
+
+// CompareCampaigns compares the results of a base campaign with those of a new campaign, along with their coverage
+// dumps if both are provided.
+// Returns the comparison of both campaigns.
+func CompareCampaigns(base *ResultsReport, new *ResultsReport, baseCoverage *coverage.CoverageDump, newCoverage *coverage.CoverageDump) *CampaignComparison {
+	baseBugIds, newBugIds := make([]string, 0), make([]string, 0)
+	for _, bug := range base.Bugs {
+		baseBugIds = append(baseBugIds, bug.ID)
+	}
+	for _, bug := range new.Bugs {
+		newBugIds = append(newBugIds, bug.ID)
+	}
+	baseFindings, newFindings := make([]string, 0), make([]string, 0)
+	for _, finding := range base.Findings {
+		baseFindings = append(baseFindings, finding.RootCause)
+	}
+	for _, finding := range new.Findings {
+		newFindings = append(newFindings, finding.RootCause)
+	}
+
+	comparison := &CampaignComparison{
+		NewBugIDs:       subtractStrings(newBugIds, baseBugIds),
+		MissingBugIDs:   subtractStrings(baseBugIds, newBugIds),
+		NewFindings:     subtractStrings(newFindings, baseFindings),
+		MissingFindings: subtractStrings(baseFindings, newFindings),
+		MetricDeltas:    make([]CampaignMetricDelta, 0),
+	}
+
+	// Compare the final totals of each metric. Campaigns may have run for different durations, which is reported
+	// along with them.
+	metricTotals := func(metrics coverageTimeSeriesSample) []int64 {
+		return []int64{
+			int64(metrics.ElapsedSeconds),
+			int64(metrics.CallsTested),
+			int64(metrics.SequencesTested),
+			int64(metrics.CoveredInstructions),
+			int64(metrics.CoveredBranches),
+			int64(metrics.Dataflows),
+			int64(metrics.Tokenflows),
+			int64(metrics.BugsFound),
+		}
+	}
+	metricNames := []string{"elapsedSeconds", "callsTested", "sequencesTested", "coveredInstructions", "coveredBranches", "dataflows", "tokenflows", "bugsFound"}
+	baseTotals, newTotals := metricTotals(base.Metrics), metricTotals(new.Metrics)
+	for i, name := range metricNames {
+		comparison.MetricDeltas = append(comparison.MetricDeltas, CampaignMetricDelta{
+			Name:  name,
+			Base:  baseTotals[i],
+			New:   newTotals[i],
+			Delta: newTotals[i] - baseTotals[i],
+		})
+	}
+
+	if baseCoverage != nil && newCoverage != nil {
+		comparison.CoverageDiffs = coverage.DiffCoverageDumps(baseCoverage, newCoverage)
+	}
+	return comparison
+}
+
+// subtractStrings returns the distinct strings of a which are not in b, sorted.
+func subtractStrings(a []string, b []string) []string {
+	bStrings := make(map[string]struct{}, len(b))
+	for _, s := range b {
+		bStrings[s] = struct{}{}
+	}
+	result := make([]string, 0)
+	for _, s := range a {
+		if _, ok := bStrings[s]; !ok {
+			bStrings[s] = struct{}{}
+			result = append(result, s)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,77 @@
+package fuzzing
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/crytic/medusa/fuzzing/bugdetector"
+	"github.com/crytic/medusa/fuzzing/coverage"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCompareCampaigns ensures the results of two campaigns read from their directories are compared: bugs and
+// findings found by only one of them, the deltas of their metrics, and the branches covered by only one of them.
+func TestCompareCampaigns(t *testing.T) {
+	writeResults := func(bugIds []string, coveredBranches uint64) string {
+		report := &ResultsReport{Bugs: make([]ResultsReportBug, 0)}
+		for _, bugId := range bugIds {
+			report.Bugs = append(report.Bugs, ResultsReportBug{ID: bugId, BugLocation: bugdetector.ParseBugID(bugId), Status: TestCaseStatusFailed})
+		}
+		report.Findings = groupBugFindings(report.Bugs)
+		report.Metrics.CoveredBranches = coveredBranches
+		report.Metrics.BugsFound = len(bugIds)
+
+		dir := t.TempDir()
+		b, err := json.Marshal(report)
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, resultsReportFileName), b, 0644))
+		return dir
+	}
+	baseDir := writeResults([]string{
+		"OVERFLOW-0x0000000000000000000000000000000000001000-42-ADD",
+		"REENTRANCY-0x0000000000000000000000000000000000001000-10-CALL",
+	}, 10)
+	newDir := writeResults([]string{
+		"OVERFLOW-0x0000000000000000000000000000000000001000-42-ADD",
+		"ETHERLEAKING-0x0000000000000000000000000000000000001000",
+	}, 14)
+
+	base, err := ReadResultsReport(baseDir)
+	assert.NoError(t, err)
+	new, err := ReadResultsReport(filepath.Join(newDir, resultsReportFileName))
+	assert.NoError(t, err)
+	_, err = ReadResultsReport(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+
+	baseCoverage := &coverage.CoverageDump{Contracts: []coverage.ContractCoverageDump{{
+		Contract: "Vault",
+		Branches: []coverage.BranchCoverageDumpEntry{{Pc: 1}, {Pc: 2}},
+	}}}
+	newCoverage := &coverage.CoverageDump{Contracts: []coverage.ContractCoverageDump{{
+		Contract: "Vault",
+		Branches: []coverage.BranchCoverageDumpEntry{{Pc: 2}, {Pc: 3}},
+	}}}
+	comparison := CompareCampaigns(base, new, baseCoverage, newCoverage)
+	assert.EqualValues(t, []string{"ETHERLEAKING-0x0000000000000000000000000000000000001000"}, comparison.NewBugIDs)
+	assert.EqualValues(t, []string{"REENTRANCY-0x0000000000000000000000000000000000001000-10-CALL"}, comparison.MissingBugIDs)
+	assert.Len(t, comparison.NewFindings, 1)
+	assert.Len(t, comparison.MissingFindings, 1)
+	for _, metric := range comparison.MetricDeltas {
+		switch metric.Name {
+		case "coveredBranches":
+			assert.EqualValues(t, CampaignMetricDelta{Name: "coveredBranches", Base: 10, New: 14, Delta: 4}, metric)
+		case "bugsFound":
+			assert.EqualValues(t, 0, metric.Delta)
+		}
+	}
+
+	// Newly covered branches and regressions should be listed by contract.
+	assert.Len(t, comparison.CoverageDiffs, 1)
+	assert.EqualValues(t, 3, comparison.CoverageDiffs[0].AddedBranches[0].Pc)
+	assert.EqualValues(t, 1, comparison.CoverageDiffs[0].RemovedBranches[0].Pc)
+
+	// Coverage should not be compared without both coverage dumps.
+	assert.Nil(t, CompareCampaigns(base, new, baseCoverage, nil).CoverageDiffs)
+}
@@ -0,0 +1,82 @@
+package fuzzing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/crytic/medusa-geth/accounts/abi"
+	"github.com/crytic/medusa-geth/common"
+	compilationTypes "github.com/crytic/medusa/compilation/types"
+	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/fuzzing/config"
+	fuzzerTypes "github.com/crytic/medusa/fuzzing/contracts"
+	fuzzingCorpus "github.com/crytic/medusa/fuzzing/corpus"
+	"github.com/crytic/medusa/logging"
+	"github.com/holiman/uint256"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestResultsReport ensures the results report lists every bug recorded by the bug detector, including those which
+// have no test case because their call sequences are not shrunk, along with the failed test cases, and measures the
+// elapsed time from the start of the fuzzer's campaign.
+func TestResultsReport(t *testing.T) {
+	projectConfig, err := config.GetDefaultProjectConfig("")
+	assert.NoError(t, err)
+	projectConfig.Fuzzing.BugDetectionConfig.Enabled = true
+	corpus, err := fuzzingCorpus.NewCorpus("", &projectConfig.Fuzzing)
+	assert.NoError(t, err)
+	fuzzer := &Fuzzer{
+		config:         *projectConfig,
+		corpus:         corpus,
+		metrics:        newFuzzerMetrics(1, nil, &projectConfig.Fuzzing),
+		logger:         logging.NewLogger(zerolog.Disabled),
+		storageLayouts: newStorageLayoutRegistry(),
+		startTime:      time.Now().Add(-time.Minute),
+	}
+	defer fuzzer.metrics.stopIndicatorAggregator()
+
+	// A shrunk bug with a test case, and two bugs without, one of which recorded its tainted value.
+	address := common.HexToAddress("0x1000").Hex()
+	shrunkBugId := "REENTRANCY-" + address + "-12"
+	valueBugId := "UNSAFEDELEGATECALL-" + address + "-34"
+	overflowBugId := "OVERFLOW-" + address + "-56-ADD"
+	bugMap := corpus.BugMap()
+	for _, bugId := range []string{shrunkBugId, overflowBugId} {
+		_, err = bugMap.CoverBug(bugId)
+		assert.NoError(t, err)
+	}
+	_, err = bugMap.CoverBugWithValue(valueBugId, uint256.NewInt(0x1234))
+	assert.NoError(t, err)
+	fuzzer.testCases = []TestCase{
+		&BugDetectorTestCase{status: TestCaseStatusFailed, bugId: shrunkBugId, callSequence: &calls.CallSequence{}},
+		&PropertyTestCase{status: TestCaseStatusPassed},
+		&AssertionTestCase{
+			status:         TestCaseStatusFailed,
+			targetContract: fuzzerTypes.NewContract("Vault", "src/Vault.sol", &compilationTypes.CompiledContract{}, nil),
+			targetMethod:   abi.Method{Sig: "withdraw()"},
+		},
+	}
+
+	report := fuzzer.resultsReport()
+	assert.GreaterOrEqual(t, report.Metrics.ElapsedSeconds, uint64(60))
+	assert.Len(t, report.Bugs, 3)
+	assert.Len(t, report.FailedTests, 1)
+	assert.EqualValues(t, "Assertion Test: Vault.withdraw()", report.FailedTests[0].Name)
+
+	// Bugs are sorted by ID, and only the shrunk one has a call sequence.
+	assert.EqualValues(t, overflowBugId, report.Bugs[0].ID)
+	assert.EqualValues(t, "OVERFLOW", report.Bugs[0].Type)
+	assert.EqualValues(t, 56, *report.Bugs[0].Pc)
+	assert.EqualValues(t, TestCaseStatusFailed, report.Bugs[0].Status)
+	assert.Nil(t, report.Bugs[0].CallSequence)
+	assert.EqualValues(t, shrunkBugId, report.Bugs[1].ID)
+	assert.NotNil(t, report.Bugs[1].CallSequence)
+	assert.EqualValues(t, valueBugId, report.Bugs[2].ID)
+	assert.EqualValues(t, "0x1234", report.Bugs[2].TaintedValue)
+
+	// Bugs recorded without the bug detector enabled are not reported.
+	fuzzer.config.Fuzzing.BugDetectionConfig.Enabled = false
+	report = fuzzer.resultsReport()
+	assert.Len(t, report.Bugs, 1)
+}
@@ -0,0 +1,72 @@
+package fuzzing
+
+import (
+	"github.com/crytic/medusa/chain"
+	"github.com/crytic/medusa/chain/rpcserver"
+	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/logging/colors"
+)
+
+// startRPCServer exposes a clone of the provided base test chain over JSON-RPC, if enabled by the project
+// configuration. The base test chain is cloned and mutated by the campaign, so the server is given its own snapshot
+// of it rather than sharing it. Returns the started server, or nil if it is disabled or could not be started.
+func (f *Fuzzer) startRPCServer(baseTestChain *chain.TestChain) *rpcserver.Server {
+	serverConfig := f.config.Fuzzing.RPCServerConfig
+	if !serverConfig.Enabled {
+		return nil
+	}
+
+	servedChain, err := baseTestChain.Clone(nil)
+	if err != nil {
+		f.logger.Error("Failed to clone the test chain to serve over JSON-RPC", err)
+		return nil
+	}
+	server, err := rpcserver.NewServer(servedChain)
+	if err != nil {
+		f.logger.Error("Failed to create the JSON-RPC server", err)
+		return nil
+	}
+	err = server.Start(serverConfig.Address)
+	if err != nil {
+		f.logger.Error("Failed to start the JSON-RPC server", err)
+		return nil
+	}
+
+	f.logger.Info("Serving the test chain over JSON-RPC at ", colors.Bold, "http://", server.Address(), colors.Reset)
+	return server
+}
+
+// serveRPCServerAfterCampaign replays every failing call sequence onto a clone of the base test chain, serves the
+// resulting chain, and blocks until the fuzzer is terminated. This allows reproducers to be inspected interactively.
+func (f *Fuzzer) serveRPCServerAfterCampaign(server *rpcserver.Server, baseTestChain *chain.TestChain) {
+	if server == nil || !f.config.Fuzzing.RPCServerConfig.ServeAfterCampaign {
+		return
+	}
+
+	replayChain, err := baseTestChain.Clone(nil)
+	if err != nil {
+		f.logger.Error("Failed to clone the test chain to replay failing call sequences", err)
+		return
+	}
+
+	for _, testCase := range f.TestCasesWithStatus(TestCaseStatusFailed) {
+		if testCase.CallSequence() == nil {
+			continue
+		}
+		callSequence, err := testCase.CallSequence().Clone()
+		if err != nil {
+			f.logger.Error("Failed to clone the call sequence for test ", testCase.Name(), err)
+			continue
+		}
+		_, err = calls.ExecuteCallSequence(replayChain, callSequence)
+		if err != nil {
+			f.logger.Error("Failed to replay the call sequence for test ", testCase.Name(), err)
+			continue
+		}
+		f.logger.Info("Replayed the failing call sequence for test ", colors.Bold, testCase.Name(), colors.Reset, " up to block ", replayChain.HeadBlockNumber())
+	}
+	server.SetTestChain(replayChain)
+
+	f.logger.Info("Campaign finished, still serving the test chain over JSON-RPC at ", colors.Bold, "http://", server.Address(), colors.Reset, " (interrupt to exit)")
+	<-f.emergencyCtx.Done()
+}
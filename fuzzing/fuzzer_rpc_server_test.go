@@ -0,0 +1,44 @@
+package fuzzing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/crytic/medusa-geth/common/hexutil"
+	"github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa-geth/rpc"
+	"github.com/crytic/medusa/chain"
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/crytic/medusa/logging"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestStartRPCServerServesClone ensures the JSON-RPC server serves a snapshot of the base test chain, so blocks
+// committed to the base test chain once the server started are not observed by it.
+func TestStartRPCServerServesClone(t *testing.T) {
+	projectConfig, err := config.GetDefaultProjectConfig("")
+	assert.NoError(t, err)
+	projectConfig.Fuzzing.RPCServerConfig.Enabled = true
+	projectConfig.Fuzzing.RPCServerConfig.Address = "127.0.0.1:0"
+	fuzzer := &Fuzzer{config: *projectConfig, logger: logging.NewLogger(zerolog.Disabled)}
+
+	baseTestChain, err := chain.NewTestChain(context.Background(), types.GenesisAlloc{}, nil)
+	assert.NoError(t, err)
+	server := fuzzer.startRPCServer(baseTestChain)
+	assert.NotNil(t, server)
+	defer server.Close()
+
+	// Commit a block to the base test chain after the server started.
+	_, err = baseTestChain.PendingBlockCreate()
+	assert.NoError(t, err)
+	assert.NoError(t, baseTestChain.PendingBlockCommit())
+	assert.EqualValues(t, 1, baseTestChain.HeadBlockNumber())
+
+	client, err := rpc.Dial("http://" + server.Address())
+	assert.NoError(t, err)
+	defer client.Close()
+	var blockNumber hexutil.Uint64
+	assert.NoError(t, client.Call(&blockNumber, "eth_blockNumber"))
+	assert.EqualValues(t, 0, blockNumber)
+}
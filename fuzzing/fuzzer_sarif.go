@@ -0,0 +1,280 @@
+package fuzzing
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/crytic/medusa/utils"
+)
+
+// sarifSchema and sarifVersion describe the version of the SARIF format the SARIF file is written in.
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+// sarifRuleDefinition describes a rule findings of a bug type are reported under, in a SARIF file.
+type sarifRuleDefinition struct {
+	// name describes the human-readable name of the rule.
+	name string
+
+	// description describes the bug type the rule reports.
+	description string
+
+	// level describes the SARIF level findings of the rule are reported with.
+	level string
+}
+
+// sarifRuleDefinitions describes the rules bugs are reported under, by bug type. Bugs of other types are reported
+// under a generic rule.
+var sarifRuleDefinitions = map[string]sarifRuleDefinition{
+	"REENTRANCY":               {name: "Reentrancy", description: "State read before an external call is written after it, allowing the callee to reenter with stale state.", level: "error"},
+	"OVERFLOW":                 {name: "IntegerOverflow", description: "An arithmetic operation on attacker-influenced values overflowed or underflowed.", level: "error"},
+	"SUICIDAL":                 {name: "Suicidal", description: "A contract can be self-destructed by an arbitrary sender.", level: "error"},
+	"ETHERLEAKING":             {name: "EtherLeaking", description: "Ether was transferred to an arbitrary sender who never deposited it.", level: "error"},
+	"UNSAFEDELEGATECALL":       {name: "UnsafeDelegatecall", description: "The target of a DELEGATECALL is controlled by an arbitrary sender.", level: "error"},
+	"BLOCKDEPENDENCY":          {name: "BlockDependency", description: "A branch or value transfer depends on block properties which can be influenced by block producers.", level: "warning"},
+	"UNINITIALIZEDSTORAGEREAD": {name: "UninitializedStorageRead", description: "A state variable was read before it was ever written.", level: "warning"},
+	"PROPERTYVIOLATION":        {name: "PropertyViolation", description: "A user-defined property test registered as an oracle failed.", level: "error"},
+}
+
+// sarifSeverityLevels describes the SARIF level findings are reported with, by severity.
+var sarifSeverityLevels = map[FindingSeverity]string{
+	FindingSeverityCritical: "error",
+	FindingSeverityHigh:     "error",
+	FindingSeverityMedium:   "warning",
+	FindingSeverityLow:      "note",
+}
+
+// sarifLog describes the root object of a SARIF file.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+// sarifRun describes a single run of an analysis tool, and the results it reported.
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+// sarifTool describes the analysis tool which produced a run.
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+// sarifDriver describes the component of the analysis tool which reported the results, and its rules.
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationUri string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+// sarifRule describes a rule results are reported under.
+type sarifRule struct {
+	ID                   string                 `json:"id"`
+	Name                 string                 `json:"name"`
+	ShortDescription     sarifMessage           `json:"shortDescription"`
+	DefaultConfiguration sarifRuleConfiguration `json:"defaultConfiguration"`
+}
+
+// sarifRuleConfiguration describes the default configuration of a rule.
+type sarifRuleConfiguration struct {
+	Level string `json:"level"`
+}
+
+// sarifMessage describes a message of a SARIF file.
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+// sarifResult describes a finding reported under a rule.
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations,omitempty"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	Properties          map[string]any    `json:"properties,omitempty"`
+}
+
+// sarifLocation describes the location of a finding.
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+// sarifPhysicalLocation describes the location of a finding within a file.
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+// sarifArtifactLocation describes the file a finding is located in.
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// sarifRegion describes the region of a file a finding is located in.
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+// String returns a human-readable description of the call, as used in the reproducers of SARIF results.
+func (c ResultsReportCall) String() string {
+	target := "<contract creation>"
+	if c.To != nil {
+		target = c.To.Hex()
+	}
+	if c.ContractName != "" {
+		target = c.ContractName
+	}
+	if c.Function == "" {
+		return fmt.Sprintf("%s(data=%s) (sender=%s, value=%s)", target, c.Data, c.From.Hex(), c.Value)
+	}
+
+	// Describe the call with the name of its function and its arguments.
+	functionName, _, _ := strings.Cut(c.Function, "(")
+	args := make([]string, 0, len(c.Arguments))
+	for _, arg := range c.Arguments {
+		b, err := json.Marshal(arg.Value)
+		if err != nil {
+			b = []byte("<unresolved arg>")
+		}
+		args = append(args, string(b))
+	}
+	return fmt.Sprintf("%s.%s(%s) (sender=%s, value=%s)", target, functionName, strings.Join(args, ", "), c.From.Hex(), c.Value)
+}
+
+// sarifURI returns the URI of the provided source file in a SARIF file, relative to the working directory if the
+// file is within it, so that code scanning tools can resolve it against the repository.
+func sarifURI(sourcePath string) string {
+	if workingDirectory, err := os.Getwd(); err == nil && filepath.IsAbs(sourcePath) {
+		if relativePath, err := filepath.Rel(workingDirectory, sourcePath); err == nil && !strings.HasPrefix(relativePath, "..") {
+			sourcePath = relativePath
+		}
+	}
+	return filepath.ToSlash(sourcePath)
+}
+
+// newSARIFLog returns a SARIF log reporting the findings of the provided results report, each under the rule of its
+// bug type, along with the source location and reproducing call sequence of its primary bug.
+func newSARIFLog(report *ResultsReport) *sarifLog {
+	rules := make(map[string]sarifRule)
+	results := make([]sarifResult, 0, len(report.Findings))
+	for _, finding := range report.Findings {
+		bug := finding.primary
+		// Obtain the rule of the bug type, adding it to the rules reported upon its first finding.
+		ruleDefinition, ok := sarifRuleDefinitions[bug.Type]
+		if !ok {
+			ruleDefinition = sarifRuleDefinition{name: bug.Type, description: "A bug detected by the bug detector.", level: "warning"}
+		}
+		if _, ok := rules[bug.Type]; !ok {
+			rules[bug.Type] = sarifRule{
+				ID:                   bug.Type,
+				Name:                 ruleDefinition.name,
+				ShortDescription:     sarifMessage{Text: ruleDefinition.description},
+				DefaultConfiguration: sarifRuleConfiguration{Level: ruleDefinition.level},
+			}
+		}
+
+		// Describe the finding, along with the call sequence reproducing it.
+		var message strings.Builder
+		message.WriteString(ruleDefinition.description)
+		if bug.ContractName != "" {
+			message.WriteString(fmt.Sprintf(" Detected in %s", bug.ContractName))
+			if bug.Opcode != "" {
+				message.WriteString(fmt.Sprintf(" at %s", bug.Opcode))
+			}
+			message.WriteString(".")
+		}
+		if bug.StateVariable != "" {
+			message.WriteString(fmt.Sprintf(" State variable: %s.", bug.StateVariable))
+		}
+		if len(finding.Annotations) > 0 {
+			message.WriteString(fmt.Sprintf(" Severity: %s (%s).", finding.Severity, strings.Join(finding.Annotations, ", ")))
+		} else {
+			message.WriteString(fmt.Sprintf(" Severity: %s.", finding.Severity))
+		}
+		if len(bug.CallSequence) > 0 {
+			message.WriteString("\nReproducer:")
+			for i, call := range bug.CallSequence {
+				message.WriteString(fmt.Sprintf("\n%d) %s", i+1, call.String()))
+			}
+		}
+
+		result := sarifResult{
+			RuleID:              bug.Type,
+			Level:               sarifSeverityLevels[finding.Severity],
+			Message:             sarifMessage{Text: message.String()},
+			PartialFingerprints: map[string]string{"medusaRootCause": finding.RootCause},
+			Properties: map[string]any{
+				"bugId":        bug.ID,
+				"bugIds":       finding.BugIDs,
+				"occurrences":  finding.Occurrences,
+				"severity":     finding.Severity,
+				"score":        finding.Score,
+				"annotations":  finding.Annotations,
+				"callSequence": bug.CallSequence,
+			},
+		}
+		if bug.Source != nil {
+			result.Locations = []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: sarifURI(bug.Source.File)},
+					Region:           sarifRegion{StartLine: bug.Source.Line, StartColumn: bug.Source.Column},
+				},
+			}}
+		}
+		results = append(results, result)
+	}
+
+	// Sort the rules by ID, so the file is deterministic.
+	sortedRules := make([]sarifRule, 0, len(rules))
+	for _, rule := range rules {
+		sortedRules = append(sortedRules, rule)
+	}
+	sort.Slice(sortedRules, func(i, j int) bool {
+		return sortedRules[i].ID < sortedRules[j].ID
+	})
+
+	return &sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "medusa",
+				InformationUri: "https://github.com/crytic/medusa",
+				Rules:          sortedRules,
+			}},
+			Results: results,
+		}},
+	}
+}
+
+// writeSARIFReport writes the bugs found over the fuzzing campaign, grouped by root cause, to a results.sarif file in
+// the corpus directory (or crytic-export if unset), if enabled, so that code scanning tools can ingest them. It must
+// only be called once all workers exited.
+func (f *Fuzzer) writeSARIFReport() {
+	if !f.config.Fuzzing.SARIFFileEnabled {
+		return
+	}
+	path := filepath.Join(f.coverageTimeSeriesDirectory(), "results.sarif")
+	b, err := json.MarshalIndent(newSARIFLog(f.resultsReport()), "", "\t")
+	if err == nil {
+		err = utils.MakeDirectory(filepath.Dir(path))
+	}
+	if err == nil {
+		err = os.WriteFile(path, b, 0644)
+	}
+	if err != nil {
+		f.logger.Error("Failed to write the SARIF file", err)
+		return
+	}
+	f.logger.Info("SARIF results saved to: ", path)
+}
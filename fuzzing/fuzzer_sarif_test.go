@@ -0,0 +1,69 @@
+package fuzzing
+
+import (
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/fuzzing/bugdetector"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSARIFLog ensures bugs are reported as SARIF results under the rule of their bug type, located at their source
+// line, with their reproducing call sequence in their message.
+func TestSARIFLog(t *testing.T) {
+	contractAddress := common.HexToAddress("0x1000")
+	report := &ResultsReport{
+		Bugs: []ResultsReportBug{
+			{
+				ID:           "OVERFLOW-0x0000000000000000000000000000000000001000-42-ADD",
+				BugLocation:  bugdetector.ParseBugID("OVERFLOW-0x0000000000000000000000000000000000001000-42-ADD"),
+				ContractName: "Vault",
+				Source:       &ResultsReportSourceLocation{File: "src/Vault.sol", Line: 12, Column: 5},
+				Status:       TestCaseStatusFailed,
+				CallSequence: []ResultsReportCall{{
+					To:           &contractAddress,
+					ContractName: "Vault",
+					Function:     "deposit(uint256)",
+					Arguments:    []ResultsReportArgument{{Name: "amount", Type: "uint256", Value: "115792089237316195423570985008687907853269984665640564039457584007913129639935"}},
+					Value:        "0",
+				}},
+			},
+			{
+				ID:          "ETHERLEAKING-0x0000000000000000000000000000000000010000",
+				BugLocation: bugdetector.ParseBugID("ETHERLEAKING-0x0000000000000000000000000000000000010000"),
+				Status:      TestCaseStatusRunning,
+			},
+		},
+	}
+
+	// The bug IDs should be decoded into their locations.
+	assert.EqualValues(t, "OVERFLOW", report.Bugs[0].Type)
+	assert.EqualValues(t, contractAddress, *report.Bugs[0].Address)
+	assert.EqualValues(t, 42, *report.Bugs[0].Pc)
+	assert.EqualValues(t, "ADD", report.Bugs[0].Opcode)
+	assert.Nil(t, report.Bugs[1].Pc)
+
+	// Each bug type should be reported as a rule, and each finding as a result, sorted by the ID of its primary bug.
+	report.Findings = groupBugFindings(report.Bugs)
+	sarif := newSARIFLog(report)
+	assert.EqualValues(t, sarifVersion, sarif.Version)
+	assert.Len(t, sarif.Runs, 1)
+	rules := sarif.Runs[0].Tool.Driver.Rules
+	assert.Len(t, rules, 2)
+	assert.EqualValues(t, "ETHERLEAKING", rules[0].ID)
+	assert.EqualValues(t, "OVERFLOW", rules[1].ID)
+
+	// The overflow should be located at its source line, with its reproducer in its message.
+	results := sarif.Runs[0].Results
+	assert.Len(t, results, 2)
+	assert.EqualValues(t, "OVERFLOW", results[1].RuleID)
+	assert.EqualValues(t, "warning", results[1].Level)
+	assert.Len(t, results[1].Locations, 1)
+	assert.EqualValues(t, "src/Vault.sol", results[1].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.EqualValues(t, 12, results[1].Locations[0].PhysicalLocation.Region.StartLine)
+	assert.Contains(t, results[1].Message.Text, "Vault.deposit(\"115792089237316195423570985008687907853269984665640564039457584007913129639935\")")
+	assert.EqualValues(t, "OVERFLOW|src/Vault.sol:12:5|", results[1].PartialFingerprints["medusaRootCause"])
+
+	// The ether leak has no source location.
+	assert.Empty(t, results[0].Locations)
+}
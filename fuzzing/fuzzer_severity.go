@@ -0,0 +1,145 @@
+package fuzzing
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/crytic/medusa-geth/common"
+)
+
+// FindingSeverity describes the severity of a finding, as estimated from its bug type and the evidence of its
+// exploitability.
+type FindingSeverity string
+
+const (
+	// FindingSeverityCritical describes findings whose exploitation was observed to move value.
+	FindingSeverityCritical FindingSeverity = "critical"
+	// FindingSeverityHigh describes findings which likely allow value to be stolen or locked.
+	FindingSeverityHigh FindingSeverity = "high"
+	// FindingSeverityMedium describes findings which corrupt state, but were not observed to move value.
+	FindingSeverityMedium FindingSeverity = "medium"
+	// FindingSeverityLow describes findings which are unlikely to be exploitable on their own.
+	FindingSeverityLow FindingSeverity = "low"
+)
+
+// bugTypeBaseScores describes the score findings start from, by bug type, before they are annotated with the evidence
+// of their exploitability. Findings of other types start from the lowest score.
+var bugTypeBaseScores = map[string]int{
+	"ETHERLEAKING":             70,
+	"SUICIDAL":                 70,
+	"UNSAFEDELEGATECALL":       70,
+	"REENTRANCY":               40,
+	"PROPERTYVIOLATION":        40,
+	"OVERFLOW":                 40,
+	"BLOCKDEPENDENCY":          10,
+	"UNINITIALIZEDSTORAGEREAD": 10,
+}
+
+// oneEther describes one ether, in wei.
+var oneEther = new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+
+// ResultsReportExploitability describes the evidence of the exploitability of a bug, observed when replaying its
+// shrunken call sequence.
+type ResultsReportExploitability struct {
+	// EtherGained describes the net amount of ether the tracked addresses gained over the call sequence, in wei, if
+	// balance deltas were tracked.
+	EtherGained string `json:"etherGained,omitempty"`
+
+	// WrittenSlot describes the storage slot written by the bug (e.g. after a reentrant call), if one was recorded.
+	WrittenSlot *common.Hash `json:"writtenSlot,omitempty"`
+
+	// WrittenStateVariable describes the state variable held in WrittenSlot, if it could be named.
+	WrittenStateVariable string `json:"writtenStateVariable,omitempty"`
+
+	// BalanceLikeSlotWritten indicates whether WrittenSlot holds a balance-like state variable, such as an integer
+	// entry of a mapping keyed by address or a variable named after balances.
+	BalanceLikeSlotWritten bool `json:"balanceLikeSlotWritten,omitempty"`
+
+	// ReachedTokenTransfer indicates whether the code the bug was detected in transferred tokens or ether in the
+	// transaction detecting the bug.
+	ReachedTokenTransfer bool `json:"reachedTokenTransfer,omitempty"`
+}
+
+// newResultsReportExploitability returns the evidence of the exploitability of the provided bug test case, or nil if
+// none was observed.
+func newResultsReportExploitability(testCase *BugDetectorTestCase) *ResultsReportExploitability {
+	exploitability := &ResultsReportExploitability{
+		WrittenSlot:          testCase.writtenSlot,
+		WrittenStateVariable: testCase.writtenStateVariable,
+		BalanceLikeSlotWritten: testCase.writtenSlot != nil &&
+			isBalanceLikeStateVariable(testCase.writtenStateVariable, testCase.writtenValueType),
+		ReachedTokenTransfer: testCase.reachedTokenTransfer,
+	}
+	if testCase.etherGained != nil && testCase.etherGained.Sign() > 0 {
+		exploitability.EtherGained = testCase.etherGained.String()
+	}
+	if *exploitability == (ResultsReportExploitability{}) {
+		return nil
+	}
+	return exploitability
+}
+
+// isBalanceLikeStateVariable indicates whether the state variable with the provided name, whose value has the provided
+// type if it is a mapping entry, is likely to hold a balance: an integer entry of a mapping keyed by address (e.g.
+// balances[0x...]), or a variable named after balances.
+func isBalanceLikeStateVariable(name string, valueType string) bool {
+	if strings.Contains(name, "[") && (strings.HasPrefix(valueType, "uint") || strings.HasPrefix(valueType, "int")) {
+		return true
+	}
+	return strings.Contains(strings.ToLower(name), "balance")
+}
+
+// scoreFinding annotates the provided finding with its severity, estimated from the type of its bugs and the evidence
+// of exploitability observed for its primary bug: the ether leaked, whether a reentrant call writes a balance-like
+// slot, and whether an overflowed value reached a token transfer.
+func scoreFinding(finding *ResultsReportFinding) {
+	score, ok := bugTypeBaseScores[finding.Type]
+	if !ok {
+		score = 10
+	}
+	annotations := make([]string, 0)
+
+	if exploitability := finding.primary.Exploitability; exploitability != nil {
+		switch finding.Type {
+		case "ETHERLEAKING":
+			if etherGained, ok := new(big.Int).SetString(exploitability.EtherGained, 10); ok && etherGained.Sign() > 0 {
+				score += 10
+				if etherGained.Cmp(oneEther) >= 0 {
+					score += 10
+				}
+				annotations = append(annotations, fmt.Sprintf("leaks %s wei to tracked addresses", etherGained.String()))
+			}
+		case "REENTRANCY":
+			if exploitability.BalanceLikeSlotWritten {
+				score += 30
+				annotations = append(annotations, fmt.Sprintf("reentrant call writes balance-like %s", exploitability.WrittenStateVariable))
+			} else if exploitability.WrittenStateVariable != "" {
+				annotations = append(annotations, fmt.Sprintf("reentrant call writes %s", exploitability.WrittenStateVariable))
+			}
+		case "OVERFLOW":
+			if exploitability.ReachedTokenTransfer {
+				score += 30
+				annotations = append(annotations, "overflowed value reached a token transfer")
+			}
+		}
+	}
+
+	// Note how many bugs share the finding's root cause.
+	if finding.Occurrences > 1 {
+		annotations = append(annotations, fmt.Sprintf("detected %d times", finding.Occurrences))
+	}
+
+	finding.Score = score
+	finding.Annotations = annotations
+	switch {
+	case score >= 90:
+		finding.Severity = FindingSeverityCritical
+	case score >= 60:
+		finding.Severity = FindingSeverityHigh
+	case score >= 30:
+		finding.Severity = FindingSeverityMedium
+	default:
+		finding.Severity = FindingSeverityLow
+	}
+}
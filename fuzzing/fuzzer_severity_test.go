@@ -0,0 +1,58 @@
+package fuzzing
+
+import (
+	"testing"
+
+	"github.com/crytic/medusa/fuzzing/bugdetector"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestScoreFindings ensures findings are scored from their bug type and the evidence of their exploitability, and
+// sorted from the most to the least severe.
+func TestScoreFindings(t *testing.T) {
+	newBug := func(bugId string, exploitability *ResultsReportExploitability) ResultsReportBug {
+		return ResultsReportBug{
+			ID:             bugId,
+			BugLocation:    bugdetector.ParseBugID(bugId),
+			Status:         TestCaseStatusFailed,
+			CallSequence:   make([]ResultsReportCall, 1),
+			Exploitability: exploitability,
+		}
+	}
+	findings := groupBugFindings([]ResultsReportBug{
+		newBug("BLOCKDEPENDENCY-0x0000000000000000000000000000000000001000-1-TIMESTAMP", nil),
+		newBug("OVERFLOW-0x0000000000000000000000000000000000001000-2-ADD", nil),
+		newBug("OVERFLOW-0x0000000000000000000000000000000000001000-3-SSTORE", &ResultsReportExploitability{ReachedTokenTransfer: true}),
+		newBug("REENTRANCY-0x0000000000000000000000000000000000001000-4-CALL", &ResultsReportExploitability{WrittenStateVariable: "balances[0x10000]", BalanceLikeSlotWritten: true}),
+		newBug("ETHERLEAKING-0x0000000000000000000000000000000000001000", &ResultsReportExploitability{EtherGained: "2000000000000000000"}),
+	})
+
+	severities := make([]FindingSeverity, 0, len(findings))
+	primaryBugIds := make([]string, 0, len(findings))
+	for _, finding := range findings {
+		severities = append(severities, finding.Severity)
+		primaryBugIds = append(primaryBugIds, finding.PrimaryBugID)
+	}
+	assert.EqualValues(t, []FindingSeverity{
+		FindingSeverityCritical,
+		FindingSeverityHigh,
+		FindingSeverityHigh,
+		FindingSeverityMedium,
+		FindingSeverityLow,
+	}, severities)
+	assert.EqualValues(t, []string{
+		"ETHERLEAKING-0x0000000000000000000000000000000000001000",
+		"OVERFLOW-0x0000000000000000000000000000000000001000-3-SSTORE",
+		"REENTRANCY-0x0000000000000000000000000000000000001000-4-CALL",
+		"OVERFLOW-0x0000000000000000000000000000000000001000-2-ADD",
+		"BLOCKDEPENDENCY-0x0000000000000000000000000000000000001000-1-TIMESTAMP",
+	}, primaryBugIds)
+	assert.EqualValues(t, []string{"leaks 2000000000000000000 wei to tracked addresses"}, findings[0].Annotations)
+	assert.EqualValues(t, []string{"reentrant call writes balance-like balances[0x10000]"}, findings[2].Annotations)
+
+	// Integer entries of mappings keyed by address, and variables named after balances, are balance-like.
+	assert.True(t, isBalanceLikeStateVariable("deposits[0x10000]", "uint256"))
+	assert.True(t, isBalanceLikeStateVariable("totalBalance", ""))
+	assert.False(t, isBalanceLikeStateVariable("owners[0x10000]", "bool"))
+	assert.False(t, isBalanceLikeStateVariable("owner", ""))
+}
@@ -0,0 +1,129 @@
+package fuzzing
+
+import (
+	"math/rand"
+
+	"github.com/crytic/medusa/fuzzing/calls"
+)
+
+// addStatefulSnapshot records the provided call history, leading to a persistent chain state, as a snapshot which
+// workers can use as an alternative starting state. The oldest snapshots are discarded once the configured maximum is
+// exceeded.
+func (f *Fuzzer) addStatefulSnapshot(history calls.CallSequence) {
+	f.statefulSnapshotsLock.Lock()
+	defer f.statefulSnapshotsLock.Unlock()
+
+	f.statefulSnapshots = append(f.statefulSnapshots, history)
+	if maxSnapshots := f.config.Fuzzing.StatefulMode.MaxSnapshots; maxSnapshots > 0 && len(f.statefulSnapshots) > maxSnapshots {
+		f.statefulSnapshots = f.statefulSnapshots[len(f.statefulSnapshots)-maxSnapshots:]
+	}
+}
+
+// statefulSnapshot returns a copy of a recorded snapshot, or nil if none exist. If latest is set, the most recent
+// snapshot is returned, otherwise a random one is selected using the provided random provider.
+func (f *Fuzzer) statefulSnapshot(randomProvider *rand.Rand, latest bool) (calls.CallSequence, error) {
+	f.statefulSnapshotsLock.Lock()
+	defer f.statefulSnapshotsLock.Unlock()
+
+	if len(f.statefulSnapshots) == 0 {
+		return nil, nil
+	}
+	index := len(f.statefulSnapshots) - 1
+	if !latest {
+		index = randomProvider.Intn(len(f.statefulSnapshots))
+	}
+	return f.statefulSnapshots[index].Clone()
+}
+
+// isPersistentWorker determines whether the worker executes call sequences against a persistent chain state.
+func (fw *FuzzerWorker) isPersistentWorker() bool {
+	statefulConfig := fw.fuzzer.config.Fuzzing.StatefulMode
+	return statefulConfig.Enabled && fw.workerIndex < statefulConfig.PersistentWorkers
+}
+
+// prepareStatefulPrefix prepares the chain state the next call sequence should be executed on top of, when the
+// stateful mode is enabled. Persistent workers continue from their accumulated history, resuming from the latest
+// snapshot if they have none. Other workers start new call sequences from a random snapshot with the configured
+// probability. Corpus call sequences being replayed always start from the testing base.
+// Returns the calls the chain state reflects on top of the testing base, or an error if one occurred.
+func (fw *FuzzerWorker) prepareStatefulPrefix(isNewSequence bool) (calls.CallSequence, error) {
+	statefulConfig := fw.fuzzer.config.Fuzzing.StatefulMode
+	if !statefulConfig.Enabled {
+		return nil, nil
+	}
+
+	// Corpus call sequences must be replayed against the testing base, so drop any accumulated state.
+	if !isNewSequence {
+		return nil, fw.resetStatefulHistory()
+	}
+
+	if fw.isPersistentWorker() {
+		// If we have accumulated state, continue from it.
+		if len(fw.statefulHistory) > 0 {
+			return fw.statefulHistory, nil
+		}
+		return fw.replayStatefulSnapshot(true)
+	}
+
+	if fw.randomProvider.Float32() < statefulConfig.SnapshotProbability {
+		return fw.replayStatefulSnapshot(false)
+	}
+	return nil, nil
+}
+
+// replayStatefulSnapshot executes a recorded snapshot on top of the testing base. If latest is set, the most recent
+// snapshot is used, otherwise a random one is. A snapshot which fails to replay is not fatal, the chain is simply
+// reset to the testing base.
+// Returns the calls the chain state reflects on top of the testing base, or an error if one occurred.
+func (fw *FuzzerWorker) replayStatefulSnapshot(latest bool) (calls.CallSequence, error) {
+	snapshot, err := fw.fuzzer.statefulSnapshot(fw.randomProvider, latest)
+	if err != nil || snapshot == nil {
+		return nil, err
+	}
+
+	executedSnapshot, err := calls.ExecuteCallSequence(fw.chain, snapshot)
+	if err != nil || len(executedSnapshot) != len(snapshot) {
+		fw.fuzzer.logger.Debug("[Worker ", fw.workerIndex, "] failed to replay a stateful snapshot: ", err)
+		return nil, fw.resetStatefulHistory()
+	}
+	if fw.isPersistentWorker() {
+		fw.statefulHistory = executedSnapshot
+		fw.sequencesSinceSnapshot = 0
+	}
+	return executedSnapshot, nil
+}
+
+// finishStatefulSequence accumulates the provided call sequence, executed on top of the worker's history, into the
+// persistent chain state, periodically recording it as a snapshot. The history is only kept by persistent workers, for
+// new call sequences which did not produce shrink requests and did not exceed the configured history length.
+// Returns a boolean indicating whether the chain state should be kept rather than reverted to the testing base.
+func (fw *FuzzerWorker) finishStatefulSequence(history calls.CallSequence, isNewSequence bool, shrinkRequests []ShrinkCallSequenceRequest) bool {
+	statefulConfig := fw.fuzzer.config.Fuzzing.StatefulMode
+	if !fw.isPersistentWorker() || !isNewSequence || len(shrinkRequests) > 0 || len(history) > statefulConfig.MaxHistoryLength {
+		fw.statefulHistory = nil
+		fw.sequencesSinceSnapshot = 0
+		return false
+	}
+
+	fw.statefulHistory = history
+	fw.sequencesSinceSnapshot++
+	if fw.sequencesSinceSnapshot >= statefulConfig.SnapshotInterval {
+		snapshot, err := history.Clone()
+		if err == nil {
+			fw.fuzzer.addStatefulSnapshot(snapshot)
+		}
+		fw.sequencesSinceSnapshot = 0
+	}
+	return true
+}
+
+// resetStatefulHistory drops any accumulated state by reverting the chain to the testing base.
+// Returns an error if one occurred.
+func (fw *FuzzerWorker) resetStatefulHistory() error {
+	if len(fw.statefulHistory) == 0 {
+		return nil
+	}
+	fw.statefulHistory = nil
+	fw.sequencesSinceSnapshot = 0
+	return fw.chain.RevertToBlockIndex(fw.testingBaseBlockIndex)
+}
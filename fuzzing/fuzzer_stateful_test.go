@@ -0,0 +1,174 @@
+package fuzzing
+
+import (
+	"context"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/crytic/medusa-geth/accounts/abi"
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa/chain"
+	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/crytic/medusa/logging"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+// statefulTestSender and statefulTestRecipient describe the accounts the stateful mode tests transfer value between.
+var (
+	statefulTestSender    = common.HexToAddress("0x10000")
+	statefulTestRecipient = common.HexToAddress("0x20000")
+)
+
+// statefulTestMethod describes the method the value transfers of the stateful mode tests call.
+var statefulTestMethod = abi.NewMethod("deposit", "deposit", abi.Function, "payable", false, true, nil, nil)
+
+// newStatefulTestWorker returns a worker with the provided index of a fuzzer with the provided stateful mode
+// configuration, whose chain's testing base funds statefulTestSender.
+func newStatefulTestWorker(t *testing.T, fuzzer *Fuzzer, workerIndex int) *FuzzerWorker {
+	testChain, err := chain.NewTestChain(context.Background(), types.GenesisAlloc{
+		statefulTestSender: {Balance: big.NewInt(1_000_000_000_000_000_000)},
+	}, nil)
+	assert.NoError(t, err)
+	return &FuzzerWorker{
+		workerIndex:           workerIndex,
+		fuzzer:                fuzzer,
+		chain:                 testChain,
+		randomProvider:        rand.New(rand.NewSource(0)),
+		testingBaseBlockIndex: uint64(len(testChain.CommittedBlocks())),
+	}
+}
+
+// executeStatefulTestTransfers executes the provided amount of value transfers from statefulTestSender to
+// statefulTestRecipient on the worker's chain, on top of its current state.
+// Returns the executed call sequence.
+func executeStatefulTestTransfers(t *testing.T, worker *FuzzerWorker, count int) calls.CallSequence {
+	fetchElementFunc := func(index int) (*calls.CallSequenceElement, error) {
+		if index >= count {
+			return nil, nil
+		}
+		msg := calls.NewCallMessageWithAbiValueData(statefulTestSender, &statefulTestRecipient, 0, big.NewInt(1), 100_000, nil, nil, nil, &calls.CallMessageDataAbiValues{
+			Method:      &statefulTestMethod,
+			InputValues: []any{},
+		})
+		msg.FillFromTestChainProperties(worker.chain)
+		return calls.NewCallSequenceElement(nil, msg, 1, 1), nil
+	}
+	executedSequence, err := calls.ExecuteCallSequenceIteratively(worker.chain, fetchElementFunc, nil)
+	assert.NoError(t, err)
+	assert.Len(t, executedSequence, count)
+	return executedSequence
+}
+
+// assertStatefulTestTransfers asserts the amount of value transfers the worker's chain state reflects.
+func assertStatefulTestTransfers(t *testing.T, worker *FuzzerWorker, count uint64) {
+	assert.EqualValues(t, count, worker.chain.State().GetBalance(statefulTestRecipient).Uint64())
+}
+
+// TestStatefulSnapshotRoundTrip ensures persistent workers accumulate the state of their call sequences, record it as
+// a snapshot once the snapshot interval elapsed, and that the snapshot replays to the same state on a persistent
+// worker after its state was reset for a corpus call sequence, as well as on a non-persistent worker.
+func TestStatefulSnapshotRoundTrip(t *testing.T) {
+	projectConfig, err := config.GetDefaultProjectConfig("")
+	assert.NoError(t, err)
+	projectConfig.Fuzzing.StatefulMode = config.StatefulModeConfig{
+		Enabled:             true,
+		PersistentWorkers:   1,
+		SnapshotInterval:    2,
+		MaxSnapshots:        1,
+		SnapshotProbability: 1,
+		MaxHistoryLength:    10,
+	}
+	fuzzer := &Fuzzer{config: *projectConfig, logger: logging.NewLogger(zerolog.Disabled)}
+	worker := newStatefulTestWorker(t, fuzzer, 0)
+	assert.True(t, worker.isPersistentWorker())
+
+	// Without a snapshot, a persistent worker starts from the testing base.
+	prefix, err := worker.prepareStatefulPrefix(true)
+	assert.NoError(t, err)
+	assert.Empty(t, prefix)
+
+	// The state of new call sequences is kept, and only recorded as a snapshot once the interval elapsed.
+	history := executeStatefulTestTransfers(t, worker, 2)
+	assert.True(t, worker.finishStatefulSequence(history, true, nil))
+	snapshot, err := fuzzer.statefulSnapshot(worker.randomProvider, true)
+	assert.NoError(t, err)
+	assert.Nil(t, snapshot)
+
+	prefix, err = worker.prepareStatefulPrefix(true)
+	assert.NoError(t, err)
+	assert.Len(t, prefix, 2)
+	history = append(prefix, executeStatefulTestTransfers(t, worker, 1)...)
+	assert.True(t, worker.finishStatefulSequence(history, true, nil))
+	assertStatefulTestTransfers(t, worker, 3)
+	assert.Len(t, fuzzer.statefulSnapshots, 1)
+
+	// Snapshots are copies, so modifying one does not affect the recorded snapshot.
+	snapshot, err = fuzzer.statefulSnapshot(worker.randomProvider, true)
+	assert.NoError(t, err)
+	assert.Len(t, snapshot, 3)
+	snapshot[0].Call.Value = big.NewInt(100)
+	assert.EqualValues(t, 1, fuzzer.statefulSnapshots[0][0].Call.Value.Uint64())
+
+	// Corpus call sequences are replayed against the testing base.
+	prefix, err = worker.prepareStatefulPrefix(false)
+	assert.NoError(t, err)
+	assert.Empty(t, prefix)
+	assert.Empty(t, worker.statefulHistory)
+	assertStatefulTestTransfers(t, worker, 0)
+	assert.False(t, worker.finishStatefulSequence(executeStatefulTestTransfers(t, worker, 1), false, nil))
+	assert.NoError(t, worker.chain.RevertToBlockIndex(worker.testingBaseBlockIndex))
+
+	// The next new call sequence resumes from the latest snapshot.
+	prefix, err = worker.prepareStatefulPrefix(true)
+	assert.NoError(t, err)
+	assert.Len(t, prefix, 3)
+	assert.Len(t, worker.statefulHistory, 3)
+	assertStatefulTestTransfers(t, worker, 3)
+
+	// Non-persistent workers start from a snapshot, but do not keep the state of their call sequences.
+	otherWorker := newStatefulTestWorker(t, fuzzer, 1)
+	assert.False(t, otherWorker.isPersistentWorker())
+	prefix, err = otherWorker.prepareStatefulPrefix(true)
+	assert.NoError(t, err)
+	assert.Len(t, prefix, 3)
+	assert.Empty(t, otherWorker.statefulHistory)
+	assertStatefulTestTransfers(t, otherWorker, 3)
+	history = append(prefix, executeStatefulTestTransfers(t, otherWorker, 1)...)
+	assert.False(t, otherWorker.finishStatefulSequence(history, true, nil))
+}
+
+// TestFinishStatefulSequenceDropsHistory ensures persistent workers drop their accumulated state once a call sequence
+// produced shrink requests or exceeded the maximum history length, and that only the latest snapshots are retained.
+func TestFinishStatefulSequenceDropsHistory(t *testing.T) {
+	projectConfig, err := config.GetDefaultProjectConfig("")
+	assert.NoError(t, err)
+	projectConfig.Fuzzing.StatefulMode = config.StatefulModeConfig{
+		Enabled:           true,
+		PersistentWorkers: 1,
+		SnapshotInterval:  1,
+		MaxSnapshots:      2,
+		MaxHistoryLength:  3,
+	}
+	fuzzer := &Fuzzer{config: *projectConfig, logger: logging.NewLogger(zerolog.Disabled)}
+	worker := newStatefulTestWorker(t, fuzzer, 0)
+
+	history := executeStatefulTestTransfers(t, worker, 1)
+	assert.True(t, worker.finishStatefulSequence(history, true, nil))
+	assert.False(t, worker.finishStatefulSequence(history, true, []ShrinkCallSequenceRequest{{}}))
+	assert.Empty(t, worker.statefulHistory)
+
+	history = append(history, executeStatefulTestTransfers(t, worker, 2)...)
+	assert.True(t, worker.finishStatefulSequence(history, true, nil))
+	history = append(history, executeStatefulTestTransfers(t, worker, 1)...)
+	assert.False(t, worker.finishStatefulSequence(history, true, nil))
+	assert.Empty(t, worker.statefulHistory)
+
+	// Each kept call sequence was recorded as a snapshot, of which the two latest are retained.
+	assert.Len(t, fuzzer.statefulSnapshots, 2)
+	assert.Len(t, fuzzer.statefulSnapshots[0], 1)
+	assert.Len(t, fuzzer.statefulSnapshots[1], 3)
+}
@@ -0,0 +1,145 @@
+package fuzzing
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/crytic/medusa-geth/core/vm"
+	compilationTypes "github.com/crytic/medusa/compilation/types"
+	"github.com/crytic/medusa/fuzzing/coverage"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/branchdistance"
+)
+
+// resolveDirectedTargets resolves the targets configured for the target-directed mode to runtime bytecode program
+// counters, by contract name. Targets are either "Contract:pc", where the pc references a JUMPI or JUMPDEST in the
+// contract's runtime bytecode, or "path/to/File.sol:line".
+// Returns the resolved program counters, or an error if a target is malformed or could not be resolved.
+func (f *Fuzzer) resolveDirectedTargets() (map[string][]uint64, error) {
+	targetPcs := make(map[string][]uint64)
+	for _, target := range f.config.Fuzzing.TargetDirected.Targets {
+		separatorIndex := strings.LastIndex(target, ":")
+		if separatorIndex <= 0 || separatorIndex == len(target)-1 {
+			return nil, fmt.Errorf("target '%v' must be of the form 'Contract:pc' or 'path/to/File.sol:line'", target)
+		}
+		location, position := target[:separatorIndex], target[separatorIndex+1:]
+
+		// Targets referencing a source file are resolved to every instruction mapped to the line.
+		resolved := false
+		if strings.HasSuffix(location, ".sol") || strings.ContainsAny(location, `/\`) {
+			line, err := strconv.Atoi(position)
+			if err != nil || line <= 0 {
+				return nil, fmt.Errorf("target '%v' does not specify a valid line number", target)
+			}
+			for contractName, pcs := range f.resolveSourceLineTarget(location, line) {
+				targetPcs[contractName] = append(targetPcs[contractName], pcs...)
+				resolved = true
+			}
+		} else {
+			pc, err := strconv.ParseUint(position, 0, 64)
+			if err != nil {
+				return nil, fmt.Errorf("target '%v' does not specify a valid program counter", target)
+			}
+			for _, contract := range f.contractDefinitions {
+				if contract.Name() == location {
+					// Distances are only recorded at branches, so a pc must reference one or a block they lead to.
+					if !isDirectedTargetInstruction(contract.CompiledContract().RuntimeBytecode, pc) {
+						return nil, fmt.Errorf("target '%v' does not reference a JUMPI or JUMPDEST instruction in the runtime bytecode of contract '%v'", target, location)
+					}
+					targetPcs[location] = append(targetPcs[location], pc)
+					resolved = true
+					break
+				}
+			}
+		}
+		if !resolved {
+			return nil, fmt.Errorf("target '%v' could not be resolved to any instruction of a known contract", target)
+		}
+	}
+	return targetPcs, nil
+}
+
+// isDirectedTargetInstruction determines whether the provided pc is the start of a JUMPI or JUMPDEST instruction in the
+// provided bytecode, rather than another instruction or push data.
+func isDirectedTargetInstruction(bytecode []byte, pc uint64) bool {
+	it := branchdistance.NewInstructionIterator(bytecode)
+	for it.Next() && it.PC() <= pc {
+		if it.PC() == pc {
+			return it.Op() == vm.JUMPI || it.Op() == vm.JUMPDEST
+		}
+	}
+	return false
+}
+
+// resolveSourceLineTarget resolves a source line to the runtime bytecode program counters of the instructions mapped
+// to it, by contract name. The source path may be relative to the compilation's source paths.
+func (f *Fuzzer) resolveSourceLineTarget(sourcePath string, line int) map[string][]uint64 {
+	sourcePath = filepath.ToSlash(filepath.Clean(sourcePath))
+	targetPcs := make(map[string][]uint64)
+	for _, contract := range f.contractDefinitions {
+		compilation := contract.Compilation()
+		compiledContract := contract.CompiledContract()
+		sourceMap, err := compilationTypes.ParseSourceMap(compiledContract.SrcMapsRuntime)
+		if err != nil || len(sourceMap) == 0 {
+			continue
+		}
+		indexToOffset := coverage.GetInstructionIndexToOffsetLookup(compiledContract.RuntimeBytecode)
+
+		// Determine the byte range of the line in each matching source unit.
+		lineRanges := make(map[int][2]int)
+		for sourceUnitId, path := range compilation.SourceIdToPath {
+			normalizedPath := filepath.ToSlash(filepath.Clean(path))
+			if normalizedPath != sourcePath && !strings.HasSuffix(normalizedPath, "/"+sourcePath) {
+				continue
+			}
+			if start, end, ok := sourceLineRange(compilation, path, line); ok {
+				lineRanges[sourceUnitId] = [2]int{start, end}
+			}
+		}
+		if len(lineRanges) == 0 {
+			continue
+		}
+
+		// Collect every instruction whose source range starts within the line.
+		for _, sourceMapElement := range sourceMap {
+			lineRange, ok := lineRanges[sourceMapElement.SourceUnitID]
+			if !ok || sourceMapElement.Offset < lineRange[0] || sourceMapElement.Offset >= lineRange[1] {
+				continue
+			}
+			if sourceMapElement.Index < len(indexToOffset) {
+				targetPcs[contract.Name()] = append(targetPcs[contract.Name()], uint64(indexToOffset[sourceMapElement.Index]))
+			}
+		}
+	}
+	return targetPcs
+}
+
+// sourceLineRange returns the byte range [start, end) of the provided 1-based line in a source file of the
+// compilation, reading the source file if it was not cached. Returns false if the line does not exist.
+func sourceLineRange(compilation *compilationTypes.Compilation, sourcePath string, line int) (int, int, bool) {
+	sourceCode, ok := compilation.SourceCode[sourcePath]
+	if !ok || sourceCode == nil {
+		var err error
+		sourceCode, err = os.ReadFile(sourcePath)
+		if err != nil {
+			return 0, 0, false
+		}
+	}
+
+	start := 0
+	for currentLine := 1; currentLine < line; currentLine++ {
+		newlineIndex := bytes.IndexByte(sourceCode[start:], '\n')
+		if newlineIndex < 0 {
+			return 0, 0, false
+		}
+		start += newlineIndex + 1
+	}
+	end := len(sourceCode)
+	if newlineIndex := bytes.IndexByte(sourceCode[start:], '\n'); newlineIndex >= 0 {
+		end = start + newlineIndex
+	}
+	return start, end, true
+}
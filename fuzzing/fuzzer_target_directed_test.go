@@ -0,0 +1,49 @@
+package fuzzing
+
+import (
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	compilationTypes "github.com/crytic/medusa/compilation/types"
+	"github.com/crytic/medusa/fuzzing/config"
+	fuzzerTypes "github.com/crytic/medusa/fuzzing/contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestResolveDirectedTargetsProgramCounter ensures "Contract:pc" targets are only resolved if the pc references a
+// JUMPI or JUMPDEST instruction in the runtime bytecode of the contract, rather than another instruction, push data
+// or a pc beyond the bytecode.
+func TestResolveDirectedTargetsProgramCounter(t *testing.T) {
+	// PUSH1 0x06, PUSH1 0x5b (push data matching a JUMPDEST at pc 3), JUMPI, STOP, JUMPDEST, STOP
+	contract := fuzzerTypes.NewContract("Target", "Target.sol", &compilationTypes.CompiledContract{
+		RuntimeBytecode: common.FromHex("0x6006605b57005b00"),
+	}, nil)
+	projectConfig, err := config.GetDefaultProjectConfig("")
+	assert.NoError(t, err)
+	fuzzer := &Fuzzer{config: *projectConfig, contractDefinitions: fuzzerTypes.Contracts{contract}}
+
+	tests := []struct {
+		target string
+		pc     uint64
+		valid  bool
+	}{
+		{target: "Target:4", pc: 4, valid: true},
+		{target: "Target:0x6", pc: 6, valid: true},
+		{target: "Target:0"},
+		{target: "Target:3"},
+		{target: "Target:5"},
+		{target: "Target:100"},
+		{target: "Target:jumpi"},
+		{target: "Other:4"},
+	}
+	for _, test := range tests {
+		fuzzer.config.Fuzzing.TargetDirected.Targets = []string{test.target}
+		targetPcs, err := fuzzer.resolveDirectedTargets()
+		if !test.valid {
+			assert.Error(t, err, test.target)
+			continue
+		}
+		assert.NoError(t, err, test.target)
+		assert.EqualValues(t, map[string][]uint64{"Target": {test.pc}}, targetPcs, test.target)
+	}
+}
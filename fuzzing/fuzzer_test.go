@@ -13,6 +13,7 @@ import (
 	"github.com/crytic/medusa/chain"
 	"github.com/crytic/medusa/events"
 	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/fuzzing/coordinator"
 	"github.com/crytic/medusa/fuzzing/executiontracer"
 	"github.com/crytic/medusa/fuzzing/valuegeneration"
 
@@ -733,6 +734,32 @@ func TestDeploymentsWithArgs(t *testing.T) {
 	})
 }
 
+// TestDeploymentsWithConstructorArgsSearch runs a test to ensure contracts with no config provided constructor
+// arguments are deployed with searched arguments which cover their init bytecode. It expects the property to fail,
+// indicating the contract was fully initialized.
+func TestDeploymentsWithConstructorArgsSearch(t *testing.T) {
+	runFuzzerTest(t, &fuzzerSolcFileTest{
+		filePath: "testdata/contracts/deployments/constructor_args_search.sol",
+		configUpdates: func(config *config.ProjectConfig) {
+			config.Fuzzing.TargetContracts = []string{"ConstructorArgsSearch"}
+			config.Fuzzing.ConstructorArgsSearch.Enabled = true
+			config.Fuzzing.ConstructorArgsSearch.Iterations = 500
+			config.Fuzzing.TestLimit = 500 // this test should expose a failure quickly.
+			config.Fuzzing.Testing.AssertionTesting.Enabled = false
+			config.Fuzzing.Testing.OptimizationTesting.Enabled = false
+			config.Slither.UseSlither = false
+		},
+		method: func(f *fuzzerTestContext) {
+			// Start the fuzzer
+			err := f.fuzzer.Start()
+			assert.NoError(t, err)
+
+			// Check to see if there are any failures
+			assert.EqualValues(t, 1, len(f.fuzzer.TestCasesWithStatus(TestCaseStatusFailed)))
+		},
+	})
+}
+
 // TestValueGenerationGenerateAllTypes runs a test to ensure various types of fuzzer inputs can be generated.
 func TestValueGenerationGenerateAllTypes(t *testing.T) {
 	runFuzzerTest(t, &fuzzerSolcFileTest{
@@ -1012,6 +1039,67 @@ func TestCorpusReplayability(t *testing.T) {
 	})
 }
 
+// TestDistributedCorpusExchange runs two fuzzer instances synchronizing with the same coordinator, one after the
+// other, and ensures the call sequences discovered by the first are replayed and added to the corpus of the second.
+func TestDistributedCorpusExchange(t *testing.T) {
+	runFuzzerTest(t, &fuzzerSolcFileTest{
+		filePath: "testdata/contracts/value_generation/match_uints_xy.sol",
+		configUpdates: func(config *config.ProjectConfig) {
+			config.Fuzzing.TargetContracts = []string{"TestContract"}
+			config.Fuzzing.Workers = 1
+			config.Fuzzing.TestLimit = 1_000
+			config.Fuzzing.FitnessMetricConfig.CodeCoverageEnabled = true
+			config.Fuzzing.Testing.PropertyTesting.Enabled = false
+			config.Fuzzing.Testing.AssertionTesting.Enabled = false
+			config.Fuzzing.Testing.OptimizationTesting.Enabled = false
+			config.Slither.UseSlither = false
+		},
+		method: func(f *fuzzerTestContext) {
+			// Host the coordinator both instances synchronize with.
+			server := coordinator.NewServer()
+			assert.NoError(t, server.Start("127.0.0.1:0"))
+			defer server.Close()
+			f.fuzzer.config.Fuzzing.Distributed.Enabled = true
+			f.fuzzer.config.Fuzzing.Distributed.Address = server.Address()
+
+			// The first instance publishes its corpus call sequences before exiting.
+			err := f.fuzzer.Start()
+			assert.NoError(t, err)
+			assertCorpusCallSequencesCollected(f, true)
+			publishedHashes := make(map[common.Hash]struct{})
+			for _, callSequence := range f.fuzzer.corpus.CallSequenceFiles() {
+				hash, err := callSequence.Hash()
+				assert.NoError(t, err)
+				publishedHashes[hash] = struct{}{}
+			}
+
+			// The second instance receives them upon starting, and replays them before generating its own.
+			secondFuzzer, err := NewFuzzer(f.fuzzer.config)
+			assert.NoError(t, err)
+			secondFuzzer.config.Fuzzing.TestLimit = 1
+			err = secondFuzzer.Start()
+			assert.NoError(t, err)
+
+			// The received call sequences achieved coverage the corpus of the second instance did not when replayed,
+			// so at least one of them was added to it as-is.
+			receivedSequenceAdded := false
+			for _, callSequence := range secondFuzzer.corpus.CallSequenceFiles() {
+				hash, err := callSequence.Hash()
+				assert.NoError(t, err)
+				if _, published := publishedHashes[hash]; published {
+					receivedSequenceAdded = true
+				}
+			}
+			assert.True(t, receivedSequenceAdded)
+
+			// The corpus of the second instance covers everything the first one did.
+			coverageIncreased, err := secondFuzzer.corpus.CodeCoverageMaps().Update(f.fuzzer.corpus.CodeCoverageMaps())
+			assert.NoError(t, err)
+			assert.False(t, coverageIncreased)
+		},
+	})
+}
+
 // TestDeploymentOrderWithCoverage will ensure that changing the order of deployment for the target contracts does not
 // lead to the same coverage. This is also proof that changing the order changes the addresses of the contracts leading
 // to the coverage not being useful.
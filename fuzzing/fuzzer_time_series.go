@@ -0,0 +1,182 @@
+package fuzzing
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/crytic/medusa/fuzzing/coverage"
+	"github.com/crytic/medusa/utils"
+)
+
+// coverageTimeSeriesCSVHeader describes the header row of a coverage time series written as CSV, matching the fields of
+// coverageTimeSeriesSample.
+var coverageTimeSeriesCSVHeader = []string{
+	"timestamp", "elapsedSeconds", "callsTested", "sequencesTested", "coveredInstructions", "coveredBranches",
+	"dataflows", "tokenflows", "bugsFound",
+}
+
+// coverageTimeSeriesSample describes the fuzzing metrics sampled at some point of a fuzzing campaign.
+type coverageTimeSeriesSample struct {
+	// Timestamp is the unix time at which the sample was taken.
+	Timestamp int64 `json:"timestamp"`
+	// ElapsedSeconds is the amount of seconds elapsed since the time series started.
+	ElapsedSeconds uint64 `json:"elapsedSeconds"`
+	// CallsTested is the amount of calls tested so far.
+	CallsTested uint64 `json:"callsTested"`
+	// SequencesTested is the amount of call sequences tested so far.
+	SequencesTested uint64 `json:"sequencesTested"`
+	// CoveredInstructions is the amount of distinct instructions covered by the corpus.
+	CoveredInstructions int `json:"coveredInstructions"`
+	// CoveredBranches is the amount of distinct branches covered by the corpus.
+	CoveredBranches uint64 `json:"coveredBranches"`
+	// Dataflows is the amount of distinct dataflows recorded, or zero if dataflow is not traced.
+	Dataflows int `json:"dataflows"`
+	// Tokenflows is the amount of distinct tokenflows recorded, or zero if tokenflow is not traced.
+	Tokenflows int `json:"tokenflows"`
+	// BugsFound is the amount of distinct bugs found by the bug detector, or zero if it is disabled.
+	BugsFound int `json:"bugsFound"`
+}
+
+// csvRow returns the sample as a CSV row, ordered as coverageTimeSeriesCSVHeader.
+func (s coverageTimeSeriesSample) csvRow() []string {
+	return []string{
+		strconv.FormatInt(s.Timestamp, 10),
+		strconv.FormatUint(s.ElapsedSeconds, 10),
+		strconv.FormatUint(s.CallsTested, 10),
+		strconv.FormatUint(s.SequencesTested, 10),
+		strconv.Itoa(s.CoveredInstructions),
+		strconv.FormatUint(s.CoveredBranches, 10),
+		strconv.Itoa(s.Dataflows),
+		strconv.Itoa(s.Tokenflows),
+		strconv.Itoa(s.BugsFound),
+	}
+}
+
+// coverageTimeSeriesWriter writes samples of fuzzing metrics to a CSV or JSON file as a time series.
+type coverageTimeSeriesWriter struct {
+	// path is the path of the file the time series is written to.
+	path string
+	// format is the format the time series is written in, either "csv" or "json".
+	format string
+	// startTime is the time the writer was created, which samples record their elapsed time relative to.
+	startTime time.Time
+	// samples describes the samples written so far, which are rewritten as a whole when written as JSON.
+	samples []coverageTimeSeriesSample
+	// lock is a mutex used to prevent concurrent writes of the time series.
+	lock sync.Mutex
+}
+
+// newCoverageTimeSeriesWriter returns a new coverageTimeSeriesWriter which writes a time series in the provided format
+// to the provided directory.
+func newCoverageTimeSeriesWriter(directory string, format string) *coverageTimeSeriesWriter {
+	return &coverageTimeSeriesWriter{
+		path:      filepath.Join(directory, "coverage_time_series."+format),
+		format:    format,
+		startTime: time.Now(),
+		samples:   make([]coverageTimeSeriesSample, 0),
+	}
+}
+
+// Write adds the provided sample to the time series and writes it to disk.
+// Returns an error if one occurred.
+func (w *coverageTimeSeriesWriter) Write(sample coverageTimeSeriesSample) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	sample.ElapsedSeconds = uint64(time.Since(w.startTime).Seconds())
+	w.samples = append(w.samples, sample)
+
+	// Create the directory if it does not exist
+	err := utils.MakeDirectory(filepath.Dir(w.path))
+	if err != nil {
+		return err
+	}
+
+	var b []byte
+	if w.format == "json" {
+		b, err = json.MarshalIndent(w.samples, "", "\t")
+		if err != nil {
+			return err
+		}
+	} else {
+		var builder strings.Builder
+		builder.WriteString(strings.Join(coverageTimeSeriesCSVHeader, ",") + "\n")
+		for _, s := range w.samples {
+			builder.WriteString(strings.Join(s.csvRow(), ",") + "\n")
+		}
+		b = []byte(builder.String())
+	}
+
+	err = os.WriteFile(w.path, b, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write coverage time series at %v: %v", w.path, err)
+	}
+	return nil
+}
+
+// coverageTimeSeriesDirectory returns the directory the coverage time series is written to.
+func (f *Fuzzer) coverageTimeSeriesDirectory() string {
+	// Write to the default directory if we have no corpus directory set.
+	if f.config.Fuzzing.CorpusDirectory != "" {
+		return f.config.Fuzzing.CorpusDirectory
+	}
+	return "crytic-export"
+}
+
+// sampleCoverageTimeSeries samples the fuzzing metrics making up a coverage time series.
+func (f *Fuzzer) sampleCoverageTimeSeries() coverageTimeSeriesSample {
+	sample := coverageTimeSeriesSample{
+		Timestamp:       time.Now().Unix(),
+		CallsTested:     f.metrics.CallsTested().Uint64(),
+		SequencesTested: f.metrics.SequencesTested().Uint64(),
+		CoveredBranches: f.corpus.CoverageMaps().BranchesHit(),
+	}
+
+	// Counting covered instructions walks the bytecode of every contract, which is fine at the sampling interval.
+	coveredInstructions, err := coverage.GetUniquePCsCount(f.compilations, f.corpus.CoverageMaps(), f.logger)
+	if err == nil {
+		sample.CoveredInstructions = coveredInstructions
+	}
+	if f.config.Fuzzing.UseDataflowTracing() {
+		sample.Dataflows = f.metrics.DataflowSet().TotalDataflowCount(false)
+	}
+	if f.config.Fuzzing.UseTokenflowTracing() {
+		sample.Tokenflows = f.metrics.TokenflowMaps().TotalTokenflowCount(true)
+	}
+	if f.config.Fuzzing.UseBugDetector() {
+		sample.BugsFound = len(f.corpus.BugMap().BugDetectionResult())
+	}
+	return sample
+}
+
+// writeCoverageTimeSeriesLoop periodically samples the fuzzing metrics into the coverage time series until the fuzzer
+// stops.
+func (f *Fuzzer) writeCoverageTimeSeriesLoop() {
+	ticker := time.NewTicker(time.Duration(f.config.Fuzzing.CoverageTimeSeries.Interval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.ctx.Done():
+			return
+		case <-ticker.C:
+			f.writeCoverageTimeSeries()
+		}
+	}
+}
+
+// writeCoverageTimeSeries samples the fuzzing metrics into the coverage time series, if enabled.
+func (f *Fuzzer) writeCoverageTimeSeries() {
+	if f.coverageTimeSeriesWriter == nil {
+		return
+	}
+	err := f.coverageTimeSeriesWriter.Write(f.sampleCoverageTimeSeries())
+	if err != nil {
+		f.logger.Error("Failed to write the coverage time series", err)
+	}
+}
@@ -1,9 +1,11 @@
 package fuzzing
 
 import (
+	"bytes"
 	"fmt"
 	"math/big"
 	"math/rand"
+	"slices"
 	"strings"
 
 	"github.com/crytic/medusa/chain/types"
@@ -18,15 +20,23 @@ import (
 	"github.com/crytic/medusa/fuzzing/executiontracer"
 	"github.com/crytic/medusa/fuzzing/valuegeneration"
 	"github.com/crytic/medusa/utils"
+	"github.com/crytic/medusa/utils/randomutils"
 	"golang.org/x/exp/maps"
 
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/balancedelta"
 	"github.com/crytic/medusa/fuzzing/fitnessmetrics/branchcoverage"
 	branchdistance "github.com/crytic/medusa/fuzzing/fitnessmetrics/branchdistance"
 	cmpdistance "github.com/crytic/medusa/fuzzing/fitnessmetrics/cmpdistance"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/cmplog"
 	codecoverage "github.com/crytic/medusa/fuzzing/fitnessmetrics/codecoverage"
 	"github.com/crytic/medusa/fuzzing/fitnessmetrics/dataflow"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/edgecoverage"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/pathcoverage"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/selectorcoverage"
 	"github.com/crytic/medusa/fuzzing/fitnessmetrics/storagewrite"
 	"github.com/crytic/medusa/fuzzing/fitnessmetrics/tokenflow"
+	"github.com/crytic/medusa/fuzzing/logindex"
 )
 
 // FuzzerWorker describes a single thread worker utilizing its own go-ethereum test node to run property tests against
@@ -46,6 +56,17 @@ type FuzzerWorker struct {
 	// prior to any fuzzing activity. This block number is reverted to after testing each call sequence to reset state.
 	testingBaseBlockIndex uint64
 
+	// statefulHistory describes the calls executed on top of the testing base which the chain state currently
+	// reflects, when executing in the stateful mode. Call sequences are executed on top of this history.
+	statefulHistory calls.CallSequence
+	// sequencesSinceSnapshot describes the amount of call sequences accumulated into statefulHistory since it was last
+	// snapshotted.
+	sequencesSinceSnapshot int
+
+	// branchPrefix describes the shared prefix new call sequences are executed on top of when prefix branching is
+	// enabled, or nil if they are executed on top of the testing base.
+	branchPrefix *callSequencePrefixBranch
+
 	// deployedContracts describes a mapping of deployed contractDefinitions and the addresses they were deployed to.
 	deployedContracts map[common.Address]*fuzzerTypes.Contract
 
@@ -54,6 +75,10 @@ type FuzzerWorker struct {
 	// before executing tests.
 	stateChangingMethods []fuzzerTypes.DeployedContractMethod
 
+	// stateChangingMethodChooser chooses the indices of stateChangingMethods generated calls target, by their
+	// configured weights, or is nil if they are chosen uniformly.
+	stateChangingMethodChooser *randomutils.WeightedRandomChooser[int]
+
 	// pureMethods is a list of contract functions which are side-effect free with respect to the EVM (view and/or pure in terms of Solidity mutability).
 	pureMethods []fuzzerTypes.DeployedContractMethod
 
@@ -62,6 +87,10 @@ type FuzzerWorker struct {
 	// before the execution of the next call sequence.
 	shrinkCallSequenceRequests []ShrinkCallSequenceRequest
 
+	// lastCallAddedToCorpus indicates whether the call sequence ending with the last call executed was added to the
+	// corpus, so that call sequence test functions can restrict more expensive checks to admitted call sequences.
+	lastCallAddedToCorpus bool
+
 	// randomProvider provides random data as inputs to decisions throughout the worker.
 	randomProvider *rand.Rand
 	// sequenceGenerator creates entirely new or mutated call sequences based on corpus call sequences, for use in
@@ -88,6 +117,15 @@ type FuzzerWorker struct {
 	// branchCoverageTracer is used to collect branch coverage data during fuzzing.
 	branchCoverageTracer *branchcoverage.CoverageTracer
 
+	// edgeCoverageTracer is used to collect edge coverage data during fuzzing.
+	edgeCoverageTracer *edgecoverage.CoverageTracer
+
+	// pathCoverageTracer is used to collect transaction path hashes during fuzzing.
+	pathCoverageTracer *pathcoverage.PathCoverageTracer
+
+	// selectorCoverageTracer is used to collect the function selectors executed successfully during fuzzing.
+	selectorCoverageTracer *selectorcoverage.SelectorCoverageTracer
+
 	// cmpDistanceTracer is used to collect comparison operation data during fuzzing.
 	cmpDistanceTracer *cmpdistance.CmpDistanceTracer
 
@@ -103,15 +141,49 @@ type FuzzerWorker struct {
 	// tokenflowTracer is used to record the token flow being triggered during fuzzing.
 	tokenflowTracer *tokenflow.TokenflowTracer
 
+	// balanceDeltaTracer is used to record the net balance deltas of tracked addresses during fuzzing.
+	balanceDeltaTracer *balancedelta.BalanceDeltaTracer
+
 	// bugDetectorTracer is used to detect the bugs during fuzzing.
 	bugDetectorTracer *bugdetector.BugDetectorTracer
 
+	// heavyTracersForced describes whether heavy tracers attached with tracer sampling should trace every transaction,
+	// as they do while replaying corpus call sequences or shrinking.
+	heavyTracersForced bool
+
+	// logIndex describes the index of the logs emitted by the calls last provided to LogIndex, and logIndexedCalls the
+	// chain references of the calls it indexed, so it can be updated incrementally.
+	logIndex        *logindex.LogIndex
+	logIndexedCalls []*calls.CallSequenceElementChainReference
+
+	// cmpLogTracer is used to record the operands of comparisons feeding conditional jumps during fuzzing.
+	cmpLogTracer *cmplog.CmpLogTracer
+	// cmpLogPairs describes the most recent comparison operand pairs recorded by cmpLogTracer, which the call sequence
+	// generator substitutes into call data.
+	cmpLogPairs []cmplog.OperandPair
+
 	// for indicator tracers solely
-	codeCoverageIndicatorTracer   *codecoverage.CoverageTracer
-	branchCoverageIndicatorTracer *branchcoverage.CoverageTracer
-	dataFlowIndicatorTracer       *dataflow.DataflowTracer
-	storageWriteIndicatorTracer   *storagewrite.StorageWriteTracer
-	tokenflowIndicatorTracer      *tokenflow.TokenflowTracer
+	codeCoverageIndicatorTracer     *codecoverage.CoverageTracer
+	branchCoverageIndicatorTracer   *branchcoverage.CoverageTracer
+	edgeCoverageIndicatorTracer     *edgecoverage.CoverageTracer
+	pathCoverageIndicatorTracer     *pathcoverage.PathCoverageTracer
+	selectorCoverageIndicatorTracer *selectorcoverage.SelectorCoverageTracer
+	dataFlowIndicatorTracer         *dataflow.DataflowTracer
+	storageWriteIndicatorTracer     *storagewrite.StorageWriteTracer
+	tokenflowIndicatorTracer        *tokenflow.TokenflowTracer
+	balanceDeltaIndicatorTracer     *balancedelta.BalanceDeltaTracer
+
+	// resultReleasers describes the tracers whose results are released for reuse once a newly generated call was
+	// checked, so that steady-state fuzzing does not allocate new results for each call.
+	resultReleasers []fitnessmetrics.ResultReleaser
+
+	// tracedMetrics describes whether the tracer of each fitness metric attached to the chain traces the current call
+	// sequence, which it does not if the metric saturated.
+	tracedMetrics map[fitnessmetrics.FitnessMetric]bool
+
+	// metricGeneration describes the generation of the metrics paused through Fuzzer.ReloadMetricConfig which the
+	// worker's tracers were attached for. The worker restarts once it changes.
+	metricGeneration uint64
 }
 
 // newFuzzerWorker creates a new FuzzerWorker, assigning it the provided worker index/id and associating it to the
@@ -208,6 +280,16 @@ func (fw *FuzzerWorker) getNewCorpusCallSequenceWeight() *big.Int {
 	return new(big.Int).Add(fw.workerMetrics().sequencesTested, big.NewInt(1))
 }
 
+// getPowerScheduledCallSequenceWeight returns the weight that the provided call sequence should be added to the
+// corpus with, as determined by the fuzzer's power schedule hook from the weight of a new corpus item.
+func (fw *FuzzerWorker) getPowerScheduledCallSequenceWeight(callSequence calls.CallSequence) *big.Int {
+	weight := fw.getNewCorpusCallSequenceWeight()
+	if fw.fuzzer.Hooks.PowerScheduleFunc == nil {
+		return weight
+	}
+	return fw.fuzzer.Hooks.PowerScheduleFunc(fw, callSequence, weight)
+}
+
 // onChainContractDeploymentAddedEvent is the event callback used when the chain detects a new contract deployment.
 // It attempts bytecode matching and updates the list of deployed contracts the worker should use for fuzz testing.
 func (fw *FuzzerWorker) onChainContractDeploymentAddedEvent(event chain.ContractDeploymentsAddedEvent) error {
@@ -221,8 +303,11 @@ func (fw *FuzzerWorker) onChainContractDeploymentAddedEvent(event chain.Contract
 	// Add the contract address to our value set so our generator can use it in calls.
 	fw.valueSet.AddAddress(event.Contract.Address)
 
-	// Try to match it to a known contract definition
-	matchedDefinition := fw.fuzzer.contractDefinitions.MatchBytecode(event.Contract.InitBytecode, event.Contract.RuntimeBytecode)
+	// Try to match it to a known contract definition, on-chain targets being matched by address
+	matchedDefinition := fw.fuzzer.onChainContractDefinition(event.Contract.Address)
+	if matchedDefinition == nil {
+		matchedDefinition = fw.fuzzer.contractDefinitions.MatchBytecode(event.Contract.InitBytecode, event.Contract.RuntimeBytecode)
+	}
 	// If we didn't match any deployment, report it.
 	if matchedDefinition == nil {
 		if fw.fuzzer.config.Fuzzing.Testing.StopOnFailedContractMatching {
@@ -234,6 +319,7 @@ func (fw *FuzzerWorker) onChainContractDeploymentAddedEvent(event chain.Contract
 
 	// Set our deployed contract address in our deployed contract lookup, so we can reference it later.
 	fw.deployedContracts[event.Contract.Address] = matchedDefinition
+	fw.fuzzer.storageLayouts.register(event.Contract.Address, matchedDefinition)
 
 	// Update our methods
 	fw.updateMethods()
@@ -253,30 +339,40 @@ func (fw *FuzzerWorker) onChainContractDeploymentAddedEvent(event chain.Contract
 // onChainContractDiscoveryEvent is the event callback used when the chain detects a contract that was not deployed during the lifetime of
 // the TestChain, but exists on chain. It attempts bytecode matching and updates the list of deployed contracts the worker should use for fuzz testing.
 func (fw *FuzzerWorker) onChainContractDiscoveryEvent(event chain.ContractDiscoveryEvent) error {
+	// Add the contract address to our value set so our generator can use it in calls.
+	fw.valueSet.AddAddress(event.Contract.Address)
 
-	// Do not track the deployed contract if the contract deployment was a dynamic one and testAllContracts is false
-	if !fw.fuzzer.config.Fuzzing.Testing.TestAllContracts && !event.IsInitialization {
-		// Add the contract address to our value set so our generator can use it in calls.
-		fw.valueSet.AddAddress(event.Contract.Address)
+	// Do not track the discovered contract if it is not a predeployed one and testAllContracts is false. Contracts
+	// which were created (e.g. by factories) are still matched, so that the coverage of their code is recorded.
+	trackContract := fw.fuzzer.config.Fuzzing.Testing.TestAllContracts || event.IsInitialization
+	created := event.Contract.InitBytecode != nil
+	if !trackContract && !created {
 		return nil
 	}
 
-	// Add the contract address to our value set so our generator can use it in calls.
-	fw.valueSet.AddAddress(event.Contract.Address)
-
 	// Try to match it to a known contract definition
 	matchedDefinition := fw.fuzzer.contractDefinitions.MatchBytecode(event.Contract.InitBytecode, event.Contract.RuntimeBytecode)
 	// If we didn't match any deployment, report it.
 	if matchedDefinition == nil {
-		if fw.fuzzer.config.Fuzzing.Testing.StopOnFailedContractMatching {
+		if trackContract && fw.fuzzer.config.Fuzzing.Testing.StopOnFailedContractMatching {
 			return fmt.Errorf("could not match bytecode of a deployed contract to any contract definition known to the fuzzer")
 		} else {
 			return nil
 		}
 	}
 
+	// Record the coverage of created contracts with that of their contract definition, as their code may differ from
+	// the compiled bytecode (e.g. due to immutables or constructor arguments).
+	if created {
+		fw.registerDeployedContractCoverage(matchedDefinition, event.Contract)
+	}
+	if !trackContract {
+		return nil
+	}
+
 	// Set our deployed contract address in our deployed contract lookup, so we can reference it later.
 	fw.deployedContracts[event.Contract.Address] = matchedDefinition
+	fw.fuzzer.storageLayouts.register(event.Contract.Address, matchedDefinition)
 
 	// Update our methods
 	fw.updateMethods()
@@ -331,14 +427,29 @@ func (fw *FuzzerWorker) updateMethods() {
 	fw.stateChangingMethods = make([]fuzzerTypes.DeployedContractMethod, 0)
 	fw.pureMethods = make([]fuzzerTypes.DeployedContractMethod, 0)
 
-	// Loop through each deployed contract
-	for contractAddress, contractDefinition := range fw.deployedContracts {
+	// Loop through each deployed contract. Contracts and methods are enumerated in a deterministic order, so the
+	// methods chosen by a seeded random provider are reproducible.
+	contractAddresses := maps.Keys(fw.deployedContracts)
+	slices.SortFunc(contractAddresses, func(a, b common.Address) int {
+		return bytes.Compare(a[:], b[:])
+	})
+	for _, contractAddress := range contractAddresses {
 		// ignore the helper contract methods
 		if contractAddress == FuzzHelperContractAddress {
 			continue
 		}
+		contractDefinition := fw.deployedContracts[contractAddress]
+
 		// If we deployed the contract, also enumerate property tests and state changing methods.
-		for _, method := range contractDefinition.CompiledContract().Abi.Methods {
+		contractAbi := contractDefinition.CompiledContract().Abi
+		methodNames := maps.Keys(contractAbi.Methods)
+		slices.Sort(methodNames)
+		for _, methodName := range methodNames {
+			method := contractAbi.Methods[methodName]
+			// Skip methods which the contract functions configuration excludes from generated calls.
+			if !fw.functionTargeted(contractDefinition, &method) {
+				continue
+			}
 			// Any non-constant method should be tracked as a state changing method.
 			if method.IsConstant() {
 				// Only track the pure/view method if testing view methods is enabled
@@ -350,6 +461,7 @@ func (fw *FuzzerWorker) updateMethods() {
 			}
 		}
 	}
+	fw.updateStateChangingMethodChooser()
 }
 
 // bindCorpusElement ensures that the de-serialized corpus element is ready for runtime use.
@@ -362,9 +474,13 @@ func (fw *FuzzerWorker) bindCorpusElement(currentIndex int) error {
 		return nil
 	}
 
-	// Obtain the corpus element
-	element := fw.sequenceGenerator.baseSequence[currentIndex]
+	// Obtain the corpus element and bind it
+	return fw.bindCallSequenceElement(fw.sequenceGenerator.baseSequence[currentIndex])
+}
 
+// bindCallSequenceElement resolves the contract definition and ABI metadata of a de-serialized call sequence element
+// needed for runtime execution against the worker's chain. Returns an error if they could not be resolved.
+func (fw *FuzzerWorker) bindCallSequenceElement(element *calls.CallSequenceElement) error {
 	// If it is a contract creation, there is nothing to do.
 	if element.Call.To == nil {
 		return nil
@@ -383,6 +499,15 @@ func (fw *FuzzerWorker) bindCorpusElement(currentIndex int) error {
 		if err := abiValues.Resolve(contractDefinition.CompiledContract().Abi); err != nil {
 			return fmt.Errorf("error resolving method in contract '%v': %v", element.Contract.Name(), err)
 		}
+	} else if method, err := contractDefinition.CompiledContract().Abi.MethodById(element.Call.Data); err == nil {
+		// If our sequence element only has raw call data (e.g. it was imported from another tool), decode its ABI
+		// values so it can be mutated like any other call. We only do so if they encode back to the same call data.
+		if inputValues, err := method.Inputs.Unpack(element.Call.Data[4:]); err == nil {
+			abiValues := &calls.CallMessageDataAbiValues{Method: method, InputValues: inputValues}
+			if data, err := abiValues.Pack(); err == nil && bytes.Equal(data, element.Call.Data) {
+				element.Call.DataAbiValues = abiValues
+			}
+		}
 	}
 
 	return nil
@@ -397,13 +522,17 @@ func (fw *FuzzerWorker) testNextCallSequence() ([]ShrinkCallSequenceRequest, err
 	// We will make a copy of the worker's base value set so that we can rollback to it at the end of the call sequence
 	originalValueSet := fw.valueSet.Clone()
 
-	// After testing the sequence, we'll want to rollback changes to reset our testing state.
+	// After testing the sequence, we'll want to rollback changes to reset our testing state, unless the stateful mode
+	// keeps the chain state for the next sequence.
 	var err error
+	keepChainState := false
 	defer func() {
 		// Reset the value set back to the original
 		fw.valueSet = originalValueSet
-		if err == nil {
-			err = fw.chain.RevertToBlockIndex(fw.testingBaseBlockIndex)
+		fw.heavyTracersForced = false
+		if err == nil && !keepChainState {
+			fw.statefulHistory = nil
+			err = fw.revertToBranchPrefix()
 		}
 	}()
 
@@ -414,6 +543,25 @@ func (fw *FuzzerWorker) testNextCallSequence() ([]ShrinkCallSequenceRequest, err
 		return nil, err
 	}
 
+	// Skip tracing the fitness metrics which saturated, and re-probe them once due.
+	fw.updateTracedMetrics()
+
+	// Un-executed or remote corpus call sequences being replayed may be traced in full by heavy tracers. Sequences
+	// mutated from the corpus are sampled like new ones.
+	fw.heavyTracersForced = fw.sequenceGenerator.ReplayingCorpus() && fw.fuzzer.config.Fuzzing.TracerSampling.TraceCorpusReplays
+
+	// In the stateful mode, or when branching from a prefix, our sequence may execute on top of previously executed
+	// calls rather than the testing base.
+	var sequencePrefix calls.CallSequence
+	if fw.fuzzer.config.Fuzzing.PrefixBranching.Enabled {
+		sequencePrefix, err = fw.prepareBranchPrefix(isNewSequence)
+	} else {
+		sequencePrefix, err = fw.prepareStatefulPrefix(isNewSequence)
+	}
+	if err != nil {
+		return nil, err
+	}
+
 	// Define our shrink requests we'll collect during execution.
 	shrinkCallSequenceRequests := make([]ShrinkCallSequenceRequest, 0)
 
@@ -440,6 +588,12 @@ func (fw *FuzzerWorker) testNextCallSequence() ([]ShrinkCallSequenceRequest, err
 	executionCheckFunc := func(currentlyExecutedSequence calls.CallSequence) (bool, error) {
 		// Get the last call sequence element that was executed
 		latestCallSequenceElement := currentlyExecutedSequence[len(currentlyExecutedSequence)-1]
+
+		// If we executed on top of a prefix, the prefix is required to reproduce our state, so we consider it part of
+		// the sequence when updating the corpus and running tests.
+		if len(sequencePrefix) > 0 {
+			currentlyExecutedSequence = append(append(make(calls.CallSequence, 0, len(sequencePrefix)+len(currentlyExecutedSequence)), sequencePrefix...), currentlyExecutedSequence...)
+		}
 		// Get the decoded return values and add it to the base value set
 		// Don't throw an error since we care more about coverage than adding the return values to the base value set
 		decodedReturnValues, err := latestCallSequenceElement.DecodedReturnValues()
@@ -447,6 +601,15 @@ func (fw *FuzzerWorker) testNextCallSequence() ([]ShrinkCallSequenceRequest, err
 			fw.valueSet.Add(decodedReturnValues)
 		}
 
+		// Record the comparison operands observed by the call, so they can be substituted into future call data.
+		fw.recordCmpLog(latestCallSequenceElement)
+
+		// Record the storage slots read and written by the call, so the next call may be ordered along dataflow.
+		fw.recordDataflowOrdering(latestCallSequenceElement)
+
+		// Record the block properties the call's branch conditions depend on, so the calls following it warp them.
+		fw.recordBlockDependencies(latestCallSequenceElement)
+
 		// Check for updates to coverage and corpus.
 		// If we detect coverage changes, add this sequence with weight as 1 + sequences tested (to avoid zero weights)
 		// err = fw.fuzzer.corpus.CheckSequenceCoverageAndUpdate(currentlyExecutedSequence, fw.getNewCorpusCallSequenceWeight(), true)
@@ -454,13 +617,29 @@ func (fw *FuzzerWorker) testNextCallSequence() ([]ShrinkCallSequenceRequest, err
 		// 	return true, err
 		// }
 
+		// Attribute any additional fitness metric results to the last call, before the corpus is checked.
+		for _, callSequenceFitnessFunc := range fw.fuzzer.Hooks.CallSequenceFitnessFuncs {
+			err = callSequenceFitnessFunc(fw, currentlyExecutedSequence)
+			if err != nil {
+				return true, err
+			}
+		}
+
 		// For fitness metrics, checking for updates to various fitness mertics and corpus
 		// If we detect some fitness metrics changes, add this sequence with weight as 1 + sequences tested (to avoid zero weights)
-		err = fw.fuzzer.corpus.CheckSequenceMetricAndUpdate(currentlyExecutedSequence, fw.getNewCorpusCallSequenceWeight(), true)
+		fw.lastCallAddedToCorpus, err = fw.fuzzer.corpus.CheckSequenceMetricAndUpdate(currentlyExecutedSequence, fw.getPowerScheduledCallSequenceWeight(currentlyExecutedSequence), true)
 		if err != nil {
 			return true, err
 		}
 
+		// If the sequence was added to the corpus, the corpus call sequences it was derived from proved productive,
+		// so the adaptive power schedule rewards them with more mutation energy. Likewise, the transition between the
+		// on-chain targets of its last two calls is rewarded in the interaction model.
+		if fw.lastCallAddedToCorpus {
+			fw.fuzzer.corpus.RewardMutationTargets(fw.sequenceGenerator.popMutationTargets())
+			fw.recordOnChainInteraction(currentlyExecutedSequence)
+		}
+
 		// Loop through each test function, signal our worker tested a call, and collect any requests to shrink
 		// this call sequence.
 		for _, callSequenceTestFunc := range fw.fuzzer.Hooks.CallSequenceTestFuncs {
@@ -475,14 +654,23 @@ func (fw *FuzzerWorker) testNextCallSequence() ([]ShrinkCallSequenceRequest, err
 		fw.workerMetrics().callsTested.Add(fw.workerMetrics().callsTested, big.NewInt(1))
 		lastCallSequenceElement := currentlyExecutedSequence[len(currentlyExecutedSequence)-1]
 		fw.workerMetrics().gasUsed.Add(fw.workerMetrics().gasUsed, new(big.Int).SetUint64(lastCallSequenceElement.ChainReference.Block.MessageResults[lastCallSequenceElement.ChainReference.TransactionIndex].Receipt.GasUsed))
-		fw.workerMetrics().updateRevertMetrics(lastCallSequenceElement)
+		fw.workerMetrics().updateRevertMetrics(lastCallSequenceElement, fw.deployedContracts)
+		if fw.fuzzer.config.Fuzzing.GasProfilingEnabled {
+			err = fw.workerMetrics().recordGasProfile(currentlyExecutedSequence)
+			if err != nil {
+				return true, fmt.Errorf("error recording the gas profile of call sequence element: %v", err)
+			}
+		}
 
 		// Update indicators for our fuzzing session
-		err = fw.fuzzer.metrics.updateIndicators(latestCallSequenceElement)
+		err = fw.workerMetrics().updateIndicators(latestCallSequenceElement)
 		if err != nil {
 			return true, fmt.Errorf("error updating fuzzing indicators from call sequence element: %v", err)
 		}
 
+		// The fitness metrics of the call were merged, so the tracers can reuse them for later calls.
+		fw.releaseTracerResults(latestCallSequenceElement)
+
 		// If our fuzzer context or the emergency context is cancelled, exit out immediately without results.
 		if utils.CheckContextDone(fw.fuzzer.ctx) {
 			return true, nil
@@ -528,6 +716,17 @@ func (fw *FuzzerWorker) testNextCallSequence() ([]ShrinkCallSequenceRequest, err
 		}
 	}
 
+	// In the stateful mode, persistent workers keep the resulting chain state for the next sequence.
+	if fw.fuzzer.config.Fuzzing.StatefulMode.Enabled {
+		history := append(append(make(calls.CallSequence, 0, len(sequencePrefix)+len(executedSequence)), sequencePrefix...), executedSequence...)
+		keepChainState = fw.finishStatefulSequence(history, isNewSequence, shrinkCallSequenceRequests)
+	}
+
+	// When branching from prefixes, the next call sequence may branch from a prefix of this one.
+	if fw.fuzzer.config.Fuzzing.PrefixBranching.Enabled {
+		fw.finishBranchPrefix(executedSequence, isNewSequence, shrinkCallSequenceRequests)
+	}
+
 	// Return our results accordingly.
 	return shrinkCallSequenceRequests, nil
 }
@@ -539,11 +738,15 @@ func (fw *FuzzerWorker) testShrunkenCallSequence(possibleShrunkSequence calls.Ca
 	// After testing the sequence, we'll want to rollback changes to reset our testing state.
 	var err error
 	defer func() {
+		fw.heavyTracersForced = false
 		if err == nil {
 			err = fw.chain.RevertToBlockIndex(fw.testingBaseBlockIndex)
 		}
 	}()
 
+	// Shrunk call sequences are traced in full by heavy tracers, so the verifier observes the same results.
+	fw.heavyTracersForced = true
+
 	// Our "fetch next call method" method will simply fetch and fix the call message in case any fields are not correct due to shrinking.
 	fetchElementFunc := func(currentIndex int) (*calls.CallSequenceElement, error) {
 		// If we are at the end of our sequence, return nil indicating we should stop executing.
@@ -568,7 +771,7 @@ func (fw *FuzzerWorker) testShrunkenCallSequence(possibleShrunkSequence calls.Ca
 
 		// For fitness metrics, checking for updates to various fitness mertics and corpus (using only the section of the sequence we tested so far).
 		// If we detect some fitness metrics changes, add this sequence with weight as 1 + sequences tested (to avoid zero weights)
-		seqErr := fw.fuzzer.corpus.CheckSequenceMetricAndUpdate(currentlyExecutedSequence, fw.getNewCorpusCallSequenceWeight(), true)
+		_, seqErr := fw.fuzzer.corpus.CheckSequenceMetricAndUpdate(currentlyExecutedSequence, fw.getPowerScheduledCallSequenceWeight(currentlyExecutedSequence), true)
 		if seqErr != nil {
 			return true, seqErr
 		}
@@ -654,6 +857,7 @@ func (fw *FuzzerWorker) shrinkCallSequence(shrinkRequest ShrinkCallSequenceReque
 					if i > 0 {
 						possibleShrunkSequence[i-1].BlockNumberDelay += removedCall.BlockNumberDelay
 						possibleShrunkSequence[i-1].BlockTimestampDelay += removedCall.BlockTimestampDelay
+						possibleShrunkSequence[i-1].TimeJump += removedCall.TimeJump
 					}
 				}
 
@@ -736,11 +940,9 @@ func (fw *FuzzerWorker) shrinkCallSequence(shrinkRequest ShrinkCallSequenceReque
 	return optimizedSequence, err
 }
 
-// run takes a base Chain in a setup state ready for testing, clones it, and begins executing fuzzed transaction calls
-// and asserting properties are upheld. This runs until Fuzzer.ctx or Fuzzer.emergencyCtx cancels the operation.
-// Returns a boolean indicating whether Fuzzer.ctx or Fuzzer.emergencyCtx has indicated we cancel the operation, and an
-// error if one occurred.
-func (fw *FuzzerWorker) run(baseTestChain *chain.TestChain) (bool, error) {
+// setUpChain clones the provided base Chain in a setup state ready for testing as the worker's chain, attaching the
+// worker's tracers and event handlers to it. Returns an error if one occurred.
+func (fw *FuzzerWorker) setUpChain(baseTestChain *chain.TestChain) error {
 	// Clone our chain, attaching our necessary components for fuzzing post-genesis, prior to all blocks being copied.
 	// This means any tracers added or events subscribed to within this inner function are done so prior to chain
 	// setup (initial contract deployments), so data regarding that can be tracked as well.
@@ -777,6 +979,15 @@ func (fw *FuzzerWorker) run(baseTestChain *chain.TestChain) (bool, error) {
 		return nil
 	})
 
+	if err != nil {
+		return err
+	}
+
+	// Let the worker choose the values returned by mock contracts, once the chain's setup has been replayed.
+	if len(fw.chain.MockContracts()) > 0 {
+		fw.chain.MockResponseProvider = fw.sequenceGenerator.generateMockReturnData
+	}
+
 	// emit contract discovery event for initially present contracts
 	for _, targetAddress := range fw.fuzzer.config.Fuzzing.TargetContracts {
 		if common.IsHexAddress(targetAddress) {
@@ -791,7 +1002,7 @@ func (fw *FuzzerWorker) run(baseTestChain *chain.TestChain) (bool, error) {
 					IsInitialization: true,
 				})
 			} else {
-				return false, fmt.Errorf("the on-chain contract in %s is empty", strings.ToLower(targetAddress))
+				return fmt.Errorf("the on-chain contract in %s is empty", strings.ToLower(targetAddress))
 			}
 		}
 	}
@@ -804,9 +1015,20 @@ func (fw *FuzzerWorker) run(baseTestChain *chain.TestChain) (bool, error) {
 			initialContractsSet[addr] = struct{}{}
 		}
 		fw.coverageTracer.SetInitialContractsSet(&initialContractsSet)
+		fw.coverageTracer.SetAddressAttribution(fw.fuzzer.config.Fuzzing.CoverageAddressAttribution)
 	}
 
 	// If we encountered an error during cloning, return it.
+	return err
+}
+
+// run takes a base Chain in a setup state ready for testing, clones it, and begins executing fuzzed transaction calls
+// and asserting properties are upheld. This runs until Fuzzer.ctx or Fuzzer.emergencyCtx cancels the operation.
+// Returns a boolean indicating whether Fuzzer.ctx or Fuzzer.emergencyCtx has indicated we cancel the operation, and an
+// error if one occurred.
+func (fw *FuzzerWorker) run(baseTestChain *chain.TestChain) (bool, error) {
+	// Clone our chain, attaching our tracers and event handlers.
+	err := fw.setUpChain(baseTestChain)
 	if err != nil {
 		return false, err
 	}
@@ -826,6 +1048,9 @@ func (fw *FuzzerWorker) run(baseTestChain *chain.TestChain) (bool, error) {
 	// Increase our generation metric as we successfully generated a test node
 	fw.workerMetrics().workerStartupCount.Add(fw.workerMetrics().workerStartupCount, big.NewInt(1))
 
+	// Send any fuzzing indicators recorded but not yet merged once this worker exits
+	defer fw.workerMetrics().flushIndicators()
+
 	// Save the current block index as all contracts have been deployed at this point, and we'll want to revert
 	// to this state between testing.
 	fw.testingBaseBlockIndex = uint64(len(fw.chain.CommittedBlocks()))
@@ -877,6 +1102,12 @@ func (fw *FuzzerWorker) run(baseTestChain *chain.TestChain) (bool, error) {
 			return true, nil
 		}
 
+		// If metrics were paused or resumed since our tracers were attached, exit so we are recreated with tracers
+		// attached accordingly.
+		if fw.metricGeneration != fw.fuzzer.metricControl.generation.Load() {
+			break
+		}
+
 		// Emit an event indicating the worker is about to test a new call sequence.
 		err := fw.Events.CallSequenceTesting.Publish(FuzzerWorkerCallSequenceTestingEvent{
 			Worker: fw,
@@ -0,0 +1,43 @@
+package fuzzing
+
+import (
+	"github.com/crytic/medusa/fuzzing/bugdetector"
+	"github.com/crytic/medusa/fuzzing/calls"
+)
+
+// recordBlockDependencies records the block properties the branch conditions of the provided call sequence element
+// were found to depend on by the block dependency bug detector, so the calls following it in the sequence warp them.
+func (fw *FuzzerWorker) recordBlockDependencies(element *calls.CallSequenceElement) {
+	if !fw.fuzzer.config.Fuzzing.BlockWarp.Enabled || element.ChainReference == nil {
+		return
+	}
+	if bugMap := bugdetector.GetBugDetectorTracerResults(element.ChainReference.MessageResults()); bugMap != nil {
+		fw.sequenceGenerator.blockDependencies |= bugMap.BlockDependencies()
+	}
+}
+
+// applyBlockWarp advances the block time before the provided call by a warp, with the configured probability, if a
+// previous call in the sequence had branch conditions depending on the block timestamp or number. The block number is
+// advanced alongside it according to the configured block time if the branch conditions depended on it, or by a
+// single block otherwise. The warp is added to the delays the call already has, so it does not discard a time jump
+// applied by the time acceleration schedule.
+func (g *CallSequenceGenerator) applyBlockWarp(element *calls.CallSequenceElement) {
+	blockWarp := g.worker.fuzzer.config.Fuzzing.BlockWarp
+	if !blockWarp.Enabled || g.blockDependencies == 0 {
+		return
+	}
+	if g.worker.randomProvider.Float32() >= blockWarp.WarpProbability {
+		return
+	}
+
+	warp := blockWarp.Warps[g.worker.randomProvider.Intn(len(blockWarp.Warps))]
+	if warp == 0 {
+		return
+	}
+	element.BlockTimestampDelay += warp
+	if g.blockDependencies&bugdetector.NumberDependency != 0 {
+		element.BlockNumberDelay += max(warp/blockWarp.BlockTime, 1)
+	} else {
+		element.BlockNumberDelay++
+	}
+}
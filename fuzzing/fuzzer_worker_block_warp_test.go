@@ -0,0 +1,119 @@
+package fuzzing
+
+import (
+	"math/rand"
+	"testing"
+
+	chainTypes "github.com/crytic/medusa/chain/types"
+	"github.com/crytic/medusa/fuzzing/bugdetector"
+	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestApplyBlockWarp ensures calls are only warped after a call whose branch conditions depended on the block, that
+// the block number is only scaled by the block time if they depended on it, and that warps are added to the delays
+// calls already have.
+func TestApplyBlockWarp(t *testing.T) {
+	tests := []struct {
+		name                string
+		enabled             bool
+		warpProbability     float32
+		blockDependencies   bugdetector.BlockDependency
+		expectedNumberDelay uint64
+		expectedTimeDelay   uint64
+	}{
+		{
+			name:                "disabled",
+			warpProbability:     1,
+			blockDependencies:   bugdetector.TimestampDependency,
+			expectedNumberDelay: 2,
+			expectedTimeDelay:   30,
+		},
+		{
+			name:                "no block dependency",
+			enabled:             true,
+			warpProbability:     1,
+			expectedNumberDelay: 2,
+			expectedTimeDelay:   30,
+		},
+		{
+			name:                "never warped",
+			enabled:             true,
+			blockDependencies:   bugdetector.TimestampDependency,
+			expectedNumberDelay: 2,
+			expectedTimeDelay:   30,
+		},
+		{
+			name:                "timestamp dependency",
+			enabled:             true,
+			warpProbability:     1,
+			blockDependencies:   bugdetector.TimestampDependency,
+			expectedNumberDelay: 3,
+			expectedTimeDelay:   86_430,
+		},
+		{
+			name:                "number dependency",
+			enabled:             true,
+			warpProbability:     1,
+			blockDependencies:   bugdetector.NumberDependency,
+			expectedNumberDelay: 7_202,
+			expectedTimeDelay:   86_430,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			projectConfig, err := config.GetDefaultProjectConfig("")
+			assert.NoError(t, err)
+			projectConfig.Fuzzing.BlockWarp = config.BlockWarpConfig{
+				Enabled:         test.enabled,
+				Warps:           []uint64{86_400},
+				WarpProbability: test.warpProbability,
+				BlockTime:       12,
+			}
+			worker := &FuzzerWorker{fuzzer: &Fuzzer{config: *projectConfig}, randomProvider: rand.New(rand.NewSource(0))}
+			generator := &CallSequenceGenerator{worker: worker, blockDependencies: test.blockDependencies}
+
+			element := calls.NewCallSequenceElement(nil, nil, 2, 30)
+			generator.applyBlockWarp(element)
+			assert.EqualValues(t, test.expectedNumberDelay, element.BlockNumberDelay)
+			assert.EqualValues(t, test.expectedTimeDelay, element.BlockTimestampDelay)
+		})
+	}
+}
+
+// TestRecordBlockDependencies ensures the block properties the branch conditions of executed calls depended on are
+// accumulated over the call sequence, and only if block warps are enabled.
+func TestRecordBlockDependencies(t *testing.T) {
+	// getExecutedElement returns a call sequence element whose execution recorded the provided block dependencies.
+	getExecutedElement := func(dependency bugdetector.BlockDependency) *calls.CallSequenceElement {
+		bugMap := bugdetector.NewBugMap()
+		bugMap.AddBlockDependency(dependency)
+		element := calls.NewCallSequenceElement(nil, nil, 0, 0)
+		element.ChainReference = &calls.CallSequenceElementChainReference{
+			Block: &chainTypes.Block{MessageResults: []*chainTypes.MessageResults{{
+				AdditionalResults: map[string]any{"BugDetectorTracerResults": bugMap},
+			}}},
+		}
+		return element
+	}
+
+	for _, enabled := range []bool{false, true} {
+		projectConfig, err := config.GetDefaultProjectConfig("")
+		assert.NoError(t, err)
+		projectConfig.Fuzzing.BlockWarp.Enabled = enabled
+		worker := &FuzzerWorker{fuzzer: &Fuzzer{config: *projectConfig}}
+		worker.sequenceGenerator = &CallSequenceGenerator{worker: worker}
+
+		worker.recordBlockDependencies(calls.NewCallSequenceElement(nil, nil, 0, 0))
+		worker.recordBlockDependencies(getExecutedElement(0))
+		assert.EqualValues(t, 0, worker.sequenceGenerator.blockDependencies)
+		worker.recordBlockDependencies(getExecutedElement(bugdetector.TimestampDependency))
+		worker.recordBlockDependencies(getExecutedElement(bugdetector.NumberDependency))
+		if enabled {
+			assert.EqualValues(t, bugdetector.TimestampDependency|bugdetector.NumberDependency, worker.sequenceGenerator.blockDependencies)
+		} else {
+			assert.EqualValues(t, 0, worker.sequenceGenerator.blockDependencies)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package fuzzing
+
+import (
+	"math/big"
+	"math/rand"
+	"slices"
+	"sync"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/crytic/medusa/utils/randomutils"
+)
+
+// newStrategyChooser creates a weighted random chooser over the provided strategy weights, in a deterministic order,
+// which makes its choices with the provided random provider.
+func newStrategyChooser(weights map[string]uint64, randomProvider *rand.Rand) *randomutils.WeightedRandomChooser[string] {
+	strategies := make([]string, 0, len(weights))
+	for strategy := range weights {
+		strategies = append(strategies, strategy)
+	}
+	slices.Sort(strategies)
+
+	chooser := randomutils.NewWeightedRandomChooserWithRand[string](randomProvider, &sync.Mutex{})
+	for _, strategy := range strategies {
+		chooser.AddChoices(randomutils.NewWeightedRandomChoice(strategy, new(big.Int).SetUint64(weights[strategy])))
+	}
+	return chooser
+}
+
+// chooseStrategy returns a weighted random strategy from the provided chooser, or an empty string, which is treated as
+// the random strategy, if none could be chosen.
+func chooseStrategy(chooser *randomutils.WeightedRandomChooser[string]) string {
+	strategy, err := chooser.Choose()
+	if strategy == nil || err != nil {
+		return ""
+	}
+	return *strategy
+}
+
+// initializeSenderStrategy chooses the sender strategy of the next call sequence, along with its attacker and the
+// sender it starts at in the round-robin strategy.
+func (g *CallSequenceGenerator) initializeSenderStrategy() {
+	senders := g.worker.fuzzer.senders
+	g.senderStrategy = chooseStrategy(g.senderStrategyChooser)
+	if len(senders) == 0 {
+		return
+	}
+	g.senderOffset = g.worker.randomProvider.Intn(len(senders))
+	g.attacker = senders[g.senderOffset]
+}
+
+// selectSender returns the sender of the next generated call, according to the sender strategy of the current call
+// sequence.
+func (g *CallSequenceGenerator) selectSender() common.Address {
+	senders := g.worker.fuzzer.senders
+	switch g.senderStrategy {
+	case config.RoundRobinSenderStrategy:
+		return senders[(g.senderOffset+g.fetchIndex)%len(senders)]
+	case config.StickyAttackerSenderStrategy:
+		return g.attacker
+	case config.OwnerThenAttackerSenderStrategy:
+		if g.fetchIndex < g.worker.fuzzer.config.Fuzzing.SenderStrategy.OwnerCalls {
+			return g.worker.fuzzer.deployer
+		}
+		return g.attacker
+	default:
+		return senders[g.worker.randomProvider.Intn(len(senders))]
+	}
+}
+
+// generateValue returns the msg.value of a generated call from the provided sender to a payable method of the
+// contract deployed at the provided address, according to a weighted random value strategy.
+func (g *CallSequenceGenerator) generateValue(sender common.Address, contract common.Address) *big.Int {
+	switch chooseStrategy(g.valueStrategyChooser) {
+	case config.ZeroHeavyValueStrategy:
+		// Send no value nine times out of ten.
+		if g.worker.randomProvider.Intn(10) != 0 {
+			return big.NewInt(0)
+		}
+	case config.BalanceProportionalValueStrategy:
+		// Send between none and all of the sender's balance, in eighths.
+		balance := g.worker.chain.State().GetBalance(sender).ToBig()
+		balance.Mul(balance, big.NewInt(int64(g.worker.randomProvider.Intn(9))))
+		return balance.Div(balance, big.NewInt(8))
+	case config.DustValueStrategy:
+		return big.NewInt(int64(g.worker.randomProvider.Intn(1_000) + 1))
+	case config.ThresholdValueStrategy:
+		// Send a comparison operand logged for the contract, or one off it, to hit exact thresholds.
+		if g.worker.fuzzer.operandDictionary != nil {
+			if value := g.worker.fuzzer.operandDictionary.RandomInteger(contract, g.worker.randomProvider.Intn); value != nil {
+				value.Add(value, big.NewInt(int64(g.worker.randomProvider.Intn(3)-1)))
+				if value.Sign() < 0 || value.BitLen() > 256 {
+					return big.NewInt(0)
+				}
+				return value
+			}
+		}
+	}
+	return g.config.ValueGenerator.GenerateInteger(false, 64)
+}
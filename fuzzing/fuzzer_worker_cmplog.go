@@ -0,0 +1,112 @@
+package fuzzing
+
+import (
+	"math/big"
+	"slices"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/cmplog"
+	"github.com/holiman/uint256"
+)
+
+// cmpLogSubstitution describes a call data word which matched a logged comparison operand, and the value it should be
+// substituted with.
+type cmpLogSubstitution struct {
+	// offset is the offset of the matching word in the call data.
+	offset int
+	// value is the value to write at the offset.
+	value *uint256.Int
+}
+
+// recordCmpLog records the comparison operands observed during the execution of the provided call sequence element,
+// retaining the most recent operand pairs for substitution into call data. The operands are also added to the value
+// set for the current call sequence and, along with the KECCAK256 preimages observed, to the fuzzer's operand
+// dictionary for the contract called.
+func (fw *FuzzerWorker) recordCmpLog(element *calls.CallSequenceElement) {
+	if fw.cmpLogTracer == nil || element.ChainReference == nil {
+		return
+	}
+
+	messageResults := element.ChainReference.MessageResults()
+	operandPairs := cmplog.GetCmpLogTracerResults(messageResults)
+	if fw.fuzzer.operandDictionary != nil && element.Call.To != nil {
+		fw.recordOperandDictionary(*element.Call.To, operandPairs, cmplog.GetCmpLogTracerPreimages(messageResults))
+	}
+	if len(operandPairs) == 0 {
+		return
+	}
+	for _, operandPair := range operandPairs {
+		fw.valueSet.AddInteger(operandPair.Lhs.ToBig())
+		fw.valueSet.AddInteger(operandPair.Rhs.ToBig())
+	}
+
+	// Retain only the most recent operand pairs.
+	fw.cmpLogPairs = append(fw.cmpLogPairs, operandPairs...)
+	if excess := len(fw.cmpLogPairs) - fw.fuzzer.config.Fuzzing.CmpLog.MaxOperandPairs; excess > 0 {
+		fw.cmpLogPairs = slices.Clone(fw.cmpLogPairs[excess:])
+	}
+}
+
+// recordOperandDictionary adds the provided comparison operands and KECCAK256 preimages, observed in a call to the
+// provided contract, to the fuzzer's operand dictionary. Preimages are also split into 32-byte words (e.g. the key of
+// a mapping entry), which are added as integers.
+func (fw *FuzzerWorker) recordOperandDictionary(contract common.Address, operandPairs []cmplog.OperandPair, preimages [][]byte) {
+	for _, operandPair := range operandPairs {
+		fw.fuzzer.operandDictionary.AddInteger(contract, operandPair.Lhs.ToBig())
+		fw.fuzzer.operandDictionary.AddInteger(contract, operandPair.Rhs.ToBig())
+	}
+	for _, preimage := range preimages {
+		fw.fuzzer.operandDictionary.AddBytes(contract, preimage)
+		for offset := 0; offset+32 <= len(preimage); offset += 32 {
+			fw.fuzzer.operandDictionary.AddInteger(contract, new(big.Int).SetBytes(preimage[offset:offset+32]))
+		}
+	}
+}
+
+// prefetchModifyCallFuncCmpLog is a PrefetchModifyCallFunc, called by a CallSequenceGenerator to substitute a call data
+// word matching a logged comparison operand with the operand it was compared against, prior to the call sequence
+// element being fetched. If no word matches, the element is mutated as with prefetchModifyCallFuncMutate instead.
+// Returns an error if one occurs.
+func prefetchModifyCallFuncCmpLog(sequenceGenerator *CallSequenceGenerator, element *calls.CallSequenceElement) error {
+	// If this element has no ABI value based call data, exit early.
+	if element.Call == nil || element.Call.DataAbiValues == nil {
+		return nil
+	}
+
+	// Encode our call data so that we can look for words matching logged operands.
+	abiValuesMsgData := element.Call.DataAbiValues
+	callData, err := abiValuesMsgData.Pack()
+	if err != nil || len(sequenceGenerator.worker.cmpLogPairs) == 0 {
+		return prefetchModifyCallFuncMutate(sequenceGenerator, element)
+	}
+
+	// Collect every substitution available for the words of our arguments.
+	substitutions := make([]cmpLogSubstitution, 0)
+	for offset := len(abiValuesMsgData.Method.ID); offset+32 <= len(callData); offset += 32 {
+		word := new(uint256.Int).SetBytes32(callData[offset : offset+32])
+		for _, operandPair := range sequenceGenerator.worker.cmpLogPairs {
+			for _, substitute := range operandPair.Substitutes(word) {
+				substitutions = append(substitutions, cmpLogSubstitution{offset: offset, value: substitute})
+			}
+		}
+	}
+	if len(substitutions) == 0 {
+		return prefetchModifyCallFuncMutate(sequenceGenerator, element)
+	}
+
+	// Apply a random substitution and decode our arguments from the updated call data. If the substituted value is not
+	// valid for the argument type, we leave the element unmodified.
+	substitution := substitutions[sequenceGenerator.worker.randomProvider.Intn(len(substitutions))]
+	substitutedWord := substitution.value.Bytes32()
+	copy(callData[substitution.offset:], substitutedWord[:])
+	inputValues, err := abiValuesMsgData.Method.Inputs.Unpack(callData[len(abiValuesMsgData.Method.ID):])
+	if err != nil {
+		return nil
+	}
+	abiValuesMsgData.InputValues = inputValues
+
+	// Re-encode the message's calldata
+	element.Call.WithDataAbiValues(abiValuesMsgData)
+	return nil
+}
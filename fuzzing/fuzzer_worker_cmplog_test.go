@@ -0,0 +1,97 @@
+package fuzzing
+
+import (
+	"math/big"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/crytic/medusa-geth/accounts/abi"
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/cmplog"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPrefetchModifyCallFuncCmpLog ensures call data words matching a logged comparison operand are substituted with
+// the operand they were compared against, re-encoding the call's arguments, and that substitutions which are not
+// valid for the argument's type leave the call unmodified.
+func TestPrefetchModifyCallFuncCmpLog(t *testing.T) {
+	contractAbi, err := abi.JSON(strings.NewReader(`[
+		{"type": "function", "name": "set", "stateMutability": "nonpayable", "inputs": [{"name": "key", "type": "uint256"}, {"name": "value", "type": "uint256"}]},
+		{"type": "function", "name": "toggle", "stateMutability": "nonpayable", "inputs": [{"name": "enabled", "type": "bool"}]}
+	]`))
+	assert.NoError(t, err)
+
+	// newOperandPair returns an OperandPair for a comparison of the provided operands.
+	newOperandPair := func(op vm.OpCode, lhs uint64, rhs uint64) cmplog.OperandPair {
+		return cmplog.OperandPair{Op: op, Lhs: *uint256.NewInt(lhs), Rhs: *uint256.NewInt(rhs)}
+	}
+	tests := []struct {
+		name         string
+		method       string
+		inputValues  []any
+		operandPairs []cmplog.OperandPair
+		expected     [][]any
+	}{
+		{
+			name:         "equality",
+			method:       "set",
+			inputValues:  []any{big.NewInt(3), big.NewInt(7)},
+			operandPairs: []cmplog.OperandPair{newOperandPair(vm.EQ, 7, 1234)},
+			expected:     [][]any{{big.NewInt(3), big.NewInt(1234)}},
+		},
+		{
+			name:         "ordering",
+			method:       "set",
+			inputValues:  []any{big.NewInt(1234), big.NewInt(9)},
+			operandPairs: []cmplog.OperandPair{newOperandPair(vm.LT, 100, 1234)},
+			expected:     [][]any{{big.NewInt(100), big.NewInt(9)}, {big.NewInt(101), big.NewInt(9)}, {big.NewInt(99), big.NewInt(9)}},
+		},
+		{
+			name:         "multiple words",
+			method:       "set",
+			inputValues:  []any{big.NewInt(7), big.NewInt(7)},
+			operandPairs: []cmplog.OperandPair{newOperandPair(vm.EQ, 5, 7)},
+			expected:     [][]any{{big.NewInt(5), big.NewInt(7)}, {big.NewInt(7), big.NewInt(5)}},
+		},
+		{
+			name:         "invalid for type",
+			method:       "toggle",
+			inputValues:  []any{true},
+			operandPairs: []cmplog.OperandPair{newOperandPair(vm.EQ, 1, 2)},
+			expected:     [][]any{{true}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			worker := &FuzzerWorker{cmpLogPairs: test.operandPairs}
+			generator := &CallSequenceGenerator{worker: worker}
+			method := contractAbi.Methods[test.method]
+			to := common.HexToAddress("0x1234")
+			for seed := int64(0); seed < 10; seed++ {
+				worker.randomProvider = rand.New(rand.NewSource(seed))
+				msg := calls.NewCallMessageWithAbiValueData(common.HexToAddress("0x10000"), &to, 0, big.NewInt(0), 1_000_000, big.NewInt(1), big.NewInt(1), big.NewInt(1), &calls.CallMessageDataAbiValues{
+					Method:      &method,
+					InputValues: append([]any(nil), test.inputValues...),
+				})
+				element := calls.NewCallSequenceElement(nil, msg, 0, 0)
+				assert.NoError(t, prefetchModifyCallFuncCmpLog(generator, element))
+				assert.Contains(t, test.expected, element.Call.DataAbiValues.InputValues)
+
+				// The call data is re-encoded from the substituted arguments.
+				data, err := element.Call.DataAbiValues.Pack()
+				assert.NoError(t, err)
+				assert.EqualValues(t, data, element.Call.Data)
+			}
+		})
+	}
+
+	// Calls without ABI values are left unmodified.
+	generator := &CallSequenceGenerator{worker: &FuzzerWorker{cmpLogPairs: []cmplog.OperandPair{newOperandPair(vm.EQ, 1, 2)}}}
+	element := calls.NewCallSequenceElement(nil, calls.NewCallMessage(common.Address{}, nil, 0, big.NewInt(0), 0, nil, nil, nil, []byte{1}), 0, 0)
+	assert.NoError(t, prefetchModifyCallFuncCmpLog(generator, element))
+	assert.EqualValues(t, []byte{1}, element.Call.Data)
+}
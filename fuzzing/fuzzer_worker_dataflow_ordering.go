@@ -0,0 +1,63 @@
+package fuzzing
+
+import (
+	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/fuzzing/contracts"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/dataflow"
+)
+
+// getMethodKey returns the dataflow.MethodKey identifying the provided method of the contract deployed at the
+// provided address.
+func getMethodKey(method *contracts.DeployedContractMethod) dataflow.MethodKey {
+	key := dataflow.MethodKey{Address: method.Address}
+	copy(key.Selector[:], method.Method.ID)
+	return key
+}
+
+// recordDataflowOrdering records the storage slots read by the provided call sequence element as read by the method it
+// called, in the fuzzer's slot readers, and retains the storage slots it wrote, so that the next call generated for
+// the sequence may target a method reading them.
+func (fw *FuzzerWorker) recordDataflowOrdering(element *calls.CallSequenceElement) {
+	fw.sequenceGenerator.writtenVariables = nil
+	if fw.fuzzer.slotReaders == nil || element.ChainReference == nil || element.Call.To == nil {
+		return
+	}
+	dataflowSet := dataflow.GetDataflowTracerResults(element.ChainReference.MessageResults())
+	if dataflowSet == nil {
+		return
+	}
+
+	// Attribute the reads to the method called, identified by the selector of the call data.
+	if len(element.Call.Data) >= 4 {
+		key := dataflow.MethodKey{Address: *element.Call.To}
+		copy(key.Selector[:], element.Call.Data[:4])
+		fw.fuzzer.slotReaders.Record(key, dataflowSet.ReadVariables())
+	}
+	fw.sequenceGenerator.writtenVariables = dataflowSet.WrittenVariables()
+}
+
+// dataflowOrderedMethod returns a random state-changing method previously observed reading a storage slot written by
+// the last call of the current sequence, with the configured probability.
+// Returns nil if no such method was chosen.
+func (g *CallSequenceGenerator) dataflowOrderedMethod() *contracts.DeployedContractMethod {
+	slotReaders := g.worker.fuzzer.slotReaders
+	if slotReaders == nil || len(g.writtenVariables) == 0 {
+		return nil
+	}
+	if g.worker.randomProvider.Float32() >= g.worker.fuzzer.config.Fuzzing.Dataflow.OrderingProbability {
+		return nil
+	}
+
+	// Collect the methods reading any of the written slots, and choose one of them.
+	readers := make([]*contracts.DeployedContractMethod, 0)
+	for i := range g.worker.stateChangingMethods {
+		method := &g.worker.stateChangingMethods[i]
+		if slotReaders.ReadsAny(getMethodKey(method), g.writtenVariables) {
+			readers = append(readers, method)
+		}
+	}
+	if len(readers) == 0 {
+		return nil
+	}
+	return readers[g.worker.randomProvider.Intn(len(readers))]
+}
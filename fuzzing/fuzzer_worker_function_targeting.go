@@ -0,0 +1,149 @@
+package fuzzing
+
+import (
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/crytic/medusa-geth/accounts/abi"
+	"github.com/crytic/medusa-geth/common/hexutil"
+	"github.com/crytic/medusa/fuzzing/config"
+	fuzzerTypes "github.com/crytic/medusa/fuzzing/contracts"
+	"github.com/crytic/medusa/utils/randomutils"
+)
+
+// functionMatches indicates whether the provided function identifier, a signature (e.g. "setOwner(address)") or a
+// 4-byte selector (e.g. "0x13af4035"), identifies the provided method.
+func functionMatches(function string, method *abi.Method) bool {
+	return function == method.Sig || strings.EqualFold(function, hexutil.Encode(method.ID))
+}
+
+// functionListed indicates whether any of the provided function identifiers identifies the provided method.
+func functionListed(functions []string, method *abi.Method) bool {
+	for _, function := range functions {
+		if functionMatches(function, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// functionTargeted indicates whether generated calls may target the provided method of the provided contract,
+// according to the contract functions configuration of the project configuration.
+func (fw *FuzzerWorker) functionTargeted(contract *fuzzerTypes.Contract, method *abi.Method) bool {
+	contractFunctions, ok := fw.fuzzer.config.Fuzzing.ContractFunctions[contract.Name()]
+	if !ok {
+		return true
+	}
+	if len(contractFunctions.TargetFunctions) > 0 {
+		return functionListed(contractFunctions.TargetFunctions, method)
+	}
+	return !functionListed(contractFunctions.ExcludedFunctions, method)
+}
+
+// functionWeight returns the relative frequency generated calls target the provided method of the provided contract
+// with, according to the contract functions configuration of the project configuration. A weight keyed by the
+// method's signature takes precedence over one keyed by its selector.
+func (fw *FuzzerWorker) functionWeight(contract *fuzzerTypes.Contract, method *abi.Method) uint64 {
+	contractFunctions, ok := fw.fuzzer.config.Fuzzing.ContractFunctions[contract.Name()]
+	if !ok {
+		return 1
+	}
+	if weight, ok := contractFunctions.Weights[method.Sig]; ok {
+		return weight
+	}
+	for function, weight := range contractFunctions.Weights {
+		if functionMatches(function, method) {
+			return weight
+		}
+	}
+	return 1
+}
+
+// updateStateChangingMethodChooser creates the weighted random chooser of the indices of stateChangingMethods, if any
+// of them is weighted differently from the others. Otherwise, methods are chosen uniformly and the chooser is nil.
+func (fw *FuzzerWorker) updateStateChangingMethodChooser() {
+	fw.stateChangingMethodChooser = nil
+	weights := make([]uint64, len(fw.stateChangingMethods))
+	weighted := false
+	for i := range fw.stateChangingMethods {
+		weights[i] = fw.functionWeight(fw.stateChangingMethods[i].Contract, &fw.stateChangingMethods[i].Method)
+		weighted = weighted || weights[i] != weights[0]
+	}
+	if !weighted {
+		return
+	}
+
+	fw.stateChangingMethodChooser = randomutils.NewWeightedRandomChooserWithRand[int](fw.randomProvider, &sync.Mutex{})
+	for i, weight := range weights {
+		fw.stateChangingMethodChooser.AddChoices(randomutils.NewWeightedRandomChoice(i, new(big.Int).SetUint64(weight)))
+	}
+}
+
+// chooseStateChangingMethod returns a state changing method for a generated call to target, chosen with a probability
+// proportional to its weight. The worker must have at least one state changing method.
+func (fw *FuzzerWorker) chooseStateChangingMethod() *fuzzerTypes.DeployedContractMethod {
+	if fw.stateChangingMethodChooser != nil {
+		if index, err := fw.stateChangingMethodChooser.Choose(); err == nil && index != nil {
+			return &fw.stateChangingMethods[*index]
+		}
+	}
+	return &fw.stateChangingMethods[fw.randomProvider.Intn(len(fw.stateChangingMethods))]
+}
+
+// warnUnmatchedContractFunctions logs a warning for each contract of the contract functions configuration which is not
+// known to the fuzzer, and each function configured for a known contract which none of its methods match, as they are
+// likely misspelled.
+func (f *Fuzzer) warnUnmatchedContractFunctions() {
+	contractNames := make([]string, 0, len(f.config.Fuzzing.ContractFunctions))
+	for contractName := range f.config.Fuzzing.ContractFunctions {
+		contractNames = append(contractNames, contractName)
+	}
+	sort.Strings(contractNames)
+
+	for _, contractName := range contractNames {
+		var contractAbi *abi.ABI
+		for _, contract := range f.contractDefinitions {
+			if contract.Name() == contractName {
+				contractAbi = &contract.CompiledContract().Abi
+				break
+			}
+		}
+		if contractAbi == nil {
+			f.logger.Warn("Ignoring the function configuration of unknown contract ", contractName)
+			continue
+		}
+
+		for _, function := range configuredFunctions(f.config.Fuzzing.ContractFunctions[contractName]) {
+			matched := false
+			for _, method := range contractAbi.Methods {
+				if functionMatches(function, &method) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				f.logger.Warn("No method of contract ", contractName, " matches the configured function ", function)
+			}
+		}
+	}
+}
+
+// configuredFunctions returns the distinct function identifiers of the provided contract functions configuration,
+// sorted.
+func configuredFunctions(contractFunctions config.ContractFunctionsConfig) []string {
+	functions := append(append([]string{}, contractFunctions.TargetFunctions...), contractFunctions.ExcludedFunctions...)
+	for function := range contractFunctions.Weights {
+		functions = append(functions, function)
+	}
+	sort.Strings(functions)
+
+	distinct := make([]string, 0, len(functions))
+	for i, function := range functions {
+		if i == 0 || function != functions[i-1] {
+			distinct = append(distinct, function)
+		}
+	}
+	return distinct
+}
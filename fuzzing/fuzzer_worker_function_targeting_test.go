@@ -0,0 +1,58 @@
+package fuzzing
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/crytic/medusa-geth/accounts/abi"
+	"github.com/crytic/medusa-geth/common/hexutil"
+	compilationTypes "github.com/crytic/medusa/compilation/types"
+	"github.com/crytic/medusa/fuzzing/config"
+	fuzzerTypes "github.com/crytic/medusa/fuzzing/contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFunctionTargeting ensures the contract functions configuration excludes functions from generated calls by
+// signature or selector, and weights how often the remaining ones are chosen.
+func TestFunctionTargeting(t *testing.T) {
+	addressType, _ := abi.NewType("address", "", nil)
+	setOwner := abi.NewMethod("setOwner", "setOwner", abi.Function, "nonpayable", false, false, abi.Arguments{{Type: addressType}}, nil)
+	deposit := abi.NewMethod("deposit", "deposit", abi.Function, "payable", false, false, nil, nil)
+	withdraw := abi.NewMethod("withdraw", "withdraw", abi.Function, "nonpayable", false, false, nil, nil)
+	vault := fuzzerTypes.NewContract("Vault", "Vault.sol", &compilationTypes.CompiledContract{}, nil)
+	other := fuzzerTypes.NewContract("Other", "Other.sol", &compilationTypes.CompiledContract{}, nil)
+
+	projectConfig, err := config.GetDefaultProjectConfig("")
+	assert.NoError(t, err)
+	projectConfig.Fuzzing.ContractFunctions = map[string]config.ContractFunctionsConfig{
+		"Vault": {
+			ExcludedFunctions: []string{hexutil.Encode(setOwner.ID)},
+			Weights:           map[string]uint64{"withdraw()": 99},
+		},
+	}
+	fw := &FuzzerWorker{fuzzer: &Fuzzer{config: *projectConfig}, randomProvider: rand.New(rand.NewSource(0))}
+
+	// Functions are excluded by selector, and only for the configured contract.
+	assert.False(t, fw.functionTargeted(vault, &setOwner))
+	assert.True(t, fw.functionTargeted(vault, &deposit))
+	assert.True(t, fw.functionTargeted(other, &setOwner))
+	assert.EqualValues(t, 99, fw.functionWeight(vault, &withdraw))
+	assert.EqualValues(t, 1, fw.functionWeight(vault, &deposit))
+
+	// Weighted functions should be chosen proportionally more often.
+	fw.stateChangingMethods = []fuzzerTypes.DeployedContractMethod{{Contract: vault, Method: deposit}, {Contract: vault, Method: withdraw}}
+	fw.updateStateChangingMethodChooser()
+	assert.NotNil(t, fw.stateChangingMethodChooser)
+	withdrawals := 0
+	for i := 0; i < 1000; i++ {
+		if fw.chooseStateChangingMethod().Method.Name == "withdraw" {
+			withdrawals++
+		}
+	}
+	assert.Greater(t, withdrawals, 900)
+
+	// Methods with equal weights are chosen uniformly, without a chooser.
+	fw.stateChangingMethods = []fuzzerTypes.DeployedContractMethod{{Contract: vault, Method: deposit}, {Contract: other, Method: setOwner}}
+	fw.updateStateChangingMethodChooser()
+	assert.Nil(t, fw.stateChangingMethodChooser)
+}
@@ -0,0 +1,226 @@
+package fuzzing
+
+import (
+	"reflect"
+
+	"github.com/crytic/medusa-geth/accounts/abi"
+	"github.com/crytic/medusa-geth/common"
+	fuzzerTypes "github.com/crytic/medusa/fuzzing/contracts"
+	"github.com/crytic/medusa/fuzzing/valuegeneration"
+	"github.com/crytic/medusa/utils/reflectionutils"
+)
+
+// nestedCallKind describes how a bytes-typed argument is inferred to be decoded by its callee.
+type nestedCallKind int
+
+const (
+	// nestedCallKindNone indicates the argument is not inferred to carry call data.
+	nestedCallKindNone nestedCallKind = iota
+	// nestedCallKindSelfBatch indicates a bytes[] argument which is inferred to be a batch of call data dispatched to
+	// the callee itself (e.g. multicall(bytes[])).
+	nestedCallKindSelfBatch
+	// nestedCallKindTargeted indicates a bytes argument which is inferred to be call data dispatched to the address
+	// provided by a sibling argument (e.g. execute(address,bytes)).
+	nestedCallKindTargeted
+	// nestedCallKindTargetedParallel indicates a bytes[] argument which is inferred to be a batch of call data, each
+	// dispatched to the address at the same index of a sibling address[] argument (e.g. execute(address[],bytes[])).
+	nestedCallKindTargetedParallel
+	// nestedCallKindTargetedBatch indicates a tuple or tuple[] argument whose elements pair an address with call data
+	// (e.g. Multicall3's aggregate((address,bytes)[])).
+	nestedCallKindTargetedBatch
+)
+
+// isUint256Type determines whether the provided type is uint256.
+func isUint256Type(t *abi.Type) bool {
+	return t.T == abi.UintTy && t.Size == 256
+}
+
+// isListOf determines whether the provided type is a dynamic or fixed size array whose elements are of the type
+// accepted by the provided function.
+func isListOf(t *abi.Type, isElemType func(*abi.Type) bool) bool {
+	return (t.T == abi.SliceTy || t.T == abi.ArrayTy) && isElemType(t.Elem)
+}
+
+// hasArgumentShape determines whether the provided arguments are of the types accepted by the provided functions, in
+// order, and no others.
+func hasArgumentShape(arguments abi.Arguments, isArgumentTypes ...func(*abi.Type) bool) bool {
+	if len(arguments) != len(isArgumentTypes) {
+		return false
+	}
+	for i, isArgumentType := range isArgumentTypes {
+		if !isArgumentType(&arguments[i].Type) {
+			return false
+		}
+	}
+	return true
+}
+
+// isAddressType, isBytesType, isAddressListType, isBytesListType describe the argument types which make up the shapes
+// of nested calls.
+var (
+	isAddressType     = func(t *abi.Type) bool { return t.T == abi.AddressTy }
+	isBytesType       = func(t *abi.Type) bool { return t.T == abi.BytesTy }
+	isAddressListType = func(t *abi.Type) bool { return isListOf(t, isAddressType) }
+	isBytesListType   = func(t *abi.Type) bool { return isListOf(t, isBytesType) }
+)
+
+// tupleNestedCallFields returns the indexes of the address and bytes elements of a tuple type which is inferred to
+// describe a nested call, or false if the tuple does not describe one. Such tuples start with the target address and
+// end with the call data, with any bool or uint256 elements in between (e.g. Multicall3's Call, Call3 and Call3Value).
+func tupleNestedCallFields(tupleType *abi.Type) (int, int, bool) {
+	elems := tupleType.TupleElems
+	if tupleType.T != abi.TupleTy || len(elems) < 2 || elems[0].T != abi.AddressTy || elems[len(elems)-1].T != abi.BytesTy {
+		return -1, -1, false
+	}
+	for _, elem := range elems[1 : len(elems)-1] {
+		if elem.T != abi.BoolTy && !isUint256Type(elem) {
+			return -1, -1, false
+		}
+	}
+	return 0, len(elems) - 1, true
+}
+
+// inferNestedCallArguments infers, from the shape of the method ABI alone, which arguments of a method are ABI-decoded
+// by the callee into nested calls. This captures router and multicall patterns whose bytes arguments act as coverage
+// walls when filled with random bytes. Only whole argument shapes are matched, as bytes arguments are commonly used
+// for opaque payloads (e.g. ERC721 safeTransferFrom, ERC1363 transferAndCall).
+// Returns the nestedCallKind for each input, and for each input the index of the sibling address argument used as
+// the nested call target (or -1 if not applicable).
+func inferNestedCallArguments(method *abi.Method) ([]nestedCallKind, []int) {
+	inputs := method.Inputs
+	kinds := make([]nestedCallKind, len(inputs))
+	targets := make([]int, len(inputs))
+	for i := range targets {
+		targets[i] = -1
+	}
+
+	switch {
+	case hasArgumentShape(inputs, isAddressType, isBytesType):
+		kinds[1], targets[1] = nestedCallKindTargeted, 0
+	case hasArgumentShape(inputs, isAddressListType, isBytesListType):
+		kinds[1], targets[1] = nestedCallKindTargetedParallel, 0
+	case hasArgumentShape(inputs, isBytesListType):
+		kinds[0] = nestedCallKindSelfBatch
+	case hasArgumentShape(inputs, isUint256Type, isBytesListType):
+		kinds[1] = nestedCallKindSelfBatch
+	default:
+		// Tuples describing nested calls are specific enough to be matched regardless of the other arguments.
+		for i, input := range inputs {
+			inputType := &input.Type
+			if inputType.T == abi.SliceTy || inputType.T == abi.ArrayTy {
+				inputType = inputType.Elem
+			}
+			if _, _, ok := tupleNestedCallFields(inputType); ok {
+				kinds[i] = nestedCallKindTargetedBatch
+			}
+		}
+	}
+	return kinds, targets
+}
+
+// applyNestedCalls replaces, with the configured probability, the generated or mutated values of arguments which are
+// inferred to carry call data with valid call encodings against known contract ABIs. Nested calls are generated
+// recursively until the configured maximum depth is reached.
+func (g *CallSequenceGenerator) applyNestedCalls(contractAddress common.Address, method *abi.Method, args []any, depth int) {
+	if g.config.NestedCallProbability <= 0 || uint64(depth) >= g.config.NestedCallMaxDepth {
+		return
+	}
+
+	kinds, targets := inferNestedCallArguments(method)
+	for i, kind := range kinds {
+		if kind == nestedCallKindNone || g.worker.randomProvider.Float32() >= g.config.NestedCallProbability {
+			continue
+		}
+
+		switch kind {
+		case nestedCallKindSelfBatch:
+			values := reflectionutils.CopyReflectedType(reflect.ValueOf(args[i]))
+			for j := 0; j < values.Len(); j++ {
+				values.Index(j).Set(reflect.ValueOf(g.generateNestedCallData(contractAddress, depth+1)))
+			}
+			args[i] = values.Interface()
+		case nestedCallKindTargeted:
+			target := g.randomNestedCallTarget()
+			args[targets[i]] = target
+			args[i] = g.generateNestedCallData(target, depth+1)
+		case nestedCallKindTargetedParallel:
+			addresses := reflectionutils.CopyReflectedType(reflect.ValueOf(args[targets[i]]))
+			values := reflectionutils.CopyReflectedType(reflect.ValueOf(args[i]))
+			for j := 0; j < values.Len() && j < addresses.Len(); j++ {
+				target := g.randomNestedCallTarget()
+				addresses.Index(j).Set(reflect.ValueOf(target))
+				values.Index(j).Set(reflect.ValueOf(g.generateNestedCallData(target, depth+1)))
+			}
+			args[targets[i]] = addresses.Interface()
+			args[i] = values.Interface()
+		case nestedCallKindTargetedBatch:
+			args[i] = g.applyNestedCallsToTuples(&method.Inputs[i].Type, args[i], depth)
+		}
+	}
+}
+
+// applyNestedCallsToTuples populates the address and call data fields of a tuple, or every tuple in an array/slice of
+// tuples, with valid nested calls. Returns the updated value.
+func (g *CallSequenceGenerator) applyNestedCallsToTuples(inputType *abi.Type, value any, depth int) any {
+	if inputType.T == abi.SliceTy || inputType.T == abi.ArrayTy {
+		values := reflectionutils.CopyReflectedType(reflect.ValueOf(value))
+		for j := 0; j < values.Len(); j++ {
+			values.Index(j).Set(reflect.ValueOf(g.applyNestedCallsToTuples(inputType.Elem, values.Index(j).Interface(), depth)))
+		}
+		return values.Interface()
+	}
+
+	addressIndex, bytesIndex, ok := tupleNestedCallFields(inputType)
+	if !ok {
+		return value
+	}
+	tuple := reflectionutils.CopyReflectedType(reflect.ValueOf(value))
+	target := g.randomNestedCallTarget()
+	reflectionutils.SetField(tuple.Field(addressIndex), target)
+	reflectionutils.SetField(tuple.Field(bytesIndex), g.generateNestedCallData(target, depth+1))
+	return tuple.Interface()
+}
+
+// randomNestedCallTarget selects the address of a random deployed contract with state changing methods to use as a
+// nested call target. If none exist, a generated address is returned.
+func (g *CallSequenceGenerator) randomNestedCallTarget() common.Address {
+	if len(g.worker.stateChangingMethods) == 0 {
+		return g.config.ValueGenerator.GenerateAddress()
+	}
+	return g.worker.stateChangingMethods[g.worker.randomProvider.Intn(len(g.worker.stateChangingMethods))].Address
+}
+
+// generateNestedCallData generates ABI-encoded call data (selector and arguments) for a random method of the contract
+// deployed at the provided address. If no methods are known for the address, random bytes are returned instead.
+func (g *CallSequenceGenerator) generateNestedCallData(contractAddress common.Address, depth int) []byte {
+	// Collect the known methods for the target contract.
+	candidates := make([]*fuzzerTypes.DeployedContractMethod, 0)
+	for i := range g.worker.stateChangingMethods {
+		if g.worker.stateChangingMethods[i].Address == contractAddress {
+			candidates = append(candidates, &g.worker.stateChangingMethods[i])
+		}
+	}
+	for i := range g.worker.pureMethods {
+		if g.worker.pureMethods[i].Address == contractAddress {
+			candidates = append(candidates, &g.worker.pureMethods[i])
+		}
+	}
+	if len(candidates) == 0 {
+		return g.config.ValueGenerator.GenerateBytes()
+	}
+
+	// Generate arguments for a random method, recursively populating nested calls.
+	selectedMethod := candidates[g.worker.randomProvider.Intn(len(candidates))]
+	args := make([]any, len(selectedMethod.Method.Inputs))
+	for i := 0; i < len(args); i++ {
+		args[i] = valuegeneration.GenerateAbiValue(g.config.ValueGenerator, &selectedMethod.Method.Inputs[i].Type)
+	}
+	g.applyNestedCalls(contractAddress, &selectedMethod.Method, args, depth)
+
+	// Encode the call data, falling back to random bytes if our values could not be packed.
+	packedArgs, err := selectedMethod.Method.Inputs.Pack(args...)
+	if err != nil {
+		return g.config.ValueGenerator.GenerateBytes()
+	}
+	return append(append([]byte{}, selectedMethod.Method.ID...), packedArgs...)
+}
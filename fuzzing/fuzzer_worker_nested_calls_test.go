@@ -0,0 +1,162 @@
+package fuzzing
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/crytic/medusa-geth/accounts/abi"
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa/chain"
+	compilationTypes "github.com/crytic/medusa/compilation/types"
+	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/fuzzing/config"
+	fuzzerTypes "github.com/crytic/medusa/fuzzing/contracts"
+	"github.com/crytic/medusa/fuzzing/valuegeneration"
+	"github.com/stretchr/testify/assert"
+)
+
+// nestedCallsTestAbi describes methods whose arguments have, or do not have, the shape of nested calls.
+const nestedCallsTestAbi = `[
+	{"type": "function", "name": "execute", "stateMutability": "nonpayable", "inputs": [{"name": "target", "type": "address"}, {"name": "data", "type": "bytes"}]},
+	{"type": "function", "name": "executeBatch", "stateMutability": "nonpayable", "inputs": [{"name": "targets", "type": "address[]"}, {"name": "data", "type": "bytes[]"}]},
+	{"type": "function", "name": "multicall", "stateMutability": "nonpayable", "inputs": [{"name": "data", "type": "bytes[]"}]},
+	{"type": "function", "name": "multicallWithDeadline", "stateMutability": "nonpayable", "inputs": [{"name": "deadline", "type": "uint256"}, {"name": "data", "type": "bytes[]"}]},
+	{"type": "function", "name": "aggregate", "stateMutability": "nonpayable", "inputs": [{"name": "calls", "type": "tuple[]", "components": [{"name": "target", "type": "address"}, {"name": "callData", "type": "bytes"}]}]},
+	{"type": "function", "name": "aggregate3Value", "stateMutability": "payable", "inputs": [{"name": "calls", "type": "tuple[]", "components": [{"name": "target", "type": "address"}, {"name": "allowFailure", "type": "bool"}, {"name": "value", "type": "uint256"}, {"name": "callData", "type": "bytes"}]}]},
+	{"type": "function", "name": "tryAggregate", "stateMutability": "nonpayable", "inputs": [{"name": "requireSuccess", "type": "bool"}, {"name": "calls", "type": "tuple[]", "components": [{"name": "target", "type": "address"}, {"name": "callData", "type": "bytes"}]}]},
+	{"type": "function", "name": "transferAndCall", "stateMutability": "nonpayable", "inputs": [{"name": "to", "type": "address"}, {"name": "value", "type": "uint256"}, {"name": "data", "type": "bytes"}]},
+	{"type": "function", "name": "safeTransferFrom", "stateMutability": "nonpayable", "inputs": [{"name": "from", "type": "address"}, {"name": "to", "type": "address"}, {"name": "tokenId", "type": "uint256"}, {"name": "data", "type": "bytes"}]},
+	{"type": "function", "name": "callback", "stateMutability": "nonpayable", "inputs": [{"name": "data", "type": "bytes"}]},
+	{"type": "function", "name": "executeOrder", "stateMutability": "nonpayable", "inputs": [{"name": "maker", "type": "address"}, {"name": "amount", "type": "uint256"}]},
+	{"type": "function", "name": "delegate", "stateMutability": "nonpayable", "inputs": [{"name": "delegatee", "type": "address"}]},
+	{"type": "function", "name": "submit", "stateMutability": "nonpayable", "inputs": [{"name": "order", "type": "tuple", "components": [{"name": "maker", "type": "address"}, {"name": "amount", "type": "uint256"}, {"name": "salt", "type": "bytes32"}]}]},
+	{"type": "function", "name": "set", "stateMutability": "nonpayable", "inputs": [{"name": "value", "type": "uint256"}]}
+]`
+
+// parseNestedCallsTestAbi parses nestedCallsTestAbi.
+func parseNestedCallsTestAbi(t *testing.T) abi.ABI {
+	contractAbi, err := abi.JSON(strings.NewReader(nestedCallsTestAbi))
+	assert.NoError(t, err)
+	return contractAbi
+}
+
+// TestInferNestedCallArguments ensures only arguments of methods whose whole argument shape describes nested calls are
+// inferred to carry call data, regardless of method and argument names.
+func TestInferNestedCallArguments(t *testing.T) {
+	contractAbi := parseNestedCallsTestAbi(t)
+	none := nestedCallKindNone
+	tests := []struct {
+		method  string
+		kinds   []nestedCallKind
+		targets []int
+	}{
+		{"execute", []nestedCallKind{none, nestedCallKindTargeted}, []int{-1, 0}},
+		{"executeBatch", []nestedCallKind{none, nestedCallKindTargetedParallel}, []int{-1, 0}},
+		{"multicall", []nestedCallKind{nestedCallKindSelfBatch}, []int{-1}},
+		{"multicallWithDeadline", []nestedCallKind{none, nestedCallKindSelfBatch}, []int{-1, -1}},
+		{"aggregate", []nestedCallKind{nestedCallKindTargetedBatch}, []int{-1}},
+		{"aggregate3Value", []nestedCallKind{nestedCallKindTargetedBatch}, []int{-1}},
+		{"tryAggregate", []nestedCallKind{none, nestedCallKindTargetedBatch}, []int{-1, -1}},
+		{"transferAndCall", []nestedCallKind{none, none, none}, []int{-1, -1, -1}},
+		{"safeTransferFrom", []nestedCallKind{none, none, none, none}, []int{-1, -1, -1, -1}},
+		{"callback", []nestedCallKind{none}, []int{-1}},
+		{"executeOrder", []nestedCallKind{none, none}, []int{-1, -1}},
+		{"delegate", []nestedCallKind{none}, []int{-1}},
+		{"submit", []nestedCallKind{none}, []int{-1}},
+	}
+	for _, test := range tests {
+		method := contractAbi.Methods[test.method]
+		kinds, targets := inferNestedCallArguments(&method)
+		assert.EqualValues(t, test.kinds, kinds, test.method)
+		assert.EqualValues(t, test.targets, targets, test.method)
+	}
+}
+
+// newNestedCallsTestGenerator creates a CallSequenceGenerator which always populates nested call arguments, with a
+// single deployed contract exposing the methods of nestedCallsTestAbi.
+func newNestedCallsTestGenerator(t *testing.T, contractAbi abi.ABI, contractAddress common.Address) *CallSequenceGenerator {
+	projectConfig, err := config.GetDefaultProjectConfig("")
+	assert.NoError(t, err)
+	projectConfig.Fuzzing.NestedCalls.Probability = 1
+	fuzzer := &Fuzzer{config: *projectConfig}
+	randomProvider := rand.New(rand.NewSource(0))
+	generatorConfig, err := defaultCallSequenceGeneratorConfigFunc(fuzzer, valuegeneration.NewValueSet(), randomProvider)
+	assert.NoError(t, err)
+
+	testChain, err := chain.NewTestChain(context.Background(), types.GenesisAlloc{}, nil)
+	assert.NoError(t, err)
+	contract := fuzzerTypes.NewContract("Router", "Router.sol", &compilationTypes.CompiledContract{Abi: contractAbi}, nil)
+	worker := &FuzzerWorker{fuzzer: fuzzer, chain: testChain, randomProvider: randomProvider}
+	worker.stateChangingMethods = []fuzzerTypes.DeployedContractMethod{
+		{Address: contractAddress, Contract: contract, Method: contractAbi.Methods["set"]},
+	}
+	return &CallSequenceGenerator{worker: worker, config: generatorConfig}
+}
+
+// assertNestedCallData asserts the provided call data calls the set method of nestedCallsTestAbi.
+func assertNestedCallData(t *testing.T, contractAbi abi.ABI, data []byte) {
+	assert.True(t, bytes.HasPrefix(data, contractAbi.Methods["set"].ID))
+	_, err := contractAbi.Methods["set"].Inputs.Unpack(data[4:])
+	assert.NoError(t, err)
+}
+
+// TestApplyNestedCalls ensures arguments inferred to carry call data are populated with valid calls to deployed
+// contracts, when calls are generated or mutated.
+func TestApplyNestedCalls(t *testing.T) {
+	contractAbi := parseNestedCallsTestAbi(t)
+	contractAddress := common.HexToAddress("0x1234")
+	generator := newNestedCallsTestGenerator(t, contractAbi, contractAddress)
+
+	// A targeted nested call targets a deployed contract.
+	execute := contractAbi.Methods["execute"]
+	args := []any{common.Address{}, []byte{1, 2, 3}}
+	generator.applyNestedCalls(contractAddress, &execute, args, 0)
+	assert.EqualValues(t, contractAddress, args[0])
+	assertNestedCallData(t, contractAbi, args[1].([]byte))
+
+	// Parallel batches target a deployed contract at each index.
+	executeBatch := contractAbi.Methods["executeBatch"]
+	args = []any{[]common.Address{{}, {}}, [][]byte{{1}, {2}, {3}}}
+	generator.applyNestedCalls(contractAddress, &executeBatch, args, 0)
+	for _, target := range args[0].([]common.Address) {
+		assert.EqualValues(t, contractAddress, target)
+	}
+	for i, data := range args[1].([][]byte) {
+		if i < 2 {
+			assertNestedCallData(t, contractAbi, data)
+		} else {
+			assert.EqualValues(t, []byte{3}, data)
+		}
+	}
+
+	// Arguments of methods which do not have the shape of nested calls are left untouched.
+	transferAndCall := contractAbi.Methods["transferAndCall"]
+	args = []any{common.Address{1}, big.NewInt(1), []byte{1, 2, 3}}
+	generator.applyNestedCalls(contractAddress, &transferAndCall, args, 0)
+	assert.EqualValues(t, []any{common.Address{1}, big.NewInt(1), []byte{1, 2, 3}}, args)
+
+	// A maximum depth of zero disables nested calls.
+	generator.config.NestedCallMaxDepth = 0
+	args = []any{common.Address{}, []byte{1, 2, 3}}
+	generator.applyNestedCalls(contractAddress, &execute, args, 0)
+	assert.EqualValues(t, []any{common.Address{}, []byte{1, 2, 3}}, args)
+	generator.config.NestedCallMaxDepth = 2
+
+	// Mutated calls are populated with nested calls too, and their call data is re-encoded.
+	msg := calls.NewCallMessageWithAbiValueData(common.Address{}, &contractAddress, 0, big.NewInt(0), 1_000_000, nil, nil, nil, &calls.CallMessageDataAbiValues{
+		Method:      &execute,
+		InputValues: []any{common.Address{}, []byte{1, 2, 3}},
+	})
+	element := calls.NewCallSequenceElement(nil, msg, 0, 0)
+	assert.NoError(t, prefetchModifyCallFuncMutate(generator, element))
+	assert.EqualValues(t, contractAddress, element.Call.DataAbiValues.InputValues[0])
+	assertNestedCallData(t, contractAbi, element.Call.DataAbiValues.InputValues[1].([]byte))
+	unpacked, err := execute.Inputs.Unpack(element.Call.Data[4:])
+	assert.NoError(t, err)
+	assert.EqualValues(t, element.Call.DataAbiValues.InputValues[1], unpacked[1])
+}
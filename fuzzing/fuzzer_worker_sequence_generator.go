@@ -3,10 +3,15 @@ package fuzzing
 import (
 	"fmt"
 	"math/big"
+	"sync"
 
+	"github.com/crytic/medusa-geth/accounts/abi"
 	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/chain"
+	"github.com/crytic/medusa/fuzzing/bugdetector"
 	"github.com/crytic/medusa/fuzzing/calls"
 	"github.com/crytic/medusa/fuzzing/contracts"
+	"github.com/crytic/medusa/fuzzing/corpus"
 	"github.com/crytic/medusa/fuzzing/valuegeneration"
 	"github.com/crytic/medusa/utils"
 	"github.com/crytic/medusa/utils/randomutils"
@@ -38,6 +43,33 @@ type CallSequenceGenerator struct {
 	// mutationStrategyChooser is a weighted random selector of functions that prepare the CallSequenceGenerator with
 	// a baseSequence derived from corpus entries.
 	mutationStrategyChooser *randomutils.WeightedRandomChooser[CallSequenceGeneratorMutationStrategy]
+
+	// replayingCorpus describes whether the baseSequence is a corpus call sequence replayed without mutations, either
+	// un-executed or received from another fuzzer instance.
+	replayingCorpus bool
+
+	// mutationTargets describes the corpus call sequences the baseSequence was derived from, which are rewarded by the
+	// adaptive power schedule if the generated sequence proves productive.
+	mutationTargets []*corpus.MutationTarget
+
+	// writtenVariables describes the storage slots written by the last call executed in the current sequence, used to
+	// order the next generated call along dataflow.
+	writtenVariables []string
+
+	// blockDependencies describes the block properties the branch conditions of previous calls in the current
+	// sequence depended on, which are warped before the calls following them.
+	blockDependencies bugdetector.BlockDependency
+
+	// senderStrategyChooser and valueStrategyChooser are weighted random selectors of the configured sender and value
+	// strategies.
+	senderStrategyChooser *randomutils.WeightedRandomChooser[string]
+	valueStrategyChooser  *randomutils.WeightedRandomChooser[string]
+
+	// senderStrategy describes the sender strategy of the current sequence, attacker the sender it sends calls from in
+	// the sticky attacker strategies, and senderOffset the sender it starts at in the round-robin strategy.
+	senderStrategy string
+	attacker       common.Address
+	senderOffset   int
 }
 
 // CallSequenceGeneratorConfig defines the configuration for a CallSequenceGenerator to be created and used by a
@@ -87,6 +119,16 @@ type CallSequenceGeneratorConfig struct {
 	// number of calls from each.
 	RandomMutatedInterleaveAtRandomWeight uint64
 
+	// RandomCmpLogCorpusHeadWeight defines the weight that the CallSequenceGenerator should use the call sequence
+	// generation strategy of taking the head of a corpus sequence and substituting call data words matching logged
+	// comparison operands with the operand they were compared against.
+	RandomCmpLogCorpusHeadWeight uint64
+
+	// RandomCallTransplantWeight defines the weight that the CallSequenceGenerator should use the call sequence
+	// generation strategy of taking the head of a corpus sequence and inserting a high-value call from the corpus
+	// (e.g. one which produced a new tokenflow) at a random position of it.
+	RandomCallTransplantWeight uint64
+
 	// ValueGenerator defines the value provider to use when generating new values for call sequences. This is used both
 	// for ABI call data generation, and generation of additional values such as the "value" field of a
 	// transaction/call.
@@ -94,6 +136,14 @@ type CallSequenceGeneratorConfig struct {
 
 	// ValueMutator defines the value provider to use when mutating corpus call sequences.
 	ValueMutator valuegeneration.ValueMutator
+
+	// NestedCallProbability defines the probability that a bytes-typed argument which is inferred to be decoded into
+	// nested calls (e.g. router/multicall patterns) is populated with valid call data against a known contract ABI,
+	// rather than random bytes.
+	NestedCallProbability float32
+
+	// NestedCallMaxDepth defines the maximum depth of nested calls generated for such arguments.
+	NestedCallMaxDepth uint64
 }
 
 // CallSequenceGeneratorFunc defines a method used to populate a provided call sequence with generated calls.
@@ -120,7 +170,9 @@ func NewCallSequenceGenerator(worker *FuzzerWorker, config *CallSequenceGenerato
 	generator := &CallSequenceGenerator{
 		worker:                  worker,
 		config:                  config,
-		mutationStrategyChooser: randomutils.NewWeightedRandomChooser[CallSequenceGeneratorMutationStrategy](),
+		mutationStrategyChooser: randomutils.NewWeightedRandomChooserWithRand[CallSequenceGeneratorMutationStrategy](randomutils.ForkRandomProvider(worker.randomProvider), &sync.Mutex{}),
+		senderStrategyChooser:   newStrategyChooser(worker.fuzzer.config.Fuzzing.SenderStrategy.Strategies, randomutils.ForkRandomProvider(worker.randomProvider)),
+		valueStrategyChooser:    newStrategyChooser(worker.fuzzer.config.Fuzzing.ValueStrategy.Strategies, randomutils.ForkRandomProvider(worker.randomProvider)),
 	}
 
 	generator.mutationStrategyChooser.AddChoices(
@@ -180,11 +232,32 @@ func NewCallSequenceGenerator(worker *FuzzerWorker, config *CallSequenceGenerato
 			},
 			new(big.Int).SetUint64(config.RandomMutatedInterleaveAtRandomWeight),
 		),
+		randomutils.NewWeightedRandomChoice(
+			CallSequenceGeneratorMutationStrategy{
+				CallSequenceGeneratorFunc: callSeqGenFuncCorpusHead,
+				PrefetchModifyCallFunc:    prefetchModifyCallFuncCmpLog,
+			},
+			new(big.Int).SetUint64(config.RandomCmpLogCorpusHeadWeight),
+		),
+		randomutils.NewWeightedRandomChoice(
+			CallSequenceGeneratorMutationStrategy{
+				CallSequenceGeneratorFunc: callSeqGenFuncTransplantCall,
+				PrefetchModifyCallFunc:    nil,
+			},
+			new(big.Int).SetUint64(config.RandomCallTransplantWeight),
+		),
 	)
 
 	return generator
 }
 
+// ReplayingCorpus indicates whether the sequence initialized by InitializeNextSequence is a corpus call sequence
+// replayed without mutations, either un-executed or received from another fuzzer instance. Sequences mutated from the
+// corpus are not replays.
+func (g *CallSequenceGenerator) ReplayingCorpus() bool {
+	return g.replayingCorpus
+}
+
 // InitializeNextSequence prepares the CallSequenceGenerator for generating a new sequence. Each element can be
 // obtained by calling PopSequenceElement iteratively.
 // Returns a boolean indicating whether the initialized sequence is a newly generated sequence (rather than an
@@ -194,12 +267,26 @@ func (g *CallSequenceGenerator) InitializeNextSequence() (bool, error) {
 	g.baseSequence = make(calls.CallSequence, g.worker.fuzzer.config.Fuzzing.CallSequenceLength)
 	g.fetchIndex = 0
 	g.prefetchModifyCallFunc = nil
+	g.replayingCorpus = false
+	g.mutationTargets = g.mutationTargets[:0]
+	g.writtenVariables = nil
+	g.blockDependencies = 0
+	g.initializeSenderStrategy()
 
 	// Check if there are any previously un-executed corpus call sequences. If there are, the fuzzer should execute
 	// those first.
 	unexecutedSequence := g.worker.fuzzer.corpus.UnexecutedCallSequence()
 	if unexecutedSequence != nil {
 		g.baseSequence = *unexecutedSequence
+		g.replayingCorpus = true
+		return false, nil
+	}
+
+	// Next, execute any call sequences received from other fuzzer instances, so they are validated against our chain
+	// before being used in mutations.
+	if remoteSequence := g.worker.fuzzer.corpus.RemoteCallSequence(); remoteSequence != nil {
+		g.baseSequence = *remoteSequence
+		g.replayingCorpus = true
 		return false, nil
 	}
 
@@ -233,6 +320,37 @@ func (g *CallSequenceGenerator) InitializeNextSequence() (bool, error) {
 	return true, nil
 }
 
+// randomMutationTargetSequence obtains a weighted random call sequence from the corpus to derive the baseSequence from,
+// recording it as a mutation target along with the energy it was chosen with.
+// Returns the call sequence, or an error if one occurs.
+func (g *CallSequenceGenerator) randomMutationTargetSequence() (calls.CallSequence, error) {
+	corpusSequence, target, err := g.worker.fuzzer.corpus.RandomMutationTarget(g.worker.randomProvider)
+	if err != nil {
+		return nil, err
+	}
+	if target != nil {
+		g.mutationTargets = append(g.mutationTargets, target)
+		g.worker.workerMetrics().recordMutationTargetEnergy(g.worker.fuzzer.corpus.MutationTargetEnergy(target))
+	}
+	return corpusSequence, nil
+}
+
+// setDictionaryContract directs the value generator to sample values from the operand dictionary of the provided
+// contract, if it supports one.
+func (g *CallSequenceGenerator) setDictionaryContract(contract common.Address) {
+	if generator, ok := g.config.ValueGenerator.(*valuegeneration.MutationalValueGenerator); ok {
+		generator.SetDictionaryContract(contract)
+	}
+}
+
+// popMutationTargets returns the corpus call sequences the current sequence was derived from, and clears them so they
+// are only rewarded once per sequence.
+func (g *CallSequenceGenerator) popMutationTargets() []*corpus.MutationTarget {
+	targets := g.mutationTargets
+	g.mutationTargets = nil
+	return targets
+}
+
 // PopSequenceElement obtains the next element for our call sequence requested by InitializeNextSequence. If there are no elements
 // left to return, this method returns nil. If an error occurs, it is returned instead.
 func (g *CallSequenceGenerator) PopSequenceElement() (*calls.CallSequenceElement, error) {
@@ -267,6 +385,8 @@ func (g *CallSequenceGenerator) PopSequenceElement() (*calls.CallSequenceElement
 	// TODO: This feels a little hacky
 	if !g.worker.fuzzer.corpus.InitializingCorpus() {
 		element.Call.FillFromTestChainProperties(g.worker.chain)
+		g.applyTimeAcceleration(element)
+		g.applyBlockWarp(element)
 	}
 
 	if g.worker.fuzzer.config.Fuzzing.Testing.HelperContract.Enabled {
@@ -322,12 +442,17 @@ func (g *CallSequenceGenerator) generateNewElement() (*calls.CallSequenceElement
 	var selectedMethod *contracts.DeployedContractMethod
 	if (len(g.worker.pureMethods) > 0 && g.worker.randomProvider.Intn(1000) == 0) || callOnlyPureFunctions {
 		selectedMethod = &g.worker.pureMethods[g.worker.randomProvider.Intn(len(g.worker.pureMethods))]
-	} else {
-		selectedMethod = &g.worker.stateChangingMethods[g.worker.randomProvider.Intn(len(g.worker.stateChangingMethods))]
+	} else if selectedMethod = g.dataflowOrderedMethod(); selectedMethod == nil {
+		if selectedMethod = g.interleavedOnChainMethod(); selectedMethod == nil {
+			selectedMethod = g.worker.chooseStateChangingMethod()
+		}
 	}
 
-	// Select a random sender
-	selectedSender := g.worker.fuzzer.senders[g.worker.randomProvider.Intn(len(g.worker.fuzzer.senders))]
+	// Select a sender according to the sender strategy of the sequence
+	selectedSender := g.selectSender()
+
+	// Sample values from the operand dictionary of the selected contract.
+	g.setDictionaryContract(selectedMethod.Address)
 
 	// Generate fuzzed parameters for the function call
 	args := make([]any, len(selectedMethod.Method.Inputs))
@@ -337,11 +462,14 @@ func (g *CallSequenceGenerator) generateNewElement() (*calls.CallSequenceElement
 		args[i] = valuegeneration.GenerateAbiValue(g.config.ValueGenerator, &input.Type)
 	}
 
+	// Populate arguments which are inferred to carry nested call data with valid call encodings.
+	g.applyNestedCalls(selectedMethod.Address, &selectedMethod.Method, args, 0)
+
 	// If this is a payable function, generate value to send
 	var value *big.Int
 	value = big.NewInt(0)
 	if selectedMethod.Method.StateMutability == "payable" {
-		value = g.config.ValueGenerator.GenerateInteger(false, 64)
+		value = g.generateValue(selectedSender, selectedMethod.Address)
 	}
 
 	// Create our message using the provided parameters.
@@ -368,6 +496,11 @@ func (g *CallSequenceGenerator) generateNewElement() (*calls.CallSequenceElement
 		blockTimestampDelay = g.config.ValueGenerator.GenerateInteger(false, 64).Uint64() % (g.worker.fuzzer.config.Fuzzing.MaxBlockTimestampDelay + 1)
 	}
 
+	// Advance time along with the block number, if a block time is configured.
+	if blockTime := g.worker.fuzzer.config.Fuzzing.ForkTimeline.BlockTime; blockTime > 0 {
+		blockTimestampDelay = blockNumberDelay * blockTime
+	}
+
 	// For each block we jump, we need a unique time stamp for chain semantics, so if our block number jump is too small,
 	// while our timestamp jump is larger, we cap it.
 	if blockNumberDelay > blockTimestampDelay {
@@ -382,12 +515,43 @@ func (g *CallSequenceGenerator) generateNewElement() (*calls.CallSequenceElement
 	return calls.NewCallSequenceElement(selectedMethod.Contract, msg, blockNumberDelay, blockTimestampDelay), nil
 }
 
+// applyTimeAcceleration advances the block time before the provided call by a jump from the time acceleration
+// schedule, with the configured probability, if the call starts a new sequence segment. The block number is advanced
+// alongside it according to the configured block time. Calls taken from corpus call sequences have the time jump of
+// their previous position removed first, so that the schedule follows the segments of the current sequence, while
+// corpus call sequences replayed without mutations keep their time jumps.
+func (g *CallSequenceGenerator) applyTimeAcceleration(element *calls.CallSequenceElement) {
+	timeAcceleration := g.worker.fuzzer.config.Fuzzing.TimeAcceleration
+	if !timeAcceleration.Enabled || g.replayingCorpus {
+		return
+	}
+	if element.TimeJump > 0 {
+		element.BlockTimestampDelay -= min(element.TimeJump, element.BlockTimestampDelay)
+		element.BlockNumberDelay -= min(max(element.TimeJump/timeAcceleration.BlockTime, 1), element.BlockNumberDelay)
+		element.TimeJump = 0
+	}
+	if g.fetchIndex == 0 || g.fetchIndex%timeAcceleration.SegmentLength != 0 {
+		return
+	}
+	if g.worker.randomProvider.Float32() >= timeAcceleration.JumpProbability {
+		return
+	}
+
+	jump := timeAcceleration.Jumps[g.worker.randomProvider.Intn(len(timeAcceleration.Jumps))]
+	if jump == 0 {
+		return
+	}
+	element.TimeJump = jump
+	element.BlockTimestampDelay += jump
+	element.BlockNumberDelay += max(jump/timeAcceleration.BlockTime, 1)
+}
+
 // callSeqGenFuncCorpusHead is a CallSequenceGeneratorFunc which prepares a CallSequenceGenerator to generate a sequence
 // whose head is based off of an existing corpus call sequence.
 // Returns an error if one occurs.
 func callSeqGenFuncCorpusHead(sequenceGenerator *CallSequenceGenerator, sequence calls.CallSequence) error {
 	// Obtain a call sequence from the corpus
-	corpusSequence, err := sequenceGenerator.worker.fuzzer.corpus.RandomMutationTargetSequence()
+	corpusSequence, err := sequenceGenerator.randomMutationTargetSequence()
 	if err != nil {
 		return fmt.Errorf("could not obtain corpus call sequence for head mutation: %v", err)
 	}
@@ -404,7 +568,7 @@ func callSeqGenFuncCorpusHead(sequenceGenerator *CallSequenceGenerator, sequence
 // Returns an error if one occurs.
 func callSeqGenFuncCorpusTail(sequenceGenerator *CallSequenceGenerator, sequence calls.CallSequence) error {
 	// Obtain a call sequence from the corpus
-	corpusSequence, err := sequenceGenerator.worker.fuzzer.corpus.RandomMutationTargetSequence()
+	corpusSequence, err := sequenceGenerator.randomMutationTargetSequence()
 	if err != nil {
 		return fmt.Errorf("could not obtain corpus call sequence for tail mutation: %v", err)
 	}
@@ -423,11 +587,11 @@ func callSeqGenFuncCorpusTail(sequenceGenerator *CallSequenceGenerator, sequence
 // Returns an error if one occurs.
 func callSeqGenFuncSpliceAtRandom(sequenceGenerator *CallSequenceGenerator, sequence calls.CallSequence) error {
 	// Obtain two corpus call sequence entries
-	headSequence, err := sequenceGenerator.worker.fuzzer.corpus.RandomMutationTargetSequence()
+	headSequence, err := sequenceGenerator.randomMutationTargetSequence()
 	if err != nil {
 		return fmt.Errorf("could not obtain head corpus call sequence for splice-at-random corpus mutation: %v", err)
 	}
-	tailSequence, err := sequenceGenerator.worker.fuzzer.corpus.RandomMutationTargetSequence()
+	tailSequence, err := sequenceGenerator.randomMutationTargetSequence()
 	if err != nil {
 		return fmt.Errorf("could not obtain tail corpus call sequence for splice-at-random corpus mutation: %v", err)
 	}
@@ -455,11 +619,11 @@ func callSeqGenFuncSpliceAtRandom(sequenceGenerator *CallSequenceGenerator, sequ
 // Returns an error if one occurs.
 func callSeqGenFuncInterleaveAtRandom(sequenceGenerator *CallSequenceGenerator, sequence calls.CallSequence) error {
 	// Obtain two corpus call sequence entries
-	firstSequence, err := sequenceGenerator.worker.fuzzer.corpus.RandomMutationTargetSequence()
+	firstSequence, err := sequenceGenerator.randomMutationTargetSequence()
 	if err != nil {
 		return fmt.Errorf("could not obtain first corpus call sequence for interleave-at-random corpus mutation: %v", err)
 	}
-	secondSequence, err := sequenceGenerator.worker.fuzzer.corpus.RandomMutationTargetSequence()
+	secondSequence, err := sequenceGenerator.randomMutationTargetSequence()
 	if err != nil {
 		return fmt.Errorf("could not obtain second corpus call sequence for interleave-at-random corpus mutation: %v", err)
 	}
@@ -491,15 +655,59 @@ func callSeqGenFuncInterleaveAtRandom(sequenceGenerator *CallSequenceGenerator,
 	return nil
 }
 
+// callSeqGenFuncTransplantCall is a CallSequenceGeneratorFunc which prepares a CallSequenceGenerator to generate a
+// sequence whose head is based off of an existing corpus call sequence, into which a high-value call from the corpus
+// (e.g. one which produced a new tokenflow) is inserted at a random position.
+// Returns an error if one occurs.
+func callSeqGenFuncTransplantCall(sequenceGenerator *CallSequenceGenerator, sequence calls.CallSequence) error {
+	// Obtain a call sequence and a high-value call from the corpus
+	corpusSequence, err := sequenceGenerator.randomMutationTargetSequence()
+	if err != nil {
+		return fmt.Errorf("could not obtain corpus call sequence for call transplant corpus mutation: %v", err)
+	}
+	highValueCall, err := sequenceGenerator.worker.fuzzer.corpus.RandomHighValueCall(sequenceGenerator.worker.randomProvider)
+	if err != nil {
+		return fmt.Errorf("could not obtain high-value call for call transplant corpus mutation: %v", err)
+	}
+
+	// Copy the head of the corpus sequence to our destination sequence.
+	headSequenceLength := utils.Min(len(sequence), len(corpusSequence))
+	copy(sequence, corpusSequence[:headSequenceLength])
+
+	// If no high-value call is known yet, the head alone is used.
+	if highValueCall == nil {
+		return nil
+	}
+
+	// Insert the high-value call at a random position within or directly after the head, shifting the calls after it
+	// (and dropping the last one if the sequence is full).
+	i := sequenceGenerator.worker.randomProvider.Intn(utils.Min(headSequenceLength+1, len(sequence)))
+	copy(sequence[i+1:], sequence[i:])
+	sequence[i] = highValueCall
+
+	return nil
+}
+
 // prefetchModifyCallFuncMutate is a PrefetchModifyCallFunc, called by a CallSequenceGenerator to apply mutations
 // to a call sequence element, prior to it being fetched.
 // Returns an error if one occurs.
 func prefetchModifyCallFuncMutate(sequenceGenerator *CallSequenceGenerator, element *calls.CallSequenceElement) error {
+	// Mutate the values mock contracts returned to the call, so it observes different external data when replayed.
+	err := sequenceGenerator.mutateMockResponses(element)
+	if err != nil {
+		return err
+	}
+
 	// If this element has no ABI value based call data, exit early.
 	if element.Call == nil || element.Call.DataAbiValues == nil {
 		return nil
 	}
 
+	// Sample values from the operand dictionary of the contract being called.
+	if element.Call.To != nil {
+		sequenceGenerator.setDictionaryContract(*element.Call.To)
+	}
+
 	// Loop for each input value and mutate it
 	abiValuesMsgData := element.Call.DataAbiValues
 	for i := 0; i < len(abiValuesMsgData.InputValues); i++ {
@@ -509,8 +717,71 @@ func prefetchModifyCallFuncMutate(sequenceGenerator *CallSequenceGenerator, elem
 		}
 		abiValuesMsgData.InputValues[i] = mutatedInput
 	}
+	// Populate arguments which are inferred to carry nested call data with valid call encodings, as mutations rarely
+	// preserve them.
+	if element.Call.To != nil {
+		sequenceGenerator.applyNestedCalls(*element.Call.To, abiValuesMsgData.Method, abiValuesMsgData.InputValues, 0)
+	}
+
 	// Re-encode the message's calldata
 	element.Call.WithDataAbiValues(abiValuesMsgData)
 
 	return nil
 }
+
+// generateMockReturnData is a chain.MockResponseProviderFunc which chooses the return data of a call made to a function
+// of a mock contract during a transaction. Half of the time, the function keeps returning the data it last returned.
+// Otherwise, fuzzed values are generated for its outputs.
+// Returns the return data, or nil if the function should return the data it last returned.
+func (g *CallSequenceGenerator) generateMockReturnData(mock *chain.MockContract, method *abi.Method) []byte {
+	if g.worker.randomProvider.Intn(2) == 0 {
+		return nil
+	}
+	outputs := make([]any, len(method.Outputs))
+	for i := 0; i < len(outputs); i++ {
+		outputs[i] = valuegeneration.GenerateAbiValue(g.config.ValueGenerator, &method.Outputs[i].Type)
+	}
+	returnData, err := method.Outputs.Pack(outputs...)
+	if err != nil {
+		return nil
+	}
+	return returnData
+}
+
+// mutateMockResponses mutates the values returned by mock contracts to the provided call sequence element when it
+// was last executed, which are replayed when it is executed again.
+// Returns an error if one occurs.
+func (g *CallSequenceGenerator) mutateMockResponses(element *calls.CallSequenceElement) error {
+	mockContracts := g.worker.chain.MockContracts()
+	for i, response := range element.MockResponses {
+		// Skip responses of mocks or functions which are no longer installed, or which no longer decode.
+		mock, ok := mockContracts[response.Address]
+		if !ok {
+			continue
+		}
+		method, err := mock.Abi().MethodById(response.Selector)
+		if err != nil {
+			continue
+		}
+		outputs, err := method.Outputs.Unpack(response.ReturnData)
+		if err != nil {
+			continue
+		}
+
+		// Mutate each output value and re-encode the return data.
+		for j := 0; j < len(outputs); j++ {
+			outputs[j], err = valuegeneration.MutateAbiValue(g.config.ValueGenerator, g.config.ValueMutator, &method.Outputs[j].Type, outputs[j])
+			if err != nil {
+				return fmt.Errorf("error when mutating mock contract return value: %v", err)
+			}
+		}
+		returnData, err := method.Outputs.Pack(outputs...)
+		if err != nil {
+			return fmt.Errorf("error when encoding mutated mock contract return values: %v", err)
+		}
+		mutatedResponse := *response
+		mutatedResponse.ReturnData = returnData
+		element.MockResponses[i] = &mutatedResponse
+	}
+	return nil
+}
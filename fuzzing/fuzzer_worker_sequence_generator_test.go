@@ -0,0 +1,160 @@
+package fuzzing
+
+import (
+	"context"
+	"math/big"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/crytic/medusa-geth/accounts/abi"
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa/chain"
+	"github.com/crytic/medusa/fuzzing/bugdetector"
+	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/fuzzing/config"
+	fuzzingCorpus "github.com/crytic/medusa/fuzzing/corpus"
+	"github.com/crytic/medusa/fuzzing/valuegeneration"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestInitializeNextSequenceCorpusReplays ensures only un-executed or remote corpus call sequences replayed without
+// mutations are reported as corpus replays, so heavy tracers are not forced on sequences mutated from the corpus.
+func TestInitializeNextSequenceCorpusReplays(t *testing.T) {
+	projectConfig, err := config.GetDefaultProjectConfig("")
+	assert.NoError(t, err)
+	randomProvider := rand.New(rand.NewSource(0))
+
+	testChain, err := chain.NewTestChain(context.Background(), types.GenesisAlloc{}, nil)
+	assert.NoError(t, err)
+	corpus, err := fuzzingCorpus.NewCorpus("", &projectConfig.Fuzzing)
+	assert.NoError(t, err)
+	assert.NoError(t, corpus.Initialize(testChain, nil, randomProvider))
+
+	fuzzer := &Fuzzer{config: *projectConfig, corpus: corpus, metrics: newFuzzerMetrics(1, nil, &projectConfig.Fuzzing)}
+	defer fuzzer.metrics.stopIndicatorAggregator()
+	worker := &FuzzerWorker{fuzzer: fuzzer, chain: testChain, randomProvider: randomProvider}
+	generatorConfig, err := defaultCallSequenceGeneratorConfigFunc(fuzzer, valuegeneration.NewValueSet(), randomProvider)
+	assert.NoError(t, err)
+	generatorConfig.NewSequenceProbability = 0
+	generator := NewCallSequenceGenerator(worker, generatorConfig)
+
+	// An empty corpus yields a new sequence, which is not a replay.
+	isNewSequence, err := generator.InitializeNextSequence()
+	assert.NoError(t, err)
+	assert.True(t, isNewSequence)
+	assert.False(t, generator.ReplayingCorpus())
+
+	// Remote call sequences are replayed without mutations.
+	contractAbi, err := abi.JSON(strings.NewReader(`[{"type": "function", "name": "set", "stateMutability": "nonpayable", "inputs": [{"name": "value", "type": "uint256"}]}]`))
+	assert.NoError(t, err)
+	method := contractAbi.Methods["set"]
+	to := common.HexToAddress("0x1234")
+	msg := calls.NewCallMessageWithAbiValueData(common.HexToAddress("0x10000"), &to, 0, big.NewInt(0), 1_000_000, big.NewInt(1), big.NewInt(1), big.NewInt(1), &calls.CallMessageDataAbiValues{
+		Method:      &method,
+		InputValues: []any{big.NewInt(1)},
+	})
+	sequence := calls.CallSequence{calls.NewCallSequenceElement(nil, msg, 0, 0)}
+	corpus.AddRemoteCallSequences([]calls.CallSequence{sequence})
+	isNewSequence, err = generator.InitializeNextSequence()
+	assert.NoError(t, err)
+	assert.False(t, isNewSequence)
+	assert.True(t, generator.ReplayingCorpus())
+
+	// Once the sequence can be mutated, sequences derived from it are not replays.
+	assert.NoError(t, corpus.MarkCallSequenceForMutation(sequence, big.NewInt(1)))
+	for i := 0; i < 10; i++ {
+		isNewSequence, err = generator.InitializeNextSequence()
+		assert.NoError(t, err)
+		assert.True(t, isNewSequence)
+		assert.False(t, generator.ReplayingCorpus())
+	}
+}
+
+// TestApplyTimeAcceleration ensures the time jumps of the time acceleration schedule are applied at segment boundaries
+// to both new calls and calls taken from the corpus, that calls taken from the corpus lose the time jump of their
+// previous position, that corpus call sequences replayed without mutations keep theirs, and that block warps add to
+// them rather than replacing them.
+func TestApplyTimeAcceleration(t *testing.T) {
+	projectConfig, err := config.GetDefaultProjectConfig("")
+	assert.NoError(t, err)
+	projectConfig.Fuzzing.Testing.HelperContract.Enabled = false
+	projectConfig.Fuzzing.TimeAcceleration = config.TimeAccelerationConfig{
+		Enabled:         true,
+		SegmentLength:   2,
+		Jumps:           []uint64{86_400},
+		JumpProbability: 1,
+		BlockTime:       12,
+	}
+	randomProvider := rand.New(rand.NewSource(0))
+
+	testChain, err := chain.NewTestChain(context.Background(), types.GenesisAlloc{}, nil)
+	assert.NoError(t, err)
+	corpus, err := fuzzingCorpus.NewCorpus("", &projectConfig.Fuzzing)
+	assert.NoError(t, err)
+	assert.NoError(t, corpus.Initialize(testChain, nil, randomProvider))
+	fuzzer := &Fuzzer{config: *projectConfig, corpus: corpus}
+	worker := &FuzzerWorker{fuzzer: fuzzer, chain: testChain, randomProvider: randomProvider}
+	generator := &CallSequenceGenerator{worker: worker}
+
+	// getCorpusSequence returns a corpus call sequence whose second call carries a time jump, as applied at its
+	// previous position in a sequence with segments of a single call.
+	to := common.HexToAddress("0x1234")
+	getCorpusSequence := func() calls.CallSequence {
+		sequence := make(calls.CallSequence, 4)
+		for i := range sequence {
+			msg := calls.NewCallMessage(common.HexToAddress("0x10000"), &to, 0, big.NewInt(0), 1_000_000, big.NewInt(1), big.NewInt(1), big.NewInt(1), nil)
+			sequence[i] = calls.NewCallSequenceElement(nil, msg, 1, 10)
+		}
+		sequence[1].TimeJump = 86_400
+		sequence[1].BlockTimestampDelay += 86_400
+		sequence[1].BlockNumberDelay += 7_200
+		return sequence
+	}
+
+	// popSequence pops every element of the generator's base sequence.
+	popSequence := func() {
+		generator.fetchIndex = 0
+		for i := 0; i < len(generator.baseSequence); i++ {
+			element, err := generator.PopSequenceElement()
+			assert.NoError(t, err)
+			assert.NotNil(t, element)
+		}
+	}
+
+	// A mutated sequence follows the segments of the current sequence.
+	generator.baseSequence = getCorpusSequence()
+	popSequence()
+	for i, element := range generator.baseSequence {
+		if i == 2 {
+			assert.EqualValues(t, 86_400, element.TimeJump, i)
+			assert.EqualValues(t, 86_410, element.BlockTimestampDelay, i)
+			assert.EqualValues(t, 7_201, element.BlockNumberDelay, i)
+		} else {
+			assert.EqualValues(t, 0, element.TimeJump, i)
+			assert.EqualValues(t, 10, element.BlockTimestampDelay, i)
+			assert.EqualValues(t, 1, element.BlockNumberDelay, i)
+		}
+	}
+
+	// A block warp is added to the time jump.
+	projectConfig.Fuzzing.BlockWarp = config.BlockWarpConfig{Enabled: true, Warps: []uint64{3_600}, WarpProbability: 1, BlockTime: 12}
+	fuzzer.config = *projectConfig
+	generator.baseSequence = getCorpusSequence()
+	generator.blockDependencies = bugdetector.TimestampDependency
+	popSequence()
+	assert.EqualValues(t, 86_400, generator.baseSequence[2].TimeJump)
+	assert.EqualValues(t, 90_010, generator.baseSequence[2].BlockTimestampDelay)
+	assert.EqualValues(t, 7_202, generator.baseSequence[2].BlockNumberDelay)
+
+	// A replayed sequence keeps its time jumps.
+	projectConfig.Fuzzing.BlockWarp.Enabled = false
+	fuzzer.config = *projectConfig
+	generator.baseSequence = getCorpusSequence()
+	generator.replayingCorpus = true
+	popSequence()
+	assert.EqualValues(t, getCorpusSequence()[1].BlockTimestampDelay, generator.baseSequence[1].BlockTimestampDelay)
+	assert.EqualValues(t, 86_400, generator.baseSequence[1].TimeJump)
+	assert.EqualValues(t, 0, generator.baseSequence[2].TimeJump)
+}
@@ -175,7 +175,7 @@ func setupFuzzHelperContract(fuzzer *Fuzzer, testChain *chain.TestChain) (*execu
 		contractBalance = new(uint256.Int).Div(senderBalance, uint256.NewInt(2)).ToBig()
 	}
 
-	msg := calls.NewCallMessage(fuzzer.senders[0], nil, 0, contractBalance, blockGasLimit, nil, nil, nil, msgData)
+	msg := calls.NewCallMessage(fuzzer.senders[0], nil, 0, contractBalance, testChain.BlockGasLimit, nil, nil, nil, msgData)
 	msg.FillFromTestChainProperties(testChain)
 
 	block, err := testChain.PendingBlockCreate()
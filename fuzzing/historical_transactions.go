@@ -0,0 +1,173 @@
+package fuzzing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/common/hexutil"
+	"github.com/crytic/medusa-geth/rpc"
+	"github.com/crytic/medusa/fuzzing/calls"
+)
+
+// historicalBlockBatchSize describes the amount of blocks fetched from the fork's RPC in a single batch request while
+// searching for historical transactions.
+const historicalBlockBatchSize = 50
+
+// historicalTransaction describes a transaction of a block fetched from the fork's RPC, decoding only the fields used
+// to replay it, so that the transaction types of other chains do not prevent decoding blocks.
+type historicalTransaction struct {
+	From  common.Address  `json:"from"`
+	To    *common.Address `json:"to"`
+	Input hexutil.Bytes   `json:"input"`
+	Value *hexutil.Big    `json:"value"`
+}
+
+// historicalBlock describes a block fetched from the fork's RPC, along with its transactions.
+type historicalBlock struct {
+	Number       hexutil.Uint64          `json:"number"`
+	Timestamp    hexutil.Uint64          `json:"timestamp"`
+	Transactions []historicalTransaction `json:"transactions"`
+}
+
+// minedTransaction describes a historical transaction along with the block it was mined in.
+type minedTransaction struct {
+	transaction historicalTransaction
+	block       *historicalBlock
+}
+
+// importHistoricalTransactions fetches the most recent transactions sent to on-chain targets before the fork block,
+// as configured by the project configuration, and converts them into call sequences to be replayed before fuzzing.
+// Returns the call sequences, or an error if the transactions could not be fetched.
+func (f *Fuzzer) importHistoricalTransactions() ([]calls.CallSequence, error) {
+	historicalConfig := f.config.Fuzzing.HistoricalTransactions
+	if historicalConfig.Count == 0 || !f.isOnChainTarget {
+		return nil, nil
+	}
+	client, block, err := f.dialForkRpc()
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, errors.New("importing historical transactions requires a fork RPC URL")
+	}
+	defer client.Close()
+
+	// Determine the block to search back from.
+	var forkBlock hexutil.Uint64
+	if block == "latest" {
+		if err = client.CallContext(f.ctx, &forkBlock, "eth_blockNumber"); err != nil {
+			return nil, fmt.Errorf("failed to fetch the fork block number: %w", err)
+		}
+	} else if err = forkBlock.UnmarshalText([]byte(block)); err != nil {
+		return nil, err
+	}
+
+	targets := make(map[common.Address]struct{})
+	for _, target := range f.config.Fuzzing.TargetContracts {
+		if common.IsHexAddress(target) {
+			targets[common.HexToAddress(target)] = struct{}{}
+		}
+	}
+	transactions, err := fetchHistoricalTransactions(f.ctx, client, uint64(forkBlock), targets, historicalConfig.Count, historicalConfig.MaxBlocks)
+	if err != nil {
+		return nil, err
+	}
+	sequences := f.historicalCallSequences(transactions)
+	f.logger.Info(fmt.Sprintf("Imported %d historical transaction(s) to on-chain targets as %d call sequence(s)", len(transactions), len(sequences)))
+	return sequences, nil
+}
+
+// fetchHistoricalTransactions searches the blocks of the provided RPC back from the provided block, in batches, for at
+// most the provided amount of transactions sent to the provided targets, searching at most the provided amount of
+// blocks.
+// Returns the transactions found, in the order they were mined, or an error if blocks could not be fetched.
+func fetchHistoricalTransactions(ctx context.Context, client *rpc.Client, fromBlock uint64, targets map[common.Address]struct{}, count uint64, maxBlocks uint64) ([]minedTransaction, error) {
+	transactions := make([]minedTransaction, 0)
+	searched := uint64(0)
+	next := fromBlock
+	for uint64(len(transactions)) < count && searched < maxBlocks && searched <= fromBlock {
+		// Fetch a batch of blocks, from the most recent one.
+		batchSize := min(historicalBlockBatchSize, maxBlocks-searched, fromBlock-searched+1)
+		blocks := make([]historicalBlock, batchSize)
+		batch := make([]rpc.BatchElem, batchSize)
+		for i := range batch {
+			batch[i] = rpc.BatchElem{
+				Method: "eth_getBlockByNumber",
+				Args:   []any{hexutil.Uint64(next - uint64(i)), true},
+				Result: &blocks[i],
+			}
+		}
+		if err := client.BatchCallContext(ctx, batch); err != nil {
+			return nil, fmt.Errorf("failed to fetch blocks from the fork RPC: %w", err)
+		}
+
+		// Collect the transactions to the targets, most recent first.
+		for i := range blocks {
+			if batch[i].Error != nil {
+				return nil, fmt.Errorf("failed to fetch block %d from the fork RPC: %w", next-uint64(i), batch[i].Error)
+			}
+			for j := len(blocks[i].Transactions) - 1; j >= 0 && uint64(len(transactions)) < count; j-- {
+				tx := blocks[i].Transactions[j]
+				if tx.To == nil || len(tx.Input) < 4 {
+					continue
+				}
+				if _, isTarget := targets[*tx.To]; isTarget {
+					transactions = append(transactions, minedTransaction{transaction: tx, block: &blocks[i]})
+				}
+			}
+		}
+		searched += batchSize
+		next -= min(batchSize, next)
+	}
+
+	slices.Reverse(transactions)
+	return transactions, nil
+}
+
+// historicalCallSequences converts the provided historical transactions into call sequences of at most the call
+// sequence length, in the order they were mined. Unless directed to keep the original senders, each distinct sender is
+// replaced by one of the fuzzer's senders, so that calls from the same account keep coming from the same sender. Block
+// number and timestamp delays between transactions are kept, within the configured maximum delays.
+func (f *Fuzzer) historicalCallSequences(transactions []minedTransaction) []calls.CallSequence {
+	senders := make(map[common.Address]common.Address)
+	sequenceLength := f.config.Fuzzing.CallSequenceLength
+	sequences := make([]calls.CallSequence, 0, (len(transactions)+sequenceLength-1)/sequenceLength)
+	for i, mined := range transactions {
+		if i%sequenceLength == 0 {
+			sequences = append(sequences, make(calls.CallSequence, 0, sequenceLength))
+		}
+
+		sender := mined.transaction.From
+		if !f.config.Fuzzing.HistoricalTransactions.KeepSenders {
+			if _, ok := senders[sender]; !ok {
+				senders[sender] = f.senders[len(senders)%len(f.senders)]
+			}
+			sender = senders[sender]
+		}
+		value := big.NewInt(0)
+		if mined.transaction.Value != nil {
+			value = mined.transaction.Value.ToInt()
+		}
+		msg := calls.NewCallMessage(sender, mined.transaction.To, 0, value, f.config.Fuzzing.TransactionGasLimit, nil, nil, nil, mined.transaction.Input)
+		msg.SkipFromEOACheck = f.config.Fuzzing.HistoricalTransactions.KeepSenders
+
+		// Keep the delays between the transactions, each sequence starting in a new block.
+		blockNumberDelay, blockTimestampDelay := uint64(1), uint64(1)
+		if i%sequenceLength != 0 {
+			previous := transactions[i-1].block
+			blockNumberDelay = min(uint64(mined.block.Number-previous.Number), f.config.Fuzzing.MaxBlockNumberDelay)
+			blockTimestampDelay = min(uint64(mined.block.Timestamp-previous.Timestamp), f.config.Fuzzing.MaxBlockTimestampDelay)
+			if blockNumberDelay > 0 {
+				blockTimestampDelay = max(blockTimestampDelay, 1)
+			}
+		}
+
+		sequence := &sequences[len(sequences)-1]
+		*sequence = append(*sequence, calls.NewCallSequenceElement(nil, msg, blockNumberDelay, blockTimestampDelay))
+	}
+	return sequences
+}
@@ -0,0 +1,39 @@
+package fuzzing
+
+import (
+	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/fuzzing/logindex"
+)
+
+// LogIndex returns the index of the logs emitted by the calls of the provided call sequence, executed on the worker's
+// chain, so custom oracles, such as call sequence test functions, can query the events a sequence emitted. The index
+// is updated incrementally as the worker's current call sequence grows, and rebuilt when another sequence is provided.
+// It must not be modified, and is only valid until the next call.
+func (fw *FuzzerWorker) LogIndex(callSequence calls.CallSequence) *logindex.LogIndex {
+	if fw.logIndex == nil {
+		fw.logIndex = logindex.NewLogIndex()
+	}
+
+	// Keep the logs of the calls which were already indexed from the same execution. Calls which were not executed
+	// have no chain reference, and are never considered indexed, so they are indexed once executed.
+	indexed := 0
+	for indexed < len(fw.logIndexedCalls) && indexed < len(callSequence) {
+		chainReference := callSequence[indexed].ChainReference
+		if chainReference == nil || fw.logIndexedCalls[indexed] != chainReference {
+			break
+		}
+		indexed++
+	}
+	fw.logIndex.Truncate(indexed)
+	fw.logIndexedCalls = fw.logIndexedCalls[:indexed]
+
+	// Index the logs of the remaining calls.
+	for i := indexed; i < len(callSequence); i++ {
+		chainReference := callSequence[i].ChainReference
+		if chainReference != nil {
+			fw.logIndex.Add(i, chainReference.MessageResults().Receipt.Logs)
+		}
+		fw.logIndexedCalls = append(fw.logIndexedCalls, chainReference)
+	}
+	return fw.logIndex
+}
@@ -0,0 +1,51 @@
+package fuzzing
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	coretypes "github.com/crytic/medusa-geth/core/types"
+	chainTypes "github.com/crytic/medusa/chain/types"
+	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/fuzzing/logindex"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFuzzerWorkerLogIndex ensures the log index of a worker indexes the logs of calls once they are executed, keeps
+// those of calls already indexed from the same execution, and never considers calls which were not executed indexed.
+func TestFuzzerWorkerLogIndex(t *testing.T) {
+	// Create a block whose transactions each emitted a single log.
+	block := &chainTypes.Block{}
+	for i := 0; i < 3; i++ {
+		log := &coretypes.Log{Address: common.BigToAddress(big.NewInt(int64(i + 1)))}
+		block.MessageResults = append(block.MessageResults, &chainTypes.MessageResults{
+			Receipt: &coretypes.Receipt{Logs: []*coretypes.Log{log}},
+		})
+	}
+	chainReference := func(i int) *calls.CallSequenceElementChainReference {
+		return &calls.CallSequenceElementChainReference{Block: block, TransactionIndex: i}
+	}
+	callIndexes := func(index *logindex.LogIndex) []int {
+		var callIndexes []int
+		for _, log := range index.Query(logindex.Query{}) {
+			callIndexes = append(callIndexes, log.CallIndex)
+		}
+		return callIndexes
+	}
+
+	// Calls which were not executed yet are not indexed, until they are executed.
+	worker := &FuzzerWorker{}
+	callSequence := calls.CallSequence{{ChainReference: chainReference(0)}, {}}
+	assert.EqualValues(t, []int{0}, callIndexes(worker.LogIndex(callSequence)))
+	callSequence[1].ChainReference = chainReference(1)
+	assert.EqualValues(t, []int{0, 1}, callIndexes(worker.LogIndex(callSequence)))
+
+	// A call which was not executed is not considered indexed, even if the call previously provided at its position
+	// was not executed either, so it is indexed once executed, along with the calls following it.
+	callSequence = calls.CallSequence{{}, {ChainReference: chainReference(2)}}
+	assert.EqualValues(t, []int{1}, callIndexes(worker.LogIndex(callSequence)))
+	assert.EqualValues(t, []int{1}, callIndexes(worker.LogIndex(callSequence)))
+	callSequence[0].ChainReference = chainReference(0)
+	assert.EqualValues(t, []int{0, 1}, callIndexes(worker.LogIndex(callSequence)))
+}
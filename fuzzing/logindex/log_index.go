@@ -0,0 +1,224 @@
+package logindex
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/crytic/medusa-geth/accounts/abi"
+	"github.com/crytic/medusa-geth/common"
+	coretypes "github.com/crytic/medusa-geth/core/types"
+)
+
+// IndexedLog describes a log emitted by a call of a call sequence.
+type IndexedLog struct {
+	// Log describes the log emitted.
+	Log *coretypes.Log
+
+	// CallIndex describes the index of the call in the call sequence which emitted the log.
+	CallIndex int
+}
+
+// Unpack decodes the provided event from the log, indexed and non-indexed arguments alike.
+// Returns the arguments of the event by name, or an error if the log does not describe the event.
+func (l *IndexedLog) Unpack(event *abi.Event) (map[string]any, error) {
+	if len(l.Log.Topics) == 0 || l.Log.Topics[0] != event.ID {
+		return nil, fmt.Errorf("log does not describe the %v event", event.Sig)
+	}
+	args := make(map[string]any)
+	if err := event.Inputs.UnpackIntoMap(args, l.Log.Data); err != nil {
+		return nil, err
+	}
+	var indexed abi.Arguments
+	for _, input := range event.Inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+		}
+	}
+	if err := abi.ParseTopicsIntoMap(args, indexed, l.Log.Topics[1:]); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+// Query describes a filter over indexed logs, with the same semantics as an eth_getLogs filter.
+type Query struct {
+	// Addresses describes the addresses of the contracts which may have emitted the logs. If empty, logs emitted by
+	// any contract match.
+	Addresses []common.Address
+
+	// Topics describes the topics the logs must have, by position. Each position matches any of the topics it lists,
+	// or any topic if it lists none.
+	Topics [][]common.Hash
+}
+
+// EventQuery returns a Query matching the provided event, emitted by any of the provided addresses, or any contract if
+// none are provided. Values may be provided for the event's indexed arguments, in order, to only match logs with
+// those values, where nil matches any value.
+// Returns the query, or an error if the values could not be encoded as topics.
+func EventQuery(event *abi.Event, addresses []common.Address, indexedValues ...any) (Query, error) {
+	topicValues := make([][]any, 0, len(indexedValues))
+	for _, value := range indexedValues {
+		if value == nil {
+			topicValues = append(topicValues, nil)
+		} else {
+			topicValues = append(topicValues, []any{value})
+		}
+	}
+	topics, err := abi.MakeTopics(topicValues...)
+	if err != nil {
+		return Query{}, err
+	}
+	return Query{
+		Addresses: addresses,
+		Topics:    append([][]common.Hash{{event.ID}}, topics...),
+	}, nil
+}
+
+// matches determines whether the provided log matches the Query.
+func (q *Query) matches(log *coretypes.Log) bool {
+	if len(q.Addresses) > 0 && !slices.Contains(q.Addresses, log.Address) {
+		return false
+	}
+	if len(q.Topics) > len(log.Topics) {
+		return false
+	}
+	for i, topics := range q.Topics {
+		if len(topics) > 0 && !slices.Contains(topics, log.Topics[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// LogIndex stores the logs emitted by the calls of a call sequence, indexed by the contract which emitted them and by
+// their first topic (the event signature), so that test oracles and detectors can query them to express protocol-level
+// invariants.
+type LogIndex struct {
+	// logs describes all logs indexed, in the order they were emitted.
+	logs []*IndexedLog
+
+	// logsByAddress describes the logs indexed, by the address of the contract which emitted them.
+	logsByAddress map[common.Address][]*IndexedLog
+
+	// logsByTopic describes the logs indexed, by their first topic.
+	logsByTopic map[common.Hash][]*IndexedLog
+}
+
+// NewLogIndex returns a new, empty LogIndex.
+func NewLogIndex() *LogIndex {
+	return &LogIndex{
+		logs:          make([]*IndexedLog, 0),
+		logsByAddress: make(map[common.Address][]*IndexedLog),
+		logsByTopic:   make(map[common.Hash][]*IndexedLog),
+	}
+}
+
+// Add indexes the provided logs, emitted by the call at the provided index of the call sequence. Calls must be added
+// in order.
+func (i *LogIndex) Add(callIndex int, logs []*coretypes.Log) {
+	for _, log := range logs {
+		indexedLog := &IndexedLog{Log: log, CallIndex: callIndex}
+		i.logs = append(i.logs, indexedLog)
+		i.logsByAddress[log.Address] = append(i.logsByAddress[log.Address], indexedLog)
+		if len(log.Topics) > 0 {
+			i.logsByTopic[log.Topics[0]] = append(i.logsByTopic[log.Topics[0]], indexedLog)
+		}
+	}
+}
+
+// Truncate removes the logs emitted by the calls at or after the provided index of the call sequence, such as when the
+// calls are reverted.
+func (i *LogIndex) Truncate(callCount int) {
+	keep := func(logs []*IndexedLog) []*IndexedLog {
+		end := len(logs)
+		for end > 0 && logs[end-1].CallIndex >= callCount {
+			end--
+		}
+		return logs[:end]
+	}
+	i.logs = keep(i.logs)
+	for address, logs := range i.logsByAddress {
+		if logs = keep(logs); len(logs) > 0 {
+			i.logsByAddress[address] = logs
+		} else {
+			delete(i.logsByAddress, address)
+		}
+	}
+	for topic, logs := range i.logsByTopic {
+		if logs = keep(logs); len(logs) > 0 {
+			i.logsByTopic[topic] = logs
+		} else {
+			delete(i.logsByTopic, topic)
+		}
+	}
+}
+
+// Len returns the amount of logs indexed.
+func (i *LogIndex) Len() int {
+	return len(i.logs)
+}
+
+// candidates returns the smallest set of indexed logs which contains every log matching the provided query.
+func (i *LogIndex) candidates(query Query) []*IndexedLog {
+	candidates := i.logs
+	if len(query.Addresses) == 1 {
+		candidates = i.logsByAddress[query.Addresses[0]]
+	}
+	if len(query.Topics) > 0 && len(query.Topics[0]) == 1 {
+		if logsByTopic := i.logsByTopic[query.Topics[0][0]]; len(logsByTopic) < len(candidates) {
+			candidates = logsByTopic
+		}
+	}
+	return candidates
+}
+
+// Query returns the indexed logs matching the provided query, in the order they were emitted.
+func (i *LogIndex) Query(query Query) []*IndexedLog {
+	var results []*IndexedLog
+	for _, log := range i.candidates(query) {
+		if query.matches(log.Log) {
+			results = append(results, log)
+		}
+	}
+	return results
+}
+
+// Count returns the amount of indexed logs matching the provided query.
+func (i *LogIndex) Count(query Query) int {
+	return len(i.Query(query))
+}
+
+// Exists determines whether any indexed log matches the provided query.
+func (i *LogIndex) Exists(query Query) bool {
+	for _, log := range i.candidates(query) {
+		if query.matches(log.Log) {
+			return true
+		}
+	}
+	return false
+}
+
+// Unmatched returns the indexed logs matching the provided query, for which no other log matching the provided match
+// query also satisfies the provided match function, e.g. Transfer events from a victim without a matching Deposit
+// event. Each log matching the match query is matched to at most one log. If the match function is nil, any log
+// matching the match query matches.
+func (i *LogIndex) Unmatched(query Query, matchQuery Query, matchFunc func(log *IndexedLog, match *IndexedLog) bool) []*IndexedLog {
+	matches := i.Query(matchQuery)
+	matched := make([]bool, len(matches))
+
+	var unmatched []*IndexedLog
+	for _, log := range i.Query(query) {
+		found := false
+		for j, match := range matches {
+			if !matched[j] && match != log && (matchFunc == nil || matchFunc(log, match)) {
+				matched[j] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			unmatched = append(unmatched, log)
+		}
+	}
+	return unmatched
+}
@@ -0,0 +1,83 @@
+package logindex
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/crytic/medusa-geth/accounts/abi"
+	"github.com/crytic/medusa-geth/common"
+	coretypes "github.com/crytic/medusa-geth/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// tokenAbi describes the events emitted by the token and vault in the tests below.
+const tokenAbi = `[
+	{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256","indexed":false}]},
+	{"type":"event","name":"Deposit","inputs":[{"name":"account","type":"address","indexed":true},{"name":"amount","type":"uint256","indexed":false}]}
+]`
+
+// TestLogIndex ensures logs are queried by address, event and indexed arguments, unmatched logs are found, and logs
+// of truncated calls are removed.
+func TestLogIndex(t *testing.T) {
+	parsedAbi, err := abi.JSON(strings.NewReader(tokenAbi))
+	assert.NoError(t, err)
+	transfer, deposit := parsedAbi.Events["Transfer"], parsedAbi.Events["Deposit"]
+	token, vault := common.HexToAddress("0x1000"), common.HexToAddress("0x2000")
+	victim, attacker := common.HexToAddress("0xaaaa"), common.HexToAddress("0xbbbb")
+
+	// Create logs of the events above.
+	makeLog := func(address common.Address, event abi.Event, indexed []common.Address, amount int64) *coretypes.Log {
+		topics := []common.Hash{event.ID}
+		for _, value := range indexed {
+			topics = append(topics, common.BytesToHash(value.Bytes()))
+		}
+		data, err := abi.Arguments{event.Inputs[len(event.Inputs)-1]}.Pack(big.NewInt(amount))
+		assert.NoError(t, err)
+		return &coretypes.Log{Address: address, Topics: topics, Data: data}
+	}
+
+	// The victim transfers to the vault and deposits, then transfers to the attacker without depositing.
+	index := NewLogIndex()
+	index.Add(0, []*coretypes.Log{
+		makeLog(token, transfer, []common.Address{victim, vault}, 10),
+		makeLog(vault, deposit, []common.Address{victim}, 10),
+	})
+	index.Add(1, []*coretypes.Log{
+		makeLog(token, transfer, []common.Address{victim, attacker}, 5),
+	})
+	assert.EqualValues(t, 3, index.Len())
+
+	// Query transfers from the victim, and transfers to the attacker.
+	fromVictim, err := EventQuery(&transfer, []common.Address{token}, victim)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, index.Count(fromVictim))
+	toAttacker, err := EventQuery(&transfer, nil, nil, attacker)
+	assert.NoError(t, err)
+	assert.True(t, index.Exists(toAttacker))
+	deposits, err := EventQuery(&deposit, []common.Address{token})
+	assert.NoError(t, err)
+	assert.False(t, index.Exists(deposits))
+
+	// Find the transfer from the victim without a matching deposit, and decode it.
+	deposits, err = EventQuery(&deposit, []common.Address{vault})
+	assert.NoError(t, err)
+	unmatched := index.Unmatched(fromVictim, deposits, func(log *IndexedLog, match *IndexedLog) bool {
+		logArgs, err := log.Unpack(&transfer)
+		assert.NoError(t, err)
+		matchArgs, err := match.Unpack(&deposit)
+		assert.NoError(t, err)
+		return logArgs["from"] == matchArgs["account"] && logArgs["value"].(*big.Int).Cmp(matchArgs["amount"].(*big.Int)) == 0
+	})
+	assert.Len(t, unmatched, 1)
+	assert.EqualValues(t, 1, unmatched[0].CallIndex)
+	args, err := unmatched[0].Unpack(&transfer)
+	assert.NoError(t, err)
+	assert.EqualValues(t, attacker, args["to"])
+
+	// Truncating the second call should remove its transfer.
+	index.Truncate(1)
+	assert.EqualValues(t, 2, index.Len())
+	assert.False(t, index.Exists(toAttacker))
+	assert.Empty(t, index.Unmatched(fromVictim, deposits, nil))
+}
@@ -2,6 +2,7 @@ package fuzzing
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -10,26 +11,136 @@ import (
 	"github.com/crytic/medusa/chain"
 	compilationTypes "github.com/crytic/medusa/compilation/types"
 	"github.com/crytic/medusa/fuzzing/executiontracer"
+	"github.com/crytic/medusa/fuzzing/explorer"
 
 	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/common/hexutil"
+	"github.com/crytic/medusa-geth/rpc"
 )
 
-func (f *Fuzzer) loadOnChainContract(targetAddress string) (*compilationTypes.CompiledContract, error) {
+// loadOnChainContract loads the ABI of the on-chain contract at the provided address, from a verified contract source,
+// the local ABI files, or its runtime bytecode, in that order. The storage layout is loaded along with a verified ABI,
+// if the verified contract source reports it.
+// Returns the contract, the implementation reported by the verified contract source if the contract is a proxy (or a
+// zero address otherwise), or an error if no ABI could be loaded.
+func (f *Fuzzer) loadOnChainContract(targetAddress string) (*compilationTypes.CompiledContract, common.Address, error) {
 	targetAddress = strings.ToLower(targetAddress)
-	contractAbiStr, err := getAbiStr(targetAddress)
-	if err != nil {
-		return nil, err
+	var implementationHint common.Address
+	var contractAbiStr string
+	var storageLayout *compilationTypes.StorageLayout
+	verifiedContract, err := f.fetchVerifiedContract(targetAddress)
+	if err == nil {
+		contractAbiStr = string(verifiedContract.ABI)
+		implementationHint = verifiedContract.Implementation
+		if len(verifiedContract.StorageLayout) > 0 {
+			storageLayout, err = compilationTypes.ParseStorageLayout(verifiedContract.StorageLayout)
+			if err != nil {
+				f.logger.Warn(fmt.Sprintf("Failed to parse the storage layout of %s, its state variables will not be named", targetAddress), err)
+			}
+		}
+	} else {
+		contractAbiStr, err = getAbiStr(targetAddress)
+		if err != nil {
+			contractAbiStr, err = f.synthesizeAbiStr(targetAddress, err)
+			if err != nil {
+				return nil, implementationHint, err
+			}
+		}
 	}
 
 	contractAbi, err := abi.JSON(strings.NewReader(contractAbiStr))
 	if err != nil {
-		return nil, fmt.Errorf("ABI Parser error: %v, contractInfo: %#v", err, contractAbi)
+		return nil, implementationHint, fmt.Errorf("ABI Parser error: %v, contractInfo: %#v", err, contractAbi)
 	}
 
 	contract := compilationTypes.CompiledContract{
-		Abi: contractAbi,
+		Abi:           contractAbi,
+		StorageLayout: storageLayout,
 	}
-	return &contract, nil
+	return &contract, implementationHint, nil
+}
+
+// fetchVerifiedContract obtains the verified on-chain contract at the provided address from the block explorer API,
+// then from Sourcify, as enabled by the project configuration. Failures are logged, so that the caller may fall back to
+// the local ABI files.
+// Returns the verified contract, or an error if it could not be fetched.
+func (f *Fuzzer) fetchVerifiedContract(address string) (*explorer.VerifiedContract, error) {
+	if f.contractSources == nil {
+		explorerConfig := f.config.Fuzzing.Explorer
+		f.contractSources = make([]explorer.ContractSource, 0)
+		if explorerConfig.Enabled {
+			f.contractSources = append(f.contractSources, explorer.NewClient(explorerConfig.ApiUrl, explorerConfig.ApiKey, explorerConfig.ChainId, explorerConfig.CacheDirectory, explorerConfig.RequestsPerSecond))
+		}
+		if explorerConfig.SourcifyEnabled {
+			f.contractSources = append(f.contractSources, explorer.NewSourcifyClient(explorerConfig.SourcifyApiUrl, explorerConfig.ChainId, explorerConfig.CacheDirectory))
+		}
+	}
+	if len(f.contractSources) == 0 {
+		return nil, errors.New("fetching verified ABIs is disabled")
+	}
+
+	for _, source := range f.contractSources {
+		contract, err := source.FetchContract(common.HexToAddress(address))
+		if err != nil {
+			f.logger.Warn(fmt.Sprintf("Failed to fetch the verified ABI of %s from %s", address, source.Name()), err)
+			continue
+		}
+		f.logger.Info(fmt.Sprintf("Fetched the verified ABI of %s (%s, compiler %s)", address, contract.ContractName, contract.CompilerVersion))
+		return contract, nil
+	}
+	return nil, fmt.Errorf("no verified ABI found for %s", address)
+}
+
+// dialForkRpc connects to the RPC of the forked chain, if fork mode is enabled by the project configuration.
+// Returns the RPC client and the block to query state at, or a nil client if fork mode is disabled.
+func (f *Fuzzer) dialForkRpc() (*rpc.Client, string, error) {
+	forkConfig := f.config.Fuzzing.TestChainConfig.ForkConfig
+	if !forkConfig.ForkModeEnabled || forkConfig.RpcUrl == "" {
+		return nil, "", nil
+	}
+	client, err := rpc.Dial(forkConfig.RpcUrl)
+	if err != nil {
+		return nil, "", err
+	}
+	block := "latest"
+	if forkConfig.RpcBlock != 0 {
+		block = hexutil.Uint64(forkConfig.RpcBlock).String()
+	}
+	return client, block, nil
+}
+
+// synthesizeAbiStr synthesizes an ABI for the on-chain contract at the provided address from the function selectors
+// found in its runtime bytecode, which is fetched from the forked chain's RPC, if enabled by the project configuration.
+// The provided error describes why the local ABI files could not be used, and is returned if the heuristic is disabled.
+// Returns the ABI as a JSON string, or an error if none could be synthesized.
+func (f *Fuzzer) synthesizeAbiStr(address string, localAbiErr error) (string, error) {
+	if !f.config.Fuzzing.Explorer.SelectorHeuristicEnabled {
+		return "", localAbiErr
+	}
+
+	// Fetch the runtime bytecode at the forked block.
+	client, block, err := f.dialForkRpc()
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch the code of %s: %w", address, err)
+	} else if client == nil {
+		return "", localAbiErr
+	}
+	defer client.Close()
+	var code hexutil.Bytes
+	err = client.Call(&code, "eth_getCode", common.HexToAddress(address), block)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch the code of %s: %w", address, err)
+	}
+
+	contract, unresolved, err := explorer.SynthesizeContract(common.HexToAddress(address), code)
+	if err != nil {
+		return "", err
+	}
+	if len(unresolved) > 0 {
+		f.logger.Warn(fmt.Sprintf("Could not resolve %d selector(s) of %s, their functions will not be fuzzed", len(unresolved), address))
+	}
+	f.logger.Info(fmt.Sprintf("Synthesized an ABI for %s from the selectors in its bytecode", address))
+	return string(contract.ABI), nil
 }
 
 func getAbiStrFromJson(address string) (string, error) {
@@ -49,36 +160,7 @@ func getAbiStrFromJson(address string) (string, error) {
 		return "", fmt.Errorf("no ABI found for address %s in %s", address, abiFilePath)
 	}
 
-	var methodAbis []string
-	for _, sig := range signatures {
-		parts := strings.SplitN(sig, "(", 2)
-		if len(parts) < 2 {
-			continue // Invalid signature format
-		}
-		name := parts[0]
-		inputTypesStr := strings.TrimSuffix(parts[1], ")")
-
-		var inputTypes []string
-		if inputTypesStr != "" {
-			inputTypes = strings.Split(inputTypesStr, ",")
-		}
-
-		var inputsJson []string
-		for i, inputType := range inputTypes {
-			inputsJson = append(inputsJson, fmt.Sprintf(`{"name": "arg%d", "type": "%s"}`, i, inputType))
-		}
-
-		// Assume payable to allow fuzzing with value transfers.
-		// Assume no outputs for simplicity.
-		methodAbi := fmt.Sprintf(
-			`{"type": "function", "name": "%s", "inputs": [%s], "outputs": [], "stateMutability": "payable"}`,
-			name,
-			strings.Join(inputsJson, ","),
-		)
-		methodAbis = append(methodAbis, methodAbi)
-	}
-
-	return "[" + strings.Join(methodAbis, ",") + "]", nil
+	return explorer.SynthesizeAbi(signatures), nil
 }
 
 const ABIPath string = "abis"
@@ -110,10 +192,15 @@ func chainSetupOnChain(fuzzer *Fuzzer, testChain *chain.TestChain) (*executiontr
 
 	for _, contractDefinition := range fuzzer.contractDefinitions {
 		contractAddress := common.HexToAddress(contractDefinition.Name())
-		contractDefinition.CompiledContract().RuntimeBytecode = testChain.State().GetCode(contractAddress)
-		if len(contractDefinition.CompiledContract().RuntimeBytecode) == 0 {
+		code := testChain.State().GetCode(contractAddress)
+		if len(code) == 0 {
 			return nil, fmt.Errorf("failed to get code for on-chain target contract %s", contractAddress.Hex())
 		}
+
+		// The code is only recorded once, as workers read it concurrently when the forked chain state is refreshed.
+		if len(contractDefinition.CompiledContract().RuntimeBytecode) == 0 {
+			contractDefinition.CompiledContract().RuntimeBytecode = code
+		}
 	}
 	return nil, nil
 }
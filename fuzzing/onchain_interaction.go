@@ -0,0 +1,137 @@
+package fuzzing
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/fuzzing/calls"
+	fuzzerTypes "github.com/crytic/medusa/fuzzing/contracts"
+)
+
+// onChainTransition describes a call to the contract at address to directly following a call to the contract at
+// address from, within a call sequence.
+type onChainTransition struct {
+	from common.Address
+	to   common.Address
+}
+
+// onChainInteractionModel counts, for each transition between on-chain targets, how often the call sequence ending
+// with it was added to the corpus. It is shared between workers to interleave calls among targets, favouring the
+// transitions which reach new cross-contract paths.
+type onChainInteractionModel struct {
+	// rewards maps each transition to the amount of times it produced new coverage.
+	rewards map[onChainTransition]uint64
+
+	// lock offers concurrent thread safety for reward accesses.
+	lock sync.RWMutex
+}
+
+// newOnChainInteractionModel returns a new onChainInteractionModel with no transitions rewarded.
+func newOnChainInteractionModel() *onChainInteractionModel {
+	return &onChainInteractionModel{
+		rewards: make(map[onChainTransition]uint64),
+	}
+}
+
+// reward records that a call to the contract at address to directly following a call to the contract at address from
+// produced new coverage.
+func (m *onChainInteractionModel) reward(from common.Address, to common.Address) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.rewards[onChainTransition{from: from, to: to}]++
+}
+
+// weight returns the weight of a transition from the contract at address from to the contract at address to, which is
+// one more than the amount of times it produced new coverage, so that transitions never observed can still be chosen.
+func (m *onChainInteractionModel) weight(from common.Address, to common.Address) uint64 {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.rewards[onChainTransition{from: from, to: to}] + 1
+}
+
+// onChainContractDefinition returns the definition of the on-chain target at the provided address. On-chain targets
+// are matched by address rather than bytecode, as several targets may share the same bytecode (e.g. tokens or proxies
+// deployed from the same source) while being fuzzed with different ABIs.
+// Returns nil if the address is not an on-chain target.
+func (f *Fuzzer) onChainContractDefinition(address common.Address) *fuzzerTypes.Contract {
+	if !f.isOnChainTarget {
+		return nil
+	}
+	name := strings.ToLower(address.Hex())
+	for _, contractDefinition := range f.contractDefinitions {
+		if contractDefinition.Name() == name {
+			return contractDefinition
+		}
+	}
+	return nil
+}
+
+// recordOnChainInteraction rewards the transition between the on-chain targets of the last two calls of the provided
+// call sequence, which was added to the corpus, in the fuzzer's interaction model. Calls to the same target are not
+// recorded, as the model only drives switches between targets.
+func (fw *FuzzerWorker) recordOnChainInteraction(callSequence calls.CallSequence) {
+	if fw.fuzzer.onChainInteractions == nil || len(callSequence) < 2 {
+		return
+	}
+	from := callSequence[len(callSequence)-2].Call.To
+	to := callSequence[len(callSequence)-1].Call.To
+	if from == nil || to == nil || *from == *to {
+		return
+	}
+	fw.fuzzer.onChainInteractions.reward(*from, *to)
+}
+
+// interleavedOnChainMethod returns a random state-changing method of an on-chain target other than the one called by
+// the previous call of the current sequence, with the configured probability. The target is chosen with a probability
+// proportional to the weight of the transition to it in the fuzzer's interaction model.
+// Returns nil if no such method was chosen.
+func (g *CallSequenceGenerator) interleavedOnChainMethod() *fuzzerTypes.DeployedContractMethod {
+	model := g.worker.fuzzer.onChainInteractions
+	if model == nil || g.fetchIndex == 0 {
+		return nil
+	}
+	previous := g.baseSequence[g.fetchIndex-1]
+	if previous == nil || previous.Call.To == nil {
+		return nil
+	}
+	if g.worker.randomProvider.Float32() >= g.worker.fuzzer.config.Fuzzing.OnChainInteraction.SwitchProbability {
+		return nil
+	}
+
+	// Group the methods of the other targets by address. Methods are enumerated sorted by address, so the methods of
+	// each target are contiguous.
+	from := *previous.Call.To
+	type targetMethods struct {
+		start, end int
+		weight     uint64
+	}
+	methods := g.worker.stateChangingMethods
+	targets := make([]targetMethods, 0)
+	var totalWeight uint64
+	for start := 0; start < len(methods); {
+		end := start + 1
+		for end < len(methods) && methods[end].Address == methods[start].Address {
+			end++
+		}
+		if methods[start].Address != from {
+			weight := model.weight(from, methods[start].Address)
+			targets = append(targets, targetMethods{start: start, end: end, weight: weight})
+			totalWeight += weight
+		}
+		start = end
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	// Choose a target according to the weights of the transitions to it, then one of its methods.
+	choice := uint64(g.worker.randomProvider.Int63n(int64(totalWeight)))
+	selected := 0
+	for choice >= targets[selected].weight {
+		choice -= targets[selected].weight
+		selected++
+	}
+	target := targets[selected]
+	return &methods[target.start+g.worker.randomProvider.Intn(target.end-target.start)]
+}
@@ -0,0 +1,126 @@
+package fuzzing
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/crytic/medusa-geth/accounts/abi"
+	"github.com/crytic/medusa-geth/common"
+	compilationTypes "github.com/crytic/medusa/compilation/types"
+	fuzzerTypes "github.com/crytic/medusa/fuzzing/contracts"
+	"github.com/crytic/medusa/fuzzing/explorer"
+)
+
+// loadOnChainTarget creates the contract definitions of the on-chain target at the provided address. If the target is
+// a proxy and proxy resolution is enabled by the project configuration, the target is fuzzed through the proxy address
+// using the ABI of its implementation (along with the proxy's own), and a definition without any methods is added for
+// the implementation, so that the fitness metrics recorded while executing its code are attributed to it.
+// Returns the contract definitions, or an error if no ABI could be loaded for the target.
+func (f *Fuzzer) loadOnChainTarget(targetAddress string) (fuzzerTypes.Contracts, error) {
+	contract, implementationHint, err := f.loadOnChainContract(targetAddress)
+	implementationAddress := f.resolveOnChainProxy(targetAddress, implementationHint)
+	if implementationAddress == (common.Address{}) {
+		if err != nil {
+			return nil, err
+		}
+		return fuzzerTypes.Contracts{fuzzerTypes.NewContract(targetAddress, targetAddress, contract, nil)}, nil
+	}
+
+	// Fuzz the implementation's methods through the proxy. The proxy's own ABI is optional, as proxies are rarely
+	// verified on their own.
+	implementationName := strings.ToLower(implementationAddress.Hex())
+	implementationContract, _, implementationErr := f.loadOnChainContract(implementationName)
+	implementationDefinition := &compilationTypes.CompiledContract{}
+	if implementationErr != nil {
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the ABI of proxy %s or its implementation %s: %w", targetAddress, implementationName, implementationErr)
+		}
+		f.logger.Warn(fmt.Sprintf("Failed to load the ABI of the implementation %s of proxy %s, only the proxy's methods will be fuzzed", implementationName, targetAddress), implementationErr)
+	} else {
+		// The implementation's code runs against the proxy's storage, so both are read with its storage layout.
+		contract = &compilationTypes.CompiledContract{
+			Abi:           mergeProxyAbis(contract, implementationContract),
+			StorageLayout: implementationContract.StorageLayout,
+		}
+		implementationDefinition.StorageLayout = implementationContract.StorageLayout
+	}
+
+	return fuzzerTypes.Contracts{
+		fuzzerTypes.NewContract(targetAddress, targetAddress, contract, nil),
+		fuzzerTypes.NewContract(implementationName, implementationName, implementationDefinition, nil),
+	}, nil
+}
+
+// addOnChainContractDefinitions adds the provided on-chain contract definitions to the fuzzer. Definitions of addresses
+// which are already known, such as an implementation shared by several proxies, are only added once. If an address is
+// both a target and the implementation of another target, the definition with methods is kept, so that it is fuzzed.
+func (f *Fuzzer) addOnChainContractDefinitions(contractDefinitions fuzzerTypes.Contracts) {
+	for _, contractDefinition := range contractDefinitions {
+		index := slices.IndexFunc(f.contractDefinitions, func(existing *fuzzerTypes.Contract) bool {
+			return existing.Name() == contractDefinition.Name()
+		})
+		if index < 0 {
+			f.contractDefinitions = append(f.contractDefinitions, contractDefinition)
+		} else if len(f.contractDefinitions[index].CompiledContract().Abi.Methods) == 0 {
+			f.contractDefinitions[index] = contractDefinition
+		}
+	}
+}
+
+// resolveOnChainProxy determines whether the on-chain target at the provided address is a proxy, from the standard
+// proxy storage slots of the forked chain, or from the implementation reported by a verified contract source
+// otherwise. Failures are logged, and the target is then treated as a regular contract.
+// Returns the address of the implementation, or a zero address if the target is not a proxy or proxy resolution is
+// disabled.
+func (f *Fuzzer) resolveOnChainProxy(targetAddress string, implementationHint common.Address) common.Address {
+	if !f.config.Fuzzing.Explorer.ProxyResolutionEnabled {
+		return common.Address{}
+	}
+
+	client, block, err := f.dialForkRpc()
+	if err != nil {
+		f.logger.Warn(fmt.Sprintf("Failed to determine whether %s is a proxy", targetAddress), err)
+	} else if client != nil {
+		defer client.Close()
+		implementationAddress, kind, err := explorer.ResolveProxy(client, common.HexToAddress(targetAddress), block)
+		if err != nil {
+			f.logger.Warn(fmt.Sprintf("Failed to determine whether %s is a proxy", targetAddress), err)
+		} else if implementationAddress != (common.Address{}) {
+			f.logger.Info(fmt.Sprintf("Resolved %s proxy %s to implementation %s", kind, targetAddress, strings.ToLower(implementationAddress.Hex())))
+			return implementationAddress
+		}
+	}
+
+	if implementationHint != (common.Address{}) {
+		f.logger.Info(fmt.Sprintf("Resolved proxy %s to implementation %s reported by the block explorer", targetAddress, strings.ToLower(implementationHint.Hex())))
+	}
+	return implementationHint
+}
+
+// mergeProxyAbis merges the ABI of a proxy with the ABI of its implementation. The implementation's entries take
+// precedence, as calls to methods the proxy does not handle itself are forwarded to it. The proxy ABI may be nil.
+// Returns the merged ABI.
+func mergeProxyAbis(proxyContract *compilationTypes.CompiledContract, implementationContract *compilationTypes.CompiledContract) abi.ABI {
+	merged := implementationContract.Abi
+	if proxyContract == nil {
+		return merged
+	}
+	merged.Methods = maps.Clone(proxyContract.Abi.Methods)
+	if merged.Methods == nil {
+		merged.Methods = make(map[string]abi.Method)
+	}
+	maps.Copy(merged.Methods, implementationContract.Abi.Methods)
+	merged.Events = maps.Clone(proxyContract.Abi.Events)
+	if merged.Events == nil {
+		merged.Events = make(map[string]abi.Event)
+	}
+	maps.Copy(merged.Events, implementationContract.Abi.Events)
+	merged.Errors = maps.Clone(proxyContract.Abi.Errors)
+	if merged.Errors == nil {
+		merged.Errors = make(map[string]abi.Error)
+	}
+	maps.Copy(merged.Errors, implementationContract.Abi.Errors)
+	return merged
+}
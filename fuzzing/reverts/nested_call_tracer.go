@@ -0,0 +1,149 @@
+package reverts
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core/tracing"
+	coretypes "github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa-geth/eth/tracers"
+	"github.com/crytic/medusa/chain"
+	"github.com/crytic/medusa/chain/types"
+)
+
+// nestedCallTracerResultsKey describes the key to use when storing tracer results in call message results, or when
+// querying them.
+const nestedCallTracerResultsKey = "NestedCallTracerResults"
+
+// GetNestedCallTracerResults obtains the nested call frames stored by a NestedCallTracer from message results, in the
+// order they were entered. This is nil if the message dispatched no nested calls, or if no NestedCallTracer was
+// attached during this message execution.
+func GetNestedCallTracerResults(messageResults *types.MessageResults) []NestedCallFrame {
+	// Try to obtain the results the tracer should've stored.
+	if genericResult, ok := messageResults.AdditionalResults[nestedCallTracerResultsKey]; ok {
+		if castedResult, ok := genericResult.([]NestedCallFrame); ok {
+			return castedResult
+		}
+	}
+
+	// If we could not obtain them, return nil.
+	return nil
+}
+
+// NestedCallFrame describes a call frame dispatching a call encoded within the input of its parent call frame (e.g.
+// one of the calls provided to multicall(bytes[])), along with its own result.
+type NestedCallFrame struct {
+	// Parent describes the index of the nested call frame which this one is nested within, or -1 if it is nested
+	// within the top-level call.
+	Parent int
+
+	// CodeAddress describes the address of the code executed by the call frame, whose ABI describes the call.
+	CodeAddress common.Address
+
+	// Selector describes the function selector the call frame was entered with.
+	Selector [4]byte
+
+	// Err describes the error the call frame exited with, if any.
+	Err error
+
+	// ReturnData describes the return or revert data of the call frame.
+	ReturnData []byte
+}
+
+// nestedCallTracerCallFrameState tracks state across call frames in the NestedCallTracer.
+type nestedCallTracerCallFrameState struct {
+	// input describes the input the call frame was entered with.
+	input []byte
+
+	// nestedIndex describes the index of the nested call frame this call frame is, or is nested within, or -1 if none.
+	nestedIndex int
+
+	// nested indicates whether this call frame is a nested call frame.
+	nested bool
+}
+
+// NestedCallTracer implements tracers.Tracer to capture the result of each call dispatched from call data encoded in
+// the input of its parent call frame, so revert metrics can be attributed to the functions actually exercised.
+type NestedCallTracer struct {
+	// callFrameStates describes the state tracked by the tracer per call frame.
+	callFrameStates []nestedCallTracerCallFrameState
+
+	// nestedCallFrames describes the nested call frames entered during the current transaction.
+	nestedCallFrames []NestedCallFrame
+
+	// nativeTracer is the underlying tracer used to capture EVM execution.
+	nativeTracer *chain.TestChainTracer
+}
+
+// NewNestedCallTracer returns a new NestedCallTracer.
+func NewNestedCallTracer() *NestedCallTracer {
+	tracer := &NestedCallTracer{}
+	innerTracer := &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnTxStart: tracer.OnTxStart,
+			OnEnter:   tracer.OnEnter,
+			OnExit:    tracer.OnExit,
+		},
+	}
+	tracer.nativeTracer = &chain.TestChainTracer{Tracer: innerTracer, CaptureTxEndSetAdditionalResults: tracer.CaptureTxEndSetAdditionalResults}
+	return tracer
+}
+
+// NativeTracer returns the underlying TestChainTracer.
+func (t *NestedCallTracer) NativeTracer() *chain.TestChainTracer {
+	return t.nativeTracer
+}
+
+// OnTxStart is called upon the start of transaction execution, as defined by tracers.Tracer.
+func (t *NestedCallTracer) OnTxStart(vm *tracing.VMContext, tx *coretypes.Transaction, from common.Address) {
+	t.callFrameStates = t.callFrameStates[:0]
+	t.nestedCallFrames = nil
+}
+
+// OnEnter is called upon entering of the call frame, as defined by tracers.Tracer. A call frame is nested if the call
+// data it was entered with is encoded within the input of its parent call frame.
+func (t *NestedCallTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	callFrameState := nestedCallTracerCallFrameState{input: input, nestedIndex: -1}
+	if len(t.callFrameStates) > 0 {
+		parentState := &t.callFrameStates[len(t.callFrameStates)-1]
+		callFrameState.nestedIndex = parentState.nestedIndex
+
+		opcode := vm.OpCode(typ)
+		if opcode != vm.CREATE && opcode != vm.CREATE2 && len(input) >= 4 && bytes.Contains(parentState.input, input) {
+			callFrameState.nested = true
+			callFrameState.nestedIndex = len(t.nestedCallFrames)
+			nestedCallFrame := NestedCallFrame{Parent: parentState.nestedIndex, CodeAddress: to}
+			copy(nestedCallFrame.Selector[:], input)
+			t.nestedCallFrames = append(t.nestedCallFrames, nestedCallFrame)
+		}
+	}
+	t.callFrameStates = append(t.callFrameStates, callFrameState)
+}
+
+// OnExit is called upon exiting of the call frame, as defined by tracers.Tracer. It records the result of nested call
+// frames.
+func (t *NestedCallTracer) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if len(t.callFrameStates) == 0 {
+		return
+	}
+	callFrameState := t.callFrameStates[len(t.callFrameStates)-1]
+	t.callFrameStates = t.callFrameStates[:len(t.callFrameStates)-1]
+	if callFrameState.nested {
+		nestedCallFrame := &t.nestedCallFrames[callFrameState.nestedIndex]
+		nestedCallFrame.Err = err
+		nestedCallFrame.ReturnData = bytes.Clone(output)
+	}
+}
+
+// CaptureTxEndSetAdditionalResults can be used to set additional results captured from execution tracing. If this
+// tracer is used during transaction execution (block creation), the results can later be queried from the block.
+// This method will only be called on the added tracer if it implements the extended TestChainTracer interface.
+func (t *NestedCallTracer) CaptureTxEndSetAdditionalResults(results *types.MessageResults) {
+	// Store our tracer results, if any nested call was dispatched.
+	if len(t.nestedCallFrames) > 0 {
+		results.AdditionalResults[nestedCallTracerResultsKey] = t.nestedCallFrames
+	}
+	t.nestedCallFrames = nil
+}
@@ -0,0 +1,82 @@
+package reverts
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core"
+	coretypes "github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa/chain"
+	"github.com/crytic/medusa/chain/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNestedCallTracer ensures calls dispatched from call data encoded in the input of their parent call frame are
+// recorded with their own result, along with the nested call they are in turn nested within.
+func TestNestedCallTracer(t *testing.T) {
+	sender := common.HexToAddress("0x10000")
+	router := common.HexToAddress("0x20000")
+	reverter := common.HexToAddress("0x30000")
+	stopper := common.HexToAddress("0x40000")
+
+	testChain, err := chain.NewTestChain(context.Background(), coretypes.GenesisAlloc{
+		// Calls the address in the first argument word with the remaining call data (after the selector and address
+		// word), ignoring its result.
+		router: {Code: common.FromHex("0x6024360360246000376000600060243603600060006004355af100")},
+		// Reverts with no data.
+		reverter: {Code: common.FromHex("0x60006000fd")},
+		// Returns without data.
+		stopper: {Code: common.FromHex("0x00")},
+	}, nil)
+	assert.NoError(t, err)
+
+	// routedCall encodes a call to the router, dispatching the provided call data to the provided target.
+	routedCall := func(selector string, target common.Address, data []byte) []byte {
+		return append(append(common.FromHex(selector), common.LeftPadBytes(target.Bytes(), 32)...), data...)
+	}
+	traceNestedCalls := func(data []byte) []NestedCallFrame {
+		tracer := NewNestedCallTracer()
+		_, err := testChain.CallContract(&core.Message{
+			From:            sender,
+			To:              &router,
+			Value:           big.NewInt(0),
+			GasLimit:        testChain.BlockGasLimit,
+			GasPrice:        big.NewInt(0),
+			GasFeeCap:       big.NewInt(0),
+			GasTipCap:       big.NewInt(0),
+			Data:            data,
+			SkipNonceChecks: true,
+		}, nil, tracer.NativeTracer())
+		assert.NoError(t, err)
+
+		messageResults := &types.MessageResults{AdditionalResults: make(map[string]any)}
+		tracer.CaptureTxEndSetAdditionalResults(messageResults)
+		return GetNestedCallTracerResults(messageResults)
+	}
+
+	// Calls made without call data are not nested calls.
+	assert.Nil(t, traceNestedCalls(routedCall("0x11111111", stopper, nil)))
+
+	// A nested call is recorded with its own result.
+	nestedCallFrames := traceNestedCalls(routedCall("0x11111111", reverter, common.FromHex("0x22222222")))
+	assert.Len(t, nestedCallFrames, 1)
+	assert.EqualValues(t, -1, nestedCallFrames[0].Parent)
+	assert.EqualValues(t, reverter, nestedCallFrames[0].CodeAddress)
+	assert.EqualValues(t, [4]byte{0x22, 0x22, 0x22, 0x22}, nestedCallFrames[0].Selector)
+	assert.ErrorIs(t, nestedCallFrames[0].Err, vm.ErrExecutionReverted)
+
+	// Nested calls record the nested call they are nested within, while the top-level call does not revert.
+	nestedCallFrames = traceNestedCalls(routedCall("0x11111111", router, routedCall("0x33333333", stopper, common.FromHex("0x44444444"))))
+	assert.Len(t, nestedCallFrames, 2)
+	assert.EqualValues(t, -1, nestedCallFrames[0].Parent)
+	assert.EqualValues(t, router, nestedCallFrames[0].CodeAddress)
+	assert.EqualValues(t, [4]byte{0x33, 0x33, 0x33, 0x33}, nestedCallFrames[0].Selector)
+	assert.NoError(t, nestedCallFrames[0].Err)
+	assert.EqualValues(t, 0, nestedCallFrames[1].Parent)
+	assert.EqualValues(t, stopper, nestedCallFrames[1].CodeAddress)
+	assert.EqualValues(t, [4]byte{0x44, 0x44, 0x44, 0x44}, nestedCallFrames[1].Selector)
+	assert.NoError(t, nestedCallFrames[1].Err)
+}
@@ -0,0 +1,198 @@
+package statediff
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/logging"
+	"github.com/crytic/medusa/logging/colors"
+)
+
+// BalanceDiff describes the change of the balance of an account.
+type BalanceDiff struct {
+	// From describes the balance before the change.
+	From *big.Int `json:"from"`
+
+	// To describes the balance after the change.
+	To *big.Int `json:"to"`
+}
+
+// NonceDiff describes the change of the nonce of an account.
+type NonceDiff struct {
+	// From describes the nonce before the change.
+	From uint64 `json:"from"`
+
+	// To describes the nonce after the change.
+	To uint64 `json:"to"`
+}
+
+// HashDiff describes the change of a word of state, such as a code hash or a storage slot value.
+type HashDiff struct {
+	// From describes the word before the change.
+	From common.Hash `json:"from"`
+
+	// To describes the word after the change.
+	To common.Hash `json:"to"`
+}
+
+// AccountDiff describes the changes made to the state of an account. Fields which did not change are nil.
+type AccountDiff struct {
+	// Balance describes the change of the balance of the account.
+	Balance *BalanceDiff `json:"balance,omitempty"`
+
+	// Nonce describes the change of the nonce of the account.
+	Nonce *NonceDiff `json:"nonce,omitempty"`
+
+	// CodeHash describes the change of the hash of the code of the account, e.g. when it is deployed.
+	CodeHash *HashDiff `json:"codeHash,omitempty"`
+
+	// Storage describes the changes of the storage slots of the account, indexed by slot.
+	Storage map[common.Hash]*HashDiff `json:"storage,omitempty"`
+}
+
+// isEmpty determines whether the AccountDiff describes no change.
+func (d *AccountDiff) isEmpty() bool {
+	return d.Balance == nil && d.Nonce == nil && d.CodeHash == nil && len(d.Storage) == 0
+}
+
+// StateDiff describes the changes made to the state of the chain by a transaction, or by a call sequence when the
+// diffs of its transactions are merged.
+type StateDiff struct {
+	// Accounts describes the changes made to each account, indexed by address.
+	Accounts map[common.Address]*AccountDiff `json:"accounts"`
+}
+
+// NewStateDiff returns a new StateDiff describing no change.
+func NewStateDiff() *StateDiff {
+	return &StateDiff{
+		Accounts: make(map[common.Address]*AccountDiff),
+	}
+}
+
+// IsEmpty determines whether the StateDiff describes no change.
+func (s *StateDiff) IsEmpty() bool {
+	return s == nil || len(s.Accounts) == 0
+}
+
+// account returns the AccountDiff for the provided address, creating it if it does not exist.
+func (s *StateDiff) account(address common.Address) *AccountDiff {
+	accountDiff, ok := s.Accounts[address]
+	if !ok {
+		accountDiff = &AccountDiff{}
+		s.Accounts[address] = accountDiff
+	}
+	return accountDiff
+}
+
+// Merge applies the changes described by the provided StateDiff, made after those described by this one, so that
+// this StateDiff describes the changes made by both. Values changed by both keep their original value and take the
+// latest one, and values changed back to their original value are no longer described as changed.
+func (s *StateDiff) Merge(other *StateDiff) {
+	if other == nil {
+		return
+	}
+	for address, otherAccountDiff := range other.Accounts {
+		accountDiff := s.account(address)
+		if otherAccountDiff.Balance != nil {
+			if accountDiff.Balance == nil {
+				accountDiff.Balance = &BalanceDiff{From: new(big.Int).Set(otherAccountDiff.Balance.From)}
+			}
+			accountDiff.Balance.To = new(big.Int).Set(otherAccountDiff.Balance.To)
+			if accountDiff.Balance.From.Cmp(accountDiff.Balance.To) == 0 {
+				accountDiff.Balance = nil
+			}
+		}
+		if otherAccountDiff.Nonce != nil {
+			if accountDiff.Nonce == nil {
+				accountDiff.Nonce = &NonceDiff{From: otherAccountDiff.Nonce.From}
+			}
+			accountDiff.Nonce.To = otherAccountDiff.Nonce.To
+			if accountDiff.Nonce.From == accountDiff.Nonce.To {
+				accountDiff.Nonce = nil
+			}
+		}
+		accountDiff.CodeHash = mergeHashDiff(accountDiff.CodeHash, otherAccountDiff.CodeHash)
+		for slot, otherSlotDiff := range otherAccountDiff.Storage {
+			if accountDiff.Storage == nil {
+				accountDiff.Storage = make(map[common.Hash]*HashDiff)
+			}
+			if slotDiff := mergeHashDiff(accountDiff.Storage[slot], otherSlotDiff); slotDiff != nil {
+				accountDiff.Storage[slot] = slotDiff
+			} else {
+				delete(accountDiff.Storage, slot)
+			}
+		}
+		if accountDiff.isEmpty() {
+			delete(s.Accounts, address)
+		}
+	}
+}
+
+// mergeHashDiff merges the provided HashDiff, made after the existing one, into it.
+// Returns the merged HashDiff, or nil if the word was changed back to its original value.
+func mergeHashDiff(existing *HashDiff, later *HashDiff) *HashDiff {
+	if later == nil {
+		return existing
+	}
+	merged := &HashDiff{From: later.From, To: later.To}
+	if existing != nil {
+		merged.From = existing.From
+	}
+	if merged.From == merged.To {
+		return nil
+	}
+	return merged
+}
+
+// Log returns a logging.LogBuffer that represents this StateDiff, listing the changes of each account sorted by
+// address. This buffer will be passed to the underlying logger which will format it accordingly for console or file.
+func (s *StateDiff) Log() *logging.LogBuffer {
+	buffer := logging.NewLogBuffer()
+	if s.IsEmpty() {
+		buffer.Append("<no state changes>\n")
+		return buffer
+	}
+
+	addresses := make([]common.Address, 0, len(s.Accounts))
+	for address := range s.Accounts {
+		addresses = append(addresses, address)
+	}
+	slices.SortFunc(addresses, func(a, b common.Address) int {
+		return bytes.Compare(a[:], b[:])
+	})
+
+	for _, address := range addresses {
+		accountDiff := s.Accounts[address]
+		buffer.Append(colors.Bold, address.String(), colors.Reset, "\n")
+		if accountDiff.Balance != nil {
+			buffer.Append(fmt.Sprintf("\tbalance: %v -> %v\n", accountDiff.Balance.From, accountDiff.Balance.To))
+		}
+		if accountDiff.Nonce != nil {
+			buffer.Append(fmt.Sprintf("\tnonce: %v -> %v\n", accountDiff.Nonce.From, accountDiff.Nonce.To))
+		}
+		if accountDiff.CodeHash != nil {
+			buffer.Append(fmt.Sprintf("\tcode hash: %v -> %v\n", accountDiff.CodeHash.From, accountDiff.CodeHash.To))
+		}
+
+		slots := make([]common.Hash, 0, len(accountDiff.Storage))
+		for slot := range accountDiff.Storage {
+			slots = append(slots, slot)
+		}
+		slices.SortFunc(slots, func(a, b common.Hash) int {
+			return bytes.Compare(a[:], b[:])
+		})
+		for _, slot := range slots {
+			slotDiff := accountDiff.Storage[slot]
+			buffer.Append(fmt.Sprintf("\tstorage[%v]: %v -> %v\n", slot, slotDiff.From, slotDiff.To))
+		}
+	}
+	return buffer
+}
+
+// String returns a string representation of this StateDiff.
+func (s *StateDiff) String() string {
+	return s.Log().String()
+}
@@ -0,0 +1,105 @@
+package statediff
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core"
+	gethTypes "github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa-geth/crypto"
+	"github.com/crytic/medusa/chain"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestStateDiffMerge ensures merging state diffs keeps the original value and the latest value of each change, and
+// drops changes undone by later state diffs.
+func TestStateDiffMerge(t *testing.T) {
+	account := common.HexToAddress("0x1234")
+	slotA, slotB := common.HexToHash("0x1"), common.HexToHash("0x2")
+
+	// Create a first state diff changing a balance and two slots.
+	stateDiff := NewStateDiff()
+	stateDiff.Accounts[account] = &AccountDiff{
+		Balance: &BalanceDiff{From: big.NewInt(10), To: big.NewInt(5)},
+		Storage: map[common.Hash]*HashDiff{
+			slotA: {From: common.HexToHash("0x0"), To: common.HexToHash("0x7")},
+			slotB: {From: common.HexToHash("0x3"), To: common.HexToHash("0x4")},
+		},
+	}
+
+	// Merge a second state diff changing the balance again, undoing the change of one slot and bumping the nonce.
+	laterStateDiff := NewStateDiff()
+	laterStateDiff.Accounts[account] = &AccountDiff{
+		Balance: &BalanceDiff{From: big.NewInt(5), To: big.NewInt(1)},
+		Nonce:   &NonceDiff{From: 0, To: 1},
+		Storage: map[common.Hash]*HashDiff{
+			slotB: {From: common.HexToHash("0x4"), To: common.HexToHash("0x3")},
+		},
+	}
+	stateDiff.Merge(laterStateDiff)
+
+	accountDiff := stateDiff.Accounts[account]
+	assert.EqualValues(t, 10, accountDiff.Balance.From.Int64())
+	assert.EqualValues(t, 1, accountDiff.Balance.To.Int64())
+	assert.EqualValues(t, &NonceDiff{From: 0, To: 1}, accountDiff.Nonce)
+	assert.Len(t, accountDiff.Storage, 1)
+	assert.EqualValues(t, common.HexToHash("0x7"), accountDiff.Storage[slotA].To)
+
+	// Undoing every remaining change should leave an empty state diff.
+	undoStateDiff := NewStateDiff()
+	undoStateDiff.Accounts[account] = &AccountDiff{
+		Balance: &BalanceDiff{From: big.NewInt(1), To: big.NewInt(10)},
+		Nonce:   &NonceDiff{From: 1, To: 0},
+		Storage: map[common.Hash]*HashDiff{
+			slotA: {From: common.HexToHash("0x7"), To: common.HexToHash("0x0")},
+		},
+	}
+	stateDiff.Merge(undoStateDiff)
+	assert.True(t, stateDiff.IsEmpty())
+}
+
+// TestStateDiffTracer ensures a StateDiffTracer records the balance, nonce and storage changes of a transaction
+// deploying a contract with value.
+func TestStateDiffTracer(t *testing.T) {
+	// Create a chain with a funded sender and a state diff tracer attached.
+	sender := common.HexToAddress("0x10000")
+	genesisAlloc := gethTypes.GenesisAlloc{
+		sender: {Balance: big.NewInt(1_000_000)},
+	}
+	testChain, err := chain.NewTestChain(context.Background(), genesisAlloc, nil)
+	assert.NoError(t, err)
+	defer testChain.Close()
+	testChain.AddTracer(NewStateDiffTracer().NativeTracer(), true, false)
+
+	// Deploy a contract whose constructor stores 1 in slot 0, sending it some value.
+	_, err = testChain.PendingBlockCreate()
+	assert.NoError(t, err)
+	err = testChain.PendingBlockAddTx(&core.Message{
+		From:      sender,
+		Nonce:     0,
+		Value:     big.NewInt(100),
+		GasLimit:  1_000_000,
+		GasPrice:  big.NewInt(0),
+		GasFeeCap: big.NewInt(0),
+		GasTipCap: big.NewInt(0),
+		Data:      common.FromHex("0x600160005500"),
+	})
+	assert.NoError(t, err)
+
+	stateDiff := GetStateDiffTracerResults(testChain.PendingBlock().MessageResults[0])
+	assert.NotNil(t, stateDiff)
+	contract := crypto.CreateAddress(sender, 0)
+
+	senderDiff := stateDiff.Accounts[sender]
+	assert.NotNil(t, senderDiff)
+	assert.EqualValues(t, 1_000_000, senderDiff.Balance.From.Int64())
+	assert.EqualValues(t, 999_900, senderDiff.Balance.To.Int64())
+	assert.EqualValues(t, &NonceDiff{From: 0, To: 1}, senderDiff.Nonce)
+
+	contractDiff := stateDiff.Accounts[contract]
+	assert.NotNil(t, contractDiff)
+	assert.EqualValues(t, 100, contractDiff.Balance.To.Int64())
+	assert.EqualValues(t, &HashDiff{From: common.Hash{}, To: common.HexToHash("0x1")}, contractDiff.Storage[common.Hash{}])
+}
@@ -0,0 +1,183 @@
+package statediff
+
+import (
+	"math/big"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core/tracing"
+	coretypes "github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa-geth/eth/tracers"
+	"github.com/crytic/medusa/chain"
+	"github.com/crytic/medusa/chain/types"
+)
+
+// stateDiffTracerResultsKey describes the key to use when storing tracer results in call message results, or when
+// querying them.
+const stateDiffTracerResultsKey = "StateDiffTracerResults"
+
+// GetStateDiffTracerResults obtains the StateDiff stored by a StateDiffTracer from message results, describing the
+// state changes made by the message. This is nil if the message made no state change, or if no StateDiff was recorded
+// by a tracer (e.g. StateDiffTracer was not attached during this message execution).
+func GetStateDiffTracerResults(messageResults *types.MessageResults) *StateDiff {
+	// Try to obtain the results the tracer should've stored.
+	if genericResult, ok := messageResults.AdditionalResults[stateDiffTracerResultsKey]; ok {
+		if castedResult, ok := genericResult.(*StateDiff); ok {
+			return castedResult
+		}
+	}
+
+	// If we could not obtain them, return nil.
+	return nil
+}
+
+// accountState describes the state of an account which the StateDiffTracer compares before and after a transaction.
+type accountState struct {
+	balance  *big.Int
+	nonce    uint64
+	codeHash common.Hash
+}
+
+// StateDiffTracer implements tracers.Tracer to record the balance, nonce, code and storage changes made by each
+// transaction, similar to a prestate tracer in diff mode. The original state of each account and storage slot is read
+// from the StateDB when the transaction first touches it, and compared with its state once the transaction ends.
+type StateDiffTracer struct {
+	// evmContext holds the VM context during tracing
+	evmContext *tracing.VMContext
+
+	// accounts describes the original state of the accounts touched by the current transaction.
+	accounts map[common.Address]*accountState
+
+	// storage describes the original values of the storage slots written by the current transaction.
+	storage map[common.Address]map[common.Hash]common.Hash
+
+	// nativeTracer is the underlying tracer used to capture EVM execution.
+	nativeTracer *chain.TestChainTracer
+}
+
+// NewStateDiffTracer returns a new StateDiffTracer.
+func NewStateDiffTracer() *StateDiffTracer {
+	tracer := &StateDiffTracer{}
+	nativeTracer := &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnTxStart: tracer.OnTxStart,
+			OnEnter:   tracer.OnEnter,
+			OnOpcode:  tracer.OnOpcode,
+		},
+	}
+	tracer.nativeTracer = &chain.TestChainTracer{Tracer: nativeTracer, CaptureTxEndSetAdditionalResults: tracer.CaptureTxEndSetAdditionalResults}
+	return tracer
+}
+
+// NativeTracer returns the underlying TestChainTracer.
+func (t *StateDiffTracer) NativeTracer() *chain.TestChainTracer {
+	return t.nativeTracer
+}
+
+// touchAccount records the original state of the provided account, if it was not touched by the transaction yet.
+func (t *StateDiffTracer) touchAccount(address common.Address) {
+	if _, ok := t.accounts[address]; ok {
+		return
+	}
+	t.accounts[address] = &accountState{
+		balance:  t.evmContext.StateDB.GetBalance(address).ToBig(),
+		nonce:    t.evmContext.StateDB.GetNonce(address),
+		codeHash: t.evmContext.StateDB.GetCodeHash(address),
+	}
+}
+
+// OnTxStart is called upon the start of transaction execution, as defined by tracers.Tracer.
+func (t *StateDiffTracer) OnTxStart(vm *tracing.VMContext, tx *coretypes.Transaction, from common.Address) {
+	t.evmContext = vm
+	t.accounts = make(map[common.Address]*accountState)
+	t.storage = make(map[common.Address]map[common.Hash]common.Hash)
+
+	// The sender and coinbase change before the first call frame is entered, as gas is bought.
+	t.touchAccount(from)
+	t.touchAccount(vm.Coinbase)
+}
+
+// OnEnter is called upon entering of the call frame, as defined by tracers.Tracer.
+func (t *StateDiffTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	// Call frames are entered before any value is transferred, or code deployed.
+	t.touchAccount(from)
+	t.touchAccount(to)
+}
+
+// OnOpcode records data from an EVM state update, as defined by tracers.Tracer.
+func (t *StateDiffTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	switch vm.OpCode(op) {
+	case vm.SSTORE:
+		// Record the original value of the slot upon its first write, as it was not changed by the transaction yet.
+		stack := scope.StackData()
+		if len(stack) < 1 {
+			return
+		}
+		address := scope.Address()
+		slot := common.Hash(stack[len(stack)-1].Bytes32())
+		accountStorage, ok := t.storage[address]
+		if !ok {
+			accountStorage = make(map[common.Hash]common.Hash)
+			t.storage[address] = accountStorage
+		}
+		if _, ok := accountStorage[slot]; !ok {
+			accountStorage[slot] = t.evmContext.StateDB.GetState(address, slot)
+		}
+	case vm.SELFDESTRUCT:
+		// The beneficiary receives the balance of the contract without a call frame being entered.
+		stack := scope.StackData()
+		if len(stack) < 1 {
+			return
+		}
+		t.touchAccount(common.Address(stack[len(stack)-1].Bytes20()))
+	}
+}
+
+// diff returns the StateDiff of the current transaction, comparing the original state of the accounts and storage
+// slots it touched with their current state.
+func (t *StateDiffTracer) diff() *StateDiff {
+	stateDiff := NewStateDiff()
+	for address, original := range t.accounts {
+		accountDiff := &AccountDiff{}
+		if balance := t.evmContext.StateDB.GetBalance(address).ToBig(); balance.Cmp(original.balance) != 0 {
+			accountDiff.Balance = &BalanceDiff{From: original.balance, To: balance}
+		}
+		if nonce := t.evmContext.StateDB.GetNonce(address); nonce != original.nonce {
+			accountDiff.Nonce = &NonceDiff{From: original.nonce, To: nonce}
+		}
+		if codeHash := t.evmContext.StateDB.GetCodeHash(address); codeHash != original.codeHash {
+			accountDiff.CodeHash = &HashDiff{From: original.codeHash, To: codeHash}
+		}
+		if !accountDiff.isEmpty() {
+			stateDiff.Accounts[address] = accountDiff
+		}
+	}
+	for address, accountStorage := range t.storage {
+		for slot, original := range accountStorage {
+			if value := t.evmContext.StateDB.GetState(address, slot); value != original {
+				accountDiff := stateDiff.account(address)
+				if accountDiff.Storage == nil {
+					accountDiff.Storage = make(map[common.Hash]*HashDiff)
+				}
+				accountDiff.Storage[slot] = &HashDiff{From: original, To: value}
+			}
+		}
+	}
+	return stateDiff
+}
+
+// CaptureTxEndSetAdditionalResults can be used to set additional results captured from execution tracing. If this
+// tracer is used during transaction execution (block creation), the results can later be queried from the block.
+// This method will only be called on the added tracer if it implements the extended TestChainTracer interface.
+func (t *StateDiffTracer) CaptureTxEndSetAdditionalResults(results *types.MessageResults) {
+	if t.evmContext == nil || t.accounts == nil {
+		return
+	}
+
+	// Store our tracer results, if the transaction changed any state.
+	if stateDiff := t.diff(); !stateDiff.IsEmpty() {
+		results.AdditionalResults[stateDiffTracerResultsKey] = stateDiff
+	}
+	t.accounts = nil
+	t.storage = nil
+}
@@ -0,0 +1,91 @@
+package fuzzing
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/crytic/medusa-geth/common"
+	compilationTypes "github.com/crytic/medusa/compilation/types"
+	"github.com/crytic/medusa/fuzzing/calls"
+	fuzzerTypes "github.com/crytic/medusa/fuzzing/contracts"
+	"github.com/crytic/medusa/fuzzing/storagelayout"
+)
+
+// storageLayoutRegistry describes the storage layouts of the contracts deployed on the chains of workers, by address,
+// so that storage slots can be named after the state variables they hold. It is safe for concurrent use.
+type storageLayoutRegistry struct {
+	// layouts maps the addresses of deployed contracts to their storage layouts.
+	layouts map[common.Address]*compilationTypes.StorageLayout
+
+	// lock provides thread-synchronization when accessing layouts.
+	lock sync.RWMutex
+}
+
+// newStorageLayoutRegistry creates an empty storageLayoutRegistry.
+func newStorageLayoutRegistry() *storageLayoutRegistry {
+	return &storageLayoutRegistry{
+		layouts: make(map[common.Address]*compilationTypes.StorageLayout),
+	}
+}
+
+// register records the storage layout of the provided contract definition, if it has one, for the provided address.
+func (r *storageLayoutRegistry) register(address common.Address, contractDefinition *fuzzerTypes.Contract) {
+	if contractDefinition == nil || contractDefinition.CompiledContract().StorageLayout == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.layouts[address] = contractDefinition.CompiledContract().StorageLayout
+}
+
+// get returns the storage layout recorded for the provided address, or nil if none was.
+func (r *storageLayoutRegistry) get(address common.Address) *compilationTypes.StorageLayout {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.layouts[address]
+}
+
+// StorageLayout returns the storage layout of the contract deployed at the provided address, from its compilation
+// or its verified source for on-chain contracts.
+// Returns the storage layout, or nil if it is unknown.
+func (f *Fuzzer) StorageLayout(address common.Address) *compilationTypes.StorageLayout {
+	if layout := f.storageLayouts.get(address); layout != nil {
+		return layout
+	}
+	if contractDefinition := f.onChainContractDefinition(address); contractDefinition != nil {
+		return contractDefinition.CompiledContract().StorageLayout
+	}
+	return nil
+}
+
+// StateVariableName returns the name of the state variable held in the provided storage slot of the contract deployed
+// at the provided address (see storagelayout.VariableName).
+// Returns the name, or an empty string if the contract's storage layout is unknown or the slot holds no known variable.
+func (f *Fuzzer) StateVariableName(address common.Address, slot common.Hash) string {
+	return storagelayout.VariableName(f.StorageLayout(address), slot)
+}
+
+// ReadStateVariable reads the state variable described by the provided path (see storagelayout.Read) from the storage
+// of the contract deployed at the provided address, in the current state of the worker's chain. It is intended for
+// custom oracles, such as call sequence test functions.
+// Returns the value of the variable, or an error if the contract's storage layout is unknown or the path does not
+// describe a variable in it.
+func (fw *FuzzerWorker) ReadStateVariable(address common.Address, path string) (*storagelayout.Value, error) {
+	return storagelayout.Read(fw.fuzzer.StorageLayout(address), fw.chain.State(), address, path)
+}
+
+// ReadStateVariableAfter reads the state variable described by the provided path (see storagelayout.Read) from the
+// storage of the contract deployed at the provided address, in the state of the worker's chain after the block which
+// included the provided call sequence element, so oracles can compare variables at any point in a call sequence.
+// Returns the value of the variable, or an error if the element was not executed, the contract's storage layout is
+// unknown, or the path does not describe a variable in it.
+func (fw *FuzzerWorker) ReadStateVariableAfter(element *calls.CallSequenceElement, address common.Address, path string) (*storagelayout.Value, error) {
+	if element.ChainReference == nil {
+		return nil, fmt.Errorf("could not read %s, as the call sequence element was not executed", path)
+	}
+	state, err := fw.chain.StateAfterBlockNumber(element.ChainReference.Block.Header.Number.Uint64())
+	if err != nil {
+		return nil, err
+	}
+	return storagelayout.Read(fw.fuzzer.StorageLayout(address), state, address, path)
+}
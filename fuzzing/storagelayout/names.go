@@ -0,0 +1,121 @@
+package storagelayout
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/crypto"
+	"github.com/crytic/medusa/compilation/types"
+)
+
+// VariableName returns the name of the state variable stored at the provided storage slot, using the provided layout.
+// Members of structs and elements of static arrays are named by their path (e.g. config.owner or prices[2]). If several
+// variables are packed in the slot, their names are joined with commas. Slots holding the elements of mappings and
+// dynamic arrays are hashed, so only the slots of the variables themselves are named.
+// Returns the name, or an empty string if the slot holds no known variable.
+func VariableName(layout *types.StorageLayout, slot common.Hash) string {
+	if layout == nil {
+		return ""
+	}
+	names := variableNames(layout, layout.Storage, "", big.NewInt(0), slot.Big())
+	return strings.Join(names, ", ")
+}
+
+// AddressMappingEntryName returns the path of the entry of a mapping state variable keyed by address which is stored
+// at the provided storage slot, for the first of the provided keys the slot is the entry of (e.g.
+// balances[0x...]), along with the canonical name of the type of the mapping's values (e.g. uint256). As mapping entry
+// slots are hashed, only the provided keys can be recognized.
+// Returns the path and value type, or empty strings if the slot holds no entry of such a mapping for the keys.
+func AddressMappingEntryName(layout *types.StorageLayout, slot common.Hash, keys []common.Address) (string, string) {
+	if layout == nil {
+		return "", ""
+	}
+	for _, variable := range layout.Storage {
+		typ := layout.Types[variable.Type]
+		keyType := layout.Types[typ.Key]
+		if typ.Encoding != "mapping" || !(keyType.Label == "address" || keyType.Label == "address payable" || strings.HasPrefix(keyType.Label, "contract ")) {
+			continue
+		}
+		start, ok := new(big.Int).SetString(variable.Slot, 10)
+		if !ok {
+			continue
+		}
+		for _, key := range keys {
+			if crypto.Keccak256Hash(common.LeftPadBytes(key.Bytes(), 32), common.BigToHash(start).Bytes()) == slot {
+				return fmt.Sprintf("%s[%s]", variable.Label, key.Hex()), layout.Types[typ.Value].Label
+			}
+		}
+	}
+	return "", ""
+}
+
+// variableNames returns the paths of the provided variables, or of their members or elements, stored at the provided
+// slot, prefixing them with the provided path. The slots of the variables are relative to the provided base slot.
+func variableNames(layout *types.StorageLayout, variables []types.StorageLayoutVariable, prefix string, base *big.Int, slot *big.Int) []string {
+	names := make([]string, 0)
+	for _, variable := range variables {
+		start, ok := new(big.Int).SetString(variable.Slot, 10)
+		if !ok {
+			continue
+		}
+		start.Add(start, base)
+		path := variable.Label
+		if prefix != "" {
+			path = prefix + "." + variable.Label
+		}
+		names = append(names, typeNames(layout, variable.Type, path, start, variable.Offset, slot)...)
+	}
+	return names
+}
+
+// typeNames returns the path of the variable of the provided type stored from the provided slot and offset, or the
+// paths of its members or elements, if it occupies the provided slot.
+func typeNames(layout *types.StorageLayout, typeId string, path string, start *big.Int, offset uint64, slot *big.Int) []string {
+	typ, ok := layout.Types[typeId]
+	if !ok {
+		return nil
+	}
+	size, err := strconv.ParseUint(typ.NumberOfBytes, 10, 64)
+	if err != nil || size == 0 {
+		return nil
+	}
+
+	// Skip variables which do not occupy the slot.
+	relative := new(big.Int).Sub(slot, start)
+	slots := (offset + size + 31) / 32
+	if relative.Sign() < 0 || relative.Cmp(new(big.Int).SetUint64(slots)) >= 0 {
+		return nil
+	}
+
+	// Name the members of structs and the elements of static arrays occupying the slot.
+	if typ.Encoding == "inplace" && len(typ.Members) > 0 {
+		return variableNames(layout, typ.Members, path, start, slot)
+	}
+	if typ.Encoding == "inplace" && typ.Base != "" {
+		elementSize, err := strconv.ParseUint(layout.Types[typ.Base].NumberOfBytes, 10, 64)
+		if err != nil || elementSize == 0 {
+			return []string{path}
+		}
+		length := size / elementSize
+		if staticLength := staticArrayLength(typ); staticLength != nil && staticLength.IsUint64() {
+			length = staticLength.Uint64()
+		}
+		if elementSize <= 16 {
+			perSlot := 32 / elementSize
+			first := relative.Uint64() * perSlot
+			names := make([]string, 0, perSlot)
+			for i := first; i < first+perSlot && i < length; i++ {
+				names = append(names, fmt.Sprintf("%s[%d]", path, i))
+			}
+			return names
+		}
+		slotsPerElement := (elementSize + 31) / 32
+		index := relative.Uint64() / slotsPerElement
+		elementStart := new(big.Int).Add(start, new(big.Int).SetUint64(index*slotsPerElement))
+		return typeNames(layout, typ.Base, fmt.Sprintf("%s[%d]", path, index), elementStart, 0, slot)
+	}
+	return []string{path}
+}
@@ -0,0 +1,341 @@
+package storagelayout
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/crypto"
+	"github.com/crytic/medusa/compilation/types"
+)
+
+// StateReader describes the state storage variables are read from, such as a vm.StateDB.
+type StateReader interface {
+	// GetState returns the value of the provided storage slot of the contract at the provided address.
+	GetState(address common.Address, slot common.Hash) common.Hash
+}
+
+// Value describes the value of a state variable read from storage.
+type Value struct {
+	// Path describes the path the variable was read with (e.g. balances[0x...]).
+	Path string
+
+	// Type describes the canonical name of the variable's type (e.g. uint256).
+	Type string
+
+	// Slot describes the storage slot the variable starts at.
+	Slot common.Hash
+
+	// Offset describes the offset, in bytes, of the variable within its slot.
+	Offset uint64
+
+	// Value describes the decoded value: a *big.Int for integers and enums, a common.Address for addresses and
+	// contracts, a bool for booleans, a string for strings, and a []byte for fixed-size and dynamic byte arrays.
+	Value any
+}
+
+// String returns a string representation of the value, in the form path = value.
+func (v *Value) String() string {
+	switch value := v.Value.(type) {
+	case []byte:
+		return fmt.Sprintf("%s = 0x%x", v.Path, value)
+	case string:
+		return fmt.Sprintf("%s = %q", v.Path, value)
+	default:
+		return fmt.Sprintf("%s = %v", v.Path, value)
+	}
+}
+
+// location describes where a variable, or an element of one, is stored while resolving a path.
+type location struct {
+	// slot describes the storage slot the variable starts at.
+	slot *big.Int
+
+	// offset describes the offset, in bytes, of the variable within its slot.
+	offset uint64
+
+	// typeId describes the identifier of the variable's type in the layout.
+	typeId string
+}
+
+// Read reads the state variable described by the provided path from the storage of the contract at the provided
+// address, using the provided layout. Paths start with the name of a state variable, followed by any amount of struct
+// member accesses (.member), mapping lookups ([key]) and array indexing ([index]), such as balances[0x...] or
+// positions[3].owner. Keys of mappings are parsed according to the mapping's key type: addresses and fixed-size byte
+// arrays are given in hex, integers in decimal or hex, and strings and bytes verbatim.
+// Returns the value of the variable, or an error if the path does not describe a variable of a value type, string or
+// bytes in the layout.
+func Read(layout *types.StorageLayout, state StateReader, address common.Address, path string) (*Value, error) {
+	if layout == nil {
+		return nil, errors.New("the storage layout of the contract is unknown")
+	}
+	loc, err := resolve(layout, state, address, path)
+	if err != nil {
+		return nil, err
+	}
+	typ := layout.Types[loc.typeId]
+	value := &Value{
+		Path:   path,
+		Type:   typ.Label,
+		Slot:   common.BigToHash(loc.slot),
+		Offset: loc.offset,
+	}
+
+	switch typ.Encoding {
+	case "bytes":
+		data := readBytes(state, address, loc.slot)
+		if typ.Label == "string" {
+			value.Value = string(data)
+		} else {
+			value.Value = data
+		}
+	case "inplace":
+		if len(typ.Members) > 0 || strings.HasSuffix(typ.Label, "]") {
+			return nil, fmt.Errorf("%s is a %s, read one of its members or elements instead", path, typ.Label)
+		}
+		size, err := strconv.ParseUint(typ.NumberOfBytes, 10, 64)
+		if err != nil || size == 0 || size+loc.offset > 32 {
+			return nil, fmt.Errorf("%s has an invalid size in the storage layout", path)
+		}
+		word := state.GetState(address, common.BigToHash(loc.slot))
+		value.Value = decodeValue(typ.Label, word[32-loc.offset-size:32-loc.offset])
+	default:
+		return nil, fmt.Errorf("%s is a %s, read one of its elements instead", path, typ.Label)
+	}
+	return value, nil
+}
+
+// resolve determines the location of the variable described by the provided path.
+// Returns the location, or an error if the path does not describe a variable in the layout.
+func resolve(layout *types.StorageLayout, state StateReader, address common.Address, path string) (*location, error) {
+	// Resolve the state variable the path starts with.
+	name, rest := splitPathSegment(path)
+	var loc *location
+	for _, variable := range layout.Storage {
+		if variable.Label == name {
+			slot, ok := new(big.Int).SetString(variable.Slot, 10)
+			if !ok {
+				return nil, fmt.Errorf("state variable %s has an invalid slot in the storage layout", name)
+			}
+			loc = &location{slot: slot, offset: variable.Offset, typeId: variable.Type}
+		}
+	}
+	if loc == nil {
+		return nil, fmt.Errorf("state variable %s is not in the storage layout", name)
+	}
+
+	// Resolve each member access and lookup in turn.
+	for rest != "" {
+		typ, ok := layout.Types[loc.typeId]
+		if !ok {
+			return nil, fmt.Errorf("type %s is not in the storage layout", loc.typeId)
+		}
+
+		if strings.HasPrefix(rest, ".") {
+			var member string
+			member, rest = splitPathSegment(rest[1:])
+			found := false
+			for _, m := range typ.Members {
+				if m.Label == member {
+					memberSlot, ok := new(big.Int).SetString(m.Slot, 10)
+					if !ok {
+						return nil, fmt.Errorf("member %s has an invalid slot in the storage layout", member)
+					}
+					loc = &location{slot: memberSlot.Add(memberSlot, loc.slot), offset: m.Offset, typeId: m.Type}
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("%s has no member %s", typ.Label, member)
+			}
+			continue
+		}
+
+		if !strings.HasPrefix(rest, "[") || !strings.Contains(rest, "]") {
+			return nil, fmt.Errorf("invalid path %s", path)
+		}
+		end := strings.Index(rest, "]")
+		key := rest[1:end]
+		rest = rest[end+1:]
+
+		var err error
+		switch typ.Encoding {
+		case "mapping":
+			loc, err = resolveMappingValue(layout, loc, typ, key)
+		case "dynamic_array":
+			loc, err = resolveArrayElement(layout, state, address, loc, typ, key, true)
+		case "inplace":
+			loc, err = resolveArrayElement(layout, state, address, loc, typ, key, false)
+		default:
+			err = fmt.Errorf("%s cannot be indexed", typ.Label)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return loc, nil
+}
+
+// splitPathSegment splits the provided path into its leading identifier and the remainder of the path.
+func splitPathSegment(path string) (string, string) {
+	end := strings.IndexAny(path, ".[")
+	if end < 0 {
+		return path, ""
+	}
+	return path[:end], path[end:]
+}
+
+// resolveMappingValue determines the location of the value of the provided key in the provided mapping, which is
+// stored at keccak256(h(key) . slot), where h pads value types to 32 bytes and leaves strings and bytes unpadded.
+// Returns the location, or an error if the key could not be parsed as the mapping's key type.
+func resolveMappingValue(layout *types.StorageLayout, loc *location, typ types.StorageLayoutType, key string) (*location, error) {
+	keyType := layout.Types[typ.Key]
+	var encodedKey []byte
+	switch {
+	case keyType.Encoding == "bytes":
+		encodedKey = []byte(key)
+	case keyType.Label == "address" || strings.HasPrefix(keyType.Label, "contract ") || keyType.Label == "address payable":
+		if !common.IsHexAddress(key) {
+			return nil, fmt.Errorf("mapping key %s is not an address", key)
+		}
+		encodedKey = common.LeftPadBytes(common.HexToAddress(key).Bytes(), 32)
+	case keyType.Label == "bool":
+		value, err := strconv.ParseBool(key)
+		if err != nil {
+			return nil, fmt.Errorf("mapping key %s is not a boolean", key)
+		}
+		encodedKey = make([]byte, 32)
+		if value {
+			encodedKey[31] = 1
+		}
+	case strings.HasPrefix(keyType.Label, "bytes"):
+		data, err := hexToBytes(key)
+		if err != nil || len(data) > 32 {
+			return nil, fmt.Errorf("mapping key %s is not a fixed-size byte array", key)
+		}
+		encodedKey = common.RightPadBytes(data, 32)
+	default:
+		value, ok := new(big.Int).SetString(key, 0)
+		if !ok {
+			return nil, fmt.Errorf("mapping key %s is not an integer", key)
+		}
+		encodedKey = common.BigToHash(toTwosComplement(value)).Bytes()
+	}
+
+	slot := crypto.Keccak256(encodedKey, common.BigToHash(loc.slot).Bytes())
+	return &location{slot: new(big.Int).SetBytes(slot), typeId: typ.Value}, nil
+}
+
+// resolveArrayElement determines the location of the element at the provided index of the provided array. Elements of
+// static arrays are stored from the array's slot, while those of dynamic arrays are stored from keccak256(slot), the
+// array's slot holding its length. Elements smaller than 16 bytes are packed within slots.
+// Returns the location, or an error if the index could not be parsed or is out of bounds.
+func resolveArrayElement(layout *types.StorageLayout, state StateReader, address common.Address, loc *location, typ types.StorageLayoutType, key string, dynamic bool) (*location, error) {
+	if typ.Base == "" {
+		return nil, fmt.Errorf("%s cannot be indexed", typ.Label)
+	}
+	index, ok := new(big.Int).SetString(key, 0)
+	if !ok || index.Sign() < 0 {
+		return nil, fmt.Errorf("array index %s is not a non-negative integer", key)
+	}
+
+	// Check the index against the length of the array.
+	var length *big.Int
+	if dynamic {
+		length = state.GetState(address, common.BigToHash(loc.slot)).Big()
+	} else {
+		length = staticArrayLength(typ)
+	}
+	if length != nil && index.Cmp(length) >= 0 {
+		return nil, fmt.Errorf("array index %s is out of bounds of %s of length %v", key, typ.Label, length)
+	}
+
+	start := new(big.Int).Set(loc.slot)
+	if dynamic {
+		start.SetBytes(crypto.Keccak256(common.BigToHash(loc.slot).Bytes()))
+	}
+	elementSize, err := strconv.ParseUint(layout.Types[typ.Base].NumberOfBytes, 10, 64)
+	if err != nil || elementSize == 0 {
+		return nil, fmt.Errorf("%s has an invalid element size in the storage layout", typ.Label)
+	}
+	if elementSize <= 16 {
+		perSlot := new(big.Int).SetUint64(32 / elementSize)
+		slotIndex, elementIndex := new(big.Int).QuoRem(index, perSlot, new(big.Int))
+		return &location{slot: start.Add(start, slotIndex), offset: elementIndex.Uint64() * elementSize, typeId: typ.Base}, nil
+	}
+	slotsPerElement := new(big.Int).SetUint64((elementSize + 31) / 32)
+	return &location{slot: start.Add(start, index.Mul(index, slotsPerElement)), typeId: typ.Base}, nil
+}
+
+// staticArrayLength returns the length of the provided static array type, parsed from its label (e.g. uint8[4]).
+// Returns the length, or nil if it could not be parsed.
+func staticArrayLength(typ types.StorageLayoutType) *big.Int {
+	start := strings.LastIndex(typ.Label, "[")
+	if start < 0 {
+		return nil
+	}
+	length, ok := new(big.Int).SetString(strings.TrimSuffix(typ.Label[start+1:], "]"), 10)
+	if !ok {
+		return nil
+	}
+	return length
+}
+
+// readBytes reads a string or bytes value stored at the provided slot. Values shorter than 32 bytes are stored in the
+// slot along with twice their length in the lowest byte, while longer ones store twice their length plus one in the
+// slot and their data from keccak256(slot).
+func readBytes(state StateReader, address common.Address, slot *big.Int) []byte {
+	word := state.GetState(address, common.BigToHash(slot))
+	if word[31]&1 == 0 {
+		length := int(word[31] / 2)
+		return append([]byte{}, word[:length]...)
+	}
+
+	length := new(big.Int).Rsh(word.Big(), 1).Uint64()
+	data := make([]byte, 0, length)
+	dataSlot := new(big.Int).SetBytes(crypto.Keccak256(common.BigToHash(slot).Bytes()))
+	for uint64(len(data)) < length {
+		chunk := state.GetState(address, common.BigToHash(dataSlot))
+		data = append(data, chunk[:min(32, length-uint64(len(data)))]...)
+		dataSlot.Add(dataSlot, big.NewInt(1))
+	}
+	return data
+}
+
+// decodeValue decodes the provided bytes of a value of the provided type, extracted from its slot.
+func decodeValue(label string, data []byte) any {
+	switch {
+	case label == "bool":
+		return new(big.Int).SetBytes(data).Sign() != 0
+	case label == "address" || label == "address payable" || strings.HasPrefix(label, "contract "):
+		return common.BytesToAddress(data)
+	case strings.HasPrefix(label, "int"):
+		value := new(big.Int).SetBytes(data)
+		if len(data) > 0 && data[0]&0x80 != 0 {
+			value.Sub(value, new(big.Int).Lsh(big.NewInt(1), uint(len(data)*8)))
+		}
+		return value
+	case strings.HasPrefix(label, "bytes"):
+		return append([]byte{}, data...)
+	default:
+		return new(big.Int).SetBytes(data)
+	}
+}
+
+// toTwosComplement returns the 256-bit two's complement representation of the provided integer.
+func toTwosComplement(value *big.Int) *big.Int {
+	if value.Sign() >= 0 {
+		return value
+	}
+	return new(big.Int).Add(value, new(big.Int).Lsh(big.NewInt(1), 256))
+}
+
+// hexToBytes decodes the provided hex string, with or without a 0x prefix.
+func hexToBytes(s string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(s, "0x"))
+}
@@ -0,0 +1,179 @@
+package storagelayout
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/crypto"
+	"github.com/crytic/medusa/compilation/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// testLayout describes the storage layout of the following contract, as output by solc:
+//
+//	contract Vault {
+//	    uint256 total;
+//	    address owner;
+//	    bool paused;
+//	    mapping(address => uint256) balances;
+//	    uint256[] deposits;
+//	    struct Config { uint128 fee; uint128 cap; address admin; }
+//	    Config config;
+//	    string name;
+//	    uint8[4] weights;
+//	    int8 delta;
+//	}
+const testLayout = `{
+	"storage": [
+		{"label": "total", "contract": "Vault.sol:Vault", "slot": "0", "offset": 0, "type": "t_uint256"},
+		{"label": "owner", "contract": "Vault.sol:Vault", "slot": "1", "offset": 0, "type": "t_address"},
+		{"label": "paused", "contract": "Vault.sol:Vault", "slot": "1", "offset": 20, "type": "t_bool"},
+		{"label": "balances", "contract": "Vault.sol:Vault", "slot": "2", "offset": 0, "type": "t_mapping(t_address,t_uint256)"},
+		{"label": "deposits", "contract": "Vault.sol:Vault", "slot": "3", "offset": 0, "type": "t_array(t_uint256)dyn_storage"},
+		{"label": "config", "contract": "Vault.sol:Vault", "slot": "4", "offset": 0, "type": "t_struct(Config)storage"},
+		{"label": "name", "contract": "Vault.sol:Vault", "slot": "6", "offset": 0, "type": "t_string_storage"},
+		{"label": "weights", "contract": "Vault.sol:Vault", "slot": "7", "offset": 0, "type": "t_array(t_uint8)4_storage"},
+		{"label": "delta", "contract": "Vault.sol:Vault", "slot": "8", "offset": 0, "type": "t_int8"}
+	],
+	"types": {
+		"t_address": {"encoding": "inplace", "label": "address", "numberOfBytes": "20"},
+		"t_bool": {"encoding": "inplace", "label": "bool", "numberOfBytes": "1"},
+		"t_int8": {"encoding": "inplace", "label": "int8", "numberOfBytes": "1"},
+		"t_uint8": {"encoding": "inplace", "label": "uint8", "numberOfBytes": "1"},
+		"t_uint128": {"encoding": "inplace", "label": "uint128", "numberOfBytes": "16"},
+		"t_uint256": {"encoding": "inplace", "label": "uint256", "numberOfBytes": "32"},
+		"t_string_storage": {"encoding": "bytes", "label": "string", "numberOfBytes": "32"},
+		"t_mapping(t_address,t_uint256)": {"encoding": "mapping", "label": "mapping(address => uint256)", "numberOfBytes": "32", "key": "t_address", "value": "t_uint256"},
+		"t_array(t_uint256)dyn_storage": {"encoding": "dynamic_array", "label": "uint256[]", "numberOfBytes": "32", "base": "t_uint256"},
+		"t_array(t_uint8)4_storage": {"encoding": "inplace", "label": "uint8[4]", "numberOfBytes": "32", "base": "t_uint8"},
+		"t_struct(Config)storage": {"encoding": "inplace", "label": "struct Vault.Config", "numberOfBytes": "64", "members": [
+			{"label": "fee", "contract": "Vault.sol:Vault", "slot": "0", "offset": 0, "type": "t_uint128"},
+			{"label": "cap", "contract": "Vault.sol:Vault", "slot": "0", "offset": 16, "type": "t_uint128"},
+			{"label": "admin", "contract": "Vault.sol:Vault", "slot": "1", "offset": 0, "type": "t_address"}
+		]}
+	}
+}`
+
+// testState is a StateReader over the storage of a single contract.
+type testState map[common.Hash]common.Hash
+
+// GetState returns the value of the provided storage slot.
+func (s testState) GetState(address common.Address, slot common.Hash) common.Hash {
+	return s[slot]
+}
+
+// slotHash returns the storage slot with the provided index.
+func slotHash(index int64) common.Hash {
+	return common.BigToHash(big.NewInt(index))
+}
+
+// newTestState returns the storage of a Vault contract with every variable set.
+func newTestState() testState {
+	owner := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	holder := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	state := testState{}
+	state[slotHash(0)] = slotHash(1000)
+
+	// owner and paused are packed in slot 1.
+	var packed common.Hash
+	copy(packed[12:], owner.Bytes())
+	packed[11] = 1
+	state[slotHash(1)] = packed
+
+	state[crypto.Keccak256Hash(common.LeftPadBytes(holder.Bytes(), 32), slotHash(2).Bytes())] = slotHash(42)
+	state[slotHash(3)] = slotHash(2)
+	depositsStart := crypto.Keccak256Hash(slotHash(3).Bytes()).Big()
+	state[common.BigToHash(new(big.Int).Add(depositsStart, big.NewInt(1)))] = slotHash(7)
+
+	// config.fee and config.cap are packed in slot 4, config.admin is in slot 5.
+	var fees common.Hash
+	fees[15] = 3
+	fees[31] = 5
+	state[slotHash(4)] = fees
+	state[slotHash(5)] = common.BytesToHash(owner.Bytes())
+
+	var name common.Hash
+	copy(name[:], "vault")
+	name[31] = 10
+	state[slotHash(6)] = name
+
+	var weights common.Hash
+	weights[31], weights[30], weights[29], weights[28] = 1, 2, 3, 4
+	state[slotHash(7)] = weights
+	state[slotHash(8)] = common.BytesToHash([]byte{0xff})
+	return state
+}
+
+// TestRead tests that state variables of each kind are read and decoded from storage.
+func TestRead(t *testing.T) {
+	layout, err := types.ParseStorageLayout([]byte(testLayout))
+	assert.NoError(t, err)
+	state := newTestState()
+	address := common.Address{1}
+
+	expected := map[string]any{
+		"total":  big.NewInt(1000),
+		"owner":  common.HexToAddress("0x00000000000000000000000000000000000000aa"),
+		"paused": true,
+		"balances[0x00000000000000000000000000000000000000bb]": big.NewInt(42),
+		"balances[0x00000000000000000000000000000000000000cc]": big.NewInt(0),
+		"deposits[1]":  big.NewInt(7),
+		"config.fee":   big.NewInt(5),
+		"config.cap":   big.NewInt(3),
+		"config.admin": common.HexToAddress("0x00000000000000000000000000000000000000aa"),
+		"name":         "vault",
+		"weights[2]":   big.NewInt(3),
+		"delta":        big.NewInt(-1),
+	}
+	for path, value := range expected {
+		read, err := Read(layout, state, address, path)
+		if assert.NoError(t, err, path) {
+			assert.EqualValues(t, fmt.Sprint(value), fmt.Sprint(read.Value), path)
+		}
+	}
+
+	// Paths which do not describe a value are rejected.
+	for _, path := range []string{"missing", "config", "deposits", "deposits[2]", "weights[4]", "balances[1]", "config.missing", "total[0]"} {
+		_, err := Read(layout, state, address, path)
+		assert.Error(t, err, path)
+	}
+}
+
+// TestVariableName tests that storage slots are named after the state variables they hold.
+func TestVariableName(t *testing.T) {
+	layout, err := types.ParseStorageLayout([]byte(testLayout))
+	assert.NoError(t, err)
+
+	assert.EqualValues(t, "total", VariableName(layout, slotHash(0)))
+	assert.EqualValues(t, "owner, paused", VariableName(layout, slotHash(1)))
+	assert.EqualValues(t, "balances", VariableName(layout, slotHash(2)))
+	assert.EqualValues(t, "config.fee, config.cap", VariableName(layout, slotHash(4)))
+	assert.EqualValues(t, "config.admin", VariableName(layout, slotHash(5)))
+	assert.EqualValues(t, "weights[0], weights[1], weights[2], weights[3]", VariableName(layout, slotHash(7)))
+	assert.EqualValues(t, "", VariableName(layout, slotHash(9)))
+	assert.EqualValues(t, "", VariableName(nil, slotHash(0)))
+}
+
+// TestAddressMappingEntryName tests that the storage slots of the entries of mappings keyed by address are named for
+// the keys provided.
+func TestAddressMappingEntryName(t *testing.T) {
+	layout, err := types.ParseStorageLayout([]byte(testLayout))
+	assert.NoError(t, err)
+	holder := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	other := common.HexToAddress("0x00000000000000000000000000000000000000cc")
+	slot := crypto.Keccak256Hash(common.LeftPadBytes(holder.Bytes(), 32), slotHash(2).Bytes())
+
+	name, valueType := AddressMappingEntryName(layout, slot, []common.Address{other, holder})
+	assert.EqualValues(t, "balances["+holder.Hex()+"]", name)
+	assert.EqualValues(t, "uint256", valueType)
+
+	// Slots of other keys, or of no mapping entry, are not named.
+	name, _ = AddressMappingEntryName(layout, slot, []common.Address{other})
+	assert.EqualValues(t, "", name)
+	name, _ = AddressMappingEntryName(layout, slotHash(2), []common.Address{holder})
+	assert.EqualValues(t, "", name)
+	name, _ = AddressMappingEntryName(nil, slot, []common.Address{holder})
+	assert.EqualValues(t, "", name)
+}
@@ -0,0 +1,100 @@
+package fuzzing
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/fuzzing/calls"
+	fuzzerTypes "github.com/crytic/medusa/fuzzing/contracts"
+	"github.com/crytic/medusa/logging"
+	"github.com/crytic/medusa/logging/colors"
+	"github.com/holiman/uint256"
+)
+
+// BugDetectorTestCase describes a bug found by the bug detector and reported by a BugDetectorTestCaseProvider.
+type BugDetectorTestCase struct {
+	// status describes the status of the test case
+	status TestCaseStatus
+	// bugId describes the ID of the bug detected (e.g. REENTRANCY-addr-pc)
+	bugId string
+	// bugValue describes the concrete tainted value which reached the sink of the bug, if one was recorded.
+	bugValue *uint256.Int
+	// stateVariable describes the name of the state variable the bug involves, if the bug involves a storage slot of a
+	// contract whose storage layout is known.
+	stateVariable string
+	// callSequence describes the shrunken call sequence which triggers the bug
+	callSequence *calls.CallSequence
+	// contract describes the contract definition of the code the bug was detected in, if it could be resolved once
+	// the call sequence was shrunk.
+	contract *fuzzerTypes.Contract
+	// etherGained describes the net amount of ether the tracked addresses gained over the shrunken call sequence, if
+	// balance deltas were tracked.
+	etherGained *big.Int
+	// writtenSlot describes the storage slot written by the bug (e.g. after a reentrant call), if one was recorded.
+	writtenSlot *common.Hash
+	// writtenStateVariable and writtenValueType describe the state variable held in writtenSlot and the type of its
+	// value, if it could be named.
+	writtenStateVariable string
+	writtenValueType     string
+	// reachedTokenTransfer indicates whether the code the bug was detected in transferred tokens or ether in the
+	// transaction detecting the bug.
+	reachedTokenTransfer bool
+}
+
+// Status describes the TestCaseStatus used to define the current state of the test.
+func (t *BugDetectorTestCase) Status() TestCaseStatus {
+	return t.status
+}
+
+// CallSequence describes the types.CallSequence of calls sent to the EVM which resulted in this TestCase result.
+// This should be nil if the result is not related to the CallSequence.
+func (t *BugDetectorTestCase) CallSequence() *calls.CallSequence {
+	return t.callSequence
+}
+
+// BugID describes the ID of the bug detected.
+func (t *BugDetectorTestCase) BugID() string {
+	return t.bugId
+}
+
+// Name describes the name of the test case.
+func (t *BugDetectorTestCase) Name() string {
+	return fmt.Sprintf("Bug Detector: %s", t.bugId)
+}
+
+// LogMessage obtains a buffer that represents the result of the BugDetectorTestCase. This buffer can be passed to a
+// logger for console or file logging.
+func (t *BugDetectorTestCase) LogMessage() *logging.LogBuffer {
+	// If the test failed, return a failure message.
+	buffer := logging.NewLogBuffer()
+	if t.Status() == TestCaseStatusFailed {
+		buffer.Append(colors.RedBold, fmt.Sprintf("[%s] ", t.Status()), colors.Bold, t.Name(), colors.Reset, "\n")
+		buffer.Append(fmt.Sprintf("Bug \"%s\" was detected after the following call sequence:\n", t.bugId))
+		buffer.Append(colors.Bold, "[Call Sequence]", colors.Reset, "\n")
+		buffer.Append(t.CallSequence().Log().Elements()...)
+		if t.bugValue != nil {
+			buffer.Append(colors.Bold, "[Tainted Value]", colors.Reset, "\n")
+			buffer.Append(t.bugValue.Hex(), "\n")
+		}
+		if t.stateVariable != "" {
+			buffer.Append(colors.Bold, "[State Variable]", colors.Reset, "\n")
+			buffer.Append(t.stateVariable, "\n")
+		}
+		return buffer
+	}
+
+	buffer.Append(colors.GreenBold, fmt.Sprintf("[%s] ", t.Status()), colors.Bold, t.Name(), colors.Reset)
+	return buffer
+}
+
+// Message obtains a text-based printable message which describes the result of the BugDetectorTestCase.
+func (t *BugDetectorTestCase) Message() string {
+	// Internally, we just call log message and convert it to a string. This can be useful for 3rd party apps
+	return t.LogMessage().String()
+}
+
+// ID obtains a unique identifier for a test result.
+func (t *BugDetectorTestCase) ID() string {
+	return t.bugId
+}
@@ -0,0 +1,251 @@
+package fuzzing
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa/fuzzing/bugdetector"
+	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/fuzzing/config"
+	fuzzerTypes "github.com/crytic/medusa/fuzzing/contracts"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/balancedelta"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/tokenflow"
+	"github.com/crytic/medusa/fuzzing/storagelayout"
+)
+
+// BugDetectorTestCaseProvider is a BugDetectorTestCase provider which spawns a test case for every distinct bug ID
+// found by the bug detector. Each call sequence triggering a new bug is shrunk while preserving that exact bug ID,
+// rather than any failure, so every finding is reported with its own minimal call sequence.
+type BugDetectorTestCaseProvider struct {
+	// fuzzer describes the Fuzzer which this provider is attached to.
+	fuzzer *Fuzzer
+
+	// testCases is a map of bug IDs to bug detector test cases. Test cases are added here and registered with the
+	// fuzzer once a bug is first detected.
+	testCases map[string]*BugDetectorTestCase
+
+	// testCasesLock is used for thread-synchronization when updating testCases
+	testCasesLock sync.Mutex
+}
+
+// attachBugDetectorTestCaseProvider attaches a new BugDetectorTestCaseProvider to the Fuzzer and returns it.
+func attachBugDetectorTestCaseProvider(fuzzer *Fuzzer) *BugDetectorTestCaseProvider {
+	// Create a test case provider
+	t := &BugDetectorTestCaseProvider{
+		fuzzer: fuzzer,
+	}
+
+	// Subscribe the provider to relevant events the fuzzer emits.
+	fuzzer.Events.FuzzerStarting.Subscribe(t.onFuzzerStarting)
+
+	// Add the provider's call sequence test function to the fuzzer.
+	fuzzer.Hooks.CallSequenceTestFuncs = append(fuzzer.Hooks.CallSequenceTestFuncs, t.callSequencePostCallTest)
+	return t
+}
+
+// onFuzzerStarting is the event handler triggered when the Fuzzer is starting a fuzzing campaign. Test cases are only
+// created once bugs are detected, so it simply resets the provider's state.
+func (t *BugDetectorTestCaseProvider) onFuzzerStarting(event FuzzerStartingEvent) error {
+	// Reset our state
+	t.testCasesLock.Lock()
+	t.testCases = make(map[string]*BugDetectorTestCase)
+	t.testCasesLock.Unlock()
+	return nil
+}
+
+// detectsBug determines whether any call in the provided call sequence triggered the bug with the provided ID.
+func (t *BugDetectorTestCaseProvider) detectsBug(callSequence calls.CallSequence, bugId string) bool {
+	for _, element := range callSequence {
+		if element.ChainReference == nil {
+			continue
+		}
+		if bugMap := bugdetector.GetBugDetectorTracerResults(element.ChainReference.MessageResults()); bugMap != nil && bugMap.ContainsBug(bugId) {
+			return true
+		}
+	}
+	return false
+}
+
+// bugStateVariable returns the name of the state variable involved in the bug with the provided ID, for bugs which
+// involve a storage slot (e.g. UNINITIALIZEDSTORAGEREAD-codeAddress-pc-slot), named using the storage layout of the
+// code reading it.
+// Returns the name, or an empty string if the bug involves no storage slot or the slot could not be named.
+func (t *BugDetectorTestCaseProvider) bugStateVariable(bugId string) string {
+	location := bugdetector.ParseBugID(bugId)
+	if location.Address == nil || location.Slot == nil {
+		return ""
+	}
+	return t.fuzzer.StateVariableName(*location.Address, *location.Slot)
+}
+
+// bugWrittenStateVariable names the state variable held in the storage slot written by the bug with the provided ID,
+// using the storage layout of the code the bug was detected in. Entries of mappings keyed by address are recognized
+// for the senders, the deployer and the accounts called in the provided call sequence.
+// Returns the name and the type of its value, or empty strings if the slot could not be named.
+func (t *BugDetectorTestCaseProvider) bugWrittenStateVariable(bugId string, slot common.Hash, callSequence calls.CallSequence) (string, string) {
+	location := bugdetector.ParseBugID(bugId)
+	if location.Address == nil {
+		return "", ""
+	}
+	layout := t.fuzzer.StorageLayout(*location.Address)
+	if name := storagelayout.VariableName(layout, slot); name != "" {
+		return name, ""
+	}
+
+	keys := append([]common.Address{t.fuzzer.deployer, *location.Address}, t.fuzzer.senders...)
+	for _, element := range callSequence {
+		keys = append(keys, element.Call.From)
+		if element.Call.To != nil {
+			keys = append(keys, *element.Call.To)
+		}
+	}
+	return storagelayout.AddressMappingEntryName(layout, slot, keys)
+}
+
+// bugReachedTokenTransfer indicates whether the code the bug with the provided ID was detected in transferred tokens or
+// ether in the transaction of the provided call sequence element, or the bug's sink is itself a call, as recorded by
+// the token flow tracers, if attached.
+func bugReachedTokenTransfer(bugId string, element *calls.CallSequenceElement) bool {
+	location := bugdetector.ParseBugID(bugId)
+	if location.Opcode == vm.CALL.String() {
+		return true
+	}
+	tokenflowSet := tokenflow.GetTokenflowTracerResults(element.ChainReference.MessageResults())
+	if location.Address == nil || tokenflowSet == nil {
+		return false
+	}
+	for _, flow := range tokenflowSet.Tokenflows() {
+		if flow.Position.Address == *location.Address {
+			return true
+		}
+	}
+	return false
+}
+
+// callSequenceEtherGained returns the net amount of ether the tracked addresses gained over the provided call
+// sequence, as recorded by the balance delta tracers, or nil if no balance deltas were recorded.
+func callSequenceEtherGained(callSequence calls.CallSequence) *big.Int {
+	if len(callSequence) == 0 || callSequence[len(callSequence)-1].ChainReference == nil {
+		return nil
+	}
+	balanceDeltaSet := balancedelta.GetBalanceDeltaTracerResults(callSequence[len(callSequence)-1].ChainReference.MessageResults())
+	if balanceDeltaSet == nil {
+		return nil
+	}
+	etherGained := big.NewInt(0)
+	for _, gain := range balanceDeltaSet.Gains() {
+		if gain.Token == (common.Address{}) {
+			etherGained.Add(etherGained, gain.Delta)
+		}
+	}
+	return etherGained
+}
+
+// bugContract resolves the contract definition of the code the bug with the provided ID was detected in, using the
+// contracts deployed on the provided worker's chain.
+// Returns the contract definition, or nil if the bug is not located in code or its code could not be matched.
+func (t *BugDetectorTestCaseProvider) bugContract(worker *FuzzerWorker, bugId string) *fuzzerTypes.Contract {
+	location := bugdetector.ParseBugID(bugId)
+	if location.Address == nil || location.Pc == nil {
+		return nil
+	}
+	if contract, ok := worker.deployedContracts[*location.Address]; ok {
+		return contract
+	}
+	runtimeBytecode := worker.chain.State().GetCode(*location.Address)
+	if len(runtimeBytecode) == 0 {
+		return nil
+	}
+	return worker.fuzzer.contractDefinitions.MatchBytecode(nil, runtimeBytecode)
+}
+
+// callSequencePostCallTest is a CallSequenceTestFunc that performs post-call testing logic for the attached Fuzzer
+// and any underlying FuzzerWorker. It is called after every call made in a call sequence. It requests a shrunken
+// call sequence for every bug detected in the last call which was not detected before.
+func (t *BugDetectorTestCaseProvider) callSequencePostCallTest(worker *FuzzerWorker, callSequence calls.CallSequence) ([]ShrinkCallSequenceRequest, error) {
+	// Create a list of shrink call sequence verifiers, which we populate for each new bug we want a call sequence
+	// shrunk for.
+	shrinkRequests := make([]ShrinkCallSequenceRequest, 0)
+
+	// Obtain the bugs detected in the last call made in our sequence.
+	if len(callSequence) == 0 || callSequence[len(callSequence)-1].ChainReference == nil {
+		return shrinkRequests, nil
+	}
+	bugMap := bugdetector.GetBugDetectorTracerResults(callSequence[len(callSequence)-1].ChainReference.MessageResults())
+	if bugMap == nil {
+		return shrinkRequests, nil
+	}
+
+	for _, bugId := range bugMap.BugIDs() {
+		// If this bug was already detected, it is already being (or has been) shrunk.
+		t.testCasesLock.Lock()
+		if _, testCaseExists := t.testCases[bugId]; testCaseExists {
+			t.testCasesLock.Unlock()
+			continue
+		}
+
+		// Create our test case, add it to our test cases and register it with the fuzzer.
+		testCase := &BugDetectorTestCase{
+			status:        TestCaseStatusRunning,
+			bugId:         bugId,
+			stateVariable: t.bugStateVariable(bugId),
+		}
+		t.testCases[bugId] = testCase
+		t.testCasesLock.Unlock()
+		t.fuzzer.RegisterTestCase(testCase)
+
+		// We provide a shrink verifier which only accepts shrunken sequences which trigger this exact bug ID.
+		shrinkRequest := ShrinkCallSequenceRequest{
+			TestName:             testCase.Name(),
+			CallSequenceToShrink: callSequence,
+			VerifierFunction: func(worker *FuzzerWorker, shrunkenCallSequence calls.CallSequence) (bool, error) {
+				return t.detectsBug(shrunkenCallSequence, bugId), nil
+			},
+			FinishedCallback: func(worker *FuzzerWorker, shrunkenCallSequence calls.CallSequence, verbosity config.VerbosityLevel) error {
+				// When we're finished shrinking, attach an execution trace to the last call. If verboseTracing is true, attach to all calls.
+				if len(shrunkenCallSequence) > 0 {
+					_, err := calls.ExecuteCallSequenceWithExecutionTracer(worker.chain, worker.fuzzer.contractDefinitions, shrunkenCallSequence, verbosity)
+					if err != nil {
+						return err
+					}
+				}
+
+				// Record the tainted value which reached the sink in our final sequence, if any, along with the
+				// evidence of the bug's exploitability observed in the transaction detecting it.
+				for _, element := range shrunkenCallSequence {
+					if element.ChainReference == nil {
+						continue
+					}
+					if shrunkBugMap := bugdetector.GetBugDetectorTracerResults(element.ChainReference.MessageResults()); shrunkBugMap != nil && shrunkBugMap.ContainsBug(bugId) {
+						testCase.bugValue = shrunkBugMap.BugValue(bugId)
+						testCase.writtenSlot = shrunkBugMap.BugSlot(bugId)
+						testCase.reachedTokenTransfer = bugReachedTokenTransfer(bugId, element)
+						break
+					}
+				}
+				testCase.etherGained = callSequenceEtherGained(shrunkenCallSequence)
+
+				// Resolve the contract the bug is located in, so it can be reported along with its source location.
+				testCase.contract = t.bugContract(worker, bugId)
+				if testCase.writtenSlot != nil {
+					testCase.writtenStateVariable, testCase.writtenValueType = t.bugWrittenStateVariable(bugId, *testCase.writtenSlot, shrunkenCallSequence)
+				}
+
+				// Update our test state and report it finalized.
+				testCase.status = TestCaseStatusFailed
+				testCase.callSequence = &shrunkenCallSequence
+				worker.workerMetrics().failedSequences.Add(worker.workerMetrics().failedSequences, big.NewInt(1))
+				worker.Fuzzer().ReportTestCaseFinished(testCase)
+				return nil
+			},
+			RecordResultInCorpus: true,
+		}
+
+		// Add our shrink request to our list.
+		shrinkRequests = append(shrinkRequests, shrinkRequest)
+	}
+
+	return shrinkRequests, nil
+}
@@ -0,0 +1,77 @@
+package fuzzing
+
+import (
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	chainTypes "github.com/crytic/medusa/chain/types"
+	"github.com/crytic/medusa/fuzzing/bugdetector"
+	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/logging"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+// getBugDetectorTestElement returns a call sequence element whose execution detected the provided bugs.
+func getBugDetectorTestElement(t *testing.T, bugIds ...string) *calls.CallSequenceElement {
+	bugMap := bugdetector.NewBugMap()
+	for _, bugId := range bugIds {
+		_, err := bugMap.CoverBug(bugId)
+		assert.NoError(t, err)
+	}
+	element := calls.NewCallSequenceElement(nil, nil, 0, 0)
+	element.ChainReference = &calls.CallSequenceElementChainReference{
+		Block: &chainTypes.Block{MessageResults: []*chainTypes.MessageResults{{
+			AdditionalResults: map[string]any{"BugDetectorTracerResults": bugMap},
+		}}},
+	}
+	return element
+}
+
+// TestBugDetectorShrinkVerifier ensures a shrink request is made once for each distinct bug detected by the last call
+// of a call sequence, and that its verifier only accepts shrunken call sequences which detect that exact bug ID, in any
+// of their calls.
+func TestBugDetectorShrinkVerifier(t *testing.T) {
+	fuzzer := &Fuzzer{logger: logging.NewLogger(zerolog.Disabled)}
+	provider := &BugDetectorTestCaseProvider{fuzzer: fuzzer}
+	assert.NoError(t, provider.onFuzzerStarting(FuzzerStartingEvent{}))
+
+	address := common.HexToAddress("0x1000").Hex()
+	reentrancyBugId := "REENTRANCY-" + address + "-12"
+	overflowBugId := "OVERFLOW-" + address + "-56-ADD"
+	otherOverflowBugId := "OVERFLOW-" + address + "-78-ADD"
+
+	// Bugs detected before the last call are not requested to be shrunk again, nor are those already detected.
+	callSequence := calls.CallSequence{
+		getBugDetectorTestElement(t, otherOverflowBugId),
+		getBugDetectorTestElement(t, reentrancyBugId, overflowBugId),
+	}
+	shrinkRequests, err := provider.callSequencePostCallTest(nil, callSequence)
+	assert.NoError(t, err)
+	assert.Len(t, shrinkRequests, 2)
+	assert.Len(t, fuzzer.testCases, 2)
+	shrinkRequests, err = provider.callSequencePostCallTest(nil, callSequence[1:])
+	assert.NoError(t, err)
+	assert.Empty(t, shrinkRequests)
+
+	// Each verifier only accepts call sequences detecting its own bug.
+	shrinkRequests, err = provider.callSequencePostCallTest(nil, callSequence[:1])
+	assert.NoError(t, err)
+	assert.Len(t, shrinkRequests, 1)
+	tests := []struct {
+		callSequence calls.CallSequence
+		accepted     bool
+	}{
+		{callSequence: calls.CallSequence{getBugDetectorTestElement(t, otherOverflowBugId)}, accepted: true},
+		{callSequence: calls.CallSequence{getBugDetectorTestElement(t, otherOverflowBugId, reentrancyBugId)}, accepted: true},
+		{callSequence: calls.CallSequence{getBugDetectorTestElement(t, otherOverflowBugId), getBugDetectorTestElement(t)}, accepted: true},
+		{callSequence: calls.CallSequence{getBugDetectorTestElement(t, overflowBugId)}, accepted: false},
+		{callSequence: calls.CallSequence{getBugDetectorTestElement(t), calls.NewCallSequenceElement(nil, nil, 0, 0)}, accepted: false},
+		{callSequence: calls.CallSequence{}, accepted: false},
+	}
+	for i, test := range tests {
+		accepted, err := shrinkRequests[0].VerifierFunction(nil, test.callSequence)
+		assert.NoError(t, err)
+		assert.EqualValues(t, test.accepted, accepted, i)
+	}
+}
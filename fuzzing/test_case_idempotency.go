@@ -0,0 +1,106 @@
+package fuzzing
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/crytic/medusa-geth/accounts/abi"
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa/fuzzing/calls"
+	fuzzerTypes "github.com/crytic/medusa/fuzzing/contracts"
+	"github.com/crytic/medusa/logging"
+	"github.com/crytic/medusa/logging/colors"
+	"github.com/holiman/uint256"
+)
+
+// IdempotencyTestCase describes a test being run by an IdempotencyTestCaseProvider.
+type IdempotencyTestCase struct {
+	// status describes the status of the test case
+	status TestCaseStatus
+	// targetContract describes the target contract where the test case was found
+	targetContract *fuzzerTypes.Contract
+	// targetMethod describes the target method for the test case
+	targetMethod abi.Method
+	// callSequence describes the call sequence after which repeating the last call changed state
+	callSequence *calls.CallSequence
+	// stateDifferences describes the state changed by the repeated call
+	stateDifferences []IdempotencyStateDifference
+}
+
+// IdempotencyStateDifference describes a storage slot or balance changed by a repeated invocation of a method.
+type IdempotencyStateDifference struct {
+	// Address is the address of the account whose state changed.
+	Address common.Address
+	// Slot is the storage slot which changed, or nil if the balance of the account changed.
+	Slot *common.Hash
+	// Before is the value of the slot or balance prior to the repeated invocation.
+	Before *uint256.Int
+	// After is the value of the slot or balance after the repeated invocation.
+	After *uint256.Int
+}
+
+// String returns a human-readable description of the state difference.
+func (d IdempotencyStateDifference) String() string {
+	if d.Slot == nil {
+		return fmt.Sprintf("balance of %s: %s -> %s", d.Address.String(), d.Before.Dec(), d.After.Dec())
+	}
+	return fmt.Sprintf("storage of %s at slot %s: %s -> %s", d.Address.String(), d.Slot.Hex(), d.Before.Hex(), d.After.Hex())
+}
+
+// Status describes the TestCaseStatus used to define the current state of the test.
+func (t *IdempotencyTestCase) Status() TestCaseStatus {
+	return t.status
+}
+
+// CallSequence describes the types.CallSequence of calls sent to the EVM which resulted in this TestCase result.
+// This should be nil if the result is not related to the CallSequence.
+func (t *IdempotencyTestCase) CallSequence() *calls.CallSequence {
+	return t.callSequence
+}
+
+// StateDifferences describes the storage slots and balances changed by the repeated invocation of the target method
+// which failed this test case. This is nil if the test case did not fail.
+func (t *IdempotencyTestCase) StateDifferences() []IdempotencyStateDifference {
+	return t.stateDifferences
+}
+
+// Name describes the name of the test case.
+func (t *IdempotencyTestCase) Name() string {
+	return fmt.Sprintf("Idempotency Test: %s.%s", t.targetContract.Name(), t.targetMethod.Sig)
+}
+
+// LogMessage obtains a buffer that represents the result of the IdempotencyTestCase. This buffer can be passed to a
+// logger for console or file logging.
+func (t *IdempotencyTestCase) LogMessage() *logging.LogBuffer {
+	// If the test failed, return a failure message.
+	buffer := logging.NewLogBuffer()
+	if t.Status() == TestCaseStatusFailed {
+		buffer.Append(colors.RedBold, fmt.Sprintf("[%s] ", t.Status()), colors.Bold, t.Name(), colors.Reset, "\n")
+		buffer.Append(fmt.Sprintf("Repeating the last call to method \"%s.%s\" changed state again after the following call sequence:\n", t.targetContract.Name(), t.targetMethod.Sig))
+		buffer.Append(colors.Bold, "[Call Sequence]", colors.Reset, "\n")
+		buffer.Append(t.CallSequence().Log().Elements()...)
+
+		// List the state the repeated call changed
+		if len(t.stateDifferences) > 0 {
+			buffer.Append(colors.Bold, "[State Changed By Repeated Call]", colors.Reset, "\n")
+			for _, stateDifference := range t.stateDifferences {
+				buffer.Append(stateDifference.String(), "\n")
+			}
+		}
+		return buffer
+	}
+
+	buffer.Append(colors.GreenBold, fmt.Sprintf("[%s] ", t.Status()), colors.Bold, t.Name(), colors.Reset)
+	return buffer
+}
+
+// Message obtains a text-based printable message which describes the result of the IdempotencyTestCase.
+func (t *IdempotencyTestCase) Message() string {
+	// Internally, we just call log message and convert it to a string. This can be useful for 3rd party apps
+	return t.LogMessage().String()
+}
+
+// ID obtains a unique identifier for a test result.
+func (t *IdempotencyTestCase) ID() string {
+	return strings.Replace(fmt.Sprintf("IDEMPOTENCY-%s-%s", t.targetContract.Name(), t.targetMethod.Sig), "_", "-", -1)
+}
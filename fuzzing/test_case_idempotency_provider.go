@@ -0,0 +1,401 @@
+package fuzzing
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core"
+	"github.com/crytic/medusa-geth/core/tracing"
+	coretypes "github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa-geth/core/vm"
+	"github.com/crytic/medusa-geth/eth/tracers"
+	"github.com/crytic/medusa/chain"
+	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/fuzzing/config"
+	"github.com/crytic/medusa/fuzzing/contracts"
+	"github.com/holiman/uint256"
+
+	"golang.org/x/exp/slices"
+)
+
+// IdempotencyTestCaseProvider is an IdempotencyTestCase provider which spawns test cases for every state-changing
+// contract method and ensures that repeating an identical call to any of them does not keep changing state. This
+// catches double-withdrawal and double-claim patterns. As repeating calls is costly, only the last call of call
+// sequences added to the corpus is checked.
+type IdempotencyTestCaseProvider struct {
+	// fuzzer describes the Fuzzer which this provider is attached to.
+	fuzzer *Fuzzer
+
+	// testCases is a map of contract-method IDs to idempotency test cases.
+	testCases map[contracts.ContractMethodID]*IdempotencyTestCase
+
+	// testCasesLock is used for thread-synchronization when updating testCases
+	testCasesLock sync.Mutex
+}
+
+// attachIdempotencyTestCaseProvider attaches a new IdempotencyTestCaseProvider to the Fuzzer and returns it.
+func attachIdempotencyTestCaseProvider(fuzzer *Fuzzer) *IdempotencyTestCaseProvider {
+	// Create a test case provider
+	t := &IdempotencyTestCaseProvider{
+		fuzzer: fuzzer,
+	}
+
+	// Subscribe the provider to relevant events the fuzzer emits.
+	fuzzer.Events.FuzzerStarting.Subscribe(t.onFuzzerStarting)
+	fuzzer.Events.FuzzerStopping.Subscribe(t.onFuzzerStopping)
+	fuzzer.Events.WorkerCreated.Subscribe(t.onWorkerCreated)
+
+	// Add the provider's call sequence test function to the fuzzer.
+	fuzzer.Hooks.CallSequenceTestFuncs = append(fuzzer.Hooks.CallSequenceTestFuncs, t.callSequencePostCallTest)
+	return t
+}
+
+// idempotencyStateRecorder records the storage slots and balances changed during a message call, along with their
+// values prior to the call. Values are recorded as opcodes and call frames are about to change them, and compared once
+// the outermost call frame exits, prior to gas being refunded, so that gas payments are not considered.
+type idempotencyStateRecorder struct {
+	// storage maps the storage slots changed by address to their value prior to the call.
+	storage map[common.Address]map[common.Hash]common.Hash
+	// balances maps the addresses whose balance may have changed (other than for gas) to their balance prior to the
+	// call.
+	balances map[common.Address]*uint256.Int
+	// msg is the message call being recorded. Its value transfer is expected to change balances on every call, so it
+	// is not considered a state difference.
+	msg *core.Message
+	// stateDB is the state the message call executes over.
+	stateDB tracing.StateDB
+	// stateDifferences describes the state changed by the last message call recorded, once it completed.
+	stateDifferences []IdempotencyStateDifference
+	// nativeTracer is the underlying tracer used to capture EVM execution.
+	nativeTracer *chain.TestChainTracer
+}
+
+// newIdempotencyStateRecorder returns a new idempotencyStateRecorder for the provided message call.
+func newIdempotencyStateRecorder(msg *core.Message) *idempotencyStateRecorder {
+	recorder := &idempotencyStateRecorder{msg: msg}
+	recorder.nativeTracer = &chain.TestChainTracer{
+		Tracer: &tracers.Tracer{
+			Hooks: &tracing.Hooks{
+				OnTxStart: recorder.OnTxStart,
+				OnEnter:   recorder.OnEnter,
+				OnExit:    recorder.OnExit,
+				OnOpcode:  recorder.OnOpcode,
+			},
+		},
+	}
+	return recorder
+}
+
+// OnTxStart is called upon the start of transaction execution, as defined by tracers.Tracer.
+func (r *idempotencyStateRecorder) OnTxStart(vm *tracing.VMContext, tx *coretypes.Transaction, from common.Address) {
+	r.storage = make(map[common.Address]map[common.Hash]common.Hash)
+	r.balances = make(map[common.Address]*uint256.Int)
+	r.stateDB = vm.StateDB
+	r.stateDifferences = nil
+}
+
+// OnEnter is called upon entering of the call frame, as defined by tracers.Tracer. Call frames transfer value before
+// executing, so the balances of both parties are recorded.
+func (r *idempotencyStateRecorder) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if value != nil && value.Sign() > 0 {
+		r.recordBalance(from)
+		r.recordBalance(to)
+	}
+}
+
+// OnExit is called after a call to finalize tracing completes for the top of a call frame, as defined by
+// tracers.Tracer. Once the outermost call frame exits, the recorded values are compared with the current ones.
+func (r *idempotencyStateRecorder) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if depth == 0 {
+		r.stateDifferences = r.compare()
+	}
+}
+
+// OnOpcode records data from an EVM state update, as defined by tracers.Tracer. The values about to be changed by
+// storage writes and self-destructs are recorded.
+func (r *idempotencyStateRecorder) OnOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	stackData := scope.StackData()
+	if len(stackData) == 0 {
+		return
+	}
+	switch vm.OpCode(op) {
+	case vm.SSTORE:
+		address, slot := scope.Address(), common.Hash(stackData[len(stackData)-1].Bytes32())
+		if _, ok := r.storage[address]; !ok {
+			r.storage[address] = make(map[common.Hash]common.Hash)
+		}
+		if _, ok := r.storage[address][slot]; !ok {
+			r.storage[address][slot] = r.stateDB.GetState(address, slot)
+		}
+	case vm.SELFDESTRUCT:
+		r.recordBalance(scope.Address())
+		r.recordBalance(common.Address(stackData[len(stackData)-1].Bytes20()))
+	}
+}
+
+// recordBalance records the current balance of the provided address, if it was not recorded yet.
+func (r *idempotencyStateRecorder) recordBalance(address common.Address) {
+	if _, ok := r.balances[address]; !ok {
+		r.balances[address] = new(uint256.Int).Set(r.stateDB.GetBalance(address))
+	}
+}
+
+// compare compares the values recorded prior to the call with the current ones, returning the storage slots and
+// balances which differ, sorted by address and slot. Balances are compared after accounting for the value transferred
+// by the message call itself.
+func (r *idempotencyStateRecorder) compare() []IdempotencyStateDifference {
+	stateDifferences := make([]IdempotencyStateDifference, 0)
+	for address, slots := range r.storage {
+		for slot, before := range slots {
+			after := r.stateDB.GetState(address, slot)
+			if after != before {
+				slot := slot
+				stateDifferences = append(stateDifferences, IdempotencyStateDifference{
+					Address: address,
+					Slot:    &slot,
+					Before:  new(uint256.Int).SetBytes32(before[:]),
+					After:   new(uint256.Int).SetBytes32(after[:]),
+				})
+			}
+		}
+	}
+	for address, before := range r.balances {
+		expected := new(uint256.Int).Set(before)
+		if r.msg.Value != nil && r.msg.Value.Sign() > 0 {
+			value := uint256.MustFromBig(r.msg.Value)
+			if address == r.msg.From {
+				expected.Sub(expected, value)
+			}
+			if r.msg.To != nil && address == *r.msg.To {
+				expected.Add(expected, value)
+			}
+		}
+		if after := r.stateDB.GetBalance(address); !after.Eq(expected) {
+			stateDifferences = append(stateDifferences, IdempotencyStateDifference{
+				Address: address,
+				Before:  before,
+				After:   new(uint256.Int).Set(after),
+			})
+		}
+	}
+
+	sort.Slice(stateDifferences, func(i, j int) bool {
+		if c := bytes.Compare(stateDifferences[i].Address[:], stateDifferences[j].Address[:]); c != 0 {
+			return c < 0
+		}
+		if stateDifferences[i].Slot == nil || stateDifferences[j].Slot == nil {
+			return stateDifferences[j].Slot == nil && stateDifferences[i].Slot != nil
+		}
+		return bytes.Compare(stateDifferences[i].Slot[:], stateDifferences[j].Slot[:]) < 0
+	})
+	return stateDifferences
+}
+
+// checkIdempotency repeats the last call of the provided call sequence twice in succession over the worker's current
+// chain state (which is left unchanged), and determines whether the second repetition still changed state.
+// Returns the method ID of the last call, the state changed by the second repetition (empty if the method behaved
+// idempotently or the check was inconclusive), or an error if one occurs.
+func (t *IdempotencyTestCaseProvider) checkIdempotency(worker *FuzzerWorker, callSequence calls.CallSequence) (*contracts.ContractMethodID, []IdempotencyStateDifference, error) {
+	// If we have an empty call sequence, there is nothing to repeat
+	if len(callSequence) == 0 {
+		return nil, nil, nil
+	}
+
+	// Obtain the contract and method from the last call made in our sequence
+	lastCall := callSequence[len(callSequence)-1]
+	lastCallMethod, err := lastCall.Method()
+	if err != nil {
+		return nil, nil, err
+	}
+	methodId := contracts.GetContractMethodID(lastCall.Contract, lastCallMethod)
+
+	// If the original call failed, repeating it tells us nothing.
+	if lastCall.ChainReference == nil || lastCall.ChainReference.MessageResults().ExecutionResult.Failed() {
+		return &methodId, nil, nil
+	}
+
+	// Repeat the call, ignoring the nonce as it was already used by the original call.
+	msg := lastCall.Call.ToCoreMessage()
+	msg.SkipNonceChecks = true
+	return &methodId, repeatedCallStateDifferences(worker.chain, msg), nil
+}
+
+// repeatedCallStateDifferences executes the provided message call twice in succession over the current state of the
+// provided chain (which is left unchanged), and records the state changed by the second repetition.
+// Returns the state differences, or nil if the repetitions could not be executed (e.g. the message is no longer valid
+// on its own) or the second one failed, as its changes were discarded and the check is inconclusive.
+func repeatedCallStateDifferences(testChain *chain.TestChain, msg *core.Message) []IdempotencyStateDifference {
+	msgs := []*core.Message{msg, msg}
+	recorder := newIdempotencyStateRecorder(msg)
+	msgResults, err := testChain.CallContracts(msgs, nil, recorder.nativeTracer)
+	if err != nil || len(msgResults) < len(msgs) || msgResults[len(msgs)-1].Failed() {
+		return nil
+	}
+	return recorder.stateDifferences
+}
+
+// onFuzzerStarting is the event handler triggered when the Fuzzer is starting a fuzzing campaign. It creates test cases
+// in a "not started" state for every state-changing method discovered in the contract definitions known to the Fuzzer.
+func (t *IdempotencyTestCaseProvider) onFuzzerStarting(event FuzzerStartingEvent) error {
+	// Reset our state
+	t.testCases = make(map[contracts.ContractMethodID]*IdempotencyTestCase)
+
+	// Create a test case for every state-changing method.
+	excludedMethods := t.fuzzer.config.Fuzzing.Testing.IdempotencyTesting.ExcludeFunctionSignatures
+	for _, contract := range t.fuzzer.ContractDefinitions() {
+		// If we're not testing all contracts, verify the current contract is one we specified in our target contracts
+		if !t.fuzzer.config.Fuzzing.Testing.TestAllContracts && !slices.Contains(t.fuzzer.config.Fuzzing.TargetContracts, contract.Name()) {
+			continue
+		}
+
+		for _, method := range contract.AssertionTestMethods {
+			// Methods which cannot change state are trivially idempotent, and excluded methods are expected not to be.
+			if method.IsConstant() || slices.Contains(excludedMethods, strings.Join([]string{contract.Name(), method.Sig}, ".")) {
+				continue
+			}
+
+			// Create local variables to avoid pointer types in the loop being overridden.
+			contract := contract
+			method := method
+
+			// Create our test case
+			testCase := &IdempotencyTestCase{
+				status:         TestCaseStatusNotStarted,
+				targetContract: contract,
+				targetMethod:   method,
+				callSequence:   nil,
+			}
+
+			// Add to our test cases and register them with the fuzzer
+			methodId := contracts.GetContractMethodID(contract, &method)
+			t.testCases[methodId] = testCase
+			t.fuzzer.RegisterTestCase(testCase)
+		}
+	}
+	return nil
+}
+
+// onFuzzerStopping is the event handler triggered when the Fuzzer is stopping the fuzzing campaign and all workers
+// have been destroyed. It sets test cases in "running" states to "passed".
+func (t *IdempotencyTestCaseProvider) onFuzzerStopping(event FuzzerStoppingEvent) error {
+	// Loop through each test case and set any tests with a running status to a passed status.
+	for _, testCase := range t.testCases {
+		if testCase.status == TestCaseStatusRunning {
+			testCase.status = TestCaseStatusPassed
+		}
+	}
+	return nil
+}
+
+// onWorkerCreated is the event handler triggered when a FuzzerWorker is created by the Fuzzer. It subscribes to
+// relevant worker events.
+func (t *IdempotencyTestCaseProvider) onWorkerCreated(event FuzzerWorkerCreatedEvent) error {
+	// Subscribe to relevant worker events.
+	event.Worker.Events.ContractAdded.Subscribe(t.onWorkerDeployedContractAdded)
+	return nil
+}
+
+// onWorkerDeployedContractAdded is the event handler triggered when a FuzzerWorker detects a new contract deployment
+// on its underlying chain. Any test cases for methods of the deployed contract which are in a "not started" state are
+// put into a "running" state, as they are now potentially reachable for testing.
+func (t *IdempotencyTestCaseProvider) onWorkerDeployedContractAdded(event FuzzerWorkerContractAddedEvent) error {
+	// If we don't have a contract definition, we can't run tests against the contract.
+	if event.ContractDefinition == nil {
+		return nil
+	}
+
+	// Loop through all methods and find ones for which we have tests
+	for _, method := range event.ContractDefinition.CompiledContract().Abi.Methods {
+		methodId := contracts.GetContractMethodID(event.ContractDefinition, &method)
+		t.testCasesLock.Lock()
+		testCase, testCaseExists := t.testCases[methodId]
+		t.testCasesLock.Unlock()
+		if testCaseExists && testCase.Status() == TestCaseStatusNotStarted {
+			testCase.status = TestCaseStatusRunning
+		}
+	}
+	return nil
+}
+
+// callSequencePostCallTest is a CallSequenceTestFunc that performs post-call testing logic for the attached Fuzzer
+// and any underlying FuzzerWorker. It is called after every call made in a call sequence. If the call sequence was
+// added to the corpus, it repeats the last call and checks whether the repetition keeps changing state.
+func (t *IdempotencyTestCaseProvider) callSequencePostCallTest(worker *FuzzerWorker, callSequence calls.CallSequence) ([]ShrinkCallSequenceRequest, error) {
+	// Create a list of shrink call sequence verifiers, which we populate for each failed test we want a call sequence
+	// shrunk for.
+	shrinkRequests := make([]ShrinkCallSequenceRequest, 0)
+
+	// Only call sequences added to the corpus are checked, to bound the cost of repeating calls.
+	if !worker.lastCallAddedToCorpus || len(callSequence) == 0 {
+		return shrinkRequests, nil
+	}
+
+	// Obtain the test case for the method called last, if we are testing it and it did not already fail.
+	lastCallMethod, err := callSequence[len(callSequence)-1].Method()
+	if err != nil {
+		return nil, err
+	}
+	t.testCasesLock.Lock()
+	testCase, testCaseExists := t.testCases[contracts.GetContractMethodID(callSequence[len(callSequence)-1].Contract, lastCallMethod)]
+	t.testCasesLock.Unlock()
+	if !testCaseExists || testCase.Status() == TestCaseStatusFailed {
+		return shrinkRequests, nil
+	}
+
+	// Repeat the last call and check whether it kept changing state.
+	methodId, stateDifferences, err := t.checkIdempotency(worker, callSequence)
+	if err != nil {
+		return nil, err
+	}
+
+	// If we failed a test, we provide a shrink verifier which will update the call sequence for each shrunken sequence
+	// provided that fails the test.
+	if len(stateDifferences) > 0 {
+		shrinkRequest := ShrinkCallSequenceRequest{
+			TestName:             testCase.Name(),
+			CallSequenceToShrink: callSequence,
+			VerifierFunction: func(worker *FuzzerWorker, shrunkenCallSequence calls.CallSequence) (bool, error) {
+				// If repeating the last call of the shrunken sequence keeps changing state for the same method, this
+				// shrunk sequence is satisfactory.
+				shrunkSeqMethodId, shrunkSeqStateDifferences, err := t.checkIdempotency(worker, shrunkenCallSequence)
+				if err != nil {
+					return false, err
+				}
+				return len(shrunkSeqStateDifferences) > 0 && *methodId == *shrunkSeqMethodId, nil
+			},
+			FinishedCallback: func(worker *FuzzerWorker, shrunkenCallSequence calls.CallSequence, verbosity config.VerbosityLevel) error {
+				// When we're finished shrinking, attach an execution trace to the last call. If verboseTracing is true, attach to all calls.
+				if len(shrunkenCallSequence) > 0 {
+					_, err = calls.ExecuteCallSequenceWithExecutionTracer(worker.chain, worker.fuzzer.contractDefinitions, shrunkenCallSequence, verbosity)
+					if err != nil {
+						return err
+					}
+				}
+
+				// Record the state changed by repeating the last call of our final sequence.
+				_, shrunkSeqStateDifferences, err := t.checkIdempotency(worker, shrunkenCallSequence)
+				if err != nil {
+					return err
+				}
+
+				// Update our test state and report it finalized.
+				testCase.status = TestCaseStatusFailed
+				testCase.callSequence = &shrunkenCallSequence
+				testCase.stateDifferences = shrunkSeqStateDifferences
+				worker.workerMetrics().failedSequences.Add(worker.workerMetrics().failedSequences, big.NewInt(1))
+				worker.Fuzzer().ReportTestCaseFinished(testCase)
+				return nil
+			},
+			RecordResultInCorpus: true,
+		}
+
+		// Add our shrink request to our list.
+		shrinkRequests = append(shrinkRequests, shrinkRequest)
+	}
+
+	return shrinkRequests, nil
+}
@@ -0,0 +1,81 @@
+package fuzzing
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/crytic/medusa-geth/accounts/abi"
+	"github.com/crytic/medusa-geth/common"
+	"github.com/crytic/medusa-geth/core"
+	"github.com/crytic/medusa-geth/core/types"
+	"github.com/crytic/medusa/chain"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRepeatedCallStateDifferences repeats calls to contracts deployed in the genesis block, ensuring only the state
+// changed by the second repetition is reported, excluding the gas paid and the value transferred by the call itself.
+func TestRepeatedCallStateDifferences(t *testing.T) {
+	sender := common.HexToAddress("0x10000")
+	sink := common.HexToAddress("0x20000")
+	counter := common.HexToAddress("0x30000")
+	withdrawer := common.HexToAddress("0x40000")
+	recipient := common.HexToAddress("0x50000")
+
+	genesisAlloc := types.GenesisAlloc{
+		sender: {Balance: new(big.Int).Div(abi.MaxInt256, big.NewInt(2))},
+		// STOP: accepts any call without changing state.
+		sink: {Code: common.FromHex("0x00")},
+		// Increments storage slot 0 on every call.
+		counter: {Code: common.FromHex("0x600054600101600055" + "00")},
+		// Sends 1 wei of its own balance to the recipient on every call.
+		withdrawer: {
+			Code:    common.FromHex("0x6000600060006000600173" + recipient.Hex()[2:] + "5af1" + "00"),
+			Balance: big.NewInt(100),
+		},
+	}
+	testChain, err := chain.NewTestChain(context.Background(), genesisAlloc, nil)
+	assert.NoError(t, err)
+
+	newMessage := func(to common.Address, value int64) *core.Message {
+		return &core.Message{
+			From:            sender,
+			To:              &to,
+			Value:           big.NewInt(value),
+			GasLimit:        testChain.BlockGasLimit,
+			GasPrice:        big.NewInt(1),
+			GasFeeCap:       big.NewInt(1),
+			GasTipCap:       big.NewInt(1),
+			SkipNonceChecks: true,
+		}
+	}
+
+	// Calls which do not change state are idempotent, even if they transfer value.
+	assert.Empty(t, repeatedCallStateDifferences(testChain, newMessage(sink, 0)))
+	assert.Empty(t, repeatedCallStateDifferences(testChain, newMessage(sink, 5)))
+
+	// Storage changed by the second repetition is reported, relative to the first repetition.
+	stateDifferences := repeatedCallStateDifferences(testChain, newMessage(counter, 5))
+	assert.Len(t, stateDifferences, 1)
+	assert.EqualValues(t, counter, stateDifferences[0].Address)
+	assert.EqualValues(t, common.Hash{}, *stateDifferences[0].Slot)
+	assert.EqualValues(t, 1, stateDifferences[0].Before.Uint64())
+	assert.EqualValues(t, 2, stateDifferences[0].After.Uint64())
+
+	// Balances changed by the contract itself are reported, sorted by address, whether or not the call transfers value.
+	for _, value := range []int64{0, 5} {
+		stateDifferences = repeatedCallStateDifferences(testChain, newMessage(withdrawer, value))
+		assert.Len(t, stateDifferences, 2)
+		assert.EqualValues(t, withdrawer, stateDifferences[0].Address)
+		assert.Nil(t, stateDifferences[0].Slot)
+		assert.EqualValues(t, 99+value, stateDifferences[0].Before.Uint64())
+		assert.EqualValues(t, 98+2*value, stateDifferences[0].After.Uint64())
+		assert.EqualValues(t, recipient, stateDifferences[1].Address)
+		assert.EqualValues(t, 1, stateDifferences[1].Before.Uint64())
+		assert.EqualValues(t, 2, stateDifferences[1].After.Uint64())
+	}
+
+	// The chain state is left unchanged.
+	assert.True(t, testChain.State().GetBalance(recipient).IsZero())
+	assert.EqualValues(t, 100, testChain.State().GetBalance(withdrawer).Uint64())
+}
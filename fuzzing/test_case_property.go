@@ -24,6 +24,9 @@ type PropertyTestCase struct {
 	callSequence *calls.CallSequence
 	// propertyTestTrace describes the execution trace when running the callSequence
 	propertyTestTrace *executiontracer.ExecutionTrace
+	// bugId describes the ID of the bug recorded in the bug map when the property failed, if property tests are
+	// registered as oracles of the bug detector.
+	bugId string
 }
 
 // Status describes the TestCaseStatus used to define the current state of the test.
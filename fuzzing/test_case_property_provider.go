@@ -7,9 +7,12 @@ import (
 	"sync"
 
 	"github.com/crytic/medusa-geth/core"
+	"github.com/crytic/medusa/chain"
+	"github.com/crytic/medusa/fuzzing/bugdetector"
 	"github.com/crytic/medusa/fuzzing/calls"
 	"github.com/crytic/medusa/fuzzing/contracts"
 	"github.com/crytic/medusa/fuzzing/executiontracer"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/branchdistance"
 	"golang.org/x/exp/slices"
 )
 
@@ -43,6 +46,10 @@ type propertyTestCaseProviderWorkerState struct {
 
 	// propertyTestMethodsLock is used for thread-synchronization when updating propertyTestMethods
 	propertyTestMethodsLock sync.Mutex
+
+	// branchDistanceTracer is used to trace the branch distances of property test methods, if they guide branch
+	// distances. It is created the first time it is used.
+	branchDistanceTracer *branchdistance.BranchDistanceTracer
 }
 
 // attachPropertyTestCaseProvider attaches a new PropertyTestCaseProvider to the Fuzzer and returns it.
@@ -64,15 +71,21 @@ func attachPropertyTestCaseProvider(fuzzer *Fuzzer) *PropertyTestCaseProvider {
 
 	// Add the provider's call sequence test function to the fuzzer.
 	fuzzer.Hooks.CallSequenceTestFuncs = append(fuzzer.Hooks.CallSequenceTestFuncs, t.callSequencePostCallTest)
+
+	// If property tests guide branch distances, add the provider's call sequence fitness function to the fuzzer.
+	if fuzzer.config.Fuzzing.Testing.PropertyTesting.GuideBranchDistance {
+		fuzzer.Hooks.CallSequenceFitnessFuncs = append(fuzzer.Hooks.CallSequenceFitnessFuncs, t.callSequenceBranchDistanceGuidance)
+	}
 	return t
 }
 
 // checkPropertyTestFailed executes a given property test method to see if it returns a failed status. This is used to
 // facilitate testing of property test methods after every call the Fuzzer makes when testing call sequences.
-// A boolean indicating whether an execution trace should be captured and returned is provided to the method.
+// A boolean indicating whether an execution trace should be captured and returned is provided to the method, along with
+// any additional tracers to attach when an execution trace is not captured.
 // Returns a boolean indicating if the property test failed, an optional execution trace for the property test call,
 // or an error if one occurred.
-func (t *PropertyTestCaseProvider) checkPropertyTestFailed(worker *FuzzerWorker, propertyTestMethod *contracts.DeployedContractMethod, trace bool) (bool, *executiontracer.ExecutionTrace, error) {
+func (t *PropertyTestCaseProvider) checkPropertyTestFailed(worker *FuzzerWorker, propertyTestMethod *contracts.DeployedContractMethod, trace bool, additionalTracers ...*chain.TestChainTracer) (bool, *executiontracer.ExecutionTrace, error) {
 	// Generate our ABI input data for the call. In this case, property test methods take no arguments, so the
 	// variadic argument list here is empty.
 	data, err := propertyTestMethod.Contract.CompiledContract().Abi.Pack(propertyTestMethod.Method.Name)
@@ -91,7 +104,7 @@ func (t *PropertyTestCaseProvider) checkPropertyTestFailed(worker *FuzzerWorker,
 	if trace {
 		executionResult, executionTrace, err = executiontracer.CallWithExecutionTrace(worker.chain, worker.fuzzer.contractDefinitions, msg.ToCoreMessage(), nil, worker.fuzzer.config.Fuzzing.Testing.Verbosity)
 	} else {
-		executionResult, err = worker.Chain().CallContract(msg.ToCoreMessage(), nil)
+		executionResult, err = worker.Chain().CallContract(msg.ToCoreMessage(), nil, additionalTracers...)
 	}
 	if err != nil {
 		return false, nil, fmt.Errorf("failed to call property test method: %v", err)
@@ -335,6 +348,13 @@ func (t *PropertyTestCaseProvider) callSequencePostCallTest(worker *FuzzerWorker
 					testCase.status = TestCaseStatusFailed
 					testCase.callSequence = &shrunkenCallSequence
 					testCase.propertyTestTrace = executionTrace
+					if worker.fuzzer.config.Fuzzing.Testing.PropertyTesting.ReportAsBugs && worker.fuzzer.config.Fuzzing.UseBugDetector() {
+						testCase.bugId = bugdetector.PropertyViolationBugID(workerPropertyTestMethod.Address, workerPropertyTestMethod.Method.Name)
+						_, err = worker.fuzzer.corpus.BugMap().CoverBug(testCase.bugId)
+						if err != nil {
+							return err
+						}
+					}
 					worker.workerMetrics().failedSequences.Add(worker.workerMetrics().failedSequences, big.NewInt(1))
 					worker.Fuzzer().ReportTestCaseFinished(testCase)
 					return nil
@@ -349,3 +369,47 @@ func (t *PropertyTestCaseProvider) callSequencePostCallTest(worker *FuzzerWorker
 
 	return shrinkRequests, nil
 }
+
+// callSequenceBranchDistanceGuidance is a CallSequenceFitnessFunc which traces the branch distances of the property
+// test methods which have not failed yet after every call the Fuzzer makes, and attributes them to the call. The
+// distances to flipping the branches guarding the result of a property test are thereby part of the call's fitness,
+// so calls bringing a property test closer to failing are added to the corpus.
+func (t *PropertyTestCaseProvider) callSequenceBranchDistanceGuidance(worker *FuzzerWorker, callSequence calls.CallSequence) error {
+	// If the branch distances of the call were not traced (e.g. the metric is paused, or the call was not sampled),
+	// there is nothing to attribute property test distances to.
+	lastCall := callSequence[len(callSequence)-1]
+	if worker.branchDistanceTracer == nil || lastCall.ChainReference == nil {
+		return nil
+	}
+	callDistanceMaps := branchdistance.GetBranchDistanceTracerResults(lastCall.ChainReference.MessageResults())
+	if callDistanceMaps == nil {
+		return nil
+	}
+
+	// Obtain the test provider state for this worker, creating its tracer if needed.
+	workerState := &t.workerStates[worker.WorkerIndex()]
+	if workerState.branchDistanceTracer == nil {
+		workerState.branchDistanceTracer = worker.newBranchDistanceTracer()
+	}
+
+	// Loop through all property test methods which have not failed, tracing them and merging their distances.
+	for propertyTestMethodId, workerPropertyTestMethod := range workerState.propertyTestMethods {
+		t.testCasesLock.Lock()
+		testCase := t.testCases[propertyTestMethodId]
+		t.testCasesLock.Unlock()
+		if testCase.Status() == TestCaseStatusFailed {
+			continue
+		}
+
+		workerPropertyTestMethod := workerPropertyTestMethod
+		_, _, err := t.checkPropertyTestFailed(worker, &workerPropertyTestMethod, false, workerState.branchDistanceTracer.NativeTracer())
+		if err != nil {
+			return err
+		}
+		err = workerState.branchDistanceTracer.MergeResults(callDistanceMaps)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
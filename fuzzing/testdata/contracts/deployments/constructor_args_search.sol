@@ -0,0 +1,22 @@
+// This contract is used to test the search for constructor arguments covering the most of the init bytecode.
+contract ConstructorArgsSearch {
+    bool initialized;
+
+    constructor(uint _x, bool _enabled) {
+        require(_x < 1000);
+        if (_enabled) {
+            if (_x % 2 == 0) {
+                initialized = true;
+            }
+        }
+    }
+
+    function property_checkInitialized() public returns (bool) {
+        return !initialized;
+    }
+
+    function dummyFunction(uint a) public {
+        // This exists so the fuzzer knows there are state changing methods to target, instead of quitting early.
+        a = 8;
+    }
+}
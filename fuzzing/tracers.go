@@ -3,82 +3,182 @@ package fuzzing
 import (
 	"github.com/crytic/medusa-geth/common"
 	"github.com/crytic/medusa/chain"
+	"github.com/crytic/medusa/chain/types"
 	"github.com/crytic/medusa/fuzzing/bugdetector"
+	"github.com/crytic/medusa/fuzzing/calls"
+	"github.com/crytic/medusa/fuzzing/config"
+	fuzzerTypes "github.com/crytic/medusa/fuzzing/contracts"
+	"github.com/crytic/medusa/fuzzing/executiontracer"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/balancedelta"
 	"github.com/crytic/medusa/fuzzing/fitnessmetrics/branchcoverage"
 	"github.com/crytic/medusa/fuzzing/fitnessmetrics/branchdistance"
 	cmpdistance "github.com/crytic/medusa/fuzzing/fitnessmetrics/cmpdistance"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/cmplog"
 	codecoverage "github.com/crytic/medusa/fuzzing/fitnessmetrics/codecoverage"
 	"github.com/crytic/medusa/fuzzing/fitnessmetrics/dataflow"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/edgecoverage"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/pathcoverage"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics/selectorcoverage"
 	"github.com/crytic/medusa/fuzzing/fitnessmetrics/storagewrite"
 	"github.com/crytic/medusa/fuzzing/fitnessmetrics/tokenflow"
+	"github.com/crytic/medusa/fuzzing/reverts"
+	"github.com/crytic/medusa/fuzzing/statediff"
 )
 
 func (fw *FuzzerWorker) attachTracersToChain(initializedChain *chain.TestChain) {
-	// attach fitness metric tracers
+	// attach tracers for the metrics which were not paused while fuzzing, restarting the worker once that changes
+	var fuzzingConfig config.FuzzingConfig
+	fuzzingConfig, fw.metricGeneration = fw.fuzzer.runtimeFuzzingConfig()
+
+	// attach fitness metric tracers, which skip the call sequences their metric is saturated for
+	fw.tracedMetrics = make(map[fitnessmetrics.FitnessMetric]bool)
+
+	// tracers which only record a few opcodes receive them through a multiplexer, which dispatches each opcode once to
+	// the tracers handling it, rather than each tracer receiving every opcode
+	multiplexer := chain.NewTestChainTracerMultiplexer()
+
+	// track the storage slots written over each call sequence, for the tracers recording reads of state variables
+	// which were never written
+	var sequenceWrites *dataflow.SequenceWrites
+	if fw.fuzzer.config.Fuzzing.Dataflow.TrackUninitializedReads || (fuzzingConfig.UseBugDetector() && fw.fuzzer.config.Fuzzing.BugDetectionConfig.UninitializedStorageRead) {
+		sequenceWrites = dataflow.NewSequenceWrites()
+		initializedChain.Events.BlocksRemoved.Subscribe(sequenceWrites.OnBlocksRemoved)
+		initializedChain.Events.PendingBlockDiscarded.Subscribe(sequenceWrites.OnPendingBlockDiscarded)
+	}
 
 	// code coverage tracer
-	if fw.fuzzer.config.Fuzzing.FitnessMetricConfig.CodeCoverageEnabled {
-		fw.codeCoverageTracer = codecoverage.NewCoverageTracer(fw.fuzzer.contractDefinitions)
-		initializedChain.AddTracer(fw.codeCoverageTracer.NativeTracer(), true, false)
+	if fuzzingConfig.FitnessMetricConfig.CodeCoverageEnabled {
+		fw.codeCoverageTracer = codecoverage.NewCoverageTracer(fw.fuzzer.metricExclusions.FilterContracts(fw.fuzzer.contractDefinitions))
+		fw.codeCoverageTracer.SetExclusions(fw.fuzzer.metricExclusions)
+		fw.attachMetricTracer(initializedChain, fitnessmetrics.CodeCoverageMetric, fw.codeCoverageTracer.NativeTracer())
 	}
 
 	// branch coverage tracer
-	if fw.fuzzer.config.Fuzzing.FitnessMetricConfig.BranchCoverageEnabled {
-		fw.branchCoverageTracer = branchcoverage.NewCoverageTracer(fw.fuzzer.contractDefinitions)
-		initializedChain.AddTracer(fw.branchCoverageTracer.NativeTracer(), true, false)
+	if fuzzingConfig.FitnessMetricConfig.BranchCoverageEnabled {
+		fw.branchCoverageTracer = branchcoverage.NewCoverageTracer(fw.fuzzer.metricExclusions.FilterContracts(fw.fuzzer.contractDefinitions))
+		fw.branchCoverageTracer.SetExclusions(fw.fuzzer.metricExclusions)
+		fw.branchCoverageTracer.SetContextDepth(fw.fuzzer.config.Fuzzing.BranchCoverage.ContextDepth)
+		fw.branchCoverageTracer.SetDynamicBranchMaps(fw.fuzzer.dynamicBranchCoverageMaps)
+		fw.multiplexMetricTracer(initializedChain, multiplexer, fitnessmetrics.BranchCoverageMetric, fw.branchCoverageTracer)
+	}
+
+	// edge coverage tracer
+	if fuzzingConfig.FitnessMetricConfig.EdgeCoverageEnabled {
+		fw.edgeCoverageTracer = edgecoverage.NewCoverageTracer()
+		fw.attachMetricTracer(initializedChain, fitnessmetrics.EdgeCoverageMetric, fw.edgeCoverageTracer.NativeTracer())
+	}
+
+	// path coverage tracer
+	if fuzzingConfig.FitnessMetricConfig.PathCoverageEnabled {
+		fw.pathCoverageTracer = pathcoverage.NewPathCoverageTracer()
+		fw.multiplexMetricTracer(initializedChain, multiplexer, fitnessmetrics.PathCoverageMetric, fw.pathCoverageTracer)
+	}
+
+	// selector coverage tracer
+	if fuzzingConfig.FitnessMetricConfig.SelectorCoverageEnabled {
+		fw.selectorCoverageTracer = selectorcoverage.NewSelectorCoverageTracer()
+		fw.attachMetricTracer(initializedChain, fitnessmetrics.SelectorCoverageMetric, fw.selectorCoverageTracer.NativeTracer())
 	}
 
 	// cmp distance tracer
-	if fw.fuzzer.config.Fuzzing.FitnessMetricConfig.CmpDistanceEnabled {
+	if fuzzingConfig.FitnessMetricConfig.CmpDistanceEnabled {
 		fw.cmpDistanceTracer = cmpdistance.NewCmpDistanceTracer(fw.fuzzer.contractDefinitions)
-		initializedChain.AddTracer(fw.cmpDistanceTracer.NativeTracer(), true, false)
+		fw.multiplexMetricTracer(initializedChain, multiplexer, fitnessmetrics.CmpDistanceMetric, fw.cmpDistanceTracer)
 	}
 
 	// branch distance tracer
-	if fw.fuzzer.config.Fuzzing.FitnessMetricConfig.BranchDistanceEnabled {
-		fw.branchDistanceTracer = branchdistance.NewBranchDistanceTracer(fw.fuzzer.contractDefinitions)
-		initializedChain.AddTracer(fw.branchDistanceTracer.NativeTracer(), true, false)
+	if fuzzingConfig.FitnessMetricConfig.BranchDistanceEnabled {
+		fw.branchDistanceTracer = fw.newBranchDistanceTracer()
+		branchDistanceTraced := fw.metricTraced(fitnessmetrics.BranchDistanceMetric)
+		initializedChain.AddTracer(chain.NewSampledTestChainTracer(fw.branchDistanceTracer.NativeTracer(), func() bool {
+			return branchDistanceTraced() && fw.sampleHeavyTracers()
+		}), true, false)
 	}
 
 	// data flow tracer
-	if fw.fuzzer.config.Fuzzing.FitnessMetricConfig.DataflowEnabled {
+	if fuzzingConfig.FitnessMetricConfig.DataflowEnabled {
 		fw.dataFlowTracer = dataflow.NewDataflowTracer()
-		initializedChain.AddTracer(fw.dataFlowTracer.NativeTracer(), true, false)
+		fw.dataFlowTracer.SetTrackOutflows(fw.fuzzer.config.Fuzzing.Dataflow.TrackOutflows)
+		if fw.fuzzer.config.Fuzzing.Dataflow.TrackUninitializedReads {
+			fw.dataFlowTracer.SetSequenceWrites(sequenceWrites)
+		}
+		if fw.fuzzer.config.Fuzzing.Dataflow.PersistWrites {
+			fw.dataFlowTracer.SetSeedDataflowSet(fw.fuzzer.corpus.DataflowSet())
+		}
+		fw.attachMetricTracer(initializedChain, fitnessmetrics.DataflowMetric, fw.dataFlowTracer.NativeTracer())
 	}
 
 	// storage write tracer
-	if fw.fuzzer.config.Fuzzing.FitnessMetricConfig.StorageWriteEnabled {
+	if fuzzingConfig.FitnessMetricConfig.StorageWriteEnabled {
 		fw.storageWriteTracer = storagewrite.NewStorageWriteTracer()
-		initializedChain.AddTracer(fw.storageWriteTracer.NativeTracer(), true, false)
+		fw.storageWriteTracer.SetValueBucketer(fw.fuzzer.storageWriteBucketer)
+		fw.multiplexMetricTracer(initializedChain, multiplexer, fitnessmetrics.StorageWriteMetric, fw.storageWriteTracer)
 	}
 
 	// token flow tracer
-	if fw.fuzzer.config.Fuzzing.FitnessMetricConfig.TokenflowEnabled {
+	if fuzzingConfig.FitnessMetricConfig.TokenflowEnabled {
 		fw.tokenflowTracer = tokenflow.NewTokenflowTracer()
-		initializedChain.AddTracer(fw.tokenflowTracer.NativeTracer(), true, false)
+		fw.tokenflowTracer.SetSelectorRegistry(fw.fuzzer.tokenSelectorRegistry)
+		fw.attachMetricTracer(initializedChain, fitnessmetrics.TokenflowMetric, fw.tokenflowTracer.NativeTracer())
+	}
+
+	// balance delta tracer
+	if fuzzingConfig.FitnessMetricConfig.BalanceDeltaEnabled {
+		fw.balanceDeltaTracer = balancedelta.NewBalanceDeltaTracer(fw.fuzzer.balanceDeltaHolders, fw.fuzzer.balanceDeltaTokens)
+		initializedChain.Events.BlocksRemoved.Subscribe(fw.balanceDeltaTracer.OnBlocksRemoved)
+		initializedChain.Events.PendingBlockDiscarded.Subscribe(fw.balanceDeltaTracer.OnPendingBlockDiscarded)
+		fw.attachMetricTracer(initializedChain, fitnessmetrics.BalanceDeltaMetric, fw.balanceDeltaTracer.NativeTracer())
+	}
+
+	// comparison operand log tracer
+	if fw.fuzzer.config.Fuzzing.CmpLog.Enabled {
+		fw.cmpLogTracer = cmplog.NewCmpLogTracer(fw.fuzzer.config.Fuzzing.CmpLog.MaxOperandPairs)
+		initializedChain.AddTracer(fw.cmpLogTracer.NativeTracer(), true, false)
+	}
+
+	// console.log tracer, capturing the strings logged by each call so they can be reported without execution traces
+	if initializedChain.ConsoleLogContract() != nil {
+		initializedChain.AddTracer(executiontracer.NewConsoleLogTracer(initializedChain).NativeTracer(), true, false)
+	}
+
+	// nested call tracer, capturing the result of calls dispatched from call data encoded in the arguments of a call,
+	// so revert metrics can be attributed to them
+	if fw.fuzzer.revertReporter.Enabled {
+		initializedChain.AddTracer(reverts.NewNestedCallTracer().NativeTracer(), true, false)
+	}
+
+	// state diff tracer
+	if fw.fuzzer.config.Fuzzing.StateDiffsEnabled {
+		initializedChain.AddTracer(statediff.NewStateDiffTracer().NativeTracer(), true, false)
 	}
 
 	// attach bug detector
-	if fw.fuzzer.config.Fuzzing.UseBugDetector() {
+	if fuzzingConfig.UseBugDetector() {
 		fw.bugDetectorTracer = bugdetector.NewBugDetectorTracer(FuzzHelperContractAddress, &fw.fuzzer.config.Fuzzing.BugDetectionConfig)
-		initializedChain.AddTracer(fw.bugDetectorTracer.NativeTracer(), true, false)
 
-		// set original ether for ether leaking
-		if fw.fuzzer.config.Fuzzing.BugDetectionConfig.EtherLeaking {
-			fw.bugDetectorTracer.SetOriginalEther(fw.fuzzer.config.Fuzzing.SenderAddressBalances)
+		// Uninitialized storage reads are detected against all writes of the sequence, so it cannot be sampled.
+		if fw.fuzzer.config.Fuzzing.BugDetectionConfig.UninitializedStorageRead {
+			initializedChain.AddTracer(fw.bugDetectorTracer.NativeTracer(), true, false)
+		} else {
+			initializedChain.AddTracer(chain.NewSampledTestChainTracer(fw.bugDetectorTracer.NativeTracer(), fw.sampleHeavyTracers), true, false)
 		}
 
-		if fw.fuzzer.config.Fuzzing.BugDetectionConfig.EtherLeaking || fw.fuzzer.config.Fuzzing.BugDetectionConfig.UnsafeDelegateCall {
-			var ads []common.Address
-			for _, addr := range fw.fuzzer.config.Fuzzing.SenderAddresses {
-				ads = append(ads, common.HexToAddress(addr))
-			}
-			if FuzzHelperContractAddress != common.HexToAddress("0x") {
-				ads = append(ads, FuzzHelperContractAddress)
-			}
+		if fw.fuzzer.config.Fuzzing.BugDetectionConfig.UninitializedStorageRead {
+			fw.bugDetectorTracer.SetSequenceWrites(sequenceWrites)
+		}
 
-			fw.bugDetectorTracer.SetAdversarialAddresses(ads)
+		// set the adversarial addresses of the base chain, and the ether they held, for every detector
+		if baseAdversaries := fw.fuzzer.currentBaseAdversaries(); baseAdversaries != nil {
+			fw.bugDetectorTracer.SetAdversaries(baseAdversaries.addresses, baseAdversaries.originalEther)
 		}
+
+		// treat the values returned by mock contracts as taint sources
+		var mockAddresses []common.Address
+		for address := range initializedChain.MockContracts() {
+			mockAddresses = append(mockAddresses, address)
+		}
+		fw.bugDetectorTracer.SetMockAddresses(mockAddresses)
 	}
 
 	// debug: tracing execution trace
@@ -88,32 +188,174 @@ func (fw *FuzzerWorker) attachTracersToChain(initializedChain *chain.TestChain)
 	// for fair comparison, we need to attach the indicator tracers solely
 
 	// code coverage tracer
-	if fw.fuzzer.config.Fuzzing.MetricRecordConfig.CodeCoverageEnabled {
-		fw.codeCoverageIndicatorTracer = codecoverage.NewCoverageTracer(fw.fuzzer.contractDefinitions)
+	if fuzzingConfig.MetricRecordConfig.CodeCoverageEnabled {
+		fw.codeCoverageIndicatorTracer = codecoverage.NewCoverageTracer(fw.fuzzer.metricExclusions.FilterContracts(fw.fuzzer.contractDefinitions))
+		fw.codeCoverageIndicatorTracer.SetExclusions(fw.fuzzer.metricExclusions)
 		initializedChain.AddTracer(fw.codeCoverageIndicatorTracer.NativeTracer(), true, false)
 	}
 
 	// branch coverage tracer
-	if fw.fuzzer.config.Fuzzing.MetricRecordConfig.BranchCoverageEnabled {
-		fw.branchCoverageIndicatorTracer = branchcoverage.NewCoverageTracer(fw.fuzzer.contractDefinitions)
-		initializedChain.AddTracer(fw.branchCoverageIndicatorTracer.NativeTracer(), true, false)
+	if fuzzingConfig.MetricRecordConfig.BranchCoverageEnabled {
+		fw.branchCoverageIndicatorTracer = branchcoverage.NewCoverageTracer(fw.fuzzer.metricExclusions.FilterContracts(fw.fuzzer.contractDefinitions))
+		fw.branchCoverageIndicatorTracer.SetExclusions(fw.fuzzer.metricExclusions)
+		fw.branchCoverageIndicatorTracer.SetContextDepth(fw.fuzzer.config.Fuzzing.BranchCoverage.ContextDepth)
+		fw.branchCoverageIndicatorTracer.SetDynamicBranchMaps(fw.fuzzer.dynamicBranchCoverageMaps)
+		initializedChain.AddTracer(multiplexer.Multiplex(fw.branchCoverageIndicatorTracer), true, false)
+	}
+
+	// edge coverage tracer
+	if fuzzingConfig.MetricRecordConfig.EdgeCoverageEnabled {
+		fw.edgeCoverageIndicatorTracer = edgecoverage.NewCoverageTracer()
+		initializedChain.AddTracer(fw.edgeCoverageIndicatorTracer.NativeTracer(), true, false)
+	}
+
+	// path coverage tracer
+	if fuzzingConfig.MetricRecordConfig.PathCoverageEnabled {
+		fw.pathCoverageIndicatorTracer = pathcoverage.NewPathCoverageTracer()
+		initializedChain.AddTracer(multiplexer.Multiplex(fw.pathCoverageIndicatorTracer), true, false)
+	}
+
+	// selector coverage tracer
+	if fuzzingConfig.MetricRecordConfig.SelectorCoverageEnabled {
+		fw.selectorCoverageIndicatorTracer = selectorcoverage.NewSelectorCoverageTracer()
+		initializedChain.AddTracer(fw.selectorCoverageIndicatorTracer.NativeTracer(), true, false)
 	}
 
 	// data flow tracer
-	if fw.fuzzer.config.Fuzzing.MetricRecordConfig.DataflowEnabled {
+	if fuzzingConfig.MetricRecordConfig.DataflowEnabled {
 		fw.dataFlowIndicatorTracer = dataflow.NewDataflowTracer()
+		fw.dataFlowIndicatorTracer.SetTrackOutflows(fw.fuzzer.config.Fuzzing.Dataflow.TrackOutflows)
+		if fw.fuzzer.config.Fuzzing.Dataflow.TrackUninitializedReads {
+			fw.dataFlowIndicatorTracer.SetSequenceWrites(sequenceWrites)
+		}
+		if fw.fuzzer.config.Fuzzing.Dataflow.PersistWrites {
+			fw.dataFlowIndicatorTracer.SetSeedDataflowSet(fw.fuzzer.metrics.DataflowSet())
+		}
 		initializedChain.AddTracer(fw.dataFlowIndicatorTracer.NativeTracer(), true, false)
 	}
 
 	// storage write tracer
-	if fw.fuzzer.config.Fuzzing.MetricRecordConfig.StorageWriteEnabled {
+	if fuzzingConfig.MetricRecordConfig.StorageWriteEnabled {
 		fw.storageWriteIndicatorTracer = storagewrite.NewStorageWriteTracer()
-		initializedChain.AddTracer(fw.storageWriteIndicatorTracer.NativeTracer(), true, false)
+		fw.storageWriteIndicatorTracer.SetValueBucketer(fw.fuzzer.storageWriteBucketer)
+		initializedChain.AddTracer(multiplexer.Multiplex(fw.storageWriteIndicatorTracer), true, false)
 	}
 
 	// token flow tracer
-	if fw.fuzzer.config.Fuzzing.MetricRecordConfig.TokenflowEnabled {
+	if fuzzingConfig.MetricRecordConfig.TokenflowEnabled {
 		fw.tokenflowIndicatorTracer = tokenflow.NewTokenflowTracer()
+		fw.tokenflowIndicatorTracer.SetSelectorRegistry(fw.fuzzer.tokenSelectorRegistry)
 		initializedChain.AddTracer(fw.tokenflowIndicatorTracer.NativeTracer(), true, false)
 	}
+
+	// balance delta tracer
+	if fuzzingConfig.MetricRecordConfig.BalanceDeltaEnabled {
+		fw.balanceDeltaIndicatorTracer = balancedelta.NewBalanceDeltaTracer(fw.fuzzer.balanceDeltaHolders, fw.fuzzer.balanceDeltaTokens)
+		initializedChain.Events.BlocksRemoved.Subscribe(fw.balanceDeltaIndicatorTracer.OnBlocksRemoved)
+		initializedChain.Events.PendingBlockDiscarded.Subscribe(fw.balanceDeltaIndicatorTracer.OnPendingBlockDiscarded)
+		initializedChain.AddTracer(fw.balanceDeltaIndicatorTracer.NativeTracer(), true, false)
+	}
+
+	// attach the multiplexer dispatching opcodes to the tracers above
+	if multiplexer.HasHandlers() {
+		initializedChain.AddTracer(multiplexer.NativeTracer(), true, false)
+	}
+
+	// Track the tracers which recycle their results. Tracers which were not attached are nil, which they ignore.
+	fw.resultReleasers = []fitnessmetrics.ResultReleaser{
+		fw.codeCoverageTracer,
+		fw.branchCoverageTracer,
+		fw.edgeCoverageTracer,
+		fw.selectorCoverageTracer,
+		fw.cmpDistanceTracer,
+		fw.branchDistanceTracer,
+		fw.dataFlowTracer,
+		fw.storageWriteTracer,
+		fw.tokenflowTracer,
+		fw.codeCoverageIndicatorTracer,
+		fw.branchCoverageIndicatorTracer,
+		fw.edgeCoverageIndicatorTracer,
+		fw.selectorCoverageIndicatorTracer,
+		fw.dataFlowIndicatorTracer,
+		fw.storageWriteIndicatorTracer,
+		fw.tokenflowIndicatorTracer,
+	}
+}
+
+// metricTraced registers the provided fitness metric as traced, and returns a sampling function determining whether
+// its tracer traces the transactions of the current call sequence, as decided by updateTracedMetrics.
+func (fw *FuzzerWorker) metricTraced(metric fitnessmetrics.FitnessMetric) func() bool {
+	fw.tracedMetrics[metric] = fw.fuzzer.corpus.SaturationMonitor().Enabled(metric)
+	return func() bool {
+		return fw.tracedMetrics[metric]
+	}
+}
+
+// attachMetricTracer attaches the provided tracer of a fitness metric to the chain. If saturation detection is
+// enabled, it only traces the call sequences its metric is not saturated for.
+func (fw *FuzzerWorker) attachMetricTracer(initializedChain *chain.TestChain, metric fitnessmetrics.FitnessMetric, tracer *chain.TestChainTracer) {
+	if fw.fuzzer.config.Fuzzing.FitnessMetricConfig.Saturation.Enabled {
+		tracer = chain.NewSampledTestChainTracer(tracer, fw.metricTraced(metric))
+	}
+	initializedChain.AddTracer(tracer, true, false)
+}
+
+// multiplexMetricTracer attaches the provided tracer of a fitness metric to the chain, dispatching its opcodes through
+// the provided multiplexer. If saturation detection is enabled, it only traces the call sequences its metric is not
+// saturated for.
+func (fw *FuzzerWorker) multiplexMetricTracer(initializedChain *chain.TestChain, multiplexer *chain.TestChainTracerMultiplexer, metric fitnessmetrics.FitnessMetric, tracer chain.OpcodeStepTracer) {
+	if fw.fuzzer.config.Fuzzing.FitnessMetricConfig.Saturation.Enabled {
+		initializedChain.AddTracer(multiplexer.MultiplexSampled(tracer, fw.metricTraced(metric)), true, false)
+	} else {
+		initializedChain.AddTracer(multiplexer.Multiplex(tracer), true, false)
+	}
+}
+
+// updateTracedMetrics decides which fitness metric tracers trace the next call sequence, skipping the metrics which
+// saturated, and re-probing them once the saturation monitor's re-probe interval elapsed. It is called before each
+// call sequence, so saturation takes effect without restarting the worker.
+func (fw *FuzzerWorker) updateTracedMetrics() {
+	saturationMonitor := fw.fuzzer.corpus.SaturationMonitor()
+	for metric := range fw.tracedMetrics {
+		fw.tracedMetrics[metric] = saturationMonitor.Enabled(metric)
+	}
+}
+
+// newBranchDistanceTracer creates a branch distance tracer for the contracts whose fitness metrics are traced,
+// directed towards the targets of the target-directed mode, if any.
+func (fw *FuzzerWorker) newBranchDistanceTracer() *branchdistance.BranchDistanceTracer {
+	tracer := branchdistance.NewBranchDistanceTracer(fw.fuzzer.metricExclusions.FilterContracts(fw.fuzzer.contractDefinitions), fw.fuzzer.config.Fuzzing.BranchDistance, fw.fuzzer.directedTargetPcs)
+	tracer.SetExclusions(fw.fuzzer.metricExclusions)
+	tracer.SetDynamicBranchMaps(fw.fuzzer.dynamicBranchDistanceMaps)
+	tracer.SetBackPropagationFailures(fw.fuzzer.metrics.branchDistanceBackPropagationFailures)
+	return tracer
+}
+
+// registerDeployedContractCoverage registers the code of a contract created on the worker's chain, which was matched
+// to the provided contract definition, with the coverage tracers which record coverage by contract definition.
+func (fw *FuzzerWorker) registerDeployedContractCoverage(contract *fuzzerTypes.Contract, deployedContract *types.DeployedContractBytecode) {
+	fw.codeCoverageTracer.RegisterDeployedContract(contract, deployedContract.InitBytecode, deployedContract.RuntimeBytecode)
+	fw.branchCoverageTracer.RegisterDeployedContract(contract, deployedContract.InitBytecode, deployedContract.RuntimeBytecode)
+	fw.codeCoverageIndicatorTracer.RegisterDeployedContract(contract, deployedContract.InitBytecode, deployedContract.RuntimeBytecode)
+	fw.branchCoverageIndicatorTracer.RegisterDeployedContract(contract, deployedContract.InitBytecode, deployedContract.RuntimeBytecode)
+}
+
+// releaseTracerResults releases the results recorded by tracers for the provided call sequence element, so that they
+// are reused for later calls. The results are removed from the element's message results, so they must no longer be
+// needed once released.
+func (fw *FuzzerWorker) releaseTracerResults(element *calls.CallSequenceElement) {
+	if element == nil || element.ChainReference == nil {
+		return
+	}
+	messageResults := element.ChainReference.MessageResults()
+	for _, releaser := range fw.resultReleasers {
+		releaser.ReleaseResults(messageResults)
+	}
+}
+
+// sampleHeavyTracers decides whether heavy tracers attached with tracer sampling should trace the next transaction.
+// Returns true if it is traced in full, or if it was sampled with the configured probability.
+func (fw *FuzzerWorker) sampleHeavyTracers() bool {
+	probability := fw.fuzzer.config.Fuzzing.TracerSampling.Probability
+	return fw.heavyTracersForced || probability >= 1 || fw.randomProvider.Float32() < probability
 }
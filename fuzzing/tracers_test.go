@@ -0,0 +1,51 @@
+package fuzzing
+
+import (
+	"testing"
+
+	"github.com/crytic/medusa/fuzzing/config"
+	fuzzingCorpus "github.com/crytic/medusa/fuzzing/corpus"
+	"github.com/crytic/medusa/fuzzing/fitnessmetrics"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestUpdateTracedMetrics ensures fitness metric tracers stop tracing from the call sequence following their metric's
+// saturation, without the worker's chain being rebuilt, and trace again once their metric is re-probed.
+func TestUpdateTracedMetrics(t *testing.T) {
+	tests := []struct {
+		reprobeInterval uint64
+		reprobed        bool
+	}{
+		{reprobeInterval: 3600, reprobed: false},
+		{reprobeInterval: 0, reprobed: true},
+	}
+	for _, test := range tests {
+		projectConfig, err := config.GetDefaultProjectConfig("")
+		assert.NoError(t, err)
+		projectConfig.Fuzzing.FitnessMetricConfig.Saturation = config.FitnessMetricSaturationConfig{
+			Enabled:         true,
+			Window:          1,
+			MinNewItems:     1,
+			ReprobeInterval: test.reprobeInterval,
+		}
+		corpus, err := fuzzingCorpus.NewCorpus("", &projectConfig.Fuzzing)
+		assert.NoError(t, err)
+		worker := &FuzzerWorker{
+			fuzzer:        &Fuzzer{config: *projectConfig, corpus: corpus},
+			tracedMetrics: make(map[fitnessmetrics.FitnessMetric]bool),
+		}
+		codeCoverageTraced := worker.metricTraced(fitnessmetrics.CodeCoverageMetric)
+		branchCoverageTraced := worker.metricTraced(fitnessmetrics.BranchCoverageMetric)
+		assert.True(t, codeCoverageTraced())
+		assert.True(t, branchCoverageTraced())
+
+		// Saturating a metric does not affect the call sequence being traced.
+		corpus.SaturationMonitor().Record(fitnessmetrics.CodeCoverageMetric, true, false)
+		assert.True(t, codeCoverageTraced())
+
+		// The next call sequence is only traced by the metrics which did not saturate, unless re-probed.
+		worker.updateTracedMetrics()
+		assert.EqualValues(t, test.reprobed, codeCoverageTraced())
+		assert.True(t, branchCoverageTraced())
+	}
+}
@@ -291,6 +291,10 @@ func TestABIGenerationAndMutation(t *testing.T) {
 
 			// Verify the types of the value and mutated value are the same
 			assert.EqualValues(t, reflect.ValueOf(value).Type().String(), reflect.ValueOf(mutatedValue).Type().String())
+
+			// Verify the mutated value still has a valid ABI encoding
+			_, err = abi.Arguments{arg}.Pack(mutatedValue)
+			assert.NoError(t, err)
 		}
 	}
 }
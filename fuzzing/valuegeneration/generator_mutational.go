@@ -19,6 +19,14 @@ type MutationalValueGenerator struct {
 	// operations.
 	valueSet *ValueSet
 
+	// operandDictionary describes the dictionary of harvested comparison operands and hash preimages which values may
+	// be sampled from, or nil if none is used.
+	operandDictionary *OperandDictionary
+
+	// dictionaryContract describes the address of the contract being called, whose operandDictionary values are
+	// sampled.
+	dictionaryContract common.Address
+
 	// RandomValueGenerator is included to inherit from the random generator
 	*RandomValueGenerator
 }
@@ -79,6 +87,11 @@ type MutationalValueGeneratorConfig struct {
 	// it is done so by being replaced with a newly generated one instead. Value range is [0.0, 1.0].
 	MutateIntegerGenerateNewBias float32
 
+	// DictionaryValueProbability defines the probability in which an integer, byte array or address generated (or
+	// mutated) by the value generator is sampled from the operand dictionary of the contract being called, if one is
+	// set. Value range is [0.0, 1.0].
+	DictionaryValueProbability float32
+
 	// RandomValueGeneratorConfig is adhered to in this structure, to power the underlying RandomValueGenerator.
 	*RandomValueGeneratorConfig
 }
@@ -101,6 +114,64 @@ func NewMutationalValueGenerator(config *MutationalValueGeneratorConfig, valueSe
 	return generator
 }
 
+// SetOperandDictionary sets the OperandDictionary values may be sampled from, as configured by the
+// DictionaryValueProbability.
+func (g *MutationalValueGenerator) SetOperandDictionary(operandDictionary *OperandDictionary) {
+	g.operandDictionary = operandDictionary
+}
+
+// SetDictionaryContract sets the address of the contract being called, whose OperandDictionary values are sampled.
+func (g *MutationalValueGenerator) SetDictionaryContract(contract common.Address) {
+	g.dictionaryContract = contract
+}
+
+// dictionaryInteger decides whether a value should be sampled from the OperandDictionary, according to the
+// DictionaryValueProbability, and samples an integer if so.
+// Returns the sampled integer, or nil if none was sampled.
+func (g *MutationalValueGenerator) dictionaryInteger() *big.Int {
+	if g.operandDictionary == nil || g.randomProvider.Float32() >= g.config.DictionaryValueProbability {
+		return nil
+	}
+	return g.operandDictionary.RandomInteger(g.dictionaryContract, g.randomProvider.Intn)
+}
+
+// dictionaryBytes decides whether a value should be sampled from the OperandDictionary, according to the
+// DictionaryValueProbability, and samples a byte array if so. Byte values (e.g. hash preimages) and integer values
+// (as 32-byte words) are sampled evenly. If a positive length is provided, the value is truncated or right-padded with
+// zeros to it.
+// Returns the sampled byte array, or nil if none was sampled.
+func (g *MutationalValueGenerator) dictionaryBytes(length int) []byte {
+	if g.operandDictionary == nil || g.randomProvider.Float32() >= g.config.DictionaryValueProbability {
+		return nil
+	}
+	var b []byte
+	if g.randomProvider.Intn(2) == 0 {
+		b = g.operandDictionary.RandomBytes(g.dictionaryContract, g.randomProvider.Intn)
+	}
+	if b == nil {
+		if i := g.operandDictionary.RandomInteger(g.dictionaryContract, g.randomProvider.Intn); i != nil {
+			b = common.BigToHash(i).Bytes()
+		}
+	}
+	if b == nil || length <= 0 {
+		return b
+	}
+	fixed := make([]byte, length)
+	copy(fixed, b)
+	return fixed
+}
+
+// dictionaryAddress decides whether a value should be sampled from the OperandDictionary, according to the
+// DictionaryValueProbability, and samples an address from the integers which fit in one if so.
+// Returns the sampled address, and a boolean indicating whether one was sampled.
+func (g *MutationalValueGenerator) dictionaryAddress() (common.Address, bool) {
+	i := g.dictionaryInteger()
+	if i == nil || i.Sign() <= 0 || i.BitLen() > common.AddressLength*8 {
+		return common.Address{}, false
+	}
+	return common.BigToAddress(i), true
+}
+
 // getMutationParams takes a length of inputs and returns an initial input index to start with as a base value, as well
 // as a random number of mutations which should be performed (within the mutation range specified by the
 // MutationalValueGeneratorConfig).
@@ -156,6 +227,12 @@ func (g *MutationalValueGenerator) mutateIntegerInternal(i *big.Int, signed bool
 	// Calculate our integer bounds
 	min, max := utils.GetIntegerConstraints(signed, bitLength)
 
+	// If our probability directs us to, use a harvested operand of the contract being called instead. Operands are
+	// unsigned words, so they wrap around to negative values for signed integers.
+	if operand := g.dictionaryInteger(); operand != nil {
+		return utils.ConstrainIntegerToBounds(operand, min, max)
+	}
+
 	// Obtain our inputs. We also add our min/max values for this range to the list of inputs.
 	// Note: We exclude min being added if we're requesting an unsigned integer, as zero is already
 	// in our set, and we don't want duplicates.
@@ -243,6 +320,15 @@ var bytesMutationMethods = []func(*MutationalValueGenerator, []byte, ...[]byte)
 		i := g.randomProvider.Intn(len(b))
 		return append(b[:i], b[i+1:]...)
 	},
+	// Resize to a boundary length around the 32-byte word size, padding with zeros or truncating
+	func(g *MutationalValueGenerator, b []byte, inputs ...[]byte) []byte {
+		lengths := []int{0, 1, 31, 32, 33, 64}
+		length := lengths[g.randomProvider.Intn(len(lengths))]
+		if length <= len(b) {
+			return b[:length]
+		}
+		return append(b, make([]byte, length-len(b))...)
+	},
 }
 
 // mutateBytesInternal takes a byte array and a length. This function returns either a fixed length byte array (based on
@@ -250,10 +336,15 @@ var bytesMutationMethods = []func(*MutationalValueGenerator, []byte, ...[]byte)
 // the provided input.
 // If a nil input is provided, this method uses an existing base value set value as the starting point for mutation.
 func (g *MutationalValueGenerator) mutateBytesInternal(b []byte, length int) []byte {
-	// If we have no inputs or our bias directs us to, use the random generator instead
+	// If our probability directs us to, use a harvested value of the contract being called instead.
+	if dictionaryValue := g.dictionaryBytes(length); dictionaryValue != nil {
+		return dictionaryValue
+	}
+
+	// If we have no inputs to start from or our bias directs us to, use the random generator instead
 	inputs := g.valueSet.Bytes()
 	randomGeneratorDecision := g.randomProvider.Float32()
-	if len(inputs) == 0 || randomGeneratorDecision < g.config.GenerateRandomBytesBias {
+	if (len(inputs) == 0 && b == nil) || randomGeneratorDecision < g.config.GenerateRandomBytesBias {
 		// If the length is non-zero, generate a fixed byte array
 		if length > 0 {
 			return g.RandomValueGenerator.GenerateFixedBytes(length)
@@ -263,7 +354,7 @@ func (g *MutationalValueGenerator) mutateBytesInternal(b []byte, length int) []b
 	}
 
 	// Determine which value we'll use as an initial input, and how many mutations we will perform.
-	inputIdx, mutationCount := g.getMutationParams(len(inputs))
+	inputIdx, mutationCount := g.getMutationParams(max(len(inputs), 1))
 	var input []byte
 	if b != nil {
 		input = slices.Clone(b)
@@ -385,6 +476,11 @@ func (g *MutationalValueGenerator) GenerateAddress() common.Address {
 		return g.RandomValueGenerator.GenerateAddress()
 	}
 
+	// If our probability directs us to, use a harvested operand of the contract being called instead.
+	if address, ok := g.dictionaryAddress(); ok {
+		return address
+	}
+
 	// Obtain our addresses from our value set. If we have none, generate a random one instead.
 	addresses := g.valueSet.Addresses()
 	if len(addresses) == 0 {
@@ -401,11 +497,73 @@ func (g *MutationalValueGenerator) MutateAddress(addr common.Address) common.Add
 	// Determine whether to perform mutations against this input or just return it as-is.
 	randomGeneratorDecision := g.randomProvider.Float32()
 	if randomGeneratorDecision < g.config.MutateAddressProbability {
+		if address, ok := g.dictionaryAddress(); ok {
+			return address
+		}
 		return g.RandomValueGenerator.GenerateAddress()
 	}
 	return addr
 }
 
+// arrayMutationMethods define methods which take the elements of an array and transform its structure, keeping its
+// length if it is fixed. Elements set to nil are generated new by the value generator. This is used in a loop to mutate
+// array structures.
+var arrayMutationMethods = []func(g *MutationalValueGenerator, value []any, fixedLength bool) []any{
+	// Swap two random elements
+	func(g *MutationalValueGenerator, value []any, fixedLength bool) []any {
+		if len(value) < 2 {
+			return value
+		}
+		i, j := g.randomProvider.Intn(len(value)), g.randomProvider.Intn(len(value))
+		value[i], value[j] = value[j], value[i]
+		return value
+	},
+	// Overwrite a random element with a copy of another
+	func(g *MutationalValueGenerator, value []any, fixedLength bool) []any {
+		if len(value) < 2 {
+			return value
+		}
+		value[g.randomProvider.Intn(len(value))] = value[g.randomProvider.Intn(len(value))]
+		return value
+	},
+	// Regenerate a random element
+	func(g *MutationalValueGenerator, value []any, fixedLength bool) []any {
+		if len(value) == 0 {
+			return value
+		}
+		value[g.randomProvider.Intn(len(value))] = nil
+		return value
+	},
+	// Insert a newly generated element at a random position
+	func(g *MutationalValueGenerator, value []any, fixedLength bool) []any {
+		if fixedLength {
+			return value
+		}
+		return slices.Insert(value, g.randomProvider.Intn(len(value)+1), nil)
+	},
+	// Remove a random element
+	func(g *MutationalValueGenerator, value []any, fixedLength bool) []any {
+		if fixedLength || len(value) == 0 {
+			return value
+		}
+		i := g.randomProvider.Intn(len(value))
+		return slices.Delete(value, i, i+1)
+	},
+	// Resize the array to a boundary length (empty, a single element, or the maximum generated length), generating
+	// any new elements
+	func(g *MutationalValueGenerator, value []any, fixedLength bool) []any {
+		if fixedLength {
+			return value
+		}
+		lengths := []int{0, 1, g.config.GenerateRandomArrayMaxSize}
+		length := lengths[g.randomProvider.Intn(len(lengths))]
+		if length <= len(value) {
+			return value[:length]
+		}
+		return append(value, make([]any, length-len(value))...)
+	},
+}
+
 // MutateArray takes a dynamic or fixed sized array as input, and returns a mutated value based off of the input.
 // Returns the mutated value. If any element of the returned array is nil, the value generator will be called upon
 // to generate it new.
@@ -416,7 +574,7 @@ func (g *MutationalValueGenerator) MutateArray(value []any, fixedLength bool) []
 		// Determine how many mutations we'll apply
 		_, mutationCount := g.getMutationParams(1)
 		for i := 0; i < mutationCount; i++ {
-			// TODO: Apply array structure mutations (swap, insert, delete)
+			value = arrayMutationMethods[g.randomProvider.Intn(len(arrayMutationMethods))](g, value, fixedLength)
 		}
 		return value
 	}
@@ -0,0 +1,140 @@
+package valuegeneration
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/crytic/medusa-geth/common"
+)
+
+// contractOperands describes the values harvested for a single contract in an OperandDictionary.
+type contractOperands struct {
+	// integers describes the harvested integer values, e.g. comparison operands.
+	integers []*big.Int
+	// nextInteger describes the index of integers to replace next once it is full.
+	nextInteger int
+	// bytes describes the harvested byte values, e.g. hash preimages.
+	bytes [][]byte
+	// nextBytes describes the index of bytes to replace next once it is full.
+	nextBytes int
+	// seen is used to deduplicate harvested values, keyed by their string representation.
+	seen map[string]struct{}
+}
+
+// OperandDictionary describes a dynamic, per-contract dictionary of concrete values harvested during fuzzing, such as
+// the operands of comparisons and the preimages of hashes computed by the contract. Unlike a ValueSet, it persists
+// across call sequences and is shared between workers, so that magic constants a contract checks its inputs against
+// can be sampled when generating arguments for calls to it. Each contract retains up to a maximum amount of integer
+// and byte values, the oldest being replaced first.
+type OperandDictionary struct {
+	// contracts describes the values harvested for each contract, keyed by the address of the contract called.
+	contracts map[common.Address]*contractOperands
+
+	// maxEntries describes the maximum amount of integer and byte values retained per contract.
+	maxEntries int
+
+	// lock offers concurrent thread safety for value accesses.
+	lock sync.RWMutex
+}
+
+// NewOperandDictionary creates a new, empty OperandDictionary retaining up to the provided amount of integer and byte
+// values per contract.
+func NewOperandDictionary(maxEntries int) *OperandDictionary {
+	return &OperandDictionary{
+		contracts:  make(map[common.Address]*contractOperands),
+		maxEntries: maxEntries,
+	}
+}
+
+// contractOperands returns the values harvested for the provided contract, creating them if they do not exist. The
+// write lock must be held.
+func (d *OperandDictionary) contractOperands(contract common.Address) *contractOperands {
+	operands, exists := d.contracts[contract]
+	if !exists {
+		operands = &contractOperands{
+			integers: make([]*big.Int, 0),
+			bytes:    make([][]byte, 0),
+			seen:     make(map[string]struct{}),
+		}
+		d.contracts[contract] = operands
+	}
+	return operands
+}
+
+// AddInteger adds the provided integer to the dictionary of the provided contract, unless it already holds it.
+func (d *OperandDictionary) AddInteger(contract common.Address, value *big.Int) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	operands := d.contractOperands(contract)
+	key := "i" + value.String()
+	if _, exists := operands.seen[key]; exists || d.maxEntries <= 0 {
+		return
+	}
+	operands.seen[key] = struct{}{}
+	if len(operands.integers) < d.maxEntries {
+		operands.integers = append(operands.integers, new(big.Int).Set(value))
+		return
+	}
+	delete(operands.seen, "i"+operands.integers[operands.nextInteger].String())
+	operands.integers[operands.nextInteger] = new(big.Int).Set(value)
+	operands.nextInteger = (operands.nextInteger + 1) % d.maxEntries
+}
+
+// AddBytes adds the provided bytes to the dictionary of the provided contract, unless it already holds them.
+func (d *OperandDictionary) AddBytes(contract common.Address, value []byte) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	operands := d.contractOperands(contract)
+	key := "b" + string(value)
+	if _, exists := operands.seen[key]; exists || d.maxEntries <= 0 {
+		return
+	}
+	operands.seen[key] = struct{}{}
+	if len(operands.bytes) < d.maxEntries {
+		operands.bytes = append(operands.bytes, common.CopyBytes(value))
+		return
+	}
+	delete(operands.seen, "b"+string(operands.bytes[operands.nextBytes]))
+	operands.bytes[operands.nextBytes] = common.CopyBytes(value)
+	operands.nextBytes = (operands.nextBytes + 1) % d.maxEntries
+}
+
+// RandomInteger returns a copy of a random integer harvested for the provided contract, using the provided function
+// to choose an index below the provided bound. Returns nil if no integers were harvested for the contract.
+func (d *OperandDictionary) RandomInteger(contract common.Address, intn func(int) int) *big.Int {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	operands, exists := d.contracts[contract]
+	if !exists || len(operands.integers) == 0 {
+		return nil
+	}
+	return new(big.Int).Set(operands.integers[intn(len(operands.integers))])
+}
+
+// RandomBytes returns a copy of random bytes harvested for the provided contract, using the provided function to
+// choose an index below the provided bound. Returns nil if no bytes were harvested for the contract.
+func (d *OperandDictionary) RandomBytes(contract common.Address, intn func(int) int) []byte {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	operands, exists := d.contracts[contract]
+	if !exists || len(operands.bytes) == 0 {
+		return nil
+	}
+	return common.CopyBytes(operands.bytes[intn(len(operands.bytes))])
+}
+
+// Size returns the total amount of integer and byte values harvested across all contracts.
+func (d *OperandDictionary) Size() int {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	size := 0
+	for _, operands := range d.contracts {
+		size += len(operands.integers) + len(operands.bytes)
+	}
+	return size
+}
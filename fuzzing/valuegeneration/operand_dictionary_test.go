@@ -0,0 +1,41 @@
+package valuegeneration
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestOperandDictionary tests that an OperandDictionary keeps values per contract, deduplicates them, and replaces the
+// oldest values once full.
+func TestOperandDictionary(t *testing.T) {
+	dictionary := NewOperandDictionary(2)
+	contract := common.HexToAddress("0x1234")
+	otherContract := common.HexToAddress("0x5678")
+	first := func(int) int { return 0 }
+
+	// No values are sampled for contracts without any.
+	assert.Nil(t, dictionary.RandomInteger(contract, first))
+	assert.Nil(t, dictionary.RandomBytes(contract, first))
+
+	// Duplicates are ignored, and values are kept apart per contract.
+	dictionary.AddInteger(contract, big.NewInt(7))
+	dictionary.AddInteger(contract, big.NewInt(7))
+	dictionary.AddBytes(contract, []byte{1, 2})
+	assert.EqualValues(t, 2, dictionary.Size())
+	assert.EqualValues(t, big.NewInt(7), dictionary.RandomInteger(contract, first))
+	assert.EqualValues(t, []byte{1, 2}, dictionary.RandomBytes(contract, first))
+	assert.Nil(t, dictionary.RandomInteger(otherContract, first))
+
+	// Once full, the oldest values are replaced first.
+	dictionary.AddInteger(contract, big.NewInt(8))
+	dictionary.AddInteger(contract, big.NewInt(9))
+	assert.EqualValues(t, 3, dictionary.Size())
+	assert.EqualValues(t, big.NewInt(9), dictionary.RandomInteger(contract, first))
+
+	// Replaced values can be added again.
+	dictionary.AddInteger(contract, big.NewInt(7))
+	assert.EqualValues(t, big.NewInt(7), dictionary.RandomInteger(contract, func(int) int { return 1 }))
+}
@@ -1,39 +1,108 @@
 package valuegeneration
 
 import (
+	"bytes"
 	"encoding/hex"
 	"hash"
 	"math/big"
 	"reflect"
+	"slices"
+	"strings"
 
 	"github.com/crytic/medusa/utils/reflectionutils"
 
 	"github.com/crytic/medusa-geth/common"
 	"golang.org/x/crypto/sha3"
-	"golang.org/x/exp/maps"
 )
 
+// orderedValues represents a set of values indexed by unique keys, which are kept ordered as values are added and
+// removed, so the values can be enumerated in a deterministic order without being sorted upon every enumeration.
+type orderedValues[K any, V any] struct {
+	// keys describes the keys of the values, in ascending order.
+	keys []K
+	// values describes the values, in the order of their keys.
+	values []V
+	// compare describes the function used to order keys.
+	compare func(a, b K) int
+}
+
+// newOrderedValues creates an empty orderedValues whose keys are ordered by the provided comparison function.
+func newOrderedValues[K any, V any](compare func(a, b K) int) *orderedValues[K, V] {
+	return &orderedValues[K, V]{
+		keys:    make([]K, 0),
+		values:  make([]V, 0),
+		compare: compare,
+	}
+}
+
+// clone creates a copy of the orderedValues.
+func (o *orderedValues[K, V]) clone() *orderedValues[K, V] {
+	return &orderedValues[K, V]{
+		keys:    slices.Clone(o.keys),
+		values:  slices.Clone(o.values),
+		compare: o.compare,
+	}
+}
+
+// set adds the value with the provided key, replacing any value with the same key.
+func (o *orderedValues[K, V]) set(key K, value V) {
+	i, found := slices.BinarySearchFunc(o.keys, key, o.compare)
+	if found {
+		o.values[i] = value
+		return
+	}
+	o.keys = slices.Insert(o.keys, i, key)
+	o.values = slices.Insert(o.values, i, value)
+}
+
+// contains checks if a value with the provided key exists.
+func (o *orderedValues[K, V]) contains(key K) bool {
+	_, found := slices.BinarySearchFunc(o.keys, key, o.compare)
+	return found
+}
+
+// remove removes the value with the provided key, if any.
+func (o *orderedValues[K, V]) remove(key K) {
+	i, found := slices.BinarySearchFunc(o.keys, key, o.compare)
+	if found {
+		o.keys = slices.Delete(o.keys, i, i+1)
+		o.values = slices.Delete(o.values, i, i+1)
+	}
+}
+
+// list returns the values in the order of their keys. The returned slice is shared with the orderedValues, so it must
+// not be modified, but it can be appended to without affecting the orderedValues.
+func (o *orderedValues[K, V]) list() []V {
+	return slices.Clip(o.values)
+}
+
 // ValueSet represents potential values of significance within the source code to be used in fuzz tests.
 type ValueSet struct {
-	// addresses represents a set of common.Address to use in fuzz tests. A mapping is used to avoid duplicates.
-	addresses map[common.Address]any
-	// integers represents a set of integers to use in fuzz tests. A mapping is used to avoid duplicates.
-	integers map[string]*big.Int
-	// strings represents a set of strings to use in fuzz tests. A mapping is used to avoid duplicates.
-	strings map[string]any
-	// bytes represents a set of bytes to use in fuzz tests. A mapping is used to avoid duplicates.
-	bytes map[string][]byte
+	// addresses represents a set of common.Address to use in fuzz tests, ordered so they can be enumerated
+	// deterministically.
+	addresses *orderedValues[common.Address, common.Address]
+	// integers represents a set of integers to use in fuzz tests, ordered by their string representation.
+	integers *orderedValues[string, *big.Int]
+	// strings represents a set of strings to use in fuzz tests, ordered so they can be enumerated deterministically.
+	strings *orderedValues[string, string]
+	// bytes represents a set of bytes to use in fuzz tests, ordered by their hash.
+	bytes *orderedValues[string, []byte]
 	// hashProvider represents a hash provider used to create keys for some data.
 	hashProvider hash.Hash
 }
 
+// compareAddresses orders addresses by their bytes.
+func compareAddresses(a, b common.Address) int {
+	return bytes.Compare(a[:], b[:])
+}
+
 // NewValueSet initializes a new ValueSet object for use with a Fuzzer.
 func NewValueSet() *ValueSet {
 	baseValueSet := &ValueSet{
-		addresses:    make(map[common.Address]any, 0),
-		integers:     make(map[string]*big.Int, 0),
-		strings:      make(map[string]any, 0),
-		bytes:        make(map[string][]byte, 0),
+		addresses:    newOrderedValues[common.Address, common.Address](compareAddresses),
+		integers:     newOrderedValues[string, *big.Int](strings.Compare),
+		strings:      newOrderedValues[string, string](strings.Compare),
+		bytes:        newOrderedValues[string, []byte](strings.Compare),
 		hashProvider: sha3.NewLegacyKeccak256(),
 	}
 	return baseValueSet
@@ -42,105 +111,82 @@ func NewValueSet() *ValueSet {
 // Clone creates a copy of the current ValueSet.
 func (vs *ValueSet) Clone() *ValueSet {
 	baseValueSet := &ValueSet{
-		addresses:    maps.Clone(vs.addresses),
-		integers:     maps.Clone(vs.integers),
-		strings:      maps.Clone(vs.strings),
-		bytes:        maps.Clone(vs.bytes),
+		addresses:    vs.addresses.clone(),
+		integers:     vs.integers.clone(),
+		strings:      vs.strings.clone(),
+		bytes:        vs.bytes.clone(),
 		hashProvider: sha3.NewLegacyKeccak256(),
 	}
 	return baseValueSet
 }
 
-// Addresses returns a list of addresses contained within the set.
+// Addresses returns a list of addresses contained within the set, in a deterministic order. The returned slice must
+// not be modified.
 func (vs *ValueSet) Addresses() []common.Address {
-	res := make([]common.Address, len(vs.addresses))
-	count := 0
-	for k := range vs.addresses {
-		res[count] = k
-		count++
-	}
-	return res
+	return vs.addresses.list()
 }
 
 // AddAddress adds an address item to the ValueSet.
 func (vs *ValueSet) AddAddress(a common.Address) {
-	vs.addresses[a] = nil
+	vs.addresses.set(a, a)
 }
 
 // ContainsAddress checks if an address is contained in the ValueSet.
 func (vs *ValueSet) ContainsAddress(a common.Address) bool {
-	_, contains := vs.addresses[a]
-	return contains
+	return vs.addresses.contains(a)
 }
 
 // RemoveAddress removes an address item from the ValueSet.
 func (vs *ValueSet) RemoveAddress(a common.Address) {
-	delete(vs.addresses, a)
+	vs.addresses.remove(a)
 }
 
-// Integers returns a list of integers contained within the set.
+// Integers returns a list of integers contained within the set, in a deterministic order. The returned slice must
+// not be modified.
 func (vs *ValueSet) Integers() []*big.Int {
-	res := make([]*big.Int, len(vs.integers))
-	count := 0
-	for _, v := range vs.integers {
-		res[count] = v
-		count++
-	}
-	return res
+	return vs.integers.list()
 }
 
 // AddInteger adds an integer item to the ValueSet.
 func (vs *ValueSet) AddInteger(b *big.Int) {
-	vs.integers[b.String()] = b
+	vs.integers.set(b.String(), b)
 }
 
 // ContainsInteger checks if an integer is contained in the ValueSet.
 func (vs *ValueSet) ContainsInteger(b *big.Int) bool {
-	_, contains := vs.integers[b.String()]
-	return contains
+	return vs.integers.contains(b.String())
 }
 
 // RemoveInteger removes an integer item from the ValueSet.
 func (vs *ValueSet) RemoveInteger(b *big.Int) {
-	delete(vs.integers, b.String())
+	vs.integers.remove(b.String())
 }
 
-// Strings returns a list of strings contained within the set.
+// Strings returns a list of strings contained within the set, in a deterministic order. The returned slice must not
+// be modified.
 func (vs *ValueSet) Strings() []string {
-	res := make([]string, len(vs.strings))
-	count := 0
-	for k := range vs.strings {
-		res[count] = k
-		count++
-	}
-	return res
+	return vs.strings.list()
 }
 
 // AddString adds a string item to the ValueSet.
 func (vs *ValueSet) AddString(s string) {
-	vs.strings[s] = nil
+	vs.strings.set(s, s)
 }
 
 // ContainsString checks if a string is contained in the ValueSet.
 func (vs *ValueSet) ContainsString(s string) bool {
-	_, contains := vs.strings[s]
-	return contains
+	return vs.strings.contains(s)
 }
 
 // RemoveString removes a string item from the ValueSet.
 func (vs *ValueSet) RemoveString(s string) {
-	delete(vs.strings, s)
+	vs.strings.remove(s)
 }
 
-// Bytes returns a list of bytes contained within the set.
+// Bytes returns a list of bytes contained within the set, in a deterministic order. The returned slice must not be
+// modified.
 func (vs *ValueSet) Bytes() [][]byte {
-	res := make([][]byte, len(vs.bytes))
-	count := 0
-	for _, v := range vs.bytes {
-		res[count] = v
-		count++
-	}
-	return res
+	return vs.bytes.list()
 }
 
 // AddBytes adds a byte sequence to the ValueSet.
@@ -150,8 +196,8 @@ func (vs *ValueSet) AddBytes(b []byte) {
 	hashStr := hex.EncodeToString(vs.hashProvider.Sum(nil))
 	vs.hashProvider.Reset()
 
-	// Add our hash to our "set" (map)
-	vs.bytes[hashStr] = b
+	// Add our hash to our ordered set
+	vs.bytes.set(hashStr, b)
 }
 
 // ContainsBytes checks if a byte sequence is contained in the ValueSet.
@@ -162,8 +208,7 @@ func (vs *ValueSet) ContainsBytes(b []byte) bool {
 	vs.hashProvider.Reset()
 
 	// Check if the key exists in our lookup
-	_, contains := vs.bytes[hashStr]
-	return contains
+	return vs.bytes.contains(hashStr)
 }
 
 // RemoveBytes removes a byte sequence item from the ValueSet.
@@ -173,7 +218,7 @@ func (vs *ValueSet) RemoveBytes(b []byte) {
 	hashStr := hex.EncodeToString(vs.hashProvider.Sum(nil))
 	vs.hashProvider.Reset()
 
-	delete(vs.bytes, hashStr)
+	vs.bytes.remove(hashStr)
 }
 
 // Add adds one or more values. Note the values must be a primitive type (signed/unsigned integer, address, string,
@@ -0,0 +1,114 @@
+package valuegeneration
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/crytic/medusa-geth/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// getTestValueSet creates a ValueSet with the same values, added in an order which depends on the provided flag.
+func getTestValueSet(reversed bool) *ValueSet {
+	values := []any{
+		common.HexToAddress("0x3000"), common.HexToAddress("0x1000"), common.HexToAddress("0x2000"),
+		big.NewInt(100), big.NewInt(7), big.NewInt(-3), uint64(42),
+		"medusa", "fuzz", "abc",
+		[]byte{3, 2, 1}, []byte{1, 2, 3}, [4]byte{0xde, 0xad, 0xbe, 0xef},
+	}
+	if reversed {
+		for i, j := 0, len(values)-1; i < j; i, j = i+1, j-1 {
+			values[i], values[j] = values[j], values[i]
+		}
+	}
+	valueSet := NewValueSet()
+	valueSet.Add(values)
+	return valueSet
+}
+
+// TestValueSetOrder ensures values are enumerated in the same order regardless of the order they were added in, and
+// that the ordered values stay consistent as values are removed or the set is cloned.
+func TestValueSetOrder(t *testing.T) {
+	valueSet := getTestValueSet(false)
+	reversedValueSet := getTestValueSet(true)
+	assert.EqualValues(t, valueSet.Addresses(), reversedValueSet.Addresses())
+	assert.EqualValues(t, valueSet.Integers(), reversedValueSet.Integers())
+	assert.EqualValues(t, valueSet.Strings(), reversedValueSet.Strings())
+	assert.EqualValues(t, valueSet.Bytes(), reversedValueSet.Bytes())
+	assert.EqualValues(t, []common.Address{common.HexToAddress("0x1000"), common.HexToAddress("0x2000"), common.HexToAddress("0x3000")}, valueSet.Addresses())
+	assert.EqualValues(t, []string{"abc", "fuzz", "medusa"}, valueSet.Strings())
+
+	// Duplicates are ignored.
+	valueSet.Add([]any{common.HexToAddress("0x1000"), big.NewInt(7), "abc", []byte{1, 2, 3}})
+	assert.Len(t, valueSet.Addresses(), 3)
+	assert.Len(t, valueSet.Integers(), 4)
+	assert.Len(t, valueSet.Strings(), 3)
+	assert.Len(t, valueSet.Bytes(), 3)
+
+	// Clones are independent of the set they were cloned from.
+	clonedValueSet := valueSet.Clone()
+	valueSet.RemoveAddress(common.HexToAddress("0x2000"))
+	valueSet.RemoveInteger(big.NewInt(7))
+	valueSet.RemoveString("fuzz")
+	valueSet.RemoveBytes([]byte{1, 2, 3})
+	assert.False(t, valueSet.ContainsAddress(common.HexToAddress("0x2000")))
+	assert.False(t, valueSet.ContainsInteger(big.NewInt(7)))
+	assert.False(t, valueSet.ContainsString("fuzz"))
+	assert.False(t, valueSet.ContainsBytes([]byte{1, 2, 3}))
+	assert.EqualValues(t, []common.Address{common.HexToAddress("0x1000"), common.HexToAddress("0x3000")}, valueSet.Addresses())
+	assert.EqualValues(t, []string{"abc", "medusa"}, valueSet.Strings())
+	assert.EqualValues(t, reversedValueSet.Addresses(), clonedValueSet.Addresses())
+	assert.EqualValues(t, reversedValueSet.Integers(), clonedValueSet.Integers())
+	assert.EqualValues(t, reversedValueSet.Strings(), clonedValueSet.Strings())
+	assert.EqualValues(t, reversedValueSet.Bytes(), clonedValueSet.Bytes())
+
+	// Appending to an enumeration does not affect the set.
+	_ = append(clonedValueSet.Strings(), "appended")
+	assert.EqualValues(t, []string{"abc", "fuzz", "medusa"}, clonedValueSet.Strings())
+}
+
+// TestValueSetDeterministicGeneration ensures generators seeded with the same seed generate the same values from value
+// sets holding the same values, regardless of the order they were added in.
+func TestValueSetDeterministicGeneration(t *testing.T) {
+	generatorConfig := &MutationalValueGeneratorConfig{
+		MinMutationRounds:            0,
+		MaxMutationRounds:            1,
+		GenerateRandomAddressBias:    0.2,
+		GenerateRandomIntegerBias:    0.2,
+		GenerateRandomStringBias:     0.2,
+		GenerateRandomBytesBias:      0.2,
+		MutateAddressProbability:     0.5,
+		MutateBytesProbability:       0.5,
+		MutateBytesGenerateNewBias:   0.5,
+		MutateStringProbability:      0.5,
+		MutateStringGenerateNewBias:  0.5,
+		MutateIntegerProbability:     0.5,
+		MutateIntegerGenerateNewBias: 0.5,
+		RandomValueGeneratorConfig: &RandomValueGeneratorConfig{
+			GenerateRandomArrayMinSize:  0,
+			GenerateRandomArrayMaxSize:  10,
+			GenerateRandomBytesMinSize:  0,
+			GenerateRandomBytesMaxSize:  10,
+			GenerateRandomStringMinSize: 0,
+			GenerateRandomStringMaxSize: 10,
+		},
+	}
+
+	// generateValues generates a sequence of values from the provided value set with a generator seeded with a fixed
+	// seed.
+	generateValues := func(valueSet *ValueSet) []any {
+		generator := NewMutationalValueGenerator(generatorConfig, valueSet, rand.New(rand.NewSource(1)))
+		values := make([]any, 0)
+		for i := 0; i < 64; i++ {
+			values = append(values,
+				generator.GenerateAddress(),
+				generator.GenerateInteger(false, 256),
+				generator.GenerateString(),
+				generator.GenerateBytes(),
+			)
+		}
+		return values
+	}
+	assert.EqualValues(t, generateValues(getTestValueSet(false)), generateValues(getTestValueSet(true)))
+}
@@ -0,0 +1,166 @@
+package logging
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// Diagnostics provides structured debug logging for a subsystem (e.g. a tracer), gated by a verbosity level which can
+// be changed at runtime, along with counters of the anomalies the subsystem recovers from without failing.
+// Diagnostics are logged through a sub-logger of GlobalLogger, at the debug level, whenever the verbosity of the
+// subsystem allows it, regardless of the level of GlobalLogger.
+type Diagnostics struct {
+	// name describes the name of the subsystem, used to configure its verbosity and to tag its logs.
+	name string
+
+	// verbosity describes the highest verbosity level of the diagnostics which are logged. Zero disables logging.
+	verbosity atomic.Int32
+
+	// logger describes the sub-logger diagnostics are logged through, created when the verbosity is set.
+	logger atomic.Pointer[Logger]
+
+	// counters maps the name of an anomaly to the amount of times it occurred.
+	counters sync.Map
+}
+
+var (
+	// diagnostics describes the Diagnostics of every subsystem, by name.
+	diagnostics = make(map[string]*Diagnostics)
+
+	// diagnosticsLock provides thread-synchronization for accesses to diagnostics.
+	diagnosticsLock sync.Mutex
+)
+
+// NewDiagnostics returns the Diagnostics of the subsystem with the provided name, registering it if it was not
+// registered yet. Subsystems are expected to register their Diagnostics once, in a package-level variable.
+func NewDiagnostics(name string) *Diagnostics {
+	diagnosticsLock.Lock()
+	defer diagnosticsLock.Unlock()
+
+	if d, ok := diagnostics[name]; ok {
+		return d
+	}
+	d := &Diagnostics{name: name}
+	diagnostics[name] = d
+	return d
+}
+
+// SetDiagnosticsVerbosity sets the verbosity of the subsystem with the provided name, which may be done while it is
+// running. The sub-logger diagnostics are logged through is derived from GlobalLogger at this time, so this must be
+// called again if GlobalLogger is replaced.
+// Returns false if no subsystem with the provided name was registered.
+func SetDiagnosticsVerbosity(name string, verbosity int) bool {
+	diagnosticsLock.Lock()
+	d, ok := diagnostics[name]
+	diagnosticsLock.Unlock()
+	if !ok {
+		return false
+	}
+	d.SetVerbosity(verbosity)
+	return true
+}
+
+// DiagnosticsCounters returns the amount of times each anomaly occurred, by subsystem name and anomaly name.
+// Subsystems which did not count any anomaly are omitted.
+func DiagnosticsCounters() map[string]map[string]uint64 {
+	diagnosticsLock.Lock()
+	defer diagnosticsLock.Unlock()
+
+	counters := make(map[string]map[string]uint64)
+	for name, d := range diagnostics {
+		if subsystemCounters := d.Counters(); len(subsystemCounters) > 0 {
+			counters[name] = subsystemCounters
+		}
+	}
+	return counters
+}
+
+// ResetDiagnosticsCounters clears the anomaly counters of every subsystem, e.g. when a new campaign starts.
+func ResetDiagnosticsCounters() {
+	diagnosticsLock.Lock()
+	defer diagnosticsLock.Unlock()
+
+	for _, d := range diagnostics {
+		d.counters.Clear()
+	}
+}
+
+// DiagnosticsSubsystems returns the names of the registered subsystems, sorted.
+func DiagnosticsSubsystems() []string {
+	diagnosticsLock.Lock()
+	defer diagnosticsLock.Unlock()
+
+	names := make([]string, 0, len(diagnostics))
+	for name := range diagnostics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetVerbosity sets the highest verbosity level of the diagnostics which are logged. Zero disables logging.
+func (d *Diagnostics) SetVerbosity(verbosity int) {
+	if verbosity > 0 && GlobalLogger != nil {
+		logger := GlobalLogger.NewSubLogger("subsystem", d.name)
+		logger.SetLevel(zerolog.DebugLevel)
+		d.logger.Store(logger)
+	}
+	d.verbosity.Store(int32(verbosity))
+}
+
+// Enabled indicates whether diagnostics of the provided verbosity level are logged. Callers on hot paths should check
+// it before building the arguments of Log.
+func (d *Diagnostics) Enabled(verbosity int) bool {
+	return int32(verbosity) <= d.verbosity.Load()
+}
+
+// Log logs the provided message along with its structured fields, if diagnostics of the provided verbosity level are
+// logged. Fields are attached to structured logs, and appended to the message of unstructured ones as key=value pairs.
+func (d *Diagnostics) Log(verbosity int, msg string, fields StructuredLogInfo) {
+	if !d.Enabled(verbosity) {
+		return
+	}
+	logger := d.logger.Load()
+	if logger == nil {
+		return
+	}
+	if len(fields) == 0 {
+		logger.Debug(msg)
+		return
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", key, fields[key]))
+	}
+	logger.Debug(msg, " (", strings.Join(pairs, ", "), ")", fields)
+}
+
+// Count records an occurrence of the anomaly with the provided name.
+func (d *Diagnostics) Count(anomaly string) {
+	counter, ok := d.counters.Load(anomaly)
+	if !ok {
+		counter, _ = d.counters.LoadOrStore(anomaly, new(atomic.Uint64))
+	}
+	counter.(*atomic.Uint64).Add(1)
+}
+
+// Counters returns the amount of times each anomaly occurred, by anomaly name.
+func (d *Diagnostics) Counters() map[string]uint64 {
+	counters := make(map[string]uint64)
+	d.counters.Range(func(anomaly, counter any) bool {
+		counters[anomaly.(string)] = counter.(*atomic.Uint64).Load()
+		return true
+	})
+	return counters
+}
@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDiagnostics verifies that diagnostics are only logged up to the verbosity of their subsystem, regardless of the
+// level of the global logger, and that the anomalies they count are reported until they are reset.
+func TestDiagnostics(t *testing.T) {
+	// Replace the global logger with one only logging informational messages to a buffer
+	globalLogger := GlobalLogger
+	defer func() {
+		GlobalLogger = globalLogger
+	}()
+	var buf bytes.Buffer
+	GlobalLogger = NewLogger(zerolog.InfoLevel)
+	GlobalLogger.AddWriter(&buf, STRUCTURED, false)
+
+	// Subsystems are registered once
+	diagnostics := NewDiagnostics("test")
+	assert.Same(t, diagnostics, NewDiagnostics("test"))
+	assert.Contains(t, DiagnosticsSubsystems(), "test")
+	assert.False(t, SetDiagnosticsVerbosity("unknown", 1))
+
+	// Nothing is logged until the verbosity of the subsystem is raised
+	diagnostics.Log(1, "hidden", nil)
+	assert.Empty(t, buf.String())
+	assert.True(t, SetDiagnosticsVerbosity("test", 1))
+	assert.True(t, diagnostics.Enabled(1))
+	assert.False(t, diagnostics.Enabled(2))
+	diagnostics.Log(2, "too verbose", nil)
+	diagnostics.Log(1, "shown", StructuredLogInfo{"pc": 42})
+	assert.NotContains(t, buf.String(), "too verbose")
+	assert.Contains(t, buf.String(), `"subsystem":"test"`)
+	assert.Contains(t, buf.String(), `"info":{"pc":42}`)
+	assert.Contains(t, buf.String(), "shown (pc=42)")
+
+	// Anomalies are counted until reset
+	diagnostics.Count("STACKOUTOFSCOPE")
+	diagnostics.Count("STACKOUTOFSCOPE")
+	diagnostics.Count("UNKNOWNCODEHASH")
+	assert.EqualValues(t, map[string]uint64{"STACKOUTOFSCOPE": 2, "UNKNOWNCODEHASH": 1}, DiagnosticsCounters()["test"])
+	ResetDiagnosticsCounters()
+	assert.NotContains(t, DiagnosticsCounters(), "test")
+	diagnostics.SetVerbosity(0)
+}
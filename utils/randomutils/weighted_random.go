@@ -28,6 +28,11 @@ func NewWeightedRandomChoice[T any](data T, weight *big.Int) *WeightedRandomChoi
 	}
 }
 
+// Weight returns the weight of the WeightedRandomChoice.
+func (c *WeightedRandomChoice[T]) Weight() *big.Int {
+	return new(big.Int).Set(c.weight)
+}
+
 // WeightedRandomChooser takes a series of WeightedRandomChoice objects which wrap underlying data, and returns one
 // of the weighted options randomly.
 type WeightedRandomChooser[T any] struct {
@@ -95,8 +100,40 @@ func (c *WeightedRandomChooser[T]) RemoveChoices(indices map[int]bool) {
 	}
 }
 
+// SetWeight sets the weight of a WeightedRandomChoice previously added to the WeightedRandomChooser, updating the
+// likelihood of its future selection.
+func (c *WeightedRandomChooser[T]) SetWeight(choice *WeightedRandomChoice[T], weight *big.Int) {
+	c.randomProviderLock.Lock()
+	defer c.randomProviderLock.Unlock()
+
+	c.totalWeight = new(big.Int).Add(new(big.Int).Sub(c.totalWeight, choice.weight), weight)
+	choice.weight = new(big.Int).Set(weight)
+}
+
 // Choose selects a random weighted item from the WeightedRandomChooser, or returns an error if one occurs.
 func (c *WeightedRandomChooser[T]) Choose() (*T, error) {
+	return c.ChooseWithRand(c.randomProvider)
+}
+
+// ChooseWithRand selects a random weighted item from the WeightedRandomChooser using the provided random provider
+// rather than its own, or returns an error if one occurs.
+func (c *WeightedRandomChooser[T]) ChooseWithRand(randomProvider *rand.Rand) (*T, error) {
+	choice, err := c.ChooseChoiceWithRand(randomProvider)
+	if err != nil {
+		return nil, err
+	}
+	return &choice.Data, nil
+}
+
+// ChooseChoice selects a random WeightedRandomChoice from the WeightedRandomChooser, or returns an error if one occurs.
+func (c *WeightedRandomChooser[T]) ChooseChoice() (*WeightedRandomChoice[T], error) {
+	return c.ChooseChoiceWithRand(c.randomProvider)
+}
+
+// ChooseChoiceWithRand selects a random WeightedRandomChoice from the WeightedRandomChooser using the provided random
+// provider rather than its own, so callers sharing the chooser make their choices independently of one another.
+// Returns an error if one occurs.
+func (c *WeightedRandomChooser[T]) ChooseChoiceWithRand(randomProvider *rand.Rand) (*WeightedRandomChoice[T], error) {
 	// If we have no choices or 0 total weight, return nil.
 	if len(c.Choices) == 0 || c.totalWeight.Cmp(big.NewInt(0)) == 0 {
 		return nil, fmt.Errorf("could not return a weighted random choice because no choices exist with non-zero weights")
@@ -111,7 +148,7 @@ func (c *WeightedRandomChooser[T]) Choose() (*T, error) {
 	// If it's a larger number, we calculate the position with a bit more work.
 	var selectedWeightPosition *big.Int
 	if c.totalWeight.IsInt64() && unsafe.Sizeof(0) == 64 {
-		selectedWeightPosition = big.NewInt(int64(c.randomProvider.Intn(int(c.totalWeight.Int64()))))
+		selectedWeightPosition = big.NewInt(int64(randomProvider.Intn(int(c.totalWeight.Int64()))))
 	} else {
 		// Next we'll determine how many bits/bytes are needed to represent our random value
 		bitLength := c.totalWeight.BitLen()
@@ -123,13 +160,16 @@ func (c *WeightedRandomChooser[T]) Choose() (*T, error) {
 
 		// Generate the number of bytes needed.
 		randomData := make([]byte, byteLength)
-		_, err := c.randomProvider.Read(randomData)
+		_, err := randomProvider.Read(randomData)
 		if err != nil {
 			return nil, err
 		}
 
-		// If we have unused bits, we'll want to mask/clear them out (big.Int uses big endian for byte parsing)
-		randomData[0] = randomData[0] & (byte(0xFF) >> unusedBits)
+		// If we have unused bits, we'll want to mask/clear them out (big.Int uses big endian for byte parsing), keeping
+		// the used bits of the leading byte.
+		if unusedBits != 0 {
+			randomData[0] = randomData[0] & (byte(0xFF) >> (8 - unusedBits))
+		}
 
 		// We use these bytes to get an index in [0, total weight] to use to return an item.
 		// TODO: this may be the correct bit size but have too many bits set to actually be in range, so we perform
@@ -142,7 +182,7 @@ func (c *WeightedRandomChooser[T]) Choose() (*T, error) {
 	for _, choice := range c.Choices {
 		// If our selected weight position is in range for this item, return it
 		if selectedWeightPosition.Cmp(choice.weight) < 0 {
-			return &choice.Data, nil
+			return choice, nil
 		}
 
 		// Subtract the choice weight from the current position, and go to the next item to see if it's in range.
@@ -37,3 +37,14 @@ func SliceWhere[T any](x []T, f func(x T) bool) []T {
 	}
 	return r
 }
+
+// SliceResizeZeroed returns a slice of the provided length whose elements are all zero values, reusing the memory of
+// the provided slice if it has sufficient capacity.
+func SliceResizeZeroed[T any](x []T, length int) []T {
+	if cap(x) < length {
+		return make([]T, length)
+	}
+	x = x[:length]
+	clear(x)
+	return x
+}